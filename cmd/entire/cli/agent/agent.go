@@ -5,6 +5,8 @@ package agent
 
 import (
 	"io"
+
+	"github.com/entireio/cli/cmd/entire/cli/validation"
 )
 
 // Agent defines the interface for interacting with a coding agent.
@@ -161,3 +163,14 @@ type TranscriptChunker interface {
 	// Handles format-specific reassembly (JSONL concatenation, JSON message merging).
 	ReassembleTranscript(chunks [][]byte) ([]byte, error)
 }
+
+// IDProfileProvider is implemented by agents whose session, tool use or
+// subagent IDs don't fit validation's default rules (e.g. IDs with dots or
+// colons). Register hands the profiles to the validation package, keyed by
+// the agent's type.
+type IDProfileProvider interface {
+	Agent
+
+	// IDProfiles returns the rules this agent's IDs are validated against.
+	IDProfiles() validation.IDProfiles
+}
@@ -0,0 +1,277 @@
+// Package aider implements the Agent interface for Aider.
+//
+// Aider has no lifecycle hooks. It records each run in .aider.chat.history.md
+// at the repository root and commits its own edits ("auto-commits"). Entire
+// picks up those commits from the git hooks and reads the chat history to
+// build the checkpoint for each one.
+package aider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+//nolint:gochecknoinits // Agent self-registration is the intended pattern
+func init() {
+	agent.Register(agent.AgentNameAider, NewAiderAgent)
+}
+
+const (
+	// HistoryFileName is the chat history Aider writes at the repository root.
+	HistoryFileName = ".aider.chat.history.md"
+
+	// InputHistoryFileName is Aider's prompt input history.
+	InputHistoryFileName = ".aider.input.history"
+
+	// ConfigFileName is Aider's per-repository configuration file.
+	ConfigFileName = ".aider.conf.yml"
+)
+
+// Ensure AiderAgent implements the watcher and transcript adapter interfaces
+var (
+	_ agent.FileWatcher        = (*AiderAgent)(nil)
+	_ agent.TranscriptAnalyzer = (*AiderAgent)(nil)
+	_ agent.TranscriptChunker  = (*AiderAgent)(nil)
+)
+
+// AiderAgent implements the Agent interface for Aider.
+//
+//nolint:revive // AiderAgent is clearer than Agent in this context
+type AiderAgent struct{}
+
+func NewAiderAgent() agent.Agent {
+	return &AiderAgent{}
+}
+
+// Name returns the agent registry key.
+func (a *AiderAgent) Name() agent.AgentName {
+	return agent.AgentNameAider
+}
+
+// Type returns the agent type identifier.
+func (a *AiderAgent) Type() agent.AgentType {
+	return agent.AgentTypeAider
+}
+
+// Description returns a human-readable description.
+func (a *AiderAgent) Description() string {
+	return "Aider - AI pair programming in your terminal"
+}
+
+// DetectPresence checks if Aider has been used or configured in the repository.
+func (a *AiderAgent) DetectPresence() (bool, error) {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		// Not in a git repo, fall back to CWD-relative check
+		repoRoot = "."
+	}
+
+	for _, name := range []string{HistoryFileName, ConfigFileName} {
+		if _, err := os.Stat(filepath.Join(repoRoot, name)); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetHookConfigPath returns an empty string as Aider has no hook configuration.
+func (a *AiderAgent) GetHookConfigPath() string {
+	return ""
+}
+
+// SupportsHooks returns false as Aider has no lifecycle hooks.
+func (a *AiderAgent) SupportsHooks() bool {
+	return false
+}
+
+// ParseHookInput always fails: Aider does not call hooks.
+func (a *AiderAgent) ParseHookInput(_ agent.HookType, _ io.Reader) (*agent.HookInput, error) {
+	return nil, errors.New("aider does not support hooks")
+}
+
+// GetSessionID extracts the session ID from hook input.
+func (a *AiderAgent) GetSessionID(input *agent.HookInput) string {
+	return input.SessionID
+}
+
+// TransformSessionID is the identity function: Aider session IDs are derived
+// from the chat start time and are already valid Entire session IDs.
+func (a *AiderAgent) TransformSessionID(agentSessionID string) string {
+	return agentSessionID
+}
+
+// ExtractAgentSessionID returns the input unchanged (see TransformSessionID).
+func (a *AiderAgent) ExtractAgentSessionID(entireSessionID string) string {
+	return entireSessionID
+}
+
+// ProtectedDirs returns the files and directories Aider keeps at the repository
+// root. These must survive rewind like other agents' config directories.
+func (a *AiderAgent) ProtectedDirs() []string {
+	return []string{HistoryFileName, InputHistoryFileName, ConfigFileName, ".aider.tags.cache.v3", ".aider.tags.cache.v4"}
+}
+
+// GetSessionDir returns the repository root, where Aider writes its history.
+func (a *AiderAgent) GetSessionDir(repoPath string) (string, error) {
+	return repoPath, nil
+}
+
+// ResolveSessionFile returns the chat history file. All Aider chats in a
+// repository share it; see LatestChat.
+func (a *AiderAgent) ResolveSessionFile(sessionDir, _ string) string {
+	return filepath.Join(sessionDir, HistoryFileName)
+}
+
+// ReadSession reads the chat history. NativeData holds the whole history file.
+func (a *AiderAgent) ReadSession(input *agent.HookInput) (*agent.AgentSession, error) {
+	if input.SessionRef == "" {
+		return nil, errors.New("session reference (history path) is required")
+	}
+
+	data, err := os.ReadFile(input.SessionRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat history: %w", err)
+	}
+
+	session := &agent.AgentSession{
+		SessionID:  input.SessionID,
+		AgentName:  a.Name(),
+		SessionRef: input.SessionRef,
+		StartTime:  time.Now(),
+		NativeData: data,
+	}
+	if chat, ok := LatestChat(data); ok {
+		session.ModifiedFiles = chat.EditedFiles(0)
+		if !chat.StartedAt.IsZero() {
+			session.StartTime = chat.StartedAt
+		}
+	}
+	return session, nil
+}
+
+// WriteSession writes the chat history back. Aider has no resume format of its
+// own; restoring the history lets `aider --restore-chat-history` pick it up.
+func (a *AiderAgent) WriteSession(session *agent.AgentSession) error {
+	if session == nil {
+		return errors.New("session is nil")
+	}
+
+	// Verify this session belongs to Aider
+	if session.AgentName != "" && session.AgentName != a.Name() {
+		return fmt.Errorf("session belongs to agent %q, not %q", session.AgentName, a.Name())
+	}
+
+	if session.SessionRef == "" {
+		return errors.New("session reference (history path) is required")
+	}
+
+	if len(session.NativeData) == 0 {
+		return errors.New("session has no native data to write")
+	}
+
+	if err := os.WriteFile(session.SessionRef, session.NativeData, 0o600); err != nil {
+		return fmt.Errorf("failed to write chat history: %w", err)
+	}
+	return nil
+}
+
+// FormatResumeCommand returns the command to continue an Aider chat.
+func (a *AiderAgent) FormatResumeCommand(_ string) string {
+	return "aider --restore-chat-history"
+}
+
+// FileWatcher interface implementation
+
+// GetWatchPaths returns the chat history file of the current repository.
+func (a *AiderAgent) GetWatchPaths() ([]string, error) {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repo root: %w", err)
+	}
+	return []string{filepath.Join(repoRoot, HistoryFileName)}, nil
+}
+
+// OnFileChange reports activity in the latest chat when the history changes.
+// Returns nil if the history has no chats yet.
+func (a *AiderAgent) OnFileChange(path string) (*agent.SessionChange, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from GetWatchPaths
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil //nolint:nilnil // nil,nil means no session activity
+		}
+		return nil, fmt.Errorf("failed to read chat history: %w", err)
+	}
+
+	chat, ok := LatestChat(data)
+	if !ok {
+		return nil, nil //nolint:nilnil // nil,nil means no session activity
+	}
+	return &agent.SessionChange{
+		SessionID:  chat.SessionID(),
+		SessionRef: path,
+		EventType:  agent.HookStop,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// TranscriptAnalyzer interface implementation
+
+// GetTranscriptPosition returns the number of lines in the latest chat of the
+// history file. Each Aider run is a separate session, so positions are relative
+// to the start of that run rather than to the file.
+// Returns 0 if the file doesn't exist or is empty.
+func (a *AiderAgent) GetTranscriptPosition(path string) (int, error) {
+	chat, ok, err := readLatestChat(path)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return chat.Position(), nil
+}
+
+// ExtractModifiedFilesFromOffset returns the files Aider edited in the latest
+// chat since the given line offset, and the chat's current length in lines.
+func (a *AiderAgent) ExtractModifiedFilesFromOffset(path string, startOffset int) (files []string, currentPosition int, err error) {
+	chat, ok, err := readLatestChat(path)
+	if err != nil || !ok {
+		return nil, 0, err
+	}
+	return chat.EditedFiles(startOffset), chat.Position(), nil
+}
+
+// TranscriptChunker interface implementation
+
+// ChunkTranscript splits the Markdown history at line boundaries.
+func (a *AiderAgent) ChunkTranscript(content []byte, maxSize int) ([][]byte, error) {
+	chunks, err := agent.ChunkJSONL(content, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to chunk chat history: %w", err)
+	}
+	return chunks, nil
+}
+
+// ReassembleTranscript concatenates line-based chunks.
+func (a *AiderAgent) ReassembleTranscript(chunks [][]byte) ([]byte, error) {
+	return agent.ReassembleJSONL(chunks), nil
+}
+
+func readLatestChat(path string) (Chat, bool, error) {
+	if path == "" {
+		return Chat{}, false, nil
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // Reading from controlled transcript path
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Chat{}, false, nil
+		}
+		return Chat{}, false, fmt.Errorf("failed to read chat history: %w", err)
+	}
+	chat, ok := LatestChat(data)
+	return chat, ok, nil
+}
@@ -0,0 +1,158 @@
+package aider
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+)
+
+func TestName(t *testing.T) {
+	t.Parallel()
+
+	ag := &AiderAgent{}
+	if name := ag.Name(); name != agent.AgentNameAider {
+		t.Errorf("Name() = %q, want %q", name, agent.AgentNameAider)
+	}
+	if typ := ag.Type(); typ != agent.AgentTypeAider {
+		t.Errorf("Type() = %q, want %q", typ, agent.AgentTypeAider)
+	}
+	if ag.SupportsHooks() {
+		t.Error("SupportsHooks() = true, want false")
+	}
+}
+
+func TestDetectPresence(t *testing.T) {
+	t.Run("no aider files", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		present, err := (&AiderAgent{}).DetectPresence()
+		if err != nil {
+			t.Fatalf("DetectPresence() error = %v", err)
+		}
+		if present {
+			t.Error("DetectPresence() = true, want false")
+		}
+	})
+
+	t.Run("chat history present", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		if err := os.WriteFile(filepath.Join(dir, HistoryFileName), []byte(testHistory), 0o600); err != nil {
+			t.Fatalf("failed to write history: %v", err)
+		}
+
+		present, err := (&AiderAgent{}).DetectPresence()
+		if err != nil {
+			t.Fatalf("DetectPresence() error = %v", err)
+		}
+		if !present {
+			t.Error("DetectPresence() = false, want true")
+		}
+	})
+}
+
+func TestResolveSessionFile(t *testing.T) {
+	t.Parallel()
+
+	got := (&AiderAgent{}).ResolveSessionFile("/repo", "ignored")
+	if want := filepath.Join("/repo", HistoryFileName); got != want {
+		t.Errorf("ResolveSessionFile() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractModifiedFilesFromOffset(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+	if err := os.WriteFile(path, []byte(testHistory), 0o600); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+	ag := &AiderAgent{}
+
+	pos, err := ag.GetTranscriptPosition(path)
+	if err != nil {
+		t.Fatalf("GetTranscriptPosition() error = %v", err)
+	}
+	if want := latestTestChat(t).Position(); pos != want {
+		t.Errorf("GetTranscriptPosition() = %d, want %d", pos, want)
+	}
+
+	files, current, err := ag.ExtractModifiedFilesFromOffset(path, 0)
+	if err != nil {
+		t.Fatalf("ExtractModifiedFilesFromOffset() error = %v", err)
+	}
+	// Edits from the earlier chat are not included
+	if want := []string{"main.go", "README.md"}; !slices.Equal(files, want) {
+		t.Errorf("ExtractModifiedFilesFromOffset() files = %q, want %q", files, want)
+	}
+	if current != pos {
+		t.Errorf("ExtractModifiedFilesFromOffset() position = %d, want %d", current, pos)
+	}
+
+	files, _, err = ag.ExtractModifiedFilesFromOffset(path, pos)
+	if err != nil {
+		t.Fatalf("ExtractModifiedFilesFromOffset() error = %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("ExtractModifiedFilesFromOffset(pos) files = %q, want none", files)
+	}
+}
+
+func TestGetTranscriptPosition_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	pos, err := (&AiderAgent{}).GetTranscriptPosition(filepath.Join(t.TempDir(), HistoryFileName))
+	if err != nil {
+		t.Fatalf("GetTranscriptPosition() error = %v", err)
+	}
+	if pos != 0 {
+		t.Errorf("GetTranscriptPosition() = %d, want 0", pos)
+	}
+}
+
+func TestOnFileChange(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+	if err := os.WriteFile(path, []byte(testHistory), 0o600); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+
+	change, err := (&AiderAgent{}).OnFileChange(path)
+	if err != nil {
+		t.Fatalf("OnFileChange() error = %v", err)
+	}
+	if change == nil {
+		t.Fatal("OnFileChange() returned nil")
+	}
+	if change.SessionID != "aider-20260302-143005" {
+		t.Errorf("SessionID = %q, want aider-20260302-143005", change.SessionID)
+	}
+}
+
+func TestChunkTranscript_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ag := &AiderAgent{}
+	content := []byte(testHistory)
+
+	chunks, err := ag.ChunkTranscript(content, 200)
+	if err != nil {
+		t.Fatalf("ChunkTranscript() error = %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("ChunkTranscript() got %d chunks, want at least 2", len(chunks))
+	}
+
+	reassembled, err := ag.ReassembleTranscript(chunks)
+	if err != nil {
+		t.Fatalf("ReassembleTranscript() error = %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(reassembled), bytes.TrimSpace(content)) {
+		t.Errorf("round trip mismatch:\n%s", reassembled)
+	}
+}
@@ -0,0 +1,322 @@
+package aider
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+)
+
+// Aider appends every run to .aider.chat.history.md. Each run starts with a
+// "# aider chat started at <time>" header; user input lines are prefixed with
+// "#### ", tool output (edits, commits, token reports) with "> ", and anything
+// else is assistant output.
+
+const (
+	chatHeaderPrefix = "# aider chat started at "
+	userLinePrefix   = "#### "
+	toolLinePrefix   = "> "
+
+	chatHeaderTimeLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	appliedEditRegex = regexp.MustCompile(`^Applied edit to (.+)$`)
+	commitRegex      = regexp.MustCompile(`^Commit ([0-9a-f]{7,40}) (.*)$`)
+	tokensRegex      = regexp.MustCompile(`([0-9][0-9.,]*)([kKmM]?) (sent|received|cache write|cache hit)`)
+)
+
+// promptCommands are slash commands whose argument is a prompt to the model.
+// Other slash commands (/add, /drop, /run, ...) are not prompts.
+var promptCommands = []string{"/ask ", "/code ", "/architect "}
+
+// Chat is one Aider run within the chat history file.
+type Chat struct {
+	// StartedAt is the time from the chat header. Zero if the header is missing
+	// (history written before the first header) or unparsable.
+	StartedAt time.Time
+
+	// StartLine is the 0-based line of the chat header within the history file.
+	StartLine int
+
+	// Lines are the chat's lines, header included, without trailing blank lines.
+	// Positions within a chat are indexes into Lines.
+	Lines []string
+}
+
+// Commit is an Aider auto-commit reported in the chat history.
+type Commit struct {
+	Hash    string // Abbreviated hash as printed by Aider
+	Message string
+	Line    int // Index into Chat.Lines
+}
+
+// ParseHistory splits chat history content into chats, oldest first.
+func ParseHistory(data []byte) []Chat {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	var chats []Chat
+	var current *Chat
+	for i, line := range lines {
+		if strings.HasPrefix(line, chatHeaderPrefix) {
+			if current != nil {
+				chats = append(chats, *current)
+			}
+			current = &Chat{StartLine: i}
+			if t, err := time.ParseInLocation(chatHeaderTimeLayout, strings.TrimSpace(strings.TrimPrefix(line, chatHeaderPrefix)), time.Local); err == nil {
+				current.StartedAt = t
+			}
+		}
+		if current == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			current = &Chat{StartLine: i}
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	if current != nil {
+		chats = append(chats, *current)
+	}
+
+	for i := range chats {
+		chats[i].Lines = trimTrailingBlankLines(chats[i].Lines)
+	}
+	return chats
+}
+
+// LatestChat returns the most recent chat in the history, or false if there is none.
+func LatestChat(data []byte) (Chat, bool) {
+	chats := ParseHistory(data)
+	if len(chats) == 0 {
+		return Chat{}, false
+	}
+	return chats[len(chats)-1], true
+}
+
+// SessionID returns the Entire session ID for the chat, derived from its start time.
+// Chats without a header time fall back to their line offset in the history file.
+func (c Chat) SessionID() string {
+	if c.StartedAt.IsZero() {
+		return "aider-line-" + strconv.Itoa(c.StartLine)
+	}
+	return "aider-" + c.StartedAt.Format("20060102-150405")
+}
+
+// Position returns the chat's current length in lines.
+func (c Chat) Position() int {
+	return len(c.Lines)
+}
+
+// Content returns the chat as stored in checkpoint metadata.
+func (c Chat) Content() []byte {
+	if len(c.Lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(c.Lines, "\n") + "\n")
+}
+
+// Prompts returns the user prompts starting at line offset from.
+// Consecutive "#### " lines form one prompt; slash commands other than
+// /ask, /code and /architect are skipped.
+func (c Chat) Prompts(from int) []string {
+	var prompts []string
+	var current []string
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		if prompt, ok := normalizePrompt(strings.Join(current, "\n")); ok {
+			prompts = append(prompts, prompt)
+		}
+		current = nil
+	}
+
+	for i := max(from, 0); i < len(c.Lines); i++ {
+		line := c.Lines[i]
+		if text, ok := strings.CutPrefix(line, userLinePrefix); ok {
+			current = append(current, text)
+			continue
+		}
+		if line == strings.TrimSpace(userLinePrefix) {
+			current = append(current, "")
+			continue
+		}
+		flush()
+	}
+	flush()
+	return prompts
+}
+
+func normalizePrompt(prompt string) (string, bool) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(prompt, "/") {
+		return prompt, true
+	}
+	for _, cmd := range promptCommands {
+		if rest, ok := strings.CutPrefix(prompt, cmd); ok {
+			rest = strings.TrimSpace(rest)
+			return rest, rest != ""
+		}
+	}
+	return "", false
+}
+
+// Role identifies who produced a chat message.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a run of consecutive chat lines from the same source.
+type Message struct {
+	Role Role
+	Text string
+
+	// EditedFiles are the files reported by "Applied edit to" lines (tool messages only).
+	EditedFiles []string
+}
+
+// Messages groups the chat's lines (after the header) into messages.
+func (c Chat) Messages() []Message {
+	var messages []Message
+	var role Role
+	var current []string
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+			msg := Message{Role: role, Text: text}
+			if role == RoleTool {
+				for _, line := range current {
+					if m := appliedEditRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+						msg.EditedFiles = append(msg.EditedFiles, strings.TrimSpace(m[1]))
+					}
+				}
+			}
+			messages = append(messages, msg)
+		}
+		current = nil
+	}
+
+	for _, line := range c.Lines {
+		if strings.HasPrefix(line, chatHeaderPrefix) {
+			continue
+		}
+		lineRole, text := RoleAssistant, line
+		switch {
+		case strings.HasPrefix(line, userLinePrefix) || line == strings.TrimSpace(userLinePrefix):
+			lineRole, text = RoleUser, strings.TrimPrefix(line, userLinePrefix)
+		case strings.HasPrefix(line, toolLinePrefix) || line == strings.TrimSpace(toolLinePrefix):
+			lineRole, text = RoleTool, strings.TrimPrefix(line, toolLinePrefix)
+		case strings.TrimSpace(line) == "":
+			// Blank lines separate messages but don't change the speaker
+			if role != RoleAssistant {
+				flush()
+			}
+			if len(current) > 0 {
+				current = append(current, "")
+			}
+			continue
+		}
+		if lineRole != role {
+			flush()
+			role = lineRole
+		}
+		current = append(current, text)
+	}
+	flush()
+	return messages
+}
+
+// EditedFiles returns the files Aider reported editing, starting at line offset from.
+// Paths are as printed by Aider (relative to the repository root), deduplicated in order.
+func (c Chat) EditedFiles(from int) []string {
+	seen := make(map[string]bool)
+	var files []string
+	c.eachToolLine(from, func(_ int, text string) {
+		if m := appliedEditRegex.FindStringSubmatch(text); m != nil {
+			file := strings.TrimSpace(m[1])
+			if file != "" && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	})
+	return files
+}
+
+// Commits returns the auto-commits Aider reported, starting at line offset from.
+func (c Chat) Commits(from int) []Commit {
+	var commits []Commit
+	c.eachToolLine(from, func(i int, text string) {
+		if m := commitRegex.FindStringSubmatch(text); m != nil {
+			commits = append(commits, Commit{Hash: m[1], Message: strings.TrimSpace(m[2]), Line: i})
+		}
+	})
+	return commits
+}
+
+// TokenUsage sums the "Tokens: ..." reports starting at line offset from.
+// Each report is one model call.
+func (c Chat) TokenUsage(from int) *agent.TokenUsage {
+	usage := &agent.TokenUsage{}
+	c.eachToolLine(from, func(_ int, text string) {
+		report, ok := strings.CutPrefix(text, "Tokens: ")
+		if !ok {
+			return
+		}
+		usage.APICallCount++
+		for _, m := range tokensRegex.FindAllStringSubmatch(report, -1) {
+			n := parseTokenCount(m[1], m[2])
+			switch m[3] {
+			case "sent":
+				usage.InputTokens += n
+			case "received":
+				usage.OutputTokens += n
+			case "cache write":
+				usage.CacheCreationTokens += n
+			case "cache hit":
+				usage.CacheReadTokens += n
+			}
+		}
+	})
+	return usage
+}
+
+// eachToolLine calls fn with the index and text (prefix removed) of each tool output line.
+func (c Chat) eachToolLine(from int, fn func(i int, text string)) {
+	for i := max(from, 0); i < len(c.Lines); i++ {
+		if text, ok := strings.CutPrefix(c.Lines[i], toolLinePrefix); ok {
+			fn(i, strings.TrimSpace(text))
+		}
+	}
+}
+
+// parseTokenCount parses Aider's abbreviated counts such as "950", "2.3k" or "1,204".
+func parseTokenCount(number, suffix string) int {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(suffix) {
+	case "k":
+		f *= 1_000
+	case "m":
+		f *= 1_000_000
+	}
+	return int(f + 0.5)
+}
+
+func trimTrailingBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
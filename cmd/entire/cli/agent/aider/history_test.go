@@ -0,0 +1,211 @@
+package aider
+
+import (
+	"slices"
+	"testing"
+)
+
+const testHistory = `
+# aider chat started at 2026-03-01 09:15:00
+
+#### fix the typo
+
+I'll fix the typo in README.md.
+
+> Applied edit to README.md
+> Commit 1a2b3c4 docs: Fix typo in README
+> Tokens: 950 sent, 120 received.
+
+# aider chat started at 2026-03-02 14:30:05
+
+#### /add main.go
+
+> Added main.go to the chat
+
+#### add a --verbose flag
+#### and document it
+
+Here are the changes.
+
+> Applied edit to main.go
+> Applied edit to README.md
+> Commit abcdef0 feat: Add --verbose flag
+> Tokens: 2.3k sent, 1.2k cache write, 500 cache hit, 150 received.
+
+#### /ask why does this work?
+
+Because the flag is parsed first.
+
+> Tokens: 1,204 sent, 80 received.
+
+`
+
+func latestTestChat(t *testing.T) Chat {
+	t.Helper()
+	chat, ok := LatestChat([]byte(testHistory))
+	if !ok {
+		t.Fatal("LatestChat() found no chat")
+	}
+	return chat
+}
+
+func TestParseHistory(t *testing.T) {
+	t.Parallel()
+
+	chats := ParseHistory([]byte(testHistory))
+	if len(chats) != 2 {
+		t.Fatalf("ParseHistory() got %d chats, want 2", len(chats))
+	}
+
+	if got := chats[0].SessionID(); got != "aider-20260301-091500" {
+		t.Errorf("first SessionID() = %q, want aider-20260301-091500", got)
+	}
+	if got := chats[1].SessionID(); got != "aider-20260302-143005" {
+		t.Errorf("second SessionID() = %q, want aider-20260302-143005", got)
+	}
+	if chats[0].StartLine != 1 {
+		t.Errorf("first StartLine = %d, want 1", chats[0].StartLine)
+	}
+	if last := chats[0].Lines[len(chats[0].Lines)-1]; last != "> Tokens: 950 sent, 120 received." {
+		t.Errorf("trailing blank lines not trimmed, last line = %q", last)
+	}
+}
+
+func TestParseHistory_NoHeader(t *testing.T) {
+	t.Parallel()
+
+	chats := ParseHistory([]byte("\n#### hello\n\nhi\n"))
+	if len(chats) != 1 {
+		t.Fatalf("ParseHistory() got %d chats, want 1", len(chats))
+	}
+	if got := chats[0].SessionID(); got != "aider-line-1" {
+		t.Errorf("SessionID() = %q, want aider-line-1", got)
+	}
+}
+
+func TestLatestChat_Empty(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := LatestChat([]byte("\n\n")); ok {
+		t.Error("LatestChat() found a chat in empty history")
+	}
+}
+
+func TestChat_Prompts(t *testing.T) {
+	t.Parallel()
+
+	chat := latestTestChat(t)
+	got := chat.Prompts(0)
+	want := []string{"add a --verbose flag\nand document it", "why does this work?"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Prompts(0) = %q, want %q", got, want)
+	}
+
+	// Starting after the first prompt only returns the /ask prompt
+	got = chat.Prompts(chat.Commits(0)[0].Line)
+	if !slices.Equal(got, want[1:]) {
+		t.Errorf("Prompts(offset) = %q, want %q", got, want[1:])
+	}
+}
+
+func TestChat_EditedFiles(t *testing.T) {
+	t.Parallel()
+
+	chat := latestTestChat(t)
+	got := chat.EditedFiles(0)
+	want := []string{"main.go", "README.md"}
+	if !slices.Equal(got, want) {
+		t.Errorf("EditedFiles(0) = %q, want %q", got, want)
+	}
+
+	if got := chat.EditedFiles(chat.Position()); len(got) != 0 {
+		t.Errorf("EditedFiles(Position()) = %q, want none", got)
+	}
+}
+
+func TestChat_Commits(t *testing.T) {
+	t.Parallel()
+
+	commits := latestTestChat(t).Commits(0)
+	if len(commits) != 1 {
+		t.Fatalf("Commits(0) got %d commits, want 1", len(commits))
+	}
+	if commits[0].Hash != "abcdef0" || commits[0].Message != "feat: Add --verbose flag" {
+		t.Errorf("Commits(0)[0] = %+v", commits[0])
+	}
+}
+
+func TestChat_TokenUsage(t *testing.T) {
+	t.Parallel()
+
+	chat := latestTestChat(t)
+	usage := chat.TokenUsage(0)
+	if usage.APICallCount != 2 {
+		t.Errorf("APICallCount = %d, want 2", usage.APICallCount)
+	}
+	if usage.InputTokens != 2300+1204 {
+		t.Errorf("InputTokens = %d, want %d", usage.InputTokens, 2300+1204)
+	}
+	if usage.OutputTokens != 150+80 {
+		t.Errorf("OutputTokens = %d, want %d", usage.OutputTokens, 150+80)
+	}
+	if usage.CacheCreationTokens != 1200 {
+		t.Errorf("CacheCreationTokens = %d, want 1200", usage.CacheCreationTokens)
+	}
+	if usage.CacheReadTokens != 500 {
+		t.Errorf("CacheReadTokens = %d, want 500", usage.CacheReadTokens)
+	}
+}
+
+func TestParseTokenCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		number, suffix string
+		want           int
+	}{
+		{"950", "", 950},
+		{"2.3", "k", 2300},
+		{"1,204", "", 1204},
+		{"1.5", "M", 1_500_000},
+		{"x", "", 0},
+	}
+	for _, tt := range tests {
+		if got := parseTokenCount(tt.number, tt.suffix); got != tt.want {
+			t.Errorf("parseTokenCount(%q, %q) = %d, want %d", tt.number, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestChat_Messages(t *testing.T) {
+	t.Parallel()
+
+	chat, ok := LatestChat([]byte(`# aider chat started at 2026-03-01 09:15:00
+
+#### fix the typo
+
+I'll fix it.
+
+It's in README.md.
+
+> Applied edit to README.md
+> Commit 1a2b3c4 docs: Fix typo
+`))
+	if !ok {
+		t.Fatal("LatestChat() found no chat")
+	}
+
+	messages := chat.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("Messages() got %d messages, want 3: %+v", len(messages), messages)
+	}
+	if messages[0].Role != RoleUser || messages[0].Text != "fix the typo" {
+		t.Errorf("messages[0] = %+v", messages[0])
+	}
+	if messages[1].Role != RoleAssistant || messages[1].Text != "I'll fix it.\n\nIt's in README.md." {
+		t.Errorf("messages[1] = %+v", messages[1])
+	}
+	if messages[2].Role != RoleTool || !slices.Equal(messages[2].EditedFiles, []string{"README.md"}) {
+		t.Errorf("messages[2] = %+v", messages[2])
+	}
+}
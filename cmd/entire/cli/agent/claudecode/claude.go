@@ -15,6 +15,7 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/sessionid"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
 )
 
 //nolint:gochecknoinits // Agent self-registration is the intended pattern
@@ -115,6 +116,23 @@ func (c *ClaudeCodeAgent) ParseHookInput(hookType agent.HookType, reader io.Read
 		}
 		input.SessionID = raw.SessionID
 		input.SessionRef = raw.TranscriptPath
+		if raw.Source != "" {
+			input.RawData["source"] = raw.Source
+		}
+
+	case agent.HookSubagentStop:
+		var raw subagentStopRaw
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse subagent stop: %w", err)
+		}
+		input.SessionID = raw.SessionID
+		input.SessionRef = raw.TranscriptPath
+		if raw.AgentID != "" {
+			input.RawData["agent_id"] = raw.AgentID
+		}
+		if raw.AgentTranscriptPath != "" {
+			input.RawData["agent_transcript_path"] = raw.AgentTranscriptPath
+		}
 
 	case agent.HookPreToolUse:
 		var raw taskHookInputRaw
@@ -390,7 +408,7 @@ func (c *ClaudeCodeAgent) GetTranscriptPosition(path string) (int, error) {
 
 // ExtractModifiedFilesFromOffset extracts files modified since a given line number.
 // For Claude Code (JSONL format), offset is the starting line number.
-// Uses bufio.Reader to handle arbitrarily long lines (no size limit).
+// Lines before startOffset are read but not decoded; there is no line length limit.
 // Returns:
 //   - files: list of file paths modified by Claude (from Write/Edit tools)
 //   - currentPosition: total number of lines in the file
@@ -406,33 +424,16 @@ func (c *ClaudeCodeAgent) ExtractModifiedFilesFromOffset(path string, startOffse
 	}
 	defer file.Close()
 
-	reader := bufio.NewReader(file)
 	var lines []TranscriptLine
-	lineNum := 0
-
-	for {
-		lineData, readErr := reader.ReadBytes('\n')
-		if readErr != nil && readErr != io.EOF {
-			return nil, 0, fmt.Errorf("failed to read transcript: %w", readErr)
-		}
-
-		if len(lineData) > 0 {
-			lineNum++
-			if lineNum > startOffset {
-				var line TranscriptLine
-				if parseErr := json.Unmarshal(lineData, &line); parseErr == nil {
-					lines = append(lines, line)
-				}
-				// Skip malformed lines silently
-			}
-		}
-
-		if readErr == io.EOF {
-			break
-		}
+	scanner := transcript.NewScannerFromLine(file, startOffset)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Line())
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, 0, scanErr //nolint:wrapcheck // already wrapped by the transcript package
 	}
 
-	return ExtractModifiedFiles(lines), lineNum, nil
+	return ExtractModifiedFiles(lines), scanner.LinesRead(), nil
 }
 
 // TranscriptChunker interface implementation
@@ -65,4 +65,44 @@ func TestParseHookInput_SessionStart_NoPrompt(t *testing.T) {
 	if result.UserPrompt != "" {
 		t.Errorf("UserPrompt = %q, want empty", result.UserPrompt)
 	}
+	if _, ok := result.RawData["source"]; ok {
+		t.Errorf("RawData[source] = %v, want unset", result.RawData["source"])
+	}
+}
+
+func TestParseHookInput_SessionStart_Resume(t *testing.T) {
+	t.Parallel()
+
+	c := &ClaudeCodeAgent{}
+	input := `{"session_id":"sess-456","transcript_path":"/tmp/transcript.jsonl","source":"resume"}`
+
+	result, err := c.ParseHookInput(agent.HookSessionStart, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseHookInput() error = %v", err)
+	}
+	if result.RawData["source"] != "resume" {
+		t.Errorf("RawData[source] = %v, want %q", result.RawData["source"], "resume")
+	}
+}
+
+func TestParseHookInput_SubagentStop(t *testing.T) {
+	t.Parallel()
+
+	c := &ClaudeCodeAgent{}
+	input := `{"session_id":"sess-789","transcript_path":"/tmp/transcript.jsonl","stop_hook_active":false,"agent_id":"a1b2c3","agent_transcript_path":"/tmp/agent-a1b2c3.jsonl"}`
+
+	result, err := c.ParseHookInput(agent.HookSubagentStop, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseHookInput() error = %v", err)
+	}
+
+	if result.SessionID != "sess-789" {
+		t.Errorf("SessionID = %q, want %q", result.SessionID, "sess-789")
+	}
+	if result.RawData["agent_id"] != "a1b2c3" {
+		t.Errorf("RawData[agent_id] = %v, want %q", result.RawData["agent_id"], "a1b2c3")
+	}
+	if result.RawData["agent_transcript_path"] != "/tmp/agent-a1b2c3.jsonl" {
+		t.Errorf("RawData[agent_transcript_path] = %v, want %q", result.RawData["agent_transcript_path"], "/tmp/agent-a1b2c3.jsonl")
+	}
 }
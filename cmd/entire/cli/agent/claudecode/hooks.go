@@ -13,10 +13,12 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 )
 
-// Ensure ClaudeCodeAgent implements HookSupport and HookHandler
+// Ensure ClaudeCodeAgent implements the hook and transcript adapter interfaces
 var (
-	_ agent.HookSupport = (*ClaudeCodeAgent)(nil)
-	_ agent.HookHandler = (*ClaudeCodeAgent)(nil)
+	_ agent.HookSupport        = (*ClaudeCodeAgent)(nil)
+	_ agent.HookHandler        = (*ClaudeCodeAgent)(nil)
+	_ agent.TranscriptAnalyzer = (*ClaudeCodeAgent)(nil)
+	_ agent.TranscriptChunker  = (*ClaudeCodeAgent)(nil)
 )
 
 // Claude Code hook names - these become subcommands under `entire hooks claude-code`
@@ -28,6 +30,7 @@ const (
 	HookNamePreTask          = "pre-task"
 	HookNamePostTask         = "post-task"
 	HookNamePostTodo         = "post-todo"
+	HookNameSubagentStop     = "subagent-stop"
 )
 
 // ClaudeSettingsFileName is the settings file used by Claude Code.
@@ -48,6 +51,7 @@ func (c *ClaudeCodeAgent) GetHookNames() []string {
 		HookNamePreTask,
 		HookNamePostTask,
 		HookNamePostTodo,
+		HookNameSubagentStop,
 	}
 }
 
@@ -81,13 +85,19 @@ func (c *ClaudeCodeAgent) InstallHooks(localDev bool, force bool) (int, error) {
 	// rawPermissions preserves unknown permission fields (e.g., "ask")
 	var rawPermissions map[string]json.RawMessage
 
+	// rawHooks preserves hook events Entire doesn't manage; upgraded is set
+	// when existing hooks use a legacy shape and need rewriting
+	var rawHooks map[string]json.RawMessage
+	upgraded := false
+
 	existingData, readErr := os.ReadFile(settingsPath) //nolint:gosec // path is constructed from cwd + fixed path
 	if readErr == nil {
 		if err := json.Unmarshal(existingData, &rawSettings); err != nil {
 			return 0, fmt.Errorf("failed to parse existing settings.json: %w", err)
 		}
 		if hooksRaw, ok := rawSettings["hooks"]; ok {
-			if err := json.Unmarshal(hooksRaw, &settings.Hooks); err != nil {
+			settings.Hooks, rawHooks, upgraded, err = parseClaudeHooks(hooksRaw)
+			if err != nil {
 				return 0, fmt.Errorf("failed to parse hooks in settings.json: %w", err)
 			}
 		}
@@ -109,13 +119,14 @@ func (c *ClaudeCodeAgent) InstallHooks(localDev bool, force bool) (int, error) {
 		settings.Hooks.SessionStart = removeEntireHooks(settings.Hooks.SessionStart)
 		settings.Hooks.SessionEnd = removeEntireHooks(settings.Hooks.SessionEnd)
 		settings.Hooks.Stop = removeEntireHooks(settings.Hooks.Stop)
+		settings.Hooks.SubagentStop = removeEntireHooks(settings.Hooks.SubagentStop)
 		settings.Hooks.UserPromptSubmit = removeEntireHooks(settings.Hooks.UserPromptSubmit)
 		settings.Hooks.PreToolUse = removeEntireHooksFromMatchers(settings.Hooks.PreToolUse)
 		settings.Hooks.PostToolUse = removeEntireHooksFromMatchers(settings.Hooks.PostToolUse)
 	}
 
 	// Define hook commands
-	var sessionStartCmd, sessionEndCmd, stopCmd, userPromptSubmitCmd, preTaskCmd, postTaskCmd, postTodoCmd string
+	var sessionStartCmd, sessionEndCmd, stopCmd, userPromptSubmitCmd, preTaskCmd, postTaskCmd, postTodoCmd, subagentStopCmd string
 	if localDev {
 		sessionStartCmd = "go run ${CLAUDE_PROJECT_DIR}/cmd/entire/main.go hooks claude-code session-start"
 		sessionEndCmd = "go run ${CLAUDE_PROJECT_DIR}/cmd/entire/main.go hooks claude-code session-end"
@@ -124,6 +135,7 @@ func (c *ClaudeCodeAgent) InstallHooks(localDev bool, force bool) (int, error) {
 		preTaskCmd = "go run ${CLAUDE_PROJECT_DIR}/cmd/entire/main.go hooks claude-code pre-task"
 		postTaskCmd = "go run ${CLAUDE_PROJECT_DIR}/cmd/entire/main.go hooks claude-code post-task"
 		postTodoCmd = "go run ${CLAUDE_PROJECT_DIR}/cmd/entire/main.go hooks claude-code post-todo"
+		subagentStopCmd = "go run ${CLAUDE_PROJECT_DIR}/cmd/entire/main.go hooks claude-code subagent-stop"
 	} else {
 		sessionStartCmd = "entire hooks claude-code session-start"
 		sessionEndCmd = "entire hooks claude-code session-end"
@@ -132,6 +144,7 @@ func (c *ClaudeCodeAgent) InstallHooks(localDev bool, force bool) (int, error) {
 		preTaskCmd = "entire hooks claude-code pre-task"
 		postTaskCmd = "entire hooks claude-code post-task"
 		postTodoCmd = "entire hooks claude-code post-todo"
+		subagentStopCmd = "entire hooks claude-code subagent-stop"
 	}
 
 	count := 0
@@ -149,6 +162,10 @@ func (c *ClaudeCodeAgent) InstallHooks(localDev bool, force bool) (int, error) {
 		settings.Hooks.Stop = addHookToMatcher(settings.Hooks.Stop, "", stopCmd)
 		count++
 	}
+	if !hookCommandExists(settings.Hooks.SubagentStop, subagentStopCmd) {
+		settings.Hooks.SubagentStop = addHookToMatcher(settings.Hooks.SubagentStop, "", subagentStopCmd)
+		count++
+	}
 	if !hookCommandExists(settings.Hooks.UserPromptSubmit, userPromptSubmitCmd) {
 		settings.Hooks.UserPromptSubmit = addHookToMatcher(settings.Hooks.UserPromptSubmit, "", userPromptSubmitCmd)
 		count++
@@ -184,14 +201,14 @@ func (c *ClaudeCodeAgent) InstallHooks(localDev bool, force bool) (int, error) {
 		permissionsChanged = true
 	}
 
-	if count == 0 && !permissionsChanged {
+	if count == 0 && !permissionsChanged && !upgraded {
 		return 0, nil // All hooks and permissions already installed
 	}
 
-	// Marshal hooks and update raw settings
-	hooksJSON, err := json.Marshal(settings.Hooks)
+	// Marshal hooks in the schema the installed Claude Code expects
+	hooksJSON, err := marshalClaudeHooks(settings.Hooks, rawHooks, installedClaudeHookSchema())
 	if err != nil {
-		return 0, fmt.Errorf("failed to marshal hooks: %w", err)
+		return 0, err
 	}
 	rawSettings["hooks"] = hooksJSON
 
@@ -238,8 +255,10 @@ func (c *ClaudeCodeAgent) UninstallHooks() error {
 	}
 
 	var settings ClaudeSettings
+	var rawHooks map[string]json.RawMessage
 	if hooksRaw, ok := rawSettings["hooks"]; ok {
-		if err := json.Unmarshal(hooksRaw, &settings.Hooks); err != nil {
+		settings.Hooks, rawHooks, _, err = parseClaudeHooks(hooksRaw)
+		if err != nil {
 			return fmt.Errorf("failed to parse hooks: %w", err)
 		}
 	}
@@ -248,6 +267,7 @@ func (c *ClaudeCodeAgent) UninstallHooks() error {
 	settings.Hooks.SessionStart = removeEntireHooks(settings.Hooks.SessionStart)
 	settings.Hooks.SessionEnd = removeEntireHooks(settings.Hooks.SessionEnd)
 	settings.Hooks.Stop = removeEntireHooks(settings.Hooks.Stop)
+	settings.Hooks.SubagentStop = removeEntireHooks(settings.Hooks.SubagentStop)
 	settings.Hooks.UserPromptSubmit = removeEntireHooks(settings.Hooks.UserPromptSubmit)
 	settings.Hooks.PreToolUse = removeEntireHooksFromMatchers(settings.Hooks.PreToolUse)
 	settings.Hooks.PostToolUse = removeEntireHooksFromMatchers(settings.Hooks.PostToolUse)
@@ -295,10 +315,10 @@ func (c *ClaudeCodeAgent) UninstallHooks() error {
 		}
 	}
 
-	// Marshal hooks back
-	hooksJSON, err := json.Marshal(settings.Hooks)
+	// Marshal hooks back, upgrading any legacy entries left behind
+	hooksJSON, err := marshalClaudeHooks(settings.Hooks, rawHooks, installedClaudeHookSchema())
 	if err != nil {
-		return fmt.Errorf("failed to marshal hooks: %w", err)
+		return err
 	}
 	rawSettings["hooks"] = hooksJSON
 
@@ -326,10 +346,16 @@ func (c *ClaudeCodeAgent) AreHooksInstalled() bool {
 		return false
 	}
 
-	var settings ClaudeSettings
-	if err := json.Unmarshal(data, &settings); err != nil {
+	var rawSettings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawSettings); err != nil {
 		return false
 	}
+	var settings ClaudeSettings
+	if hooksRaw, ok := rawSettings["hooks"]; ok {
+		if settings.Hooks, _, _, err = parseClaudeHooks(hooksRaw); err != nil {
+			return false
+		}
+	}
 
 	// Check for at least one of our hooks (new or old format)
 	return hookCommandExists(settings.Hooks.Stop, "entire hooks claude-code stop") ||
@@ -348,6 +374,7 @@ func (c *ClaudeCodeAgent) GetSupportedHooks() []agent.HookType {
 		agent.HookSessionEnd,
 		agent.HookUserPromptSubmit,
 		agent.HookStop,
+		agent.HookSubagentStop,
 		agent.HookPreToolUse,
 		agent.HookPostToolUse,
 	}
@@ -0,0 +1,324 @@
+package claudecode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Claude Code has changed the shape of the "hooks" section of settings.json
+// across releases. The current shape is an array of matcher groups per event:
+//
+//	"Stop": [{"matcher": "", "hooks": [{"type": "command", "command": "..."}]}]
+//
+// Events that don't match tools may omit the "matcher" key. Older files may
+// instead contain a flat list of hook entries per event, an object keyed by
+// matcher, or a single matcher group instead of an array. parseClaudeHooks
+// reads all of these,
+// and marshalClaudeHooks writes them back in the current shape, so legacy
+// entries are upgraded in place whenever Entire rewrites the file.
+
+// claudeHookEvents are the hook events Entire reads and writes. Events not
+// listed here (e.g. Notification) are preserved untouched.
+var claudeHookEvents = []string{
+	"SessionStart",
+	"SessionEnd",
+	"UserPromptSubmit",
+	"Stop",
+	"SubagentStop",
+	"PreToolUse",
+	"PostToolUse",
+}
+
+// claudeToolEvents are the events whose matcher selects a tool.
+var claudeToolEvents = map[string]bool{
+	"PreToolUse":  true,
+	"PostToolUse": true,
+}
+
+// claudeHookSchema describes how hooks are written for a Claude Code version.
+type claudeHookSchema struct {
+	// OmitEmptyMatcher drops `"matcher": ""` from groups of events that don't
+	// match tools. Older releases require the key on every group.
+	OmitEmptyMatcher bool
+}
+
+// claudeMatcherOptionalVersion is the first Claude Code release Entire writes
+// matcher-less groups for. Unknown versions get the older, more explicit
+// shape, which every release accepts.
+var claudeMatcherOptionalVersion = [3]int{2, 0, 0}
+
+// claudeVersionTimeout bounds how long `claude --version` may take.
+const claudeVersionTimeout = 3 * time.Second
+
+// claudeVersionPattern matches the version in `claude --version` output,
+// e.g. "2.0.14 (Claude Code)".
+var claudeVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// detectClaudeVersion returns the output of `claude --version`.
+// Tests replace it to avoid depending on the installed CLI.
+var detectClaudeVersion = func() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), claudeVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "claude", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run claude --version: %w", err)
+	}
+	return string(out), nil
+}
+
+// installedClaudeHookSchema returns the schema for the installed Claude Code,
+// falling back to the explicit shape if the version can't be determined.
+func installedClaudeHookSchema() claudeHookSchema {
+	out, err := detectClaudeVersion()
+	if err != nil {
+		return claudeHookSchema{}
+	}
+	version, ok := parseClaudeVersion(out)
+	if !ok {
+		return claudeHookSchema{}
+	}
+	return claudeHookSchemaFor(version)
+}
+
+// claudeHookSchemaFor returns the schema a Claude Code version expects.
+func claudeHookSchemaFor(version [3]int) claudeHookSchema {
+	return claudeHookSchema{OmitEmptyMatcher: !versionLess(version, claudeMatcherOptionalVersion)}
+}
+
+// parseClaudeVersion extracts major.minor.patch from `claude --version` output.
+func parseClaudeVersion(s string) ([3]int, bool) {
+	m := claudeVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return [3]int{}, false
+	}
+	var version [3]int
+	for i := range version {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return [3]int{}, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+func versionLess(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// parseClaudeHooks parses the "hooks" section of settings.json, accepting
+// legacy shapes. It returns the events Entire manages, the raw value of every
+// event (so unmanaged ones can be written back unchanged), and whether any
+// managed event was in a legacy shape.
+func parseClaudeHooks(data json.RawMessage) (ClaudeHooks, map[string]json.RawMessage, bool, error) {
+	var hooks ClaudeHooks
+	rawHooks := make(map[string]json.RawMessage)
+	if len(data) == 0 || bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return hooks, rawHooks, false, nil
+	}
+	if err := json.Unmarshal(data, &rawHooks); err != nil {
+		return ClaudeHooks{}, nil, false, fmt.Errorf("hooks must be an object: %w", err)
+	}
+
+	upgraded := false
+	for _, event := range claudeHookEvents {
+		raw, ok := rawHooks[event]
+		if !ok {
+			continue
+		}
+		matchers, legacy, err := parseHookMatchers(raw)
+		if err != nil {
+			return ClaudeHooks{}, nil, false, fmt.Errorf("invalid %s hooks: %w", event, err)
+		}
+		upgraded = upgraded || legacy
+		*hookEventField(&hooks, event) = matchers
+	}
+	return hooks, rawHooks, upgraded, nil
+}
+
+// marshalClaudeHooks writes hooks in the current shape for schema, keeping
+// events Entire doesn't manage as they were in rawHooks.
+func marshalClaudeHooks(hooks ClaudeHooks, rawHooks map[string]json.RawMessage, schema claudeHookSchema) (json.RawMessage, error) {
+	out := make(map[string]json.RawMessage, len(rawHooks)+len(claudeHookEvents))
+	for event, raw := range rawHooks {
+		out[event] = raw
+	}
+	for _, event := range claudeHookEvents {
+		matchers := *hookEventField(&hooks, event)
+		if len(matchers) == 0 {
+			delete(out, event)
+			continue
+		}
+		groups := make([]claudeHookGroupJSON, len(matchers))
+		for i, m := range matchers {
+			groups[i] = claudeHookGroupJSON{Hooks: m.Hooks}
+			if m.Matcher != "" || claudeToolEvents[event] || !schema.OmitEmptyMatcher {
+				matcher := m.Matcher
+				groups[i].Matcher = &matcher
+			}
+		}
+		data, err := json.Marshal(groups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s hooks: %w", event, err)
+		}
+		out[event] = data
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal hooks: %w", err)
+	}
+	return data, nil
+}
+
+// claudeHookGroupJSON is a matcher group as written to settings.json.
+type claudeHookGroupJSON struct {
+	Matcher *string           `json:"matcher,omitempty"`
+	Hooks   []ClaudeHookEntry `json:"hooks"`
+}
+
+// hookEventField returns the ClaudeHooks field for event.
+func hookEventField(hooks *ClaudeHooks, event string) *[]ClaudeHookMatcher {
+	switch event {
+	case "SessionStart":
+		return &hooks.SessionStart
+	case "SessionEnd":
+		return &hooks.SessionEnd
+	case "UserPromptSubmit":
+		return &hooks.UserPromptSubmit
+	case "Stop":
+		return &hooks.Stop
+	case "SubagentStop":
+		return &hooks.SubagentStop
+	case "PreToolUse":
+		return &hooks.PreToolUse
+	case "PostToolUse":
+		return &hooks.PostToolUse
+	default:
+		panic("unknown Claude hook event " + event)
+	}
+}
+
+// parseHookMatchers parses one event's hooks. It accepts:
+//
+//   - [{"matcher": "Task", "hooks": [...]}]: the current shape
+//   - [{"hooks": [...]}]: a group without a matcher (current for events without tools)
+//   - [{"type": "command", "command": "..."}]: flat entries, optionally with their own "matcher"
+//   - {"matcher": "Task", "hooks": [...]}: a single group
+//   - {"Task": [...]}: groups keyed by matcher, each a list of entries or a group
+//
+// legacy is true for anything but the current shape.
+func parseHookMatchers(raw json.RawMessage) (matchers []ClaudeHookMatcher, legacy bool, err error) {
+	trimmed := bytes.TrimSpace(raw)
+	switch {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+		return nil, false, nil
+	case trimmed[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, false, err //nolint:wrapcheck // caller adds the event name
+		}
+		for _, item := range items {
+			m, flat, err := parseHookItem(item, "")
+			if err != nil {
+				return nil, false, err
+			}
+			if !flat {
+				matchers = append(matchers, m)
+				continue
+			}
+			// Fold consecutive flat entries into one group per matcher
+			legacy = true
+			if n := len(matchers); n > 0 && matchers[n-1].Matcher == m.Matcher {
+				matchers[n-1].Hooks = append(matchers[n-1].Hooks, m.Hooks...)
+			} else {
+				matchers = append(matchers, m)
+			}
+		}
+		return matchers, legacy, nil
+	case trimmed[0] == '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &obj); err != nil {
+			return nil, false, err //nolint:wrapcheck // caller adds the event name
+		}
+		if _, ok := obj["hooks"]; ok {
+			m, _, err := parseHookItem(trimmed, "")
+			if err != nil {
+				return nil, false, err
+			}
+			return []ClaudeHookMatcher{m}, true, nil
+		}
+		// Keyed by matcher; sort for a stable result
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			m, err := parseKeyedHookGroup(obj[key], key)
+			if err != nil {
+				return nil, false, err
+			}
+			matchers = append(matchers, m)
+		}
+		return matchers, true, nil
+	default:
+		return nil, false, errors.New("expected an array or object")
+	}
+}
+
+// parseKeyedHookGroup parses the value of {"<matcher>": ...}: either a list of
+// entries or a group object.
+func parseKeyedHookGroup(raw json.RawMessage, matcher string) (ClaudeHookMatcher, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []ClaudeHookEntry
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return ClaudeHookMatcher{}, err //nolint:wrapcheck // caller adds the event name
+		}
+		return ClaudeHookMatcher{Matcher: matcher, Hooks: entries}, nil
+	}
+	m, _, err := parseHookItem(trimmed, matcher)
+	return m, err
+}
+
+// parseHookItem parses an element of an event's array: a matcher group or a
+// flat entry (flat is true). defaultMatcher applies when the item has no matcher.
+func parseHookItem(raw json.RawMessage, defaultMatcher string) (m ClaudeHookMatcher, flat bool, err error) {
+	var item struct {
+		Matcher *string           `json:"matcher"`
+		Hooks   []ClaudeHookEntry `json:"hooks"`
+		Type    string            `json:"type"`
+		Command string            `json:"command"`
+	}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return ClaudeHookMatcher{}, false, err //nolint:wrapcheck // caller adds the event name
+	}
+	matcher := defaultMatcher
+	if item.Matcher != nil {
+		matcher = *item.Matcher
+	}
+	if item.Hooks == nil && item.Command != "" {
+		entryType := item.Type
+		if entryType == "" {
+			entryType = "command"
+		}
+		return ClaudeHookMatcher{
+			Matcher: matcher,
+			Hooks:   []ClaudeHookEntry{{Type: entryType, Command: item.Command}},
+		}, true, nil
+	}
+	return ClaudeHookMatcher{Matcher: matcher, Hooks: item.Hooks}, false, nil
+}
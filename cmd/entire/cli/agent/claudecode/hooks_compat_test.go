@@ -0,0 +1,202 @@
+package claudecode
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubClaudeVersion makes detectClaudeVersion return output (or fail if empty).
+func stubClaudeVersion(t *testing.T, output string) {
+	t.Helper()
+	original := detectClaudeVersion
+	detectClaudeVersion = func() (string, error) {
+		if output == "" {
+			return "", errors.New("claude not found")
+		}
+		return output, nil
+	}
+	t.Cleanup(func() { detectClaudeVersion = original })
+}
+
+func TestParseHookMatchers(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		want       []ClaudeHookMatcher
+		wantLegacy bool
+	}{
+		{
+			name: "current",
+			raw:  `[{"matcher": "Task", "hooks": [{"type": "command", "command": "a"}]}]`,
+			want: []ClaudeHookMatcher{{Matcher: "Task", Hooks: []ClaudeHookEntry{{Type: "command", Command: "a"}}}},
+		},
+		{
+			name: "group without matcher",
+			raw:  `[{"hooks": [{"type": "command", "command": "a"}]}]`,
+			want: []ClaudeHookMatcher{{Hooks: []ClaudeHookEntry{{Type: "command", Command: "a"}}}},
+		},
+		{
+			name: "flat entries",
+			raw:  `[{"type": "command", "command": "a"}, {"command": "b"}, {"command": "c", "matcher": "Task"}]`,
+			want: []ClaudeHookMatcher{
+				{Hooks: []ClaudeHookEntry{{Type: "command", Command: "a"}, {Type: "command", Command: "b"}}},
+				{Matcher: "Task", Hooks: []ClaudeHookEntry{{Type: "command", Command: "c"}}},
+			},
+			wantLegacy: true,
+		},
+		{
+			name:       "single group object",
+			raw:        `{"matcher": "Task", "hooks": [{"type": "command", "command": "a"}]}`,
+			want:       []ClaudeHookMatcher{{Matcher: "Task", Hooks: []ClaudeHookEntry{{Type: "command", Command: "a"}}}},
+			wantLegacy: true,
+		},
+		{
+			name: "keyed by matcher",
+			raw:  `{"TodoWrite": {"hooks": [{"type": "command", "command": "b"}]}, "Task": [{"type": "command", "command": "a"}]}`,
+			want: []ClaudeHookMatcher{
+				{Matcher: "Task", Hooks: []ClaudeHookEntry{{Type: "command", Command: "a"}}},
+				{Matcher: "TodoWrite", Hooks: []ClaudeHookEntry{{Type: "command", Command: "b"}}},
+			},
+			wantLegacy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, legacy, err := parseHookMatchers(json.RawMessage(tt.raw))
+			if err != nil {
+				t.Fatalf("parseHookMatchers() error = %v", err)
+			}
+			if legacy != tt.wantLegacy {
+				t.Errorf("legacy = %v, want %v", legacy, tt.wantLegacy)
+			}
+			gotJSON, _ := json.Marshal(got)      //nolint:errchkjson // test comparison
+			wantJSON, _ := json.Marshal(tt.want) //nolint:errchkjson // test comparison
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("parseHookMatchers() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	if _, _, err := parseHookMatchers(json.RawMessage(`"entire hooks claude-code stop"`)); err == nil {
+		t.Error("parseHookMatchers() accepted a string")
+	}
+}
+
+func TestParseClaudeVersion(t *testing.T) {
+	version, ok := parseClaudeVersion("2.0.14 (Claude Code)\n")
+	if !ok || version != [3]int{2, 0, 14} {
+		t.Errorf("parseClaudeVersion() = %v, %v", version, ok)
+	}
+	if _, ok := parseClaudeVersion("command not found"); ok {
+		t.Error("parseClaudeVersion() parsed output without a version")
+	}
+	if claudeHookSchemaFor([3]int{1, 0, 90}).OmitEmptyMatcher {
+		t.Error("1.x schema should keep empty matchers")
+	}
+	if !claudeHookSchemaFor([3]int{2, 1, 0}).OmitEmptyMatcher {
+		t.Error("2.x schema should omit empty matchers")
+	}
+}
+
+func TestInstallHooks_UpgradesLegacyHooks(t *testing.T) {
+	stubClaudeVersion(t, "")
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	writeSettingsFile(t, tempDir, `{
+  "hooks": {
+    "Stop": [{"type": "command", "command": "echo done"}],
+    "PostToolUse": {"Write": [{"type": "command", "command": "gofmt -w ."}]},
+    "Notification": [{"matcher": "", "hooks": [{"type": "command", "command": "notify-send hi"}]}]
+  }
+}`)
+
+	agent := &ClaudeCodeAgent{}
+	if _, err := agent.InstallHooks(false, false); err != nil {
+		t.Fatalf("InstallHooks() error = %v", err)
+	}
+
+	settings := readClaudeSettings(t, tempDir)
+	if !hookCommandExists(settings.Hooks.Stop, "echo done") || !hookCommandExists(settings.Hooks.Stop, "entire hooks claude-code stop") {
+		t.Errorf("Stop hooks = %+v, want user and Entire hooks", settings.Hooks.Stop)
+	}
+	if !hookCommandExistsWithMatcher(settings.Hooks.PostToolUse, "Write", "gofmt -w .") {
+		t.Errorf("PostToolUse hooks = %+v, want the Write hook upgraded", settings.Hooks.PostToolUse)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, ".claude", "settings.json"))
+	if err != nil {
+		t.Fatalf("failed to read settings.json: %v", err)
+	}
+	if !strings.Contains(string(data), "notify-send hi") {
+		t.Errorf("Notification hook was not preserved:\n%s", data)
+	}
+
+	// Legacy shapes alone trigger a rewrite, even with Entire hooks present
+	writeSettingsFile(t, tempDir, `{
+  "hooks": {"Stop": [{"type": "command", "command": "entire hooks claude-code stop"}]}
+}`)
+	if _, err := agent.InstallHooks(false, false); err != nil {
+		t.Fatalf("InstallHooks() error = %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(tempDir, ".claude", "settings.json"))
+	if err != nil {
+		t.Fatalf("failed to read settings.json: %v", err)
+	}
+	var raw struct {
+		Hooks map[string][]map[string]json.RawMessage `json:"hooks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("settings.json not in the current shape: %v\n%s", err, data)
+	}
+	if _, ok := raw.Hooks["Stop"][0]["hooks"]; !ok {
+		t.Errorf("Stop entry not wrapped in a matcher group:\n%s", data)
+	}
+}
+
+func TestInstallHooks_SchemaForClaudeVersion(t *testing.T) {
+	tests := []struct {
+		version          string
+		wantEmptyMatcher bool
+	}{
+		{"1.0.90 (Claude Code)", true},
+		{"2.0.14 (Claude Code)", false},
+		{"", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			stubClaudeVersion(t, tt.version)
+			tempDir := t.TempDir()
+			t.Chdir(tempDir)
+
+			agent := &ClaudeCodeAgent{}
+			if _, err := agent.InstallHooks(false, false); err != nil {
+				t.Fatalf("InstallHooks() error = %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(tempDir, ".claude", "settings.json"))
+			if err != nil {
+				t.Fatalf("failed to read settings.json: %v", err)
+			}
+			var raw struct {
+				Hooks map[string][]map[string]json.RawMessage `json:"hooks"`
+			}
+			if err := json.Unmarshal(data, &raw); err != nil {
+				t.Fatalf("failed to parse settings.json: %v", err)
+			}
+			if _, ok := raw.Hooks["Stop"][0]["matcher"]; ok != tt.wantEmptyMatcher {
+				t.Errorf("Stop group has matcher = %v, want %v:\n%s", ok, tt.wantEmptyMatcher, data)
+			}
+			// Tool matchers are always written
+			if _, ok := raw.Hooks["PreToolUse"][0]["matcher"]; !ok {
+				t.Errorf("PreToolUse group missing matcher:\n%s", data)
+			}
+			if !agent.AreHooksInstalled() {
+				t.Error("AreHooksInstalled() = false after install")
+			}
+		})
+	}
+}
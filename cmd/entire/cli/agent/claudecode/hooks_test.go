@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -292,6 +293,13 @@ func TestUninstallHooks(t *testing.T) {
 	if !agent.AreHooksInstalled() {
 		t.Error("hooks should be installed before uninstall")
 	}
+	settingsData, err := os.ReadFile(filepath.Join(tempDir, ".claude", ClaudeSettingsFileName))
+	if err != nil {
+		t.Fatalf("failed to read settings.json: %v", err)
+	}
+	if !strings.Contains(string(settingsData), "entire hooks claude-code subagent-stop") {
+		t.Errorf("settings.json doesn't register the SubagentStop hook:\n%s", settingsData)
+	}
 
 	// Uninstall
 	err = agent.UninstallHooks()
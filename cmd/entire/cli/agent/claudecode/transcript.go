@@ -397,7 +397,12 @@ func CalculateTotalTokenUsage(transcriptPath string, startLine int, subagentsDir
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse transcript: %w", err)
 	}
+	return CalculateTotalTokenUsageFromLines(transcript, subagentsDir), nil
+}
 
+// CalculateTotalTokenUsageFromLines is CalculateTotalTokenUsage for a turn
+// whose transcript lines the caller already parsed.
+func CalculateTotalTokenUsageFromLines(transcript []TranscriptLine, subagentsDir string) *agent.TokenUsage {
 	// Calculate token usage from parsed transcript
 	mainUsage := CalculateTokenUsage(transcript)
 
@@ -425,5 +430,5 @@ func CalculateTotalTokenUsage(transcriptPath string, startLine int, subagentsDir
 		}
 	}
 
-	return mainUsage, nil
+	return mainUsage
 }
@@ -13,6 +13,7 @@ type ClaudeHooks struct {
 	SessionEnd       []ClaudeHookMatcher `json:"SessionEnd,omitempty"`
 	UserPromptSubmit []ClaudeHookMatcher `json:"UserPromptSubmit,omitempty"`
 	Stop             []ClaudeHookMatcher `json:"Stop,omitempty"`
+	SubagentStop     []ClaudeHookMatcher `json:"SubagentStop,omitempty"`
 	PreToolUse       []ClaudeHookMatcher `json:"PreToolUse,omitempty"`
 	PostToolUse      []ClaudeHookMatcher `json:"PostToolUse,omitempty"`
 }
@@ -33,6 +34,17 @@ type ClaudeHookEntry struct {
 type sessionInfoRaw struct {
 	SessionID      string `json:"session_id"`
 	TranscriptPath string `json:"transcript_path"`
+	// Source is why SessionStart fired: startup, resume, clear or compact
+	Source string `json:"source"`
+}
+
+// subagentStopRaw is the JSON structure from SubagentStop hooks. Newer Claude
+// Code releases identify the subagent that finished; older ones don't.
+type subagentStopRaw struct {
+	SessionID           string `json:"session_id"`
+	TranscriptPath      string `json:"transcript_path"`
+	AgentID             string `json:"agent_id"`
+	AgentTranscriptPath string `json:"agent_transcript_path"`
 }
 
 // userPromptSubmitRaw is the JSON structure from UserPromptSubmit hooks.
@@ -5,16 +5,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 )
 
-// Ensure GeminiCLIAgent implements HookSupport and HookHandler
+// Ensure GeminiCLIAgent implements the hook and transcript adapter interfaces
 var (
-	_ agent.HookSupport = (*GeminiCLIAgent)(nil)
-	_ agent.HookHandler = (*GeminiCLIAgent)(nil)
+	_ agent.HookSupport        = (*GeminiCLIAgent)(nil)
+	_ agent.HookHandler        = (*GeminiCLIAgent)(nil)
+	_ agent.TranscriptAnalyzer = (*GeminiCLIAgent)(nil)
+	_ agent.TranscriptChunker  = (*GeminiCLIAgent)(nil)
 )
 
 // Gemini CLI hook names - these become subcommands under `entire hooks gemini`
@@ -39,6 +42,21 @@ const GeminiSettingsFileName = "settings.json"
 var entireHookPrefixes = []string{
 	"entire ",
 	"go run ${GEMINI_PROJECT_DIR}/cmd/entire/main.go ",
+	localDevHookPrefixWindows,
+}
+
+// localDevHookPrefixWindows runs local-dev hooks on Windows, where hook
+// commands may run in PowerShell or cmd, neither of which expands
+// ${GEMINI_PROJECT_DIR}. Hooks run in the project directory, so a relative
+// path works in any shell.
+const localDevHookPrefixWindows = "go run ./cmd/entire/main.go "
+
+// localDevHookPrefix returns the command prefix of local-dev hooks on goos.
+func localDevHookPrefix(goos string) string {
+	if goos == "windows" {
+		return localDevHookPrefixWindows
+	}
+	return "go run ${GEMINI_PROJECT_DIR}/cmd/entire/main.go "
 }
 
 // GetHookNames returns the hook verbs Gemini CLI supports.
@@ -106,7 +124,7 @@ func (g *GeminiCLIAgent) InstallHooks(localDev bool, force bool) (int, error) {
 	// Define hook commands based on localDev mode
 	var cmdPrefix string
 	if localDev {
-		cmdPrefix = "go run ${GEMINI_PROJECT_DIR}/cmd/entire/main.go hooks gemini "
+		cmdPrefix = localDevHookPrefix(runtime.GOOS) + "hooks gemini "
 	} else {
 		cmdPrefix = "entire hooks gemini "
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -107,6 +108,19 @@ func TestInstallHooks_LocalDev(t *testing.T) {
 	verifyHookCommand(t, settings.Hooks.Notification, "", "go run ${GEMINI_PROJECT_DIR}/cmd/entire/main.go hooks gemini notification")
 }
 
+func TestLocalDevHookPrefix(t *testing.T) {
+	t.Parallel()
+	for _, goos := range []string{"linux", "windows"} {
+		prefix := localDevHookPrefix(goos)
+		if !isEntireHook(prefix + "hooks gemini session-start") {
+			t.Errorf("local-dev hook on %s isn't recognized as an Entire hook", goos)
+		}
+		if goos == "windows" && strings.Contains(prefix, "$") {
+			t.Errorf("localDevHookPrefix(windows) = %q, want no shell variables", prefix)
+		}
+	}
+}
+
 func TestInstallHooks_Idempotent(t *testing.T) {
 	tempDir := t.TempDir()
 	t.Chdir(tempDir)
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"slices"
 	"sync"
+
+	"github.com/entireio/cli/cmd/entire/cli/validation"
 )
 
 var (
@@ -16,10 +18,14 @@ type Factory func() Agent
 
 // Register adds an agent factory to the registry.
 // Called from init() in each agent implementation.
+// Agents implementing IDProfileProvider also register their ID rules.
 func Register(name AgentName, factory Factory) {
 	registryMu.Lock()
 	defer registryMu.Unlock()
 	registry[name] = factory
+	if ag, ok := factory().(IDProfileProvider); ok {
+		validation.RegisterIDProfiles(string(ag.Type()), ag.IDProfiles())
+	}
 }
 
 // Get retrieves an agent by name.
@@ -80,12 +86,14 @@ type AgentType string
 const (
 	AgentNameClaudeCode AgentName = "claude-code"
 	AgentNameGemini     AgentName = "gemini"
+	AgentNameAider      AgentName = "aider"
 )
 
 // Agent type constants (type identifiers stored in metadata/trailers)
 const (
 	AgentTypeClaudeCode AgentType = "Claude Code"
 	AgentTypeGemini     AgentType = "Gemini CLI"
+	AgentTypeAider      AgentType = "Aider"
 	AgentTypeUnknown    AgentType = "Agent" // Fallback for backwards compatibility
 )
 
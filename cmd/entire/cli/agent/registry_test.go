@@ -1,8 +1,11 @@
 package agent
 
 import (
+	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/validation"
 )
 
 func TestRegistryOperations(t *testing.T) {
@@ -254,3 +257,40 @@ type protectedDirAgent struct {
 }
 
 func (p *protectedDirAgent) ProtectedDirs() []string { return p.dirs }
+
+// dottedIDAgent is a mock whose tool use IDs contain dots.
+type dottedIDAgent struct {
+	mockAgent
+}
+
+func (d *dottedIDAgent) Type() AgentType { return "Dotted ID Agent" }
+
+func (d *dottedIDAgent) IDProfiles() validation.IDProfiles {
+	profiles := validation.DefaultIDProfiles()
+	profiles.ToolUse = validation.IDProfile{Pattern: regexp.MustCompile(`^[a-z0-9.]+$`), Requirement: "must be lowercase alphanumeric with dots"}
+	return profiles
+}
+
+func TestRegister_IDProfiles(t *testing.T) {
+	originalRegistry := make(map[AgentName]Factory)
+	registryMu.Lock()
+	for k, v := range registry {
+		originalRegistry[k] = v
+	}
+	registryMu.Unlock()
+	defer func() {
+		registryMu.Lock()
+		registry = originalRegistry
+		registryMu.Unlock()
+	}()
+
+	profiles := validation.ProfilesFor("Dotted ID Agent")
+	if err := profiles.ValidateToolUseID("call.1"); err == nil {
+		t.Fatal("expected the default rules to reject dots before registration")
+	}
+	Register(AgentName("dotted"), func() Agent { return &dottedIDAgent{} })
+	profiles = validation.ProfilesFor("Dotted ID Agent")
+	if err := profiles.ValidateToolUseID("call.1"); err != nil {
+		t.Errorf("ValidateToolUseID(call.1) = %v, want the agent's rules to allow it", err)
+	}
+}
@@ -10,6 +10,7 @@ const (
 	HookSessionEnd       HookType = "session_end"
 	HookUserPromptSubmit HookType = "user_prompt_submit"
 	HookStop             HookType = "stop"
+	HookSubagentStop     HookType = "subagent_stop"
 	HookPreToolUse       HookType = "pre_tool_use"
 	HookPostToolUse      HookType = "post_tool_use"
 )
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// noteKindHeadings are the Markdown headings of `entire notes` per note kind.
+var noteKindHeadings = map[string]string{
+	session.NoteKindAssumption: "Assumptions",
+	session.NoteKindCaveat:     "Caveats",
+	session.NoteKindTodo:       "TODOs",
+	session.NoteKindQuestion:   "Open questions",
+	session.NoteKindNote:       "Notes",
+}
+
+func newNotesCmd() *cobra.Command {
+	var jsonFlag bool
+	var rangeFlag string
+
+	cmd := &cobra.Command{
+		Use:   "notes [commit]",
+		Short: "Show the notes the agent recorded about its work, as Markdown",
+		Long: `Shows the notes agents recorded with the entire_note MCP tool while working
+on a commit (assumptions, caveats, TODOs, open questions), grouped by kind
+as Markdown, ready to paste into a pull request description.
+
+Defaults to HEAD. With --range, shows the notes of every commit in the range,
+e.g. 'entire notes --range main..' for a feature branch. Prints nothing when
+there are no notes.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			commitRef := "HEAD"
+			if len(args) > 0 {
+				if rangeFlag != "" {
+					return errors.New("use either a commit or --range, not both")
+				}
+				commitRef = args[0]
+			}
+			return runNotes(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), commitRef, rangeFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	addCommitRangeFlag(cmd, &rangeFlag)
+
+	return cmd
+}
+
+// agentNoteJSON is a note with the checkpoint and session it belongs to.
+type agentNoteJSON struct {
+	checkpoint.Note
+
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	SessionID    string          `json:"session_id"`
+}
+
+func runNotes(ctx context.Context, w, errW io.Writer, commitRef, rangeSpec string, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	cpIDs := make(map[id.CheckpointID]bool)
+	if rangeSpec != "" {
+		commitRange, err := resolveCommitRange(repo, rangeSpec)
+		if err != nil {
+			return err
+		}
+		cpIDs = commitRange.CheckpointIDs
+	} else {
+		hash, err := repo.ResolveRevision(plumbing.Revision(commitRef))
+		if err != nil {
+			return fmt.Errorf("commit not found: %s", commitRef)
+		}
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return fmt.Errorf("failed to get commit: %w", err)
+		}
+		if cpID, found := trailers.ParseCheckpoint(commit.Message); found {
+			cpIDs[cpID] = true
+		}
+	}
+
+	store := checkpoint.NewGitStore(repo)
+	notes := []agentNoteJSON{}
+	for cpID := range cpIDs {
+		summary, err := store.ReadCommitted(ctx, cpID)
+		if err != nil {
+			return fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+		}
+		if summary == nil {
+			continue
+		}
+		for i := range summary.Sessions {
+			metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+			if err != nil {
+				return fmt.Errorf("failed to read session %d of checkpoint %s: %w", i, cpID, err)
+			}
+			for _, note := range metadata.Notes {
+				notes = append(notes, agentNoteJSON{Note: note, CheckpointID: cpID, SessionID: metadata.SessionID})
+			}
+		}
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		if !notes[i].At.Equal(notes[j].At) {
+			return notes[i].At.Before(notes[j].At)
+		}
+		return notes[i].Text < notes[j].Text
+	})
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(notes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal notes: %w", err)
+		}
+		_, err = w.Write(data)
+		return err //nolint:wrapcheck // write to stdout
+	}
+	if len(notes) == 0 {
+		// stdout stays empty, so the output can go straight into a PR body
+		fmt.Fprintln(errW, "No agent notes.")
+		return nil
+	}
+	fmt.Fprint(w, formatNotesMarkdown(notes))
+	return nil
+}
+
+// formatNotesMarkdown renders notes grouped by kind.
+func formatNotesMarkdown(notes []agentNoteJSON) string {
+	var sb strings.Builder
+	sb.WriteString("## Agent notes\n")
+	for _, kind := range session.NoteKinds {
+		first := true
+		for _, note := range notes {
+			if note.Kind != kind {
+				continue
+			}
+			if first {
+				fmt.Fprintf(&sb, "\n### %s\n\n", noteKindHeadings[kind])
+				first = false
+			}
+			sb.WriteString("- " + strings.Join(strings.Fields(note.Text), " "))
+			if len(note.Files) > 0 {
+				sb.WriteString(" (`" + strings.Join(note.Files, "`, `") + "`)")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+)
+
+func TestFormatNotesMarkdown(t *testing.T) {
+	notes := []agentNoteJSON{
+		{Note: checkpoint.Note{Kind: "todo", Text: "Handle\nthe empty case"}},
+		{Note: checkpoint.Note{Kind: "assumption", Text: "Timestamps are UTC", Files: []string{"a.go", "b.go"}}},
+		{Note: checkpoint.Note{Kind: "todo", Text: "Add a test"}},
+	}
+	want := "## Agent notes\n" +
+		"\n### Assumptions\n\n" +
+		"- Timestamps are UTC (`a.go`, `b.go`)\n" +
+		"\n### TODOs\n\n" +
+		"- Handle the empty case\n" +
+		"- Add a test\n"
+	if got := formatNotesMarkdown(notes); got != want {
+		t.Errorf("formatNotesMarkdown() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+func newAttributionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attribution",
+		Short: "Inspect agent vs. human attribution",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newAttributionPreviewCmd())
+	cmd.AddCommand(newAttributionShowCmd())
+	cmd.AddCommand(newAttributionListCmd())
+
+	return cmd
+}
+
+func newAttributionPreviewCmd() *cobra.Command {
+	var worktreeFlag bool
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Preview the attribution your next commit would record",
+		Long: `Computes the agent/human attribution for each active session as if you
+committed right now, without creating a commit or modifying session state.
+
+By default the candidate commit is your staged changes (the git index), which
+is what 'git commit' would record. Use --worktree to also include unstaged and
+untracked changes, as with 'git add -A && git commit'.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runAttributionPreview(cmd.OutOrStdout(), GetStrategy(), worktreeFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&worktreeFlag, "worktree", false, "Include unstaged and untracked changes in the candidate commit")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// attributionPreviewJSON is the JSON shape of a single session preview.
+type attributionPreviewJSON struct {
+	SessionID        string            `json:"session_id"`
+	Agent            string            `json:"agent,omitempty"`
+	BaseCommit       string            `json:"base_commit"`
+	CheckpointCommit string            `json:"checkpoint_commit,omitempty"`
+	Steps            int               `json:"steps"`
+	FilesTouched     []string          `json:"files_touched"`
+	AgentLines       int               `json:"agent_lines"`
+	HumanAdded       int               `json:"human_added"`
+	HumanModified    int               `json:"human_modified"`
+	HumanRemoved     int               `json:"human_removed"`
+	TotalCommitted   int               `json:"total_committed"`
+	AgentPercentage  reportfmt.Percent `json:"agent_percentage"`
+	HasCheckpoints   bool              `json:"has_checkpoints"`
+
+	Files []checkpoint.FileAttribution `json:"files,omitempty"`
+}
+
+func runAttributionPreview(w io.Writer, strat strategy.Strategy, includeWorktree, jsonOutput bool) error {
+	previewer, ok := strat.(strategy.AttributionPreviewer)
+	if !ok {
+		fmt.Fprintf(w, "Attribution preview is not supported by the %s strategy.\n", strat.Name())
+		return nil
+	}
+
+	previews, err := previewer.PreviewAttribution(includeWorktree)
+	if err != nil {
+		return fmt.Errorf("failed to preview attribution: %w", err)
+	}
+
+	if jsonOutput {
+		return printAttributionPreviewJSON(w, previews)
+	}
+
+	if len(previews) == 0 {
+		fmt.Fprintln(w, "No active sessions in this worktree.")
+		return nil
+	}
+
+	source := "staged changes"
+	if includeWorktree {
+		source = "staged and unstaged changes"
+	}
+	fmt.Fprintf(w, "Attribution preview (%s)\n", source)
+
+	for _, p := range previews {
+		fmt.Fprintln(w)
+		agentLabel := string(p.AgentType)
+		if agentLabel == "" {
+			agentLabel = "unknown agent"
+		}
+		fmt.Fprintf(w, "Session %s (%s)\n", p.SessionID, agentLabel)
+
+		if p.Attribution == nil {
+			fmt.Fprintln(w, "  No checkpoints yet; nothing to attribute.")
+			continue
+		}
+
+		a := p.Attribution
+		fmt.Fprintf(w, "  Agent:  %d lines (%s)\n", a.AgentLines, reportfmt.DetectLocale().Percent(a.AgentPercentage, 1))
+		fmt.Fprintf(w, "  Human:  %d added, %d modified, %d removed\n", a.HumanAdded, a.HumanModified, a.HumanRemoved)
+		fmt.Fprintf(w, "  Total:  %d lines committed\n", a.TotalCommitted)
+	}
+
+	return nil
+}
+
+func printAttributionPreviewJSON(w io.Writer, previews []strategy.AttributionPreview) error {
+	data, err := jsonutil.MarshalIndentWithNewline(attributionPreviewEntries(previews), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal attribution preview: %w", err)
+	}
+	fmt.Fprint(w, string(data))
+	return nil
+}
+
+// attributionPreviewEntries converts previews to their JSON shape.
+func attributionPreviewEntries(previews []strategy.AttributionPreview) []attributionPreviewJSON {
+	output := make([]attributionPreviewJSON, len(previews))
+	for i, p := range previews {
+		entry := attributionPreviewJSON{
+			SessionID:        p.SessionID,
+			Agent:            string(p.AgentType),
+			BaseCommit:       p.BaseCommit,
+			CheckpointCommit: p.CheckpointCommit,
+			Steps:            p.StepCount,
+			FilesTouched:     p.FilesTouched,
+			HasCheckpoints:   p.Attribution != nil,
+		}
+		if entry.FilesTouched == nil {
+			entry.FilesTouched = []string{}
+		}
+		if a := p.Attribution; a != nil {
+			entry.AgentLines = a.AgentLines
+			entry.HumanAdded = a.HumanAdded
+			entry.HumanModified = a.HumanModified
+			entry.HumanRemoved = a.HumanRemoved
+			entry.TotalCommitted = a.TotalCommitted
+			entry.AgentPercentage = reportfmt.Percent(a.AgentPercentage)
+			entry.Files = a.Files
+		}
+		output[i] = entry
+	}
+	return output
+}
+
+func newAttributionShowCmd() *cobra.Command {
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "show [commit]",
+		Short: "Show the per-file attribution recorded for a commit",
+		Long: `Shows the agent/human attribution recorded when a commit was made, broken
+down per file, so you can see which files were mostly agent-written.
+
+Defaults to HEAD. The commit must have an Entire-Checkpoint trailer.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			commitRef := "HEAD"
+			if len(args) > 0 {
+				commitRef = args[0]
+			}
+			return runAttributionShow(cmd.Context(), cmd.OutOrStdout(), commitRef, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// sessionAttributionJSON is the JSON shape of one session's recorded attribution.
+type sessionAttributionJSON struct {
+	SessionID   string                         `json:"session_id"`
+	Agent       string                         `json:"agent,omitempty"`
+	Automation  []string                       `json:"automation,omitempty"`
+	Attribution *checkpoint.InitialAttribution `json:"attribution"`
+	// ReconstructionConfidence is set when the attribution was computed from
+	// checkpoints rebuilt from the transcript (see 'entire checkpoint recover').
+	ReconstructionConfidence float64 `json:"reconstruction_confidence,omitempty"`
+}
+
+type commitAttributionJSON struct {
+	Commit       string                   `json:"commit"`
+	CheckpointID string                   `json:"checkpoint_id"`
+	Sessions     []sessionAttributionJSON `json:"sessions"`
+}
+
+func runAttributionShow(ctx context.Context, w io.Writer, commitRef string, jsonOutput bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commitRef))
+	if err != nil {
+		return fmt.Errorf("commit not found: %s", commitRef)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+	store := checkpoint.NewGitStore(repo)
+	cpID, found := trailers.ParseCheckpoint(commit.Message)
+	var summary *checkpoint.CheckpointSummary
+	if found {
+		if summary, err = store.ReadCommitted(ctx, cpID); err != nil {
+			return fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+		}
+	}
+
+	result := commitAttributionJSON{
+		Commit:       hash.String(),
+		CheckpointID: cpID.String(),
+		Sessions:     []sessionAttributionJSON{},
+	}
+	if summary != nil {
+		for i := range summary.Sessions {
+			metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+			if err != nil {
+				return fmt.Errorf("failed to read session %d of checkpoint %s: %w", i, cpID, err)
+			}
+			result.Sessions = append(result.Sessions, sessionAttributionJSON{
+				SessionID:   metadata.SessionID,
+				Agent:       string(metadata.Agent),
+				Automation:  metadata.Automation,
+				Attribution: metadata.InitialAttribution,
+
+				ReconstructionConfidence: metadata.ReconstructionConfidence,
+			})
+		}
+	} else {
+		// The commit's note carries the same attribution, e.g. in clones that
+		// only fetched refs/notes/entire or after the trailer was lost in a rewrite
+		note, err := store.ReadCommitNote(*hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit note: %w", err)
+		}
+		if note == nil {
+			// Cherry-picks and reverts are attributed from their original
+			if note, err = strategy.ProvenanceNote(ctx, repo, commit); err != nil {
+				return fmt.Errorf("failed to derive attribution from the original commit: %w", err)
+			}
+		}
+		switch {
+		case note != nil:
+			cpID = note.CheckpointID
+			result.CheckpointID = cpID.String()
+			for _, session := range note.Sessions {
+				result.Sessions = append(result.Sessions, sessionAttributionJSON{
+					SessionID:   session.SessionID,
+					Agent:       string(session.Agent),
+					Attribution: session.Attribution,
+				})
+			}
+		case !found:
+			return fmt.Errorf("commit %s has no %s trailer", hash.String()[:7], trailers.CheckpointTrailerKey)
+		default:
+			return fmt.Errorf("checkpoint %s not found on %s", cpID, paths.MetadataBranchName)
+		}
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal attribution: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	fmt.Fprintf(w, "Commit %s: %s\n", hash.String()[:7], subject)
+	fmt.Fprintf(w, "Checkpoint %s\n", cpID)
+	for _, session := range result.Sessions {
+		fmt.Fprintln(w)
+		printSessionAttribution(w, session)
+	}
+	return nil
+}
+
+// shortSubmoduleCommit abbreviates a submodule's commit, "none" when the
+// submodule was added or removed.
+func shortSubmoduleCommit(commit string) string {
+	if commit == "" {
+		return "none"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// shortCommits abbreviates commits and joins them with commas.
+func shortCommits(commits []string) string {
+	short := make([]string, len(commits))
+	for i, commit := range commits {
+		short[i] = commit[:min(len(commit), 7)]
+	}
+	return strings.Join(short, ", ")
+}
+
+func printSessionAttribution(w io.Writer, session sessionAttributionJSON) {
+	agentLabel := session.Agent
+	if agentLabel == "" {
+		agentLabel = "unknown agent"
+	}
+	if len(session.Automation) > 0 {
+		agentLabel += ", autonomous: " + strings.Join(session.Automation, ", ")
+	}
+	a := session.Attribution
+	if a == nil {
+		fmt.Fprintf(w, "Session %s (%s): no attribution recorded\n", session.SessionID, agentLabel)
+		return
+	}
+	if a.Skipped == strategy.AttributionSkippedMerge {
+		fmt.Fprintf(w, "Session %s (%s): attribution skipped (merge commit)\n", session.SessionID, agentLabel)
+		return
+	}
+	fmt.Fprintf(w, "Session %s (%s): %s agent (%d of %d lines)\n",
+		session.SessionID, agentLabel, reportfmt.DetectLocale().Percent(a.AgentPercentage, 1), a.AgentLines, a.TotalCommitted)
+	if session.ReconstructionConfidence > 0 {
+		fmt.Fprintf(w, "  Approximate: checkpoints were reconstructed from the transcript (%s of edits replayed).\n",
+			reportfmt.DetectLocale().Percent(session.ReconstructionConfidence*100, 0))
+	}
+	if a.SupersededBy != "" {
+		fmt.Fprintf(w, "  Squashed into checkpoint %s by a fixup; counted there.\n", a.SupersededBy)
+	}
+	if len(a.AmendedFrom) > 0 {
+		fmt.Fprintf(w, "  Includes %d amended or squashed commit(s).\n", len(a.AmendedFrom))
+	}
+	if a.CherryPickOf != "" {
+		fmt.Fprintf(w, "  Cherry-picked from %s; the counts are its.\n", shortCommits([]string{a.CherryPickOf}))
+	}
+	if a.RevertOf != "" {
+		fmt.Fprintf(w, "  Reverts %s; the counts are its, negated.\n", shortCommits([]string{a.RevertOf}))
+	}
+	if len(a.CherryPickedTo) > 0 {
+		fmt.Fprintf(w, "  Cherry-picked to %s.\n", shortCommits(a.CherryPickedTo))
+	}
+	if len(a.RevertedBy) > 0 {
+		fmt.Fprintf(w, "  Reverted by %s.\n", shortCommits(a.RevertedBy))
+	}
+
+	if len(a.BinaryFiles) > 0 {
+		fmt.Fprintf(w, "  Binary: %d file(s), %s by the agent, %s by humans\n",
+			len(a.BinaryFiles), settings.FormatByteSize(a.BinaryAgentBytes), settings.FormatByteSize(a.BinaryHumanBytes))
+	}
+
+	if len(a.GeneratedFiles) > 0 {
+		fmt.Fprintf(w, "  Generated (not counted above): %d file(s), %d of %d lines by the agent\n",
+			len(a.GeneratedFiles), a.GeneratedAgentLines, a.GeneratedTotalCommitted)
+	}
+
+	for _, sm := range a.Submodules {
+		by := "humans"
+		if sm.Agent {
+			by = "the agent"
+		}
+		fmt.Fprintf(w, "  Submodule %s: %s -> %s, by %s\n", sm.Path, shortSubmoduleCommit(sm.From), shortSubmoduleCommit(sm.To), by)
+	}
+
+	if len(a.Files) == 0 {
+		if len(a.BinaryFiles) == 0 && len(a.Submodules) == 0 {
+			fmt.Fprintln(w, "  No per-file breakdown (recorded before per-file attribution existed).")
+		}
+		return
+	}
+
+	// Most agent-written files first
+	files := make([]checkpoint.FileAttribution, len(a.Files))
+	copy(files, a.Files)
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].AgentPercentage != files[j].AgentPercentage {
+			return files[i].AgentPercentage > files[j].AgentPercentage
+		}
+		return files[i].AgentLines > files[j].AgentLines
+	})
+
+	width := len("File")
+	for _, f := range files {
+		width = max(width, len([]rune(f.Path)))
+	}
+	fmt.Fprintf(w, "  %-*s  %7s  %7s  %7s  %7s  %7s\n", width, "File", "Agent", "Human+", "Human~", "Human-", "Agent%")
+	for _, f := range files {
+		fmt.Fprintf(w, "  %-*s  %7d  %7d  %7d  %7d  %6.0f%%\n",
+			width, f.Path, f.AgentLines, f.HumanAdded, f.HumanModified, f.HumanRemoved, f.AgentPercentage)
+	}
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newAttributionListCmd() *cobra.Command {
+	var jsonFlag bool
+	var lf listFlags
+	var taskTypeFlag, rangeFlag string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the attribution recorded for committed checkpoints",
+		Long: `Lists committed checkpoints with the agent share of the commit each one is
+linked to, newest first.
+
+--agent, --branch, --task-type, --range, --since and --until filter the list.
+With --limit, a cursor for the next page is printed to stderr.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			opts, err := lf.resolve(time.Now())
+			if err != nil {
+				return err
+			}
+			return runAttributionList(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, taskTypeFlag, rangeFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	addListFlags(cmd, &lf, listSortNewest, true)
+	cmd.Flags().StringVar(&taskTypeFlag, "task-type", "", "Only show checkpoints with a session of this task type (e.g. bugfix)")
+	addCommitRangeFlag(cmd, &rangeFlag)
+
+	return cmd
+}
+
+// attributionListJSON is one committed checkpoint in `entire attribution list`.
+type attributionListJSON struct {
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	CreatedAt    reportfmt.Time  `json:"created_at"`
+	Agent        string          `json:"agent,omitempty"`
+	Branch       string          `json:"branch,omitempty"`
+	Sessions     int             `json:"sessions"`
+	// TaskTypes are the task types of the sessions, without repeats.
+	TaskTypes      []string `json:"task_types,omitempty"`
+	AgentLines     int      `json:"agent_lines"`
+	TotalCommitted int      `json:"total_committed"`
+	// AgentShare is nil when no session recorded attribution.
+	AgentShare *reportfmt.Percent `json:"agent_share,omitempty"`
+}
+
+func runAttributionList(ctx context.Context, w, errW io.Writer, opts listOptions, taskType, rangeSpec string, jsonOutput bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	commitRange, err := resolveCommitRange(repo, rangeSpec)
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+	infos, err := store.ListCommitted(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	entries := []attributionListJSON{}
+	for _, info := range infos {
+		if !opts.matchesAgent(string(info.Agent)) {
+			continue
+		}
+		if !opts.Period.Contains(info.CreatedAt) || !commitRange.ContainsCheckpoint(info.CheckpointID) {
+			continue
+		}
+		entries = append(entries, attributionListJSON{
+			CheckpointID: info.CheckpointID,
+			CreatedAt:    reportfmt.NewTime(info.CreatedAt),
+			Agent:        string(info.Agent),
+			Sessions:     max(info.SessionCount, 1),
+		})
+	}
+	// The branch and task types are in the session metadata, so filtering by
+	// them reads every candidate
+	read := func(e *attributionListJSON) { readAttributionListEntry(ctx, store, e) }
+	if opts.Branch != "" || taskType != "" {
+		filtered := entries[:0]
+		for _, e := range entries {
+			read(&e)
+			if (opts.Branch == "" || e.Branch == opts.Branch) && (taskType == "" || slices.Contains(e.TaskTypes, taskType)) {
+				filtered = append(filtered, e)
+			}
+		}
+		entries, read = filtered, nil
+	}
+	entries, next, err := pageList(entries, func(e attributionListJSON) listKey {
+		return listKey{Time: e.CreatedAt.Time, ID: e.CheckpointID.String()}
+	}, opts)
+	if err != nil {
+		return err
+	}
+	defer writeNextCursor(errW, next)
+	if read != nil {
+		for i := range entries {
+			read(&entries[i])
+		}
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal attribution: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No committed checkpoints match.")
+		return nil
+	}
+	loc := reportfmt.DetectLocale()
+	fmt.Fprintf(w, "%-12s  %-16s  %-12s  %-20s  %-10s  %7s  %s\n", "Checkpoint", "Created", "Agent", "Branch", "Task", "Agent%", "Lines")
+	for _, e := range entries {
+		share := "-"
+		if e.AgentShare != nil {
+			share = loc.Percent(float64(*e.AgentShare), 1)
+		}
+		agentLabel := e.Agent
+		if agentLabel == "" {
+			agentLabel = unknownPlaceholder
+		}
+		branch := e.Branch
+		if branch == "" {
+			branch = "-"
+		}
+		task := strings.Join(e.TaskTypes, ",")
+		if task == "" {
+			task = "-"
+		}
+		fmt.Fprintf(w, "%-12s  %-16s  %-12s  %-20s  %-10s  %7s  %d of %d\n",
+			e.CheckpointID, loc.DateTime(e.CreatedAt.Time), agentLabel, branch, task, share, e.AgentLines, e.TotalCommitted)
+	}
+	return nil
+}
+
+// readAttributionListEntry fills in e's branch and attribution from the
+// metadata of its sessions, the way `entire stats` adds them up.
+func readAttributionListEntry(ctx context.Context, store *checkpoint.GitStore, e *attributionListJSON) {
+	attributed := false
+	for i := range e.Sessions {
+		metadata, err := store.ReadSessionMetadata(ctx, e.CheckpointID, i)
+		if err != nil {
+			continue
+		}
+		if metadata.Branch != "" {
+			e.Branch = metadata.Branch
+		}
+		if metadata.TaskType != "" && !slices.Contains(e.TaskTypes, metadata.TaskType) {
+			e.TaskTypes = append(e.TaskTypes, metadata.TaskType)
+		}
+		if attr := metadata.InitialAttribution; attr != nil && attr.SupersededBy == "" {
+			attributed = true
+			e.AgentLines += attr.AgentLines
+			e.TotalCommitted = max(e.TotalCommitted, attr.TotalCommitted)
+		}
+	}
+	if attributed && e.TotalCommitted > 0 {
+		share := reportfmt.Percent(float64(e.AgentLines) / float64(e.TotalCommitted) * 100)
+		e.AgentShare = &share
+	}
+}
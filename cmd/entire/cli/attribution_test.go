@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestRunAttributionPreview_UnsupportedStrategy(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := runAttributionPreview(&buf, strategy.NewAutoCommitStrategy(), false, false); err != nil {
+		t.Fatalf("runAttributionPreview() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "not supported") {
+		t.Errorf("expected unsupported message, got: %q", buf.String())
+	}
+}
+
+func TestPrintAttributionPreviewJSON_NoCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := printAttributionPreviewJSON(&buf, []strategy.AttributionPreview{
+		{SessionID: "session-1", BaseCommit: "abc123"},
+	})
+	if err != nil {
+		t.Fatalf("printAttributionPreviewJSON() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{`"session_id": "session-1"`, `"has_checkpoints": false`, `"files_touched": []`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output, got: %s", want, out)
+		}
+	}
+}
+
+func TestRunAttributionShow(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, initial := setupCleanTestRepo(t)
+
+	cpID := id.MustCheckpointID("a1b2c3d4e5f6")
+	store := checkpoint.NewGitStore(repo)
+	if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+		CheckpointID: cpID,
+		SessionID:    "2026-10-14-show-session",
+		Strategy:     "manual-commit",
+		Agent:        agent.AgentTypeClaudeCode,
+		FilesTouched: []string{"generated.go", "notes.md"},
+		InitialAttribution: &checkpoint.InitialAttribution{
+			AgentLines: 10, HumanAdded: 10, TotalCommitted: 20, AgentPercentage: 50,
+			Files: []checkpoint.FileAttribution{
+				{Path: "notes.md", HumanAdded: 10, TotalCommitted: 10},
+				{Path: "generated.go", AgentLines: 10, TotalCommitted: 10, AgentPercentage: 100},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+
+	head := storeRangeTestCommit(t, repo, cpID.String(), initial)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), head)); err != nil {
+		t.Fatalf("failed to update master: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runAttributionShow(context.Background(), &buf, "master", false); err != nil {
+		t.Fatalf("runAttributionShow() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Session 2026-10-14-show-session (Claude Code): 50.0% agent (10 of 20 lines)") {
+		t.Errorf("missing session summary:\n%s", out)
+	}
+	// Most agent-written file first
+	if gen, notes := strings.Index(out, "generated.go"), strings.Index(out, "notes.md"); gen < 0 || notes < 0 || gen > notes {
+		t.Errorf("expected generated.go listed before notes.md:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runAttributionShow(context.Background(), &buf, "master", true); err != nil {
+		t.Fatalf("runAttributionShow(json) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"path": "generated.go"`) {
+		t.Errorf("expected per-file entries in JSON:\n%s", buf.String())
+	}
+
+	if err := runAttributionShow(context.Background(), &buf, initial.String(), false); err == nil {
+		t.Error("expected error for commit without checkpoint trailer")
+	}
+}
+
+func TestRunAttributionList(t *testing.T) {
+	setupMCPRepo(t)
+
+	var out, errOut bytes.Buffer
+	if err := runAttributionList(context.Background(), &out, &errOut, listOptions{Limit: 1, Sort: listSortNewest}, "", "", true); err != nil {
+		t.Fatalf("runAttributionList() error = %v", err)
+	}
+	var page []attributionListJSON
+	if err := json.Unmarshal(out.Bytes(), &page); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(page) != 1 || !strings.Contains(errOut.String(), "--cursor") {
+		t.Fatalf("page = %+v, stderr %q; want one entry and a cursor", page, errOut.String())
+	}
+
+	out.Reset()
+	errOut.Reset()
+	if err := runAttributionList(context.Background(), &out, &errOut, listOptions{Agent: "claude-code", Sort: listSortNewest}, "", "", true); err != nil {
+		t.Fatalf("runAttributionList() error = %v", err)
+	}
+	var all []attributionListJSON
+	if err := json.Unmarshal(out.Bytes(), &all); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(all) != 2 || errOut.Len() != 0 {
+		t.Fatalf("all = %+v, want both checkpoints without a cursor", all)
+	}
+	for _, e := range all {
+		if e.CheckpointID.String() == "b1b2c3d4e5f6" && (e.AgentShare == nil || *e.AgentShare != 80 || e.AgentLines != 8) {
+			t.Errorf("attributed checkpoint = %+v, want 80%% agent share", e)
+		}
+		if e.CheckpointID.String() == "a1b2c3d4e5f6" && e.AgentShare != nil {
+			t.Errorf("unattributed checkpoint has agent share %v", *e.AgentShare)
+		}
+	}
+
+	out.Reset()
+	if err := runAttributionList(context.Background(), &out, io.Discard, listOptions{Branch: "no-such-branch", Sort: listSortNewest}, "", "", false); err != nil {
+		t.Fatalf("runAttributionList() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "No committed checkpoints match") {
+		t.Errorf("branch filter output = %q, want no matches", out.String())
+	}
+	out.Reset()
+	if err := runAttributionList(context.Background(), &out, io.Discard, listOptions{Sort: listSortNewest}, "refactor", "", true); err != nil {
+		t.Fatalf("runAttributionList() error = %v", err)
+	}
+	var refactors []attributionListJSON
+	if err := json.Unmarshal(out.Bytes(), &refactors); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(refactors) != 1 || refactors[0].CheckpointID.String() != "b1b2c3d4e5f6" || !slices.Equal(refactors[0].TaskTypes, []string{"refactor"}) {
+		t.Errorf("task type filter = %+v, want only the refactor checkpoint", refactors)
+	}
+}
+
+func TestRunAttributionList_Range(t *testing.T) {
+	setupSearchRepo(t)
+
+	var out bytes.Buffer
+	if err := runAttributionList(context.Background(), &out, io.Discard, listOptions{Sort: listSortNewest}, "", "HEAD~1..", true); err != nil {
+		t.Fatalf("runAttributionList() error = %v", err)
+	}
+	var entries []attributionListJSON
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(entries) != 1 || entries[0].CheckpointID.String() != "b1b2c3d4e5f6" {
+		t.Errorf("entries = %+v, want only the checkpoint of HEAD", entries)
+	}
+
+	if err := runAttributionList(context.Background(), &out, io.Discard, listOptions{Sort: listSortNewest}, "", "main..nope", true); err == nil {
+		t.Error("runAttributionList() with an unknown revision should fail")
+	}
+}
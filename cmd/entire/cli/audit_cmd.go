@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/spf13/cobra"
+)
+
+// errAuditFailed is returned by `entire audit verify` when the audit chain
+// shows tampering.
+var errAuditFailed = NewSilentError(errors.New("audit verification failed"))
+
+// minHeadPrefix is the shortest head hash prefix --head accepts.
+const minHeadPrefix = 7
+
+func newAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Commands for auditing recorded attribution",
+	}
+	cmd.AddCommand(newAuditVerifyCmd())
+	return cmd
+}
+
+func newAuditVerifyCmd() *cobra.Command {
+	var remoteFlag string
+	var headFlags []string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that recorded attributions weren't edited after the fact",
+		Long: `Checks the audit chain on the metadata branch and exits non-zero when it
+shows that recorded attribution was changed.
+
+Every time a session's attribution is written (when a commit is condensed,
+or an amend or fixup updates it), a record is added to the chain with the
+hash of the attribution, the tree hashes of the commits it was measured
+between and the hash of the previous record. verify checks that every
+record is unchanged and linked to the one before it, that recorded commits
+still have the recorded trees, and that every attribution matches its
+latest record. Attributions written before the chain existed are counted
+but can't be checked.
+
+Anyone who can push the metadata branch can rewrite the whole chain, so
+keep the heads verify prints somewhere else, e.g. in release notes or a CI
+log, and pass them back with --head: verify then fails unless each is still
+part of the chain. Merging the metadata branch of several clones forks the
+chain, so there can be more than one head.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runAuditVerify(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), remoteFlag, headFlags, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&remoteFlag, "remote", "origin", "Remote to fetch the metadata branch from when it's missing (empty to never fetch)")
+	cmd.Flags().StringSliceVar(&headFlags, "head", nil, "Head hash from an earlier verify that must still be in the chain (repeatable)")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Print the results as JSON")
+
+	return cmd
+}
+
+func runAuditVerify(ctx context.Context, w, errW io.Writer, remote string, heads []string, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, h := range heads {
+		if len(h) < minHeadPrefix {
+			return fmt.Errorf("invalid --head %q: use at least %d characters of the hash", h, minHeadPrefix)
+		}
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	if remote != "" {
+		if err := ensureMetadataBranch(ctx, repo, remote); err != nil {
+			return fmt.Errorf("%s is not available locally and couldn't be fetched from %s: %w", paths.MetadataBranchName, remote, err)
+		}
+	}
+
+	store := checkpoint.NewGitStore(repo)
+	report, err := store.VerifyAuditChain(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify audit chain: %w", err)
+	}
+	if len(heads) > 0 {
+		records, err := store.ReadAuditChain(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read audit chain: %w", err)
+		}
+		for _, h := range heads {
+			if !auditChainHas(records, h) {
+				report.Problems = append(report.Problems, fmt.Sprintf("head %s is no longer in the chain: it was rewritten or removed", h))
+			}
+		}
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err //nolint:wrapcheck // write to stdout
+		}
+	} else {
+		writeAuditReport(w, report)
+	}
+	if len(report.Problems) > 0 {
+		if jsonOutput {
+			fmt.Fprintf(errW, "Audit verification found %d problem(s).\n", len(report.Problems))
+		}
+		return errAuditFailed
+	}
+	return nil
+}
+
+// auditChainHas reports whether a record's hash starts with hash.
+func auditChainHas(records []checkpoint.AuditRecord, hash string) bool {
+	hash = strings.ToLower(hash)
+	for _, r := range records {
+		if strings.HasPrefix(r.Hash, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAuditReport(w io.Writer, report *checkpoint.AuditReport) {
+	if report.Records == 0 && report.Unrecorded == 0 && len(report.Problems) == 0 {
+		fmt.Fprintln(w, "No attributions recorded yet.")
+		return
+	}
+	fmt.Fprintf(w, "Audit chain: %d record(s), %d head(s)\n", report.Records, len(report.Heads))
+	for _, h := range report.Heads {
+		fmt.Fprintf(w, "  head %s\n", h)
+	}
+	fmt.Fprintf(w, "Attributions: %d checked", report.Checked)
+	if report.Unrecorded > 0 {
+		fmt.Fprintf(w, ", %d written before the chain started", report.Unrecorded)
+	}
+	fmt.Fprintln(w)
+
+	if len(report.Problems) == 0 {
+		fmt.Fprintln(w, "\nNo tampering found.")
+		return
+	}
+	fmt.Fprintf(w, "\n%d problem(s):\n", len(report.Problems))
+	for _, p := range report.Problems {
+		fmt.Fprintf(w, "  %s\n", p)
+	}
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+)
+
+func TestAuditChainHas(t *testing.T) {
+	records := []checkpoint.AuditRecord{{Seq: 1, Hash: "1a2b3c4d5e6f7a8b"}}
+	if !auditChainHas(records, "1A2B3C4") {
+		t.Error("auditChainHas() should match a hash prefix case-insensitively")
+	}
+	if auditChainHas(records, "ffffffff") {
+		t.Error("auditChainHas() matched an unknown hash")
+	}
+}
+
+func TestWriteAuditReport(t *testing.T) {
+	var buf bytes.Buffer
+	writeAuditReport(&buf, &checkpoint.AuditReport{
+		Records:    3,
+		Heads:      []string{"abc123"},
+		Checked:    2,
+		Unrecorded: 1,
+		Problems:   []string{"checkpoint a1b2c3d4e5f6, session s1: attribution was edited after record 2"},
+	})
+	out := buf.String()
+	for _, want := range []string{"3 record(s), 1 head(s)", "head abc123", "2 checked, 1 written before the chain started", "1 problem(s)", "edited after record 2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/spf13/cobra"
+)
+
+// Line origins reported by `entire blame`.
+const (
+	blameOriginAgent = "agent"
+	blameOriginHuman = "human"
+	// blameOriginMixed marks lines from a checkpointed commit whose recorded
+	// attribution has no per-line ranges (older checkpoints, or checkpoint
+	// metadata that isn't available locally), so agent vs. human can't be told apart.
+	blameOriginMixed = "mixed"
+	// blameOriginBoundary marks lines from commits outside --range, which
+	// aren't attributed (git blame's boundary commits).
+	blameOriginBoundary = "boundary"
+)
+
+func newBlameCmd() *cobra.Command {
+	var revFlag string
+	var rangeFlag string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "blame <file>",
+		Short: "Show which lines of a file were written by an agent",
+		Long: `Annotates each line of a file with whether it came from an agent or a human,
+and which checkpoint and session produced it.
+
+Each line is traced to the commit that introduced it (as git blame does). Lines
+from commits without an Entire-Checkpoint trailer or a note in
+refs/notes/entire are human. For checkpointed commits, the line ranges
+recorded when the agent's checkpoint was replayed against the commit decide
+agent vs. human; the note is used when the checkpoint metadata isn't
+available locally or a rewrite dropped the trailer. Checkpoints recorded before line
+ranges existed show "mixed" for files both the agent and a human edited.
+
+With --range, only lines introduced by commits in the range are attributed
+and counted; the others are marked with ^ like git blame's boundary commits.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runBlame(cmd.Context(), cmd.OutOrStdout(), args[0], revFlag, rangeFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&revFlag, "rev", "HEAD", "Blame the file as of this revision")
+	addCommitRangeFlag(cmd, &rangeFlag)
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+type blameLineJSON struct {
+	Line         int    `json:"line"`
+	Commit       string `json:"commit"`
+	Origin       string `json:"origin"`
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+	SessionID    string `json:"session_id,omitempty"`
+	Agent        string `json:"agent,omitempty"`
+	Text         string `json:"text"`
+}
+
+type blameJSON struct {
+	File       string          `json:"file"`
+	Revision   string          `json:"revision"`
+	Range      string          `json:"range,omitempty"`
+	AgentLines int             `json:"agent_lines"`
+	HumanLines int             `json:"human_lines"`
+	MixedLines int             `json:"mixed_lines"`
+	Lines      []blameLineJSON `json:"lines"`
+}
+
+// blameSession is one session of a checkpointed commit, with what it recorded
+// about the blamed file.
+type blameSession struct {
+	SessionID string
+	Agent     string
+	// File is the recorded attribution of the blamed file (nil if none)
+	File *checkpoint.FileAttribution
+	// Touched is true if the session touched the file but recorded no attribution
+	// (auto-commit checkpoints and checkpoints from older versions)
+	Touched bool
+}
+
+// blameCommit is what `entire blame` knows about a commit that introduced lines.
+type blameCommit struct {
+	CheckpointID id.CheckpointID // empty for commits without a trailer
+	Found        bool            // checkpoint metadata was found
+	Sessions     []blameSession
+	// lineMap maps 0-based lines of the blamed revision to 1-based lines of
+	// the file as of this commit (0 = not found)
+	lineMap []int
+}
+
+func runBlame(ctx context.Context, w io.Writer, file, rev, rangeSpec string, jsonOutput bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	relPath, err := repoRelativePath(file)
+	if err != nil {
+		return err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("revision not found: %s", rev)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+	content, err := fileContentAt(commit, relPath)
+	if err != nil {
+		return fmt.Errorf("%s not found in %s: %w", relPath, rev, err)
+	}
+	blame, err := git.Blame(commit, relPath)
+	if err != nil {
+		return fmt.Errorf("failed to blame %s: %w", relPath, err)
+	}
+	commitRange, err := resolveCommitRange(repo, rangeSpec)
+	if err != nil {
+		return err
+	}
+
+	store := checkpoint.NewGitStore(repo)
+	commits := make(map[plumbing.Hash]*blameCommit)
+	result := blameJSON{File: relPath, Revision: hash.String(), Lines: []blameLineJSON{}}
+	if commitRange != nil {
+		result.Range = commitRange.Spec
+	}
+	for i, line := range blame.Lines {
+		if commitRange != nil && !commitRange.Commits[line.Hash] {
+			result.Lines = append(result.Lines, blameLineJSON{
+				Line: i + 1, Commit: line.Hash.String(), Origin: blameOriginBoundary, Text: line.Text,
+			})
+			continue
+		}
+		info, ok := commits[line.Hash]
+		if !ok {
+			info, err = loadBlameCommit(ctx, repo, store, line.Hash, relPath, content)
+			if err != nil {
+				return err
+			}
+			commits[line.Hash] = info
+		}
+
+		entry := blameLineJSON{Line: i + 1, Commit: line.Hash.String(), Text: line.Text}
+		classifyBlameLine(&entry, info, i)
+		switch entry.Origin {
+		case blameOriginAgent:
+			result.AgentLines++
+		case blameOriginMixed:
+			result.MixedLines++
+		default:
+			result.HumanLines++
+		}
+		result.Lines = append(result.Lines, entry)
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal blame: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+	printBlame(w, result)
+	return nil
+}
+
+// loadBlameCommit reads the checkpoint metadata of commitHash and maps the
+// blamed revision's lines onto the file as of that commit. Without metadata
+// on the metadata branch, the commit's note in refs/notes/entire is used.
+func loadBlameCommit(ctx context.Context, repo *git.Repository, store *checkpoint.GitStore, commitHash plumbing.Hash, relPath, content string) (*blameCommit, error) {
+	info := &blameCommit{}
+	commit, err := repo.CommitObject(commitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commitHash.String()[:7], err)
+	}
+	cpID, found := trailers.ParseCheckpoint(commit.Message)
+	var summary *checkpoint.CheckpointSummary
+	if found {
+		info.CheckpointID = cpID
+		// Metadata branch not fetched or checkpoint pruned: fall back to the note
+		summary, _ = store.ReadCommitted(ctx, cpID) //nolint:errcheck // missing metadata degrades to the note or "mixed"
+	}
+	var note *checkpoint.CommitNote
+	if summary == nil {
+		// The note carries the same attribution, and survives rewrites that
+		// drop the trailer
+		if note, err = store.ReadCommitNote(commitHash); err != nil {
+			return nil, fmt.Errorf("failed to read commit note: %w", err)
+		}
+		if note != nil {
+			info.CheckpointID = note.CheckpointID
+		}
+	}
+	if info.CheckpointID.IsEmpty() {
+		return info, nil
+	}
+
+	if committedContent, err := fileContentAt(commit, relPath); err == nil {
+		info.lineMap = mapLinesToAncestor(committedContent, content)
+	}
+
+	switch {
+	case summary != nil:
+		info.Found = true
+		for i := range summary.Sessions {
+			metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read session %d of checkpoint %s: %w", i, cpID, err)
+			}
+			info.Sessions = append(info.Sessions, newBlameSession(metadata.SessionID, metadata.Agent, metadata.InitialAttribution, metadata.FilesTouched, relPath))
+		}
+	case note != nil:
+		info.Found = true
+		for _, session := range note.Sessions {
+			info.Sessions = append(info.Sessions, newBlameSession(session.SessionID, session.Agent, session.Attribution, session.FilesTouched, relPath))
+		}
+	}
+	return info, nil
+}
+
+// newBlameSession is what a session's recorded attribution says about relPath.
+func newBlameSession(sessionID string, agentType agent.AgentType, attribution *checkpoint.InitialAttribution, filesTouched []string, relPath string) blameSession {
+	session := blameSession{SessionID: sessionID, Agent: string(agentType)}
+	if attribution == nil {
+		session.Touched = slices.Contains(filesTouched, relPath)
+		return session
+	}
+	for j := range attribution.Files {
+		if attribution.Files[j].Path == relPath {
+			session.File = &attribution.Files[j]
+			break
+		}
+	}
+	return session
+}
+
+// classifyBlameLine sets the origin, checkpoint and session of the 0-based line.
+// The first session that claims the line as agent-written wins.
+func classifyBlameLine(entry *blameLineJSON, info *blameCommit, line int) {
+	entry.Origin = blameOriginHuman
+	if info.CheckpointID.IsEmpty() {
+		return
+	}
+	entry.CheckpointID = info.CheckpointID.String()
+	if !info.Found {
+		entry.Origin = blameOriginMixed
+		return
+	}
+
+	committedLine := 0
+	if line < len(info.lineMap) {
+		committedLine = info.lineMap[line]
+	}
+	var mixed *blameSession
+	for i := range info.Sessions {
+		s := &info.Sessions[i]
+		origin := blameOriginHuman
+		switch {
+		case s.Touched:
+			origin = blameOriginAgent
+		case s.File == nil, s.File.AgentLines == 0:
+		case len(s.File.AgentRanges) > 0:
+			if s.File.IsAgentLine(committedLine) {
+				origin = blameOriginAgent
+			}
+		case s.File.HumanAdded == 0 && s.File.HumanModified == 0:
+			origin = blameOriginAgent
+		default:
+			origin = blameOriginMixed
+		}
+
+		if origin == blameOriginAgent {
+			entry.Origin, entry.SessionID, entry.Agent = blameOriginAgent, s.SessionID, s.Agent
+			return
+		}
+		if origin == blameOriginMixed && mixed == nil {
+			mixed = s
+		}
+	}
+	if mixed != nil {
+		entry.Origin, entry.SessionID, entry.Agent = blameOriginMixed, mixed.SessionID, mixed.Agent
+	}
+}
+
+func printBlame(w io.Writer, result blameJSON) {
+	width := len(fmt.Sprint(len(result.Lines)))
+	for _, line := range result.Lines {
+		commit, origin := line.Commit[:7], line.Origin
+		if origin == blameOriginBoundary {
+			commit, origin = "^"+line.Commit[:6], ""
+		}
+		fmt.Fprintf(w, "%s %-5s %-12s %*d| %s\n",
+			commit, origin, line.CheckpointID, width, line.Line, line.Text)
+	}
+
+	total := result.AgentLines + result.HumanLines + result.MixedLines
+	if total == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d agent, %d human", result.AgentLines, result.HumanLines)
+	if result.MixedLines > 0 {
+		fmt.Fprintf(w, ", %d mixed", result.MixedLines)
+	}
+	if result.Range != "" {
+		fmt.Fprintf(w, " in %s", result.Range)
+	}
+	fmt.Fprintf(w, " (%s agent)\n", reportfmt.DetectLocale().Percent(float64(result.AgentLines)/float64(total)*100, 1))
+
+	// Which session each checkpoint's agent lines came from
+	seen := make(map[string]bool)
+	for _, line := range result.Lines {
+		if line.SessionID == "" || seen[line.CheckpointID+line.SessionID] {
+			continue
+		}
+		if len(seen) == 0 {
+			fmt.Fprintln(w, "\nCheckpoints:")
+		}
+		seen[line.CheckpointID+line.SessionID] = true
+		agentLabel := line.Agent
+		if agentLabel == "" {
+			agentLabel = "unknown agent"
+		}
+		fmt.Fprintf(w, "  %s  session %s (%s)\n", line.CheckpointID, line.SessionID, agentLabel)
+	}
+}
+
+// repoRelativePath converts a path given on the command line to a
+// slash-separated path relative to the repository root.
+func repoRelativePath(file string) (string, error) {
+	root, err := paths.RepoRoot()
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", file, err)
+	}
+	// Resolve symlinks on both sides (e.g. /var -> /private/var on macOS)
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", file)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func fileContentAt(commit *object.Commit, relPath string) (string, error) {
+	f, err := commit.File(relPath)
+	if err != nil {
+		return "", err //nolint:wrapcheck // callers add context
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", relPath, err)
+	}
+	return content, nil
+}
+
+// mapLinesToAncestor maps each 0-based line of content to the 1-based line it
+// corresponds to in ancestor, or 0 if the line isn't unchanged from ancestor.
+func mapLinesToAncestor(ancestor, content string) []int {
+	dmp := diffmatchpatch.New()
+	text1, text2, _ := dmp.DiffLinesToChars(ancestor, content)
+	diffs := dmp.DiffMain(text1, text2, false)
+
+	var lineMap []int
+	ancestorLine := 0
+	for _, d := range diffs {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for k := range n {
+				lineMap = append(lineMap, ancestorLine+k+1)
+			}
+			ancestorLine += n
+		case diffmatchpatch.DiffInsert:
+			lineMap = append(lineMap, make([]int, n)...)
+		case diffmatchpatch.DiffDelete:
+			ancestorLine += n
+		}
+	}
+	return lineMap
+}
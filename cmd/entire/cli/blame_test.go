@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitBlameTestFile writes main.go and commits it with message.
+func commitBlameTestFile(t *testing.T, repo *git.Repository, dir, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write main.go: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, err := wt.Add("main.go"); err != nil {
+		t.Fatalf("failed to stage main.go: %v", err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@test.com", When: time.Now()}
+	if _, err := wt.Commit(message, &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+}
+
+// setupBlameTestRepo commits main.go as a human, then commits a checkpoint
+// whose agent wrote lines 3-4 of it; the user added line 5.
+func setupBlameTestRepo(t *testing.T) {
+	t.Helper()
+	t.Setenv("LC_ALL", "C")
+	repo, dir := initTestRepo(t)
+
+	commitBlameTestFile(t, repo, dir, "package main\n\n", "human start")
+
+	cpID := id.MustCheckpointID("b1a2e3c4d5f6")
+	writeTestCheckpoint(t, repo, checkpoint.WriteCommittedOptions{
+		CheckpointID: cpID,
+		SessionID:    "2026-10-14-blame-session",
+		FilesTouched: []string{"main.go"},
+		InitialAttribution: &checkpoint.InitialAttribution{
+			AgentLines: 2, HumanAdded: 1, TotalCommitted: 3,
+			Files: []checkpoint.FileAttribution{{
+				Path: "main.go", AgentLines: 2, HumanAdded: 1, TotalCommitted: 3,
+				AgentRanges: []checkpoint.LineRange{{Start: 3, End: 4}},
+			}},
+		},
+	})
+	commitBlameTestFile(t, repo, dir, "package main\n\nfunc main() {\n}\n// TODO\n",
+		"add main\n\nEntire-Checkpoint: "+cpID.String()+"\n")
+}
+
+func TestRunBlame(t *testing.T) {
+	setupBlameTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := runBlame(context.Background(), &buf, "main.go", "HEAD", "", true); err != nil {
+		t.Fatalf("runBlame() error = %v", err)
+	}
+	var origins []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if _, origin, ok := strings.Cut(line, `"origin": "`); ok {
+			origins = append(origins, strings.TrimSuffix(origin, `",`))
+		}
+	}
+	want := []string{"human", "human", "agent", "agent", "human"}
+	if !slices.Equal(origins, want) {
+		t.Errorf("origins = %v, want %v\n%s", origins, want, buf.String())
+	}
+
+	buf.Reset()
+	if err := runBlame(context.Background(), &buf, "main.go", "HEAD", "", false); err != nil {
+		t.Fatalf("runBlame() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"agent b1a2e3c4d5f6 3| func main() {",
+		"2 agent, 3 human (40.0% agent)",
+		"b1a2e3c4d5f6  session 2026-10-14-blame-session (Claude Code)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+
+	if err := runBlame(context.Background(), &buf, "missing.go", "HEAD", "", false); err == nil {
+		t.Error("expected error for a file that isn't in the revision")
+	}
+}
+
+func TestClassifyBlameLine_WithoutRanges(t *testing.T) {
+	t.Parallel()
+
+	cpID := id.MustCheckpointID("c1a2e3c4d5f6")
+	tests := []struct {
+		name    string
+		session blameSession
+		want    string
+	}{
+		{"agent only", blameSession{File: &checkpoint.FileAttribution{AgentLines: 3, TotalCommitted: 3}}, blameOriginAgent},
+		{"human only", blameSession{File: &checkpoint.FileAttribution{HumanAdded: 3, TotalCommitted: 3}}, blameOriginHuman},
+		{"both", blameSession{File: &checkpoint.FileAttribution{AgentLines: 1, HumanAdded: 2}}, blameOriginMixed},
+		{"touched without attribution", blameSession{Touched: true}, blameOriginAgent},
+		{"not touched", blameSession{}, blameOriginHuman},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var entry blameLineJSON
+			classifyBlameLine(&entry, &blameCommit{CheckpointID: cpID, Found: true, Sessions: []blameSession{tt.session}}, 0)
+			if entry.Origin != tt.want {
+				t.Errorf("Origin = %q, want %q", entry.Origin, tt.want)
+			}
+		})
+	}
+
+	var entry blameLineJSON
+	classifyBlameLine(&entry, &blameCommit{}, 0)
+	if entry.Origin != blameOriginHuman || entry.CheckpointID != "" {
+		t.Errorf("commit without checkpoint: %+v, want human", entry)
+	}
+}
+
+func TestMapLinesToAncestor(t *testing.T) {
+	t.Parallel()
+
+	got := mapLinesToAncestor("a\nb\nc\n", "x\na\nc\ny\n")
+	want := []int{0, 1, 3, 0}
+	if !slices.Equal(got, want) {
+		t.Errorf("mapLinesToAncestor() = %v, want %v", got, want)
+	}
+}
+
+func TestRunBlame_Range(t *testing.T) {
+	setupBlameTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := runBlame(context.Background(), &buf, "main.go", "HEAD", "HEAD~1..", false); err != nil {
+		t.Fatalf("runBlame() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"       1| package main",
+		"agent b1a2e3c4d5f6 3| func main() {",
+		"2 agent, 1 human in HEAD~1.. (66.7% agent)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(out, "^") {
+		t.Errorf("line from before the range isn't marked as a boundary:\n%s", out)
+	}
+
+	if err := runBlame(context.Background(), &buf, "main.go", "HEAD", "nope..", false); err == nil {
+		t.Error("expected error for an unknown revision in --range")
+	}
+}
+
+func TestRunBlame_CommitNote(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, dir := initTestRepo(t)
+
+	// The agent's commit lost its trailer in a rewrite; its note remains
+	commitBlameTestFile(t, repo, dir, "package main\n\n", "human start")
+	commitBlameTestFile(t, repo, dir, "package main\n\nfunc main() {\n}\n", "add main")
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	note := &checkpoint.CommitNote{
+		Version:      checkpoint.CommitNoteVersion,
+		CheckpointID: id.MustCheckpointID("c0ffee123456"),
+		Sessions: []checkpoint.CommitNoteSession{{
+			SessionID: "2026-10-14-note-session",
+			Agent:     agent.AgentTypeClaudeCode,
+			Attribution: &checkpoint.InitialAttribution{
+				AgentLines: 2, TotalCommitted: 2,
+				Files: []checkpoint.FileAttribution{{
+					Path: "main.go", AgentLines: 2, TotalCommitted: 2,
+					AgentRanges: []checkpoint.LineRange{{Start: 3, End: 4}},
+				}},
+			},
+		}},
+	}
+	if err := checkpoint.NewGitStore(repo).WriteCommitNotes(map[plumbing.Hash]*checkpoint.CommitNote{head.Hash(): note}, "test", "test@test.com"); err != nil {
+		t.Fatalf("WriteCommitNotes() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runBlame(context.Background(), &buf, "main.go", "HEAD", "", false); err != nil {
+		t.Fatalf("runBlame() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"agent c0ffee123456 3| func main() {",
+		"2 agent, 2 human (50.0% agent)",
+		"c0ffee123456  session 2026-10-14-note-session (Claude Code)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// The audit chain makes edits to recorded attributions evident. Whenever a
+// session's attribution is written to the metadata branch, a record is added
+// in the same commit under audit/, holding the hash of the attribution, the
+// trees it was measured between, and the hash of the previous record.
+//
+// Records are named <seq>-<sha256 of the record>.json, so every record is a
+// new file and merging the metadata branch of two clones keeps both: the
+// chain then forks, with each fork still linked to the shared history.
+// Editing an attribution without adding a record no longer matches the
+// latest record; editing or removing a record breaks the link of the next
+// one, and rewriting the rest of the chain changes its head. Heads printed by
+// `entire audit verify` and kept elsewhere pin the chain up to that point.
+
+// AuditDir is the directory of audit records at the metadata branch root.
+const AuditDir = "audit"
+
+// AuditRecord is one link of the audit chain.
+type AuditRecord struct {
+	Seq          int             `json:"seq"`
+	Prev         string          `json:"prev,omitempty"`
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	SessionID    string          `json:"session_id"`
+
+	// AttributionHash is the SHA-256 of the session's initial_attribution
+	// as compact JSON.
+	AttributionHash string  `json:"attribution_hash"`
+	AgentPercentage float64 `json:"agent_percentage"`
+
+	// The commits the attribution was measured between, and their trees.
+	Commit     string `json:"commit,omitempty"`
+	CommitTree string `json:"commit_tree,omitempty"`
+	BaseCommit string `json:"base_commit,omitempty"`
+	BaseTree   string `json:"base_tree,omitempty"`
+
+	RecordedAt time.Time `json:"recorded_at"`
+
+	// Hash is the SHA-256 of the record file, from its name.
+	Hash string `json:"-"`
+}
+
+// AuditReport is the result of VerifyAuditChain.
+type AuditReport struct {
+	Records int `json:"records"`
+	// Heads are the records no other record links to: one per fork.
+	Heads []string `json:"heads"`
+	// Checked counts the attributions matched against the chain.
+	Checked int `json:"attributions_checked"`
+	// Unrecorded counts attributions written before the chain started.
+	Unrecorded int      `json:"attributions_unrecorded"`
+	Problems   []string `json:"problems,omitempty"`
+}
+
+// AttributionHash returns the hash an audit record stores for attribution.
+func AttributionHash(attribution *InitialAttribution) (string, error) {
+	data, err := json.Marshal(attribution)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal attribution: %w", err)
+	}
+	return rawAttributionHash(data)
+}
+
+func rawAttributionHash(raw json.RawMessage) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", fmt.Errorf("failed to compact attribution: %w", err)
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// auditRecordPath returns the tree path of the record with seq and hash.
+func auditRecordPath(seq int, hash string) string {
+	return fmt.Sprintf("%s/%08d-%s.json", AuditDir, seq, hash)
+}
+
+// parseAuditRecordPath returns the seq and hash in a record's tree path.
+func parseAuditRecordPath(path string) (int, string, bool) {
+	name, ok := strings.CutPrefix(path, AuditDir+"/")
+	if !ok {
+		return 0, "", false
+	}
+	name, ok = strings.CutSuffix(name, ".json")
+	if !ok {
+		return 0, "", false
+	}
+	seqStr, hash, ok := strings.Cut(name, "-")
+	if !ok || len(hash) != sha256.Size*2 {
+		return 0, "", false
+	}
+	seq, err := strconv.Atoi(seqStr)
+	if err != nil || seq < 1 {
+		return 0, "", false
+	}
+	return seq, hash, true
+}
+
+// addAuditRecord appends a record of a session's attribution to the chain
+// in entries, after the record with the highest seq.
+func (s *GitStore) addAuditRecord(entries map[string]object.TreeEntry, checkpointID id.CheckpointID, sessionID string, attribution *InitialAttribution) error {
+	attributionHash, err := AttributionHash(attribution)
+	if err != nil {
+		return err
+	}
+	record := AuditRecord{
+		Seq:             1,
+		CheckpointID:    checkpointID,
+		SessionID:       sessionID,
+		AttributionHash: attributionHash,
+		AgentPercentage: attribution.AgentPercentage,
+		Commit:          attribution.Commit,
+		CommitTree:      s.commitTreeHash(attribution.Commit),
+		BaseCommit:      attribution.BaseCommit,
+		BaseTree:        s.commitTreeHash(attribution.BaseCommit),
+		RecordedAt:      time.Now().UTC(),
+	}
+	// Paths sort by seq, then hash
+	last := ""
+	for path := range entries {
+		if _, _, ok := parseAuditRecordPath(path); ok && path > last {
+			last = path
+		}
+	}
+	if seq, hash, ok := parseAuditRecordPath(last); ok {
+		record.Seq = seq + 1
+		record.Prev = hash
+	}
+
+	data, err := jsonutil.MarshalIndentWithNewline(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	blobHash, err := CreateBlobFromContent(s.repo, data)
+	if err != nil {
+		return fmt.Errorf("failed to create audit record blob: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	path := auditRecordPath(record.Seq, hex.EncodeToString(sum[:]))
+	entries[path] = object.TreeEntry{Name: path, Mode: filemode.Regular, Hash: blobHash}
+	return nil
+}
+
+// commitTreeHash returns the tree hash of commit, or "" if it isn't known.
+func (s *GitStore) commitTreeHash(commit string) string {
+	if commit == "" {
+		return ""
+	}
+	c, err := s.repo.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return ""
+	}
+	return c.TreeHash.String()
+}
+
+// ReadAuditChain returns the records of the audit chain in seq order.
+// Records that can't be parsed are returned with only Seq and Hash set.
+func (s *GitStore) ReadAuditChain(ctx context.Context) ([]AuditRecord, error) {
+	_ = ctx // Reserved for future use
+
+	_, entries, err := s.getSessionsBranchEntries()
+	if err != nil {
+		return nil, err
+	}
+	records, _ := s.readAuditRecords(entries)
+	return records, nil
+}
+
+// readAuditRecords reads the records in entries, in path order, and lists
+// the ones whose content doesn't match their name.
+func (s *GitStore) readAuditRecords(entries map[string]object.TreeEntry) ([]AuditRecord, []string) {
+	var recordPaths []string
+	for path := range entries {
+		if _, _, ok := parseAuditRecordPath(path); ok {
+			recordPaths = append(recordPaths, path)
+		}
+	}
+	sort.Strings(recordPaths)
+
+	var records []AuditRecord
+	var problems []string
+	for _, path := range recordPaths {
+		seq, hash, _ := parseAuditRecordPath(path)
+		record := AuditRecord{Seq: seq, Hash: hash}
+		data, err := readBlobContent(s.repo, entries[path].Hash)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("record %d (%s) is unreadable: %v", seq, shortHash(hash), err))
+			records = append(records, record)
+			continue
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+			problems = append(problems, fmt.Sprintf("record %d (%s) was edited: its content doesn't match its hash", seq, shortHash(hash)))
+		}
+		if err := json.Unmarshal(data, &record); err != nil {
+			problems = append(problems, fmt.Sprintf("record %d (%s) is not valid JSON: %v", seq, shortHash(hash), err))
+		} else if record.Seq != seq {
+			problems = append(problems, fmt.Sprintf("record %d (%s) says it is record %d", seq, shortHash(hash), record.Seq))
+		}
+		record.Seq, record.Hash = seq, hash
+		records = append(records, record)
+	}
+	return records, problems
+}
+
+// VerifyAuditChain checks that the audit chain is intact and that every
+// attribution on the metadata branch matches its latest record.
+func (s *GitStore) VerifyAuditChain(ctx context.Context) (*AuditReport, error) {
+	_ = ctx // Reserved for future use
+
+	report := &AuditReport{Heads: []string{}}
+	if _, err := s.repo.Reference(plumbing.NewBranchReferenceName(paths.MetadataBranchName), true); err != nil {
+		return report, nil //nolint:nilerr // no metadata branch means nothing has been recorded
+	}
+	_, entries, err := s.getSessionsBranchEntries()
+	if err != nil {
+		return nil, err
+	}
+	records, problems := s.readAuditRecords(entries)
+	report.Records = len(records)
+	report.Problems = problems
+
+	// Links: every record but the first follows an existing record
+	byHash := make(map[string]AuditRecord, len(records))
+	for _, r := range records {
+		byHash[r.Hash] = r
+	}
+	linked := make(map[string]bool)
+	var genesis time.Time
+	for _, r := range records {
+		if genesis.IsZero() || (!r.RecordedAt.IsZero() && r.RecordedAt.Before(genesis)) {
+			genesis = r.RecordedAt
+		}
+		switch {
+		case r.Seq == 1 && r.Prev == "":
+		case r.Prev == "":
+			report.Problems = append(report.Problems, fmt.Sprintf("record %d (%s) doesn't link to a previous record", r.Seq, shortHash(r.Hash)))
+		default:
+			prev, ok := byHash[r.Prev]
+			if !ok {
+				report.Problems = append(report.Problems, fmt.Sprintf("record %d (%s) links to %s, which is missing or was edited", r.Seq, shortHash(r.Hash), shortHash(r.Prev)))
+			} else if prev.Seq != r.Seq-1 {
+				report.Problems = append(report.Problems, fmt.Sprintf("record %d (%s) links to record %d", r.Seq, shortHash(r.Hash), prev.Seq))
+			}
+			linked[r.Prev] = true
+		}
+		if r.CommitTree != "" {
+			if tree := s.commitTreeHash(r.Commit); tree != "" && tree != r.CommitTree {
+				report.Problems = append(report.Problems, fmt.Sprintf("record %d (%s): commit %s has tree %s, not %s", r.Seq, shortHash(r.Hash), shortHash(r.Commit), shortHash(tree), shortHash(r.CommitTree)))
+			}
+		}
+		if r.BaseTree != "" {
+			if tree := s.commitTreeHash(r.BaseCommit); tree != "" && tree != r.BaseTree {
+				report.Problems = append(report.Problems, fmt.Sprintf("record %d (%s): base commit %s has tree %s, not %s", r.Seq, shortHash(r.Hash), shortHash(r.BaseCommit), shortHash(tree), shortHash(r.BaseTree)))
+			}
+		}
+	}
+	for _, r := range records {
+		if !linked[r.Hash] {
+			report.Heads = append(report.Heads, r.Hash)
+		}
+	}
+
+	// The latest records of each session, by checkpoint and session ID
+	// (more than one where the chain forked)
+	latest := make(map[string][]AuditRecord)
+	for _, r := range records {
+		key := r.CheckpointID.String() + "/" + r.SessionID
+		switch current := latest[key]; {
+		case len(current) == 0 || r.Seq > current[0].Seq:
+			latest[key] = []AuditRecord{r}
+		case r.Seq == current[0].Seq:
+			latest[key] = append(current, r)
+		}
+	}
+
+	var metadataPaths []string
+	for path := range entries {
+		if isSessionMetadataPath(path) {
+			metadataPaths = append(metadataPaths, path)
+		}
+	}
+	sort.Strings(metadataPaths)
+	for _, path := range metadataPaths {
+		data, err := readBlobContent(s.repo, entries[path].Hash)
+		if err != nil {
+			continue
+		}
+		var metadata struct {
+			SessionID   string          `json:"session_id"`
+			Attribution json.RawMessage `json:"initial_attribution"`
+		}
+		if err := json.Unmarshal(data, &metadata); err != nil || len(metadata.Attribution) == 0 || string(metadata.Attribution) == "null" {
+			continue
+		}
+		parts := strings.Split(path, "/")
+		checkpointID := parts[0] + parts[1]
+		hash, err := rawAttributionHash(metadata.Attribution)
+		if err != nil {
+			continue
+		}
+
+		candidates := latest[checkpointID+"/"+metadata.SessionID]
+		if len(candidates) == 0 {
+			var attribution InitialAttribution
+			_ = json.Unmarshal(metadata.Attribution, &attribution) //nolint:errcheck // a zero time counts as before the chain
+			if len(records) == 0 || attribution.CalculatedAt.Before(genesis) {
+				report.Unrecorded++
+			} else {
+				report.Problems = append(report.Problems, fmt.Sprintf("checkpoint %s, session %s: attribution is missing from the audit chain", checkpointID, metadata.SessionID))
+			}
+			continue
+		}
+		report.Checked++
+		matched := false
+		for _, r := range candidates {
+			matched = matched || r.AttributionHash == hash
+		}
+		if !matched {
+			report.Problems = append(report.Problems, fmt.Sprintf("checkpoint %s, session %s: attribution was edited after record %d (recorded agent share %.1f%%)",
+				checkpointID, metadata.SessionID, candidates[0].Seq, candidates[0].AgentPercentage))
+		}
+	}
+	return report, nil
+}
+
+// isSessionMetadataPath reports whether path is a session's metadata.json:
+// <id[:2]>/<id[2:]>/<index>/metadata.json.
+func isSessionMetadataPath(path string) bool {
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[3] != paths.MetadataFileName {
+		return false
+	}
+	_, err := strconv.Atoi(parts[2])
+	return err == nil
+}
+
+// readBlobContent returns the content of a blob.
+func readBlobContent(repo *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob reader: %w", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
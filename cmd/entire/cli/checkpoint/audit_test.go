@@ -0,0 +1,191 @@
+package checkpoint
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// writeAttributedCheckpoint writes a committed checkpoint whose session has
+// an attribution of commit against base.
+func writeAttributedCheckpoint(t *testing.T, store *GitStore, checkpointID id.CheckpointID, commit plumbing.Hash, agentLines int) {
+	t.Helper()
+	if err := store.WriteCommitted(context.Background(), WriteCommittedOptions{
+		CheckpointID: checkpointID,
+		SessionID:    "test-session",
+		Strategy:     "manual-commit",
+		Transcript:   []byte(`{"type":"user","message":{"content":"hi"}}` + "\n"),
+		AuthorName:   "Test",
+		AuthorEmail:  "test@test.com",
+		InitialAttribution: &InitialAttribution{
+			CalculatedAt:    time.Now().UTC(),
+			AgentLines:      agentLines,
+			TotalCommitted:  10,
+			AgentPercentage: float64(agentLines) * 10,
+			Commit:          commit.String(),
+			BaseCommit:      commit.String(),
+		},
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+}
+
+// rewriteMetadataBranch commits edit's changes to the metadata branch, as
+// someone editing it by hand would.
+func rewriteMetadataBranch(t *testing.T, store *GitStore, edit func(entries map[string]object.TreeEntry)) {
+	t.Helper()
+	ref, entries, err := store.getSessionsBranchEntries()
+	if err != nil {
+		t.Fatalf("failed to read metadata branch: %v", err)
+	}
+	edit(entries)
+	treeHash, err := BuildTreeFromEntries(store.repo, entries)
+	if err != nil {
+		t.Fatalf("failed to build tree: %v", err)
+	}
+	commitHash, err := store.createCommit(treeHash, ref.Hash(), "edit", "Test", "test@test.com")
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if err := store.repo.Storer.SetReference(plumbing.NewHashReference(ref.Name(), commitHash)); err != nil {
+		t.Fatalf("failed to update metadata branch: %v", err)
+	}
+}
+
+func replaceBlob(t *testing.T, store *GitStore, entries map[string]object.TreeEntry, path, from, to string) {
+	t.Helper()
+	data, err := readBlobContent(store.repo, entries[path].Hash)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	edited := strings.Replace(string(data), from, to, 1)
+	if edited == string(data) {
+		t.Fatalf("%q not found in %s", from, path)
+	}
+	hash, err := CreateBlobFromContent(store.repo, []byte(edited))
+	if err != nil {
+		t.Fatalf("failed to create blob: %v", err)
+	}
+	entries[path] = object.TreeEntry{Name: path, Mode: filemode.Regular, Hash: hash}
+}
+
+func TestVerifyAuditChain_Intact(t *testing.T) {
+	repo, commit := setupBranchTestRepo(t)
+	store := NewGitStore(repo)
+	ctx := context.Background()
+
+	first := id.MustCheckpointID("a1b2c3d4e5f6")
+	writeAttributedCheckpoint(t, store, first, commit, 4)
+	writeAttributedCheckpoint(t, store, id.MustCheckpointID("b2c3d4e5f6a1"), commit, 6)
+
+	// A legitimate update adds a record
+	updated := &InitialAttribution{CalculatedAt: time.Now().UTC(), AgentLines: 2, TotalCommitted: 10, AgentPercentage: 20, Commit: commit.String()}
+	if err := store.UpdateSessionAttribution(ctx, first, 0, updated); err != nil {
+		t.Fatalf("UpdateSessionAttribution() error = %v", err)
+	}
+
+	records, err := store.ReadAuditChain(ctx)
+	if err != nil {
+		t.Fatalf("ReadAuditChain() error = %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("ReadAuditChain() = %d records, want 3", len(records))
+	}
+	if records[0].Prev != "" || records[1].Prev != records[0].Hash || records[2].Prev != records[1].Hash {
+		t.Errorf("records aren't chained: %+v", records)
+	}
+	commitObj, err := repo.CommitObject(commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].CommitTree != commitObj.TreeHash.String() {
+		t.Errorf("record commit tree = %q, want %s", records[0].CommitTree, commitObj.TreeHash)
+	}
+
+	report, err := store.VerifyAuditChain(ctx)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain() error = %v", err)
+	}
+	if len(report.Problems) != 0 || report.Checked != 2 || len(report.Heads) != 1 || report.Heads[0] != records[2].Hash {
+		t.Errorf("VerifyAuditChain() = %+v, want 2 checked attributions and one head", report)
+	}
+}
+
+func TestVerifyAuditChain_DetectsEditedAttribution(t *testing.T) {
+	repo, commit := setupBranchTestRepo(t)
+	store := NewGitStore(repo)
+	checkpointID := id.MustCheckpointID("a1b2c3d4e5f6")
+	writeAttributedCheckpoint(t, store, checkpointID, commit, 4)
+
+	rewriteMetadataBranch(t, store, func(entries map[string]object.TreeEntry) {
+		replaceBlob(t, store, entries, checkpointID.Path()+"/0/"+paths.MetadataFileName, `"agent_lines": 4`, `"agent_lines": 9`)
+	})
+
+	report, err := store.VerifyAuditChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyAuditChain() error = %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "edited after record 1") {
+		t.Errorf("Problems = %v, want the edited attribution", report.Problems)
+	}
+}
+
+func TestVerifyAuditChain_DetectsEditedRecord(t *testing.T) {
+	repo, commit := setupBranchTestRepo(t)
+	store := NewGitStore(repo)
+	writeAttributedCheckpoint(t, store, id.MustCheckpointID("a1b2c3d4e5f6"), commit, 4)
+	writeAttributedCheckpoint(t, store, id.MustCheckpointID("b2c3d4e5f6a1"), commit, 6)
+
+	// Removing the first record breaks the second one's link
+	rewriteMetadataBranch(t, store, func(entries map[string]object.TreeEntry) {
+		for path := range entries {
+			if seq, _, ok := parseAuditRecordPath(path); ok && seq == 1 {
+				delete(entries, path)
+			}
+		}
+	})
+
+	report, err := store.VerifyAuditChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyAuditChain() error = %v", err)
+	}
+	found := false
+	for _, p := range report.Problems {
+		found = found || strings.Contains(p, "missing or was edited")
+	}
+	if !found {
+		t.Errorf("Problems = %v, want the broken link", report.Problems)
+	}
+}
+
+func TestVerifyAuditChain_CountsOlderAttributions(t *testing.T) {
+	repo, commit := setupBranchTestRepo(t)
+	store := NewGitStore(repo)
+	checkpointID := id.MustCheckpointID("a1b2c3d4e5f6")
+	writeAttributedCheckpoint(t, store, checkpointID, commit, 4)
+
+	// As if written before the audit chain existed
+	rewriteMetadataBranch(t, store, func(entries map[string]object.TreeEntry) {
+		for path := range entries {
+			if _, _, ok := parseAuditRecordPath(path); ok {
+				delete(entries, path)
+			}
+		}
+	})
+
+	report, err := store.VerifyAuditChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyAuditChain() error = %v", err)
+	}
+	if len(report.Problems) != 0 || report.Unrecorded != 1 || report.Records != 0 {
+		t.Errorf("VerifyAuditChain() = %+v, want one unrecorded attribution", report)
+	}
+}
@@ -0,0 +1,131 @@
+package checkpoint
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Backend names, as reported by Backend.Name.
+const (
+	BackendGoGit  = "go-git"
+	BackendGitCLI = "git"
+)
+
+// Backend is the repository access shadow branch checkpoints are written
+// through: reading the base tree, writing the checkpoint's tree and commit
+// and moving the shadow branch. go-git holds whole trees in memory, which is
+// slow on repositories with a million files; the git CLI backend hands the
+// same work to git plumbing.
+type Backend interface {
+	// Name returns BackendGoGit or BackendGitCLI.
+	Name() string
+
+	// ReadBlob returns the contents of the blob hash.
+	ReadBlob(hash plumbing.Hash) ([]byte, error)
+
+	// FlattenTree adds the entries of the tree treeHash to entries by full
+	// path, like FlattenTree.
+	FlattenTree(treeHash plumbing.Hash, entries map[string]object.TreeEntry) error
+
+	// BuildTree writes a tree holding entries and returns its hash. base
+	// lists baseTreeHash, the tree entries was made from, so only what
+	// changed needs writing.
+	BuildTree(baseTreeHash plumbing.Hash, base, entries map[string]object.TreeEntry) (plumbing.Hash, error)
+
+	// CreateCommit writes a commit of treeHash with parentHash as its parent
+	// (none if zero) and returns its hash.
+	CreateCommit(treeHash, parentHash plumbing.Hash, message, authorName, authorEmail string) (plumbing.Hash, error)
+
+	// SetReference points name at hash.
+	SetReference(name plumbing.ReferenceName, hash plumbing.Hash) error
+
+	// Close releases the processes the backend started.
+	Close() error
+}
+
+// goGitBackend is the default Backend, in-process with go-git.
+type goGitBackend struct {
+	repo *git.Repository
+}
+
+// NewGoGitBackend returns a Backend that uses go-git on repo.
+func NewGoGitBackend(repo *git.Repository) Backend {
+	return &goGitBackend{repo: repo}
+}
+
+func (b *goGitBackend) Name() string { return BackendGoGit }
+
+func (b *goGitBackend) ReadBlob(hash plumbing.Hash) ([]byte, error) {
+	blob, err := b.repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s: %w", hash, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	return content, nil
+}
+
+func (b *goGitBackend) FlattenTree(treeHash plumbing.Hash, entries map[string]object.TreeEntry) error {
+	tree, err := b.repo.TreeObject(treeHash)
+	if err != nil {
+		return fmt.Errorf("failed to get tree %s: %w", treeHash, err)
+	}
+	return FlattenTree(b.repo, tree, "", entries)
+}
+
+func (b *goGitBackend) BuildTree(_ plumbing.Hash, _, entries map[string]object.TreeEntry) (plumbing.Hash, error) {
+	return BuildTreeFromEntries(b.repo, entries)
+}
+
+func (b *goGitBackend) CreateCommit(treeHash, parentHash plumbing.Hash, message, authorName, authorEmail string) (plumbing.Hash, error) {
+	sig := object.Signature{
+		Name:  authorName,
+		Email: authorEmail,
+		When:  time.Now(),
+	}
+
+	commit := &object.Commit{
+		TreeHash:  treeHash,
+		Author:    sig,
+		Committer: sig,
+		Message:   message,
+	}
+
+	// Add parent if not a new branch
+	if parentHash != plumbing.ZeroHash {
+		commit.ParentHashes = []plumbing.Hash{parentHash}
+	}
+
+	obj := b.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to encode commit: %w", err)
+	}
+
+	hash, err := b.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to store commit: %w", err)
+	}
+
+	return hash, nil
+}
+
+func (b *goGitBackend) SetReference(name plumbing.ReferenceName, hash plumbing.Hash) error {
+	if err := b.repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *goGitBackend) Close() error { return nil }
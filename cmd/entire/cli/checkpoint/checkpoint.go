@@ -152,6 +152,26 @@ type WriteTemporaryOptions struct {
 	// IsFirstCheckpoint indicates if this is the first checkpoint of the session
 	// When true, all working directory files are captured (not just modified)
 	IsFirstCheckpoint bool
+
+	// ChunkThreshold is the size from which text files are stored as
+	// content-defined chunks. 0 disables chunking.
+	ChunkThreshold int64
+
+	// SizeLimits bounds the files the checkpoint stores; larger ones are
+	// recorded in SkippedFilesPath instead.
+	SizeLimits SizeLimits
+
+	// TreeListings, if set, supplies cached listings of the base tree.
+	TreeListings *TreeListingCache
+
+	// Backend reads and writes the shadow branch. nil = go-git.
+	Backend Backend
+
+	// MetadataOnly writes the checkpoint without file contents or transcript:
+	// the tree keeps the previous checkpoint's files and records the hashes
+	// and sizes of the changed ones in MetadataOnlyManifestFileName. Used
+	// when the repository is over its quota.
+	MetadataOnly bool
 }
 
 // ReadTemporaryResult contains the result of reading a temporary checkpoint.
@@ -255,6 +275,35 @@ type WriteCommittedOptions struct {
 	// Agent identifies the agent that created this checkpoint (e.g., "Claude Code", "Cursor")
 	Agent agent.AgentType
 
+	// Automation lists why the session counts as autonomous (see CommittedMetadata.Automation)
+	Automation []string
+
+	// TaskType is the session's task type (see CommittedMetadata.TaskType)
+	TaskType string
+
+	// Subagents are the session's subagent runs (see CommittedMetadata.Subagents)
+	Subagents []SubagentMetadata
+
+	// HumanEdits are the human edits recorded between hooks (see
+	// CommittedMetadata.HumanEdits)
+	HumanEdits []HumanEdit
+
+	// Notes are the agent's notes (see CommittedMetadata.Notes)
+	Notes []Note
+
+	// SubagentTranscripts maps a subagent's ToolUseID to its transcript,
+	// written under SubagentsDirName in the session's directory
+	SubagentTranscripts map[string][]byte
+
+	// ReconstructionConfidence is set when the checkpoints were rebuilt from
+	// the transcript (see CommittedMetadata.ReconstructionConfidence)
+	ReconstructionConfidence float64
+
+	// MetadataOnly stores only the transcript's hash and size instead of the
+	// transcript, prompts, context and subagent transcripts (see
+	// CommittedMetadata.MetadataOnly)
+	MetadataOnly bool
+
 	// Transcript position at checkpoint start - tracks what was added during this checkpoint
 	TranscriptIdentifierAtStart string // Last identifier when checkpoint started (UUID for Claude, message ID for Gemini)
 	CheckpointTranscriptStart   int    // Transcript line offset at start of this checkpoint's data
@@ -329,6 +378,10 @@ type SessionContent struct {
 
 	// Context is the context.md content
 	Context string
+
+	// ContentHash is the transcript's hash as stored in content_hash.txt
+	// ("sha256:<hex>"), empty if there is none.
+	ContentHash string
 }
 
 // CommittedMetadata contains the metadata stored in metadata.json for each checkpoint.
@@ -345,10 +398,45 @@ type CommittedMetadata struct {
 	// Agent identifies the agent that created this checkpoint (e.g., "Claude Code", "Cursor")
 	Agent agent.AgentType `json:"agent,omitempty"`
 
+	// Automation lists the signals that the session ran without a person
+	// supervising it, as "kind:detail" (e.g. "ci:GitHub Actions",
+	// "headless:sdk-cli", "bot:renovate[bot]"). Empty for supervised sessions.
+	Automation []string `json:"automation,omitempty"`
+
+	// TaskType is the kind of task the session was tagged with by the prompt
+	// classifier (e.g. "bugfix", "refactor"). Empty for older checkpoints.
+	TaskType string `json:"task_type,omitempty"`
+
+	// ReconstructionConfidence is non-zero when the session's temporary
+	// checkpoints were lost and rebuilt by replaying the transcript's file
+	// edits: the share of edits that replayed cleanly (0-1). Attribution from
+	// reconstructed checkpoints is approximate.
+	ReconstructionConfidence float64 `json:"reconstruction_confidence,omitempty"`
+
+	// MetadataOnly is set when the checkpoint was written while the
+	// repository was over its quota: the transcript, prompts and context
+	// weren't stored, only TranscriptHash and TranscriptSize.
+	MetadataOnly   bool   `json:"metadata_only,omitempty"`
+	TranscriptHash string `json:"transcript_hash,omitempty"`
+	TranscriptSize int64  `json:"transcript_size,omitempty"`
+
 	// Task checkpoint fields (only populated for task checkpoints)
 	IsTask    bool   `json:"is_task,omitempty"`
 	ToolUseID string `json:"tool_use_id,omitempty"`
 
+	// Subagents are the subagent (Task tool) runs the session delegated work
+	// to, recorded as children of the session.
+	Subagents []SubagentMetadata `json:"subagents,omitempty"`
+
+	// HumanEdits are the edits `entire daemon` saw the human make while the
+	// agent was idle, in order. Their lines are already part of the
+	// attribution; they only date the human's work.
+	HumanEdits []HumanEdit `json:"human_edits,omitempty"`
+
+	// Notes are the caveats the agent recorded with the entire_note MCP tool
+	// while working on this commit, in order.
+	Notes []Note `json:"notes,omitempty"`
+
 	// Transcript position at checkpoint start - tracks what was added during this checkpoint
 	TranscriptIdentifierAtStart string `json:"transcript_identifier_at_start,omitempty"` // Last identifier when checkpoint started (UUID for Claude, message ID for Gemini)
 	CheckpointTranscriptStart   int    `json:"checkpoint_transcript_start,omitempty"`    // Transcript line offset at start of this checkpoint's data
@@ -371,6 +459,75 @@ type CommittedMetadata struct {
 	TranscriptPath string `json:"transcript_path,omitempty"`
 }
 
+// MetadataOnlyManifestFileName is the file in a metadata-only temporary
+// checkpoint's metadata directory that lists what the checkpoint would have
+// stored.
+const MetadataOnlyManifestFileName = "metadata-only.json"
+
+// MetadataOnlyManifest describes a metadata-only temporary checkpoint.
+type MetadataOnlyManifest struct {
+	Files      []MetadataOnlyFile `json:"files"`
+	Transcript *MetadataOnlyFile  `json:"transcript,omitempty"`
+}
+
+// MetadataOnlyFile is the git blob hash and size of a file that a
+// metadata-only checkpoint didn't store.
+type MetadataOnlyFile struct {
+	Path    string `json:"path"`
+	Hash    string `json:"hash,omitempty"`
+	Size    int64  `json:"size"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// SubagentsDirName is the directory under a session's directory in a
+// committed checkpoint that holds its subagents' transcripts, one
+// subdirectory per Task tool use ID.
+const SubagentsDirName = "subagents"
+
+// SubagentMetadata describes a subagent (Task tool) run on behalf of a
+// session. Subagents aren't sessions of their own: their checkpoints are
+// part of the parent session's, and their file changes count as its agent
+// work.
+type SubagentMetadata struct {
+	ToolUseID    string `json:"tool_use_id"`
+	AgentID      string `json:"agent_id,omitempty"`
+	SubagentType string `json:"subagent_type,omitempty"`
+	Description  string `json:"description,omitempty"`
+
+	// Checkpoints is the number of checkpoints the subagent made, including
+	// incremental ones.
+	Checkpoints  int      `json:"checkpoints"`
+	FilesTouched []string `json:"files_touched,omitempty"`
+
+	// Transcript is the path of the subagent's transcript relative to the
+	// session's directory, e.g. "subagents/<tool-use-id>/full.jsonl". Empty
+	// if it wasn't captured.
+	Transcript string `json:"transcript,omitempty"`
+
+	// Running is true if the subagent hadn't finished when the checkpoint
+	// was committed.
+	Running bool `json:"running,omitempty"`
+}
+
+// HumanEdit is one batch of file changes the human made while the agent was
+// idle, as recorded by `entire daemon`. Lines are counted relative to the
+// previous content the daemon saw.
+type HumanEdit struct {
+	At           time.Time `json:"at"`
+	Files        []string  `json:"files"`
+	LinesAdded   int       `json:"lines_added"`
+	LinesRemoved int       `json:"lines_removed"`
+}
+
+// Note is a note the agent recorded about its work: an assumption, caveat,
+// TODO, open question or plain note (see session.NoteKinds).
+type Note struct {
+	At    time.Time `json:"at"`
+	Kind  string    `json:"kind"`
+	Text  string    `json:"text"`
+	Files []string  `json:"files,omitempty"`
+}
+
 // GetTranscriptStart returns the transcript line offset at which this checkpoint's data begins.
 // Returns 0 for new checkpoints (start from beginning). For data written by older CLI versions,
 // falls back to the deprecated TranscriptLinesAtStart field.
@@ -424,11 +581,12 @@ type CheckpointSummary struct {
 
 // Summary contains AI-generated summary of a checkpoint.
 type Summary struct {
-	Intent    string           `json:"intent"`     // What user wanted to accomplish
-	Outcome   string           `json:"outcome"`    // What was achieved
-	Learnings LearningsSummary `json:"learnings"`  // Categorized learnings
-	Friction  []string         `json:"friction"`   // Problems/annoyances encountered
-	OpenItems []string         `json:"open_items"` // Tech debt, unfinished work
+	Intent    string           `json:"intent"`              // What user wanted to accomplish
+	Outcome   string           `json:"outcome"`             // What was achieved
+	Decisions []string         `json:"decisions,omitempty"` // Key decisions and why
+	Learnings LearningsSummary `json:"learnings"`           // Categorized learnings
+	Friction  []string         `json:"friction"`            // Problems/annoyances encountered
+	OpenItems []string         `json:"open_items"`          // Tech debt, unfinished work
 }
 
 // LearningsSummary contains learnings grouped by scope.
@@ -468,6 +626,138 @@ type InitialAttribution struct {
 	HumanRemoved    int       `json:"human_removed"`    // Lines removed by human (excluding modifications)
 	TotalCommitted  int       `json:"total_committed"`  // Net additions in commit (agent + human new lines, not total file size)
 	AgentPercentage float64   `json:"agent_percentage"` // agent_lines / total_committed * 100 (0 for deletion-only commits)
+
+	// Files breaks the totals down per changed path, sorted by path.
+	// Each file is attributed on its own, so per-file values may not add up
+	// exactly to the totals above. Empty for checkpoints written before
+	// per-file attribution existed.
+	Files []FileAttribution `json:"files,omitempty"`
+
+	// BinaryFiles lists the changed binary files (images, protobufs, files
+	// with NUL bytes), which line counts can't measure and Files leaves out.
+	// BinaryAgentBytes and BinaryHumanBytes total their byte-size changes.
+	BinaryFiles      []BinaryFileAttribution `json:"binary_files,omitempty"`
+	BinaryAgentBytes int64                   `json:"binary_agent_bytes,omitempty"`
+	BinaryHumanBytes int64                   `json:"binary_human_bytes,omitempty"`
+
+	// GeneratedFiles are the changed files marked as generated (by
+	// linguist-generated in .gitattributes or attribution.generated_files),
+	// attributed like Files but left out of the totals above, so regenerated
+	// code doesn't skew the agent percentage. GeneratedAgentLines and
+	// GeneratedTotalCommitted are their totals. Empty with
+	// attribution.generated "include", which counts them like other files.
+	GeneratedFiles          []FileAttribution `json:"generated_files,omitempty"`
+	GeneratedAgentLines     int               `json:"generated_agent_lines,omitempty"`
+	GeneratedTotalCommitted int               `json:"generated_total_committed,omitempty"`
+
+	// Submodules lists the submodules the commit moved to another commit, a
+	// category of their own: they're attributed whole, not by lines (see
+	// SubmoduleAttribution).
+	Submodules []SubmoduleAttribution `json:"submodules,omitempty"`
+
+	// BaseCommit is the commit the attribution was measured from: the
+	// session's base, or a merge's first parent. `entire ci verify` recomputes
+	// what it can against it. Empty for older checkpoints.
+	BaseCommit string `json:"base_commit,omitempty"`
+
+	// Commit is the commit attributed. Recorded, with its tree, in the audit
+	// chain (see AuditRecord). Empty for older checkpoints.
+	Commit string `json:"commit,omitempty"`
+
+	// Granularity is the unit human edits were weighted in ("word" or "char").
+	// Empty means line-level attribution.
+	Granularity string `json:"granularity,omitempty"`
+
+	// Checkpoints is "union" when lines the human kept from any of the
+	// session's checkpoints counted as the agent's. Empty means only the last
+	// checkpoint was compared.
+	Checkpoints string `json:"checkpoints,omitempty"`
+
+	// Normalization is how file contents were normalized before they were
+	// diffed ("whitespace" or "formatter"). Empty means they weren't.
+	Normalization string `json:"normalization,omitempty"`
+
+	// Skipped is why no attribution was calculated ("merge" for merge
+	// commits with attribution.merge_commits "skip"); all counts are zero.
+	Skipped string `json:"skipped,omitempty"`
+
+	// AmendedFrom lists the commits whose attribution was folded into this
+	// record, oldest first: amended commits, and fixups squashed in by
+	// `git rebase --autosquash`.
+	AmendedFrom []string `json:"amended_from,omitempty"`
+
+	// SupersededBy is the checkpoint this attribution was folded into when
+	// its commit was squashed into another by a fixup. The counts are kept
+	// for reference but belong to that checkpoint now.
+	SupersededBy id.CheckpointID `json:"superseded_by,omitempty"`
+
+	// CherryPickedTo and RevertedBy list the commits the post-commit hook
+	// detected, by patch ID, as cherry-picks and reverts of Commit.
+	CherryPickedTo []string `json:"cherry_picked_to,omitempty"`
+	RevertedBy     []string `json:"reverted_by,omitempty"`
+
+	// CherryPickOf is set in the record derived for a cherry-pick, which has
+	// the original's counts. RevertOf is set in the record derived for a
+	// revert, which has the original's counts negated, so sums over a branch
+	// net the reverted lines out. Derived records are in commit notes.
+	CherryPickOf string `json:"cherry_pick_of,omitempty"`
+	RevertOf     string `json:"revert_of,omitempty"`
+}
+
+// BinaryFileAttribution is the attribution of a single binary file in a
+// commit. Binary files are attributed whole, by byte-size delta: the agent's
+// when the commit kept the version its checkpoint wrote, the human's
+// otherwise. A rewrite that keeps the size counts zero bytes.
+type BinaryFileAttribution struct {
+	Path       string `json:"path"`
+	AgentBytes int64  `json:"agent_bytes"`
+	HumanBytes int64  `json:"human_bytes"`
+	Size       int64  `json:"size"` // Committed size; 0 when the commit deleted the file
+}
+
+// SubmoduleAttribution is the attribution of a submodule whose commit a
+// commit changed: the agent's when the commit points the submodule at a commit
+// one of the agent's checkpoints recorded, the human's otherwise. The lines
+// changed inside the submodule are attributed in its own repository.
+type SubmoduleAttribution struct {
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"` // Empty when the commit added the submodule
+	To    string `json:"to,omitempty"`   // Empty when the commit removed it
+	Agent bool   `json:"agent"`
+}
+
+// FileAttribution is the attribution of a single file in a commit, using the
+// same metrics as InitialAttribution.
+type FileAttribution struct {
+	Path            string  `json:"path"`
+	AgentLines      int     `json:"agent_lines"`
+	HumanAdded      int     `json:"human_added"`
+	HumanModified   int     `json:"human_modified"`
+	HumanRemoved    int     `json:"human_removed"`
+	TotalCommitted  int     `json:"total_committed"`
+	AgentPercentage float64 `json:"agent_percentage"`
+
+	// AgentRanges are the lines of the committed file that came unchanged from
+	// the agent's last checkpoint and were not in the base commit. Used by
+	// `entire blame`. Empty for checkpoints written before line ranges existed.
+	AgentRanges []LineRange `json:"agent_ranges,omitempty"`
+}
+
+// LineRange is an inclusive range of 1-based line numbers.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// IsAgentLine reports whether the 1-based line of the committed file is
+// covered by AgentRanges.
+func (f FileAttribution) IsAgentLine(line int) bool {
+	for _, r := range f.AgentRanges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
 }
 
 // Info provides summary information for listing checkpoints.
@@ -547,6 +837,26 @@ type WriteTemporaryTaskOptions struct {
 
 	// IncrementalData is the tool_input payload for this checkpoint
 	IncrementalData []byte
+
+	// ChunkThreshold is the size from which text files are stored as
+	// content-defined chunks. 0 disables chunking.
+	ChunkThreshold int64
+
+	// SizeLimits bounds the files the checkpoint stores; larger ones are
+	// recorded in SkippedFilesPath instead.
+	SizeLimits SizeLimits
+
+	// TreeListings, if set, supplies cached listings of the base tree.
+	TreeListings *TreeListingCache
+
+	// Backend reads and writes the shadow branch. nil = go-git.
+	Backend Backend
+
+	// MetadataOnly writes the checkpoint without file contents or transcript:
+	// the tree keeps the previous checkpoint's files and records the hashes
+	// and sizes of the changed ones in MetadataOnlyManifestFileName. Used
+	// when the repository is over its quota.
+	MetadataOnly bool
 }
 
 // TemporaryCheckpointInfo contains information about a single commit on a shadow branch.
@@ -570,6 +880,10 @@ type TemporaryCheckpointInfo struct {
 	// ToolUseID is the tool use ID for task checkpoints
 	ToolUseID string
 
+	// MetadataOnly is set for checkpoints written over quota, which don't
+	// hold the files they changed
+	MetadataOnly bool
+
 	// Timestamp is when the checkpoint was created
 	Timestamp time.Time
 }
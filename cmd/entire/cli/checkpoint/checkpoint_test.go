@@ -2,6 +2,7 @@ package checkpoint
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
 
@@ -413,6 +415,188 @@ func TestWriteTemporary_Deduplication(t *testing.T) {
 	}
 }
 
+// TestWriteTemporary_DecryptsMetadata verifies that session files encrypted at
+// rest are stored as plaintext in the checkpoint.
+func TestWriteTemporary_DecryptsMetadata(t *testing.T) {
+	repo, initialCommit := setupBranchTestRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	tempDir := worktree.Filesystem.Root()
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	key := make([]byte, encryption.KeySize)
+	t.Setenv(encryption.KeyEnvVar, base64.StdEncoding.EncodeToString(key))
+	sealed, err := encryption.Seal(key, []byte(`{"test": true}`))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	metadataDir := filepath.Join(tempDir, ".entire", "metadata", "test-session")
+	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(metadataDir, paths.TranscriptFileName), sealed, 0o600); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+
+	result, err := NewGitStore(repo).WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:         "test-session",
+		BaseCommit:        initialCommit.String(),
+		MetadataDir:       ".entire/metadata/test-session",
+		MetadataDirAbs:    metadataDir,
+		CommitMessage:     "Checkpoint",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	commit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	file, err := commit.File(".entire/metadata/test-session/" + paths.TranscriptFileName)
+	if err != nil {
+		t.Fatalf("transcript missing from checkpoint: %v", err)
+	}
+	if content, _ := file.Contents(); content != `{"test": true}` {
+		t.Errorf("checkpoint transcript = %q, want the decrypted content", content)
+	}
+}
+
+// TestWriteTemporary_MetadataOnly verifies that a metadata-only checkpoint
+// records hashes and sizes without storing the changed files.
+func TestWriteTemporary_MetadataOnly(t *testing.T) {
+	repo, initialCommit := setupBranchTestRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	tempDir := worktree.Filesystem.Root()
+	t.Chdir(tempDir)
+
+	metadataDir := filepath.Join(tempDir, ".entire", "metadata", "test-session")
+	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+	transcript := []byte(`{"type":"user"}` + "\n")
+	if err := os.WriteFile(filepath.Join(metadataDir, paths.TranscriptFileName), transcript, 0o644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	content := []byte("package main\n\nfunc main() {}\n")
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), content, 0o644); err != nil {
+		t.Fatalf("failed to write main.go: %v", err)
+	}
+
+	store := NewGitStore(repo)
+	result, err := store.WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:      "test-session",
+		BaseCommit:     initialCommit.String(),
+		NewFiles:       []string{"main.go"},
+		DeletedFiles:   []string{"README.md"},
+		MetadataDir:    ".entire/metadata/test-session",
+		MetadataDirAbs: metadataDir,
+		CommitMessage:  "Checkpoint",
+		AuthorName:     "Test",
+		AuthorEmail:    "test@test.com",
+		MetadataOnly:   true,
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+
+	commit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatalf("failed to read checkpoint commit: %v", err)
+	}
+	if !trailers.IsMetadataOnly(commit.Message) {
+		t.Errorf("commit message lacks the metadata-only trailer: %q", commit.Message)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("failed to read tree: %v", err)
+	}
+	if _, err := tree.File("main.go"); err == nil {
+		t.Error("metadata-only checkpoint stored main.go")
+	}
+	if _, err := tree.File("README.md"); err != nil {
+		t.Error("metadata-only checkpoint should keep the previous files")
+	}
+	if _, err := tree.File(".entire/metadata/test-session/" + paths.TranscriptFileName); err == nil {
+		t.Error("metadata-only checkpoint stored the transcript")
+	}
+
+	file, err := tree.File(".entire/metadata/test-session/" + MetadataOnlyManifestFileName)
+	if err != nil {
+		t.Fatalf("manifest missing: %v", err)
+	}
+	manifestJSON, err := file.Contents()
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var manifest MetadataOnlyManifest
+	if err := json.Unmarshal([]byte(manifestJSON), &manifest); err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	wantHash := plumbing.ComputeHash(plumbing.BlobObject, content)
+	want := []MetadataOnlyFile{
+		{Path: "README.md", Deleted: true},
+		{Path: "main.go", Hash: wantHash.String(), Size: int64(len(content))},
+	}
+	if len(manifest.Files) != len(want) || manifest.Files[0] != want[0] || manifest.Files[1] != want[1] {
+		t.Errorf("manifest files = %+v, want %+v", manifest.Files, want)
+	}
+	if manifest.Transcript == nil || manifest.Transcript.Size != int64(len(transcript)) {
+		t.Errorf("manifest transcript = %+v, want size %d", manifest.Transcript, len(transcript))
+	}
+	if _, err := repo.BlobObject(wantHash); err == nil {
+		t.Error("main.go's blob was written to the object store")
+	}
+}
+
+// TestWriteCommitted_MetadataOnly verifies that a metadata-only committed
+// checkpoint keeps the transcript's hash and size instead of its contents.
+func TestWriteCommitted_MetadataOnly(t *testing.T) {
+	repo, _ := setupBranchTestRepo(t)
+	store := NewGitStore(repo)
+	checkpointID := id.MustCheckpointID("c3d4e5f6a7b8")
+	transcript := []byte(`{"type":"user","message":{"content":"hi"}}` + "\n")
+
+	if err := store.WriteCommitted(context.Background(), WriteCommittedOptions{
+		CheckpointID: checkpointID,
+		SessionID:    "test-session",
+		Strategy:     "manual-commit",
+		Transcript:   transcript,
+		Prompts:      []string{"hi"},
+		Context:      []byte("# Context"),
+		FilesTouched: []string{"main.go"},
+		AuthorName:   "Test",
+		AuthorEmail:  "test@test.com",
+		MetadataOnly: true,
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+
+	content, err := store.ReadSessionContent(context.Background(), checkpointID, 0)
+	if err != nil {
+		t.Fatalf("ReadSessionContent() error = %v", err)
+	}
+	if len(content.Transcript) != 0 || content.Prompts != "" || content.Context != "" {
+		t.Errorf("metadata-only checkpoint stored content: transcript %d bytes, prompts %q, context %q",
+			len(content.Transcript), content.Prompts, content.Context)
+	}
+	metadata := content.Metadata
+	if !metadata.MetadataOnly || metadata.TranscriptSize != int64(len(transcript)) || !strings.HasPrefix(metadata.TranscriptHash, "sha256:") {
+		t.Errorf("metadata = MetadataOnly %v, TranscriptSize %d, TranscriptHash %q", metadata.MetadataOnly, metadata.TranscriptSize, metadata.TranscriptHash)
+	}
+	if len(metadata.FilesTouched) != 1 {
+		t.Errorf("FilesTouched = %v, want main.go", metadata.FilesTouched)
+	}
+}
+
 // setupBranchTestRepo creates a test repository with an initial commit.
 func setupBranchTestRepo(t *testing.T) (*git.Repository, plumbing.Hash) {
 	t.Helper()
@@ -492,6 +676,76 @@ func verifyBranchInMetadata(t *testing.T, repo *git.Repository, checkpointID id.
 
 // TestWriteCommitted_BranchField verifies that the Branch field is correctly
 // captured in metadata.json when on a branch, and is empty when in detached HEAD.
+func TestWriteTemporary_JujutsuHiddenRef(t *testing.T) {
+	tempDir := t.TempDir()
+
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# Test"), 0o644); err != nil {
+		t.Fatalf("failed to write README: %v", err)
+	}
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatalf("failed to add README: %v", err)
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	// Colocated jj repository
+	if err := os.MkdirAll(filepath.Join(tempDir, ".jj", "repo"), 0o755); err != nil {
+		t.Fatalf("failed to create .jj: %v", err)
+	}
+
+	t.Chdir(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "test.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	metadataDir := filepath.Join(tempDir, ".entire", "metadata", "test-session")
+	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(metadataDir, "full.jsonl"), []byte(`{"test": true}`), 0o644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+
+	store := NewGitStore(repo)
+	result, err := store.WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:         "test-session",
+		BaseCommit:        initialCommit.String(),
+		ModifiedFiles:     []string{"test.go"},
+		MetadataDir:       ".entire/metadata/test-session",
+		MetadataDirAbs:    metadataDir,
+		CommitMessage:     "Checkpoint 1",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+
+	branch := ShadowBranchNameForCommit(initialCommit.String(), "")
+	ref, err := repo.Reference(plumbing.ReferenceName("refs/entire/hidden/"+strings.TrimPrefix(branch, "entire/")), true)
+	if err != nil {
+		t.Fatalf("checkpoint not stored under refs/entire/hidden/: %v", err)
+	}
+	if ref.Hash() != result.CommitHash {
+		t.Errorf("hidden ref = %s, want %s", ref.Hash(), result.CommitHash)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true); err == nil {
+		t.Errorf("checkpoint also created branch %s, which jj would import as a bookmark", branch)
+	}
+}
+
 func TestWriteCommitted_BranchField(t *testing.T) {
 	t.Run("on branch", func(t *testing.T) {
 		repo, commitHash := setupBranchTestRepo(t)
@@ -1184,6 +1438,46 @@ func TestReadSessionContent_InvalidIndex(t *testing.T) {
 	}
 }
 
+// TestReadSessionMetadata verifies that session metadata can be read without
+// the transcript, including the attribution recorded at commit time.
+func TestReadSessionMetadata(t *testing.T) {
+	repo, _ := setupBranchTestRepo(t)
+	store := NewGitStore(repo)
+	checkpointID := id.MustCheckpointID("a9a8a7a6a5a4")
+
+	err := store.WriteCommitted(context.Background(), WriteCommittedOptions{
+		CheckpointID:       checkpointID,
+		SessionID:          "meta-session",
+		Strategy:           "manual-commit",
+		Transcript:         []byte(`{"large": "transcript"}`),
+		CheckpointsCount:   1,
+		AuthorName:         "Test Author",
+		AuthorEmail:        "test@example.com",
+		InitialAttribution: &InitialAttribution{AgentLines: 7, TotalCommitted: 10},
+	})
+	if err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+
+	metadata, err := store.ReadSessionMetadata(context.Background(), checkpointID, 0)
+	if err != nil {
+		t.Fatalf("ReadSessionMetadata(0) error = %v", err)
+	}
+	if metadata.SessionID != "meta-session" {
+		t.Errorf("SessionID = %q, want %q", metadata.SessionID, "meta-session")
+	}
+	if metadata.InitialAttribution == nil || metadata.InitialAttribution.AgentLines != 7 {
+		t.Errorf("InitialAttribution = %+v, want AgentLines 7", metadata.InitialAttribution)
+	}
+
+	if _, err := store.ReadSessionMetadata(context.Background(), checkpointID, 1); err == nil {
+		t.Error("ReadSessionMetadata(1) should return error for non-existent session")
+	}
+	if _, err := store.ReadSessionMetadata(context.Background(), id.MustCheckpointID("000000000000"), 0); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("ReadSessionMetadata() for missing checkpoint error = %v, want ErrCheckpointNotFound", err)
+	}
+}
+
 // TestReadLatestSessionContent verifies that ReadLatestSessionContent returns
 // the content of the most recently added session (highest index).
 func TestReadLatestSessionContent(t *testing.T) {
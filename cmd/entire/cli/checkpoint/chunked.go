@@ -0,0 +1,259 @@
+package checkpoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/binary"
+)
+
+// Content-defined chunking of large text files in shadow branch trees.
+//
+// An agent editing a multi-megabyte lockfile or snapshot rewrites the whole
+// blob on every checkpoint. With chunking enabled, a text file at or above the
+// threshold is split at boundaries chosen by its content (a rolling gear hash,
+// as in FastCDC), so an edit only changes the chunks around it and every other
+// chunk is the same blob as in the previous checkpoint. The tree stores:
+//
+//	<path>                               manifest listing the chunks
+//	.entire/chunks/<path>/000000, ...    the chunks, in order
+//
+// The chunks are part of the tree so they are reachable like any other blob.
+// Read files of shadow trees with FileContents to get them reassembled.
+
+// chunkManifestHeader is the first line of every chunk manifest.
+const chunkManifestHeader = "entire-chunked-file v1\n"
+
+// Chunk size bounds. Boundaries fall where the top chunkAvgBits bits of the
+// rolling hash are zero, which averages 64 KiB chunks.
+const (
+	chunkMinSize = 16 << 10
+	chunkMaxSize = 256 << 10
+	chunkAvgBits = 16
+)
+
+// gearTable maps each byte to a pseudo-random value for the rolling hash.
+// It is fixed so the same content always splits the same way.
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x656e74697265) // "entire"
+	for i := range table {
+		// splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// chunkBoundaries returns the end offsets of the chunks data splits into.
+func chunkBoundaries(data []byte) []int {
+	// The high bits of the gear hash mix in the most recent 64 bytes; use those
+	mask := uint64(1)<<chunkAvgBits - 1
+	mask <<= 64 - chunkAvgBits
+
+	var ends []int
+	for start := 0; start < len(data); {
+		end := min(start+chunkMaxSize, len(data))
+		if end-start > chunkMinSize {
+			var h uint64
+			for i := start + chunkMinSize; i < end; i++ {
+				h = h<<1 + gearTable[data[i]]
+				if h&mask == 0 {
+					end = i + 1
+					break
+				}
+			}
+		}
+		ends = append(ends, end)
+		start = end
+	}
+	return ends
+}
+
+// chunkPath returns the tree path of chunk i of file.
+func chunkPath(file string, i int) string {
+	return fmt.Sprintf("%s/%s/%06d", paths.EntireChunksDir, file, i)
+}
+
+// chunkedFile is a parsed chunk manifest.
+type chunkedFile struct {
+	size   int64
+	blob   plumbing.Hash // hash of the file as a single blob
+	chunks []plumbing.Hash
+}
+
+// IsChunkManifest reports whether content is a chunk manifest rather than a
+// file's own content.
+func IsChunkManifest(content string) bool {
+	return strings.HasPrefix(content, chunkManifestHeader)
+}
+
+func formatChunkManifest(m chunkedFile) []byte {
+	var b strings.Builder
+	b.WriteString(chunkManifestHeader)
+	fmt.Fprintf(&b, "size %d\n", m.size)
+	fmt.Fprintf(&b, "blob %s\n", m.blob)
+	fmt.Fprintf(&b, "chunks %d\n", len(m.chunks))
+	for _, h := range m.chunks {
+		b.WriteString(h.String())
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+func parseChunkManifest(content string) (chunkedFile, error) {
+	var m chunkedFile
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(content, chunkManifestHeader), "\n"), "\n")
+	if len(lines) < 3 {
+		return m, errors.New("truncated chunk manifest")
+	}
+	field := func(line, name string) (string, error) {
+		value, ok := strings.CutPrefix(line, name+" ")
+		if !ok {
+			return "", fmt.Errorf("chunk manifest: expected %s, got %q", name, line)
+		}
+		return value, nil
+	}
+
+	sizeStr, err := field(lines[0], "size")
+	if err != nil {
+		return m, err
+	}
+	if m.size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+		return m, fmt.Errorf("chunk manifest: invalid size %q", sizeStr)
+	}
+	blobStr, err := field(lines[1], "blob")
+	if err != nil {
+		return m, err
+	}
+	m.blob = plumbing.NewHash(blobStr)
+	countStr, err := field(lines[2], "chunks")
+	if err != nil {
+		return m, err
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count != len(lines)-3 {
+		return m, fmt.Errorf("chunk manifest: chunk count %q doesn't match %d listed chunks", countStr, len(lines)-3)
+	}
+	for _, line := range lines[3:] {
+		m.chunks = append(m.chunks, plumbing.NewHash(line))
+	}
+	return m, nil
+}
+
+// shouldChunk reports whether the file at absPath is stored chunked with the
+// given threshold, returning its content if so. Only regular text files are
+// chunked; binary files don't split into stable chunks.
+func shouldChunk(absPath string, threshold int64) ([]byte, bool) {
+	if threshold <= 0 {
+		return nil, false
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() < threshold {
+		return nil, false
+	}
+	content, err := os.ReadFile(absPath) //nolint:gosec // absPath is a repository file
+	if err != nil {
+		return nil, false
+	}
+	if isBinary, err := binary.IsBinary(bytes.NewReader(content)); err != nil || isBinary {
+		return nil, false
+	}
+	return content, true
+}
+
+// addChunkedFile stores content as chunks plus a manifest at file.
+func addChunkedFile(repo *git.Repository, file string, content []byte, mode filemode.FileMode, entries map[string]object.TreeEntry) error {
+	m := chunkedFile{
+		size: int64(len(content)),
+		blob: plumbing.ComputeHash(plumbing.BlobObject, content),
+	}
+	start := 0
+	for i, end := range chunkBoundaries(content) {
+		hash, err := CreateBlobFromContent(repo, content[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to store chunk of %s: %w", file, err)
+		}
+		name := chunkPath(file, i)
+		entries[name] = object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: hash}
+		m.chunks = append(m.chunks, hash)
+		start = end
+	}
+
+	hash, err := CreateBlobFromContent(repo, formatChunkManifest(m))
+	if err != nil {
+		return fmt.Errorf("failed to store chunk manifest of %s: %w", file, err)
+	}
+	entries[file] = object.TreeEntry{Name: file, Mode: mode, Hash: hash}
+	return nil
+}
+
+// removeChunks drops the chunks of the given files from entries, so files
+// rewritten or deleted in a checkpoint don't leave stale chunks behind.
+func removeChunks(entries map[string]object.TreeEntry, files map[string]bool) {
+	if len(files) == 0 {
+		return
+	}
+	prefix := paths.EntireChunksDir + "/"
+	for name := range entries {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndexByte(rest, '/'); i > 0 && files[rest[:i]] {
+			delete(entries, name)
+		}
+	}
+}
+
+// FileContents returns the content of f, a file of tree, reassembling it if
+// it is stored chunked.
+func FileContents(tree *object.Tree, f *object.File) (string, error) {
+	content, err := f.Contents()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if !IsChunkManifest(content) {
+		return content, nil
+	}
+
+	m, err := parseChunkManifest(content)
+	if err != nil {
+		// A file that merely starts like a manifest
+		return content, nil //nolint:nilerr // not a manifest
+	}
+	var b strings.Builder
+	b.Grow(int(m.size))
+	for i, want := range m.chunks {
+		chunk, err := tree.File(chunkPath(f.Name, i))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: missing chunk %d: %w", f.Name, i, err)
+		}
+		if chunk.Hash != want {
+			return "", fmt.Errorf("failed to read %s: chunk %d doesn't match its manifest", f.Name, i)
+		}
+		data, err := chunk.Contents()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		b.WriteString(data)
+	}
+	result := b.String()
+	if int64(len(result)) != m.size || plumbing.ComputeHash(plumbing.BlobObject, []byte(result)) != m.blob {
+		return "", fmt.Errorf("failed to read %s: reassembled chunks don't match the manifest", f.Name)
+	}
+	return result, nil
+}
@@ -0,0 +1,232 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// lockfileContent returns a deterministic text file of about size bytes.
+func lockfileContent(size int) []byte {
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // test data
+	var b strings.Builder
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "package-%d@%d.%d.%d: sha512-%x\n", i, rng.Intn(10), rng.Intn(50), rng.Intn(100), rng.Uint64())
+	}
+	return []byte(b.String())
+}
+
+func TestChunkBoundaries(t *testing.T) {
+	t.Parallel()
+	data := lockfileContent(2 << 20)
+	ends := chunkBoundaries(data)
+
+	start := 0
+	for i, end := range ends {
+		size := end - start
+		if size > chunkMaxSize || (size < chunkMinSize && i != len(ends)-1) {
+			t.Errorf("chunk %d has size %d, want between %d and %d", i, size, chunkMinSize, chunkMaxSize)
+		}
+		start = end
+	}
+	if start != len(data) {
+		t.Fatalf("chunks end at %d, want %d", start, len(data))
+	}
+
+	// An insertion near the start only changes the chunks around it
+	edited := append([]byte("inserted line\n"), data...)
+	chunks := func(data []byte) map[string]bool {
+		set := make(map[string]bool)
+		start := 0
+		for _, end := range chunkBoundaries(data) {
+			set[string(data[start:end])] = true
+			start = end
+		}
+		return set
+	}
+	before, after := chunks(data), chunks(edited)
+	shared := 0
+	for c := range after {
+		if before[c] {
+			shared++
+		}
+	}
+	if shared < len(after)-2 {
+		t.Errorf("%d of %d chunks survived an insertion, want all but the first one or two", shared, len(after))
+	}
+}
+
+func TestChunkManifest_RoundTrip(t *testing.T) {
+	t.Parallel()
+	m := chunkedFile{
+		size:   42,
+		blob:   plumbing.NewHash("1111111111111111111111111111111111111111"),
+		chunks: []plumbing.Hash{plumbing.NewHash("2222222222222222222222222222222222222222")},
+	}
+	got, err := parseChunkManifest(string(formatChunkManifest(m)))
+	if err != nil {
+		t.Fatalf("parseChunkManifest() error = %v", err)
+	}
+	if got.size != m.size || got.blob != m.blob || len(got.chunks) != 1 || got.chunks[0] != m.chunks[0] {
+		t.Errorf("parseChunkManifest() = %+v, want %+v", got, m)
+	}
+	if _, err := parseChunkManifest(chunkManifestHeader + "size 1\nblob x\nchunks 3\n"); err == nil {
+		t.Error("expected error for a chunk count that doesn't match the listed chunks")
+	}
+}
+
+func TestWriteTemporary_ChunksLargeTextFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# Test"), 0o644); err != nil {
+		t.Fatalf("failed to write README: %v", err)
+	}
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatalf("failed to add README: %v", err)
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	store := NewGitStore(repo)
+	lockPath := filepath.Join(tempDir, "yarn.lock")
+	small := filepath.Join(tempDir, "small.txt")
+	if err := os.WriteFile(small, []byte("small\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	checkpointTree := func(content []byte, first bool) *object.Tree {
+		t.Helper()
+		if content == nil {
+			if err := os.Remove(lockPath); err != nil {
+				t.Fatalf("failed to remove lockfile: %v", err)
+			}
+		} else if err := os.WriteFile(lockPath, content, 0o644); err != nil {
+			t.Fatalf("failed to write lockfile: %v", err)
+		}
+		opts := WriteTemporaryOptions{
+			SessionID:         "test-session",
+			BaseCommit:        initialCommit.String(),
+			ModifiedFiles:     []string{"yarn.lock", "small.txt"},
+			CommitMessage:     "Checkpoint",
+			AuthorName:        "Test",
+			AuthorEmail:       "test@test.com",
+			IsFirstCheckpoint: first,
+			ChunkThreshold:    64 << 10,
+		}
+		if content == nil {
+			opts.ModifiedFiles = nil
+			opts.DeletedFiles = []string{"yarn.lock"}
+		}
+		result, err := store.WriteTemporary(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("WriteTemporary() error = %v", err)
+		}
+		commit, err := repo.CommitObject(result.CommitHash)
+		if err != nil {
+			t.Fatalf("failed to read checkpoint commit: %v", err)
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			t.Fatalf("failed to read checkpoint tree: %v", err)
+		}
+		return tree
+	}
+	readFile := func(tree *object.Tree, name string) string {
+		t.Helper()
+		f, err := tree.File(name)
+		if err != nil {
+			t.Fatalf("%s not in checkpoint: %v", name, err)
+		}
+		content, err := FileContents(tree, f)
+		if err != nil {
+			t.Fatalf("FileContents(%s) error = %v", name, err)
+		}
+		return content
+	}
+	chunkHashes := func(tree *object.Tree) map[plumbing.Hash]bool {
+		t.Helper()
+		hashes := make(map[plumbing.Hash]bool)
+		_ = tree.Files().ForEach(func(f *object.File) error { //nolint:errcheck // test helper
+			if strings.HasPrefix(f.Name, paths.EntireChunksDir+"/") {
+				hashes[f.Hash] = true
+			}
+			return nil
+		})
+		return hashes
+	}
+
+	original := lockfileContent(1 << 20)
+	tree1 := checkpointTree(original, true)
+	if got := readFile(tree1, "yarn.lock"); got != string(original) {
+		t.Fatal("reassembled lockfile doesn't match the original")
+	}
+	if f, _ := tree1.File("yarn.lock"); f.Size > 4096 {
+		t.Errorf("lockfile is stored as a %d-byte blob, want a small manifest", f.Size)
+	}
+	if f, _ := tree1.File("small.txt"); f.Size != int64(len("small\n")) {
+		t.Errorf("small file is stored as %d bytes, want it stored as is", f.Size)
+	}
+	chunks1 := chunkHashes(tree1)
+	if len(chunks1) < 4 {
+		t.Fatalf("got %d chunks, want the lockfile split into several", len(chunks1))
+	}
+
+	// A small edit reuses almost every chunk
+	edited := append([]byte("# edited by the agent\n"), original...)
+	tree2 := checkpointTree(edited, false)
+	if got := readFile(tree2, "yarn.lock"); got != string(edited) {
+		t.Fatal("reassembled edited lockfile doesn't match")
+	}
+	chunks2 := chunkHashes(tree2)
+	reused := 0
+	for h := range chunks2 {
+		if chunks1[h] {
+			reused++
+		}
+	}
+	if reused < len(chunks2)-2 {
+		t.Errorf("%d of %d chunks reused after a one-line edit", reused, len(chunks2))
+	}
+
+	// Shrinking below the threshold stores the file whole, without chunks
+	tree3 := checkpointTree([]byte("tiny\n"), false)
+	if got := readFile(tree3, "yarn.lock"); got != "tiny\n" {
+		t.Errorf("yarn.lock = %q, want tiny", got)
+	}
+	if n := len(chunkHashes(tree3)); n != 0 {
+		t.Errorf("%d stale chunks left after the file shrank", n)
+	}
+
+	// Deleting a chunked file removes its chunks
+	checkpointTree(original, false)
+	tree5 := checkpointTree(nil, false)
+	if _, err := tree5.File("yarn.lock"); err == nil {
+		t.Error("deleted lockfile still in checkpoint")
+	}
+	if n := len(chunkHashes(tree5)); n != 0 {
+		t.Errorf("%d stale chunks left after the file was deleted", n)
+	}
+}
@@ -18,9 +18,11 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
 	"github.com/entireio/cli/cmd/entire/cli/validation"
 	"github.com/entireio/cli/redact"
@@ -42,21 +44,35 @@ var errStopIteration = errors.New("stop iteration")
 //   - For incremental checkpoints: checkpoints/NNN-<tool-use-id>.json
 //   - For final checkpoints: checkpoint.json and agent-<agent-id>.jsonl
 func (s *GitStore) WriteCommitted(ctx context.Context, opts WriteCommittedOptions) error {
-	_ = ctx // Reserved for future use
+	ctx, span := tracing.Start(ctx, "checkpoint.write_committed",
+		slog.String("checkpoint_id", opts.CheckpointID.String()),
+		slog.String("session_id", opts.SessionID),
+	)
+	err := s.writeCommitted(ctx, opts)
+	span.EndWithError(err)
+	return err
+}
 
+func (s *GitStore) writeCommitted(ctx context.Context, opts WriteCommittedOptions) error {
 	// Validate identifiers to prevent path traversal and malformed data
 	if opts.CheckpointID.IsEmpty() {
 		return errors.New("invalid checkpoint options: checkpoint ID is required")
 	}
-	if err := validation.ValidateSessionID(opts.SessionID); err != nil {
+	profiles := validation.ProfilesFor(string(opts.Agent))
+	if err := profiles.ValidateSessionID(opts.SessionID); err != nil {
 		return fmt.Errorf("invalid checkpoint options: %w", err)
 	}
-	if err := validation.ValidateToolUseID(opts.ToolUseID); err != nil {
+	if err := profiles.ValidateToolUseID(opts.ToolUseID); err != nil {
 		return fmt.Errorf("invalid checkpoint options: %w", err)
 	}
-	if err := validation.ValidateAgentID(opts.AgentID); err != nil {
+	if err := profiles.ValidateAgentID(opts.AgentID); err != nil {
 		return fmt.Errorf("invalid checkpoint options: %w", err)
 	}
+	for _, sub := range opts.Subagents {
+		if err := profiles.ValidateToolUseID(sub.ToolUseID); err != nil {
+			return fmt.Errorf("invalid checkpoint options: subagent: %w", err)
+		}
+	}
 
 	// Ensure sessions branch exists
 	if err := s.ensureSessionsBranch(); err != nil {
@@ -84,12 +100,24 @@ func (s *GitStore) WriteCommitted(ctx context.Context, opts WriteCommittedOption
 	}
 
 	// Write standard checkpoint entries (transcript, prompts, context, metadata)
-	if err := s.writeStandardCheckpointEntries(opts, basePath, entries); err != nil {
+	_, entriesSpan := tracing.Start(ctx, "checkpoint.write_entries")
+	err = s.writeStandardCheckpointEntries(opts, basePath, entries)
+	entriesSpan.EndWithError(err)
+	if err != nil {
 		return err
 	}
 
+	// Record the attribution in the audit chain
+	if opts.InitialAttribution != nil {
+		if err := s.addAuditRecord(entries, opts.CheckpointID, opts.SessionID, opts.InitialAttribution); err != nil {
+			return err
+		}
+	}
+
 	// Build and commit
+	_, treeSpan := tracing.Start(ctx, "checkpoint.build_tree", slog.Int("entries", len(entries)))
 	newTreeHash, err := BuildTreeFromEntries(s.repo, entries)
+	treeSpan.EndWithError(err)
 	if err != nil {
 		return err
 	}
@@ -102,7 +130,10 @@ func (s *GitStore) WriteCommitted(ctx context.Context, opts WriteCommittedOption
 
 	refName := plumbing.NewBranchReferenceName(paths.MetadataBranchName)
 	newRef := plumbing.NewHashReference(refName, newCommitHash)
-	if err := s.repo.Storer.SetReference(newRef); err != nil {
+	_, refSpan := tracing.Start(ctx, "git.update_ref", slog.String("ref", refName.String()))
+	err = s.repo.Storer.SetReference(newRef)
+	refSpan.EndWithError(err)
+	if err != nil {
 		return fmt.Errorf("failed to set branch reference: %w", err)
 	}
 
@@ -201,7 +232,7 @@ func (s *GitStore) writeFinalTaskCheckpoint(opts WriteCommittedOptions, taskPath
 	}
 
 	// Write subagent transcript if available
-	if opts.SubagentTranscriptPath != "" && opts.AgentID != "" {
+	if opts.SubagentTranscriptPath != "" && opts.AgentID != "" && !opts.MetadataOnly {
 		agentContent, readErr := os.ReadFile(opts.SubagentTranscriptPath)
 		if readErr == nil {
 			agentContent, readErr = redact.JSONLBytes(agentContent)
@@ -235,7 +266,8 @@ func (s *GitStore) writeFinalTaskCheckpoint(opts WriteCommittedOptions, taskPath
 //	│   ├── full.jsonl
 //	│   ├── prompt.txt
 //	│   ├── context.md
-//	│   └── content_hash.txt
+//	│   ├── content_hash.txt
+//	│   └── subagents/<tool-use-id>/full.jsonl  # Subagent transcripts, if any
 //	├── 2/                    # Second session
 //	└── ...
 func (s *GitStore) writeStandardCheckpointEntries(opts WriteCommittedOptions, basePath string, entries map[string]object.TreeEntry) error {
@@ -260,7 +292,7 @@ func (s *GitStore) writeStandardCheckpointEntries(opts WriteCommittedOptions, ba
 	}
 
 	// Copy additional metadata files from directory if specified (to session subdirectory)
-	if opts.MetadataDir != "" {
+	if opts.MetadataDir != "" && !opts.MetadataOnly {
 		if err := s.copyMetadataDir(opts.MetadataDir, sessionPath, entries); err != nil {
 			return fmt.Errorf("failed to copy metadata directory: %w", err)
 		}
@@ -293,12 +325,22 @@ func (s *GitStore) writeSessionToSubdirectory(opts WriteCommittedOptions, sessio
 		}
 	}
 
-	// Write transcript
-	if err := s.writeTranscript(opts, sessionPath, entries); err != nil {
-		return filePaths, err
+	var transcriptHash string
+	var transcriptSize int64
+	if opts.MetadataOnly {
+		// Over quota: keep the transcript's identity, not its contents
+		transcriptHash, transcriptSize = metadataOnlyTranscript(opts)
+		opts.Prompts = nil
+		opts.Context = nil
+		opts.SubagentTranscripts = nil
+	} else {
+		// Write transcript
+		if err := s.writeTranscript(opts, sessionPath, entries); err != nil {
+			return filePaths, err
+		}
+		filePaths.Transcript = "/" + sessionPath + paths.TranscriptFileName
+		filePaths.ContentHash = "/" + sessionPath + paths.ContentHashFileName
 	}
-	filePaths.Transcript = "/" + sessionPath + paths.TranscriptFileName
-	filePaths.ContentHash = "/" + sessionPath + paths.ContentHashFileName
 
 	// Write prompts
 	if len(opts.Prompts) > 0 {
@@ -329,6 +371,11 @@ func (s *GitStore) writeSessionToSubdirectory(opts WriteCommittedOptions, sessio
 		filePaths.Context = "/" + sessionPath + paths.ContextFileName
 	}
 
+	subagents, err := s.writeSubagentTranscripts(opts, sessionPath, entries)
+	if err != nil {
+		return filePaths, err
+	}
+
 	// Write session-level metadata.json (CommittedMetadata with all fields including initial_attribution)
 	sessionMetadata := CommittedMetadata{
 		CheckpointID:                opts.CheckpointID,
@@ -339,8 +386,17 @@ func (s *GitStore) writeSessionToSubdirectory(opts WriteCommittedOptions, sessio
 		CheckpointsCount:            opts.CheckpointsCount,
 		FilesTouched:                opts.FilesTouched,
 		Agent:                       opts.Agent,
+		Automation:                  opts.Automation,
+		TaskType:                    opts.TaskType,
+		ReconstructionConfidence:    opts.ReconstructionConfidence,
+		MetadataOnly:                opts.MetadataOnly,
+		TranscriptHash:              transcriptHash,
+		TranscriptSize:              transcriptSize,
 		IsTask:                      opts.IsTask,
 		ToolUseID:                   opts.ToolUseID,
+		Subagents:                   subagents,
+		HumanEdits:                  opts.HumanEdits,
+		Notes:                       opts.Notes,
 		TranscriptIdentifierAtStart: opts.TranscriptIdentifierAtStart,
 		CheckpointTranscriptStart:   opts.CheckpointTranscriptStart,
 		TranscriptLinesAtStart:      opts.CheckpointTranscriptStart, // Deprecated: kept for backward compat
@@ -369,6 +425,39 @@ func (s *GitStore) writeSessionToSubdirectory(opts WriteCommittedOptions, sessio
 	return filePaths, nil
 }
 
+// writeSubagentTranscripts writes each subagent's transcript to
+// subagents/<tool-use-id>/ in the session's directory and returns
+// opts.Subagents with their Transcript paths set.
+func (s *GitStore) writeSubagentTranscripts(opts WriteCommittedOptions, sessionPath string, entries map[string]object.TreeEntry) ([]SubagentMetadata, error) {
+	if len(opts.Subagents) == 0 {
+		return nil, nil
+	}
+	subagents := make([]SubagentMetadata, len(opts.Subagents))
+	copy(subagents, opts.Subagents)
+	for i := range subagents {
+		content := opts.SubagentTranscripts[subagents[i].ToolUseID]
+		if len(content) == 0 {
+			continue
+		}
+		redacted, err := redact.JSONLBytes(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redact subagent transcript secrets: %w", err)
+		}
+		blobHash, err := CreateBlobFromContent(s.repo, redacted)
+		if err != nil {
+			return nil, err
+		}
+		rel := SubagentsDirName + "/" + subagents[i].ToolUseID + "/" + paths.TranscriptFileName
+		entries[sessionPath+rel] = object.TreeEntry{
+			Name: sessionPath + rel,
+			Mode: filemode.Regular,
+			Hash: blobHash,
+		}
+		subagents[i].Transcript = rel
+	}
+	return subagents, nil
+}
+
 // writeCheckpointSummary writes the root-level CheckpointSummary with aggregated statistics.
 // sessions is the complete sessions array (already built by the caller).
 func (s *GitStore) writeCheckpointSummary(opts WriteCommittedOptions, basePath string, entries map[string]object.TreeEntry, sessions []SessionFilePaths) error {
@@ -562,6 +651,25 @@ func (s *GitStore) writeTranscript(opts WriteCommittedOptions, basePath string,
 	return nil
 }
 
+// metadataOnlyTranscript returns the content hash (as in content_hash.txt)
+// and size of the transcript a metadata-only checkpoint doesn't store.
+func metadataOnlyTranscript(opts WriteCommittedOptions) (string, int64) {
+	transcript := opts.Transcript
+	if len(transcript) == 0 && opts.TranscriptPath != "" {
+		transcript, _ = os.ReadFile(opts.TranscriptPath) //nolint:errcheck // transcript may not exist yet
+	}
+	if len(transcript) == 0 && opts.MetadataDir != "" {
+		transcript, _ = encryption.ReadFile(filepath.Join(opts.MetadataDir, paths.TranscriptFileName)) //nolint:errcheck // transcript may not exist yet
+	}
+	if len(transcript) == 0 {
+		return "", 0
+	}
+	if redacted, err := redact.JSONLBytes(transcript); err == nil {
+		transcript = redacted
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(transcript)), int64(len(transcript))
+}
+
 // mergeFilesTouched combines two file lists, removing duplicates.
 func mergeFilesTouched(existing, additional []string) []string {
 	seen := make(map[string]bool)
@@ -738,9 +846,67 @@ func (s *GitStore) ReadSessionContent(ctx context.Context, checkpointID id.Check
 		}
 	}
 
+	if file, fileErr := sessionTree.File(paths.ContentHashFileName); fileErr == nil {
+		if content, contentErr := file.Contents(); contentErr == nil {
+			result.ContentHash = strings.TrimSpace(content)
+		}
+	}
+
 	return result, nil
 }
 
+// ReadSessionMetadata reads only the metadata of a session within a checkpoint,
+// without loading its transcript. sessionIndex is 0-based.
+// Returns ErrCheckpointNotFound if the checkpoint doesn't exist.
+func (s *GitStore) ReadSessionMetadata(ctx context.Context, checkpointID id.CheckpointID, sessionIndex int) (*CommittedMetadata, error) {
+	_ = ctx // Reserved for future use
+
+	tree, err := s.getSessionsBranchTree()
+	if err != nil {
+		return nil, ErrCheckpointNotFound
+	}
+	checkpointTree, err := tree.Tree(checkpointID.Path())
+	if err != nil {
+		return nil, ErrCheckpointNotFound
+	}
+	sessionTree, err := checkpointTree.Tree(strconv.Itoa(sessionIndex))
+	if err != nil {
+		return nil, fmt.Errorf("session %d not found: %w", sessionIndex, err)
+	}
+	metadataFile, err := sessionTree.File(paths.MetadataFileName)
+	if err != nil {
+		return nil, fmt.Errorf("session %d has no metadata: %w", sessionIndex, err)
+	}
+	return s.readMetadataFromBlob(metadataFile.Hash)
+}
+
+// ReadSubagentTranscript reads the transcript of a session's subagent,
+// identified by its Task tool use ID.
+func (s *GitStore) ReadSubagentTranscript(ctx context.Context, checkpointID id.CheckpointID, sessionIndex int, toolUseID string) ([]byte, error) {
+	_ = ctx // Reserved for future use
+
+	if err := validation.ValidateToolUseID(toolUseID); err != nil {
+		return nil, fmt.Errorf("invalid subagent: %w", err)
+	}
+	tree, err := s.getSessionsBranchTree()
+	if err != nil {
+		return nil, ErrCheckpointNotFound
+	}
+	checkpointTree, err := tree.Tree(checkpointID.Path())
+	if err != nil {
+		return nil, ErrCheckpointNotFound
+	}
+	file, err := checkpointTree.File(strconv.Itoa(sessionIndex) + "/" + SubagentsDirName + "/" + toolUseID + "/" + paths.TranscriptFileName)
+	if err != nil {
+		return nil, fmt.Errorf("subagent %s of session %d has no transcript: %w", toolUseID, sessionIndex, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subagent transcript: %w", err)
+	}
+	return []byte(content), nil
+}
+
 // ReadLatestSessionContent is a convenience method that reads the latest session's content.
 // This is equivalent to ReadSessionContent(ctx, checkpointID, len(summary.Sessions)-1).
 func (s *GitStore) ReadLatestSessionContent(ctx context.Context, checkpointID id.CheckpointID) (*SessionContent, error) {
@@ -930,6 +1096,26 @@ func LookupSessionLog(cpID id.CheckpointID) ([]byte, string, error) {
 func (s *GitStore) UpdateSummary(ctx context.Context, checkpointID id.CheckpointID, summary *Summary) error {
 	_ = ctx // Reserved for future use
 
+	return s.updateSessionMetadata(checkpointID, -1, "summary", func(metadata *CommittedMetadata) {
+		metadata.Summary = summary
+	})
+}
+
+// UpdateSessionAttribution replaces the attribution of a session's metadata.
+// sessionIndex is 0-based. Returns ErrCheckpointNotFound if the checkpoint
+// doesn't exist.
+func (s *GitStore) UpdateSessionAttribution(ctx context.Context, checkpointID id.CheckpointID, sessionIndex int, attribution *InitialAttribution) error {
+	_ = ctx // Reserved for future use
+
+	return s.updateSessionMetadata(checkpointID, sessionIndex, "attribution", func(metadata *CommittedMetadata) {
+		metadata.InitialAttribution = attribution
+	})
+}
+
+// updateSessionMetadata rewrites one session's metadata.json on the sessions
+// branch. sessionIndex -1 means the latest session; what names the change in
+// the commit message.
+func (s *GitStore) updateSessionMetadata(checkpointID id.CheckpointID, sessionIndex int, what string, update func(*CommittedMetadata)) error {
 	// Ensure sessions branch exists
 	if err := s.ensureSessionsBranch(); err != nil {
 		return fmt.Errorf("failed to ensure sessions branch: %w", err)
@@ -941,7 +1127,7 @@ func (s *GitStore) UpdateSummary(ctx context.Context, checkpointID id.Checkpoint
 		return err
 	}
 
-	// Read root CheckpointSummary to find the latest session
+	// Read root CheckpointSummary to find the session
 	basePath := checkpointID.Path() + "/"
 	rootMetadataPath := basePath + paths.MetadataFileName
 	entry, exists := entries[rootMetadataPath]
@@ -954,9 +1140,11 @@ func (s *GitStore) UpdateSummary(ctx context.Context, checkpointID id.Checkpoint
 		return fmt.Errorf("failed to read checkpoint summary: %w", err)
 	}
 
-	// Find the latest session's metadata path (0-based indexing)
-	latestIndex := len(checkpointSummary.Sessions) - 1
-	sessionMetadataPath := fmt.Sprintf("%s%d/%s", basePath, latestIndex, paths.MetadataFileName)
+	// Find the session's metadata path (0-based indexing)
+	if sessionIndex < 0 {
+		sessionIndex = len(checkpointSummary.Sessions) - 1
+	}
+	sessionMetadataPath := fmt.Sprintf("%s%d/%s", basePath, sessionIndex, paths.MetadataFileName)
 	sessionEntry, exists := entries[sessionMetadataPath]
 	if !exists {
 		return fmt.Errorf("session metadata not found at %s", sessionMetadataPath)
@@ -967,9 +1155,24 @@ func (s *GitStore) UpdateSummary(ctx context.Context, checkpointID id.Checkpoint
 	if err != nil {
 		return fmt.Errorf("failed to read session metadata: %w", err)
 	}
+	attributionBefore, err := AttributionHash(existingMetadata.InitialAttribution)
+	if err != nil {
+		return err
+	}
+	update(existingMetadata)
 
-	// Update the summary
-	existingMetadata.Summary = summary
+	// A changed attribution is recorded in the audit chain
+	if attribution := existingMetadata.InitialAttribution; attribution != nil {
+		attributionAfter, err := AttributionHash(attribution)
+		if err != nil {
+			return err
+		}
+		if attributionAfter != attributionBefore {
+			if err := s.addAuditRecord(entries, checkpointID, existingMetadata.SessionID, attribution); err != nil {
+				return err
+			}
+		}
+	}
 
 	// Write updated session metadata
 	metadataJSON, err := jsonutil.MarshalIndentWithNewline(existingMetadata, "", "  ")
@@ -993,7 +1196,7 @@ func (s *GitStore) UpdateSummary(ctx context.Context, checkpointID id.Checkpoint
 	}
 
 	authorName, authorEmail := getGitAuthorFromRepo(s.repo)
-	commitMsg := fmt.Sprintf("Update summary for checkpoint %s (session: %s)", checkpointID, existingMetadata.SessionID)
+	commitMsg := fmt.Sprintf("Update %s for checkpoint %s (session: %s)", what, checkpointID, existingMetadata.SessionID)
 	newCommitHash, err := s.createCommit(newTreeHash, ref.Hash(), commitMsg, authorName, authorEmail)
 	if err != nil {
 		return err
@@ -1126,7 +1329,7 @@ func (s *GitStore) copyMetadataDir(metadataDir, basePath string, entries map[str
 		}
 
 		// Store at checkpoint path
-		fullPath := basePath + relPath
+		fullPath := basePath + filepath.ToSlash(relPath)
 		entries[fullPath] = object.TreeEntry{
 			Name: fullPath,
 			Mode: mode,
@@ -1154,7 +1357,7 @@ func createRedactedBlobFromFile(repo *git.Repository, filePath, treePath string)
 		mode = filemode.Executable
 	}
 
-	content, err := os.ReadFile(filePath) //nolint:gosec // filePath comes from walking the metadata directory
+	content, err := encryption.ReadFile(filePath)
 	if err != nil {
 		return plumbing.ZeroHash, 0, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -0,0 +1,91 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestWriteTemporary_SkipsEntireIgnoredFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	write(".entireignore", "dist/\n")
+	write("dist/app.js", "committed\n")
+	for _, name := range []string{".entireignore", "dist/app.js"} {
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	// The agent rebuilds dist/ and edits main.go
+	write("dist/app.js", "rebuilt\n")
+	write("dist/app.js.map", "{}\n")
+	write("main.go", "package main\n")
+
+	result, err := NewGitStore(repo).WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:         "test-session",
+		BaseCommit:        initialCommit.String(),
+		ModifiedFiles:     []string{"dist/app.js"},
+		NewFiles:          []string{"dist/app.js.map", "main.go"},
+		CommitMessage:     "Checkpoint",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	commit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("failed to get tree: %v", err)
+	}
+
+	if _, err := tree.File("main.go"); err != nil {
+		t.Errorf("main.go is missing from the checkpoint: %v", err)
+	}
+	if _, err := tree.File("dist/app.js.map"); err == nil {
+		t.Error("ignored new file dist/app.js.map was captured")
+	}
+	f, err := tree.File("dist/app.js")
+	if err != nil {
+		t.Fatalf("dist/app.js is missing from the checkpoint: %v", err)
+	}
+	if content, _ := f.Contents(); content != "committed\n" {
+		t.Errorf("dist/app.js = %q, want the committed contents", content)
+	}
+}
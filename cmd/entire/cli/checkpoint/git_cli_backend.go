@@ -0,0 +1,232 @@
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// gitCLIBackend is the Backend for very large repositories. It runs git
+// plumbing in the repository: ls-tree lists trees, a long-running
+// cat-file --batch reads blobs, and trees are built in a temporary index
+// read from the base tree, so write-tree only rewrites the trees of the
+// paths that changed. Blobs are still written with go-git; both share the
+// object store.
+type gitCLIBackend struct {
+	dir string
+
+	// cat-file --batch, started on the first ReadBlob
+	catFile *exec.Cmd
+	catIn   io.WriteCloser
+	catOut  *bufio.Reader
+}
+
+// NewGitCLIBackend returns a Backend that runs git in repoRoot.
+func NewGitCLIBackend(repoRoot string) Backend {
+	return &gitCLIBackend{dir: repoRoot}
+}
+
+func (b *gitCLIBackend) Name() string { return BackendGitCLI }
+
+// git runs git with args, stdin and extra environment variables, and
+// returns its output.
+func (b *gitCLIBackend) git(stdin []byte, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(context.Background(), "git", args...)
+	cmd.Dir = b.dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return output, nil
+}
+
+func (b *gitCLIBackend) ReadBlob(hash plumbing.Hash) ([]byte, error) {
+	if b.catFile == nil {
+		cmd := exec.CommandContext(context.Background(), "git", "cat-file", "--batch")
+		cmd.Dir = b.dir
+		in, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+		}
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+		}
+		b.catFile, b.catIn, b.catOut = cmd, in, bufio.NewReader(out)
+	}
+
+	if _, err := fmt.Fprintln(b.catIn, hash); err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	// "<hash> <type> <size>\n<contents>\n", or "<hash> missing\n"
+	header, err := b.catOut.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("failed to read blob %s: %s", hash, strings.TrimSpace(header))
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: bad size %q", hash, fields[2])
+	}
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(b.catOut, content); err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	if fields[1] != plumbing.BlobObject.String() {
+		return nil, fmt.Errorf("failed to read blob %s: object is a %s", hash, fields[1])
+	}
+	return content[:size], nil
+}
+
+func (b *gitCLIBackend) FlattenTree(treeHash plumbing.Hash, entries map[string]object.TreeEntry) error {
+	output, err := b.git(nil, nil, "ls-tree", "-r", "-z", treeHash.String())
+	if err != nil {
+		return fmt.Errorf("failed to list tree %s: %w", treeHash, err)
+	}
+	// "<mode> <type> <hash>\t<path>\0" per file
+	for len(output) > 0 {
+		var record []byte
+		record, output, _ = bytes.Cut(output, []byte{0})
+		meta, path, ok := bytes.Cut(record, []byte{'\t'})
+		fields := strings.Fields(string(meta))
+		if !ok || len(fields) != 3 {
+			return fmt.Errorf("failed to list tree %s: unexpected entry %q", treeHash, record)
+		}
+		mode, err := filemode.New(fields[0])
+		if err != nil {
+			return fmt.Errorf("failed to list tree %s: %w", treeHash, err)
+		}
+		entries[string(path)] = object.TreeEntry{
+			Name: string(path),
+			Mode: mode,
+			Hash: plumbing.NewHash(fields[2]),
+		}
+	}
+	return nil
+}
+
+func (b *gitCLIBackend) BuildTree(baseTreeHash plumbing.Hash, base, entries map[string]object.TreeEntry) (plumbing.Hash, error) {
+	// Removals go first, so a file replaced by a directory (or the other
+	// way around) doesn't conflict with itself
+	var removed, changed []string
+	for path := range base {
+		if _, ok := entries[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	for path, entry := range entries {
+		if old, ok := base[path]; !ok || old.Mode != entry.Mode || old.Hash != entry.Hash {
+			changed = append(changed, path)
+		}
+	}
+	if baseTreeHash != plumbing.ZeroHash && len(removed) == 0 && len(changed) == 0 {
+		return baseTreeHash, nil
+	}
+	sort.Strings(removed)
+	sort.Strings(changed)
+
+	var info bytes.Buffer
+	for _, path := range removed {
+		fmt.Fprintf(&info, "0 %s\t%s\x00", plumbing.ZeroHash, path)
+	}
+	for _, path := range changed {
+		entry := entries[path]
+		fmt.Fprintf(&info, "%o %s\t%s\x00", uint32(entry.Mode), entry.Hash, path)
+	}
+
+	indexDir, err := os.MkdirTemp("", "entire-index-")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(indexDir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(indexDir, "index")}
+
+	if baseTreeHash != plumbing.ZeroHash {
+		if _, err := b.git(nil, env, "read-tree", baseTreeHash.String()); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to read base tree: %w", err)
+		}
+	}
+	if _, err := b.git(info.Bytes(), env, "update-index", "--add", "--replace", "-z", "--index-info"); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to update temporary index: %w", err)
+	}
+	output, err := b.git(nil, env, "write-tree")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to write tree: %w", err)
+	}
+	hash := strings.TrimSpace(string(output))
+	if !plumbing.IsHash(hash) {
+		return plumbing.ZeroHash, fmt.Errorf("failed to write tree: unexpected output %q", hash)
+	}
+	return plumbing.NewHash(hash), nil
+}
+
+func (b *gitCLIBackend) CreateCommit(treeHash, parentHash plumbing.Hash, message, authorName, authorEmail string) (plumbing.Hash, error) {
+	// Unsigned, like the go-git backend's commits
+	args := []string{"commit-tree", "--no-gpg-sign", treeHash.String()}
+	if parentHash != plumbing.ZeroHash {
+		args = append(args, "-p", parentHash.String())
+	}
+	args = append(args, "-F", "-")
+	env := []string{
+		"GIT_AUTHOR_NAME=" + authorName,
+		"GIT_AUTHOR_EMAIL=" + authorEmail,
+		"GIT_COMMITTER_NAME=" + authorName,
+		"GIT_COMMITTER_EMAIL=" + authorEmail,
+	}
+	output, err := b.git([]byte(message), env, args...)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create commit: %w", err)
+	}
+	hash := strings.TrimSpace(string(output))
+	if !plumbing.IsHash(hash) {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create commit: unexpected output %q", hash)
+	}
+	return plumbing.NewHash(hash), nil
+}
+
+func (b *gitCLIBackend) SetReference(name plumbing.ReferenceName, hash plumbing.Hash) error {
+	if _, err := b.git(nil, nil, "update-ref", name.String(), hash.String()); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *gitCLIBackend) Close() error {
+	if b.catFile == nil {
+		return nil
+	}
+	closeErr := b.catIn.Close()
+	waitErr := b.catFile.Wait()
+	b.catFile = nil
+	if err := errors.Join(closeErr, waitErr); err != nil {
+		return fmt.Errorf("failed to stop git cat-file: %w", err)
+	}
+	return nil
+}
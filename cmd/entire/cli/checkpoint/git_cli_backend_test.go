@@ -0,0 +1,233 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestGitCLIBackend_MatchesGoGit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gitRun := func(args ...string) string {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	gitRun("init", "-q")
+	for name, content := range map[string]string{
+		"README.md":       "# readme\n",
+		"src/main.go":     "package main\n",
+		"src/lib/lib.go":  "package lib\n",
+		"docs/guide.md":   "guide\n",
+		"docs/old/api.md": "api\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "initial")
+	headTree := plumbing.NewHash(gitRun("rev-parse", "HEAD^{tree}"))
+	headCommit := plumbing.NewHash(gitRun("rev-parse", "HEAD"))
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goGit := NewGoGitBackend(repo)
+	cli := NewGitCLIBackend(dir)
+	t.Cleanup(func() {
+		if err := cli.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	want := make(map[string]object.TreeEntry)
+	if err := goGit.FlattenTree(headTree, want); err != nil {
+		t.Fatalf("go-git FlattenTree() error = %v", err)
+	}
+	base := make(map[string]object.TreeEntry)
+	if err := cli.FlattenTree(headTree, base); err != nil {
+		t.Fatalf("git FlattenTree() error = %v", err)
+	}
+	if len(base) != len(want) {
+		t.Fatalf("git FlattenTree() = %d entries, want %d", len(base), len(want))
+	}
+	for name, entry := range want {
+		if base[name] != entry {
+			t.Errorf("git FlattenTree()[%q] = %+v, want %+v", name, base[name], entry)
+		}
+	}
+
+	content, err := cli.ReadBlob(base["src/main.go"].Hash)
+	if err != nil || string(content) != "package main\n" {
+		t.Errorf("ReadBlob() = %q, %v", content, err)
+	}
+	if _, err := cli.ReadBlob(plumbing.NewHash(strings.Repeat("1", 40))); err == nil {
+		t.Error("ReadBlob() of a missing object succeeded")
+	}
+	if _, err := cli.ReadBlob(headTree); err == nil {
+		t.Error("ReadBlob() of a tree succeeded")
+	}
+	// The batch process keeps working after errors
+	if content, err := cli.ReadBlob(base["README.md"].Hash); err != nil || string(content) != "# readme\n" {
+		t.Errorf("ReadBlob() after errors = %q, %v", content, err)
+	}
+
+	// Unchanged entries give back the base tree
+	if hash, err := cli.BuildTree(headTree, base, base); err != nil || hash != headTree {
+		t.Errorf("BuildTree(unchanged) = %s, %v; want %s", hash, err, headTree)
+	}
+
+	// Edit, add, delete, make a directory of a file and a file of a directory
+	blob, err := CreateBlobFromContent(repo, []byte("changed\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string]object.TreeEntry, len(base))
+	for name, entry := range base {
+		entries[name] = entry
+	}
+	entries["src/main.go"] = object.TreeEntry{Name: "src/main.go", Mode: filemode.Executable, Hash: blob}
+	entries["new/dir/file.txt"] = object.TreeEntry{Name: "new/dir/file.txt", Mode: filemode.Regular, Hash: blob}
+	delete(entries, "docs/guide.md")
+	delete(entries, "README.md")
+	entries["README.md/inside.md"] = object.TreeEntry{Name: "README.md/inside.md", Mode: filemode.Regular, Hash: blob}
+	delete(entries, "docs/old/api.md")
+	entries["docs/old"] = object.TreeEntry{Name: "docs/old", Mode: filemode.Regular, Hash: blob}
+
+	wantTree, err := goGit.BuildTree(headTree, base, entries)
+	if err != nil {
+		t.Fatalf("go-git BuildTree() error = %v", err)
+	}
+	gotTree, err := cli.BuildTree(headTree, base, entries)
+	if err != nil {
+		t.Fatalf("git BuildTree() error = %v", err)
+	}
+	if gotTree != wantTree {
+		t.Errorf("git BuildTree() = %s, want go-git's %s", gotTree, wantTree)
+	}
+
+	commitHash, err := cli.CreateCommit(gotTree, headCommit, "Checkpoint\n\nEntire-Session: test\n", "Agent", "agent@example.com")
+	if err != nil {
+		t.Fatalf("CreateCommit() error = %v", err)
+	}
+	ref := plumbing.NewBranchReferenceName("entire/test")
+	if err := cli.SetReference(ref, commitHash); err != nil {
+		t.Fatalf("SetReference() error = %v", err)
+	}
+	resolved, err := repo.Reference(ref, true)
+	if err != nil || resolved.Hash() != commitHash {
+		t.Fatalf("%s = %v, %v; want %s", ref, resolved, err, commitHash)
+	}
+	commit, err := repo.CommitObject(commitHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.TreeHash != gotTree || len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != headCommit ||
+		commit.Message != "Checkpoint\n\nEntire-Session: test\n" || commit.Author.Email != "agent@example.com" {
+		t.Errorf("commit = tree %s, parents %v, message %q, author %s", commit.TreeHash, commit.ParentHashes, commit.Message, commit.Author.Email)
+	}
+}
+
+func TestWriteTemporary_GitCLIBackend(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# Test"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	metadataDir := filepath.Join(tempDir, ".entire", "metadata", "test-session")
+	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(metadataDir, "full.jsonl"), []byte(`{"test": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewGitStore(repo)
+	write := func(backend Backend) (WriteTemporaryResult, plumbing.Hash) {
+		t.Helper()
+		result, err := store.WriteTemporary(context.Background(), WriteTemporaryOptions{
+			SessionID:         "test-session",
+			BaseCommit:        initialCommit.String(),
+			ModifiedFiles:     []string{"main.go"},
+			DeletedFiles:      []string{"README.md"},
+			MetadataDir:       ".entire/metadata/test-session",
+			MetadataDirAbs:    metadataDir,
+			CommitMessage:     "Checkpoint",
+			AuthorName:        "Test",
+			AuthorEmail:       "test@test.com",
+			IsFirstCheckpoint: true,
+			Backend:           backend,
+		})
+		if err != nil {
+			t.Fatalf("WriteTemporary(%s) error = %v", backend.Name(), err)
+		}
+		commit, err := repo.CommitObject(result.CommitHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result, commit.TreeHash
+	}
+
+	_, wantTree := write(NewGoGitBackend(repo))
+	if err := store.DeleteShadowBranch(initialCommit.String(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewGitCLIBackend(tempDir)
+	defer cli.Close()
+	first, gotTree := write(cli)
+	if first.Skipped || gotTree != wantTree {
+		t.Errorf("git backend checkpoint tree = %s (skipped %v), want go-git's %s", gotTree, first.Skipped, wantTree)
+	}
+	if !store.ShadowBranchExists(initialCommit.String(), "") {
+		t.Error("git backend didn't create the shadow branch")
+	}
+	if again, _ := write(cli); !again.Skipped || again.CommitHash != first.CommitHash {
+		t.Errorf("unchanged checkpoint = %+v, want it skipped", again)
+	}
+}
@@ -0,0 +1,359 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Size limits and Git LFS in shadow branch trees.
+//
+// An agent that builds a release bundle or regenerates a dataset can write
+// files big enough to balloon .git with every checkpoint. A file larger than
+// SizeLimits.MaxFileSize, or one that would take the checkpoint's new content
+// past SizeLimits.MaxCheckpointSize, is skipped: the tree keeps its previous
+// contents and SkippedFilesPath records its path, blob hash, size and why it
+// was skipped, so the checkpoint says what it doesn't hold.
+//
+// Files tracked by Git LFS (filter=lfs in the root .gitattributes) are stored
+// as LFS pointers, as `git add` stores them, with their content in the local
+// LFS store (.git/lfs/objects) rather than the object store. Use
+// ParseLFSPointer to recognise them, e.g. in attribution, where a pointer
+// isn't three lines of text but a reference to a binary object.
+
+// SkippedFilesPath is the tree path of the record of skipped files.
+const SkippedFilesPath = paths.EntireDir + "/skipped-files.json"
+
+// Reasons a file was skipped.
+const (
+	SkipReasonFileSize       = "file_size"
+	SkipReasonCheckpointSize = "checkpoint_size"
+)
+
+// lfsPointerHeader is the first line of every Git LFS pointer file.
+const lfsPointerHeader = "version https://git-lfs.github.com/spec/v1\n"
+
+// LFSPointerMaxSize bounds Git LFS pointer files, as git-lfs does.
+const LFSPointerMaxSize = 1024
+
+// SizeLimits bounds what a temporary checkpoint stores. 0 means no limit.
+type SizeLimits struct {
+	// MaxFileSize is the largest file, in bytes, a checkpoint stores.
+	MaxFileSize int64
+	// MaxCheckpointSize bounds the bytes of new file content one checkpoint
+	// adds; files are taken in order until the next would exceed it.
+	MaxCheckpointSize int64
+}
+
+// SkippedFile is a file a checkpoint didn't store.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Hash   string `json:"hash"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+// SkippedFilesManifest is the content of SkippedFilesPath.
+type SkippedFilesManifest struct {
+	Files []SkippedFile `json:"files"`
+}
+
+// ReadSkippedFiles returns the files tree's checkpoint skipped, by path.
+// Empty if none were skipped or tree is nil.
+func ReadSkippedFiles(tree *object.Tree) map[string]SkippedFile {
+	skipped := make(map[string]SkippedFile)
+	if tree == nil {
+		return skipped
+	}
+	f, err := tree.File(SkippedFilesPath)
+	if err != nil {
+		return skipped
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return skipped
+	}
+	var m SkippedFilesManifest
+	if json.Unmarshal([]byte(content), &m) != nil {
+		return skipped
+	}
+	for _, s := range m.Files {
+		skipped[s.Path] = s
+	}
+	return skipped
+}
+
+// readSkippedEntries reads the record of skipped files in entries.
+func readSkippedEntries(backend Backend, entries map[string]object.TreeEntry) map[string]SkippedFile {
+	skipped := make(map[string]SkippedFile)
+	entry, ok := entries[SkippedFilesPath]
+	if !ok {
+		return skipped
+	}
+	content, err := backend.ReadBlob(entry.Hash)
+	if err != nil {
+		return skipped
+	}
+	var m SkippedFilesManifest
+	if json.Unmarshal(content, &m) != nil {
+		return skipped
+	}
+	for _, s := range m.Files {
+		skipped[s.Path] = s
+	}
+	return skipped
+}
+
+// writeSkippedEntries records skipped in entries, or removes the record if
+// nothing is skipped.
+func writeSkippedEntries(repo *git.Repository, entries map[string]object.TreeEntry, skipped map[string]SkippedFile) error {
+	if len(skipped) == 0 {
+		delete(entries, SkippedFilesPath)
+		return nil
+	}
+	m := SkippedFilesManifest{Files: make([]SkippedFile, 0, len(skipped))}
+	for _, s := range skipped {
+		m.Files = append(m.Files, s)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	data, err := jsonutil.MarshalIndentWithNewline(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal skipped files: %w", err)
+	}
+	hash, err := CreateBlobFromContent(repo, data)
+	if err != nil {
+		return err
+	}
+	entries[SkippedFilesPath] = object.TreeEntry{Name: SkippedFilesPath, Mode: filemode.Regular, Hash: hash}
+	return nil
+}
+
+// checkSizeLimits returns the files of modifiedFiles the checkpoint skips
+// under limits. lfs files are stored as pointers and never skipped; files
+// whose content is already in entries cost nothing.
+func checkSizeLimits(repoRoot string, modifiedFiles []string, entries map[string]object.TreeEntry, limits SizeLimits, lfs *lfsTracking) map[string]SkippedFile {
+	skipped := make(map[string]SkippedFile)
+	if limits.MaxFileSize <= 0 && limits.MaxCheckpointSize <= 0 {
+		return skipped
+	}
+	// Go in path order, so which files fit the checkpoint limit doesn't
+	// depend on the order hooks report them in
+	files := append([]string(nil), modifiedFiles...)
+	sort.Strings(files)
+	var written int64
+	for _, file := range files {
+		if lfs.tracks(file) {
+			continue
+		}
+		absPath := filepath.Join(repoRoot, file)
+		info, err := os.Lstat(absPath)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		size := info.Size()
+		fileTooLarge := limits.MaxFileSize > 0 && size > limits.MaxFileSize
+		if !fileTooLarge && limits.MaxCheckpointSize <= 0 {
+			continue
+		}
+		hash, err := hashFile(absPath, size)
+		if err != nil {
+			continue
+		}
+		if entry, ok := entries[file]; ok && entry.Hash == hash {
+			continue // Already stored
+		}
+		reason := SkipReasonFileSize
+		if !fileTooLarge {
+			if written+size <= limits.MaxCheckpointSize {
+				written += size
+				continue
+			}
+			reason = SkipReasonCheckpointSize
+		}
+		skipped[file] = SkippedFile{Path: file, Hash: hash.String(), Size: size, Reason: reason}
+	}
+	return skipped
+}
+
+// hashFile computes the git blob hash of a file without reading it into memory.
+func hashFile(absPath string, size int64) (plumbing.Hash, error) {
+	f, err := os.Open(absPath) //nolint:gosec // absPath is a repository file
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to open %s: %w", absPath, err)
+	}
+	defer f.Close()
+	hasher := plumbing.NewHasher(plumbing.BlobObject, size)
+	if _, err := io.Copy(hasher, f); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", absPath, err)
+	}
+	return hasher.Sum(), nil
+}
+
+// LFSPointer is a parsed Git LFS pointer file.
+type LFSPointer struct {
+	// OID is the SHA-256 of the object's content, in hex
+	OID  string
+	Size int64
+}
+
+// ParseLFSPointer parses content as a Git LFS pointer file.
+func ParseLFSPointer(content string) (LFSPointer, bool) {
+	if len(content) > LFSPointerMaxSize || !strings.HasPrefix(content, lfsPointerHeader) {
+		return LFSPointer{}, false
+	}
+	var p LFSPointer
+	for _, line := range strings.Split(strings.TrimPrefix(content, lfsPointerHeader), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "oid":
+			p.OID = strings.TrimPrefix(value, "sha256:")
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return LFSPointer{}, false
+			}
+			p.Size = n
+		}
+	}
+	if _, err := hex.DecodeString(p.OID); err != nil || len(p.OID) != sha256.Size*2 {
+		return LFSPointer{}, false
+	}
+	return p, true
+}
+
+// lfsPointerFor returns the pointer file git-lfs writes for content.
+func lfsPointerFor(content []byte) (LFSPointer, []byte) {
+	sum := sha256.Sum256(content)
+	p := LFSPointer{OID: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+	return p, []byte(fmt.Sprintf("%soid sha256:%s\nsize %d\n", lfsPointerHeader, p.OID, p.Size))
+}
+
+// lfsTracking tells which paths Git LFS tracks, from the filter attribute
+// in the root .gitattributes. A nil lfsTracking tracks nothing.
+type lfsTracking struct {
+	matcher gitattributes.Matcher
+	// objectsDir is the local LFS store, .git/lfs/objects
+	objectsDir string
+}
+
+// loadLFSTracking reads repoRoot's .gitattributes. Returns nil if no path
+// has filter=lfs.
+func loadLFSTracking(repoRoot string) *lfsTracking {
+	data, err := os.ReadFile(filepath.Join(repoRoot, ".gitattributes")) //nolint:gosec // fixed name under the repo root
+	if err != nil || !bytes.Contains(data, []byte("filter=lfs")) {
+		return nil
+	}
+	attrs, err := gitattributes.ReadAttributes(bytes.NewReader(data), nil, true)
+	if err != nil {
+		return nil
+	}
+	objectsDir, err := LFSObjectsDir(repoRoot)
+	if err != nil {
+		return nil
+	}
+	return &lfsTracking{matcher: gitattributes.NewMatcher(attrs), objectsDir: objectsDir}
+}
+
+func (l *lfsTracking) tracks(path string) bool {
+	if l == nil {
+		return false
+	}
+	results, _ := l.matcher.Match(strings.Split(path, "/"), []string{"filter"})
+	filter, ok := results["filter"]
+	return ok && filter.IsValueSet() && filter.Value() == "lfs"
+}
+
+// addLFSPointer stores the file at absPath as an LFS pointer, with its
+// content in the local LFS store.
+func (l *lfsTracking) addLFSPointer(repo *git.Repository, file, absPath string, entries map[string]object.TreeEntry) error {
+	content, err := os.ReadFile(absPath) //nolint:gosec // absPath is a repository file
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if _, ok := ParseLFSPointer(string(content)); ok {
+		// Not smudged, e.g. git lfs isn't installed: store the pointer as is
+		return addBlobEntry(repo, file, absPath, content, entries)
+	}
+	p, pointer := lfsPointerFor(content)
+	objectPath := lfsObjectPath(l.objectsDir, p.OID)
+	if _, err := os.Stat(objectPath); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(objectPath), 0o750); err != nil {
+			return fmt.Errorf("failed to create LFS object directory: %w", err)
+		}
+		tmp := objectPath + ".tmp"
+		if err := os.WriteFile(tmp, content, 0o600); err != nil {
+			return fmt.Errorf("failed to write LFS object for %s: %w", file, err)
+		}
+		if err := os.Rename(tmp, objectPath); err != nil {
+			return fmt.Errorf("failed to write LFS object for %s: %w", file, err)
+		}
+	}
+	return addBlobEntry(repo, file, absPath, pointer, entries)
+}
+
+func addBlobEntry(repo *git.Repository, file, absPath string, content []byte, entries map[string]object.TreeEntry) error {
+	hash, err := CreateBlobFromContent(repo, content)
+	if err != nil {
+		return err
+	}
+	mode := filemode.Regular
+	if info, err := os.Stat(absPath); err == nil && info.Mode()&0o111 != 0 {
+		mode = filemode.Executable
+	}
+	entries[file] = object.TreeEntry{Name: file, Mode: mode, Hash: hash}
+	return nil
+}
+
+// LFSObjectsDir returns the local LFS store of the repository at repoRoot,
+// shared by all its worktrees.
+func LFSObjectsDir(repoRoot string) (string, error) {
+	cmd := exec.CommandContext(context.Background(), "git", "rev-parse", "--git-common-dir")
+	cmd.Dir = repoRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find git directory: %w", err)
+	}
+	commonDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(repoRoot, commonDir)
+	}
+	return filepath.Join(commonDir, "lfs", "objects"), nil
+}
+
+// ReadLFSObject reads the content p points to from the local LFS store.
+func ReadLFSObject(repoRoot string, p LFSPointer) ([]byte, error) {
+	objectsDir, err := LFSObjectsDir(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(lfsObjectPath(objectsDir, p.OID)) //nolint:gosec // path from a hex object ID
+	if err != nil {
+		return nil, fmt.Errorf("LFS object %s isn't in the local store: %w", p.OID[:12], err)
+	}
+	return content, nil
+}
+
+func lfsObjectPath(objectsDir, oid string) string {
+	return filepath.Join(objectsDir, oid[0:2], oid[2:4], oid)
+}
@@ -0,0 +1,190 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestWriteTemporary_SizeLimitsAndLFS(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	write(".gitattributes", "*.psd filter=lfs diff=lfs merge=lfs -text\n")
+	write("data.csv", "a,b\n")
+	for _, name := range []string{".gitattributes", "data.csv"} {
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	big := strings.Repeat("x", 2048)
+	write("data.csv", big)
+	write("dump.bin", big)
+	write("art.psd", big)
+	write("main.go", "package main\n")
+
+	result, err := NewGitStore(repo).WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:         "test-session",
+		BaseCommit:        initialCommit.String(),
+		ModifiedFiles:     []string{"data.csv"},
+		NewFiles:          []string{"dump.bin", "art.psd", "main.go"},
+		CommitMessage:     "Checkpoint",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+		SizeLimits:        SizeLimits{MaxFileSize: 1024},
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	commit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("failed to get tree: %v", err)
+	}
+
+	// Oversized files keep their previous version and are recorded
+	if f, err := tree.File("data.csv"); err != nil {
+		t.Errorf("data.csv is missing: %v", err)
+	} else if content, _ := f.Contents(); content != "a,b\n" {
+		t.Errorf("data.csv = %q, want the committed contents", content)
+	}
+	if _, err := tree.File("dump.bin"); err == nil {
+		t.Error("oversized new file dump.bin was captured")
+	}
+	skipped := ReadSkippedFiles(tree)
+	if len(skipped) != 2 || skipped["dump.bin"].Size != 2048 || skipped["data.csv"].Reason != SkipReasonFileSize {
+		t.Errorf("skipped files = %+v, want data.csv and dump.bin", skipped)
+	}
+	if _, err := tree.File("main.go"); err != nil {
+		t.Errorf("main.go is missing: %v", err)
+	}
+
+	// LFS-tracked files are stored as pointers, their content in the LFS store
+	f, err := tree.File("art.psd")
+	if err != nil {
+		t.Fatalf("art.psd is missing: %v", err)
+	}
+	content, err := f.Contents()
+	if err != nil {
+		t.Fatalf("failed to read art.psd: %v", err)
+	}
+	pointer, ok := ParseLFSPointer(content)
+	if !ok || pointer.Size != 2048 {
+		t.Fatalf("art.psd = %q, want an LFS pointer", content)
+	}
+	object, err := ReadLFSObject(tempDir, pointer)
+	if err != nil {
+		t.Fatalf("ReadLFSObject() error = %v", err)
+	}
+	if string(object) != big {
+		t.Error("LFS object doesn't have the file's content")
+	}
+}
+
+func TestWriteTemporary_CheckpointSizeLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# test\n"), 0o644); err != nil {
+		t.Fatalf("failed to write README.md: %v", err)
+	}
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatalf("failed to add README.md: %v", err)
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(strings.Repeat("y", 400)), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	result, err := NewGitStore(repo).WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:         "test-session",
+		BaseCommit:        initialCommit.String(),
+		NewFiles:          []string{"a.txt", "b.txt", "c.txt"},
+		CommitMessage:     "Checkpoint",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+		SizeLimits:        SizeLimits{MaxCheckpointSize: 1000},
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	commit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("failed to get tree: %v", err)
+	}
+	skipped := ReadSkippedFiles(tree)
+	if len(skipped) != 1 || skipped["c.txt"].Reason != SkipReasonCheckpointSize {
+		t.Errorf("skipped files = %+v, want c.txt over the checkpoint limit", skipped)
+	}
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	t.Parallel()
+	_, pointer := lfsPointerFor([]byte("hello"))
+	p, ok := ParseLFSPointer(string(pointer))
+	if !ok || p.Size != 5 || len(p.OID) != 64 {
+		t.Errorf("ParseLFSPointer(%q) = %+v, %v", pointer, p, ok)
+	}
+	for _, content := range []string{
+		"",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:nothex\nsize 5\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + p.OID + "\nsize -1\n",
+		"just a\nthree-line\ntext file\n",
+	} {
+		if _, ok := ParseLFSPointer(content); ok {
+			t.Errorf("ParseLFSPointer(%q) should fail", content)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Commit notes are a second home for checkpoint metadata: a git note under
+// refs/notes/entire on each commit with a checkpoint, holding its metadata and
+// attribution (not transcripts). Notes are attached to the commit itself, so
+// they travel with it whenever the notes ref is fetched, even into clones that
+// never fetch entire/checkpoints/v1.
+//
+// The notes ref is a regular git notes tree: one blob per annotated commit,
+// named by the commit hash, optionally fanned out into directories (git does
+// this for large note trees). Notes written here are never fanned out; both
+// layouts are read.
+//
+// Fetched notes are kept in refs/notes/remotes/<remote>/entire rather than
+// over the local ref, so a fetch never drops notes that haven't been pushed
+// yet. Reads fall back to them; pushing merges them into the local ref.
+
+// CommitNoteVersion is the format version of commit notes.
+const CommitNoteVersion = 1
+
+// CommitNote is the checkpoint metadata stored in a commit's git note.
+type CommitNote struct {
+	Version      int                 `json:"version"`
+	CheckpointID id.CheckpointID     `json:"checkpoint_id"`
+	Strategy     string              `json:"strategy"`
+	Branch       string              `json:"branch,omitempty"`
+	FilesTouched []string            `json:"files_touched"`
+	TokenUsage   *agent.TokenUsage   `json:"token_usage,omitempty"`
+	Sessions     []CommitNoteSession `json:"sessions"`
+}
+
+// CommitNoteSession is one session of a checkpoint in a commit note.
+type CommitNoteSession struct {
+	SessionID        string              `json:"session_id"`
+	Agent            agent.AgentType     `json:"agent,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	CheckpointsCount int                 `json:"checkpoints_count"`
+	FilesTouched     []string            `json:"files_touched"`
+	TokenUsage       *agent.TokenUsage   `json:"token_usage,omitempty"`
+	Summary          *Summary            `json:"summary,omitempty"`
+	Attribution      *InitialAttribution `json:"attribution,omitempty"`
+}
+
+// RemoteNotesRefName returns the ref fetched notes of remote are kept in.
+func RemoteNotesRefName(remote string) plumbing.ReferenceName {
+	return plumbing.ReferenceName("refs/notes/remotes/" + remote + "/entire")
+}
+
+// NotesFetchRefspec returns the fetch refspec that keeps the notes of remote
+// up to date in RemoteNotesRefName.
+func NotesFetchRefspec(remote string) string {
+	return "+" + paths.NotesRefName + ":" + RemoteNotesRefName(remote).String()
+}
+
+// BuildCommitNote builds the note for a committed checkpoint. Returns
+// ErrCheckpointNotFound if the checkpoint doesn't exist.
+func (s *GitStore) BuildCommitNote(ctx context.Context, checkpointID id.CheckpointID) (*CommitNote, error) {
+	summary, err := s.ReadCommitted(ctx, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, ErrCheckpointNotFound
+	}
+
+	note := &CommitNote{
+		Version:      CommitNoteVersion,
+		CheckpointID: summary.CheckpointID,
+		Strategy:     summary.Strategy,
+		Branch:       summary.Branch,
+		FilesTouched: summary.FilesTouched,
+		TokenUsage:   summary.TokenUsage,
+		Sessions:     make([]CommitNoteSession, 0, len(summary.Sessions)),
+	}
+	for i := range summary.Sessions {
+		m, err := s.ReadSessionMetadata(ctx, checkpointID, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session %d of checkpoint %s: %w", i, checkpointID, err)
+		}
+		note.Sessions = append(note.Sessions, CommitNoteSession{
+			SessionID:        m.SessionID,
+			Agent:            m.Agent,
+			CreatedAt:        m.CreatedAt,
+			CheckpointsCount: m.CheckpointsCount,
+			FilesTouched:     m.FilesTouched,
+			TokenUsage:       m.TokenUsage,
+			Summary:          m.Summary,
+			Attribution:      m.InitialAttribution,
+		})
+	}
+	return note, nil
+}
+
+// ReadCommitNote returns the note of commit, or nil if it has none. Notes
+// fetched from remotes are used when there is no local one.
+func (s *GitStore) ReadCommitNote(commit plumbing.Hash) (*CommitNote, error) {
+	refNames := []plumbing.ReferenceName{plumbing.ReferenceName(paths.NotesRefName)}
+	refs, err := s.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	_ = refs.ForEach(func(ref *plumbing.Reference) error { //nolint:errcheck // callback never fails
+		name := ref.Name().String()
+		if strings.HasPrefix(name, "refs/notes/remotes/") && strings.HasSuffix(name, "/entire") {
+			refNames = append(refNames, ref.Name())
+		}
+		return nil
+	})
+
+	for _, refName := range refNames {
+		_, notes, err := s.notesEntries(refName)
+		if err != nil {
+			return nil, err
+		}
+		if blobHash, ok := notes[commit]; ok {
+			return s.readCommitNoteBlob(blobHash)
+		}
+	}
+	return nil, nil //nolint:nilnil // no note
+}
+
+// ListCommitNotes returns the hashes of all commits with a local note.
+func (s *GitStore) ListCommitNotes() ([]plumbing.Hash, error) {
+	_, notes, err := s.notesEntries(plumbing.ReferenceName(paths.NotesRefName))
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]plumbing.Hash, 0, len(notes))
+	for commit := range notes {
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// WriteCommitNotes adds or replaces the notes of the given commits in a
+// single notes commit.
+func (s *GitStore) WriteCommitNotes(notes map[plumbing.Hash]*CommitNote, authorName, authorEmail string) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	parent, entries, err := s.notesEntries(plumbing.ReferenceName(paths.NotesRefName))
+	if err != nil {
+		return err
+	}
+	for commit, note := range notes {
+		data, err := jsonutil.MarshalIndentWithNewline(note, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal note for %s: %w", commit, err)
+		}
+		blobHash, err := CreateBlobFromContent(s.repo, data)
+		if err != nil {
+			return fmt.Errorf("failed to store note for %s: %w", commit, err)
+		}
+		entries[commit] = blobHash
+	}
+	msg := fmt.Sprintf("Notes added by 'entire' for %d commit(s)", len(notes))
+	return s.commitNotes(entries, []plumbing.Hash{parent}, msg, authorName, authorEmail)
+}
+
+// MergeCommitNotes merges the notes of another notes commit (such as a
+// remote's) into the local notes ref. Where both have a note for a commit,
+// the local one is kept.
+func (s *GitStore) MergeCommitNotes(other plumbing.Hash, authorName, authorEmail string) error {
+	otherCommit, err := s.repo.CommitObject(other)
+	if err != nil {
+		return fmt.Errorf("failed to read notes commit %s: %w", other, err)
+	}
+	merged, err := s.noteBlobsInTree(otherCommit.TreeHash)
+	if err != nil {
+		return err
+	}
+	parent, local, err := s.notesEntries(plumbing.ReferenceName(paths.NotesRefName))
+	if err != nil {
+		return err
+	}
+	if parent == other {
+		return nil
+	}
+	if parent != plumbing.ZeroHash {
+		parentCommit, err := s.repo.CommitObject(parent)
+		if err != nil {
+			return fmt.Errorf("failed to read notes commit %s: %w", parent, err)
+		}
+		if merged, err := otherCommit.IsAncestor(parentCommit); err == nil && merged {
+			return nil // Already merged
+		}
+		if behind, err := parentCommit.IsAncestor(otherCommit); err == nil && behind {
+			parent = plumbing.ZeroHash // Fast-forward
+		}
+	}
+	if parent == plumbing.ZeroHash {
+		ref := plumbing.NewHashReference(plumbing.ReferenceName(paths.NotesRefName), other)
+		if err := s.repo.Storer.SetReference(ref); err != nil {
+			return fmt.Errorf("failed to update %s: %w", paths.NotesRefName, err)
+		}
+		return nil
+	}
+	for commit, blob := range local {
+		merged[commit] = blob
+	}
+	return s.commitNotes(merged, []plumbing.Hash{parent, other}, "Merge remote notes", authorName, authorEmail)
+}
+
+// notesEntries returns the notes commit refName points at (zero if there is
+// none) and its notes, mapping annotated commits to note blobs.
+func (s *GitStore) notesEntries(refName plumbing.ReferenceName) (plumbing.Hash, map[plumbing.Hash]plumbing.Hash, error) {
+	ref, err := s.repo.Reference(refName, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return plumbing.ZeroHash, make(map[plumbing.Hash]plumbing.Hash), nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, nil, fmt.Errorf("failed to read %s: %w", refName, err)
+	}
+	commit, err := s.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return plumbing.ZeroHash, nil, fmt.Errorf("failed to read %s: %w", refName, err)
+	}
+	entries, err := s.noteBlobsInTree(commit.TreeHash)
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	return ref.Hash(), entries, nil
+}
+
+// noteBlobsInTree reads a notes tree, undoing any fanout.
+func (s *GitStore) noteBlobsInTree(treeHash plumbing.Hash) (map[plumbing.Hash]plumbing.Hash, error) {
+	tree, err := s.repo.TreeObject(treeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes tree: %w", err)
+	}
+	flat := make(map[string]object.TreeEntry)
+	if err := FlattenTree(s.repo, tree, "", flat); err != nil {
+		return nil, fmt.Errorf("failed to read notes tree: %w", err)
+	}
+	entries := make(map[plumbing.Hash]plumbing.Hash, len(flat))
+	for path, entry := range flat {
+		name := strings.ReplaceAll(path, "/", "")
+		if len(name) != 40 || !plumbing.IsHash(name) {
+			continue // Not a note (git allows other files in notes trees)
+		}
+		entries[plumbing.NewHash(name)] = entry.Hash
+	}
+	return entries, nil
+}
+
+func (s *GitStore) readCommitNoteBlob(blobHash plumbing.Hash) (*CommitNote, error) {
+	blob, err := s.repo.BlobObject(blobHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note: %w", err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note: %w", err)
+	}
+	defer reader.Close()
+	var note CommitNote
+	if err := json.NewDecoder(reader).Decode(&note); err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+	return &note, nil
+}
+
+// commitNotes writes entries as the new notes tree and points the notes ref at it.
+func (s *GitStore) commitNotes(entries map[plumbing.Hash]plumbing.Hash, parents []plumbing.Hash, message, authorName, authorEmail string) error {
+	treeEntries := make(map[string]object.TreeEntry, len(entries))
+	for commit, blob := range entries {
+		name := commit.String()
+		treeEntries[name] = object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: blob}
+	}
+	treeHash, err := BuildTreeFromEntries(s.repo, treeEntries)
+	if err != nil {
+		return fmt.Errorf("failed to build notes tree: %w", err)
+	}
+
+	sig := object.Signature{Name: authorName, Email: authorEmail, When: time.Now()}
+	commit := &object.Commit{
+		TreeHash:  treeHash,
+		Author:    sig,
+		Committer: sig,
+		Message:   message,
+	}
+	for _, p := range parents {
+		if p != plumbing.ZeroHash {
+			commit.ParentHashes = append(commit.ParentHashes, p)
+		}
+	}
+	obj := s.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return fmt.Errorf("failed to encode notes commit: %w", err)
+	}
+	commitHash, err := s.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return fmt.Errorf("failed to store notes commit: %w", err)
+	}
+
+	ref := plumbing.NewHashReference(plumbing.ReferenceName(paths.NotesRefName), commitHash)
+	if err := s.repo.Storer.SetReference(ref); err != nil {
+		return fmt.Errorf("failed to update %s: %w", paths.NotesRefName, err)
+	}
+	return nil
+}
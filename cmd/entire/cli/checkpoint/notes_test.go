@@ -0,0 +1,169 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func newNotesTestStore(t *testing.T) *GitStore {
+	t.Helper()
+	repo, err := git.PlainInit(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	return NewGitStore(repo)
+}
+
+func TestCommitNotes_BuildWriteRead(t *testing.T) {
+	store := newNotesTestStore(t)
+	ctx := context.Background()
+	cpID := id.MustCheckpointID("a1b2c3d4e5f6")
+	err := store.WriteCommitted(ctx, WriteCommittedOptions{
+		CheckpointID: cpID,
+		SessionID:    "2026-10-14-notes",
+		Strategy:     "manual-commit",
+		Agent:        agent.AgentTypeClaudeCode,
+		FilesTouched: []string{"main.go"},
+		Transcript:   []byte(`{"type":"user","message":{"content":"hi"}}` + "\n"),
+		InitialAttribution: &InitialAttribution{
+			AgentLines:      9,
+			TotalCommitted:  10,
+			AgentPercentage: 90,
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+
+	note, err := store.BuildCommitNote(ctx, cpID)
+	if err != nil {
+		t.Fatalf("BuildCommitNote() error = %v", err)
+	}
+	if len(note.Sessions) != 1 || note.Sessions[0].Attribution == nil || note.Sessions[0].Attribution.AgentLines != 9 {
+		t.Fatalf("note sessions = %+v, want one session with its attribution", note.Sessions)
+	}
+	if _, err := store.BuildCommitNote(ctx, id.MustCheckpointID("ffffffffffff")); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("BuildCommitNote() of unknown checkpoint error = %v, want ErrCheckpointNotFound", err)
+	}
+
+	commit := plumbing.NewHash("1111111111111111111111111111111111111111")
+	if err := store.WriteCommitNotes(map[plumbing.Hash]*CommitNote{commit: note}, "Test", "test@example.com"); err != nil {
+		t.Fatalf("WriteCommitNotes() error = %v", err)
+	}
+	got, err := store.ReadCommitNote(commit)
+	if err != nil {
+		t.Fatalf("ReadCommitNote() error = %v", err)
+	}
+	if got == nil || got.CheckpointID != cpID || got.Sessions[0].SessionID != "2026-10-14-notes" {
+		t.Errorf("ReadCommitNote() = %+v, want the written note", got)
+	}
+	if got, err := store.ReadCommitNote(plumbing.NewHash("2222222222222222222222222222222222222222")); err != nil || got != nil {
+		t.Errorf("ReadCommitNote() of commit without note = %+v, %v; want nil, nil", got, err)
+	}
+
+	commits, err := store.ListCommitNotes()
+	if err != nil || len(commits) != 1 || commits[0] != commit {
+		t.Errorf("ListCommitNotes() = %v, %v; want [%s]", commits, err, commit)
+	}
+}
+
+func TestReadCommitNote_FannedOutAndRemote(t *testing.T) {
+	store := newNotesTestStore(t)
+	commit := plumbing.NewHash("3333333333333333333333333333333333333333")
+	blob, err := CreateBlobFromContent(store.repo, []byte(`{"version":1,"checkpoint_id":"a1b2c3d4e5f6","strategy":"manual-commit","files_touched":[],"sessions":[]}`))
+	if err != nil {
+		t.Fatalf("CreateBlobFromContent() error = %v", err)
+	}
+	// A notes tree as git writes it for many notes: 33/33333...
+	name := commit.String()[:2] + "/" + commit.String()[2:]
+	treeHash, err := BuildTreeFromEntries(store.repo, map[string]object.TreeEntry{
+		name: {Name: name, Mode: filemode.Regular, Hash: blob},
+	})
+	if err != nil {
+		t.Fatalf("BuildTreeFromEntries() error = %v", err)
+	}
+	notesCommit := writeNotesTestCommit(t, store, treeHash)
+	// Only fetched from a remote, not in the local ref
+	if err := store.repo.Storer.SetReference(plumbing.NewHashReference(RemoteNotesRefName("origin"), notesCommit)); err != nil {
+		t.Fatalf("SetReference() error = %v", err)
+	}
+
+	got, err := store.ReadCommitNote(commit)
+	if err != nil {
+		t.Fatalf("ReadCommitNote() error = %v", err)
+	}
+	if got == nil || got.CheckpointID.String() != "a1b2c3d4e5f6" {
+		t.Errorf("ReadCommitNote() = %+v, want the remote's fanned-out note", got)
+	}
+}
+
+func TestMergeCommitNotes_KeepsLocalNotes(t *testing.T) {
+	store := newNotesTestStore(t)
+	shared := plumbing.NewHash("4444444444444444444444444444444444444444")
+	remoteOnly := plumbing.NewHash("5555555555555555555555555555555555555555")
+	localOnly := plumbing.NewHash("6666666666666666666666666666666666666666")
+	note := func(cp string) *CommitNote {
+		return &CommitNote{Version: CommitNoteVersion, CheckpointID: id.MustCheckpointID(cp)}
+	}
+
+	// Remote notes, written first, then set aside as a remote would have them
+	if err := store.WriteCommitNotes(map[plumbing.Hash]*CommitNote{shared: note("aaaaaaaaaaaa"), remoteOnly: note("bbbbbbbbbbbb")}, "Test", "test@example.com"); err != nil {
+		t.Fatalf("WriteCommitNotes() error = %v", err)
+	}
+	ref, err := store.repo.Reference(plumbing.ReferenceName(paths.NotesRefName), true)
+	if err != nil {
+		t.Fatalf("failed to read notes ref: %v", err)
+	}
+	remoteNotes := ref.Hash()
+	if err := store.repo.Storer.RemoveReference(plumbing.ReferenceName(paths.NotesRefName)); err != nil {
+		t.Fatalf("RemoveReference() error = %v", err)
+	}
+
+	if err := store.WriteCommitNotes(map[plumbing.Hash]*CommitNote{shared: note("cccccccccccc"), localOnly: note("dddddddddddd")}, "Test", "test@example.com"); err != nil {
+		t.Fatalf("WriteCommitNotes() error = %v", err)
+	}
+	if err := store.MergeCommitNotes(remoteNotes, "Test", "test@example.com"); err != nil {
+		t.Fatalf("MergeCommitNotes() error = %v", err)
+	}
+
+	for commit, want := range map[plumbing.Hash]string{shared: "cccccccccccc", remoteOnly: "bbbbbbbbbbbb", localOnly: "dddddddddddd"} {
+		got, err := store.ReadCommitNote(commit)
+		if err != nil || got == nil || got.CheckpointID.String() != want {
+			t.Errorf("ReadCommitNote(%s) = %+v, %v; want checkpoint %s", commit.String()[:7], got, err, want)
+		}
+	}
+
+	// Merging the same notes again is a no-op
+	before, _ := store.repo.Reference(plumbing.ReferenceName(paths.NotesRefName), true) //nolint:errcheck // checked above
+	if err := store.MergeCommitNotes(remoteNotes, "Test", "test@example.com"); err != nil {
+		t.Fatalf("MergeCommitNotes() error = %v", err)
+	}
+	after, _ := store.repo.Reference(plumbing.ReferenceName(paths.NotesRefName), true) //nolint:errcheck // checked above
+	if before.Hash() != after.Hash() {
+		t.Error("merging already merged notes created a new notes commit")
+	}
+}
+
+func writeNotesTestCommit(t *testing.T, store *GitStore, treeHash plumbing.Hash) plumbing.Hash {
+	t.Helper()
+	commit := &object.Commit{TreeHash: treeHash, Message: "Notes added by 'git notes add'"}
+	obj := store.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	hash, err := store.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("failed to store commit: %v", err)
+	}
+	return hash
+}
@@ -0,0 +1,127 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/validation"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// WriteReconstructedOptions describes a temporary checkpoint rebuilt from a
+// session transcript after its shadow branch was lost.
+type WriteReconstructedOptions struct {
+	// SessionID is the session identifier
+	SessionID string
+
+	// BaseCommit is the commit the session's checkpoints are based on
+	BaseCommit string
+
+	// WorktreeID is the internal git worktree identifier (empty for main worktree)
+	WorktreeID string
+
+	// Files maps repo-relative paths to their reconstructed content. It holds
+	// every file the session changed up to this checkpoint, not only this
+	// checkpoint's changes: the tree is the base commit's tree plus Files.
+	Files map[string][]byte
+
+	// Transcript is stored as the session's transcript in the metadata directory
+	Transcript []byte
+
+	// CommitMessage is the commit subject line
+	CommitMessage string
+
+	// Confidence is the share of the transcript's file edits that could be
+	// replayed, recorded in the Entire-Reconstructed trailer.
+	Confidence float64
+
+	// AuthorName is the name to use for commits
+	AuthorName string
+
+	// AuthorEmail is the email to use for commits
+	AuthorEmail string
+}
+
+// WriteReconstructed appends a reconstructed checkpoint to the shadow branch
+// of opts.BaseCommit, creating the branch if it doesn't exist. Unlike
+// WriteTemporary it doesn't read the working tree: the code comes from
+// opts.Files, so the checkpoint can be rebuilt after the files changed again.
+func (s *GitStore) WriteReconstructed(ctx context.Context, opts WriteReconstructedOptions) (plumbing.Hash, error) {
+	_ = ctx // Reserved for future use
+
+	if opts.BaseCommit == "" {
+		return plumbing.ZeroHash, errors.New("BaseCommit is required for reconstructed checkpoint")
+	}
+	if err := validation.ValidateSessionID(opts.SessionID); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("invalid reconstructed checkpoint options: %w", err)
+	}
+
+	base, err := s.repo.CommitObject(plumbing.NewHash(opts.BaseCommit))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to read base commit %s: %w", opts.BaseCommit, err)
+	}
+	baseTree, err := base.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to read base tree: %w", err)
+	}
+	entries := make(map[string]object.TreeEntry)
+	if err := FlattenTree(s.repo, baseTree, "", entries); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to flatten base tree: %w", err)
+	}
+
+	files := make([]string, 0, len(opts.Files))
+	for file := range opts.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		blobHash, err := CreateBlobFromContent(s.repo, opts.Files[file])
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to store %s: %w", file, err)
+		}
+		mode := filemode.Regular
+		if existing, ok := entries[file]; ok && existing.Mode == filemode.Executable {
+			mode = filemode.Executable
+		}
+		entries[file] = object.TreeEntry{Name: file, Mode: mode, Hash: blobHash}
+	}
+
+	metadataDir := paths.SessionMetadataDirFromSessionID(opts.SessionID)
+	if len(opts.Transcript) > 0 {
+		blobHash, err := CreateBlobFromContent(s.repo, opts.Transcript)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to store transcript: %w", err)
+		}
+		transcriptPath := metadataDir + "/" + paths.TranscriptFileName
+		entries[transcriptPath] = object.TreeEntry{Name: transcriptPath, Mode: filemode.Regular, Hash: blobHash}
+	}
+
+	treeHash, err := BuildTreeFromEntries(s.repo, entries)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to build tree: %w", err)
+	}
+
+	shadowBranchName := ShadowBranchNameForCommit(opts.BaseCommit, opts.WorktreeID)
+	refName := ShadowRefName(s.repo, shadowBranchName)
+	parentHash := plumbing.ZeroHash
+	if ref, err := s.repo.Reference(refName, true); err == nil {
+		parentHash = ref.Hash()
+	}
+
+	commitMsg := trailers.FormatReconstructed(trailers.FormatShadowCommit(opts.CommitMessage, metadataDir, opts.SessionID), opts.Confidence)
+	commitHash, err := s.createCommit(treeHash, parentHash, commitMsg, opts.AuthorName, opts.AuthorEmail)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create commit: %w", err)
+	}
+	if err := s.repo.Storer.SetReference(plumbing.NewHashReference(refName, commitHash)); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to update branch reference: %w", err)
+	}
+	return commitHash, nil
+}
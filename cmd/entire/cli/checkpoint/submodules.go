@@ -0,0 +1,61 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Submodules in shadow branch trees.
+//
+// A submodule is a gitlink entry in the tree: the path and the commit the
+// submodule has checked out, not its files. A checkpoint that lists a
+// submodule's path records the commit the submodule is at then, so a
+// checkpoint shows when the agent moved a submodule. Changes to the files
+// inside it belong to the submodule's own repository.
+
+// SubmoduleCommits returns the commits of tree's submodules by path.
+func SubmoduleCommits(tree *object.Tree) map[string]plumbing.Hash {
+	commits := make(map[string]plumbing.Hash)
+	if tree == nil {
+		return commits
+	}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err != nil {
+			break // io.EOF, or a tree that can't be read
+		}
+		if entry.Mode == filemode.Submodule {
+			commits[name] = entry.Hash
+		}
+	}
+	return commits
+}
+
+// SubmoduleHead returns the commit checked out in the submodule at absPath,
+// false if it isn't checked out.
+func SubmoduleHead(absPath string) (plumbing.Hash, bool) {
+	// Without its own .git, git would answer for the superproject
+	if _, err := os.Stat(filepath.Join(absPath, ".git")); err != nil {
+		return plumbing.ZeroHash, false
+	}
+	cmd := exec.CommandContext(context.Background(), "git", "rev-parse", "HEAD")
+	cmd.Dir = absPath
+	output, err := cmd.Output()
+	if err != nil {
+		return plumbing.ZeroHash, false
+	}
+	hash := strings.TrimSpace(string(output))
+	if !plumbing.IsHash(hash) {
+		return plumbing.ZeroHash, false
+	}
+	return plumbing.NewHash(hash), true
+}
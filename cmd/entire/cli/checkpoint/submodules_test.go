@@ -0,0 +1,97 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+func TestWriteTemporary_RecordsSubmoduleCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	gitRun := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	sub := filepath.Join(tempDir, "lib")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(sub, "init", "-q")
+	if err := os.WriteFile(filepath.Join(sub, "lib.go"), []byte("package lib\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(sub, "add", "lib.go")
+	gitRun(sub, "commit", "-q", "-m", "first")
+	gitRun(tempDir, "init", "-q")
+	gitRun(tempDir, "add", "lib")
+	gitRun(tempDir, "commit", "-q", "-m", "add lib")
+	base := gitRun(tempDir, "rev-parse", "HEAD")
+
+	// The agent commits in the submodule
+	if err := os.WriteFile(filepath.Join(sub, "lib.go"), []byte("package lib\n\nfunc F() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(sub, "commit", "-q", "-am", "second")
+	moved := plumbing.NewHash(gitRun(sub, "rev-parse", "HEAD"))
+
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+	repo, err := git.PlainOpen(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseCommit, err := repo.CommitObject(plumbing.NewHash(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseTree, err := baseCommit.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commits := SubmoduleCommits(baseTree); len(commits) != 1 || commits["lib"] == moved {
+		t.Fatalf("SubmoduleCommits(base) = %v, want lib at its first commit", commits)
+	}
+
+	result, err := NewGitStore(repo).WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:         "test-session",
+		BaseCommit:        base,
+		ModifiedFiles:     []string{"lib"},
+		CommitMessage:     "Checkpoint",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	commit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := tree.FindEntry("lib")
+	if err != nil {
+		t.Fatalf("lib is missing: %v", err)
+	}
+	if entry.Mode != filemode.Submodule || entry.Hash != moved {
+		t.Errorf("lib = %v %s, want the submodule at %s", entry.Mode, entry.Hash, moved)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,11 +17,15 @@ import (
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/entireignore"
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
 	"github.com/entireio/cli/cmd/entire/cli/validation"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
 	"github.com/entireio/cli/redact"
 
 	"github.com/go-git/go-git/v5"
@@ -54,6 +59,14 @@ func HashWorktreeID(worktreeID string) string {
 // If the new tree hash matches the last checkpoint's tree hash, the checkpoint
 // is skipped to avoid duplicate commits (deduplication).
 func (s *GitStore) WriteTemporary(ctx context.Context, opts WriteTemporaryOptions) (WriteTemporaryResult, error) {
+	ctx, span := tracing.Start(ctx, "checkpoint.write_temporary", slog.String("session_id", opts.SessionID))
+	result, err := s.writeTemporary(ctx, opts)
+	span.SetAttributes(slog.Bool("skipped", result.Skipped))
+	span.EndWithError(err)
+	return result, err
+}
+
+func (s *GitStore) writeTemporary(ctx context.Context, opts WriteTemporaryOptions) (WriteTemporaryResult, error) {
 	// Validate base commit - required for shadow branch naming
 	if opts.BaseCommit == "" {
 		return WriteTemporaryResult{}, errors.New("BaseCommit is required for temporary checkpoint")
@@ -105,8 +118,28 @@ func (s *GitStore) WriteTemporary(ctx context.Context, opts WriteTemporaryOption
 		allDeletedFiles = opts.DeletedFiles
 	}
 
+	// Files .entireignore ignores keep their base tree contents. An unreadable
+	// ignore file doesn't stop checkpoints: everything is captured.
+	if ignore, err := loadEntireIgnore(); err == nil {
+		allFiles = ignore.Filter(allFiles)
+		allDeletedFiles = ignore.Filter(allDeletedFiles)
+	}
+
 	// Build tree with changes
-	treeHash, err := s.buildTreeWithChanges(baseTreeHash, allFiles, allDeletedFiles, opts.MetadataDir, opts.MetadataDirAbs)
+	backend := s.backendOrDefault(opts.Backend)
+	_, treeSpan := tracing.Start(ctx, "checkpoint.build_tree",
+		slog.Int("files", len(allFiles)),
+		slog.Int("deleted_files", len(allDeletedFiles)),
+		slog.Bool("metadata_only", opts.MetadataOnly),
+		slog.String("backend", backend.Name()),
+	)
+	var treeHash plumbing.Hash
+	if opts.MetadataOnly {
+		treeHash, err = s.buildMetadataOnlyTree(backend, baseTreeHash, allFiles, allDeletedFiles, opts.MetadataDir, filepath.Join(opts.MetadataDirAbs, paths.TranscriptFileName))
+	} else {
+		treeHash, err = s.buildTreeWithChanges(backend, baseTreeHash, allFiles, allDeletedFiles, opts.MetadataDir, opts.MetadataDirAbs, opts.ChunkThreshold, opts.SizeLimits, opts.TreeListings)
+	}
+	treeSpan.EndWithError(err)
 	if err != nil {
 		return WriteTemporaryResult{}, fmt.Errorf("failed to build tree: %w", err)
 	}
@@ -121,16 +154,21 @@ func (s *GitStore) WriteTemporary(ctx context.Context, opts WriteTemporaryOption
 
 	// Create checkpoint commit with trailers
 	commitMsg := trailers.FormatShadowCommit(opts.CommitMessage, opts.MetadataDir, opts.SessionID)
+	if opts.MetadataOnly {
+		commitMsg = trailers.FormatMetadataOnly(commitMsg)
+	}
 
-	commitHash, err := s.createCommit(treeHash, parentHash, commitMsg, opts.AuthorName, opts.AuthorEmail)
+	commitHash, err := backend.CreateCommit(treeHash, parentHash, commitMsg, opts.AuthorName, opts.AuthorEmail)
 	if err != nil {
 		return WriteTemporaryResult{}, fmt.Errorf("failed to create commit: %w", err)
 	}
 
 	// Update branch reference
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
-	newRef := plumbing.NewHashReference(refName, commitHash)
-	if err := s.repo.Storer.SetReference(newRef); err != nil {
+	refName := ShadowRefName(s.repo, shadowBranchName)
+	_, refSpan := tracing.Start(ctx, "git.update_ref", slog.String("ref", refName.String()))
+	err = backend.SetReference(refName, commitHash)
+	refSpan.EndWithError(err)
+	if err != nil {
 		return WriteTemporaryResult{}, fmt.Errorf("failed to update branch reference: %w", err)
 	}
 
@@ -147,7 +185,7 @@ func (s *GitStore) ReadTemporary(ctx context.Context, baseCommit, worktreeID str
 	_ = ctx // Reserved for future use
 
 	shadowBranchName := ShadowBranchNameForCommit(baseCommit, worktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := ShadowRefName(s.repo, shadowBranchName)
 
 	ref, err := s.repo.Reference(refName, true)
 	if err != nil {
@@ -258,28 +296,43 @@ func (s *GitStore) WriteTemporaryTask(ctx context.Context, opts WriteTemporaryTa
 	allFiles = append(allFiles, opts.ModifiedFiles...)
 	allFiles = append(allFiles, opts.NewFiles...)
 
-	// Build new tree with code changes (no metadata dir yet)
-	newTreeHash, err := s.buildTreeWithChanges(baseTreeHash, allFiles, opts.DeletedFiles, "", "")
-	if err != nil {
-		return plumbing.ZeroHash, fmt.Errorf("failed to build tree: %w", err)
-	}
+	backend := s.backendOrDefault(opts.Backend)
+	commitMessage := opts.CommitMessage
+	var newTreeHash plumbing.Hash
+	if opts.MetadataOnly {
+		transcriptPath := opts.SubagentTranscriptPath
+		if transcriptPath == "" {
+			transcriptPath = opts.TranscriptPath
+		}
+		taskMetadataDir := paths.EntireMetadataDir + "/" + opts.SessionID + "/tasks/" + opts.ToolUseID
+		newTreeHash, err = s.buildMetadataOnlyTree(backend, baseTreeHash, allFiles, opts.DeletedFiles, taskMetadataDir, transcriptPath)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to build tree: %w", err)
+		}
+		commitMessage = trailers.FormatMetadataOnly(commitMessage)
+	} else {
+		// Build new tree with code changes (no metadata dir yet)
+		newTreeHash, err = s.buildTreeWithChanges(backend, baseTreeHash, allFiles, opts.DeletedFiles, "", "", opts.ChunkThreshold, opts.SizeLimits, opts.TreeListings)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to build tree: %w", err)
+		}
 
-	// Add task metadata to tree
-	newTreeHash, err = s.addTaskMetadataToTree(newTreeHash, opts)
-	if err != nil {
-		return plumbing.ZeroHash, fmt.Errorf("failed to add task metadata: %w", err)
+		// Add task metadata to tree
+		newTreeHash, err = s.addTaskMetadataToTree(backend, newTreeHash, opts)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to add task metadata: %w", err)
+		}
 	}
 
 	// Create the commit
-	commitHash, err := s.createCommit(newTreeHash, parentHash, opts.CommitMessage, opts.AuthorName, opts.AuthorEmail)
+	commitHash, err := backend.CreateCommit(newTreeHash, parentHash, commitMessage, opts.AuthorName, opts.AuthorEmail)
 	if err != nil {
 		return plumbing.ZeroHash, fmt.Errorf("failed to create commit: %w", err)
 	}
 
 	// Update shadow branch reference
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
-	ref := plumbing.NewHashReference(refName, commitHash)
-	if err := s.repo.Storer.SetReference(ref); err != nil {
+	refName := ShadowRefName(s.repo, shadowBranchName)
+	if err := backend.SetReference(refName, commitHash); err != nil {
 		return plumbing.ZeroHash, fmt.Errorf("failed to update shadow branch reference: %w", err)
 	}
 
@@ -288,17 +341,14 @@ func (s *GitStore) WriteTemporaryTask(ctx context.Context, opts WriteTemporaryTa
 
 // addTaskMetadataToTree adds task checkpoint metadata to a git tree.
 // When IsIncremental is true, only adds the incremental checkpoint file.
-func (s *GitStore) addTaskMetadataToTree(baseTreeHash plumbing.Hash, opts WriteTemporaryTaskOptions) (plumbing.Hash, error) {
-	// Get base tree and flatten it
-	baseTree, err := s.repo.TreeObject(baseTreeHash)
-	if err != nil {
-		return plumbing.ZeroHash, fmt.Errorf("failed to get base tree: %w", err)
-	}
-
-	entries := make(map[string]object.TreeEntry)
-	if err := FlattenTree(s.repo, baseTree, "", entries); err != nil {
+func (s *GitStore) addTaskMetadataToTree(backend Backend, baseTreeHash plumbing.Hash, opts WriteTemporaryTaskOptions) (plumbing.Hash, error) {
+	// Flatten the base tree
+	base := make(map[string]object.TreeEntry)
+	if err := backend.FlattenTree(baseTreeHash, base); err != nil {
 		return plumbing.ZeroHash, fmt.Errorf("failed to flatten tree: %w", err)
 	}
+	entries := maps.Clone(base)
+	var err error
 
 	// Compute metadata paths
 	sessionMetadataDir := paths.EntireMetadataDir + "/" + opts.SessionID
@@ -414,7 +464,7 @@ func (s *GitStore) addTaskMetadataToTree(baseTreeHash plumbing.Hash, opts WriteT
 	}
 
 	// Build new tree from entries
-	return BuildTreeFromEntries(s.repo, entries)
+	return backend.BuildTree(baseTreeHash, base, entries) //nolint:wrapcheck // the backends describe their errors
 }
 
 // ListTemporaryCheckpoints lists all checkpoint commits on a shadow branch.
@@ -438,7 +488,7 @@ func (s *GitStore) ListCheckpointsForBranch(ctx context.Context, branchName, ses
 func (s *GitStore) listCheckpointsForBranch(ctx context.Context, shadowBranchName, sessionID string, limit int) ([]TemporaryCheckpointInfo, error) {
 	_ = ctx // Reserved for future use
 
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := ShadowRefName(s.repo, shadowBranchName)
 
 	ref, err := s.repo.Reference(refName, true)
 	if err != nil {
@@ -459,49 +509,48 @@ func (s *GitStore) listCheckpointsForBranch(ctx context.Context, shadowBranchNam
 		}
 		count++
 
-		// Verify commit belongs to target session via Entire-Session trailer
-		commitSessionID, hasTrailer := trailers.ParseSession(c.Message)
-		if !hasTrailer {
-			return nil // Skip commits without session trailer
-		}
-		if sessionID != "" && commitSessionID != sessionID {
-			return nil // Skip commits from other sessions
+		info, ok := temporaryCheckpointInfoFromCommit(c, sessionID)
+		if !ok {
+			return nil // No session trailer, or a different session
 		}
 
-		// Get first line of message
-		message := c.Message
-		if idx := strings.Index(message, "\n"); idx > 0 {
-			message = message[:idx]
-		}
+		results = append(results, info)
 
-		info := TemporaryCheckpointInfo{
-			CommitHash: c.Hash,
-			Message:    message,
-			SessionID:  commitSessionID,
-			Timestamp:  c.Author.When,
+		if len(results) >= limit {
+			return errStop
 		}
+		return nil
+	})
 
-		// Check for task checkpoint first
-		taskMetadataDir, foundTask := trailers.ParseTaskMetadata(c.Message)
-		if foundTask {
-			info.IsTaskCheckpoint = true
-			info.MetadataDir = taskMetadataDir
-			info.ToolUseID = extractToolUseIDFromPath(taskMetadataDir)
-		} else {
-			metadataDir, found := trailers.ParseMetadata(c.Message)
-			if found {
-				info.MetadataDir = metadataDir
-			}
-		}
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, fmt.Errorf("error iterating commits: %w", err)
+	}
 
-		results = append(results, info)
+	return results, nil
+}
 
-		if len(results) >= limit {
+// ListCheckpointsInRange lists checkpoint commits starting at from and walking back
+// through shadow branch history until (and including) until. A zero until walks to
+// the root. The sessionID filter, if provided, limits results to that session.
+// Used to drill into a checkpoint epoch without scanning the whole branch.
+func (s *GitStore) ListCheckpointsInRange(ctx context.Context, from, until plumbing.Hash, sessionID string) ([]TemporaryCheckpointInfo, error) {
+	_ = ctx // Reserved for future use
+
+	iter, err := s.repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit log: %w", err)
+	}
+
+	var results []TemporaryCheckpointInfo
+	err = iter.ForEach(func(c *object.Commit) error {
+		if info, ok := temporaryCheckpointInfoFromCommit(c, sessionID); ok {
+			results = append(results, info)
+		}
+		if c.Hash == until {
 			return errStop
 		}
 		return nil
 	})
-
 	if err != nil && !errors.Is(err, errStop) {
 		return nil, fmt.Errorf("error iterating commits: %w", err)
 	}
@@ -509,6 +558,49 @@ func (s *GitStore) listCheckpointsForBranch(ctx context.Context, shadowBranchNam
 	return results, nil
 }
 
+// temporaryCheckpointInfoFromCommit builds checkpoint info from a shadow branch commit.
+// Returns false for commits without an Entire-Session trailer or, when sessionID is
+// non-empty, commits belonging to a different session.
+func temporaryCheckpointInfoFromCommit(c *object.Commit, sessionID string) (TemporaryCheckpointInfo, bool) {
+	// Verify commit belongs to target session via Entire-Session trailer
+	commitSessionID, hasTrailer := trailers.ParseSession(c.Message)
+	if !hasTrailer {
+		return TemporaryCheckpointInfo{}, false
+	}
+	if sessionID != "" && commitSessionID != sessionID {
+		return TemporaryCheckpointInfo{}, false
+	}
+
+	// Get first line of message
+	message := c.Message
+	if idx := strings.Index(message, "\n"); idx > 0 {
+		message = message[:idx]
+	}
+
+	info := TemporaryCheckpointInfo{
+		CommitHash:   c.Hash,
+		Message:      message,
+		SessionID:    commitSessionID,
+		MetadataOnly: trailers.IsMetadataOnly(c.Message),
+		Timestamp:    c.Author.When,
+	}
+
+	// Check for task checkpoint first
+	taskMetadataDir, foundTask := trailers.ParseTaskMetadata(c.Message)
+	if foundTask {
+		info.IsTaskCheckpoint = true
+		info.MetadataDir = taskMetadataDir
+		info.ToolUseID = extractToolUseIDFromPath(taskMetadataDir)
+	} else {
+		metadataDir, found := trailers.ParseMetadata(c.Message)
+		if found {
+			info.MetadataDir = metadataDir
+		}
+	}
+
+	return info, true
+}
+
 // ListAllTemporaryCheckpoints lists checkpoint commits from ALL shadow branches.
 // This is used for checkpoint lookup when the base commit is unknown (e.g., HEAD advanced since session start).
 // The sessionID filter, if provided, limits results to commits from that session.
@@ -604,7 +696,7 @@ func (s *GitStore) GetTranscriptFromCommit(commitHash plumbing.Hash, metadataDir
 // worktreeID should be empty for main worktree or the internal git worktree name for linked worktrees.
 func (s *GitStore) ShadowBranchExists(baseCommit, worktreeID string) bool {
 	shadowBranchName := ShadowBranchNameForCommit(baseCommit, worktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := ShadowRefName(s.repo, shadowBranchName)
 	_, err := s.repo.Reference(refName, true)
 	return err == nil
 }
@@ -635,6 +727,12 @@ func ShadowBranchNameForCommit(baseCommit, worktreeID string) string {
 	return ShadowBranchPrefix + commitPart + "-" + worktreeHash
 }
 
+// ShadowRefName returns the reference shadowBranch is stored under in repo:
+// a branch, or a hidden ref in colocated jj repositories (see vcs.Backend).
+func ShadowRefName(repo *git.Repository, shadowBranch string) plumbing.ReferenceName {
+	return vcs.Detect(repo).ShadowRef(shadowBranch)
+}
+
 // ParseShadowBranchName extracts the commit prefix and worktree hash from a shadow branch name.
 // Input format: "entire/<commit[:7]>-<worktreeHash[:6]>"
 // Returns (commitPrefix, worktreeHash, ok). Returns ("", "", false) if not a valid shadow branch.
@@ -658,7 +756,7 @@ func ParseShadowBranchName(branchName string) (commitPrefix, worktreeHash string
 // getOrCreateShadowBranch gets or creates the shadow branch for checkpoints.
 // Returns (parentHash, baseTreeHash, error).
 func (s *GitStore) getOrCreateShadowBranch(branchName string) (plumbing.Hash, plumbing.Hash, error) {
-	refName := plumbing.NewBranchReferenceName(branchName)
+	refName := ShadowRefName(s.repo, branchName)
 	ref, err := s.repo.Reference(refName, true)
 
 	if err == nil {
@@ -687,10 +785,15 @@ func (s *GitStore) getOrCreateShadowBranch(branchName string) (plumbing.Hash, pl
 // buildTreeWithChanges builds a git tree with the given changes.
 // metadataDir is the relative path for git tree entries, metadataDirAbs is the absolute path
 // for filesystem operations (needed when CLI is run from a subdirectory).
+// Text files of at least chunkThreshold bytes are stored chunked (0 = never).
 func (s *GitStore) buildTreeWithChanges(
+	backend Backend,
 	baseTreeHash plumbing.Hash,
 	modifiedFiles, deletedFiles []string,
 	metadataDir, metadataDirAbs string,
+	chunkThreshold int64,
+	limits SizeLimits,
+	listings *TreeListingCache,
 ) (plumbing.Hash, error) {
 	// Get repo root for resolving file paths
 	// This is critical because fileExists() and createBlobFromFile() use os.Stat()
@@ -701,17 +804,35 @@ func (s *GitStore) buildTreeWithChanges(
 		return plumbing.ZeroHash, fmt.Errorf("failed to get repo root: %w", err)
 	}
 
-	// Get the base tree
-	baseTree, err := s.repo.TreeObject(baseTreeHash)
-	if err != nil {
-		return plumbing.ZeroHash, fmt.Errorf("failed to get base tree: %w", err)
-	}
-
 	// Flatten existing tree
-	entries := make(map[string]object.TreeEntry)
-	if err := FlattenTree(s.repo, baseTree, "", entries); err != nil {
+	base := make(map[string]object.TreeEntry)
+	if err := flattenTreeCached(backend, baseTreeHash, listings, base); err != nil {
 		return plumbing.ZeroHash, fmt.Errorf("failed to flatten base tree: %w", err)
 	}
+	entries := maps.Clone(base)
+
+	// Files over the size limits keep their previous contents; the record of
+	// skipped files is updated for the files this checkpoint changes
+	lfs := loadLFSTracking(repoRoot)
+	newlySkipped := checkSizeLimits(repoRoot, modifiedFiles, entries, limits, lfs)
+	skipped := readSkippedEntries(backend, entries)
+
+	// Drop the chunks of every file about to be deleted or rewritten
+	rewritten := make(map[string]bool, len(modifiedFiles)+len(deletedFiles))
+	for _, file := range modifiedFiles {
+		rewritten[file] = true
+	}
+	for _, file := range deletedFiles {
+		rewritten[file] = true
+	}
+	for file := range rewritten {
+		delete(skipped, file)
+	}
+	for file, skip := range newlySkipped {
+		delete(rewritten, file)
+		skipped[file] = skip
+	}
+	removeChunks(entries, rewritten)
 
 	// Remove deleted files
 	for _, file := range deletedFiles {
@@ -720,13 +841,41 @@ func (s *GitStore) buildTreeWithChanges(
 
 	// Add/update modified files
 	for _, file := range modifiedFiles {
+		if _, ok := newlySkipped[file]; ok {
+			continue
+		}
 		// Resolve path relative to repo root for filesystem operations
 		absPath := filepath.Join(repoRoot, file)
+		if entry, ok := entries[file]; ok && entry.Mode == filemode.Submodule {
+			if head, ok := SubmoduleHead(absPath); ok {
+				entry.Hash = head
+				entries[file] = entry
+			}
+			continue
+		}
 		if !fileExists(absPath) {
 			delete(entries, file)
 			continue
 		}
 
+		if lfs.tracks(file) {
+			if err := lfs.addLFSPointer(s.repo, file, absPath, entries); err != nil {
+				return plumbing.ZeroHash, err
+			}
+			continue
+		}
+
+		if content, ok := shouldChunk(absPath, chunkThreshold); ok {
+			mode := filemode.Regular
+			if info, err := os.Stat(absPath); err == nil && info.Mode()&0o111 != 0 {
+				mode = filemode.Executable
+			}
+			if err := addChunkedFile(s.repo, file, content, mode, entries); err != nil {
+				return plumbing.ZeroHash, err
+			}
+			continue
+		}
+
 		blobHash, mode, err := createBlobFromFile(s.repo, absPath)
 		if err != nil {
 			// Skip files that can't be staged (may have been deleted since detection)
@@ -746,43 +895,98 @@ func (s *GitStore) buildTreeWithChanges(
 			return plumbing.ZeroHash, fmt.Errorf("failed to add metadata directory: %w", err)
 		}
 	}
+	if err := writeSkippedEntries(s.repo, entries, skipped); err != nil {
+		return plumbing.ZeroHash, err
+	}
 
 	// Build tree
-	return BuildTreeFromEntries(s.repo, entries)
+	return backend.BuildTree(baseTreeHash, base, entries) //nolint:wrapcheck // the backends describe their errors
 }
 
-// createCommit creates a commit object.
-func (s *GitStore) createCommit(treeHash, parentHash plumbing.Hash, message, authorName, authorEmail string) (plumbing.Hash, error) {
-	now := time.Now()
-	sig := object.Signature{
-		Name:  authorName,
-		Email: authorEmail,
-		When:  now,
+// buildMetadataOnlyTree returns the tree of baseTreeHash with a manifest of
+// the changed files' and the transcript's hashes and sizes in metadataDir.
+// Nothing but the manifest is written to the object store, so the tree still
+// holds the previous contents of the changed files.
+func (s *GitStore) buildMetadataOnlyTree(backend Backend, baseTreeHash plumbing.Hash, changedFiles, deletedFiles []string, metadataDir, transcriptPath string) (plumbing.Hash, error) {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get repo root: %w", err)
 	}
 
-	commit := &object.Commit{
-		TreeHash:  treeHash,
-		Author:    sig,
-		Committer: sig,
-		Message:   message,
+	manifest := MetadataOnlyManifest{Files: []MetadataOnlyFile{}}
+	for _, file := range changedFiles {
+		f, ok := metadataOnlyFile(filepath.Join(repoRoot, file))
+		if !ok {
+			f.Deleted = true
+		}
+		f.Path = file
+		manifest.Files = append(manifest.Files, f)
 	}
-
-	// Add parent if not a new branch
-	if parentHash != plumbing.ZeroHash {
-		commit.ParentHashes = []plumbing.Hash{parentHash}
+	for _, file := range deletedFiles {
+		manifest.Files = append(manifest.Files, MetadataOnlyFile{Path: file, Deleted: true})
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+	if transcriptPath != "" {
+		if content, readErr := encryption.ReadFile(transcriptPath); readErr == nil {
+			f := metadataOnlyContent(content)
+			f.Path = filepath.Base(transcriptPath)
+			manifest.Transcript = &f
+		}
+	}
+	manifestJSON, err := jsonutil.MarshalIndentWithNewline(manifest, "", "  ")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to marshal manifest: %w", err)
 	}
 
-	obj := s.repo.Storer.NewEncodedObject()
-	if err := commit.Encode(obj); err != nil {
-		return plumbing.ZeroHash, fmt.Errorf("failed to encode commit: %w", err)
+	base := make(map[string]object.TreeEntry)
+	if err := backend.FlattenTree(baseTreeHash, base); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to flatten base tree: %w", err)
+	}
+	entries := maps.Clone(base)
+	blobHash, err := CreateBlobFromContent(s.repo, manifestJSON)
+	if err != nil {
+		return plumbing.ZeroHash, err
 	}
+	manifestPath := metadataDir + "/" + MetadataOnlyManifestFileName
+	entries[manifestPath] = object.TreeEntry{
+		Name: manifestPath,
+		Mode: filemode.Regular,
+		Hash: blobHash,
+	}
+	return backend.BuildTree(baseTreeHash, base, entries) //nolint:wrapcheck // the backends describe their errors
+}
 
-	hash, err := s.repo.Storer.SetEncodedObject(obj)
+// metadataOnlyFile returns the git blob hash and size of a file without
+// writing it to the object store. False if the file can't be read.
+func metadataOnlyFile(path string) (MetadataOnlyFile, bool) {
+	content, err := os.ReadFile(path) //nolint:gosec // path from checkpoint options
 	if err != nil {
-		return plumbing.ZeroHash, fmt.Errorf("failed to store commit: %w", err)
+		return MetadataOnlyFile{}, false
 	}
+	return metadataOnlyContent(content), true
+}
 
-	return hash, nil
+// metadataOnlyContent returns the git blob hash and size of content.
+func metadataOnlyContent(content []byte) MetadataOnlyFile {
+	return MetadataOnlyFile{
+		Hash: plumbing.ComputeHash(plumbing.BlobObject, content).String(),
+		Size: int64(len(content)),
+	}
+}
+
+// createCommit creates a commit object.
+func (s *GitStore) createCommit(treeHash, parentHash plumbing.Hash, message, authorName, authorEmail string) (plumbing.Hash, error) {
+	return NewGoGitBackend(s.repo).CreateCommit(treeHash, parentHash, message, authorName, authorEmail)
+}
+
+// backendOrDefault returns backend, or the go-git backend if it is nil.
+func (s *GitStore) backendOrDefault(backend Backend) Backend {
+	if backend == nil {
+		return NewGoGitBackend(s.repo)
+	}
+	return backend
 }
 
 // Helper functions extracted from strategy/common.go
@@ -816,6 +1020,15 @@ func FlattenTree(repo *git.Repository, tree *object.Tree, prefix string, entries
 	return nil
 }
 
+// loadEntireIgnore loads the repository's .entireignore.
+func loadEntireIgnore() (*entireignore.Matcher, error) {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repo root: %w", err)
+	}
+	return entireignore.Load(repoRoot) //nolint:wrapcheck // already names the file
+}
+
 // fileExists checks if a file exists at the given path.
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -871,6 +1084,20 @@ func createBlobFromFile(repo *git.Repository, filePath string) (plumbing.Hash, f
 	return hash, mode, nil
 }
 
+// createMetadataBlobFromFile creates a blob object from a session metadata file,
+// decrypting it if it is encrypted at rest. Checkpoints are always plaintext.
+func createMetadataBlobFromFile(repo *git.Repository, filePath string) (plumbing.Hash, filemode.FileMode, error) {
+	content, err := encryption.ReadFile(filePath)
+	if err != nil {
+		return plumbing.ZeroHash, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	hash, err := CreateBlobFromContent(repo, content)
+	if err != nil {
+		return plumbing.ZeroHash, 0, err
+	}
+	return hash, filemode.Regular, nil
+}
+
 // addDirectoryToEntriesWithAbsPath recursively adds all files in a directory to the entries map.
 func addDirectoryToEntriesWithAbsPath(repo *git.Repository, dirPathAbs, dirPathRel string, entries map[string]object.TreeEntry) error {
 	err := filepath.Walk(dirPathAbs, func(path string, info os.FileInfo, err error) error {
@@ -887,12 +1114,12 @@ func addDirectoryToEntriesWithAbsPath(repo *git.Repository, dirPathAbs, dirPathR
 			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
 		}
 
-		blobHash, mode, err := createBlobFromFile(repo, path)
+		blobHash, mode, err := createMetadataBlobFromFile(repo, path)
 		if err != nil {
 			return fmt.Errorf("failed to create blob for %s: %w", path, err)
 		}
 
-		treePath := filepath.Join(dirPathRel, relWithinDir)
+		treePath := filepath.ToSlash(filepath.Join(dirPathRel, relWithinDir))
 		entries[treePath] = object.TreeEntry{
 			Name: treePath,
 			Mode: mode,
@@ -0,0 +1,177 @@
+package checkpoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// TreeListingCacheDirName is the state directory flattened tree listings are
+// cached in (see fsenv.StateDir).
+const TreeListingCacheDirName = "entire-tree-cache"
+
+// treeListingHeader starts every cached listing, followed by the tree hash.
+const treeListingHeader = "entire-tree-listing v1 "
+
+// maxCachedTreeListings bounds the cache; older listings are pruned on Store.
+const maxCachedTreeListings = 8
+
+// TreeListingCache keeps flattened tree listings on disk, keyed by tree hash,
+// so the first checkpoint of a session doesn't have to read every tree object
+// of a large repository. Trees are immutable, so a listing never goes stale.
+// The session-start warm-up fills it in the background.
+type TreeListingCache struct {
+	dir string
+}
+
+// NewTreeListingCache creates a cache in dir. The directory is created on
+// the first Store.
+func NewTreeListingCache(dir string) *TreeListingCache {
+	return &TreeListingCache{dir: dir}
+}
+
+func (c *TreeListingCache) path(treeHash plumbing.Hash) string {
+	return filepath.Join(c.dir, treeHash.String())
+}
+
+// Has reports whether the listing of treeHash is cached.
+func (c *TreeListingCache) Has(treeHash plumbing.Hash) bool {
+	if c == nil {
+		return false
+	}
+	_, err := os.Stat(c.path(treeHash))
+	return err == nil
+}
+
+// Load returns the cached listing of treeHash, or false if it isn't cached or
+// can't be read.
+func (c *TreeListingCache) Load(treeHash plumbing.Hash) (map[string]object.TreeEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(treeHash))
+	if err != nil {
+		return nil, false
+	}
+	entries, err := parseTreeListing(data, treeHash)
+	if err != nil {
+		return nil, false
+	}
+	return entries, true
+}
+
+// Store caches the listing of treeHash, as produced by FlattenTree.
+func (c *TreeListingCache) Store(treeHash plumbing.Hash, entries map[string]object.TreeEntry) error {
+	if err := os.MkdirAll(c.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create tree listing cache: %w", err)
+	}
+	// Write to a temp file and rename, so concurrent readers never see a partial listing
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write tree listing: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(formatTreeListing(treeHash, entries)); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write tree listing: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write tree listing: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(treeHash)); err != nil {
+		return fmt.Errorf("failed to write tree listing: %w", err)
+	}
+	c.prune()
+	return nil
+}
+
+// prune removes all but the most recently written listings.
+func (c *TreeListingCache) prune() {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type listing struct {
+		name    string
+		modTime int64
+	}
+	var listings []listing
+	for _, e := range dirEntries {
+		if !plumbing.IsHash(e.Name()) {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			listings = append(listings, listing{e.Name(), info.ModTime().UnixNano()})
+		}
+	}
+	if len(listings) <= maxCachedTreeListings {
+		return
+	}
+	sort.Slice(listings, func(i, j int) bool { return listings[i].modTime > listings[j].modTime })
+	for _, l := range listings[maxCachedTreeListings:] {
+		_ = os.Remove(filepath.Join(c.dir, l.name))
+	}
+}
+
+// flattenTreeCached flattens the tree treeHash into entries with backend,
+// using the cached listing when there is one.
+func flattenTreeCached(backend Backend, treeHash plumbing.Hash, listings *TreeListingCache, entries map[string]object.TreeEntry) error {
+	if cached, ok := listings.Load(treeHash); ok {
+		for name, entry := range cached {
+			entries[name] = entry
+		}
+		return nil
+	}
+	return backend.FlattenTree(treeHash, entries) //nolint:wrapcheck // the backends describe their errors
+}
+
+// formatTreeListing encodes a listing as a header line followed by
+// NUL-terminated "<mode> <hash> <path>" records, sorted by path.
+func formatTreeListing(treeHash plumbing.Hash, entries map[string]object.TreeEntry) []byte {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString(treeListingHeader + treeHash.String() + "\n")
+	for _, name := range names {
+		entry := entries[name]
+		fmt.Fprintf(&b, "%o %s %s\x00", uint32(entry.Mode), entry.Hash, name)
+	}
+	return b.Bytes()
+}
+
+func parseTreeListing(data []byte, treeHash plumbing.Hash) (map[string]object.TreeEntry, error) {
+	header, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok || string(header) != treeListingHeader+treeHash.String() {
+		return nil, errors.New("tree listing header doesn't match")
+	}
+	entries := make(map[string]object.TreeEntry)
+	for _, record := range strings.Split(string(body), "\x00") {
+		if record == "" {
+			continue
+		}
+		modeStr, rest, ok1 := strings.Cut(record, " ")
+		hashStr, name, ok2 := strings.Cut(rest, " ")
+		if !ok1 || !ok2 || !plumbing.IsHash(hashStr) {
+			return nil, fmt.Errorf("malformed tree listing record %q", record)
+		}
+		mode, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed tree listing mode %q", modeStr)
+		}
+		entries[name] = object.TreeEntry{Name: name, Mode: filemode.FileMode(mode), Hash: plumbing.NewHash(hashStr)}
+	}
+	return entries, nil
+}
@@ -0,0 +1,136 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestTreeListingCache_RoundTripAndPrune(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTreeListingCache(filepath.Join(t.TempDir(), TreeListingCacheDirName))
+	treeHash := plumbing.NewHash("1111111111111111111111111111111111111111")
+	entries := map[string]object.TreeEntry{
+		"README.md":        {Name: "README.md", Mode: filemode.Regular, Hash: plumbing.NewHash("2222222222222222222222222222222222222222")},
+		"bin/run script":   {Name: "bin/run script", Mode: filemode.Executable, Hash: plumbing.NewHash("3333333333333333333333333333333333333333")},
+		"link-to-readme":   {Name: "link-to-readme", Mode: filemode.Symlink, Hash: plumbing.NewHash("4444444444444444444444444444444444444444")},
+		"vendor/submodule": {Name: "vendor/submodule", Mode: filemode.Submodule, Hash: plumbing.NewHash("5555555555555555555555555555555555555555")},
+	}
+
+	if _, ok := cache.Load(treeHash); ok || cache.Has(treeHash) {
+		t.Fatal("empty cache reported a listing")
+	}
+	if err := cache.Store(treeHash, entries); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	got, ok := cache.Load(treeHash)
+	if !ok || len(got) != len(entries) {
+		t.Fatalf("Load() = %d entries, %v; want %d", len(got), ok, len(entries))
+	}
+	for name, want := range entries {
+		if got[name] != want {
+			t.Errorf("Load()[%q] = %+v, want %+v", name, got[name], want)
+		}
+	}
+
+	// A listing stored under another tree's name is ignored
+	other := plumbing.NewHash("6666666666666666666666666666666666666666")
+	if err := os.Rename(cache.path(treeHash), cache.path(other)); err != nil {
+		t.Fatalf("failed to rename listing: %v", err)
+	}
+	if _, ok := cache.Load(other); ok {
+		t.Error("Load() accepted a listing for a different tree")
+	}
+
+	for i := range maxCachedTreeListings + 3 {
+		hash := plumbing.ComputeHash(plumbing.TreeObject, []byte{byte(i)})
+		if err := cache.Store(hash, entries); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+	dirEntries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		t.Fatalf("failed to read cache dir: %v", err)
+	}
+	if len(dirEntries) != maxCachedTreeListings {
+		t.Errorf("cache holds %d listings, want %d", len(dirEntries), maxCachedTreeListings)
+	}
+}
+
+func TestWriteTemporary_UsesCachedBaseTreeListing(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# Test"), 0o644); err != nil {
+		t.Fatalf("failed to write README: %v", err)
+	}
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatalf("failed to add README: %v", err)
+	}
+	initialCommit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	t.Chdir(tempDir)
+	paths.ClearRepoRootCache()
+
+	commit, err := repo.CommitObject(initialCommit)
+	if err != nil {
+		t.Fatalf("failed to read commit: %v", err)
+	}
+	readme, err := commit.File("README.md")
+	if err != nil {
+		t.Fatalf("failed to read README: %v", err)
+	}
+	// The cached listing has an entry the real tree doesn't, so a checkpoint
+	// containing it was built from the cache
+	cache := NewTreeListingCache(t.TempDir())
+	if err := cache.Store(commit.TreeHash, map[string]object.TreeEntry{
+		"README.md":  {Name: "README.md", Mode: filemode.Regular, Hash: readme.Hash},
+		"from-cache": {Name: "from-cache", Mode: filemode.Regular, Hash: readme.Hash},
+	}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	result, err := NewGitStore(repo).WriteTemporary(context.Background(), WriteTemporaryOptions{
+		SessionID:     "test-session",
+		BaseCommit:    initialCommit.String(),
+		ModifiedFiles: []string{"new.txt"},
+		CommitMessage: "Checkpoint",
+		AuthorName:    "Test",
+		AuthorEmail:   "test@test.com",
+		TreeListings:  cache,
+	})
+	if err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	checkpointCommit, err := repo.CommitObject(result.CommitHash)
+	if err != nil {
+		t.Fatalf("failed to read checkpoint commit: %v", err)
+	}
+	for _, name := range []string{"README.md", "new.txt", "from-cache"} {
+		if _, err := checkpointCommit.File(name); err != nil {
+			t.Errorf("%s missing from checkpoint: %v", name, err)
+		}
+	}
+}
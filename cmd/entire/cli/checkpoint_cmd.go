@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newCheckpointCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Manage checkpoints",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newCheckpointListCmd())
+	cmd.AddCommand(newCheckpointDiffCmd())
+	cmd.AddCommand(newCheckpointRestoreCmd())
+	cmd.AddCommand(newCheckpointPruneCmd())
+	cmd.AddCommand(newCheckpointRecoverCmd())
+
+	return cmd
+}
+
+func newCheckpointPruneCmd() *cobra.Command {
+	var olderThanFlag string
+	var keepFlag int
+	var mergedFlag string
+	var includeCommittedFlag bool
+	var dryRunFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old checkpoints according to retention policies",
+		Long: `Deletes shadow branches (temporary checkpoints) that match the given
+retention policies. When several policies are given, an item is pruned only if it
+matches all of them.
+
+Policies:
+  --older-than <age>        Latest checkpoint is older than <age> (e.g. 30d, 2w, 12h)
+  --keep-per-session <n>    Keep the n most recent items for each session
+  --merged <branch>         Work is already part of <branch>: the shadow branch's
+                            base commit is behind the branch tip, or a commit on the
+                            branch references the checkpoint
+
+Without policy flags, the retention setting is used (see 'entire config').
+
+Shadow branches of sessions that are currently active are never pruned.
+
+Committed checkpoints on the entire/checkpoints/v1 branch are permanent history
+linked from commit trailers, and are only pruned with --include-committed.
+
+Session state left without checkpoints can be removed afterwards with 'entire clean'.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+
+			if olderThanFlag == "" && keepFlag == 0 && mergedFlag == "" {
+				s, err := LoadEntireSettings()
+				if err != nil {
+					return err
+				}
+				if s.Retention.IsSet() {
+					olderThanFlag = s.Retention.OlderThan
+					keepFlag = s.Retention.KeepPerSession
+					mergedFlag = s.Retention.Merged
+				}
+			}
+
+			opts := strategy.PruneOptions{
+				KeepPerSession:   keepFlag,
+				MergedInto:       mergedFlag,
+				IncludeCommitted: includeCommittedFlag,
+			}
+			if olderThanFlag != "" {
+				d, err := parseRetentionDuration(olderThanFlag)
+				if err != nil {
+					return err
+				}
+				opts.OlderThan = d
+			}
+			if keepFlag < 0 {
+				return errors.New("--keep-per-session must not be negative")
+			}
+
+			return runCheckpointPrune(cmd.OutOrStdout(), opts, dryRunFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThanFlag, "older-than", "", "Prune items older than this age (e.g. 30d, 2w, 12h)")
+	cmd.Flags().IntVar(&keepFlag, "keep-per-session", 0, "Keep this many most recent items per session")
+	cmd.Flags().StringVar(&mergedFlag, "merged", "", "Only prune items whose work is merged into this branch")
+	cmd.Flags().BoolVar(&includeCommittedFlag, "include-committed", false, "Also prune committed checkpoints on entire/checkpoints/v1")
+	cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would be pruned without deleting anything")
+
+	return cmd
+}
+
+func runCheckpointPrune(w io.Writer, opts strategy.PruneOptions, dryRun bool) error {
+	// Initialize logging so structured logs go to .entire/logs/ instead of stderr.
+	// Error is non-fatal: if logging init fails, logs go to stderr (acceptable fallback).
+	defer initLogging("")()
+
+	items, err := strategy.ListPruneCandidates(opts)
+	if errors.Is(err, strategy.ErrNoPrunePolicy) {
+		return errors.New("specify at least one of --older-than, --keep-per-session or --merged, or set retention in settings")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list prune candidates: %w", err)
+	}
+
+	return runCheckpointPruneWithItems(w, items, dryRun)
+}
+
+// runCheckpointPruneWithItems prints or deletes the selected items.
+// Separated for testability.
+func runCheckpointPruneWithItems(w io.Writer, items []strategy.CleanupItem, dryRun bool) error {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "Nothing to prune.")
+		return nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(w, "Would prune %d items:\n\n", len(items))
+		printPruneItems(w, items)
+		return nil
+	}
+
+	result, err := strategy.DeleteAllCleanupItems(items)
+	if err != nil {
+		return fmt.Errorf("failed to prune checkpoints: %w", err)
+	}
+
+	deleted := len(result.ShadowBranches) + len(result.Checkpoints)
+	failed := len(result.FailedBranches) + len(result.FailedCheckpoints)
+
+	fmt.Fprintf(w, "Pruned %d items", deleted)
+	if len(result.ShadowBranches) > 0 || len(result.Checkpoints) > 0 {
+		fmt.Fprintf(w, " (%d shadow branches, %d checkpoints)", len(result.ShadowBranches), len(result.Checkpoints))
+	}
+	fmt.Fprintln(w, ".")
+
+	if failed > 0 {
+		fmt.Fprintf(w, "\nFailed to prune %d items:\n", failed)
+		for _, branch := range result.FailedBranches {
+			fmt.Fprintf(w, "  %s\n", branch)
+		}
+		for _, cp := range result.FailedCheckpoints {
+			fmt.Fprintf(w, "  %s\n", cp)
+		}
+		return fmt.Errorf("failed to prune %d items", failed)
+	}
+
+	return nil
+}
+
+func printPruneItems(w io.Writer, items []strategy.CleanupItem) {
+	var branches, checkpoints []strategy.CleanupItem
+	for _, item := range items {
+		switch item.Type {
+		case strategy.CleanupTypeShadowBranch:
+			branches = append(branches, item)
+		case strategy.CleanupTypeCheckpoint:
+			checkpoints = append(checkpoints, item)
+		case strategy.CleanupTypeSessionState:
+			// Not produced by prune
+		}
+	}
+
+	if len(branches) > 0 {
+		fmt.Fprintf(w, "Shadow branches (%d):\n", len(branches))
+		for _, item := range branches {
+			fmt.Fprintf(w, "  %s  (%s)\n", item.ID, item.Reason)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(checkpoints) > 0 {
+		fmt.Fprintf(w, "Committed checkpoints (%d):\n", len(checkpoints))
+		for _, item := range checkpoints {
+			fmt.Fprintf(w, "  %s  (%s)\n", item.ID, item.Reason)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// parseRetentionDuration parses an age such as "30d", "2w" or any Go duration ("12h").
+func parseRetentionDuration(s string) (time.Duration, error) {
+	return settings.ParseAge(s) //nolint:wrapcheck // already describes the age
+}
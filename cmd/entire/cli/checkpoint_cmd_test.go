@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestParseRetentionDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"abc", 0, true},
+		{"d", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRetentionDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRetentionDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRetentionDuration(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRunCheckpointPruneWithItems_DryRun(t *testing.T) {
+	t.Parallel()
+
+	items := []strategy.CleanupItem{
+		{Type: strategy.CleanupTypeShadowBranch, ID: "entire/abc1234-e3b0c4", Reason: "older than 30d"},
+		{Type: strategy.CleanupTypeCheckpoint, ID: "a1b2c3d4e5f6", Reason: "merged into main"},
+	}
+
+	var buf bytes.Buffer
+	if err := runCheckpointPruneWithItems(&buf, items, true); err != nil {
+		t.Fatalf("runCheckpointPruneWithItems() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Would prune 2 items",
+		"entire/abc1234-e3b0c4  (older than 30d)",
+		"Committed checkpoints (1):",
+		"a1b2c3d4e5f6  (merged into main)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunCheckpointPruneWithItems_Empty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := runCheckpointPruneWithItems(&buf, nil, false); err != nil {
+		t.Fatalf("runCheckpointPruneWithItems() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Nothing to prune") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
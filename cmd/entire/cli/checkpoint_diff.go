@@ -0,0 +1,498 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/binary"
+	utildiff "github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/spf13/cobra"
+)
+
+// diffWorktree is the `checkpoint diff` argument meaning the working tree.
+const diffWorktree = "worktree"
+
+// diffContextLines is how many unchanged lines surround each hunk.
+const diffContextLines = 3
+
+// Output modes of `entire checkpoint diff`.
+const (
+	diffModePatch    = "patch"
+	diffModeStat     = "stat"
+	diffModeNameOnly = "name-only"
+)
+
+func newCheckpointDiffCmd() *cobra.Command {
+	var statFlag, nameOnlyFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <checkpoint> [<checkpoint>|worktree]",
+		Short: "Show the code changes between checkpoints",
+		Long: `Shows a unified diff of the code between two checkpoints, or between a
+checkpoint and the working tree.
+
+Checkpoints are committed checkpoint IDs (as shown by 'entire explain') or
+temporary checkpoint commit hashes (as shown by 'entire rewind --list'), and
+unique prefixes of either. With a single checkpoint, shows what that
+checkpoint changed: the diff from the checkpoint before it, or from the
+commit it was based on.
+
+The working tree includes untracked files that aren't ignored. Session
+metadata stored alongside temporary checkpoints is left out.`,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeDiffCheckpoints,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			to := ""
+			if len(args) == 2 {
+				to = args[1]
+			}
+			mode := diffModePatch
+			switch {
+			case statFlag:
+				mode = diffModeStat
+			case nameOnlyFlag:
+				mode = diffModeNameOnly
+			}
+			return runCheckpointDiff(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], to, mode)
+		},
+	}
+
+	cmd.Flags().BoolVar(&statFlag, "stat", false, "Show a summary of changed files instead of the diff")
+	cmd.Flags().BoolVar(&nameOnlyFlag, "name-only", false, "Show only the names of changed files")
+	cmd.MarkFlagsMutuallyExclusive("stat", "name-only")
+
+	return cmd
+}
+
+func runCheckpointDiff(ctx context.Context, w, errW io.Writer, fromArg, toArg, mode string) error {
+	if fromArg == diffWorktree {
+		return errors.New("the first argument must be a checkpoint; put worktree second")
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+
+	fromCommit, fromLabel, err := resolveDiffCheckpoint(ctx, repo, store, fromArg)
+	if err != nil {
+		return err
+	}
+
+	var from, to diffSnapshot
+	toLabel := ""
+	switch toArg {
+	case "":
+		// What the checkpoint itself changed
+		toLabel, fromLabel = fromLabel, "its parent"
+		to, err = treeSnapshot(fromCommit)
+		if err != nil {
+			return err
+		}
+		var parent *object.Commit
+		parent, err = diffCheckpointParent(repo, fromCommit)
+		if err == nil && parent != nil {
+			from, err = treeSnapshot(parent)
+		}
+	case diffWorktree:
+		toLabel = "the working tree"
+		from, err = treeSnapshot(fromCommit)
+		if err == nil {
+			to, err = worktreeSnapshot(ctx)
+		}
+	default:
+		var toCommit *object.Commit
+		toCommit, toLabel, err = resolveDiffCheckpoint(ctx, repo, store, toArg)
+		if err != nil {
+			return err
+		}
+		from, err = treeSnapshot(fromCommit)
+		if err == nil {
+			to, err = treeSnapshot(toCommit)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	patches, err := diffSnapshots(from, to)
+	if err != nil {
+		return err
+	}
+	if len(patches) == 0 {
+		// An empty diff on stdout is easy to mistake for a failure
+		fmt.Fprintf(errW, "No changes between %s and %s.\n", fromLabel, toLabel)
+		return nil
+	}
+	return writeCheckpointDiff(w, patches, mode)
+}
+
+// resolveDiffCheckpoint finds the commit holding the code of a committed
+// checkpoint (the commit that links to it) or of a temporary checkpoint (its
+// shadow branch commit). Returns the commit and a label for messages.
+func resolveDiffCheckpoint(ctx context.Context, repo *git.Repository, store *checkpoint.GitStore, prefix string) (*object.Commit, string, error) {
+	committed, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	var matches []id.CheckpointID
+	for _, info := range committed {
+		if strings.HasPrefix(info.CheckpointID.String(), prefix) {
+			matches = append(matches, info.CheckpointID)
+		}
+	}
+	switch {
+	case len(matches) > 1:
+		return nil, "", fmt.Errorf("ambiguous checkpoint prefix %q matches %d checkpoints", prefix, len(matches))
+	case len(matches) == 1:
+		commits, err := getAssociatedCommits(repo, matches[0], true)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(commits) == 0 {
+			return nil, "", fmt.Errorf("checkpoint %s isn't linked from any commit reachable from HEAD", matches[0])
+		}
+		commit, err := repo.CommitObject(plumbing.NewHash(commits[0].SHA))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read commit %s: %w", commits[0].ShortSHA, err)
+		}
+		return commit, "checkpoint " + matches[0].String(), nil
+	}
+
+	temporary, err := store.ListAllTemporaryCheckpoints(ctx, "", branchCheckpointsLimit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list temporary checkpoints: %w", err)
+	}
+	var tempMatches []checkpoint.TemporaryCheckpointInfo
+	for _, tc := range temporary {
+		if strings.HasPrefix(tc.CommitHash.String(), prefix) {
+			tempMatches = append(tempMatches, tc)
+		}
+	}
+	switch len(tempMatches) {
+	case 0:
+		return nil, "", fmt.Errorf("checkpoint not found: %s", prefix)
+	case 1:
+		commit, err := repo.CommitObject(tempMatches[0].CommitHash)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read checkpoint %s: %w", prefix, err)
+		}
+		return commit, "temporary checkpoint " + tempMatches[0].CommitHash.String()[:7], nil
+	default:
+		return nil, "", fmt.Errorf("ambiguous checkpoint prefix %q matches %d temporary checkpoints", prefix, len(tempMatches))
+	}
+}
+
+// diffCheckpointParent returns the commit a checkpoint's changes are shown
+// against: its first parent, or for the first checkpoint of a shadow branch
+// the base commit of its session. Nil means an empty tree.
+func diffCheckpointParent(repo *git.Repository, commit *object.Commit) (*object.Commit, error) {
+	parentHash := plumbing.ZeroHash
+	if len(commit.ParentHashes) > 0 {
+		parentHash = commit.ParentHashes[0]
+	} else if sessionID, ok := trailers.ParseSession(commit.Message); ok {
+		if state, err := strategy.LoadSessionState(sessionID); err == nil && state != nil && state.BaseCommit != "" {
+			parentHash = plumbing.NewHash(state.BaseCommit)
+		}
+	}
+	if parentHash == plumbing.ZeroHash {
+		return nil, nil
+	}
+	parent, err := repo.CommitObject(parentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parent commit %s: %w", parentHash.String()[:7], err)
+	}
+	return parent, nil
+}
+
+// diffSnapshot is the code of one side of a checkpoint diff, by path.
+type diffSnapshot map[string]diffSnapshotFile
+
+type diffSnapshotFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	read func() (string, error)
+}
+
+// isDiffExcluded reports whether a tree path is Entire's own data rather
+// than code: session metadata and the chunks of chunked files.
+func isDiffExcluded(name string) bool {
+	return strings.HasPrefix(name, paths.EntireMetadataDir+"/") || strings.HasPrefix(name, paths.EntireChunksDir+"/")
+}
+
+func treeSnapshot(commit *object.Commit) (diffSnapshot, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", commit.Hash.String()[:7], err)
+	}
+	snapshot := diffSnapshot{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if isDiffExcluded(f.Name) {
+			return nil
+		}
+		snapshot[f.Name] = diffSnapshotFile{
+			hash: f.Hash,
+			mode: f.Mode,
+			read: func() (string, error) { return checkpoint.FileContents(tree, f) },
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", commit.Hash.String()[:7], err)
+	}
+	return snapshot, nil
+}
+
+// worktreeSnapshot reads the tracked and untracked, non-ignored files of the
+// working tree.
+func worktreeSnapshot(ctx context.Context) (diffSnapshot, error) {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repo root: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = repoRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list working tree files: %w", err)
+	}
+
+	snapshot := diffSnapshot{}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" || isDiffExcluded(name) {
+			continue
+		}
+		if _, seen := snapshot[name]; seen {
+			continue
+		}
+		absPath := filepath.Join(repoRoot, filepath.FromSlash(name))
+		info, err := os.Lstat(absPath)
+		if err != nil || info.IsDir() {
+			// Deleted but still in the index, or a submodule
+			continue
+		}
+		var content []byte
+		mode := filemode.Regular
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(absPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read link %s: %w", name, err)
+			}
+			content, mode = []byte(target), filemode.Symlink
+		default:
+			content, err = os.ReadFile(absPath) //nolint:gosec // path comes from git ls-files
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			if info.Mode()&0o111 != 0 {
+				mode = filemode.Executable
+			}
+		}
+		snapshot[name] = diffSnapshotFile{
+			hash: plumbing.ComputeHash(plumbing.BlobObject, content),
+			mode: mode,
+			read: func() (string, error) { return string(content), nil },
+		}
+	}
+	return snapshot, nil
+}
+
+// diffSnapshots compares two snapshots file by file, in path order.
+func diffSnapshots(from, to diffSnapshot) ([]*checkpointFilePatch, error) {
+	names := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var patches []*checkpointFilePatch
+	for _, name := range names {
+		f, inFrom := from[name]
+		t, inTo := to[name]
+		if inFrom && inTo && f.hash == t.hash && f.mode == t.mode {
+			continue
+		}
+		var oldContent, newContent string
+		var err error
+		if inFrom {
+			if oldContent, err = f.read(); err != nil {
+				return nil, err
+			}
+		}
+		if inTo {
+			if newContent, err = t.read(); err != nil {
+				return nil, err
+			}
+		}
+		// A chunked file and its plain counterpart have different hashes
+		if inFrom && inTo && oldContent == newContent && f.mode == t.mode {
+			continue
+		}
+
+		patch := &checkpointFilePatch{}
+		if inFrom {
+			patch.from = &diffFile{path: name, hash: plumbing.ComputeHash(plumbing.BlobObject, []byte(oldContent)), mode: f.mode}
+		}
+		if inTo {
+			patch.to = &diffFile{path: name, hash: plumbing.ComputeHash(plumbing.BlobObject, []byte(newContent)), mode: t.mode}
+		}
+		patch.binary = isBinaryContent(oldContent) || isBinaryContent(newContent)
+		if !patch.binary {
+			for _, d := range utildiff.Do(oldContent, newContent) {
+				op := diff.Equal
+				switch d.Type {
+				case diffmatchpatch.DiffInsert:
+					op = diff.Add
+					patch.added += countDiffLines(d.Text)
+				case diffmatchpatch.DiffDelete:
+					op = diff.Delete
+					patch.deleted += countDiffLines(d.Text)
+				case diffmatchpatch.DiffEqual:
+				}
+				patch.chunks = append(patch.chunks, diffChunk{content: d.Text, op: op})
+			}
+		}
+		patches = append(patches, patch)
+	}
+	return patches, nil
+}
+
+func isBinaryContent(content string) bool {
+	isBinary, err := binary.IsBinary(strings.NewReader(content))
+	return err == nil && isBinary
+}
+
+func countDiffLines(text string) int {
+	n := strings.Count(text, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		n++
+	}
+	return n
+}
+
+func writeCheckpointDiff(w io.Writer, patches []*checkpointFilePatch, mode string) error {
+	switch mode {
+	case diffModeNameOnly:
+		for _, p := range patches {
+			fmt.Fprintln(w, p.path())
+		}
+		return nil
+	case diffModeStat:
+		stats := make(object.FileStats, 0, len(patches))
+		added, deleted := 0, 0
+		for _, p := range patches {
+			stats = append(stats, object.FileStat{Name: p.path(), Addition: p.added, Deletion: p.deleted})
+			added += p.added
+			deleted += p.deleted
+		}
+		fmt.Fprint(w, stats.String())
+		fmt.Fprintf(w, " %d file%s changed, %d insertion%s(+), %d deletion%s(-)\n",
+			len(patches), plural(len(patches)), added, plural(added), deleted, plural(deleted))
+		return nil
+	default:
+		var buf bytes.Buffer
+		if err := diff.NewUnifiedEncoder(&buf, diffContextLines).Encode(checkpointPatch(patches)); err != nil {
+			return fmt.Errorf("failed to format diff: %w", err)
+		}
+		_, err := w.Write(buf.Bytes())
+		return err //nolint:wrapcheck // writing to the command's output
+	}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// checkpointPatch, checkpointFilePatch, diffFile and diffChunk implement
+// go-git's diff.Patch so the unified encoder can format the changes.
+type checkpointPatch []*checkpointFilePatch
+
+func (p checkpointPatch) FilePatches() []diff.FilePatch {
+	patches := make([]diff.FilePatch, len(p))
+	for i, fp := range p {
+		patches[i] = fp
+	}
+	return patches
+}
+
+func (p checkpointPatch) Message() string { return "" }
+
+type checkpointFilePatch struct {
+	from, to       *diffFile
+	binary         bool
+	chunks         []diff.Chunk
+	added, deleted int
+}
+
+func (p *checkpointFilePatch) IsBinary() bool { return p.binary }
+
+func (p *checkpointFilePatch) Files() (diff.File, diff.File) {
+	// Return untyped nils for missing sides; the encoder checks from == nil
+	var from, to diff.File
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+func (p *checkpointFilePatch) Chunks() []diff.Chunk { return p.chunks }
+
+func (p *checkpointFilePatch) path() string {
+	if p.to != nil {
+		return p.to.path
+	}
+	return p.from.path
+}
+
+type diffFile struct {
+	path string
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+func (f *diffFile) Hash() plumbing.Hash     { return f.hash }
+func (f *diffFile) Mode() filemode.FileMode { return f.mode }
+func (f *diffFile) Path() string            { return f.path }
+
+type diffChunk struct {
+	content string
+	op      diff.Operation
+}
+
+func (c diffChunk) Content() string      { return c.content }
+func (c diffChunk) Type() diff.Operation { return c.op }
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+// setupCheckpointDiffRepo creates two commits linked to committed
+// checkpoints a1b2c3d4e5f6 and b1b2c3d4e5f6, and returns the repo directory.
+func setupCheckpointDiffRepo(t *testing.T) string {
+	t.Helper()
+	repo, dir := initTestRepo(t)
+
+	commits := []struct {
+		checkpointID string
+		files        map[string]string
+	}{
+		{"a1b2c3d4e5f6", map[string]string{"main.go": "package main\n\nfunc main() {}\n"}},
+		{"b1b2c3d4e5f6", map[string]string{
+			"main.go": "package main\n\nfunc main() {\n\thelper()\n}\n",
+			"util.go": "package main\n\nfunc helper() {}\n",
+		}},
+	}
+	for _, c := range commits {
+		commitTestCheckpoint(t, repo, dir, c.files, checkpoint.WriteCommittedOptions{
+			CheckpointID: id.MustCheckpointID(c.checkpointID),
+			SessionID:    "2026-10-14-diff",
+			Transcript:   []byte(`{"type":"user","message":{"content":"hi"}}` + "\n"),
+		})
+	}
+	return dir
+}
+
+func TestRunCheckpointDiff(t *testing.T) {
+	dir := setupCheckpointDiffRepo(t)
+
+	run := func(t *testing.T, from, to, mode string) (string, string) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := runCheckpointDiff(context.Background(), &stdout, &stderr, from, to, mode); err != nil {
+			t.Fatalf("runCheckpointDiff(%q, %q) error = %v", from, to, err)
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	t.Run("single checkpoint shows what it changed", func(t *testing.T) {
+		out, _ := run(t, "b1b2", "", diffModePatch)
+		for _, want := range []string{
+			"diff --git a/main.go b/main.go",
+			"-func main() {}",
+			"+\thelper()",
+			"new file mode 100644",
+			"+func helper() {}",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("diff is missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("two checkpoints", func(t *testing.T) {
+		out, _ := run(t, "a1b2c3d4e5f6", "b1b2c3d4e5f6", diffModeNameOnly)
+		if out != "main.go\nutil.go\n" {
+			t.Errorf("--name-only = %q", out)
+		}
+		out, _ = run(t, "a1b2c3d4e5f6", "b1b2c3d4e5f6", diffModeStat)
+		if !strings.Contains(out, " main.go | ") || !strings.Contains(out, "2 files changed, 6 insertions(+), 1 deletion(-)") {
+			t.Errorf("--stat = %q", out)
+		}
+	})
+
+	t.Run("worktree", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("todo\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		metadataDir := filepath.Join(dir, ".entire", "metadata", "2026-10-14-diff")
+		if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(metadataDir, "full.jsonl"), []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		out, _ := run(t, "b1b2c3d4e5f6", diffWorktree, diffModeNameOnly)
+		if out != "notes.txt\n" {
+			t.Errorf("--name-only against the worktree = %q, want only the untracked file", out)
+		}
+
+		if err := os.Remove(filepath.Join(dir, "notes.txt")); err != nil {
+			t.Fatal(err)
+		}
+		out, errOut := run(t, "b1b2c3d4e5f6", diffWorktree, diffModePatch)
+		if out != "" || !strings.Contains(errOut, "No changes between checkpoint b1b2c3d4e5f6 and the working tree") {
+			t.Errorf("unchanged worktree: stdout %q, stderr %q", out, errOut)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if err := runCheckpointDiff(context.Background(), &stdout, &stderr, "ffff", "", diffModePatch); err == nil || !strings.Contains(err.Error(), "checkpoint not found") {
+			t.Errorf("unknown checkpoint: error = %v", err)
+		}
+		if err := runCheckpointDiff(context.Background(), &stdout, &stderr, diffWorktree, "a1b2", diffModePatch); err == nil {
+			t.Error("worktree as the first argument should fail")
+		}
+	})
+}
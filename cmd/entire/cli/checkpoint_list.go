@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// noEpoch is the --epoch flag value meaning "list epochs, don't drill down".
+const noEpoch = -1
+
+func newCheckpointListCmd() *cobra.Command {
+	var sessionFlag string
+	var epochFlag int
+	var jsonFlag bool
+	var lf listFlags
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List uncommitted checkpoints grouped into epochs",
+		Long: fmt.Sprintf(`Lists the checkpoints each session has created since its last commit.
+
+Checkpoints are rolled up into epochs of %d so long sessions stay fast to list.
+Use --epoch to drill down into the individual checkpoints of one epoch.
+
+By default all sessions in the current worktree are shown, oldest first.
+--since and --until only show sessions that were active during that period.
+With --limit, a cursor for the next page of sessions is printed to stderr.`, session.CheckpointEpochSize),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			opts, err := lf.resolve(time.Now())
+			if err != nil {
+				return err
+			}
+			return runCheckpointList(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), sessionFlag, epochFlag, opts, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionFlag, "session", "", "Only show this session")
+	registerFlagCompletion(cmd, "session", completeSessionIDs)
+	cmd.Flags().IntVar(&epochFlag, "epoch", noEpoch, "List the checkpoints in this epoch (requires a single session)")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	addListFlags(cmd, &lf, listSortOldest, false)
+
+	return cmd
+}
+
+func runCheckpointList(ctx context.Context, w, errW io.Writer, sessionFilter string, epochIndex int, opts listOptions, jsonOutput bool) error {
+	period := opts.Period
+	states, err := strategy.ListSessionStates()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	worktreePath, err := strategy.GetWorktreePath()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree path: %w", err)
+	}
+
+	var selected []*strategy.SessionState
+	for _, state := range states {
+		if period.IsBounded() && !period.Overlaps(state.StartedAt, sessionLastActive(state)) {
+			continue
+		}
+		if !opts.matchesAgent(string(state.AgentType)) {
+			continue
+		}
+		if sessionFilter != "" {
+			if state.SessionID == sessionFilter {
+				selected = append(selected, state)
+			}
+			continue
+		}
+		if state.WorktreePath == worktreePath && state.StepCount > 0 {
+			selected = append(selected, state)
+		}
+	}
+	selected, next, err := pageList(selected, func(state *strategy.SessionState) listKey {
+		return listKey{Time: state.StartedAt, ID: state.SessionID}
+	}, opts)
+	if err != nil {
+		return err
+	}
+	defer writeNextCursor(errW, next)
+
+	if epochIndex != noEpoch {
+		if len(selected) != 1 {
+			return errors.New("--epoch requires exactly one session; use --session to pick one")
+		}
+		return runCheckpointListEpoch(ctx, w, selected[0], epochIndex, jsonOutput)
+	}
+
+	if jsonOutput {
+		return printCheckpointEpochsJSON(w, selected)
+	}
+
+	if len(selected) == 0 {
+		if sessionFilter != "" {
+			fmt.Fprintf(w, "Session %s not found.\n", sessionFilter)
+		} else if period.IsBounded() {
+			fmt.Fprintf(w, "No uncommitted checkpoints in this worktree %s.\n", period.Describe())
+		} else {
+			fmt.Fprintln(w, "No uncommitted checkpoints in this worktree.")
+		}
+		return nil
+	}
+
+	for i, state := range selected {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		printCheckpointEpochs(w, state)
+	}
+	return nil
+}
+
+// sessionLastActive returns when the session was last active, or the zero
+// time if it may still be running.
+func sessionLastActive(state *strategy.SessionState) time.Time {
+	if state.EndedAt != nil {
+		return *state.EndedAt
+	}
+	if state.LastInteractionTime != nil {
+		return *state.LastInteractionTime
+	}
+	return time.Time{}
+}
+
+// checkpointEpochsForDisplay returns the epochs of the session's current checkpoint
+// cycle. Checkpoints written before epochs existed (or by an older CLI mid-cycle)
+// are represented by a leading epoch without commit bounds.
+func checkpointEpochsForDisplay(state *strategy.SessionState) []session.CheckpointEpoch {
+	if state.StepCount == 0 && len(state.CheckpointEpochs) == 0 {
+		return nil
+	}
+
+	var epochs []session.CheckpointEpoch
+	firstIndexed := state.StepCount + 1
+	if len(state.CheckpointEpochs) > 0 {
+		firstIndexed = state.CheckpointEpochs[0].FirstStep
+	}
+	if firstIndexed > 1 {
+		epochs = append(epochs, session.CheckpointEpoch{
+			FirstStep: 1,
+			LastStep:  firstIndexed - 1,
+		})
+	}
+	epochs = append(epochs, state.CheckpointEpochs...)
+
+	for i := range epochs {
+		epochs[i].Index = i
+	}
+	return epochs
+}
+
+func printCheckpointEpochs(w io.Writer, state *strategy.SessionState) {
+	agentLabel := string(state.AgentType)
+	if agentLabel == "" {
+		agentLabel = "unknown agent"
+	}
+	fmt.Fprintf(w, "Session %s (%s): %d checkpoints on %s\n",
+		state.SessionID, agentLabel, state.StepCount,
+		checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID))
+
+	epochs := checkpointEpochsForDisplay(state)
+	if len(epochs) == 0 {
+		fmt.Fprintln(w, "  No checkpoints since the last commit.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %-6s %-12s %-6s %-17s %-17s %s\n", "Epoch", "Checkpoints", "Tasks", "Started", "Ended", "Files")
+	for _, e := range epochs {
+		steps := "-"
+		if e.Count() > 0 {
+			steps = fmt.Sprintf("%d-%d", e.FirstStep, e.LastStep)
+		}
+		fmt.Fprintf(w, "  %-6d %-12s %-6d %-17s %-17s %s\n",
+			e.Index,
+			steps,
+			e.TaskCheckpoints,
+			formatEpochTime(e.StartedAt),
+			formatEpochTime(e.EndedAt),
+			formatEpochFiles(e))
+	}
+}
+
+func formatEpochTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatEpochFiles(e session.CheckpointEpoch) string {
+	if e.LastCommit == "" {
+		return "(not indexed)"
+	}
+	return fmt.Sprintf("%d", len(e.FilesTouched))
+}
+
+type checkpointEpochJSON struct {
+	Index        int       `json:"index"`
+	FirstStep    int       `json:"first_step"`
+	LastStep     int       `json:"last_step"`
+	Count        int       `json:"count"`
+	Tasks        int       `json:"task_checkpoints"`
+	StartedAt    time.Time `json:"started_at,omitzero"`
+	EndedAt      time.Time `json:"ended_at,omitzero"`
+	FirstCommit  string    `json:"first_commit,omitempty"`
+	LastCommit   string    `json:"last_commit,omitempty"`
+	FilesTouched []string  `json:"files_touched"`
+}
+
+type checkpointSessionJSON struct {
+	SessionID    string                `json:"session_id"`
+	Agent        string                `json:"agent,omitempty"`
+	ShadowBranch string                `json:"shadow_branch"`
+	Checkpoints  int                   `json:"checkpoints"`
+	Epochs       []checkpointEpochJSON `json:"epochs"`
+}
+
+func toCheckpointEpochJSON(e session.CheckpointEpoch) checkpointEpochJSON {
+	files := e.FilesTouched
+	if files == nil {
+		files = []string{}
+	}
+	return checkpointEpochJSON{
+		Index:        e.Index,
+		FirstStep:    e.FirstStep,
+		LastStep:     e.LastStep,
+		Count:        e.Count(),
+		Tasks:        e.TaskCheckpoints,
+		StartedAt:    e.StartedAt,
+		EndedAt:      e.EndedAt,
+		FirstCommit:  e.FirstCommit,
+		LastCommit:   e.LastCommit,
+		FilesTouched: files,
+	}
+}
+
+func printCheckpointEpochsJSON(w io.Writer, states []*strategy.SessionState) error {
+	output := make([]checkpointSessionJSON, 0, len(states))
+	for _, state := range states {
+		epochs := checkpointEpochsForDisplay(state)
+		entry := checkpointSessionJSON{
+			SessionID:    state.SessionID,
+			Agent:        string(state.AgentType),
+			ShadowBranch: checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID),
+			Checkpoints:  state.StepCount,
+			Epochs:       make([]checkpointEpochJSON, 0, len(epochs)),
+		}
+		for _, e := range epochs {
+			entry.Epochs = append(entry.Epochs, toCheckpointEpochJSON(e))
+		}
+		output = append(output, entry)
+	}
+
+	data, err := jsonutil.MarshalIndentWithNewline(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoints: %w", err)
+	}
+	fmt.Fprint(w, string(data))
+	return nil
+}
+
+// runCheckpointListEpoch lists the individual checkpoints of one epoch. Indexed epochs
+// walk only the commits between their bounds; the unindexed leading epoch walks the
+// shadow branch and keeps the oldest checkpoints.
+func runCheckpointListEpoch(ctx context.Context, w io.Writer, state *strategy.SessionState, epochIndex int, jsonOutput bool) error {
+	epochs := checkpointEpochsForDisplay(state)
+	if epochIndex < 0 || epochIndex >= len(epochs) {
+		return fmt.Errorf("session %s has no epoch %d (has %d)", state.SessionID, epochIndex, len(epochs))
+	}
+	epoch := epochs[epochIndex]
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+
+	var points []checkpoint.TemporaryCheckpointInfo
+	if epoch.LastCommit != "" {
+		points, err = store.ListCheckpointsInRange(ctx,
+			plumbing.NewHash(epoch.LastCommit), plumbing.NewHash(epoch.FirstCommit), state.SessionID)
+	} else {
+		branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+		ref, refErr := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+		if refErr != nil {
+			return fmt.Errorf("shadow branch %s not found: %w", branch, refErr)
+		}
+		points, err = store.ListCheckpointsInRange(ctx, ref.Hash(), plumbing.ZeroHash, state.SessionID)
+		if err == nil && len(points) > epoch.Count() {
+			points = points[len(points)-epoch.Count():]
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	if jsonOutput {
+		type pointJSON struct {
+			Commit           string    `json:"commit"`
+			Message          string    `json:"message"`
+			Timestamp        time.Time `json:"timestamp"`
+			IsTaskCheckpoint bool      `json:"is_task_checkpoint"`
+		}
+		output := struct {
+			SessionID   string              `json:"session_id"`
+			Epoch       checkpointEpochJSON `json:"epoch"`
+			Checkpoints []pointJSON         `json:"checkpoints"`
+		}{
+			SessionID:   state.SessionID,
+			Epoch:       toCheckpointEpochJSON(epoch),
+			Checkpoints: make([]pointJSON, 0, len(points)),
+		}
+		for _, p := range points {
+			output.Checkpoints = append(output.Checkpoints, pointJSON{
+				Commit:           p.CommitHash.String(),
+				Message:          p.Message,
+				Timestamp:        p.Timestamp,
+				IsTaskCheckpoint: p.IsTaskCheckpoint,
+			})
+		}
+		data, err := jsonutil.MarshalIndentWithNewline(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal checkpoints: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	fmt.Fprintf(w, "Session %s, epoch %d (checkpoints %d-%d)\n", state.SessionID, epoch.Index, epoch.FirstStep, epoch.LastStep)
+	if len(points) == 0 {
+		fmt.Fprintln(w, "  No checkpoints found.")
+		return nil
+	}
+	for _, p := range points {
+		label := sanitizeForTerminal(p.Message)
+		if p.IsTaskCheckpoint {
+			label = "[Task] " + label
+		}
+		fmt.Fprintf(w, "  %s  %s  %s\n", p.CommitHash.String()[:7], p.Timestamp.Local().Format("2006-01-02 15:04"), label)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestCheckpointEpochsForDisplay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no checkpoints", func(t *testing.T) {
+		t.Parallel()
+		if got := checkpointEpochsForDisplay(&strategy.SessionState{}); len(got) != 0 {
+			t.Errorf("expected no epochs, got %d", len(got))
+		}
+	})
+
+	t.Run("legacy session without epochs", func(t *testing.T) {
+		t.Parallel()
+		got := checkpointEpochsForDisplay(&strategy.SessionState{StepCount: 7})
+		if len(got) != 1 || got[0].FirstStep != 1 || got[0].LastStep != 7 || got[0].LastCommit != "" {
+			t.Errorf("unexpected epochs: %+v", got)
+		}
+	})
+
+	t.Run("indexing started mid-cycle", func(t *testing.T) {
+		t.Parallel()
+		state := &strategy.SessionState{
+			StepCount: 12,
+			CheckpointEpochs: []session.CheckpointEpoch{
+				{Index: 0, FirstStep: 5, LastStep: 12, FirstCommit: "a", LastCommit: "b"},
+			},
+		}
+		got := checkpointEpochsForDisplay(state)
+		if len(got) != 2 {
+			t.Fatalf("expected 2 epochs, got %+v", got)
+		}
+		if got[0].FirstStep != 1 || got[0].LastStep != 4 {
+			t.Errorf("unexpected leading epoch: %+v", got[0])
+		}
+		if got[1].Index != 1 || got[1].LastCommit != "b" {
+			t.Errorf("indexed epoch should be renumbered after the leading epoch: %+v", got[1])
+		}
+	})
+}
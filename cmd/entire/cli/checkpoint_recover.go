@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newCheckpointRecoverCmd() *cobra.Command {
+	var sessionFlag string
+	var dryRunFlag bool
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "recover",
+		Short: "Rebuild deleted shadow branches from session transcripts",
+		Long: `Rebuilds the temporary checkpoints of sessions whose shadow branch was
+deleted, so the next commit still gets its attribution and the session can
+be reviewed and rewound.
+
+Each turn of the transcript since the session's last commit becomes one
+checkpoint: the Write, Edit and MultiEdit tool calls of the turn are replayed
+against the commit the session started from. Edits whose old text can't be
+found (because the file was changed outside the agent) are skipped. The
+checkpoints are marked reconstructed, and the share of edits that replayed
+becomes the confidence shown with the committed attribution.
+
+Only sessions that created checkpoints since their last commit, whose shadow
+branch is missing and whose transcript is still on disk are rebuilt. Use
+--session to rebuild one session. 'entire doctor' also runs this.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runCheckpointRecover(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), sessionFlag, dryRunFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionFlag, "session", "", "Only rebuild this session")
+	cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would be rebuilt without writing anything")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	registerFlagCompletion(cmd, "session", completeSessionIDs)
+
+	return cmd
+}
+
+// reconstructJSON is the JSON shape of one rebuilt session.
+type reconstructJSON struct {
+	SessionID    string   `json:"session_id"`
+	ShadowBranch string   `json:"shadow_branch"`
+	Checkpoints  int      `json:"checkpoints"`
+	Files        []string `json:"files"`
+	AppliedEdits int      `json:"applied_edits"`
+	SkippedEdits int      `json:"skipped_edits"`
+	Confidence   float64  `json:"confidence"`
+}
+
+func runCheckpointRecover(ctx context.Context, w, errW io.Writer, sessionID string, dryRun, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var results []strategy.ReconstructResult
+	var recoverErr error
+	if sessionID != "" {
+		state, err := strategy.LoadSessionState(sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+		}
+		if state == nil {
+			return fmt.Errorf("session not found: %s", sessionID)
+		}
+		repo, err := openRepository()
+		if err != nil {
+			return err
+		}
+		if !strategy.NeedsReconstruction(repo, state) {
+			fmt.Fprintf(w, "Session %s doesn't need recovery: it has no checkpoints since its last commit, its shadow branch exists, or its transcript is gone.\n", sessionID)
+			return nil
+		}
+		result, err := strategy.ReconstructSession(ctx, state, dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to recover session %s: %w", sessionID, err)
+		}
+		results = append(results, *result)
+	} else {
+		results, recoverErr = strategy.ReconstructLostShadowBranches(ctx, dryRun)
+		if recoverErr != nil && len(results) == 0 {
+			return fmt.Errorf("failed to recover shadow branches: %w", recoverErr)
+		}
+		if recoverErr != nil {
+			// Some sessions were rebuilt; report the rest without failing
+			fmt.Fprintf(errW, "Warning: %v\n", recoverErr)
+		}
+	}
+
+	if jsonOutput {
+		out := make([]reconstructJSON, 0, len(results))
+		for _, r := range results {
+			out = append(out, reconstructJSON{
+				SessionID:    r.SessionID,
+				ShadowBranch: r.ShadowBranch,
+				Checkpoints:  r.Checkpoints,
+				Files:        r.Files,
+				AppliedEdits: r.AppliedEdits,
+				SkippedEdits: r.SkippedEdits,
+				Confidence:   r.Confidence,
+			})
+		}
+		data, err := jsonutil.MarshalIndentWithNewline(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal results: %w", err)
+		}
+		_, err = w.Write(data)
+		return err //nolint:wrapcheck // write to stdout
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No sessions have lost their shadow branch.")
+		return nil
+	}
+	verb := "Reconstructed"
+	if dryRun {
+		verb = "Would reconstruct"
+	}
+	locale := reportfmt.DetectLocale()
+	for _, r := range results {
+		if r.Checkpoints == 0 {
+			fmt.Fprintf(w, "Session %s: no file edits to replay since its last commit.\n", r.SessionID)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s for session %s: %d checkpoint(s), %d file(s), %s confidence (%d of %d edits replayed)\n",
+			verb, r.ShadowBranch, r.SessionID, r.Checkpoints, len(r.Files),
+			locale.Percent(r.Confidence*100, 0), r.AppliedEdits, r.AppliedEdits+r.SkippedEdits)
+	}
+	return nil
+}
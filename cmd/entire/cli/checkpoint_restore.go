@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/charmbracelet/huh"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	utildiff "github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newCheckpointRestoreCmd() *cobra.Command {
+	var patchFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <checkpoint> [--] <paths...>",
+		Short: "Restore files, or single hunks, from a checkpoint",
+		Long: `Restores the given files from a checkpoint into the working tree, and
+leaves every other file alone, unlike 'entire rewind'. Use it to take back
+one file the agent got right without losing your own edits elsewhere.
+
+Checkpoints are named as for 'entire checkpoint diff': committed checkpoint
+IDs, temporary checkpoint commit hashes, or unique prefixes of either. Paths
+are relative to the current directory; a directory restores the checkpoint's
+files under it. Files the checkpoint doesn't have are not deleted.
+
+With --patch, each hunk where a file differs from the checkpoint is shown
+and only the ones you pick are restored, so your other edits in the same
+file are kept. Without paths, --patch offers every file that differs.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeRestoreArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if len(args) == 1 && !patchFlag {
+				return errors.New("name the files to restore (entire checkpoint restore <checkpoint> -- <paths...>), or pick hunks with --patch")
+			}
+			var pick hunkPicker
+			if patchFlag {
+				if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+					return errors.New("--patch needs an interactive terminal")
+				}
+				pick = askRestoreHunk
+			}
+			return runCheckpointRestore(cmd.Context(), cmd.OutOrStdout(), args[0], args[1:], pick)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&patchFlag, "patch", "p", false, "Pick the hunks to restore interactively")
+
+	return cmd
+}
+
+// hunkPicker decides whether to restore a hunk of path. Nil restores whole
+// files.
+type hunkPicker func(path string, h restoreHunk, index, total int) (bool, error)
+
+// restoreHunk is a run of changed lines between the working tree and the
+// checkpoint: Current is replaced by Checkpoint when it's restored. A
+// WholeFile hunk is a file that can't be split into hunks: binary, a
+// symlink, or missing from the working tree.
+type restoreHunk struct {
+	Current    string
+	Checkpoint string
+	WholeFile  bool
+}
+
+func runCheckpointRestore(ctx context.Context, w io.Writer, checkpointArg string, pathArgs []string, pick hunkPicker) error {
+	if checkpointArg == diffWorktree {
+		return errors.New("restore needs a checkpoint to restore from")
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	commit, label, err := resolveDiffCheckpoint(ctx, repo, checkpoint.NewGitStore(repo), checkpointArg)
+	if err != nil {
+		return err
+	}
+	snapshot, err := treeSnapshot(commit)
+	if err != nil {
+		return err
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get repo root: %w", err)
+	}
+	names, err := restorePaths(repoRoot, snapshot, pathArgs)
+	if err != nil {
+		return err
+	}
+
+	// Everything is asked first, so cancelling the picker changes nothing
+	var plan []undoFile
+	partial := make(map[string]bool)
+	for _, name := range names {
+		file := snapshot[name]
+		content, err := file.read()
+		if err != nil {
+			return fmt.Errorf("failed to read %s from %s: %w", name, label, err)
+		}
+		absPath := filepath.Join(repoRoot, filepath.FromSlash(name))
+		current, currentMode, exists, err := readWorktreeFile(absPath)
+		if err != nil {
+			return err
+		}
+		if exists && string(current) == content && currentMode == file.mode {
+			continue
+		}
+
+		result := []byte(content)
+		mode := file.mode
+		if pick != nil {
+			if exists && currentMode != filemode.Symlink && file.mode != filemode.Symlink &&
+				!isBinaryContent(string(current)) && !isBinaryContent(content) {
+				var picked, total int
+				result, picked, total, err = pickRestoreHunks(name, string(current), content, pick)
+				if err != nil {
+					return err
+				}
+				if picked == 0 {
+					continue
+				}
+				if picked < total {
+					// Mode changes are only taken with the whole file
+					partial[name], mode = true, currentMode
+				}
+			} else {
+				ok, err := pick(name, restoreHunk{Current: string(current), Checkpoint: content, WholeFile: true}, 1, 1)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+			}
+		}
+
+		plan = append(plan, undoFile{Path: name, Action: undoActionRevert, Content: result, Mode: mode})
+	}
+
+	if len(plan) == 0 {
+		fmt.Fprintf(w, "Nothing restored: the files match %s or no hunks were picked.\n", label)
+		return nil
+	}
+	if err := writeWorktreeFiles(repoRoot, plan); err != nil {
+		return err
+	}
+	for _, f := range plan {
+		if partial[f.Path] {
+			fmt.Fprintf(w, "  restored  %s (some hunks)\n", f.Path)
+		} else {
+			fmt.Fprintf(w, "  restored  %s\n", f.Path)
+		}
+	}
+	fmt.Fprintf(w, "Restored %d file(s) from %s.\n", len(plan), label)
+	return nil
+}
+
+// restorePaths returns the checkpoint files named by pathArgs, which are
+// relative to the current directory, in path order. Without pathArgs it
+// returns all of them.
+func restorePaths(repoRoot string, snapshot diffSnapshot, pathArgs []string) ([]string, error) {
+	selected := make(map[string]bool)
+	if len(pathArgs) == 0 {
+		for name := range snapshot {
+			selected[name] = true
+		}
+	}
+	for _, arg := range pathArgs {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", arg, err)
+		}
+		rel, err := filepath.Rel(repoRoot, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the repository", arg)
+		}
+		rel = filepath.ToSlash(rel)
+		found := false
+		for name := range snapshot {
+			if rel == "." || name == rel || strings.HasPrefix(name, rel+"/") {
+				selected[name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s isn't in the checkpoint", arg)
+		}
+	}
+
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pickRestoreHunks asks about each hunk where current differs from content
+// and returns current with the picked hunks replaced by the checkpoint's.
+func pickRestoreHunks(name, current, content string, pick hunkPicker) ([]byte, int, int, error) {
+	diffs := utildiff.Do(current, content)
+	total := 0
+	for i := range diffs {
+		if diffs[i].Type != diffmatchpatch.DiffEqual && (i == 0 || diffs[i-1].Type == diffmatchpatch.DiffEqual) {
+			total++
+		}
+	}
+
+	var out strings.Builder
+	picked, index := 0, 0
+	for i := 0; i < len(diffs); {
+		if diffs[i].Type == diffmatchpatch.DiffEqual {
+			out.WriteString(diffs[i].Text)
+			i++
+			continue
+		}
+		var h restoreHunk
+		for ; i < len(diffs) && diffs[i].Type != diffmatchpatch.DiffEqual; i++ {
+			switch diffs[i].Type {
+			case diffmatchpatch.DiffDelete:
+				h.Current += diffs[i].Text
+			case diffmatchpatch.DiffInsert:
+				h.Checkpoint += diffs[i].Text
+			case diffmatchpatch.DiffEqual:
+			}
+		}
+		index++
+		ok, err := pick(name, h, index, total)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if ok {
+			picked++
+			out.WriteString(h.Checkpoint)
+		} else {
+			out.WriteString(h.Current)
+		}
+	}
+	return []byte(out.String()), picked, total, nil
+}
+
+// askRestoreHunk shows a hunk as a diff from the working tree to the
+// checkpoint and asks whether to restore it.
+func askRestoreHunk(path string, h restoreHunk, index, total int) (bool, error) {
+	title := fmt.Sprintf("Restore hunk %d/%d of %s?", index, total, path)
+	if h.WholeFile {
+		title = fmt.Sprintf("Restore all of %s?", path)
+	}
+	var description string
+	switch {
+	case isBinaryContent(h.Current) || isBinaryContent(h.Checkpoint):
+		description = "Binary file"
+	case h.WholeFile && h.Current == "":
+		description = "Not in the working tree"
+	default:
+		var b strings.Builder
+		writeHunkLines(&b, "-", h.Current)
+		writeHunkLines(&b, "+", h.Checkpoint)
+		description = strings.TrimSuffix(b.String(), "\n")
+	}
+
+	var restore bool
+	form := NewAccessibleForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(title).
+				Description(description).
+				Affirmative("Restore").
+				Negative("Keep mine").
+				Value(&restore),
+		),
+	)
+	if err := form.Run(); err != nil {
+		if errors.Is(err, huh.ErrUserAborted) {
+			return false, NewSilentError(errors.New("restore cancelled"))
+		}
+		return false, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	return restore, nil
+}
+
+func writeHunkLines(b *strings.Builder, prefix, text string) {
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			b.WriteString(prefix + strings.TrimSuffix(line, "\n") + "\n")
+		}
+	}
+}
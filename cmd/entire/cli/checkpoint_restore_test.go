@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunCheckpointRestore_Paths(t *testing.T) {
+	setupCheckpointDiffRepo(t)
+	// Edits after the second checkpoint
+	writeUndoFile(t, "main.go", "package main\n\nfunc main() { broken() }\n")
+	writeUndoFile(t, "util.go", "package main\n\nfunc helper() { mine() }\n")
+
+	var out bytes.Buffer
+	if err := runCheckpointRestore(context.Background(), &out, "b1b2", []string{"main.go"}, nil); err != nil {
+		t.Fatalf("runCheckpointRestore() error = %v", err)
+	}
+	if got := readUndoFile(t, "main.go"); got != "package main\n\nfunc main() {\n\thelper()\n}\n" {
+		t.Errorf("main.go = %q, want the checkpoint's", got)
+	}
+	if got := readUndoFile(t, "util.go"); !strings.Contains(got, "mine()") {
+		t.Errorf("util.go = %q, want the edit kept", got)
+	}
+	if !strings.Contains(out.String(), "Restored 1 file(s) from checkpoint b1b2c3d4e5f6.") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	if err := runCheckpointRestore(context.Background(), &out, "b1b2", []string{"missing.go"}, nil); err == nil {
+		t.Error("runCheckpointRestore(missing.go) error = nil, want a not-in-checkpoint error")
+	}
+}
+
+func TestRunCheckpointRestore_Hunks(t *testing.T) {
+	setupCheckpointDiffRepo(t)
+	// Two edits to main.go: restore the first, keep the second
+	writeUndoFile(t, "main.go", "package mine\n\nfunc main() {\n\thelper()\n}\n\nfunc extra() {}\n")
+
+	var asked []restoreHunk
+	pick := func(path string, h restoreHunk, index, total int) (bool, error) {
+		if path != "main.go" || total != 2 {
+			t.Errorf("pick(%s, %d/%d), want main.go with 2 hunks", path, index, total)
+		}
+		asked = append(asked, h)
+		return index == 1, nil
+	}
+	var out bytes.Buffer
+	if err := runCheckpointRestore(context.Background(), &out, "b1b2", nil, pick); err != nil {
+		t.Fatalf("runCheckpointRestore() error = %v", err)
+	}
+	if len(asked) != 2 || asked[0].Current != "package mine\n" || asked[0].Checkpoint != "package main\n" {
+		t.Errorf("hunks = %+v", asked)
+	}
+	if got, want := readUndoFile(t, "main.go"), "package main\n\nfunc main() {\n\thelper()\n}\n\nfunc extra() {}\n"; got != want {
+		t.Errorf("main.go = %q, want %q", got, want)
+	}
+	if _, err := os.Stat("util.go"); err != nil {
+		t.Errorf("util.go should be untouched: %v", err)
+	}
+	if !strings.Contains(out.String(), "restored  main.go (some hunks)") {
+		t.Errorf("output = %q", out.String())
+	}
+}
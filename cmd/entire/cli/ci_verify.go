@@ -0,0 +1,283 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+)
+
+// errVerifyFailed is returned by `entire ci verify` when a recorded
+// attribution doesn't hold up, failing the CI job.
+var errVerifyFailed = NewSilentError(errors.New("attribution verification failed"))
+
+// Statuses of a verified commit.
+const (
+	verifyStatusOK           = "ok"
+	verifyStatusFailed       = "failed"
+	verifyStatusUnattributed = "unattributed"
+)
+
+func newCICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Commands for CI pipelines",
+	}
+	cmd.AddCommand(newCIVerifyCmd())
+	return cmd
+}
+
+func newCIVerifyCmd() *cobra.Command {
+	var remoteFlag string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "verify <range>",
+		Short: "Check the recorded attribution of a range of commits against the commits",
+		Long: `Checks the attribution recorded for each commit in a revision range (e.g.
+origin/main..HEAD) and exits non-zero when a record was tampered with or no
+longer matches its commit.
+
+For each session of a commit's checkpoint, the parts of the attribution that
+follow from the commit alone are recomputed and must match exactly: which
+files changed, the lines of the files the agent didn't touch, and the size
+changes of binary files. The split of the agent's files between agent and
+human depends on the session's temporary checkpoints, which aren't kept
+after the commit, so for those files the record's invariants are checked
+(the totals and percentages add up, agent lines only in files the agent
+touched, agent line ranges within the committed file). Transcripts are
+checked against their stored hashes, and a commit whose checkpoint is
+missing fails.
+
+Records written before attribution stored its base commit, and records that
+fold in amended commits, are only checked for consistency.
+
+verify only reads: it doesn't need hooks or a session, and works on a
+detached HEAD. The metadata branch is fetched from --remote when it isn't
+there locally, as in a fresh CI checkout; the range needs full history
+(fetch-depth: 0 or GIT_DEPTH: 0).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runCIVerify(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], remoteFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&remoteFlag, "remote", "origin", "Remote to fetch the metadata branch from when it's missing (empty to never fetch)")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Print the results as JSON")
+
+	return cmd
+}
+
+// ciVerifyReport is the result of `entire ci verify`.
+type ciVerifyReport struct {
+	Range        string           `json:"range"`
+	Commits      []ciVerifyCommit `json:"commits"`
+	NoCheckpoint int              `json:"commits_without_checkpoint"`
+	Failed       int              `json:"failed"`
+}
+
+// ciVerifyCommit is a verified commit with a checkpoint.
+type ciVerifyCommit struct {
+	Commit       string            `json:"commit"`
+	Subject      string            `json:"subject"`
+	CheckpointID id.CheckpointID   `json:"checkpoint_id"`
+	Status       string            `json:"status"`
+	Problems     []string          `json:"problems,omitempty"`
+	Sessions     []ciVerifySession `json:"sessions"`
+}
+
+// ciVerifySession is the verification of one session's attribution.
+type ciVerifySession struct {
+	SessionID string   `json:"session_id"`
+	Derived   bool     `json:"recomputed"`
+	Note      string   `json:"note,omitempty"`
+	Problems  []string `json:"problems,omitempty"`
+}
+
+func runCIVerify(ctx context.Context, w, errW io.Writer, spec, remote string, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	commitRange, err := resolveCommitRange(repo, spec)
+	if err != nil {
+		return err
+	}
+	if commitRange == nil {
+		return errors.New("missing revision range: use e.g. origin/main..HEAD")
+	}
+	if remote != "" {
+		if err := ensureMetadataBranch(ctx, repo, remote); err != nil {
+			return fmt.Errorf("%s is not available locally and couldn't be fetched from %s: %w", paths.MetadataBranchName, remote, err)
+		}
+	}
+
+	report, err := verifyCommitRange(ctx, repo, commitRange)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err //nolint:wrapcheck // write to stdout
+		}
+	} else {
+		writeCIVerifyReport(w, report)
+	}
+	if report.Failed > 0 {
+		if jsonOutput {
+			fmt.Fprintf(errW, "Attribution verification failed for %d commit(s).\n", report.Failed)
+		}
+		return errVerifyFailed
+	}
+	return nil
+}
+
+// verifyCommitRange verifies the commits of r with a checkpoint, oldest
+// first.
+func verifyCommitRange(ctx context.Context, repo *git.Repository, r *commitRange) (*ciVerifyReport, error) {
+	commits := make([]*object.Commit, 0, len(r.Commits))
+	for hash := range r.Commits {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		commits = append(commits, commit)
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		if !commits[i].Committer.When.Equal(commits[j].Committer.When) {
+			return commits[i].Committer.When.Before(commits[j].Committer.When)
+		}
+		return commits[i].Hash.String() < commits[j].Hash.String()
+	})
+
+	store := checkpoint.NewGitStore(repo)
+	report := &ciVerifyReport{Range: r.Spec, Commits: []ciVerifyCommit{}}
+	for _, commit := range commits {
+		cpID, found := trailers.ParseCheckpoint(commit.Message)
+		if !found {
+			report.NoCheckpoint++
+			continue
+		}
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		result := verifyCommit(ctx, repo, store, commit, cpID)
+		result.Subject = subject
+		if result.Status == verifyStatusFailed {
+			report.Failed++
+		}
+		report.Commits = append(report.Commits, result)
+	}
+	return report, nil
+}
+
+// verifyCommit verifies the attribution commit's checkpoint records.
+func verifyCommit(ctx context.Context, repo *git.Repository, store *checkpoint.GitStore, commit *object.Commit, cpID id.CheckpointID) ciVerifyCommit {
+	result := ciVerifyCommit{Commit: commit.Hash.String(), CheckpointID: cpID, Status: verifyStatusUnattributed, Sessions: []ciVerifySession{}}
+	summary, err := store.ReadCommitted(ctx, cpID)
+	if err != nil || summary == nil {
+		result.Status = verifyStatusFailed
+		result.Problems = append(result.Problems, fmt.Sprintf("checkpoint %s isn't on %s", cpID, paths.MetadataBranchName))
+		return result
+	}
+	for i := range summary.Sessions {
+		content, err := store.ReadSessionContent(ctx, cpID, i)
+		if err != nil {
+			result.Status = verifyStatusFailed
+			result.Problems = append(result.Problems, fmt.Sprintf("session %d can't be read: %v", i, err))
+			continue
+		}
+		metadata := content.Metadata
+		session := ciVerifySession{SessionID: metadata.SessionID}
+		if problem := transcriptHashProblem(content); problem != "" {
+			session.Problems = append(session.Problems, problem)
+		}
+		if attr := metadata.InitialAttribution; attr != nil {
+			v := strategy.VerifyAttribution(repo, commit, metadata.FilesTouched, attr)
+			session.Derived, session.Note = v.Derived, v.Note
+			session.Problems = append(session.Problems, v.Problems...)
+			if result.Status == verifyStatusUnattributed {
+				result.Status = verifyStatusOK
+			}
+		} else {
+			session.Note = "no attribution recorded"
+		}
+		if len(session.Problems) > 0 {
+			result.Status = verifyStatusFailed
+		}
+		result.Sessions = append(result.Sessions, session)
+	}
+	return result
+}
+
+// transcriptHashProblem checks a session's transcript against the hash
+// stored next to it. Chunked transcripts are skipped, since reassembling
+// them needn't reproduce the original bytes.
+func transcriptHashProblem(content *checkpoint.SessionContent) string {
+	if content.Metadata.MetadataOnly || content.ContentHash == "" || len(content.Transcript) == 0 || len(content.Transcript) > agent.MaxChunkSize {
+		return ""
+	}
+	if got := fmt.Sprintf("sha256:%x", sha256.Sum256(content.Transcript)); got != content.ContentHash {
+		return "transcript doesn't match its stored hash"
+	}
+	return ""
+}
+
+func writeCIVerifyReport(w io.Writer, report *ciVerifyReport) {
+	fmt.Fprintf(w, "Verifying attribution in %s\n\n", report.Range)
+	if len(report.Commits) == 0 {
+		fmt.Fprintf(w, "No commits with a checkpoint (%d without).\n", report.NoCheckpoint)
+		return
+	}
+	for _, c := range report.Commits {
+		label := "ok"
+		switch c.Status {
+		case verifyStatusFailed:
+			label = "FAIL"
+		case verifyStatusUnattributed:
+			label = "-"
+		}
+		fmt.Fprintf(w, "  %-4s  %s %s\n", label, c.Commit[:7], c.Subject)
+		for _, p := range c.Problems {
+			fmt.Fprintf(w, "          %s\n", p)
+		}
+		for _, s := range c.Sessions {
+			switch {
+			case len(s.Problems) > 0:
+				fmt.Fprintf(w, "        session %s:\n", s.SessionID)
+				for _, p := range s.Problems {
+					fmt.Fprintf(w, "          %s\n", p)
+				}
+			case !s.Derived && s.Note != "":
+				fmt.Fprintf(w, "        session %s: consistency only, %s\n", s.SessionID, s.Note)
+			}
+		}
+	}
+
+	verified := len(report.Commits) - report.Failed
+	fmt.Fprintf(w, "\n%d commit(s) verified, %d failed, %d without a checkpoint.\n", verified, report.Failed, report.NoCheckpoint)
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitVerifyTestFiles writes files and commits them with message.
+func commitVerifyTestFiles(t *testing.T, repo *git.Repository, files map[string]string, message string, when time.Time) plumbing.Hash {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		if _, err := wt.Add(path); err != nil {
+			t.Fatalf("failed to stage %s: %v", path, err)
+		}
+	}
+	sig := &object.Signature{Name: "test", Email: "test@test.com", When: when}
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: sig, Committer: sig})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	return hash
+}
+
+func TestRunCIVerify(t *testing.T) {
+	repo, initial := setupCleanTestRepo(t)
+	store := checkpoint.NewGitStore(repo)
+	now := time.Now()
+
+	// main.go is the agent's, README.md the human's
+	first := commitVerifyTestFiles(t, repo, map[string]string{
+		"main.go":   "package main\n\nfunc main() {}\n",
+		"README.md": "# App\n",
+	}, "feat: add main\n\nEntire-Checkpoint: a1b2c3d4e5f6\n", now.Add(-2*time.Minute))
+	second := commitVerifyTestFiles(t, repo, map[string]string{
+		"README.md": "# App\n\nUsage.\n",
+	}, "docs: usage\n\nEntire-Checkpoint: b1b2c3d4e5f6\n", now.Add(-time.Minute))
+	commitVerifyTestFiles(t, repo, map[string]string{"notes.txt": "by hand\n"}, "chore: notes", now)
+
+	write := func(cpID string, attr *checkpoint.InitialAttribution, filesTouched []string) {
+		t.Helper()
+		if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+			CheckpointID:       id.MustCheckpointID(cpID),
+			SessionID:          "2026-10-14-" + cpID,
+			Strategy:           "manual-commit",
+			Agent:              agent.AgentTypeClaudeCode,
+			Transcript:         []byte(`{"type":"user","message":"hi"}` + "\n"),
+			FilesTouched:       filesTouched,
+			InitialAttribution: attr,
+		}); err != nil {
+			t.Fatalf("WriteCommitted() error = %v", err)
+		}
+	}
+	write("a1b2c3d4e5f6", &checkpoint.InitialAttribution{
+		BaseCommit: initial.String(),
+		AgentLines: 3, HumanAdded: 1, TotalCommitted: 4, AgentPercentage: 75,
+		Files: []checkpoint.FileAttribution{
+			{Path: "README.md", HumanAdded: 1, TotalCommitted: 1},
+			{Path: "main.go", AgentLines: 3, TotalCommitted: 3, AgentPercentage: 100, AgentRanges: []checkpoint.LineRange{{Start: 1, End: 3}}},
+		},
+	}, []string{"main.go"})
+	// The commit added 2 lines to README.md, the record claims 5
+	write("b1b2c3d4e5f6", &checkpoint.InitialAttribution{
+		BaseCommit: first.String(),
+		HumanAdded: 5, TotalCommitted: 5,
+		Files: []checkpoint.FileAttribution{{Path: "README.md", HumanAdded: 5, TotalCommitted: 5}},
+	}, []string{"main.go"})
+
+	var out, errOut bytes.Buffer
+	err := runCIVerify(context.Background(), &out, &errOut, initial.String()+"..HEAD", "", false)
+	if !errors.Is(err, errVerifyFailed) {
+		t.Fatalf("runCIVerify() error = %v, want errVerifyFailed", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"  ok    " + first.String()[:7] + " feat: add main",
+		"  FAIL  " + second.String()[:7] + " docs: usage",
+		"README.md: recorded 0 agent, 5 added, 0 modified, 0 removed, 5 committed, the commit has 0 agent, 2 added",
+		"1 commit(s) verified, 1 failed, 1 without a checkpoint.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output =\n%s\nwant it to contain %q", got, want)
+		}
+	}
+
+	// The first commit alone passes
+	out.Reset()
+	if err := runCIVerify(context.Background(), &out, &errOut, initial.String()+".."+first.String(), "", true); err != nil {
+		t.Errorf("runCIVerify(first) error = %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), `"recomputed": true`) {
+		t.Errorf("JSON output =\n%s", out.String())
+	}
+}
+
+func TestRunCIVerify_MissingCheckpoint(t *testing.T) {
+	repo, initial := setupCleanTestRepo(t)
+	commitVerifyTestFiles(t, repo, map[string]string{"main.go": "package main\n"}, "feat\n\nEntire-Checkpoint: c1b2c3d4e5f6\n", time.Now())
+
+	var out bytes.Buffer
+	err := runCIVerify(context.Background(), &out, &out, initial.String()+"..", "", false)
+	if !errors.Is(err, errVerifyFailed) || !strings.Contains(out.String(), "checkpoint c1b2c3d4e5f6 isn't on entire/checkpoints/v1") {
+		t.Errorf("runCIVerify() error = %v, output =\n%s", err, out.String())
+	}
+}
@@ -0,0 +1,90 @@
+// Package cienv detects continuous integration runners. CI checkouts run
+// agents without a terminal, often on a detached HEAD in a throwaway
+// worktree, so Entire keys their data by commit and skips interactive features.
+package cienv
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvVar forces CI mode on ("1", "true") or off ("0", "false"), overriding
+// detection from the provider's environment variables.
+const EnvVar = "ENTIRE_CI"
+
+// Environment describes the CI runner Entire is running in, if any.
+type Environment struct {
+	// Enabled is true when running in CI.
+	Enabled bool
+
+	// Provider names the CI service (e.g. "GitHub Actions"), or "CI" when
+	// only the generic CI variable is set. Empty outside CI.
+	Provider string
+
+	// Branch is the branch being built as reported by the provider. CI often
+	// checks out a detached HEAD, so git alone can't tell. Empty if unknown.
+	Branch string
+}
+
+type provider struct {
+	name string
+	// marker is the variable whose presence identifies the provider.
+	marker string
+	// branchVars are tried in order; the first non-empty one is the branch.
+	branchVars []string
+}
+
+var providers = []provider{
+	{"GitHub Actions", "GITHUB_ACTIONS", []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME"}},
+	{"GitLab CI", "GITLAB_CI", []string{"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME"}},
+	{"Buildkite", "BUILDKITE", []string{"BUILDKITE_BRANCH"}},
+	{"CircleCI", "CIRCLECI", []string{"CIRCLE_BRANCH"}},
+	{"Jenkins", "JENKINS_URL", []string{"CHANGE_BRANCH", "BRANCH_NAME", "GIT_BRANCH"}},
+	{"Azure Pipelines", "TF_BUILD", []string{"SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH"}},
+}
+
+// Detect inspects the environment for a CI runner.
+func Detect() Environment {
+	override := strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar)))
+	if override == "0" || override == "false" {
+		return Environment{}
+	}
+	for _, p := range providers {
+		if os.Getenv(p.marker) == "" {
+			continue
+		}
+		env := Environment{Enabled: true, Provider: p.name}
+		for _, v := range p.branchVars {
+			if branch := normalizeBranch(os.Getenv(v)); branch != "" {
+				env.Branch = branch
+				break
+			}
+		}
+		return env
+	}
+	if ci := strings.ToLower(os.Getenv("CI")); ci == "true" || ci == "1" {
+		return Environment{Enabled: true, Provider: "CI"}
+	}
+	if override == "1" || override == "true" {
+		return Environment{Enabled: true, Provider: "CI"}
+	}
+	return Environment{}
+}
+
+// Enabled reports whether Entire is running in CI.
+func Enabled() bool {
+	return Detect().Enabled
+}
+
+// normalizeBranch strips the ref prefixes some providers include
+// ("refs/heads/main", "origin/main").
+func normalizeBranch(branch string) string {
+	branch = strings.TrimSpace(branch)
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	branch = strings.TrimPrefix(branch, "origin/")
+	if strings.HasPrefix(branch, "refs/") {
+		// Tags and pull request refs aren't branches
+		return ""
+	}
+	return branch
+}
@@ -0,0 +1,51 @@
+package cienv
+
+import "testing"
+
+// clearCIEnv unsets the variables Detect looks at, so the tests behave the
+// same on a developer machine and on a CI runner.
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(EnvVar, "")
+	t.Setenv("CI", "")
+	for _, p := range providers {
+		t.Setenv(p.marker, "")
+		for _, v := range p.branchVars {
+			t.Setenv(v, "")
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Environment
+	}{
+		{"not CI", nil, Environment{}},
+		{"generic", map[string]string{"CI": "true"}, Environment{Enabled: true, Provider: "CI"}},
+		{"GitHub push", map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF_NAME": "main"},
+			Environment{Enabled: true, Provider: "GitHub Actions", Branch: "main"}},
+		{"GitHub pull request", map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_HEAD_REF": "feature", "GITHUB_REF_NAME": "42/merge"},
+			Environment{Enabled: true, Provider: "GitHub Actions", Branch: "feature"}},
+		{"Jenkins remote branch", map[string]string{"JENKINS_URL": "https://ci.example.com", "GIT_BRANCH": "origin/release"},
+			Environment{Enabled: true, Provider: "Jenkins", Branch: "release"}},
+		{"Azure full ref", map[string]string{"TF_BUILD": "True", "BUILD_SOURCEBRANCH": "refs/heads/main"},
+			Environment{Enabled: true, Provider: "Azure Pipelines", Branch: "main"}},
+		{"Azure tag", map[string]string{"TF_BUILD": "True", "BUILD_SOURCEBRANCH": "refs/tags/v1.0"},
+			Environment{Enabled: true, Provider: "Azure Pipelines"}},
+		{"forced on", map[string]string{EnvVar: "1"}, Environment{Enabled: true, Provider: "CI"}},
+		{"forced off", map[string]string{EnvVar: "false", "CI": "true", "GITLAB_CI": "true"}, Environment{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearCIEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := Detect(); got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package classify tags prompts with the kind of task they ask for (bugfix,
+// refactor, tests, docs, greenfield), so reports can break agent work down by
+// task type.
+//
+// The built-in classifier matches keywords. A repository can plug in its own
+// classifier instead, an external command configured in settings, which reads
+// the prompt on stdin and prints a task type.
+package classify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TaskType is the kind of task a prompt asks for.
+type TaskType string
+
+// Built-in task types. External classifiers may return others.
+const (
+	Bugfix     TaskType = "bugfix"
+	Refactor   TaskType = "refactor"
+	Tests      TaskType = "tests"
+	Docs       TaskType = "docs"
+	Greenfield TaskType = "greenfield"
+	// Other is a prompt no rule matched.
+	Other TaskType = "other"
+)
+
+// Classifier tags a prompt with a task type.
+type Classifier interface {
+	Classify(ctx context.Context, prompt string) TaskType
+}
+
+// rule matches the keywords of one task type. Each match scores a point.
+type rule struct {
+	taskType TaskType
+	pattern  *regexp.Regexp
+}
+
+// keywordRules are in tie-break order: when two task types score the same,
+// the earlier one wins ("fix the failing test" is a bugfix).
+var keywordRules = []rule{
+	{Bugfix, regexp.MustCompile(`\b(fix(es|ed|ing)?|bugs?|broken|crash(es|ing)?|regression|errors?|fails?|failing|panics?|doesn'?t work|not working|wrong|issue)\b`)},
+	{Tests, regexp.MustCompile(`\b(tests?|testing|unit tests?|coverage|specs?|assert(ions?)?|mocks?|fixtures?|e2e|integration tests?)\b`)},
+	{Refactor, regexp.MustCompile(`\b(refactor(ing|ed)?|clean ?up|rename|restructure|simplify|extract|reorgani[sz]e|dedup(licate)?|move .+ (to|into)|split .+ into|tidy)\b`)},
+	{Docs, regexp.MustCompile(`\b(docs?|documentation|document|readme|comments?|docstrings?|changelog|godoc|explain in|typos?)\b`)},
+	{Greenfield, regexp.MustCompile(`\b(add|implement|create|build|new|introduce|support|scaffold|set ?up|write a|feature)\b`)},
+}
+
+// Keywords is the built-in keyword classifier.
+type Keywords struct{}
+
+// Classify returns the task type whose keywords match prompt most often, or
+// Other if none match.
+func (Keywords) Classify(_ context.Context, prompt string) TaskType {
+	text := strings.ToLower(prompt)
+	best, bestScore := Other, 0
+	for _, r := range keywordRules {
+		if score := len(r.pattern.FindAllStringIndex(text, -1)); score > bestScore {
+			best, bestScore = r.taskType, score
+		}
+	}
+	return best
+}
+
+// commandTimeout bounds an external classifier, which runs inside the
+// prompt-submit hook.
+const commandTimeout = 5 * time.Second
+
+// validTaskType matches task types an external classifier may return.
+var validTaskType = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Command is an external classifier: a shell command that reads the prompt
+// on stdin and prints a task type as the first word of its output. If the
+// command fails, times out or prints something that isn't a task type,
+// Fallback classifies instead.
+type Command struct {
+	Command  string
+	Fallback Classifier
+}
+
+// Classify runs the command on prompt.
+func (c Command) Classify(ctx context.Context, prompt string) TaskType {
+	taskType, err := c.run(ctx, prompt)
+	if err != nil {
+		return c.Fallback.Classify(ctx, prompt)
+	}
+	return taskType
+}
+
+func (c Command) run(ctx context.Context, prompt string) (TaskType, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = strings.NewReader(prompt)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("classifier command failed: %w", err)
+	}
+	fields := strings.Fields(string(bytes.ToLower(out)))
+	if len(fields) == 0 || !validTaskType.MatchString(fields[0]) {
+		return "", fmt.Errorf("classifier command printed %q, not a task type", bytes.TrimSpace(out))
+	}
+	return TaskType(fields[0]), nil
+}
+
+// New returns the external classifier for command, or the keyword classifier
+// if command is empty.
+func New(command string) Classifier { //nolint:ireturn // callers choose by configuration
+	if command == "" {
+		return Keywords{}
+	}
+	return Command{Command: command, Fallback: Keywords{}}
+}
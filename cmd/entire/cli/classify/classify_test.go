@@ -0,0 +1,48 @@
+package classify
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prompt string
+		want   TaskType
+	}{
+		{"Fix the crash when the config file is missing", Bugfix},
+		{"fix the failing test in parser_test.go", Bugfix},
+		{"Add unit tests for the tokenizer and raise coverage", Tests},
+		{"Refactor the storage layer and extract an interface", Refactor},
+		{"Update the README and document the new flags", Docs},
+		{"Implement a new export command", Greenfield},
+		{"hello", Other},
+		{"", Other},
+	}
+	for _, tt := range tests {
+		if got := (Keywords{}).Classify(context.Background(), tt.prompt); got != tt.want {
+			t.Errorf("Classify(%q) = %s, want %s", tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if got := New(`read prompt; case "$prompt" in *perf*) echo "Perf tuning";; *) echo nonsense!;; esac`).Classify(ctx, "perf: speed up the parser"); got != "perf" {
+		t.Errorf("command classifier = %q, want perf", got)
+	}
+	// Output that isn't a task type falls back to the keyword rules
+	if got := New(`echo nonsense!`).Classify(ctx, "fix the crash"); got != Bugfix {
+		t.Errorf("invalid output = %q, want the keyword fallback %s", got, Bugfix)
+	}
+	if got := New(`exit 3`).Classify(ctx, "write docs for the API"); got != Docs {
+		t.Errorf("failing command = %q, want the keyword fallback %s", got, Docs)
+	}
+	if _, ok := New("").(Keywords); !ok {
+		t.Error("New(\"\") is not the keyword classifier")
+	}
+}
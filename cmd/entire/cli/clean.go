@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 	"github.com/spf13/cobra"
 )
@@ -21,7 +20,8 @@ This command finds and removes orphaned data from any strategy:
 
   Shadow branches (entire/<commit-hash>)
     Created by manual-commit strategy. Normally auto-cleaned when sessions
-    are condensed during commits.
+    are condensed during commits. Branches still needed by a live session
+    in any worktree are kept (see 'entire gc').
 
   Session state files (.git/entire-sessions/)
     Track active sessions. Orphaned when no checkpoints or shadow branches
@@ -50,10 +50,14 @@ The entire/checkpoints/v1 branch itself is never deleted.`,
 func runClean(w io.Writer, force bool) error {
 	// Initialize logging so structured logs go to .entire/logs/ instead of stderr.
 	// Error is non-fatal: if logging init fails, logs go to stderr (acceptable fallback).
-	logging.SetLogLevelGetter(GetLogLevel)
-	if err := logging.Init(""); err == nil {
-		defer logging.Close()
+	defer initLogging("")()
+
+	// Don't race a gc or clean started from another worktree
+	release, err := acquireGCLock()
+	if err != nil {
+		return err
 	}
+	defer release()
 
 	// List all cleanup items
 	items, err := strategy.ListAllCleanupItems()
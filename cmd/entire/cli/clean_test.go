@@ -2,10 +2,15 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 	"github.com/go-git/go-git/v5"
@@ -15,15 +20,7 @@ import (
 
 func setupCleanTestRepo(t *testing.T) (*git.Repository, plumbing.Hash) {
 	t.Helper()
-
-	dir := t.TempDir()
-	repo, err := git.PlainInit(dir, false)
-	if err != nil {
-		t.Fatalf("failed to init git repo: %v", err)
-	}
-
-	t.Chdir(dir)
-	paths.ClearRepoRootCache()
+	repo, _ := initTestRepo(t)
 
 	// Create initial commit
 	emptyTree := &object.Tree{Entries: []object.TreeEntry{}}
@@ -65,6 +62,59 @@ func setupCleanTestRepo(t *testing.T) (*git.Repository, plumbing.Hash) {
 	return repo, commitHash
 }
 
+// initTestRepo creates an empty repository and changes into it. Returns the
+// repository and its directory.
+func initTestRepo(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	return repo, dir
+}
+
+// writeTestCheckpoint writes a committed checkpoint, by default of a
+// manual-commit Claude Code session.
+func writeTestCheckpoint(t *testing.T, repo *git.Repository, opts checkpoint.WriteCommittedOptions) {
+	t.Helper()
+	if opts.Strategy == "" {
+		opts.Strategy = "manual-commit"
+	}
+	if opts.Agent == "" {
+		opts.Agent = agent.AgentTypeClaudeCode
+	}
+	if err := checkpoint.NewGitStore(repo).WriteCommitted(context.Background(), opts); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+}
+
+// commitTestCheckpoint writes files, commits them with a trailer linking
+// opts.CheckpointID, and writes the checkpoint (see writeTestCheckpoint).
+func commitTestCheckpoint(t *testing.T, repo *git.Repository, dir string, files map[string]string, opts checkpoint.WriteCommittedOptions) {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	if _, err := wt.Commit("Agent change\n\nEntire-Checkpoint: "+opts.CheckpointID.String()+"\n", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	writeTestCheckpoint(t, repo, opts)
+}
+
 func TestRunClean_NoOrphanedItems(t *testing.T) {
 	setupCleanTestRepo(t)
 
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+)
+
+// commitRange is the set of commits selected by a --range revision range,
+// and the checkpoints those commits link to.
+type commitRange struct {
+	Spec          string
+	Commits       map[plumbing.Hash]bool
+	CheckpointIDs map[id.CheckpointID]bool
+}
+
+// ContainsCheckpoint reports whether a commit in the range links to cpID.
+// A nil range contains every checkpoint.
+func (r *commitRange) ContainsCheckpoint(cpID id.CheckpointID) bool {
+	return r == nil || r.CheckpointIDs[cpID]
+}
+
+// addCommitRangeFlag registers --range on cmd.
+func addCommitRangeFlag(cmd *cobra.Command, spec *string) {
+	cmd.Flags().StringVar(spec, "range", "", "Only include commits in this revision range (e.g. v1.2..v1.3, main..feature, HEAD~10..)")
+}
+
+// resolveCommitRange resolves a revision range using git's syntax:
+//
+//   - "A..B": commits reachable from B but not from A
+//   - "A..", "..B": the missing side defaults to HEAD
+//   - "A...B": commits reachable from either A or B but not both
+//   - "B": B and all its ancestors
+//
+// Returns nil for an empty spec.
+func resolveCommitRange(repo *git.Repository, spec string) (*commitRange, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil //nolint:nilnil // nil range means "no filter"
+	}
+
+	var include, exclude []plumbing.Hash
+	switch {
+	case strings.Contains(spec, "..."):
+		left, right, _ := strings.Cut(spec, "...")
+		a, err := resolveRangeRevision(repo, left)
+		if err != nil {
+			return nil, err
+		}
+		b, err := resolveRangeRevision(repo, right)
+		if err != nil {
+			return nil, err
+		}
+		bases, err := mergeBases(repo, a, b)
+		if err != nil {
+			return nil, err
+		}
+		include, exclude = []plumbing.Hash{a, b}, bases
+	case strings.Contains(spec, ".."):
+		left, right, _ := strings.Cut(spec, "..")
+		a, err := resolveRangeRevision(repo, left)
+		if err != nil {
+			return nil, err
+		}
+		b, err := resolveRangeRevision(repo, right)
+		if err != nil {
+			return nil, err
+		}
+		include, exclude = []plumbing.Hash{b}, []plumbing.Hash{a}
+	default:
+		b, err := resolveRangeRevision(repo, spec)
+		if err != nil {
+			return nil, err
+		}
+		include = []plumbing.Hash{b}
+	}
+
+	excluded := make(map[plumbing.Hash]bool)
+	for _, hash := range exclude {
+		if err := walkAncestors(repo, hash, nil, func(c *object.Commit) { excluded[c.Hash] = true }); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &commitRange{
+		Spec:          spec,
+		Commits:       make(map[plumbing.Hash]bool),
+		CheckpointIDs: make(map[id.CheckpointID]bool),
+	}
+	for _, hash := range include {
+		err := walkAncestors(repo, hash, excluded, func(c *object.Commit) {
+			r.Commits[c.Hash] = true
+			if cpID, found := trailers.ParseCheckpoint(c.Message); found {
+				r.CheckpointIDs[cpID] = true
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// resolveRangeRevision resolves one side of a range; empty means HEAD.
+func resolveRangeRevision(repo *git.Repository, rev string) (plumbing.Hash, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unknown revision %q in --range: %w", rev, err)
+	}
+	return *hash, nil
+}
+
+// walkAncestors calls fn for start and each of its ancestors, skipping commits in seen.
+func walkAncestors(repo *git.Repository, start plumbing.Hash, seen map[plumbing.Hash]bool, fn func(*object.Commit)) error {
+	if seen[start] {
+		return nil
+	}
+	commit, err := repo.CommitObject(start)
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", start, err)
+	}
+	iter := object.NewCommitPreorderIter(commit, seen, nil)
+	defer iter.Close()
+	err = iter.ForEach(func(c *object.Commit) error {
+		fn(c)
+		return nil
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to walk history from %s: %w", start, err)
+	}
+	return nil
+}
+
+func mergeBases(repo *git.Repository, a, b plumbing.Hash) ([]plumbing.Hash, error) {
+	commitA, err := repo.CommitObject(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", a, err)
+	}
+	commitB, err := repo.CommitObject(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", b, err)
+	}
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find merge base: %w", err)
+	}
+	hashes := make([]plumbing.Hash, len(bases))
+	for i, base := range bases {
+		hashes[i] = base.Hash
+	}
+	return hashes, nil
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// storeRangeTestCommit writes an empty-tree commit linking to cpID.
+func storeRangeTestCommit(t *testing.T, repo *git.Repository, cpID string, parents ...plumbing.Hash) plumbing.Hash {
+	t.Helper()
+
+	parent, err := repo.CommitObject(parents[0])
+	if err != nil {
+		t.Fatalf("failed to read parent: %v", err)
+	}
+	sig := object.Signature{Name: "test", Email: "test@test.com"}
+	commit := &object.Commit{
+		TreeHash:     parent.TreeHash,
+		Author:       sig,
+		Committer:    sig,
+		Message:      "change\n\nEntire-Checkpoint: " + cpID + "\n",
+		ParentHashes: parents,
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("failed to store commit: %v", err)
+	}
+	return hash
+}
+
+func TestResolveCommitRange(t *testing.T) {
+	repo, initial := setupCleanTestRepo(t)
+
+	// initial - a - b (main)
+	//            \
+	//             c (feature)
+	a := storeRangeTestCommit(t, repo, "aaaaaaaaaaaa", initial)
+	b := storeRangeTestCommit(t, repo, "bbbbbbbbbbbb", a)
+	c := storeRangeTestCommit(t, repo, "cccccccccccc", a)
+	for name, hash := range map[string]plumbing.Hash{"main": b, "feature": c} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash)); err != nil {
+			t.Fatalf("failed to set branch %s: %v", name, err)
+		}
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))); err != nil {
+		t.Fatalf("failed to set HEAD: %v", err)
+	}
+	if _, err := repo.CreateTag("v1", a, nil); err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"main..feature", []string{"cccccccccccc"}},
+		{"v1..", []string{"bbbbbbbbbbbb"}},
+		{"..feature", []string{"cccccccccccc"}},
+		{"main...feature", []string{"bbbbbbbbbbbb", "cccccccccccc"}},
+		{"v1", []string{"aaaaaaaaaaaa"}},
+		{"feature..main", []string{"bbbbbbbbbbbb"}},
+	}
+	for _, tt := range tests {
+		r, err := resolveCommitRange(repo, tt.spec)
+		if err != nil {
+			t.Errorf("resolveCommitRange(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if len(r.CheckpointIDs) != len(tt.want) {
+			t.Errorf("resolveCommitRange(%q) checkpoints = %v, want %v", tt.spec, r.CheckpointIDs, tt.want)
+			continue
+		}
+		for _, want := range tt.want {
+			if !r.ContainsCheckpoint(id.MustCheckpointID(want)) {
+				t.Errorf("resolveCommitRange(%q) missing checkpoint %s", tt.spec, want)
+			}
+		}
+	}
+
+	if r, err := resolveCommitRange(repo, ""); err != nil || r != nil {
+		t.Errorf("empty spec = %v, %v; want nil range", r, err)
+	}
+	var all *commitRange
+	if !all.ContainsCheckpoint(id.MustCheckpointID("aaaaaaaaaaaa")) {
+		t.Error("nil range should contain every checkpoint")
+	}
+	if _, err := resolveCommitRange(repo, "main..nope"); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// Dynamic shell completion. Completion runs on every <TAB>, so these read
+// only local state and the local checkpoint branch, never the network, and
+// complete nothing rather than print errors. Candidates carry a description
+// after a tab, which zsh and fish show and bash skips.
+
+// completionFunc is the signature cobra uses for argument and flag completion.
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completionDescriptionWidth bounds descriptions so menus stay one line each.
+const completionDescriptionWidth = 50
+
+// completeCheckpointIDs completes committed checkpoint IDs, newest first.
+func completeCheckpointIDs(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return checkpointIDCandidates(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRestoreArgs completes the checkpoint, then files.
+func completeRestoreArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return checkpointIDCandidates(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// completeDiffCheckpoints completes `checkpoint diff`: a checkpoint, then a
+// second checkpoint or "worktree".
+func completeDiffCheckpoints(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return checkpointIDCandidates(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		candidates := checkpointIDCandidates(cmd.Context(), toComplete)
+		if strings.HasPrefix(diffWorktree, toComplete) {
+			candidates = append([]string{diffWorktree + "\tthe working tree"}, candidates...)
+		}
+		return candidates, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func checkpointIDCandidates(ctx context.Context, toComplete string) []string {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return nil
+	}
+	committed, err := checkpoint.NewGitStore(repo).ListCommitted(ctx)
+	if err != nil {
+		return nil
+	}
+	sort.SliceStable(committed, func(i, j int) bool {
+		return committed[i].CreatedAt.After(committed[j].CreatedAt)
+	})
+
+	var candidates []string
+	for _, info := range committed {
+		cpID := info.CheckpointID.String()
+		if !strings.HasPrefix(cpID, toComplete) {
+			continue
+		}
+		desc := info.CreatedAt.Local().Format("2006-01-02 15:04")
+		if info.Agent != "" {
+			desc += " " + string(info.Agent)
+		}
+		candidates = append(candidates, completionCandidate(cpID, desc))
+	}
+	return candidates
+}
+
+// completeSessionIDs completes the IDs of sessions in the local session
+// state, most recently active first, then sessions only known from committed
+// checkpoints.
+func completeSessionIDs(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var candidates []string
+	seen := make(map[string]bool)
+
+	if states, err := strategy.ListSessionStates(); err == nil {
+		sort.SliceStable(states, func(i, j int) bool {
+			return sessionLastActive(states[i]).After(sessionLastActive(states[j]))
+		})
+		for _, state := range states {
+			if seen[state.SessionID] || !strings.HasPrefix(state.SessionID, toComplete) {
+				continue
+			}
+			seen[state.SessionID] = true
+			desc := string(state.AgentType)
+			if state.FirstPrompt != "" {
+				desc = strings.TrimSpace(desc + " " + state.FirstPrompt)
+			}
+			candidates = append(candidates, completionCandidate(state.SessionID, desc))
+		}
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if repo, err := openRepository(); err == nil {
+		if committed, err := checkpoint.NewGitStore(repo).ListCommitted(ctx); err == nil {
+			sort.SliceStable(committed, func(i, j int) bool {
+				return committed[i].CreatedAt.After(committed[j].CreatedAt)
+			})
+			for _, info := range committed {
+				ids := info.SessionIDs
+				if len(ids) == 0 && info.SessionID != "" {
+					ids = []string{info.SessionID}
+				}
+				for _, sessionID := range ids {
+					if seen[sessionID] || !strings.HasPrefix(sessionID, toComplete) {
+						continue
+					}
+					seen[sessionID] = true
+					candidates = append(candidates, completionCandidate(sessionID, "committed "+info.CreatedAt.Local().Format("2006-01-02")))
+				}
+			}
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRewindPoints completes the rewind points `entire rewind --list` shows.
+func completeRewindPoints(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	points, err := GetStrategy().GetRewindPoints(20)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var candidates []string
+	for _, p := range points {
+		if strings.HasPrefix(p.ID, toComplete) {
+			candidates = append(candidates, completionCandidate(p.ID, p.Date.Local().Format("2006-01-02 15:04")+" "+firstLine(p.Message)))
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLocalBranches completes local branch names, leaving out Entire's own
+// shadow and metadata branches.
+func completeLocalBranches(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	repo, err := openRepository()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var candidates []string
+	_ = refs.ForEach(func(ref *plumbing.Reference) error { //nolint:errcheck // callback never fails
+		name := ref.Name().Short()
+		if !strings.HasPrefix(name, "entire/") && strings.HasPrefix(name, toComplete) {
+			candidates = append(candidates, name)
+		}
+		return nil
+	})
+	slices.Sort(candidates)
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstArg completes only the first positional argument with fn.
+func completeFirstArg(fn completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return fn(cmd, args, toComplete)
+	}
+}
+
+// completionCandidate formats a candidate with its description on one line.
+func completionCandidate(value, desc string) string {
+	desc = strings.Join(strings.Fields(desc), " ")
+	if desc == "" {
+		return value
+	}
+	return value + "\t" + stringutil.TruncateRunes(desc, completionDescriptionWidth, "...")
+}
+
+// registerFlagCompletion attaches fn to a flag of cmd.
+func registerFlagCompletion(cmd *cobra.Command, flag string, fn completionFunc) {
+	//nolint:errcheck,gosec // completion is optional, the flag is defined by the caller
+	cmd.RegisterFlagCompletionFunc(flag, fn)
+}
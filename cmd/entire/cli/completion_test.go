@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// complete runs cobra's hidden __complete command and returns the candidate
+// values, without descriptions or the trailing directive line.
+func complete(t *testing.T, args ...string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := NewRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(append([]string{"__complete"}, args...))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("__complete %v: %v", args, err)
+	}
+	var values []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		value, _, _ := strings.Cut(line, "\t")
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestCompletion_CheckpointIDs(t *testing.T) {
+	setupMCPRepo(t)
+
+	if got := complete(t, "explain", "--checkpoint", ""); len(got) != 2 {
+		t.Errorf("explain --checkpoint completions = %v, want both checkpoints", got)
+	}
+	if got := complete(t, "checkpoint", "diff", "a1"); strings.Join(got, ",") != "a1b2c3d4e5f6" {
+		t.Errorf("checkpoint diff a1<TAB> = %v", got)
+	}
+	if got := complete(t, "checkpoint", "diff", "a1b2c3d4e5f6", "w"); strings.Join(got, ",") != diffWorktree {
+		t.Errorf("checkpoint diff <id> w<TAB> = %v, want worktree", got)
+	}
+	if got := complete(t, "checkpoint", "diff", "a1b2c3d4e5f6", "b1b2c3d4e5f6", ""); len(got) != 0 {
+		t.Errorf("third checkpoint diff argument completed %v", got)
+	}
+}
+
+func TestCompletion_SessionIDs(t *testing.T) {
+	setupMCPRepo(t)
+
+	got := complete(t, "transcript", "export", "2026-10-14-s")
+	if strings.Join(got, ",") != "2026-10-14-second" {
+		t.Errorf("transcript export completions = %v", got)
+	}
+	if got := complete(t, "transcript", "export", "2026-10-14-second", ""); len(got) != 0 {
+		t.Errorf("second transcript export argument completed %v", got)
+	}
+	if got := complete(t, "explain", "--session", ""); len(got) != 2 {
+		t.Errorf("explain --session completions = %v, want both committed sessions", got)
+	}
+}
+
+func TestCompletionCandidate(t *testing.T) {
+	t.Parallel()
+	if got := completionCandidate("abc", ""); got != "abc" {
+		t.Errorf("completionCandidate without description = %q", got)
+	}
+	got := completionCandidate("abc", "claude-code fix the\nflaky   test "+strings.Repeat("x", 80))
+	value, desc, ok := strings.Cut(got, "\t")
+	if !ok || value != "abc" || strings.Contains(desc, "\n") || !strings.HasPrefix(desc, "claude-code fix the flaky test") || len([]rune(desc)) > completionDescriptionWidth {
+		t.Errorf("completionCandidate() = %q", got)
+	}
+}
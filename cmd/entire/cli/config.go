@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/settings"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/redact"
 
 	// Import claudecode to register the agent
 	_ "github.com/entireio/cli/cmd/entire/cli/agent/claudecode"
@@ -98,6 +100,19 @@ func GetLogLevel() string {
 	return s.LogLevel
 }
 
+// configureRedaction applies the redaction allowlist from settings, so secrets
+// are redacted the same way by hooks and commands. An invalid pattern is
+// reported on stderr and leaves the built-in rules in effect.
+func configureRedaction() {
+	s, err := settings.Load()
+	if err != nil || s.Redaction == nil {
+		return
+	}
+	if err := redact.SetAllowlist(s.Redaction.Allowlist); err != nil {
+		fmt.Fprintf(os.Stderr, "[entire] Warning: %v\n", err)
+	}
+}
+
 // GetAgentsWithHooksInstalled returns names of agents that have hooks installed.
 func GetAgentsWithHooksInstalled() []agent.AgentName {
 	var installed []agent.AgentName
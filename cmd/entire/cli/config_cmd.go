@@ -0,0 +1,594 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+// Settings scopes, lowest precedence first.
+const (
+	configScopeUser    = "user"
+	configScopeProject = "project"
+	configScopeLocal   = "local"
+)
+
+// configOriginDefault is the origin of values no settings file sets.
+const configOriginDefault = "default"
+
+// configEnvOverrides are settings that an environment variable replaces.
+var configEnvOverrides = map[string]string{
+	"log_level": logging.LogLevelEnvVar,
+	"state_dir": fsenv.StateDirEnvVar,
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Get, set and list settings",
+		Long: `Get, set and list settings.
+
+Settings are read from three files, each overriding the one before it field by
+field:
+
+  user     ~/.config/entire/settings.json ($XDG_CONFIG_HOME/entire/settings.json)
+  project  .entire/settings.json, shared with the team
+  local    .entire/settings.local.json, personal and gitignored
+
+ENTIRE_LOG_LEVEL and ENTIRE_STATE_DIR override log_level and state_dir, and
+command-line flags override settings.
+
+Keys are dotted paths into the settings, e.g. attribution.granularity or
+strategy_options.push_sessions.`,
+	}
+
+	cmd.AddCommand(newConfigListCmd())
+	cmd.AddCommand(newConfigGetCmd())
+	cmd.AddCommand(newConfigSetCmd())
+	cmd.AddCommand(newConfigUnsetCmd())
+
+	return cmd
+}
+
+func newConfigListCmd() *cobra.Command {
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List effective settings and where each comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runConfigList(cmd.OutOrStdout(), jsonFlag)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	return cmd
+}
+
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a setting",
+		Long: `Print the effective value of a setting. Strings are printed as is, other
+values as JSON. Exits with status 1 and prints nothing if the key is not set.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigGet(cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	var scope configScopeFlags
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting in settings.local.json, settings.json or the user settings",
+		Long: `Set a setting. The value is parsed as JSON when it is valid JSON (true, 30,
+["stop"]), and used as a string otherwise.
+
+Writes .entire/settings.local.json unless --project or --user is given. The
+file is validated before it is written.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := scope.scope()
+			if err != nil {
+				return err
+			}
+			return runConfigSet(cmd.OutOrStdout(), target, args[0], args[1])
+		},
+	}
+	scope.register(cmd)
+	return cmd
+}
+
+func newConfigUnsetCmd() *cobra.Command {
+	var scope configScopeFlags
+
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a setting from settings.local.json, settings.json or the user settings",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := scope.scope()
+			if err != nil {
+				return err
+			}
+			return runConfigUnset(cmd.OutOrStdout(), target, args[0])
+		},
+	}
+	scope.register(cmd)
+	return cmd
+}
+
+type configScopeFlags struct {
+	project bool
+	user    bool
+}
+
+func (f *configScopeFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.project, "project", false, "Write .entire/settings.json instead of settings.local.json")
+	cmd.Flags().BoolVar(&f.user, "user", false, "Write the user settings file, which applies to every repository")
+	cmd.MarkFlagsMutuallyExclusive("project", "user")
+}
+
+func (f *configScopeFlags) scope() (string, error) {
+	switch {
+	case f.user:
+		if settings.UserSettingsFile() == "" {
+			return "", errors.New("no home directory for the user settings file; set XDG_CONFIG_HOME")
+		}
+		return configScopeUser, nil
+	case f.project:
+		return configScopeProject, nil
+	default:
+		return configScopeLocal, nil
+	}
+}
+
+// configScopePath returns the settings file of scope.
+func configScopePath(scope string) string {
+	switch scope {
+	case configScopeUser:
+		return settings.UserSettingsFile()
+	case configScopeProject:
+		if p, err := paths.AbsPath(settings.EntireSettingsFile); err == nil {
+			return p
+		}
+		return settings.EntireSettingsFile
+	default:
+		if p, err := paths.AbsPath(settings.EntireSettingsLocalFile); err == nil {
+			return p
+		}
+		return settings.EntireSettingsLocalFile
+	}
+}
+
+// configEntry is one effective setting in `entire config list`.
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Origin string `json:"origin"`
+}
+
+func runConfigList(w io.Writer, jsonOutput bool) error {
+	entries, err := effectiveConfigEntries()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal settings: %w", err)
+		}
+		_, err = w.Write(data)
+		return err //nolint:wrapcheck // write to stdout
+	}
+
+	keyWidth := len("KEY")
+	for _, e := range entries {
+		keyWidth = max(keyWidth, len(e.Key))
+	}
+	fmt.Fprintf(w, "%-*s  %-10s  %s\n", keyWidth, "KEY", "ORIGIN", "VALUE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%-*s  %-10s  %s\n", keyWidth, e.Key, e.Origin, formatConfigValue(e.Value))
+	}
+	return nil
+}
+
+func runConfigGet(w io.Writer, key string) error {
+	if !isSettingsKey(key) {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if env, ok := configEnvOverrides[key]; ok && os.Getenv(env) != "" {
+		fmt.Fprintln(w, os.Getenv(env))
+		return nil
+	}
+
+	effective, err := effectiveSettingsMap()
+	if err != nil {
+		return err
+	}
+	value, ok := lookupConfigPath(effective, key)
+	if !ok {
+		return NewSilentError(fmt.Errorf("%s is not set", key))
+	}
+	fmt.Fprintln(w, formatConfigValue(value))
+	return nil
+}
+
+func runConfigSet(w io.Writer, scope, key, rawValue string) error {
+	if !isSettingsKey(key) {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	path := configScopePath(scope)
+	m, err := readSettingsMap(path)
+	if err != nil {
+		return err
+	}
+	if err := setConfigPath(m, key, parseConfigValue(rawValue)); err != nil {
+		return err
+	}
+	if err := validateSettingsMap(m); err != nil {
+		return err
+	}
+	if err := writeSettingsMap(path, m); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Set %s in %s\n", key, path)
+	return nil
+}
+
+func runConfigUnset(w io.Writer, scope, key string) error {
+	if !isSettingsKey(key) {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	path := configScopePath(scope)
+	m, err := readSettingsMap(path)
+	if err != nil {
+		return err
+	}
+	if !deleteConfigPath(m, key) {
+		fmt.Fprintf(w, "%s is not set in %s\n", key, path)
+		return nil
+	}
+	if err := writeSettingsMap(path, m); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Unset %s in %s\n", key, path)
+	return nil
+}
+
+// effectiveSettingsMap returns the merged settings as a JSON object.
+func effectiveSettingsMap() (map[string]any, error) {
+	s, err := LoadEntireSettings()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	return decodeSettingsMap(data)
+}
+
+// effectiveConfigEntries flattens the effective settings into dotted keys and
+// finds the origin of each: an environment variable or the settings file with
+// the highest precedence that sets it.
+func effectiveConfigEntries() ([]configEntry, error) {
+	effective, err := effectiveSettingsMap()
+	if err != nil {
+		return nil, err
+	}
+	scopes := []string{configScopeLocal, configScopeProject, configScopeUser}
+	files := make(map[string]map[string]any, len(scopes))
+	for _, scope := range scopes {
+		if path := configScopePath(scope); path != "" {
+			if files[scope], err = readSettingsMap(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	flat := make(map[string]any)
+	flattenConfig("", effective, flat)
+	for key, env := range configEnvOverrides {
+		if v := os.Getenv(env); v != "" {
+			flat[key] = v
+		}
+	}
+
+	entries := make([]configEntry, 0, len(flat))
+	for key, value := range flat {
+		origin := configOriginDefault
+		if env, ok := configEnvOverrides[key]; ok && os.Getenv(env) != "" {
+			origin = "env:" + env
+		} else {
+			for _, scope := range scopes {
+				if _, ok := lookupConfigPath(files[scope], key); ok {
+					origin = scope
+					break
+				}
+			}
+		}
+		entries = append(entries, configEntry{Key: key, Value: value, Origin: origin})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// flattenConfig adds every leaf of m to out under its dotted key. Arrays are leaves.
+func flattenConfig(prefix string, m map[string]any, out map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flattenConfig(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func lookupConfigPath(m map[string]any, key string) (any, bool) {
+	var cur any = m
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func setConfigPath(m map[string]any, key string, value any) error {
+	parts := strings.Split(key, ".")
+	cur := m
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := cur[part]
+		if !ok {
+			child := make(map[string]any)
+			cur[part] = child
+			cur = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not an object", key, strings.Join(parts[:i+1], "."))
+		}
+		cur = child
+	}
+	cur[parts[len(parts)-1]] = value
+	return nil
+}
+
+// deleteConfigPath removes key from m along with objects it leaves empty.
+func deleteConfigPath(m map[string]any, key string) bool {
+	part, rest, nested := strings.Cut(key, ".")
+	if !nested {
+		_, ok := m[part]
+		delete(m, part)
+		return ok
+	}
+	child, ok := m[part].(map[string]any)
+	if !ok || !deleteConfigPath(child, rest) {
+		return false
+	}
+	if len(child) == 0 {
+		delete(m, part)
+	}
+	return true
+}
+
+// parseConfigValue parses a command-line value as JSON, falling back to the
+// plain string.
+func parseConfigValue(raw string) any {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return raw
+	}
+	return v
+}
+
+func formatConfigValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// isSettingsKey reports whether key names a settings field, or a path below
+// a free-form object such as strategy_options.
+func isSettingsKey(key string) bool {
+	t := reflect.TypeFor[settings.EntireSettings]()
+	for _, part := range strings.Split(key, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() { //nolint:exhaustive // only objects have keys
+		case reflect.Map:
+			return part != ""
+		case reflect.Struct:
+			field, ok := settingsField(t, part)
+			if !ok {
+				return false
+			}
+			t = field.Type
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func settingsField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func readSettingsMap(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is a settings file location
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]any), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	m, err := decodeSettingsMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return m, nil
+}
+
+func decodeSettingsMap(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	m := make(map[string]any)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing settings: %w", err)
+	}
+	return m, nil
+}
+
+func writeSettingsMap(path string, m map[string]any) error {
+	data, err := jsonutil.MarshalIndentWithNewline(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	//nolint:gosec // G306: settings file is config, not secrets; 0o644 is appropriate
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// validateSettingsMap checks that m is a valid settings file.
+func validateSettingsMap(m map[string]any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	var s settings.EntireSettings
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	return validateSettings(&s)
+}
+
+// validateSettings checks the values the settings types can't express.
+func validateSettings(s *settings.EntireSettings) error {
+	if s.Strategy != "" {
+		if _, err := strategy.Get(s.Strategy); err != nil {
+			return fmt.Errorf("invalid strategy %q: use one of %s", s.Strategy, strings.Join(strategy.List(), ", "))
+		}
+	}
+	if _, err := s.Attribution.EffectiveGranularity(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Attribution.EffectiveMergeCommits(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Attribution.EffectiveCheckpoints(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Attribution.EffectiveNormalize(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Attribution.EffectiveGenerated(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Reporting.Location(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Reporting.FirstWeekday(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if s.Redaction != nil {
+		for _, pattern := range s.Redaction.Allowlist {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid redaction allowlist pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	if r := s.Retention; r != nil {
+		if r.OlderThan != "" {
+			if _, err := parseRetentionDuration(r.OlderThan); err != nil {
+				return fmt.Errorf("invalid retention older_than: %w", err)
+			}
+		}
+		if r.KeepPerSession < 0 {
+			return errors.New("invalid retention keep_per_session: must not be negative")
+		}
+	}
+	if _, err := s.Quota.Limit(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.SessionExpiry.EffectiveTTL(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Workspace.RepoRoots("."); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, _, _, err := s.RepositoryBackend.Effective(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, _, err := s.SizeLimits.Limits(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Encryption.EffectiveKeySource(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.PushPolicy.EffectiveAction(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if err := s.PromptPolicy.Validate(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.OpenTelemetry.EffectiveEndpoint(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	if _, err := s.Summarizer.EffectiveProvider(); err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	return nil
+}
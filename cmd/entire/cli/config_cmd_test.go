@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+)
+
+func TestConfigSetGetList(t *testing.T) {
+	setupTestRepo(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(logging.LogLevelEnvVar, "")
+
+	var out bytes.Buffer
+	for _, step := range []struct{ scope, key, value string }{
+		{configScopeUser, "attribution.granularity", "word"},
+		{configScopeUser, "log_level", "warn"},
+		{configScopeProject, "attribution.merge_commits", "skip"},
+		{configScopeProject, "disabled_hooks", `["stop"]`},
+		{configScopeLocal, "log_level", "debug"},
+		{configScopeLocal, "retention.keep_per_session", "3"},
+	} {
+		if err := runConfigSet(&out, step.scope, step.key, step.value); err != nil {
+			t.Fatalf("set %s=%s in %s: %v", step.key, step.value, step.scope, err)
+		}
+	}
+
+	got := func(key string) string {
+		t.Helper()
+		out.Reset()
+		if err := runConfigGet(&out, key); err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+	if v := got("attribution"); v != `{"granularity":"word","merge_commits":"skip"}` {
+		t.Errorf("attribution = %s, want user and project fields merged", v)
+	}
+	if v := got("log_level"); v != "debug" {
+		t.Errorf("log_level = %s, want the local value", v)
+	}
+	if v := got("disabled_hooks"); v != `["stop"]` {
+		t.Errorf("disabled_hooks = %s", v)
+	}
+	if v := got("retention.keep_per_session"); v != "3" {
+		t.Errorf("retention.keep_per_session = %s", v)
+	}
+
+	entries, err := effectiveConfigEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origins := make(map[string]string)
+	for _, e := range entries {
+		origins[e.Key] = e.Origin
+	}
+	wantOrigins := map[string]string{
+		"attribution.granularity":   configScopeUser,
+		"attribution.merge_commits": configScopeProject,
+		"log_level":                 configScopeLocal,
+		"strategy":                  configOriginDefault,
+	}
+	for key, want := range wantOrigins {
+		if origins[key] != want {
+			t.Errorf("origin of %s = %q, want %q", key, origins[key], want)
+		}
+	}
+
+	t.Setenv(logging.LogLevelEnvVar, "error")
+	if v := got("log_level"); v != "error" {
+		t.Errorf("log_level with %s set = %s", logging.LogLevelEnvVar, v)
+	}
+
+	if err := runConfigUnset(&out, configScopeLocal, "retention.keep_per_session"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(".entire", "settings.local.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var local map[string]any
+	if err := json.Unmarshal(data, &local); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := local["retention"]; ok {
+		t.Errorf("unset left an empty retention object: %s", data)
+	}
+	if err := runConfigGet(&out, "retention.keep_per_session"); err == nil {
+		t.Error("get of an unset key succeeded")
+	}
+}
+
+func TestConfigSet_Validates(t *testing.T) {
+	setupTestRepo(t)
+
+	tests := []struct {
+		key, value, wantErr string
+	}{
+		{"no_such_setting", "1", "unknown setting"},
+		{"attribution.nope", "1", "unknown setting"},
+		{"enabled", "maybe", "invalid settings"},
+		{"attribution.granularity", "sentence", "invalid attribution granularity"},
+		{"reporting.timezone", "Mars/Olympus", "invalid reporting timezone"},
+		{"retention.older_than", "soon", "invalid retention older_than"},
+		{"strategy", "yolo", "invalid strategy"},
+		{"redaction.allowlist", `["("]`, "invalid redaction allowlist pattern"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := runConfigSet(&out, configScopeLocal, tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("set %s=%s: error = %v, want %q", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(".entire", "settings.local.json")); !os.IsNotExist(err) {
+		t.Errorf("rejected values were written: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runConfigSet(&out, configScopeLocal, "strategy_options.summarize.enabled", "true"); err != nil {
+		t.Errorf("free-form strategy_options key rejected: %v", err)
+	}
+}
+
+func TestParseConfigValue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"true", "true"},
+		{"30", "30"},
+		{`["a","b"]`, `["a","b"]`},
+		{"30d", `"30d"`},
+		{"Europe/Berlin", `"Europe/Berlin"`},
+		{`"quoted"`, `"quoted"`},
+		{"1 2", `"1 2"`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(parseConfigValue(tt.raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("parseConfigValue(%q) = %s, want %s", tt.raw, data, tt.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// daemonDefaultDebounce is how long `entire daemon` waits for a burst of
+// file changes (a save, a formatter run) to settle before recording it.
+const daemonDefaultDebounce = 2 * time.Second
+
+func newDaemonCmd() *cobra.Command {
+	var debounce time.Duration
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Watch the worktree and record your edits between agent turns",
+		Long: `Runs in the foreground and watches this worktree for file changes. While a
+session is idle (its agent isn't working on a turn), each change you make is
+recorded in the session as a human edit, with its time, files and line
+counts, and condensed into the next commit's checkpoint.
+
+Human edits date your work between hooks; attribution already counts it at
+the next prompt or commit. Changes while the agent is working aren't
+recorded, since they can't be told apart from the agent's. Files git ignores,
+.git and .entire aren't watched. Stop the daemon with Ctrl-C. Hooks don't
+need the daemon: session start warms up the first checkpoint's caches in a
+process of its own.
+
+With --metrics-addr, the daemon also serves the Prometheus metrics of
+'entire serve' at /metrics on that address.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if debounce <= 0 {
+				return errors.New("--debounce must be positive")
+			}
+			return runDaemon(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), debounce, metricsAddr)
+		},
+	}
+
+	cmd.Flags().DurationVar(&debounce, "debounce", daemonDefaultDebounce, "How long changes must settle before they are recorded")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at /metrics on this address (e.g. 127.0.0.1:9681)")
+
+	return cmd
+}
+
+func runDaemon(ctx context.Context, w, errW io.Writer, debounce time.Duration, metricsAddr string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	root, err := strategy.GetWorktreePath()
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	if metricsAddr != "" {
+		listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", metricsAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", metricsAddr, err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /metrics", serveMetrics)
+		go func() {
+			if err := serveOn(ctx, w, listener, mux, "metrics", "/metrics"); err != nil {
+				fmt.Fprintf(errW, "Warning: %v\n", err)
+			}
+		}()
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watchTree(ctx, watcher, root, root); err != nil {
+		return err
+	}
+
+	recorder := strategy.NewHumanEditRecorder(root)
+	fmt.Fprintf(w, "Watching %s for edits between agent turns (Ctrl-C to stop)\n", root)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(errW, "Warning: file watcher: %v\n", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			rel, relErr := filepath.Rel(root, event.Name)
+			if relErr != nil || daemonSkipped(rel) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+					if err := watchTree(ctx, watcher, root, event.Name); err != nil {
+						fmt.Fprintf(errW, "Warning: %v\n", err)
+					}
+					continue
+				}
+			}
+			pending[filepath.ToSlash(rel)] = true
+			timer.Reset(debounce)
+		case <-timer.C:
+			files := make([]string, 0, len(pending))
+			for file := range pending {
+				files = append(files, file)
+			}
+			clear(pending)
+			sort.Strings(files)
+			files = withoutIgnored(ctx, root, files)
+			if len(files) == 0 {
+				continue
+			}
+			sessionID, edit, err := recorder.Record(ctx, files)
+			if err != nil {
+				fmt.Fprintf(errW, "Warning: %v\n", err)
+				continue
+			}
+			if edit != nil {
+				fmt.Fprintf(w, "%s  Recorded human edit in session %s: %s (+%d/-%d)\n",
+					edit.At.Local().Format("15:04:05"), sessionID, strings.Join(edit.Files, ", "), edit.LinesAdded, edit.LinesRemoved)
+			}
+		}
+	}
+}
+
+// watchTree adds dir and the directories under it that git doesn't ignore to
+// watcher. fsnotify doesn't watch subdirectories on its own.
+func watchTree(ctx context.Context, watcher *fsnotify.Watcher, root, dir string) error {
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // skip unreadable directories
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if rel, relErr := filepath.Rel(root, path); relErr == nil && rel != "." && daemonSkipped(rel) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+
+	ignored := make(map[string]bool)
+	rels := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		if rel, relErr := filepath.Rel(root, d); relErr == nil && rel != "." {
+			rels = append(rels, filepath.ToSlash(rel)+"/")
+		}
+	}
+	for _, rel := range gitIgnored(ctx, root, rels) {
+		ignored[strings.TrimSuffix(rel, "/")] = true
+	}
+
+	for _, d := range dirs {
+		rel, _ := filepath.Rel(root, d) //nolint:errcheck // d is under root
+		if isUnderIgnored(filepath.ToSlash(rel), ignored) {
+			continue
+		}
+		if err := watcher.Add(d); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", d, err)
+		}
+	}
+	return nil
+}
+
+// isUnderIgnored reports whether rel or one of its parent directories is in ignored.
+func isUnderIgnored(rel string, ignored map[string]bool) bool {
+	for p := rel; p != "." && p != "/" && p != ""; p = filepath.ToSlash(filepath.Dir(p)) {
+		if ignored[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// daemonSkipped reports whether the daemon leaves rel (relative to the
+// worktree root) alone: git's and Entire's own directories.
+func daemonSkipped(rel string) bool {
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return first == ".git" || first == paths.EntireDir
+}
+
+// withoutIgnored returns files without the ones git ignores.
+func withoutIgnored(ctx context.Context, root string, files []string) []string {
+	ignored := make(map[string]bool)
+	for _, file := range gitIgnored(ctx, root, files) {
+		ignored[file] = true
+	}
+	kept := files[:0]
+	for _, file := range files {
+		if !ignored[file] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// gitIgnored returns which of paths (relative to root) git ignores. Errors
+// leave everything unignored.
+func gitIgnored(ctx context.Context, root string, paths []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "check-ignore", "--stdin", "-z")
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	output, err := cmd.Output()
+	// Exit status 1 means none of the paths are ignored
+	if err != nil && len(output) == 0 {
+		return nil
+	}
+	var ignored []string
+	for _, p := range bytes.Split(output, []byte{0}) {
+		if len(p) > 0 {
+			ignored = append(ignored, string(p))
+		}
+	}
+	return ignored
+}
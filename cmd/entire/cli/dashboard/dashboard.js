@@ -0,0 +1,220 @@
+// Entire dashboard. Reads the API of the server it is served from and reloads
+// whenever /api/v1/events reports a change. No dependencies.
+"use strict";
+
+const PRICE_FIELDS = ["input_price", "output_price", "cache_read_price"];
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path, { headers: { Accept: "application/json" } });
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(`${path}: ${body.error || resp.status}`);
+  }
+  return body;
+}
+
+// el builds an element with text content (never HTML) and optional class.
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined && text !== null) node.textContent = String(text);
+  if (className) node.className = className;
+  return node;
+}
+
+function row(cells) {
+  const tr = document.createElement("tr");
+  for (const cell of cells) tr.appendChild(cell instanceof Node ? wrap(cell) : el("td", cell));
+  return tr;
+}
+
+function wrap(node) {
+  if (node.tagName === "TD") return node;
+  const td = document.createElement("td");
+  td.appendChild(node);
+  return td;
+}
+
+function fill(tbody, rows, emptyText, columns) {
+  if (rows.length === 0) {
+    const td = el("td", emptyText, "muted");
+    td.colSpan = columns;
+    rows = [row([td])];
+  }
+  tbody.replaceChildren(...rows);
+}
+
+function num(n) {
+  return el("td", Number(n).toLocaleString(), "num");
+}
+
+function ago(iso) {
+  if (!iso) return "";
+  const seconds = (Date.now() - new Date(iso).getTime()) / 1000;
+  if (seconds < 60) return "just now";
+  if (seconds < 3600) return `${Math.floor(seconds / 60)}m ago`;
+  if (seconds < 86400) return `${Math.floor(seconds / 3600)}h ago`;
+  return `${Math.floor(seconds / 86400)}d ago`;
+}
+
+function truncate(text, n) {
+  if (!text) return "";
+  const chars = Array.from(text);
+  return chars.length > n ? chars.slice(0, n).join("") + "…" : text;
+}
+
+// bars draws a bar chart of values (null for no data) with a title per bar.
+function bars(container, values, titles, max) {
+  const top = max || Math.max(1, ...values.filter((v) => v !== null));
+  container.replaceChildren(
+    ...values.map((v, i) => {
+      const bar = el("div", null, v === null || v === 0 ? "bar empty" : "bar");
+      const fillEl = document.createElement("div");
+      if (v !== null && v > 0) fillEl.style.height = `${(v / top) * 100}%`;
+      bar.appendChild(fillEl);
+      bar.title = titles[i];
+      return bar;
+    }),
+  );
+}
+
+function prices() {
+  const params = new URLSearchParams();
+  for (const name of PRICE_FIELDS) {
+    const value = localStorage.getItem(`entire.${name}`);
+    if (value) params.set(name, value);
+  }
+  return params;
+}
+
+function renderAttribution(a) {
+  $("agent-share").textContent = a.agent_share === undefined ? "–" : `${a.agent_share.toFixed(1)}%`;
+  $("agent-lines").textContent = `${a.agent_lines.toLocaleString()} of ${a.total_committed.toLocaleString()} lines`;
+  $("checkpoint-count").textContent = a.checkpoints.toLocaleString();
+  fill(
+    $("agents"),
+    a.agents.map((g) => row([el("td", g.agent || "unknown"), num(g.checkpoints), num(g.sessions), num(g.agent_lines)])),
+    "No checkpoints yet.",
+    4,
+  );
+}
+
+function renderStats(s) {
+  $("commit-count").textContent = `${s.commits.toLocaleString()} commit(s) with checkpoints`;
+  bars(
+    $("weeks-chart"),
+    s.weeks.map((w) => (w.agent_share === undefined ? null : w.agent_share)),
+    s.weeks.map((w) => {
+      const start = w.start.slice(0, 10);
+      return w.agent_share === undefined ? `${start}: no attributed commits` : `${start}: ${w.agent_share.toFixed(1)}% (${w.agent_lines} of ${w.total_committed} lines)`;
+    }),
+    100,
+  );
+  $("days-title").textContent = s.has_cost ? "Cost per day" : "Tokens per day";
+  bars(
+    $("days-chart"),
+    s.days.map((d) => (s.has_cost ? d.cost || 0 : d.tokens)),
+    s.days.map((d) => (s.has_cost ? `${d.date}: $${(d.cost || 0).toFixed(2)}` : `${d.date}: ${d.tokens.toLocaleString()} tokens`)),
+  );
+}
+
+function renderSessions(resp) {
+  const sessions = resp.sessions || [];
+  const active = sessions.filter((s) => !s.ended_at && s.phase !== "ended");
+  $("active-sessions").textContent = active.length.toLocaleString();
+  $("session-count").textContent = `${sessions.length.toLocaleString()} tracked`;
+  fill(
+    $("sessions"),
+    sessions.map((s) =>
+      row([
+        el("code", s.session_id),
+        el("td", s.agent || "unknown"),
+        el("td", s.phase),
+        el("td", s.worktree_id || "main"),
+        num(s.steps),
+        el("td", ago(s.started_at)),
+        el("td", truncate(s.first_prompt, 80)),
+      ]),
+    ),
+    "No sessions in this clone.",
+    7,
+  );
+}
+
+function renderCheckpoints(page) {
+  fill(
+    $("checkpoints"),
+    page.checkpoints.map((c) =>
+      row([
+        el("code", c.checkpoint_id),
+        el("td", ago(c.created_at)),
+        el("td", c.agent || "unknown"),
+        el("code", c.session_id),
+        num(c.steps),
+        num((c.files_touched || []).length),
+      ]),
+    ),
+    "No committed checkpoints yet.",
+    6,
+  );
+}
+
+async function refresh() {
+  try {
+    const [attribution, stats, sessions, checkpoints] = await Promise.all([
+      getJSON("/api/v1/attribution"),
+      getJSON(`/api/v1/stats?${prices()}`),
+      getJSON("/api/v1/local-sessions"),
+      getJSON("/api/v1/checkpoints?limit=20"),
+    ]);
+    renderAttribution(attribution);
+    renderStats(stats);
+    renderSessions(sessions);
+    renderCheckpoints(checkpoints);
+    $("error").hidden = true;
+  } catch (err) {
+    $("error").textContent = err.message;
+    $("error").hidden = false;
+  }
+}
+
+function listen() {
+  const live = $("live");
+  const events = new EventSource("/api/v1/events");
+  events.addEventListener("open", () => {
+    live.textContent = "● live";
+    live.className = "live on";
+  });
+  events.addEventListener("update", () => refresh());
+  events.addEventListener("error", (e) => {
+    if (e.data) {
+      $("error").textContent = JSON.parse(e.data).error;
+      $("error").hidden = false;
+    } else {
+      // EventSource reconnects by itself
+      live.textContent = "○ reconnecting";
+      live.className = "live off";
+    }
+  });
+}
+
+function setupPrices() {
+  const form = $("prices");
+  for (const name of PRICE_FIELDS) {
+    form.elements[name].value = localStorage.getItem(`entire.${name}`) || "";
+  }
+  form.addEventListener("change", () => {
+    for (const name of PRICE_FIELDS) {
+      const value = form.elements[name].value;
+      if (value) localStorage.setItem(`entire.${name}`, value);
+      else localStorage.removeItem(`entire.${name}`);
+    }
+    refresh();
+  });
+}
+
+setupPrices();
+listen();
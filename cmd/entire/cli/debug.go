@@ -66,7 +66,7 @@ func runDebugAutoCommit(w io.Writer, transcriptPath string) error {
 
 	// Print strategy info
 	strat := GetStrategy()
-	isAutoCommit := strat.Name() == strategy.StrategyNameAutoCommit
+	isAutoCommit := strategy.CommitsToActiveBranch(strat.Name())
 	printStrategyInfo(w, strat, isAutoCommit)
 
 	// Print session state
@@ -260,7 +260,7 @@ func printDecision(w io.Writer, isAutoCommit bool, stratName string, totalChange
 	fmt.Fprintln(w, "Result: NO - Auto-commit would NOT be triggered")
 	fmt.Fprintln(w, "Reasons:")
 	if !isAutoCommit {
-		fmt.Fprintf(w, "  - Strategy is not auto-commit (using %s)\n", stratName)
+		fmt.Fprintf(w, "  - Strategy does not commit to the active branch (using %s)\n", stratName)
 	}
 	if totalChanges == 0 {
 		fmt.Fprintln(w, "  - No file changes to commit")
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+// filesystemEnvironment inspects the current repository's git dir.
+// Returns false outside a git repository.
+func filesystemEnvironment() (fsenv.Environment, bool) {
+	commonDir, err := strategy.GetGitCommonDir()
+	if err != nil {
+		return fsenv.Environment{}, false
+	}
+	return fsenv.Detect(commonDir), true
+}
+
+// writeFilesystemStatus explains degraded mode (read-only or network git dir,
+// queued ref writes). Writes nothing for a healthy repository.
+// Returns the queued ref writes so callers can act on them.
+func writeFilesystemStatus(w io.Writer) []fsenv.QueuedRef {
+	env, ok := filesystemEnvironment()
+	if !ok {
+		return nil
+	}
+	pending, err := fsenv.PendingRefs(env.GitCommonDir)
+	if err != nil {
+		fmt.Fprintf(w, "\n⚠ %v\n", err)
+	}
+	if !env.Degraded() && len(pending) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	if env.Degraded() {
+		fmt.Fprintf(w, "⚠ Degraded mode: %s\n", strings.Join(env.Reasons(), "; "))
+		fmt.Fprintf(w, "  Session state is kept in %s\n", env.FallbackDir)
+	}
+	if len(pending) > 0 {
+		fmt.Fprintf(w, "  %d ref write(s) queued until the git dir is writable (run `entire doctor` to retry)\n", len(pending))
+	}
+	return pending
+}
+
+// fixFilesystemDegradation reports degraded mode and tries to apply queued
+// ref writes. Used by `entire doctor`.
+func fixFilesystemDegradation(w, errW io.Writer) {
+	pending := writeFilesystemStatus(w)
+	env, _ := filesystemEnvironment()
+	if env.ReadOnly {
+		fmt.Fprintln(w, "  Checkpoints need to write objects to the git dir and fail until it is writable again.")
+		fmt.Fprintf(w, "  Set %s or state_dir in .entire/settings.local.json to move session state elsewhere.\n", fsenv.StateDirEnvVar)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		fmt.Fprintf(errW, "Warning: %v\n", err)
+		return
+	}
+	queue, ok := repo.Storer.(*fsenv.RefQueueStorer)
+	if !ok {
+		return
+	}
+	applied, remaining, err := queue.Flush()
+	if err != nil {
+		fmt.Fprintf(errW, "Warning: %v\n", err)
+	}
+	fmt.Fprintf(w, "  -> Applied %d queued ref write(s), %d still queued\n", applied, remaining)
+	fmt.Fprintln(w)
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +26,13 @@ func newDoctorCmd() *cobra.Command {
 		Short: "Fix stuck sessions",
 		Long: `Scan for stuck or problematic sessions and offer to fix them.
 
+If the git directory is read-only or on a network filesystem, doctor also
+explains the degraded mode Entire is running in and retries ref writes that
+were queued while the git directory couldn't be written. Checkpoints that a
+crashed hook left half done are finished or rolled back (hooks also do this
+on their next run), and shadow branches that were deleted are reconstructed
+from session transcripts (see 'entire checkpoint recover').
+
 A session is considered stuck if:
   - It is in ACTIVE or ACTIVE_COMMITTED phase with no interaction for over 1 hour
   - It is in ENDED phase with uncondensed checkpoint data on a shadow branch
@@ -59,6 +65,25 @@ type stuckSession struct {
 }
 
 func runSessionsFix(cmd *cobra.Command, force bool) error {
+	// Explain read-only / network filesystem degradation and retry queued ref writes
+	fixFilesystemDegradation(cmd.OutOrStdout(), cmd.ErrOrStderr())
+
+	// Finish or undo checkpoints a crashed hook left half done
+	if recovered, err := strategy.RecoverInterruptedCheckpoints(); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+	} else if recovered > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Recovered %d interrupted checkpoint(s).\n\n", recovered)
+	}
+
+	// Rebuild shadow branches that were deleted, so their sessions can still be condensed
+	reconstructed, err := strategy.ReconstructLostShadowBranches(cmd.Context(), false)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+	}
+	for _, r := range reconstructed {
+		fmt.Fprintf(cmd.OutOrStdout(), "Reconstructed %s for session %s from its transcript (%d checkpoint(s)).\n\n", r.ShadowBranch, r.SessionID, r.Checkpoints)
+	}
+
 	// Load all session states
 	states, err := strategy.ListSessionStates()
 	if err != nil {
@@ -158,7 +183,7 @@ func runSessionsFix(cmd *cobra.Command, force bool) error {
 func classifySession(state *strategy.SessionState, repo *git.Repository, now time.Time) *stuckSession {
 	// Determine shadow branch info
 	shadowBranch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranch)
+	refName := checkpoint.ShadowRefName(repo, shadowBranch)
 	_, refErr := repo.Reference(refName, true)
 	hasShadowBranch := refErr == nil
 
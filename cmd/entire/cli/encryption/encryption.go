@@ -0,0 +1,275 @@
+// Package encryption encrypts the session files Entire keeps on disk outside
+// git objects: session state and the transcript, prompt and summary copies
+// under .entire/metadata. It uses AES-256-GCM with a key from the
+// ENTIRE_ENCRYPTION_KEY environment variable or the OS keychain.
+//
+// Git objects (shadow branches and entire/checkpoints/v1) stay plaintext:
+// they are pushed and shared, and every reader would need the key.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// KeyEnvVar holds a base64-encoded 32-byte key.
+const KeyEnvVar = "ENTIRE_ENCRYPTION_KEY"
+
+// Keychain item holding the base64-encoded key.
+const (
+	KeychainService = "entire-cli"
+	KeychainAccount = "encryption-key"
+)
+
+// KeySize is the AES-256 key size in bytes.
+const KeySize = 32
+
+// magic prefixes encrypted files. It is also the GCM additional data, so a
+// ciphertext can't be passed off under a different header version.
+var magic = []byte("ENTIRE-ENC\x00\x01")
+
+// ErrNoKey is returned when encryption is needed but no key is configured.
+var ErrNoKey = errors.New("no encryption key: set " + KeyEnvVar + " or add one to the keychain (see README)")
+
+// IsEncrypted reports whether data was produced by Seal.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// ParseKey decodes a base64-encoded 32-byte key.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid encryption key: got %d bytes, want %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with key.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(magic), len(magic)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	copy(out, magic)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(key, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("data is not encrypted")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	body := data[len(magic):]
+	if len(body) < gcm.NonceSize() {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	plaintext, err := gcm.Open(nil, body[:gcm.NonceSize()], body[gcm.NonceSize():], magic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt (wrong key?): %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// Config is the encryption setting resolved from settings. Stores that write
+// many files resolve it once with LoadConfig instead of per file.
+type Config struct {
+	Enabled bool
+	// KeySource is passed to LoadKey.
+	KeySource string
+}
+
+// LoadConfig resolves the encryption setting. It fails if settings can't be
+// read, since that can't tell whether encryption is on.
+func LoadConfig() (Config, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load encryption settings: %w", err)
+	}
+	source, err := s.Encryption.EffectiveKeySource()
+	if err != nil {
+		return Config{}, err //nolint:wrapcheck // already describes the setting
+	}
+	return Config{Enabled: s.Encryption.IsEnabled(), KeySource: source}, nil
+}
+
+// Encode encrypts data if encryption is enabled, and returns it unchanged
+// otherwise. It fails rather than writing plaintext if encryption is enabled
+// but there's no key.
+func (c Config) Encode(data []byte) ([]byte, error) {
+	if !c.Enabled {
+		return data, nil
+	}
+	key, err := LoadKey(c.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	return Seal(key, data)
+}
+
+// Decode decrypts data if it is encrypted, and returns it unchanged
+// otherwise, so files written before encryption was enabled stay readable.
+func (c Config) Decode(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	key, err := LoadKey(c.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	return Open(key, data)
+}
+
+// Encode is Config.Encode with the current settings. It fails if settings
+// can't be read rather than risk writing plaintext.
+func Encode(data []byte) ([]byte, error) {
+	c, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return c.Encode(data)
+}
+
+// Decode is Config.Decode with the current settings.
+func Decode(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	c, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(data)
+}
+
+// ReadFile reads and decodes a file.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // callers pass session file paths
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers check os errors like fs.ErrNotExist
+	}
+	return Decode(data)
+}
+
+// WriteFile encodes data and writes it to path.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	encoded, err := Encode(data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, encoded, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadKey returns the key from source: "env", "keychain", or "" for the
+// environment variable if set and the keychain otherwise.
+func LoadKey(source string) ([]byte, error) {
+	switch source {
+	case settings.EncryptionKeyEnv:
+		return envKey()
+	case settings.EncryptionKeyKeychain:
+		return keychainKey()
+	case "":
+		if os.Getenv(KeyEnvVar) != "" {
+			return envKey()
+		}
+		return keychainKey()
+	default:
+		return nil, fmt.Errorf("invalid encryption key source %q", source)
+	}
+}
+
+func envKey() ([]byte, error) {
+	encoded := os.Getenv(KeyEnvVar)
+	if encoded == "" {
+		return nil, ErrNoKey
+	}
+	return ParseKey(encoded)
+}
+
+// The keychain lookup runs a subprocess, so it's done once per process.
+var (
+	keychainOnce   sync.Once
+	keychainResult []byte
+	keychainErr    error
+)
+
+func keychainKey() ([]byte, error) {
+	keychainOnce.Do(func() {
+		encoded, err := readKeychain(runtime.GOOS)
+		if err != nil {
+			keychainErr = err
+			return
+		}
+		keychainResult, keychainErr = ParseKey(encoded)
+	})
+	return keychainResult, keychainErr
+}
+
+// keychainCommand returns the command that prints the key on goos, or nil if
+// there's no supported keychain.
+func keychainCommand(goos string) []string {
+	switch goos {
+	case "darwin":
+		return []string{"security", "find-generic-password", "-s", KeychainService, "-a", KeychainAccount, "-w"}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return []string{"secret-tool", "lookup", "service", KeychainService, "account", KeychainAccount}
+	default:
+		return nil
+	}
+}
+
+func readKeychain(goos string) (string, error) {
+	args := keychainCommand(goos)
+	if args == nil {
+		return "", fmt.Errorf("%w (no supported keychain on %s)", ErrNoKey, goos)
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", fmt.Errorf("%w (%s not found)", ErrNoKey, args[0])
+	}
+	out, err := exec.CommandContext(context.Background(), args[0], args[1:]...).Output() //nolint:gosec // fixed keychain command
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		return "", fmt.Errorf("%w (no %s/%s keychain item)", ErrNoKey, KeychainService, KeychainAccount)
+	}
+	return string(out), nil
+}
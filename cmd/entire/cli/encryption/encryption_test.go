@@ -0,0 +1,171 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// setupRepo creates a repo with the given .entire/settings.json and an
+// isolated user settings directory, and changes into it.
+func setupRepo(t *testing.T, settingsJSON string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, d := range []string{".git", ".entire"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(settingsJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	t.Cleanup(paths.ClearRepoRootCache)
+	return dir
+}
+
+func TestSealOpen(t *testing.T) {
+	key := testKey(t)
+	plaintext := []byte(`{"session_id": "abc"}`)
+
+	sealed, err := Seal(key, plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Error("sealed data should be detected as encrypted")
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Error("sealed data contains the plaintext")
+	}
+
+	opened, err := Open(key, sealed)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Open() = %q, want %q", opened, plaintext)
+	}
+
+	if _, err := Open(testKey(t), sealed); err == nil {
+		t.Error("Open() with the wrong key should fail")
+	}
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := Open(key, sealed); err == nil {
+		t.Error("Open() of tampered data should fail")
+	}
+	if _, err := Open(key, magic); err == nil {
+		t.Error("Open() of truncated data should fail")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := testKey(t)
+	parsed, err := ParseKey(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err != nil || !bytes.Equal(parsed, key) {
+		t.Errorf("ParseKey() = %x, %v; want %x", parsed, err, key)
+	}
+	for _, bad := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseKey(bad); err == nil {
+			t.Errorf("ParseKey(%q) should fail", bad)
+		}
+	}
+}
+
+func TestEncodeDecode_EnvKey(t *testing.T) {
+	dir := setupRepo(t, `{"encryption": {"enabled": true, "key_source": "env"}}`)
+	t.Setenv(KeyEnvVar, base64.StdEncoding.EncodeToString(testKey(t)))
+
+	path := filepath.Join(dir, "state.json")
+	plaintext := []byte("transcript line\n")
+	if err := WriteFile(path, plaintext, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(raw) {
+		t.Error("file on disk should be encrypted")
+	}
+	got, err := ReadFile(path)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("ReadFile() = %q, %v; want %q", got, err, plaintext)
+	}
+
+	// Plaintext written before encryption was enabled stays readable.
+	got, err = Decode(plaintext)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Decode(plaintext) = %q, %v", got, err)
+	}
+}
+
+func TestEncode_EnabledWithoutKey(t *testing.T) {
+	setupRepo(t, `{"encryption": {"enabled": true, "key_source": "env"}}`)
+	t.Setenv(KeyEnvVar, "")
+
+	if _, err := Encode([]byte("secret")); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Encode() error = %v, want ErrNoKey", err)
+	}
+}
+
+func TestEncode_Disabled(t *testing.T) {
+	setupRepo(t, `{}`)
+
+	got, err := Encode([]byte("plain"))
+	if err != nil || string(got) != "plain" {
+		t.Errorf("Encode() = %q, %v; want plaintext unchanged", got, err)
+	}
+}
+
+func TestEncode_UnreadableSettings(t *testing.T) {
+	setupRepo(t, `{"encryption": {"enabled": tr`)
+
+	if got, err := Encode([]byte("secret")); err == nil {
+		t.Errorf("Encode() = %q, want an error rather than plaintext", got)
+	}
+}
+
+func TestConfig_EncodeDecode(t *testing.T) {
+	t.Setenv(KeyEnvVar, base64.StdEncoding.EncodeToString(testKey(t)))
+	c := Config{Enabled: true, KeySource: "env"}
+
+	// A resolved config doesn't read settings, so no repo is needed.
+	sealed, err := c.Encode([]byte("secret"))
+	if err != nil || !IsEncrypted(sealed) {
+		t.Fatalf("Encode() = %q, %v; want encrypted", sealed, err)
+	}
+	got, err := c.Decode(sealed)
+	if err != nil || string(got) != "secret" {
+		t.Errorf("Decode() = %q, %v", got, err)
+	}
+}
+
+func TestKeychainCommand(t *testing.T) {
+	if args := keychainCommand("darwin"); len(args) == 0 || args[0] != "security" {
+		t.Errorf("darwin keychain command = %v", args)
+	}
+	if args := keychainCommand("linux"); len(args) == 0 || args[0] != "secret-tool" {
+		t.Errorf("linux keychain command = %v", args)
+	}
+	if args := keychainCommand("windows"); args != nil {
+		t.Errorf("windows keychain command = %v, want none", args)
+	}
+}
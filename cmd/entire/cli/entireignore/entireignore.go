@@ -0,0 +1,79 @@
+// Package entireignore reads .entireignore, the gitignore-style patterns of
+// files checkpoints don't capture and attribution doesn't count, such as
+// build output and generated files the agent happened to touch.
+package entireignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// FileName is the ignore file, at the repository root.
+const FileName = ".entireignore"
+
+// Matcher matches slash-separated, repo-relative paths. A nil Matcher
+// matches nothing.
+type Matcher struct {
+	matcher gitignore.Matcher
+}
+
+// Load reads repoRoot's .entireignore. Returns nil if there isn't one.
+func Load(repoRoot string) (*Matcher, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, FileName)) //nolint:gosec // fixed name under the repo root
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil //nolint:nilnil // no ignore file is not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	return Parse(data), nil
+}
+
+// Parse parses gitignore-syntax patterns: one per line, '#' comments, '!'
+// negations, a trailing '/' for directories and a leading '/' to anchor a
+// pattern at the repository root. Returns nil if there are no patterns.
+func Parse(data []byte) *Matcher {
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	return &Matcher{matcher: gitignore.NewMatcher(patterns)}
+}
+
+// Match reports whether path, or a directory it's in, is ignored.
+func (m *Matcher) Match(path string) bool {
+	if m == nil || path == "" {
+		return false
+	}
+	return m.matcher.Match(strings.Split(filepath.ToSlash(path), "/"), false)
+}
+
+// Filter returns the paths that aren't ignored.
+func (m *Matcher) Filter(paths []string) []string {
+	if m == nil {
+		return paths
+	}
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !m.Match(path) {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,70 @@
+package entireignore
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	m := Parse([]byte(`# build output
+dist/
+*.min.js
+/generated
+!keep.min.js
+`))
+	tests := map[string]bool{
+		"dist/app.js":          true,
+		"web/dist/app.js":      true,
+		"dist.go":              false,
+		"web/app.min.js":       true,
+		"web/keep.min.js":      false,
+		"generated/api.go":     true,
+		"pkg/generated/api.go": false,
+		"main.go":              false,
+	}
+	for path, want := range tests {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	got := m.Filter([]string{"main.go", "dist/app.js", "web/keep.min.js"})
+	if want := []string{"main.go", "web/keep.min.js"}; !slices.Equal(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+}
+
+func TestNilMatcher(t *testing.T) {
+	t.Parallel()
+	if m := Parse([]byte("# nothing\n\n")); m != nil {
+		t.Fatalf("Parse(comments only) = %v, want nil", m)
+	}
+	var m *Matcher
+	if m.Match("dist/app.js") {
+		t.Error("nil Matcher matched")
+	}
+	if got := m.Filter([]string{"a"}); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Filter() = %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	m, err := Load(dir)
+	if err != nil || m != nil {
+		t.Fatalf("Load() without a file = %v, %v, want nil, nil", m, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("*.log\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = Load(dir); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !m.Match("logs/app.log") {
+		t.Error("*.log didn't match logs/app.log")
+	}
+}
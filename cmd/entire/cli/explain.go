@@ -16,6 +16,7 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 	"github.com/entireio/cli/cmd/entire/cli/summarize"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
@@ -70,6 +71,7 @@ func newExplainCmd() *cobra.Command {
 	var generateFlag bool
 	var forceFlag bool
 	var searchAllFlag bool
+	var sinceFlag, untilFlag string
 
 	cmd := &cobra.Command{
 		Use:   "explain",
@@ -84,9 +86,13 @@ explain specific items.
 
 Filtering the list view:
   --session      Filter checkpoints by session ID (or prefix)
+  --since        Only show checkpoints from this time on (e.g. 2w, 2026-01-31)
+  --until        Only show checkpoints before this time
 
 Viewing specific items:
-  --commit       Explain a specific commit (shows its associated checkpoint)
+  --commit       Explain a specific commit (shows its associated checkpoint,
+                 or the metadata in its note in refs/notes/entire when the
+                 checkpoint isn't available)
   --checkpoint   Explain a specific checkpoint by ID
 
 Output verbosity levels (for --checkpoint):
@@ -133,13 +139,19 @@ Note: --session filters the list view; --commit and --checkpoint are mutually ex
 
 			// Convert short flag to verbose (verbose = !short)
 			verbose := !shortFlag
-			return runExplain(cmd.OutOrStdout(), cmd.ErrOrStderr(), sessionFlag, commitFlag, checkpointFlag, noPagerFlag, verbose, fullFlag, rawTranscriptFlag, generateFlag, forceFlag, searchAllFlag)
+			period, err := resolveReportPeriod(sinceFlag, untilFlag, time.Now())
+			if err != nil {
+				return err
+			}
+			return runExplain(cmd.OutOrStdout(), cmd.ErrOrStderr(), sessionFlag, commitFlag, checkpointFlag, noPagerFlag, verbose, fullFlag, rawTranscriptFlag, generateFlag, forceFlag, searchAllFlag, period)
 		},
 	}
 
 	cmd.Flags().StringVar(&sessionFlag, "session", "", "Filter checkpoints by session ID (or prefix)")
 	cmd.Flags().StringVar(&commitFlag, "commit", "", "Explain a specific commit (SHA or ref, \"commit-ish\")")
 	cmd.Flags().StringVarP(&checkpointFlag, "checkpoint", "c", "", "Explain a specific checkpoint (ID or prefix)")
+	registerFlagCompletion(cmd, "session", completeSessionIDs)
+	registerFlagCompletion(cmd, "checkpoint", completeCheckpointIDs)
 	cmd.Flags().BoolVar(&noPagerFlag, "no-pager", false, "Disable pager output")
 	cmd.Flags().BoolVarP(&shortFlag, "short", "s", false, "Show summary only (omit prompts and files)")
 	cmd.Flags().BoolVar(&fullFlag, "full", false, "Show full parsed transcript (all prompts/responses)")
@@ -147,6 +159,7 @@ Note: --session filters the list view; --commit and --checkpoint are mutually ex
 	cmd.Flags().BoolVar(&generateFlag, "generate", false, "Generate an AI summary for the checkpoint")
 	cmd.Flags().BoolVar(&forceFlag, "force", false, "Regenerate summary even if one already exists (requires --generate)")
 	cmd.Flags().BoolVar(&searchAllFlag, "search-all", false, "Search all commits (no branch/depth limit, may be slow)")
+	addReportPeriodFlags(cmd, &sinceFlag, &untilFlag)
 
 	// Make --short, --full, and --raw-transcript mutually exclusive
 	cmd.MarkFlagsMutuallyExclusive("short", "full", "raw-transcript")
@@ -157,7 +170,7 @@ Note: --session filters the list view; --commit and --checkpoint are mutually ex
 }
 
 // runExplain routes to the appropriate explain function based on flags.
-func runExplain(w, errW io.Writer, sessionID, commitRef, checkpointID string, noPager, verbose, full, rawTranscript, generate, force, searchAll bool) error {
+func runExplain(w, errW io.Writer, sessionID, commitRef, checkpointID string, noPager, verbose, full, rawTranscript, generate, force, searchAll bool, period reportPeriod) error {
 	// Count mutually exclusive flags (--commit and --checkpoint are mutually exclusive)
 	// --session is now a filter for the list view, not a separate mode
 	flagCount := 0
@@ -174,6 +187,9 @@ func runExplain(w, errW io.Writer, sessionID, commitRef, checkpointID string, no
 	if flagCount > 1 {
 		return errors.New("cannot specify multiple of --session, --commit, --checkpoint")
 	}
+	if period.IsBounded() && flagCount > 0 {
+		return errors.New("--since and --until filter the list view; they can't be combined with --commit or --checkpoint")
+	}
 
 	// Route to appropriate handler
 	if commitRef != "" {
@@ -184,7 +200,7 @@ func runExplain(w, errW io.Writer, sessionID, commitRef, checkpointID string, no
 	}
 
 	// Default or with session filter: show list view (optionally filtered by session)
-	return runExplainBranchWithFilter(w, noPager, sessionID)
+	return runExplainBranchWithFilter(w, noPager, sessionID, period)
 }
 
 // runExplainCheckpoint explains a specific checkpoint.
@@ -701,6 +717,13 @@ func formatTranscriptBytes(transcriptBytes []byte, fallback string) string {
 
 // formatSummaryDetails formats the detailed sections of an AI summary.
 func formatSummaryDetails(sb *strings.Builder, summary *checkpoint.Summary) {
+	if len(summary.Decisions) > 0 {
+		sb.WriteString("\nDecisions:\n")
+		for _, decision := range summary.Decisions {
+			fmt.Fprintf(sb, "  - %s\n", decision)
+		}
+	}
+
 	// Learnings section
 	hasLearnings := len(summary.Learnings.Repo) > 0 ||
 		len(summary.Learnings.Code) > 0 ||
@@ -778,7 +801,7 @@ func getCurrentWorktreeHash() string {
 	if err != nil {
 		return ""
 	}
-	worktreeID, err := paths.GetWorktreeID(repoRoot)
+	worktreeID, err := strategy.ShadowWorktreeID(repoRoot)
 	if err != nil {
 		return ""
 	}
@@ -1086,7 +1109,7 @@ func convertTemporaryCheckpoint(repo *git.Repository, tc checkpoint.TemporaryChe
 
 // runExplainBranchWithFilter shows checkpoints on the current branch, optionally filtered by session.
 // This is strategy-agnostic - it queries checkpoints directly.
-func runExplainBranchWithFilter(w io.Writer, noPager bool, sessionFilter string) error {
+func runExplainBranchWithFilter(w io.Writer, noPager bool, sessionFilter string, period reportPeriod) error {
 	repo, err := openRepository()
 	if err != nil {
 		return fmt.Errorf("not a git repository: %w", err)
@@ -1116,6 +1139,15 @@ func runExplainBranchWithFilter(w io.Writer, noPager bool, sessionFilter string)
 		logging.Warn(context.Background(), "failed to get branch checkpoints", "error", err)
 		points = nil
 	}
+	if period.IsBounded() {
+		var inPeriod []strategy.RewindPoint
+		for _, p := range points {
+			if period.Contains(p.Date) {
+				inPeriod = append(inPeriod, p)
+			}
+		}
+		points = inPeriod
+	}
 
 	// Format output
 	output := formatBranchCheckpoints(branchName, points, sessionFilter)
@@ -1127,7 +1159,7 @@ func runExplainBranchWithFilter(w io.Writer, noPager bool, sessionFilter string)
 // runExplainBranchDefault shows all checkpoints on the current branch grouped by date.
 // This is a convenience wrapper that calls runExplainBranchWithFilter with no filter.
 func runExplainBranchDefault(w io.Writer, noPager bool) error {
-	return runExplainBranchWithFilter(w, noPager, "")
+	return runExplainBranchWithFilter(w, noPager, "", reportPeriod{})
 }
 
 // outputExplainContent outputs content with optional pager support.
@@ -1161,6 +1193,24 @@ func runExplainCommit(w io.Writer, commitRef string, noPager, verbose, full, sea
 
 	// Extract Entire-Checkpoint trailer
 	checkpointID, hasCheckpoint := trailers.ParseCheckpoint(commit.Message)
+	store := checkpoint.NewGitStore(repo)
+	var summary *checkpoint.CheckpointSummary
+	if hasCheckpoint {
+		summary, _ = store.ReadCommitted(context.Background(), checkpointID) //nolint:errcheck // falls back to the note
+	}
+	if summary == nil {
+		// The commit's note carries the metadata without the transcript, e.g. in
+		// clones that only fetched refs/notes/entire or after a rewrite dropped
+		// the trailer
+		note, err := store.ReadCommitNote(*hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit note: %w", err)
+		}
+		if note != nil {
+			outputExplainContent(w, formatCommitNoteOutput(note, verbose || full), noPager)
+			return nil
+		}
+	}
 	if !hasCheckpoint {
 		fmt.Fprintln(w, "No associated Entire checkpoint")
 		fmt.Fprintf(w, "\nCommit %s does not have an Entire-Checkpoint trailer.\n", hash.String()[:7])
@@ -1173,6 +1223,56 @@ func runExplainCommit(w io.Writer, commitRef string, noPager, verbose, full, sea
 	return runExplainCheckpoint(w, w, checkpointID.String(), noPager, verbose, full, false, false, false, searchAll)
 }
 
+// formatCommitNoteOutput formats the checkpoint metadata of a commit's note.
+// Notes carry no transcript, so there are no prompts to show.
+func formatCommitNoteOutput(note *checkpoint.CommitNote, verbose bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Checkpoint: %s\n", note.CheckpointID)
+	fmt.Fprintf(&sb, "Source: commit note in %s (no transcript)\n", paths.NotesRefName)
+	if note.TokenUsage != nil {
+		totalTokens := note.TokenUsage.InputTokens + note.TokenUsage.CacheCreationTokens +
+			note.TokenUsage.CacheReadTokens + note.TokenUsage.OutputTokens
+		fmt.Fprintf(&sb, "Tokens: %d\n", totalTokens)
+	}
+
+	for _, session := range note.Sessions {
+		sb.WriteString("\n")
+		agentLabel := string(session.Agent)
+		if agentLabel == "" {
+			agentLabel = "unknown agent"
+		}
+		fmt.Fprintf(&sb, "Session: %s (%s)\n", session.SessionID, agentLabel)
+		fmt.Fprintf(&sb, "Created: %s\n", session.CreatedAt.Format("2006-01-02 15:04:05"))
+		if a := session.Attribution; a != nil {
+			fmt.Fprintf(&sb, "Attribution: %d of %d committed lines by the agent (%s)\n",
+				a.AgentLines, a.TotalCommitted, reportfmt.DetectLocale().Percent(a.AgentPercentage, 1))
+		}
+		if session.Summary != nil {
+			fmt.Fprintf(&sb, "Intent: %s\n", session.Summary.Intent)
+			fmt.Fprintf(&sb, "Outcome: %s\n", session.Summary.Outcome)
+		} else {
+			sb.WriteString("Intent: (not generated)\n")
+			sb.WriteString("Outcome: (not generated)\n")
+		}
+
+		if verbose {
+			if session.Summary != nil {
+				formatSummaryDetails(&sb, session.Summary)
+			}
+			sb.WriteString("\n")
+			if len(session.FilesTouched) > 0 {
+				fmt.Fprintf(&sb, "Files: (%d)\n", len(session.FilesTouched))
+				for _, file := range session.FilesTouched {
+					fmt.Fprintf(&sb, "  - %s\n", file)
+				}
+			} else {
+				sb.WriteString("Files: (none)\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
 // formatSessionInfo formats session information for display.
 func formatSessionInfo(session *strategy.Session, sourceRef string, checkpoints []checkpointDetail) string {
 	var sb strings.Builder
@@ -353,7 +353,7 @@ func TestExplainDefault_NoCheckpoints_ShowsHelpfulMessage(t *testing.T) {
 func TestExplainBothFlagsError(t *testing.T) {
 	// Test that providing both --session and --commit returns an error
 	var stdout, stderr bytes.Buffer
-	err := runExplain(&stdout, &stderr, "session-id", "commit-sha", "", false, false, false, false, false, false, false)
+	err := runExplain(&stdout, &stderr, "session-id", "commit-sha", "", false, false, false, false, false, false, false, reportPeriod{})
 
 	if err == nil {
 		t.Error("expected error when both flags provided, got nil")
@@ -813,7 +813,7 @@ func TestRunExplain_MutualExclusivityError(t *testing.T) {
 	var buf, errBuf bytes.Buffer
 
 	// Providing both --session and --checkpoint should error
-	err := runExplain(&buf, &errBuf, "session-id", "", "checkpoint-id", false, false, false, false, false, false, false)
+	err := runExplain(&buf, &errBuf, "session-id", "", "checkpoint-id", false, false, false, false, false, false, false, reportPeriod{})
 
 	if err == nil {
 		t.Error("expected error when multiple flags provided")
@@ -2345,6 +2345,65 @@ func TestRunExplainCommit_NoCheckpointTrailer(t *testing.T) {
 	}
 }
 
+func TestRunExplainCommit_CommitNote(t *testing.T) {
+	// A commit whose trailer was dropped in a rewrite, with its note kept
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+
+	repo, err := git.PlainInit(tmpDir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("content"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := w.Add("test.txt"); err != nil {
+		t.Fatalf("failed to add test file: %v", err)
+	}
+	hash, err := w.Commit("Rewritten commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to create commit: %v", err)
+	}
+	note := &checkpoint.CommitNote{
+		Version:      checkpoint.CommitNoteVersion,
+		CheckpointID: id.MustCheckpointID("c0ffee123456"),
+		FilesTouched: []string{"test.txt"},
+		Sessions: []checkpoint.CommitNoteSession{{
+			SessionID:    "2026-10-14-note-session",
+			Agent:        agent.AgentTypeClaudeCode,
+			FilesTouched: []string{"test.txt"},
+			Summary:      &checkpoint.Summary{Intent: "Add test.txt", Outcome: "Added it"},
+			Attribution:  &checkpoint.InitialAttribution{AgentLines: 1, TotalCommitted: 1, AgentPercentage: 100},
+		}},
+	}
+	if err := checkpoint.NewGitStore(repo).WriteCommitNotes(map[plumbing.Hash]*checkpoint.CommitNote{hash: note}, "Test", "test@test.com"); err != nil {
+		t.Fatalf("WriteCommitNotes() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runExplainCommit(&buf, hash.String()[:7], true, true, false, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{
+		"Checkpoint: c0ffee123456",
+		"Session: 2026-10-14-note-session (Claude Code)",
+		"Attribution: 1 of 1 committed lines by the agent",
+		"Intent: Add test.txt",
+		"  - test.txt",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+}
+
 func TestRunExplainCommit_WithCheckpointTrailer(t *testing.T) {
 	// Create test repo with a commit that has an Entire-Checkpoint trailer
 	tmpDir := t.TempDir()
@@ -2493,7 +2552,7 @@ func TestRunExplain_SessionFlagFiltersListView(t *testing.T) {
 	// When session is specified alone, it should NOT error for mutual exclusivity
 	// It should route to the list view with a filter (which may fail for other reasons
 	// like not being in a git repo, but not for mutual exclusivity)
-	err := runExplain(&buf, &errBuf, "some-session", "", "", false, false, false, false, false, false, false)
+	err := runExplain(&buf, &errBuf, "some-session", "", "", false, false, false, false, false, false, false, reportPeriod{})
 
 	// Should NOT be a mutual exclusivity error
 	if err != nil && strings.Contains(err.Error(), "cannot specify multiple") {
@@ -2505,7 +2564,7 @@ func TestRunExplain_SessionWithCheckpointStillMutuallyExclusive(t *testing.T) {
 	// Test that --session with --checkpoint is still an error
 	var buf, errBuf bytes.Buffer
 
-	err := runExplain(&buf, &errBuf, "some-session", "", "some-checkpoint", false, false, false, false, false, false, false)
+	err := runExplain(&buf, &errBuf, "some-session", "", "some-checkpoint", false, false, false, false, false, false, false, reportPeriod{})
 
 	if err == nil {
 		t.Error("expected error when --session and --checkpoint both specified")
@@ -2519,7 +2578,7 @@ func TestRunExplain_SessionWithCommitStillMutuallyExclusive(t *testing.T) {
 	// Test that --session with --commit is still an error
 	var buf, errBuf bytes.Buffer
 
-	err := runExplain(&buf, &errBuf, "some-session", "some-commit", "", false, false, false, false, false, false, false)
+	err := runExplain(&buf, &errBuf, "some-session", "some-commit", "", false, false, false, false, false, false, false, reportPeriod{})
 
 	if err == nil {
 		t.Error("expected error when --session and --commit both specified")
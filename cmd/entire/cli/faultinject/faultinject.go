@@ -0,0 +1,178 @@
+// Package faultinject injects failures into Entire's own writes so tests can
+// check that hooks survive them: ref writes that fail, session state writes
+// cut short as if the process crashed, and slow git storage.
+//
+// It is off unless ENTIRE_FAULTS is set, e.g.
+//
+//	ENTIRE_FAULTS=ref-write:0.2,state-truncate:0.1,git-delay:0.5:50ms
+//
+// Each entry is a fault name, the probability it fires on each opportunity,
+// and for git-delay how long to sleep. ENTIRE_FAULTS_SEED makes the sequence
+// of faults reproducible. This is a testing aid for integration tests and
+// 'entire selftest --chaos', not a user setting.
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+)
+
+// EnvVar configures the faults to inject.
+const EnvVar = "ENTIRE_FAULTS"
+
+// SeedEnvVar seeds the random choice of when faults fire.
+const SeedEnvVar = "ENTIRE_FAULTS_SEED"
+
+// Fault names a kind of injected failure.
+type Fault string
+
+const (
+	// RefWrite fails git ref updates.
+	RefWrite Fault = "ref-write"
+	// StateTruncate cuts session state writes short and fails them.
+	StateTruncate Fault = "state-truncate"
+	// GitDelay sleeps before git storage writes.
+	GitDelay Fault = "git-delay"
+)
+
+// defaultDelay is the git-delay sleep when the entry doesn't give one.
+const defaultDelay = 100 * time.Millisecond
+
+// ErrInjected is wrapped by every injected failure.
+var ErrInjected = errors.New("injected fault")
+
+type faultConfig struct {
+	probability float64
+	delay       time.Duration
+}
+
+type config struct {
+	spec   string
+	seed   string
+	faults map[Fault]faultConfig
+	rng    *rand.Rand
+}
+
+var (
+	mu      sync.Mutex
+	current *config
+)
+
+// Enabled reports whether any fault is configured.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(load().faults) > 0
+}
+
+// load returns the configuration for the current environment, parsing it
+// again when ENTIRE_FAULTS or its seed changed. Callers must hold mu.
+func load() *config {
+	spec, seed := os.Getenv(EnvVar), os.Getenv(SeedEnvVar)
+	if current != nil && current.spec == spec && current.seed == seed {
+		return current
+	}
+	c := &config{spec: spec, seed: seed, faults: make(map[Fault]faultConfig)}
+	for _, entry := range strings.Split(spec, ",") {
+		name, fc, ok := parseEntry(strings.TrimSpace(entry))
+		if ok {
+			c.faults[name] = fc
+		}
+	}
+	s, err := strconv.ParseUint(seed, 10, 64)
+	if err != nil {
+		s = rand.Uint64() //nolint:gosec // fault timing doesn't need a secure source
+	}
+	c.rng = rand.New(rand.NewPCG(s, s)) //nolint:gosec // see above
+	current = c
+	return c
+}
+
+// parseEntry parses "name[:probability[:delay]]". A missing probability
+// means always; malformed entries are ignored.
+func parseEntry(entry string) (Fault, faultConfig, bool) {
+	parts := strings.Split(entry, ":")
+	name := Fault(parts[0])
+	switch name {
+	case RefWrite, StateTruncate, GitDelay:
+	default:
+		return "", faultConfig{}, false
+	}
+	fc := faultConfig{probability: 1, delay: defaultDelay}
+	if len(parts) > 1 {
+		p, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || p < 0 || p > 1 {
+			return "", faultConfig{}, false
+		}
+		fc.probability = p
+	}
+	if len(parts) > 2 {
+		d, err := time.ParseDuration(parts[2])
+		if err != nil || d < 0 {
+			return "", faultConfig{}, false
+		}
+		fc.delay = d
+	}
+	return name, fc, true
+}
+
+// fire reports whether fault f fires at this opportunity, and its config.
+func fire(f Fault) (faultConfig, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	c := load()
+	fc, ok := c.faults[f]
+	if !ok || c.rng.Float64() >= fc.probability {
+		return fc, false
+	}
+	return fc, true
+}
+
+func logFault(f Fault, what string) {
+	ctx := logging.WithComponent(context.Background(), "faultinject")
+	logging.Warn(ctx, "injected fault", slog.String("fault", string(f)), slog.String("target", what))
+}
+
+// Fail returns an injected error for f's operation on what, or nil when the
+// fault doesn't fire.
+func Fail(f Fault, what string) error {
+	if _, ok := fire(f); !ok {
+		return nil
+	}
+	logFault(f, what)
+	return fmt.Errorf("%w: %s %s", ErrInjected, f, what)
+}
+
+// Delay sleeps if GitDelay fires.
+func Delay(what string) {
+	fc, ok := fire(GitDelay)
+	if !ok {
+		return
+	}
+	logFault(GitDelay, what)
+	time.Sleep(fc.delay)
+}
+
+// Truncate returns data cut to a random shorter length and an injected error
+// when StateTruncate fires, for writers to write the partial data and then
+// fail the way a crash mid-write would. Otherwise it returns data and nil.
+func Truncate(what string, data []byte) ([]byte, error) {
+	if _, ok := fire(StateTruncate); !ok || len(data) == 0 {
+		return data, nil
+	}
+	mu.Lock()
+	n := load().rng.IntN(len(data))
+	mu.Unlock()
+	logFault(StateTruncate, what)
+	return data[:n], fmt.Errorf("%w: %s %s after %d of %d bytes", ErrInjected, StateTruncate, what, n, len(data))
+}
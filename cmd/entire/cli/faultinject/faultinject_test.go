@@ -0,0 +1,118 @@
+package faultinject
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  faultConfig
+		ok    bool
+	}{
+		{"ref-write", faultConfig{probability: 1, delay: defaultDelay}, true},
+		{"state-truncate:0.25", faultConfig{probability: 0.25, delay: defaultDelay}, true},
+		{"git-delay:0.5:20ms", faultConfig{probability: 0.5, delay: 20 * time.Millisecond}, true},
+		{"git-delay:2", faultConfig{}, false},
+		{"git-delay:0.5:soon", faultConfig{}, false},
+		{"disk-full", faultConfig{}, false},
+		{"", faultConfig{}, false},
+	}
+	for _, tt := range tests {
+		_, got, ok := parseEntry(tt.entry)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseEntry(%q) = %+v, %v; want %+v, %v", tt.entry, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFail(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	if Enabled() || Fail(RefWrite, "refs/heads/x") != nil {
+		t.Fatal("faults injected without ENTIRE_FAULTS")
+	}
+
+	t.Setenv(EnvVar, "ref-write")
+	err := Fail(RefWrite, "refs/heads/x")
+	if !errors.Is(err, ErrInjected) || !strings.Contains(err.Error(), "ref-write refs/heads/x") {
+		t.Errorf("Fail() = %v, want an injected ref-write error", err)
+	}
+	if Fail(StateTruncate, "state.json") != nil {
+		t.Error("unconfigured fault fired")
+	}
+}
+
+func TestFail_SeedIsReproducible(t *testing.T) {
+	t.Setenv(EnvVar, "ref-write:0.5")
+	draws := func() string {
+		current = nil
+		var sb strings.Builder
+		for range 32 {
+			if Fail(RefWrite, "r") != nil {
+				sb.WriteByte('x')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		return sb.String()
+	}
+	t.Setenv(SeedEnvVar, "7")
+	first := draws()
+	if second := draws(); first != second {
+		t.Errorf("same seed gave %s and %s", first, second)
+	}
+	if !strings.Contains(first, "x") || !strings.Contains(first, ".") {
+		t.Errorf("p=0.5 over 32 draws gave %s", first)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	data := []byte(`{"session_id":"abc"}`)
+
+	t.Setenv(EnvVar, "")
+	if got, err := Truncate("state.json", data); err != nil || string(got) != string(data) {
+		t.Errorf("Truncate() without faults = %q, %v", got, err)
+	}
+
+	t.Setenv(EnvVar, "state-truncate")
+	got, err := Truncate("state.json", data)
+	if !errors.Is(err, ErrInjected) || len(got) >= len(data) {
+		t.Errorf("Truncate() = %q, %v; want a shorter prefix and an injected error", got, err)
+	}
+}
+
+func TestStorer_RefWrite(t *testing.T) {
+	repo, err := git.PlainInit(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		t.Fatalf("storer is %T", repo.Storer)
+	}
+	s := &Storer{Storage: fsStorage}
+	ref := plumbing.NewHashReference("refs/heads/entire/test", plumbing.NewHash("1234567890123456789012345678901234567890"))
+
+	t.Setenv(EnvVar, "ref-write")
+	if err := s.SetReference(ref); !errors.Is(err, ErrInjected) {
+		t.Fatalf("SetReference() = %v, want an injected error", err)
+	}
+	if _, err := fsStorage.Reference(ref.Name()); !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		t.Errorf("failed write left the ref behind: %v", err)
+	}
+
+	t.Setenv(EnvVar, "")
+	if err := s.SetReference(ref); err != nil {
+		t.Fatalf("SetReference() without faults = %v", err)
+	}
+	if got, err := fsStorage.Reference(ref.Name()); err != nil || got.Hash() != ref.Hash() {
+		t.Errorf("ref = %v, %v; want %s", got, err, ref.Hash())
+	}
+}
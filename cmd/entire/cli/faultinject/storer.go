@@ -0,0 +1,48 @@
+package faultinject
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// Storer wraps the filesystem storage of a repository to inject RefWrite
+// failures and GitDelay sleeps into its writes.
+//
+// It embeds *filesystem.Storage so optional interfaces go-git checks for
+// (packfile writers, loose object access) keep working.
+type Storer struct {
+	*filesystem.Storage
+}
+
+// SetReference writes ref unless RefWrite fires.
+func (s *Storer) SetReference(ref *plumbing.Reference) error {
+	Delay(ref.Name().String())
+	if err := Fail(RefWrite, ref.Name().String()); err != nil {
+		return err
+	}
+	return s.Storage.SetReference(ref) //nolint:wrapcheck // pass go-git errors through unchanged
+}
+
+// CheckAndSetReference writes ref if old still matches, unless RefWrite fires.
+func (s *Storer) CheckAndSetReference(ref, old *plumbing.Reference) error {
+	Delay(ref.Name().String())
+	if err := Fail(RefWrite, ref.Name().String()); err != nil {
+		return err
+	}
+	return s.Storage.CheckAndSetReference(ref, old) //nolint:wrapcheck // pass go-git errors through unchanged
+}
+
+// RemoveReference deletes the ref unless RefWrite fires.
+func (s *Storer) RemoveReference(name plumbing.ReferenceName) error {
+	Delay(name.String())
+	if err := Fail(RefWrite, name.String()); err != nil {
+		return err
+	}
+	return s.Storage.RemoveReference(name) //nolint:wrapcheck // pass go-git errors through unchanged
+}
+
+// SetEncodedObject writes an object, after a GitDelay sleep if it fires.
+func (s *Storer) SetEncodedObject(o plumbing.EncodedObject) (plumbing.Hash, error) {
+	Delay("object")
+	return s.Storage.SetEncodedObject(o) //nolint:wrapcheck // pass go-git errors through unchanged
+}
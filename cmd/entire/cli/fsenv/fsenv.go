@@ -0,0 +1,144 @@
+// Package fsenv detects git directories Entire can't safely write to (read-only
+// mounts, network filesystems) and provides the fallbacks used there: a state
+// directory outside the repository and a queue for ref writes that failed.
+package fsenv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// StateDirEnvVar overrides the base directory used for state outside the repository.
+const StateDirEnvVar = "ENTIRE_STATE_DIR"
+
+// Environment describes the filesystem the git common dir lives on.
+type Environment struct {
+	// GitCommonDir is the shared git directory that was inspected.
+	GitCommonDir string
+
+	// ReadOnly is true if files can't be created in GitCommonDir.
+	ReadOnly bool
+
+	// FilesystemType names the filesystem if it is a network filesystem
+	// (e.g. "nfs", "smb"), where lock files and atomic renames are unreliable.
+	// Empty for local filesystems or when it can't be determined.
+	FilesystemType string
+
+	// FallbackDir is the directory outside the repository used for session
+	// state and queued ref writes (see FallbackDir).
+	FallbackDir string
+}
+
+// Degraded reports whether Entire should keep its state outside the git dir.
+func (e Environment) Degraded() bool {
+	return e.ReadOnly || e.FilesystemType != ""
+}
+
+// Reasons returns human-readable explanations of why the environment is degraded.
+func (e Environment) Reasons() []string {
+	var reasons []string
+	if e.ReadOnly {
+		reasons = append(reasons, e.GitCommonDir+" is read-only")
+	}
+	if e.FilesystemType != "" {
+		reasons = append(reasons, fmt.Sprintf("%s is on a network filesystem (%s)", e.GitCommonDir, e.FilesystemType))
+	}
+	return reasons
+}
+
+var (
+	detectMu    sync.Mutex
+	detectCache = map[string]Environment{}
+)
+
+// Detect inspects commonDir. Results are cached for the life of the process,
+// since hooks call it on every state access.
+func Detect(commonDir string) Environment {
+	commonDir = absPath(commonDir)
+
+	detectMu.Lock()
+	defer detectMu.Unlock()
+	if env, ok := detectCache[commonDir]; ok {
+		return env
+	}
+
+	env := Environment{
+		GitCommonDir:   commonDir,
+		ReadOnly:       !isWritable(commonDir),
+		FilesystemType: networkFilesystemType(commonDir),
+		FallbackDir:    FallbackDir(commonDir),
+	}
+	detectCache[commonDir] = env
+	return env
+}
+
+// ResetCache clears cached Detect results. Used by tests.
+func ResetCache() {
+	detectMu.Lock()
+	defer detectMu.Unlock()
+	detectCache = map[string]Environment{}
+}
+
+// StateDir returns where a state directory called name (e.g. "entire-sessions")
+// lives: inside commonDir normally, under FallbackDir when commonDir is degraded.
+func StateDir(commonDir, name string) string {
+	env := Detect(commonDir)
+	if env.Degraded() {
+		return filepath.Join(env.FallbackDir, name)
+	}
+	return filepath.Join(commonDir, name)
+}
+
+// FallbackDir returns the per-repository directory outside the repository:
+// <base>/<repo-name>-<hash of commonDir>, where base is $ENTIRE_STATE_DIR,
+// the state_dir setting, or paths.UserStateDir ($XDG_STATE_HOME/entire or
+// ~/.local/state/entire, %LOCALAPPDATA%\entire on Windows).
+func FallbackDir(commonDir string) string {
+	commonDir = absPath(commonDir)
+	sum := sha256.Sum256([]byte(commonDir))
+	name := filepath.Base(filepath.Dir(commonDir))
+	if filepath.Base(commonDir) != ".git" {
+		name = strings.TrimSuffix(filepath.Base(commonDir), ".git")
+	}
+	return filepath.Join(fallbackBase(), name+"-"+hex.EncodeToString(sum[:])[:12])
+}
+
+func fallbackBase() string {
+	if dir := os.Getenv(StateDirEnvVar); dir != "" {
+		return dir
+	}
+	if s, err := settings.Load(); err == nil && s.StateDir != "" {
+		return s.StateDir
+	}
+	if dir := paths.UserStateDir(); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "entire-state")
+}
+
+// isWritable reports whether a file can be created in dir.
+func isWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".entire-write-check-*")
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+	return true
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return filepath.Clean(abs)
+	}
+	return filepath.Clean(path)
+}
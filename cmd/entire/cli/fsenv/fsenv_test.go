@@ -0,0 +1,59 @@
+package fsenv
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFallbackDir(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv(StateDirEnvVar, base)
+
+	a := FallbackDir("/work/project/.git")
+	b := FallbackDir("/other/project/.git")
+	if filepath.Dir(a) != base {
+		t.Errorf("FallbackDir() = %q, want it under %q", a, base)
+	}
+	if !strings.HasPrefix(filepath.Base(a), "project-") {
+		t.Errorf("FallbackDir() = %q, want it named after the repository", a)
+	}
+	if a == b {
+		t.Errorf("FallbackDir() = %q for two repositories, want distinct dirs", a)
+	}
+	if got := FallbackDir("/work/project/.git"); got != a {
+		t.Errorf("FallbackDir() = %q, then %q; want stable", a, got)
+	}
+}
+
+func TestStateDir_WritableLocalDir(t *testing.T) {
+	ResetCache()
+	t.Cleanup(ResetCache)
+	commonDir := t.TempDir()
+
+	env := Detect(commonDir)
+	if env.ReadOnly {
+		t.Fatalf("Detect(%q).ReadOnly = true, want false", commonDir)
+	}
+	if env.Degraded() {
+		// Some CI sandboxes keep TMPDIR on a network mount
+		t.Skipf("temp dir is on %s", env.FilesystemType)
+	}
+	if got, want := StateDir(commonDir, "entire-sessions"), filepath.Join(commonDir, "entire-sessions"); got != want {
+		t.Errorf("StateDir() = %q, want %q", got, want)
+	}
+}
+
+func TestEnvironment_Reasons(t *testing.T) {
+	env := Environment{GitCommonDir: "/repo/.git", ReadOnly: true, FilesystemType: "nfs"}
+	if !env.Degraded() {
+		t.Error("Degraded() = false, want true")
+	}
+	reasons := env.Reasons()
+	if len(reasons) != 2 || !strings.Contains(reasons[0], "read-only") || !strings.Contains(reasons[1], "nfs") {
+		t.Errorf("Reasons() = %q", reasons)
+	}
+	if (Environment{GitCommonDir: "/repo/.git"}).Degraded() {
+		t.Error("Degraded() = true for a healthy environment")
+	}
+}
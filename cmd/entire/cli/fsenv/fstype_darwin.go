@@ -0,0 +1,33 @@
+//go:build darwin
+
+package fsenv
+
+import "syscall"
+
+// networkFilesystems are the statfs type names of network filesystems.
+var networkFilesystems = map[string]bool{
+	"nfs":    true,
+	"smbfs":  true,
+	"afpfs":  true,
+	"webdav": true,
+}
+
+// networkFilesystemType returns the name of the network filesystem path is
+// on, or "" for local filesystems.
+func networkFilesystemType(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ""
+	}
+	name := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	if networkFilesystems[string(name)] {
+		return string(name)
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package fsenv
+
+import "syscall"
+
+// networkFilesystems maps statfs magic numbers of network filesystems to names.
+var networkFilesystems = map[int64]string{
+	0x6969:     "nfs",
+	0x517B:     "smb",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x5346414F: "afs",
+	0x73757245: "coda",
+	0x01021997: "9p",
+}
+
+// networkFilesystemType returns the name of the network filesystem path is
+// on, or "" for local filesystems.
+func networkFilesystemType(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ""
+	}
+	return networkFilesystems[int64(st.Type)] //nolint:unconvert // Type is int32 on some architectures
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package fsenv
+
+// networkFilesystemType can't detect network filesystems on this platform;
+// read-only detection still applies.
+func networkFilesystemType(string) string {
+	return ""
+}
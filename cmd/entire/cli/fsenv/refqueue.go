@@ -0,0 +1,303 @@
+package fsenv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// PendingRefsFileName is the queue of ref writes inside FallbackDir.
+const PendingRefsFileName = "pending-refs.json"
+
+// QueuedRef is a ref write that failed because the git dir couldn't be
+// written, kept until it can be applied.
+type QueuedRef struct {
+	Name string `json:"name"`
+	// Target is the hash, or "ref: <name>" for symbolic refs. Empty when Deleted.
+	Target   string    `json:"target,omitempty"`
+	Deleted  bool      `json:"deleted,omitempty"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// RefQueueStorer wraps the filesystem storage of a repository whose git dir is
+// degraded. Ref writes that fail with a permission or locking error are queued
+// in FallbackDir instead of failing the hook, and reads see the queued values.
+// Queued writes are retried after every successful ref write and by Flush.
+//
+// It embeds *filesystem.Storage so optional interfaces go-git checks for
+// (packfile writers, loose object access) keep working.
+type RefQueueStorer struct {
+	*filesystem.Storage
+	queuePath string
+}
+
+// queueMu serializes queue file updates within the process. Concurrent hooks in
+// other processes can still race; the last writer wins, which at worst drops a
+// queued write that a later checkpoint re-creates.
+var queueMu sync.Mutex
+
+// WrapStorage returns s wrapped in a RefQueueStorer if commonDir is degraded
+// or refs are still queued from an earlier run, and nil otherwise.
+func WrapStorage(s *filesystem.Storage, commonDir string) *RefQueueStorer {
+	env := Detect(commonDir)
+	queuePath := filepath.Join(env.FallbackDir, PendingRefsFileName)
+	if !env.Degraded() {
+		if _, err := os.Stat(queuePath); err != nil {
+			return nil
+		}
+	}
+	return &RefQueueStorer{Storage: s, queuePath: queuePath}
+}
+
+// SetReference writes ref, queueing it if the git dir rejects the write.
+func (s *RefQueueStorer) SetReference(ref *plumbing.Reference) error {
+	if err := s.Storage.SetReference(ref); err != nil {
+		if !IsDegradedWriteError(err) {
+			return err //nolint:wrapcheck // pass go-git errors through unchanged
+		}
+		return s.enqueue(queuedRefFor(ref))
+	}
+	if err := s.dropQueued(ref.Name()); err != nil {
+		return err
+	}
+	_, _, _ = s.Flush() //nolint:dogsled // best effort; failures stay queued
+	return nil
+}
+
+// CheckAndSetReference writes ref if old still matches, queueing it if the git
+// dir rejects the write. The check is made against queued values too.
+func (s *RefQueueStorer) CheckAndSetReference(ref, old *plumbing.Reference) error {
+	if old != nil {
+		current, err := s.Reference(old.Name())
+		if err == nil && current.Hash() != old.Hash() {
+			return storage.ErrReferenceHasChanged
+		}
+	}
+	return s.SetReference(ref)
+}
+
+// Reference returns the queued value of name if there is one.
+func (s *RefQueueStorer) Reference(name plumbing.ReferenceName) (*plumbing.Reference, error) {
+	queued, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range queued {
+		if q.Name == name.String() {
+			if q.Deleted {
+				return nil, plumbing.ErrReferenceNotFound
+			}
+			return plumbing.NewReferenceFromStrings(q.Name, q.Target), nil
+		}
+	}
+	return s.Storage.Reference(name) //nolint:wrapcheck // pass go-git errors through unchanged
+}
+
+// IterReferences iterates stored refs with queued writes applied.
+func (s *RefQueueStorer) IterReferences() (storer.ReferenceIter, error) {
+	queued, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	iter, err := s.Storage.IterReferences()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // pass go-git errors through unchanged
+	}
+	if len(queued) == 0 {
+		return iter, nil
+	}
+
+	overrides := make(map[string]QueuedRef, len(queued))
+	for _, q := range queued {
+		overrides[q.Name] = q
+	}
+	var refs []*plumbing.Reference
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if _, ok := overrides[ref.Name().String()]; !ok {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // pass go-git errors through unchanged
+	}
+	for _, q := range queued {
+		if !q.Deleted {
+			refs = append(refs, plumbing.NewReferenceFromStrings(q.Name, q.Target))
+		}
+	}
+	return storer.NewReferenceSliceIter(refs), nil
+}
+
+// RemoveReference deletes name, queueing the deletion if the git dir rejects it.
+func (s *RefQueueStorer) RemoveReference(name plumbing.ReferenceName) error {
+	if err := s.Storage.RemoveReference(name); err != nil {
+		if !IsDegradedWriteError(err) {
+			return err //nolint:wrapcheck // pass go-git errors through unchanged
+		}
+		return s.enqueue(QueuedRef{Name: name.String(), Deleted: true, QueuedAt: time.Now()})
+	}
+	return s.dropQueued(name)
+}
+
+// PendingRefs returns the ref writes queued for commonDir.
+func PendingRefs(commonDir string) ([]QueuedRef, error) {
+	s := &RefQueueStorer{queuePath: filepath.Join(Detect(commonDir).FallbackDir, PendingRefsFileName)}
+	return s.load()
+}
+
+// Pending returns the queued ref writes.
+func (s *RefQueueStorer) Pending() ([]QueuedRef, error) {
+	return s.load()
+}
+
+// Flush applies queued ref writes to the git dir. Writes that still fail with
+// a degraded-filesystem error stay queued.
+func (s *RefQueueStorer) Flush() (applied, remaining int, err error) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	queued, err := s.loadLocked()
+	if err != nil || len(queued) == 0 {
+		return 0, 0, err
+	}
+
+	var keep []QueuedRef
+	var firstErr error
+	for _, q := range queued {
+		var writeErr error
+		if q.Deleted {
+			writeErr = s.Storage.RemoveReference(plumbing.ReferenceName(q.Name))
+		} else {
+			writeErr = s.Storage.SetReference(plumbing.NewReferenceFromStrings(q.Name, q.Target))
+		}
+		switch {
+		case writeErr == nil:
+			applied++
+		case IsDegradedWriteError(writeErr):
+			keep = append(keep, q)
+		default:
+			// Not retryable; drop it rather than retrying forever
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to apply queued ref %s: %w", q.Name, writeErr)
+			}
+		}
+	}
+	if err := s.saveLocked(keep); err != nil {
+		return applied, len(keep), err
+	}
+	return applied, len(keep), firstErr
+}
+
+func (s *RefQueueStorer) enqueue(ref QueuedRef) error {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	queued, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	queued = replaceQueued(queued, ref)
+	return s.saveLocked(queued)
+}
+
+func (s *RefQueueStorer) dropQueued(name plumbing.ReferenceName) error {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	queued, err := s.loadLocked()
+	if err != nil || len(queued) == 0 {
+		return err
+	}
+	kept := queued[:0]
+	for _, q := range queued {
+		if q.Name != name.String() {
+			kept = append(kept, q)
+		}
+	}
+	if len(kept) == len(queued) {
+		return nil
+	}
+	return s.saveLocked(kept)
+}
+
+func (s *RefQueueStorer) load() ([]QueuedRef, error) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *RefQueueStorer) loadLocked() ([]QueuedRef, error) {
+	data, err := os.ReadFile(s.queuePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ref queue: %w", err)
+	}
+	var queued []QueuedRef
+	if err := json.Unmarshal(data, &queued); err != nil {
+		return nil, fmt.Errorf("failed to parse ref queue %s: %w", s.queuePath, err)
+	}
+	return queued, nil
+}
+
+func (s *RefQueueStorer) saveLocked(queued []QueuedRef) error {
+	if len(queued) == 0 {
+		if err := os.Remove(s.queuePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove ref queue: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(queued, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ref queue: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.queuePath), 0o750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	// Write to a temp file and rename so readers never see a partial queue
+	tmp := s.queuePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write ref queue: %w", err)
+	}
+	if err := os.Rename(tmp, s.queuePath); err != nil {
+		return fmt.Errorf("failed to write ref queue: %w", err)
+	}
+	return nil
+}
+
+// IsDegradedWriteError reports whether err is the kind of failure read-only
+// mounts and network filesystems produce: permission, read-only or lock errors.
+func IsDegradedWriteError(err error) bool {
+	return errors.Is(err, os.ErrPermission) ||
+		errors.Is(err, syscall.EROFS) ||
+		errors.Is(err, syscall.EACCES) ||
+		errors.Is(err, syscall.EPERM) ||
+		errors.Is(err, syscall.ENOLCK)
+}
+
+func queuedRefFor(ref *plumbing.Reference) QueuedRef {
+	strs := ref.Strings()
+	return QueuedRef{Name: strs[0], Target: strs[1], QueuedAt: time.Now()}
+}
+
+func replaceQueued(queued []QueuedRef, ref QueuedRef) []QueuedRef {
+	for i, q := range queued {
+		if q.Name == ref.Name {
+			queued[i] = ref
+			return queued
+		}
+	}
+	return append(queued, ref)
+}
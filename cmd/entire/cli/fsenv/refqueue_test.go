@@ -0,0 +1,133 @@
+package fsenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+func newTestQueueStorer(t *testing.T) *RefQueueStorer {
+	t.Helper()
+	repo, err := git.PlainInit(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		t.Fatalf("unexpected storer %T", repo.Storer)
+	}
+	return &RefQueueStorer{Storage: fsStorage, queuePath: filepath.Join(t.TempDir(), PendingRefsFileName)}
+}
+
+func TestRefQueueStorer_QueuedRefsOverlayAndFlush(t *testing.T) {
+	s := newTestQueueStorer(t)
+	name := plumbing.NewBranchReferenceName("entire/abc1234")
+	hash := plumbing.NewHash("1111111111111111111111111111111111111111")
+
+	if err := s.enqueue(queuedRefFor(plumbing.NewHashReference(name, hash))); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+
+	ref, err := s.Reference(name)
+	if err != nil || ref.Hash() != hash {
+		t.Fatalf("Reference() = %v, %v; want queued hash", ref, err)
+	}
+	if _, err := s.Storage.Reference(name); err == nil {
+		t.Fatal("queued ref should not be in the git dir yet")
+	}
+
+	iter, err := s.IterReferences()
+	if err != nil {
+		t.Fatalf("IterReferences() error = %v", err)
+	}
+	found := false
+	_ = iter.ForEach(func(r *plumbing.Reference) error {
+		found = found || r.Name() == name
+		return nil
+	})
+	if !found {
+		t.Error("IterReferences() did not include the queued ref")
+	}
+
+	applied, remaining, err := s.Flush()
+	if err != nil || applied != 1 || remaining != 0 {
+		t.Fatalf("Flush() = %d, %d, %v; want 1, 0, nil", applied, remaining, err)
+	}
+	if ref, err := s.Storage.Reference(name); err != nil || ref.Hash() != hash {
+		t.Errorf("after Flush, Reference() = %v, %v; want %s", ref, err, hash)
+	}
+	if _, err := os.Stat(s.queuePath); !os.IsNotExist(err) {
+		t.Errorf("queue file should be removed once empty, stat err = %v", err)
+	}
+}
+
+func TestRefQueueStorer_QueuedDeletion(t *testing.T) {
+	s := newTestQueueStorer(t)
+	name := plumbing.NewBranchReferenceName("entire/def5678")
+	if err := s.Storage.SetReference(plumbing.NewHashReference(name, plumbing.NewHash("2222222222222222222222222222222222222222"))); err != nil {
+		t.Fatalf("SetReference() error = %v", err)
+	}
+
+	if err := s.enqueue(QueuedRef{Name: name.String(), Deleted: true}); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	if _, err := s.Reference(name); !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		t.Errorf("Reference() error = %v, want ErrReferenceNotFound", err)
+	}
+
+	pending, err := s.Pending()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("Pending() = %v, %v", pending, err)
+	}
+	if _, _, err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if _, err := s.Storage.Reference(name); err == nil {
+		t.Error("queued deletion was not applied")
+	}
+}
+
+func TestRefQueueStorer_SetReferenceDropsQueuedEntry(t *testing.T) {
+	s := newTestQueueStorer(t)
+	name := plumbing.NewBranchReferenceName("entire/abc1234")
+	stale := plumbing.NewHashReference(name, plumbing.NewHash("1111111111111111111111111111111111111111"))
+	if err := s.enqueue(queuedRefFor(stale)); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+
+	fresh := plumbing.NewHashReference(name, plumbing.NewHash("3333333333333333333333333333333333333333"))
+	if err := s.SetReference(fresh); err != nil {
+		t.Fatalf("SetReference() error = %v", err)
+	}
+	ref, err := s.Reference(name)
+	if err != nil || ref.Hash() != fresh.Hash() {
+		t.Errorf("Reference() = %v, %v; want the written value, not the stale queued one", ref, err)
+	}
+	if pending, _ := s.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() = %v, want empty", pending)
+	}
+}
+
+func TestIsDegradedWriteError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&os.PathError{Op: "open", Path: "refs/heads/x.lock", Err: syscall.EROFS}, true},
+		{&os.PathError{Op: "open", Path: "refs/heads/x.lock", Err: syscall.EACCES}, true},
+		{&os.PathError{Op: "flock", Path: "refs/heads/x", Err: syscall.ENOLCK}, true},
+		{&os.PathError{Op: "open", Path: "refs/heads/x", Err: syscall.ENOENT}, false},
+		{plumbing.ErrReferenceNotFound, false},
+	}
+	for _, tt := range tests {
+		if got := IsDegradedWriteError(tt.err); got != tt.want {
+			t.Errorf("IsDegradedWriteError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
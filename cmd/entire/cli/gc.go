@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
+	"github.com/spf13/cobra"
+)
+
+// defaultGCPruneExpire is git gc's own default grace period for unreachable
+// objects, which protects objects a concurrent command has written but not
+// yet referenced.
+const defaultGCPruneExpire = "2.weeks.ago"
+
+func newGCCmd() *cobra.Command {
+	var forceFlag bool
+	var pruneFlag string
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove Entire data that no live session in any worktree needs",
+		Long: `Remove orphaned Entire data (shadow branches, session state, checkpoint
+metadata), like 'entire clean', and show what is kept because a session needs it.
+
+Shadow branches and session state are shared by all worktrees of a repository.
+gc discovers the sessions of every worktree and counts, per shadow branch, the
+sessions that still need it: sessions that haven't ended, and ended sessions
+with checkpoints that were never condensed. Branches with any live user are
+kept, so running gc in one worktree can't break a session in another. Sessions
+whose worktree has been removed don't count.
+
+Checkpoints live in git's object store, which is content-addressed: a file
+that doesn't change between checkpoints, sessions or commits is stored once.
+gc reports how much of the object store only Entire's refs (shadow branches
+and the checkpoint metadata branch) use, and how many objects are still loose.
+Entire writes each checkpoint's objects loose, one file per object, until they
+are packed.
+
+Only one gc runs per repository at a time.
+
+Default: shows a preview of items that would be deleted.
+With --force, actually deletes the orphaned items, then repacks the loose
+objects (git repack -d) and prunes unreachable objects older than --prune, so
+the space of deleted shadow branches is reclaimed.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runGC(cmd.Context(), cmd.OutOrStdout(), forceFlag, pruneFlag)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Actually delete items (default: dry run)")
+	cmd.Flags().StringVar(&pruneFlag, "prune", defaultGCPruneExpire, "With --force, prune unreachable objects older than this (a git date, or \"now\")")
+
+	return cmd
+}
+
+func runGC(ctx context.Context, w io.Writer, force bool, pruneExpire string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	defer initLogging("")()
+
+	release, err := acquireGCLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	items, err := strategy.ListAllCleanupItems()
+	if err != nil {
+		return fmt.Errorf("failed to list orphaned items: %w", err)
+	}
+
+	before, err := measureGCStorage(ctx)
+	if err != nil {
+		return err
+	}
+	writeGCStorage(w, before)
+
+	if err := writeKeptShadowBranches(w); err != nil {
+		return err
+	}
+	if err := runCleanWithItems(w, force, items); err != nil {
+		return err
+	}
+	if !force {
+		return nil
+	}
+
+	if err := reclaimObjectSpace(ctx, pruneExpire); err != nil {
+		return err
+	}
+	after, err := measureGCStorage(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nRepacked objects: %s on disk, now %s (%s reclaimed).\n",
+		formatDiskSize(before.objectBytes()), formatDiskSize(after.objectBytes()),
+		formatDiskSize(max(0, before.objectBytes()-after.objectBytes())))
+	return nil
+}
+
+// gcStorage is how much of the object store Entire's data takes.
+type gcStorage struct {
+	// Bytes on disk of the objects reachable only from shadow branches, and
+	// only from the checkpoint metadata branch; -1 when git can't tell
+	// (rev-list --disk-usage needs git 2.38).
+	ShadowBytes   int64
+	MetadataBytes int64
+	ShadowRefs    int
+
+	LooseObjects int
+	LooseBytes   int64
+	Packs        int
+	PackBytes    int64
+}
+
+func (s *gcStorage) objectBytes() int64 {
+	return s.LooseBytes + s.PackBytes
+}
+
+// userRefsExcluded are rev-list arguments for every ref that isn't Entire's,
+// whose objects Entire's refs merely share.
+var userRefsExcluded = []string{"--not", "HEAD", "--exclude=entire/*", "--branches", "--exclude=*/entire/*", "--remotes", "--tags"}
+
+func measureGCStorage(ctx context.Context) (*gcStorage, error) {
+	storage := &gcStorage{}
+	branches, err := strategy.ListShadowBranches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow branches: %w", err)
+	}
+	storage.ShadowRefs = len(branches)
+
+	repo, err := openRepository()
+	if err != nil {
+		return nil, err
+	}
+	// Shadow branches are hidden refs under jj, regular branches otherwise
+	shadowRefs := vcs.Detect(repo).ShadowRef(checkpoint.ShadowBranchPrefix + "*")
+	storage.ShadowBytes = entireDiskUsage(ctx, "--exclude=refs/heads/"+paths.MetadataBranchName, "--glob="+shadowRefs.String())
+	storage.MetadataBytes = entireDiskUsage(ctx, "--glob=refs/heads/"+paths.MetadataBranchName)
+
+	output, err := exec.CommandContext(ctx, "git", "count-objects", "-v").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count objects: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "count":
+			storage.LooseObjects = int(n)
+		case "size":
+			storage.LooseBytes = n * 1024
+		case "packs":
+			storage.Packs = int(n)
+		case "size-pack":
+			storage.PackBytes = n * 1024
+		}
+	}
+	return storage, nil
+}
+
+// entireDiskUsage returns the bytes on disk of the objects reachable from
+// revs but from no ref outside Entire's, or -1 if git can't tell.
+func entireDiskUsage(ctx context.Context, revs ...string) int64 {
+	args := append([]string{"rev-list", "--objects", "--disk-usage"}, revs...)
+	args = append(args, userRefsExcluded...)
+	output, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func writeGCStorage(w io.Writer, s *gcStorage) {
+	size := func(n int64) string {
+		if n < 0 {
+			return "unknown (needs git 2.38 or later)"
+		}
+		return formatDiskSize(n)
+	}
+	fmt.Fprintln(w, "Storage used only by Entire:")
+	fmt.Fprintf(w, "  Shadow branches (%d):   %s\n", s.ShadowRefs, size(s.ShadowBytes))
+	fmt.Fprintf(w, "  Checkpoint metadata:   %s\n", size(s.MetadataBytes))
+	fmt.Fprintf(w, "Object store: %d loose object(s) (%s), %d pack(s) (%s)\n\n",
+		s.LooseObjects, formatDiskSize(s.LooseBytes), s.Packs, formatDiskSize(s.PackBytes))
+}
+
+// reclaimObjectSpace packs loose objects, dropping the ones already packed,
+// and prunes unreachable objects older than pruneExpire, such as those of
+// deleted shadow branches.
+func reclaimObjectSpace(ctx context.Context, pruneExpire string) error {
+	if output, err := exec.CommandContext(ctx, "git", "repack", "-d", "-q").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to repack objects: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if output, err := exec.CommandContext(ctx, "git", "prune", "--expire="+pruneExpire).CombinedOutput(); err != nil { //nolint:gosec // pruneExpire is passed as a single --expire value
+		return fmt.Errorf("failed to prune objects: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// formatDiskSize formats a byte count with binary units.
+func formatDiskSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// acquireGCLock takes the repository-wide cleanup lock shared by gc and clean.
+func acquireGCLock() (func(), error) {
+	release, err := strategy.AcquireGCLock()
+	if errors.Is(err, session.ErrGCRunning) {
+		return nil, err //nolint:wrapcheck // message is already user-facing
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take cleanup lock: %w", err)
+	}
+	return release, nil
+}
+
+// writeKeptShadowBranches lists shadow branches kept because live sessions
+// (in this or another worktree) still need them.
+func writeKeptShadowBranches(w io.Writer) error {
+	branches, err := strategy.ListShadowBranches()
+	if err != nil {
+		return fmt.Errorf("failed to list shadow branches: %w", err)
+	}
+	refs, err := strategy.CollectSessionRefs()
+	if err != nil {
+		return fmt.Errorf("failed to discover sessions: %w", err)
+	}
+
+	var kept []string
+	for _, branch := range branches {
+		if refs.ShadowBranchRefCount(branch) > 0 {
+			kept = append(kept, branch)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "Kept %d shadow branch(es) still used by live sessions:\n", len(kept))
+	for _, branch := range kept {
+		fmt.Fprintf(w, "  %s\n", branch)
+		for _, state := range refs.ShadowBranchUsers(branch) {
+			worktree := state.WorktreePath
+			if worktree == "" {
+				worktree = unknownPlaceholder
+			}
+			fmt.Fprintf(w, "    session %s (%s) in %s\n", state.SessionID, session.PhaseFromString(string(state.Phase)), worktree)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
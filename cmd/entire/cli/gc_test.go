@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRunGC_KeepsLiveSessionBranches(t *testing.T) {
+	repo, commitHash := setupCleanTestRepo(t)
+
+	// A session still running in a linked worktree (its directory exists)
+	state := &strategy.SessionState{
+		SessionID:    "2026-10-14-live-session",
+		BaseCommit:   commitHash.String(),
+		WorktreeID:   "feature",
+		WorktreePath: t.TempDir(),
+		StartedAt:    time.Now().Add(-2 * time.Hour),
+		Phase:        session.PhaseActive,
+		StepCount:    1,
+	}
+	if err := strategy.SaveSessionState(state); err != nil {
+		t.Fatalf("SaveSessionState() error = %v", err)
+	}
+	liveBranch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+	for _, b := range []string{liveBranch, "entire/abc1234"} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(b), commitHash)); err != nil {
+			t.Fatalf("failed to create branch %s: %v", b, err)
+		}
+	}
+
+	var stdout bytes.Buffer
+	if err := runGC(context.Background(), &stdout, true, defaultGCPruneExpire); err != nil {
+		t.Fatalf("runGC() error = %v", err)
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"Kept 1 shadow branch(es) still used by live sessions",
+		"session 2026-10-14-live-session (active) in " + state.WorktreePath,
+		"Deleted 1 items",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(liveBranch), true); err != nil {
+		t.Errorf("live session's shadow branch %s was deleted", liveBranch)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName("entire/abc1234"), true); err == nil {
+		t.Error("orphaned shadow branch entire/abc1234 should have been deleted")
+	}
+}
+
+func TestRunGC_Concurrent(t *testing.T) {
+	setupCleanTestRepo(t)
+
+	release, err := acquireGCLock()
+	if err != nil {
+		t.Fatalf("acquireGCLock() error = %v", err)
+	}
+	defer release()
+
+	var stdout bytes.Buffer
+	if err := runGC(context.Background(), &stdout, false, defaultGCPruneExpire); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("runGC() error = %v, want an already-running error", err)
+	}
+}
+
+func TestRunGC_ReportsAndReclaimsStorage(t *testing.T) {
+	repo, commitHash := setupCleanTestRepo(t)
+
+	// An orphaned shadow branch whose checkpoint holds a file nothing else has
+	blob := storeTestShadowCheckpoint(t, repo, commitHash, plumbing.NewBranchReferenceName("entire/abc1234"))
+
+	var stdout bytes.Buffer
+	if err := runGC(context.Background(), &stdout, false, defaultGCPruneExpire); err != nil {
+		t.Fatalf("runGC() error = %v", err)
+	}
+	before, err := measureGCStorage(context.Background())
+	if err != nil {
+		t.Fatalf("measureGCStorage() error = %v", err)
+	}
+	if before.ShadowRefs != 1 || before.ShadowBytes <= 0 || before.LooseObjects == 0 {
+		t.Fatalf("storage before gc = %+v, want one shadow branch with loose objects", before)
+	}
+	if !strings.Contains(stdout.String(), "Shadow branches (1):   "+formatDiskSize(before.ShadowBytes)) {
+		t.Errorf("output =\n%s\nwant the shadow branches' size", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := runGC(context.Background(), &stdout, true, "now"); err != nil {
+		t.Fatalf("runGC(force) error = %v", err)
+	}
+	after, err := measureGCStorage(context.Background())
+	if err != nil {
+		t.Fatalf("measureGCStorage() error = %v", err)
+	}
+	if after.ShadowRefs != 0 || after.LooseObjects != 0 || after.Packs == 0 {
+		t.Errorf("storage after gc = %+v, want no shadow branches and everything packed", after)
+	}
+	if !strings.Contains(stdout.String(), "reclaimed") {
+		t.Errorf("output =\n%s\nwant the reclaimed space", stdout.String())
+	}
+	if _, err := repo.BlobObject(blob); err == nil {
+		t.Error("the deleted shadow branch's blob should have been pruned")
+	}
+}
+
+func TestMeasureGCStorage_Jujutsu(t *testing.T) {
+	repo, commitHash := setupCleanTestRepo(t)
+	if err := os.MkdirAll(filepath.Join(".jj", "repo"), 0o755); err != nil {
+		t.Fatalf("failed to create .jj: %v", err)
+	}
+
+	// Under jj, shadow branches are hidden refs rather than branches
+	storeTestShadowCheckpoint(t, repo, commitHash, plumbing.ReferenceName(vcs.JujutsuShadowRefPrefix+"abc1234"))
+
+	storage, err := measureGCStorage(context.Background())
+	if err != nil {
+		t.Fatalf("measureGCStorage() error = %v", err)
+	}
+	if storage.ShadowRefs != 1 || storage.ShadowBytes <= 0 {
+		t.Errorf("storage = %+v, want one shadow branch taking space", storage)
+	}
+}
+
+// storeTestShadowCheckpoint points ref at a checkpoint on top of parent whose
+// tree holds a file nothing else has, and returns that file's blob.
+func storeTestShadowCheckpoint(t *testing.T, repo *git.Repository, parent plumbing.Hash, ref plumbing.ReferenceName) plumbing.Hash {
+	t.Helper()
+	blob := storeTestBlob(t, repo, strings.Repeat("only on the shadow branch\n", 4096))
+	tree := storeTestTree(t, repo, []object.TreeEntry{{Name: "big.txt", Mode: filemode.Regular, Hash: blob}})
+	sig := object.Signature{Name: "test", Email: "test@test.com", When: time.Now()}
+	shadow := &object.Commit{Author: sig, Committer: sig, Message: "checkpoint", TreeHash: tree, ParentHashes: []plumbing.Hash{parent}}
+	obj := repo.Storer.NewEncodedObject()
+	if err := shadow.Encode(obj); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	shadowHash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("SetEncodedObject() error = %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(ref, shadowHash)); err != nil {
+		t.Fatalf("failed to create shadow branch: %v", err)
+	}
+	return blob
+}
+
+func storeTestBlob(t *testing.T, repo *git.Repository, content string) plumbing.Hash {
+	t.Helper()
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("SetEncodedObject() error = %v", err)
+	}
+	return hash
+}
+
+func storeTestTree(t *testing.T, repo *git.Repository, entries []object.TreeEntry) plumbing.Hash {
+	t.Helper()
+	obj := repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{Entries: entries}).Encode(obj); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("SetEncodedObject() error = %v", err)
+	}
+	return hash
+}
@@ -360,20 +360,27 @@ func FetchAndCheckoutRemoteBranch(branchName string) error {
 // Uses git CLI instead of go-git for fetch because go-git doesn't use credential helpers,
 // which breaks HTTPS URLs that require authentication.
 func FetchMetadataBranch() error {
-	branchName := paths.MetadataBranchName
-
 	// Use git CLI for fetch (go-git's fetch can be tricky with auth)
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	refSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branchName, branchName)
+	err := fetchMetadataBranchFrom(ctx, "origin")
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return errors.New("fetch timed out after 2 minutes")
+	}
+	return err
+}
+
+// fetchMetadataBranchFrom fetches the entire/checkpoints/v1 branch from remote
+// and points the local branch at it.
+func fetchMetadataBranchFrom(ctx context.Context, remote string) error {
+	branchName := paths.MetadataBranchName
+
+	refSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branchName, remote, branchName)
 	//nolint:gosec // G204: branchName is a constant from paths package
-	fetchCmd := exec.CommandContext(ctx, "git", "fetch", "origin", refSpec)
+	fetchCmd := exec.CommandContext(ctx, "git", "fetch", remote, refSpec)
 	if output, err := fetchCmd.CombinedOutput(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return errors.New("fetch timed out after 2 minutes")
-		}
-		return fmt.Errorf("failed to fetch %s from origin: %s: %w", branchName, strings.TrimSpace(string(output)), err)
+		return fmt.Errorf("failed to fetch %s from %s: %s: %w", branchName, remote, strings.TrimSpace(string(output)), err)
 	}
 
 	repo, err := openRepository()
@@ -382,9 +389,9 @@ func FetchMetadataBranch() error {
 	}
 
 	// Get the remote branch reference
-	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branchName), true)
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, branchName), true)
 	if err != nil {
-		return fmt.Errorf("branch '%s' not found on origin: %w", branchName, err)
+		return fmt.Errorf("branch '%s' not found on %s: %w", branchName, remote, err)
 	}
 
 	// Create or update local branch pointing to the same commit
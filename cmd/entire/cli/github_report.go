@@ -0,0 +1,378 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultGitHubAPIURL    = "https://api.github.com"
+	defaultGitHubServerURL = "https://github.com"
+
+	// githubCheckName is the name of the check run `--check` posts.
+	githubCheckName = "Entire attribution"
+
+	// githubPageSize is the page size of list requests (the API's maximum).
+	githubPageSize = 100
+)
+
+func newGitHubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "github",
+		Short: "Report attribution on GitHub pull requests",
+	}
+	cmd.AddCommand(newGitHubReportCmd())
+	return cmd
+}
+
+// githubReportOptions are the flags of `entire github report`.
+type githubReportOptions struct {
+	PR        int
+	Repo      string
+	Remote    string
+	Check     bool
+	NoComment bool
+	DryRun    bool
+	JSON      bool
+}
+
+func newGitHubReportCmd() *cobra.Command {
+	var opts githubReportOptions
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Post the attribution of a pull request's commits as a comment or check run",
+		Long: `Adds up the attribution of a pull request's commits and posts it on the pull
+request as a comment: the agent share, the files with their agent
+percentages, and the sessions behind the commits, linked to their metadata
+on the entire/checkpoints/v1 branch. Reruns update the same comment. With
+--check, the summary is also posted as a neutral check run on the pull
+request's head commit.
+
+Attribution is read from the local entire/checkpoints/v1 branch, which is
+fetched from --remote when missing. The token comes from GITHUB_TOKEN (or
+GH_TOKEN), and GITHUB_API_URL/GITHUB_SERVER_URL point at GitHub Enterprise.
+
+In GitHub Actions, --repo and --pr default to the workflow's repository and
+pull request, so 'entire github report --check' is enough. The job needs
+pull-requests: write (and checks: write for --check) permissions, and a
+checkout with fetch-depth: 0. --dry-run prints the comment instead.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runGitHubReport(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.PR, "pr", 0, "Pull request number (default: the pull request of the GitHub Actions event)")
+	cmd.Flags().StringVar(&opts.Repo, "repo", "", "Repository as owner/name (default: GITHUB_REPOSITORY, then the remote's URL)")
+	cmd.Flags().StringVar(&opts.Remote, "remote", "origin", "Remote to fetch the metadata branch from and read the repository from")
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "Also post the summary as a check run")
+	cmd.Flags().BoolVar(&opts.NoComment, "no-comment", false, "Don't post or update the comment")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print the comment instead of posting anything")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "With --dry-run, print the report as JSON")
+
+	return cmd
+}
+
+func runGitHubReport(ctx context.Context, w, errW io.Writer, opts githubReportOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repoSlug := opts.Repo
+	if repoSlug == "" {
+		repoSlug = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if repoSlug == "" {
+		if _, path, ok := remoteRepoPath(ctx, opts.Remote); ok {
+			repoSlug = path
+		}
+	}
+	if strings.Count(repoSlug, "/") != 1 {
+		return errors.New("could not tell the repository: use --repo owner/name")
+	}
+	prNumber := opts.PR
+	if prNumber == 0 {
+		prNumber = githubEventPRNumber(os.Getenv("GITHUB_EVENT_PATH"))
+	}
+	if prNumber <= 0 {
+		return errors.New("could not tell the pull request: use --pr <number>")
+	}
+
+	client := newGitHubClient()
+	pr, err := client.pullRequest(ctx, repoSlug, prNumber)
+	if err != nil {
+		return err
+	}
+	commits, err := client.pullRequestCommits(ctx, repoSlug, prNumber)
+	if err != nil {
+		return err
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	if err := ensureMetadataBranch(ctx, repo, opts.Remote); err != nil {
+		fmt.Fprintf(errW, "Warning: %s is not available locally (%v); commits will show no attribution.\n", paths.MetadataBranchName, err)
+	}
+	serverURL := envOr("GITHUB_SERVER_URL", defaultGitHubServerURL)
+	report := buildPRReport(ctx, checkpoint.NewGitStore(repo), commits, func(cpID id.CheckpointID, index int) string {
+		return fmt.Sprintf("%s/%s/tree/%s/%s/%d", strings.TrimSuffix(serverURL, "/"), repoSlug, paths.MetadataBranchName, cpID.Path(), index)
+	})
+	body := report.markdown()
+
+	if opts.DryRun {
+		if opts.JSON {
+			data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal report: %w", err)
+			}
+			_, err = w.Write(data)
+			return err //nolint:wrapcheck // write to stdout
+		}
+		fmt.Fprint(w, body)
+		return nil
+	}
+
+	if !opts.NoComment {
+		commentURL, updated, err := client.upsertReportComment(ctx, repoSlug, prNumber, body)
+		if err != nil {
+			return err
+		}
+		verb := "Posted"
+		if updated {
+			verb = "Updated"
+		}
+		fmt.Fprintf(w, "%s attribution comment on %s#%d: %s\n", verb, repoSlug, prNumber, commentURL)
+	}
+	if opts.Check {
+		checkURL, err := client.createCheckRun(ctx, repoSlug, pr.Head.SHA, report.title(), body)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Posted check run %q: %s\n", githubCheckName, checkURL)
+	}
+	return nil
+}
+
+// githubEventPRNumber returns the pull request number of the GitHub Actions
+// event in the file at eventPath, 0 if there is none.
+func githubEventPRNumber(eventPath string) int {
+	if eventPath == "" {
+		return 0
+	}
+	data, err := os.ReadFile(eventPath) //nolint:gosec // path comes from the Actions runner
+	if err != nil {
+		return 0
+	}
+	var event struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return 0
+	}
+	if event.PullRequest.Number > 0 {
+		return event.PullRequest.Number
+	}
+	return event.Number
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// githubClient is a minimal GitHub REST API client.
+type githubClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newGitHubClient() *githubClient {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return &githubClient{
+		baseURL: strings.TrimSuffix(envOr("GITHUB_API_URL", defaultGitHubAPIURL), "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request with a JSON body (if in is non-nil) and decodes the
+// JSON response into out (if non-nil).
+func (c *githubClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "entire-cli")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub API %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("GitHub API %s %s: failed to read response: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr) //nolint:errcheck // the status is reported either way
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		hint := ""
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			hint = " (check GITHUB_TOKEN and its permissions)"
+		}
+		return fmt.Errorf("GitHub API %s %s: %d %s%s", method, path, resp.StatusCode, apiErr.Message, hint)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("GitHub API %s %s: failed to parse response: %w", method, path, err)
+	}
+	return nil
+}
+
+type githubPullRequest struct {
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+func (c *githubClient) pullRequest(ctx context.Context, repo string, number int) (*githubPullRequest, error) {
+	var pr githubPullRequest
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// pullRequestCommits lists the pull request's commits, oldest first. The
+// API returns at most 250.
+func (c *githubClient) pullRequestCommits(ctx context.Context, repo string, number int) ([]prReportCommit, error) {
+	var commits []prReportCommit
+	for page := 1; ; page++ {
+		var batch []struct {
+			SHA    string `json:"sha"`
+			Commit struct {
+				Message string `json:"message"`
+			} `json:"commit"`
+		}
+		path := fmt.Sprintf("/repos/%s/pulls/%d/commits?per_page=%d&page=%d", repo, number, githubPageSize, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, b := range batch {
+			commits = append(commits, prReportCommit{SHA: b.SHA, Message: b.Commit.Message})
+		}
+		if len(batch) < githubPageSize {
+			return commits, nil
+		}
+	}
+}
+
+// upsertReportComment updates the pull request's report comment, or posts
+// one. Returns the comment's URL and whether it already existed.
+func (c *githubClient) upsertReportComment(ctx context.Context, repo string, number int, body string) (string, bool, error) {
+	type comment struct {
+		ID      int64  `json:"id"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+	}
+	for page := 1; ; page++ {
+		var batch []comment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", repo, number, githubPageSize, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return "", false, err
+		}
+		for _, existing := range batch {
+			if !strings.HasPrefix(existing.Body, prReportMarker) {
+				continue
+			}
+			var updated comment
+			path := fmt.Sprintf("/repos/%s/issues/comments/%s", repo, strconv.FormatInt(existing.ID, 10))
+			if err := c.do(ctx, http.MethodPatch, path, map[string]string{"body": body}, &updated); err != nil {
+				return "", false, err
+			}
+			return updated.HTMLURL, true, nil
+		}
+		if len(batch) < githubPageSize {
+			break
+		}
+	}
+	var created comment
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), map[string]string{"body": body}, &created); err != nil {
+		return "", false, err
+	}
+	return created.HTMLURL, false, nil
+}
+
+// createCheckRun posts a completed, neutral check run on headSHA.
+func (c *githubClient) createCheckRun(ctx context.Context, repo, headSHA, title, summary string) (string, error) {
+	in := map[string]any{
+		"name":       githubCheckName,
+		"head_sha":   headSHA,
+		"status":     "completed",
+		"conclusion": "neutral",
+		"output": map[string]string{
+			"title":   title,
+			"summary": summary,
+		},
+	}
+	var out struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/repos/"+repo+"/check-runs", in, &out); err != nil {
+		return "", err
+	}
+	return out.HTMLURL, nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+func TestGitHubEventPRNumber(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if got := githubEventPRNumber(write("pr.json", `{"action": "opened", "number": 7, "pull_request": {"number": 7}}`)); got != 7 {
+		t.Errorf("pull_request event = %d, want 7", got)
+	}
+	if got := githubEventPRNumber(write("push.json", `{"ref": "refs/heads/main"}`)); got != 0 {
+		t.Errorf("push event = %d, want 0", got)
+	}
+	if got := githubEventPRNumber(""); got != 0 {
+		t.Errorf("no event = %d, want 0", got)
+	}
+}
+
+func TestRunGitHubReport(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, _ := setupCleanTestRepo(t)
+	writePRReportCheckpoint(t, checkpoint.NewGitStore(repo), id.MustCheckpointID("a1b2c3d4e5f6"), "2026-10-14-gh")
+
+	var patched, checkRun map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/pulls/7":
+			w.Write([]byte(`{"head": {"sha": "abcdef1234567890abcdef1234567890abcdef12"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/pulls/7/commits":
+			w.Write([]byte(`[{"sha": "1111111111111111111111111111111111111111", "commit": {"message": "feat\n\nEntire-Checkpoint: a1b2c3d4e5f6\n"}}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/issues/7/comments":
+			w.Write([]byte(`[{"id": 1, "body": "LGTM"}, {"id": 42, "body": "` + prReportMarker + `\nold"}]`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/acme/app/issues/comments/42":
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.Write([]byte(`{"id": 42, "html_url": "https://github.com/acme/app/pull/7#issuecomment-42"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/app/check-runs":
+			json.NewDecoder(r.Body).Decode(&checkRun)
+			w.Write([]byte(`{"html_url": "https://github.com/acme/app/runs/1"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	t.Setenv("GITHUB_SERVER_URL", "")
+
+	var out bytes.Buffer
+	err := runGitHubReport(context.Background(), &out, &out, githubReportOptions{PR: 7, Repo: "acme/app", Remote: "origin", Check: true})
+	if err != nil {
+		t.Fatalf("runGitHubReport() error = %v", err)
+	}
+	body, _ := patched["body"].(string) //nolint:errcheck // checked below
+	if !strings.Contains(body, "75.0% agent-written (30 of 40 lines)") ||
+		!strings.Contains(body, "https://github.com/acme/app/tree/entire/checkpoints/v1/a1/b2c3d4e5f6/0") {
+		t.Errorf("updated comment =\n%s", body)
+	}
+	if checkRun["head_sha"] != "abcdef1234567890abcdef1234567890abcdef12" || checkRun["conclusion"] != "neutral" {
+		t.Errorf("check run = %+v", checkRun)
+	}
+	if !strings.Contains(out.String(), "Updated attribution comment on acme/app#7") {
+		t.Errorf("output = %q", out.String())
+	}
+}
@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultGitLabServerURL = "https://gitlab.com"
+
+	// gitlabPageSize is the page size of list requests (the API's maximum).
+	gitlabPageSize = 100
+)
+
+// errMRPolicyFailed is returned by `entire gitlab report --enforce-policy`
+// when a merge request commit violates the push policy, failing the job.
+var errMRPolicyFailed = NewSilentError(errors.New("merge request violates the push policy"))
+
+func newGitLabCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitlab",
+		Short: "Report attribution on GitLab merge requests",
+	}
+	cmd.AddCommand(newGitLabReportCmd())
+	return cmd
+}
+
+// gitlabReportOptions are the flags of `entire gitlab report`.
+type gitlabReportOptions struct {
+	MR            int
+	Project       string
+	Remote        string
+	EnforcePolicy bool
+	NoNote        bool
+	DryRun        bool
+	JSON          bool
+}
+
+func newGitLabReportCmd() *cobra.Command {
+	var opts gitlabReportOptions
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Post the attribution of a merge request's commits as a note",
+		Long: `Adds up the attribution of a merge request's commits and posts it on the
+merge request as a note: the agent share, the files with their agent
+percentages, and the sessions behind the commits, linked to their metadata
+on the entire/checkpoints/v1 branch. Reruns update the same note.
+
+With --enforce-policy, the commits are also checked against push_policy in
+the settings, as the pre-push hook does; the note lists the violations and
+the command exits non-zero, failing the pipeline. With push_policy.action
+warn it only reports them.
+
+Attribution is read from the local entire/checkpoints/v1 branch, which is
+fetched from --remote when missing. The token comes from GITLAB_TOKEN (a
+token with the api scope), and CI_SERVER_URL/CI_API_V4_URL point at a
+self-managed instance, defaulting to the remote's host.
+
+In GitLab CI merge request pipelines, --project and --mr default to the
+pipeline's project and merge request, so 'entire gitlab report' is enough.
+Set GIT_DEPTH: 0 so the commits and the metadata branch can be read.
+--dry-run prints the note instead.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runGitLabReport(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.MR, "mr", 0, "Merge request IID (default: CI_MERGE_REQUEST_IID)")
+	cmd.Flags().StringVar(&opts.Project, "project", "", "Project path as group/name (default: CI_PROJECT_PATH, then the remote's URL)")
+	cmd.Flags().StringVar(&opts.Remote, "remote", "origin", "Remote to fetch the metadata branch from and read the project from")
+	cmd.Flags().BoolVar(&opts.EnforcePolicy, "enforce-policy", false, "Check the commits against push_policy and exit non-zero on violations")
+	cmd.Flags().BoolVar(&opts.NoNote, "no-note", false, "Don't post or update the note")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print the note instead of posting it")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "With --dry-run, print the report as JSON")
+
+	return cmd
+}
+
+func runGitLabReport(ctx context.Context, w, errW io.Writer, opts gitlabReportOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	remoteHost, remotePath, _ := remoteRepoPath(ctx, opts.Remote)
+	project := opts.Project
+	if project == "" {
+		project = os.Getenv("CI_PROJECT_PATH")
+	}
+	if project == "" {
+		project = remotePath
+	}
+	if !strings.Contains(project, "/") {
+		return errors.New("could not tell the project: use --project group/name")
+	}
+	mrIID := opts.MR
+	if mrIID == 0 {
+		mrIID, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID")) //nolint:errcheck // unset or invalid is reported below
+	}
+	if mrIID <= 0 {
+		return errors.New("could not tell the merge request: use --mr <iid>")
+	}
+
+	var policy *settings.PushPolicySettings
+	action := settings.PushPolicyBlock
+	if opts.EnforcePolicy {
+		s, err := settings.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load settings: %w", err)
+		}
+		if !s.PushPolicy.IsSet() {
+			return errors.New("--enforce-policy needs push_policy in the settings")
+		}
+		policy = s.PushPolicy
+		if action, err = policy.EffectiveAction(); err != nil {
+			return err //nolint:wrapcheck // already describes the setting
+		}
+	}
+
+	serverURL := defaultGitLabServerURL
+	if remoteHost != "" && remoteHost != "gitlab.com" {
+		serverURL = "https://" + remoteHost
+	}
+	serverURL = strings.TrimSuffix(envOr("CI_SERVER_URL", serverURL), "/")
+	client := newGitLabClient(envOr("CI_API_V4_URL", serverURL+"/api/v4"))
+	commits, err := client.mergeRequestCommits(ctx, project, mrIID)
+	if err != nil {
+		return err
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	if err := ensureMetadataBranch(ctx, repo, opts.Remote); err != nil {
+		fmt.Fprintf(errW, "Warning: %s is not available locally (%v); commits will show no attribution.\n", paths.MetadataBranchName, err)
+	}
+	store := checkpoint.NewGitStore(repo)
+	report := buildPRReport(ctx, store, commits, func(cpID id.CheckpointID, index int) string {
+		return fmt.Sprintf("%s/%s/-/tree/%s/%s/%d", serverURL, project, paths.MetadataBranchName, cpID.Path(), index)
+	})
+	body := report.markdown()
+	violations := prPolicyViolations(ctx, store, commits, policy)
+	if opts.EnforcePolicy {
+		body += policyMarkdown(violations)
+	}
+
+	switch {
+	case opts.DryRun && opts.JSON:
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err //nolint:wrapcheck // write to stdout
+		}
+	case opts.DryRun:
+		fmt.Fprint(w, body)
+	case !opts.NoNote:
+		noteURL, updated, err := client.upsertReportNote(ctx, project, mrIID, body, serverURL)
+		if err != nil {
+			return err
+		}
+		verb := "Posted"
+		if updated {
+			verb = "Updated"
+		}
+		fmt.Fprintf(w, "%s attribution note on %s!%d: %s\n", verb, project, mrIID, noteURL)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	fmt.Fprintf(errW, "Entire push policy: %d commit(s) violate the policy:\n", len(violations))
+	for _, v := range violations {
+		fmt.Fprintf(errW, "  %s %s: %s\n", v.Commit[:min(7, len(v.Commit))], v.Subject, v.Reason)
+	}
+	if action == settings.PushPolicyWarn {
+		return nil
+	}
+	return errMRPolicyFailed
+}
+
+// gitlabClient is a minimal GitLab REST API (v4) client.
+type gitlabClient struct {
+	baseURL string
+	// header is PRIVATE-TOKEN for personal, project and group tokens, and
+	// JOB-TOKEN for CI_JOB_TOKEN.
+	header string
+	token  string
+	http   *http.Client
+}
+
+func newGitLabClient(baseURL string) *gitlabClient {
+	c := &gitlabClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		c.header, c.token = "PRIVATE-TOKEN", token
+	} else if token := os.Getenv("CI_JOB_TOKEN"); token != "" {
+		c.header, c.token = "JOB-TOKEN", token
+	}
+	return c
+}
+
+// gitlabMRPath returns the API path of project's merge request iid.
+func gitlabMRPath(project string, iid int) string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(project), iid)
+}
+
+// do sends a request with a JSON body (if in is non-nil) and decodes the
+// JSON response into out (if non-nil).
+func (c *gitlabClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "entire-cli")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set(c.header, c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitLab API %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("GitLab API %s %s: failed to read response: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Errors come as {"message": "..."} or {"error": "..."}; message can
+		// also be an object of field errors.
+		var apiErr struct {
+			Message any    `json:"message"`
+			Error   string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &apiErr) //nolint:errcheck // the status is reported either way
+		message := apiErr.Error
+		if apiErr.Message != nil {
+			message = fmt.Sprint(apiErr.Message)
+		}
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		hint := ""
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			hint = " (check GITLAB_TOKEN and its scopes)"
+		}
+		return fmt.Errorf("GitLab API %s %s: %d %s%s", method, path, resp.StatusCode, message, hint)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("GitLab API %s %s: failed to parse response: %w", method, path, err)
+	}
+	return nil
+}
+
+// mergeRequestCommits lists the merge request's commits, oldest first.
+func (c *gitlabClient) mergeRequestCommits(ctx context.Context, project string, iid int) ([]prReportCommit, error) {
+	var commits []prReportCommit
+	for page := 1; ; page++ {
+		var batch []struct {
+			ID      string `json:"id"`
+			Message string `json:"message"`
+		}
+		path := fmt.Sprintf("%s/commits?per_page=%d&page=%d", gitlabMRPath(project, iid), gitlabPageSize, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, b := range batch {
+			commits = append(commits, prReportCommit{SHA: b.ID, Message: b.Message})
+		}
+		if len(batch) < gitlabPageSize {
+			break
+		}
+	}
+	// The API lists the newest commit first
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+// upsertReportNote updates the merge request's report note, or posts one.
+// Returns the note's URL and whether it already existed.
+func (c *gitlabClient) upsertReportNote(ctx context.Context, project string, iid int, body, serverURL string) (string, bool, error) {
+	type note struct {
+		ID     int64  `json:"id"`
+		Body   string `json:"body"`
+		System bool   `json:"system"`
+	}
+	noteURL := func(n note) string {
+		return fmt.Sprintf("%s/%s/-/merge_requests/%d#note_%d", serverURL, project, iid, n.ID)
+	}
+	notesPath := gitlabMRPath(project, iid) + "/notes"
+	for page := 1; ; page++ {
+		var batch []note
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", notesPath, gitlabPageSize, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return "", false, err
+		}
+		for _, existing := range batch {
+			if existing.System || !strings.HasPrefix(existing.Body, prReportMarker) {
+				continue
+			}
+			var updated note
+			path := notesPath + "/" + strconv.FormatInt(existing.ID, 10)
+			if err := c.do(ctx, http.MethodPut, path, map[string]string{"body": body}, &updated); err != nil {
+				return "", false, err
+			}
+			return noteURL(updated), true, nil
+		}
+		if len(batch) < gitlabPageSize {
+			break
+		}
+	}
+	var created note
+	if err := c.do(ctx, http.MethodPost, notesPath, map[string]string{"body": body}, &created); err != nil {
+		return "", false, err
+	}
+	return noteURL(created), false, nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+func TestRunGitLabReport(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, _ := setupCleanTestRepo(t)
+	writePRReportCheckpoint(t, checkpoint.NewGitStore(repo), id.MustCheckpointID("a1b2c3d4e5f6"), "2026-10-14-gl")
+	if err := os.MkdirAll(filepath.Dir(EntireSettingsFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(EntireSettingsFile, []byte(`{"push_policy": {"max_agent_percentage": 50}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var posted map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/projects/acme%2Fweb%2Fapp/merge_requests/7/commits":
+			w.Write([]byte(`[{"id": "2222222222222222222222222222222222222222", "message": "by hand"},
+				{"id": "1111111111111111111111111111111111111111", "message": "feat\n\nEntire-Checkpoint: a1b2c3d4e5f6\n"}]`))
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/projects/acme%2Fweb%2Fapp/merge_requests/7/notes":
+			w.Write([]byte(`[{"id": 1, "body": "` + prReportMarker + `\nfrom a bot", "system": true}]`))
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == "/projects/acme%2Fweb%2Fapp/merge_requests/7/notes":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"id": 42}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CI_API_V4_URL", server.URL)
+	t.Setenv("CI_SERVER_URL", "https://gitlab.example.com")
+	t.Setenv("GITLAB_TOKEN", "test-token")
+
+	var out, errOut bytes.Buffer
+	err := runGitLabReport(context.Background(), &out, &errOut, gitlabReportOptions{MR: 7, Project: "acme/web/app", Remote: "origin", EnforcePolicy: true})
+	if !errors.Is(err, errMRPolicyFailed) {
+		t.Fatalf("runGitLabReport() error = %v, want errMRPolicyFailed", err)
+	}
+	body, _ := posted["body"].(string) //nolint:errcheck // checked below
+	if !strings.Contains(body, "75.0% agent-written (30 of 40 lines)") ||
+		!strings.Contains(body, "https://gitlab.example.com/acme/web/app/-/tree/entire/checkpoints/v1/a1/b2c3d4e5f6/0") ||
+		!strings.Contains(body, "- :x: 1111111 feat: agent share 75.0% is above 50.0%") {
+		t.Errorf("posted note =\n%s", body)
+	}
+	if !strings.Contains(out.String(), "Posted attribution note on acme/web/app!7: https://gitlab.example.com/acme/web/app/-/merge_requests/7#note_42") {
+		t.Errorf("output = %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "1 commit(s) violate the policy") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+}
+
+func TestGitLabMergeRequestCommits_OldestFirst(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`[{"id": "c", "message": "third"}, {"id": "b", "message": "second"}, {"id": "a", "message": "first"}]`))
+	}))
+	defer server.Close()
+
+	commits, err := newGitLabClient(server.URL).mergeRequestCommits(context.Background(), "acme/app", 1)
+	if err != nil {
+		t.Fatalf("mergeRequestCommits() error = %v", err)
+	}
+	if len(commits) != 3 || commits[0].SHA != "a" || commits[2].SHA != "c" {
+		t.Errorf("commits = %+v, want a, b, c", commits)
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+// hookCountsFileName is the state file counting hook executions and
+// failures in this repository, for the /metrics endpoint.
+const hookCountsFileName = "entire-hook-counts.json"
+
+// hookCountsLockTimeout is how long a hook waits to count itself before
+// giving up. Counting never delays a hook by more.
+const hookCountsLockTimeout = 500 * time.Millisecond
+
+// hookCount is how often one hook ran and failed.
+type hookCount struct {
+	Hook string `json:"hook"`
+	// Agent is empty for git hooks.
+	Agent      string `json:"agent,omitempty"`
+	Type       string `json:"type"`
+	Executions int64  `json:"executions"`
+	Failures   int64  `json:"failures"`
+}
+
+func hookCountsPath() (string, error) {
+	commonDir, err := strategy.GetGitCommonDir()
+	if err != nil {
+		return "", err //nolint:wrapcheck // already wrapped by GetGitCommonDir
+	}
+	return fsenv.StateDir(commonDir, hookCountsFileName), nil
+}
+
+// recordHookExecution counts a hook execution, and a failure if hookErr is
+// set. Best effort: a count that can't be saved is only logged.
+func recordHookExecution(ctx context.Context, hookName, hookType, agentName string, hookErr error) {
+	if err := addHookExecution(ctx, hookName, hookType, agentName, hookErr != nil); err != nil {
+		logging.Debug(ctx, "failed to count hook execution", slog.String("hook", hookName), slog.String("error", err.Error()))
+	}
+}
+
+func addHookExecution(ctx context.Context, hookName, hookType, agentName string, failed bool) error {
+	path, err := hookCountsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	release, err := session.LockFile(ctx, path+".lock", hookCountsLockTimeout)
+	if err != nil {
+		return err //nolint:wrapcheck // already names the file
+	}
+	defer release()
+
+	counts, err := loadHookCounts(path)
+	if err != nil {
+		return err
+	}
+	i := sort.Search(len(counts), func(i int) bool { return !hookCountLess(counts[i], hookName, agentName) })
+	if i == len(counts) || counts[i].Hook != hookName || counts[i].Agent != agentName {
+		counts = append(counts[:i], append([]hookCount{{Hook: hookName, Agent: agentName, Type: hookType}}, counts[i:]...)...)
+	}
+	counts[i].Executions++
+	if failed {
+		counts[i].Failures++
+	}
+
+	data, err := jsonutil.MarshalIndentWithNewline(counts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode hook counts: %w", err)
+	}
+	// Replaced whole, so /metrics never reads a half-written file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write hook counts: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write hook counts: %w", err)
+	}
+	return nil
+}
+
+// hookCountLess orders counts by agent, then hook.
+func hookCountLess(c hookCount, hookName, agentName string) bool {
+	if c.Agent != agentName {
+		return c.Agent < agentName
+	}
+	return c.Hook < hookName
+}
+
+// readHookCounts returns the hook counts of this repository, none if no hook
+// has run yet.
+func readHookCounts() ([]hookCount, error) {
+	path, err := hookCountsPath()
+	if err != nil {
+		return nil, err
+	}
+	return loadHookCounts(path)
+}
+
+func loadHookCounts(path string) ([]hookCount, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is in the state directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook counts: %w", err)
+	}
+	var counts []hookCount
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return counts, nil
+}
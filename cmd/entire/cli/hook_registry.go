@@ -102,6 +102,14 @@ func init() {
 		return handleClaudeCodePostTodo()
 	})
 
+	RegisterHookHandler(agent.AgentNameClaudeCode, claudecode.HookNameSubagentStop, func() error {
+		enabled, err := IsEnabled()
+		if err == nil && !enabled {
+			return nil
+		}
+		return handleClaudeCodeSubagentStop()
+	})
+
 	// Register Gemini CLI handlers
 	RegisterHookHandler(agent.AgentNameGemini, geminicli.HookNameSessionStart, func() error {
 		enabled, err := IsEnabled()
@@ -228,6 +236,8 @@ func newAgentHooksCmd(agentName agent.AgentName, handler agent.HookHandler) *cob
 		Hidden: true,
 		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
 			agentHookLogCleanup = initHookLogging()
+			configureRedaction()
+			recoverInterruptedCheckpoints()
 			return nil
 		},
 		PersistentPostRunE: func(_ *cobra.Command, _ []string) error {
@@ -246,12 +256,12 @@ func newAgentHooksCmd(agentName agent.AgentName, handler agent.HookHandler) *cob
 }
 
 // getHookType returns the hook type based on the hook name.
-// Returns "subagent" for task-related hooks (pre-task, post-task, post-todo),
+// Returns "subagent" for task-related hooks (pre-task, post-task, post-todo, subagent-stop),
 // "tool" for tool-related hooks (before-tool, after-tool),
 // "agent" for all other agent hooks.
 func getHookType(hookName string) string {
 	switch hookName {
-	case claudecode.HookNamePreTask, claudecode.HookNamePostTask, claudecode.HookNamePostTodo:
+	case claudecode.HookNamePreTask, claudecode.HookNamePostTask, claudecode.HookNamePostTodo, claudecode.HookNameSubagentStop:
 		return "subagent"
 	case geminicli.HookNameBeforeTool, geminicli.HookNameAfterTool:
 		return "tool"
@@ -278,12 +288,23 @@ func newAgentHookVerbCmdWithLogging(agentName agent.AgentName, hookName string)
 			// Initialize logging context with agent name
 			ctx := logging.WithAgent(logging.WithComponent(context.Background(), "hooks"), agentName)
 
+			if skipDisabledHook(ctx, hookName) {
+				return nil
+			}
+
+			var hookErr error
+			finishTrace := startHookTrace(agentName, hookName)
+			defer func() { finishTrace(hookErr) }()
+
 			// Get strategy name for logging
 			strategyName := unknownStrategyName //nolint:ineffassign,wastedassign // already present in codebase
 			strategyName = GetStrategy().Name()
 
 			hookType := getHookType(hookName)
 
+			ctx, finishSpan := startHookSpan(ctx, hookName, hookType, slog.String("agent", string(agentName)), slog.String("strategy", strategyName))
+			defer func() { finishSpan(hookErr) }()
+
 			logging.Debug(ctx, "hook invoked",
 				slog.String("hook", hookName),
 				slog.String("hook_type", hookType),
@@ -304,7 +325,8 @@ func newAgentHookVerbCmdWithLogging(agentName agent.AgentName, hookName string)
 			currentHookAgentName = agentName
 			defer func() { currentHookAgentName = "" }()
 
-			hookErr := handler()
+			hookErr = handler()
+			recordHookExecution(ctx, hookName, hookType, string(agentName), hookErr)
 
 			logging.LogDuration(ctx, slog.LevelDebug, "hook completed", start,
 				slog.String("hook", hookName),
@@ -11,6 +11,7 @@ import (
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/session"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 )
@@ -126,6 +127,35 @@ func parseSubagentCheckpointHookInput(r io.Reader) (*SubagentCheckpointHookInput
 	return &input, nil
 }
 
+// SubagentStopHookInput represents the JSON input from the SubagentStop hook.
+// AgentID and AgentTranscriptPath are empty on Claude Code releases that don't
+// report which subagent finished.
+type SubagentStopHookInput struct {
+	SessionID           string `json:"session_id"`
+	TranscriptPath      string `json:"transcript_path"`
+	AgentID             string `json:"agent_id"`
+	AgentTranscriptPath string `json:"agent_transcript_path"`
+}
+
+// parseSubagentStopHookInput parses SubagentStop hook input from reader
+func parseSubagentStopHookInput(r io.Reader) (*SubagentStopHookInput, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("empty input")
+	}
+
+	var input SubagentStopHookInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return &input, nil
+}
+
 // taskToolInput represents the tool_input structure for the Task tool.
 // Used to extract subagent_type and description for descriptive commit messages.
 type taskToolInput struct {
@@ -265,8 +295,26 @@ func handleSessionStartCommon() error {
 	// Build informational message
 	message := "\n\nPowered by Entire:\n  This conversation will be linked to your next commit."
 
-	// Check for concurrent sessions and append count if any
+	// Archive sessions that never ended before they are counted as concurrent
+	strategy.ExpireStaleSessionsIfDue(logCtx, input.SessionID)
+
 	strat := GetStrategy()
+
+	// A resumed session may find HEAD moved or its checkpoints gone. Compaction
+	// restarts nothing, so there is nothing to re-validate.
+	if resumer, ok := strat.(strategy.SessionResumer); ok {
+		source, _ := input.RawData["source"].(string)
+		if source != "compact" {
+			resume, err := resumer.ResumeSession(logCtx, input.SessionID, source)
+			if err != nil {
+				logging.Warn(logCtx, "failed to resume session", slog.String("error", err.Error()))
+			} else if summary := strategy.DescribeResume(resume); summary != "" {
+				message += "\n  Resumed session: " + summary + "."
+			}
+		}
+	}
+
+	// Check for concurrent sessions and append count if any
 	if concurrentChecker, ok := strat.(strategy.ConcurrentSessionChecker); ok {
 		if count, err := concurrentChecker.CountOtherActiveSessionsWithCheckpoints(input.SessionID); err == nil && count > 0 {
 			message += fmt.Sprintf("\n  %d other active conversation(s) in this workspace will also be included.\n  Use 'entire status' for more information.", count)
@@ -278,16 +326,18 @@ func handleSessionStartCommon() error {
 		return err
 	}
 
+	// Precompute what the first checkpoint needs while the user types the first prompt
+	startWarmup()
+
 	// Fire EventSessionStart for the current session (if state exists).
 	// This handles ENDED → IDLE (re-entering a session).
 	// TODO(ENT-221): dispatch ActionWarnStaleSession for ACTIVE/ACTIVE_COMMITTED sessions.
-	if state, loadErr := strategy.LoadSessionState(input.SessionID); loadErr != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load session state on start: %v\n", loadErr)
-	} else if state != nil {
+	err = strategy.UpdateSessionState(input.SessionID, func(state *strategy.SessionState) error {
 		strategy.TransitionAndLog(state, session.EventSessionStart, session.TransitionContext{})
-		if saveErr := strategy.SaveSessionState(state); saveErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to update session state on start: %v\n", saveErr)
-		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update session state on start: %v\n", err)
 	}
 
 	return nil
@@ -296,6 +346,10 @@ func handleSessionStartCommon() error {
 // hookResponse represents a JSON response.
 // Used to control whether Agent continues processing the prompt.
 type hookResponse struct {
+	// Continue false stops the agent from processing the prompt, showing
+	// StopReason instead
+	Continue      *bool  `json:"continue,omitempty"`
+	StopReason    string `json:"stopReason,omitempty"`
 	SystemMessage string `json:"systemMessage,omitempty"`
 }
 
@@ -309,3 +363,70 @@ func outputHookResponse(reason string) error {
 	}
 	return nil
 }
+
+// outputHookStopResponse outputs a JSON response that stops the prompt with
+// stopReason
+func outputHookStopResponse(stopReason string) error {
+	stop := false
+	resp := hookResponse{
+		Continue:   &stop,
+		StopReason: stopReason,
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		return fmt.Errorf("failed to encode hook response: %w", err)
+	}
+	return nil
+}
+
+// checkPromptPolicies answers the prompt-submit hook with a stop response
+// if the prompt violates a prompt policy. Returns true if it was stopped.
+// A prompt on a protected branch may instead be moved to a session branch,
+// which the hook tells the user about.
+func checkPromptPolicies(ctx context.Context, sessionID string) bool {
+	s, err := LoadEntireSettings()
+	if err != nil || !s.PromptPolicy.IsSet() {
+		return false
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return false
+	}
+	env := currentPromptPolicyEnv(repoRoot)
+	var notice string
+	branch, err := leaveProtectedBranch(s.PromptPolicy, repoRoot, sessionID, env)
+	if err != nil {
+		logging.Warn(ctx, "failed to switch to a session branch", slog.String("error", err.Error()))
+	}
+	if branch != "" {
+		logging.Info(ctx, "switched to session branch", slog.String("from", env.Branch), slog.String("branch", branch))
+		notice = fmt.Sprintf("Entire: %s is a protected branch, so this session continues on the new branch %s.", env.Branch, branch)
+		env.Branch = branch
+	}
+	reason := evaluatePromptPolicies(ctx, s.PromptPolicy, env)
+	if reason == "" {
+		if notice != "" {
+			if err := outputHookResponse(notice); err != nil {
+				logging.Warn(ctx, "failed to report session branch", slog.String("error", err.Error()))
+			}
+		}
+		return false
+	}
+	if err := outputHookStopResponse(reason); err != nil {
+		logging.Warn(ctx, "failed to stop prompt", slog.String("error", err.Error()))
+		return false
+	}
+	return true
+}
+
+// recoverInterruptedCheckpoints finishes or undoes checkpoints that a crashed
+// hook left half done, before this hook reads session state.
+func recoverInterruptedCheckpoints() {
+	logCtx := logging.WithComponent(context.Background(), "checkpoint")
+	recovered, err := strategy.RecoverInterruptedCheckpoints()
+	if err != nil {
+		logging.Warn(logCtx, "failed to recover interrupted checkpoints", slog.String("error", err.Error()))
+	}
+	if recovered > 0 {
+		logging.Info(logCtx, "recovered interrupted checkpoints", slog.Int("count", recovered))
+	}
+}
@@ -0,0 +1,175 @@
+// hooks_aider.go captures Aider auto-commits from the git hooks.
+// Aider has no lifecycle hooks, so the checkpoint for each of its commits is
+// built from the chat history when the commit's prepare-commit-msg hook runs.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/agent/aider"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+)
+
+// aiderAttributionSuffix is appended to the git author/committer name by Aider
+// (--attribute-author / --attribute-committer, both on by default).
+const aiderAttributionSuffix = "(aider)"
+
+// isAiderCommit reports whether the commit being created was made by Aider.
+func isAiderCommit() bool {
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		if strings.HasSuffix(strings.TrimSpace(os.Getenv(name)), aiderAttributionSuffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// captureAiderCommit saves a checkpoint for an Aider auto-commit and adds the
+// checkpoint trailer to its message. The post-commit hook then condenses the
+// Aider session like any other session.
+//
+// This runs before the strategy's prepare-commit-msg handler, which keeps the
+// trailer it finds. Adding it here avoids the interactive "link this commit?"
+// prompt, which would block Aider. Only the manual-commit strategy is supported:
+// auto-commit would create a commit of its own from inside the hook.
+// Failures are logged and never fail the commit.
+func captureAiderCommit(ctx context.Context, strat strategy.Strategy, commitMsgFile string) {
+	if !isAiderCommit() || strat.Name() != strategy.StrategyNameManualCommit {
+		return
+	}
+	logCtx := logging.WithAgent(ctx, agent.AgentNameAider)
+
+	if err := saveAiderCheckpoint(logCtx, strat, commitMsgFile); err != nil {
+		logging.Warn(logCtx, "aider: failed to capture commit",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func saveAiderCheckpoint(logCtx context.Context, strat strategy.Strategy, commitMsgFile string) error {
+	content, err := os.ReadFile(commitMsgFile) //nolint:gosec // commitMsgFile is provided by git hook
+	if err != nil {
+		return fmt.Errorf("failed to read commit message: %w", err)
+	}
+	message := string(content)
+	if _, found := trailers.ParseCheckpoint(message); found {
+		return nil // Already linked
+	}
+
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get repo root: %w", err)
+	}
+	ag, err := agent.Get(agent.AgentNameAider)
+	if err != nil {
+		return fmt.Errorf("failed to get aider agent: %w", err)
+	}
+	historyPath := ag.ResolveSessionFile(repoRoot, "")
+
+	history, err := os.ReadFile(historyPath) //nolint:gosec // path is inside the repository
+	if err != nil {
+		return fmt.Errorf("failed to read chat history: %w", err)
+	}
+	chat, ok := aider.LatestChat(history)
+	if !ok {
+		logging.Debug(logCtx, "aider: chat history is empty")
+		return nil
+	}
+	sessionID := chat.SessionID()
+
+	// Everything since the last condensation belongs to this commit.
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session state: %w", err)
+	}
+	var start int
+	if state != nil {
+		start = state.CheckpointTranscriptStart
+	}
+
+	modifiedFiles := FilterAndNormalizePaths(chat.EditedFiles(start), repoRoot)
+	if len(modifiedFiles) == 0 {
+		logging.Debug(logCtx, "aider: no edits since last checkpoint",
+			slog.String("session_id", sessionID),
+		)
+		return nil
+	}
+
+	metadataDir := paths.SessionMetadataDirFromSessionID(sessionID)
+	metadataDirAbs, err := paths.AbsPath(metadataDir)
+	if err != nil {
+		metadataDirAbs = metadataDir
+	}
+	if err := os.MkdirAll(metadataDirAbs, 0o750); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	// Store only the current chat: transcript positions are relative to it.
+	if err := encryption.WriteFile(filepath.Join(metadataDirAbs, paths.TranscriptFileName), chat.Content(), 0o600); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	prompts := chat.Prompts(0)
+	if err := encryption.WriteFile(filepath.Join(metadataDirAbs, paths.PromptFileName), []byte(strings.Join(prompts, "\n\n---\n\n")), 0o600); err != nil {
+		return fmt.Errorf("failed to write prompt file: %w", err)
+	}
+
+	author, err := GetGitAuthor()
+	if err != nil {
+		return fmt.Errorf("failed to get git author: %w", err)
+	}
+
+	commitMessage := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	var lastPrompt string
+	if len(prompts) > 0 {
+		lastPrompt = prompts[len(prompts)-1]
+	}
+	var tokenUsage *agent.TokenUsage
+	if usage := chat.TokenUsage(start); usage.APICallCount > 0 {
+		tokenUsage = usage
+	}
+
+	if err := strat.EnsureSetup(); err != nil {
+		logging.Warn(logCtx, "aider: failed to ensure strategy setup", slog.String("error", err.Error()))
+	}
+	if err := strat.SaveChanges(strategy.SaveContext{
+		SessionID:           sessionID,
+		ModifiedFiles:       modifiedFiles,
+		MetadataDir:         metadataDir,
+		MetadataDirAbs:      metadataDirAbs,
+		CommitMessage:       commitMessage,
+		Prompt:              lastPrompt,
+		AuthorName:          author.Name,
+		AuthorEmail:         author.Email,
+		AgentType:           agent.AgentTypeAider,
+		StepTranscriptStart: start,
+		TokenUsage:          tokenUsage,
+	}); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	cpID, err := id.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate checkpoint ID: %w", err)
+	}
+	message = trailers.FormatCheckpoint(strings.TrimRight(message, "\n"), cpID)
+	if err := os.WriteFile(commitMsgFile, []byte(message), 0o600); err != nil {
+		return fmt.Errorf("failed to write commit message: %w", err)
+	}
+
+	logging.Info(logCtx, "aider: captured auto-commit",
+		slog.String("session_id", sessionID),
+		slog.String("checkpoint_id", cpID.String()),
+		slog.Int("files", len(modifiedFiles)),
+	)
+	return nil
+}
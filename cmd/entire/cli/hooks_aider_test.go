@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/agent/aider"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+)
+
+func TestIsAiderCommit(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorName    string
+		committerName string
+		want          bool
+	}{
+		{name: "human commit", authorName: "Jane Doe", committerName: "Jane Doe", want: false},
+		{name: "aider author", authorName: "Jane Doe (aider)", committerName: "Jane Doe", want: true},
+		{name: "aider committer", authorName: "Jane Doe", committerName: "Jane Doe (aider)", want: true},
+		{name: "no identity in env", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIT_AUTHOR_NAME", tt.authorName)
+			t.Setenv("GIT_COMMITTER_NAME", tt.committerName)
+			if got := isAiderCommit(); got != tt.want {
+				t.Errorf("isAiderCommit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveAiderCheckpoint(t *testing.T) {
+	setupCleanTestRepo(t)
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+
+	history := `# aider chat started at 2026-03-02 14:30:05
+
+#### greet the user
+
+> Applied edit to hello.txt
+> Tokens: 2.3k sent, 150 received.
+`
+	if err := os.WriteFile(filepath.Join(dir, aider.HistoryFileName), []byte(history), 0o600); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(msgFile, []byte("feat: Greet the user\n"), 0o600); err != nil {
+		t.Fatalf("failed to write commit message: %v", err)
+	}
+
+	if err := saveAiderCheckpoint(context.Background(), strategy.NewManualCommitStrategy(), msgFile); err != nil {
+		t.Fatalf("saveAiderCheckpoint() error = %v", err)
+	}
+
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		t.Fatalf("failed to read commit message: %v", err)
+	}
+	if _, found := trailers.ParseCheckpoint(string(content)); !found {
+		t.Errorf("commit message has no checkpoint trailer:\n%s", content)
+	}
+
+	state, err := strategy.LoadSessionState("aider-20260302-143005")
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	if state == nil {
+		t.Fatal("session state was not created")
+	}
+	if state.AgentType != agent.AgentTypeAider {
+		t.Errorf("AgentType = %q, want %q", state.AgentType, agent.AgentTypeAider)
+	}
+	if state.StepCount != 1 {
+		t.Errorf("StepCount = %d, want 1", state.StepCount)
+	}
+
+	// A second run for the same commit message keeps the existing trailer
+	if err := saveAiderCheckpoint(context.Background(), strategy.NewManualCommitStrategy(), msgFile); err != nil {
+		t.Fatalf("second saveAiderCheckpoint() error = %v", err)
+	}
+	again, err := os.ReadFile(msgFile)
+	if err != nil {
+		t.Fatalf("failed to read commit message: %v", err)
+	}
+	if string(again) != string(content) {
+		t.Errorf("commit message changed on second run:\n%s", again)
+	}
+}
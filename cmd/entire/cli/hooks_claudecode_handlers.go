@@ -14,10 +14,12 @@ import (
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/agent/claudecode"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/session"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
 )
 
 // hookInputData contains parsed hook input and session identifiers.
@@ -70,6 +72,11 @@ func captureInitialState() error {
 		return err
 	}
 
+	// A prompt stopped by a prompt policy never runs, so there's nothing to capture
+	if checkPromptPolicies(logging.WithAgent(logging.WithComponent(context.Background(), "hooks"), hookData.agent.Name()), hookData.sessionID) {
+		return nil
+	}
+
 	// CLI captures state directly (including transcript position)
 	if err := CapturePrePromptState(hookData.sessionID, hookData.input.SessionRef); err != nil {
 		return err
@@ -138,7 +145,7 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 
 	// Copy transcript
 	logFile := filepath.Join(sessionDirAbs, paths.TranscriptFileName)
-	if err := copyFile(transcriptPath, logFile); err != nil {
+	if err := copyToMetadata(transcriptPath, logFile); err != nil {
 		return fmt.Errorf("failed to copy transcript: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Copied transcript to: %s\n", sessionDir+"/"+paths.TranscriptFileName)
@@ -150,42 +157,46 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 		fmt.Fprintf(os.Stderr, "Warning: failed to load pre-prompt state: %v\n", err)
 	}
 
+	// Session state has the transcript bookmark, and the fallback offset below
+	sessionState, loadErr := strategy.LoadSessionState(sessionID)
+	if loadErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load session state: %v\n", loadErr)
+	}
+
 	// Determine transcript offset: prefer pre-prompt state, fall back to session state.
 	// Pre-prompt state has the offset when the transcript path was available at prompt time.
 	// Session state has the offset updated after each successful checkpoint save (auto-commit).
 	var transcriptOffset int
+	var bookmark *transcript.Bookmark
 	if preState != nil && preState.StepTranscriptStart > 0 {
 		transcriptOffset = preState.StepTranscriptStart
+		bookmark = preState.StepTranscriptBookmark
 		fmt.Fprintf(os.Stderr, "Pre-prompt state found: parsing transcript from line %d\n", transcriptOffset)
-	} else {
+	} else if sessionState != nil && sessionState.CheckpointTranscriptStart > 0 {
 		// Fall back to session state (e.g., auto-commit strategy updates it after each save)
-		sessionState, loadErr := strategy.LoadSessionState(sessionID)
-		if loadErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to load session state: %v\n", loadErr)
-		}
-		if sessionState != nil && sessionState.CheckpointTranscriptStart > 0 {
-			transcriptOffset = sessionState.CheckpointTranscriptStart
-			fmt.Fprintf(os.Stderr, "Session state found: parsing transcript from line %d\n", transcriptOffset)
-		}
+		transcriptOffset = sessionState.CheckpointTranscriptStart
+		fmt.Fprintf(os.Stderr, "Session state found: parsing transcript from line %d\n", transcriptOffset)
+	}
+	if bookmark == nil && sessionState != nil {
+		bookmark = sessionState.TranscriptBookmark
 	}
 
 	// Parse transcript (optionally from offset for strategies that track transcript position)
-	// When transcriptOffset > 0, only parse NEW lines since the last checkpoint
-	var transcript []transcriptLine
-	var totalLines int
+	// When transcriptOffset > 0, only parse NEW lines since the last checkpoint. A
+	// bookmark at or before the offset lets the parser seek past the old lines
+	// instead of reading them.
+	transcript, totalLines, endBookmark, err := parseTranscriptFromBookmark(transcriptPath, transcriptOffset, bookmark)
+	if err != nil {
+		return fmt.Errorf("failed to parse transcript from line %d: %w", transcriptOffset, err)
+	}
 	if transcriptOffset > 0 {
-		// Parse only NEW lines since last checkpoint
-		transcript, totalLines, err = parseTranscriptFromLine(transcriptPath, transcriptOffset)
-		if err != nil {
-			return fmt.Errorf("failed to parse transcript from line %d: %w", transcriptOffset, err)
-		}
 		fmt.Fprintf(os.Stderr, "Parsed %d new transcript lines (total: %d)\n", len(transcript), totalLines)
-	} else {
-		// First prompt or no session state - parse entire transcript
-		// Use parseTranscriptFromLine with offset 0 to also get totalLines
-		transcript, totalLines, err = parseTranscriptFromLine(transcriptPath, 0)
-		if err != nil {
-			return fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	// Remember where the transcript ends, so the next hook starts there
+	if sessionState != nil && endBookmark != nil {
+		if saveErr := saveTranscriptBookmark(sessionID, endBookmark); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save transcript bookmark: %v\n", saveErr)
 		}
 	}
 
@@ -193,7 +204,7 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 	allPrompts := extractUserPrompts(transcript)
 	promptFile := filepath.Join(sessionDirAbs, paths.PromptFileName)
 	promptContent := strings.Join(allPrompts, "\n\n---\n\n")
-	if err := os.WriteFile(promptFile, []byte(promptContent), 0o600); err != nil {
+	if err := encryption.WriteFile(promptFile, []byte(promptContent), 0o600); err != nil {
 		return fmt.Errorf("failed to write prompt file: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Extracted %d prompt(s) to: %s\n", len(allPrompts), sessionDir+"/"+paths.PromptFileName)
@@ -201,7 +212,7 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 	// Extract summary
 	summaryFile := filepath.Join(sessionDirAbs, paths.SummaryFileName)
 	summary := extractLastAssistantMessage(transcript)
-	if err := os.WriteFile(summaryFile, []byte(summary), 0o600); err != nil {
+	if err := encryption.WriteFile(summaryFile, []byte(summary), 0o600); err != nil {
 		return fmt.Errorf("failed to write summary file: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Extracted summary to: %s\n", sessionDir+"/"+paths.SummaryFileName)
@@ -234,17 +245,13 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 		fmt.Fprintf(os.Stderr, "Warning: failed to compute file changes: %v\n", err)
 	}
 
-	// Filter and normalize all paths (CLI responsibility)
-	relModifiedFiles := FilterAndNormalizePaths(modifiedFiles, repoRoot)
-	var relNewFiles, relDeletedFiles []string
-	if changes != nil {
-		relNewFiles = FilterAndNormalizePaths(changes.New, repoRoot)
-		relDeletedFiles = FilterAndNormalizePaths(changes.Deleted, repoRoot)
-	}
+	// Files in other repositories of the workspace are checkpointed there,
+	// files in submodules as the submodule (see submodules.go)
+	relModifiedFiles, relNewFiles, relDeletedFiles, workspaceFiles := stopHookFiles(modifiedFiles, changes, repoRoot)
 
 	// Check if there are any changes to commit
 	totalChanges := len(relModifiedFiles) + len(relNewFiles) + len(relDeletedFiles)
-	if totalChanges == 0 {
+	if totalChanges == 0 && len(workspaceFiles) == 0 {
 		fmt.Fprintf(os.Stderr, "No files were modified during this session\n")
 		fmt.Fprintf(os.Stderr, "Skipping commit\n")
 		// Still transition phase even when skipping commit — the turn is ending.
@@ -313,8 +320,10 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 	if transcriptPath != "" {
 		// Subagents are stored in a subagents/ directory next to the main transcript
 		subagentsDir := filepath.Join(filepath.Dir(transcriptPath), sessionID, "subagents")
-		usage, err := claudecode.CalculateTotalTokenUsage(transcriptPath, transcriptLinesAtStart, subagentsDir)
-		if err != nil {
+		if transcriptLinesAtStart == transcriptOffset {
+			// The turn's lines are already parsed
+			tokenUsage = claudecode.CalculateTotalTokenUsageFromLines(transcript, subagentsDir)
+		} else if usage, err := claudecode.CalculateTotalTokenUsage(transcriptPath, transcriptLinesAtStart, subagentsDir); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to calculate token usage: %v\n", err)
 		} else {
 			tokenUsage = usage
@@ -330,6 +339,7 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 		MetadataDir:              sessionDir,
 		MetadataDirAbs:           sessionDirAbs,
 		CommitMessage:            commitMessage,
+		Prompt:                   lastPrompt,
 		TranscriptPath:           transcriptPath,
 		AuthorName:               author.Name,
 		AuthorEmail:              author.Email,
@@ -342,6 +352,7 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 	if err := strat.SaveChanges(ctx); err != nil {
 		return fmt.Errorf("failed to save changes: %w", err)
 	}
+	saveWorkspaceCheckpoints(ctx, workspaceFiles)
 
 	// Update session state with new transcript position for strategies that create
 	// commits on the active branch (auto-commit strategy). This prevents parsing old transcript
@@ -349,26 +360,24 @@ func commitWithMetadata() error { //nolint:maintidx // already present in codeba
 	// Note: Shadow strategy tracks transcript position per-step via StepTranscriptStart in
 	// pre-prompt state, but doesn't advance CheckpointTranscriptStart in session state because
 	// its checkpoints accumulate all files touched across the entire session.
-	if strat.Name() == strategy.StrategyNameAutoCommit {
-		// Load session state for updating transcript position
-		sessionState, loadErr := strategy.LoadSessionState(sessionID)
-		if loadErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to load session state: %v\n", loadErr)
-		}
-		// Create session state lazily if it doesn't exist (backward compat for resumed sessions
-		// or if InitializeSession was never called/failed)
-		if sessionState == nil {
-			sessionState = &strategy.SessionState{
-				SessionID: sessionID,
+	if strategy.CommitsToActiveBranch(strat.Name()) {
+		var stepCount int
+		updateErr := strategy.UpsertSessionState(sessionID, func(state *strategy.SessionState) (*strategy.SessionState, error) {
+			// Create session state lazily if it doesn't exist (backward compat for resumed sessions
+			// or if InitializeSession was never called/failed)
+			if state == nil {
+				state = &strategy.SessionState{SessionID: sessionID}
 			}
-		}
-		sessionState.CheckpointTranscriptStart = totalLines
-		sessionState.StepCount++
-		if updateErr := strategy.SaveSessionState(sessionState); updateErr != nil {
+			state.CheckpointTranscriptStart = totalLines
+			state.StepCount++
+			stepCount = state.StepCount
+			return state, nil
+		})
+		if updateErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to update session state: %v\n", updateErr)
 		} else {
 			fmt.Fprintf(os.Stderr, "Updated session state: transcript position=%d, checkpoint=%d\n",
-				totalLines, sessionState.StepCount)
+				totalLines, stepCount)
 		}
 	}
 
@@ -696,6 +705,130 @@ func handleClaudeCodePostTask() error {
 	return nil
 }
 
+// handleClaudeCodeSubagentStop handles the SubagentStop hook. It saves an
+// incremental checkpoint for the active task as soon as a subagent finishes,
+// so long multi-agent tasks get a checkpoint per subagent rather than only the
+// one at the end of the turn. PostToolUse[Task] still writes the task's final
+// checkpoint with the subagent transcript.
+//
+// When several tasks run at once, the checkpoint goes to the most recently
+// started one, as for handleClaudeCodePostTodo.
+func handleClaudeCodeSubagentStop() error {
+	input, err := parseSubagentStopHookInput(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to parse SubagentStop input: %w", err)
+	}
+
+	// Get agent for logging context
+	ag, err := GetCurrentHookAgent()
+	if err != nil {
+		return fmt.Errorf("failed to get agent: %w", err)
+	}
+
+	logCtx := logging.WithAgent(logging.WithComponent(context.Background(), "hooks"), ag.Name())
+	logging.Info(logCtx, "subagent-stop",
+		slog.String("hook", "subagent-stop"),
+		slog.String("hook_type", "subagent"),
+		slog.String("model_session_id", input.SessionID),
+		slog.String("transcript_path", input.TranscriptPath),
+		slog.String("agent_id", input.AgentID),
+	)
+
+	// Subagents started outside a Task tool call have no pre-task state; their
+	// changes are picked up by the next Stop checkpoint
+	taskToolUseID, found := FindActivePreTaskFile()
+	if !found {
+		return nil
+	}
+
+	// Skip on default branch to avoid polluting main/master history
+	if skip, branchName := ShouldSkipOnDefaultBranch(); skip {
+		fmt.Fprintf(os.Stderr, "Entire: skipping subagent checkpoint on branch '%s'\n", branchName)
+		return nil
+	}
+
+	// Files the task created are new relative to the untracked files captured
+	// when it started
+	preState, err := LoadPreTaskState(taskToolUseID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load pre-task state: %v\n", err)
+	}
+	changes, err := DetectFileChanges(preState.PreUntrackedFiles())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to detect changed files: %v\n", err)
+		return nil
+	}
+
+	// If no file changes, skip creating a checkpoint
+	if len(changes.Modified) == 0 && len(changes.New) == 0 && len(changes.Deleted) == 0 {
+		fmt.Fprintf(os.Stderr, "[entire] No file changes detected, skipping subagent checkpoint\n")
+		return nil
+	}
+
+	// Get git author
+	author, err := GetGitAuthor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get git author: %v\n", err)
+		return nil
+	}
+
+	// Get the active strategy
+	strat := GetStrategy()
+
+	// Ensure strategy setup is complete
+	if err := strat.EnsureSetup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to ensure strategy setup: %v\n", err)
+		return nil
+	}
+
+	sessionID := input.SessionID
+	if sessionID == "" {
+		sessionID = paths.ExtractSessionIDFromTranscriptPath(input.TranscriptPath)
+	}
+
+	// Prefer the path Claude Code reports, then the conventional location
+	subagentTranscriptPath := input.AgentTranscriptPath
+	if subagentTranscriptPath == "" && input.AgentID != "" {
+		subagentTranscriptPath = AgentTranscriptPath(filepath.Dir(input.TranscriptPath), input.AgentID)
+	}
+	if subagentTranscriptPath != "" && !fileExists(subagentTranscriptPath) {
+		subagentTranscriptPath = ""
+	}
+
+	seq := GetNextCheckpointSequence(sessionID, taskToolUseID)
+	todoContent := "Subagent finished"
+	if input.AgentID != "" {
+		todoContent = fmt.Sprintf("Subagent %s finished", input.AgentID)
+	}
+
+	ctx := strategy.TaskCheckpointContext{
+		SessionID:              sessionID,
+		ToolUseID:              taskToolUseID,
+		AgentID:                input.AgentID,
+		ModifiedFiles:          changes.Modified,
+		NewFiles:               changes.New,
+		DeletedFiles:           changes.Deleted,
+		TranscriptPath:         input.TranscriptPath,
+		SubagentTranscriptPath: subagentTranscriptPath,
+		AuthorName:             author.Name,
+		AuthorEmail:            author.Email,
+		IsIncremental:          true,
+		IncrementalSequence:    seq,
+		IncrementalType:        "SubagentStop",
+		TodoContent:            todoContent,
+		AgentType:              ag.Type(),
+	}
+
+	if err := strat.SaveTaskCheckpoint(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save subagent checkpoint: %v\n", err)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "[entire] Created incremental checkpoint #%d for SubagentStop (task: %s)\n",
+		seq, taskToolUseID[:min(12, len(taskToolUseID))])
+	return nil
+}
+
 // handleClaudeCodeSessionStart handles the SessionStart hook for Claude Code.
 func handleClaudeCodeSessionStart() error {
 	return handleSessionStartCommon()
@@ -745,6 +878,9 @@ func transitionSessionTurnEnd(sessionID string) {
 	if turnState == nil {
 		return
 	}
+	// Condensing takes a while, so save only what changes and keep what
+	// other hooks save meanwhile
+	changes := strategy.TrackSessionState(turnState)
 	remaining := strategy.TransitionAndLog(turnState, session.EventTurnEnd, session.TransitionContext{})
 
 	// Dispatch strategy-specific actions (e.g., ActionCondense for ACTIVE_COMMITTED → IDLE)
@@ -757,28 +893,36 @@ func transitionSessionTurnEnd(sessionID string) {
 		}
 	}
 
-	if updateErr := strategy.SaveSessionState(turnState); updateErr != nil {
+	if updateErr := changes.Save(); updateErr != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to update session phase on turn end: %v\n", updateErr)
 	}
 }
 
 // markSessionEnded transitions the session to ENDED phase via the state machine.
 func markSessionEnded(sessionID string) error {
-	state, err := strategy.LoadSessionState(sessionID)
+	var ended *strategy.SessionState
+	err := strategy.UpdateSessionState(sessionID, func(state *strategy.SessionState) error {
+		strategy.TransitionAndLog(state, session.EventSessionStop, session.TransitionContext{})
+
+		now := time.Now()
+		state.EndedAt = &now
+		ended = state
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to load session state: %w", err)
+		return fmt.Errorf("failed to save session state: %w", err)
 	}
-	if state == nil {
+	if ended == nil {
 		return nil // No state file, nothing to update
 	}
 
-	strategy.TransitionAndLog(state, session.EventSessionStop, session.TransitionContext{})
-
-	now := time.Now()
-	state.EndedAt = &now
-
-	if err := strategy.SaveSessionState(state); err != nil {
-		return fmt.Errorf("failed to save session state: %w", err)
+	// The session ends in the other repositories of its workspace too
+	if ended.WorkspaceRoot == "" {
+		for _, root := range ended.WorkspaceRepos {
+			if err := inWorkspaceRepo(root, func() error { return markSessionEnded(sessionID) }); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to end session in workspace repository %s: %v\n", root, err)
+			}
+		}
 	}
 	return nil
 }
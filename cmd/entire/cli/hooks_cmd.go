@@ -3,6 +3,7 @@ package cli
 import (
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	// Import agents to ensure they are registered before we iterate
+	_ "github.com/entireio/cli/cmd/entire/cli/agent/aider"
 	_ "github.com/entireio/cli/cmd/entire/cli/agent/claudecode"
 	_ "github.com/entireio/cli/cmd/entire/cli/agent/geminicli"
 
@@ -11,12 +12,20 @@ import (
 
 func newHooksCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "hooks",
-		Short:  "Hook handlers",
-		Long:   "Commands called by hooks. These are internal and not for direct user use.",
-		Hidden: true, // Internal command, not for direct user use
+		Use:   "hooks",
+		Short: "Enable, disable and debug individual hooks",
+		Long: `Enable or disable individual hooks without uninstalling them, and record and
+replay agent hook invocations with 'entire hooks trace' and 'entire hooks replay'.
+
+The agent and git subcommands are called by the installed hooks and are not
+for direct use.`,
 	}
 
+	cmd.AddCommand(newHooksDisableCmd())
+	cmd.AddCommand(newHooksEnableCmd())
+	cmd.AddCommand(newHooksTraceCmd())
+	cmd.AddCommand(newHooksReplayCmd())
+
 	// Git hooks are strategy-level (not agent-specific)
 	cmd.AddCommand(newHooksGitCmd())
 
@@ -13,6 +13,7 @@ import (
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/agent/geminicli"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
@@ -58,6 +59,7 @@ type geminiSessionContext struct {
 	summary        string
 	modifiedFiles  []string
 	commitMessage  string
+	lastPrompt     string
 }
 
 // parseGeminiSessionEnd parses the session-end hook input and validates transcript.
@@ -110,7 +112,7 @@ func setupGeminiSessionDir(ctx *geminiSessionContext) error {
 	}
 
 	logFile := filepath.Join(sessionDirAbs, paths.TranscriptFileName)
-	if err := copyFile(ctx.transcriptPath, logFile); err != nil {
+	if err := copyToMetadata(ctx.transcriptPath, logFile); err != nil {
 		return fmt.Errorf("failed to copy transcript: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Copied transcript to: %s\n", ctx.sessionDir+"/"+paths.TranscriptFileName)
@@ -134,7 +136,7 @@ func extractGeminiMetadata(ctx *geminiSessionContext) error {
 
 	promptFile := filepath.Join(ctx.sessionDirAbs, paths.PromptFileName)
 	promptContent := strings.Join(allPrompts, "\n\n---\n\n")
-	if err := os.WriteFile(promptFile, []byte(promptContent), 0o600); err != nil {
+	if err := encryption.WriteFile(promptFile, []byte(promptContent), 0o600); err != nil {
 		return fmt.Errorf("failed to write prompt file: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Extracted %d prompt(s) to: %s\n", len(allPrompts), ctx.sessionDir+"/"+paths.PromptFileName)
@@ -146,7 +148,7 @@ func extractGeminiMetadata(ctx *geminiSessionContext) error {
 	ctx.summary = summary
 
 	summaryFile := filepath.Join(ctx.sessionDirAbs, paths.SummaryFileName)
-	if err := os.WriteFile(summaryFile, []byte(summary), 0o600); err != nil {
+	if err := encryption.WriteFile(summaryFile, []byte(summary), 0o600); err != nil {
 		return fmt.Errorf("failed to write summary file: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Extracted summary to: %s\n", ctx.sessionDir+"/"+paths.SummaryFileName)
@@ -161,6 +163,7 @@ func extractGeminiMetadata(ctx *geminiSessionContext) error {
 	if len(allPrompts) > 0 {
 		lastPrompt = allPrompts[len(allPrompts)-1]
 	}
+	ctx.lastPrompt = lastPrompt
 	ctx.commitMessage = generateCommitMessage(lastPrompt)
 	fmt.Fprintf(os.Stderr, "Using commit message: %s\n", ctx.commitMessage)
 
@@ -207,15 +210,12 @@ func commitGeminiSession(ctx *geminiSessionContext) error {
 		fmt.Fprintf(os.Stderr, "Warning: failed to compute file changes: %v\n", err)
 	}
 
-	relModifiedFiles := FilterAndNormalizePaths(ctx.modifiedFiles, repoRoot)
-	var relNewFiles, relDeletedFiles []string
-	if changes != nil {
-		relNewFiles = FilterAndNormalizePaths(changes.New, repoRoot)
-		relDeletedFiles = FilterAndNormalizePaths(changes.Deleted, repoRoot)
-	}
+	// Files in other repositories of the workspace are checkpointed there,
+	// files in submodules as the submodule (see submodules.go)
+	relModifiedFiles, relNewFiles, relDeletedFiles, workspaceFiles := stopHookFiles(ctx.modifiedFiles, changes, repoRoot)
 
 	totalChanges := len(relModifiedFiles) + len(relNewFiles) + len(relDeletedFiles)
-	if totalChanges == 0 {
+	if totalChanges == 0 && len(workspaceFiles) == 0 {
 		fmt.Fprintf(os.Stderr, "No files were modified during this session\n")
 		fmt.Fprintf(os.Stderr, "Skipping commit\n")
 		if cleanupErr := CleanupPrePromptState(ctx.sessionID); cleanupErr != nil {
@@ -264,6 +264,7 @@ func commitGeminiSession(ctx *geminiSessionContext) error {
 		MetadataDir:              ctx.sessionDir,
 		MetadataDirAbs:           ctx.sessionDirAbs,
 		CommitMessage:            ctx.commitMessage,
+		Prompt:                   ctx.lastPrompt,
 		TranscriptPath:           ctx.transcriptPath,
 		AuthorName:               author.Name,
 		AuthorEmail:              author.Email,
@@ -276,6 +277,7 @@ func commitGeminiSession(ctx *geminiSessionContext) error {
 	if err := strat.SaveChanges(saveCtx); err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
 	}
+	saveWorkspaceCheckpoints(saveCtx, workspaceFiles)
 
 	if cleanupErr := CleanupPrePromptState(ctx.sessionID); cleanupErr != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to cleanup pre-prompt state: %v\n", cleanupErr)
@@ -326,7 +328,7 @@ func createContextFileForGemini(contextFile, commitMessage, sessionID string, pr
 		sb.WriteString("\n")
 	}
 
-	if err := os.WriteFile(contextFile, []byte(sb.String()), 0o600); err != nil {
+	if err := encryption.WriteFile(contextFile, []byte(sb.String()), 0o600); err != nil {
 		return fmt.Errorf("failed to write context file: %w", err)
 	}
 	return nil
@@ -391,7 +393,7 @@ func handleGeminiAfterTool() error {
 // handleGeminiBeforeAgent handles the BeforeAgent hook for Gemini CLI.
 // This is equivalent to Claude Code's UserPromptSubmit - it fires when the user submits a prompt.
 // We capture the initial state here so we can track what files were modified during the session.
-// It also checks for concurrent sessions and blocks if another session has uncommitted changes.
+// Prompts that violate a prompt policy are stopped before anything is captured.
 func handleGeminiBeforeAgent() error {
 	// Always use the Gemini agent for Gemini hooks (don't use GetAgent() which may
 	// return Claude based on auto-detection in environments like VSCode)
@@ -429,6 +431,11 @@ func handleGeminiBeforeAgent() error {
 		return errors.New("no session_id in input")
 	}
 
+	// A prompt stopped by a prompt policy never runs, so there's nothing to capture
+	if checkPromptPolicies(logCtx, input.SessionID) {
+		return nil
+	}
+
 	// Capture pre-prompt state with transcript position (Gemini-specific)
 	// This captures both untracked files and the current transcript message count
 	// so we can calculate token usage for just this prompt/response cycle
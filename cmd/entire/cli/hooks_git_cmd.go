@@ -2,10 +2,13 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 
 	"github.com/spf13/cobra"
@@ -20,6 +23,8 @@ type gitHookContext struct {
 	start        time.Time
 	strategy     strategy.Strategy
 	strategyName string
+	// finishSpan ends the hook's span started by logInvoked.
+	finishSpan func(error)
 }
 
 // newGitHookContext creates a new git hook context with logging initialized.
@@ -29,14 +34,16 @@ func newGitHookContext(hookName string) *gitHookContext {
 		start:        time.Now(),
 		ctx:          logging.WithComponent(context.Background(), "hooks"),
 		strategyName: unknownStrategyName,
+		finishSpan:   func(error) {},
 	}
 	g.strategy = GetStrategy()
 	g.strategyName = g.strategy.Name()
 	return g
 }
 
-// logInvoked logs that the hook was invoked.
+// logInvoked logs that the hook was invoked and starts its span.
 func (g *gitHookContext) logInvoked(extraAttrs ...any) {
+	g.ctx, g.finishSpan = startHookSpan(g.ctx, g.hookName, "git", slog.String("strategy", g.strategyName))
 	attrs := []any{
 		slog.String("hook", g.hookName),
 		slog.String("hook_type", "git"),
@@ -45,9 +52,12 @@ func (g *gitHookContext) logInvoked(extraAttrs ...any) {
 	logging.Debug(g.ctx, g.hookName+" hook invoked", append(attrs, extraAttrs...)...)
 }
 
-// logCompleted logs hook completion with duration at DEBUG level.
-// The actual work logging (checkpoint operations) happens at INFO level in the handlers.
+// logCompleted logs hook completion with duration at DEBUG level, counts it
+// and ends its span. The actual work logging (checkpoint operations) happens
+// at INFO level in the handlers.
 func (g *gitHookContext) logCompleted(err error, extraAttrs ...any) {
+	recordHookExecution(g.ctx, g.hookName, "git", "", err)
+	g.finishSpan(err)
 	attrs := []any{
 		slog.String("hook", g.hookName),
 		slog.String("hook_type", "git"),
@@ -60,16 +70,8 @@ func (g *gitHookContext) logCompleted(err error, extraAttrs ...any) {
 // initHookLogging initializes logging for hooks by finding the most recent session.
 // Returns a cleanup function that should be deferred.
 func initHookLogging() func() {
-	// Set up log level getter so logging can read from settings
-	logging.SetLogLevelGetter(GetLogLevel)
-
 	// Read session ID for the slog attribute (empty string is fine - log file is fixed)
-	sessionID := strategy.FindMostRecentSession()
-	if err := logging.Init(sessionID); err != nil {
-		// Init failed - logging will use stderr fallback
-		return func() {}
-	}
-	return logging.Close
+	return initLogging(strategy.FindMostRecentSession())
 }
 
 // hookLogCleanup stores the cleanup function for hook logging.
@@ -84,6 +86,8 @@ func newHooksGitCmd() *cobra.Command {
 		Hidden: true, // Internal command, not for direct user use
 		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
 			hookLogCleanup = initHookLogging()
+			configureRedaction()
+			recoverInterruptedCheckpoints()
 			return nil
 		},
 		PersistentPostRunE: func(_ *cobra.Command, _ []string) error {
@@ -98,6 +102,8 @@ func newHooksGitCmd() *cobra.Command {
 	cmd.AddCommand(newHooksGitCommitMsgCmd())
 	cmd.AddCommand(newHooksGitPostCommitCmd())
 	cmd.AddCommand(newHooksGitPrePushCmd())
+	cmd.AddCommand(newHooksGitPostRewriteCmd())
+	cmd.AddCommand(newHooksGitPostCheckoutCmd())
 
 	return cmd
 }
@@ -115,8 +121,13 @@ func newHooksGitPrepareCommitMsgCmd() *cobra.Command {
 			}
 
 			g := newGitHookContext("prepare-commit-msg")
+			if skipDisabledHook(g.ctx, g.hookName) {
+				return nil
+			}
 			g.logInvoked(slog.String("source", source))
 
+			captureAiderCommit(g.ctx, g.strategy, commitMsgFile)
+
 			if handler, ok := g.strategy.(strategy.PrepareCommitMsgHandler); ok {
 				hookErr := handler.PrepareCommitMsg(commitMsgFile, source)
 				g.logCompleted(hookErr, slog.String("source", source))
@@ -136,6 +147,9 @@ func newHooksGitCommitMsgCmd() *cobra.Command {
 			commitMsgFile := args[0]
 
 			g := newGitHookContext("commit-msg")
+			if skipDisabledHook(g.ctx, g.hookName) {
+				return nil
+			}
 			g.logInvoked()
 
 			if handler, ok := g.strategy.(strategy.CommitMsgHandler); ok {
@@ -156,6 +170,9 @@ func newHooksGitPostCommitCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			g := newGitHookContext("post-commit")
+			if skipDisabledHook(g.ctx, g.hookName) {
+				return nil
+			}
 			g.logInvoked()
 
 			if handler, ok := g.strategy.(strategy.PostCommitHandler); ok {
@@ -172,13 +189,33 @@ func newHooksGitPrePushCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "pre-push <remote>",
 		Short: "Handle pre-push git hook",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		Long: `Pushes session logs alongside the user's push, after checking the pushed
+commits (read from stdin, as git passes them) against push_policy. Exits with
+status 1 only when the policy blocks the push.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
 			remote := args[0]
 
 			g := newGitHookContext("pre-push")
+			if skipDisabledHook(g.ctx, g.hookName) {
+				return nil
+			}
 			g.logInvoked(slog.String("remote", remote))
 
+			// Check the push policy first, so a rejected push doesn't send its
+			// session logs either
+			if s, err := settings.Load(); err == nil && s.PushPolicy.IsSet() {
+				updates := parsePushUpdates(cmd.InOrStdin())
+				if err := enforcePushPolicy(g.ctx, cmd.ErrOrStderr(), remote, updates, s.PushPolicy); err != nil {
+					if errors.Is(err, errPushBlocked) {
+						g.logCompleted(err, slog.String("remote", remote))
+						return err
+					}
+					// A policy that can't be checked doesn't block the push
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not check the push policy: %v\n", err)
+				}
+			}
+
 			if handler, ok := g.strategy.(strategy.PrePushHandler); ok {
 				hookErr := handler.PrePush(remote)
 				g.logCompleted(hookErr, slog.String("remote", remote))
@@ -188,3 +225,54 @@ func newHooksGitPrePushCmd() *cobra.Command {
 		},
 	}
 }
+
+func newHooksGitPostRewriteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "post-rewrite <amend|rebase>",
+		Short: "Handle post-rewrite git hook",
+		Long: `Moves the shadow branches of sessions based on commits that git commit --amend
+or git rebase replaced. Reads the rewritten commits from stdin, as git passes them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rewriteType := args[0]
+
+			g := newGitHookContext("post-rewrite")
+			if skipDisabledHook(g.ctx, g.hookName) {
+				return nil
+			}
+			g.logInvoked(slog.String("rewrite_type", rewriteType))
+
+			if handler, ok := g.strategy.(strategy.PostRewriteHandler); ok {
+				hookErr := handler.PostRewrite(rewriteType, strategy.ParseCommitRewrites(cmd.InOrStdin()))
+				g.logCompleted(hookErr, slog.String("rewrite_type", rewriteType))
+			}
+
+			return nil
+		},
+	}
+}
+
+func newHooksGitPostCheckoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "post-checkout <previous-head> <new-head> <branch-flag>",
+		Short: "Handle post-checkout git hook",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(_ *cobra.Command, args []string) error {
+			previousHead, newHead := args[0], args[1]
+			branchCheckout := args[2] == "1"
+
+			g := newGitHookContext("post-checkout")
+			if skipDisabledHook(g.ctx, g.hookName) {
+				return nil
+			}
+			g.logInvoked(slog.Bool("branch_checkout", branchCheckout))
+
+			if handler, ok := g.strategy.(strategy.PostCheckoutHandler); ok {
+				hookErr := handler.PostCheckout(previousHead, newHead, branchCheckout)
+				g.logCompleted(hookErr, slog.Bool("branch_checkout", branchCheckout))
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
+)
+
+// startHookSpan starts the span of a hook execution when OpenTelemetry
+// export is configured (the opentelemetry setting or OTEL_EXPORTER_OTLP_*).
+// The spans the hook's work starts become its children. The returned
+// function ends the span, records the hook in the executions and duration
+// metrics and exports everything; the hook calls it with its error.
+func startHookSpan(ctx context.Context, hookName, hookType string, attrs ...slog.Attr) (context.Context, func(error)) {
+	initTracing()
+	if !tracing.Enabled() {
+		return ctx, func(error) {}
+	}
+	start := time.Now()
+	attrs = append([]slog.Attr{slog.String("hook", hookName), slog.String("hook_type", hookType)}, attrs...)
+	ctx, span := tracing.Start(ctx, "hook "+hookName, attrs...)
+	return ctx, func(hookErr error) {
+		span.EndWithError(hookErr)
+		tracing.RecordHook(start, hookErr, attrs...)
+		if err := tracing.Shutdown(context.Background()); err != nil {
+			logging.Debug(ctx, "failed to export hook telemetry", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// initTracing starts recording spans if OpenTelemetry export is configured.
+// Invalid settings leave it to the environment variables.
+func initTracing() {
+	var endpoint, serviceName string
+	var headers map[string]string
+	if s, err := settings.Load(); err == nil && s.OpenTelemetry != nil {
+		if e, err := s.OpenTelemetry.EffectiveEndpoint(); err == nil {
+			endpoint = e
+		}
+		headers, serviceName = s.OpenTelemetry.Headers, s.OpenTelemetry.ServiceName
+	}
+	tracing.Init(tracing.NewConfig(endpoint, headers, serviceName, buildinfo.Version))
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
+)
+
+func TestStartHookSpan_ExportsWhenConfigured(t *testing.T) {
+	setupTestDir(t)
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+
+	ctx, finish := startHookSpan(context.Background(), "stop", "agent")
+	if !tracing.Enabled() {
+		t.Fatal("tracing isn't enabled with OTEL_EXPORTER_OTLP_ENDPOINT set")
+	}
+	_, span := tracing.Start(ctx, "checkpoint.write_temporary")
+	span.End()
+	finish(errors.New("hook failed"))
+
+	if tracing.Enabled() {
+		t.Error("tracing is still enabled after the hook finished")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "/v1/traces" || paths[1] != "/v1/metrics" {
+		t.Errorf("collector got %v, want traces and metrics", paths)
+	}
+}
+
+func TestStartHookSpan_OffByDefault(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+
+	_, finish := startHookSpan(context.Background(), "stop", "agent")
+	defer finish(nil)
+	if tracing.Enabled() {
+		t.Error("tracing is enabled without an endpoint")
+	}
+}
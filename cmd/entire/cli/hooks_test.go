@@ -268,6 +268,33 @@ func TestParseSubagentCheckpointHookInput(t *testing.T) {
 	}
 }
 
+func TestParseSubagentStopHookInput(t *testing.T) {
+	input := `{
+		"session_id": "abc123",
+		"transcript_path": "/tmp/abc123.jsonl",
+		"stop_hook_active": false,
+		"agent_id": "agent789",
+		"agent_transcript_path": "/tmp/agent-agent789.jsonl"
+	}`
+	got, err := parseSubagentStopHookInput(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseSubagentStopHookInput() error = %v", err)
+	}
+	if got.SessionID != "abc123" || got.AgentID != "agent789" || got.AgentTranscriptPath != "/tmp/agent-agent789.jsonl" {
+		t.Errorf("parseSubagentStopHookInput() = %+v", got)
+	}
+
+	// Older releases don't identify the subagent
+	got, err = parseSubagentStopHookInput(strings.NewReader(`{"session_id": "abc123", "transcript_path": "/tmp/abc123.jsonl"}`))
+	if err != nil || got.AgentID != "" {
+		t.Errorf("parseSubagentStopHookInput() without agent = %+v, %v", got, err)
+	}
+
+	if _, err := parseSubagentStopHookInput(strings.NewReader("")); err == nil {
+		t.Error("parseSubagentStopHookInput(empty) should fail")
+	}
+}
+
 func TestParseSubagentTypeAndDescription(t *testing.T) {
 	tests := []struct {
 		name            string
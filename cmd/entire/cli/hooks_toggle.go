@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// HooksDisabledEnvVar is the kill switch for hooks. "1", "true" or "all"
+// disables every hook; otherwise it's a comma-separated list of hook names.
+const HooksDisabledEnvVar = "ENTIRE_HOOKS_DISABLED"
+
+// allHooks is the hook name that matches every hook.
+const allHooks = "all"
+
+// gitHookNames are the git hooks Entire installs.
+var gitHookNames = []string{"prepare-commit-msg", "commit-msg", "post-commit", "pre-push", "post-rewrite", "post-checkout"}
+
+// knownHookNames returns every hook name that can be toggled: git hooks and
+// the hook verbs of all agents.
+func knownHookNames() []string {
+	names := slices.Clone(gitHookNames)
+	for _, agentName := range agent.List() {
+		ag, err := agent.Get(agentName)
+		if err != nil {
+			continue
+		}
+		if handler, ok := ag.(agent.HookHandler); ok {
+			names = append(names, handler.GetHookNames()...)
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// isHookDisabled reports whether hookName should pass through, either
+// because of ENTIRE_HOOKS_DISABLED or the disabled_hooks setting.
+// Settings that can't be loaded leave hooks enabled.
+func isHookDisabled(hookName string) bool {
+	if hookListMatches(parseDisabledHooksEnv(os.Getenv(HooksDisabledEnvVar)), hookName) {
+		return true
+	}
+	s, err := settings.Load()
+	if err != nil {
+		return false
+	}
+	return hookListMatches(s.DisabledHooks, hookName)
+}
+
+// skipDisabledHook logs and reports whether hookName is disabled. Hook
+// commands call it first and return nil straight away when it's true.
+func skipDisabledHook(ctx context.Context, hookName string) bool {
+	if !isHookDisabled(hookName) {
+		return false
+	}
+	logging.Debug(ctx, "hook disabled, passing through", slog.String("hook", hookName))
+	return true
+}
+
+// parseDisabledHooksEnv parses the value of ENTIRE_HOOKS_DISABLED.
+func parseDisabledHooksEnv(value string) []string {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "", "0", "false":
+		return nil
+	case "1", "true", allHooks:
+		return []string{allHooks}
+	}
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func hookListMatches(list []string, hookName string) bool {
+	return slices.Contains(list, allHooks) || slices.Contains(list, hookName)
+}
+
+func newHooksDisableCmd() *cobra.Command {
+	var useProjectSettings bool
+
+	cmd := &cobra.Command{
+		Use:   "disable <hook>... | all",
+		Short: "Make hooks pass through without uninstalling them",
+		Long: `Make hooks pass through without uninstalling them.
+
+Disabled hooks stay installed but exit immediately, which helps rule Entire
+out when debugging agent or git issues. Re-enable them with 'entire hooks enable'.
+
+Set ENTIRE_HOOKS_DISABLED=1 to disable every hook for a single shell or
+command without touching settings, or to a comma-separated list of hook names.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooksToggle(cmd.OutOrStdout(), args, false, useProjectSettings)
+		},
+	}
+	cmd.Flags().BoolVar(&useProjectSettings, "project", false, "Update settings.json instead of settings.local.json")
+	return cmd
+}
+
+func newHooksEnableCmd() *cobra.Command {
+	var useProjectSettings bool
+
+	cmd := &cobra.Command{
+		Use:   "enable <hook>... | all",
+		Short: "Re-enable hooks disabled with 'entire hooks disable'",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooksToggle(cmd.OutOrStdout(), args, true, useProjectSettings)
+		},
+	}
+	cmd.Flags().BoolVar(&useProjectSettings, "project", false, "Update settings.json instead of settings.local.json")
+	return cmd
+}
+
+// runHooksToggle enables or disables the named hooks in settings.
+func runHooksToggle(w io.Writer, names []string, enable, useProjectSettings bool) error {
+	known := knownHookNames()
+	for _, name := range names {
+		if name != allHooks && !slices.Contains(known, name) {
+			return fmt.Errorf("unknown hook %q (known hooks: %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	s, err := LoadEntireSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	switch {
+	case enable && slices.Contains(names, allHooks):
+		s.DisabledHooks = nil
+	case enable:
+		if slices.Contains(s.DisabledHooks, allHooks) {
+			return errors.New("all hooks are disabled; run 'entire hooks enable all' first")
+		}
+		s.DisabledHooks = slices.DeleteFunc(s.DisabledHooks, func(name string) bool {
+			return slices.Contains(names, name)
+		})
+	case slices.Contains(names, allHooks):
+		s.DisabledHooks = []string{allHooks}
+	default:
+		for _, name := range names {
+			if !slices.Contains(s.DisabledHooks, name) {
+				s.DisabledHooks = append(s.DisabledHooks, name)
+			}
+		}
+		sort.Strings(s.DisabledHooks)
+	}
+	if len(s.DisabledHooks) == 0 {
+		s.DisabledHooks = nil
+	}
+
+	if useProjectSettings {
+		err = SaveEntireSettings(s)
+	} else {
+		err = SaveEntireSettingsLocal(s)
+	}
+	if err != nil {
+		return err
+	}
+
+	effective, err := LoadEntireSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if len(s.DisabledHooks) == 0 && len(effective.DisabledHooks) > 0 {
+		// Another scope still disables hooks; an explicit empty list in this
+		// scope overrides it.
+		if err := clearDisabledHooks(useProjectSettings); err != nil {
+			return err
+		}
+		if effective, err = LoadEntireSettings(); err != nil {
+			return fmt.Errorf("failed to load settings: %w", err)
+		}
+	}
+
+	if len(effective.DisabledHooks) == 0 {
+		fmt.Fprintln(w, "All hooks are enabled.")
+	} else {
+		fmt.Fprintf(w, "Disabled hooks: %s\n", strings.Join(effective.DisabledHooks, ", "))
+	}
+	if env := os.Getenv(HooksDisabledEnvVar); enable && len(parseDisabledHooksEnv(env)) > 0 {
+		fmt.Fprintf(w, "Note: %s=%s is set and still disables hooks in this environment.\n", HooksDisabledEnvVar, env)
+	}
+	return nil
+}
+
+// clearDisabledHooks writes an empty disabled_hooks list to the project or
+// local settings file.
+func clearDisabledHooks(useProjectSettings bool) error {
+	path := configScopePath(configScopeLocal)
+	if useProjectSettings {
+		path = configScopePath(configScopeProject)
+	}
+	m, err := readSettingsMap(path)
+	if err != nil {
+		return err
+	}
+	m["disabled_hooks"] = []string{}
+	return writeSettingsMap(path, m)
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent/claudecode"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+func TestParseDisabledHooksEnv(t *testing.T) {
+	tests := map[string][]string{
+		"":                    nil,
+		"0":                   nil,
+		"false":               nil,
+		"1":                   {allHooks},
+		"TRUE":                {allHooks},
+		"all":                 {allHooks},
+		"stop, post-commit ,": {"stop", "post-commit"},
+	}
+	for value, want := range tests {
+		if got := parseDisabledHooksEnv(value); !slices.Equal(got, want) {
+			t.Errorf("parseDisabledHooksEnv(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestIsHookDisabled(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv(HooksDisabledEnvVar, "")
+
+	if isHookDisabled(claudecode.HookNameStop) {
+		t.Error("hook disabled without settings")
+	}
+
+	writeSettings(t, `{"strategy": "manual-commit", "enabled": true, "disabled_hooks": ["stop"]}`)
+	if !isHookDisabled(claudecode.HookNameStop) {
+		t.Error("stop not disabled by settings")
+	}
+	if isHookDisabled("post-commit") {
+		t.Error("post-commit disabled but not listed")
+	}
+
+	t.Setenv(HooksDisabledEnvVar, "1")
+	if !isHookDisabled("post-commit") {
+		t.Error("post-commit not disabled by the kill switch")
+	}
+}
+
+func TestRunHooksToggle(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv(HooksDisabledEnvVar, "")
+	writeSettings(t, testSettingsEnabled)
+
+	var stdout bytes.Buffer
+	if err := runHooksToggle(&stdout, []string{"stop", "post-commit"}, false, false); err != nil {
+		t.Fatalf("disable error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Disabled hooks: post-commit, stop") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+	if !isHookDisabled("stop") || !isHookDisabled("post-commit") {
+		t.Error("hooks not disabled after disable")
+	}
+	enabled, err := IsEnabled()
+	if err != nil || !enabled {
+		t.Errorf("IsEnabled() = %v, %v; disabling hooks shouldn't disable Entire", enabled, err)
+	}
+
+	stdout.Reset()
+	if err := runHooksToggle(&stdout, []string{"stop"}, true, false); err != nil {
+		t.Fatalf("enable error = %v", err)
+	}
+	if isHookDisabled("stop") || !isHookDisabled("post-commit") {
+		t.Error("enable stop should leave only post-commit disabled")
+	}
+
+	stdout.Reset()
+	if err := runHooksToggle(&stdout, []string{allHooks}, true, false); err != nil {
+		t.Fatalf("enable all error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "All hooks are enabled.") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	if err := runHooksToggle(&stdout, []string{"no-such-hook"}, false, false); err == nil {
+		t.Error("expected error for unknown hook")
+	}
+}
+
+func TestRunHooksToggle_ProjectKeepsOtherScopes(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv(HooksDisabledEnvVar, "")
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.MkdirAll(filepath.Join(configHome, "entire"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settings.UserSettingsFile(), []byte(`{"log_level": "debug", "sync_remote": "mine"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeSettings(t, testSettingsEnabled)
+	if err := os.WriteFile(EntireSettingsLocalFile, []byte(`{"trace_hooks": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runHooksToggle(&stdout, []string{"stop"}, false, true); err != nil {
+		t.Fatalf("disable error = %v", err)
+	}
+	data, err := os.ReadFile(EntireSettingsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := string(data)
+	if !strings.Contains(project, `"disabled_hooks"`) {
+		t.Errorf("project settings don't disable stop:\n%s", project)
+	}
+	for _, leaked := range []string{"log_level", "sync_remote", "trace_hooks"} {
+		if strings.Contains(project, leaked) {
+			t.Errorf("project settings contain %s from another scope:\n%s", leaked, project)
+		}
+	}
+}
+
+func TestRunHooksToggle_LocalEnableOverridesProject(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv(HooksDisabledEnvVar, "")
+	writeSettings(t, testSettingsEnabled)
+
+	var stdout bytes.Buffer
+	if err := runHooksToggle(&stdout, []string{"stop"}, false, true); err != nil {
+		t.Fatalf("project disable error = %v", err)
+	}
+	stdout.Reset()
+	if err := runHooksToggle(&stdout, []string{"stop"}, true, false); err != nil {
+		t.Fatalf("local enable error = %v", err)
+	}
+	if isHookDisabled("stop") {
+		t.Error("stop still disabled after enabling it in local settings")
+	}
+	if !strings.Contains(stdout.String(), "All hooks are enabled.") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	data, err := os.ReadFile(EntireSettingsLocalFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"disabled_hooks": []`) {
+		t.Errorf("local settings don't override disabled_hooks:\n%s", data)
+	}
+	data, err = os.ReadFile(EntireSettingsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"stop"`) {
+		t.Errorf("local enable changed project settings:\n%s", data)
+	}
+}
+
+func TestRunHooksToggle_PrintsEffectiveList(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv(HooksDisabledEnvVar, "")
+	writeSettings(t, testSettingsEnabled)
+	if err := os.WriteFile(EntireSettingsLocalFile, []byte(`{"disabled_hooks": ["stop"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runHooksToggle(&stdout, []string{"post-commit"}, false, true); err != nil {
+		t.Fatalf("project disable error = %v", err)
+	}
+	// The local list overrides the project one, so only stop stays disabled
+	if !strings.Contains(stdout.String(), "Disabled hooks: stop\n") {
+		t.Errorf("output doesn't show the effective list: %s", stdout.String())
+	}
+}
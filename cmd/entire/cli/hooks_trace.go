@@ -0,0 +1,484 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+// HooksTraceEnvVar turns on hook tracing for a single shell or command
+// when set to "1" or "true", like the trace_hooks setting.
+const HooksTraceEnvVar = "ENTIRE_HOOKS_TRACE"
+
+// hookReplayEnvVar is set on replayed invocations so they aren't traced again.
+const hookReplayEnvVar = "ENTIRE_HOOK_REPLAY"
+
+// hookTraceDirName is the state directory holding traced invocations.
+const hookTraceDirName = "entire-hook-traces"
+
+// hookTraceCapacity is how many invocations the trace keeps; older ones
+// are deleted as new ones are recorded.
+const hookTraceCapacity = 50
+
+// hookTraceEnvPrefixes are the environment variables recorded with an
+// invocation. Names that look like credentials are left out.
+var hookTraceEnvPrefixes = []string{"ENTIRE_", "CLAUDE_", "GEMINI_"}
+
+// hookReplayExecutable returns the binary `entire hooks replay` runs.
+// Tests replace it.
+var hookReplayExecutable = os.Executable
+
+// hookTrace is one recorded agent hook invocation.
+type hookTrace struct {
+	Seq   int             `json:"seq"`
+	Time  time.Time       `json:"time"`
+	Agent agent.AgentName `json:"agent"`
+	Hook  string          `json:"hook"`
+	// Args are the arguments the hook was called with, after "entire".
+	Args       []string          `json:"args"`
+	Dir        string            `json:"dir"`
+	Env        map[string]string `json:"env,omitempty"`
+	Stdin      string            `json:"stdin"`
+	Stdout     string            `json:"stdout"`
+	ExitCode   int               `json:"exit_code"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// hookTracingEnabled reports whether agent hook invocations are recorded.
+func hookTracingEnabled() bool {
+	if os.Getenv(hookReplayEnvVar) != "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(HooksTraceEnvVar))) {
+	case "1", "true":
+		return true
+	case "0", "false":
+		return false
+	}
+	s, err := settings.Load()
+	return err == nil && s.TraceHooks
+}
+
+// startHookTrace starts recording an agent hook invocation if tracing is on.
+// It reads stdin up front and hands the handler a copy, and tees stdout.
+// The returned function restores stdin and stdout and saves the trace; the
+// hook command calls it with the handler's error.
+func startHookTrace(agentName agent.AgentName, hookName string) func(error) {
+	if !hookTracingEnabled() {
+		return func(error) {}
+	}
+	logCtx := logging.WithComponent(context.Background(), "hooks")
+	start := time.Now()
+
+	origStdin, origStdout := os.Stdin, os.Stdout
+	stdin, err := io.ReadAll(origStdin)
+	if err != nil {
+		logging.Warn(logCtx, "hook trace: failed to read stdin", slog.String("error", err.Error()))
+	}
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		logging.Warn(logCtx, "hook trace: failed to create pipe", slog.String("error", err.Error()))
+		return func(error) {}
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		_ = stdinR.Close()
+		_ = stdinW.Close()
+		logging.Warn(logCtx, "hook trace: failed to create pipe", slog.String("error", err.Error()))
+		return func(error) {}
+	}
+	go func() {
+		_, _ = stdinW.Write(stdin)
+		_ = stdinW.Close()
+	}()
+	var stdout bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.MultiWriter(origStdout, &stdout), stdoutR)
+		close(copied)
+	}()
+	os.Stdin, os.Stdout = stdinR, stdoutW
+
+	return func(hookErr error) {
+		_ = stdoutW.Close()
+		<-copied
+		_ = stdoutR.Close()
+		_ = stdinR.Close()
+		os.Stdin, os.Stdout = origStdin, origStdout
+
+		dir, _ := os.Getwd() //nolint:errcheck // an empty dir replays in the current directory
+		trace := hookTrace{
+			Time:       start,
+			Agent:      agentName,
+			Hook:       hookName,
+			Args:       []string{"hooks", string(agentName), hookName},
+			Dir:        dir,
+			Env:        hookTraceEnv(os.Environ()),
+			Stdin:      string(stdin),
+			Stdout:     stdout.String(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if hookErr != nil {
+			trace.ExitCode = 1
+			trace.Error = hookErr.Error()
+		}
+		if err := saveHookTrace(&trace); err != nil {
+			logging.Warn(logCtx, "hook trace: failed to save", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// hookTraceEnv picks the variables of environ worth recording.
+func hookTraceEnv(environ []string) map[string]string {
+	env := map[string]string{}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || name == hookReplayEnvVar {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		if strings.Contains(upper, "TOKEN") || strings.Contains(upper, "KEY") || strings.Contains(upper, "SECRET") {
+			continue
+		}
+		for _, prefix := range hookTraceEnvPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				env[name] = value
+				break
+			}
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+func hookTraceDir() (string, error) {
+	commonDir, err := strategy.GetGitCommonDir()
+	if err != nil {
+		return "", err //nolint:wrapcheck // already wrapped by GetGitCommonDir
+	}
+	return fsenv.StateDir(commonDir, hookTraceDirName), nil
+}
+
+// hookTraceSeqs returns the sequence numbers of the recorded traces, oldest first.
+func hookTraceSeqs(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook traces: %w", err)
+	}
+	var seqs []int
+	for _, e := range entries {
+		seq, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	return seqs, nil
+}
+
+func hookTracePath(dir string, seq int) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.json", seq))
+}
+
+// saveHookTrace numbers trace after the newest recorded one, writes it and
+// drops the oldest traces beyond hookTraceCapacity.
+func saveHookTrace(trace *hookTrace) error {
+	dir, err := hookTraceDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create hook trace directory: %w", err)
+	}
+	seqs, err := hookTraceSeqs(dir)
+	if err != nil {
+		return err
+	}
+	trace.Seq = 1
+	if len(seqs) > 0 {
+		trace.Seq = seqs[len(seqs)-1] + 1
+	}
+	data, err := jsonutil.MarshalIndentWithNewline(trace, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook trace: %w", err)
+	}
+	if err := os.WriteFile(hookTracePath(dir, trace.Seq), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write hook trace: %w", err)
+	}
+	seqs = append(seqs, trace.Seq)
+	for _, seq := range seqs[:max(len(seqs)-hookTraceCapacity, 0)] {
+		_ = os.Remove(hookTracePath(dir, seq))
+	}
+	return nil
+}
+
+func loadHookTrace(dir string, seq int) (*hookTrace, error) {
+	data, err := os.ReadFile(hookTracePath(dir, seq))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no hook trace #%d (see 'entire hooks trace')", seq)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook trace: %w", err)
+	}
+	var trace hookTrace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, fmt.Errorf("failed to parse hook trace #%d: %w", seq, err)
+	}
+	return &trace, nil
+}
+
+// listHookTraces returns the recorded traces, newest first.
+func listHookTraces() ([]hookTrace, error) {
+	dir, err := hookTraceDir()
+	if err != nil {
+		return nil, err
+	}
+	seqs, err := hookTraceSeqs(dir)
+	if err != nil {
+		return nil, err
+	}
+	traces := make([]hookTrace, 0, len(seqs))
+	for i := len(seqs) - 1; i >= 0; i-- {
+		trace, err := loadHookTrace(dir, seqs[i])
+		if err != nil {
+			continue
+		}
+		traces = append(traces, *trace)
+	}
+	return traces, nil
+}
+
+func parseHookTraceSeq(arg string) (int, error) {
+	seq, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || seq <= 0 {
+		return 0, fmt.Errorf("invalid trace number %q", arg)
+	}
+	return seq, nil
+}
+
+func newHooksTraceCmd() *cobra.Command {
+	var jsonFlag, enableFlag, disableFlag, useProjectSettings bool
+
+	cmd := &cobra.Command{
+		Use:   "trace [n]",
+		Short: "Show recorded agent hook invocations",
+		Long: `Show recorded agent hook invocations, newest first, or invocation n in full.
+
+With tracing on, every agent hook invocation is recorded: its stdin payload,
+ENTIRE_*, CLAUDE_* and GEMINI_* environment variables, stdout and exit code.
+The last 50 are kept in the repository's state directory. Turn tracing on
+with --enable, or set ENTIRE_HOOKS_TRACE=1 for a single shell.
+
+Re-run a recorded invocation against the current binary with
+'entire hooks replay <n>'.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if enableFlag || disableFlag {
+				if len(args) > 0 {
+					return errors.New("--enable and --disable don't take a trace number")
+				}
+				return runHooksTraceToggle(cmd.OutOrStdout(), enableFlag, useProjectSettings)
+			}
+			if len(args) == 1 {
+				seq, err := parseHookTraceSeq(args[0])
+				if err != nil {
+					return err
+				}
+				return runHooksTraceShow(cmd.OutOrStdout(), seq, jsonFlag)
+			}
+			return runHooksTraceList(cmd.OutOrStdout(), jsonFlag)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&enableFlag, "enable", false, "Turn hook tracing on")
+	cmd.Flags().BoolVar(&disableFlag, "disable", false, "Turn hook tracing off")
+	cmd.Flags().BoolVar(&useProjectSettings, "project", false, "With --enable or --disable, update settings.json instead of settings.local.json")
+	cmd.MarkFlagsMutuallyExclusive("enable", "disable")
+	return cmd
+}
+
+func runHooksTraceToggle(w io.Writer, enable, useProjectSettings bool) error {
+	s, err := LoadEntireSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	s.TraceHooks = enable
+	if useProjectSettings {
+		err = SaveEntireSettings(s)
+	} else {
+		err = SaveEntireSettingsLocal(s)
+	}
+	if err != nil {
+		return err
+	}
+	if enable {
+		fmt.Fprintln(w, "Hook tracing is on. Recorded invocations: 'entire hooks trace'.")
+	} else {
+		fmt.Fprintln(w, "Hook tracing is off.")
+	}
+	return nil
+}
+
+func runHooksTraceList(w io.Writer, jsonOutput bool) error {
+	traces, err := listHookTraces()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(traces, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal hook traces: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+	if len(traces) == 0 {
+		if hookTracingEnabled() {
+			fmt.Fprintln(w, "No hook invocations recorded yet.")
+		} else {
+			fmt.Fprintln(w, "No hook invocations recorded. Turn tracing on with 'entire hooks trace --enable'.")
+		}
+		return nil
+	}
+	fmt.Fprintf(w, "%5s  %-19s  %-12s  %-20s  %4s  %s\n", "#", "Time", "Agent", "Hook", "Exit", "Duration")
+	for _, t := range traces {
+		fmt.Fprintf(w, "%5d  %-19s  %-12s  %-20s  %4d  %dms\n",
+			t.Seq, t.Time.Local().Format("2006-01-02 15:04:05"), t.Agent, t.Hook, t.ExitCode, t.DurationMs)
+	}
+	return nil
+}
+
+func runHooksTraceShow(w io.Writer, seq int, jsonOutput bool) error {
+	dir, err := hookTraceDir()
+	if err != nil {
+		return err
+	}
+	trace, err := loadHookTrace(dir, seq)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(trace, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal hook trace: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	fmt.Fprintf(w, "Invocation #%d: entire %s\n", trace.Seq, strings.Join(trace.Args, " "))
+	fmt.Fprintf(w, "Time:     %s\n", trace.Time.Local().Format(time.RFC3339))
+	fmt.Fprintf(w, "Dir:      %s\n", trace.Dir)
+	fmt.Fprintf(w, "Exit:     %d\n", trace.ExitCode)
+	if trace.Error != "" {
+		fmt.Fprintf(w, "Error:    %s\n", trace.Error)
+	}
+	fmt.Fprintf(w, "Duration: %dms\n", trace.DurationMs)
+	if len(trace.Env) > 0 {
+		fmt.Fprintln(w, "\nEnvironment:")
+		names := make([]string, 0, len(trace.Env))
+		for name := range trace.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s=%s\n", name, trace.Env[name])
+		}
+	}
+	fmt.Fprintf(w, "\nStdin:\n%s\n", strings.TrimRight(trace.Stdin, "\n"))
+	if trace.Stdout != "" {
+		fmt.Fprintf(w, "\nStdout:\n%s\n", strings.TrimRight(trace.Stdout, "\n"))
+	}
+	return nil
+}
+
+func newHooksReplayCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay <n>",
+		Short: "Re-run a recorded agent hook invocation",
+		Long: `Re-run recorded invocation n (see 'entire hooks trace') against the current
+binary, with the recorded stdin payload and environment variables, in the
+directory it ran in.
+
+The hook really runs: it can create checkpoints and update session state
+like the original invocation did. Replays are not traced themselves.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			seq, err := parseHookTraceSeq(args[0])
+			if err != nil {
+				return err
+			}
+			return runHooksReplay(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), seq)
+		},
+	}
+}
+
+func runHooksReplay(ctx context.Context, w, errW io.Writer, seq int) error {
+	dir, err := hookTraceDir()
+	if err != nil {
+		return err
+	}
+	trace, err := loadHookTrace(dir, seq)
+	if err != nil {
+		return err
+	}
+	exe, err := hookReplayExecutable()
+	if err != nil {
+		return fmt.Errorf("failed to find the entire binary: %w", err)
+	}
+
+	replay := exec.CommandContext(ctx, exe, trace.Args...) //nolint:gosec // args come from a trace this binary recorded
+	if info, err := os.Stat(trace.Dir); err == nil && info.IsDir() {
+		replay.Dir = trace.Dir
+	}
+	replay.Env = os.Environ()
+	for name, value := range trace.Env {
+		replay.Env = append(replay.Env, name+"="+value)
+	}
+	replay.Env = append(replay.Env, hookReplayEnvVar+"=1")
+	replay.Stdin = strings.NewReader(trace.Stdin)
+	replay.Stdout = w
+	replay.Stderr = errW
+
+	exitCode := 0
+	if err := replay.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to replay hook: %w", err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	fmt.Fprintf(errW, "Replayed #%d (entire %s): exit %d, recorded exit %d\n",
+		trace.Seq, strings.Join(trace.Args, " "), exitCode, trace.ExitCode)
+	if exitCode != 0 {
+		return NewSilentError(fmt.Errorf("replayed hook exited with %d", exitCode))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+)
+
+func TestStartHookTrace_RecordsInvocation(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, testSettingsEnabled)
+	t.Setenv(HooksTraceEnvVar, "1")
+	t.Setenv("CLAUDE_PROJECT_DIR", "/work/project")
+	t.Setenv("CLAUDE_API_KEY", "sk-not-recorded")
+
+	payload := `{"session_id":"abc","transcript_path":"/tmp/t.jsonl"}`
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdinW.WriteString(payload); err != nil {
+		t.Fatal(err)
+	}
+	stdinW.Close()
+	origStdin, origStdout := os.Stdin, os.Stdout
+	os.Stdin = stdinR
+	t.Cleanup(func() { os.Stdin, os.Stdout = origStdin, origStdout })
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+
+	finish := startHookTrace(agent.AgentNameClaudeCode, "stop")
+	got, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("handler stdin = %q, want %q", got, payload)
+	}
+	fmt.Fprint(os.Stdout, `{"continue":true}`)
+	finish(errors.New("boom"))
+
+	if os.Stdout != devNull || os.Stdin != stdinR {
+		t.Error("stdin and stdout weren't restored")
+	}
+	traces, err := listHookTraces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1", len(traces))
+	}
+	trace := traces[0]
+	if trace.Seq != 1 || trace.Hook != "stop" || trace.Agent != agent.AgentNameClaudeCode {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+	if trace.Stdin != payload || trace.Stdout != `{"continue":true}` {
+		t.Errorf("stdin = %q, stdout = %q", trace.Stdin, trace.Stdout)
+	}
+	if trace.ExitCode != 1 || trace.Error != "boom" {
+		t.Errorf("exit = %d, error = %q; want 1, boom", trace.ExitCode, trace.Error)
+	}
+	if trace.Env["CLAUDE_PROJECT_DIR"] != "/work/project" {
+		t.Errorf("env = %v, want CLAUDE_PROJECT_DIR", trace.Env)
+	}
+	if _, ok := trace.Env["CLAUDE_API_KEY"]; ok {
+		t.Error("credential-like variable was recorded")
+	}
+}
+
+func TestStartHookTrace_OffByDefault(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, testSettingsEnabled)
+	t.Setenv(HooksTraceEnvVar, "")
+
+	startHookTrace(agent.AgentNameClaudeCode, "stop")(nil)
+
+	traces, err := listHookTraces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 0 {
+		t.Errorf("got %d traces with tracing off, want 0", len(traces))
+	}
+}
+
+func TestSaveHookTrace_KeepsNewest(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, testSettingsEnabled)
+
+	for range hookTraceCapacity + 5 {
+		if err := saveHookTrace(&hookTrace{Agent: agent.AgentNameClaudeCode, Hook: "stop"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	traces, err := listHookTraces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != hookTraceCapacity {
+		t.Fatalf("got %d traces, want %d", len(traces), hookTraceCapacity)
+	}
+	if traces[0].Seq != hookTraceCapacity+5 || traces[len(traces)-1].Seq != 6 {
+		t.Errorf("kept #%d..#%d, want #6..#%d", traces[len(traces)-1].Seq, traces[0].Seq, hookTraceCapacity+5)
+	}
+}
+
+func TestRunHooksReplay(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, testSettingsEnabled)
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A stand-in binary that echoes its stdin, arguments and environment
+	script := filepath.Join(t.TempDir(), "entire")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat\necho \" $* $CLAUDE_PROJECT_DIR $"+hookReplayEnvVar+"\"\nexit 3\n"), 0o700); err != nil { //nolint:gosec // test script must be executable
+		t.Fatal(err)
+	}
+	orig := hookReplayExecutable
+	hookReplayExecutable = func() (string, error) { return script, nil }
+	t.Cleanup(func() { hookReplayExecutable = orig })
+
+	trace := hookTrace{
+		Agent: agent.AgentNameClaudeCode,
+		Hook:  "stop",
+		Args:  []string{"hooks", "claude-code", "stop"},
+		Dir:   dir,
+		Env:   map[string]string{"CLAUDE_PROJECT_DIR": "/work/project"},
+		Stdin: `{"session_id":"abc"}`,
+	}
+	if err := saveHookTrace(&trace); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = runHooksReplay(context.Background(), &stdout, &stderr, trace.Seq)
+	var silent *SilentError
+	if !errors.As(err, &silent) {
+		t.Fatalf("runHooksReplay() error = %v, want a silent error for exit 3", err)
+	}
+	want := `{"session_id":"abc"} hooks claude-code stop /work/project 1`
+	if got := strings.TrimSpace(stdout.String()); got != want {
+		t.Errorf("replay stdout = %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "exit 3, recorded exit 0") {
+		t.Errorf("replay summary = %q", stderr.String())
+	}
+
+	if err := runHooksReplay(context.Background(), &stdout, &stderr, 99); err == nil || !strings.Contains(err.Error(), "no hook trace #99") {
+		t.Errorf("replay of a missing trace: error = %v", err)
+	}
+}
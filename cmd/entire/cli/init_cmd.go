@@ -0,0 +1,510 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// initAgentCandidate is an agent `entire init` looks for.
+type initAgentCandidate struct {
+	// Name is the registry key, empty for agents Entire doesn't integrate
+	// with yet
+	Name    agent.AgentName
+	Display string
+	// Binary is the executable looked up on PATH
+	Binary string
+}
+
+// initAgentCandidates are the agents `entire init` detects, in the order
+// they're offered.
+var initAgentCandidates = []initAgentCandidate{
+	{Name: agent.AgentNameClaudeCode, Display: string(agent.AgentTypeClaudeCode), Binary: "claude"},
+	{Display: "Codex", Binary: "codex"},
+	{Name: agent.AgentNameGemini, Display: string(agent.AgentTypeGemini), Binary: "gemini"},
+	{Name: agent.AgentNameAider, Display: string(agent.AgentTypeAider), Binary: "aider"},
+}
+
+// initLookPath finds agent binaries. A variable so tests don't depend on
+// what's installed.
+var initLookPath = exec.LookPath
+
+// detectedAgent is an initAgentCandidate and where it was found.
+type detectedAgent struct {
+	initAgentCandidate
+	// OnPath is set when the agent's binary is installed
+	OnPath bool
+	// InRepo is set when the repository has the agent's configuration
+	InRepo bool
+}
+
+// Detected reports whether the agent was found at all.
+func (d detectedAgent) Detected() bool { return d.OnPath || d.InRepo }
+
+// Supported reports whether Entire can install hooks for the agent.
+func (d detectedAgent) Supported() bool { return d.Name != "" }
+
+// detectAgents looks for every initAgentCandidate on PATH and in the
+// repository.
+func detectAgents() []detectedAgent {
+	detected := make([]detectedAgent, 0, len(initAgentCandidates))
+	for _, c := range initAgentCandidates {
+		d := detectedAgent{initAgentCandidate: c}
+		if _, err := initLookPath(c.Binary); err == nil {
+			d.OnPath = true
+		}
+		if c.Name != "" {
+			if ag, err := agent.Get(c.Name); err == nil {
+				if present, err := ag.DetectPresence(); err == nil && present {
+					d.InRepo = true
+				}
+			}
+		}
+		detected = append(detected, d)
+	}
+	return detected
+}
+
+// initOptions are the choices `entire init` applies.
+type initOptions struct {
+	Agents    []agent.AgentName
+	Strategy  string
+	Retention *settings.RetentionSettings
+	Local     bool
+	LocalDev  bool
+	Force     bool
+	Telemetry bool
+}
+
+// Retention presets offered by the wizard
+const (
+	initRetentionNone = "none"
+	initRetention30d  = "30d"
+	initRetention90d  = "90d"
+	initRetentionKeep = "keep"
+)
+
+// initRetentionKeepPerSession is what the "keep" preset keeps per session.
+const initRetentionKeepPerSession = 20
+
+func newInitCmd() *cobra.Command {
+	var agentNames []string
+	var strategyFlag string
+	var olderThan string
+	var keepPerSession int
+	var yes bool
+	var useLocalSettings bool
+	var localDev bool
+	var forceHooks bool
+	var telemetry bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up Entire for the agents you use",
+		Long: `Set up Entire in the current repository, step by step.
+
+Detects which agents are installed (Claude Code, Codex, Gemini CLI, Aider) or
+configured in the repository, asks which ones to capture, which strategy to
+use and how long to keep checkpoints, then writes the settings, installs the
+agent and git hooks and checks that everything is in place.
+
+With --yes, or without a terminal, nothing is asked: the flags are used and
+every detected agent is set up.
+
+  entire init --yes --agent claude-code --agent gemini --strategy auto-commit`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if _, err := paths.RepoRoot(); err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Not a git repository. Please run 'entire init' from within a git repository.")
+				return NewSilentError(errors.New("not a git repository"))
+			}
+
+			w := cmd.OutOrStdout()
+			detected := detectAgents()
+			printDetectedAgents(w, detected)
+
+			opts := initOptions{
+				Strategy:  strategyFlag,
+				Local:     useLocalSettings,
+				LocalDev:  localDev,
+				Force:     forceHooks,
+				Telemetry: telemetry,
+			}
+			for _, name := range agentNames {
+				opts.Agents = append(opts.Agents, agent.AgentName(name))
+			}
+			if olderThan != "" || keepPerSession > 0 {
+				opts.Retention = &settings.RetentionSettings{OlderThan: olderThan, KeepPerSession: keepPerSession}
+			}
+
+			interactive := !yes && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			if interactive {
+				if err := runInitWizard(detected, &opts, cmd.Flags().Changed("retention-older-than") || cmd.Flags().Changed("retention-keep-per-session")); err != nil {
+					return err
+				}
+			} else if len(opts.Agents) == 0 {
+				opts.Agents = defaultInitAgents(detected)
+			}
+			return runInit(w, opts, interactive)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&agentNames, "agent", nil, "Agent to set up (repeatable, e.g. --agent claude-code --agent gemini)")
+	cmd.Flags().StringVar(&strategyFlag, "strategy", "", "Strategy to use (manual-commit, auto-commit or stacked)")
+	cmd.Flags().StringVar(&olderThan, "retention-older-than", "", "Prune checkpoints older than this, e.g. 30d (retention.older_than)")
+	cmd.Flags().IntVar(&keepPerSession, "retention-keep-per-session", 0, "Keep only this many recent checkpoints per session (retention.keep_per_session)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask, use the flags and the detected agents")
+	cmd.Flags().BoolVar(&useLocalSettings, "local", false, "Write settings to settings.local.json instead of settings.json")
+	cmd.Flags().BoolVar(&localDev, "local-dev", false, "Use go run instead of entire binary for hooks")
+	cmd.Flags().MarkHidden("local-dev") //nolint:errcheck,gosec // flag is defined above
+	cmd.Flags().BoolVarP(&forceHooks, "force", "f", false, "Force reinstall hooks (removes existing Entire hooks first)")
+	cmd.Flags().BoolVar(&telemetry, "telemetry", true, "Ask about anonymous usage analytics (--telemetry=false opts out without asking)")
+	//nolint:errcheck,gosec // completion is optional, flag is defined above
+	cmd.RegisterFlagCompletionFunc("agent", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, 0, len(initAgentCandidates))
+		for _, c := range initAgentCandidates {
+			if c.Name != "" {
+				names = append(names, string(c.Name))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+	//nolint:errcheck,gosec // completion is optional, flag is defined above
+	cmd.RegisterFlagCompletionFunc("strategy", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return strategy.List(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+// printDetectedAgents lists what detectAgents found.
+func printDetectedAgents(w io.Writer, detected []detectedAgent) {
+	fmt.Fprintln(w, "Detected agents:")
+	for _, d := range detected {
+		var where []string
+		if d.OnPath {
+			where = append(where, "installed")
+		}
+		if d.InRepo {
+			where = append(where, "configured in this repository")
+		}
+		switch {
+		case !d.Detected():
+			fmt.Fprintf(w, "  - %s: not found\n", d.Display)
+		case !d.Supported():
+			fmt.Fprintf(w, "  ! %s: %s, not supported by Entire yet\n", d.Display, strings.Join(where, ", "))
+		default:
+			fmt.Fprintf(w, "  ✓ %s: %s\n", d.Display, strings.Join(where, ", "))
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// defaultInitAgents are the detected agents Entire supports, or the default
+// agent if none was detected.
+func defaultInitAgents(detected []detectedAgent) []agent.AgentName {
+	var names []agent.AgentName
+	for _, d := range detected {
+		if d.Detected() && d.Supported() {
+			names = append(names, d.Name)
+		}
+	}
+	if len(names) == 0 {
+		names = []agent.AgentName{agent.DefaultAgentName}
+	}
+	return names
+}
+
+// runInitWizard asks for the options the flags didn't set.
+func runInitWizard(detected []detectedAgent, opts *initOptions, retentionSet bool) error {
+	if len(opts.Agents) == 0 {
+		opts.Agents = defaultInitAgents(detected)
+	}
+	agentNames := make([]string, len(opts.Agents))
+	for i, name := range opts.Agents {
+		agentNames[i] = string(name)
+	}
+	var agentOptions []huh.Option[string]
+	for _, d := range detected {
+		if !d.Supported() {
+			continue
+		}
+		label := d.Display
+		if d.Detected() {
+			label += " (detected)"
+		}
+		agentOptions = append(agentOptions, huh.NewOption(label, string(d.Name)))
+	}
+
+	if opts.Strategy == "" {
+		opts.Strategy = strategy.DefaultStrategyName
+	}
+	var strategyOptions []huh.Option[string]
+	for _, name := range strategy.List() {
+		label := name
+		if name == strategy.DefaultStrategyName {
+			label += " (recommended)"
+		}
+		strategyOptions = append(strategyOptions, huh.NewOption(label, name))
+	}
+
+	retention := initRetentionNone
+	groups := []*huh.Group{
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Which agents should Entire capture?").
+				Options(agentOptions...).
+				Validate(func(selected []string) error {
+					if len(selected) == 0 {
+						return errors.New("pick at least one agent")
+					}
+					return nil
+				}).
+				Value(&agentNames),
+			huh.NewSelect[string]().
+				Title("Strategy").
+				Description("manual-commit checkpoints on shadow branches and links them to your commits; auto-commit commits after every agent turn; stacked does too, as a stack to review commit by commit").
+				Options(strategyOptions...).
+				Value(&opts.Strategy),
+		),
+	}
+	if !retentionSet {
+		groups = append(groups, huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Checkpoint retention").
+				Description("The default policy of `entire checkpoint prune`").
+				Options(
+					huh.NewOption("Keep everything", initRetentionNone),
+					huh.NewOption("Prune checkpoints older than 30 days", initRetention30d),
+					huh.NewOption("Prune checkpoints older than 90 days", initRetention90d),
+					huh.NewOption(fmt.Sprintf("Keep the %d most recent checkpoints per session", initRetentionKeepPerSession), initRetentionKeep),
+				).
+				Value(&retention),
+		))
+	}
+
+	if err := NewAccessibleForm(groups...).Run(); err != nil {
+		return fmt.Errorf("init cancelled: %w", err)
+	}
+
+	opts.Agents = opts.Agents[:0]
+	for _, name := range agentNames {
+		opts.Agents = append(opts.Agents, agent.AgentName(name))
+	}
+	switch retention {
+	case initRetention30d, initRetention90d:
+		opts.Retention = &settings.RetentionSettings{OlderThan: retention}
+	case initRetentionKeep:
+		opts.Retention = &settings.RetentionSettings{KeepPerSession: initRetentionKeepPerSession}
+	}
+	return nil
+}
+
+// validateInitOptions checks opts before anything is written.
+func validateInitOptions(opts initOptions) error {
+	if len(opts.Agents) == 0 {
+		return errors.New("no agent to set up (use --agent)")
+	}
+	for _, name := range opts.Agents {
+		ag, err := agent.Get(name)
+		if err != nil {
+			return fmt.Errorf("unknown agent %q (available: %s)", name, JoinAgentNames(agent.List()))
+		}
+		_, hooks := ag.(agent.HookSupport)
+		_, watched := ag.(agent.FileWatcher)
+		if !hooks && !watched {
+			return fmt.Errorf("agent %s does not support hooks", name)
+		}
+		if name == agent.AgentNameAider && opts.Strategy != strategy.StrategyNameManualCommit {
+			return fmt.Errorf("aider requires the %s strategy", strategy.StrategyNameManualCommit)
+		}
+	}
+	if _, err := strategy.Get(opts.Strategy); err != nil {
+		return fmt.Errorf("unknown strategy: %s (available: %s)", opts.Strategy, strings.Join(strategy.List(), ", "))
+	}
+	if r := opts.Retention; r != nil {
+		if r.OlderThan != "" {
+			if _, err := parseRetentionDuration(r.OlderThan); err != nil {
+				return fmt.Errorf("invalid retention older_than: %w", err)
+			}
+		}
+		if r.KeepPerSession < 0 {
+			return errors.New("invalid retention keep_per_session: must not be negative")
+		}
+	}
+	return nil
+}
+
+// runInit writes the settings, installs the hooks for opts and prints a
+// summary of what's in place. Telemetry consent is only asked when
+// interactive.
+func runInit(w io.Writer, opts initOptions, interactive bool) error {
+	if opts.Strategy == "" {
+		opts.Strategy = strategy.DefaultStrategyName
+	}
+	if mapped, ok := strategyDisplayToInternal[opts.Strategy]; ok {
+		opts.Strategy = mapped
+	}
+	if err := validateInitOptions(opts); err != nil {
+		return err
+	}
+
+	// Agent hooks don't depend on settings
+	for _, name := range opts.Agents {
+		ag, err := agent.Get(name)
+		if err != nil {
+			return fmt.Errorf("failed to get agent %s: %w", name, err)
+		}
+		if hookAgent, ok := ag.(agent.HookSupport); ok {
+			if _, err := hookAgent.InstallHooks(opts.LocalDev, opts.Force); err != nil {
+				return fmt.Errorf("failed to install hooks for %s: %w", name, err)
+			}
+		}
+	}
+
+	if _, err := setupEntireDirectory(); err != nil {
+		return fmt.Errorf("failed to setup .entire directory: %w", err)
+	}
+
+	// Load existing settings to preserve other options
+	s, err := LoadEntireSettings()
+	if err != nil {
+		s = &EntireSettings{}
+	}
+	s.Strategy = opts.Strategy
+	s.Enabled = true
+	s.LocalDev = opts.LocalDev
+	if opts.Retention != nil {
+		s.Retention = opts.Retention
+	}
+	if !opts.Telemetry || os.Getenv(telemetryOptOutEnv) != "" {
+		f := false
+		s.Telemetry = &f
+	}
+	saveSettings := func() error {
+		if opts.Local {
+			return SaveEntireSettingsLocal(s)
+		}
+		return SaveEntireSettings(s)
+	}
+	if err := saveSettings(); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	// Install git hooks AFTER saving settings (InstallGitHook reads local_dev from settings)
+	if _, err := strategy.InstallGitHook(true); err != nil {
+		return fmt.Errorf("failed to install git hooks: %w", err)
+	}
+
+	strat, err := strategy.Get(opts.Strategy)
+	if err != nil {
+		return fmt.Errorf("failed to get strategy: %w", err)
+	}
+	if err := strat.EnsureSetup(); err != nil {
+		return fmt.Errorf("failed to setup strategy: %w", err)
+	}
+
+	if interactive {
+		fmt.Fprintln(w)
+		if err := promptTelemetryConsent(s, opts.Telemetry); err != nil {
+			return fmt.Errorf("telemetry consent: %w", err)
+		}
+		if err := saveSettings(); err != nil {
+			return fmt.Errorf("failed to save settings: %w", err)
+		}
+	}
+
+	return printInitSummary(w, opts)
+}
+
+// initCheck is one line of the summary `entire init` ends with.
+type initCheck struct {
+	OK     bool
+	Detail string
+}
+
+// verifyInit checks that what runInit set up is in place.
+func verifyInit(opts initOptions) []initCheck {
+	var checks []initCheck
+	for _, name := range opts.Agents {
+		ag, err := agent.Get(name)
+		if err != nil {
+			checks = append(checks, initCheck{Detail: fmt.Sprintf("%s: unknown agent", name)})
+			continue
+		}
+		if hookAgent, ok := ag.(agent.HookSupport); ok {
+			installed := hookAgent.AreHooksInstalled()
+			detail := fmt.Sprintf("%s hooks installed", ag.Type())
+			if !installed {
+				detail = fmt.Sprintf("%s hooks missing (%s)", ag.Type(), ag.GetHookConfigPath())
+			}
+			checks = append(checks, initCheck{OK: installed, Detail: detail})
+			continue
+		}
+		checks = append(checks, initCheck{OK: true, Detail: fmt.Sprintf("%s captured by the git hooks", ag.Type())})
+	}
+
+	if strategy.IsGitHookInstalled() {
+		checks = append(checks, initCheck{OK: true, Detail: "Git hooks installed"})
+	} else {
+		checks = append(checks, initCheck{Detail: "Git hooks missing"})
+	}
+
+	configDisplay := configDisplayProject
+	if opts.Local {
+		configDisplay = configDisplayLocal
+	}
+	s, err := LoadEntireSettings()
+	switch {
+	case err != nil:
+		checks = append(checks, initCheck{Detail: fmt.Sprintf("Settings unreadable: %v", err)})
+	case !s.Enabled || s.Strategy != opts.Strategy:
+		checks = append(checks, initCheck{Detail: fmt.Sprintf("Settings in %s overridden (enabled %t, strategy %s)", configDisplay, s.Enabled, s.Strategy)})
+	default:
+		detail := fmt.Sprintf("Project configured (%s, strategy %s", configDisplay, s.Strategy)
+		if r := s.Retention; r.IsSet() {
+			var policy []string
+			if r.OlderThan != "" {
+				policy = append(policy, "older than "+r.OlderThan)
+			}
+			if r.KeepPerSession > 0 {
+				policy = append(policy, fmt.Sprintf("keep %d per session", r.KeepPerSession))
+			}
+			detail += ", retention " + strings.Join(policy, ", ")
+		}
+		checks = append(checks, initCheck{OK: true, Detail: detail + ")"})
+	}
+	return checks
+}
+
+// printInitSummary prints verifyInit's checks, failing if any did.
+func printInitSummary(w io.Writer, opts initOptions) error {
+	checks := verifyInit(opts)
+	fmt.Fprintln(w)
+	for _, c := range checks {
+		mark := "✓"
+		if !c.OK {
+			mark = "✗"
+		}
+		fmt.Fprintf(w, "%s %s\n", mark, c.Detail)
+	}
+	if slices.ContainsFunc(checks, func(c initCheck) bool { return !c.OK }) {
+		fmt.Fprintln(w, "\nSetup is incomplete. Run `entire doctor` or `entire init --force` to fix it.")
+		return NewSilentError(errors.New("init verification failed"))
+	}
+	fmt.Fprintln(w, "\nReady.")
+	return nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+// stubInitLookPath makes detectAgents find only the given binaries.
+func stubInitLookPath(t *testing.T, binaries ...string) {
+	t.Helper()
+	orig := initLookPath
+	initLookPath = func(file string) (string, error) {
+		if slices.Contains(binaries, file) {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { initLookPath = orig })
+}
+
+func TestDetectAgents(t *testing.T) {
+	setupTestRepo(t)
+	stubInitLookPath(t, "codex", "aider")
+	if err := os.MkdirAll(".gemini", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	detected := detectAgents()
+	got := make(map[string]detectedAgent, len(detected))
+	for _, d := range detected {
+		got[d.Display] = d
+	}
+	if d := got["Claude Code"]; d.Detected() {
+		t.Errorf("Claude Code detected = %+v, want not found", d)
+	}
+	if d := got["Codex"]; !d.OnPath || d.Supported() {
+		t.Errorf("Codex = %+v, want installed but unsupported", d)
+	}
+	if d := got["Gemini CLI"]; !d.InRepo || d.OnPath {
+		t.Errorf("Gemini CLI = %+v, want configured in the repository only", d)
+	}
+	if d := got["Aider"]; !d.OnPath {
+		t.Errorf("Aider = %+v, want installed", d)
+	}
+
+	// Codex is reported but never picked
+	want := []agent.AgentName{agent.AgentNameGemini, agent.AgentNameAider}
+	if names := defaultInitAgents(detected); !slices.Equal(names, want) {
+		t.Errorf("defaultInitAgents() = %v, want %v", names, want)
+	}
+	var out bytes.Buffer
+	printDetectedAgents(&out, detected)
+	if !strings.Contains(out.String(), "Codex: installed, not supported by Entire yet") {
+		t.Errorf("printDetectedAgents() = %q, want Codex flagged as unsupported", out.String())
+	}
+}
+
+func TestDefaultInitAgents_NoneDetected(t *testing.T) {
+	t.Parallel()
+	detected := []detectedAgent{{initAgentCandidate: initAgentCandidate{Display: "Codex", Binary: "codex"}, OnPath: true}}
+	if names := defaultInitAgents(detected); !slices.Equal(names, []agent.AgentName{agent.DefaultAgentName}) {
+		t.Errorf("defaultInitAgents() = %v, want the default agent", names)
+	}
+}
+
+func TestValidateInitOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		opts    initOptions
+		wantErr string
+	}{
+		{"valid", initOptions{Agents: []agent.AgentName{agent.AgentNameClaudeCode, agent.AgentNameGemini}, Strategy: strategy.StrategyNameAutoCommit}, ""},
+		{"no agents", initOptions{Strategy: strategy.StrategyNameManualCommit}, "no agent"},
+		{"unknown agent", initOptions{Agents: []agent.AgentName{"codex"}, Strategy: strategy.StrategyNameManualCommit}, `unknown agent "codex"`},
+		{"aider with auto-commit", initOptions{Agents: []agent.AgentName{agent.AgentNameAider}, Strategy: strategy.StrategyNameAutoCommit}, "requires the manual-commit strategy"},
+		{"unknown strategy", initOptions{Agents: []agent.AgentName{agent.AgentNameClaudeCode}, Strategy: "nightly"}, "unknown strategy"},
+		{"bad retention", initOptions{Agents: []agent.AgentName{agent.AgentNameClaudeCode}, Strategy: strategy.StrategyNameManualCommit, Retention: &settings.RetentionSettings{OlderThan: "soon"}}, "older_than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateInitOptions(tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateInitOptions() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateInitOptions() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunInit_MultipleAgents(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, `{"strategy_options": {"push_sessions": false}}`)
+
+	var out bytes.Buffer
+	err := runInit(&out, initOptions{
+		Agents:    []agent.AgentName{agent.AgentNameClaudeCode, agent.AgentNameGemini},
+		Strategy:  strategyDisplayAutoCommit,
+		Retention: &settings.RetentionSettings{OlderThan: "30d"},
+	}, false)
+	if err != nil {
+		t.Fatalf("runInit() error = %v\n%s", err, out.String())
+	}
+
+	for _, path := range []string{".claude/settings.json", ".gemini/settings.json"} {
+		if _, err := os.Stat(filepath.FromSlash(path)); err != nil {
+			t.Errorf("%s not written: %v", path, err)
+		}
+	}
+	s, err := LoadEntireSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled || s.Strategy != strategy.StrategyNameAutoCommit || s.Retention == nil || s.Retention.OlderThan != "30d" {
+		t.Errorf("settings = enabled %t, strategy %q, retention %+v", s.Enabled, s.Strategy, s.Retention)
+	}
+	if s.StrategyOptions["push_sessions"] != false {
+		t.Errorf("strategy_options not preserved: %v", s.StrategyOptions)
+	}
+	// --telemetry=false wasn't given, but without asking nothing is recorded
+	// as consent
+	if s.Telemetry != nil && *s.Telemetry {
+		t.Errorf("telemetry = %v, want not opted in", *s.Telemetry)
+	}
+
+	for _, want := range []string{
+		"✓ Claude Code hooks installed",
+		"✓ Gemini CLI hooks installed",
+		"✓ Git hooks installed",
+		"✓ Project configured (.entire/settings.json, strategy auto-commit, retention older than 30d)",
+		"Ready.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunInit_ReportsOverriddenSettings(t *testing.T) {
+	setupTestRepo(t)
+	localPath := filepath.Join(filepath.Dir(EntireSettingsFile), "settings.local.json")
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(localPath, []byte(`{"enabled": false}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := runInit(&out, initOptions{Agents: []agent.AgentName{agent.AgentNameClaudeCode}}, false)
+	var silent *SilentError
+	if !errors.As(err, &silent) {
+		t.Fatalf("runInit() error = %v, want a failed verification", err)
+	}
+	if !strings.Contains(out.String(), "✗ Settings in .entire/settings.json overridden (enabled false") {
+		t.Errorf("summary = %q, want the local override reported", out.String())
+	}
+}
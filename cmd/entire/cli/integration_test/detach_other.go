@@ -0,0 +1,9 @@
+//go:build integration && !unix
+
+package integration
+
+import "os/exec"
+
+// detachFromTerminal is a no-op on non-Unix platforms, which have no
+// /dev/tty for huh to open.
+func detachFromTerminal(*exec.Cmd) {}
@@ -0,0 +1,14 @@
+//go:build integration && unix
+
+package integration
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachFromTerminal starts cmd in a new session, without a controlling
+// terminal, so huh can't open /dev/tty for interactive prompts.
+func detachFromTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
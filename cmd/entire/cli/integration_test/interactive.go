@@ -4,6 +4,7 @@ package integration
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +31,9 @@ func (env *TestEnv) RunCommandInteractive(args []string, respond func(ptyFile *o
 
 	// Start command with a pty
 	ptmx, err := pty.Start(cmd)
+	if errors.Is(err, pty.ErrUnsupported) {
+		env.T.Skip("ptys aren't supported on this platform")
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to start pty: %w", err)
 	}
@@ -101,7 +101,8 @@ func TestShadow_UntrackedFilePreservation(t *testing.T) {
 
 	// Verify session state has captured untracked files
 	sessionStateDir := filepath.Join(env.RepoDir, ".git", "entire-sessions")
-	stateFiles, err := os.ReadDir(sessionStateDir)
+	// The directory also holds the state lock and checkpoint intents
+	stateFiles, err := filepath.Glob(filepath.Join(sessionStateDir, "*.json"))
 	if err != nil {
 		t.Fatalf("Failed to read session state dir: %v", err)
 	}
@@ -109,7 +110,7 @@ func TestShadow_UntrackedFilePreservation(t *testing.T) {
 		t.Fatal("Expected session state file")
 	}
 
-	stateFile := filepath.Join(sessionStateDir, stateFiles[0].Name())
+	stateFile := stateFiles[0]
 	stateData, err := os.ReadFile(stateFile)
 	if err != nil {
 		t.Fatalf("Failed to read session state file: %v", err)
@@ -8,7 +8,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"testing"
 	"time"
 
@@ -473,8 +472,8 @@ func TestResume_AfterMergingMain(t *testing.T) {
 }
 
 // RunResume executes the resume command and returns the combined output.
-// The subprocess is detached from the controlling terminal (via Setsid) to prevent
-// interactive prompts from hanging tests. This simulates non-interactive environments like CI.
+// The subprocess is detached from the controlling terminal (see
+// detachFromTerminal) to prevent interactive prompts from hanging tests. This simulates non-interactive environments like CI.
 func (env *TestEnv) RunResume(branchName string) (string, error) {
 	env.T.Helper()
 
@@ -484,8 +483,7 @@ func (env *TestEnv) RunResume(branchName string) (string, error) {
 	cmd.Env = append(os.Environ(),
 		"ENTIRE_TEST_CLAUDE_PROJECT_DIR="+env.ClaudeProjectDir,
 	)
-	// Detach from controlling terminal so huh can't open /dev/tty for interactive prompts
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	detachFromTerminal(cmd)
 
 	output, err := cmd.CombinedOutput()
 	return string(output), err
@@ -0,0 +1,90 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestSelftest runs `entire selftest` against the test binary: every stage of
+// the simulated session should pass and the temporary repository is removed.
+func TestSelftest(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command(getTestBinary(), "selftest", "--json")
+	cmd.Dir = t.TempDir()
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("selftest failed: %v\nOutput: %s", err, output)
+	}
+
+	var result struct {
+		Passed       bool   `json:"passed"`
+		ArtifactsDir string `json:"artifacts_dir"`
+		Stages       []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Detail string `json:"detail"`
+		} `json:"stages"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("failed to parse selftest output: %v\nOutput: %s", err, output)
+	}
+
+	if !result.Passed {
+		t.Errorf("selftest did not pass: %s", output)
+	}
+	if len(result.Stages) != 7 {
+		t.Errorf("got %d stages, want 7", len(result.Stages))
+	}
+	for _, stage := range result.Stages {
+		if stage.Status != "pass" {
+			t.Errorf("stage %s: status %s (%s)", stage.Name, stage.Status, stage.Detail)
+		}
+	}
+	if result.ArtifactsDir != "" {
+		t.Errorf("artifacts_dir = %q, want empty after a passing run", result.ArtifactsDir)
+		_ = os.RemoveAll(result.ArtifactsDir)
+	}
+}
+
+// TestSelftest_Chaos runs `entire selftest --chaos`: hooks run under injected
+// ref-write failures, truncated state writes and slow git storage, and the
+// session afterwards must still checkpoint and attribute correctly.
+func TestSelftest_Chaos(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command(getTestBinary(), "selftest", "--chaos", "--json")
+	cmd.Dir = t.TempDir()
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("selftest --chaos failed: %v\nOutput: %s", err, output)
+	}
+
+	var result struct {
+		Passed bool `json:"passed"`
+		Stages []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Detail string `json:"detail"`
+		} `json:"stages"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("failed to parse selftest output: %v\nOutput: %s", err, output)
+	}
+
+	if !result.Passed {
+		t.Errorf("selftest --chaos did not pass: %s", output)
+	}
+	if len(result.Stages) != 9 {
+		t.Errorf("got %d stages, want 9", len(result.Stages))
+	}
+	for _, stage := range result.Stages {
+		if stage.Status != "pass" {
+			t.Errorf("stage %s: status %s (%s)", stage.Name, stage.Status, stage.Detail)
+		}
+	}
+}
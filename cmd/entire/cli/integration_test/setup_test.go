@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"testing"
 )
 
@@ -20,6 +21,9 @@ func TestMain(m *testing.M) {
 	}
 
 	testBinaryPath = filepath.Join(tmpDir, "entire")
+	if runtime.GOOS == "windows" {
+		testBinaryPath += ".exe"
+	}
 
 	moduleRoot := findModuleRoot()
 	buildCmd := exec.Command("go", "build", "-o", testBinaryPath, ".")
@@ -32,6 +36,10 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
+	// Hooks run as child processes; keep session start from spawning
+	// background warm-ups that outlive the test's temp directories
+	os.Setenv("ENTIRE_NO_WARMUP", "1")
+
 	// Run tests
 	code := m.Run()
 
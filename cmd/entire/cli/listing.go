@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+
+	"github.com/spf13/cobra"
+)
+
+// Sort orders of list commands.
+const (
+	listSortNewest = "newest"
+	listSortOldest = "oldest"
+)
+
+// listOptions are the pagination, filter and sort flags list commands share.
+type listOptions struct {
+	// Limit is the page size; 0 lists everything.
+	Limit int
+	// Cursor continues after the last item of a previous page.
+	Cursor string
+	Agent  string
+	Branch string
+	// Tag only lists sessions with this tag (sessions list only).
+	Tag    string
+	Period reportPeriod
+	Sort   string
+}
+
+// listFlags holds the raw flag values of addListFlags until resolve.
+type listFlags struct {
+	opts  listOptions
+	since string
+	until string
+}
+
+// addListFlags registers --limit, --cursor, --agent, --sort (defaulting to
+// defaultSort), --since and --until, and --branch if withBranch.
+func addListFlags(cmd *cobra.Command, f *listFlags, defaultSort string, withBranch bool) {
+	cmd.Flags().IntVar(&f.opts.Limit, "limit", 0, "Show at most this many items (0 = all); prints a cursor for the next page")
+	cmd.Flags().StringVar(&f.opts.Cursor, "cursor", "", "Continue after the last item of a previous page")
+	cmd.Flags().StringVar(&f.opts.Agent, "agent", "", "Only show items of this agent (e.g. claude-code)")
+	if withBranch {
+		cmd.Flags().StringVar(&f.opts.Branch, "branch", "", "Only show items of this branch")
+	}
+	cmd.Flags().StringVar(&f.opts.Sort, "sort", defaultSort, "Sort order: newest or oldest")
+	addReportPeriodFlags(cmd, &f.since, &f.until)
+}
+
+// resolve validates the flags and parses --since/--until.
+func (f *listFlags) resolve(now time.Time) (listOptions, error) {
+	opts := f.opts
+	if opts.Limit < 0 {
+		return opts, errors.New("--limit must not be negative")
+	}
+	if opts.Sort != listSortNewest && opts.Sort != listSortOldest {
+		return opts, fmt.Errorf("invalid --sort %q: use newest or oldest", opts.Sort)
+	}
+	if opts.Cursor != "" {
+		if _, err := decodeListCursor(opts.Cursor); err != nil {
+			return opts, err
+		}
+	}
+	period, err := resolveReportPeriod(f.since, f.until, now)
+	if err != nil {
+		return opts, err
+	}
+	opts.Period = period
+	return opts, nil
+}
+
+// matchesAgent reports whether an item of agentType passes the --agent
+// filter, which takes the agent's name ("claude-code") or its type
+// ("Claude Code").
+func (o listOptions) matchesAgent(agentType string) bool {
+	if o.Agent == "" || strings.EqualFold(o.Agent, agentType) {
+		return true
+	}
+	ag, err := agent.Get(agent.AgentName(strings.ToLower(o.Agent)))
+	return err == nil && string(ag.Type()) == agentType
+}
+
+// listKey is an item's position in a list: its time, with its ID breaking ties.
+type listKey struct {
+	Time time.Time
+	ID   string
+}
+
+// before reports whether k sorts before other in order.
+func (k listKey) before(other listKey, order string) bool {
+	if !k.Time.Equal(other.Time) {
+		if order == listSortOldest {
+			return k.Time.Before(other.Time)
+		}
+		return k.Time.After(other.Time)
+	}
+	return k.ID < other.ID
+}
+
+// encodeListCursor makes an opaque cursor pointing just after k.
+func encodeListCursor(k listKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(k.Time.UTC().Format(time.RFC3339Nano) + "|" + k.ID))
+}
+
+func decodeListCursor(cursor string) (listKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return listKey{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	timestamp, itemID, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return listKey{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return listKey{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return listKey{Time: t, ID: itemID}, nil
+}
+
+// pageList sorts items by key in opts.Sort order and returns the page after
+// opts.Cursor, with the cursor of the next page ("" on the last page). Items
+// are filtered before paging, so a cursor stays valid as items are added.
+func pageList[T any](items []T, key func(T) listKey, opts listOptions) ([]T, string, error) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return key(items[i]).before(key(items[j]), opts.Sort)
+	})
+	start := 0
+	if opts.Cursor != "" {
+		after, err := decodeListCursor(opts.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			return after.before(key(items[i]), opts.Sort)
+		})
+	}
+	items = items[start:]
+	if opts.Limit == 0 || len(items) <= opts.Limit {
+		return items, "", nil
+	}
+	page := items[:opts.Limit]
+	return page, encodeListCursor(key(page[len(page)-1])), nil
+}
+
+// writeNextCursor tells the user how to get the next page. It goes to errW
+// so --json output stays a plain array.
+func writeNextCursor(errW io.Writer, cursor string) {
+	if cursor == "" {
+		return
+	}
+	fmt.Fprintf(errW, "More results: rerun with --cursor %s\n", cursor)
+}
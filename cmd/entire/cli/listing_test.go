@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPageList_Cursor(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	// Two items share a timestamp; the ID breaks the tie
+	items := []listKey{
+		{Time: base, ID: "a"},
+		{Time: base.Add(2 * time.Hour), ID: "c"},
+		{Time: base.Add(time.Hour), ID: "b2"},
+		{Time: base.Add(time.Hour), ID: "b1"},
+	}
+	key := func(k listKey) listKey { return k }
+
+	var got []string
+	opts := listOptions{Limit: 2, Sort: listSortNewest}
+	for range 3 {
+		page, next, err := pageList(slices.Clone(items), key, opts)
+		if err != nil {
+			t.Fatalf("pageList() error = %v", err)
+		}
+		for _, k := range page {
+			got = append(got, k.ID)
+		}
+		if next == "" {
+			break
+		}
+		opts.Cursor = next
+	}
+	if want := []string{"c", "b1", "b2", "a"}; !slices.Equal(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+
+	page, next, err := pageList(slices.Clone(items), key, listOptions{Sort: listSortOldest})
+	if err != nil || next != "" || page[0].ID != "a" || page[3].ID != "c" {
+		t.Errorf("oldest first = %v (next %q, err %v), want a..c in one page", page, next, err)
+	}
+}
+
+func TestPageList_CursorSurvivesNewItems(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	items := []listKey{{Time: base, ID: "old"}, {Time: base.Add(time.Hour), ID: "new"}}
+	key := func(k listKey) listKey { return k }
+	_, next, err := pageList(slices.Clone(items), key, listOptions{Limit: 1, Sort: listSortNewest})
+	if err != nil || next == "" {
+		t.Fatalf("first page: next %q, err %v", next, err)
+	}
+
+	// A newer item arriving doesn't shift the next page
+	items = append(items, listKey{Time: base.Add(2 * time.Hour), ID: "newest"})
+	page, _, err := pageList(items, key, listOptions{Limit: 1, Cursor: next, Sort: listSortNewest})
+	if err != nil || len(page) != 1 || page[0].ID != "old" {
+		t.Errorf("second page = %v (err %v), want [old]", page, err)
+	}
+}
+
+func TestListFlags_Resolve(t *testing.T) {
+	setupCleanTestRepo(t)
+
+	for _, f := range []listFlags{
+		{opts: listOptions{Limit: -1, Sort: listSortNewest}},
+		{opts: listOptions{Sort: "random"}},
+		{opts: listOptions{Cursor: "not a cursor!", Sort: listSortNewest}},
+		{opts: listOptions{Sort: listSortNewest}, since: "someday"},
+	} {
+		if _, err := f.resolve(time.Now()); err == nil {
+			t.Errorf("resolve(%+v) = nil error, want an error", f)
+		}
+	}
+	opts, err := (&listFlags{opts: listOptions{Limit: 5, Sort: listSortOldest}, since: "2w"}).resolve(time.Now())
+	if err != nil || opts.Limit != 5 || opts.Period.Since.IsZero() {
+		t.Errorf("resolve() = %+v, %v; want limit 5 and a since bound", opts, err)
+	}
+}
@@ -60,8 +60,16 @@ var (
 	// logLevelGetter is an optional callback to get log level from settings.
 	// Set by SetLogLevelGetter before Init is called.
 	logLevelGetter func() string
+
+	// levelOverride and fileOverride come from the --log-level and --log-file
+	// flags. Set by SetOverrides before Init is called.
+	levelOverride string
+	fileOverride  string
 )
 
+// StderrLogFile is the --log-file value that writes logs to stderr.
+const StderrLogFile = "-"
+
 // SetLogLevelGetter sets a callback function to get the log level from settings.
 // This allows the logging package to read settings without a circular dependency.
 // The callback is only used if ENTIRE_LOG_LEVEL env var is not set.
@@ -71,12 +79,24 @@ func SetLogLevelGetter(getter func() string) {
 	logLevelGetter = getter
 }
 
+// SetOverrides sets the log level and log file given on the command line.
+// A non-empty level takes precedence over ENTIRE_LOG_LEVEL and settings; a
+// non-empty file replaces .entire/logs/entire.log, with StderrLogFile
+// meaning stderr.
+func SetOverrides(level, file string) {
+	mu.Lock()
+	defer mu.Unlock()
+	levelOverride = level
+	fileOverride = file
+}
+
 // Init initializes the logger for a session, writing JSON logs to
-// .entire/logs/entire.log.
+// .entire/logs/entire.log, or the file set by SetOverrides.
 //
 // If sessionID is non-empty, it is stored as an slog attribute on every log line for filtering.
 // If the log file cannot be created, falls back to stderr.
-// Log level is controlled by ENTIRE_LOG_LEVEL environment variable.
+// Log level is controlled by SetOverrides, then the ENTIRE_LOG_LEVEL
+// environment variable, then settings.
 func Init(sessionID string) error {
 	// Validate session ID if provided (used only for the slog attribute, not the filename)
 	if sessionID != "" {
@@ -98,8 +118,11 @@ func Init(sessionID string) error {
 		logFile = nil
 	}
 
-	// Get log level from environment first, then settings
-	levelStr := os.Getenv(LogLevelEnvVar)
+	// Get log level from the flag first, then environment, then settings
+	levelStr := levelOverride
+	if levelStr == "" {
+		levelStr = os.Getenv(LogLevelEnvVar)
+	}
 	if levelStr == "" && logLevelGetter != nil {
 		levelStr = logLevelGetter()
 	}
@@ -110,22 +133,30 @@ func Init(sessionID string) error {
 		fmt.Fprintf(os.Stderr, "[entire] Warning: invalid log level %q, defaulting to INFO\n", levelStr)
 	}
 
+	currentSessionID = sessionID
+	if fileOverride == StderrLogFile {
+		logger = createLogger(os.Stderr, level)
+		return nil
+	}
+
 	// Determine log file path
-	repoRoot, err := paths.RepoRoot()
-	if err != nil {
-		// Fall back to current directory
-		repoRoot = "."
+	logFilePath := fileOverride
+	if logFilePath == "" {
+		repoRoot, err := paths.RepoRoot()
+		if err != nil {
+			// Fall back to current directory
+			repoRoot = "."
+		}
+		logFilePath = filepath.Join(repoRoot, LogsDir, "entire.log")
 	}
 
-	logsPath := filepath.Join(repoRoot, LogsDir)
-	if err := os.MkdirAll(logsPath, 0o750); err != nil {
+	if err := os.MkdirAll(filepath.Dir(logFilePath), 0o750); err != nil {
 		// Fall back to stderr
 		logger = createLogger(os.Stderr, level)
 		return nil
 	}
 
-	logFilePath := filepath.Join(logsPath, "entire.log")
-	f, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec // fixed filename, not user-controlled
+	f, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec // default filename is fixed; --log-file is the user's own choice
 	if err != nil {
 		// Fall back to stderr
 		logger = createLogger(os.Stderr, level)
@@ -135,7 +166,6 @@ func Init(sessionID string) error {
 	logFile = f
 	logBufWriter = bufio.NewWriterSize(f, 8192) // 8KB buffer for batched writes
 	logger = createLogger(logBufWriter, level)
-	currentSessionID = sessionID
 
 	return nil
 }
@@ -164,6 +194,8 @@ func resetLogger() {
 	defer mu.Unlock()
 	logger = nil
 	currentSessionID = ""
+	levelOverride = ""
+	fileOverride = ""
 	if logBufWriter != nil {
 		_ = logBufWriter.Flush()
 		logBufWriter = nil
@@ -180,6 +180,36 @@ func TestInit_RespectsLogLevel(t *testing.T) {
 	}
 }
 
+func TestInit_Overrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+
+	initGitRepo(t, tmpDir)
+
+	// The flag level wins over the environment
+	t.Setenv(LogLevelEnvVar, "ERROR")
+	logPath := filepath.Join(tmpDir, "debug", "hooks.log")
+	SetOverrides("debug", logPath)
+	t.Cleanup(resetLogger)
+
+	if err := Init(testSessionID); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	Debug(context.Background(), "debug message")
+	Close()
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(content), "debug message") {
+		t.Errorf("DEBUG message should be logged with --log-level debug, got: %s", content)
+	}
+	if _, err := os.Stat(testLogFilePath(tmpDir)); !os.IsNotExist(err) {
+		t.Errorf("default log file should not be created with --log-file, stat err = %v", err)
+	}
+}
+
 func TestInit_InvalidLogLevelWarns(t *testing.T) {
 	tmpDir := t.TempDir()
 	t.Chdir(tmpDir)
@@ -0,0 +1,353 @@
+// Package mcp implements a Model Context Protocol server over stdio, so agents
+// can call Entire as a set of tools and resources during a session.
+//
+// Only the parts of the protocol Entire needs are implemented: tools and
+// resources, without subscriptions, prompts or sampling. Messages are
+// newline-delimited JSON-RPC 2.0, as the stdio transport specifies.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProtocolVersion is the MCP revision this server implements.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the protocol revisions the server accepts from clients.
+// Tools and resources are unchanged across them.
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	// codeResourceNotFound is the MCP error for reading an unknown resource.
+	codeResourceNotFound = -32002
+)
+
+// maxMessageSize bounds a single request line.
+const maxMessageSize = 10 * 1024 * 1024
+
+// ToolHandler runs a tool with its JSON arguments. The result is returned to
+// the client as JSON text; an error is returned as a tool error the model can read.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Tool is a function the client can call.
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema is the JSON Schema of the arguments object.
+	InputSchema map[string]any
+	Handler     ToolHandler
+}
+
+// Resource is a document the client can read.
+type Resource struct {
+	URI         string
+	Name        string
+	Description string
+	MimeType    string
+	Read        func(ctx context.Context) (string, error)
+}
+
+// ResourceTemplate describes a family of resources, such as one per checkpoint.
+type ResourceTemplate struct {
+	URITemplate string
+	Name        string
+	Description string
+	MimeType    string
+	// Read returns the content of uri, and false if uri isn't one of the
+	// template's resources.
+	Read func(ctx context.Context, uri string) (string, bool, error)
+}
+
+// Server is an MCP server. Register tools and resources, then call Serve.
+type Server struct {
+	name      string
+	version   string
+	tools     []Tool
+	resources []Resource
+	templates []ResourceTemplate
+
+	writeMu sync.Mutex
+}
+
+// NewServer creates a server that introduces itself to clients as name and version.
+func NewServer(name, version string) *Server {
+	return &Server{name: name, version: version}
+}
+
+// AddTool registers a tool.
+func (s *Server) AddTool(t Tool) {
+	s.tools = append(s.tools, t)
+}
+
+// AddResource registers a resource.
+func (s *Server) AddResource(r Resource) {
+	s.resources = append(s.resources, r)
+}
+
+// AddResourceTemplate registers a resource template.
+func (s *Server) AddResourceTemplate(t ResourceTemplate) {
+	s.templates = append(s.templates, t)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads requests from r and writes responses to w until r is exhausted
+// or ctx is done. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err //nolint:wrapcheck // context errors are returned as is
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.handleMessage(ctx, line); resp != nil {
+			if err := s.write(w, resp); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handleMessage handles one JSON-RPC message. Returns nil for notifications.
+func (s *Server) handleMessage(ctx context.Context, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}}
+	}
+	isNotification := len(req.ID) == 0
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if isNotification {
+			return nil
+		}
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}}
+	}
+
+	result, err := s.dispatch(ctx, req.Method, req.Params)
+	if isNotification {
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Result = nil
+		resp.Error = rpcErr
+	}
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "notifications/initialized", "notifications/cancelled":
+		return struct{}{}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(ctx, params)
+	case "resources/list":
+		return s.listResources(), nil
+	case "resources/templates/list":
+		return s.listResourceTemplates(), nil
+	case "resources/read":
+		return s.readResource(ctx, params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid initialize params: " + err.Error()}
+		}
+	}
+	// Answer with the client's version if we speak it, otherwise with ours
+	// and let the client decide whether to continue
+	version := ProtocolVersion
+	for _, v := range supportedVersions {
+		if v == p.ProtocolVersion {
+			version = v
+		}
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+		},
+		"serverInfo": map[string]any{"name": s.name, "version": s.version},
+	}, nil
+}
+
+func (s *Server) listTools() any {
+	tools := make([]map[string]any, 0, len(s.tools))
+	for _, t := range s.tools {
+		schema := t.InputSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		tools = append(tools, map[string]any{
+			"name":        t.Name,
+			"description": t.Description,
+			"inputSchema": schema,
+		})
+	}
+	return map[string]any{"tools": tools}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params: " + err.Error()}
+	}
+	for _, t := range s.tools {
+		if t.Name != p.Name {
+			continue
+		}
+		args := p.Arguments
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+		result, err := t.Handler(ctx, args)
+		if err != nil {
+			// Tool failures are results the model can see and react to
+			return map[string]any{
+				"content": []map[string]any{{"type": "text", "text": err.Error()}},
+				"isError": true,
+			}, nil
+		}
+		text, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s result: %w", t.Name, err)
+		}
+		return map[string]any{
+			"content": []map[string]any{{"type": "text", "text": string(text)}},
+			"isError": false,
+		}, nil
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+}
+
+func (s *Server) listResources() any {
+	resources := make([]map[string]any, 0, len(s.resources))
+	for _, r := range s.resources {
+		resources = append(resources, map[string]any{
+			"uri":         r.URI,
+			"name":        r.Name,
+			"description": r.Description,
+			"mimeType":    r.MimeType,
+		})
+	}
+	return map[string]any{"resources": resources}
+}
+
+func (s *Server) listResourceTemplates() any {
+	templates := make([]map[string]any, 0, len(s.templates))
+	for _, t := range s.templates {
+		templates = append(templates, map[string]any{
+			"uriTemplate": t.URITemplate,
+			"name":        t.Name,
+			"description": t.Description,
+			"mimeType":    t.MimeType,
+		})
+	}
+	return map[string]any{"resourceTemplates": templates}
+}
+
+func (s *Server) readResource(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid resources/read params: " + err.Error()}
+	}
+
+	contents := func(text, mimeType string) any {
+		return map[string]any{"contents": []map[string]any{{"uri": p.URI, "mimeType": mimeType, "text": text}}}
+	}
+	for _, r := range s.resources {
+		if r.URI == p.URI {
+			text, err := r.Read(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return contents(text, r.MimeType), nil
+		}
+	}
+	for _, t := range s.templates {
+		text, ok, err := t.Read(ctx, p.URI)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return contents(text, t.MimeType), nil
+		}
+	}
+	return nil, &rpcError{Code: codeResourceNotFound, Message: "resource not found: " + p.URI}
+}
+
+func (s *Server) write(w io.Writer, resp *response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// serve runs s on the given request lines and returns the decoded responses.
+func serve(t *testing.T, s *Server, lines ...string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	var responses []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response %q is not JSON: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func testServer() *Server {
+	s := NewServer("test", "1.0.0")
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echoes its arguments",
+		Handler: func(_ context.Context, args json.RawMessage) (any, error) {
+			var v map[string]any
+			if err := json.Unmarshal(args, &v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		},
+	})
+	s.AddTool(Tool{
+		Name: "fail",
+		Handler: func(_ context.Context, _ json.RawMessage) (any, error) {
+			return nil, errors.New("tool failed")
+		},
+	})
+	s.AddResource(Resource{
+		URI:      "test://status",
+		Name:     "Status",
+		MimeType: "text/plain",
+		Read:     func(_ context.Context) (string, error) { return "ok", nil },
+	})
+	s.AddResourceTemplate(ResourceTemplate{
+		URITemplate: "test://items/{id}",
+		Name:        "Item",
+		MimeType:    "text/plain",
+		Read: func(_ context.Context, uri string) (string, bool, error) {
+			id, ok := strings.CutPrefix(uri, "test://items/")
+			return "item " + id, ok, nil
+		},
+	})
+	return s
+}
+
+func TestServe_Initialize(t *testing.T) {
+	t.Parallel()
+	responses := serve(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"c","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+	)
+	if len(responses) != 2 {
+		t.Fatalf("got %d responses, want 2 (notifications get none): %v", len(responses), responses)
+	}
+
+	result, ok := responses[0]["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("initialize result = %v", responses[0])
+	}
+	if result["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's supported version", result["protocolVersion"])
+	}
+	if info := result["serverInfo"].(map[string]any); info["name"] != "test" || info["version"] != "1.0.0" {
+		t.Errorf("serverInfo = %v", info)
+	}
+
+	result, ok = responses[1]["result"].(map[string]any)
+	if !ok || result["protocolVersion"] != ProtocolVersion {
+		t.Errorf("unsupported client version: result = %v, want protocolVersion %s", responses[1], ProtocolVersion)
+	}
+}
+
+func TestServe_Tools(t *testing.T) {
+	t.Parallel()
+	responses := serve(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"a":1}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fail"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"missing"}}`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("got %d responses, want 4", len(responses))
+	}
+
+	tools := responses[0]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != 2 {
+		t.Fatalf("tools/list returned %d tools, want 2", len(tools))
+	}
+	if schema := tools[0].(map[string]any)["inputSchema"].(map[string]any); schema["type"] != "object" {
+		t.Errorf("default inputSchema = %v, want an object schema", schema)
+	}
+
+	echo := responses[1]["result"].(map[string]any)
+	if echo["isError"] != false {
+		t.Errorf("echo isError = %v", echo["isError"])
+	}
+	text := echo["content"].([]any)[0].(map[string]any)["text"].(string)
+	var echoed map[string]any
+	if err := json.Unmarshal([]byte(text), &echoed); err != nil || echoed["a"] != float64(1) {
+		t.Errorf("echo text = %q, want the arguments as JSON", text)
+	}
+
+	// Tool failures are results, so the model sees them
+	failed := responses[2]["result"].(map[string]any)
+	if failed["isError"] != true || failed["content"].([]any)[0].(map[string]any)["text"] != "tool failed" {
+		t.Errorf("failing tool result = %v", failed)
+	}
+
+	if code := responses[3]["error"].(map[string]any)["code"]; code != float64(codeInvalidParams) {
+		t.Errorf("unknown tool error code = %v, want %d", code, codeInvalidParams)
+	}
+}
+
+func TestServe_Resources(t *testing.T) {
+	t.Parallel()
+	responses := serve(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/templates/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"test://status"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"test://items/42"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"test://nope"}}`,
+	)
+	if len(responses) != 5 {
+		t.Fatalf("got %d responses, want 5", len(responses))
+	}
+
+	if resources := responses[0]["result"].(map[string]any)["resources"].([]any); len(resources) != 1 {
+		t.Errorf("resources/list = %v", resources)
+	}
+	if templates := responses[1]["result"].(map[string]any)["resourceTemplates"].([]any); len(templates) != 1 {
+		t.Errorf("resources/templates/list = %v", templates)
+	}
+
+	readText := func(resp map[string]any) string {
+		contents := resp["result"].(map[string]any)["contents"].([]any)
+		return contents[0].(map[string]any)["text"].(string)
+	}
+	if got := readText(responses[2]); got != "ok" {
+		t.Errorf("read status = %q, want ok", got)
+	}
+	if got := readText(responses[3]); got != "item 42" {
+		t.Errorf("read template = %q, want %q", got, "item 42")
+	}
+	if code := responses[4]["error"].(map[string]any)["code"]; code != float64(codeResourceNotFound) {
+		t.Errorf("unknown resource error code = %v, want %d", code, codeResourceNotFound)
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	t.Parallel()
+	responses := serve(t, testServer(),
+		`not json`,
+		`{"jsonrpc":"2.0","id":"a","method":"bogus"}`,
+		`{"jsonrpc":"1.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("got %d responses, want 4", len(responses))
+	}
+
+	wantCodes := []int{codeParseError, codeMethodNotFound, codeInvalidRequest}
+	for i, want := range wantCodes {
+		rpcErr, ok := responses[i]["error"].(map[string]any)
+		if !ok || rpcErr["code"] != float64(want) {
+			t.Errorf("response %d = %v, want error code %d", i, responses[i], want)
+		}
+	}
+	if responses[1]["id"] != "a" {
+		t.Errorf("error response id = %v, want the request's id", responses[1]["id"])
+	}
+	if _, ok := responses[3]["result"]; !ok {
+		t.Errorf("ping response = %v, want a result", responses[3])
+	}
+}
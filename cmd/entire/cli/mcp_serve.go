@@ -0,0 +1,546 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/mcp"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+// stdioProtocolAnnotation marks commands whose stdout carries a protocol, so
+// nothing else (like the version check notice) may be printed to it.
+const stdioProtocolAnnotation = "entire.io/stdio-protocol"
+
+// mcpCheckpointURIPrefix prefixes the URIs of checkpoint resources.
+const mcpCheckpointURIPrefix = "entire://checkpoints/"
+
+// Default and maximum number of entries list tools return.
+const (
+	mcpDefaultListLimit = 20
+	mcpMaxListLimit     = 200
+)
+
+func newMCPCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Expose Entire to agents over the Model Context Protocol",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newMCPServeCmd())
+
+	return cmd
+}
+
+func newMCPServeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run an MCP server on stdin/stdout",
+		Long: `Runs a Model Context Protocol server on stdin/stdout, so an agent can query
+this repository's checkpoint history, current attribution and restore points
+during a session.
+
+Tools:
+  list_checkpoints     Committed checkpoints, newest first
+  get_checkpoint       Metadata, prompts, summary and attribution of a checkpoint
+  session_status       Sessions in progress and what they have touched
+  current_attribution  Attribution the next commit would record
+  list_restore_points  Points 'entire rewind' can restore to
+  entire_note          Record a note (assumption, caveat, TODO, question) on
+                       the session, attached to the next commit's checkpoint
+
+Resources:
+  entire://status                       Sessions in progress
+  entire://checkpoints/{checkpoint_id}  A committed checkpoint
+
+Apart from entire_note, which adds to the session state, the server is
+read-only: it never creates checkpoints or rewinds.
+
+Register it with Claude Code:
+  claude mcp add entire -- entire mcp serve`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{stdioProtocolAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// stdout is reserved for the protocol
+			if checkDisabledGuard(cmd.ErrOrStderr()) {
+				return nil
+			}
+			return runMCPServe(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+}
+
+func runMCPServe(ctx context.Context, r io.Reader, w io.Writer) error {
+	if err := newMCPServer().Serve(ctx, r, w); err != nil {
+		return fmt.Errorf("mcp server failed: %w", err)
+	}
+	return nil
+}
+
+// speaksProtocolOnStdout reports whether cmd's stdout carries a protocol.
+func speaksProtocolOnStdout(cmd *cobra.Command) bool {
+	return cmd.Annotations[stdioProtocolAnnotation] == "true"
+}
+
+// newMCPServer builds the server with Entire's tools and resources.
+func newMCPServer() *mcp.Server {
+	s := mcp.NewServer("entire", buildinfo.Version)
+
+	s.AddTool(mcp.Tool{
+		Name:        "list_checkpoints",
+		Description: "List committed checkpoints in this repository, newest first. Each checkpoint links a git commit to the agent session that produced it.",
+		InputSchema: objectSchema(map[string]any{
+			"session_id": stringProperty("Only list checkpoints of this session"),
+			"limit":      integerProperty(fmt.Sprintf("Maximum number of checkpoints (default %d)", mcpDefaultListLimit)),
+		}),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var p struct {
+				SessionID string `json:"session_id"`
+				Limit     int    `json:"limit"`
+			}
+			if err := decodeMCPArgs(args, &p); err != nil {
+				return nil, err
+			}
+			return mcpListCheckpoints(ctx, p.SessionID, p.Limit)
+		},
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "get_checkpoint",
+		Description: "Get a committed checkpoint: its sessions' prompts, summary, token usage and line attribution. Transcripts are not included.",
+		InputSchema: objectSchema(map[string]any{
+			"checkpoint_id": stringProperty("12-character checkpoint ID, as in the Entire-Checkpoint commit trailer"),
+		}, "checkpoint_id"),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var p struct {
+				CheckpointID string `json:"checkpoint_id"`
+			}
+			if err := decodeMCPArgs(args, &p); err != nil {
+				return nil, err
+			}
+			return mcpGetCheckpoint(ctx, p.CheckpointID)
+		},
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "session_status",
+		Description: "List agent sessions in progress in this repository: phase, steps, files touched and the commit they are based on.",
+		InputSchema: objectSchema(map[string]any{
+			"all_worktrees": booleanProperty("Include sessions of other worktrees"),
+		}),
+		Handler: func(_ context.Context, args json.RawMessage) (any, error) {
+			var p struct {
+				AllWorktrees bool `json:"all_worktrees"`
+			}
+			if err := decodeMCPArgs(args, &p); err != nil {
+				return nil, err
+			}
+			return mcpSessionStatus(p.AllWorktrees)
+		},
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "current_attribution",
+		Description: "Preview the agent/human line attribution the next commit would record for each session in this worktree, without committing.",
+		InputSchema: objectSchema(map[string]any{
+			"include_worktree": booleanProperty("Include unstaged and untracked changes, not just staged ones"),
+		}),
+		Handler: func(_ context.Context, args json.RawMessage) (any, error) {
+			var p struct {
+				IncludeWorktree bool `json:"include_worktree"`
+			}
+			if err := decodeMCPArgs(args, &p); err != nil {
+				return nil, err
+			}
+			return mcpCurrentAttribution(GetStrategy(), p.IncludeWorktree)
+		},
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "list_restore_points",
+		Description: "List the points 'entire rewind' can restore the worktree to, newest first. Restoring is left to the user.",
+		InputSchema: objectSchema(map[string]any{
+			"limit": integerProperty(fmt.Sprintf("Maximum number of restore points (default %d)", mcpDefaultListLimit)),
+		}),
+		Handler: func(_ context.Context, args json.RawMessage) (any, error) {
+			var p struct {
+				Limit int `json:"limit"`
+			}
+			if err := decodeMCPArgs(args, &p); err != nil {
+				return nil, err
+			}
+			return mcpListRestorePoints(GetStrategy(), p.Limit)
+		},
+	})
+
+	s.AddTool(mcp.Tool{
+		Name: "entire_note",
+		Description: "Record a note about your work for the human who reviews it: an assumption you made, a caveat, a TODO to revisit or an open question. " +
+			"Notes are attached to the checkpoint of the next commit and shown with it in 'entire show' and 'entire notes'.",
+		InputSchema: objectSchema(map[string]any{
+			"text":       stringProperty("The note, e.g. \"I assumed the API returns UTC timestamps\""),
+			"kind":       enumProperty("Kind of note (default note)", session.NoteKinds),
+			"files":      stringArrayProperty("Files the note is about, relative to the repository root"),
+			"session_id": stringProperty("Session to add the note to (default: the most recent session in this worktree)"),
+		}, "text"),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var p struct {
+				Text      string   `json:"text"`
+				Kind      string   `json:"kind"`
+				Files     []string `json:"files"`
+				SessionID string   `json:"session_id"`
+			}
+			if err := decodeMCPArgs(args, &p); err != nil {
+				return nil, err
+			}
+			sessionID, err := strategy.AddSessionNote(ctx, p.SessionID, p.Kind, p.Text, p.Files)
+			if err != nil {
+				return nil, err //nolint:wrapcheck // already describes the problem
+			}
+			return map[string]any{"session_id": sessionID, "recorded": true}, nil
+		},
+	})
+
+	s.AddResource(mcp.Resource{
+		URI:         "entire://status",
+		Name:        "Session status",
+		Description: "Agent sessions in progress in this worktree",
+		MimeType:    "application/json",
+		Read: func(_ context.Context) (string, error) {
+			return mcpJSONText(mcpSessionStatus(false))
+		},
+	})
+
+	s.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: mcpCheckpointURIPrefix + "{checkpoint_id}",
+		Name:        "Checkpoint",
+		Description: "A committed checkpoint, as returned by get_checkpoint",
+		MimeType:    "application/json",
+		Read: func(ctx context.Context, uri string) (string, bool, error) {
+			cpID, ok := strings.CutPrefix(uri, mcpCheckpointURIPrefix)
+			if !ok {
+				return "", false, nil
+			}
+			text, err := mcpJSONText(mcpGetCheckpoint(ctx, cpID))
+			return text, true, err
+		},
+	})
+
+	return s
+}
+
+// mcpCheckpointJSON is a committed checkpoint in list_checkpoints.
+type mcpCheckpointJSON struct {
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	SessionID    string          `json:"session_id"`
+	SessionIDs   []string        `json:"session_ids,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Agent        agent.AgentType `json:"agent,omitempty"`
+	Steps        int             `json:"steps"`
+	FilesTouched []string        `json:"files_touched"`
+	IsTask       bool            `json:"is_task,omitempty"`
+}
+
+// mcpCheckpointDetailJSON is a committed checkpoint in get_checkpoint.
+type mcpCheckpointDetailJSON struct {
+	CheckpointID id.CheckpointID            `json:"checkpoint_id"`
+	Strategy     string                     `json:"strategy"`
+	Branch       string                     `json:"branch,omitempty"`
+	FilesTouched []string                   `json:"files_touched"`
+	TokenUsage   *agent.TokenUsage          `json:"token_usage,omitempty"`
+	Sessions     []mcpCheckpointSessionJSON `json:"sessions"`
+}
+
+// mcpCheckpointSessionJSON is one session of a checkpoint in get_checkpoint.
+type mcpCheckpointSessionJSON struct {
+	SessionID    string                         `json:"session_id"`
+	Agent        agent.AgentType                `json:"agent,omitempty"`
+	CreatedAt    time.Time                      `json:"created_at"`
+	Steps        int                            `json:"steps"`
+	FilesTouched []string                       `json:"files_touched"`
+	Prompts      string                         `json:"prompts,omitempty"`
+	Summary      *checkpoint.Summary            `json:"summary,omitempty"`
+	TokenUsage   *agent.TokenUsage              `json:"token_usage,omitempty"`
+	Attribution  *checkpoint.InitialAttribution `json:"attribution,omitempty"`
+	Notes        []checkpoint.Note              `json:"notes,omitempty"`
+}
+
+// mcpSessionJSON is a session in progress in session_status.
+type mcpSessionJSON struct {
+	SessionID        string          `json:"session_id"`
+	Agent            agent.AgentType `json:"agent,omitempty"`
+	Phase            string          `json:"phase"`
+	StartedAt        time.Time       `json:"started_at"`
+	LastInteraction  *time.Time      `json:"last_interaction,omitempty"`
+	Steps            int             `json:"steps"`
+	FilesTouched     []string        `json:"files_touched"`
+	BaseCommit       string          `json:"base_commit"`
+	WorktreePath     string          `json:"worktree_path,omitempty"`
+	LastCheckpointID id.CheckpointID `json:"last_checkpoint_id,omitempty"`
+	FirstPrompt      string          `json:"first_prompt,omitempty"`
+	Title            string          `json:"title,omitempty"`
+	Tags             []string        `json:"tags,omitempty"`
+}
+
+// mcpRestorePointJSON is a restore point in list_restore_points.
+type mcpRestorePointJSON struct {
+	ID           string          `json:"id"`
+	Message      string          `json:"message"`
+	Date         time.Time       `json:"date"`
+	SessionID    string          `json:"session_id,omitempty"`
+	Agent        agent.AgentType `json:"agent,omitempty"`
+	CheckpointID id.CheckpointID `json:"checkpoint_id,omitempty"`
+	Prompt       string          `json:"prompt,omitempty"`
+	IsTask       bool            `json:"is_task,omitempty"`
+	LogsOnly     bool            `json:"logs_only,omitempty"`
+}
+
+func mcpListCheckpoints(ctx context.Context, sessionID string, limit int) ([]mcpCheckpointJSON, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	committed, err := checkpoint.NewGitStore(repo).ListCommitted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	sort.SliceStable(committed, func(i, j int) bool {
+		return committed[i].CreatedAt.After(committed[j].CreatedAt)
+	})
+
+	limit = clampMCPLimit(limit)
+	result := []mcpCheckpointJSON{}
+	for _, info := range committed {
+		if len(result) == limit {
+			break
+		}
+		if sessionID != "" && info.SessionID != sessionID && !slices.Contains(info.SessionIDs, sessionID) {
+			continue
+		}
+		entry := mcpCheckpointJSON{
+			CheckpointID: info.CheckpointID,
+			SessionID:    info.SessionID,
+			CreatedAt:    info.CreatedAt,
+			Agent:        info.Agent,
+			Steps:        info.CheckpointsCount,
+			FilesTouched: nonNilStrings(info.FilesTouched),
+			IsTask:       info.IsTask,
+		}
+		if len(info.SessionIDs) > 1 {
+			entry.SessionIDs = info.SessionIDs
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func mcpGetCheckpoint(ctx context.Context, rawID string) (*mcpCheckpointDetailJSON, error) {
+	cpID, err := id.NewCheckpointID(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint ID %q: %w", rawID, err)
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	store := checkpoint.NewGitStore(repo)
+	summary, err := store.ReadCommitted(ctx, cpID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("%w: %s", checkpoint.ErrCheckpointNotFound, cpID)
+	}
+
+	detail := &mcpCheckpointDetailJSON{
+		CheckpointID: summary.CheckpointID,
+		Strategy:     summary.Strategy,
+		Branch:       summary.Branch,
+		FilesTouched: nonNilStrings(summary.FilesTouched),
+		TokenUsage:   summary.TokenUsage,
+		Sessions:     []mcpCheckpointSessionJSON{},
+	}
+	for i := range summary.Sessions {
+		content, err := store.ReadSessionContent(ctx, cpID, i)
+		if errors.Is(err, checkpoint.ErrCheckpointNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+		}
+		m := content.Metadata
+		detail.Sessions = append(detail.Sessions, mcpCheckpointSessionJSON{
+			SessionID:    m.SessionID,
+			Agent:        m.Agent,
+			CreatedAt:    m.CreatedAt,
+			Steps:        m.CheckpointsCount,
+			FilesTouched: nonNilStrings(m.FilesTouched),
+			Prompts:      content.Prompts,
+			Summary:      m.Summary,
+			TokenUsage:   m.TokenUsage,
+			Attribution:  m.InitialAttribution,
+			Notes:        m.Notes,
+		})
+	}
+	return detail, nil
+}
+
+func mcpSessionStatus(allWorktrees bool) ([]mcpSessionJSON, error) {
+	states, err := strategy.ListSessionStates()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped by ListSessionStates
+	}
+	worktreePath := ""
+	if !allWorktrees {
+		if worktreePath, err = strategy.GetWorktreePath(); err != nil {
+			return nil, fmt.Errorf("failed to get worktree path: %w", err)
+		}
+	}
+
+	result := []mcpSessionJSON{}
+	for _, st := range states {
+		if worktreePath != "" && st.WorktreePath != worktreePath {
+			continue
+		}
+		result = append(result, mcpSessionJSON{
+			SessionID:        st.SessionID,
+			Agent:            st.AgentType,
+			Phase:            string(st.Phase),
+			StartedAt:        st.StartedAt,
+			LastInteraction:  st.LastInteractionTime,
+			Steps:            st.StepCount,
+			FilesTouched:     nonNilStrings(st.FilesTouched),
+			BaseCommit:       st.BaseCommit,
+			WorktreePath:     st.WorktreePath,
+			LastCheckpointID: st.LastCheckpointID,
+			FirstPrompt:      st.FirstPrompt,
+			Title:            st.DisplayTitle(),
+			Tags:             st.Tags,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+	return result, nil
+}
+
+func mcpCurrentAttribution(strat strategy.Strategy, includeWorktree bool) ([]attributionPreviewJSON, error) {
+	previewer, ok := strat.(strategy.AttributionPreviewer)
+	if !ok {
+		return nil, fmt.Errorf("attribution preview is not supported by the %s strategy", strat.Name())
+	}
+	previews, err := previewer.PreviewAttribution(includeWorktree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview attribution: %w", err)
+	}
+	return attributionPreviewEntries(previews), nil
+}
+
+func mcpListRestorePoints(strat strategy.Strategy, limit int) ([]mcpRestorePointJSON, error) {
+	points, err := strat.GetRewindPoints(clampMCPLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list restore points: %w", err)
+	}
+	result := make([]mcpRestorePointJSON, 0, len(points))
+	for _, p := range points {
+		result = append(result, mcpRestorePointJSON{
+			ID:           p.ID,
+			Message:      p.Message,
+			Date:         p.Date,
+			SessionID:    p.SessionID,
+			Agent:        p.Agent,
+			CheckpointID: p.CheckpointID,
+			Prompt:       p.SessionPrompt,
+			IsTask:       p.IsTaskCheckpoint,
+			LogsOnly:     p.IsLogsOnly,
+		})
+	}
+	return result, nil
+}
+
+// decodeMCPArgs decodes tool arguments, rejecting unknown ones so a model
+// that misspells an argument finds out instead of getting unfiltered results.
+func decodeMCPArgs(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(strings.NewReader(string(args)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// mcpJSONText renders a resource's content.
+func mcpJSONText[T any](v T, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	data, err := jsonutil.MarshalIndentWithNewline(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return string(data), nil
+}
+
+func clampMCPLimit(limit int) int {
+	if limit <= 0 {
+		return mcpDefaultListLimit
+	}
+	return min(limit, mcpMaxListLimit)
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+func integerProperty(description string) map[string]any {
+	return map[string]any{"type": "integer", "minimum": 1, "description": description}
+}
+
+func enumProperty(description string, values []string) map[string]any {
+	return map[string]any{"type": "string", "enum": values, "description": description}
+}
+
+func stringArrayProperty(description string) map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": description}
+}
+
+func booleanProperty(description string) map[string]any {
+	return map[string]any{"type": "boolean", "description": description}
+}
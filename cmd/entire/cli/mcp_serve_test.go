@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+// callMCP sends one request to a fresh server and returns its result.
+func callMCP(t *testing.T, method, params string) map[string]any {
+	t.Helper()
+	req := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + "}\n"
+	var out strings.Builder
+	if err := runMCPServe(context.Background(), strings.NewReader(req), &out); err != nil {
+		t.Fatalf("runMCPServe() error = %v", err)
+	}
+	var resp struct {
+		Result map[string]any `json:"result"`
+		Error  map[string]any `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &resp); err != nil {
+		t.Fatalf("response %q is not JSON: %v", out.String(), err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("%s returned error %v", method, resp.Error)
+	}
+	return resp.Result
+}
+
+// callMCPTool calls a tool and decodes its JSON text into v. Returns whether
+// the tool reported an error, and the text.
+func callMCPTool(t *testing.T, name, args string, v any) (bool, string) {
+	t.Helper()
+	result := callMCP(t, "tools/call", `{"name":"`+name+`","arguments":`+args+`}`)
+	text := result["content"].([]any)[0].(map[string]any)["text"].(string)
+	isError := result["isError"] == true
+	if !isError && v != nil {
+		if err := json.Unmarshal([]byte(text), v); err != nil {
+			t.Fatalf("%s result %q is not JSON: %v", name, text, err)
+		}
+	}
+	return isError, text
+}
+
+func setupMCPRepo(t *testing.T) {
+	t.Helper()
+	repo, _ := initTestRepo(t)
+
+	for _, opts := range []checkpoint.WriteCommittedOptions{
+		{
+			CheckpointID: id.MustCheckpointID("a1b2c3d4e5f6"),
+			SessionID:    "2026-10-14-first",
+			FilesTouched: []string{"main.go"},
+			Prompts:      []string{"add a main function"},
+		},
+		{
+			CheckpointID: id.MustCheckpointID("b1b2c3d4e5f6"),
+			SessionID:    "2026-10-14-second",
+			FilesTouched: []string{"util.go"},
+			Prompts:      []string{"extract a helper"},
+			TaskType:     "refactor",
+			InitialAttribution: &checkpoint.InitialAttribution{
+				AgentLines:      8,
+				HumanAdded:      2,
+				TotalCommitted:  10,
+				AgentPercentage: 80,
+			},
+		},
+	} {
+		opts.Transcript = []byte(`{"type":"user","message":{"content":"hi"}}` + "\n")
+		writeTestCheckpoint(t, repo, opts)
+	}
+}
+
+func TestMCPServe_Checkpoints(t *testing.T) {
+	setupMCPRepo(t)
+
+	var all []mcpCheckpointJSON
+	if isError, text := callMCPTool(t, "list_checkpoints", `{}`, &all); isError {
+		t.Fatalf("list_checkpoints failed: %s", text)
+	}
+	if len(all) != 2 {
+		t.Fatalf("list_checkpoints returned %d checkpoints, want 2", len(all))
+	}
+
+	var filtered []mcpCheckpointJSON
+	callMCPTool(t, "list_checkpoints", `{"session_id":"2026-10-14-second","limit":5}`, &filtered)
+	if len(filtered) != 1 || filtered[0].CheckpointID.String() != "b1b2c3d4e5f6" {
+		t.Errorf("list_checkpoints filtered by session = %+v", filtered)
+	}
+
+	var detail mcpCheckpointDetailJSON
+	if isError, text := callMCPTool(t, "get_checkpoint", `{"checkpoint_id":"b1b2c3d4e5f6"}`, &detail); isError {
+		t.Fatalf("get_checkpoint failed: %s", text)
+	}
+	if len(detail.Sessions) != 1 {
+		t.Fatalf("get_checkpoint returned %d sessions, want 1", len(detail.Sessions))
+	}
+	s := detail.Sessions[0]
+	if s.SessionID != "2026-10-14-second" || !strings.Contains(s.Prompts, "extract a helper") {
+		t.Errorf("session = %+v, want the second session with its prompts", s)
+	}
+	if s.Attribution == nil || s.Attribution.AgentLines != 8 {
+		t.Errorf("attribution = %+v, want 8 agent lines", s.Attribution)
+	}
+}
+
+func TestMCPServe_ToolErrors(t *testing.T) {
+	setupMCPRepo(t)
+
+	tests := []struct {
+		name, tool, args, want string
+	}{
+		{"unknown checkpoint", "get_checkpoint", `{"checkpoint_id":"ffffffffffff"}`, "not found"},
+		{"invalid checkpoint ID", "get_checkpoint", `{"checkpoint_id":"../etc"}`, "invalid checkpoint ID"},
+		{"misspelled argument", "list_checkpoints", `{"sessionid":"x"}`, "invalid arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isError, text := callMCPTool(t, tt.tool, tt.args, nil)
+			if !isError || !strings.Contains(text, tt.want) {
+				t.Errorf("%s(%s) = (isError %v) %q, want an error containing %q", tt.tool, tt.args, isError, text, tt.want)
+			}
+		})
+	}
+}
+
+func TestMCPServe_CheckpointResource(t *testing.T) {
+	setupMCPRepo(t)
+
+	result := callMCP(t, "resources/read", `{"uri":"entire://checkpoints/a1b2c3d4e5f6"}`)
+	contents := result["contents"].([]any)[0].(map[string]any)
+	if contents["mimeType"] != "application/json" {
+		t.Errorf("mimeType = %v, want application/json", contents["mimeType"])
+	}
+	var detail mcpCheckpointDetailJSON
+	if err := json.Unmarshal([]byte(contents["text"].(string)), &detail); err != nil {
+		t.Fatalf("resource text is not JSON: %v", err)
+	}
+	if detail.CheckpointID.String() != "a1b2c3d4e5f6" || len(detail.Sessions) != 1 {
+		t.Errorf("resource = %+v, want checkpoint a1b2c3d4e5f6", detail)
+	}
+}
+
+func TestMCPServe_EntireNote(t *testing.T) {
+	setupMCPRepo(t)
+
+	worktreePath, err := strategy.GetWorktreePath()
+	if err != nil {
+		t.Fatalf("GetWorktreePath() error = %v", err)
+	}
+	now := time.Now()
+	state := &strategy.SessionState{
+		SessionID:           "2026-10-14-notes",
+		BaseCommit:          "0123456789abcdef0123456789abcdef01234567",
+		WorktreePath:        worktreePath,
+		StartedAt:           now,
+		LastInteractionTime: &now,
+	}
+	if err := strategy.SaveSessionState(state); err != nil {
+		t.Fatalf("SaveSessionState() error = %v", err)
+	}
+
+	var result map[string]any
+	if isError, text := callMCPTool(t, "entire_note", `{"text":"I assumed timestamps are UTC","kind":"assumption","files":["main.go"]}`, &result); isError {
+		t.Fatalf("entire_note failed: %s", text)
+	}
+	if result["session_id"] != state.SessionID {
+		t.Errorf("entire_note session = %v, want the session in progress", result["session_id"])
+	}
+	if isError, text := callMCPTool(t, "entire_note", `{"text":"later","kind":"wish"}`, nil); !isError || !strings.Contains(text, "invalid note kind") {
+		t.Errorf("entire_note with an unknown kind = (isError %v) %q", isError, text)
+	}
+
+	loaded, err := strategy.LoadSessionState(state.SessionID)
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	if len(loaded.Notes) != 1 || loaded.Notes[0].Kind != "assumption" || loaded.Notes[0].Text != "I assumed timestamps are UTC" || len(loaded.Notes[0].Files) != 1 {
+		t.Errorf("Notes = %+v, want the assumption", loaded.Notes)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 )
@@ -65,7 +66,7 @@ func ReadTaskCheckpoint(taskMetadataDir string) (*TaskCheckpoint, error) {
 // WriteTaskPrompt writes the task prompt to the task metadata directory.
 func WriteTaskPrompt(taskMetadataDir, prompt string) error {
 	promptFile := filepath.Join(taskMetadataDir, paths.PromptFileName)
-	if err := os.WriteFile(promptFile, []byte(prompt), 0o600); err != nil {
+	if err := encryption.WriteFile(promptFile, []byte(prompt), 0o600); err != nil {
 		return fmt.Errorf("failed to write prompt file: %w", err)
 	}
 	return nil
@@ -81,5 +82,5 @@ func CopyAgentTranscript(srcTranscript, taskMetadataDir, agentID string) error {
 	}
 
 	dstTranscript := filepath.Join(taskMetadataDir, fmt.Sprintf("agent-%s.jsonl", agentID))
-	return copyFile(srcTranscript, dstTranscript)
+	return copyToMetadata(srcTranscript, dstTranscript)
 }
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate Entire data to another storage layout",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(newMigrateNotesCmd())
+	cmd.AddCommand(newMigrateConventionsCmd())
+	return cmd
+}
+
+func newMigrateNotesCmd() *cobra.Command {
+	var dryRun bool
+	var remote string
+
+	cmd := &cobra.Command{
+		Use:   "notes",
+		Short: "Copy checkpoint metadata into git notes on each commit",
+		Long: fmt.Sprintf(`Attach the metadata and attribution of every checkpoint on %s
+to its commit as a git note under %s.
+
+Commits are found by their Entire-Checkpoint trailers on all local and
+remote-tracking branches. Commits that already have a note are left alone.
+The remote's fetch configuration gets a refspec for the notes, so 'git fetch'
+keeps notes written in other clones.
+
+Set "commit_notes": true in .entire/settings.json to keep writing notes for new
+commits and push them along with %s.`, paths.MetadataBranchName, paths.NotesRefName, paths.MetadataBranchName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runMigrateNotes(cmd.Context(), cmd.OutOrStdout(), remote, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be migrated without writing notes")
+	cmd.Flags().StringVar(&remote, "remote", "origin", "Remote to configure fetching notes from (empty to skip)")
+
+	return cmd
+}
+
+func runMigrateNotes(ctx context.Context, w io.Writer, remote string, dryRun bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+
+	annotated, err := store.ListCommitNotes()
+	if err != nil {
+		return fmt.Errorf("failed to read commit notes: %w", err)
+	}
+	hasNote := make(map[plumbing.Hash]bool, len(annotated))
+	for _, commit := range annotated {
+		hasNote[commit] = true
+	}
+
+	commits, err := checkpointCommits(repo)
+	if err != nil {
+		return err
+	}
+	notes := make(map[plumbing.Hash]*checkpoint.CommitNote)
+	var skipped, missing int
+	for _, c := range commits {
+		if hasNote[c.hash] {
+			skipped++
+			continue
+		}
+		note, err := store.BuildCommitNote(ctx, c.checkpointID)
+		if errors.Is(err, checkpoint.ErrCheckpointNotFound) {
+			missing++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to build note for %s: %w", c.hash.String()[:7], err)
+		}
+		notes[c.hash] = note
+	}
+
+	verb := "Wrote"
+	if dryRun {
+		verb = "Would write"
+	} else {
+		authorName, authorEmail := strategy.GetGitAuthorFromRepo(repo)
+		if err := store.WriteCommitNotes(notes, authorName, authorEmail); err != nil {
+			return fmt.Errorf("failed to write commit notes: %w", err)
+		}
+	}
+	fmt.Fprintf(w, "%s notes for %d commit(s) to %s.\n", verb, len(notes), paths.NotesRefName)
+	if skipped > 0 {
+		fmt.Fprintf(w, "%d commit(s) already had a note.\n", skipped)
+	}
+	if missing > 0 {
+		fmt.Fprintf(w, "%d commit(s) reference checkpoints not on %s (not fetched?).\n", missing, paths.MetadataBranchName)
+	}
+
+	if remote == "" || dryRun {
+		return nil
+	}
+	added, err := addNotesFetchRefspec(ctx, repo, remote)
+	if err != nil {
+		return err
+	}
+	if added {
+		fmt.Fprintf(w, "Configured 'git fetch %s' to fetch commit notes.\n", remote)
+	}
+	return nil
+}
+
+type checkpointCommit struct {
+	hash         plumbing.Hash
+	checkpointID id.CheckpointID
+}
+
+// checkpointCommits returns the commits with a checkpoint trailer reachable
+// from local and remote-tracking branches, except Entire's own branches.
+func checkpointCommits(repo *git.Repository) ([]checkpointCommit, error) {
+	var commits []checkpointCommit
+	err := walkBranchHistory(repo, func(c *object.Commit) error {
+		if cpID, ok := trailers.ParseCheckpoint(c.Message); ok {
+			commits = append(commits, checkpointCommit{hash: c.Hash, checkpointID: cpID})
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// walkBranchHistory calls visit once for every commit reachable from local and
+// remote-tracking branches, except Entire's own branches.
+func walkBranchHistory(repo *git.Repository, visit func(*object.Commit) error) error {
+	refs, err := repo.References()
+	if err != nil {
+		return fmt.Errorf("failed to list references: %w", err)
+	}
+	var tips []plumbing.Hash
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		name := ref.Name()
+		var branch string
+		switch {
+		case name.IsBranch():
+			branch = name.Short()
+		case name.IsRemote():
+			_, branch, _ = strings.Cut(name.Short(), "/")
+		default:
+			return nil
+		}
+		if !strings.HasPrefix(branch, "entire/") {
+			tips = append(tips, ref.Hash())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	for len(tips) > 0 {
+		hash := tips[len(tips)-1]
+		tips = tips[:len(tips)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		c, err := repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to walk history at %s: %w", hash, err)
+		}
+		if err := visit(c); err != nil {
+			return err
+		}
+		tips = append(tips, c.ParentHashes...)
+	}
+	return nil
+}
+
+// addNotesFetchRefspec adds the commit notes refspec to the fetch
+// configuration of remote. Returns false if it was already there or the
+// remote doesn't exist.
+func addNotesFetchRefspec(ctx context.Context, repo *git.Repository, remote string) (bool, error) {
+	if _, err := repo.Remote(remote); err != nil {
+		return false, nil //nolint:nilerr // no such remote, nothing to configure
+	}
+	key := "remote." + remote + ".fetch"
+	refspec := checkpoint.NotesFetchRefspec(remote)
+	output, _ := exec.CommandContext(ctx, "git", "config", "--get-all", key).Output() //nolint:errcheck // unset key exits non-zero
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) == refspec {
+			return false, nil
+		}
+	}
+	if out, err := exec.CommandContext(ctx, "git", "config", "--add", key, refspec).CombinedOutput(); err != nil {
+		return false, fmt.Errorf("failed to configure %s: %s", key, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// importedStrategyName is the strategy recorded in notes written from
+// attribution conventions, which no strategy created.
+const importedStrategyName = "imported"
+
+func newMigrateConventionsCmd() *cobra.Command {
+	var rulesPath string
+	var dryRun bool
+	var remote string
+
+	cmd := &cobra.Command{
+		Use:   "conventions --rules <file>",
+		Short: "Import attribution from commit conventions like [AI] prefixes or co-author trailers",
+		Long: fmt.Sprintf(`Attribute commits made before Entire was set up, from the conventions a team
+used to mark AI-written code, and store the attribution as a git note under %s.
+
+The rules file lists conventions in order; the first rule whose conditions all
+match a commit applies:
+
+  rules:
+    - name: ai-prefix
+      subject: '^\[AI\]'           # regular expression on the subject line
+      agent: Claude Code
+    - name: aider
+      subject: '^aider: '
+      agent: Aider
+    - name: copilot
+      trailer: Co-authored-by      # a trailer whose value matches 'value'
+      value: '(?i)copilot'
+      share: 50                    # percent of added lines counted as agent's (default 100)
+
+Conditions are subject, message (whole commit message), author ("Name <email>")
+and trailer/value, all regular expressions. Commits with a checkpoint or a note,
+and merge commits, are left alone.`, paths.NotesRefName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runMigrateConventions(cmd.Context(), cmd.OutOrStdout(), rulesPath, remote, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "YAML file with the conventions to import")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without writing notes")
+	cmd.Flags().StringVar(&remote, "remote", "origin", "Remote to configure fetching notes from (empty to skip)")
+	_ = cmd.MarkFlagRequired("rules") //nolint:errcheck // flag is defined above
+
+	return cmd
+}
+
+// conventionRules is the rules file of `entire migrate conventions`.
+type conventionRules struct {
+	Rules []*conventionRule `yaml:"rules"`
+}
+
+// conventionRule maps commits matching all of its conditions to an agent.
+type conventionRule struct {
+	Name    string   `yaml:"name"`
+	Subject string   `yaml:"subject"`
+	Message string   `yaml:"message"`
+	Author  string   `yaml:"author"`
+	Trailer string   `yaml:"trailer"`
+	Value   string   `yaml:"value"`
+	Agent   string   `yaml:"agent"`
+	Share   *float64 `yaml:"share"`
+
+	subject, message, author, value *regexp.Regexp
+}
+
+var conventionRuleNameRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// loadConventionRules reads and validates a rules file.
+func loadConventionRules(path string) ([]*conventionRule, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var file conventionRules
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse rules %s: %w", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("no rules in %s", path)
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range file.Rules {
+		if !conventionRuleNameRegex.MatchString(rule.Name) {
+			return nil, fmt.Errorf("rule %d: name %q must be letters, digits, '.', '_' or '-'", i+1, rule.Name)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %q is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+		if (rule.Trailer == "") != (rule.Value == "") {
+			return nil, fmt.Errorf("rule %q: trailer and value must be set together", rule.Name)
+		}
+		if rule.Subject == "" && rule.Message == "" && rule.Author == "" && rule.Trailer == "" {
+			return nil, fmt.Errorf("rule %q has no conditions", rule.Name)
+		}
+		if rule.Share != nil && (*rule.Share <= 0 || *rule.Share > 100) {
+			return nil, fmt.Errorf("rule %q: share must be between 0 and 100", rule.Name)
+		}
+		for _, field := range []struct {
+			pattern string
+			re      **regexp.Regexp
+		}{
+			{rule.Subject, &rule.subject},
+			{rule.Message, &rule.message},
+			{rule.Author, &rule.author},
+			{rule.Value, &rule.value},
+		} {
+			if field.pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(field.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid pattern %q: %w", rule.Name, field.pattern, err)
+			}
+			*field.re = re
+		}
+	}
+	return file.Rules, nil
+}
+
+// matches reports whether all of the rule's conditions match c.
+func (r *conventionRule) matches(c *object.Commit) bool {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	if r.subject != nil && !r.subject.MatchString(subject) {
+		return false
+	}
+	if r.message != nil && !r.message.MatchString(c.Message) {
+		return false
+	}
+	if r.author != nil && !r.author.MatchString(fmt.Sprintf("%s <%s>", c.Author.Name, c.Author.Email)) {
+		return false
+	}
+	if r.value != nil && !hasTrailerMatching(c.Message, r.Trailer, r.value) {
+		return false
+	}
+	return true
+}
+
+// agentShare returns the fraction of added lines the rule counts as agent's.
+func (r *conventionRule) agentShare() float64 {
+	if r.Share == nil {
+		return 1
+	}
+	return *r.Share / 100
+}
+
+func (r *conventionRule) agentType() agent.AgentType {
+	if r.Agent == "" {
+		return agent.AgentTypeUnknown
+	}
+	return agent.AgentType(r.Agent)
+}
+
+// hasTrailerMatching reports whether message has a key trailer (compared
+// case-insensitively, as git does) whose value matches value.
+func hasTrailerMatching(message, key string, value *regexp.Regexp) bool {
+	for _, line := range strings.Split(message, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) && value.MatchString(strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+func runMigrateConventions(ctx context.Context, w io.Writer, rulesPath, remote string, dryRun bool) error {
+	rules, err := loadConventionRules(rulesPath)
+	if err != nil {
+		return err
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+
+	annotated, err := store.ListCommitNotes()
+	if err != nil {
+		return fmt.Errorf("failed to read commit notes: %w", err)
+	}
+	hasNote := make(map[plumbing.Hash]bool, len(annotated))
+	for _, commit := range annotated {
+		hasNote[commit] = true
+	}
+
+	notes := make(map[plumbing.Hash]*checkpoint.CommitNote)
+	perRule := make(map[string]int)
+	var skipped, empty int
+	err = walkBranchHistory(repo, func(c *object.Commit) error {
+		if len(c.ParentHashes) > 1 {
+			return nil
+		}
+		var rule *conventionRule
+		for _, r := range rules {
+			if r.matches(c) {
+				rule = r
+				break
+			}
+		}
+		if rule == nil {
+			return nil
+		}
+		if _, ok := trailers.ParseCheckpoint(c.Message); ok || hasNote[c.Hash] {
+			skipped++
+			return nil
+		}
+		note, err := buildConventionNote(c, rule)
+		if err != nil {
+			return err
+		}
+		if note == nil {
+			empty++
+			return nil
+		}
+		notes[c.Hash] = note
+		perRule[rule.Name]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "Wrote"
+	if dryRun {
+		verb = "Would write"
+	} else {
+		authorName, authorEmail := strategy.GetGitAuthorFromRepo(repo)
+		if err := store.WriteCommitNotes(notes, authorName, authorEmail); err != nil {
+			return fmt.Errorf("failed to write commit notes: %w", err)
+		}
+	}
+	fmt.Fprintf(w, "%s notes for %d commit(s) to %s.\n", verb, len(notes), paths.NotesRefName)
+	for _, rule := range rules {
+		if n := perRule[rule.Name]; n > 0 {
+			fmt.Fprintf(w, "  %s: %d commit(s)\n", rule.Name, n)
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(w, "%d matching commit(s) already had a checkpoint or note.\n", skipped)
+	}
+	if empty > 0 {
+		fmt.Fprintf(w, "%d matching commit(s) changed no text files.\n", empty)
+	}
+
+	if remote == "" || dryRun {
+		return nil
+	}
+	added, err := addNotesFetchRefspec(ctx, repo, remote)
+	if err != nil {
+		return err
+	}
+	if added {
+		fmt.Fprintf(w, "Configured 'git fetch %s' to fetch commit notes.\n", remote)
+	}
+	return nil
+}
+
+// buildConventionNote attributes c by rule. Returns nil if c changes no text
+// files.
+func buildConventionNote(c *object.Commit, rule *conventionRule) (*checkpoint.CommitNote, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", c.Hash.String()[:7], err)
+	}
+	var parentTree *object.Tree
+	if len(c.ParentHashes) == 1 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parent of %s: %w", c.Hash.String()[:7], err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to read tree of %s: %w", parent.Hash.String()[:7], err)
+		}
+	}
+
+	attribution := strategy.CalculateImportedAttribution(parentTree, tree, rule.agentShare())
+	if attribution == nil || len(attribution.Files) == 0 {
+		return nil, nil //nolint:nilnil // nil note means nothing to attribute
+	}
+	files := make([]string, 0, len(attribution.Files))
+	for _, f := range attribution.Files {
+		files = append(files, f.Path)
+	}
+	sort.Strings(files)
+
+	// Notes need a checkpoint ID; this one only exists in the note
+	cpID, err := id.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate checkpoint ID: %w", err)
+	}
+	return &checkpoint.CommitNote{
+		Version:      checkpoint.CommitNoteVersion,
+		CheckpointID: cpID,
+		Strategy:     importedStrategyName,
+		FilesTouched: files,
+		Sessions: []checkpoint.CommitNoteSession{{
+			SessionID:    "imported-" + rule.Name,
+			Agent:        rule.agentType(),
+			CreatedAt:    c.Author.When,
+			FilesTouched: files,
+			Attribution:  attribution,
+		}},
+	}, nil
+}
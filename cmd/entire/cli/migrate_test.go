@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRunMigrateNotes(t *testing.T) {
+	repo, initial := setupCleanTestRepo(t)
+
+	cpID := id.MustCheckpointID("a1b2c3d4e5f6")
+	store := checkpoint.NewGitStore(repo)
+	if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+		CheckpointID: cpID,
+		SessionID:    "2026-10-14-migrate-session",
+		Strategy:     "manual-commit",
+		Agent:        agent.AgentTypeClaudeCode,
+		FilesTouched: []string{"main.go"},
+		InitialAttribution: &checkpoint.InitialAttribution{
+			AgentLines: 5, TotalCommitted: 10, AgentPercentage: 50,
+		},
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+
+	// initial - withCheckpoint - missing (master)
+	withCheckpoint := storeRangeTestCommit(t, repo, cpID.String(), initial)
+	missing := storeRangeTestCommit(t, repo, "ffffffffffff", withCheckpoint)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), missing)); err != nil {
+		t.Fatalf("failed to update master: %v", err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://example.com/repo.git"}}); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runMigrateNotes(context.Background(), &buf, "origin", true); err != nil {
+		t.Fatalf("runMigrateNotes(dry run) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Would write notes for 1 commit(s)") {
+		t.Errorf("unexpected dry run output:\n%s", buf.String())
+	}
+	if commits, _ := store.ListCommitNotes(); len(commits) != 0 { //nolint:errcheck // checked below
+		t.Fatalf("dry run wrote notes for %v", commits)
+	}
+
+	buf.Reset()
+	if err := runMigrateNotes(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("runMigrateNotes() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Wrote notes for 1 commit(s)", "1 commit(s) reference checkpoints not on", "Configured 'git fetch origin'"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	note, err := store.ReadCommitNote(withCheckpoint)
+	if err != nil || note == nil || note.CheckpointID != cpID {
+		t.Fatalf("ReadCommitNote() = %+v, %v; want note for %s", note, err, cpID)
+	}
+	fetch, err := exec.CommandContext(context.Background(), "git", "config", "--get-all", "remote.origin.fetch").Output()
+	if err != nil || !strings.Contains(string(fetch), checkpoint.NotesFetchRefspec("origin")) {
+		t.Errorf("remote.origin.fetch = %q, %v; want the notes refspec", fetch, err)
+	}
+
+	// Running again leaves existing notes and config alone
+	buf.Reset()
+	if err := runMigrateNotes(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("runMigrateNotes() second run error = %v", err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, "Wrote notes for 0 commit(s)") || !strings.Contains(out, "1 commit(s) already had a note") || strings.Contains(out, "Configured") {
+		t.Errorf("unexpected second run output:\n%s", out)
+	}
+}
+
+func TestRunAttributionShow_FromCommitNote(t *testing.T) {
+	repo, initial := setupCleanTestRepo(t)
+
+	// Only the note is available, as in a clone that never fetched the metadata branch
+	store := checkpoint.NewGitStore(repo)
+	head := storeRangeTestCommit(t, repo, "a1b2c3d4e5f6", initial)
+	note := &checkpoint.CommitNote{
+		Version:      checkpoint.CommitNoteVersion,
+		CheckpointID: id.MustCheckpointID("a1b2c3d4e5f6"),
+		Sessions: []checkpoint.CommitNoteSession{{
+			SessionID:   "2026-10-14-note-session",
+			Agent:       agent.AgentTypeClaudeCode,
+			Attribution: &checkpoint.InitialAttribution{AgentLines: 3, TotalCommitted: 4, AgentPercentage: 75},
+		}},
+	}
+	if err := store.WriteCommitNotes(map[plumbing.Hash]*checkpoint.CommitNote{head: note}, "test", "test@test.com"); err != nil {
+		t.Fatalf("WriteCommitNotes() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runAttributionShow(context.Background(), &buf, head.String(), false); err != nil {
+		t.Fatalf("runAttributionShow() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Session 2026-10-14-note-session (Claude Code): 75.0% agent (3 of 4 lines)") {
+		t.Errorf("expected attribution from the commit note:\n%s", buf.String())
+	}
+}
+
+func TestRunMigrateConventions(t *testing.T) {
+	repo, _ := setupCleanTestRepo(t)
+	dir := repoWorktree(t, repo)
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	commitFile := func(name, content, message string) plumbing.Hash {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		hash, err := wt.Commit(message, &git.CommitOptions{Author: &object.Signature{Name: "Dev", Email: "dev@example.com", When: time.Now()}})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		return hash
+	}
+	aiPrefixed := commitFile("a.go", "one\ntwo\nthree\nfour\n", "[AI] Add a")
+	coAuthored := commitFile("b.go", "one\ntwo\n", "Add b\n\nCo-authored-by: GitHub Copilot <copilot@github.com>\n")
+	human := commitFile("c.go", "one\n", "Add c")
+	withCheckpoint := commitFile("d.go", "one\n", "[AI] Add d\n\nEntire-Checkpoint: a1b2c3d4e5f6\n")
+
+	rulesPath := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := `rules:
+  - name: ai-prefix
+    subject: '^\[AI\]'
+    agent: Claude Code
+  - name: copilot
+    trailer: co-authored-by
+    value: '(?i)copilot'
+    share: 50
+`
+	if err := os.WriteFile(rulesPath, []byte(rules), 0o644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runMigrateConventions(context.Background(), &buf, rulesPath, "", true); err != nil {
+		t.Fatalf("runMigrateConventions(dry run) error = %v", err)
+	}
+	store := checkpoint.NewGitStore(repo)
+	if commits, _ := store.ListCommitNotes(); len(commits) != 0 { //nolint:errcheck // checked below
+		t.Fatalf("dry run wrote notes for %v", commits)
+	}
+
+	buf.Reset()
+	if err := runMigrateConventions(context.Background(), &buf, rulesPath, "", false); err != nil {
+		t.Fatalf("runMigrateConventions() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Wrote notes for 2 commit(s)", "ai-prefix: 1 commit(s)", "copilot: 1 commit(s)", "1 matching commit(s) already had a checkpoint or note"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+
+	for commit, want := range map[plumbing.Hash]struct {
+		agent      agent.AgentType
+		agentLines int
+		total      int
+	}{
+		aiPrefixed: {agent.AgentTypeClaudeCode, 4, 4},
+		coAuthored: {agent.AgentTypeUnknown, 1, 2},
+	} {
+		note, err := store.ReadCommitNote(commit)
+		if err != nil || note == nil || len(note.Sessions) != 1 {
+			t.Fatalf("ReadCommitNote(%s) = %+v, %v; want an imported note", commit.String()[:7], note, err)
+		}
+		s := note.Sessions[0]
+		if s.Agent != want.agent || s.Attribution == nil || s.Attribution.AgentLines != want.agentLines || s.Attribution.TotalCommitted != want.total {
+			t.Errorf("note of %s = agent %q, attribution %+v; want %q with %d of %d lines", commit.String()[:7], s.Agent, s.Attribution, want.agent, want.agentLines, want.total)
+		}
+	}
+	for _, commit := range []plumbing.Hash{human, withCheckpoint} {
+		if note, err := store.ReadCommitNote(commit); err != nil || note != nil {
+			t.Errorf("ReadCommitNote(%s) = %+v, %v; want no note", commit.String()[:7], note, err)
+		}
+	}
+}
+
+func TestLoadConventionRules_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for name, rules := range map[string]string{
+		"unknown field":    "rules:\n  - name: x\n    subjet: '^AI'\n",
+		"no conditions":    "rules:\n  - name: x\n    agent: Aider\n",
+		"trailer no value": "rules:\n  - name: x\n    trailer: Co-authored-by\n",
+		"bad pattern":      "rules:\n  - name: x\n    subject: '('\n",
+		"bad share":        "rules:\n  - name: x\n    subject: '^AI'\n    share: 150\n",
+		"no rules":         "rules: []\n",
+	} {
+		path := filepath.Join(t.TempDir(), "rules.yaml")
+		if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+			t.Fatalf("failed to write rules: %v", err)
+		}
+		if _, err := loadConventionRules(path); err == nil {
+			t.Errorf("%s: loadConventionRules() succeeded, want an error", name)
+		}
+	}
+}
@@ -18,6 +18,7 @@ const (
 	EntireDir         = ".entire"
 	EntireTmpDir      = ".entire/tmp"
 	EntireMetadataDir = ".entire/metadata"
+	EntireChunksDir   = ".entire/chunks"
 )
 
 // Metadata file names
@@ -36,6 +37,9 @@ const (
 // MetadataBranchName is the orphan branch used by auto-commit and manual-commit strategies to store metadata
 const MetadataBranchName = "entire/checkpoints/v1"
 
+// NotesRefName is the notes ref commit notes with checkpoint metadata are stored under
+const NotesRefName = "refs/notes/entire"
+
 // CheckpointPath returns the sharded storage path for a checkpoint ID.
 // Uses first 2 characters as shard (256 buckets), remaining as folder name.
 // Example: "a3b2c4d5e6f7" -> "a3/b2c4d5e6f7"
@@ -132,17 +136,18 @@ func IsInfrastructurePath(path string) bool {
 	return strings.HasPrefix(path, EntireDir+"/") || path == EntireDir
 }
 
-// ToRelativePath converts an absolute path to relative.
+// ToRelativePath converts an absolute path to relative, with forward slashes
+// as in git trees.
 // Returns empty string if the path is outside the working directory.
 func ToRelativePath(absPath, cwd string) string {
 	if !filepath.IsAbs(absPath) {
-		return absPath
+		return filepath.ToSlash(absPath)
 	}
 	relPath, err := filepath.Rel(cwd, absPath)
 	if err != nil || strings.HasPrefix(relPath, "..") {
 		return ""
 	}
-	return relPath
+	return filepath.ToSlash(relPath)
 }
 
 // nonAlphanumericRegex matches any non-alphanumeric character
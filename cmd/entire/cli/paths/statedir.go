@@ -0,0 +1,34 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// UserStateDir returns the base directory for Entire's state outside
+// repositories: %LOCALAPPDATA%\entire on Windows, $XDG_STATE_HOME/entire or
+// ~/.local/state/entire elsewhere. Empty if none can be determined.
+func UserStateDir() string {
+	return userStateDir(runtime.GOOS)
+}
+
+func userStateDir(goos string) string {
+	if goos == "windows" {
+		// Local, not roaming: state is per machine and can be large
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "entire")
+		}
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			return filepath.Join(home, "AppData", "Local", "entire")
+		}
+		return ""
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "entire")
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		return filepath.Join(home, ".local", "state", "entire")
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUserStateDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("LOCALAPPDATA", "")
+
+	if got, want := userStateDir("linux"), filepath.Join(home, ".local", "state", "entire"); got != want {
+		t.Errorf("userStateDir(linux) = %q, want %q", got, want)
+	}
+	if got, want := userStateDir("windows"), filepath.Join(home, "AppData", "Local", "entire"); got != want {
+		t.Errorf("userStateDir(windows) without LOCALAPPDATA = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "xdg"))
+	t.Setenv("LOCALAPPDATA", filepath.Join(home, "local"))
+	if got, want := userStateDir("darwin"), filepath.Join(home, "xdg", "entire"); got != want {
+		t.Errorf("userStateDir(darwin) = %q, want %q", got, want)
+	}
+	// XDG_STATE_HOME isn't a Windows convention
+	if got, want := userStateDir("windows"), filepath.Join(home, "local", "entire"); got != want {
+		t.Errorf("userStateDir(windows) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Pull request reports (`entire github report`, `entire gitlab report`) add
+// up the attribution of a pull request's commits and post it as a comment
+// the forge shows on the pull request. The forge clients only list the
+// commits and post the Markdown; everything else is shared here.
+
+// prReportMarker identifies the report comment, so reruns update it instead
+// of adding another one.
+const prReportMarker = "<!-- entire-attribution-report -->"
+
+// prReportMaxFiles is how many files the report lists, most agent lines first.
+const prReportMaxFiles = 20
+
+// prReportCommit is a pull request commit as the forge lists it.
+type prReportCommit struct {
+	SHA     string
+	Message string
+}
+
+// prReport is the attribution of a pull request's commits.
+type prReport struct {
+	Commits        int               `json:"commits"`
+	Attributed     int               `json:"attributed_commits"`
+	AgentLines     int               `json:"agent_lines"`
+	TotalCommitted int               `json:"total_committed"`
+	AgentShare     reportfmt.Percent `json:"agent_share"`
+	Files          []prReportFile    `json:"files"`
+	Sessions       []prReportSession `json:"sessions"`
+}
+
+// prReportFile is one file's attribution summed over the commits.
+type prReportFile struct {
+	Path           string            `json:"path"`
+	AgentLines     int               `json:"agent_lines"`
+	TotalCommitted int               `json:"total_committed"`
+	AgentShare     reportfmt.Percent `json:"agent_share"`
+}
+
+// prReportSession is a session behind one of the commits.
+type prReportSession struct {
+	SessionID    string          `json:"session_id"`
+	Agent        string          `json:"agent,omitempty"`
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	Commit       string          `json:"commit"`
+	AgentLines   int             `json:"agent_lines"`
+	Link         string          `json:"link,omitempty"`
+}
+
+// buildPRReport reads the attribution of commits from the local metadata
+// branch. sessionLink returns the URL of a session's metadata, "" for none.
+func buildPRReport(ctx context.Context, store *checkpoint.GitStore, commits []prReportCommit, sessionLink func(cpID id.CheckpointID, index int) string) *prReport {
+	report := &prReport{Commits: len(commits), Files: []prReportFile{}, Sessions: []prReportSession{}}
+	files := make(map[string]*prReportFile)
+	for _, c := range commits {
+		cpID, found := trailers.ParseCheckpoint(c.Message)
+		if !found {
+			continue
+		}
+		summary, err := store.ReadCommitted(ctx, cpID)
+		if err != nil || summary == nil {
+			continue
+		}
+		var agentLines, totalCommitted int
+		attributed := false
+		for i := range summary.Sessions {
+			metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+			if err != nil {
+				continue
+			}
+			session := prReportSession{
+				SessionID:    metadata.SessionID,
+				Agent:        string(metadata.Agent),
+				CheckpointID: cpID,
+				Commit:       c.SHA,
+				Link:         sessionLink(cpID, i),
+			}
+			if attr := metadata.InitialAttribution; attr != nil && attr.SupersededBy == "" {
+				attributed = true
+				session.AgentLines = attr.AgentLines
+				agentLines += attr.AgentLines
+				totalCommitted = max(totalCommitted, attr.TotalCommitted)
+				for _, f := range attr.Files {
+					file, ok := files[f.Path]
+					if !ok {
+						file = &prReportFile{Path: f.Path}
+						files[f.Path] = file
+					}
+					file.AgentLines += f.AgentLines
+					file.TotalCommitted += f.TotalCommitted
+				}
+			}
+			report.Sessions = append(report.Sessions, session)
+		}
+		if attributed {
+			report.Attributed++
+			report.AgentLines += agentLines
+			report.TotalCommitted += totalCommitted
+		}
+	}
+	if report.TotalCommitted > 0 {
+		report.AgentShare = reportfmt.Percent(float64(report.AgentLines) / float64(report.TotalCommitted) * 100)
+	}
+	for _, f := range files {
+		if f.TotalCommitted > 0 {
+			f.AgentShare = reportfmt.Percent(float64(f.AgentLines) / float64(f.TotalCommitted) * 100)
+		}
+		report.Files = append(report.Files, *f)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		if report.Files[i].AgentLines != report.Files[j].AgentLines {
+			return report.Files[i].AgentLines > report.Files[j].AgentLines
+		}
+		return report.Files[i].Path < report.Files[j].Path
+	})
+	return report
+}
+
+// title is the one-line summary of the report.
+func (r *prReport) title() string {
+	if r.Attributed == 0 {
+		return "No agent attribution recorded"
+	}
+	return fmt.Sprintf("%s agent-written (%d of %d lines)",
+		reportfmt.DetectLocale().Percent(float64(r.AgentShare), 1), r.AgentLines, r.TotalCommitted)
+}
+
+// markdown renders the report comment, starting with prReportMarker.
+func (r *prReport) markdown() string {
+	loc := reportfmt.DetectLocale()
+	var sb strings.Builder
+	sb.WriteString(prReportMarker + "\n")
+	sb.WriteString("## Entire attribution: " + r.title() + "\n\n")
+	fmt.Fprintf(&sb, "%d of %d commit(s) have attribution recorded.\n", r.Attributed, r.Commits)
+	if r.Attributed == 0 {
+		return sb.String()
+	}
+
+	files := r.Files
+	if len(files) > 0 {
+		sb.WriteString("\n| File | Agent lines | Lines | Agent % |\n| --- | ---: | ---: | ---: |\n")
+		if len(files) > prReportMaxFiles {
+			files = files[:prReportMaxFiles]
+		}
+		for _, f := range files {
+			fmt.Fprintf(&sb, "| `%s` | %d | %d | %s |\n", f.Path, f.AgentLines, f.TotalCommitted, loc.Percent(float64(f.AgentShare), 0))
+		}
+		if more := len(r.Files) - len(files); more > 0 {
+			fmt.Fprintf(&sb, "\n…and %d more file(s).\n", more)
+		}
+	}
+
+	if len(r.Sessions) > 0 {
+		sb.WriteString("\n### Sessions\n\n")
+		for _, s := range r.Sessions {
+			label := "`" + s.SessionID + "`"
+			if s.Link != "" {
+				label = "[" + label + "](" + s.Link + ")"
+			}
+			agentLabel := s.Agent
+			if agentLabel == "" {
+				agentLabel = unknownPlaceholder
+			}
+			fmt.Fprintf(&sb, "- %s (%s): %d agent lines in %s, checkpoint `%s`\n", label, agentLabel, s.AgentLines, s.Commit[:min(7, len(s.Commit))], s.CheckpointID)
+		}
+	}
+	return sb.String()
+}
+
+// prPolicyViolations returns the commits that violate policy, as the
+// pre-push hook would judge them.
+func prPolicyViolations(ctx context.Context, store *checkpoint.GitStore, commits []prReportCommit, policy *settings.PushPolicySettings) []pushPolicyViolation {
+	if !policy.IsSet() {
+		return nil
+	}
+	approval := policy.EffectiveApprovalTrailer()
+	var violations []pushPolicyViolation
+	for _, c := range commits {
+		if reason := pushPolicyReason(ctx, store, c.Message, policy, approval); reason != "" {
+			subject, _, _ := strings.Cut(c.Message, "\n")
+			violations = append(violations, pushPolicyViolation{Commit: c.SHA, Subject: subject, Reason: reason})
+		}
+	}
+	return violations
+}
+
+// policyMarkdown renders policy violations as a report section.
+func policyMarkdown(violations []pushPolicyViolation) string {
+	var sb strings.Builder
+	sb.WriteString("\n### Policy\n\n")
+	if len(violations) == 0 {
+		sb.WriteString("All commits meet the push policy.\n")
+		return sb.String()
+	}
+	for _, v := range violations {
+		fmt.Fprintf(&sb, "- :x: %s %s: %s\n", v.Commit[:min(7, len(v.Commit))], v.Subject, v.Reason)
+	}
+	return sb.String()
+}
+
+// ensureMetadataBranch fetches the metadata branch from remote when it isn't
+// there locally, as in a fresh CI checkout.
+func ensureMetadataBranch(ctx context.Context, repo *git.Repository, remote string) error {
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(paths.MetadataBranchName), true); err == nil {
+		return nil
+	}
+	return fetchMetadataBranchFrom(ctx, remote)
+}
+
+// remoteRepoPath returns the repository path ("owner/name", or
+// "group/subgroup/name" on GitLab) and host of remote's URL.
+func remoteRepoPath(ctx context.Context, remote string) (host, repoPath string, ok bool) {
+	output, err := exec.CommandContext(ctx, "git", "remote", "get-url", remote).Output()
+	if err != nil {
+		return "", "", false
+	}
+	return parseRemoteRepoPath(strings.TrimSpace(string(output)))
+}
+
+// parseRemoteRepoPath parses https, ssh:// and scp-like (git@host:path)
+// remote URLs.
+func parseRemoteRepoPath(remoteURL string) (host, repoPath string, ok bool) {
+	if !strings.Contains(remoteURL, "://") {
+		// scp-like: [user@]host:path
+		userHost, path, found := strings.Cut(remoteURL, ":")
+		if !found {
+			return "", "", false
+		}
+		_, h, hasUser := strings.Cut(userHost, "@")
+		if !hasUser {
+			h = userHost
+		}
+		host, repoPath = h, path
+	} else {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return "", "", false
+		}
+		host, repoPath = u.Hostname(), u.Path
+	}
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if host == "" || !strings.Contains(repoPath, "/") {
+		return "", "", false
+	}
+	return host, repoPath, true
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+func TestParseRemoteRepoPath(t *testing.T) {
+	tests := []struct {
+		url, host, path string
+		ok              bool
+	}{
+		{"git@github.com:entireio/cli.git", "github.com", "entireio/cli", true},
+		{"https://github.com/entireio/cli", "github.com", "entireio/cli", true},
+		{"ssh://git@gitlab.example.com:2222/group/sub/project.git", "gitlab.example.com", "group/sub/project", true},
+		{"https://token@gitlab.com/group/project.git/", "gitlab.com", "group/project", true},
+		{"/srv/git/project.git", "", "", false},
+		{"https://github.com/cli", "", "", false},
+	}
+	for _, tt := range tests {
+		host, path, ok := parseRemoteRepoPath(tt.url)
+		if host != tt.host || path != tt.path || ok != tt.ok {
+			t.Errorf("parseRemoteRepoPath(%q) = %q, %q, %v; want %q, %q, %v", tt.url, host, path, ok, tt.host, tt.path, tt.ok)
+		}
+	}
+}
+
+// writePRReportCheckpoint stores a committed checkpoint with attribution for
+// generated.go (all agent) and notes.md (all human).
+func writePRReportCheckpoint(t *testing.T, store *checkpoint.GitStore, cpID id.CheckpointID, sessionID string) {
+	t.Helper()
+	if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+		CheckpointID: cpID,
+		SessionID:    sessionID,
+		Strategy:     "manual-commit",
+		Agent:        agent.AgentTypeClaudeCode,
+		InitialAttribution: &checkpoint.InitialAttribution{
+			AgentLines: 30, HumanAdded: 10, TotalCommitted: 40, AgentPercentage: 75,
+			Files: []checkpoint.FileAttribution{
+				{Path: "generated.go", AgentLines: 30, TotalCommitted: 30, AgentPercentage: 100},
+				{Path: "notes.md", HumanAdded: 10, TotalCommitted: 10},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+}
+
+func TestBuildPRReport(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, _ := setupCleanTestRepo(t)
+	store := checkpoint.NewGitStore(repo)
+	writePRReportCheckpoint(t, store, id.MustCheckpointID("a1b2c3d4e5f6"), "2026-10-14-pr-one")
+	writePRReportCheckpoint(t, store, id.MustCheckpointID("b1b2c3d4e5f6"), "2026-10-14-pr-two")
+
+	commits := []prReportCommit{
+		{SHA: "1111111111111111111111111111111111111111", Message: "one\n\nEntire-Checkpoint: a1b2c3d4e5f6\n"},
+		{SHA: "2222222222222222222222222222222222222222", Message: "two\n\nEntire-Checkpoint: b1b2c3d4e5f6\n"},
+		{SHA: "3333333333333333333333333333333333333333", Message: "by hand"},
+	}
+	report := buildPRReport(context.Background(), store, commits, func(cpID id.CheckpointID, index int) string {
+		return "https://example.com/" + cpID.Path()
+	})
+
+	if report.Commits != 3 || report.Attributed != 2 || report.AgentLines != 60 || report.TotalCommitted != 80 || report.AgentShare != 75 {
+		t.Errorf("report = %+v, want 60 of 80 agent lines in 2 of 3 commits", report)
+	}
+	if len(report.Files) != 2 || report.Files[0].Path != "generated.go" || report.Files[0].AgentLines != 60 || report.Files[1].AgentShare != 0 {
+		t.Errorf("Files = %+v, want generated.go first with 60 agent lines", report.Files)
+	}
+
+	md := report.markdown()
+	for _, want := range []string{
+		prReportMarker,
+		"## Entire attribution: 75.0% agent-written (60 of 80 lines)",
+		"2 of 3 commit(s) have attribution recorded.",
+		"| `generated.go` | 60 | 60 | 100% |",
+		"- [`2026-10-14-pr-one`](https://example.com/a1/b2c3d4e5f6) (Claude Code): 30 agent lines in 1111111, checkpoint `a1b2c3d4e5f6`",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown() missing %q:\n%s", want, md)
+		}
+	}
+}
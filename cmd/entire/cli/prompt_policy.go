@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// Prompt policies (prompt_policy in settings) are checked by the
+// prompt-submit hooks before anything is captured. The first policy a prompt
+// violates stops it: the hook answers {"continue": false, "stopReason": ...}
+// and the agent shows the reason instead of running the prompt. A policy that
+// can't be checked, e.g. on an unborn branch, lets the prompt through, like
+// every other hook failure.
+//
+// With protected_branch_action "branch", a prompt on a protected branch
+// isn't stopped: the hook first moves the worktree to a new session branch
+// (see switchToSessionBranch), so every session starts off its own branch.
+
+// maxListedPolicyPaths is how many offending files a stop reason names.
+const maxListedPolicyPaths = 5
+
+// sessionBranchIDLength is how much of the session ID names its branch.
+const sessionBranchIDLength = 8
+
+// maxSessionBranchAttempts bounds the numbered names tried when a session's
+// branch name is taken.
+const maxSessionBranchAttempts = 10
+
+// promptPolicyEnv is what the policies look at. Changes is a function so
+// the diff only runs when a policy needs it, and tests can stub it.
+type promptPolicyEnv struct {
+	// Branch is HEAD's branch, "" when detached.
+	Branch string
+	// Cwd is the directory the prompt was submitted from, relative to the
+	// repository root and slash-separated.
+	Cwd string
+	// Changes returns the uncommitted changes: lines added plus removed per
+	// tracked file, and 0 for untracked files.
+	Changes func() (map[string]int, error)
+}
+
+// promptPolicy is one prompt-time check. It returns the stop reason of a
+// violation, "" if the prompt may go ahead.
+type promptPolicy struct {
+	name  string
+	check func(p *settings.PromptPolicySettings, env promptPolicyEnv) (string, error)
+}
+
+// promptPolicies run in order; the first violation stops the prompt.
+var promptPolicies = []promptPolicy{
+	{name: "protected_branches", check: checkProtectedBranch},
+	{name: "allowed_paths", check: checkAllowedPaths},
+	{name: "max_dirty_lines", check: checkDirtyLines},
+}
+
+// evaluatePromptPolicies returns the stop reason of the first policy the
+// prompt violates, "" if there is none.
+func evaluatePromptPolicies(ctx context.Context, p *settings.PromptPolicySettings, env promptPolicyEnv) string {
+	if !p.IsSet() {
+		return ""
+	}
+	// Several policies look at the changes; diff once
+	changes := env.Changes
+	var cached map[string]int
+	var cachedErr error
+	var diffed bool
+	env.Changes = func() (map[string]int, error) {
+		if !diffed {
+			cached, cachedErr = changes()
+			diffed = true
+		}
+		return cached, cachedErr
+	}
+	for _, policy := range promptPolicies {
+		reason, err := policy.check(p, env)
+		if err != nil {
+			logging.Warn(ctx, "skipping prompt policy", slog.String("policy", policy.name), slog.String("error", err.Error()))
+			continue
+		}
+		if reason != "" {
+			logging.Info(ctx, "prompt stopped by policy", slog.String("policy", policy.name))
+			return reason
+		}
+	}
+	return ""
+}
+
+func checkProtectedBranch(p *settings.PromptPolicySettings, env promptPolicyEnv) (string, error) {
+	if env.Branch == "" {
+		return "", nil
+	}
+	pattern, err := p.ProtectedBranch(env.Branch)
+	if err != nil || pattern == "" {
+		return "", err
+	}
+	return fmt.Sprintf("Entire: %s is a protected branch (prompt_policy.protected_branches: %q), agents don't run on it. Switch to a branch of your own, e.g. `git switch -c my-change`, and resubmit the prompt.", env.Branch, pattern), nil
+}
+
+func checkAllowedPaths(p *settings.PromptPolicySettings, env promptPolicyEnv) (string, error) {
+	allowed, err := p.EffectiveAllowedPaths()
+	if err != nil || len(allowed) == 0 {
+		return "", err
+	}
+	list := strings.Join(allowed, ", ")
+	if !insideAllowedPaths(env.Cwd, allowed) {
+		return fmt.Sprintf("Entire: this session runs in %s, outside prompt_policy.allowed_paths (%s). Start the agent in one of those directories.", displayRepoPath(env.Cwd), list), nil
+	}
+	changes, err := env.Changes()
+	if err != nil {
+		return "", err
+	}
+	var outside []string
+	for file := range changes {
+		if !insideAllowedPaths(file, allowed) {
+			outside = append(outside, file)
+		}
+	}
+	if len(outside) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Entire: %s uncommitted changes outside prompt_policy.allowed_paths (%s): %s. Commit, stash or revert them and resubmit the prompt.",
+		pluralFiles(len(outside)), list, listPolicyPaths(outside)), nil
+}
+
+func checkDirtyLines(p *settings.PromptPolicySettings, env promptPolicyEnv) (string, error) {
+	if p.MaxDirtyLines <= 0 {
+		return "", nil
+	}
+	changes, err := env.Changes()
+	if err != nil {
+		return "", err
+	}
+	var total int
+	for _, lines := range changes {
+		total += lines
+	}
+	if total <= p.MaxDirtyLines {
+		return "", nil
+	}
+	return fmt.Sprintf("Entire: the worktree has %d uncommitted changed lines, over prompt_policy.max_dirty_lines (%d). Commit or stash your changes so this prompt's work can be reviewed on its own, then resubmit it.",
+		total, p.MaxDirtyLines), nil
+}
+
+// leaveProtectedBranch switches a prompt on a protected branch to a session
+// branch if the policy asks for that. It returns the branch switched to, ""
+// if there was nothing to do. On error HEAD is unchanged and the
+// protected_branches policy stops the prompt as usual.
+func leaveProtectedBranch(p *settings.PromptPolicySettings, repoRoot, sessionID string, env promptPolicyEnv) (string, error) {
+	if env.Branch == "" {
+		return "", nil
+	}
+	action, err := p.EffectiveProtectedBranchAction()
+	if err != nil || action != settings.ProtectedBranchSwitch {
+		return "", err
+	}
+	pattern, err := p.ProtectedBranch(env.Branch)
+	if err != nil || pattern == "" {
+		return "", err
+	}
+	prefix, err := p.EffectiveSessionBranchPrefix()
+	if err != nil {
+		return "", err
+	}
+	return switchToSessionBranch(repoRoot, prefix+sessionBranchID(sessionID))
+}
+
+// sessionBranchID is the start of the session ID, reduced to characters
+// that are safe in a branch name.
+func sessionBranchID(sessionID string) string {
+	var b strings.Builder
+	for _, r := range sessionID {
+		if b.Len() == sessionBranchIDLength {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return unknownSessionID
+	}
+	return b.String()
+}
+
+// switchToSessionBranch creates the named branch at HEAD and switches to it,
+// keeping uncommitted changes in the worktree. If the name is taken, e.g. by
+// an earlier prompt of the same session that was switched back, the first
+// free name-2, name-3, ... is used instead. It returns the branch created.
+func switchToSessionBranch(repoRoot, name string) (string, error) {
+	for attempt := 1; attempt <= maxSessionBranchAttempts; attempt++ {
+		branch := name
+		if attempt > 1 {
+			branch = fmt.Sprintf("%s-%d", name, attempt)
+		}
+		if err := ValidateBranchName(branch); err != nil {
+			return "", err
+		}
+		if _, err := gitOutput(repoRoot, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			continue
+		}
+		if _, err := gitOutput(repoRoot, "checkout", "-q", "-b", branch); err != nil {
+			return "", fmt.Errorf("failed to create session branch %s: %w", branch, err)
+		}
+		return branch, nil
+	}
+	return "", fmt.Errorf("failed to create session branch: %s and %d numbered variants already exist", name, maxSessionBranchAttempts-1)
+}
+
+// insideAllowedPaths reports whether the repository-relative file is one of
+// the allowed directories or inside one.
+func insideAllowedPaths(file string, allowed []string) bool {
+	for _, dir := range allowed {
+		if dir == "." || file == dir || strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func displayRepoPath(rel string) string {
+	if rel == "." {
+		return "the repository root"
+	}
+	return rel + "/"
+}
+
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "1 file has"
+	}
+	return strconv.Itoa(n) + " files have"
+}
+
+// listPolicyPaths names the first maxListedPolicyPaths files, sorted.
+func listPolicyPaths(files []string) string {
+	slices.Sort(files)
+	if len(files) <= maxListedPolicyPaths {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:maxListedPolicyPaths], ", "), len(files)-maxListedPolicyPaths)
+}
+
+// currentPromptPolicyEnv describes the worktree the prompt was submitted in.
+func currentPromptPolicyEnv(repoRoot string) promptPolicyEnv {
+	env := promptPolicyEnv{Cwd: "."}
+	if branch, err := GetCurrentBranch(); err == nil {
+		env.Branch = branch
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(repoRoot, cwd); err == nil && !strings.HasPrefix(rel, "..") {
+			env.Cwd = filepath.ToSlash(rel)
+		}
+	}
+	env.Changes = func() (map[string]int, error) { return uncommittedChanges(repoRoot) }
+	return env
+}
+
+// uncommittedChanges returns the lines added plus removed per tracked file
+// changed since HEAD, and untracked files with 0 lines. Entire's own
+// .entire/ files aren't counted.
+func uncommittedChanges(repoRoot string) (map[string]int, error) {
+	changes := make(map[string]int)
+	numstat, err := gitOutput(repoRoot, "diff", "HEAD", "--numstat", "--no-renames", "-z")
+	if err != nil {
+		return nil, err
+	}
+	// "<added>\t<removed>\t<path>\0", "-" counts for binary files
+	for _, record := range strings.Split(numstat, "\x00") {
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])   //nolint:errcheck // "-" for binary files counts as 0
+		removed, _ := strconv.Atoi(fields[1]) //nolint:errcheck // "-" for binary files counts as 0
+		if !strings.HasPrefix(fields[2], paths.EntireDir+"/") {
+			changes[fields[2]] = added + removed
+		}
+	}
+	untracked, err := gitOutput(repoRoot, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(untracked, "\x00") {
+		if _, ok := changes[file]; !ok && file != "" && !strings.HasPrefix(file, paths.EntireDir+"/") {
+			changes[file] = 0
+		}
+	}
+	return changes, nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(context.Background(), "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+func TestEvaluatePromptPolicies(t *testing.T) {
+	t.Parallel()
+
+	changes := func(c map[string]int) func() (map[string]int, error) {
+		return func() (map[string]int, error) { return c, nil }
+	}
+	tests := []struct {
+		name   string
+		policy *settings.PromptPolicySettings
+		env    promptPolicyEnv
+		want   string // substring of the stop reason, "" = not stopped
+	}{
+		{
+			name:   "no policy",
+			policy: nil,
+			env:    promptPolicyEnv{Branch: "main", Cwd: "."},
+		},
+		{
+			name:   "protected branch",
+			policy: &settings.PromptPolicySettings{ProtectedBranches: []string{"main", "release/*"}},
+			env:    promptPolicyEnv{Branch: "release/2.0", Cwd: "."},
+			want:   `release/2.0 is a protected branch (prompt_policy.protected_branches: "release/*")`,
+		},
+		{
+			name:   "unprotected branch",
+			policy: &settings.PromptPolicySettings{ProtectedBranches: []string{"main"}},
+			env:    promptPolicyEnv{Branch: "feature", Cwd: "."},
+		},
+		{
+			name:   "detached HEAD isn't a protected branch",
+			policy: &settings.PromptPolicySettings{ProtectedBranches: []string{"*"}},
+			env:    promptPolicyEnv{Cwd: "."},
+		},
+		{
+			name:   "dirty over the limit",
+			policy: &settings.PromptPolicySettings{MaxDirtyLines: 100},
+			env:    promptPolicyEnv{Cwd: ".", Changes: changes(map[string]int{"a.go": 60, "b.go": 41})},
+			want:   "101 uncommitted changed lines, over prompt_policy.max_dirty_lines (100)",
+		},
+		{
+			name:   "dirty at the limit",
+			policy: &settings.PromptPolicySettings{MaxDirtyLines: 100},
+			env:    promptPolicyEnv{Cwd: ".", Changes: changes(map[string]int{"a.go": 100})},
+		},
+		{
+			name:   "submitted outside the allowed paths",
+			policy: &settings.PromptPolicySettings{AllowedPaths: []string{"services/api", "libs"}},
+			env:    promptPolicyEnv{Cwd: "docs", Changes: changes(nil)},
+			want:   "this session runs in docs/, outside prompt_policy.allowed_paths (services/api, libs)",
+		},
+		{
+			name:   "changes outside the allowed paths",
+			policy: &settings.PromptPolicySettings{AllowedPaths: []string{"services/api"}},
+			env: promptPolicyEnv{Cwd: "services/api/handlers", Changes: changes(map[string]int{
+				"services/api/main.go": 3, "services/apiv2/main.go": 1, "infra/main.tf": 0,
+			})},
+			want: "2 files have uncommitted changes outside prompt_policy.allowed_paths (services/api): infra/main.tf, services/apiv2/main.go.",
+		},
+		{
+			name:   "inside the allowed paths",
+			policy: &settings.PromptPolicySettings{AllowedPaths: []string{"services/api"}},
+			env:    promptPolicyEnv{Cwd: "services/api", Changes: changes(map[string]int{"services/api/main.go": 3})},
+		},
+		{
+			name:   "the first violation wins",
+			policy: &settings.PromptPolicySettings{ProtectedBranches: []string{"main"}, MaxDirtyLines: 1},
+			env:    promptPolicyEnv{Branch: "main", Cwd: ".", Changes: changes(map[string]int{"a.go": 10})},
+			want:   "protected branch",
+		},
+		{
+			name:   "a policy that can't be checked lets the prompt through",
+			policy: &settings.PromptPolicySettings{MaxDirtyLines: 1},
+			env: promptPolicyEnv{Cwd: ".", Changes: func() (map[string]int, error) {
+				return nil, errors.New("unborn branch")
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := evaluatePromptPolicies(context.Background(), tt.policy, tt.env)
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("evaluatePromptPolicies() = %q, want the prompt let through", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("evaluatePromptPolicies() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluatePromptPolicies_DiffsOnce(t *testing.T) {
+	t.Parallel()
+	var calls int
+	env := promptPolicyEnv{Cwd: ".", Changes: func() (map[string]int, error) {
+		calls++
+		return map[string]int{"a.go": 1}, nil
+	}}
+	policy := &settings.PromptPolicySettings{AllowedPaths: []string{"."}, MaxDirtyLines: 10}
+	if got := evaluatePromptPolicies(context.Background(), policy, env); got != "" {
+		t.Errorf("evaluatePromptPolicies() = %q", got)
+	}
+	if calls != 1 {
+		t.Errorf("Changes called %d times, want once", calls)
+	}
+}
+
+func TestListPolicyPaths(t *testing.T) {
+	t.Parallel()
+	got := listPolicyPaths([]string{"g", "f", "e", "d", "c", "b", "a"})
+	if got != "a, b, c, d, e and 2 more" {
+		t.Errorf("listPolicyPaths() = %q", got)
+	}
+}
+
+func TestUncommittedChanges(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun("init", "-q")
+	write("a.txt", "one\ntwo\nthree\n")
+	write("src/b.txt", "b\n")
+	write(".entire/settings.json", "{}\n")
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "initial")
+
+	write("a.txt", "one\nTWO\nthree\nfour\n")       // 2 added, 1 removed
+	write("src/b.txt", "b\nstaged\n")               // 1 added, staged below
+	write("new dir/untracked.txt", "not counted\n") // untracked
+	write(".entire/settings.json", `{"enabled": true}`+"\n")
+	gitRun("add", "src/b.txt")
+
+	changes, err := uncommittedChanges(dir)
+	if err != nil {
+		t.Fatalf("uncommittedChanges() error = %v", err)
+	}
+	want := map[string]int{"a.txt": 3, "src/b.txt": 1, "new dir/untracked.txt": 0}
+	if len(changes) != len(want) {
+		t.Errorf("uncommittedChanges() = %v, want %v", changes, want)
+	}
+	for file, lines := range want {
+		if got, ok := changes[file]; !ok || got != lines {
+			t.Errorf("uncommittedChanges()[%q] = %d, %t; want %d", file, got, ok, lines)
+		}
+	}
+}
+
+func TestSessionBranchID(t *testing.T) {
+	t.Parallel()
+	for sessionID, want := range map[string]string{
+		"3f2a9c1e-77b0-4d5e-9a1b-0c2d3e4f5a6b": "3f2a9c1e",
+		"ab/c d":                               "abcd",
+		"":                                     unknownSessionID,
+	} {
+		if got := sessionBranchID(sessionID); got != want {
+			t.Errorf("sessionBranchID(%q) = %q, want %q", sessionID, got, want)
+		}
+	}
+}
+
+func TestLeaveProtectedBranch(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	gitRun := func(args ...string) string {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	gitRun("init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "initial")
+	gitRun("branch", "agent/3f2a9c1e")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := promptPolicyEnv{Branch: "main", Cwd: "."}
+
+	block := &settings.PromptPolicySettings{ProtectedBranches: []string{"main"}}
+	if branch, err := leaveProtectedBranch(block, dir, "3f2a9c1e-77b0", env); err != nil || branch != "" {
+		t.Errorf("leaveProtectedBranch(block) = %q, %v; want nothing done", branch, err)
+	}
+
+	policy := &settings.PromptPolicySettings{ProtectedBranches: []string{"main"}, ProtectedBranchAction: settings.ProtectedBranchSwitch}
+	if branch, err := leaveProtectedBranch(policy, dir, "3f2a9c1e-77b0", promptPolicyEnv{Branch: "feature", Cwd: "."}); err != nil || branch != "" {
+		t.Errorf("leaveProtectedBranch(feature) = %q, %v; want nothing done", branch, err)
+	}
+	// agent/3f2a9c1e is taken, so the next free name is used
+	branch, err := leaveProtectedBranch(policy, dir, "3f2a9c1e-77b0", env)
+	if err != nil || branch != "agent/3f2a9c1e-2" {
+		t.Fatalf("leaveProtectedBranch() = %q, %v; want agent/3f2a9c1e-2", branch, err)
+	}
+	if head := gitRun("symbolic-ref", "--short", "HEAD"); head != branch {
+		t.Errorf("HEAD = %s, want %s", head, branch)
+	}
+	if status := gitRun("status", "--porcelain"); status != "M a.txt" {
+		t.Errorf("status = %q, want the uncommitted change kept", status)
+	}
+	// The new branch isn't protected, so the prompt goes ahead on it
+	env.Branch = branch
+	if reason := evaluatePromptPolicies(context.Background(), policy, env); reason != "" {
+		t.Errorf("evaluatePromptPolicies() = %q on the session branch", reason)
+	}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// errPushBlocked is returned by the pre-push hook when the push policy
+// rejects a commit. The hook script fails the push on it (exit status 1).
+var errPushBlocked = NewSilentError(errors.New("push blocked by the push policy"))
+
+// pushUpdate is one ref update git passes to the pre-push hook on stdin.
+type pushUpdate struct {
+	LocalRef, LocalSHA, RemoteRef, RemoteSHA string
+}
+
+// parsePushUpdates reads the pre-push hook's stdin:
+// "<local ref> <local sha> <remote ref> <remote sha>" per line.
+func parsePushUpdates(r io.Reader) []pushUpdate {
+	var updates []pushUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		updates = append(updates, pushUpdate{LocalRef: fields[0], LocalSHA: fields[1], RemoteRef: fields[2], RemoteSHA: fields[3]})
+	}
+	return updates
+}
+
+// pushPolicyViolation is a pushed commit the push policy rejects.
+type pushPolicyViolation struct {
+	Commit  string
+	Subject string
+	Reason  string
+}
+
+// enforcePushPolicy checks the commits git is about to push to remote
+// against policy and reports violations to errW. Returns errPushBlocked if
+// the push must fail.
+func enforcePushPolicy(ctx context.Context, errW io.Writer, remote string, updates []pushUpdate, policy *settings.PushPolicySettings) error {
+	action, err := policy.EffectiveAction()
+	if err != nil {
+		return err //nolint:wrapcheck // already describes the setting
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	violations, err := checkPushPolicy(ctx, repo, remote, updates, policy)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(errW, "Entire push policy: %d commit(s) violate the policy:\n", len(violations))
+	for _, v := range violations {
+		fmt.Fprintf(errW, "  %s %s: %s\n", v.Commit[:7], v.Subject, v.Reason)
+	}
+	if action == settings.PushPolicyWarn {
+		fmt.Fprintln(errW, "Pushing anyway (push_policy.action is warn).")
+		return nil
+	}
+	fmt.Fprintln(errW, "Push blocked. Fix or approve the commits, or ask whoever set the policy.")
+	return errPushBlocked
+}
+
+// checkPushPolicy returns the commits of updates not yet on remote that
+// violate policy, oldest first.
+func checkPushPolicy(ctx context.Context, repo *git.Repository, remote string, updates []pushUpdate, policy *settings.PushPolicySettings) ([]pushPolicyViolation, error) {
+	if !policy.IsSet() {
+		return nil, nil
+	}
+	store := checkpoint.NewGitStore(repo)
+	approval := policy.EffectiveApprovalTrailer()
+	seen := make(map[string]bool)
+	entireBranches := plumbing.NewBranchReferenceName(checkpoint.ShadowBranchPrefix).String()
+	shadowRefs := vcs.ShadowRefPrefix(vcs.Detect(repo))
+	var violations []pushPolicyViolation
+	for _, u := range updates {
+		// Deletions, and Entire's own branches, shadow refs and notes, carry no commits to check
+		if isZeroSHA(u.LocalSHA) || strings.HasPrefix(u.LocalRef, entireBranches) || strings.HasPrefix(u.LocalRef, shadowRefs) || strings.HasPrefix(u.LocalRef, "refs/notes/") {
+			continue
+		}
+		hashes, err := pushedCommits(ctx, remote, u)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hashes {
+			if seen[h] {
+				continue
+			}
+			seen[h] = true
+			commit, err := repo.CommitObject(plumbing.NewHash(h))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read commit %s: %w", h, err)
+			}
+			subject, _, _ := strings.Cut(commit.Message, "\n")
+			if reason := pushPolicyReason(ctx, store, commit.Message, policy, approval); reason != "" {
+				violations = append(violations, pushPolicyViolation{Commit: h, Subject: subject, Reason: reason})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// pushPolicyReason returns why a commit with message violates policy, or "".
+func pushPolicyReason(ctx context.Context, store *checkpoint.GitStore, message string, policy *settings.PushPolicySettings, approval string) string {
+	cpID, found := trailers.ParseCheckpoint(message)
+	if !found {
+		return ""
+	}
+	agentLines, totalCommitted, attributed := commitAttributionTotals(ctx, store, cpID)
+	if !attributed {
+		if policy.RequireAttribution {
+			return fmt.Sprintf("checkpoint %s has no attribution recorded", cpID)
+		}
+		return ""
+	}
+	if policy.MaxAgentPercentage <= 0 || totalCommitted <= 0 {
+		return ""
+	}
+	share := float64(agentLines) / float64(totalCommitted) * 100
+	if share <= policy.MaxAgentPercentage || trailers.HasTrailer(message, approval) {
+		return ""
+	}
+	loc := reportfmt.DetectLocale()
+	return fmt.Sprintf("agent share %s is above %s without an %q trailer",
+		loc.Percent(share, 1), loc.Percent(policy.MaxAgentPercentage, 1), approval+":")
+}
+
+// commitAttributionTotals adds up the attribution of a checkpoint's sessions
+// the way `entire attribution list` does.
+func commitAttributionTotals(ctx context.Context, store *checkpoint.GitStore, cpID id.CheckpointID) (agentLines, totalCommitted int, attributed bool) {
+	summary, err := store.ReadCommitted(ctx, cpID)
+	if err != nil || summary == nil {
+		return 0, 0, false
+	}
+	for i := range summary.Sessions {
+		metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+		if err != nil {
+			continue
+		}
+		if attr := metadata.InitialAttribution; attr != nil && attr.SupersededBy == "" {
+			attributed = true
+			agentLines += attr.AgentLines
+			totalCommitted = max(totalCommitted, attr.TotalCommitted)
+		}
+	}
+	return agentLines, totalCommitted, attributed
+}
+
+// pushedCommits lists the commits of u that remote doesn't have yet, oldest
+// first: since the remote's old tip, or not on any of its branches for a new
+// branch or an old tip we haven't fetched.
+func pushedCommits(ctx context.Context, remote string, u pushUpdate) ([]string, error) {
+	revList := func(exclude string) ([]string, error) {
+		output, err := exec.CommandContext(ctx, "git", "rev-list", "--reverse", u.LocalSHA, "--not", exclude).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pushed commits of %s: %w", u.LocalRef, err)
+		}
+		return strings.Fields(string(output)), nil
+	}
+	if !isZeroSHA(u.RemoteSHA) {
+		if hashes, err := revList(u.RemoteSHA); err == nil {
+			return hashes, nil
+		}
+	}
+	return revList("--remotes=" + remote)
+}
+
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
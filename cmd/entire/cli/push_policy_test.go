@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func storePolicyTestCommit(t *testing.T, repo *git.Repository, message string, parent plumbing.Hash) plumbing.Hash {
+	t.Helper()
+	parentCommit, err := repo.CommitObject(parent)
+	if err != nil {
+		t.Fatalf("failed to read parent: %v", err)
+	}
+	sig := object.Signature{Name: "test", Email: "test@test.com"}
+	commit := &object.Commit{TreeHash: parentCommit.TreeHash, Author: sig, Committer: sig, Message: message, ParentHashes: []plumbing.Hash{parent}}
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("failed to store commit: %v", err)
+	}
+	return hash
+}
+
+func TestCheckPushPolicy(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, initial := setupCleanTestRepo(t)
+	store := checkpoint.NewGitStore(repo)
+	for _, cp := range []struct {
+		id        string
+		agentPct  float64
+		agentLine int
+	}{{"a1b2c3d4e5f6", 90, 9}, {"b1b2c3d4e5f6", 90, 9}, {"c1b2c3d4e5f6", 50, 5}} {
+		if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+			CheckpointID: id.MustCheckpointID(cp.id),
+			SessionID:    "2026-10-14-policy-" + cp.id,
+			Strategy:     "manual-commit",
+			InitialAttribution: &checkpoint.InitialAttribution{
+				AgentLines: cp.agentLine, HumanAdded: 10 - cp.agentLine, TotalCommitted: 10, AgentPercentage: cp.agentPct,
+			},
+		}); err != nil {
+			t.Fatalf("WriteCommitted() error = %v", err)
+		}
+	}
+
+	tip := initial
+	for _, message := range []string{
+		"over threshold\n\nEntire-Checkpoint: a1b2c3d4e5f6\n",
+		"approved\n\nEntire-Checkpoint: b1b2c3d4e5f6\nAI-Approved-By: Jane\n",
+		"under threshold\n\nEntire-Checkpoint: c1b2c3d4e5f6\n",
+		"lost checkpoint\n\nEntire-Checkpoint: d1b2c3d4e5f6\n",
+		"human only",
+	} {
+		tip = storePolicyTestCommit(t, repo, message, tip)
+	}
+	updates := parsePushUpdates(strings.NewReader(
+		"refs/heads/master " + tip.String() + " refs/heads/master " + initial.String() + "\n" +
+			"(delete) 0000000000000000000000000000000000000000 refs/heads/old " + initial.String() + "\n"))
+	if len(updates) != 2 {
+		t.Fatalf("parsePushUpdates() = %+v, want 2 updates", updates)
+	}
+
+	policy := &settings.PushPolicySettings{RequireAttribution: true, MaxAgentPercentage: 80}
+	violations, err := checkPushPolicy(context.Background(), repo, "origin", updates, policy)
+	if err != nil {
+		t.Fatalf("checkPushPolicy() error = %v", err)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("violations = %+v, want the commit over the threshold and the one without attribution", violations)
+	}
+	if violations[0].Subject != "over threshold" || !strings.Contains(violations[0].Reason, `90.0% is above 80.0% without an "AI-Approved-By:" trailer`) {
+		t.Errorf("violations[0] = %+v", violations[0])
+	}
+	if violations[1].Subject != "lost checkpoint" || !strings.Contains(violations[1].Reason, "no attribution recorded") {
+		t.Errorf("violations[1] = %+v", violations[1])
+	}
+
+	// Without require_attribution, only the threshold applies
+	policy.RequireAttribution = false
+	if violations, err := checkPushPolicy(context.Background(), repo, "origin", updates, policy); err != nil || len(violations) != 1 {
+		t.Errorf("checkPushPolicy() = %+v, %v; want only the commit over the threshold", violations, err)
+	}
+}
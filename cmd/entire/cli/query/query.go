@@ -0,0 +1,562 @@
+// Package query parses and evaluates the expressions of `entire query`, a
+// small SQL-like language over the rows of a data source:
+//
+//	[select FIELD, ... from] SOURCE [where CONDITION] [since TIME] [until TIME]
+//	    [order by FIELD [asc|desc]] [limit N]
+//
+// Conditions compare a field with a value using =, !=, <, <=, >, >= or ~
+// (contains, ignoring case), test a boolean field on its own, and combine
+// with and, or, not and parentheses. Values are numbers, bare words or
+// quoted strings. Keywords are case-insensitive; field names are not.
+//
+// The package knows nothing about where rows come from: callers describe
+// their sources as a Schema, build Rows, and resolve since/until themselves.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a field's values.
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Bool
+	Time
+)
+
+// Field is a column of a source.
+type Field struct {
+	Name string
+	Kind Kind
+}
+
+// Schema maps source names to their fields, in output order.
+type Schema map[string][]Field
+
+// Row is one row of a source, by field name. Values are string, float64,
+// bool or time.Time according to the field's kind; nil means no value, and
+// never matches a comparison.
+type Row map[string]any
+
+// Query is a parsed query.
+type Query struct {
+	Source string
+	// Fields are the selected fields, all of the source's when none were
+	// selected.
+	Fields []Field
+	// Where is nil when every row matches.
+	Where Expr
+	// Since and Until are the raw since/until values, "" when absent.
+	Since, Until string
+	// OrderBy is "" to keep the rows in the source's order.
+	OrderBy string
+	Desc    bool
+	// Limit is 0 for no limit.
+	Limit int
+}
+
+// Expr is a condition on a row.
+type Expr interface {
+	Match(row Row) bool
+}
+
+// Parse parses input against schema, checking that the source and every
+// field exist and that values fit their fields.
+func Parse(input string, schema Schema) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, schema: schema}
+	return p.parseQuery()
+}
+
+// Run filters, orders and limits rows as q says. Since/until must already
+// have been applied by the caller.
+func (q *Query) Run(rows []Row) []Row {
+	var out []Row
+	for _, row := range rows {
+		if q.Where == nil || q.Where.Match(row) {
+			out = append(out, row)
+		}
+	}
+	if q.OrderBy != "" {
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := out[i][q.OrderBy], out[j][q.OrderBy]
+			if a == nil || b == nil {
+				return a != nil // missing values last
+			}
+			if q.Desc {
+				a, b = b, a
+			}
+			return less(a, b)
+		})
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
+
+// less orders two values of the same kind.
+func less(a, b any) bool {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		return ok && av < bv
+	case string:
+		bv, ok := b.(string)
+		return ok && av < bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && !av && bv
+	case time.Time:
+		bv, ok := b.(time.Time)
+		return ok && av.Before(bv)
+	}
+	return false
+}
+
+// FieldNames returns the names of source's fields, in order.
+func (s Schema) FieldNames(source string) []string {
+	names := make([]string, 0, len(s[source]))
+	for _, f := range s[source] {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func (s Schema) field(source, name string) (Field, error) {
+	for _, f := range s[source] {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return Field{}, fmt.Errorf("unknown field %q: %s has %s", name, source, strings.Join(s.FieldNames(source), ", "))
+}
+
+// Expressions
+
+type andExpr struct{ left, right Expr }
+
+func (e andExpr) Match(row Row) bool { return e.left.Match(row) && e.right.Match(row) }
+
+type orExpr struct{ left, right Expr }
+
+func (e orExpr) Match(row Row) bool { return e.left.Match(row) || e.right.Match(row) }
+
+type notExpr struct{ expr Expr }
+
+func (e notExpr) Match(row Row) bool { return !e.expr.Match(row) }
+
+// truthExpr tests a boolean field on its own.
+type truthExpr struct{ field string }
+
+func (e truthExpr) Match(row Row) bool {
+	v, ok := row[e.field].(bool)
+	return ok && v
+}
+
+// compareExpr compares a field with a value of the field's kind.
+type compareExpr struct {
+	field string
+	op    string
+	value any
+}
+
+func (e compareExpr) Match(row Row) bool {
+	v := row[e.field]
+	if v == nil {
+		return false
+	}
+	if e.op == "~" {
+		s, ok := v.(string)
+		return ok && strings.Contains(strings.ToLower(s), strings.ToLower(e.value.(string))) //nolint:forcetypeassert // checked at parse time
+	}
+	var cmp int
+	switch fv := v.(type) {
+	case float64:
+		cmp = compareOrdered(fv, e.value.(float64)) //nolint:forcetypeassert // checked at parse time
+	case string:
+		cmp = strings.Compare(fv, e.value.(string)) //nolint:forcetypeassert // checked at parse time
+	case bool:
+		if fv == e.value.(bool) { //nolint:forcetypeassert // checked at parse time
+			cmp = 0
+		} else {
+			cmp = 1
+		}
+	default:
+		return false
+	}
+	switch e.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits input into tokens. Words run until whitespace, a quote, a
+// parenthesis, a comma or an operator, so ages (30d), dates and branch names
+// (feature/x) are single words.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ","})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(input[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string starting at %q", input[i:])
+			}
+			tokens = append(tokens, token{tokString, input[i+1 : i+1+end]})
+			i += end + 2
+		case strings.IndexByte("=!<>~", c) >= 0:
+			op := string(c)
+			if i+1 < len(input) && input[i+1] == '=' && c != '~' {
+				op += "="
+			}
+			i += len(op)
+			switch op {
+			case "!":
+				return nil, errors.New("unexpected \"!\": use != or not")
+			case "==":
+				op = "="
+			}
+			tokens = append(tokens, token{tokOp, op})
+		default:
+			start := i
+			for i < len(input) && strings.IndexByte(" \t\n\r()',\"=!<>~", input[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, input[start:i]})
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+// Parser
+
+// clauseKeywords end a where condition.
+var clauseKeywords = []string{"since", "until", "order", "limit"}
+
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+	source string
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the keyword kw, consuming it if so.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokWord && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) word(what string) (string, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return "", fmt.Errorf("expected %s, got %s", what, describe(t))
+	}
+	return t.text, nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	var selected []string
+	if p.keyword("select") {
+		for {
+			name, err := p.word("a field name")
+			if err != nil {
+				return nil, err
+			}
+			if name != "*" {
+				selected = append(selected, name)
+			}
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+		if !p.keyword("from") {
+			return nil, fmt.Errorf("expected from, got %s", describe(p.peek()))
+		}
+	}
+
+	sources := make([]string, 0, len(p.schema))
+	for name := range p.schema {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+	source, err := p.word("a source (" + strings.Join(sources, ", ") + ")")
+	if err != nil {
+		return nil, err
+	}
+	source = strings.ToLower(source)
+	if _, ok := p.schema[source]; !ok {
+		return nil, fmt.Errorf("unknown source %q: use %s", source, strings.Join(sources, " or "))
+	}
+	p.source = source
+
+	q := &Query{Source: source, Fields: p.schema[source]}
+	if len(selected) > 0 {
+		q.Fields = nil
+		for _, name := range selected {
+			f, err := p.schema.field(source, name)
+			if err != nil {
+				return nil, err
+			}
+			q.Fields = append(q.Fields, f)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for p.peek().kind != tokEOF {
+		clause, err := p.word("where, since, until, order by or limit")
+		if err != nil {
+			return nil, err
+		}
+		clause = strings.ToLower(clause)
+		if seen[clause] {
+			return nil, fmt.Errorf("%s given twice", clause)
+		}
+		seen[clause] = true
+		switch clause {
+		case "where":
+			if q.Where, err = p.parseOr(); err != nil {
+				return nil, err
+			}
+		case "since":
+			if q.Since, err = p.value("a time after since"); err != nil {
+				return nil, err
+			}
+		case "until":
+			if q.Until, err = p.value("a time after until"); err != nil {
+				return nil, err
+			}
+		case "order":
+			if !p.keyword("by") {
+				return nil, fmt.Errorf("expected by after order, got %s", describe(p.peek()))
+			}
+			name, err := p.word("a field name after order by")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.schema.field(source, name); err != nil {
+				return nil, err
+			}
+			q.OrderBy = name
+			if p.keyword("desc") {
+				q.Desc = true
+			} else {
+				p.keyword("asc")
+			}
+		case "limit":
+			text, err := p.word("a number after limit")
+			if err != nil {
+				return nil, err
+			}
+			if q.Limit, err = strconv.Atoi(text); err != nil || q.Limit <= 0 {
+				return nil, fmt.Errorf("invalid limit %q: use a positive number", text)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q: expected where, since, until, order by or limit", clause)
+		}
+	}
+	return q, nil
+}
+
+// value reads a word or a quoted string.
+func (p *parser) value(what string) (string, error) {
+	t := p.next()
+	if t.kind != tokWord && t.kind != tokString {
+		return "", fmt.Errorf("expected %s, got %s", what, describe(t))
+	}
+	return t.text, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.keyword("not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("expected ), got %s", describe(t))
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	if t := p.peek(); t.kind == tokWord && slices.Contains(clauseKeywords, strings.ToLower(t.text)) {
+		return nil, fmt.Errorf("expected a condition, got %s", describe(t))
+	}
+	name, err := p.word("a field name")
+	if err != nil {
+		return nil, err
+	}
+	f, err := p.schema.field(p.source, name)
+	if err != nil {
+		return nil, err
+	}
+	if f.Kind == Time {
+		return nil, fmt.Errorf("%s is a time: filter it with since and until", name)
+	}
+	if p.peek().kind != tokOp {
+		if f.Kind != Bool {
+			return nil, fmt.Errorf("expected an operator after %s, got %s", name, describe(p.peek()))
+		}
+		return truthExpr{field: name}, nil
+	}
+	op := p.next().text
+	text, err := p.value("a value after " + name + " " + op)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := compareExpr{field: name, op: op}
+	switch f.Kind {
+	case Number:
+		if op == "~" {
+			return nil, fmt.Errorf("~ only applies to text, and %s is a number", name)
+		}
+		n, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is a number, not %q", name, text)
+		}
+		expr.value = n
+	case Bool:
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("%s is true or false: compare it with = or !=", name)
+		}
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s is true or false, not %q", name, text)
+		}
+		expr.value = b
+	default:
+		expr.value = text
+	}
+	return expr, nil
+}
@@ -0,0 +1,111 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+var testSchema = Schema{
+	"sessions": {
+		{Name: "session_id", Kind: String},
+		{Name: "agent", Kind: String},
+		{Name: "branch", Kind: String},
+		{Name: "agent_pct", Kind: Number},
+		{Name: "automated", Kind: Bool},
+		{Name: "created_at", Kind: Time},
+	},
+	"checkpoints": {
+		{Name: "checkpoint_id", Kind: String},
+	},
+}
+
+var testRows = []Row{
+	{"session_id": "a", "agent": "Claude Code", "branch": "main", "agent_pct": 90.0, "automated": false},
+	{"session_id": "b", "agent": "Gemini CLI", "branch": "main", "agent_pct": 40.0, "automated": true},
+	{"session_id": "c", "agent": "Claude Code", "branch": "feature/x", "agent_pct": 85.0, "automated": true},
+	{"session_id": "d", "agent": "Claude Code", "branch": "main", "agent_pct": nil, "automated": false},
+}
+
+func TestQuery_Run(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"sessions", "a,b,c,d"},
+		{"sessions where agent_pct > 80 and branch = 'main'", "a"},
+		{"SESSIONS WHERE agent_pct >= 85 OR automated", "a,b,c"},
+		{"sessions where not automated and agent_pct < 100", "a"},
+		{"sessions where agent ~ claude and (branch = feature/x or agent_pct > 89)", "a,c"},
+		{"sessions where agent_pct != 90", "b,c"},
+		{"sessions where automated = false", "a,d"},
+		{"sessions order by agent_pct desc limit 2", "a,c"},
+		{"sessions order by agent_pct", "b,c,a,d"},
+		{`select session_id from sessions where agent = "Gemini CLI"`, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query, testSchema)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var ids []string
+			for _, row := range q.Run(testRows) {
+				ids = append(ids, row["session_id"].(string)) //nolint:forcetypeassert // test rows
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("Run() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	q, err := Parse("select agent, agent_pct from sessions where agent_pct > 80 since 30d until yesterday", testSchema)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if q.Source != "sessions" || q.Since != "30d" || q.Until != "yesterday" {
+		t.Errorf("Parse() = %+v, want sessions since 30d until yesterday", q)
+	}
+	if len(q.Fields) != 2 || q.Fields[0].Name != "agent" || q.Fields[1].Kind != Number {
+		t.Errorf("Fields = %+v, want agent and agent_pct", q.Fields)
+	}
+
+	q, err = Parse("checkpoints", testSchema)
+	if err != nil || len(q.Fields) != 1 || q.Where != nil {
+		t.Errorf("Parse(checkpoints) = %+v, %v; want all fields and no condition", q, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "expected a source"},
+		{"commits", "unknown source"},
+		{"sessions where nope = 1", "unknown field \"nope\""},
+		{"sessions where agent_pct > lots", "is a number"},
+		{"sessions where agent", "expected an operator"},
+		{"sessions where automated > true", "compare it with = or !="},
+		{"sessions where created_at > 2026-01-01", "filter it with since and until"},
+		{"sessions where agent_pct ~ 8", "only applies to text"},
+		{"sessions where (agent = x", "expected )"},
+		{"sessions where agent = 'x", "unterminated string"},
+		{"sessions where agent ! x", "use != or not"},
+		{"sessions limit 0", "invalid limit"},
+		{"sessions limit 1 limit 2", "limit given twice"},
+		{"sessions order agent", "expected by"},
+		{"select agent sessions", "expected from"},
+		{"sessions where since 30d", "expected a condition"},
+		{"sessions group by agent", "unexpected \"group\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query, testSchema)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
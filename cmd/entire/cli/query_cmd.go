@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/query"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	queryFormatJSON = "json"
+	queryFormatCSV  = "csv"
+)
+
+// querySchema are the sources `entire query` reads, built from the
+// committed checkpoints' session metadata.
+var querySchema = query.Schema{
+	"sessions": {
+		{Name: "checkpoint_id", Kind: query.String},
+		{Name: "session_id", Kind: query.String},
+		{Name: "created_at", Kind: query.Time},
+		{Name: "agent", Kind: query.String},
+		{Name: "branch", Kind: query.String},
+		{Name: "task_type", Kind: query.String},
+		{Name: "automated", Kind: query.Bool},
+		{Name: "subagent", Kind: query.Bool},
+		{Name: "steps", Kind: query.Number},
+		{Name: "files", Kind: query.Number},
+		{Name: "agent_lines", Kind: query.Number},
+		{Name: "human_added", Kind: query.Number},
+		{Name: "human_modified", Kind: query.Number},
+		{Name: "human_removed", Kind: query.Number},
+		{Name: "total_committed", Kind: query.Number},
+		{Name: "agent_pct", Kind: query.Number},
+		{Name: "input_tokens", Kind: query.Number},
+		{Name: "output_tokens", Kind: query.Number},
+	},
+	"checkpoints": {
+		{Name: "checkpoint_id", Kind: query.String},
+		{Name: "created_at", Kind: query.Time},
+		{Name: "agent", Kind: query.String},
+		{Name: "branch", Kind: query.String},
+		{Name: "sessions", Kind: query.Number},
+		{Name: "files", Kind: query.Number},
+		{Name: "agent_lines", Kind: query.Number},
+		{Name: "total_committed", Kind: query.Number},
+		{Name: "agent_pct", Kind: query.Number},
+	},
+}
+
+func newQueryCmd() *cobra.Command {
+	var formatFlag string
+
+	cmd := &cobra.Command{
+		Use:   "query <expression>",
+		Short: "Query committed checkpoints with a small SQL-like language",
+		Long: `Answers ad-hoc questions about the committed checkpoints, printed as JSON
+or CSV for scripts:
+
+  entire query "sessions where agent_pct > 80 and branch = 'main' since 30d"
+  entire query "select agent, agent_pct from sessions order by agent_pct desc limit 10"
+  entire query --format csv "checkpoints where agent ~ claude until 2026-01-31"
+
+An expression is:
+
+  [select FIELD, ... from] SOURCE [where CONDITION] [since TIME] [until TIME]
+      [order by FIELD [asc|desc]] [limit N]
+
+Conditions compare a field with a value using =, !=, <, <=, >, >= or ~
+(contains, ignoring case), test a true/false field on its own, and combine
+with and, or, not and parentheses. Quote values with spaces. since and until
+take the same times as --since/--until elsewhere (2w, 30d, yesterday,
+2026-01-31). Fields without a value, such as agent_pct of a session without
+attribution, match no comparison.
+
+Sources and their fields:
+
+  sessions     ` + strings.Join(querySchema.FieldNames("sessions"), ", ") + `
+  checkpoints  ` + strings.Join(querySchema.FieldNames("checkpoints"), ", "),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if formatFlag != queryFormatJSON && formatFlag != queryFormatCSV {
+				return fmt.Errorf("invalid --format %q: use json or csv", formatFlag)
+			}
+			return runQuery(cmd.Context(), cmd.OutOrStdout(), args[0], formatFlag, time.Now())
+		},
+	}
+
+	cmd.Flags().StringVar(&formatFlag, "format", queryFormatJSON, "Output format: json or csv")
+
+	return cmd
+}
+
+func runQuery(ctx context.Context, w io.Writer, expression, format string, now time.Time) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	q, err := query.Parse(expression, querySchema)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	period, err := resolveReportPeriod(q.Since, q.Until, now)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	sessions, checkpoints, err := queryRows(ctx, checkpoint.NewGitStore(repo), period)
+	if err != nil {
+		return err
+	}
+	rows := sessions
+	if q.Source == "checkpoints" {
+		rows = checkpoints
+	}
+	rows = q.Run(rows)
+
+	if format == queryFormatCSV {
+		return writeQueryCSV(w, q.Fields, rows)
+	}
+	return writeQueryJSON(w, q.Fields, rows)
+}
+
+// queryRows reads the session and checkpoint rows of the committed
+// checkpoints created in period, newest first.
+func queryRows(ctx context.Context, store *checkpoint.GitStore, period reportPeriod) (sessions, checkpoints []query.Row, err error) {
+	infos, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	for _, info := range infos {
+		if !period.Contains(info.CreatedAt) {
+			continue
+		}
+		cp := query.Row{
+			"checkpoint_id":   info.CheckpointID.String(),
+			"created_at":      info.CreatedAt,
+			"agent":           string(info.Agent),
+			"branch":          "",
+			"sessions":        float64(max(info.SessionCount, 1)),
+			"files":           float64(len(info.FilesTouched)),
+			"agent_lines":     0.0,
+			"total_committed": 0.0,
+			"agent_pct":       nil,
+		}
+		var agentLines, totalCommitted int
+		attributed := false
+		for i := range max(info.SessionCount, 1) {
+			metadata, err := store.ReadSessionMetadata(ctx, info.CheckpointID, i)
+			if err != nil {
+				continue
+			}
+			row := query.Row{
+				"checkpoint_id":   info.CheckpointID.String(),
+				"session_id":      metadata.SessionID,
+				"created_at":      metadata.CreatedAt,
+				"agent":           string(metadata.Agent),
+				"branch":          metadata.Branch,
+				"task_type":       metadata.TaskType,
+				"automated":       len(metadata.Automation) > 0,
+				"subagent":        metadata.IsTask,
+				"steps":           float64(metadata.CheckpointsCount),
+				"files":           float64(len(metadata.FilesTouched)),
+				"agent_lines":     nil,
+				"human_added":     nil,
+				"human_modified":  nil,
+				"human_removed":   nil,
+				"total_committed": nil,
+				"agent_pct":       nil,
+				"input_tokens":    nil,
+				"output_tokens":   nil,
+			}
+			if metadata.Branch != "" {
+				cp["branch"] = metadata.Branch
+			}
+			if attr := metadata.InitialAttribution; attr != nil && attr.SupersededBy == "" && attr.Skipped == "" {
+				row["agent_lines"] = float64(attr.AgentLines)
+				row["human_added"] = float64(attr.HumanAdded)
+				row["human_modified"] = float64(attr.HumanModified)
+				row["human_removed"] = float64(attr.HumanRemoved)
+				row["total_committed"] = float64(attr.TotalCommitted)
+				row["agent_pct"] = attr.AgentPercentage
+				attributed = true
+				agentLines += attr.AgentLines
+				totalCommitted = max(totalCommitted, attr.TotalCommitted)
+			}
+			if usage := metadata.TokenUsage; usage != nil {
+				row["input_tokens"] = float64(usage.InputTokens + usage.CacheCreationTokens + usage.CacheReadTokens)
+				row["output_tokens"] = float64(usage.OutputTokens)
+			}
+			sessions = append(sessions, row)
+		}
+		if attributed {
+			cp["agent_lines"] = float64(agentLines)
+			cp["total_committed"] = float64(totalCommitted)
+			if totalCommitted > 0 {
+				cp["agent_pct"] = float64(agentLines) / float64(totalCommitted) * 100
+			}
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return sessions, checkpoints, nil
+}
+
+// writeQueryJSON writes rows as a JSON array of objects with the fields in
+// order.
+func writeQueryJSON(w io.Writer, fields []query.Field, rows []query.Row) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("{")
+		for j, f := range fields {
+			if j > 0 {
+				buf.WriteString(",")
+			}
+			key, err := json.Marshal(f.Name)
+			if err != nil {
+				return fmt.Errorf("failed to marshal query results: %w", err)
+			}
+			value, err := json.Marshal(queryJSONValue(f, row[f.Name]))
+			if err != nil {
+				return fmt.Errorf("failed to marshal query results: %w", err)
+			}
+			buf.Write(key)
+			buf.WriteString(":")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("]")
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("failed to marshal query results: %w", err)
+	}
+	out.WriteString("\n")
+	_, err := w.Write(out.Bytes())
+	return err //nolint:wrapcheck // write to stdout
+}
+
+// queryJSONValue returns v as the report JSON shows it.
+func queryJSONValue(f query.Field, v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		return reportfmt.NewTime(v)
+	case float64:
+		if f.Name == "agent_pct" {
+			return reportfmt.Percent(v)
+		}
+		return v
+	}
+	return v
+}
+
+// writeQueryCSV writes rows as CSV with a header row.
+func writeQueryCSV(w io.Writer, fields []query.Field, rows []query.Row) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(fields))
+	for _, f := range fields {
+		header = append(header, f.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, row := range rows {
+		record := make([]string, 0, len(fields))
+		for _, f := range fields {
+			var cell string
+			switch v := row[f.Name].(type) {
+			case string:
+				cell = v
+			case float64:
+				if f.Name == "agent_pct" {
+					cell = strconv.FormatFloat(v, 'f', reportfmt.PercentDecimals, 64)
+				} else {
+					cell = strconv.FormatFloat(v, 'f', -1, 64)
+				}
+			case bool:
+				cell = strconv.FormatBool(v)
+			case time.Time:
+				cell = reportfmt.Timestamp(v)
+			}
+			record = append(record, cell)
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/query"
+)
+
+func TestWriteQueryResults(t *testing.T) {
+	q, err := query.Parse("select session_id, created_at, agent_pct, automated from sessions", querySchema)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	rows := []query.Row{
+		{"session_id": "s1", "created_at": time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), "agent_pct": 87.5, "automated": true},
+		{"session_id": "s, 2", "created_at": time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC), "agent_pct": nil, "automated": false},
+	}
+
+	var buf bytes.Buffer
+	if err := writeQueryCSV(&buf, q.Fields, rows); err != nil {
+		t.Fatalf("writeQueryCSV() error = %v", err)
+	}
+	wantCSV := "session_id,created_at,agent_pct,automated\n" +
+		"s1,2026-10-01T09:00:00Z,87.50,true\n" +
+		"\"s, 2\",2026-10-02T09:00:00Z,,false\n"
+	if buf.String() != wantCSV {
+		t.Errorf("writeQueryCSV() =\n%s\nwant\n%s", buf.String(), wantCSV)
+	}
+
+	buf.Reset()
+	if err := writeQueryJSON(&buf, q.Fields, rows[:1]); err != nil {
+		t.Fatalf("writeQueryJSON() error = %v", err)
+	}
+	wantJSON := `[
+  {
+    "session_id": "s1",
+    "created_at": "2026-10-01T09:00:00Z",
+    "agent_pct": 87.50,
+    "automated": true
+  }
+]
+`
+	if buf.String() != wantJSON {
+		t.Errorf("writeQueryJSON() =\n%s\nwant\n%s", buf.String(), wantJSON)
+	}
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// reportTimeFormats are the absolute formats accepted by --since/--until,
+// interpreted in the reporting timezone unless they carry an offset.
+var reportTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// reportPeriod is the half-open time range [Since, Until) a report covers,
+// plus the calendar used to bucket it into days and weeks.
+type reportPeriod struct {
+	// Since and Until are zero when unbounded
+	Since time.Time
+	Until time.Time
+
+	// Location is the reporting timezone; nil means time.Local.
+	Location *time.Location
+	// WeekStart is the first day of a reporting week.
+	WeekStart time.Weekday
+}
+
+// Contains reports whether t falls within the period.
+func (p reportPeriod) Contains(t time.Time) bool {
+	if !p.Since.IsZero() && t.Before(p.Since) {
+		return false
+	}
+	if !p.Until.IsZero() && !t.Before(p.Until) {
+		return false
+	}
+	return true
+}
+
+// Overlaps reports whether the interval [start, end] intersects the period.
+// A zero end means the interval is still open.
+func (p reportPeriod) Overlaps(start, end time.Time) bool {
+	if !p.Until.IsZero() && !start.Before(p.Until) {
+		return false
+	}
+	if !p.Since.IsZero() && !end.IsZero() && end.Before(p.Since) {
+		return false
+	}
+	return true
+}
+
+// IsBounded reports whether --since or --until was given.
+func (p reportPeriod) IsBounded() bool {
+	return !p.Since.IsZero() || !p.Until.IsZero()
+}
+
+func (p reportPeriod) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+	return p.Location
+}
+
+// StartOfDay returns midnight of t's day in the reporting timezone.
+func (p reportPeriod) StartOfDay(t time.Time) time.Time {
+	t = t.In(p.location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns midnight on the first day of t's reporting week.
+func (p reportPeriod) StartOfWeek(t time.Time) time.Time {
+	day := p.StartOfDay(t)
+	daysIntoWeek := (int(day.Weekday()) - int(p.WeekStart) + 7) % 7
+	return day.AddDate(0, 0, -daysIntoWeek)
+}
+
+// Describe returns a human-readable description of the bounds, or "" if unbounded.
+func (p reportPeriod) Describe() string {
+	const layout = "2006-01-02 15:04"
+	switch {
+	case !p.Since.IsZero() && !p.Until.IsZero():
+		return fmt.Sprintf("%s to %s", p.Since.In(p.location()).Format(layout), p.Until.In(p.location()).Format(layout))
+	case !p.Since.IsZero():
+		return "since " + p.Since.In(p.location()).Format(layout)
+	case !p.Until.IsZero():
+		return "until " + p.Until.In(p.location()).Format(layout)
+	default:
+		return ""
+	}
+}
+
+// addReportPeriodFlags registers --since and --until on cmd.
+func addReportPeriodFlags(cmd *cobra.Command, since, until *string) {
+	cmd.Flags().StringVar(since, "since", "", "Only include activity from this time on (e.g. 2w, 30d, 12h, yesterday, 2026-01-31)")
+	cmd.Flags().StringVar(until, "until", "", "Only include activity before this time; dates include the whole day")
+}
+
+// resolveReportPeriod parses --since/--until using the reporting settings
+// (timezone and week start) from .entire/settings.json.
+func resolveReportPeriod(since, until string, now time.Time) (reportPeriod, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return reportPeriod{}, fmt.Errorf("failed to load settings: %w", err)
+	}
+	loc, err := s.Reporting.Location()
+	if err != nil {
+		return reportPeriod{}, err //nolint:wrapcheck // already describes the setting
+	}
+	weekStart, err := s.Reporting.FirstWeekday()
+	if err != nil {
+		return reportPeriod{}, err //nolint:wrapcheck // already describes the setting
+	}
+	return parseReportPeriod(since, until, now, loc, weekStart)
+}
+
+// parseReportPeriod parses --since/--until relative to now in loc.
+func parseReportPeriod(since, until string, now time.Time, loc *time.Location, weekStart time.Weekday) (reportPeriod, error) {
+	period := reportPeriod{Location: loc, WeekStart: weekStart}
+	var err error
+	if since != "" {
+		if period.Since, err = parseReportTime(since, now, loc, false); err != nil {
+			return reportPeriod{}, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if until != "" {
+		if period.Until, err = parseReportTime(until, now, loc, true); err != nil {
+			return reportPeriod{}, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !period.Since.IsZero() && !period.Until.IsZero() && !period.Since.Before(period.Until) {
+		return reportPeriod{}, errors.New("--since must be before --until")
+	}
+	return period, nil
+}
+
+// parseReportTime parses a --since/--until value:
+//
+//   - "now", "today", "yesterday"
+//   - ages such as "2w", "30d" (counted from the start of today) or "12h" (from now)
+//   - dates ("2026-01-31") and times ("2026-01-31T09:00", RFC 3339)
+//
+// Dates and day names are whole days: as an upper bound they include the day.
+func parseReportTime(s string, now time.Time, loc *time.Location, isUpperBound bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	wholeDay := func(day time.Time) time.Time {
+		if isUpperBound {
+			return day.AddDate(0, 0, 1)
+		}
+		return day
+	}
+
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "today":
+		return wholeDay(today), nil
+	case "yesterday":
+		return wholeDay(today.AddDate(0, 0, -1)), nil
+	}
+
+	if day, err := time.ParseInLocation(time.DateOnly, s, loc); err == nil {
+		return wholeDay(day), nil
+	}
+	for _, layout := range reportTimeFormats {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	if age, err := parseRetentionDuration(s); err == nil {
+		// Day and week ages align to midnight so buckets aren't cut mid-day.
+		if strings.HasSuffix(s, "d") || strings.HasSuffix(s, "w") {
+			return today.AddDate(0, 0, -int(age/(24*time.Hour))), nil
+		}
+		return now.Add(-age), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date (2026-01-31), time (2026-01-31T09:00) or age (2w, 30d, 12h)", s)
+}
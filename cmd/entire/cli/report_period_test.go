@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReportTime(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Wednesday 2026-10-14 15:30 in Berlin
+	now := time.Date(2026, 10, 14, 15, 30, 0, 0, berlin)
+
+	tests := []struct {
+		in      string
+		isUpper bool
+		want    time.Time
+	}{
+		{"now", false, now},
+		{"today", false, time.Date(2026, 10, 14, 0, 0, 0, 0, berlin)},
+		{"today", true, time.Date(2026, 10, 15, 0, 0, 0, 0, berlin)},
+		{"yesterday", false, time.Date(2026, 10, 13, 0, 0, 0, 0, berlin)},
+		{"2w", false, time.Date(2026, 9, 30, 0, 0, 0, 0, berlin)},
+		// Crosses the end of DST (2026-10-25): still midnight
+		{"30d", false, time.Date(2026, 9, 14, 0, 0, 0, 0, berlin)},
+		{"12h", false, now.Add(-12 * time.Hour)},
+		{"2026-10-01", false, time.Date(2026, 10, 1, 0, 0, 0, 0, berlin)},
+		{"2026-10-01", true, time.Date(2026, 10, 2, 0, 0, 0, 0, berlin)},
+		{"2026-10-01T09:15", true, time.Date(2026, 10, 1, 9, 15, 0, 0, berlin)},
+		{"2026-10-01T09:15:00Z", false, time.Date(2026, 10, 1, 9, 15, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseReportTime(tt.in, now, berlin, tt.isUpper)
+		if err != nil {
+			t.Errorf("parseReportTime(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseReportTime(%q, upper=%v) = %v, want %v", tt.in, tt.isUpper, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "soon", "2026-13-01", "-2w", "0d"} {
+		if _, err := parseReportTime(bad, now, berlin, false); err == nil {
+			t.Errorf("parseReportTime(%q) expected error", bad)
+		}
+	}
+}
+
+func TestParseReportPeriod(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 14, 15, 30, 0, 0, time.UTC)
+	period, err := parseReportPeriod("2026-10-01", "2026-10-07", now, time.UTC, time.Monday)
+	if err != nil {
+		t.Fatalf("parseReportPeriod() error = %v", err)
+	}
+	if !period.Contains(time.Date(2026, 10, 7, 23, 59, 0, 0, time.UTC)) {
+		t.Error("period should include the whole --until day")
+	}
+	if period.Contains(time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Error("period should end at midnight after --until")
+	}
+	if period.Contains(time.Date(2026, 9, 30, 23, 59, 0, 0, time.UTC)) {
+		t.Error("period should start at --since")
+	}
+	if got := period.Describe(); got != "2026-10-01 00:00 to 2026-10-08 00:00" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	if _, err := parseReportPeriod("2026-10-07", "2026-10-01", now, time.UTC, time.Monday); err == nil {
+		t.Error("expected error when --since is after --until")
+	}
+	if _, err := parseReportPeriod("bogus", "", now, time.UTC, time.Monday); err == nil {
+		t.Error("expected error for invalid --since")
+	}
+
+	unbounded, err := parseReportPeriod("", "", now, time.UTC, time.Monday)
+	if err != nil {
+		t.Fatalf("parseReportPeriod() error = %v", err)
+	}
+	if unbounded.IsBounded() || !unbounded.Contains(time.Time{}) {
+		t.Error("empty flags should give an unbounded period")
+	}
+}
+
+func TestReportPeriod_Overlaps(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC) }
+	period := reportPeriod{Since: day(10), Until: day(20)}
+
+	if !period.Overlaps(day(5), day(12)) {
+		t.Error("interval ending inside the period should overlap")
+	}
+	if !period.Overlaps(day(5), time.Time{}) {
+		t.Error("open interval started before the period should overlap")
+	}
+	if period.Overlaps(day(1), day(5)) {
+		t.Error("interval ending before the period should not overlap")
+	}
+	if period.Overlaps(day(20), day(25)) {
+		t.Error("interval starting at Until should not overlap")
+	}
+}
+
+func TestReportPeriod_StartOfWeek(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday
+	wed := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	monday := reportPeriod{Location: time.UTC, WeekStart: time.Monday}
+	sunday := reportPeriod{Location: time.UTC, WeekStart: time.Sunday}
+
+	if got := monday.StartOfWeek(wed).Format(time.DateOnly); got != "2026-10-12" {
+		t.Errorf("Monday week start = %s, want 2026-10-12", got)
+	}
+	if got := sunday.StartOfWeek(wed).Format(time.DateOnly); got != "2026-10-11" {
+		t.Errorf("Sunday week start = %s, want 2026-10-11", got)
+	}
+	sun := time.Date(2026, 10, 11, 8, 0, 0, 0, time.UTC)
+	if got := monday.StartOfWeek(sun).Format(time.DateOnly); got != "2026-10-05" {
+		t.Errorf("Monday week start of a Sunday = %s, want 2026-10-05", got)
+	}
+	if got := sunday.StartOfWeek(sun).Format(time.DateOnly); got != "2026-10-11" {
+		t.Errorf("Sunday week start of a Sunday = %s, want 2026-10-11", got)
+	}
+
+	// Buckets use the reporting timezone, not the timestamp's own
+	tokyo := time.FixedZone("JST", 9*60*60)
+	lateSunday := time.Date(2026, 10, 11, 20, 0, 0, 0, time.UTC) // Monday morning in Tokyo
+	inTokyo := reportPeriod{Location: tokyo, WeekStart: time.Monday}
+	if got := inTokyo.StartOfWeek(lateSunday).Format(time.DateOnly); got != "2026-10-12" {
+		t.Errorf("Tokyo week start = %s, want 2026-10-12", got)
+	}
+}
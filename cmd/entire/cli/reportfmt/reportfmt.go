@@ -0,0 +1,157 @@
+// Package reportfmt formats the numbers and dates in report output.
+//
+// Human output follows the user's locale (decimal separator, digit grouping,
+// percent sign placement). Machine output (JSON) never does: numbers have a
+// fixed number of decimals and no exponent, and timestamps are ISO-8601 in
+// UTC, so parsers downstream see the same shape on every machine.
+package reportfmt
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Decimal places of the fixed-precision machine formats.
+const (
+	PercentDecimals = 2
+	DecimalDecimals = 2
+	MoneyDecimals   = 4
+)
+
+// TimestampLayout is the machine timestamp layout: ISO-8601 in UTC with
+// second precision.
+const TimestampLayout = "2006-01-02T15:04:05Z"
+
+// HumanDateTimeLayout is the layout of dates in human output. It is the
+// ISO order in local time, which reads the same way in every locale.
+const HumanDateTimeLayout = "2006-01-02 15:04"
+
+// Locale formats numbers for people.
+type Locale struct {
+	tag     language.Tag
+	printer *message.Printer
+}
+
+// NewLocale returns the formatter for tag.
+func NewLocale(tag language.Tag) Locale {
+	return Locale{tag: tag, printer: message.NewPrinter(tag)}
+}
+
+// DetectLocale returns the formatter for the user's numeric locale, taken
+// from LC_ALL, LC_NUMERIC or LANG in that order, like the C library does.
+// Unset, "C" and "POSIX" locales and unparsable values format as English.
+func DetectLocale() Locale {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return NewLocale(parseLocale(value))
+		}
+	}
+	return NewLocale(language.English)
+}
+
+// parseLocale turns a POSIX locale name like "de_DE.UTF-8@euro" into a tag.
+func parseLocale(value string) language.Tag {
+	if i := strings.IndexAny(value, ".@"); i >= 0 {
+		value = value[:i]
+	}
+	if value == "" || value == "C" || value == "POSIX" {
+		return language.English
+	}
+	tag, err := language.Parse(strings.ReplaceAll(value, "_", "-"))
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
+
+// Tag returns the locale's language tag.
+func (l Locale) Tag() language.Tag { return l.tag }
+
+// Percent formats a value already in percent (80 is 80%) with decimals
+// fraction digits, e.g. "80.0%" in English and "80,0 %" in German.
+func (l Locale) Percent(v float64, decimals int) string {
+	return l.printer.Sprint(number.Percent(v/100, number.Scale(decimals)))
+}
+
+// Decimal formats v with decimals fraction digits and digit grouping.
+func (l Locale) Decimal(v float64, decimals int) string {
+	return l.printer.Sprint(number.Decimal(v, number.Scale(decimals)))
+}
+
+// Int formats n with digit grouping.
+func (l Locale) Int(n int) string {
+	return l.printer.Sprint(number.Decimal(n))
+}
+
+// Money formats a USD amount with cents, e.g. "$1,234.50".
+func (l Locale) Money(v float64) string {
+	return "$" + l.Decimal(v, 2)
+}
+
+// DateTime formats t in local time for people.
+func (l Locale) DateTime(t time.Time) string {
+	return t.Local().Format(HumanDateTimeLayout)
+}
+
+// Fixed formats v with exactly decimals fraction digits, a '.' separator,
+// no grouping and no exponent. Negative zero formats as zero.
+func Fixed(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if strings.Trim(s, "-0.") == "" {
+		s = strings.TrimPrefix(s, "-")
+	}
+	return s
+}
+
+// Timestamp formats t as a machine timestamp (see TimestampLayout).
+func Timestamp(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+func marshalFixed(v float64, decimals int) ([]byte, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("reportfmt: %v can't be represented in JSON", v)
+	}
+	return []byte(Fixed(v, decimals)), nil
+}
+
+// Percent is a value in percent that marshals with PercentDecimals digits.
+type Percent float64
+
+// MarshalJSON implements json.Marshaler.
+func (p Percent) MarshalJSON() ([]byte, error) { return marshalFixed(float64(p), PercentDecimals) }
+
+// Decimal is a fractional value that marshals with DecimalDecimals digits.
+type Decimal float64
+
+// MarshalJSON implements json.Marshaler.
+func (d Decimal) MarshalJSON() ([]byte, error) { return marshalFixed(float64(d), DecimalDecimals) }
+
+// Money is a USD amount that marshals with MoneyDecimals digits, enough
+// for the cost of a single day of light usage.
+type Money float64
+
+// MarshalJSON implements json.Marshaler.
+func (m Money) MarshalJSON() ([]byte, error) { return marshalFixed(float64(m), MoneyDecimals) }
+
+// Time is a time that marshals as a machine timestamp. It unmarshals any
+// RFC 3339 timestamp.
+type Time struct {
+	time.Time
+}
+
+// NewTime wraps t.
+func NewTime(t time.Time) Time { return Time{Time: t} }
+
+// MarshalJSON implements json.Marshaler.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(Timestamp(t.Time))), nil
+}
@@ -0,0 +1,117 @@
+package reportfmt
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		lcAll, lcNumeric, lang string
+		want                   language.Tag
+	}{
+		{"", "", "", language.English},
+		{"", "", "C.UTF-8", language.English},
+		{"", "", "POSIX", language.English},
+		{"", "", "de_DE.UTF-8", language.MustParse("de-DE")},
+		{"", "fr_FR@euro", "de_DE.UTF-8", language.MustParse("fr-FR")},
+		{"en_GB.UTF-8", "fr_FR", "de_DE", language.MustParse("en-GB")},
+		{"", "", "not a locale!", language.English},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_NUMERIC", tt.lcNumeric)
+		t.Setenv("LANG", tt.lang)
+		if got := DetectLocale().Tag(); got != tt.want {
+			t.Errorf("DetectLocale() with LC_ALL=%q LC_NUMERIC=%q LANG=%q = %v, want %v", tt.lcAll, tt.lcNumeric, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleFormats(t *testing.T) {
+	en := NewLocale(language.English)
+	de := NewLocale(language.German)
+
+	if got := en.Percent(80, 1); got != "80.0%" {
+		t.Errorf("en Percent = %q", got)
+	}
+	if got := de.Percent(33.333, 1); got != "33,3 %" {
+		t.Errorf("de Percent = %q", got)
+	}
+	if got := en.Decimal(1234.5, 1); got != "1,234.5" {
+		t.Errorf("en Decimal = %q", got)
+	}
+	if got := de.Decimal(1234.5, 1); got != "1.234,5" {
+		t.Errorf("de Decimal = %q", got)
+	}
+	if got := en.Int(1234567); got != "1,234,567" {
+		t.Errorf("en Int = %q", got)
+	}
+	if got := en.Money(0.5); got != "$0.50" {
+		t.Errorf("en Money = %q", got)
+	}
+}
+
+func TestFixed(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		want     string
+	}{
+		{33.333333, 2, "33.33"},
+		{80, 2, "80.00"},
+		{0.0000001, 4, "0.0000"},
+		{-0.00001, 2, "0.00"},
+		{-1.5, 1, "-1.5"},
+		{1e21, 0, "1000000000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := Fixed(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("Fixed(%v, %d) = %q, want %q", tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	zone := time.FixedZone("CEST", 2*60*60)
+	report := struct {
+		Share Percent  `json:"share"`
+		Lines Decimal  `json:"lines"`
+		Cost  Money    `json:"cost"`
+		At    Time     `json:"at"`
+		Empty *Percent `json:"empty,omitempty"`
+		Since Time     `json:"since,omitzero"`
+	}{
+		Share: 100.0 / 3,
+		Lines: 12,
+		Cost:  0.0000004,
+		At:    NewTime(time.Date(2026, 10, 14, 11, 30, 15, 987654321, zone)),
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"share":33.33,"lines":12.00,"cost":0.0000,"at":"2026-10-14T09:30:15Z"}`
+	if string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+
+	var decoded struct {
+		Share Percent `json:"share"`
+		At    Time    `json:"at"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Share != 33.33 || !decoded.At.Equal(time.Date(2026, 10, 14, 9, 30, 15, 0, time.UTC)) {
+		t.Errorf("round trip = %+v", decoded)
+	}
+
+	if _, err := json.Marshal(Percent(math.NaN())); err == nil {
+		t.Error("NaN marshaled without an error")
+	}
+}
@@ -110,6 +110,7 @@ Without --force, prompts for confirmation before deleting.`,
 
 	cmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Skip confirmation prompt and override active session guard")
 	cmd.Flags().StringVar(&sessionFlag, "session", "", "Reset a specific session by ID")
+	registerFlagCompletion(cmd, "session", completeSessionIDs)
 
 	return cmd
 }
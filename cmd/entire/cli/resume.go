@@ -43,7 +43,8 @@ to fetch it.
 If newer commits without checkpoints exist on the branch (e.g., after merging main
 or cherry-picking from elsewhere), this operation will reset your Git status to the
 most recent commit with a checkpoint.  You'll be prompted to confirm resuming in this case.`,
-		Args: cobra.ExactArgs(1),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeLocalBranches),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if checkDisabledGuard(cmd.OutOrStdout()) {
 				return nil
@@ -15,6 +15,7 @@ import (
 	agentpkg "github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
@@ -70,6 +71,7 @@ your agent's context.`,
 
 	cmd.Flags().BoolVar(&listFlag, "list", false, "List available rewind points (JSON output)")
 	cmd.Flags().StringVar(&toFlag, "to", "", "Rewind to specific commit ID (non-interactive)")
+	registerFlagCompletion(cmd, "to", completeRewindPoints)
 	cmd.Flags().BoolVar(&logsOnlyFlag, "logs-only", false, "Only restore logs, don't modify working directory (for logs-only points)")
 	cmd.Flags().BoolVar(&resetFlag, "reset", false, "Reset branch to commit (destructive, for logs-only points)")
 
@@ -139,6 +141,9 @@ func runRewindInteractive() error { //nolint:maintidx // already present in code
 			// Shadow checkpoint (uncommitted) - no sha shown (internal commit)
 			label = fmt.Sprintf("        (%s) %s%s", timestamp, sanitizeForTerminal(p.Message), sessionLabel)
 		}
+		if p.IsMetadataOnly {
+			label += " [metadata only]"
+		}
 		options = append(options, huh.NewOption(label, p.ID))
 	}
 	options = append(options, huh.NewOption("Cancel", "cancel"))
@@ -345,6 +350,7 @@ func runRewindList() error {
 		IsTaskCheckpoint bool   `json:"is_task_checkpoint"`
 		ToolUseID        string `json:"tool_use_id,omitempty"`
 		IsLogsOnly       bool   `json:"is_logs_only"`
+		IsMetadataOnly   bool   `json:"is_metadata_only,omitempty"`
 		CondensationID   string `json:"condensation_id,omitempty"`
 		SessionID        string `json:"session_id,omitempty"`
 		SessionPrompt    string `json:"session_prompt,omitempty"`
@@ -360,6 +366,7 @@ func runRewindList() error {
 			IsTaskCheckpoint: p.IsTaskCheckpoint,
 			ToolUseID:        p.ToolUseID,
 			IsLogsOnly:       p.IsLogsOnly,
+			IsMetadataOnly:   p.IsMetadataOnly,
 			CondensationID:   p.CheckpointID.String(),
 			SessionID:        p.SessionID,
 			SessionPrompt:    p.SessionPrompt,
@@ -798,8 +805,8 @@ func restoreTaskCheckpointTranscript(strat strategy.Strategy, point strategy.Rew
 // createContextFileMinimal creates a context file without staged files info
 // (since the strategy will handle staging)
 func createContextFileMinimal(contextFile, commitMessage, sessionID, promptFile, summaryFile string, transcript []transcriptLine) error {
-	prompt, _ := os.ReadFile(promptFile)   //nolint:errcheck,gosec // Best-effort loading of optional context files
-	summary, _ := os.ReadFile(summaryFile) //nolint:errcheck,gosec // Best-effort loading of optional context files
+	prompt, _ := encryption.ReadFile(promptFile)   //nolint:errcheck // Best-effort loading of optional context files
+	summary, _ := encryption.ReadFile(summaryFile) //nolint:errcheck // Best-effort loading of optional context files
 	keyActions := extractKeyActions(transcript, 10)
 
 	var content strings.Builder
@@ -815,7 +822,7 @@ func createContextFileMinimal(contextFile, commitMessage, sessionID, promptFile,
 		content.WriteString(fmt.Sprintf("- %s\n", action))
 	}
 
-	if err := os.WriteFile(contextFile, []byte(content.String()), 0o600); err != nil {
+	if err := encryption.WriteFile(contextFile, []byte(content.String()), 0o600); err != nil {
 		return fmt.Errorf("failed to write context file: %w", err)
 	}
 	return nil
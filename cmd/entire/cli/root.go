@@ -5,6 +5,8 @@ import (
 	"runtime"
 
 	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/telemetry"
 	"github.com/entireio/cli/cmd/entire/cli/versioncheck"
 	"github.com/spf13/cobra"
@@ -38,6 +40,10 @@ func NewRootCmd() *cobra.Command {
 		CompletionOptions: cobra.CompletionOptions{
 			HiddenDefaultCmd: true,
 		},
+		// Hook commands set their own PersistentPreRunE and configure redaction there
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			configureRedaction()
+		},
 		PersistentPostRun: func(cmd *cobra.Command, _ []string) {
 			// Skip for hidden commands (walk parent chain — Cobra doesn't propagate Hidden)
 			for c := cmd; c != nil; c = c.Parent() {
@@ -46,23 +52,15 @@ func NewRootCmd() *cobra.Command {
 				}
 			}
 
-			// Load settings once for telemetry and version check
-			var telemetryEnabled *bool
-			settings, err := LoadEntireSettings()
-			if err == nil {
-				telemetryEnabled = settings.Telemetry
-			}
-
-			// Check if telemetry is enabled
-			if telemetryEnabled != nil && *telemetryEnabled {
-				// Use detached tracking (non-blocking)
-				installedAgents := GetAgentsWithHooksInstalled()
-				agentStr := JoinAgentNames(installedAgents)
-				telemetry.TrackCommandDetached(cmd, settings.Strategy, agentStr, settings.Enabled, buildinfo.Version)
-			}
+			// Ask for telemetry consent once; the run itself is tracked by
+			// TrackCommand after Execute returns
+			maybeAskTelemetryConsent(cmd)
 
 			// Version check and notification (synchronous with 2s timeout)
 			// Runs AFTER command completes to avoid interfering with interactive modes
+			if speaksProtocolOnStdout(cmd) || cienv.Enabled() {
+				return
+			}
 			versioncheck.CheckAndNotify(cmd.OutOrStdout(), buildinfo.Version)
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -70,20 +68,55 @@ func NewRootCmd() *cobra.Command {
 		},
 	}
 
+	cmd.PersistentFlags().StringVar(&logFlags.level, "log-level", "", "Log level for this run: debug, info, warn or error (overrides ENTIRE_LOG_LEVEL)")
+	cmd.PersistentFlags().StringVar(&logFlags.file, "log-file", "", "Write structured logs to this file instead of .entire/logs/entire.log (- for stderr)")
+
 	// Add subcommands here
 	cmd.AddCommand(newRewindCmd())
+	cmd.AddCommand(newUndoCmd())
 	cmd.AddCommand(newResumeCmd())
 	cmd.AddCommand(newCleanCmd())
+	cmd.AddCommand(newGCCmd())
 	cmd.AddCommand(newResetCmd())
+	cmd.AddCommand(newInitCmd())
 	cmd.AddCommand(newEnableCmd())
 	cmd.AddCommand(newDisableCmd())
+	cmd.AddCommand(newUninstallCmd())
 	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newSessionsCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newImportCmd())
+	cmd.AddCommand(newWorktreeCmd())
+	cmd.AddCommand(newAttributionCmd())
+	cmd.AddCommand(newBlameCmd())
+	cmd.AddCommand(newStatsCmd())
+	cmd.AddCommand(newSearchCmd())
+	cmd.AddCommand(newTranscriptCmd())
+	cmd.AddCommand(newCheckpointCmd())
+	cmd.AddCommand(newStackCmd())
+	cmd.AddCommand(newMCPCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newMigrateCmd())
+	cmd.AddCommand(newSyncCmd())
 	cmd.AddCommand(newHooksCmd())
 	cmd.AddCommand(newVersionCmd())
 	cmd.AddCommand(newExplainCmd())
+	cmd.AddCommand(newShowCmd())
+	cmd.AddCommand(newNotesCmd())
+	cmd.AddCommand(newDaemonCmd())
+	cmd.AddCommand(newQueryCmd())
+	cmd.AddCommand(newGitHubCmd())
+	cmd.AddCommand(newGitLabCmd())
+	cmd.AddCommand(newCICmd())
+	cmd.AddCommand(newAuditCmd())
+	cmd.AddCommand(newUICmd())
 	cmd.AddCommand(newDebugCmd())
 	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newSelftestCmd())
+	cmd.AddCommand(newTelemetryCmd())
 	cmd.AddCommand(newSendAnalyticsCmd())
+	cmd.AddCommand(newWarmupCmd())
 	cmd.AddCommand(newCurlBashPostInstallCmd())
 
 	// Replace default help command with custom one that supports -t flag
@@ -92,6 +125,24 @@ func NewRootCmd() *cobra.Command {
 	return cmd
 }
 
+// logFlags holds the persistent --log-level and --log-file flags.
+var logFlags struct {
+	level string
+	file  string
+}
+
+// initLogging starts structured logging for a command, applying --log-level
+// and --log-file. Returns a cleanup function that should be deferred.
+func initLogging(sessionID string) func() {
+	logging.SetLogLevelGetter(GetLogLevel)
+	logging.SetOverrides(logFlags.level, logFlags.file)
+	if err := logging.Init(sessionID); err != nil {
+		// Init failed - logging will use stderr fallback
+		return func() {}
+	}
+	return logging.Close
+}
+
 func newVersionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -104,7 +155,7 @@ func newVersionCmd() *cobra.Command {
 	}
 }
 
-// newSendAnalyticsCmd creates the hidden command for sending analytics from a detached subprocess.
+// newSendAnalyticsCmd creates the hidden command for sending a telemetry queue batch from a detached subprocess.
 // This command is invoked by TrackCommandDetached and should not be called directly by users.
 func newSendAnalyticsCmd() *cobra.Command {
 	return &cobra.Command{
@@ -112,7 +163,7 @@ func newSendAnalyticsCmd() *cobra.Command {
 		Hidden: true,
 		Args:   cobra.ExactArgs(1),
 		Run: func(_ *cobra.Command, args []string) {
-			telemetry.SendEvent(args[0])
+			telemetry.SendBatch(args[0])
 		},
 	}
 }
@@ -0,0 +1,472 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+)
+
+// Where a search match was found.
+const (
+	searchSourceTranscript = "transcript"
+	searchSourceFile       = "file"
+	searchSourceDiff       = "diff"
+)
+
+// searchSnippetRunes bounds the text shown around a match.
+const searchSnippetRunes = 120
+
+// errNoSearchMatches is returned by `entire search` when nothing matched, so
+// scripts can test the exit code as with grep.
+var errNoSearchMatches = NewSilentError(errors.New("no matches"))
+
+func newSearchCmd() *cobra.Command {
+	var regexFlag, caseSensitiveFlag, diffsFlag, jsonFlag bool
+	var sessionFlag, rangeFlag string
+	var limitFlag int
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the transcripts of all committed checkpoints",
+		Long: `Searches the stored transcripts of every committed checkpoint: what you
+asked, what the agent answered, the tools it called with their arguments (such
+as the files it edited), and the files each session touched. Each match is
+shown with its checkpoint and session:
+
+  entire search "payment retry"
+  entire search --regex 'retr(y|ies)' --diffs
+  entire search --json "flaky test" | jq '.matches[].session_id'
+
+The query is a case-insensitive literal by default; --regex takes a Go regular
+expression, --case-sensitive matches case. With --diffs, the lines the
+checkpoint's commit added or removed are searched too. --range only searches
+the checkpoints of commits in a revision range, such as a release.
+
+Transcripts are cumulative, so a message is reported once, with the checkpoint
+it first appeared in. Exits non-zero when nothing matches.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if limitFlag < 0 {
+				return errors.New("--limit must not be negative")
+			}
+			pattern, err := compileSearchQuery(args[0], regexFlag, caseSensitiveFlag)
+			if err != nil {
+				return err
+			}
+			opts := searchOptions{Pattern: pattern, SessionID: sessionFlag, Range: rangeFlag, Diffs: diffsFlag, Limit: limitFlag}
+			return runSearch(cmd.Context(), cmd.OutOrStdout(), args[0], opts, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&regexFlag, "regex", "E", false, "Treat the query as a regular expression")
+	cmd.Flags().BoolVar(&caseSensitiveFlag, "case-sensitive", false, "Match case")
+	cmd.Flags().BoolVar(&diffsFlag, "diffs", false, "Also search the code changes of each checkpoint's commit")
+	cmd.Flags().StringVar(&sessionFlag, "session", "", "Only search this session")
+	registerFlagCompletion(cmd, "session", completeSessionIDs)
+	addCommitRangeFlag(cmd, &rangeFlag)
+	cmd.Flags().IntVar(&limitFlag, "limit", 0, "Stop after this many matches (0 = all)")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output matches as JSON")
+
+	return cmd
+}
+
+// compileSearchQuery turns the query into a regular expression.
+func compileSearchQuery(query string, isRegex, caseSensitive bool) (*regexp.Regexp, error) {
+	if query == "" {
+		return nil, errors.New("empty search query")
+	}
+	expr := query
+	if !isRegex {
+		expr = regexp.QuoteMeta(query)
+	}
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", query, err)
+	}
+	return pattern, nil
+}
+
+type searchOptions struct {
+	Pattern   *regexp.Regexp
+	SessionID string
+	// Range limits the search to checkpoints linked from commits in this
+	// revision range.
+	Range string
+	Diffs bool
+	Limit int
+}
+
+// searchMatch is one line that matched.
+type searchMatch struct {
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	SessionID    string          `json:"session_id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Branch       string          `json:"branch,omitempty"`
+	Commit       string          `json:"commit,omitempty"`
+	Source       string          `json:"source"`
+	// Role is user, assistant or tool for transcript matches.
+	Role string `json:"role,omitempty"`
+	Tool string `json:"tool,omitempty"`
+	// Path is the file of file and diff matches.
+	Path string `json:"path,omitempty"`
+	Text string `json:"text"`
+}
+
+type searchReport struct {
+	Query       string        `json:"query"`
+	Range       string        `json:"range,omitempty"`
+	Checkpoints int           `json:"checkpoints_searched"`
+	Sessions    int           `json:"sessions_searched"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	Matches     []searchMatch `json:"matches"`
+}
+
+func runSearch(ctx context.Context, w io.Writer, query string, opts searchOptions, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	report, err := searchCheckpoints(ctx, repo, opts)
+	if err != nil {
+		return err
+	}
+	report.Query = query
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal search results: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err //nolint:wrapcheck // write to stdout
+		}
+	} else {
+		writeSearchReport(w, report)
+	}
+	if len(report.Matches) == 0 {
+		return errNoSearchMatches
+	}
+	return nil
+}
+
+// searchCheckpoints searches every session of every committed checkpoint in
+// opts.Range (all if unset), oldest first.
+func searchCheckpoints(ctx context.Context, repo *git.Repository, opts searchOptions) (*searchReport, error) {
+	commitRange, err := resolveCommitRange(repo, opts.Range)
+	if err != nil {
+		return nil, err
+	}
+	store := checkpoint.NewGitStore(repo)
+	committed, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	sort.SliceStable(committed, func(i, j int) bool {
+		return committed[i].CreatedAt.Before(committed[j].CreatedAt)
+	})
+
+	var commits map[id.CheckpointID]*object.Commit
+	if opts.Diffs {
+		if commits, err = searchCommits(repo); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &searchReport{Matches: []searchMatch{}}
+	if commitRange != nil {
+		report.Range = commitRange.Spec
+	}
+	add := func(m searchMatch) bool {
+		if opts.Limit > 0 && len(report.Matches) >= opts.Limit {
+			report.Truncated = true
+			return false
+		}
+		report.Matches = append(report.Matches, m)
+		return true
+	}
+
+	// Entries of each session's transcript already searched, which later
+	// checkpoints of the session repeat
+	seen := make(map[string]int)
+	sessions := make(map[string]bool)
+	for _, info := range committed {
+		if opts.SessionID != "" && info.SessionID != opts.SessionID && !slices.Contains(info.SessionIDs, opts.SessionID) {
+			continue
+		}
+		if !commitRange.ContainsCheckpoint(info.CheckpointID) {
+			continue
+		}
+		summary, err := store.ReadCommitted(ctx, info.CheckpointID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint %s: %w", info.CheckpointID, err)
+		}
+		if summary == nil {
+			continue
+		}
+		report.Checkpoints++
+		commit := commits[info.CheckpointID]
+
+		for i := range summary.Sessions {
+			content, err := store.ReadSessionContent(ctx, info.CheckpointID, i)
+			if errors.Is(err, checkpoint.ErrCheckpointNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read checkpoint %s: %w", info.CheckpointID, err)
+			}
+			metadata := content.Metadata
+			if opts.SessionID != "" && metadata.SessionID != opts.SessionID {
+				continue
+			}
+			sessions[metadata.SessionID] = true
+			base := searchMatch{
+				CheckpointID: info.CheckpointID,
+				SessionID:    metadata.SessionID,
+				CreatedAt:    metadata.CreatedAt,
+				Branch:       metadata.Branch,
+			}
+			if commit != nil {
+				base.Commit = commit.Hash.String()
+			}
+
+			agentType := metadata.Agent
+			if agentType == "" {
+				agentType = info.Agent
+			}
+			entries, err := parseTranscriptForExport(content.Transcript, agentType)
+			if err != nil {
+				entries = nil
+			}
+			start := seen[metadata.SessionID]
+			if start > len(entries) {
+				// Not a continuation of the transcript searched before
+				start = 0
+			}
+			seen[metadata.SessionID] = len(entries)
+			for _, entry := range entries[start:] {
+				for _, m := range searchTranscriptEntry(opts.Pattern, base, entry) {
+					if !add(m) {
+						return finishSearchReport(report, sessions), nil
+					}
+				}
+			}
+
+			for _, path := range metadata.FilesTouched {
+				if opts.Pattern.MatchString(path) {
+					m := base
+					m.Source, m.Path, m.Text = searchSourceFile, path, path
+					if !add(m) {
+						return finishSearchReport(report, sessions), nil
+					}
+				}
+			}
+		}
+
+		if commit != nil {
+			matches, err := searchCommitDiff(commit, opts.Pattern, searchMatch{
+				CheckpointID: info.CheckpointID,
+				SessionID:    info.SessionID,
+				CreatedAt:    info.CreatedAt,
+				Commit:       commit.Hash.String(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				if !add(m) {
+					return finishSearchReport(report, sessions), nil
+				}
+			}
+		}
+	}
+	return finishSearchReport(report, sessions), nil
+}
+
+func finishSearchReport(report *searchReport, sessions map[string]bool) *searchReport {
+	report.Sessions = len(sessions)
+	return report
+}
+
+// searchTranscriptEntry returns a match for each line of entry's text, or of
+// its tool call, that matches.
+func searchTranscriptEntry(pattern *regexp.Regexp, base searchMatch, entry exportEntry) []searchMatch {
+	base.Source, base.Role = searchSourceTranscript, string(entry.Role)
+	text := entry.Text
+	if entry.Tool != nil {
+		base.Tool = entry.Tool.Name
+		input, err := json.Marshal(entry.Tool.Input)
+		if err == nil && len(entry.Tool.Input) > 0 {
+			text = entry.Tool.Name + " " + string(input)
+		} else {
+			text = entry.Tool.Name
+		}
+	}
+	var matches []searchMatch
+	for _, line := range strings.Split(text, "\n") {
+		if loc := pattern.FindStringIndex(line); loc != nil {
+			m := base
+			m.Text = searchSnippet(line, loc)
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// searchSnippet returns the part of line around the match at loc, on one line.
+func searchSnippet(line string, loc []int) string {
+	runes := []rune(line)
+	if len(runes) <= searchSnippetRunes {
+		return stringutil.CollapseWhitespace(line)
+	}
+	matchStart := len([]rune(line[:loc[0]]))
+	matchEnd := len([]rune(line[:loc[1]]))
+	start := max(0, matchStart-(searchSnippetRunes-(matchEnd-matchStart))/2)
+	end := min(len(runes), start+searchSnippetRunes)
+	start = max(0, end-searchSnippetRunes)
+	snippet := stringutil.CollapseWhitespace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(runes) {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// searchCommits maps checkpoints to the commit linking to them, one of them
+// if several do.
+func searchCommits(repo *git.Repository) (map[id.CheckpointID]*object.Commit, error) {
+	linked, err := checkpointCommits(repo)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[id.CheckpointID]*object.Commit, len(linked))
+	for _, c := range linked {
+		if _, found := commits[c.checkpointID]; found {
+			continue
+		}
+		commit, err := repo.CommitObject(c.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", c.hash.String()[:7], err)
+		}
+		commits[c.checkpointID] = commit
+	}
+	return commits, nil
+}
+
+// searchCommitDiff searches the lines commit added or removed, compared
+// with its first parent.
+func searchCommitDiff(commit *object.Commit, pattern *regexp.Regexp, base searchMatch) ([]searchMatch, error) {
+	to, err := treeSnapshot(commit)
+	if err != nil {
+		return nil, err
+	}
+	var from diffSnapshot
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parent of %s: %w", commit.Hash.String()[:7], err)
+		}
+		if from, err = treeSnapshot(parent); err != nil {
+			return nil, err
+		}
+	}
+	patches, err := diffSnapshots(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []searchMatch
+	for _, p := range patches {
+		for _, chunk := range p.chunks {
+			prefix := "+"
+			switch chunk.Type() {
+			case diff.Equal:
+				continue
+			case diff.Delete:
+				prefix = "-"
+			case diff.Add:
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(chunk.Content(), "\n"), "\n") {
+				if loc := pattern.FindStringIndex(line); loc != nil {
+					m := base
+					m.Source, m.Path, m.Text = searchSourceDiff, p.path(), prefix+searchSnippet(line, loc)
+					matches = append(matches, m)
+				}
+			}
+		}
+	}
+	return matches, nil
+}
+
+func writeSearchReport(w io.Writer, report *searchReport) {
+	if len(report.Matches) == 0 {
+		scope := ""
+		if report.Range != "" {
+			scope = " in " + report.Range
+		}
+		fmt.Fprintf(w, "No matches for %q in %d session(s) of %d checkpoint(s)%s.\n", report.Query, report.Sessions, report.Checkpoints, scope)
+		return
+	}
+	var last string
+	for _, m := range report.Matches {
+		if key := m.CheckpointID.String() + "\x00" + m.SessionID; key != last {
+			if last != "" {
+				fmt.Fprintln(w)
+			}
+			header := fmt.Sprintf("Checkpoint %s, session %s (%s", m.CheckpointID, m.SessionID, timeAgo(m.CreatedAt))
+			if m.Branch != "" {
+				header += ", " + m.Branch
+			}
+			if m.Commit != "" {
+				header += ", commit " + m.Commit[:7]
+			}
+			fmt.Fprintln(w, header+")")
+			last = key
+		}
+		label := m.Source
+		switch m.Source {
+		case searchSourceTranscript:
+			label = m.Role
+			if m.Tool != "" {
+				label = "tool"
+			}
+		case searchSourceFile:
+			label = "touched"
+		case searchSourceDiff:
+			label = "diff " + m.Path
+		}
+		fmt.Fprintf(w, "  %-10s %s\n", label+":", m.Text)
+	}
+	fmt.Fprintf(w, "\n%d match(es) in %d session(s) of %d checkpoint(s)", len(report.Matches), report.Sessions, report.Checkpoints)
+	if report.Truncated {
+		fmt.Fprint(w, ", stopped at --limit")
+	}
+	fmt.Fprintln(w, ".")
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+const searchTestTurn1 = `{"type":"user","uuid":"u1","message":{"content":"Add retry logic to the payment client"}}
+{"type":"assistant","uuid":"a1","message":{"content":[{"type":"text","text":"I'll add a retry loop."},{"type":"tool_use","name":"Edit","input":{"file_path":"pay.go","old_string":"x","new_string":"y"}}]}}
+`
+
+const searchTestTurn2 = `{"type":"user","uuid":"u2","message":{"content":"Now log each RETRY attempt"}}
+`
+
+// setupSearchRepo commits two checkpoints of one session, the second with
+// the session's cumulative transcript.
+func setupSearchRepo(t *testing.T) {
+	t.Helper()
+	repo, dir := initTestRepo(t)
+
+	for _, c := range []struct {
+		checkpointID, content, transcript string
+	}{
+		{"a1b2c3d4e5f6", "package pay\n\nfunc retry() {}\n", searchTestTurn1},
+		{"b1b2c3d4e5f6", "package pay\n\nfunc retry() { logAttempt() }\n", searchTestTurn1 + searchTestTurn2},
+	} {
+		commitTestCheckpoint(t, repo, dir, map[string]string{"pay.go": c.content}, checkpoint.WriteCommittedOptions{
+			CheckpointID: id.MustCheckpointID(c.checkpointID),
+			SessionID:    "2026-10-14-search",
+			Transcript:   []byte(c.transcript),
+			FilesTouched: []string{"pay.go"},
+		})
+	}
+}
+
+func searchFor(t *testing.T, query string, isRegex bool, opts searchOptions) *searchReport {
+	t.Helper()
+	pattern, err := compileSearchQuery(query, isRegex, false)
+	if err != nil {
+		t.Fatalf("compileSearchQuery(%q) error = %v", query, err)
+	}
+	opts.Pattern = pattern
+	var out bytes.Buffer
+	err = runSearch(context.Background(), &out, query, opts, true)
+	if err != nil && !errors.Is(err, errNoSearchMatches) {
+		t.Fatalf("runSearch(%q) error = %v", query, err)
+	}
+	var report searchReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	return &report
+}
+
+func TestRunSearch_TranscriptsOnce(t *testing.T) {
+	setupSearchRepo(t)
+
+	report := searchFor(t, "retry", false, searchOptions{})
+	if report.Checkpoints != 2 || report.Sessions != 1 {
+		t.Errorf("searched %d checkpoints, %d sessions, want 2 and 1", report.Checkpoints, report.Sessions)
+	}
+	// The first turn is in both transcripts but is reported with the first
+	// checkpoint only; the second turn's "RETRY" matches case-insensitively
+	var turn1, turn2 int
+	for _, m := range report.Matches {
+		if m.Source != searchSourceTranscript {
+			continue
+		}
+		switch m.CheckpointID.String() {
+		case "a1b2c3d4e5f6":
+			turn1++
+		case "b1b2c3d4e5f6":
+			turn2++
+			if m.Role != "user" || !strings.Contains(m.Text, "RETRY") {
+				t.Errorf("second checkpoint match = %+v, want the second prompt", m)
+			}
+		}
+	}
+	if turn1 != 2 || turn2 != 1 {
+		t.Errorf("matches per checkpoint = %d, %d, want 2, 1: %+v", turn1, turn2, report.Matches)
+	}
+}
+
+func TestRunSearch_ToolCallsAndDiffs(t *testing.T) {
+	setupSearchRepo(t)
+
+	report := searchFor(t, `pay\.go`, true, searchOptions{})
+	var tool, touched int
+	for _, m := range report.Matches {
+		switch {
+		case m.Source == searchSourceTranscript && m.Tool == "Edit":
+			tool++
+		case m.Source == searchSourceFile:
+			touched++
+		case m.Source == searchSourceDiff:
+			t.Errorf("diff match without --diffs: %+v", m)
+		}
+	}
+	if tool != 1 || touched != 2 {
+		t.Errorf("tool matches = %d, touched matches = %d, want 1 and 2", tool, touched)
+	}
+
+	report = searchFor(t, "logAttempt", false, searchOptions{Diffs: true})
+	if len(report.Matches) != 1 {
+		t.Fatalf("matches = %+v, want the added line", report.Matches)
+	}
+	if m := report.Matches[0]; m.Source != searchSourceDiff || m.Path != "pay.go" || m.CheckpointID.String() != "b1b2c3d4e5f6" || !strings.HasPrefix(m.Text, "+") {
+		t.Errorf("match = %+v, want an added line of pay.go in b1b2c3d4e5f6", m)
+	}
+}
+
+func TestRunSearch_Range(t *testing.T) {
+	setupSearchRepo(t)
+
+	// Only the second commit's checkpoint; its transcript repeats the first turn
+	report := searchFor(t, "retry", false, searchOptions{Range: "HEAD~1.."})
+	if report.Checkpoints != 1 || report.Range != "HEAD~1.." {
+		t.Errorf("searched %d checkpoints in %q, want 1 in HEAD~1..", report.Checkpoints, report.Range)
+	}
+	if len(report.Matches) == 0 {
+		t.Fatal("no matches in the range")
+	}
+	for _, m := range report.Matches {
+		if m.CheckpointID.String() != "b1b2c3d4e5f6" {
+			t.Errorf("match outside the range: %+v", m)
+		}
+	}
+}
+
+func TestRunSearch_NoMatchesAndLimit(t *testing.T) {
+	setupSearchRepo(t)
+
+	pattern, err := compileSearchQuery("nothing like this", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := runSearch(context.Background(), &out, "nothing like this", searchOptions{Pattern: pattern}, false); !errors.Is(err, errNoSearchMatches) {
+		t.Errorf("runSearch() error = %v, want errNoSearchMatches", err)
+	}
+	if !strings.Contains(out.String(), "No matches") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	report := searchFor(t, "retry", false, searchOptions{Limit: 1})
+	if len(report.Matches) != 1 || !report.Truncated {
+		t.Errorf("matches = %d, truncated = %v, want 1 and true", len(report.Matches), report.Truncated)
+	}
+
+	if _, err := compileSearchQuery("(", true, false); err == nil {
+		t.Error("compileSearchQuery(\"(\") should fail")
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	line := strings.Repeat("a", 200) + "needle" + strings.Repeat("b", 200)
+	loc := []int{200, 206}
+	got := searchSnippet(line, loc)
+	if !strings.Contains(got, "needle") || !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") {
+		t.Errorf("searchSnippet() = %q", got)
+	}
+	if got := searchSnippet("short  line", []int{0, 5}); got != "short line" {
+		t.Errorf("searchSnippet(short) = %q", got)
+	}
+}
@@ -0,0 +1,654 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/faultinject"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// Selftest stage statuses.
+const (
+	selftestPass = "pass"
+	selftestFail = "fail"
+	selftestSkip = "skip"
+)
+
+// Chaos mode runs this many prompt/stop rounds with faults injected.
+const (
+	selftestChaosRounds = 10
+	selftestChaosFaults = "ref-write:0.3,state-truncate:0.3,git-delay:0.2:20ms"
+)
+
+const (
+	selftestChaosSessionID = "entire-selftest-chaos"
+	selftestChaosFile      = "chaos.txt"
+)
+
+const (
+	selftestSessionID = "entire-selftest-session"
+	selftestFile      = "selftest.txt"
+	selftestLogName   = "selftest.log"
+	selftestReport    = "report.json"
+)
+
+func newSelftestCmd() *cobra.Command {
+	var keepFlag bool
+	var jsonFlag bool
+	var chaosFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Run an end-to-end check of your Entire installation",
+		Long: `Creates a throwaway git repository in a temporary directory and runs a
+simulated Claude Code session through the full hook lifecycle:
+
+  setup        Create the repository with an initial commit
+  enable       Install Entire's agent and git hooks
+  prompt       Run the UserPromptSubmit hook
+  tool-write   Have the "agent" write a file
+  stop         Run the Stop hook and check a checkpoint was saved
+  commit       Commit through git (running Entire's git hooks)
+  attribution  Check the commit is linked to session metadata with attribution
+
+Your own repositories and agent settings are not touched. When a stage fails,
+the temporary directory is kept and its path printed: it contains the repository,
+a log of every command that ran (selftest.log) and a report (report.json) to
+attach to a bug report. Use --keep to keep it after a successful run too.
+
+With --chaos, the session first runs through several rounds with faults
+injected into the hooks: failing ref writes, session state writes cut short
+and slow git storage. The remaining stages then check that nothing was left
+corrupted and that the next hooks recover and checkpoint normally.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to locate the entire binary: %w", err)
+			}
+			return runSelftest(cmd.Context(), cmd.OutOrStdout(), exe, keepFlag, jsonFlag, chaosFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&keepFlag, "keep", false, "Keep the temporary repository and artifacts after a successful run")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&chaosFlag, "chaos", false, "Inject faults into the hooks first and check Entire recovers")
+
+	return cmd
+}
+
+// selftestStage is the outcome of one selftest stage.
+type selftestStage struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// selftestResult is the full selftest report.
+type selftestResult struct {
+	Version      string          `json:"version"`
+	OS           string          `json:"os"`
+	Arch         string          `json:"arch"`
+	Passed       bool            `json:"passed"`
+	ArtifactsDir string          `json:"artifacts_dir,omitempty"`
+	Stages       []selftestStage `json:"stages"`
+}
+
+// selftestEnv is the throwaway environment the stages run in.
+type selftestEnv struct {
+	exe            string // entire binary under test
+	dir            string // temp root holding everything below
+	repoDir        string
+	binDir         string // contains an "entire" shim so git hooks run exe
+	homeDir        string // HOME of the commands, so they find projectDir
+	projectDir     string // Claude Code's project dir for repoDir under homeDir
+	transcriptPath string
+	log            io.Writer
+	extraEnv       []string // added to environ(), e.g. to inject faults
+
+	checkpointID id.CheckpointID // set by the commit stage
+}
+
+func runSelftest(ctx context.Context, w io.Writer, exe string, keep, jsonOutput, chaos bool) error {
+	dir, err := os.MkdirTemp("", "entire-selftest-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	// Resolve symlinks (macOS /var -> /private/var) so paths match what git reports.
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	logFile, err := os.Create(filepath.Join(dir, selftestLogName))
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("failed to create selftest log: %w", err)
+	}
+
+	env := &selftestEnv{
+		exe:     exe,
+		dir:     dir,
+		repoDir: filepath.Join(dir, "repo"),
+		binDir:  filepath.Join(dir, "bin"),
+		homeDir: filepath.Join(dir, "home"),
+		log:     logFile,
+	}
+	env.projectDir = filepath.Join(env.homeDir, ".claude", "projects", paths.SanitizePathForClaude(env.repoDir))
+	env.transcriptPath = filepath.Join(env.projectDir, selftestSessionID+".jsonl")
+
+	type stage struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}
+	stages := []stage{
+		{"setup", env.setup},
+		{"enable", env.enable},
+		{"prompt", env.prompt},
+		{"tool-write", env.toolWrite},
+		{"stop", env.stop},
+		{"commit", env.commit},
+		{"attribution", env.attribution},
+	}
+	if chaos {
+		// Faults before the session starts for real; recovery right after the
+		// first clean hook, which is what finishes interrupted checkpoints
+		chaosStages := []stage{stages[0], stages[1], {"chaos", env.chaos}, stages[2], {"recovery", env.recovery}}
+		stages = append(chaosStages, stages[3:]...)
+	}
+
+	result := selftestResult{
+		Version: buildinfo.Version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Passed:  true,
+	}
+	for _, stage := range stages {
+		if !result.Passed {
+			result.Stages = append(result.Stages, selftestStage{Name: stage.name, Status: selftestSkip})
+			continue
+		}
+
+		fmt.Fprintf(env.log, "=== stage %s\n", stage.name)
+		start := time.Now()
+		detail, err := stage.run(ctx)
+		entry := selftestStage{Name: stage.name, Status: selftestPass, Detail: detail, Duration: time.Since(start)}
+		if err != nil {
+			entry.Status = selftestFail
+			entry.Detail = err.Error()
+			result.Passed = false
+			fmt.Fprintf(env.log, "=== stage %s failed: %v\n", stage.name, err)
+		}
+		result.Stages = append(result.Stages, entry)
+	}
+
+	keepArtifacts := keep || !result.Passed
+	if keepArtifacts {
+		result.ArtifactsDir = dir
+		if data, err := jsonutil.MarshalIndentWithNewline(result, "", "  "); err == nil {
+			_ = os.WriteFile(filepath.Join(dir, selftestReport), data, 0o600)
+		}
+	}
+	_ = logFile.Close()
+	if !keepArtifacts {
+		_ = os.RemoveAll(dir)
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal selftest result: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+	} else {
+		printSelftestResult(w, result)
+	}
+
+	if !result.Passed {
+		return NewSilentError(errors.New("selftest failed"))
+	}
+	return nil
+}
+
+func printSelftestResult(w io.Writer, result selftestResult) {
+	fmt.Fprintf(w, "Entire selftest (%s, %s/%s)\n\n", result.Version, result.OS, result.Arch)
+	for _, stage := range result.Stages {
+		mark := "✓"
+		switch stage.Status {
+		case selftestFail:
+			mark = "✗"
+		case selftestSkip:
+			mark = "-"
+		}
+		line := fmt.Sprintf("  %s %-12s", mark, stage.Name)
+		if stage.Status == selftestSkip {
+			line += " skipped"
+		} else if stage.Detail != "" {
+			line += " " + stage.Detail
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintln(w)
+
+	if result.Passed {
+		fmt.Fprintln(w, "All stages passed.")
+	} else {
+		fmt.Fprintln(w, "Selftest failed.")
+	}
+	if result.ArtifactsDir != "" {
+		fmt.Fprintf(w, "Artifacts kept in %s\n", result.ArtifactsDir)
+		if !result.Passed {
+			fmt.Fprintf(w, "Please attach %s and %s to your bug report:\n", selftestLogName, selftestReport)
+			fmt.Fprintln(w, "  https://github.com/entireio/cli/issues")
+		}
+	}
+}
+
+// selftestReplacedEnv are the variables environ() replaces or drops from the
+// inherited environment: PATH and the home and settings directories, as the
+// commands get a home of their own.
+var selftestReplacedEnv = []string{"PATH", "HOME", "USERPROFILE", "XDG_CONFIG_HOME", "XDG_STATE_HOME"}
+
+// environ returns the environment for commands run against the throwaway repo.
+// Git variables inherited from a surrounding hook would point git at the wrong
+// repository, the user's settings would apply to it, and ENTIRE_TEST_*
+// overrides meant for the integration tests would change what the hooks do.
+// Faults are only injected where a stage asks for them through extraEnv.
+func (e *selftestEnv) environ() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "GIT_") || strings.HasPrefix(name, "ENTIRE_TEST_") ||
+			strings.HasPrefix(name, faultinject.EnvVar) || slices.Contains(selftestReplacedEnv, name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env,
+		"PATH="+e.binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"HOME="+e.homeDir,
+		"USERPROFILE="+e.homeDir,
+		"ENTIRE_TELEMETRY_OPTOUT=1",
+	)
+	return append(env, e.extraEnv...)
+}
+
+// run executes a command in the repo, logging its output to selftest.log.
+func (e *selftestEnv) run(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = e.repoDir
+	cmd.Env = e.environ()
+	// Without a terminal, the git hooks can't prompt on the one the selftest
+	// was started from
+	detachFromTerminal(cmd)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	fmt.Fprintf(e.log, "$ %s %s\n", filepath.Base(name), strings.Join(args, " "))
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		fmt.Fprintf(e.log, "%s\n", bytes.TrimRight(output, "\n"))
+	}
+	if err != nil {
+		return output, fmt.Errorf("%s %s: %w", filepath.Base(name), strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+func (e *selftestEnv) git(ctx context.Context, args ...string) ([]byte, error) {
+	return e.run(ctx, nil, "git", args...)
+}
+
+func (e *selftestEnv) entire(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	return e.run(ctx, stdin, e.exe, args...)
+}
+
+func (e *selftestEnv) setup(ctx context.Context) (string, error) {
+	for _, d := range []string{e.repoDir, e.binDir, e.projectDir} {
+		if err := os.MkdirAll(d, 0o750); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", d, err)
+		}
+	}
+	// Git hooks invoke "entire" from PATH; point it at the binary under test.
+	if err := os.Symlink(e.exe, filepath.Join(e.binDir, "entire")); err != nil {
+		return "", fmt.Errorf("failed to link entire binary: %w", err)
+	}
+
+	version, err := e.git(ctx, "--version")
+	if err != nil {
+		return "", fmt.Errorf("git is not available: %w", err)
+	}
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Entire Selftest"},
+		{"config", "user.email", "selftest@entire.invalid"},
+		{"config", "commit.gpgsign", "false"},
+	}
+	for _, args := range steps {
+		if _, err := e.git(ctx, args...); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(e.repoDir, "README.md"), []byte("# selftest\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write README.md: %w", err)
+	}
+	if _, err := e.git(ctx, "add", "README.md"); err != nil {
+		return "", err
+	}
+	if _, err := e.git(ctx, "commit", "--quiet", "-m", "Initial commit"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(version)), nil
+}
+
+func (e *selftestEnv) enable(ctx context.Context) (string, error) {
+	if _, err := e.entire(ctx, nil, "enable", "--agent", "claude-code", "--strategy", "manual-commit", "--telemetry=false"); err != nil {
+		return "", err
+	}
+	for _, hook := range []string{"prepare-commit-msg", "commit-msg", "post-commit"} {
+		if _, err := os.Stat(filepath.Join(e.repoDir, ".git", "hooks", hook)); err != nil {
+			return "", fmt.Errorf("git hook %s was not installed", hook)
+		}
+	}
+	// Commit the configuration like a real project would, so it doesn't count
+	// as uncommitted work in the attribution stage.
+	if _, err := e.git(ctx, "add", ".claude", ".entire"); err != nil {
+		return "", err
+	}
+	if _, err := e.git(ctx, "commit", "--quiet", "-m", "Enable Entire"); err != nil {
+		return "", err
+	}
+
+	detail := "agent and git hooks installed"
+	if out, err := e.git(ctx, "config", "core.hooksPath"); err == nil && len(bytes.TrimSpace(out)) > 0 {
+		detail += fmt.Sprintf(" (warning: core.hooksPath is set to %s, git may not run them)", bytes.TrimSpace(out))
+	}
+	return detail, nil
+}
+
+func (e *selftestEnv) prompt(ctx context.Context) (string, error) {
+	if err := e.writeTranscript(false); err != nil {
+		return "", err
+	}
+	if _, err := e.entire(ctx, e.hookInput(), "hooks", "claude-code", "user-prompt-submit"); err != nil {
+		return "", err
+	}
+
+	statePath := filepath.Join(e.repoDir, ".git", session.SessionStateDirName, selftestSessionID+".json")
+	if _, err := os.Stat(statePath); err != nil {
+		return "", errors.New("session state was not created")
+	}
+	return "session started", nil
+}
+
+func (e *selftestEnv) toolWrite(_ context.Context) (string, error) {
+	if err := os.WriteFile(filepath.Join(e.repoDir, selftestFile), []byte(selftestFileContent), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", selftestFile, err)
+	}
+	if err := e.writeTranscript(true); err != nil {
+		return "", err
+	}
+	return "wrote " + selftestFile, nil
+}
+
+func (e *selftestEnv) stop(ctx context.Context) (string, error) {
+	if _, err := e.entire(ctx, e.hookInput(), "hooks", "claude-code", "stop"); err != nil {
+		return "", err
+	}
+
+	backend := vcs.DetectPath(e.repoDir)
+	out, err := e.git(ctx, "for-each-ref", "--format=%(refname)", vcs.ShadowRefPrefix(backend))
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range strings.Fields(string(out)) {
+		if branch, ok := backend.ShadowBranch(plumbing.ReferenceName(ref)); ok && branch != paths.MetadataBranchName {
+			return "checkpoint saved on " + branch, nil
+		}
+	}
+	return "", errors.New("no shadow branch was created")
+}
+
+func (e *selftestEnv) commit(ctx context.Context) (string, error) {
+	if _, err := e.git(ctx, "add", selftestFile); err != nil {
+		return "", err
+	}
+	if _, err := e.git(ctx, "commit", "-m", "Add selftest greeting"); err != nil {
+		return "", err
+	}
+
+	message, err := e.git(ctx, "log", "-1", "--format=%B")
+	if err != nil {
+		return "", err
+	}
+	cpID, found := trailers.ParseCheckpoint(string(message))
+	if !found {
+		return "", fmt.Errorf("commit has no %s trailer (were the git hooks run?)", trailers.CheckpointTrailerKey)
+	}
+	e.checkpointID = cpID
+	return "linked to checkpoint " + cpID.String(), nil
+}
+
+func (e *selftestEnv) attribution(ctx context.Context) (string, error) {
+	repo, err := git.PlainOpen(e.repoDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(paths.MetadataBranchName), true); err != nil {
+		return "", fmt.Errorf("metadata branch %s was not created", paths.MetadataBranchName)
+	}
+
+	content, err := checkpoint.NewGitStore(repo).ReadLatestSessionContent(ctx, e.checkpointID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read checkpoint %s: %w", e.checkpointID, err)
+	}
+	if len(content.Transcript) == 0 {
+		return "", errors.New("checkpoint has no transcript")
+	}
+	attr := content.Metadata.InitialAttribution
+	if attr == nil {
+		return "", errors.New("checkpoint has no attribution")
+	}
+	if attr.AgentLines == 0 {
+		return "", fmt.Errorf("expected agent lines, got %d of %d committed", attr.AgentLines, attr.TotalCommitted)
+	}
+	return fmt.Sprintf("agent %d of %d lines (%.0f%%)", attr.AgentLines, attr.TotalCommitted, attr.AgentPercentage), nil
+}
+
+// chaos runs a second session through several prompt/stop rounds with
+// faults injected, each round writing chaos.txt. Hooks are expected to fail
+// some of the time; what matters is what they leave behind, which the
+// recovery stage checks.
+func (e *selftestEnv) chaos(ctx context.Context) (string, error) {
+	defer func() { e.extraEnv = nil }()
+	transcriptPath := filepath.Join(e.projectDir, selftestChaosSessionID+".jsonl")
+	input, _ := json.Marshal(map[string]string{ //nolint:errchkjson // map of strings always marshals
+		"session_id":      selftestChaosSessionID,
+		"transcript_path": transcriptPath,
+		"prompt":          "Update " + selftestChaosFile,
+	})
+
+	var transcript bytes.Buffer
+	appendTranscript := func(lines ...map[string]any) error {
+		enc := json.NewEncoder(&transcript)
+		for _, line := range lines {
+			if err := enc.Encode(line); err != nil {
+				return fmt.Errorf("failed to encode transcript: %w", err)
+			}
+		}
+		if err := os.WriteFile(transcriptPath, transcript.Bytes(), 0o600); err != nil {
+			return fmt.Errorf("failed to write transcript: %w", err)
+		}
+		return nil
+	}
+	var runs, failed, surfaced int
+	runHook := func(hook string) error {
+		runs++
+		out, err := e.entire(ctx, input, "hooks", "claude-code", hook)
+		surfaced += bytes.Count(out, []byte(faultinject.ErrInjected.Error()))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err() //nolint:wrapcheck // cancellation isn't a chaos failure
+			}
+			failed++
+		}
+		return nil
+	}
+
+	for round := 1; round <= selftestChaosRounds; round++ {
+		e.extraEnv = []string{
+			faultinject.EnvVar + "=" + selftestChaosFaults,
+			faultinject.SeedEnvVar + "=" + strconv.Itoa(round),
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		if err := appendTranscript(map[string]any{"uuid": fmt.Sprintf("chaos-user-%d", round), "type": "user", "timestamp": now,
+			"message": map[string]any{"content": fmt.Sprintf("Update %s (round %d)", selftestChaosFile, round)}}); err != nil {
+			return "", err
+		}
+		if err := runHook("user-prompt-submit"); err != nil {
+			return "", err
+		}
+
+		// Every round writes the file, so every stop has work to checkpoint
+		content := fmt.Sprintf("chaos round %d\n", round)
+		if err := os.WriteFile(filepath.Join(e.repoDir, selftestChaosFile), []byte(content), 0o600); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", selftestChaosFile, err)
+		}
+		toolID := fmt.Sprintf("toolu_chaos_%d", round)
+		if err := appendTranscript(
+			map[string]any{"uuid": fmt.Sprintf("chaos-asst-%d", round), "type": "assistant", "timestamp": now,
+				"message": map[string]any{"content": []any{map[string]any{
+					"type": "tool_use", "id": toolID, "name": "Write",
+					"input": map[string]any{"file_path": filepath.Join(e.repoDir, selftestChaosFile), "content": content},
+				}}}},
+			map[string]any{"uuid": fmt.Sprintf("chaos-result-%d", round), "type": "user", "timestamp": now,
+				"message": map[string]any{"content": []any{map[string]any{
+					"type": "tool_result", "tool_use_id": toolID, "content": "File written",
+				}}}},
+		); err != nil {
+			return "", err
+		}
+		if err := runHook("stop"); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%d rounds, %d injected failures reported, %d of %d hook runs failed", selftestChaosRounds, surfaced, failed, runs), nil
+}
+
+// recovery checks that the chaos rounds left no corrupt or half-written
+// session state, that interrupted checkpoints were finished or undone by the
+// clean prompt hook, and that the repository is consistent.
+func (e *selftestEnv) recovery(ctx context.Context) (string, error) {
+	stateDir := filepath.Join(e.repoDir, ".git", session.SessionStateDirName)
+	var states int
+	var problems []string
+	err := filepath.WalkDir(stateDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(stateDir, path) //nolint:errcheck // path is inside stateDir
+		switch {
+		case strings.HasSuffix(path, ".tmp"):
+			problems = append(problems, "leftover temp file "+rel)
+		case filepath.Base(filepath.Dir(path)) == "intents" && strings.HasSuffix(path, ".json"):
+			problems = append(problems, "unrecovered checkpoint intent "+rel)
+		case strings.HasSuffix(path, ".json") && filepath.Dir(path) == stateDir:
+			data, err := os.ReadFile(path) //nolint:gosec // path is inside the selftest repo
+			if err != nil {
+				return err
+			}
+			if data, err = encryption.Decode(data); err != nil || !json.Valid(data) {
+				problems = append(problems, "corrupt session state "+rel)
+			}
+			states++
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect session state: %w", err)
+	}
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	if _, err := e.git(ctx, "fsck", "--no-dangling", "--no-progress"); err != nil {
+		return "", fmt.Errorf("repository is inconsistent: %w", err)
+	}
+	return fmt.Sprintf("%d session state file(s) intact, no pending intents, git fsck clean", states), nil
+}
+
+const (
+	selftestPrompt      = "Create selftest.txt with a greeting"
+	selftestFileContent = "Hello from the Entire selftest!\n"
+)
+
+func (e *selftestEnv) hookInput() []byte {
+	data, _ := json.Marshal(map[string]string{ //nolint:errchkjson // map of strings always marshals
+		"session_id":      selftestSessionID,
+		"transcript_path": e.transcriptPath,
+		"prompt":          selftestPrompt,
+	})
+	return data
+}
+
+// writeTranscript writes a Claude Code transcript for the simulated session,
+// with or without the Write tool call.
+func (e *selftestEnv) writeTranscript(withWrite bool) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	lines := []map[string]any{
+		{"uuid": "selftest-user-1", "type": "user", "timestamp": now,
+			"message": map[string]any{"content": selftestPrompt}},
+	}
+	if withWrite {
+		lines = append(lines,
+			map[string]any{"uuid": "selftest-asst-1", "type": "assistant", "timestamp": now,
+				"message": map[string]any{"content": []any{map[string]any{
+					"type": "tool_use", "id": "toolu_selftest", "name": "Write",
+					"input": map[string]any{"file_path": filepath.Join(e.repoDir, selftestFile), "content": selftestFileContent},
+				}}}},
+			map[string]any{"uuid": "selftest-user-2", "type": "user", "timestamp": now,
+				"message": map[string]any{"content": []any{map[string]any{
+					"type": "tool_result", "tool_use_id": "toolu_selftest", "content": "File created",
+				}}}},
+			map[string]any{"uuid": "selftest-asst-2", "type": "assistant", "timestamp": now,
+				"message": map[string]any{"content": []any{map[string]any{"type": "text", "text": "Created " + selftestFile + "."}}}},
+		)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("failed to encode transcript: %w", err)
+		}
+	}
+	if err := os.WriteFile(e.transcriptPath, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
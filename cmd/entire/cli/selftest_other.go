@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cli
+
+import "os/exec"
+
+// detachFromTerminal is a no-op on non-Unix platforms, which have no
+// /dev/tty for the hooks to prompt on.
+func detachFromTerminal(*exec.Cmd) {}
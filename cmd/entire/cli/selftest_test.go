@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSelftest_FailureKeepsArtifacts(t *testing.T) {
+	// A binary that always fails makes the enable stage fail after setup passed.
+	exe := filepath.Join(t.TempDir(), "entire")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\necho broken >&2\nexit 1\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake binary: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	err := runSelftest(context.Background(), &stdout, exe, false, false, false)
+	var silent *SilentError
+	if !errors.As(err, &silent) {
+		t.Fatalf("runSelftest() error = %v, want SilentError", err)
+	}
+
+	output := stdout.String()
+	for _, want := range []string{"✓ setup", "✗ enable", "- prompt", "skipped", "Selftest failed.", "Artifacts kept in"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	_, dir, found := strings.Cut(output, "Artifacts kept in ")
+	if !found {
+		t.Fatal("artifacts directory not printed")
+	}
+	dir, _, _ = strings.Cut(dir, "\n")
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	for _, name := range []string{selftestLogName, selftestReport} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("artifact %s not kept: %v", name, err)
+		}
+	}
+	logData, err := os.ReadFile(filepath.Join(dir, selftestLogName))
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if !strings.Contains(string(logData), "broken") {
+		t.Errorf("log does not contain the failing command's output:\n%s", logData)
+	}
+}
+
+func TestPrintSelftestResult(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	printSelftestResult(&stdout, selftestResult{
+		Version: "1.2.3",
+		OS:      "linux",
+		Arch:    "amd64",
+		Passed:  true,
+		Stages: []selftestStage{
+			{Name: "setup", Status: selftestPass, Detail: "git version 2.43.0"},
+			{Name: "enable", Status: selftestPass},
+		},
+	})
+
+	output := stdout.String()
+	for _, want := range []string{"Entire selftest (1.2.3, linux/amd64)", "✓ setup        git version 2.43.0", "✓ enable\n", "All stages passed."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Artifacts kept") {
+		t.Errorf("output mentions artifacts for a run without them:\n%s", output)
+	}
+}
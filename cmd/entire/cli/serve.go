@@ -0,0 +1,530 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+// serveDefaultAddr is where `entire serve` listens by default. Loopback only:
+// the API has no authentication.
+const serveDefaultAddr = "127.0.0.1:7681"
+
+// Default and maximum page size of list endpoints.
+const (
+	serveDefaultPageSize = 50
+	serveMaxPageSize     = 500
+)
+
+func newServeCmd() *cobra.Command {
+	var addr string
+	var refresh time.Duration
+	var remote string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve session and attribution data over an HTTP API",
+		Long: `Runs an HTTP server with a read-only JSON API over this repository's
+checkpoints, attribution and synced shadow branches, as a backend for team
+dashboards.
+
+Run it in a clone the team pushes to: committed checkpoints come from
+entire/checkpoints/v1, sessions in progress from shadow branches developers
+pushed with 'entire sync push'. With --refresh, the server fetches both from
+--remote on that interval instead.
+
+Endpoints (all GET):
+  /api/v1/health                    Server version
+  /api/v1/checkpoints               Committed checkpoints, newest first
+                                    (?session_id, agent, since, until, limit,
+                                    cursor or offset; next_cursor continues)
+  /api/v1/checkpoints/{id}          Metadata, prompts, summary and attribution
+  /api/v1/attribution               Agent share overall and per agent (?since, until, range)
+  /api/v1/stats                     Same report as 'entire stats --json'
+                                    (?weeks, days, top, since, until, range,
+                                    input_price, output_price, cache_read_price,
+                                    task_type)
+  /api/v1/sessions                  Sessions on synced shadow branches
+  /api/v1/local-sessions            Sessions tracked in this clone, all worktrees
+  /api/v1/events                    Server-sent "update" event whenever checkpoints,
+                                    shadow branches or sessions change
+  /metrics                          Prometheus metrics: checkpoints, attribution,
+                                    sessions, hook executions and failures in
+                                    this clone, storage size
+
+The API has no authentication; it listens on loopback unless --addr says
+otherwise.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if refresh < 0 {
+				return errors.New("--refresh must not be negative")
+			}
+			if refresh > 0 && remote == "" {
+				var err error
+				if remote, err = syncRemote(""); err != nil {
+					return err
+				}
+			}
+			return runServe(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), newServeHandler(), "API", "/api/v1/", addr, refresh, remote)
+		},
+	}
+
+	cmd.AddCommand(newServeDashboardCmd())
+
+	cmd.Flags().StringVar(&addr, "addr", serveDefaultAddr, "Address to listen on")
+	cmd.Flags().DurationVar(&refresh, "refresh", 0, "Fetch checkpoints and shadow branches from --remote this often (e.g. 5m; 0 = never)")
+	cmd.Flags().StringVar(&remote, "remote", "", "Remote to fetch from with --refresh (default: sync_remote setting, or origin)")
+
+	return cmd
+}
+
+// runServe serves handler on addr until ctx is done, announcing it as the
+// Entire <what> at <path>.
+func runServe(ctx context.Context, w, errW io.Writer, handler http.Handler, what, path, addr string, refresh time.Duration, remote string) error {
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if refresh > 0 {
+		go refreshServeData(ctx, errW, remote, refresh)
+	}
+	return serveOn(ctx, w, listener, handler, what, path)
+}
+
+// serveOn serves handler on listener until ctx is done, announcing it as
+// the Entire <what> at <path>.
+func serveOn(ctx context.Context, w io.Writer, listener net.Listener, handler http.Handler, what, path string) error {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Ends open event streams on shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		_ = server.Shutdown(shutdownCtx) //nolint:errcheck // best effort on exit
+	}()
+
+	fmt.Fprintf(w, "Serving the Entire %s on http://%s%s\n", what, listener.Addr(), path)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// refreshServeData fetches the metadata branch and shadow branches from
+// remote every interval until ctx is done. Failures are reported and retried
+// on the next tick.
+func refreshServeData(ctx context.Context, errW io.Writer, remote string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := fetchMetadataBranchFrom(ctx, remote); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(errW, "refresh: %v\n", err)
+		}
+		if err := strategy.FetchShadowBranches(ctx, remote); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(errW, "refresh: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newServeHandler routes the API.
+func newServeHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/health", func(w http.ResponseWriter, _ *http.Request) {
+		writeServeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": buildinfo.Version})
+	})
+	mux.HandleFunc("GET /api/v1/checkpoints", serveListCheckpoints)
+	mux.HandleFunc("GET /api/v1/checkpoints/{id}", serveGetCheckpoint)
+	mux.HandleFunc("GET /api/v1/attribution", serveAttribution)
+	mux.HandleFunc("GET /api/v1/stats", serveStats)
+	mux.HandleFunc("GET /api/v1/sessions", serveSessions)
+	mux.HandleFunc("GET /api/v1/local-sessions", serveLocalSessions)
+	mux.HandleFunc("GET /api/v1/events", serveEvents)
+	mux.HandleFunc("GET /metrics", serveMetrics)
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		writeServeError(w, http.StatusNotFound, errors.New("not found"))
+	})
+	return mux
+}
+
+// serveCheckpointsJSON is a page of /api/v1/checkpoints.
+type serveCheckpointsJSON struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	// NextCursor continues with ?cursor= after this page; empty on the last page.
+	NextCursor  string              `json:"next_cursor,omitempty"`
+	Checkpoints []mcpCheckpointJSON `json:"checkpoints"`
+}
+
+func serveListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := serveIntParam(q.Get("limit"), serveDefaultPageSize)
+	if err == nil && (limit <= 0 || limit > serveMaxPageSize) {
+		err = fmt.Errorf("limit must be between 1 and %d", serveMaxPageSize)
+	}
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	offset, err := serveIntParam(q.Get("offset"), 0)
+	if err == nil && offset < 0 {
+		err = errors.New("offset must not be negative")
+	}
+	if err == nil && offset > 0 && q.Get("cursor") != "" {
+		err = errors.New("use either offset or cursor")
+	}
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	period, err := resolveReportPeriod(q.Get("since"), q.Get("until"), time.Now())
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	committed, err := checkpoint.NewGitStore(repo).ListCommitted(r.Context())
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list checkpoints: %w", err))
+		return
+	}
+	sessionID, agentType := q.Get("session_id"), agent.AgentType(q.Get("agent"))
+	var matched []checkpoint.CommittedInfo
+	for _, info := range committed {
+		if sessionID != "" && info.SessionID != sessionID && !slices.Contains(info.SessionIDs, sessionID) {
+			continue
+		}
+		if agentType != "" && info.Agent != agentType {
+			continue
+		}
+		if !period.Contains(info.CreatedAt) {
+			continue
+		}
+		matched = append(matched, info)
+	}
+	page := serveCheckpointsJSON{Total: len(matched), Offset: offset, Checkpoints: []mcpCheckpointJSON{}}
+	key := func(info checkpoint.CommittedInfo) listKey {
+		return listKey{Time: info.CreatedAt, ID: info.CheckpointID.String()}
+	}
+	opts := listOptions{Limit: limit, Cursor: q.Get("cursor"), Sort: listSortNewest}
+	if offset > 0 {
+		matched, _, _ = pageList(matched, key, listOptions{Sort: listSortNewest}) //nolint:errcheck // no cursor to decode
+		matched = matched[min(offset, len(matched)):]
+	}
+	matched, page.NextCursor, err = pageList(matched, key, opts)
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, info := range matched {
+		entry := mcpCheckpointJSON{
+			CheckpointID: info.CheckpointID,
+			SessionID:    info.SessionID,
+			CreatedAt:    info.CreatedAt,
+			Agent:        info.Agent,
+			Steps:        info.CheckpointsCount,
+			FilesTouched: nonNilStrings(info.FilesTouched),
+			IsTask:       info.IsTask,
+		}
+		if len(info.SessionIDs) > 1 {
+			entry.SessionIDs = info.SessionIDs
+		}
+		page.Checkpoints = append(page.Checkpoints, entry)
+	}
+	writeServeJSON(w, http.StatusOK, page)
+}
+
+func serveGetCheckpoint(w http.ResponseWriter, r *http.Request) {
+	rawID := r.PathValue("id")
+	if err := id.Validate(rawID); err != nil {
+		writeServeError(w, http.StatusBadRequest, fmt.Errorf("invalid checkpoint ID %q: %w", rawID, err))
+		return
+	}
+	detail, err := mcpGetCheckpoint(r.Context(), rawID)
+	if errors.Is(err, checkpoint.ErrCheckpointNotFound) {
+		writeServeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, detail)
+}
+
+// serveAttributionJSON is /api/v1/attribution.
+type serveAttributionJSON struct {
+	Range          string                      `json:"range,omitempty"`
+	Since          reportfmt.Time              `json:"since,omitzero"`
+	Until          reportfmt.Time              `json:"until,omitzero"`
+	Checkpoints    int                         `json:"checkpoints"`
+	AgentLines     int                         `json:"agent_lines"`
+	TotalCommitted int                         `json:"total_committed"`
+	AgentShare     *reportfmt.Percent          `json:"agent_share,omitempty"`
+	Agents         []serveAgentAttributionJSON `json:"agents"`
+}
+
+// serveAgentAttributionJSON is one agent's part of /api/v1/attribution.
+type serveAgentAttributionJSON struct {
+	Agent       agent.AgentType `json:"agent"`
+	Checkpoints int             `json:"checkpoints"`
+	Sessions    int             `json:"sessions"`
+	AgentLines  int             `json:"agent_lines"`
+}
+
+func serveAttribution(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	period, err := resolveReportPeriod(q.Get("since"), q.Get("until"), time.Now())
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	repo, err := openRepository()
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	commitRange, err := resolveCommitRange(repo, q.Get("range"))
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	store := checkpoint.NewGitStore(repo)
+	infos, err := store.ListCommitted(r.Context())
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list checkpoints: %w", err))
+		return
+	}
+
+	report := sumAttribution(r.Context(), store, infos, func(info checkpoint.CommittedInfo) bool {
+		return commitRange.ContainsCheckpoint(info.CheckpointID) && period.Contains(info.CreatedAt)
+	})
+	report.Range, report.Since, report.Until = q.Get("range"), reportfmt.NewTime(period.Since), reportfmt.NewTime(period.Until)
+	writeServeJSON(w, http.StatusOK, report)
+}
+
+// sumAttribution adds up the attribution of the checkpoints in infos that
+// include accepts, overall and per agent.
+func sumAttribution(ctx context.Context, store *checkpoint.GitStore, infos []checkpoint.CommittedInfo, include func(checkpoint.CommittedInfo) bool) serveAttributionJSON {
+	report := serveAttributionJSON{Agents: []serveAgentAttributionJSON{}}
+	byAgent := make(map[agent.AgentType]*serveAgentAttributionJSON)
+	for _, info := range infos {
+		if !include(info) {
+			continue
+		}
+		report.Checkpoints++
+		seen := make(map[agent.AgentType]bool)
+		var total int
+		for i := range max(info.SessionCount, 1) {
+			m, err := store.ReadSessionMetadata(ctx, info.CheckpointID, i)
+			if err != nil {
+				continue // Partially written or older checkpoints
+			}
+			a, ok := byAgent[m.Agent]
+			if !ok {
+				a = &serveAgentAttributionJSON{Agent: m.Agent}
+				byAgent[m.Agent] = a
+			}
+			a.Sessions++
+			if !seen[m.Agent] {
+				seen[m.Agent] = true
+				a.Checkpoints++
+			}
+			if attr := m.InitialAttribution; attr != nil && attr.SupersededBy == "" {
+				// Sessions share the commit: agent lines add up, the commit size doesn't
+				a.AgentLines += attr.AgentLines
+				report.AgentLines += attr.AgentLines
+				total = max(total, attr.TotalCommitted)
+			}
+		}
+		report.TotalCommitted += total
+	}
+	if report.TotalCommitted > 0 {
+		share := reportfmt.Percent(min(100, float64(report.AgentLines)*100/float64(report.TotalCommitted)))
+		report.AgentShare = &share
+	}
+	for _, a := range byAgent {
+		report.Agents = append(report.Agents, *a)
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		if report.Agents[i].AgentLines != report.Agents[j].AgentLines {
+			return report.Agents[i].AgentLines > report.Agents[j].AgentLines
+		}
+		return report.Agents[i].Agent < report.Agents[j].Agent
+	})
+	return report
+}
+
+func serveStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := statsOptions{Range: q.Get("range"), TaskType: q.Get("task_type")}
+	var err error
+	for _, p := range []struct {
+		name  string
+		value *int
+		def   int
+	}{{"weeks", &opts.Weeks, 12}, {"days", &opts.Days, 14}, {"top", &opts.Top, 5}} {
+		if *p.value, err = serveIntParam(q.Get(p.name), p.def); err == nil && *p.value <= 0 {
+			err = fmt.Errorf("%s must be positive", p.name)
+		}
+		if err != nil {
+			writeServeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	for _, p := range []struct {
+		name  string
+		value *float64
+	}{{"input_price", &opts.InputPrice}, {"output_price", &opts.OutputPrice}, {"cache_read_price", &opts.CacheReadPrice}} {
+		if *p.value, err = serveFloatParam(q.Get(p.name)); err == nil && *p.value < 0 {
+			err = fmt.Errorf("%s must not be negative", p.name)
+		}
+		if err != nil {
+			writeServeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if opts.Period, err = resolveReportPeriod(q.Get("since"), q.Get("until"), time.Now()); err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	commitRange, err := resolveCommitRange(repo, opts.Range)
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	commits, err := loadStatsCommits(r.Context(), checkpoint.NewGitStore(repo), commitRange, opts.TaskType)
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, buildStatsReport(commits, opts, time.Now()))
+}
+
+// serveShadowBranchJSON is a synced shadow branch in /api/v1/sessions.
+type serveShadowBranchJSON struct {
+	Branch     string                   `json:"branch"`
+	Remote     string                   `json:"remote,omitempty"`
+	BaseCommit string                   `json:"base_commit"`
+	Tip        string                   `json:"tip"`
+	UpdatedAt  time.Time                `json:"updated_at"`
+	Sessions   []serveShadowSessionJSON `json:"sessions"`
+}
+
+// serveShadowSessionJSON is a session on a synced shadow branch.
+type serveShadowSessionJSON struct {
+	SessionID string    `json:"session_id"`
+	Author    string    `json:"author"`
+	Steps     int       `json:"steps"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func serveSessions(w http.ResponseWriter, _ *http.Request) {
+	repo, err := openRepository()
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	branches, err := strategy.ListSyncedShadowBranches(repo)
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result := []serveShadowBranchJSON{}
+	for _, b := range branches {
+		entry := serveShadowBranchJSON{
+			Branch:     b.Branch,
+			Remote:     b.Remote,
+			BaseCommit: b.BaseCommit,
+			Tip:        b.Tip.String(),
+			UpdatedAt:  b.UpdatedAt,
+			Sessions:   []serveShadowSessionJSON{},
+		}
+		for _, s := range b.Sessions {
+			entry.Sessions = append(entry.Sessions, serveShadowSessionJSON(s))
+		}
+		result = append(result, entry)
+	}
+	writeServeJSON(w, http.StatusOK, map[string]any{"shadow_branches": result})
+}
+
+// serveIntParam parses an integer query parameter, def if it is empty.
+func serveIntParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return n, nil
+}
+
+// serveFloatParam parses a decimal query parameter, 0 if it is empty.
+func serveFloatParam(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return f, nil
+}
+
+func writeServeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := jsonutil.MarshalIndentWithNewline(v, "", "  ")
+	if err != nil {
+		http.Error(w, `{"error":"failed to encode response"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data) //nolint:errcheck // client went away
+}
+
+func writeServeError(w http.ResponseWriter, status int, err error) {
+	writeServeJSON(w, status, map[string]string{"error": err.Error()})
+}
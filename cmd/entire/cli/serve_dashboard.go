@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// dashboardAssets is the dashboard's single-page app. It only talks to the
+// API of the server that serves it, so it works offline.
+//
+//go:embed dashboard
+var dashboardAssets embed.FS
+
+// serveEventsPollInterval is how often /api/v1/events checks for changes.
+var serveEventsPollInterval = 2 * time.Second
+
+func newServeDashboardCmd() *cobra.Command {
+	var addr string
+	var refresh time.Duration
+	var remote string
+
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Serve a local web dashboard of sessions, checkpoints and attribution",
+		Long: `Runs the same API as 'entire serve' together with a web dashboard on top
+of it: sessions in progress, recent checkpoints, the agent share trend,
+token usage and, with prices set in the page, cost per day.
+
+The page updates live: it listens to /api/v1/events and reloads whenever a
+checkpoint is written, a shadow branch moves or a session changes phase.
+Everything is served from the binary; the page loads nothing from the
+internet.
+
+The dashboard has no authentication; it listens on loopback unless --addr
+says otherwise.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if refresh < 0 {
+				return errors.New("--refresh must not be negative")
+			}
+			if refresh > 0 && remote == "" {
+				var err error
+				if remote, err = syncRemote(""); err != nil {
+					return err
+				}
+			}
+			return runServe(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), newDashboardHandler(), "dashboard", "/", addr, refresh, remote)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", serveDefaultAddr, "Address to listen on")
+	cmd.Flags().DurationVar(&refresh, "refresh", 0, "Fetch checkpoints and shadow branches from --remote this often (e.g. 5m; 0 = never)")
+	cmd.Flags().StringVar(&remote, "remote", "", "Remote to fetch from with --refresh (default: sync_remote setting, or origin)")
+
+	return cmd
+}
+
+// newDashboardHandler serves the dashboard's assets next to the API.
+func newDashboardHandler() http.Handler {
+	assets, err := fs.Sub(dashboardAssets, "dashboard")
+	if err != nil {
+		panic(err) // The embedded directory always exists
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/", newServeHandler())
+	mux.HandleFunc("GET /metrics", serveMetrics)
+	mux.Handle("/", http.FileServerFS(assets))
+	return mux
+}
+
+func serveLocalSessions(w http.ResponseWriter, _ *http.Request) {
+	entries, err := listSessionEntries(true)
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, map[string]any{"sessions": entries})
+}
+
+// dashboardSnapshot is the data of an "update" event.
+type dashboardSnapshot struct {
+	// Version changes whenever an Entire ref or a session's state does.
+	Version        string `json:"version"`
+	Sessions       int    `json:"sessions"`
+	ActiveSessions int    `json:"active_sessions"`
+}
+
+// takeDashboardSnapshot fingerprints the checkpoint refs (metadata branch,
+// shadow branches, synced and hidden shadow refs) and session states.
+func takeDashboardSnapshot() (dashboardSnapshot, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return dashboardSnapshot{}, err
+	}
+	iter, err := repo.References()
+	if err != nil {
+		return dashboardSnapshot{}, fmt.Errorf("failed to list references: %w", err)
+	}
+	var lines []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		if strings.HasPrefix(name, "refs/heads/entire/") || strings.HasPrefix(name, "refs/entire/") {
+			lines = append(lines, name+" "+ref.Hash().String())
+		}
+		return nil
+	})
+	if err != nil {
+		return dashboardSnapshot{}, fmt.Errorf("failed to list references: %w", err)
+	}
+	states, err := strategy.ListSessionStates()
+	if err != nil {
+		return dashboardSnapshot{}, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var snap dashboardSnapshot
+	for _, state := range states {
+		snap.Sessions++
+		if state.EndedAt == nil && state.Phase != session.PhaseEnded {
+			snap.ActiveSessions++
+		}
+		lines = append(lines, fmt.Sprintf("session %s %s %d", state.SessionID, state.Phase, state.StepCount))
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	snap.Version = hex.EncodeToString(sum[:6])
+	return snap, nil
+}
+
+// serveEvents streams server-sent events: an "update" with the current
+// snapshot on connect and whenever it changes, and an "error" when it can't
+// be taken.
+func serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeServeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(serveEventsPollInterval)
+	defer ticker.Stop()
+	last := ""
+	for {
+		event, data := "update", any(nil)
+		snap, err := takeDashboardSnapshot()
+		key := snap.Version
+		if err != nil {
+			event, data, key = "error", map[string]string{"error": err.Error()}, "error: "+err.Error()
+		} else {
+			data = snap
+		}
+		if key != last {
+			encoded, encodeErr := json.Marshal(data)
+			if encodeErr == nil {
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+				flusher.Flush()
+				last = key
+			}
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
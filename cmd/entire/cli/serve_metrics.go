@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// serveMetricsStorageTTL is how long /metrics reuses a storage measurement:
+// measuring walks the history of every Entire ref.
+const serveMetricsStorageTTL = 10 * time.Minute
+
+// serveMetricsCache keeps the expensive parts of /metrics between scrapes.
+// Attribution is summed again only when the metadata branch moves.
+var serveMetricsCache struct {
+	mu sync.Mutex
+	// commonDir is the repository the cached values are of.
+	commonDir   string
+	metadataTip plumbing.Hash
+	attribution *serveAttributionJSON
+	storage     int64
+	storageAt   time.Time
+}
+
+// promFamily is a metric family in the Prometheus text format.
+type promFamily struct {
+	name    string
+	help    string
+	kind    string // "counter" or "gauge"
+	samples []promSample
+}
+
+// promSample is one sample of a family. labels are name, value pairs.
+type promSample struct {
+	labels []string
+	value  float64
+}
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	families, err := collectServeMetrics(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	writePromFamilies(&buf, families)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buf.Bytes()) //nolint:errcheck // client went away
+}
+
+// collectServeMetrics gathers the metrics of this repository. Metrics whose
+// source can't be read are left out rather than failing the scrape.
+func collectServeMetrics(ctx context.Context) ([]promFamily, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return nil, err
+	}
+	families := []promFamily{{
+		name: "entire_build_info", help: "Version of the Entire CLI serving these metrics.", kind: "gauge",
+		samples: []promSample{{labels: []string{"version", buildinfo.Version}, value: 1}},
+	}}
+
+	commonDir, err := strategy.GetGitCommonDir()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped by GetGitCommonDir
+	}
+	serveMetricsCache.mu.Lock()
+	if serveMetricsCache.commonDir != commonDir {
+		serveMetricsCache.commonDir, serveMetricsCache.attribution, serveMetricsCache.storageAt = commonDir, nil, time.Time{}
+	}
+	serveMetricsCache.mu.Unlock()
+
+	if report, err := cachedAttribution(ctx, repo); err == nil {
+		families = append(families,
+			promFamily{name: "entire_checkpoints", help: "Committed checkpoints on the metadata branch.", kind: "gauge",
+				samples: []promSample{{value: float64(report.Checkpoints)}}},
+			promFamily{name: "entire_attribution_committed_lines", help: "Lines committed in commits with a checkpoint.", kind: "gauge",
+				samples: []promSample{{value: float64(report.TotalCommitted)}}},
+		)
+		sessions := promFamily{name: "entire_checkpoint_sessions", help: "Sessions in committed checkpoints, by agent.", kind: "gauge"}
+		lines := promFamily{name: "entire_attribution_agent_lines", help: "Committed lines attributed to agents, by agent.", kind: "gauge"}
+		for _, a := range report.Agents {
+			sessions.samples = append(sessions.samples, promSample{labels: []string{"agent", string(a.Agent)}, value: float64(a.Sessions)})
+			lines.samples = append(lines.samples, promSample{labels: []string{"agent", string(a.Agent)}, value: float64(a.AgentLines)})
+		}
+		families = append(families, sessions, lines)
+	}
+
+	if snap, err := takeDashboardSnapshot(); err == nil {
+		families = append(families,
+			promFamily{name: "entire_sessions", help: "Sessions tracked in this clone.", kind: "gauge",
+				samples: []promSample{{value: float64(snap.Sessions)}}},
+			promFamily{name: "entire_sessions_active", help: "Sessions tracked in this clone that haven't ended.", kind: "gauge",
+				samples: []promSample{{value: float64(snap.ActiveSessions)}}},
+		)
+	}
+
+	if counts, err := readHookCounts(); err == nil {
+		executions := promFamily{name: "entire_hook_executions_total", help: "Hook executions in this clone.", kind: "counter"}
+		failures := promFamily{name: "entire_hook_failures_total", help: "Hook executions in this clone that failed.", kind: "counter"}
+		for _, c := range counts {
+			labels := []string{"hook", c.Hook, "agent", c.Agent, "type", c.Type}
+			executions.samples = append(executions.samples, promSample{labels: labels, value: float64(c.Executions)})
+			failures.samples = append(failures.samples, promSample{labels: labels, value: float64(c.Failures)})
+		}
+		families = append(families, executions, failures)
+	}
+
+	if size, err := cachedStorageSize(ctx, commonDir); err == nil {
+		families = append(families, promFamily{
+			name: "entire_storage_bytes", help: "Disk space Entire's refs and state directories use in this clone.", kind: "gauge",
+			samples: []promSample{{value: float64(size)}},
+		})
+	}
+	return families, nil
+}
+
+// cachedAttribution returns the attribution of every committed checkpoint,
+// summed again only when the metadata branch has moved.
+func cachedAttribution(ctx context.Context, repo *git.Repository) (*serveAttributionJSON, error) {
+	var tip plumbing.Hash
+	if ref, err := repo.Reference(plumbing.NewBranchReferenceName(paths.MetadataBranchName), true); err == nil {
+		tip = ref.Hash()
+	}
+	serveMetricsCache.mu.Lock()
+	defer serveMetricsCache.mu.Unlock()
+	if serveMetricsCache.attribution != nil && serveMetricsCache.metadataTip == tip {
+		return serveMetricsCache.attribution, nil
+	}
+
+	store := checkpoint.NewGitStore(repo)
+	infos, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	report := sumAttribution(ctx, store, infos, func(checkpoint.CommittedInfo) bool { return true })
+	serveMetricsCache.metadataTip, serveMetricsCache.attribution = tip, &report
+	return &report, nil
+}
+
+// cachedStorageSize returns Entire's footprint in this clone, measured at
+// most every serveMetricsStorageTTL.
+func cachedStorageSize(ctx context.Context, commonDir string) (int64, error) {
+	serveMetricsCache.mu.Lock()
+	defer serveMetricsCache.mu.Unlock()
+	if !serveMetricsCache.storageAt.IsZero() && time.Since(serveMetricsCache.storageAt) < serveMetricsStorageTTL {
+		return serveMetricsCache.storage, nil
+	}
+	size, err := strategy.MeasureFootprint(ctx, commonDir)
+	if err != nil {
+		return 0, err //nolint:wrapcheck // already wrapped by MeasureFootprint
+	}
+	serveMetricsCache.storage, serveMetricsCache.storageAt = size, time.Now()
+	return size, nil
+}
+
+// writePromFamilies writes families in the Prometheus text exposition
+// format, families and samples sorted for stable output.
+func writePromFamilies(w io.Writer, families []promFamily) {
+	sort.SliceStable(families, func(i, j int) bool { return families[i].name < families[j].name })
+	for _, f := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+		samples := append([]promSample{}, f.samples...)
+		sort.SliceStable(samples, func(i, j int) bool {
+			return strings.Join(samples[i].labels, "\x00") < strings.Join(samples[j].labels, "\x00")
+		})
+		for _, s := range samples {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, promLabels(s.labels), strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+}
+
+// promLabels formats name, value pairs as {name="value",...}.
+func promLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, labels[i]+`="`+escaper.Replace(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServe_Metrics(t *testing.T) {
+	setupMCPRepo(t)
+	ctx := context.Background()
+	recordHookExecution(ctx, "post-commit", "git", "", nil)
+	recordHookExecution(ctx, "post-commit", "git", "", errors.New("boom"))
+	recordHookExecution(ctx, "stop", "agent", "claude-code", nil)
+
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("GET /metrics = %d %s, want 200 text/plain", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	for _, want := range []string{
+		"# TYPE entire_checkpoints gauge\nentire_checkpoints 2\n",
+		"entire_attribution_committed_lines 10\n",
+		`entire_hook_executions_total{hook="post-commit",agent="",type="git"} 2`,
+		`entire_hook_failures_total{hook="post-commit",agent="",type="git"} 1`,
+		`entire_hook_executions_total{hook="stop",agent="claude-code",type="agent"} 1`,
+		"# TYPE entire_hook_failures_total counter\n",
+		"entire_storage_bytes ",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics is missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestPromLabels_Escapes(t *testing.T) {
+	got := promLabels([]string{"hook", `a"b\c` + "\n"})
+	if want := `{hook="a\"b\\c\n"}`; got != want {
+		t.Errorf("promLabels() = %s, want %s", got, want)
+	}
+	if got := promLabels(nil); got != "" {
+		t.Errorf("promLabels(nil) = %q, want none", got)
+	}
+}
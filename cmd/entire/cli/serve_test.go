@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func getServeJSON(t *testing.T, server *httptest.Server, path string, wantStatus int, v any) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("GET %s = %d, want %d", path, resp.StatusCode, wantStatus)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: invalid JSON: %v", path, err)
+	}
+}
+
+func TestServe_Checkpoints(t *testing.T) {
+	setupMCPRepo(t)
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+
+	var page serveCheckpointsJSON
+	getServeJSON(t, server, "/api/v1/checkpoints?limit=1", http.StatusOK, &page)
+	if page.Total != 2 || len(page.Checkpoints) != 1 {
+		t.Fatalf("first page = %d of %d checkpoints, want 1 of 2", len(page.Checkpoints), page.Total)
+	}
+	first := page.Checkpoints[0].CheckpointID
+	getServeJSON(t, server, "/api/v1/checkpoints?limit=1&offset=1", http.StatusOK, &page)
+	if len(page.Checkpoints) != 1 || page.Checkpoints[0].CheckpointID == first {
+		t.Errorf("second page = %+v, want the other checkpoint", page.Checkpoints)
+	}
+	getServeJSON(t, server, "/api/v1/checkpoints?session_id=2026-10-14-second", http.StatusOK, &page)
+	if page.Total != 1 || page.Checkpoints[0].CheckpointID.String() != "b1b2c3d4e5f6" {
+		t.Errorf("filtered by session = %+v", page)
+	}
+
+	var detail mcpCheckpointDetailJSON
+	getServeJSON(t, server, "/api/v1/checkpoints/b1b2c3d4e5f6", http.StatusOK, &detail)
+	if len(detail.Sessions) != 1 || detail.Sessions[0].Attribution == nil || detail.Sessions[0].Attribution.AgentLines != 8 {
+		t.Errorf("checkpoint detail = %+v, want the second session with its attribution", detail)
+	}
+
+	var errResp map[string]string
+	getServeJSON(t, server, "/api/v1/checkpoints/ffffffffffff", http.StatusNotFound, &errResp)
+	getServeJSON(t, server, "/api/v1/checkpoints/nope", http.StatusBadRequest, &errResp)
+	getServeJSON(t, server, "/api/v1/checkpoints?limit=0", http.StatusBadRequest, &errResp)
+	if !strings.Contains(errResp["error"], "limit") {
+		t.Errorf("error = %q, want it to name the limit", errResp["error"])
+	}
+	getServeJSON(t, server, "/api/v1/nothing", http.StatusNotFound, &errResp)
+}
+
+func TestServe_AttributionAndStats(t *testing.T) {
+	setupMCPRepo(t)
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+
+	var attribution serveAttributionJSON
+	getServeJSON(t, server, "/api/v1/attribution", http.StatusOK, &attribution)
+	if attribution.Checkpoints != 2 || attribution.AgentLines != 8 || attribution.TotalCommitted != 10 {
+		t.Errorf("attribution = %+v, want 8 of 10 lines over 2 checkpoints", attribution)
+	}
+	if attribution.AgentShare == nil || *attribution.AgentShare != 80 {
+		t.Errorf("agent share = %v, want 80", attribution.AgentShare)
+	}
+	if len(attribution.Agents) != 1 || attribution.Agents[0].Sessions != 2 || attribution.Agents[0].AgentLines != 8 {
+		t.Errorf("agents = %+v, want one agent with 2 sessions", attribution.Agents)
+	}
+
+	var stats statsReport
+	getServeJSON(t, server, "/api/v1/stats?weeks=2", http.StatusOK, &stats)
+	if stats.Commits != 2 || len(stats.Weeks) != 2 {
+		t.Errorf("stats = %d commits, %d weeks; want 2 and 2", stats.Commits, len(stats.Weeks))
+	}
+	var errResp map[string]string
+	getServeJSON(t, server, "/api/v1/stats?weeks=-1", http.StatusBadRequest, &errResp)
+}
+
+func TestServe_Sessions(t *testing.T) {
+	repo, base := setupCleanTestRepo(t)
+	branch := checkpoint.ShadowBranchNameForCommit(base.String(), "")
+	addSyncTestCheckpoint(t, repo, branch, "2026-10-14-pushed", time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC))
+	tip := addSyncTestCheckpoint(t, repo, branch, "2026-10-14-pushed", time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC))
+	// As `entire sync push` leaves it on the remote; local shadow branches aren't listed
+	if err := repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(branch)); err != nil {
+		t.Fatalf("failed to remove %s: %v", branch, err)
+	}
+	synced := plumbing.ReferenceName(strategy.ShadowSyncRefPrefix + strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix))
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(synced, tip)); err != nil {
+		t.Fatalf("failed to set %s: %v", synced, err)
+	}
+
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+
+	var resp struct {
+		ShadowBranches []serveShadowBranchJSON `json:"shadow_branches"`
+	}
+	getServeJSON(t, server, "/api/v1/sessions", http.StatusOK, &resp)
+	if len(resp.ShadowBranches) != 1 {
+		t.Fatalf("shadow branches = %+v, want the synced one", resp.ShadowBranches)
+	}
+	b := resp.ShadowBranches[0]
+	if b.Branch != branch || b.Tip != tip.String() || len(b.Sessions) != 1 {
+		t.Fatalf("shadow branch = %+v, want %s at %s with one session", b, branch, tip)
+	}
+	if s := b.Sessions[0]; s.SessionID != "2026-10-14-pushed" || s.Steps != 2 || s.Author != "test" {
+		t.Errorf("session = %+v, want 2 steps by test", s)
+	}
+}
+
+func TestServeDashboard_AssetsAndAPI(t *testing.T) {
+	setupMCPRepo(t)
+	server := httptest.NewServer(newDashboardHandler())
+	defer server.Close()
+
+	for path, want := range map[string]string{"/": "<title>Entire dashboard</title>", "/dashboard.js": "EventSource"} {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s = %d, want 200 containing %q", path, resp.StatusCode, want)
+		}
+	}
+
+	var page serveCheckpointsJSON
+	getServeJSON(t, server, "/api/v1/checkpoints", http.StatusOK, &page)
+	if page.Total != 2 {
+		t.Errorf("checkpoints through the dashboard = %d, want 2", page.Total)
+	}
+	var sessions struct {
+		Sessions []sessionListJSON `json:"sessions"`
+	}
+	getServeJSON(t, server, "/api/v1/local-sessions", http.StatusOK, &sessions)
+	if sessions.Sessions == nil {
+		t.Error("local-sessions returned null, want an empty list")
+	}
+	var errResp map[string]string
+	getServeJSON(t, server, "/api/v1/nothing", http.StatusNotFound, &errResp)
+	getServeJSON(t, server, "/api/v1/stats?input_price=-1", http.StatusBadRequest, &errResp)
+}
+
+func TestServe_StatsCost(t *testing.T) {
+	setupMCPRepo(t)
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+
+	var stats statsReport
+	getServeJSON(t, server, "/api/v1/stats?input_price=3&output_price=15", http.StatusOK, &stats)
+	if !stats.HasCost {
+		t.Error("stats with prices has_cost = false, want true")
+	}
+}
+
+func TestServe_Events(t *testing.T) {
+	repo, _ := setupCleanTestRepo(t)
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+
+	oldInterval := serveEventsPollInterval
+	serveEventsPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { serveEventsPollInterval = oldInterval })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/events", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/v1/events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readUpdate := func() dashboardSnapshot {
+		t.Helper()
+		var event string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read event: %v", err)
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if event != "update" {
+					t.Fatalf("event %q: %s, want update", event, line)
+				}
+				var snap dashboardSnapshot
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &snap); err != nil {
+					t.Fatalf("invalid event data %q: %v", line, err)
+				}
+				return snap
+			}
+		}
+	}
+
+	first := readUpdate()
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName("entire/abc1234-e3b0c4"), head.Hash())
+	if err := repo.Storer.SetReference(ref); err != nil {
+		t.Fatalf("failed to create shadow branch: %v", err)
+	}
+	if second := readUpdate(); second.Version == first.Version {
+		t.Errorf("second update has the same version %s as the first", first.Version)
+	}
+}
+
+func TestServe_CheckpointsCursor(t *testing.T) {
+	setupMCPRepo(t)
+	server := httptest.NewServer(newServeHandler())
+	defer server.Close()
+
+	var page serveCheckpointsJSON
+	getServeJSON(t, server, "/api/v1/checkpoints?limit=1", http.StatusOK, &page)
+	if page.NextCursor == "" || len(page.Checkpoints) != 1 {
+		t.Fatalf("first page = %+v, want one checkpoint and a cursor", page)
+	}
+	first, cursor := page.Checkpoints[0].CheckpointID, page.NextCursor
+	page = serveCheckpointsJSON{}
+	getServeJSON(t, server, "/api/v1/checkpoints?limit=1&cursor="+cursor, http.StatusOK, &page)
+	if len(page.Checkpoints) != 1 || page.Checkpoints[0].CheckpointID == first || page.NextCursor != "" {
+		t.Errorf("second page = %+v, want the other checkpoint and no cursor", page)
+	}
+
+	var errResp map[string]string
+	getServeJSON(t, server, "/api/v1/checkpoints?offset=1&cursor=abc", http.StatusBadRequest, &errResp)
+	getServeJSON(t, server, "/api/v1/checkpoints?cursor=!!", http.StatusBadRequest, &errResp)
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// CheckpointEpochSize is the number of checkpoints rolled up into one epoch.
+const CheckpointEpochSize = 100
+
+// CheckpointEpoch is a rolled-up summary of a contiguous run of up to
+// CheckpointEpochSize checkpoints in the current checkpoint cycle (since the
+// last condensation). Epochs let long sessions be listed without walking every
+// commit on the shadow branch; LastCommit/FirstCommit bound the drill-down walk.
+type CheckpointEpoch struct {
+	// Index is the 0-based position of this epoch in the cycle
+	Index int `json:"index"`
+
+	// FirstStep and LastStep are the StepCount values (1-based) covered by this epoch
+	FirstStep int `json:"first_step"`
+	LastStep  int `json:"last_step"`
+
+	// FirstCommit and LastCommit are the shadow branch commits of the first and
+	// last checkpoint in this epoch
+	FirstCommit string `json:"first_commit"`
+	LastCommit  string `json:"last_commit"`
+
+	// StartedAt and EndedAt are the times of the first and last checkpoint
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+
+	// FilesTouched are the files modified/created/deleted by checkpoints in this epoch
+	FilesTouched []string `json:"files_touched,omitempty"`
+
+	// TaskCheckpoints is the number of subagent task checkpoints in this epoch.
+	// They don't count as steps, so an epoch of only task checkpoints (before
+	// the cycle's first step) has LastStep == FirstStep-1.
+	TaskCheckpoints int `json:"task_checkpoints,omitempty"`
+}
+
+// Count returns the number of (step) checkpoints in the epoch.
+func (e CheckpointEpoch) Count() int {
+	return e.LastStep - e.FirstStep + 1
+}
+
+// RecordCheckpointEpoch rolls the checkpoint that was just counted in StepCount
+// into the current epoch, starting a new epoch when the current one is full.
+// Call after incrementing StepCount. The first checkpoint of a cycle (StepCount == 1)
+// discards epochs left over from a previous cycle.
+func (s *State) RecordCheckpointEpoch(commitHash string, at time.Time, files []string) {
+	if s.StepCount <= 1 {
+		// Keep an epoch of task checkpoints made before this first step
+		s.CheckpointEpochs = slices.DeleteFunc(s.CheckpointEpochs, func(e CheckpointEpoch) bool {
+			return e.LastStep > 0
+		})
+	}
+
+	n := len(s.CheckpointEpochs)
+	if n == 0 || s.CheckpointEpochs[n-1].Count() >= CheckpointEpochSize {
+		s.startCheckpointEpoch(s.StepCount, commitHash, at)
+		n++
+	}
+
+	epoch := &s.CheckpointEpochs[n-1]
+	epoch.LastStep = s.StepCount
+	epoch.extend(commitHash, at, files)
+}
+
+// RecordTaskCheckpointEpoch rolls a subagent task checkpoint into the current
+// epoch. Task checkpoints don't increment StepCount and don't fill epochs.
+func (s *State) RecordTaskCheckpointEpoch(commitHash string, at time.Time, files []string) {
+	n := len(s.CheckpointEpochs)
+	if n == 0 {
+		s.startCheckpointEpoch(s.StepCount+1, commitHash, at)
+		s.CheckpointEpochs[0].LastStep = s.StepCount
+		n++
+	}
+
+	epoch := &s.CheckpointEpochs[n-1]
+	epoch.TaskCheckpoints++
+	epoch.extend(commitHash, at, files)
+}
+
+func (s *State) startCheckpointEpoch(firstStep int, commitHash string, at time.Time) {
+	s.CheckpointEpochs = append(s.CheckpointEpochs, CheckpointEpoch{
+		Index:       len(s.CheckpointEpochs),
+		FirstStep:   firstStep,
+		FirstCommit: commitHash,
+		StartedAt:   at,
+	})
+}
+
+// extend makes the checkpoint at commitHash the epoch's last.
+func (e *CheckpointEpoch) extend(commitHash string, at time.Time, files []string) {
+	e.LastCommit = commitHash
+	e.EndedAt = at
+	e.FilesTouched = mergeSortedUnique(e.FilesTouched, files)
+}
+
+// mergeSortedUnique returns the sorted union of existing and additional.
+func mergeSortedUnique(existing, additional []string) []string {
+	if len(additional) == 0 {
+		return existing
+	}
+	seen := make(map[string]bool, len(existing)+len(additional))
+	result := make([]string, 0, len(existing)+len(additional))
+	for _, list := range [][]string{existing, additional} {
+		for _, f := range list {
+			if !seen[f] {
+				seen[f] = true
+				result = append(result, f)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
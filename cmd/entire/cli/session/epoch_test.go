@@ -0,0 +1,78 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_RecordCheckpointEpoch(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &State{}
+	total := CheckpointEpochSize*2 + 5
+	for i := 1; i <= total; i++ {
+		state.StepCount = i
+		state.RecordCheckpointEpoch(fmt.Sprintf("commit-%d", i), start.Add(time.Duration(i)*time.Minute), []string{fmt.Sprintf("f%d.go", i%3)})
+	}
+
+	require.Len(t, state.CheckpointEpochs, 3)
+
+	first := state.CheckpointEpochs[0]
+	assert.Equal(t, 0, first.Index)
+	assert.Equal(t, 1, first.FirstStep)
+	assert.Equal(t, CheckpointEpochSize, first.LastStep)
+	assert.Equal(t, CheckpointEpochSize, first.Count())
+	assert.Equal(t, "commit-1", first.FirstCommit)
+	assert.Equal(t, fmt.Sprintf("commit-%d", CheckpointEpochSize), first.LastCommit)
+	assert.Equal(t, []string{"f0.go", "f1.go", "f2.go"}, first.FilesTouched)
+
+	last := state.CheckpointEpochs[2]
+	assert.Equal(t, 2, last.Index)
+	assert.Equal(t, CheckpointEpochSize*2+1, last.FirstStep)
+	assert.Equal(t, 5, last.Count())
+	assert.Equal(t, start.Add(time.Duration(total)*time.Minute), last.EndedAt)
+}
+
+func TestState_RecordCheckpointEpoch_NewCycleResets(t *testing.T) {
+	t.Parallel()
+
+	state := &State{StepCount: 3, CheckpointEpochs: []CheckpointEpoch{{FirstStep: 1, LastStep: 2}}}
+	state.StepCount = 1 // condensation reset StepCount, first checkpoint of the new cycle
+	state.RecordCheckpointEpoch("abc", time.Now(), nil)
+
+	require.Len(t, state.CheckpointEpochs, 1)
+	assert.Equal(t, 1, state.CheckpointEpochs[0].FirstStep)
+	assert.Equal(t, "abc", state.CheckpointEpochs[0].FirstCommit)
+}
+
+func TestState_RecordTaskCheckpointEpoch(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &State{}
+
+	// A subagent checkpoint before the first step starts an epoch without steps
+	state.RecordTaskCheckpointEpoch("task-1", start, []string{"a.go"})
+	require.Len(t, state.CheckpointEpochs, 1)
+	assert.Equal(t, 0, state.CheckpointEpochs[0].Count())
+	assert.Equal(t, 1, state.CheckpointEpochs[0].TaskCheckpoints)
+
+	// The first step joins it rather than discarding it
+	state.StepCount = 1
+	state.RecordCheckpointEpoch("step-1", start.Add(time.Minute), []string{"b.go"})
+	state.RecordTaskCheckpointEpoch("task-2", start.Add(2*time.Minute), []string{"c.go"})
+	require.Len(t, state.CheckpointEpochs, 1)
+
+	epoch := state.CheckpointEpochs[0]
+	assert.Equal(t, 1, epoch.Count())
+	assert.Equal(t, 2, epoch.TaskCheckpoints)
+	assert.Equal(t, "task-1", epoch.FirstCommit)
+	assert.Equal(t, "task-2", epoch.LastCommit)
+	assert.Equal(t, start.Add(2*time.Minute), epoch.EndedAt)
+	assert.Equal(t, []string{"a.go", "b.go", "c.go"}, epoch.FilesTouched)
+}
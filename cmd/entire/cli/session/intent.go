@@ -0,0 +1,219 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/validation"
+)
+
+// Creating a checkpoint moves a git ref and then saves session state. A crash
+// between the two leaves a ref pointing at a checkpoint the state doesn't know
+// about. Operations like that write an intent first, update it once the refs
+// have moved, and remove it once the state is saved. Whoever finds an intent
+// left behind by a crashed process finishes or undoes its work (see RecoverIntents).
+
+// intentDirName is the subdirectory of the state directory holding intents.
+// It's a directory of its own so List never mistakes an intent for a session.
+const intentDirName = "intents"
+
+// IntentRef is a ref an intent's operation moves.
+type IntentRef struct {
+	Name string `json:"name"`
+	// Old is the hash before the operation, empty if the ref didn't exist.
+	Old string `json:"old,omitempty"`
+	// New is the hash the operation wrote, empty until the ref was updated.
+	New string `json:"new,omitempty"`
+}
+
+// Intent is a write-ahead record of an operation that moves refs and saves
+// session state together.
+type Intent struct {
+	SessionID string      `json:"session_id"`
+	Operation string      `json:"operation"`
+	Refs      []IntentRef `json:"refs"`
+	// State is the session state to save, set together with the refs' New
+	// hashes once all refs have moved.
+	State     *State    `json:"state,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// RefsUpdated reports whether every ref has moved, so only the state save
+// may be missing.
+func (i *Intent) RefsUpdated() bool {
+	if i.State == nil {
+		return false
+	}
+	for _, ref := range i.Refs {
+		if ref.New == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// IntentJournal is an intent being carried out. It holds the intent's lock, so
+// recovery in other processes leaves it alone.
+type IntentJournal struct {
+	store   *StateStore
+	intent  *Intent
+	path    string
+	release func()
+}
+
+// BeginIntent writes intent and locks it for the caller. Call Close when done
+// (typically deferred) and Complete once the operation has fully succeeded;
+// an intent that is closed without Complete is left for RecoverIntents.
+func (s *StateStore) BeginIntent(ctx context.Context, intent *Intent) (*IntentJournal, error) {
+	if err := validation.ValidateSessionID(intent.SessionID); err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+	dir := filepath.Join(s.stateDir, intentDirName)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create intent directory: %w", err)
+	}
+
+	path := filepath.Join(dir, intent.SessionID+".json")
+	release, err := acquireLock(ctx, path+".lock", DefaultLockTimeout)
+	if errors.Is(err, errLockBusy) {
+		return nil, fmt.Errorf("another checkpoint of session %s is in progress", intent.SessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock intent: %w", err)
+	}
+
+	j := &IntentJournal{store: s, intent: intent, path: path, release: release}
+	if err := j.write(); err != nil {
+		release()
+		return nil, err
+	}
+	return j, nil
+}
+
+// RefsUpdated records the hashes the refs now point at and the session state
+// about to be saved. From here on, recovery saves state instead of undoing the refs.
+func (j *IntentJournal) RefsUpdated(newHashes map[string]string, state *State) error {
+	for i := range j.intent.Refs {
+		j.intent.Refs[i].New = newHashes[j.intent.Refs[i].Name]
+	}
+	j.intent.State = state
+	return j.write()
+}
+
+// Complete removes the intent: the operation succeeded and needs no recovery.
+func (j *IntentJournal) Complete() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove intent: %w", err)
+	}
+	return nil
+}
+
+// Close releases the intent's lock. It is safe to call more than once.
+func (j *IntentJournal) Close() {
+	if j.release != nil {
+		j.release()
+		j.release = nil
+	}
+}
+
+func (j *IntentJournal) write() error {
+	data, err := jsonutil.MarshalIndentWithNewline(j.intent, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal intent: %w", err)
+	}
+	if data, err = j.store.encode(data); err != nil {
+		return fmt.Errorf("failed to encrypt intent: %w", err)
+	}
+	// Write to a temp file and rename so recovery never reads a partial intent
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write intent: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write intent: %w", err)
+	}
+	return nil
+}
+
+// RecoverIntents calls fn for every intent left behind by a process that
+// crashed, and removes the intents fn succeeds for. Intents still being
+// carried out by a live process are skipped. Returns the number recovered.
+func (s *StateStore) RecoverIntents(ctx context.Context, fn func(*Intent) error) (int, error) {
+	_ = ctx // Reserved for future use
+
+	dir := filepath.Join(s.stateDir, intentDirName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read intent directory: %w", err)
+	}
+
+	recovered := 0
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		release, err := tryLock(path + ".lock")
+		if errors.Is(err, errLockBusy) {
+			continue // Still in progress
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to lock intent %s: %w", entry.Name(), err))
+			continue
+		}
+
+		ok, err := s.recoverIntent(path, fn)
+		release()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ok {
+			recovered++
+		}
+	}
+	return recovered, errors.Join(errs...)
+}
+
+// recoverIntent recovers the intent at path. Callers must hold its lock.
+// Returns false without error if the intent was completed in the meantime.
+func (s *StateStore) recoverIntent(path string, fn func(*Intent) error) (bool, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is inside the state directory
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read intent: %w", err)
+	}
+	if data, err = s.decode(data); err != nil {
+		return false, fmt.Errorf("failed to read intent: %w", err)
+	}
+	var intent Intent
+	if err := json.Unmarshal(data, &intent); err != nil {
+		// A corrupt intent can't be acted on; drop it rather than failing every hook
+		_ = os.Remove(path)
+		return false, fmt.Errorf("failed to parse intent %s: %w", filepath.Base(path), err)
+	}
+	if intent.State != nil {
+		intent.State.NormalizeAfterLoad()
+	}
+
+	if err := fn(&intent); err != nil {
+		return false, fmt.Errorf("failed to recover %s of session %s: %w", intent.Operation, intent.SessionID, err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove intent: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateStore_Intents(t *testing.T) {
+	t.Parallel()
+
+	store := NewStateStoreWithDir(t.TempDir())
+	ctx := context.Background()
+	intent := &Intent{
+		SessionID: "2026-10-14-intent",
+		Operation: "checkpoint",
+		Refs:      []IntentRef{{Name: "refs/heads/entire/abc1234"}},
+		StartedAt: time.Now(),
+	}
+
+	journal, err := store.BeginIntent(ctx, intent)
+	if err != nil {
+		t.Fatalf("BeginIntent() error = %v", err)
+	}
+
+	var seen []*Intent
+	collect := func(i *Intent) error {
+		seen = append(seen, i)
+		return nil
+	}
+
+	// Held by a live operation: left alone
+	if n, err := store.RecoverIntents(ctx, collect); err != nil || n != 0 {
+		t.Fatalf("RecoverIntents() with live intent = %d, %v; want 0, nil", n, err)
+	}
+
+	if err := journal.RefsUpdated(map[string]string{"refs/heads/entire/abc1234": "1111111111111111111111111111111111111111"},
+		&State{SessionID: intent.SessionID, StepCount: 3}); err != nil {
+		t.Fatalf("RefsUpdated() error = %v", err)
+	}
+	journal.Close() // Crashed: closed without Complete
+
+	n, err := store.RecoverIntents(ctx, collect)
+	if err != nil || n != 1 {
+		t.Fatalf("RecoverIntents() = %d, %v; want 1, nil", n, err)
+	}
+	if len(seen) != 1 || !seen[0].RefsUpdated() || seen[0].State.StepCount != 3 {
+		t.Fatalf("recovered intent = %+v, want journaled refs and state", seen)
+	}
+
+	// Recovered intents are removed
+	if n, err := store.RecoverIntents(ctx, collect); err != nil || n != 0 {
+		t.Errorf("second RecoverIntents() = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestStateStore_CompletedIntentIsNotRecovered(t *testing.T) {
+	t.Parallel()
+
+	store := NewStateStoreWithDir(t.TempDir())
+	ctx := context.Background()
+	journal, err := store.BeginIntent(ctx, &Intent{SessionID: "2026-10-14-done", Operation: "checkpoint", StartedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("BeginIntent() error = %v", err)
+	}
+	if err := journal.Complete(); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	journal.Close()
+
+	n, err := store.RecoverIntents(ctx, func(*Intent) error {
+		t.Error("completed intent should not be recovered")
+		return nil
+	})
+	if err != nil || n != 0 {
+		t.Errorf("RecoverIntents() = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestStateStore_ClearRemovesIntent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := NewStateStoreWithDir(dir)
+	ctx := context.Background()
+	sessionID := "2026-10-14-cleared"
+	if err := store.Save(ctx, &State{SessionID: sessionID}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	journal, err := store.BeginIntent(ctx, &Intent{SessionID: sessionID, Operation: "checkpoint", StartedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("BeginIntent() error = %v", err)
+	}
+	journal.Close()
+
+	if err := store.Clear(ctx, sessionID); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, intentDirName, sessionID+".json")); !os.IsNotExist(err) {
+		t.Errorf("intent still exists after Clear: %v", err)
+	}
+}
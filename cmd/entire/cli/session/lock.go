@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State files are written by agent hooks, git hooks and CLI commands, often from
+// several processes at once (concurrent sessions, multiple worktrees sharing the
+// git common dir). All writes go through a single-writer lock on the state
+// directory so that read-modify-write cycles cannot interleave and lose updates.
+
+const (
+	// stateLockFileName is the lock file inside the session state directory.
+	stateLockFileName = ".lock"
+
+	// DefaultLockTimeout is how long a writer waits for the state lock before
+	// giving up with ErrStateLocked.
+	DefaultLockTimeout = 5 * time.Second
+
+	// lockRetryInterval is the delay between attempts to take a busy lock.
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// ErrStateLocked is returned when the session state lock could not be acquired
+// within the lock timeout.
+var ErrStateLocked = errors.New("session state is locked by another process")
+
+// errLockBusy is returned by tryLock when another process holds the lock.
+var errLockBusy = errors.New("lock busy")
+
+// acquireStateLock takes the exclusive writer lock for the state directory,
+// retrying until timeout elapses or ctx is done. The returned function releases it.
+func acquireStateLock(ctx context.Context, stateDir string, timeout time.Duration) (func(), error) {
+	if err := os.MkdirAll(stateDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create session state directory: %w", err)
+	}
+	release, err := acquireLock(ctx, filepath.Join(stateDir, stateLockFileName), timeout)
+	if errors.Is(err, errLockBusy) {
+		return nil, ErrStateLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock session state: %w", err)
+	}
+	return release, nil
+}
+
+// acquireLock takes the lock at lockPath, retrying until timeout elapses
+// (errLockBusy) or ctx is done.
+func acquireLock(ctx context.Context, lockPath string, timeout time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		release, err := tryLock(lockPath)
+		if err == nil {
+			return release, nil
+		}
+		if !errors.Is(err, errLockBusy) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errLockBusy
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err() //nolint:wrapcheck // callers wrap
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// LockFile takes the exclusive lock at lockPath, waiting up to timeout, for
+// other state files that concurrent hooks read, modify and write. The
+// returned function releases it.
+func LockFile(ctx context.Context, lockPath string, timeout time.Duration) (func(), error) {
+	release, err := acquireLock(ctx, lockPath, timeout)
+	if errors.Is(err, errLockBusy) {
+		return nil, fmt.Errorf("%s is locked by another process", lockPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+	return release, nil
+}
+
+// gcLockFileName is the lock file `entire gc` holds while it lists and deletes data.
+const gcLockFileName = ".gc.lock"
+
+// ErrGCRunning is returned by LockGC when another process is already collecting.
+var ErrGCRunning = errors.New("another entire gc is already running for this repository")
+
+// LockGC takes the garbage collection lock without waiting. The state directory
+// is shared by all worktrees, so this keeps gc runs started from different
+// worktrees from deleting data concurrently. The returned function releases it.
+func (s *StateStore) LockGC() (func(), error) {
+	if err := os.MkdirAll(s.stateDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create session state directory: %w", err)
+	}
+	release, err := tryLock(filepath.Join(s.stateDir, gcLockFileName))
+	if errors.Is(err, errLockBusy) {
+		return nil, ErrGCRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take gc lock: %w", err)
+	}
+	return release, nil
+}
@@ -0,0 +1,33 @@
+//go:build !unix
+
+package session
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockAge is how old a lock file must be before it is assumed to belong
+// to a process that died while holding it. State writes take milliseconds.
+const staleLockAge = 30 * time.Second
+
+// tryLock creates path exclusively; whoever creates it holds the lock.
+// Lock files left behind by a crashed process are removed once stale.
+func tryLock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec // path is inside the state directory
+	if err != nil {
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(path)
+		}
+		return nil, errLockBusy
+	}
+	_ = f.Close()
+
+	return func() {
+		_ = os.Remove(path)
+	}, nil
+}
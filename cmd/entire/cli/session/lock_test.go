@@ -0,0 +1,170 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Environment variables used to run TestStateStoreHelperProcess as a child writer.
+const (
+	helperDirEnv        = "ENTIRE_TEST_STATE_HELPER_DIR"
+	helperWriterEnv     = "ENTIRE_TEST_STATE_HELPER_WRITER"
+	helperIterationsEnv = "ENTIRE_TEST_STATE_HELPER_ITERATIONS"
+)
+
+const sharedTortureSession = "torture-shared"
+
+// TestStateStoreHelperProcess is not a real test: it is the body of the writer
+// processes spawned by TestStateStore_MultiProcessTorture.
+func TestStateStoreHelperProcess(t *testing.T) {
+	dir := os.Getenv(helperDirEnv)
+	if dir == "" {
+		t.Skip("helper process for TestStateStore_MultiProcessTorture")
+	}
+	writer := os.Getenv(helperWriterEnv)
+	iterations, err := strconv.Atoi(os.Getenv(helperIterationsEnv))
+	require.NoError(t, err)
+
+	store := NewStateStoreWithDir(dir)
+	ctx := context.Background()
+	own := "torture-" + writer
+
+	for i := range iterations {
+		// Read-modify-write on a session shared by every writer.
+		err := store.Update(ctx, sharedTortureSession, func(state *State) (*State, error) {
+			if state == nil {
+				state = &State{SessionID: sharedTortureSession}
+			}
+			state.StepCount++
+			state.FilesTouched = append(state.FilesTouched, fmt.Sprintf("%s-%d", writer, i))
+			return state, nil
+		})
+		require.NoError(t, err)
+
+		// Plain saves and clears of a session owned by this writer.
+		require.NoError(t, store.Save(ctx, &State{SessionID: own, StepCount: i + 1}))
+		if i%10 == 9 {
+			require.NoError(t, store.Clear(ctx, own))
+		}
+	}
+	require.NoError(t, store.Save(ctx, &State{SessionID: own, StepCount: iterations}))
+}
+
+// TestStateStore_MultiProcessTorture hammers one state directory from many
+// processes and checks that no update is lost and no file is left corrupt.
+func TestStateStore_MultiProcessTorture(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns writer processes")
+	}
+	t.Parallel()
+
+	const writers = 8
+	const iterations = 40
+
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	outputs := make([][]byte, writers)
+	errs := make([]error, writers)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.CommandContext(context.Background(), os.Args[0], "-test.run=^TestStateStoreHelperProcess$") //nolint:gosec // re-executing the test binary
+			cmd.Env = append(os.Environ(),
+				helperDirEnv+"="+dir,
+				helperWriterEnv+"="+strconv.Itoa(w),
+				helperIterationsEnv+"="+strconv.Itoa(iterations),
+			)
+			outputs[w], errs[w] = cmd.CombinedOutput()
+		}()
+	}
+	wg.Wait()
+	for w := range writers {
+		require.NoError(t, errs[w], "writer %d failed:\n%s", w, outputs[w])
+	}
+
+	store := NewStateStoreWithDir(dir)
+	ctx := context.Background()
+
+	shared, err := store.Load(ctx, sharedTortureSession)
+	require.NoError(t, err)
+	require.NotNil(t, shared)
+	assert.Equal(t, writers*iterations, shared.StepCount, "updates were lost")
+	assert.Len(t, shared.FilesTouched, writers*iterations)
+
+	states, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, states, writers+1)
+	for w := range writers {
+		own, err := store.Load(ctx, "torture-"+strconv.Itoa(w))
+		require.NoError(t, err)
+		require.NotNil(t, own)
+		assert.Equal(t, iterations, own.StepCount)
+	}
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "temp files left behind")
+}
+
+func TestStateStore_SaveTimesOutWhileLocked(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	release, err := acquireStateLock(context.Background(), dir, time.Second)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = acquireStateLock(context.Background(), dir, 50*time.Millisecond)
+	require.ErrorIs(t, err, ErrStateLocked)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = acquireStateLock(ctx, dir, time.Second)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStateStore_UpdateErrorWritesNothing(t *testing.T) {
+	t.Parallel()
+
+	store := NewStateStoreWithDir(t.TempDir())
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, &State{SessionID: "s1", StepCount: 1}))
+
+	errBoom := errors.New("boom")
+	err := store.Update(ctx, "s1", func(state *State) (*State, error) {
+		state.StepCount = 99
+		return state, errBoom
+	})
+	require.ErrorIs(t, err, errBoom)
+
+	state, err := store.Load(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, state.StepCount)
+}
+
+func TestStateStore_LockGC(t *testing.T) {
+	store := NewStateStoreWithDir(filepath.Join(t.TempDir(), "entire-sessions"))
+
+	release, err := store.LockGC()
+	require.NoError(t, err)
+
+	_, err = store.LockGC()
+	require.ErrorIs(t, err, ErrGCRunning)
+
+	release()
+	release, err = store.LockGC()
+	require.NoError(t, err, "lock should be free after release")
+	release()
+}
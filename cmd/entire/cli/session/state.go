@@ -8,11 +8,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/faultinject"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
 	"github.com/entireio/cli/cmd/entire/cli/validation"
 )
 
@@ -77,6 +83,11 @@ type State struct {
 	// for checkpoint condensation: "everything since last checkpoint".
 	CheckpointTranscriptStart int `json:"checkpoint_transcript_start,omitempty"`
 
+	// TranscriptBookmark is where the last hook stopped reading the transcript.
+	// Hooks resume from it rather than reparsing the whole transcript, as long
+	// as the transcript still matches it.
+	TranscriptBookmark *transcript.Bookmark `json:"transcript_bookmark,omitempty"`
+
 	// Deprecated: CondensedTranscriptLines is replaced by CheckpointTranscriptStart.
 	// Kept for backward compatibility with existing state files.
 	// Use NormalizeAfterLoad() to migrate.
@@ -94,6 +105,58 @@ type State struct {
 	// AgentType identifies the agent that created this session (e.g., "Claude Code", "Gemini CLI", "Cursor")
 	AgentType agent.AgentType `json:"agent_type,omitempty"`
 
+	// Automation is why the session is considered autonomous rather than
+	// human-supervised, detected when it starts (see checkpoint.CommittedMetadata).
+	Automation []string `json:"automation,omitempty"`
+
+	// TaskType is the kind of task the session's prompts ask for (bugfix,
+	// refactor, tests, docs, greenfield), tagged at prompt submit. A session
+	// tagged "other" is tagged again by its next prompt.
+	TaskType string `json:"task_type,omitempty"`
+
+	// Title names the session by its intent, derived from the first prompt
+	// (see DeriveTitle).
+	Title string `json:"title,omitempty"`
+
+	// Tags are the labels added with 'entire sessions tag', lowercase and
+	// sorted.
+	Tags []string `json:"tags,omitempty"`
+
+	// WorkspaceRepos are the roots of the other repositories of the
+	// workspace this session saved checkpoints in, in the order it first did.
+	WorkspaceRepos []string `json:"workspace_repos,omitempty"`
+
+	// WorkspaceRoot is set in the state of those repositories: the root of
+	// the repository whose hooks recorded the session.
+	WorkspaceRoot string `json:"workspace_root,omitempty"`
+
+	// ReconstructionConfidence is non-zero when the current cycle's shadow
+	// branch was lost and its checkpoints rebuilt from the transcript (see
+	// strategy.ReconstructSession). Cleared on condensation with StepCount.
+	ReconstructionConfidence float64 `json:"reconstruction_confidence,omitempty"`
+
+	// Subagents are the subagent (Task tool) runs since the last commit, in
+	// the order they started. They are condensed into the committed
+	// checkpoint as children of this session. Cleared on condensation with
+	// StepCount.
+	Subagents []SubagentRun `json:"subagents,omitempty"`
+
+	// HumanEdits are the edits `entire daemon` saw the human make while the
+	// session was idle, since the last commit. They date the human's work on
+	// the timeline; attribution still counts it through PromptAttributions.
+	// Cleared on condensation with StepCount.
+	HumanEdits []HumanEdit `json:"human_edits,omitempty"`
+
+	// Notes are what the agent recorded with the entire_note MCP tool since
+	// the last commit, in order. They are condensed into the committed
+	// checkpoint. Cleared on condensation with StepCount.
+	Notes []Note `json:"notes,omitempty"`
+
+	// Summary is the latest summary of the session, generated with 'entire
+	// transcript summarize'. Commit message templates can use it. Kept
+	// across condensations: it describes the whole session.
+	Summary *Summary `json:"summary,omitempty"`
+
 	// Token usage tracking (accumulated across all checkpoints in this session)
 	TokenUsage *agent.TokenUsage `json:"token_usage,omitempty"`
 
@@ -118,6 +181,68 @@ type State struct {
 	// PendingPromptAttribution holds attribution calculated at prompt start (before agent runs).
 	// This is moved to PromptAttributions when SaveChanges is called.
 	PendingPromptAttribution *PromptAttribution `json:"pending_prompt_attribution,omitempty"`
+
+	// CheckpointEpochs rolls up the checkpoints of the current cycle in groups of
+	// CheckpointEpochSize (see epoch.go). Cleared on condensation with StepCount.
+	CheckpointEpochs []CheckpointEpoch `json:"checkpoint_epochs,omitempty"`
+
+	// Resumes are the times the agent resumed this session after a restart,
+	// oldest first, at most MaxResumeRecords.
+	Resumes []ResumeRecord `json:"resumes,omitempty"`
+}
+
+// MaxResumeRecords is how many resumes a session keeps a record of.
+const MaxResumeRecords = 20
+
+// What happened to the current cycle's checkpoints when a session resumed
+const (
+	// ResumeCheckpointsKept: the shadow branch was where the session left it
+	ResumeCheckpointsKept = "kept"
+	// ResumeCheckpointsMoved: HEAD had moved and the shadow branch was moved
+	// onto it
+	ResumeCheckpointsMoved = "moved"
+	// ResumeCheckpointsRestored: the session had expired and its shadow
+	// branch was brought back from the archive
+	ResumeCheckpointsRestored = "restored"
+	// ResumeCheckpointsReconstructed: the shadow branch was gone and was
+	// rebuilt from the transcript
+	ResumeCheckpointsReconstructed = "reconstructed"
+	// ResumeCheckpointsLost: the shadow branch was gone and couldn't be
+	// rebuilt, so the cycle starts over
+	ResumeCheckpointsLost = "lost"
+)
+
+// ResumeRecord is one resume of a session, and what the CLI found and did
+// to continue it rather than start over.
+type ResumeRecord struct {
+	At time.Time `json:"at"`
+	// Source is what the agent reported, e.g. "resume"; empty if it doesn't say
+	Source string `json:"source,omitempty"`
+	// PreviousBase is the base commit the session had, set if HEAD moved
+	// while the agent wasn't running
+	PreviousBase string `json:"previous_base,omitempty"`
+	// BaseMissing is set if PreviousBase no longer exists in the repository,
+	// e.g. after a rebase and gc
+	BaseMissing bool `json:"base_missing,omitempty"`
+	// Base is the base commit the session continues from
+	Base string `json:"base"`
+	// Unarchived is set if the session had expired and its state was
+	// brought back from the archive
+	Unarchived bool `json:"unarchived,omitempty"`
+	// Checkpoints is what happened to the current cycle's checkpoints (see
+	// ResumeCheckpointsKept and the like), empty if there were none
+	Checkpoints string `json:"checkpoints,omitempty"`
+	// LostCheckpoints is how many checkpoints were lost
+	LostCheckpoints int `json:"lost_checkpoints,omitempty"`
+}
+
+// RecordResume appends r to the session's resumes, dropping the oldest
+// beyond MaxResumeRecords.
+func (s *State) RecordResume(r ResumeRecord) {
+	s.Resumes = append(s.Resumes, r)
+	if len(s.Resumes) > MaxResumeRecords {
+		s.Resumes = s.Resumes[len(s.Resumes)-MaxResumeRecords:]
+	}
 }
 
 // PromptAttribution captures line-level attribution data at the start of each prompt.
@@ -183,30 +308,106 @@ func (s *State) NormalizeAfterLoad() {
 //
 // Use StateStore directly in strategies for performance-critical state operations.
 // Use the Sessions interface (when implemented) for high-level session management.
+//
+// Sessions of the main worktree are stored directly in the state directory,
+// those of linked worktrees in worktrees/<worktree-id>/ below it, so a session
+// resumed in two worktrees keeps a separate state in each.
 type StateStore struct {
 	// stateDir is the directory where session state files are stored
 	stateDir string
+
+	// worktreeID is the worktree whose copy of a session Load and Clear prefer
+	worktreeID string
+
+	// crypt is the encryption config, resolved on first use and shared by
+	// stores on the same directory
+	crypt *storeEncryption
+}
+
+// storeEncryption resolves the encryption config once per store, so state
+// writes don't reload settings.
+type storeEncryption struct {
+	once sync.Once
+	cfg  encryption.Config
+	err  error
+}
+
+func (e *storeEncryption) config() (encryption.Config, error) {
+	e.once.Do(func() {
+		e.cfg, e.err = encryption.LoadConfig()
+	})
+	return e.cfg, e.err
+}
+
+// encode encrypts data written to the state directory if encryption is on.
+func (s *StateStore) encode(data []byte) ([]byte, error) {
+	cfg, err := s.crypt.config()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Encode(data) //nolint:wrapcheck // callers wrap
+}
+
+// decode decrypts data read from the state directory. Plaintext is returned
+// without resolving the config.
+func (s *StateStore) decode(data []byte) ([]byte, error) {
+	if !encryption.IsEncrypted(data) {
+		return data, nil
+	}
+	cfg, err := s.crypt.config()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Decode(data) //nolint:wrapcheck // callers wrap
 }
 
+// worktreeStateDirName is the subdirectory of the state directory holding
+// the session state of linked worktrees.
+const worktreeStateDirName = "worktrees"
+
+// ArchiveDirName is the subdirectory of the state directory holding the
+// state of expired sessions, which List and Load no longer see.
+const ArchiveDirName = "archive"
+
 // NewStateStore creates a new state store.
-// Uses the git common dir to store session state (shared across worktrees).
+// Uses the git common dir to store session state (shared across worktrees),
+// or a directory outside the repository if the git dir is read-only or on a
+// network filesystem (see fsenv.StateDir).
 func NewStateStore() (*StateStore, error) {
 	commonDir, err := getGitCommonDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get git common dir: %w", err)
 	}
 	return &StateStore{
-		stateDir: filepath.Join(commonDir, SessionStateDirName),
+		stateDir:   fsenv.StateDir(commonDir, SessionStateDirName),
+		worktreeID: currentWorktreeID(commonDir),
+		crypt:      &storeEncryption{},
 	}, nil
 }
 
 // NewStateStoreWithDir creates a new state store with a custom directory.
 // This is useful for testing.
 func NewStateStoreWithDir(stateDir string) *StateStore {
-	return &StateStore{stateDir: stateDir}
+	return &StateStore{stateDir: stateDir, crypt: &storeEncryption{}}
 }
 
-// Load loads the session state for the given session ID.
+// ForWorktree returns a store on the same directory that prefers the state
+// of worktreeID when a session exists in several worktrees.
+func (s *StateStore) ForWorktree(worktreeID string) *StateStore {
+	return &StateStore{stateDir: s.stateDir, worktreeID: worktreeID, crypt: s.crypt}
+}
+
+// WorktreeStateDir returns the directory holding the session state of a
+// worktree: the state directory itself for the main worktree (empty ID).
+func (s *StateStore) WorktreeStateDir(worktreeID string) string {
+	if worktreeID == "" || !validWorktreeID(worktreeID) {
+		return s.stateDir
+	}
+	return filepath.Join(s.stateDir, worktreeStateDirName, worktreeID)
+}
+
+// Load loads the session state for the given session ID, looking in the
+// preferred worktree first, then the main worktree, then any other worktree.
 // Returns (nil, nil) when session file doesn't exist (not an error condition).
 func (s *StateStore) Load(ctx context.Context, sessionID string) (*State, error) {
 	_ = ctx // Reserved for future use
@@ -216,8 +417,15 @@ func (s *StateStore) Load(ctx context.Context, sessionID string) (*State, error)
 		return nil, fmt.Errorf("invalid session ID: %w", err)
 	}
 
-	stateFile := s.stateFilePath(sessionID)
+	stateFile := s.findStateFile(sessionID)
+	if stateFile == "" {
+		return nil, nil //nolint:nilnil // nil,nil indicates session not found (expected case)
+	}
+	return s.readStateFile(stateFile)
+}
 
+// readStateFile reads one session state file. Returns (nil, nil) if it doesn't exist.
+func (s *StateStore) readStateFile(stateFile string) (*State, error) {
 	data, err := os.ReadFile(stateFile) //nolint:gosec // stateFile is derived from sessionID
 	if os.IsNotExist(err) {
 		return nil, nil //nolint:nilnil // nil,nil indicates session not found (expected case)
@@ -225,6 +433,9 @@ func (s *StateStore) Load(ctx context.Context, sessionID string) (*State, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read session state: %w", err)
 	}
+	if data, err = s.decode(data); err != nil {
+		return nil, fmt.Errorf("failed to read session state: %w", err)
+	}
 
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
@@ -235,46 +446,127 @@ func (s *StateStore) Load(ctx context.Context, sessionID string) (*State, error)
 }
 
 // Save saves the session state atomically.
+// Writers in other processes are serialized by the state directory lock.
 func (s *StateStore) Save(ctx context.Context, state *State) error {
-	_ = ctx // Reserved for future use
+	// Validate session ID to prevent path traversal
+	if err := validation.ProfilesFor(string(state.AgentType)).ValidateSessionID(state.SessionID); err != nil {
+		return fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	release, err := acquireStateLock(ctx, s.stateDir, DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
 
+	return s.write(state)
+}
+
+// Update loads the session state, applies fn and saves the result while holding
+// the state directory lock, so concurrent updates from other processes are not lost.
+// fn receives nil if the session does not exist; returning a nil state from a
+// missing session is a no-op. If fn returns an error, nothing is written.
+func (s *StateStore) Update(ctx context.Context, sessionID string, fn func(*State) (*State, error)) error {
 	// Validate session ID to prevent path traversal
-	if err := validation.ValidateSessionID(state.SessionID); err != nil {
+	if err := validation.ValidateSessionID(sessionID); err != nil {
 		return fmt.Errorf("invalid session ID: %w", err)
 	}
 
-	if err := os.MkdirAll(s.stateDir, 0o750); err != nil {
-		return fmt.Errorf("failed to create session state directory: %w", err)
+	release, err := acquireStateLock(ctx, s.stateDir, DefaultLockTimeout)
+	if err != nil {
+		return err
 	}
+	defer release()
 
+	state, err := s.Load(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	updated, err := fn(state)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		return nil
+	}
+	if updated.SessionID != sessionID {
+		return fmt.Errorf("session ID changed during update: %q != %q", updated.SessionID, sessionID)
+	}
+	return s.write(updated)
+}
+
+// write atomically replaces the state file. Callers must hold the state lock.
+func (s *StateStore) write(state *State) error {
 	data, err := jsonutil.MarshalIndentWithNewline(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal session state: %w", err)
 	}
+	if data, err = s.encode(data); err != nil {
+		return fmt.Errorf("failed to encrypt session state: %w", err)
+	}
 
-	stateFile := s.stateFilePath(state.SessionID)
+	dir := s.WorktreeStateDir(state.WorktreeID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create session state directory: %w", err)
+	}
+	stateFile := filepath.Join(dir, state.SessionID+".json")
 
-	// Atomic write: write to temp file, then rename
-	tmpFile := stateFile + ".tmp"
-	if err := os.WriteFile(tmpFile, data, 0o600); err != nil {
+	// Atomic write: write to a uniquely named temp file, then rename
+	tmp, err := os.CreateTemp(dir, state.SessionID+".json.*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+	tmpFile := tmp.Name()
+	// An injected fault writes part of the data and fails like a crash would
+	data, err = faultinject.Truncate(stateFile, data)
+	if _, writeErr := tmp.Write(data); err == nil {
+		err = writeErr
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile)
 		return fmt.Errorf("failed to write session state: %w", err)
 	}
 	if err := os.Rename(tmpFile, stateFile); err != nil {
+		_ = os.Remove(tmpFile)
 		return fmt.Errorf("failed to rename session state file: %w", err)
 	}
+
+	// Linked worktree sessions used to be stored with the main worktree's
+	if dir != s.stateDir {
+		legacyFile := filepath.Join(s.stateDir, state.SessionID+".json")
+		if legacy, err := s.readStateFile(legacyFile); err == nil && legacy != nil && legacy.WorktreeID == state.WorktreeID {
+			_ = os.Remove(legacyFile)
+		}
+	}
 	return nil
 }
 
 // Clear removes the session state file for the given session ID.
 func (s *StateStore) Clear(ctx context.Context, sessionID string) error {
-	_ = ctx // Reserved for future use
-
 	// Validate session ID to prevent path traversal
 	if err := validation.ValidateSessionID(sessionID); err != nil {
 		return fmt.Errorf("invalid session ID: %w", err)
 	}
 
-	stateFile := s.stateFilePath(sessionID)
+	release, err := acquireStateLock(ctx, s.stateDir, DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// An intent left by a crashed checkpoint would bring the state back on recovery
+	intentFile := filepath.Join(s.stateDir, intentDirName, sessionID+".json")
+	if err := os.Remove(intentFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint intent: %w", err)
+	}
+
+	stateFile := s.findStateFile(sessionID)
+	if stateFile == "" {
+		return nil // Already gone, not an error
+	}
 
 	if err := os.Remove(stateFile); err != nil {
 		if os.IsNotExist(err) {
@@ -285,6 +577,71 @@ func (s *StateStore) Clear(ctx context.Context, sessionID string) error {
 	return nil
 }
 
+// Archive moves the state file of state's session and worktree to the
+// archive directory and returns its new path, "" if it has no state file.
+// An older archived state of the session is replaced.
+func (s *StateStore) Archive(ctx context.Context, state *State) (string, error) {
+	// Validate session ID to prevent path traversal
+	if err := validation.ValidateSessionID(state.SessionID); err != nil {
+		return "", fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	release, err := acquireStateLock(ctx, s.stateDir, DefaultLockTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	stateFile := filepath.Join(s.WorktreeStateDir(state.WorktreeID), state.SessionID+".json")
+	if _, err := os.Stat(stateFile); os.IsNotExist(err) {
+		return "", nil
+	}
+	archiveDir := filepath.Join(s.stateDir, ArchiveDirName)
+	if err := os.MkdirAll(archiveDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create session archive directory: %w", err)
+	}
+	archived := filepath.Join(archiveDir, state.SessionID+".json")
+	if err := os.Rename(stateFile, archived); err != nil {
+		return "", fmt.Errorf("failed to archive session state: %w", err)
+	}
+
+	// An intent left by a crashed checkpoint would bring the state back on recovery
+	intentFile := filepath.Join(s.stateDir, intentDirName, state.SessionID+".json")
+	if err := os.Remove(intentFile); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove checkpoint intent: %w", err)
+	}
+	return archived, nil
+}
+
+// Unarchive moves the archived state of an expired session back to its
+// worktree and returns it, (nil, nil) if the session isn't archived.
+func (s *StateStore) Unarchive(ctx context.Context, sessionID string) (*State, error) {
+	// Validate session ID to prevent path traversal
+	if err := validation.ValidateSessionID(sessionID); err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	release, err := acquireStateLock(ctx, s.stateDir, DefaultLockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	archived := filepath.Join(s.stateDir, ArchiveDirName, sessionID+".json")
+	state, err := s.readStateFile(archived)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	dir := s.WorktreeStateDir(state.WorktreeID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create session state directory: %w", err)
+	}
+	if err := os.Rename(archived, filepath.Join(dir, sessionID+".json")); err != nil {
+		return nil, fmt.Errorf("failed to unarchive session state: %w", err)
+	}
+	return state, nil
+}
+
 // RemoveAll removes the entire session state directory.
 // This is used during uninstall to completely remove all session state.
 func (s *StateStore) RemoveAll() error {
@@ -294,11 +651,34 @@ func (s *StateStore) RemoveAll() error {
 	return nil
 }
 
-// List returns all session states.
+// List returns all session states of all worktrees.
 func (s *StateStore) List(ctx context.Context) ([]*State, error) {
+	states, err := s.listDir(ctx, s.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(s.stateDir, worktreeStateDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read worktree session state directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !validWorktreeID(entry.Name()) {
+			continue
+		}
+		worktreeStates, err := s.listDir(ctx, s.WorktreeStateDir(entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, worktreeStates...)
+	}
+	return states, nil
+}
+
+// listDir returns the session states stored directly in dir.
+func (s *StateStore) listDir(ctx context.Context, dir string) ([]*State, error) {
 	_ = ctx // Reserved for future use
 
-	entries, err := os.ReadDir(s.stateDir)
+	entries, err := os.ReadDir(dir)
 	if os.IsNotExist(err) {
 		return nil, nil
 	}
@@ -316,7 +696,10 @@ func (s *StateStore) List(ctx context.Context) ([]*State, error) {
 		}
 
 		sessionID := strings.TrimSuffix(entry.Name(), ".json")
-		state, err := s.Load(ctx, sessionID)
+		if validation.ValidateSessionID(sessionID) != nil {
+			continue
+		}
+		state, err := s.readStateFile(filepath.Join(dir, entry.Name()))
 		if err != nil {
 			continue // Skip corrupted state files
 		}
@@ -329,9 +712,50 @@ func (s *StateStore) List(ctx context.Context) ([]*State, error) {
 	return states, nil
 }
 
-// stateFilePath returns the path to a session state file.
-func (s *StateStore) stateFilePath(sessionID string) string {
-	return filepath.Join(s.stateDir, sessionID+".json")
+// findStateFile returns the path of the session's state file: the preferred
+// worktree's, else the main worktree's, else that of any linked worktree.
+// Returns "" if the session has no state.
+func (s *StateStore) findStateFile(sessionID string) string {
+	name := sessionID + ".json"
+	candidates := []string{filepath.Join(s.WorktreeStateDir(s.worktreeID), name)}
+	if s.worktreeID != "" {
+		candidates = append(candidates, filepath.Join(s.stateDir, name))
+	}
+	others, _ := filepath.Glob(filepath.Join(s.stateDir, worktreeStateDirName, "*", name)) //nolint:errcheck // Only fails on a malformed pattern
+	candidates = append(candidates, others...)
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// validWorktreeID reports whether id can be used as a directory name.
+// Git names linked worktrees after the base name of their path.
+func validWorktreeID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
+
+// currentWorktreeID returns the name of the linked worktree the current
+// directory is in, or "" for the main worktree and in CI mode, where
+// sessions are keyed by commit rather than worktree.
+func currentWorktreeID(commonDir string) string {
+	if cienv.Enabled() {
+		return ""
+	}
+	cmd := exec.CommandContext(context.Background(), "git", "rev-parse", "--absolute-git-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	gitDir := filepath.Clean(strings.TrimSpace(string(output)))
+	// Linked worktrees have their own git dir at <common-dir>/worktrees/<id>
+	absCommon, err := filepath.Abs(commonDir)
+	if err != nil || gitDir == absCommon || filepath.Base(filepath.Dir(gitDir)) != "worktrees" {
+		return ""
+	}
+	return filepath.Base(gitDir)
 }
 
 // getGitCommonDir returns the path to the shared git directory.
@@ -356,3 +780,61 @@ func getGitCommonDir() (string, error) {
 
 	return filepath.Clean(commonDir), nil
 }
+
+// HumanEdit is one batch of file changes the human made between hooks.
+type HumanEdit struct {
+	At           time.Time `json:"at"`
+	Files        []string  `json:"files"`
+	LinesAdded   int       `json:"lines_added"`
+	LinesRemoved int       `json:"lines_removed"`
+}
+
+// Note kinds the agent can record
+const (
+	NoteKindNote       = "note"
+	NoteKindAssumption = "assumption"
+	NoteKindTodo       = "todo"
+	NoteKindCaveat     = "caveat"
+	NoteKindQuestion   = "question"
+)
+
+// NoteKinds lists the note kinds in the order reports group them.
+var NoteKinds = []string{NoteKindAssumption, NoteKindCaveat, NoteKindTodo, NoteKindQuestion, NoteKindNote}
+
+// Note is a caveat the agent recorded about its work, e.g. "I assumed X".
+type Note struct {
+	At    time.Time `json:"at"`
+	Kind  string    `json:"kind"`
+	Text  string    `json:"text"`
+	Files []string  `json:"files,omitempty"`
+}
+
+// Summary is a short natural-language summary of a session, as of
+// GeneratedAt.
+type Summary struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Generator is the summarizer provider, e.g. "anthropic"
+	Generator string `json:"generator,omitempty"`
+	// Intent is what the session set out to do, Outcome what it achieved
+	Intent    string   `json:"intent"`
+	Outcome   string   `json:"outcome"`
+	Decisions []string `json:"decisions,omitempty"`
+	OpenItems []string `json:"open_items,omitempty"`
+	// FilesChanged are the files the session touched when it was summarized
+	FilesChanged []string `json:"files_changed,omitempty"`
+}
+
+// SubagentRun is one subagent (Task tool) run of a session.
+type SubagentRun struct {
+	ToolUseID    string `json:"tool_use_id"`
+	AgentID      string `json:"agent_id,omitempty"`
+	SubagentType string `json:"subagent_type,omitempty"`
+	Description  string `json:"description,omitempty"`
+
+	// Checkpoints counts the subagent's checkpoints, incremental and final.
+	Checkpoints  int      `json:"checkpoints"`
+	FilesTouched []string `json:"files_touched,omitempty"`
+
+	// Done is set by the subagent's final checkpoint.
+	Done bool `json:"done,omitempty"`
+}
@@ -1,9 +1,16 @@
 package session
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/faultinject"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -118,3 +125,188 @@ func TestState_NormalizeAfterLoad_JSONRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestStateStore_WorktreeDirectories(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store := NewStateStoreWithDir(dir)
+
+	// The same session resumed in the main worktree and a linked one
+	if err := store.Save(ctx, &State{SessionID: "shared", BaseCommit: "aaa"}); err != nil {
+		t.Fatalf("Save(main) error = %v", err)
+	}
+	if err := store.Save(ctx, &State{SessionID: "shared", BaseCommit: "bbb", WorktreeID: "feature"}); err != nil {
+		t.Fatalf("Save(feature) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "worktrees", "feature", "shared.json")); err != nil {
+		t.Errorf("linked worktree state not in its own directory: %v", err)
+	}
+
+	if state, err := store.Load(ctx, "shared"); err != nil || state.BaseCommit != "aaa" {
+		t.Errorf("Load() from main = %+v, %v; want the main worktree's state", state, err)
+	}
+	if state, err := store.ForWorktree("feature").Load(ctx, "shared"); err != nil || state.BaseCommit != "bbb" {
+		t.Errorf("Load() from feature = %+v, %v; want the feature worktree's state", state, err)
+	}
+	if err := store.Save(ctx, &State{SessionID: "only-linked", WorktreeID: "other"}); err != nil {
+		t.Fatalf("Save(other) error = %v", err)
+	}
+	if state, err := store.Load(ctx, "only-linked"); err != nil || state == nil || state.WorktreeID != "other" {
+		t.Errorf("Load() of another worktree's session = %+v, %v; want it found", state, err)
+	}
+
+	states, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(states) != 3 {
+		t.Errorf("List() returned %d states, want 3 across worktrees", len(states))
+	}
+
+	if err := store.ForWorktree("feature").Clear(ctx, "shared"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if state, err := store.Load(ctx, "shared"); err != nil || state == nil {
+		t.Errorf("Clear() from feature removed the main worktree's state: %+v, %v", state, err)
+	}
+}
+
+func TestStateStore_MigratesLegacyWorktreeState(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store := NewStateStoreWithDir(dir)
+
+	// Written by an older version into the shared directory
+	legacy := []byte(`{"session_id": "old", "base_commit": "aaa", "worktree_id": "feature", "started_at": "2026-10-14T09:00:00Z", "checkpoint_count": 1}`)
+	if err := os.WriteFile(filepath.Join(dir, "old.json"), legacy, 0o600); err != nil {
+		t.Fatalf("failed to write legacy state: %v", err)
+	}
+	state, err := store.ForWorktree("feature").Load(ctx, "old")
+	if err != nil || state == nil {
+		t.Fatalf("Load() of legacy state = %+v, %v", state, err)
+	}
+	state.StepCount = 2
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.json")); !os.IsNotExist(err) {
+		t.Errorf("legacy state file still exists after save (err = %v)", err)
+	}
+	states, err := store.List(ctx)
+	if err != nil || len(states) != 1 || states[0].StepCount != 2 {
+		t.Errorf("List() = %+v, %v; want the migrated state once", states, err)
+	}
+}
+
+func TestStateStore_Unarchive(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store := NewStateStoreWithDir(dir)
+
+	state := &State{SessionID: "expired", BaseCommit: "aaa", WorktreeID: "feature"}
+	require.NoError(t, store.Save(ctx, state))
+	_, err := store.Archive(ctx, state)
+	require.NoError(t, err)
+	loaded, err := store.Load(ctx, "expired")
+	require.NoError(t, err)
+	require.Nil(t, loaded, "archived session still loads")
+
+	unarchived, err := store.Unarchive(ctx, "expired")
+	require.NoError(t, err)
+	require.NotNil(t, unarchived)
+	assert.Equal(t, "aaa", unarchived.BaseCommit)
+	loaded, err = store.ForWorktree("feature").Load(ctx, "expired")
+	require.NoError(t, err)
+	require.NotNil(t, loaded, "unarchived session doesn't load")
+	_, err = os.Stat(filepath.Join(dir, ArchiveDirName, "expired.json"))
+	assert.True(t, os.IsNotExist(err), "archived state left behind (err = %v)", err)
+
+	again, err := store.Unarchive(ctx, "expired")
+	require.NoError(t, err)
+	assert.Nil(t, again, "Unarchive() of a session that isn't archived")
+}
+
+func TestState_RecordResume(t *testing.T) {
+	t.Parallel()
+	var state State
+	for i := range MaxResumeRecords + 2 {
+		state.RecordResume(ResumeRecord{LostCheckpoints: i})
+	}
+	require.Len(t, state.Resumes, MaxResumeRecords)
+	assert.Equal(t, 2, state.Resumes[0].LostCheckpoints, "oldest resumes are dropped first")
+}
+
+func TestStateStore_SaveSurvivesTruncatedWrite(t *testing.T) {
+	stateDir := t.TempDir()
+	store := NewStateStoreWithDir(stateDir)
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, &State{SessionID: "truncated", StepCount: 1}))
+
+	t.Setenv(faultinject.EnvVar, "state-truncate")
+	err := store.Save(ctx, &State{SessionID: "truncated", StepCount: 2})
+	require.ErrorIs(t, err, faultinject.ErrInjected)
+
+	t.Setenv(faultinject.EnvVar, "")
+	loaded, err := store.Load(ctx, "truncated")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, 1, loaded.StepCount, "a failed write must leave the previous state in place")
+
+	entries, err := os.ReadDir(stateDir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.NotEqual(t, ".tmp", filepath.Ext(e.Name()), "temp file %s left behind", e.Name())
+	}
+}
+
+func TestStateStore_EncryptedRoundTrip(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".entire"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".entire", "settings.json"), []byte(`{"encryption": {"enabled": true}}`), 0o644))
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(encryption.KeyEnvVar, base64.StdEncoding.EncodeToString(make([]byte, encryption.KeySize)))
+	t.Chdir(repo)
+	paths.ClearRepoRootCache()
+	t.Cleanup(paths.ClearRepoRootCache)
+
+	stateDir := t.TempDir()
+	store := NewStateStoreWithDir(stateDir)
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, &State{SessionID: "secret", FirstPrompt: "fix the login bug"}))
+
+	raw, err := os.ReadFile(filepath.Join(stateDir, "secret.json"))
+	require.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(raw), "state file should be encrypted on disk")
+	assert.NotContains(t, string(raw), "login bug")
+
+	loaded, err := store.Load(ctx, "secret")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "fix the login bug", loaded.FirstPrompt)
+}
+
+func TestStateStore_UnreadableSettingsFailsClosed(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".entire"), 0o755))
+	settingsFile := filepath.Join(repo, ".entire", "settings.json")
+	require.NoError(t, os.WriteFile(settingsFile, []byte(`{"encryption": {"enabled": tr`), 0o644))
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(repo)
+	paths.ClearRepoRootCache()
+	t.Cleanup(paths.ClearRepoRootCache)
+
+	stateDir := t.TempDir()
+	store := NewStateStoreWithDir(stateDir)
+	ctx := context.Background()
+	require.Error(t, store.Save(ctx, &State{SessionID: "secret", FirstPrompt: "fix the login bug"}))
+	_, err := os.Stat(filepath.Join(stateDir, "secret.json"))
+	assert.True(t, os.IsNotExist(err), "no plaintext state should be written")
+
+	// The config is resolved once per store: fixing settings takes a new store.
+	require.NoError(t, os.WriteFile(settingsFile, []byte(`{}`), 0o644))
+	require.Error(t, store.Save(ctx, &State{SessionID: "secret"}))
+	require.NoError(t, NewStateStoreWithDir(stateDir).Save(ctx, &State{SessionID: "secret"}))
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+)
+
+// MaxTitleRunes bounds derived titles so listings stay one line per session.
+const MaxTitleRunes = 60
+
+// MaxTagRunes bounds the length of a tag.
+const MaxTagRunes = 40
+
+// titleFillers are openings of a prompt that say nothing about its intent.
+var titleFillers = []string{
+	"please ", "can you ", "could you ", "would you ", "will you ",
+	"i want you to ", "i'd like you to ", "i would like you to ", "help me ",
+}
+
+// DeriveTitle makes a short title from a session's first prompt: its first
+// non-empty line without markdown markers or polite openings, capitalized
+// and cut at a word boundary. Returns "" for an empty prompt.
+func DeriveTitle(prompt string) string {
+	line := ""
+	for _, l := range strings.Split(prompt, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			line = l
+			break
+		}
+	}
+	line = strings.TrimLeft(line, "#>*-` \t")
+	line = stringutil.CollapseWhitespace(strings.Trim(line, `"'`))
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, filler := range titleFillers {
+			if len(line) > len(filler) && strings.EqualFold(line[:len(filler)], filler) {
+				line, trimmed = line[len(filler):], true
+			}
+		}
+	}
+	line = strings.TrimRight(line, ".!?,;: ")
+	if line == "" {
+		return ""
+	}
+	line = stringutil.CapitalizeFirst(line)
+
+	runes := []rune(line)
+	if len(runes) <= MaxTitleRunes {
+		return line
+	}
+	cut := MaxTitleRunes - len("...")
+	head := string(runes[:cut+1])
+	if i := strings.LastIndexFunc(head, unicode.IsSpace); i > 0 {
+		return strings.TrimRight(head[:i], ".,;: ") + "..."
+	}
+	return string(runes[:cut]) + "..."
+}
+
+// DisplayTitle returns the session's title, derived from its first prompt
+// for sessions recorded before titles were.
+func (s *State) DisplayTitle() string {
+	if s.Title != "" {
+		return s.Title
+	}
+	return DeriveTitle(s.FirstPrompt)
+}
+
+// NormalizeTag lowercases a tag and checks it's a single word of letters,
+// digits, '-', '_', '.' or '/'.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(tag, "#")))
+	if tag == "" {
+		return "", errors.New("empty tag")
+	}
+	if len([]rune(tag)) > MaxTagRunes {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagRunes)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_./", r) {
+			return "", fmt.Errorf("tag %q may only contain letters, digits, '-', '_', '.' and '/'", tag)
+		}
+	}
+	return tag, nil
+}
+
+// AddTags adds normalized tags, keeping Tags sorted and unique. Returns the
+// tags that weren't there yet.
+func (s *State) AddTags(tags ...string) []string {
+	var added []string
+	for _, tag := range tags {
+		if !slices.Contains(s.Tags, tag) {
+			s.Tags = append(s.Tags, tag)
+			added = append(added, tag)
+		}
+	}
+	slices.Sort(s.Tags)
+	return added
+}
+
+// RemoveTags removes tags. Returns the tags that were there.
+func (s *State) RemoveTags(tags ...string) []string {
+	var removed []string
+	s.Tags = slices.DeleteFunc(s.Tags, func(tag string) bool {
+		if slices.Contains(tags, tag) {
+			removed = append(removed, tag)
+			return true
+		}
+		return false
+	})
+	if len(s.Tags) == 0 {
+		s.Tags = nil
+	}
+	return removed
+}
+
+// HasTag reports whether the session is tagged with tag.
+func (s *State) HasTag(tag string) bool {
+	return slices.Contains(s.Tags, tag)
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDeriveTitle(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"", ""},
+		{"   \n\n", ""},
+		{"fix the flaky login test", "Fix the flaky login test"},
+		{"Please can you add pagination to the API?", "Add pagination to the API"},
+		{"\n## Refactor the parser\n\nIt is too slow.", "Refactor the parser"},
+		{`"rename Foo to Bar."`, "Rename Foo to Bar"},
+		{"please", "Please"},
+	}
+	for _, tt := range tests {
+		if got := DeriveTitle(tt.prompt); got != tt.want {
+			t.Errorf("DeriveTitle(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+
+	long := DeriveTitle(strings.Repeat("implement the feature ", 10))
+	if utf8.RuneCountInString(long) > MaxTitleRunes || long != "Implement the feature implement the feature implement the..." {
+		t.Errorf("DeriveTitle(long) = %q, want at most %d runes cut at a word", long, MaxTitleRunes)
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{"Auth": "auth", "#bug": "bug", "area/api": "area/api", "v1.2_x": "v1.2_x"} {
+		if got, err := NormalizeTag(in); err != nil || got != want {
+			t.Errorf("NormalizeTag(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "#", "two words", "a,b", strings.Repeat("x", MaxTagRunes+1)} {
+		if _, err := NormalizeTag(in); err == nil {
+			t.Errorf("NormalizeTag(%q) error = nil, want an error", in)
+		}
+	}
+}
+
+func TestState_Tags(t *testing.T) {
+	t.Parallel()
+	s := &State{}
+	if added := s.AddTags("ui", "auth", "ui"); !slices.Equal(added, []string{"ui", "auth"}) || !slices.Equal(s.Tags, []string{"auth", "ui"}) {
+		t.Errorf("AddTags() = %v, Tags = %v", added, s.Tags)
+	}
+	if removed := s.RemoveTags("auth", "none"); !slices.Equal(removed, []string{"auth"}) || !s.HasTag("ui") || s.HasTag("auth") {
+		t.Errorf("RemoveTags() = %v, Tags = %v", removed, s.Tags)
+	}
+	s.RemoveTags("ui")
+	if s.Tags != nil {
+		t.Errorf("Tags = %v, want nil once empty", s.Tags)
+	}
+}
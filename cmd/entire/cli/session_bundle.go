@@ -0,0 +1,552 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/redact"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// Format and version of the session bundles `entire export` writes.
+// Bundles of a newer version are refused rather than half imported.
+const (
+	sessionBundleFormat  = "entire-session-bundle"
+	sessionBundleVersion = 1
+)
+
+// Files of a session bundle. The manifest comes first; the others are
+// optional and listed in it with their checksums.
+const (
+	bundleManifestFile    = "manifest.json"
+	bundleStateFile       = "state.json"
+	bundleTranscriptFile  = "transcript.jsonl"
+	bundleAttributionFile = "attribution.json"
+	bundleCheckpointsFile = "checkpoints.bundle"
+	bundleShadowFile      = "shadow.bundle"
+)
+
+// maxBundleFileSize caps each file read from a session bundle.
+const maxBundleFileSize = 1 << 30
+
+// sessionBundleManifest describes a session bundle.
+type sessionBundleManifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	SessionID  string    `json:"session_id"`
+	Agent      string    `json:"agent,omitempty"`
+	ExportedAt time.Time `json:"exported_at"`
+	CLIVersion string    `json:"cli_version"`
+	// BaseCommit is the commit the shadow branch builds on; importing its
+	// checkpoints needs it.
+	BaseCommit   string   `json:"base_commit,omitempty"`
+	ShadowBranch string   `json:"shadow_branch,omitempty"`
+	Checkpoints  []string `json:"checkpoints"`
+	// Files maps each other file of the bundle to its "sha256:<hex>".
+	Files map[string]string `json:"files"`
+}
+
+// sessionBundleAttribution is the attribution of one committed checkpoint
+// of the session.
+type sessionBundleAttribution struct {
+	CheckpointID string                         `json:"checkpoint_id"`
+	SessionIndex int                            `json:"session_index"`
+	CreatedAt    time.Time                      `json:"created_at"`
+	FilesTouched []string                       `json:"files_touched,omitempty"`
+	Attribution  *checkpoint.InitialAttribution `json:"attribution,omitempty"`
+}
+
+func newExportCmd() *cobra.Command {
+	var outputFlag string
+
+	cmd := &cobra.Command{
+		Use:   "export <session-id>",
+		Short: "Package a session into a portable archive",
+		Long: `Packages a session into a single .tar.gz archive to hand to a reviewer or
+attach to an incident ticket: its state, its committed checkpoints and
+uncommitted shadow branch checkpoints as git bundles, its transcript with
+secrets redacted, and the attribution of each committed checkpoint.
+
+Checkpoints the session shares with other sessions are exported whole.
+Import the archive into another clone with 'entire import'.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeSessionIDs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runExport(cmd.Context(), cmd.OutOrStdout(), args[0], outputFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Archive to write (default entire-session-<id>.tar.gz)")
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Import a session archive written by 'entire export'",
+		Long: `Imports a session archive written by 'entire export' into this repository.
+
+Its committed checkpoints are added to the metadata branch, keeping any this
+repository already has. Its uncommitted checkpoints become the session's
+shadow branch if the commit they build on is here and the branch doesn't
+already have other checkpoints. The session's state is added as an ended
+session of this worktree unless the session is already known.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runImport(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0])
+		},
+	}
+	return cmd
+}
+
+func runExport(ctx context.Context, w io.Writer, sessionID, output string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+	committed, err := store.ListCommitted(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	sort.SliceStable(committed, func(i, j int) bool {
+		return committed[i].CreatedAt.Before(committed[j].CreatedAt)
+	})
+
+	manifest := sessionBundleManifest{
+		Format:      sessionBundleFormat,
+		Version:     sessionBundleVersion,
+		SessionID:   sessionID,
+		ExportedAt:  time.Now().UTC(),
+		CLIVersion:  buildinfo.Version,
+		Checkpoints: []string{},
+		Files:       map[string]string{},
+	}
+	var checkpointIDs []id.CheckpointID
+	attributions := []sessionBundleAttribution{}
+	var transcriptBytes []byte
+	for _, info := range committed {
+		if info.SessionID != sessionID && !slices.Contains(info.SessionIDs, sessionID) {
+			continue
+		}
+		summary, err := store.ReadCommitted(ctx, info.CheckpointID)
+		if err != nil || summary == nil {
+			continue
+		}
+		for i := range summary.Sessions {
+			metadata, err := store.ReadSessionMetadata(ctx, info.CheckpointID, i)
+			if err != nil || metadata.SessionID != sessionID {
+				continue
+			}
+			if manifest.Agent == "" {
+				manifest.Agent = string(metadata.Agent)
+			}
+			if !slices.Contains(checkpointIDs, info.CheckpointID) {
+				checkpointIDs = append(checkpointIDs, info.CheckpointID)
+				manifest.Checkpoints = append(manifest.Checkpoints, info.CheckpointID.String())
+			}
+			attributions = append(attributions, sessionBundleAttribution{
+				CheckpointID: info.CheckpointID.String(),
+				SessionIndex: i,
+				CreatedAt:    metadata.CreatedAt,
+				FilesTouched: metadata.FilesTouched,
+				Attribution:  metadata.InitialAttribution,
+			})
+			// Each checkpoint has the transcript up to it; the latest has all of it
+			if content, err := store.ReadSessionContent(ctx, info.CheckpointID, i); err == nil && len(content.Transcript) > 0 {
+				transcriptBytes = content.Transcript
+			}
+		}
+	}
+	if state == nil && len(checkpointIDs) == 0 {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	dir, err := os.MkdirTemp("", "entire-export-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	var files []string
+	addFile := func(name string, data []byte) error {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		files = append(files, name)
+		return nil
+	}
+
+	if state != nil {
+		if manifest.Agent == "" {
+			manifest.Agent = string(state.AgentType)
+		}
+		// The live transcript has everything since the last commit too
+		if state.TranscriptPath != "" {
+			if live, err := os.ReadFile(state.TranscriptPath); err == nil && len(live) > 0 {
+				transcriptBytes = live
+			}
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("failed to encode session state: %w", err)
+		}
+		if err := addFile(bundleStateFile, redactBundleJSON(data)); err != nil {
+			return err
+		}
+		if state.BaseCommit != "" {
+			branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+			if _, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true); err == nil {
+				if err := strategy.WriteShadowBundle(ctx, repo, branch, state.BaseCommit, filepath.Join(dir, bundleShadowFile)); err != nil {
+					return err //nolint:wrapcheck // already names the branch or bundle
+				}
+				files = append(files, bundleShadowFile)
+				manifest.BaseCommit, manifest.ShadowBranch = state.BaseCommit, branch
+			}
+		}
+	}
+	if len(checkpointIDs) > 0 {
+		if err := strategy.WriteCheckpointsBundle(ctx, repo, checkpointIDs, filepath.Join(dir, bundleCheckpointsFile)); err != nil {
+			return err //nolint:wrapcheck // already names the checkpoint or bundle
+		}
+		files = append(files, bundleCheckpointsFile)
+	}
+	if len(transcriptBytes) > 0 {
+		redacted, err := redact.JSONLBytes(transcriptBytes)
+		if err != nil {
+			redacted = redact.Bytes(transcriptBytes)
+		}
+		if err := addFile(bundleTranscriptFile, redacted); err != nil {
+			return err
+		}
+	}
+	data, err := jsonutil.MarshalIndentWithNewline(attributions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode attribution: %w", err)
+	}
+	if err := addFile(bundleAttributionFile, data); err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		sum, err := fileSHA256(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		manifest.Files[name] = sum
+	}
+	if output == "" {
+		output = "entire-session-" + sessionID + ".tar.gz"
+	}
+	if err := writeSessionBundle(output, dir, manifest, files); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported session %s (%d committed checkpoint(s)", sessionID, len(checkpointIDs))
+	if manifest.ShadowBranch != "" {
+		fmt.Fprintf(w, ", uncommitted checkpoints of %s", manifest.ShadowBranch)
+	}
+	fmt.Fprintf(w, ") to %s\n", output)
+	return nil
+}
+
+// redactBundleJSON redacts secrets in a JSON document and indents it.
+func redactBundleJSON(data []byte) []byte {
+	redacted, err := redact.JSONLBytes(data)
+	if err != nil {
+		return redact.Bytes(data)
+	}
+	var v any
+	if err := json.Unmarshal(redacted, &v); err != nil {
+		return redacted
+	}
+	if indented, err := jsonutil.MarshalIndentWithNewline(v, "", "  "); err == nil {
+		return indented
+	}
+	return redacted
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path is in the bundle's temporary directory
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeSessionBundle writes the manifest and files of dir to the archive at
+// output. A partly written archive is removed.
+func writeSessionBundle(output, dir string, manifest sessionBundleManifest, files []string) (err error) {
+	manifestData, err := jsonutil.MarshalIndentWithNewline(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	f, err := os.Create(output) //nolint:gosec // output is the path the user asked for
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write %s: %w", output, closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(output) //nolint:errcheck // best-effort cleanup of a partial archive
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	modTime := manifest.ExportedAt
+	if err := tw.WriteHeader(&tar.Header{Name: bundleManifestFile, Mode: 0o644, Size: int64(len(manifestData)), ModTime: modTime}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if _, err := tw.Write(manifestData); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	for _, name := range files {
+		if err := addTarFile(tw, filepath.Join(dir, name), name, modTime); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return nil
+}
+
+func addTarFile(tw *tar.Writer, path, name string, modTime time.Time) error {
+	f, err := os.Open(path) //nolint:gosec // path is in the bundle's temporary directory
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by writeSessionBundle
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by writeSessionBundle
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: info.Size(), ModTime: modTime}); err != nil {
+		return err //nolint:wrapcheck // wrapped by writeSessionBundle
+	}
+	_, err = io.Copy(tw, f)
+	return err //nolint:wrapcheck // wrapped by writeSessionBundle
+}
+
+func runImport(ctx context.Context, w, errW io.Writer, archive string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	dir, err := os.MkdirTemp("", "entire-import-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	manifest, err := readSessionBundle(archive, dir)
+	if err != nil {
+		return err
+	}
+	has := func(name string) bool { _, ok := manifest.Files[name]; return ok }
+
+	var added []string
+	if has(bundleCheckpointsFile) {
+		added, err = strategy.ImportCheckpointsBundle(ctx, filepath.Join(dir, bundleCheckpointsFile), "Import session "+manifest.SessionID)
+		if err != nil {
+			return fmt.Errorf("failed to import checkpoints: %w", err)
+		}
+	}
+	fmt.Fprintf(w, "Imported session %s: %d new committed checkpoint(s)\n", manifest.SessionID, len(added))
+
+	if !has(bundleStateFile) {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, bundleStateFile)) //nolint:gosec // path is in the bundle's temporary directory
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", bundleStateFile, err)
+	}
+	var state strategy.SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", bundleStateFile, err)
+	}
+	if state.SessionID != manifest.SessionID {
+		return fmt.Errorf("%s is of session %s, not %s", bundleStateFile, state.SessionID, manifest.SessionID)
+	}
+	existing, err := strategy.LoadSessionState(state.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", state.SessionID, err)
+	}
+	if existing != nil {
+		fmt.Fprintf(w, "Session %s is already known here; kept its state and shadow branch\n", state.SessionID)
+		return nil
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	if _, err := repo.CommitObject(plumbing.NewHash(state.BaseCommit)); err != nil {
+		fmt.Fprintf(errW, "Skipped the session's state and uncommitted checkpoints: commit %s isn't in this repository. Fetch it and import again.\n", state.BaseCommit)
+		return nil
+	}
+
+	worktreePath, err := strategy.GetWorktreePath()
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by GetWorktreePath
+	}
+	worktreeID, err := strategy.ShadowWorktreeID(worktreePath)
+	if err != nil {
+		return fmt.Errorf("failed to get worktree ID: %w", err)
+	}
+	if has(bundleShadowFile) {
+		branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, worktreeID)
+		result, err := strategy.ImportShadowBundle(ctx, filepath.Join(dir, bundleShadowFile), branch)
+		switch {
+		case errors.Is(err, strategy.ErrBundlePrerequisites):
+			fmt.Fprintf(errW, "Skipped the uncommitted checkpoints: %v\n", err)
+		case err != nil:
+			return fmt.Errorf("failed to import uncommitted checkpoints: %w", err)
+		case result == strategy.ShadowImportConflict:
+			fmt.Fprintf(errW, "Skipped the uncommitted checkpoints: %s already has other checkpoints\n", branch)
+		case result == strategy.ShadowImportApplied:
+			fmt.Fprintf(w, "Imported uncommitted checkpoints to %s\n", branch)
+		}
+	}
+
+	state.WorktreePath, state.WorktreeID = worktreePath, worktreeID
+	state.Phase = session.PhaseEnded
+	if state.EndedAt == nil {
+		endedAt := manifest.ExportedAt
+		state.EndedAt = &endedAt
+	}
+	if err := strategy.SaveSessionState(&state); err != nil {
+		return fmt.Errorf("failed to save session state: %w", err)
+	}
+	fmt.Fprintf(w, "Added session %s as an ended session of this worktree\n", state.SessionID)
+	return nil
+}
+
+// readSessionBundle extracts the archive into dir and returns its manifest
+// after checking every file against it.
+func readSessionBundle(archive, dir string) (*sessionBundleManifest, error) {
+	f, err := os.Open(archive) //nolint:gosec // archive is the path the user passed
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", archive, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a session archive: %w", archive, err)
+	}
+	defer gz.Close()
+
+	known := []string{bundleStateFile, bundleTranscriptFile, bundleAttributionFile, bundleCheckpointsFile, bundleShadowFile}
+	var manifest *sessionBundleManifest
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%s has an unexpected entry %q", archive, header.Name)
+		}
+		if header.Name == bundleManifestFile {
+			data, err := io.ReadAll(io.LimitReader(tr, maxBundleFileSize))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", bundleManifestFile, err)
+			}
+			manifest = &sessionBundleManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", bundleManifestFile, err)
+			}
+			if manifest.Format != sessionBundleFormat {
+				return nil, fmt.Errorf("%s is not a session archive", archive)
+			}
+			if manifest.Version > sessionBundleVersion {
+				return nil, fmt.Errorf("%s was written by a newer version of Entire (bundle version %d); upgrade to import it", archive, manifest.Version)
+			}
+			continue
+		}
+		if manifest == nil || !slices.Contains(known, header.Name) {
+			return nil, fmt.Errorf("%s has an unexpected entry %q", archive, header.Name)
+		}
+		want, ok := manifest.Files[header.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s has %s, which its manifest doesn't list", archive, header.Name)
+		}
+		if header.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("%s of %s is too large", header.Name, archive)
+		}
+		if err := extractBundleFile(tr, filepath.Join(dir, header.Name), want); err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", header.Name, archive, err)
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a session archive: no %s", archive, bundleManifestFile)
+	}
+	for name := range manifest.Files {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return nil, fmt.Errorf("%s is missing %s", archive, name)
+		}
+	}
+	return manifest, nil
+}
+
+// extractBundleFile writes r to path, failing if its checksum isn't want.
+func extractBundleFile(r io.Reader, path, want string) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec // path is a known name in the temporary directory
+	if err != nil {
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+	h := sha256.New()
+	_, copyErr := io.Copy(io.MultiWriter(out, h), io.LimitReader(r, maxBundleFileSize))
+	if err := out.Close(); copyErr == nil && err != nil {
+		copyErr = err
+	}
+	if copyErr != nil {
+		return fmt.Errorf("failed to extract: %w", copyErr)
+	}
+	if got := "sha256:" + hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch: got %s, manifest says %s", got, want)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	setupMCPRepo(t)
+	archive := filepath.Join(t.TempDir(), "session.tar.gz")
+	var out bytes.Buffer
+	if err := runExport(context.Background(), &out, "2026-10-14-second", archive); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "1 committed checkpoint(s)") {
+		t.Errorf("export output = %q", out.String())
+	}
+
+	files := readTestArchive(t, archive)
+	var manifest sessionBundleManifest
+	if err := json.Unmarshal(files[bundleManifestFile], &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.Format != sessionBundleFormat || manifest.SessionID != "2026-10-14-second" ||
+		len(manifest.Checkpoints) != 1 || manifest.Checkpoints[0] != "b1b2c3d4e5f6" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	var attributions []sessionBundleAttribution
+	if err := json.Unmarshal(files[bundleAttributionFile], &attributions); err != nil {
+		t.Fatalf("invalid attribution: %v", err)
+	}
+	if len(attributions) != 1 || attributions[0].Attribution == nil || attributions[0].Attribution.AgentLines != 8 {
+		t.Errorf("attribution = %+v, want the checkpoint's 8 agent lines", attributions)
+	}
+	if !strings.Contains(string(files[bundleTranscriptFile]), `"hi"`) {
+		t.Errorf("transcript = %q", files[bundleTranscriptFile])
+	}
+
+	// Import into an unrelated clone
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	out.Reset()
+	if err := runImport(context.Background(), &out, io.Discard, archive); err != nil {
+		t.Fatalf("runImport() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "1 new committed checkpoint(s)") {
+		t.Errorf("import output = %q", out.String())
+	}
+	store := checkpoint.NewGitStore(repo)
+	metadata, err := store.ReadSessionMetadata(context.Background(), id.MustCheckpointID("b1b2c3d4e5f6"), 0)
+	if err != nil || metadata.SessionID != "2026-10-14-second" {
+		t.Fatalf("imported checkpoint = %+v, %v", metadata, err)
+	}
+	if summary, err := store.ReadCommitted(context.Background(), id.MustCheckpointID("a1b2c3d4e5f6")); err == nil && summary != nil {
+		t.Error("import added a checkpoint of another session")
+	}
+
+	// Importing again adds nothing
+	out.Reset()
+	if err := runImport(context.Background(), &out, io.Discard, archive); err != nil {
+		t.Fatalf("second runImport() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "0 new committed checkpoint(s)") {
+		t.Errorf("second import output = %q", out.String())
+	}
+}
+
+func TestImport_RejectsTamperedArchive(t *testing.T) {
+	setupMCPRepo(t)
+	archive := filepath.Join(t.TempDir(), "session.tar.gz")
+	if err := runExport(context.Background(), io.Discard, "2026-10-14-second", archive); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	files := readTestArchive(t, archive)
+	files[bundleAttributionFile] = []byte("[]\n")
+	writeTestArchive(t, archive, files)
+
+	err := runImport(context.Background(), io.Discard, io.Discard, archive)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("runImport() error = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestExport_UnknownSession(t *testing.T) {
+	setupMCPRepo(t)
+	err := runExport(context.Background(), io.Discard, "2026-10-14-missing", filepath.Join(t.TempDir(), "x.tar.gz"))
+	if err == nil || !strings.Contains(err.Error(), "session not found") {
+		t.Errorf("runExport() error = %v, want session not found", err)
+	}
+}
+
+func readTestArchive(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = data
+	}
+	if _, ok := files[bundleManifestFile]; !ok {
+		t.Fatalf("archive has no manifest: %v", files)
+	}
+	return files
+}
+
+// writeTestArchive writes files to path with the manifest first.
+func writeTestArchive(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	names := []string{bundleManifestFile}
+	for name := range files {
+		if name != bundleManifestFile {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
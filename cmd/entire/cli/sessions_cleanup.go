@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCleanupCmd() *cobra.Command {
+	var olderThanFlag string
+	var dryRunFlag bool
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Archive stale sessions that never ended",
+		Long: `Archives sessions whose agent crashed or whose terminal was closed: sessions
+of any worktree that haven't ended and have had no hook for the
+session_expiry TTL (7 days by default, --older-than to override). Until then
+they count as concurrent sessions whose checkpoints join your next commit.
+
+A stale session's state is moved to the archive directory of the session
+state directory, and its shadow branch to refs/entire/archive/<branch>
+unless another session still uses it. Session start hooks do the same at
+most once an hour unless session_expiry.enabled is false.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSessionsCleanup(cmd.Context(), cmd.OutOrStdout(), olderThanFlag, dryRunFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThanFlag, "older-than", "", "Archive sessions with no hook for this long, e.g. 2d or 12h (default session_expiry.ttl)")
+	cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the sessions that would be archived without archiving them")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+func runSessionsCleanup(ctx context.Context, w io.Writer, olderThan string, dryRun, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var ttl time.Duration
+	var err error
+	if olderThan != "" {
+		ttl, err = parseRetentionDuration(olderThan)
+	} else {
+		ttl, err = strategy.SessionExpiryTTL()
+	}
+	if err != nil {
+		return err //nolint:wrapcheck // already describes the age or setting
+	}
+	if ttl == 0 {
+		fmt.Fprintln(w, "Session expiry is turned off (session_expiry.enabled is false); use --older-than to archive stale sessions anyway.")
+		return nil
+	}
+
+	expired, err := strategy.ExpireStaleSessions(ctx, ttl, "", dryRun)
+	if err != nil {
+		return err //nolint:wrapcheck // already names the session or branch
+	}
+
+	if jsonOutput {
+		if expired == nil {
+			expired = []strategy.ExpiredSession{}
+		}
+		data, err := jsonutil.MarshalIndentWithNewline(expired, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal sessions: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	if len(expired) == 0 {
+		fmt.Fprintln(w, "No stale sessions.")
+		return nil
+	}
+	verb := "Archived"
+	if dryRun {
+		verb = "Would archive"
+	}
+	fmt.Fprintf(w, "%s %d stale session(s):\n", verb, len(expired))
+	for _, e := range expired {
+		fmt.Fprintf(w, "  %s (%s, last hook %s)\n", e.SessionID, e.Phase, e.LastActivity.Local().Format("2006-01-02 15:04"))
+		if e.ArchivedRef != "" {
+			fmt.Fprintf(w, "    %s -> %s\n", e.ShadowBranch, e.ArchivedRef)
+		}
+	}
+	return nil
+}
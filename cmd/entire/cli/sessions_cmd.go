@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Inspect agent sessions",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newSessionsListCmd())
+	cmd.AddCommand(newSessionsShowCmd())
+	cmd.AddCommand(newSessionsPullCmd())
+	cmd.AddCommand(newSessionsTagCmd())
+	cmd.AddCommand(newSessionsUntagCmd())
+	cmd.AddCommand(newSessionsCleanupCmd())
+
+	return cmd
+}
+
+func newSessionsListCmd() *cobra.Command {
+	var allWorktreesFlag bool
+	var jsonFlag bool
+	var tagFlag string
+	var lf listFlags
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List sessions of this worktree, or of all worktrees",
+		Long: `Lists the sessions Entire is tracking in the current worktree, with the
+shadow branch each one writes its checkpoints to.
+
+Sessions in different worktrees of the same repository never share a shadow
+branch. --all-worktrees lists every worktree's sessions, grouped by worktree.
+
+Each session is shown with its title, derived from its first prompt, and
+its tags (see 'entire sessions tag').
+
+Sessions are sorted by start time. --since and --until only show sessions
+that were active during that period, --branch those whose worktree has that
+branch checked out, --tag those with that tag. With --limit, a cursor for the
+next page is printed to stderr.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			opts, err := lf.resolve(time.Now())
+			if err != nil {
+				return err
+			}
+			if tagFlag != "" {
+				if opts.Tag, err = session.NormalizeTag(tagFlag); err != nil {
+					return err //nolint:wrapcheck // already names the tag
+				}
+			}
+			return runSessionsList(cmd.OutOrStdout(), cmd.ErrOrStderr(), allWorktreesFlag, opts, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&allWorktreesFlag, "all-worktrees", false, "List the sessions of every worktree")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	cmd.Flags().StringVar(&tagFlag, "tag", "", "Only show sessions with this tag")
+	addListFlags(cmd, &lf, listSortNewest, true)
+
+	return cmd
+}
+
+type sessionListJSON struct {
+	SessionID       string     `json:"session_id"`
+	Agent           string     `json:"agent,omitempty"`
+	Phase           string     `json:"phase"`
+	WorktreeID      string     `json:"worktree_id"`
+	WorktreePath    string     `json:"worktree_path,omitempty"`
+	Branch          string     `json:"branch,omitempty"`
+	BaseCommit      string     `json:"base_commit"`
+	ShadowBranch    string     `json:"shadow_branch"`
+	Steps           int        `json:"steps"`
+	StartedAt       time.Time  `json:"started_at"`
+	LastInteraction *time.Time `json:"last_interaction,omitempty"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	FirstPrompt     string     `json:"first_prompt,omitempty"`
+	Title           string     `json:"title,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	TaskType        string     `json:"task_type,omitempty"`
+}
+
+// listSessionEntries returns the sessions of the current worktree, or of all
+// worktrees, newest first.
+func listSessionEntries(allWorktrees bool) ([]sessionListJSON, error) {
+	states, err := strategy.ListSessionStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	worktreePath, err := strategy.GetWorktreePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree path: %w", err)
+	}
+	currentID, err := strategy.ShadowWorktreeID(worktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree ID: %w", err)
+	}
+	branches := make(map[string]string)
+	if worktrees, err := strategy.ListWorktrees(); err == nil {
+		for _, wt := range worktrees {
+			branches[filepath.Clean(wt.Path)] = wt.Branch
+		}
+	}
+
+	entries := []sessionListJSON{}
+	for _, state := range states {
+		if !allWorktrees && state.WorktreeID != currentID {
+			continue
+		}
+		phase := state.Phase
+		if phase == "" {
+			phase = session.PhaseIdle
+		}
+		entries = append(entries, sessionListJSON{
+			SessionID:       state.SessionID,
+			Agent:           string(state.AgentType),
+			Phase:           string(phase),
+			WorktreeID:      state.WorktreeID,
+			WorktreePath:    state.WorktreePath,
+			Branch:          branches[filepath.Clean(state.WorktreePath)],
+			BaseCommit:      state.BaseCommit,
+			ShadowBranch:    checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID),
+			Steps:           state.StepCount,
+			StartedAt:       state.StartedAt,
+			LastInteraction: state.LastInteractionTime,
+			EndedAt:         state.EndedAt,
+			FirstPrompt:     state.FirstPrompt,
+			Title:           state.DisplayTitle(),
+			Tags:            state.Tags,
+			TaskType:        state.TaskType,
+		})
+	}
+	entries, _, err = pageList(entries, sessionListKey, listOptions{Sort: listSortNewest})
+	return entries, err
+}
+
+func sessionListKey(e sessionListJSON) listKey {
+	return listKey{Time: e.StartedAt, ID: e.SessionID}
+}
+
+// sessionEntryLastActive returns when e was last active, or the zero time if
+// it may still be running.
+func sessionEntryLastActive(e sessionListJSON) time.Time {
+	if e.EndedAt != nil {
+		return *e.EndedAt
+	}
+	if e.LastInteraction != nil {
+		return *e.LastInteraction
+	}
+	return time.Time{}
+}
+
+func runSessionsList(w, errW io.Writer, allWorktrees bool, opts listOptions, jsonOutput bool) error {
+	all, err := listSessionEntries(allWorktrees)
+	if err != nil {
+		return err
+	}
+	entries := []sessionListJSON{}
+	for _, e := range all {
+		if !opts.matchesAgent(e.Agent) {
+			continue
+		}
+		if opts.Branch != "" && e.Branch != opts.Branch {
+			continue
+		}
+		if opts.Tag != "" && !slices.Contains(e.Tags, opts.Tag) {
+			continue
+		}
+		if opts.Period.IsBounded() && !opts.Period.Overlaps(e.StartedAt, sessionEntryLastActive(e)) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	entries, next, err := pageList(entries, sessionListKey, opts)
+	if err != nil {
+		return err
+	}
+	defer writeNextCursor(errW, next)
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal sessions: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		if allWorktrees {
+			fmt.Fprintln(w, "No sessions.")
+		} else {
+			fmt.Fprintln(w, "No sessions in this worktree. Use --all-worktrees to list the others.")
+		}
+		return nil
+	}
+	if allWorktrees {
+		// Group the page by worktree, keeping the sort order within each
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].WorktreeID < entries[j].WorktreeID })
+	}
+	lastWorktree := "\x00"
+	for _, e := range entries {
+		if allWorktrees && e.WorktreeID != lastWorktree {
+			if lastWorktree != "\x00" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, describeWorktree(e.WorktreeID, e.WorktreePath))
+			lastWorktree = e.WorktreeID
+		}
+		shortID := e.SessionID
+		if len(shortID) > 7 {
+			shortID = shortID[:7]
+		}
+		agentLabel := e.Agent
+		if agentLabel == "" {
+			agentLabel = unknownPlaceholder
+		}
+		fmt.Fprintf(w, "  [%s] %-9s %-16s %d step(s), started %s\n", agentLabel, shortID, e.Phase, e.Steps, timeAgo(e.StartedAt))
+		fmt.Fprintf(w, "      %s\n", e.ShadowBranch)
+		switch {
+		case e.Title != "" && len(e.Tags) > 0:
+			fmt.Fprintf(w, "      %s  %s\n", e.Title, formatTags(e.Tags))
+		case e.Title != "":
+			fmt.Fprintf(w, "      %s\n", e.Title)
+		case len(e.Tags) > 0:
+			fmt.Fprintf(w, "      %s\n", formatTags(e.Tags))
+		}
+	}
+	return nil
+}
+
+// describeWorktree names a worktree for headers: "main worktree" or
+// "worktree <id>", with its path if known.
+func describeWorktree(worktreeID, worktreePath string) string {
+	name := "main worktree"
+	if worktreeID != "" {
+		name = "worktree " + worktreeID
+	}
+	if worktreePath != "" {
+		name += " (" + filepath.Clean(worktreePath) + ")"
+	}
+	return name
+}
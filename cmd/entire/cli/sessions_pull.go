@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newSessionsPullCmd() *cobra.Command {
+	var remote string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "pull <session-id>",
+		Short: "Fetch a session from the sync remote to continue it here",
+		Long: `Fetches the state and shadow branch of a session that 'entire sync push'
+pushed from another machine, so the session can be continued here.
+
+The state keeps the session's attribution baselines: the commit attribution
+is measured from and the lines the human and the agent changed at each
+prompt, so the next commit attributes the work of both machines correctly.
+Its transcript is written where the agent looks for it.
+
+The commits the session builds on must be fetched first. A session that
+was active here after the remote's state was pushed, or whose shadow branch
+has other checkpoints here, is kept unless --force is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSessionsPull(cmd.Context(), cmd.OutOrStdout(), args[0], remote, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&remote, "remote", "", "Remote to pull from (default: sync_remote setting, or origin)")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace the session's local state, transcript and checkpoints")
+
+	return cmd
+}
+
+func runSessionsPull(ctx context.Context, w io.Writer, sessionID, remote string, force bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	remote, err := syncRemote(remote)
+	if err != nil {
+		return err
+	}
+	pulled, err := strategy.PullSessionState(ctx, remote, sessionID, force)
+	switch {
+	case errors.Is(err, strategy.ErrSessionNotSynced):
+		return fmt.Errorf("%s has no state of session %s; run 'entire sync push' where it ran", remote, sessionID)
+	case errors.Is(err, strategy.ErrSessionBaseMissing):
+		return fmt.Errorf("%w; fetch the branch the session worked on and pull again", err)
+	case errors.Is(err, strategy.ErrLocalSessionNewer):
+		return fmt.Errorf("%w; pull with --force to replace it", err)
+	case err != nil:
+		return err //nolint:wrapcheck // already describes the failed pull
+	}
+	state := pulled.State
+
+	var ag agent.Agent
+	if state.AgentType != "" {
+		ag, _ = agent.GetByAgentType(state.AgentType) //nolint:errcheck // unknown agents get no transcript
+	}
+	state.TranscriptPath = ""
+	if ag != nil && len(pulled.Transcript) > 0 {
+		transcriptPath, written, err := writePulledTranscript(ag, sessionID, pulled.Transcript, force)
+		if err != nil {
+			return err
+		}
+		state.TranscriptPath = transcriptPath
+		if written {
+			fmt.Fprintf(w, "Wrote the transcript to %s\n", transcriptPath)
+		} else {
+			fmt.Fprintf(w, "Kept the newer local transcript %s\n", transcriptPath)
+		}
+	}
+	err = strategy.SavePulledSessionState(state, force)
+	switch {
+	case errors.Is(err, strategy.ErrLocalSessionNewer):
+		return fmt.Errorf("%w: %s; pull with --force to replace it", strategy.ErrLocalSessionNewer, sessionID)
+	case err != nil:
+		return fmt.Errorf("failed to save session state: %w", err)
+	}
+
+	fmt.Fprintf(w, "Pulled session %s from %s (pushed %s)", sessionID, remote, timeAgo(pulled.PushedAt))
+	if pulled.ShadowBranch != "" {
+		fmt.Fprintf(w, ", %d checkpoint(s) since its last commit on %s", state.StepCount, pulled.ShadowBranch)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "\nTo continue it:")
+	if head, err := getCurrentHeadHash(); err == nil && state.BaseCommit != "" && head != state.BaseCommit {
+		fmt.Fprintf(w, "  git checkout %s\n", state.BaseCommit[:7])
+	}
+	if pulled.ShadowBranch != "" && state.StepCount > 0 {
+		fmt.Fprintf(w, "  entire rewind --to %s\n", pulled.ShadowTip.String()[:7])
+	}
+	if ag != nil {
+		fmt.Fprintf(w, "  %s\n", ag.FormatResumeCommand(ag.ExtractAgentSessionID(sessionID)))
+	}
+	return nil
+}
+
+// writePulledTranscript writes a pulled transcript where the agent resumes
+// the session from, unless the local one has later entries (and force isn't
+// set). Returns its path and whether it was written.
+func writePulledTranscript(ag agent.Agent, sessionID string, transcript []byte, force bool) (string, bool, error) {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get repository root: %w", err)
+	}
+	sessionDir, err := ag.GetSessionDir(repoRoot)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get agent session directory: %w", err)
+	}
+	agentSessionID := ag.ExtractAgentSessionID(sessionID)
+	transcriptPath := ag.ResolveSessionFile(sessionDir, agentSessionID)
+	if !force {
+		local := paths.GetLastTimestampFromFile(transcriptPath)
+		if strategy.ClassifyTimestamps(local, paths.GetLastTimestampFromBytes(transcript)) == strategy.StatusLocalNewer {
+			return transcriptPath, false, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(transcriptPath), 0o750); err != nil {
+		return "", false, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	if err := ag.WriteSession(&agent.AgentSession{
+		SessionID:  agentSessionID,
+		AgentName:  ag.Name(),
+		RepoPath:   repoRoot,
+		SessionRef: transcriptPath,
+		NativeData: transcript,
+	}); err != nil {
+		return "", false, fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return transcriptPath, true, nil
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestRunSessionsPull(t *testing.T) {
+	remoteDir := t.TempDir()
+	if out, err := exec.CommandContext(context.Background(), "git", "init", "--bare", remoteDir).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v\n%s", err, out)
+	}
+	addRemote := func(repo *git.Repository) {
+		t.Helper()
+		if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}}); err != nil {
+			t.Fatalf("failed to add remote: %v", err)
+		}
+	}
+	const sessionID = "2026-10-14-desktop"
+	transcript := `{"type":"user","timestamp":"2026-10-14T10:00:00Z","message":{"content":"add a parser"}}` + "\n"
+
+	// On the desktop: a session with checkpoints and attribution since its base
+	repoA, base := setupCleanTestRepo(t)
+	addRemote(repoA)
+	branch := checkpoint.ShadowBranchNameForCommit(base.String(), "")
+	tip := addSyncTestCheckpoint(t, repoA, branch, sessionID, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC))
+	transcriptPath := filepath.Join(t.TempDir(), "desktop.jsonl")
+	if err := os.WriteFile(transcriptPath, []byte(transcript), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lastInteraction := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	if err := strategy.SaveSessionState(&strategy.SessionState{
+		SessionID:             sessionID,
+		BaseCommit:            base.String(),
+		AttributionBaseCommit: base.String(),
+		StartedAt:             lastInteraction.Add(-time.Hour),
+		LastInteractionTime:   &lastInteraction,
+		Phase:                 session.PhaseIdle,
+		StepCount:             1,
+		AgentType:             agent.AgentTypeClaudeCode,
+		TranscriptPath:        transcriptPath,
+		PromptAttributions:    []session.PromptAttribution{{CheckpointNumber: 1, UserLinesAdded: 3, AgentLinesAdded: 10}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := runSyncPush(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("runSyncPush() error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Pushed the state of 1 session(s)") {
+		t.Errorf("session state wasn't pushed:\n%s", buf.String())
+	}
+
+	// On the laptop
+	repoB, _ := setupCleanTestRepo(t)
+	addRemote(repoB)
+	claudeDir := t.TempDir()
+	t.Setenv("ENTIRE_TEST_CLAUDE_PROJECT_DIR", claudeDir)
+	buf.Reset()
+	if err := runSessionsPull(context.Background(), &buf, "2026-10-14-unknown", "origin", false); err == nil || !strings.Contains(err.Error(), "run 'entire sync push' where it ran") {
+		t.Errorf("runSessionsPull(unknown) error = %v, want a hint to push it", err)
+	}
+	if err := runSessionsPull(context.Background(), &buf, sessionID, "origin", false); err != nil {
+		t.Fatalf("runSessionsPull() error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Pulled session "+sessionID+" from origin") || !strings.Contains(buf.String(), "1 checkpoint(s) since its last commit on "+branch) ||
+		!strings.Contains(buf.String(), "claude -r desktop") {
+		t.Errorf("unexpected pull output:\n%s", buf.String())
+	}
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil || state == nil {
+		t.Fatalf("LoadSessionState() = %v, %v; want the pulled session", state, err)
+	}
+	if state.AttributionBaseCommit != base.String() || len(state.PromptAttributions) != 1 || state.PromptAttributions[0].UserLinesAdded != 3 {
+		t.Errorf("pulled attribution = base %s, %+v; want the desktop's baselines", state.AttributionBaseCommit, state.PromptAttributions)
+	}
+	if state.Phase != session.PhaseEnded || state.WorktreePath != repoWorktree(t, repoB) {
+		t.Errorf("pulled state = phase %s, worktree %s; want ended, in this worktree", state.Phase, state.WorktreePath)
+	}
+	wantTranscript := filepath.Join(claudeDir, "desktop.jsonl")
+	if state.TranscriptPath != wantTranscript {
+		t.Errorf("TranscriptPath = %s, want %s", state.TranscriptPath, wantTranscript)
+	}
+	if data, err := os.ReadFile(wantTranscript); err != nil || string(data) != transcript {
+		t.Errorf("pulled transcript = %q, %v; want the desktop's", data, err)
+	}
+	if ref, err := repoB.Reference(plumbing.NewBranchReferenceName(branch), true); err != nil || ref.Hash() != tip {
+		t.Errorf("pulled %s = %v, %v; want %s", branch, ref, err, tip)
+	}
+
+	// Continued on the laptop since: the desktop's older state doesn't replace it
+	later := lastInteraction.Add(time.Hour)
+	state.LastInteractionTime = &later
+	if err := strategy.SaveSessionState(state); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSessionsPull(context.Background(), &buf, sessionID, "origin", false); !errors.Is(err, strategy.ErrLocalSessionNewer) {
+		t.Errorf("runSessionsPull() over newer local state error = %v, want ErrLocalSessionNewer", err)
+	}
+	if err := runSessionsPull(context.Background(), &buf, sessionID, "origin", true); err != nil {
+		t.Errorf("runSessionsPull(force) error = %v", err)
+	}
+}
+
+func TestSavePulledSessionState_LocalActivityWhilePulling(t *testing.T) {
+	setupCleanTestRepo(t)
+	pushedAt := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	pulled := &strategy.SessionState{SessionID: "2026-10-14-desktop", LastInteractionTime: &pushedAt, StepCount: 3}
+
+	// A hook ran here after the pull checked the local state
+	later := pushedAt.Add(time.Hour)
+	if err := strategy.SaveSessionState(&strategy.SessionState{SessionID: "2026-10-14-desktop", LastInteractionTime: &later, StepCount: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.SavePulledSessionState(pulled, false); !errors.Is(err, strategy.ErrLocalSessionNewer) {
+		t.Errorf("SavePulledSessionState() error = %v, want ErrLocalSessionNewer", err)
+	}
+	if state, err := strategy.LoadSessionState("2026-10-14-desktop"); err != nil || state.StepCount != 5 {
+		t.Errorf("local state = %+v, %v; want it kept", state, err)
+	}
+
+	if err := strategy.SavePulledSessionState(pulled, true); err != nil {
+		t.Fatalf("SavePulledSessionState(force) error = %v", err)
+	}
+	if state, err := strategy.LoadSessionState("2026-10-14-desktop"); err != nil || state.StepCount != 3 {
+		t.Errorf("local state = %+v, %v; want the pulled one", state, err)
+	}
+}
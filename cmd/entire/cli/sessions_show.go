@@ -0,0 +1,417 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsShowCmd() *cobra.Command {
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "show <session-id>",
+		Short: "Show a session as a tree of its commits and subagents",
+		Long: `Shows a session as a tree: each committed checkpoint of the session, and
+the checkpoints it made since its last commit, with the subagents (Task tool
+runs) it delegated work to underneath. Subagents' checkpoints and file
+changes are part of their session's, so their work is attributed to it.
+
+With workspace repositories set up (workspace.repos), the checkpoints the
+session saved in each of them are shown too, with the agents' share of the
+lines its commits there added.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeSessionIDs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSessionsShow(cmd.Context(), cmd.OutOrStdout(), args[0], jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// sessionShowCommittedJSON is one committed checkpoint of a session.
+type sessionShowCommittedJSON struct {
+	CheckpointID string                        `json:"checkpoint_id"`
+	CreatedAt    time.Time                     `json:"created_at"`
+	Checkpoints  int                           `json:"checkpoints"`
+	FilesTouched []string                      `json:"files_touched,omitempty"`
+	Subagents    []checkpoint.SubagentMetadata `json:"subagents"`
+	HumanEdits   []checkpoint.HumanEdit        `json:"human_edits,omitempty"`
+	// Attribution is the checkpoint's line attribution in its repository.
+	Attribution *checkpoint.InitialAttribution `json:"attribution,omitempty"`
+}
+
+// sessionShowUncommittedJSON is the work of a session since its last commit.
+type sessionShowUncommittedJSON struct {
+	ShadowBranch string                        `json:"shadow_branch"`
+	Checkpoints  int                           `json:"checkpoints"`
+	Subagents    []checkpoint.SubagentMetadata `json:"subagents"`
+	HumanEdits   []checkpoint.HumanEdit        `json:"human_edits,omitempty"`
+}
+
+// sessionShowRepoJSON is the work of a session in another repository of
+// its workspace.
+type sessionShowRepoJSON struct {
+	Path        string                      `json:"path"`
+	Committed   []sessionShowCommittedJSON  `json:"committed"`
+	Uncommitted *sessionShowUncommittedJSON `json:"uncommitted,omitempty"`
+}
+
+type sessionShowJSON struct {
+	SessionID   string   `json:"session_id"`
+	Agent       string   `json:"agent,omitempty"`
+	TaskType    string   `json:"task_type,omitempty"`
+	Phase       string   `json:"phase,omitempty"`
+	FirstPrompt string   `json:"first_prompt,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Summary is the summary from 'entire transcript summarize'.
+	Summary *session.Summary `json:"summary,omitempty"`
+	// Resumes are the agent's resumes of the session after a restart.
+	Resumes     []session.ResumeRecord      `json:"resumes,omitempty"`
+	Committed   []sessionShowCommittedJSON  `json:"committed"`
+	Uncommitted *sessionShowUncommittedJSON `json:"uncommitted,omitempty"`
+	// WorkspaceRepos are the other repositories the session saved
+	// checkpoints in (see workspace.go).
+	WorkspaceRepos []sessionShowRepoJSON `json:"workspace_repos,omitempty"`
+}
+
+func runSessionsShow(ctx context.Context, w io.Writer, sessionID string, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result := sessionShowJSON{SessionID: sessionID, Committed: []sessionShowCommittedJSON{}}
+
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	if state != nil {
+		result.Agent = string(state.AgentType)
+		result.TaskType = state.TaskType
+		result.Phase = string(state.Phase)
+		result.FirstPrompt = state.FirstPrompt
+		result.Title = state.DisplayTitle()
+		result.Tags = state.Tags
+		result.Summary = state.Summary
+		result.Resumes = state.Resumes
+		result.Uncommitted = sessionUncommitted(state)
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	committed, metadata, err := sessionCommitted(ctx, repo, sessionID)
+	if err != nil {
+		return err
+	}
+	result.Committed = committed
+	if metadata != nil {
+		if result.Agent == "" {
+			result.Agent = string(metadata.Agent)
+		}
+		if result.TaskType == "" {
+			result.TaskType = metadata.TaskType
+		}
+	}
+
+	workspaceRepos, err := sessionWorkspaceRepos(ctx, sessionID, state)
+	if err != nil {
+		return err
+	}
+	result.WorkspaceRepos = workspaceRepos
+
+	if state == nil && len(result.Committed) == 0 && len(result.WorkspaceRepos) == 0 {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal session: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	label := result.Agent
+	if label == "" {
+		label = unknownPlaceholder
+	}
+	if result.TaskType != "" {
+		label += ", " + result.TaskType
+	}
+	if result.Phase != "" {
+		label += ", " + result.Phase
+	}
+	fmt.Fprintf(w, "Session %s (%s)\n", result.SessionID, label)
+	if result.Title != "" {
+		fmt.Fprintf(w, "Title: %s\n", result.Title)
+	}
+	if len(result.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:  %s\n", formatTags(result.Tags))
+	}
+	if result.FirstPrompt != "" {
+		fmt.Fprintf(w, "\"%s\"\n", stringutil.TruncateRunes(result.FirstPrompt, 60, "..."))
+	}
+	if result.Summary != nil && result.Summary.Intent != "" {
+		fmt.Fprintf(w, "Summary: %s\n", result.Summary.Intent)
+	}
+	if n := len(result.Resumes); n > 0 {
+		last := result.Resumes[n-1]
+		fmt.Fprintf(w, "Resumed %d time(s), last on %s", n, last.At.Local().Format("2006-01-02 15:04"))
+		if summary := strategy.DescribeResume(&last); summary != "" {
+			fmt.Fprintf(w, ": %s", summary)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(result.WorkspaceRepos) == 0 {
+		printSessionTree(w, result.Committed, result.Uncommitted)
+		return nil
+	}
+	fmt.Fprintf(w, "This repository%s\n", describeRepoAttribution(result.Committed))
+	printSessionTree(w, result.Committed, result.Uncommitted)
+	for _, r := range result.WorkspaceRepos {
+		fmt.Fprintf(w, "Workspace repository %s%s\n", r.Path, describeRepoAttribution(r.Committed))
+		printSessionTree(w, r.Committed, r.Uncommitted)
+	}
+	return nil
+}
+
+// sessionUncommitted returns the session's work since its last commit, nil
+// if there is none.
+func sessionUncommitted(state *strategy.SessionState) *sessionShowUncommittedJSON {
+	if state.StepCount == 0 && len(state.Subagents) == 0 && len(state.HumanEdits) == 0 {
+		return nil
+	}
+	uncommitted := &sessionShowUncommittedJSON{
+		ShadowBranch: checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID),
+		Checkpoints:  state.StepCount,
+		Subagents:    []checkpoint.SubagentMetadata{},
+	}
+	for _, edit := range state.HumanEdits {
+		uncommitted.HumanEdits = append(uncommitted.HumanEdits, checkpoint.HumanEdit(edit))
+	}
+	for _, run := range state.Subagents {
+		uncommitted.Subagents = append(uncommitted.Subagents, checkpoint.SubagentMetadata{
+			ToolUseID:    run.ToolUseID,
+			AgentID:      run.AgentID,
+			SubagentType: run.SubagentType,
+			Description:  run.Description,
+			Checkpoints:  run.Checkpoints,
+			FilesTouched: run.FilesTouched,
+			Running:      !run.Done,
+		})
+	}
+	return uncommitted
+}
+
+// sessionCommitted returns the session's committed checkpoints in repo,
+// oldest first, with the metadata of the first.
+func sessionCommitted(ctx context.Context, repo *git.Repository, sessionID string) ([]sessionShowCommittedJSON, *checkpoint.CommittedMetadata, error) {
+	store := checkpoint.NewGitStore(repo)
+	committed, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	sort.SliceStable(committed, func(i, j int) bool {
+		return committed[i].CreatedAt.Before(committed[j].CreatedAt)
+	})
+	result := []sessionShowCommittedJSON{}
+	var first *checkpoint.CommittedMetadata
+	for _, info := range committed {
+		if info.SessionID != sessionID && !slices.Contains(info.SessionIDs, sessionID) {
+			continue
+		}
+		summary, err := store.ReadCommitted(ctx, info.CheckpointID)
+		if err != nil || summary == nil {
+			continue
+		}
+		for i := range summary.Sessions {
+			metadata, err := store.ReadSessionMetadata(ctx, info.CheckpointID, i)
+			if err != nil || metadata.SessionID != sessionID {
+				continue
+			}
+			if first == nil {
+				first = metadata
+			}
+			subagents := metadata.Subagents
+			if subagents == nil {
+				subagents = []checkpoint.SubagentMetadata{}
+			}
+			result = append(result, sessionShowCommittedJSON{
+				CheckpointID: info.CheckpointID.String(),
+				CreatedAt:    metadata.CreatedAt,
+				Checkpoints:  metadata.CheckpointsCount,
+				FilesTouched: metadata.FilesTouched,
+				Subagents:    subagents,
+				HumanEdits:   metadata.HumanEdits,
+				Attribution:  metadata.InitialAttribution,
+			})
+		}
+	}
+	return result, first, nil
+}
+
+// sessionWorkspaceRepos returns the session's work in the other repositories
+// of its workspace: those it saved checkpoints in and those configured now.
+func sessionWorkspaceRepos(ctx context.Context, sessionID string, state *strategy.SessionState) ([]sessionShowRepoJSON, error) {
+	roots, err := workspaceRepoRoots()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		for _, root := range state.WorkspaceRepos {
+			if !slices.Contains(roots, root) {
+				roots = append(roots, root)
+			}
+		}
+	}
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repo root: %w", err)
+	}
+
+	var repos []sessionShowRepoJSON
+	for _, root := range roots {
+		r := sessionShowRepoJSON{Path: workspaceRepoLabel(repoRoot, root)}
+		err := inWorkspaceRepo(root, func() error {
+			memberState, err := strategy.LoadSessionState(sessionID)
+			if err != nil {
+				return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+			}
+			if memberState != nil {
+				r.Uncommitted = sessionUncommitted(memberState)
+			}
+			repo, err := openRepository()
+			if err != nil {
+				return err
+			}
+			r.Committed, _, err = sessionCommitted(ctx, repo, sessionID)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("workspace repository %s: %w", r.Path, err)
+		}
+		if len(r.Committed) > 0 || r.Uncommitted != nil {
+			repos = append(repos, r)
+		}
+	}
+	return repos, nil
+}
+
+// printSessionTree prints a session's committed checkpoints and its work
+// since the last commit as a tree.
+func printSessionTree(w io.Writer, committed []sessionShowCommittedJSON, uncommitted *sessionShowUncommittedJSON) {
+	n := len(committed)
+	if uncommitted != nil {
+		n++
+	}
+	if n == 0 {
+		fmt.Fprintln(w, "No checkpoints yet.")
+		return
+	}
+	i := 0
+	for _, c := range committed {
+		i++
+		title := fmt.Sprintf("Checkpoint %s (%s, %d checkpoint(s)%s)", c.CheckpointID, c.CreatedAt.Local().Format("2006-01-02 15:04"), c.Checkpoints, describeHumanEdits(c.HumanEdits))
+		printSessionTreeNode(w, title, c.Subagents, i == n)
+	}
+	if u := uncommitted; u != nil {
+		printSessionTreeNode(w, fmt.Sprintf("Uncommitted (%d checkpoint(s)%s on %s)", u.Checkpoints, describeHumanEdits(u.HumanEdits), u.ShadowBranch), u.Subagents, true)
+	}
+}
+
+// describeRepoAttribution formats the agents' share of the lines the
+// session's commits in one repository added, "" without attribution.
+func describeRepoAttribution(committed []sessionShowCommittedJSON) string {
+	var agentLines, total int
+	for _, c := range committed {
+		if c.Attribution != nil {
+			agentLines += c.Attribution.AgentLines
+			total += c.Attribution.TotalCommitted
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d of %d committed line(s) by agents)", agentLines, total)
+}
+
+// describeHumanEdits formats the human edits `entire daemon` recorded as a
+// suffix for a tree node's details, "" if there are none.
+func describeHumanEdits(edits []checkpoint.HumanEdit) string {
+	if len(edits) == 0 {
+		return ""
+	}
+	var added, removed int
+	for _, edit := range edits {
+		added += edit.LinesAdded
+		removed += edit.LinesRemoved
+	}
+	return fmt.Sprintf(", %d human edit(s) +%d/-%d", len(edits), added, removed)
+}
+
+// printSessionTreeNode prints one branch of the session tree with its
+// subagents as leaves.
+func printSessionTreeNode(w io.Writer, title string, subagents []checkpoint.SubagentMetadata, last bool) {
+	branch, indent := "├── ", "│   "
+	if last {
+		branch, indent = "└── ", "    "
+	}
+	fmt.Fprintln(w, branch+title)
+	for i, sub := range subagents {
+		leaf := "├── "
+		if i == len(subagents)-1 {
+			leaf = "└── "
+		}
+		fmt.Fprintln(w, indent+leaf+describeSubagent(sub))
+	}
+}
+
+// describeSubagent formats a subagent run on one line.
+func describeSubagent(sub checkpoint.SubagentMetadata) string {
+	name := sub.SubagentType
+	if name == "" {
+		name = "subagent"
+	}
+	var sb strings.Builder
+	sb.WriteString("Subagent " + name)
+	if sub.Description != "" {
+		sb.WriteString(": " + stringutil.TruncateRunes(strings.Join(strings.Fields(sub.Description), " "), 50, "..."))
+	}
+	details := []string{fmt.Sprintf("%d checkpoint(s)", sub.Checkpoints)}
+	if len(sub.FilesTouched) > 0 {
+		details = append(details, strings.Join(sub.FilesTouched, ", "))
+	}
+	if sub.Running {
+		details = append(details, "running")
+	}
+	sb.WriteString(" (" + strings.Join(details, "; ") + ")")
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestRunSessionsShow_Tree(t *testing.T) {
+	repo, base := setupCleanTestRepo(t)
+	sessionID := "2026-10-14-tree"
+
+	store := checkpoint.NewGitStore(repo)
+	if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+		CheckpointID:     id.MustCheckpointID("d1b2c3d4e5f6"),
+		SessionID:        sessionID,
+		Strategy:         "manual-commit",
+		Agent:            agent.AgentTypeClaudeCode,
+		CheckpointsCount: 3,
+		Subagents: []checkpoint.SubagentMetadata{{
+			ToolUseID:    "toolu_01explore",
+			SubagentType: "Explore",
+			Description:  "find the parser",
+			Checkpoints:  2,
+			FilesTouched: []string{"parser.go"},
+		}},
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+	if err := strategy.SaveSessionState(&strategy.SessionState{
+		SessionID:  sessionID,
+		BaseCommit: base.String(),
+		StartedAt:  time.Now(),
+		Phase:      session.PhaseActive,
+		AgentType:  agent.AgentTypeClaudeCode,
+		StepCount:  1,
+		Subagents: []session.SubagentRun{{
+			ToolUseID:    "toolu_01review",
+			SubagentType: "reviewer",
+			Checkpoints:  1,
+		}},
+		Resumes: []session.ResumeRecord{{
+			At:           time.Now(),
+			Source:       "resume",
+			PreviousBase: "1a2b3c4d5e6f",
+			Base:         base.String(),
+			Checkpoints:  session.ResumeCheckpointsMoved,
+		}},
+	}); err != nil {
+		t.Fatalf("SaveSessionState() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runSessionsShow(context.Background(), &buf, sessionID, false); err != nil {
+		t.Fatalf("runSessionsShow() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Session 2026-10-14-tree (Claude Code, active)",
+		"Resumed 1 time(s), last on ",
+		": HEAD moved from 1a2b3c4 to " + base.String()[:7] + "; its checkpoints were moved onto HEAD\n",
+		"├── Checkpoint d1b2c3d4e5f6",
+		"│   └── Subagent Explore: find the parser (2 checkpoint(s); parser.go)",
+		"└── Uncommitted (1 checkpoint(s) on entire/",
+		"    └── Subagent reviewer (1 checkpoint(s); running)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := runSessionsShow(context.Background(), &buf, "2026-10-14-missing", false); err == nil || !strings.Contains(err.Error(), "session not found") {
+		t.Errorf("runSessionsShow() of unknown session error = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newSessionsTagCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tag <session-id> <tag...>",
+		Short: "Tag a session",
+		Long: `Adds tags to a session, so it can be found by what it was for:
+'entire sessions list --tag <tag>' lists the sessions with a tag.
+
+Tags are lowercase words of letters, digits, '-', '_', '.' and '/', and are
+kept in the session's state.`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeFirstArg(completeSessionIDs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSessionsTag(cmd.OutOrStdout(), args[0], args[1:], false)
+		},
+	}
+}
+
+func newSessionsUntagCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "untag <session-id> <tag...>",
+		Short:             "Remove tags from a session",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeSessionTags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSessionsTag(cmd.OutOrStdout(), args[0], args[1:], true)
+		},
+	}
+}
+
+// runSessionsTag adds tags to a session's state, or removes them.
+func runSessionsTag(w io.Writer, sessionID string, tagArgs []string, remove bool) error {
+	tags := make([]string, 0, len(tagArgs))
+	for _, arg := range tagArgs {
+		tag, err := session.NormalizeTag(arg)
+		if err != nil {
+			return err //nolint:wrapcheck // already names the tag
+		}
+		tags = append(tags, tag)
+	}
+
+	// Tag under the state lock, so a hook saving the session meanwhile keeps its changes
+	var changed []string
+	found := false
+	err := strategy.UpsertSessionState(sessionID, func(state *strategy.SessionState) (*strategy.SessionState, error) {
+		if state == nil {
+			return nil, nil //nolint:nilnil // no session, nothing to save
+		}
+		found = true
+		if remove {
+			changed = state.RemoveTags(tags...)
+		} else {
+			changed = state.AddTags(tags...)
+		}
+		if len(changed) == 0 {
+			return nil, nil //nolint:nilnil // unchanged, nothing to save
+		}
+		return state, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag session %s: %w", sessionID, err)
+	}
+	if !found {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	switch {
+	case len(changed) == 0 && remove:
+		fmt.Fprintf(w, "Session %s has none of these tags.\n", sessionID)
+	case len(changed) == 0:
+		fmt.Fprintf(w, "Session %s already has these tags.\n", sessionID)
+	case remove:
+		fmt.Fprintf(w, "Removed %s from session %s.\n", formatTags(changed), sessionID)
+	default:
+		fmt.Fprintf(w, "Tagged session %s with %s.\n", sessionID, formatTags(changed))
+	}
+	return nil
+}
+
+// formatTags formats tags for display: "#a #b".
+func formatTags(tags []string) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = "#" + tag
+	}
+	return strings.Join(formatted, " ")
+}
+
+// completeSessionTags completes `sessions untag`: a session, then its tags.
+func completeSessionTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeSessionIDs(cmd, args, toComplete)
+	}
+	state, err := strategy.LoadSessionState(args[0])
+	if err != nil || state == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var candidates []string
+	for _, tag := range state.Tags {
+		if strings.HasPrefix(tag, toComplete) {
+			candidates = append(candidates, tag)
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
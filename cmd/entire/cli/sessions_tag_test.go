@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestRunSessionsTag(t *testing.T) {
+	setupCleanTestRepo(t)
+	for _, state := range []*strategy.SessionState{
+		{SessionID: "2026-10-14-login", BaseCommit: "0000001", StartedAt: time.Now(), FirstPrompt: "please fix the login redirect loop"},
+		{SessionID: "2026-10-14-other", BaseCommit: "0000002", StartedAt: time.Now(), Title: "Write docs"},
+	} {
+		if err := strategy.SaveSessionState(state); err != nil {
+			t.Fatalf("SaveSessionState() error = %v", err)
+		}
+	}
+
+	var out strings.Builder
+	if err := runSessionsTag(&out, "2026-10-14-login", []string{"Auth", "#bug"}, false); err != nil {
+		t.Fatalf("runSessionsTag() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Tagged session 2026-10-14-login with #auth #bug.") {
+		t.Errorf("output = %q", out.String())
+	}
+	if err := runSessionsTag(&out, "2026-10-14-login", []string{"two words"}, false); err == nil {
+		t.Error("runSessionsTag(\"two words\") error = nil, want an invalid tag error")
+	}
+	if err := runSessionsTag(&out, "2026-10-14-missing", []string{"auth"}, false); err == nil {
+		t.Error("runSessionsTag() on a missing session error = nil")
+	}
+
+	// Titles are derived for sessions recorded without one
+	out.Reset()
+	if err := runSessionsList(&out, io.Discard, false, listOptions{Tag: "auth", Sort: listSortNewest}, false); err != nil {
+		t.Fatalf("runSessionsList() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Fix the login redirect loop  #auth #bug") || strings.Contains(out.String(), "Write docs") {
+		t.Errorf("sessions tagged auth =\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runSessionsTag(&out, "2026-10-14-login", []string{"auth", "nope"}, true); err != nil {
+		t.Fatalf("runSessionsTag(untag) error = %v", err)
+	}
+	state, err := strategy.LoadSessionState("2026-10-14-login")
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	if len(state.Tags) != 1 || state.Tags[0] != "bug" {
+		t.Errorf("tags after untag = %v, want [bug]", state.Tags)
+	}
+}
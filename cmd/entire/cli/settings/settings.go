@@ -6,9 +6,17 @@ package settings
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
@@ -23,8 +31,27 @@ const (
 	EntireSettingsFile = ".entire/settings.json"
 	// EntireSettingsLocalFile is the path to the local settings override file (not committed)
 	EntireSettingsLocalFile = ".entire/settings.local.json"
+	// userSettingsFileName is the name of the user settings file in the
+	// user config directory
+	userSettingsFileName = "settings.json"
 )
 
+// UserSettingsFile returns the path of the user settings file, which applies
+// to every repository: $XDG_CONFIG_HOME/entire/settings.json, or
+// ~/.config/entire/settings.json. It is JSON with the same keys as the
+// project file, so decodeFile layers the two the same way. Returns "" if no
+// home directory is known.
+func UserSettingsFile() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "entire", userSettingsFileName)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "entire", userSettingsFileName)
+}
+
 // EntireSettings represents the .entire/settings.json configuration
 type EntireSettings struct {
 	// Strategy is the name of the git strategy to use
@@ -49,11 +76,952 @@ type EntireSettings struct {
 	// Telemetry controls anonymous usage analytics.
 	// nil = not asked yet (show prompt), true = opted in, false = opted out
 	Telemetry *bool `json:"telemetry,omitempty"`
+
+	// CommitMessages overrides the messages strategies write for checkpoints.
+	// nil = use the built-in messages.
+	CommitMessages *CommitMessageTemplates `json:"commit_messages,omitempty"`
+
+	// Reporting controls how reports (stats, list views) bucket dates.
+	// nil = local timezone, weeks starting on Monday.
+	Reporting *ReportingSettings `json:"reporting,omitempty"`
+
+	// DisabledHooks lists hooks that stay installed but pass through without
+	// doing anything (e.g. "stop", "post-commit"). "all" disables every hook.
+	DisabledHooks []string `json:"disabled_hooks,omitempty"`
+
+	// Attribution controls how commits are split between agent and human.
+	// nil = line-level attribution.
+	Attribution *AttributionSettings `json:"attribution,omitempty"`
+
+	// BotIdentities are extra git author names or emails (glob patterns, e.g.
+	// "ci-agent@*") whose sessions count as autonomous. Identities ending in
+	// "[bot]" always do.
+	BotIdentities []string `json:"bot_identities,omitempty"`
+
+	// StateDir is the base directory for session state and queued ref writes
+	// when .git is read-only or on a network filesystem.
+	// Empty = $XDG_STATE_HOME/entire (or ~/.local/state/entire),
+	// %LOCALAPPDATA%\entire on Windows.
+	StateDir string `json:"state_dir,omitempty"`
+
+	// Redaction configures secret redaction of transcripts before they are stored.
+	// nil = built-in rules only.
+	Redaction *RedactionSettings `json:"redaction,omitempty"`
+
+	// Chunking stores large text files in checkpoints as content-defined chunks.
+	// nil = off.
+	Chunking *ChunkingSettings `json:"chunking,omitempty"`
+
+	// SizeLimits bounds the files checkpoints store. nil = the defaults.
+	SizeLimits *SizeLimitsSettings `json:"size_limits,omitempty"`
+
+	// Encryption encrypts session state and transcript copies on disk.
+	// nil = off.
+	Encryption *EncryptionSettings `json:"encryption,omitempty"`
+
+	// CommitNotes also stores each commit's checkpoint metadata and attribution
+	// in a git note under refs/notes/entire, pushed along with the metadata branch.
+	CommitNotes bool `json:"commit_notes,omitempty"`
+
+	// SyncRemote is the remote `entire sync` pushes shadow branches to and
+	// pulls them from. Empty = "origin".
+	SyncRemote string `json:"sync_remote,omitempty"`
+
+	// TraceHooks records every agent hook invocation for `entire hooks trace`
+	// and `entire hooks replay`.
+	TraceHooks bool `json:"trace_hooks,omitempty"`
+
+	// Retention is the default policy of `entire checkpoint prune` when no
+	// policy flags are given. nil = flags are required.
+	Retention *RetentionSettings `json:"retention,omitempty"`
+
+	// Classifier configures how prompts are tagged with a task type.
+	// nil = built-in keyword rules.
+	Classifier *ClassifierSettings `json:"classifier,omitempty"`
+
+	// Quota caps Entire's on-disk footprint in the repository. nil = no cap.
+	Quota *QuotaSettings `json:"quota,omitempty"`
+
+	// SessionExpiry archives sessions that stopped receiving hooks, e.g.
+	// after a crash. nil = archive after DefaultSessionExpiryTTL.
+	SessionExpiry *SessionExpirySettings `json:"session_expiry,omitempty"`
+
+	// Workspace lists the other repositories the agents of a session edit,
+	// so their changes are checkpointed there under the same session ID.
+	// nil = only this repository.
+	Workspace *WorkspaceSettings `json:"workspace,omitempty"`
+
+	// Submodules configures how checkpoints treat git submodules. nil =
+	// record the submodules' commits only.
+	Submodules *SubmoduleSettings `json:"submodules,omitempty"`
+
+	// RepositoryBackend picks how checkpoints read and write the shadow
+	// branch: go-git, or the git CLI for very large repositories. nil = auto.
+	RepositoryBackend *RepositoryBackendSettings `json:"repository_backend,omitempty"`
+
+	// PushPolicy is the AI-usage policy the pre-push hook enforces on the
+	// commits being pushed. nil = no policy.
+	PushPolicy *PushPolicySettings `json:"push_policy,omitempty"`
+
+	// PromptPolicy is the guardrails the prompt-submit hooks check before a
+	// prompt reaches the agent. nil = no policy.
+	PromptPolicy *PromptPolicySettings `json:"prompt_policy,omitempty"`
+
+	// OpenTelemetry exports traces and metrics of hook executions over
+	// OTLP/HTTP. nil = off, unless the OTEL_EXPORTER_OTLP_* environment
+	// variables configure an endpoint.
+	OpenTelemetry *OpenTelemetrySettings `json:"opentelemetry,omitempty"`
+
+	// Summarizer picks the LLM that summarizes sessions (entire transcript
+	// summarize, explain --generate, auto-summarize). nil = the claude CLI.
+	Summarizer *SummarizerSettings `json:"summarizer,omitempty"`
+}
+
+// Summarizer providers.
+const (
+	SummarizerClaudeCLI = "claude-cli"
+	SummarizerAnthropic = "anthropic"
+	SummarizerOpenAI    = "openai"
+	SummarizerCommand   = "command"
+)
+
+// SummarizerSettings configures the LLM sessions are summarized with.
+type SummarizerSettings struct {
+	// Provider is "claude-cli" (default), "anthropic" (the Messages API),
+	// "openai" (any OpenAI-compatible chat completions endpoint, e.g. a
+	// local Ollama or vLLM server) or "command".
+	Provider string `json:"provider,omitempty"`
+	// Model is passed to the provider. "" = the provider's default.
+	Model string `json:"model,omitempty"`
+	// BaseURL is the API's base URL, e.g. "http://localhost:11434/v1".
+	// "" = the provider's public API.
+	BaseURL string `json:"base_url,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key, so the
+	// key itself stays out of settings. "" = ANTHROPIC_API_KEY or
+	// OPENAI_API_KEY.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// Command is run with sh -c for "command": it reads the prompt on stdin
+	// and prints the summary JSON.
+	Command string `json:"command,omitempty"`
+}
+
+// EffectiveProvider returns the configured provider, "claude-cli" if not set,
+// or an error if the settings don't work with it.
+func (s *SummarizerSettings) EffectiveProvider() (string, error) {
+	if s == nil || s.Provider == "" {
+		return SummarizerClaudeCLI, nil
+	}
+	provider := strings.ToLower(s.Provider)
+	switch provider {
+	case SummarizerClaudeCLI:
+	case SummarizerAnthropic, SummarizerOpenAI:
+		if s.BaseURL != "" {
+			if u, err := url.Parse(s.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "", fmt.Errorf("invalid summarizer base_url %q: use an http or https URL", s.BaseURL)
+			}
+		}
+	case SummarizerCommand:
+		if strings.TrimSpace(s.Command) == "" {
+			return "", errors.New("summarizer provider command needs a command")
+		}
+	default:
+		return "", fmt.Errorf("invalid summarizer provider %q: use %s, %s, %s or %s", s.Provider,
+			SummarizerClaudeCLI, SummarizerAnthropic, SummarizerOpenAI, SummarizerCommand)
+	}
+	return provider, nil
+}
+
+// OpenTelemetrySettings configures the OTLP/HTTP export of hook spans and
+// metrics. The standard OTEL_* environment variables override it.
+type OpenTelemetrySettings struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g.
+	// "http://localhost:4318". "" = don't export.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Headers are sent with every export, e.g. an API key. Keep secrets in
+	// settings.local.json or OTEL_EXPORTER_OTLP_HEADERS.
+	Headers map[string]string `json:"headers,omitempty"`
+	// ServiceName is the service.name resource attribute. "" = "entire".
+	ServiceName string `json:"service_name,omitempty"`
+}
+
+// EffectiveEndpoint returns the configured endpoint, "" if there is none, or
+// an error if it isn't an http or https URL.
+func (o *OpenTelemetrySettings) EffectiveEndpoint() (string, error) {
+	if o == nil || strings.TrimSpace(o.Endpoint) == "" {
+		return "", nil
+	}
+	endpoint := strings.TrimSpace(o.Endpoint)
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid opentelemetry endpoint %q: use an http or https URL, e.g. http://localhost:4318", o.Endpoint)
+	}
+	return endpoint, nil
+}
+
+// Push policy actions.
+const (
+	PushPolicyBlock = "block"
+	PushPolicyWarn  = "warn"
+)
+
+// PromptPolicySettings are the checks the prompt-submit hooks run before a
+// prompt reaches the agent. A prompt that fails one is stopped with a reason
+// the agent shows instead.
+type PromptPolicySettings struct {
+	// MaxDirtyLines stops prompts while uncommitted changes to tracked files
+	// add and remove more lines than this. 0 = no limit.
+	MaxDirtyLines int `json:"max_dirty_lines,omitempty"`
+
+	// ProtectedBranches are branches agent sessions don't run on. Patterns
+	// use path.Match syntax, e.g. "main" or "release/*".
+	ProtectedBranches []string `json:"protected_branches,omitempty"`
+
+	// ProtectedBranchAction is what happens to a prompt on a protected
+	// branch: "block" (default) stops it, "branch" first creates a session
+	// branch from HEAD and switches to it.
+	ProtectedBranchAction string `json:"protected_branch_action,omitempty"`
+
+	// SessionBranchPrefix starts the names of the branches "branch" creates,
+	// followed by the start of the session ID. "" = DefaultSessionBranchPrefix.
+	SessionBranchPrefix string `json:"session_branch_prefix,omitempty"`
+
+	// AllowedPaths are the repository-relative directories agents may work
+	// in. Prompts are stopped when submitted from outside them, or while
+	// files outside them have uncommitted changes. Empty = anywhere.
+	AllowedPaths []string `json:"allowed_paths,omitempty"`
+}
+
+// Protected branch actions.
+const (
+	ProtectedBranchBlock  = "block"
+	ProtectedBranchSwitch = "branch"
+)
+
+// DefaultSessionBranchPrefix starts the names of session branches created
+// on protected branches.
+const DefaultSessionBranchPrefix = "agent/"
+
+// EffectiveProtectedBranchAction returns the configured protected branch
+// action.
+func (p *PromptPolicySettings) EffectiveProtectedBranchAction() (string, error) {
+	if p == nil {
+		return ProtectedBranchBlock, nil
+	}
+	switch p.ProtectedBranchAction {
+	case "", ProtectedBranchBlock:
+		return ProtectedBranchBlock, nil
+	case ProtectedBranchSwitch:
+		return ProtectedBranchSwitch, nil
+	}
+	return "", fmt.Errorf("invalid prompt_policy protected_branch_action %q: use %s or %s", p.ProtectedBranchAction, ProtectedBranchBlock, ProtectedBranchSwitch)
+}
+
+// EffectiveSessionBranchPrefix returns the configured session branch
+// prefix, or an error if branches named with it would be invalid or
+// collide with Entire's own entire/ branches.
+func (p *PromptPolicySettings) EffectiveSessionBranchPrefix() (string, error) {
+	if p == nil || p.SessionBranchPrefix == "" {
+		return DefaultSessionBranchPrefix, nil
+	}
+	prefix := p.SessionBranchPrefix
+	if strings.ContainsAny(prefix, " \t~^:?*[\\") || strings.Contains(prefix, "..") ||
+		strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, "-") || strings.HasPrefix(prefix, "entire/") {
+		return "", fmt.Errorf("invalid prompt_policy session_branch_prefix %q: use a branch name prefix such as %s", prefix, DefaultSessionBranchPrefix)
+	}
+	return prefix, nil
+}
+
+// IsSet reports whether the policy checks anything.
+func (p *PromptPolicySettings) IsSet() bool {
+	return p != nil && (p.MaxDirtyLines > 0 || len(p.ProtectedBranches) > 0 || len(p.AllowedPaths) > 0)
+}
+
+// ProtectedBranch returns the pattern branch matches, "" if it isn't
+// protected.
+func (p *PromptPolicySettings) ProtectedBranch(branch string) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	for _, pattern := range p.ProtectedBranches {
+		matched, err := path.Match(pattern, branch)
+		if err != nil {
+			return "", fmt.Errorf("invalid prompt_policy protected_branches pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return pattern, nil
+		}
+	}
+	return "", nil
+}
+
+// EffectiveAllowedPaths returns the allowed paths cleaned, slash-separated
+// and without a trailing slash, or an error if one leaves the repository.
+// "." allows the whole repository.
+func (p *PromptPolicySettings) EffectiveAllowedPaths() ([]string, error) {
+	if p == nil {
+		return nil, nil
+	}
+	allowed := make([]string, 0, len(p.AllowedPaths))
+	for _, dir := range p.AllowedPaths {
+		cleaned := path.Clean(filepath.ToSlash(strings.TrimSpace(dir)))
+		if dir == "" || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return nil, fmt.Errorf("invalid prompt_policy allowed_paths entry %q: use a directory inside the repository, e.g. services/api", dir)
+		}
+		allowed = append(allowed, cleaned)
+	}
+	return allowed, nil
+}
+
+// Validate checks the policy's settings.
+func (p *PromptPolicySettings) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxDirtyLines < 0 {
+		return fmt.Errorf("invalid prompt_policy max_dirty_lines %d: must not be negative", p.MaxDirtyLines)
+	}
+	if _, err := p.ProtectedBranch(""); err != nil {
+		return err
+	}
+	if _, err := p.EffectiveProtectedBranchAction(); err != nil {
+		return err
+	}
+	if _, err := p.EffectiveSessionBranchPrefix(); err != nil {
+		return err
+	}
+	_, err := p.EffectiveAllowedPaths()
+	return err
+}
+
+// DefaultApprovalTrailer is the trailer that approves commits over
+// push_policy.max_agent_percentage.
+const DefaultApprovalTrailer = "AI-Approved-By"
+
+// PushPolicySettings is the policy the pre-push hook checks each pushed
+// commit against.
+type PushPolicySettings struct {
+	// RequireAttribution rejects commits linked to a checkpoint (by an
+	// Entire-Checkpoint trailer) that has no attribution recorded.
+	RequireAttribution bool `json:"require_attribution,omitempty"`
+
+	// MaxAgentPercentage rejects commits whose agent share is above it,
+	// unless they carry the approval trailer. 0 = no threshold.
+	MaxAgentPercentage float64 `json:"max_agent_percentage,omitempty"`
+
+	// ApprovalTrailer is the trailer key (e.g. "AI-Approved-By: Jane")
+	// that lets a commit over the threshold through. "" = DefaultApprovalTrailer.
+	ApprovalTrailer string `json:"approval_trailer,omitempty"`
+
+	// Action is "block" (default) to fail the push, or "warn" to only
+	// print the violations.
+	Action string `json:"action,omitempty"`
+}
+
+// IsSet reports whether the policy checks anything.
+func (p *PushPolicySettings) IsSet() bool {
+	return p != nil && (p.RequireAttribution || p.MaxAgentPercentage > 0)
+}
+
+// EffectiveApprovalTrailer returns the configured approval trailer key.
+func (p *PushPolicySettings) EffectiveApprovalTrailer() string {
+	if p == nil || strings.TrimSpace(p.ApprovalTrailer) == "" {
+		return DefaultApprovalTrailer
+	}
+	return strings.TrimSpace(p.ApprovalTrailer)
+}
+
+// EffectiveAction returns the configured action, or an error if it or the
+// threshold is invalid.
+func (p *PushPolicySettings) EffectiveAction() (string, error) {
+	if p == nil {
+		return PushPolicyBlock, nil
+	}
+	if p.MaxAgentPercentage < 0 || p.MaxAgentPercentage > 100 {
+		return "", fmt.Errorf("invalid push_policy max_agent_percentage %v: use 0 to 100", p.MaxAgentPercentage)
+	}
+	switch p.Action {
+	case "", PushPolicyBlock:
+		return PushPolicyBlock, nil
+	case PushPolicyWarn:
+		return PushPolicyWarn, nil
+	}
+	return "", fmt.Errorf("invalid push_policy action %q: use %s or %s", p.Action, PushPolicyBlock, PushPolicyWarn)
+}
+
+// QuotaSettings is a hard cap on Entire's on-disk footprint in a repository:
+// the git objects only its shadow branches, metadata branch and notes
+// reference, plus its state directories. Once the footprint reaches the cap,
+// checkpoints are written metadata-only (file hashes and sizes, no contents
+// or transcripts) until space is freed.
+type QuotaSettings struct {
+	// MaxSize is a size such as "500MB", "2GB" or a number of bytes.
+	MaxSize string `json:"max_size,omitempty"`
+}
+
+// Limit returns the quota in bytes, 0 if no quota is set.
+func (q *QuotaSettings) Limit() (int64, error) {
+	if q == nil || strings.TrimSpace(q.MaxSize) == "" {
+		return 0, nil
+	}
+	n, err := ParseByteSize(q.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quota max_size: %w", err)
+	}
+	return n, nil
+}
+
+// byteSizeUnits are the units ParseByteSize accepts, longest suffix first.
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "500MB", "1.5G" or "1048576". Units
+// are binary (1KB = 1024 bytes) and case-insensitive.
+func ParseByteSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range byteSizeUnits {
+		if numStr, ok := strings.CutSuffix(str, u.suffix); ok {
+			str, unit = strings.TrimSpace(numStr), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q: use e.g. 500MB, 2GB or a number of bytes", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// FormatByteSize formats a size in the units ParseByteSize accepts, e.g.
+// "1.5 GB".
+func FormatByteSize(n int64) string {
+	for _, u := range byteSizeUnits[:4] {
+		if n >= u.size {
+			return strconv.FormatFloat(float64(n)/float64(u.size), 'f', 1, 64) + " " + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + " B"
+}
+
+// DefaultSessionExpiryTTL is how long a session that hasn't ended may go
+// without a hook before it is archived.
+const DefaultSessionExpiryTTL = 7 * 24 * time.Hour
+
+// SessionExpirySettings configures the archival of stale sessions: sessions
+// that never received their Stop or SessionEnd hook and had no hook for TTL.
+type SessionExpirySettings struct {
+	// Enabled turns expiry off when false. Defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+	// TTL is an age such as "7d", "2w" or "12h".
+	TTL string `json:"ttl,omitempty"`
+}
+
+// EffectiveTTL returns how long a session may go without a hook before it
+// is archived, 0 if expiry is turned off.
+func (e *SessionExpirySettings) EffectiveTTL() (time.Duration, error) {
+	if e == nil {
+		return DefaultSessionExpiryTTL, nil
+	}
+	if e.Enabled != nil && !*e.Enabled {
+		return 0, nil
+	}
+	if strings.TrimSpace(e.TTL) == "" {
+		return DefaultSessionExpiryTTL, nil
+	}
+	d, err := ParseAge(e.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid session_expiry ttl: %w", err)
+	}
+	return d, nil
+}
+
+// WorkspaceSettings configures workspace mode: sessions whose agents edit
+// files in several repositories, such as sibling checkouts or repositories
+// nested in a monorepo, get checkpoints in each of them.
+type WorkspaceSettings struct {
+	// Repos are the other repositories' roots, relative to this repository's
+	// root or absolute.
+	Repos []string `json:"repos,omitempty"`
+}
+
+// RepoRoots returns the workspace repositories' roots as clean absolute
+// paths, resolving relative ones against root, without root itself.
+func (w *WorkspaceSettings) RepoRoots(root string) ([]string, error) {
+	if w == nil {
+		return nil, nil
+	}
+	var roots []string
+	for _, repo := range w.Repos {
+		if strings.TrimSpace(repo) == "" {
+			return nil, errors.New("invalid workspace repos: empty path")
+		}
+		abs := filepath.Clean(repo)
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(root, abs)
+		}
+		if abs == filepath.Clean(root) || slices.Contains(roots, abs) {
+			continue
+		}
+		roots = append(roots, abs)
+	}
+	return roots, nil
+}
+
+// SubmoduleSettings configures checkpoints of git submodules. Checkpoints
+// always record the commit a submodule the agent changed has checked out.
+type SubmoduleSettings struct {
+	// Recurse also checkpoints the files the agent changes inside a
+	// submodule, in the submodule's repository with its own shadow branch,
+	// as for workspace repositories.
+	Recurse bool `json:"recurse,omitempty"`
+}
+
+// Repository backend kinds.
+const (
+	RepositoryBackendAuto  = "auto"
+	RepositoryBackendGoGit = "go-git"
+	RepositoryBackendGit   = "git"
+)
+
+// DefaultRepositoryBackendMaxFiles is the index size above which the auto
+// backend uses the git CLI.
+const DefaultRepositoryBackendMaxFiles = 200_000
+
+// DefaultRepositoryBackendLatencyBudget is how long a go-git checkpoint
+// write may take before the auto backend switches to the git CLI.
+const DefaultRepositoryBackendLatencyBudget = time.Second
+
+// RepositoryBackendSettings configures the repository backend checkpoints
+// are written with.
+type RepositoryBackendSettings struct {
+	// Kind is "auto" (the default), "go-git" or "git". Auto uses go-git
+	// unless the index has more than MaxFiles entries or a go-git checkpoint
+	// write took longer than LatencyBudget.
+	Kind string `json:"kind,omitempty"`
+	// MaxFiles is the auto threshold. 0 = DefaultRepositoryBackendMaxFiles.
+	MaxFiles int `json:"max_files,omitempty"`
+	// LatencyBudget is a duration such as "500ms". "" =
+	// DefaultRepositoryBackendLatencyBudget.
+	LatencyBudget string `json:"latency_budget,omitempty"`
+}
+
+// Effective returns the configured kind, the auto file threshold and the
+// latency budget, with the defaults for what isn't set.
+func (r *RepositoryBackendSettings) Effective() (kind string, maxFiles int, budget time.Duration, err error) {
+	kind, maxFiles, budget = RepositoryBackendAuto, DefaultRepositoryBackendMaxFiles, DefaultRepositoryBackendLatencyBudget
+	if r == nil {
+		return kind, maxFiles, budget, nil
+	}
+	switch k := strings.TrimSpace(r.Kind); k {
+	case "":
+	case RepositoryBackendAuto, RepositoryBackendGoGit, RepositoryBackendGit:
+		kind = k
+	default:
+		return "", 0, 0, fmt.Errorf("invalid repository_backend kind %q: use auto, go-git or git", r.Kind)
+	}
+	if r.MaxFiles < 0 {
+		return "", 0, 0, errors.New("invalid repository_backend max_files: must not be negative")
+	}
+	if r.MaxFiles > 0 {
+		maxFiles = r.MaxFiles
+	}
+	if strings.TrimSpace(r.LatencyBudget) != "" {
+		budget, err = time.ParseDuration(strings.TrimSpace(r.LatencyBudget))
+		if err != nil || budget <= 0 {
+			return "", 0, 0, fmt.Errorf("invalid repository_backend latency_budget %q: use e.g. 500ms or 2s", r.LatencyBudget)
+		}
+	}
+	return kind, maxFiles, budget, nil
+}
+
+// ParseAge parses an age such as "30d", "2w" or a Go duration like "12h".
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if numStr, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.Atoi(numStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid age %q: use e.g. 30d, 2w or 12h", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q: use e.g. 30d, 2w or 12h", s)
+	}
+	return d, nil
+}
+
+// ClassifierSettings configures the prompt task-type classifier.
+type ClassifierSettings struct {
+	// Command is a shell command that reads a prompt on stdin and prints its
+	// task type. Empty = built-in keyword rules, which it falls back to on failure.
+	Command string `json:"command,omitempty"`
+}
+
+// ClassifierCommand returns the configured classifier command, "" for the
+// built-in rules.
+func (s *EntireSettings) ClassifierCommand() string {
+	if s.Classifier == nil {
+		return ""
+	}
+	return s.Classifier.Command
+}
+
+// RetentionSettings are default retention policies for `entire checkpoint prune`.
+// They mirror its --older-than, --keep-per-session and --merged flags.
+type RetentionSettings struct {
+	// OlderThan is an age such as "30d", "2w" or "12h".
+	OlderThan string `json:"older_than,omitempty"`
+	// KeepPerSession keeps this many of the most recent items per session.
+	KeepPerSession int `json:"keep_per_session,omitempty"`
+	// Merged only prunes work already merged into this branch.
+	Merged string `json:"merged,omitempty"`
+}
+
+// IsSet reports whether any retention policy is configured.
+func (r *RetentionSettings) IsSet() bool {
+	return r != nil && (r.OlderThan != "" || r.KeepPerSession > 0 || r.Merged != "")
+}
+
+// DefaultSyncRemote is the remote `entire sync` uses without sync_remote.
+const DefaultSyncRemote = "origin"
+
+// DefaultChunkMinFileSize is the size from which files are chunked when
+// chunking is enabled without a min_file_size.
+const DefaultChunkMinFileSize = 1 << 20
+
+// ChunkingSettings configures content-defined chunking of large text files
+// in checkpoints. See docs/architecture/sessions-and-checkpoints.md.
+type ChunkingSettings struct {
+	Enabled bool `json:"enabled"`
+	// MinFileSize is the size in bytes from which files are chunked.
+	// 0 = DefaultChunkMinFileSize.
+	MinFileSize int64 `json:"min_file_size,omitempty"`
+}
+
+// Threshold returns the file size from which files are chunked, 0 if
+// chunking is off.
+func (c *ChunkingSettings) Threshold() int64 {
+	if c == nil || !c.Enabled {
+		return 0
+	}
+	if c.MinFileSize <= 0 {
+		return DefaultChunkMinFileSize
+	}
+	return c.MinFileSize
+}
+
+// Encryption key sources.
+const (
+	EncryptionKeyEnv      = "env"
+	EncryptionKeyKeychain = "keychain"
+)
+
+// EncryptionSettings configures encryption at rest of the files Entire keeps
+// outside git objects. See docs/architecture/sessions-and-checkpoints.md.
+type EncryptionSettings struct {
+	Enabled bool `json:"enabled"`
+	// KeySource is where the key comes from: "env" (ENTIRE_ENCRYPTION_KEY)
+	// or "keychain". Empty = the environment variable if set, else the keychain.
+	KeySource string `json:"key_source,omitempty"`
+}
+
+// IsEnabled reports whether encryption is on.
+func (e *EncryptionSettings) IsEnabled() bool {
+	return e != nil && e.Enabled
+}
+
+// EffectiveKeySource returns the configured key source, "" for automatic, or
+// an error if it is invalid.
+func (e *EncryptionSettings) EffectiveKeySource() (string, error) {
+	if e == nil {
+		return "", nil
+	}
+	switch e.KeySource {
+	case "", EncryptionKeyEnv, EncryptionKeyKeychain:
+		return e.KeySource, nil
+	default:
+		return "", fmt.Errorf("invalid encryption key_source %q: use %q or %q", e.KeySource, EncryptionKeyEnv, EncryptionKeyKeychain)
+	}
+}
+
+// Default size limits of checkpoints.
+const (
+	DefaultMaxFileSize       = 100 << 20
+	DefaultMaxCheckpointSize = 500 << 20
+)
+
+// SizeLimitsSettings bounds the files temporary checkpoints store. Larger
+// files keep their previous contents in the checkpoint and are recorded as
+// skipped. See docs/architecture/sessions-and-checkpoints.md.
+type SizeLimitsSettings struct {
+	// MaxFileSize is the largest file a checkpoint stores, a size such as
+	// "50MB". Empty = DefaultMaxFileSize, "0" = no limit.
+	MaxFileSize string `json:"max_file_size,omitempty"`
+	// MaxCheckpointSize bounds the new file content of one checkpoint.
+	// Empty = DefaultMaxCheckpointSize, "0" = no limit.
+	MaxCheckpointSize string `json:"max_checkpoint_size,omitempty"`
+}
+
+// Limits returns the file and checkpoint size limits in bytes, 0 for no limit.
+func (l *SizeLimitsSettings) Limits() (maxFile, maxCheckpoint int64, err error) {
+	maxFile, maxCheckpoint = DefaultMaxFileSize, DefaultMaxCheckpointSize
+	if l == nil {
+		return maxFile, maxCheckpoint, nil
+	}
+	if maxFile, err = parseSizeLimit(l.MaxFileSize, maxFile); err != nil {
+		return 0, 0, fmt.Errorf("invalid size_limits max_file_size: %w", err)
+	}
+	if maxCheckpoint, err = parseSizeLimit(l.MaxCheckpointSize, maxCheckpoint); err != nil {
+		return 0, 0, fmt.Errorf("invalid size_limits max_checkpoint_size: %w", err)
+	}
+	return maxFile, maxCheckpoint, nil
+}
+
+// parseSizeLimit parses a size limit, def if empty and 0 for "0".
+func parseSizeLimit(s string, def int64) (int64, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return def, nil
+	case "0":
+		return 0, nil
+	}
+	return ParseByteSize(s)
+}
+
+// RedactionSettings configures secret redaction. See docs/architecture/sessions-and-checkpoints.md.
+type RedactionSettings struct {
+	// Allowlist holds regular expressions for text that is never redacted,
+	// such as example keys in docs or fixtures.
+	Allowlist []string `json:"allowlist,omitempty"`
+}
+
+// CommitMessageTemplates are Go text/template formats for checkpoint messages.
+// An empty template keeps the built-in message. See docs/architecture/commit-messages.md
+// for the available placeholders.
+type CommitMessageTemplates struct {
+	// Checkpoint formats the message of each checkpoint: the code commit for
+	// auto-commit, the shadow branch commit for manual-commit.
+	Checkpoint string `json:"checkpoint,omitempty"`
+
+	// Task formats the message of the checkpoint created when a subagent task completes.
+	Task string `json:"task,omitempty"`
+
+	// Suggest has the prepare-commit-msg hook suggest a message for commits
+	// of agent work, as a comment block in the editor (manual-commit only).
+	Suggest bool `json:"suggest,omitempty"`
+
+	// Suggestion formats the suggested message ({{.PromptSummary}} is the
+	// built-in suggestion).
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// ReportingSettings configures date handling in reports, so weekly and daily
+// numbers line up with the team's other tools.
+type ReportingSettings struct {
+	// Timezone is an IANA timezone name (e.g. "Europe/Berlin") used to bucket
+	// days and weeks and to interpret dates in --since/--until.
+	// Empty = the local timezone.
+	Timezone string `json:"timezone,omitempty"`
+
+	// WeekStart is the first day of a reporting week: "monday" (default) or "sunday".
+	WeekStart string `json:"week_start,omitempty"`
+}
+
+// Attribution granularities
+const (
+	AttributionGranularityLine = "line"
+	AttributionGranularityWord = "word"
+	AttributionGranularityChar = "char"
+)
+
+// AttributionSettings configures attribution. See docs/architecture/attribution.md.
+type AttributionSettings struct {
+	// Granularity is the unit a human edit to a line is measured in:
+	// "line" (default), "word" or "char". With "word" and "char", a line the
+	// user only partly changed counts as the changed fraction of a line.
+	Granularity string `json:"granularity,omitempty"`
+
+	// MergeCommits is how merge commits are attributed: "first-parent"
+	// (default) against the first parent, ignoring files the merge took
+	// unchanged from another parent, or "skip" to record no attribution.
+	MergeCommits string `json:"merge_commits,omitempty"`
+
+	// Checkpoints is which of a session's checkpoints a commit is attributed
+	// against: "last" (default) compares with the last checkpoint only,
+	// "union" also counts lines the human kept from earlier checkpoints that
+	// the agent later changed or removed.
+	Checkpoints string `json:"checkpoints,omitempty"`
+
+	// Normalize is how file contents are normalized on both sides before
+	// they're diffed, so reformatting doesn't move lines between the agent
+	// and the human: "none" (default), "whitespace" to ignore changes to
+	// indentation and spacing within lines, or "formatter" to also run the
+	// Formatters command for the file.
+	Normalize string `json:"normalize,omitempty"`
+
+	// Formatters maps file patterns (e.g. "*.go", "web/*.ts") to the shell
+	// command that formats them with normalize "formatter". The command reads
+	// the file on stdin and writes it formatted to stdout, e.g. "gofmt".
+	Formatters map[string]string `json:"formatters,omitempty"`
+
+	// Generated is how files marked as generated count: "exclude" (default)
+	// reports their lines separately and leaves them out of the agent
+	// percentage, "include" counts them like other files.
+	Generated string `json:"generated,omitempty"`
+
+	// GeneratedFiles are gitignore-style patterns of generated files, in
+	// addition to those linguist-generated marks in .gitattributes.
+	GeneratedFiles []string `json:"generated_files,omitempty"`
+}
+
+// Merge commit attribution modes
+const (
+	AttributionMergeFirstParent = "first-parent"
+	AttributionMergeSkip        = "skip"
+)
+
+// Checkpoint attribution modes
+const (
+	AttributionCheckpointsLast  = "last"
+	AttributionCheckpointsUnion = "union"
+)
+
+// Generated file attribution modes
+const (
+	AttributionGeneratedExclude = "exclude"
+	AttributionGeneratedInclude = "include"
+)
+
+// Attribution normalization modes
+const (
+	AttributionNormalizeNone       = "none"
+	AttributionNormalizeWhitespace = "whitespace"
+	AttributionNormalizeFormatter  = "formatter"
+)
+
+// EffectiveGranularity returns the configured granularity, "line" if none is set.
+func (a *AttributionSettings) EffectiveGranularity() (string, error) {
+	if a == nil || a.Granularity == "" {
+		return AttributionGranularityLine, nil
+	}
+	switch g := strings.ToLower(a.Granularity); g {
+	case AttributionGranularityLine, AttributionGranularityWord, AttributionGranularityChar:
+		return g, nil
+	default:
+		return "", fmt.Errorf("invalid attribution granularity %q: use line, word or char", a.Granularity)
+	}
+}
+
+// EffectiveMergeCommits returns how merge commits are attributed,
+// "first-parent" if not set.
+func (a *AttributionSettings) EffectiveMergeCommits() (string, error) {
+	if a == nil || a.MergeCommits == "" {
+		return AttributionMergeFirstParent, nil
+	}
+	switch m := strings.ToLower(a.MergeCommits); m {
+	case AttributionMergeFirstParent, AttributionMergeSkip:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid attribution merge_commits %q: use first-parent or skip", a.MergeCommits)
+	}
+}
+
+// EffectiveCheckpoints returns which checkpoints commits are attributed
+// against, "last" if not set.
+func (a *AttributionSettings) EffectiveCheckpoints() (string, error) {
+	if a == nil || a.Checkpoints == "" {
+		return AttributionCheckpointsLast, nil
+	}
+	switch c := strings.ToLower(a.Checkpoints); c {
+	case AttributionCheckpointsLast, AttributionCheckpointsUnion:
+		return c, nil
+	default:
+		return "", fmt.Errorf("invalid attribution checkpoints %q: use last or union", a.Checkpoints)
+	}
 }
 
-// Load loads the Entire settings from .entire/settings.json,
-// then applies any overrides from .entire/settings.local.json if it exists.
-// Returns default settings if neither file exists.
+// EffectiveNormalize returns how file contents are normalized before
+// attribution diffs them, "none" if not set.
+func (a *AttributionSettings) EffectiveNormalize() (string, error) {
+	if a == nil || a.Normalize == "" {
+		return AttributionNormalizeNone, nil
+	}
+	switch n := strings.ToLower(a.Normalize); n {
+	case AttributionNormalizeNone, AttributionNormalizeWhitespace:
+		return n, nil
+	case AttributionNormalizeFormatter:
+		if len(a.Formatters) == 0 {
+			return "", errors.New("attribution normalize \"formatter\" needs attribution.formatters, e.g. {\"*.go\": \"gofmt\"}")
+		}
+		for pattern, command := range a.Formatters {
+			if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(command) == "" {
+				return "", fmt.Errorf("invalid attribution formatter %q: %q", pattern, command)
+			}
+		}
+		return n, nil
+	default:
+		return "", fmt.Errorf("invalid attribution normalize %q: use none, whitespace or formatter", a.Normalize)
+	}
+}
+
+// EffectiveGenerated returns how generated files count, "exclude" if not set.
+func (a *AttributionSettings) EffectiveGenerated() (string, error) {
+	if a == nil || a.Generated == "" {
+		return AttributionGeneratedExclude, nil
+	}
+	switch g := strings.ToLower(a.Generated); g {
+	case AttributionGeneratedExclude, AttributionGeneratedInclude:
+		return g, nil
+	default:
+		return "", fmt.Errorf("invalid attribution generated %q: use exclude or include", a.Generated)
+	}
+}
+
+// Location returns the configured reporting timezone, or time.Local if none is set.
+func (r *ReportingSettings) Location() (*time.Location, error) {
+	if r == nil || r.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reporting timezone %q: %w", r.Timezone, err)
+	}
+	return loc, nil
+}
+
+// FirstWeekday returns the configured first day of the week (Monday by default).
+func (r *ReportingSettings) FirstWeekday() (time.Weekday, error) {
+	if r == nil || r.WeekStart == "" {
+		return time.Monday, nil
+	}
+	switch strings.ToLower(r.WeekStart) {
+	case "monday":
+		return time.Monday, nil
+	case "sunday":
+		return time.Sunday, nil
+	default:
+		return 0, fmt.Errorf("invalid reporting week_start %q: use monday or sunday", r.WeekStart)
+	}
+}
+
+// Load loads the user settings file (see UserSettingsFile), then applies
+// .entire/settings.json and any overrides from .entire/settings.local.json.
+// Later files override earlier ones field by field.
+// Returns default settings if none of the files exist.
 // Works correctly from any subdirectory within the repository.
 func Load() (*EntireSettings, error) {
 	// Get absolute paths for settings files
@@ -66,9 +1034,12 @@ func Load() (*EntireSettings, error) {
 		localSettingsFileAbs = EntireSettingsLocalFile // Fallback to relative
 	}
 
-	// Load base settings
-	settings, err := loadFromFile(settingsFileAbs)
+	// Load user settings as the base, then the project settings on top
+	settings, err := loadFromFile(UserSettingsFile())
 	if err != nil {
+		return nil, fmt.Errorf("reading user settings file: %w", err)
+	}
+	if err := decodeFile(settings, settingsFileAbs); err != nil {
 		return nil, fmt.Errorf("reading settings file: %w", err)
 	}
 
@@ -104,23 +1075,34 @@ func loadFromFile(filePath string) (*EntireSettings, error) {
 		Strategy: DefaultStrategyName,
 		Enabled:  true, // Default to enabled
 	}
+	if err := decodeFile(settings, filePath); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
 
+// decodeFile decodes a settings file over settings: fields present in the
+// file replace the current values, nested objects are merged into them.
+// A missing file (or an empty path) leaves settings unchanged.
+func decodeFile(settings *EntireSettings, filePath string) error {
+	if filePath == "" {
+		return nil
+	}
 	data, err := os.ReadFile(filePath) //nolint:gosec // path is from caller
 	if err != nil {
 		if os.IsNotExist(err) {
-			return settings, nil
+			return nil
 		}
-		return nil, fmt.Errorf("%w", err)
+		return fmt.Errorf("%w", err)
 	}
 
 	dec := json.NewDecoder(bytes.NewReader(data))
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(settings); err != nil {
-		return nil, fmt.Errorf("parsing settings file: %w", err)
+		return fmt.Errorf("parsing settings file: %w", err)
 	}
 	applyDefaults(settings)
-
-	return settings, nil
+	return nil
 }
 
 // mergeJSON merges JSON data into existing settings.
@@ -204,6 +1186,458 @@ func mergeJSON(settings *EntireSettings, data []byte) error {
 		settings.Telemetry = &t
 	}
 
+	// Merge commit_messages per template if present
+	if messagesRaw, ok := raw["commit_messages"]; ok {
+		var m struct {
+			Checkpoint string `json:"checkpoint"`
+			Task       string `json:"task"`
+			Suggest    *bool  `json:"suggest"`
+			Suggestion string `json:"suggestion"`
+		}
+		if err := json.Unmarshal(messagesRaw, &m); err != nil {
+			return fmt.Errorf("parsing commit_messages field: %w", err)
+		}
+		if settings.CommitMessages == nil {
+			settings.CommitMessages = &CommitMessageTemplates{}
+		}
+		if m.Checkpoint != "" {
+			settings.CommitMessages.Checkpoint = m.Checkpoint
+		}
+		if m.Task != "" {
+			settings.CommitMessages.Task = m.Task
+		}
+		if m.Suggest != nil {
+			settings.CommitMessages.Suggest = *m.Suggest
+		}
+		if m.Suggestion != "" {
+			settings.CommitMessages.Suggestion = m.Suggestion
+		}
+	}
+
+	// Merge reporting per field if present
+	if reportingRaw, ok := raw["reporting"]; ok {
+		var r ReportingSettings
+		if err := json.Unmarshal(reportingRaw, &r); err != nil {
+			return fmt.Errorf("parsing reporting field: %w", err)
+		}
+		if settings.Reporting == nil {
+			settings.Reporting = &ReportingSettings{}
+		}
+		if r.Timezone != "" {
+			settings.Reporting.Timezone = r.Timezone
+		}
+		if r.WeekStart != "" {
+			settings.Reporting.WeekStart = r.WeekStart
+		}
+	}
+
+	// Merge attribution per field if present
+	if attributionRaw, ok := raw["attribution"]; ok {
+		var a AttributionSettings
+		if err := json.Unmarshal(attributionRaw, &a); err != nil {
+			return fmt.Errorf("parsing attribution field: %w", err)
+		}
+		if settings.Attribution == nil {
+			settings.Attribution = &AttributionSettings{}
+		}
+		if a.Granularity != "" {
+			settings.Attribution.Granularity = a.Granularity
+		}
+		if a.MergeCommits != "" {
+			settings.Attribution.MergeCommits = a.MergeCommits
+		}
+		if a.Checkpoints != "" {
+			settings.Attribution.Checkpoints = a.Checkpoints
+		}
+		if a.Normalize != "" {
+			settings.Attribution.Normalize = a.Normalize
+		}
+		if a.Formatters != nil {
+			settings.Attribution.Formatters = a.Formatters
+		}
+		if a.Generated != "" {
+			settings.Attribution.Generated = a.Generated
+		}
+		if a.GeneratedFiles != nil {
+			settings.Attribution.GeneratedFiles = a.GeneratedFiles
+		}
+	}
+
+	// Override disabled_hooks if present; an empty list re-enables all hooks
+	if disabledRaw, ok := raw["disabled_hooks"]; ok {
+		var hooks []string
+		if err := json.Unmarshal(disabledRaw, &hooks); err != nil {
+			return fmt.Errorf("parsing disabled_hooks field: %w", err)
+		}
+		settings.DisabledHooks = hooks
+	}
+
+	// Override bot_identities if present
+	if botsRaw, ok := raw["bot_identities"]; ok {
+		var bots []string
+		if err := json.Unmarshal(botsRaw, &bots); err != nil {
+			return fmt.Errorf("parsing bot_identities field: %w", err)
+		}
+		settings.BotIdentities = bots
+	}
+
+	// Override state_dir if present and non-empty
+	if stateDirRaw, ok := raw["state_dir"]; ok {
+		var dir string
+		if err := json.Unmarshal(stateDirRaw, &dir); err != nil {
+			return fmt.Errorf("parsing state_dir field: %w", err)
+		}
+		if dir != "" {
+			settings.StateDir = dir
+		}
+	}
+
+	// Merge redaction per field if present; an allowlist replaces the previous one
+	if redactionRaw, ok := raw["redaction"]; ok {
+		var r RedactionSettings
+		if err := json.Unmarshal(redactionRaw, &r); err != nil {
+			return fmt.Errorf("parsing redaction field: %w", err)
+		}
+		if settings.Redaction == nil {
+			settings.Redaction = &RedactionSettings{}
+		}
+		if r.Allowlist != nil {
+			settings.Redaction.Allowlist = r.Allowlist
+		}
+	}
+
+	// Merge chunking per field if present
+	if chunkingRaw, ok := raw["chunking"]; ok {
+		var c struct {
+			Enabled     *bool  `json:"enabled"`
+			MinFileSize *int64 `json:"min_file_size"`
+		}
+		if err := json.Unmarshal(chunkingRaw, &c); err != nil {
+			return fmt.Errorf("parsing chunking field: %w", err)
+		}
+		if settings.Chunking == nil {
+			settings.Chunking = &ChunkingSettings{}
+		}
+		if c.Enabled != nil {
+			settings.Chunking.Enabled = *c.Enabled
+		}
+		if c.MinFileSize != nil {
+			settings.Chunking.MinFileSize = *c.MinFileSize
+		}
+	}
+
+	// Merge encryption per field if present
+	if encryptionRaw, ok := raw["encryption"]; ok {
+		var e struct {
+			Enabled   *bool   `json:"enabled"`
+			KeySource *string `json:"key_source"`
+		}
+		if err := json.Unmarshal(encryptionRaw, &e); err != nil {
+			return fmt.Errorf("parsing encryption field: %w", err)
+		}
+		if settings.Encryption == nil {
+			settings.Encryption = &EncryptionSettings{}
+		}
+		if e.Enabled != nil {
+			settings.Encryption.Enabled = *e.Enabled
+		}
+		if e.KeySource != nil {
+			settings.Encryption.KeySource = *e.KeySource
+		}
+	}
+
+	// Merge opentelemetry per field if present
+	if otelRaw, ok := raw["opentelemetry"]; ok {
+		var o struct {
+			Endpoint    *string           `json:"endpoint"`
+			Headers     map[string]string `json:"headers"`
+			ServiceName *string           `json:"service_name"`
+		}
+		if err := json.Unmarshal(otelRaw, &o); err != nil {
+			return fmt.Errorf("parsing opentelemetry field: %w", err)
+		}
+		if settings.OpenTelemetry == nil {
+			settings.OpenTelemetry = &OpenTelemetrySettings{}
+		}
+		if o.Endpoint != nil {
+			settings.OpenTelemetry.Endpoint = *o.Endpoint
+		}
+		if o.Headers != nil && settings.OpenTelemetry.Headers == nil {
+			settings.OpenTelemetry.Headers = map[string]string{}
+		}
+		for k, v := range o.Headers {
+			settings.OpenTelemetry.Headers[k] = v
+		}
+		if o.ServiceName != nil {
+			settings.OpenTelemetry.ServiceName = *o.ServiceName
+		}
+	}
+
+	// Merge summarizer per field if present
+	if summarizerRaw, ok := raw["summarizer"]; ok {
+		var sm SummarizerSettings
+		if err := json.Unmarshal(summarizerRaw, &sm); err != nil {
+			return fmt.Errorf("parsing summarizer field: %w", err)
+		}
+		if settings.Summarizer == nil {
+			settings.Summarizer = &SummarizerSettings{}
+		}
+		if sm.Provider != "" {
+			settings.Summarizer.Provider = sm.Provider
+		}
+		if sm.Model != "" {
+			settings.Summarizer.Model = sm.Model
+		}
+		if sm.BaseURL != "" {
+			settings.Summarizer.BaseURL = sm.BaseURL
+		}
+		if sm.APIKeyEnv != "" {
+			settings.Summarizer.APIKeyEnv = sm.APIKeyEnv
+		}
+		if sm.Command != "" {
+			settings.Summarizer.Command = sm.Command
+		}
+	}
+
+	// Merge size limits per field if present
+	if limitsRaw, ok := raw["size_limits"]; ok {
+		var l struct {
+			MaxFileSize       *string `json:"max_file_size"`
+			MaxCheckpointSize *string `json:"max_checkpoint_size"`
+		}
+		if err := json.Unmarshal(limitsRaw, &l); err != nil {
+			return fmt.Errorf("parsing size_limits field: %w", err)
+		}
+		if settings.SizeLimits == nil {
+			settings.SizeLimits = &SizeLimitsSettings{}
+		}
+		if l.MaxFileSize != nil {
+			settings.SizeLimits.MaxFileSize = *l.MaxFileSize
+		}
+		if l.MaxCheckpointSize != nil {
+			settings.SizeLimits.MaxCheckpointSize = *l.MaxCheckpointSize
+		}
+	}
+
+	// Override commit_notes if present
+	if commitNotesRaw, ok := raw["commit_notes"]; ok {
+		var cn bool
+		if err := json.Unmarshal(commitNotesRaw, &cn); err != nil {
+			return fmt.Errorf("parsing commit_notes field: %w", err)
+		}
+		settings.CommitNotes = cn
+	}
+
+	// Override sync_remote if present and non-empty
+	if syncRemoteRaw, ok := raw["sync_remote"]; ok {
+		var sr string
+		if err := json.Unmarshal(syncRemoteRaw, &sr); err != nil {
+			return fmt.Errorf("parsing sync_remote field: %w", err)
+		}
+		if sr != "" {
+			settings.SyncRemote = sr
+		}
+	}
+
+	// Override trace_hooks if present
+	if traceHooksRaw, ok := raw["trace_hooks"]; ok {
+		var th bool
+		if err := json.Unmarshal(traceHooksRaw, &th); err != nil {
+			return fmt.Errorf("parsing trace_hooks field: %w", err)
+		}
+		settings.TraceHooks = th
+	}
+
+	// Merge retention per field if present
+	if retentionRaw, ok := raw["retention"]; ok {
+		var r struct {
+			OlderThan      *string `json:"older_than"`
+			KeepPerSession *int    `json:"keep_per_session"`
+			Merged         *string `json:"merged"`
+		}
+		if err := json.Unmarshal(retentionRaw, &r); err != nil {
+			return fmt.Errorf("parsing retention field: %w", err)
+		}
+		if settings.Retention == nil {
+			settings.Retention = &RetentionSettings{}
+		}
+		if r.OlderThan != nil {
+			settings.Retention.OlderThan = *r.OlderThan
+		}
+		if r.KeepPerSession != nil {
+			settings.Retention.KeepPerSession = *r.KeepPerSession
+		}
+		if r.Merged != nil {
+			settings.Retention.Merged = *r.Merged
+		}
+	}
+
+	// Merge classifier per field if present
+	if classifierRaw, ok := raw["classifier"]; ok {
+		var c ClassifierSettings
+		if err := json.Unmarshal(classifierRaw, &c); err != nil {
+			return fmt.Errorf("parsing classifier field: %w", err)
+		}
+		if settings.Classifier == nil {
+			settings.Classifier = &ClassifierSettings{}
+		}
+		if c.Command != "" {
+			settings.Classifier.Command = c.Command
+		}
+	}
+
+	// Merge quota per field if present
+	if quotaRaw, ok := raw["quota"]; ok {
+		var q struct {
+			MaxSize *string `json:"max_size"`
+		}
+		if err := json.Unmarshal(quotaRaw, &q); err != nil {
+			return fmt.Errorf("parsing quota field: %w", err)
+		}
+		if settings.Quota == nil {
+			settings.Quota = &QuotaSettings{}
+		}
+		if q.MaxSize != nil {
+			settings.Quota.MaxSize = *q.MaxSize
+		}
+	}
+
+	// Merge session expiry per field if present
+	if expiryRaw, ok := raw["session_expiry"]; ok {
+		var e struct {
+			Enabled *bool   `json:"enabled"`
+			TTL     *string `json:"ttl"`
+		}
+		if err := json.Unmarshal(expiryRaw, &e); err != nil {
+			return fmt.Errorf("parsing session_expiry field: %w", err)
+		}
+		if settings.SessionExpiry == nil {
+			settings.SessionExpiry = &SessionExpirySettings{}
+		}
+		if e.Enabled != nil {
+			settings.SessionExpiry.Enabled = e.Enabled
+		}
+		if e.TTL != nil {
+			settings.SessionExpiry.TTL = *e.TTL
+		}
+	}
+
+	// Merge workspace if present; a repos list replaces the previous one
+	if workspaceRaw, ok := raw["workspace"]; ok {
+		var ws struct {
+			Repos []string `json:"repos"`
+		}
+		if err := json.Unmarshal(workspaceRaw, &ws); err != nil {
+			return fmt.Errorf("parsing workspace field: %w", err)
+		}
+		if settings.Workspace == nil {
+			settings.Workspace = &WorkspaceSettings{}
+		}
+		if ws.Repos != nil {
+			settings.Workspace.Repos = ws.Repos
+		}
+	}
+
+	// Merge submodules per field if present
+	if submodulesRaw, ok := raw["submodules"]; ok {
+		var sm struct {
+			Recurse *bool `json:"recurse"`
+		}
+		if err := json.Unmarshal(submodulesRaw, &sm); err != nil {
+			return fmt.Errorf("parsing submodules field: %w", err)
+		}
+		if settings.Submodules == nil {
+			settings.Submodules = &SubmoduleSettings{}
+		}
+		if sm.Recurse != nil {
+			settings.Submodules.Recurse = *sm.Recurse
+		}
+	}
+
+	// Merge repository backend per field if present
+	if backendRaw, ok := raw["repository_backend"]; ok {
+		var rb struct {
+			Kind          *string `json:"kind"`
+			MaxFiles      *int    `json:"max_files"`
+			LatencyBudget *string `json:"latency_budget"`
+		}
+		if err := json.Unmarshal(backendRaw, &rb); err != nil {
+			return fmt.Errorf("parsing repository_backend field: %w", err)
+		}
+		if settings.RepositoryBackend == nil {
+			settings.RepositoryBackend = &RepositoryBackendSettings{}
+		}
+		if rb.Kind != nil {
+			settings.RepositoryBackend.Kind = *rb.Kind
+		}
+		if rb.MaxFiles != nil {
+			settings.RepositoryBackend.MaxFiles = *rb.MaxFiles
+		}
+		if rb.LatencyBudget != nil {
+			settings.RepositoryBackend.LatencyBudget = *rb.LatencyBudget
+		}
+	}
+
+	// Merge push policy per field if present
+	if policyRaw, ok := raw["push_policy"]; ok {
+		var p struct {
+			RequireAttribution *bool    `json:"require_attribution"`
+			MaxAgentPercentage *float64 `json:"max_agent_percentage"`
+			ApprovalTrailer    *string  `json:"approval_trailer"`
+			Action             *string  `json:"action"`
+		}
+		if err := json.Unmarshal(policyRaw, &p); err != nil {
+			return fmt.Errorf("parsing push_policy field: %w", err)
+		}
+		if settings.PushPolicy == nil {
+			settings.PushPolicy = &PushPolicySettings{}
+		}
+		if p.RequireAttribution != nil {
+			settings.PushPolicy.RequireAttribution = *p.RequireAttribution
+		}
+		if p.MaxAgentPercentage != nil {
+			settings.PushPolicy.MaxAgentPercentage = *p.MaxAgentPercentage
+		}
+		if p.ApprovalTrailer != nil {
+			settings.PushPolicy.ApprovalTrailer = *p.ApprovalTrailer
+		}
+		if p.Action != nil {
+			settings.PushPolicy.Action = *p.Action
+		}
+	}
+
+	// Merge prompt policy per field if present
+	if policyRaw, ok := raw["prompt_policy"]; ok {
+		var p struct {
+			MaxDirtyLines         *int      `json:"max_dirty_lines"`
+			ProtectedBranches     *[]string `json:"protected_branches"`
+			ProtectedBranchAction *string   `json:"protected_branch_action"`
+			SessionBranchPrefix   *string   `json:"session_branch_prefix"`
+			AllowedPaths          *[]string `json:"allowed_paths"`
+		}
+		if err := json.Unmarshal(policyRaw, &p); err != nil {
+			return fmt.Errorf("parsing prompt_policy field: %w", err)
+		}
+		if settings.PromptPolicy == nil {
+			settings.PromptPolicy = &PromptPolicySettings{}
+		}
+		if p.MaxDirtyLines != nil {
+			settings.PromptPolicy.MaxDirtyLines = *p.MaxDirtyLines
+		}
+		if p.ProtectedBranches != nil {
+			settings.PromptPolicy.ProtectedBranches = *p.ProtectedBranches
+		}
+		if p.ProtectedBranchAction != nil {
+			settings.PromptPolicy.ProtectedBranchAction = *p.ProtectedBranchAction
+		}
+		if p.SessionBranchPrefix != nil {
+			settings.PromptPolicy.SessionBranchPrefix = *p.SessionBranchPrefix
+		}
+		if p.AllowedPaths != nil {
+			settings.PromptPolicy.AllowedPaths = *p.AllowedPaths
+		}
+	}
+
 	return nil
 }
 
@@ -213,6 +1647,14 @@ func applyDefaults(settings *EntireSettings) {
 	}
 }
 
+// EffectiveSyncRemote returns the remote `entire sync` uses.
+func (s *EntireSettings) EffectiveSyncRemote() string {
+	if s.SyncRemote == "" {
+		return DefaultSyncRemote
+	}
+	return s.SyncRemote
+}
+
 // IsSummarizeEnabled checks if auto-summarize is enabled in settings.
 // Returns false by default if settings cannot be loaded or the key is missing.
 func IsSummarizeEnabled() bool {
@@ -266,6 +1708,13 @@ func SaveLocal(settings *EntireSettings) error {
 }
 
 // saveToFile saves settings to the specified file path.
+//
+// settings is usually what Load returned, edited: the merge of the user,
+// project and local files. Writing it whole would copy the other scopes'
+// values into this file, so only what the caller changed relative to Load is
+// written over the file's own contents (nested objects field by field).
+// "strategy" and "enabled" are always written, as every settings file has
+// them. If the current settings can't be loaded, settings is written whole.
 func saveToFile(settings *EntireSettings, filePath string) error {
 	// Get absolute path for the file
 	filePathAbs, err := paths.AbsPath(filePath)
@@ -273,13 +1722,36 @@ func saveToFile(settings *EntireSettings, filePath string) error {
 		filePathAbs = filePath // Fallback to relative
 	}
 
+	after, err := settingsMap(settings)
+	if err != nil {
+		return fmt.Errorf("marshaling settings: %w", err)
+	}
+	var before map[string]any
+	if current, err := Load(); err == nil {
+		if before, err = settingsMap(current); err != nil {
+			return fmt.Errorf("marshaling settings: %w", err)
+		}
+	}
+	target := make(map[string]any)
+	if data, err := os.ReadFile(filePathAbs); err == nil { //nolint:gosec // path is from AbsPath or constant
+		if target, err = decodeMap(data); err != nil {
+			target = make(map[string]any) // Unreadable file: replace it
+		}
+	}
+	applyChanges(target, before, after)
+	for _, key := range []string{"strategy", "enabled"} {
+		if _, ok := target[key]; !ok {
+			target[key] = after[key]
+		}
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(filePathAbs)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
 		return fmt.Errorf("creating settings directory: %w", err)
 	}
 
-	data, err := jsonutil.MarshalIndentWithNewline(settings, "", "  ")
+	data, err := jsonutil.MarshalIndentWithNewline(target, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling settings: %w", err)
 	}
@@ -290,3 +1762,52 @@ func saveToFile(settings *EntireSettings, filePath string) error {
 	}
 	return nil
 }
+
+// settingsMap returns settings as a JSON object.
+func settingsMap(settings *EntireSettings) (map[string]any, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap
+	}
+	return decodeMap(data)
+}
+
+// decodeMap decodes a JSON object, keeping numbers as written.
+func decodeMap(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	m := make(map[string]any)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing settings: %w", err)
+	}
+	return m, nil
+}
+
+// applyChanges writes into target the keys whose values differ between
+// before and after, recursing into objects both have, and deletes the keys
+// after dropped. A nil before changes every key of after.
+func applyChanges(target, before, after map[string]any) {
+	for key, value := range after {
+		old, existed := before[key]
+		if existed && reflect.DeepEqual(old, value) {
+			continue
+		}
+		oldObject, oldIsObject := old.(map[string]any)
+		newObject, newIsObject := value.(map[string]any)
+		if oldIsObject && newIsObject {
+			targetObject, ok := target[key].(map[string]any)
+			if !ok {
+				targetObject = make(map[string]any)
+			}
+			applyChanges(targetObject, oldObject, newObject)
+			target[key] = targetObject
+			continue
+		}
+		target[key] = value
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			delete(target, key)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package settings
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_RejectsUnknownKeys(t *testing.T) {
@@ -135,8 +137,703 @@ func TestLoad_LocalSettingsRejectsUnknownKeys(t *testing.T) {
 	}
 }
 
+func TestLoad_MergesCommitMessageTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	entireDir := filepath.Join(tmpDir, ".entire")
+	if err := os.MkdirAll(entireDir, 0755); err != nil {
+		t.Fatalf("failed to create .entire directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git directory: %v", err)
+	}
+
+	settingsContent := `{"commit_messages": {"checkpoint": "{{.PromptSummary}}", "task": "Task: {{.TaskDescription}}", "suggest": true}}`
+	if err := os.WriteFile(filepath.Join(entireDir, "settings.json"), []byte(settingsContent), 0644); err != nil {
+		t.Fatalf("failed to write settings file: %v", err)
+	}
+	// Local settings override only the checkpoint template
+	localContent := `{"commit_messages": {"checkpoint": "wip: {{.PromptSummary}}", "suggestion": "{{.PromptSummary}}\n\nAgent: {{.Agent}}"}}`
+	if err := os.WriteFile(filepath.Join(entireDir, "settings.local.json"), []byte(localContent), 0644); err != nil {
+		t.Fatalf("failed to write local settings file: %v", err)
+	}
+
+	t.Chdir(tmpDir)
+
+	settings, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings.CommitMessages == nil {
+		t.Fatal("expected commit_messages to be loaded")
+	}
+	if settings.CommitMessages.Checkpoint != "wip: {{.PromptSummary}}" {
+		t.Errorf("checkpoint template = %q, want local override", settings.CommitMessages.Checkpoint)
+	}
+	if settings.CommitMessages.Task != "Task: {{.TaskDescription}}" {
+		t.Errorf("task template = %q, want project value", settings.CommitMessages.Task)
+	}
+	if !settings.CommitMessages.Suggest || settings.CommitMessages.Suggestion == "" {
+		t.Errorf("suggest = %v, suggestion = %q; want both merged", settings.CommitMessages.Suggest, settings.CommitMessages.Suggestion)
+	}
+}
+
+func TestReportingSettings(t *testing.T) {
+	var unset *ReportingSettings
+	if loc, err := unset.Location(); err != nil || loc != time.Local {
+		t.Errorf("nil Location() = %v, %v; want time.Local", loc, err)
+	}
+	if day, err := unset.FirstWeekday(); err != nil || day != time.Monday {
+		t.Errorf("nil FirstWeekday() = %v, %v; want Monday", day, err)
+	}
+
+	r := &ReportingSettings{Timezone: "America/New_York", WeekStart: "Sunday"}
+	if loc, err := r.Location(); err != nil || loc.String() != "America/New_York" {
+		t.Errorf("Location() = %v, %v; want America/New_York", loc, err)
+	}
+	if day, err := r.FirstWeekday(); err != nil || day != time.Sunday {
+		t.Errorf("FirstWeekday() = %v, %v; want Sunday", day, err)
+	}
+
+	bad := &ReportingSettings{Timezone: "Mars/Olympus", WeekStart: "friday"}
+	if _, err := bad.Location(); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+	if _, err := bad.FirstWeekday(); err == nil {
+		t.Error("expected error for unsupported week start")
+	}
+}
+
+func TestMergeJSON_DisabledHooks(t *testing.T) {
+	s := &EntireSettings{DisabledHooks: []string{"stop"}}
+	if err := mergeJSON(s, []byte(`{"disabled_hooks": ["post-commit", "pre-push"]}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if len(s.DisabledHooks) != 2 || s.DisabledHooks[0] != "post-commit" {
+		t.Errorf("DisabledHooks = %v, want the local list to replace the project one", s.DisabledHooks)
+	}
+	if err := mergeJSON(s, []byte(`{"disabled_hooks": []}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if len(s.DisabledHooks) != 0 {
+		t.Errorf("DisabledHooks = %v, want empty", s.DisabledHooks)
+	}
+}
+
+func TestMergeJSON_BotIdentities(t *testing.T) {
+	s := &EntireSettings{BotIdentities: []string{"release-bot"}}
+	if err := mergeJSON(s, []byte(`{"bot_identities": ["ci-agent@*"]}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if len(s.BotIdentities) != 1 || s.BotIdentities[0] != "ci-agent@*" {
+		t.Errorf("BotIdentities = %v, want the local list to replace the project one", s.BotIdentities)
+	}
+}
+
+func TestMergeJSON_StateDir(t *testing.T) {
+	s := &EntireSettings{StateDir: "/srv/entire"}
+	if err := mergeJSON(s, []byte(`{"state_dir": ""}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if s.StateDir != "/srv/entire" {
+		t.Errorf("StateDir = %q, want empty override to be ignored", s.StateDir)
+	}
+	if err := mergeJSON(s, []byte(`{"state_dir": "/tmp/entire"}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if s.StateDir != "/tmp/entire" {
+		t.Errorf("StateDir = %q, want /tmp/entire", s.StateDir)
+	}
+}
+
+func TestMergeJSON_RedactionAllowlist(t *testing.T) {
+	s := &EntireSettings{}
+	if err := mergeJSON(s, []byte(`{"redaction": {"allowlist": ["AKIA.*EXAMPLE"]}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"redaction": {}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if len(s.Redaction.Allowlist) != 1 || s.Redaction.Allowlist[0] != "AKIA.*EXAMPLE" {
+		t.Errorf("Allowlist = %v, want missing key to keep the previous list", s.Redaction.Allowlist)
+	}
+	if err := mergeJSON(s, []byte(`{"redaction": {"allowlist": []}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if len(s.Redaction.Allowlist) != 0 {
+		t.Errorf("Allowlist = %v, want empty list to clear it", s.Redaction.Allowlist)
+	}
+}
+
+func TestMergeJSON_Chunking(t *testing.T) {
+	s := &EntireSettings{}
+	if err := mergeJSON(s, []byte(`{"chunking": {"enabled": true, "min_file_size": 4096}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"chunking": {"enabled": false}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if s.Chunking.Enabled || s.Chunking.MinFileSize != 4096 {
+		t.Errorf("Chunking = %+v, want disabled with min_file_size kept", s.Chunking)
+	}
+	if got := s.Chunking.Threshold(); got != 0 {
+		t.Errorf("Threshold() = %d, want 0 when disabled", got)
+	}
+	if got := (&ChunkingSettings{Enabled: true}).Threshold(); got != DefaultChunkMinFileSize {
+		t.Errorf("Threshold() = %d, want the default when min_file_size is unset", got)
+	}
+}
+
+func TestMergeJSON_CommitNotes(t *testing.T) {
+	s := &EntireSettings{}
+	if err := mergeJSON(s, []byte(`{"commit_notes": true}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if !s.CommitNotes {
+		t.Error("CommitNotes = false, want true")
+	}
+	if err := mergeJSON(s, []byte(`{"commit_notes": false}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if s.CommitNotes {
+		t.Error("CommitNotes = true, want the local override to disable it")
+	}
+}
+
+func TestMergeJSON_SyncRemote(t *testing.T) {
+	s := &EntireSettings{}
+	if got := s.EffectiveSyncRemote(); got != DefaultSyncRemote {
+		t.Errorf("EffectiveSyncRemote() = %q, want %q", got, DefaultSyncRemote)
+	}
+	if err := mergeJSON(s, []byte(`{"sync_remote": "backup"}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"sync_remote": ""}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if got := s.EffectiveSyncRemote(); got != "backup" {
+		t.Errorf("EffectiveSyncRemote() = %q, want backup", got)
+	}
+}
+
+func TestAttributionSettings_EffectiveGranularity(t *testing.T) {
+	var unset *AttributionSettings
+	if g, err := unset.EffectiveGranularity(); err != nil || g != AttributionGranularityLine {
+		t.Errorf("nil EffectiveGranularity() = %q, %v; want line", g, err)
+	}
+	if g, err := (&AttributionSettings{Granularity: "Word"}).EffectiveGranularity(); err != nil || g != AttributionGranularityWord {
+		t.Errorf("EffectiveGranularity() = %q, %v; want word", g, err)
+	}
+	if _, err := (&AttributionSettings{Granularity: "token"}).EffectiveGranularity(); err == nil {
+		t.Error("expected error for unknown granularity")
+	}
+}
+
+func TestAttributionSettings_EffectiveMergeCommits(t *testing.T) {
+	s := &EntireSettings{}
+	if err := mergeJSON(s, []byte(`{"attribution": {"granularity": "word"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if m, err := s.Attribution.EffectiveMergeCommits(); err != nil || m != AttributionMergeFirstParent {
+		t.Errorf("EffectiveMergeCommits() = %q, %v; want first-parent", m, err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"merge_commits": "Skip"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if m, err := s.Attribution.EffectiveMergeCommits(); err != nil || m != AttributionMergeSkip {
+		t.Errorf("EffectiveMergeCommits() = %q, %v; want skip", m, err)
+	}
+	if s.Attribution.Granularity != "word" {
+		t.Errorf("Granularity = %q, want the earlier word setting kept", s.Attribution.Granularity)
+	}
+	if _, err := (&AttributionSettings{MergeCommits: "ours"}).EffectiveMergeCommits(); err == nil {
+		t.Error("expected error for unknown merge_commits mode")
+	}
+}
+
+func TestAttributionSettings_EffectiveCheckpoints(t *testing.T) {
+	s := &EntireSettings{}
+	if c, err := s.Attribution.EffectiveCheckpoints(); err != nil || c != AttributionCheckpointsLast {
+		t.Errorf("EffectiveCheckpoints() = %q, %v; want last", c, err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"merge_commits": "skip"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"checkpoints": "Union"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if c, err := s.Attribution.EffectiveCheckpoints(); err != nil || c != AttributionCheckpointsUnion {
+		t.Errorf("EffectiveCheckpoints() = %q, %v; want union", c, err)
+	}
+	if s.Attribution.MergeCommits != "skip" {
+		t.Errorf("MergeCommits = %q, want the earlier skip setting kept", s.Attribution.MergeCommits)
+	}
+	if _, err := (&AttributionSettings{Checkpoints: "all"}).EffectiveCheckpoints(); err == nil {
+		t.Error("expected error for unknown checkpoints mode")
+	}
+}
+
+func TestAttributionSettings_EffectiveNormalize(t *testing.T) {
+	s := &EntireSettings{}
+	if n, err := s.Attribution.EffectiveNormalize(); err != nil || n != AttributionNormalizeNone {
+		t.Errorf("EffectiveNormalize() = %q, %v; want none", n, err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"normalize": "Formatter", "formatters": {"*.go": "gofmt"}}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"granularity": "word"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if n, err := s.Attribution.EffectiveNormalize(); err != nil || n != AttributionNormalizeFormatter {
+		t.Errorf("EffectiveNormalize() = %q, %v; want formatter", n, err)
+	}
+	if s.Attribution.Formatters["*.go"] != "gofmt" {
+		t.Errorf("Formatters = %v, want the earlier gofmt setting kept", s.Attribution.Formatters)
+	}
+	for _, a := range []*AttributionSettings{
+		{Normalize: "gofmt"},
+		{Normalize: "formatter"},
+		{Normalize: "formatter", Formatters: map[string]string{"[": "gofmt"}},
+		{Normalize: "formatter", Formatters: map[string]string{"*.go": " "}},
+	} {
+		if _, err := a.EffectiveNormalize(); err == nil {
+			t.Errorf("EffectiveNormalize(%+v) = nil error, want invalid", a)
+		}
+	}
+}
+
+func TestAttributionSettings_EffectiveGenerated(t *testing.T) {
+	s := &EntireSettings{}
+	if g, err := s.Attribution.EffectiveGenerated(); err != nil || g != AttributionGeneratedExclude {
+		t.Errorf("EffectiveGenerated() = %q, %v; want exclude", g, err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"generated": "Include", "generated_files": ["mocks/"]}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"attribution": {"granularity": "word"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if g, err := s.Attribution.EffectiveGenerated(); err != nil || g != AttributionGeneratedInclude {
+		t.Errorf("EffectiveGenerated() = %q, %v; want include", g, err)
+	}
+	if len(s.Attribution.GeneratedFiles) != 1 {
+		t.Errorf("GeneratedFiles = %v, want the earlier patterns kept", s.Attribution.GeneratedFiles)
+	}
+	if _, err := (&AttributionSettings{Generated: "skip"}).EffectiveGenerated(); err == nil {
+		t.Error("expected error for unknown generated mode")
+	}
+}
+
+func TestSummarizerSettings_EffectiveProvider(t *testing.T) {
+	s := &EntireSettings{}
+	if p, err := s.Summarizer.EffectiveProvider(); err != nil || p != SummarizerClaudeCLI {
+		t.Errorf("EffectiveProvider() = %q, %v; want claude-cli", p, err)
+	}
+	if err := mergeJSON(s, []byte(`{"summarizer": {"provider": "OpenAI", "base_url": "http://localhost:11434/v1"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"summarizer": {"model": "llama3.1"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if p, err := s.Summarizer.EffectiveProvider(); err != nil || p != SummarizerOpenAI || s.Summarizer.Model != "llama3.1" || s.Summarizer.BaseURL == "" {
+		t.Errorf("EffectiveProvider() = %q, %v, settings %+v; want openai with both merged", p, err, s.Summarizer)
+	}
+	for _, sm := range []*SummarizerSettings{
+		{Provider: "gemini"},
+		{Provider: "command"},
+		{Provider: "anthropic", BaseURL: "localhost:8080"},
+	} {
+		if _, err := sm.EffectiveProvider(); err == nil {
+			t.Errorf("EffectiveProvider(%+v) = nil error, want invalid", sm)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"1048576": 1 << 20,
+		"500MB":   500 << 20,
+		"2gb":     2 << 30,
+		"1.5G":    3 << 29,
+		"64 KB":   64 << 10,
+	}
+	for in, want := range tests {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "big", "-1GB", "0"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Errorf("ParseByteSize(%q): expected error", in)
+		}
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := map[int64]string{
+		512:     "512 B",
+		2048:    "2.0 KB",
+		3 << 29: "1.5 GB",
+	}
+	for in, want := range tests {
+		if got := FormatByteSize(in); got != want {
+			t.Errorf("FormatByteSize(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMergeJSON_Quota(t *testing.T) {
+	s := &EntireSettings{}
+	if limit, err := s.Quota.Limit(); err != nil || limit != 0 {
+		t.Errorf("nil Limit() = %d, %v; want 0", limit, err)
+	}
+	if err := mergeJSON(s, []byte(`{"quota": {"max_size": "1GB"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if limit, err := s.Quota.Limit(); err != nil || limit != 1<<30 {
+		t.Errorf("Limit() = %d, %v; want %d", limit, err, 1<<30)
+	}
+	if _, err := (&QuotaSettings{MaxSize: "lots"}).Limit(); err == nil {
+		t.Error("expected error for invalid max_size")
+	}
+}
+
+func TestMergeJSON_SessionExpiry(t *testing.T) {
+	s := &EntireSettings{}
+	if ttl, err := s.SessionExpiry.EffectiveTTL(); err != nil || ttl != DefaultSessionExpiryTTL {
+		t.Errorf("nil EffectiveTTL() = %v, %v; want the default", ttl, err)
+	}
+	if err := mergeJSON(s, []byte(`{"session_expiry": {"ttl": "2d"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if ttl, err := s.SessionExpiry.EffectiveTTL(); err != nil || ttl != 48*time.Hour {
+		t.Errorf("EffectiveTTL() = %v, %v; want 48h", ttl, err)
+	}
+	if err := mergeJSON(s, []byte(`{"session_expiry": {"enabled": false}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if ttl, err := s.SessionExpiry.EffectiveTTL(); err != nil || ttl != 0 || s.SessionExpiry.TTL != "2d" {
+		t.Errorf("EffectiveTTL() = %v, %v with ttl %q; want 0 and the ttl kept", ttl, err, s.SessionExpiry.TTL)
+	}
+	if _, err := (&SessionExpirySettings{TTL: "soon"}).EffectiveTTL(); err == nil {
+		t.Error("expected error for invalid ttl")
+	}
+}
+
+func TestMergeJSON_Workspace(t *testing.T) {
+	s := &EntireSettings{}
+	if err := mergeJSON(s, []byte(`{"workspace": {"repos": ["../api", "/src/web", "../api", "."]}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	root := filepath.Join(string(filepath.Separator), "src", "app")
+	roots, err := s.Workspace.RepoRoots(root)
+	if err != nil {
+		t.Fatalf("RepoRoots() error = %v", err)
+	}
+	want := []string{filepath.Join(string(filepath.Separator), "src", "api"), filepath.Clean("/src/web")}
+	if len(roots) != len(want) || roots[0] != want[0] || roots[1] != want[1] {
+		t.Errorf("RepoRoots() = %v, want %v without duplicates or the repository itself", roots, want)
+	}
+	if _, err := (&WorkspaceSettings{Repos: []string{" "}}).RepoRoots(root); err == nil {
+		t.Error("expected error for an empty repo path")
+	}
+}
+
+func TestMergeJSON_RepositoryBackend(t *testing.T) {
+	s := &EntireSettings{}
+	if kind, maxFiles, budget, err := s.RepositoryBackend.Effective(); err != nil || kind != RepositoryBackendAuto ||
+		maxFiles != DefaultRepositoryBackendMaxFiles || budget != DefaultRepositoryBackendLatencyBudget {
+		t.Errorf("nil Effective() = %s, %d, %s, %v; want the defaults", kind, maxFiles, budget, err)
+	}
+	if err := mergeJSON(s, []byte(`{"repository_backend": {"kind": "git", "max_files": 1000}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"repository_backend": {"latency_budget": "250ms"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if kind, maxFiles, budget, err := s.RepositoryBackend.Effective(); err != nil || kind != RepositoryBackendGit ||
+		maxFiles != 1000 || budget != 250*time.Millisecond {
+		t.Errorf("Effective() = %s, %d, %s, %v; want git, 1000, 250ms", kind, maxFiles, budget, err)
+	}
+	for _, bad := range []RepositoryBackendSettings{{Kind: "libgit2"}, {MaxFiles: -1}, {LatencyBudget: "soon"}} {
+		if _, _, _, err := bad.Effective(); err == nil {
+			t.Errorf("Effective(%+v) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestMergeJSON_PromptPolicy(t *testing.T) {
+	s := &EntireSettings{}
+	if s.PromptPolicy.IsSet() {
+		t.Error("nil prompt policy IsSet() = true")
+	}
+	if err := mergeJSON(s, []byte(`{"prompt_policy": {"max_dirty_lines": 500, "protected_branches": ["main", "release/*"]}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if action, err := s.PromptPolicy.EffectiveProtectedBranchAction(); err != nil || action != ProtectedBranchBlock {
+		t.Errorf("EffectiveProtectedBranchAction() = %q, %v; want %q by default", action, err, ProtectedBranchBlock)
+	}
+	if err := mergeJSON(s, []byte(`{"prompt_policy": {"allowed_paths": ["services/api/", "./libs"], "protected_branch_action": "branch", "session_branch_prefix": "ai/"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	p := s.PromptPolicy
+	if action, err := p.EffectiveProtectedBranchAction(); err != nil || action != ProtectedBranchSwitch {
+		t.Errorf("EffectiveProtectedBranchAction() = %q, %v; want %q", action, err, ProtectedBranchSwitch)
+	}
+	if prefix, err := p.EffectiveSessionBranchPrefix(); err != nil || prefix != "ai/" {
+		t.Errorf("EffectiveSessionBranchPrefix() = %q, %v; want ai/", prefix, err)
+	}
+	if !p.IsSet() || p.MaxDirtyLines != 500 || len(p.ProtectedBranches) != 2 {
+		t.Errorf("PromptPolicy = %+v, want the first file's fields kept", p)
+	}
+	if allowed, err := p.EffectiveAllowedPaths(); err != nil || strings.Join(allowed, ",") != "services/api,libs" {
+		t.Errorf("EffectiveAllowedPaths() = %v, %v; want services/api,libs", allowed, err)
+	}
+	for branch, want := range map[string]string{"main": "main", "release/1.2": "release/*", "release/1/hotfix": "", "feature": ""} {
+		if got, err := p.ProtectedBranch(branch); err != nil || got != want {
+			t.Errorf("ProtectedBranch(%q) = %q, %v; want %q", branch, got, err, want)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, bad := range []PromptPolicySettings{
+		{MaxDirtyLines: -1},
+		{ProtectedBranches: []string{"release/["}},
+		{AllowedPaths: []string{"../other"}},
+		{AllowedPaths: []string{"/abs"}},
+		{AllowedPaths: []string{""}},
+		{ProtectedBranchAction: "warn"},
+		{SessionBranchPrefix: "entire/"},
+		{SessionBranchPrefix: "my agent/"},
+		{SessionBranchPrefix: "a..b/"},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestMergeJSON_SizeLimits(t *testing.T) {
+	s := &EntireSettings{}
+	if maxFile, maxCheckpoint, err := s.SizeLimits.Limits(); err != nil || maxFile != DefaultMaxFileSize || maxCheckpoint != DefaultMaxCheckpointSize {
+		t.Errorf("nil Limits() = %d, %d, %v; want the defaults", maxFile, maxCheckpoint, err)
+	}
+	if err := mergeJSON(s, []byte(`{"size_limits": {"max_file_size": "10MB"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"size_limits": {"max_checkpoint_size": "0"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if maxFile, maxCheckpoint, err := s.SizeLimits.Limits(); err != nil || maxFile != 10<<20 || maxCheckpoint != 0 {
+		t.Errorf("Limits() = %d, %d, %v; want %d, 0", maxFile, maxCheckpoint, err, 10<<20)
+	}
+	if _, _, err := (&SizeLimitsSettings{MaxFileSize: "huge"}).Limits(); err == nil {
+		t.Error("expected error for invalid max_file_size")
+	}
+}
+
+func TestMergeJSON_Encryption(t *testing.T) {
+	s := &EntireSettings{}
+	if s.Encryption.IsEnabled() {
+		t.Error("encryption should be off by default")
+	}
+	if err := mergeJSON(s, []byte(`{"encryption": {"enabled": true}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"encryption": {"key_source": "keychain"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if source, err := s.Encryption.EffectiveKeySource(); !s.Encryption.IsEnabled() || err != nil || source != EncryptionKeyKeychain {
+		t.Errorf("encryption = %+v (%v), want enabled with the keychain", s.Encryption, err)
+	}
+	if _, err := (&EncryptionSettings{KeySource: "vault"}).EffectiveKeySource(); err == nil {
+		t.Error("expected error for invalid key_source")
+	}
+}
+
+func TestMergeJSON_PushPolicy(t *testing.T) {
+	s := &EntireSettings{}
+	if s.PushPolicy.IsSet() || s.PushPolicy.EffectiveApprovalTrailer() != DefaultApprovalTrailer {
+		t.Errorf("nil push policy should check nothing and use the default trailer")
+	}
+	if err := mergeJSON(s, []byte(`{"push_policy": {"max_agent_percentage": 80, "action": "warn"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	if err := mergeJSON(s, []byte(`{"push_policy": {"require_attribution": true, "approval_trailer": "Reviewed-By"}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	p := s.PushPolicy
+	if !p.IsSet() || !p.RequireAttribution || p.MaxAgentPercentage != 80 || p.EffectiveApprovalTrailer() != "Reviewed-By" {
+		t.Errorf("PushPolicy = %+v, want both files merged", p)
+	}
+	if action, err := p.EffectiveAction(); err != nil || action != PushPolicyWarn {
+		t.Errorf("EffectiveAction() = %q, %v; want warn", action, err)
+	}
+	if _, err := (&PushPolicySettings{Action: "reject"}).EffectiveAction(); err == nil {
+		t.Error("expected error for invalid action")
+	}
+	if _, err := (&PushPolicySettings{MaxAgentPercentage: 120}).EffectiveAction(); err == nil {
+		t.Error("expected error for a threshold over 100")
+	}
+}
+
+func TestMergeJSON_OpenTelemetry(t *testing.T) {
+	s := &EntireSettings{}
+	if endpoint, err := s.OpenTelemetry.EffectiveEndpoint(); endpoint != "" || err != nil {
+		t.Errorf("EffectiveEndpoint() = %q, %v; want none by default", endpoint, err)
+	}
+	if err := mergeJSON(s, []byte(`{"opentelemetry": {"endpoint": "http://localhost:4318", "headers": {"x-team": "dev"}}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	// A local file adds a header without dropping the project's
+	if err := mergeJSON(s, []byte(`{"opentelemetry": {"headers": {"x-api-key": "secret"}}}`)); err != nil {
+		t.Fatalf("mergeJSON() error = %v", err)
+	}
+	o := s.OpenTelemetry
+	if endpoint, err := o.EffectiveEndpoint(); err != nil || endpoint != "http://localhost:4318" || o.Headers["x-team"] != "dev" || o.Headers["x-api-key"] != "secret" {
+		t.Errorf("OpenTelemetry = %+v (%v), want both files merged", o, err)
+	}
+	if _, err := (&OpenTelemetrySettings{Endpoint: "localhost:4318"}).EffectiveEndpoint(); err == nil {
+		t.Error("expected error for an endpoint without a scheme")
+	}
+}
+
 // containsUnknownField checks if the error message indicates an unknown field
 func containsUnknownField(msg string) bool {
 	// Go's json package reports unknown fields with this message format
 	return strings.Contains(msg, "unknown field")
 }
+
+func TestLoad_UserSettingsApplyBelowProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	userFile := UserSettingsFile()
+	if userFile != filepath.Join(configHome, "entire", "settings.json") {
+		t.Fatalf("UserSettingsFile() = %s", userFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(userFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	userContent := `{"log_level": "warn", "attribution": {"granularity": "word"}, "retention": {"older_than": "30d"}}`
+	if err := os.WriteFile(userFile, []byte(userContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".entire"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	projectContent := `{"strategy": "auto-commit", "attribution": {"merge_commits": "skip"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, EntireSettingsFile), []byte(projectContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	localContent := `{"log_level": "debug", "retention": {"keep_per_session": 2}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, EntireSettingsLocalFile), []byte(localContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(tmpDir)
+
+	s, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if s.Strategy != "auto-commit" || s.LogLevel != "debug" {
+		t.Errorf("strategy = %q, log_level = %q; want project strategy and local log level", s.Strategy, s.LogLevel)
+	}
+	if s.Attribution == nil || s.Attribution.Granularity != "word" || s.Attribution.MergeCommits != "skip" {
+		t.Errorf("attribution = %+v, want user and project fields merged", s.Attribution)
+	}
+	if s.Retention == nil || s.Retention.OlderThan != "30d" || s.Retention.KeepPerSession != 2 {
+		t.Errorf("retention = %+v, want user and local fields merged", s.Retention)
+	}
+}
+
+func TestLoad_UserSettingsRejectUnknownKeys(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.MkdirAll(filepath.Join(configHome, "entire"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(UserSettingsFile(), []byte(`{"strategi": "auto-commit"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "user settings") {
+		t.Errorf("Load() error = %v, want a user settings error", err)
+	}
+}
+
+func TestSave_WritesOnlyChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.MkdirAll(filepath.Join(configHome, "entire"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(UserSettingsFile(), []byte(`{"log_level": "debug", "sync_remote": "mine", "attribution": {"granularity": "word"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".entire"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	projectContent := `{"strategy": "manual-commit", "enabled": true, "attribution": {"merge_commits": "skip"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, EntireSettingsFile), []byte(projectContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, EntireSettingsLocalFile), []byte(`{"trace_hooks": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(tmpDir)
+
+	s, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	s.Attribution.Normalize = "whitespace"
+	s.DisabledHooks = []string{"stop"}
+	if err := Save(s); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	project, err := LoadFromFile(filepath.Join(tmpDir, EntireSettingsFile))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if project.LogLevel != "" || project.SyncRemote != "" || project.TraceHooks {
+		t.Errorf("project settings picked up other scopes: log_level %q, sync_remote %q, trace_hooks %v", project.LogLevel, project.SyncRemote, project.TraceHooks)
+	}
+	if project.Attribution == nil || project.Attribution.Granularity != "" || project.Attribution.MergeCommits != "skip" || project.Attribution.Normalize != "whitespace" {
+		t.Errorf("attribution = %+v, want the project's fields plus the change", project.Attribution)
+	}
+	if !slices.Equal(project.DisabledHooks, []string{"stop"}) {
+		t.Errorf("disabled_hooks = %v, want [stop]", project.DisabledHooks)
+	}
+
+	// A new file gets the changes and the fields every settings file has
+	if err := os.Remove(filepath.Join(tmpDir, EntireSettingsLocalFile)); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	s.Enabled = false
+	if err := SaveLocal(s); err != nil {
+		t.Fatalf("SaveLocal() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, EntireSettingsLocalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); strings.Contains(got, "log_level") || !strings.Contains(got, `"enabled": false`) || !strings.Contains(got, `"strategy"`) {
+		t.Errorf("local settings = %s, want only strategy and enabled", got)
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/session"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
@@ -23,6 +27,7 @@ import (
 const (
 	strategyDisplayManualCommit = "manual-commit"
 	strategyDisplayAutoCommit   = "auto-commit"
+	strategyDisplayStacked      = "stacked"
 )
 
 // Config path display strings
@@ -35,12 +40,14 @@ const (
 var strategyDisplayToInternal = map[string]string{
 	strategyDisplayManualCommit: strategy.StrategyNameManualCommit,
 	strategyDisplayAutoCommit:   strategy.StrategyNameAutoCommit,
+	strategyDisplayStacked:      strategy.StrategyNameStacked,
 }
 
 // strategyInternalToDisplay maps internal strategy names to user-friendly names
 var strategyInternalToDisplay = map[string]string{
 	strategy.StrategyNameManualCommit: strategyDisplayManualCommit,
 	strategy.StrategyNameAutoCommit:   strategyDisplayAutoCommit,
+	strategy.StrategyNameStacked:      strategyDisplayStacked,
 }
 
 func newEnableCmd() *cobra.Command {
@@ -105,13 +112,13 @@ Strategies: manual-commit (default), auto-commit`,
 	cmd.Flags().BoolVar(&useLocalSettings, "local", false, "Write settings to settings.local.json instead of settings.json")
 	cmd.Flags().BoolVar(&useProjectSettings, "project", false, "Write settings to settings.json even if it already exists")
 	cmd.Flags().StringVar(&agentName, "agent", "", "Agent to setup hooks for (e.g., claude-code). Enables non-interactive mode.")
-	cmd.Flags().StringVar(&strategyFlag, "strategy", "", "Strategy to use (manual-commit or auto-commit)")
+	cmd.Flags().StringVar(&strategyFlag, "strategy", "", "Strategy to use (manual-commit, auto-commit or stacked)")
 	cmd.Flags().BoolVarP(&forceHooks, "force", "f", false, "Force reinstall hooks (removes existing Entire hooks first)")
 	cmd.Flags().BoolVar(&skipPushSessions, "skip-push-sessions", false, "Disable automatic pushing of session logs on git push")
-	cmd.Flags().BoolVar(&telemetry, "telemetry", true, "Enable anonymous usage analytics")
+	cmd.Flags().BoolVar(&telemetry, "telemetry", true, "Ask about anonymous usage analytics (--telemetry=false opts out without asking)")
 	//nolint:errcheck,gosec // completion is optional, flag is defined above
 	cmd.RegisterFlagCompletionFunc("strategy", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
-		return []string{strategyDisplayManualCommit, strategyDisplayAutoCommit}, cobra.ShellCompDirectiveNoFileComp
+		return []string{strategyDisplayManualCommit, strategyDisplayAutoCommit, strategyDisplayStacked}, cobra.ShellCompDirectiveNoFileComp
 	})
 
 	// Provide a helpful error when --agent is used without a value
@@ -144,15 +151,16 @@ func newDisableCmd() *cobra.Command {
 By default, this command will disable Entire. Hooks will exit silently and commands will
 show a disabled message.
 
-To completely remove Entire integrations from this repository, use --uninstall:
+To completely remove Entire integrations from this repository, use --uninstall
+(or 'entire uninstall', which can also keep the data with --keep-data):
   - .entire/ directory (settings, logs, metadata)
-  - Git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push)
+  - Git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push, post-rewrite, post-checkout)
   - Session state files (.git/entire-sessions/)
   - Shadow branches (entire/<hash>)
   - Agent hooks (Claude Code, Gemini CLI)`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			if uninstall {
-				return runUninstall(cmd.OutOrStdout(), cmd.ErrOrStderr(), force)
+				return runUninstall(cmd.OutOrStdout(), cmd.ErrOrStderr(), force, false)
 			}
 			return runDisable(cmd.OutOrStdout(), useProjectSettings)
 		},
@@ -165,6 +173,40 @@ To completely remove Entire integrations from this repository, use --uninstall:
 	return cmd
 }
 
+func newUninstallCmd() *cobra.Command {
+	var force bool
+	var keepData bool
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove Entire from the current repository",
+		Long: `Remove everything Entire set up in this repository, so trying it is reversible:
+
+  - Agent hook registrations (Claude Code, Gemini CLI settings files)
+  - Git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push, post-rewrite, post-checkout)
+  - Shadow branches (entire/<hash>)
+  - State directories (.git/entire-sessions/, caches, hook traces)
+  - .entire/ directory (settings, logs, metadata)
+
+The entire/checkpoints/v1 branch is kept: it holds the history of committed
+sessions, and may already have been pushed.
+
+With --keep-data nothing is deleted: shadow branches are moved to
+refs/entire/archive/, and the state directories and .entire/ to
+.git/entire-uninstalled/<time>/, so a later 'entire enable' can pick the data
+up again by moving it back.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runUninstall(cmd.OutOrStdout(), cmd.ErrOrStderr(), force, keepData)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&keepData, "keep-data", false, "Archive shadow branches and state instead of deleting them")
+
+	return cmd
+}
+
 // isFullyEnabled checks whether Entire is already fully set up.
 // Returns whether it's fully enabled, and if so, the agent type display name and config file path.
 func isFullyEnabled() (enabled bool, agentDesc string, configPath string) {
@@ -211,8 +253,8 @@ func isFullyEnabled() (enabled bool, agentDesc string, configPath string) {
 }
 
 // runEnableWithStrategy enables Entire with a specified strategy (non-interactive).
-// The selectedStrategy can be either a display name (manual-commit, auto-commit)
-// or an internal name (manual-commit, auto-commit).
+// The selectedStrategy can be either a display name (manual-commit, auto-commit, stacked)
+// or an internal name (manual-commit, auto-commit, stacked).
 func runEnableWithStrategy(w io.Writer, selectedStrategy string, localDev, _, useLocalSettings, useProjectSettings, forceHooks, skipPushSessions, telemetry bool) error {
 	// Map the strategy to internal name if it's a display name
 	internalStrategy := selectedStrategy
@@ -223,7 +265,7 @@ func runEnableWithStrategy(w io.Writer, selectedStrategy string, localDev, _, us
 	// Validate the strategy exists
 	strat, err := strategy.Get(internalStrategy)
 	if err != nil {
-		return fmt.Errorf("unknown strategy: %s (use manual-commit or auto-commit)", selectedStrategy)
+		return fmt.Errorf("unknown strategy: %s (use manual-commit, auto-commit or stacked)", selectedStrategy)
 	}
 
 	// Detect default agent
@@ -539,18 +581,24 @@ func printWrongAgentError(w io.Writer, name string) {
 // If strategyName is provided, it sets the strategy; otherwise uses default.
 func setupAgentHooksNonInteractive(w io.Writer, ag agent.Agent, strategyName string, localDev, forceHooks, skipPushSessions, telemetry bool) error {
 	agentName := ag.Name()
-	// Check if agent supports hooks
+	// Check if agent supports hooks. Agents without hooks that are watched
+	// through their session files (Aider) only need the git hooks.
 	hookAgent, ok := ag.(agent.HookSupport)
-	if !ok {
+	_, watched := ag.(agent.FileWatcher)
+	if !ok && !watched {
 		return fmt.Errorf("agent %s does not support hooks", agentName)
 	}
 
 	fmt.Fprintf(w, "Agent: %s\n\n", ag.Type())
 
 	// Install agent hooks (agent hooks don't depend on settings)
-	installedHooks, err := hookAgent.InstallHooks(localDev, forceHooks)
-	if err != nil {
-		return fmt.Errorf("failed to install hooks for %s: %w", agentName, err)
+	var installedHooks int
+	if ok {
+		var err error
+		installedHooks, err = hookAgent.InstallHooks(localDev, forceHooks)
+		if err != nil {
+			return fmt.Errorf("failed to install hooks for %s: %w", agentName, err)
+		}
 	}
 
 	// Setup .entire directory
@@ -586,7 +634,7 @@ func setupAgentHooksNonInteractive(w io.Writer, ag agent.Agent, strategyName str
 		}
 		// Validate the strategy exists
 		if _, err := strategy.Get(internalStrategy); err != nil {
-			return fmt.Errorf("unknown strategy: %s (use manual-commit or auto-commit)", strategyName)
+			return fmt.Errorf("unknown strategy: %s (use manual-commit, auto-commit or stacked)", strategyName)
 		}
 		settings.Strategy = internalStrategy
 	}
@@ -607,13 +655,16 @@ func setupAgentHooksNonInteractive(w io.Writer, ag agent.Agent, strategyName str
 		return fmt.Errorf("failed to install git hooks: %w", err)
 	}
 
-	if installedHooks == 0 {
+	switch {
+	case !ok:
+		fmt.Fprintf(w, "%s has no agent hooks; its commits are captured by the git hooks\n", ag.Description())
+	case installedHooks == 0:
 		msg := fmt.Sprintf("Hooks for %s already installed", ag.Description())
 		if agentName == agent.AgentNameGemini {
 			msg += " (Preview)"
 		}
 		fmt.Fprintf(w, "%s\n", msg)
-	} else {
+	default:
 		msg := fmt.Sprintf("Installed %d hooks for %s", installedHooks, ag.Description())
 		if agentName == agent.AgentNameGemini {
 			msg += " (Preview)"
@@ -775,18 +826,42 @@ func shellCompletionTarget() (shellName, rcFile, completionLine string, err erro
 			filepath.Join(home, ".config", "fish", "config.fish"),
 			"entire completion fish | source",
 			nil
+	case strings.Contains(shell, "pwsh") || (shell == "" && runtime.GOOS == "windows"):
+		// Windows doesn't set SHELL; Git Bash sets it to bash
+		return "PowerShell",
+			powerShellProfile(home, runtime.GOOS),
+			"entire completion powershell | Out-String | Invoke-Expression",
+			nil
 	default:
 		return "", "", "", errUnsupportedShell
 	}
 }
 
+// powerShellProfile returns the current user's PowerShell profile: in
+// Documents\PowerShell on Windows (Documents\WindowsPowerShell when only
+// Windows PowerShell 5 has one), ~/.config/powershell elsewhere.
+func powerShellProfile(home, goos string) string {
+	const name = "Microsoft.PowerShell_profile.ps1"
+	if goos != "windows" {
+		return filepath.Join(home, ".config", "powershell", name)
+	}
+	profile := filepath.Join(home, "Documents", "PowerShell", name)
+	legacy := filepath.Join(home, "Documents", "WindowsPowerShell", name)
+	if _, err := os.Stat(profile); err != nil {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return profile
+}
+
 // promptShellCompletion offers to add shell completion to the user's rc file.
 // Only prompts if completion is not already configured.
 func promptShellCompletion(w io.Writer) error {
 	shellName, rcFile, completionLine, err := shellCompletionTarget()
 	if err != nil {
 		if errors.Is(err, errUnsupportedShell) {
-			fmt.Fprintf(w, "Note: Shell completion not available for your shell. Supported: zsh, bash, fish.\n")
+			fmt.Fprintf(w, "Note: Shell completion not available for your shell. Supported: zsh, bash, fish, PowerShell.\n")
 			return nil
 		}
 		return fmt.Errorf("shell completion: %w", err)
@@ -869,40 +944,43 @@ func promptTelemetryConsent(settings *EntireSettings, telemetryFlag bool) error
 		return nil
 	}
 
-	// Skip if already asked
+	// Skip if already asked, here or in another settings file
 	if settings.Telemetry != nil {
 		return nil
 	}
+	if consent, _ := telemetryConsent(); consent != nil {
+		return nil
+	}
 
 	// Skip if env var disables telemetry (record as disabled)
-	if os.Getenv("ENTIRE_TELEMETRY_OPTOUT") != "" {
+	if os.Getenv(telemetryOptOutEnv) != "" {
 		f := false
 		settings.Telemetry = &f
 		return nil
 	}
 
-	consent := true // Default to Yes
-	form := NewAccessibleForm(
-		huh.NewGroup(
-			huh.NewConfirm().
-				Title("Help improve Entire CLI?").
-				Description("Share anonymous usage data. No code or personal info collected.").
-				Affirmative("Yes").
-				Negative("No").
-				Value(&consent),
-		),
-	)
-
-	if err := form.Run(); err != nil {
-		return fmt.Errorf("telemetry prompt: %w", err)
+	consent, err := askTelemetryConsent()
+	if err != nil {
+		return err
 	}
 
-	settings.Telemetry = &consent
+	// Consent is personal, so it goes in the user settings file rather than
+	// the repository's. Without a home directory it stays with the repository.
+	if _, err := saveTelemetryConsent(consent); err != nil {
+		settings.Telemetry = &consent
+	}
 	return nil
 }
 
-// runUninstall completely removes Entire from the repository.
-func runUninstall(w, errW io.Writer, force bool) error {
+// uninstallArchiveDirName is the state directory `entire uninstall
+// --keep-data` moves the repository's Entire data to, one subdirectory per
+// uninstall.
+const uninstallArchiveDirName = "entire-uninstalled"
+
+// runUninstall completely removes Entire from the repository. With keepData,
+// shadow branches are moved to strategy.ArchivedShadowRefPrefix and the state
+// directories and .entire/ to the uninstall archive instead of being deleted.
+func runUninstall(w, errW io.Writer, force, keepData bool) error {
 	// Check if we're in a git repository
 	if _, err := paths.RepoRoot(); err != nil {
 		fmt.Fprintln(errW, "Not a git repository. Nothing to uninstall.")
@@ -913,13 +991,20 @@ func runUninstall(w, errW io.Writer, force bool) error {
 	sessionStateCount := countSessionStates()
 	shadowBranchCount := countShadowBranches()
 	gitHooksInstalled := strategy.IsGitHookInstalled()
-	claudeHooksInstalled := checkClaudeCodeHooksInstalled()
-	geminiHooksInstalled := checkGeminiCLIHooksInstalled()
+	agentsWithHooks := GetAgentsWithHooksInstalled()
 	entireDirExists := checkEntireDirExists()
+	stateEntries, err := strategy.ListStateEntries(uninstallArchiveDirName)
+	if err != nil {
+		fmt.Fprintf(errW, "Warning: failed to list state directories: %v\n", err)
+	}
+	// Session states are counted and removed on their own
+	otherStateEntries := slices.DeleteFunc(slices.Clone(stateEntries), func(entry string) bool {
+		return filepath.Base(entry) == session.SessionStateDirName
+	})
 
 	// Check if there's anything to uninstall
 	if !entireDirExists && !gitHooksInstalled && sessionStateCount == 0 &&
-		shadowBranchCount == 0 && !claudeHooksInstalled && !geminiHooksInstalled {
+		shadowBranchCount == 0 && len(agentsWithHooks) == 0 && len(otherStateEntries) == 0 {
 		fmt.Fprintln(w, "Entire is not installed in this repository.")
 		return nil
 	}
@@ -931,21 +1016,22 @@ func runUninstall(w, errW io.Writer, force bool) error {
 			fmt.Fprintln(w, "  - .entire/ directory")
 		}
 		if gitHooksInstalled {
-			fmt.Fprintln(w, "  - Git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push)")
+			fmt.Fprintln(w, "  - Git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push, post-rewrite, post-checkout)")
 		}
 		if sessionStateCount > 0 {
 			fmt.Fprintf(w, "  - Session state files (%d)\n", sessionStateCount)
 		}
+		if len(otherStateEntries) > 0 {
+			fmt.Fprintf(w, "  - State directories (%d)\n", len(otherStateEntries))
+		}
 		if shadowBranchCount > 0 {
 			fmt.Fprintf(w, "  - Shadow branches (%d)\n", shadowBranchCount)
 		}
-		switch {
-		case claudeHooksInstalled && geminiHooksInstalled:
-			fmt.Fprintln(w, "  - Agent hooks (Claude Code, Gemini CLI)")
-		case claudeHooksInstalled:
-			fmt.Fprintln(w, "  - Agent hooks (Claude Code)")
-		case geminiHooksInstalled:
-			fmt.Fprintln(w, "  - Agent hooks (Gemini CLI)")
+		if len(agentsWithHooks) > 0 {
+			fmt.Fprintf(w, "  - Agent hooks (%s)\n", strings.Join(agentTypes(agentsWithHooks), ", "))
+		}
+		if keepData {
+			fmt.Fprintln(w, "\nShadow branches, state and .entire/ are archived, not deleted.")
 		}
 		fmt.Fprintln(w)
 
@@ -985,6 +1071,16 @@ func runUninstall(w, errW io.Writer, force bool) error {
 		fmt.Fprintf(w, "  Removed git hooks (%d)\n", removed)
 	}
 
+	if keepData {
+		archiveDir, err := archiveEntireData(w, errW, stateEntries, entireDirExists)
+		if err != nil {
+			fmt.Fprintf(errW, "Warning: failed to archive Entire data: %v\n", err)
+		} else {
+			fmt.Fprintf(w, "\nEntire CLI uninstalled. Its data is kept in %s and under %s.\n", archiveDir, strategy.ArchivedShadowRefPrefix)
+		}
+		return nil
+	}
+
 	// 3. Remove session state files
 	statesRemoved, err := removeAllSessionStates()
 	if err != nil {
@@ -993,14 +1089,27 @@ func runUninstall(w, errW io.Writer, force bool) error {
 		fmt.Fprintf(w, "  Removed session states (%d)\n", statesRemoved)
 	}
 
-	// 4. Remove .entire/ directory
+	// 4. Remove the other state directories (caches, traces, markers)
+	var entriesRemoved int
+	for _, entry := range otherStateEntries {
+		if err := os.RemoveAll(entry); err != nil {
+			fmt.Fprintf(errW, "Warning: failed to remove %s: %v\n", entry, err)
+			continue
+		}
+		entriesRemoved++
+	}
+	if entriesRemoved > 0 {
+		fmt.Fprintf(w, "  Removed state directories (%d)\n", entriesRemoved)
+	}
+
+	// 5. Remove .entire/ directory
 	if err := removeEntireDirectory(); err != nil {
 		fmt.Fprintf(errW, "Warning: failed to remove .entire directory: %v\n", err)
 	} else if entireDirExists {
 		fmt.Fprintln(w, "  Removed .entire directory")
 	}
 
-	// 5. Remove shadow branches
+	// 6. Remove shadow branches
 	branchesRemoved, err := removeAllShadowBranches()
 	if err != nil {
 		fmt.Fprintf(errW, "Warning: failed to remove shadow branches: %v\n", err)
@@ -1012,6 +1121,73 @@ func runUninstall(w, errW io.Writer, force bool) error {
 	return nil
 }
 
+// archiveEntireData moves the state entries, the .entire directory (if it
+// exists) and the shadow branches out of the way for `entire uninstall
+// --keep-data`, and returns the archive directory.
+func archiveEntireData(w, errW io.Writer, stateEntries []string, entireDirExists bool) (string, error) {
+	commonDir, err := strategy.GetGitCommonDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git common dir: %w", err)
+	}
+	archiveDir := filepath.Join(fsenv.StateDir(commonDir, uninstallArchiveDirName), time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(archiveDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	var moved int
+	for _, entry := range stateEntries {
+		if err := os.Rename(entry, filepath.Join(archiveDir, filepath.Base(entry))); err != nil {
+			fmt.Fprintf(errW, "Warning: failed to archive %s: %v\n", entry, err)
+			continue
+		}
+		moved++
+	}
+	if moved > 0 {
+		fmt.Fprintf(w, "  Archived state directories (%d)\n", moved)
+	}
+
+	if entireDirExists {
+		entireDirAbs, err := paths.AbsPath(paths.EntireDir)
+		if err != nil {
+			entireDirAbs = paths.EntireDir
+		}
+		if err := os.Rename(entireDirAbs, filepath.Join(archiveDir, paths.EntireDir)); err != nil {
+			fmt.Fprintf(errW, "Warning: failed to archive .entire directory: %v\n", err)
+		} else {
+			fmt.Fprintln(w, "  Archived .entire directory")
+		}
+	}
+
+	branches, err := strategy.ListShadowBranches()
+	if err != nil {
+		return archiveDir, fmt.Errorf("failed to list shadow branches: %w", err)
+	}
+	archived, failed, err := strategy.ArchiveShadowBranches(branches)
+	if err != nil {
+		return archiveDir, fmt.Errorf("failed to archive shadow branches: %w", err)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(errW, "Warning: failed to archive shadow branches: %s\n", strings.Join(failed, ", "))
+	}
+	if len(archived) > 0 {
+		fmt.Fprintf(w, "  Archived %d shadow branches\n", len(archived))
+	}
+	return archiveDir, nil
+}
+
+// agentTypes returns the display names of agents.
+func agentTypes(names []agent.AgentName) []string {
+	types := make([]string, 0, len(names))
+	for _, name := range names {
+		if ag, err := agent.Get(name); err == nil {
+			types = append(types, string(ag.Type()))
+		} else {
+			types = append(types, string(name))
+		}
+	}
+	return types
+}
+
 // countSessionStates returns the number of active session state files.
 func countSessionStates() int {
 	store, err := session.NewStateStore()
@@ -1034,32 +1210,6 @@ func countShadowBranches() int {
 	return len(branches)
 }
 
-// checkClaudeCodeHooksInstalled checks if Claude Code hooks are installed.
-func checkClaudeCodeHooksInstalled() bool {
-	ag, err := agent.Get(agent.AgentNameClaudeCode)
-	if err != nil {
-		return false
-	}
-	hookAgent, ok := ag.(agent.HookSupport)
-	if !ok {
-		return false
-	}
-	return hookAgent.AreHooksInstalled()
-}
-
-// checkGeminiCLIHooksInstalled checks if Gemini CLI hooks are installed.
-func checkGeminiCLIHooksInstalled() bool {
-	ag, err := agent.Get(agent.AgentNameGemini)
-	if err != nil {
-		return false
-	}
-	hookAgent, ok := ag.(agent.HookSupport)
-	if !ok {
-		return false
-	}
-	return hookAgent.AreHooksInstalled()
-}
-
 // checkEntireDirExists checks if the .entire directory exists.
 func checkEntireDirExists() bool {
 	entireDirAbs, err := paths.AbsPath(paths.EntireDir)
@@ -1073,33 +1223,22 @@ func checkEntireDirExists() bool {
 // removeAgentHooks removes hooks from all agents that support hooks.
 func removeAgentHooks(w io.Writer) error {
 	var errs []error
-
-	// Remove Claude Code hooks
-	claudeAgent, err := agent.Get(agent.AgentNameClaudeCode)
-	if err == nil {
-		if hookAgent, ok := claudeAgent.(agent.HookSupport); ok {
-			wasInstalled := hookAgent.AreHooksInstalled()
-			if err := hookAgent.UninstallHooks(); err != nil {
-				errs = append(errs, err)
-			} else if wasInstalled {
-				fmt.Fprintln(w, "  Removed Claude Code hooks")
-			}
+	for _, name := range agent.List() {
+		ag, err := agent.Get(name)
+		if err != nil {
+			continue
 		}
-	}
-
-	// Remove Gemini CLI hooks
-	geminiAgent, err := agent.Get(agent.AgentNameGemini)
-	if err == nil {
-		if hookAgent, ok := geminiAgent.(agent.HookSupport); ok {
-			wasInstalled := hookAgent.AreHooksInstalled()
-			if err := hookAgent.UninstallHooks(); err != nil {
-				errs = append(errs, err)
-			} else if wasInstalled {
-				fmt.Fprintln(w, "  Removed Gemini CLI hooks")
-			}
+		hookAgent, ok := ag.(agent.HookSupport)
+		if !ok {
+			continue
+		}
+		wasInstalled := hookAgent.AreHooksInstalled()
+		if err := hookAgent.UninstallHooks(); err != nil {
+			errs = append(errs, err)
+		} else if wasInstalled {
+			fmt.Fprintf(w, "  Removed %s hooks\n", ag.Type())
 		}
 	}
-
 	return errors.Join(errs...)
 }
 
@@ -14,6 +14,8 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // Note: Tests for hook manipulation functions (addHookToMatcher, hookCommandExists, etc.)
@@ -434,7 +436,7 @@ func TestRunUninstall_Force_NothingInstalled(t *testing.T) {
 	setupTestRepo(t)
 
 	var stdout, stderr bytes.Buffer
-	err := runUninstall(&stdout, &stderr, true)
+	err := runUninstall(&stdout, &stderr, true, false)
 	if err != nil {
 		t.Fatalf("runUninstall() error = %v", err)
 	}
@@ -458,7 +460,7 @@ func TestRunUninstall_Force_RemovesEntireDirectory(t *testing.T) {
 	}
 
 	var stdout, stderr bytes.Buffer
-	err := runUninstall(&stdout, &stderr, true)
+	err := runUninstall(&stdout, &stderr, true, false)
 	if err != nil {
 		t.Fatalf("runUninstall() error = %v", err)
 	}
@@ -491,7 +493,7 @@ func TestRunUninstall_Force_RemovesGitHooks(t *testing.T) {
 	}
 
 	var stdout, stderr bytes.Buffer
-	err := runUninstall(&stdout, &stderr, true)
+	err := runUninstall(&stdout, &stderr, true, false)
 	if err != nil {
 		t.Fatalf("runUninstall() error = %v", err)
 	}
@@ -507,6 +509,112 @@ func TestRunUninstall_Force_RemovesGitHooks(t *testing.T) {
 	}
 }
 
+func TestRunUninstall_Force_RemovesStateAndAgentHooks(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, testSettingsEnabled)
+	if _, err := setupClaudeCodeHook(false, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{".git/entire-tree-cache/abc", ".git/entire-slow-go-git"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := runUninstall(&stdout, &stderr, true, false); err != nil {
+		t.Fatalf("runUninstall() error = %v", err)
+	}
+	for _, path := range []string{".git/entire-tree-cache", ".git/entire-slow-go-git"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed after uninstall", path)
+		}
+	}
+	if len(GetAgentsWithHooksInstalled()) != 0 {
+		t.Errorf("agent hooks still installed: %v", GetAgentsWithHooksInstalled())
+	}
+	output := stdout.String()
+	for _, want := range []string{"Removed Claude Code hooks", "Removed state directories (2)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q: %s", want, output)
+		}
+	}
+}
+
+func TestRunUninstall_KeepData(t *testing.T) {
+	setupTestRepo(t)
+	writeSettings(t, testSettingsEnabled)
+	repo, err := git.PlainOpen(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := wt.Commit("initial", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "Test", Email: "test@test.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shadow := "entire/abcdef1-123456"
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(shadow), head)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(".git/entire-sessions", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".git/entire-sessions/s1.json", []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := runUninstall(&stdout, &stderr, true, true); err != nil {
+		t.Fatalf("runUninstall() error = %v", err)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("stderr = %s", stderr.String())
+	}
+
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(shadow), true); err == nil {
+		t.Error("shadow branch should be moved after uninstall --keep-data")
+	}
+	archived, err := repo.Reference(plumbing.ReferenceName(strategy.ArchivedShadowRefPrefix+shadow), true)
+	if err != nil || archived.Hash() != head {
+		t.Errorf("archived shadow ref = %v, %v; want %s", archived, err, head)
+	}
+
+	archives, err := os.ReadDir(filepath.Join(".git", uninstallArchiveDirName))
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("archive directory = %v, %v; want one uninstall", archives, err)
+	}
+	archiveDir := filepath.Join(".git", uninstallArchiveDirName, archives[0].Name())
+	for _, path := range []string{"entire-sessions/s1.json", ".entire/settings.json"} {
+		if _, err := os.Stat(filepath.Join(archiveDir, filepath.FromSlash(path))); err != nil {
+			t.Errorf("%s not archived: %v", path, err)
+		}
+	}
+	for _, path := range []string{".git/entire-sessions", paths.EntireDir} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be moved after uninstall --keep-data", path)
+		}
+	}
+
+	// The archive survives a later uninstall
+	stdout.Reset()
+	if err := runUninstall(&stdout, &stderr, true, false); err != nil {
+		t.Fatalf("second runUninstall() error = %v", err)
+	}
+	if _, err := os.Stat(archiveDir); err != nil {
+		t.Errorf("archive removed by a later uninstall: %v", err)
+	}
+}
+
 func TestRunUninstall_NotAGitRepo(t *testing.T) {
 	// Create a temp directory without git init
 	tmpDir := t.TempDir()
@@ -514,7 +622,7 @@ func TestRunUninstall_NotAGitRepo(t *testing.T) {
 	paths.ClearRepoRootCache()
 
 	var stdout, stderr bytes.Buffer
-	err := runUninstall(&stdout, &stderr, true)
+	err := runUninstall(&stdout, &stderr, true, false)
 
 	// Should return an error (silent error)
 	if err == nil {
@@ -649,6 +757,13 @@ func TestShellCompletionTarget(t *testing.T) {
 			wantRCBase:     filepath.Join(".config", "fish", "config.fish"),
 			wantCompletion: "entire completion fish | source",
 		},
+		{
+			name:           "pwsh",
+			shell:          "/usr/local/bin/pwsh",
+			wantShell:      "PowerShell",
+			wantRCBase:     filepath.Join(".config", "powershell", "Microsoft.PowerShell_profile.ps1"),
+			wantCompletion: "entire completion powershell | Out-String | Invoke-Expression",
+		},
 		{
 			name:             "empty_shell",
 			shell:            "",
@@ -693,6 +808,26 @@ func TestShellCompletionTarget(t *testing.T) {
 	}
 }
 
+func TestPowerShellProfile(t *testing.T) {
+	home := t.TempDir()
+	profile := filepath.Join(home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+	if got := powerShellProfile(home, "windows"); got != profile {
+		t.Errorf("powerShellProfile() = %q, want %q", got, profile)
+	}
+
+	// Only Windows PowerShell 5 has a profile
+	legacy := filepath.Join(home, "Documents", "WindowsPowerShell", "Microsoft.PowerShell_profile.ps1")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := powerShellProfile(home, "windows"); got != legacy {
+		t.Errorf("powerShellProfile() = %q, want %q", got, legacy)
+	}
+}
+
 func TestAppendShellCompletion(t *testing.T) {
 	tests := []struct {
 		name           string
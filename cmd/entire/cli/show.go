@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// showPromptLimit is how many prompts per session `entire show` prints
+// without --transcript.
+const showPromptLimit = 3
+
+func newShowCmd() *cobra.Command {
+	var jsonFlag bool
+	var transcriptFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "show [commit]",
+		Short: "Show the sessions, checkpoints, attribution and prompts behind a commit",
+		Long: `Shows where a commit came from: the checkpoint linked by its
+Entire-Checkpoint trailer, each session that contributed to it with its agent,
+task type and number of checkpoints, the attribution recorded at commit time,
+and the prompts of the part of the transcript that produced the commit.
+
+Defaults to HEAD. Use --transcript to print that part of the transcript in
+full. Commits without a trailer fall back to the commit's note in
+refs/notes/entire, which carries attribution but no transcript.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			commitRef := "HEAD"
+			if len(args) > 0 {
+				commitRef = args[0]
+			}
+			return runShow(cmd.Context(), cmd.OutOrStdout(), commitRef, transcriptFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&transcriptFlag, "transcript", false, "Include the transcript excerpt that produced the commit")
+
+	return cmd
+}
+
+// showSessionJSON is the JSON shape of one session behind a commit.
+type showSessionJSON struct {
+	SessionID    string                         `json:"session_id"`
+	Agent        string                         `json:"agent,omitempty"`
+	TaskType     string                         `json:"task_type,omitempty"`
+	Checkpoints  int                            `json:"checkpoints"`
+	FilesTouched []string                       `json:"files_touched,omitempty"`
+	Attribution  *checkpoint.InitialAttribution `json:"attribution,omitempty"`
+	// ReconstructionConfidence is set when the checkpoints were rebuilt from
+	// the transcript (see 'entire checkpoint recover').
+	ReconstructionConfidence float64  `json:"reconstruction_confidence,omitempty"`
+	Prompts                  []string `json:"prompts"`
+	// Notes are what the agent recorded with the entire_note MCP tool.
+	Notes []checkpoint.Note `json:"notes,omitempty"`
+	// Transcript is the condensed transcript since the session's previous
+	// commit, only with --transcript.
+	Transcript string `json:"transcript,omitempty"`
+}
+
+type showJSON struct {
+	Commit       string            `json:"commit"`
+	Subject      string            `json:"subject"`
+	Author       string            `json:"author"`
+	Date         time.Time         `json:"date"`
+	CheckpointID string            `json:"checkpoint_id,omitempty"`
+	Sessions     []showSessionJSON `json:"sessions"`
+}
+
+func runShow(ctx context.Context, w io.Writer, commitRef string, includeTranscript, jsonOutput bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commitRef))
+	if err != nil {
+		return fmt.Errorf("commit not found: %s", commitRef)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	result := showJSON{
+		Commit:   hash.String(),
+		Subject:  firstLine(commit.Message),
+		Author:   fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email),
+		Date:     commit.Author.When,
+		Sessions: []showSessionJSON{},
+	}
+
+	store := checkpoint.NewGitStore(repo)
+	cpID, found := trailers.ParseCheckpoint(commit.Message)
+	var summary *checkpoint.CheckpointSummary
+	if found {
+		if summary, err = store.ReadCommitted(ctx, cpID); err != nil {
+			return fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+		}
+	}
+	if summary != nil {
+		result.CheckpointID = cpID.String()
+		for i := range summary.Sessions {
+			content, err := store.ReadSessionContent(ctx, cpID, i)
+			if err != nil {
+				return fmt.Errorf("failed to read session %d of checkpoint %s: %w", i, cpID, err)
+			}
+			result.Sessions = append(result.Sessions, showSession(content, includeTranscript))
+		}
+	} else {
+		// Same fallback as 'entire attribution show': the note survives
+		// rewrites that drop the trailer, but carries no transcript
+		note, err := store.ReadCommitNote(*hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit note: %w", err)
+		}
+		if note != nil {
+			result.CheckpointID = note.CheckpointID.String()
+			for _, session := range note.Sessions {
+				result.Sessions = append(result.Sessions, showSessionJSON{
+					SessionID:   session.SessionID,
+					Agent:       string(session.Agent),
+					Attribution: session.Attribution,
+					Prompts:     []string{},
+				})
+			}
+		}
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal commit details: %w", err)
+		}
+		_, err = w.Write(data)
+		return err //nolint:wrapcheck // write to stdout
+	}
+
+	fmt.Fprintf(w, "Commit %s: %s\n", hash.String()[:7], result.Subject)
+	fmt.Fprintf(w, "Author: %s, %s\n", result.Author, result.Date.Local().Format("2006-01-02 15:04"))
+	if result.CheckpointID == "" {
+		fmt.Fprintf(w, "\nNo associated Entire checkpoint: the commit has no %s trailer or note.\n", trailers.CheckpointTrailerKey)
+		return nil
+	}
+	fmt.Fprintf(w, "Checkpoint %s\n", result.CheckpointID)
+	for _, session := range result.Sessions {
+		fmt.Fprintln(w)
+		printShowSession(w, session, includeTranscript)
+	}
+	return nil
+}
+
+// showSession collects what `entire show` prints about one session.
+func showSession(content *checkpoint.SessionContent, includeTranscript bool) showSessionJSON {
+	metadata := content.Metadata
+	scoped := scopeTranscriptForCheckpoint(content.Transcript, metadata.GetTranscriptStart())
+	prompts := extractPromptsFromTranscript(scoped)
+	if len(prompts) == 0 && content.Prompts != "" {
+		// Older checkpoints and agents whose transcripts we can't parse
+		// still have prompt.txt
+		for _, prompt := range strings.Split(content.Prompts, "\n\n---\n\n") {
+			if prompt = strings.TrimSpace(prompt); prompt != "" {
+				prompts = append(prompts, prompt)
+			}
+		}
+	}
+	if prompts == nil {
+		prompts = []string{}
+	}
+
+	session := showSessionJSON{
+		SessionID:    metadata.SessionID,
+		Agent:        string(metadata.Agent),
+		TaskType:     metadata.TaskType,
+		Checkpoints:  metadata.CheckpointsCount,
+		FilesTouched: metadata.FilesTouched,
+		Attribution:  metadata.InitialAttribution,
+		Prompts:      prompts,
+		Notes:        metadata.Notes,
+
+		ReconstructionConfidence: metadata.ReconstructionConfidence,
+	}
+	if includeTranscript {
+		session.Transcript = formatTranscriptBytes(scoped, "")
+	}
+	return session
+}
+
+func printShowSession(w io.Writer, session showSessionJSON, includeTranscript bool) {
+	label := session.Agent
+	if label == "" {
+		label = "unknown agent"
+	}
+	if session.TaskType != "" {
+		label += ", " + session.TaskType
+	}
+	fmt.Fprintf(w, "Session %s (%s)\n", session.SessionID, label)
+	if session.Checkpoints > 0 {
+		fmt.Fprintf(w, "  Checkpoints: %d\n", session.Checkpoints)
+	}
+	if len(session.FilesTouched) > 0 {
+		fmt.Fprintf(w, "  Files: %s\n", strings.Join(session.FilesTouched, ", "))
+	}
+
+	if a := session.Attribution; a != nil && a.TotalCommitted > 0 {
+		fmt.Fprintf(w, "  Attribution: %s agent (%d of %d lines)\n",
+			reportfmt.DetectLocale().Percent(a.AgentPercentage, 1), a.AgentLines, a.TotalCommitted)
+	}
+	if session.ReconstructionConfidence > 0 {
+		fmt.Fprintf(w, "  Approximate: checkpoints were reconstructed from the transcript (%s of edits replayed).\n",
+			reportfmt.DetectLocale().Percent(session.ReconstructionConfidence*100, 0))
+	}
+
+	if len(session.Notes) > 0 {
+		fmt.Fprintln(w, "  Agent notes:")
+		for _, note := range session.Notes {
+			fmt.Fprintf(w, "    [%s] %s\n", note.Kind, strings.Join(strings.Fields(note.Text), " "))
+		}
+	}
+
+	if includeTranscript {
+		fmt.Fprintln(w, "  Transcript:")
+		for _, line := range strings.Split(strings.TrimRight(session.Transcript, "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+		return
+	}
+	if len(session.Prompts) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "  Prompts:")
+	prompts := session.Prompts
+	if len(prompts) > showPromptLimit {
+		// The last prompts are the ones closest to the commit
+		fmt.Fprintf(w, "    ... %d earlier prompt(s), use --transcript to see them\n", len(prompts)-showPromptLimit)
+		prompts = prompts[len(prompts)-showPromptLimit:]
+	}
+	for _, prompt := range prompts {
+		fmt.Fprintf(w, "    > %s\n", strings.Join(strings.Fields(prompt), " "))
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestRunShow(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, initial := setupCleanTestRepo(t)
+
+	cpID := id.MustCheckpointID("c1b2c3d4e5f6")
+	store := checkpoint.NewGitStore(repo)
+	if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+		CheckpointID:     cpID,
+		SessionID:        "2026-10-14-show",
+		Strategy:         "manual-commit",
+		Agent:            agent.AgentTypeClaudeCode,
+		TaskType:         "bugfix",
+		CheckpointsCount: 2,
+		FilesTouched:     []string{"parser.go"},
+		Transcript: []byte(`{"type":"user","message":{"content":"add a parser"}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Added."}]}}
+{"type":"user","message":{"content":"fix the crash on empty input"}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Fixed the nil check."}]}}
+`),
+		CheckpointTranscriptStart: 2,
+		InitialAttribution: &checkpoint.InitialAttribution{
+			AgentLines: 6, HumanAdded: 2, TotalCommitted: 8, AgentPercentage: 75,
+		},
+	}); err != nil {
+		t.Fatalf("WriteCommitted() error = %v", err)
+	}
+	head := storeRangeTestCommit(t, repo, cpID.String(), initial)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), head)); err != nil {
+		t.Fatalf("failed to update master: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runShow(context.Background(), &buf, "master", false, false); err != nil {
+		t.Fatalf("runShow() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Checkpoint c1b2c3d4e5f6",
+		"Session 2026-10-14-show (Claude Code, bugfix)",
+		"Checkpoints: 2",
+		"Attribution: 75.0% agent (6 of 8 lines)",
+		"> fix the crash on empty input",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// The first prompt belongs to an earlier commit of the session
+	if strings.Contains(out, "add a parser") {
+		t.Errorf("output includes a prompt from before the checkpoint:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runShow(context.Background(), &buf, "master", true, true); err != nil {
+		t.Fatalf("runShow(json) error = %v", err)
+	}
+	var result showJSON
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if result.Commit != head.String() || len(result.Sessions) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if s := result.Sessions[0]; len(s.Prompts) != 1 || !strings.Contains(s.Transcript, "Fixed the nil check.") {
+		t.Errorf("session = %+v, want one prompt and the transcript excerpt", s)
+	}
+}
+
+func TestRunShow_NoCheckpoint(t *testing.T) {
+	setupCleanTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := runShow(context.Background(), &buf, "HEAD", false, false); err != nil {
+		t.Fatalf("runShow() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "No associated Entire checkpoint") {
+		t.Errorf("output = %q", buf.String())
+	}
+	if err := runShow(context.Background(), &buf, "no-such-ref", false, false); err == nil {
+		t.Error("runShow() with unknown ref succeeded")
+	}
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newStackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stack",
+		Short: "Review the commits of a stacked session",
+		Long: `With the stacked strategy, every prompt that changes files becomes a commit
+of its own on the current branch, and a session's commits form a stack that
+can be reviewed change by change instead of as one squashed commit.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newStackExportCmd())
+
+	return cmd
+}
+
+// stackExportOptions are the flags of `entire stack export`.
+type stackExportOptions struct {
+	Session     string
+	Output      string
+	Stdout      bool
+	CoverLetter bool
+}
+
+func newStackExportCmd() *cobra.Command {
+	var opts stackExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a session's stack as a patch series",
+		Long: `Writes the commits of a session's stack on the current branch as a numbered
+patch series (git format-patch), one patch per commit, oldest first. Commits
+made in between the session's, e.g. by hand, are part of the series.
+
+The stack is that of the most recent stacked session, or of --session. The
+patches go to --output (default stack-<session>), or with --stdout to
+standard output as an mbox for 'git am' or 'git send-email'.
+
+Stacked commits carry a Gerrit Change-Id trailer, so the stack can also be
+pushed for review as is, one change per commit:
+
+  git push origin HEAD:refs/for/main`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runStackExport(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Session, "session", "", "Session whose stack to export (default: the most recent stacked session)")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Directory to write the patches to (default: stack-<session>)")
+	cmd.Flags().BoolVar(&opts.Stdout, "stdout", false, "Print the series as an mbox instead of writing files")
+	cmd.Flags().BoolVar(&opts.CoverLetter, "cover-letter", false, "Add a cover letter to fill in before sending the series")
+
+	return cmd
+}
+
+func runStackExport(ctx context.Context, w, errW io.Writer, opts stackExportOptions) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	stack, err := strategy.FindStack(repo, opts.Session)
+	if errors.Is(err, strategy.ErrNoStack) {
+		if opts.Session != "" {
+			return fmt.Errorf("no stacked commits of session %s on the current branch", opts.Session)
+		}
+		return errors.New("no stacked commits on the current branch; the stacked strategy creates them (entire enable --strategy stacked)")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find stack: %w", err)
+	}
+
+	// The series goes to w with --stdout, so the summary goes to errW
+	summaryW := w
+	if opts.Stdout {
+		summaryW = errW
+	}
+	printStack(summaryW, stack)
+
+	args := []string{"format-patch", "--numbered"}
+	if opts.CoverLetter {
+		args = append(args, "--cover-letter")
+	}
+	if opts.Stdout {
+		args = append(args, "--stdout")
+	} else {
+		output := opts.Output
+		if output == "" {
+			output = "stack-" + filepath.Base(stack.SessionID)
+		}
+		args = append(args, "--output-directory", output)
+	}
+	head := stack.Commits[len(stack.Commits)-1].Hash.String()
+	if stack.Base.IsZero() {
+		args = append(args, "--root", head)
+	} else {
+		args = append(args, stack.Base.String()+".."+head)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if opts.Stdout {
+		cmd.Stdout = w
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git format-patch failed: %s: %w", strings.TrimSpace(stderr.String()), err)
+	}
+	if !opts.Stdout {
+		fmt.Fprintln(w)
+		for _, file := range strings.Fields(stdout.String()) {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+	return nil
+}
+
+// printStack lists the stack's commits, oldest first.
+func printStack(w io.Writer, stack *strategy.Stack) {
+	base := "the root commit"
+	if !stack.Base.IsZero() {
+		base = stack.Base.String()[:7]
+	}
+	fmt.Fprintf(w, "Stack of session %s: %d commit(s) on %s\n", stack.SessionID, len(stack.Commits), base)
+	var withoutChangeID int
+	for i, c := range stack.Commits {
+		var note string
+		if c.CheckpointID.IsEmpty() {
+			note = " (not from the session)"
+		}
+		if c.ChangeID == "" {
+			withoutChangeID++
+		}
+		fmt.Fprintf(w, "  %d. %s %s%s\n", i+1, c.Hash.String()[:7], c.Subject, note)
+	}
+	if withoutChangeID > 0 {
+		fmt.Fprintf(w, "%d commit(s) without a Change-Id; Gerrit's commit-msg hook adds one when they are amended.\n", withoutChangeID)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+)
+
+func TestRunStackExport(t *testing.T) {
+	setupTestRepo(t)
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	commit := func(file, message string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(message+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		gitRun("add", file)
+		gitRun("commit", "-q", "-m", message)
+	}
+	stacked := func(file, subject, cpID string) {
+		t.Helper()
+		commit(file, subject+"\n\n"+trailers.CheckpointTrailerKey+": "+cpID+
+			"\n"+trailers.StrategyTrailerKey+": "+strategy.StrategyNameStacked+
+			"\n"+trailers.SessionTrailerKey+": 2026-01-02-abcdef123456"+
+			"\n"+trailers.ChangeIDTrailerKey+": "+trailers.ChangeIDForCheckpoint(id.MustCheckpointID(cpID)))
+	}
+	commit("README.md", "Initial commit")
+	stacked("a.go", "Add parser", "a1b2c3d4e5f6")
+	commit("notes.txt", "Fix up by hand")
+	stacked("b.go", "Add tests", "b1b2c3d4e5f6")
+
+	var out, errOut bytes.Buffer
+	if err := runStackExport(context.Background(), &out, &errOut, stackExportOptions{}); err != nil {
+		t.Fatalf("runStackExport() error = %v\n%s", err, errOut.String())
+	}
+	for _, want := range []string{
+		"Stack of session 2026-01-02-abcdef123456: 3 commit(s) on ",
+		"1. ", "Add parser\n",
+		"2. ", "Fix up by hand (not from the session)\n",
+		"1 commit(s) without a Change-Id",
+		"stack-2026-01-02-abcdef123456/0003-Add-tests.patch",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	patch, err := os.ReadFile(filepath.Join("stack-2026-01-02-abcdef123456", "0001-Add-parser.patch"))
+	if err != nil {
+		t.Fatalf("first patch not written: %v", err)
+	}
+	if !strings.Contains(string(patch), "Subject: [PATCH 1/3] Add parser") || !strings.Contains(string(patch), "Change-Id: I") {
+		t.Errorf("first patch = %s, want a numbered patch keeping the Change-Id", patch)
+	}
+
+	out.Reset()
+	errOut.Reset()
+	if err := runStackExport(context.Background(), &out, &errOut, stackExportOptions{Stdout: true}); err != nil {
+		t.Fatalf("runStackExport(--stdout) error = %v", err)
+	}
+	if strings.Count(out.String(), "Subject: [PATCH") != 3 || !strings.Contains(errOut.String(), "Stack of session") {
+		t.Errorf("--stdout wrote %d patches, summary %q; want the series on stdout and the summary on stderr", strings.Count(out.String(), "Subject: [PATCH"), errOut.String())
+	}
+
+	if err := runStackExport(context.Background(), &out, &errOut, stackExportOptions{Session: "2026-01-03-other"}); err == nil || !strings.Contains(err.Error(), "no stacked commits of session 2026-01-03-other") {
+		t.Errorf("runStackExport(unknown session) error = %v", err)
+	}
+}
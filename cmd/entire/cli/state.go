@@ -9,9 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/entireignore"
 	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
 
 	"github.com/go-git/go-git/v5"
 )
@@ -32,6 +35,10 @@ type PrePromptState struct {
 	LastTranscriptIdentifier string `json:"last_transcript_identifier,omitempty"` // Last identifier when prompt started (UUID for Claude, message ID for Gemini)
 	StepTranscriptStart      int    `json:"step_transcript_start,omitempty"`      // Transcript line count when this step/turn started
 
+	// StepTranscriptBookmark is the transcript position when this step/turn
+	// started, so the Stop hook can seek straight to the step's lines.
+	StepTranscriptBookmark *transcript.Bookmark `json:"step_transcript_bookmark,omitempty"`
+
 	// Deprecated: LastTranscriptLineCount is the old name for StepTranscriptStart.
 	// Kept for backward compatibility when reading state files written by older CLI versions.
 	LastTranscriptLineCount int `json:"last_transcript_line_count,omitempty"`
@@ -86,13 +93,27 @@ func CapturePrePromptState(sessionID, transcriptPath string) error {
 		return fmt.Errorf("failed to get untracked files: %w", err)
 	}
 
-	// Get transcript position (last UUID and line count)
+	// Get transcript position (last UUID and line count), reading only what
+	// was appended since the last hook when the session has a bookmark
 	var transcriptPos TranscriptPosition
+	var bookmark *transcript.Bookmark
 	if transcriptPath != "" {
-		transcriptPos, err = GetTranscriptPosition(transcriptPath)
+		sessionState, loadErr := strategy.LoadSessionState(sessionID)
+		if loadErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load session state: %v\n", loadErr)
+		}
+		var from *transcript.Bookmark
+		if sessionState != nil {
+			from = sessionState.TranscriptBookmark
+		}
+		transcriptPos, bookmark, err = GetTranscriptPositionFrom(transcriptPath, from)
 		if err != nil {
 			// Log warning but don't fail - transcript position is optional
 			fmt.Fprintf(os.Stderr, "Warning: failed to get transcript position: %v\n", err)
+		} else if sessionState != nil && bookmark != nil {
+			if saveErr := saveTranscriptBookmark(sessionID, bookmark); saveErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save transcript bookmark: %v\n", saveErr)
+			}
 		}
 	}
 
@@ -104,6 +125,7 @@ func CapturePrePromptState(sessionID, transcriptPath string) error {
 		UntrackedFiles:           untrackedFiles,
 		LastTranscriptIdentifier: transcriptPos.LastUUID,
 		StepTranscriptStart:      transcriptPos.LineCount,
+		StepTranscriptBookmark:   bookmark,
 	}
 
 	data, err := jsonutil.MarshalIndentWithNewline(state, "", "  ")
@@ -111,7 +133,7 @@ func CapturePrePromptState(sessionID, transcriptPath string) error {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(stateFile, data, 0o600); err != nil {
+	if err := encryption.WriteFile(stateFile, data, 0o600); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
@@ -120,6 +142,15 @@ func CapturePrePromptState(sessionID, transcriptPath string) error {
 	return nil
 }
 
+// saveTranscriptBookmark records where the session's transcript was last read
+// up to, so the next hook resumes there.
+func saveTranscriptBookmark(sessionID string, bookmark *transcript.Bookmark) error {
+	return strategy.UpdateSessionState(sessionID, func(state *strategy.SessionState) error { //nolint:wrapcheck // already wrapped by UpdateSessionState
+		state.TranscriptBookmark = bookmark
+		return nil
+	})
+}
+
 // CaptureGeminiPrePromptState captures current untracked files and transcript position
 // before a prompt for Gemini sessions. This is called by the BeforeAgent hook.
 // The transcriptPath is the path to the Gemini session transcript (JSON format).
@@ -182,7 +213,7 @@ func CaptureGeminiPrePromptState(sessionID, transcriptPath string) error {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(stateFile, data, 0o600); err != nil {
+	if err := encryption.WriteFile(stateFile, data, 0o600); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
@@ -199,7 +230,7 @@ func LoadPrePromptState(sessionID string) (*PrePromptState, error) {
 		return nil, nil //nolint:nilnil // already present in codebase
 	}
 
-	data, err := os.ReadFile(stateFile) //nolint:gosec // Reading from controlled git metadata path
+	data, err := encryption.ReadFile(stateFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
@@ -291,8 +322,13 @@ func DetectFileChanges(previouslyUntracked []string) (*FileChanges, error) {
 }
 
 // FilterAndNormalizePaths converts absolute paths to relative and filters out
-// infrastructure paths and paths outside the repo.
+// infrastructure paths, paths outside the repo and paths .entireignore
+// ignores. cwd is the repository root.
 func FilterAndNormalizePaths(files []string, cwd string) []string {
+	ignore, err := entireignore.Load(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 	var result []string
 	for _, file := range files {
 		relPath := paths.ToRelativePath(file, cwd)
@@ -302,6 +338,9 @@ func FilterAndNormalizePaths(files []string, cwd string) []string {
 		if paths.IsInfrastructurePath(relPath) {
 			continue // skip .entire directory
 		}
+		if ignore.Match(relPath) {
+			continue // excluded from checkpoints
+		}
 		result = append(result, relPath)
 	}
 	return result
@@ -405,7 +444,7 @@ func CapturePreTaskState(toolUseID string) error {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(stateFile, data, 0o600); err != nil {
+	if err := encryption.WriteFile(stateFile, data, 0o600); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
@@ -422,7 +461,7 @@ func LoadPreTaskState(toolUseID string) (*PreTaskState, error) {
 		return nil, nil //nolint:nilnil // already present in codebase
 	}
 
-	data, err := os.ReadFile(stateFile) //nolint:gosec // Reading from controlled git metadata path
+	data, err := encryption.ReadFile(stateFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
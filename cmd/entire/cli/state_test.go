@@ -195,6 +195,22 @@ func TestFilterAndNormalizePaths_SiblingDirectories(t *testing.T) {
 	}
 }
 
+func TestFilterAndNormalizePaths_EntireIgnore(t *testing.T) {
+	t.Parallel()
+	repoRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoRoot, ".entireignore"), []byte("dist/\n*.gen.go\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .entireignore: %v", err)
+	}
+	got := FilterAndNormalizePaths([]string{
+		filepath.Join(repoRoot, "main.go"),
+		filepath.Join(repoRoot, "dist", "app.js"),
+		"api/types.gen.go",
+	}, repoRoot)
+	if len(got) != 1 || got[0] != "main.go" {
+		t.Errorf("FilterAndNormalizePaths() = %v, want [main.go]", got)
+	}
+}
+
 func TestFindActivePreTaskFile(t *testing.T) {
 	// Create a temporary directory for testing and change to it
 	tmpDir := t.TempDir()
@@ -0,0 +1,721 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+// statsDirDepth is how many path components directories are grouped by.
+const statsDirDepth = 2
+
+// sparkTicks are the sparkline levels, lowest first.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkGap marks a period without data in a sparkline.
+const sparkGap = '·'
+
+// statsOptions controls the stats report.
+type statsOptions struct {
+	Weeks int
+	Days  int
+	Top   int
+
+	// Prices in USD per million tokens. Cost is only reported when InputPrice
+	// or OutputPrice is set.
+	InputPrice     float64
+	OutputPrice    float64
+	CacheReadPrice float64
+
+	// Period limits the report to --since/--until and sets the calendar
+	// (timezone, first day of the week) days and weeks are bucketed in.
+	Period reportPeriod
+
+	// Range limits the report to checkpoints linked from commits in this
+	// revision range (--range); empty means all checkpoints.
+	Range string
+
+	// Sample, when positive, estimates the agent share from this many
+	// checkpoints instead of reading all of them (--sample); Seed picks them.
+	Sample int
+	Seed   uint64
+
+	// TaskType limits the report to sessions tagged with this task type
+	// (--task-type); empty means all sessions.
+	TaskType string
+}
+
+func (o statsOptions) hasPrices() bool {
+	return o.InputPrice > 0 || o.OutputPrice > 0
+}
+
+func newStatsCmd() *cobra.Command {
+	var opts statsOptions
+	var jsonFlag bool
+	var sinceFlag, untilFlag string
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show agent attribution and usage trends",
+		Long: `Shows trends from the committed checkpoints on entire/checkpoints/v1:
+
+  - Agent share per week: the percentage of committed lines written by agents
+  - Top directories by agent lines
+  - Tokens per day, or cost per day when prices are given
+
+Agent lines are attributed per commit; within a commit they are split evenly
+across the files the agent touched, so directory totals are an estimate.
+
+Prices are in USD per million tokens. Cache writes are billed at the input
+price; cache reads at --cache-read-price, or the input price if it is not set.
+
+With --range, only checkpoints linked from commits in the revision range count,
+e.g. --range v1.2..v1.3 for a release or --range main..feature for a branch.
+
+With --since, the weekly trend covers the whole period and the daily trend its
+last --days days. Days and weeks are bucketed in the reporting timezone
+("reporting" in .entire/settings.json, default local time).
+
+On very large histories, --sample N reads only N checkpoints, stratified by
+week and top-level directory, and reports the agent share and agent lines with
+95% confidence intervals. --seed picks a different sample.
+
+Sessions are tagged with a task type (bugfix, refactor, tests, docs,
+greenfield, other) from their prompts; the report breaks agent lines down by
+it, and --task-type limits the report to one type.
+
+Checkpoints of sessions that aren't committed yet are listed by epoch (a run
+of up to 100 checkpoints, see 'entire checkpoint list') after the trends,
+within the period and task type; --range leaves them out.
+
+'entire stats survival' reports how much agent-written code is still in HEAD.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if opts.Weeks <= 0 || opts.Days <= 0 || opts.Top <= 0 {
+				return errors.New("--weeks, --days and --top must be positive")
+			}
+			if opts.InputPrice < 0 || opts.OutputPrice < 0 || opts.CacheReadPrice < 0 {
+				return errors.New("prices must not be negative")
+			}
+			period, err := resolveReportPeriod(sinceFlag, untilFlag, time.Now())
+			if err != nil {
+				return err
+			}
+			opts.Period = period
+			if opts.Sample < 0 {
+				return errors.New("--sample must not be negative")
+			}
+			if opts.Sample > 0 && opts.TaskType != "" {
+				return errors.New("--task-type can't be combined with --sample")
+			}
+			if opts.Sample > 0 {
+				return runStatsSample(cmd.Context(), cmd.OutOrStdout(), opts, jsonFlag)
+			}
+			return runStats(cmd.Context(), cmd.OutOrStdout(), opts, jsonFlag)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Weeks, "weeks", 12, "Number of weeks in the agent share trend")
+	cmd.Flags().IntVar(&opts.Days, "days", 14, "Number of days in the usage trend")
+	cmd.Flags().IntVar(&opts.Top, "top", 5, "Number of directories to show")
+	cmd.Flags().Float64Var(&opts.InputPrice, "input-price", 0, "Input token price in USD per million tokens")
+	cmd.Flags().Float64Var(&opts.OutputPrice, "output-price", 0, "Output token price in USD per million tokens")
+	cmd.Flags().Float64Var(&opts.CacheReadPrice, "cache-read-price", 0, "Cache read price in USD per million tokens (defaults to the input price)")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	addReportPeriodFlags(cmd, &sinceFlag, &untilFlag)
+	addCommitRangeFlag(cmd, &opts.Range)
+	cmd.Flags().IntVar(&opts.Sample, "sample", 0, "Estimate from a stratified sample of this many checkpoints")
+	cmd.Flags().Uint64Var(&opts.Seed, "seed", 1, "Seed for choosing the --sample checkpoints")
+	cmd.Flags().StringVar(&opts.TaskType, "task-type", "", "Only count sessions of this task type (e.g. bugfix, refactor, tests)")
+
+	cmd.AddCommand(newStatsSurvivalCmd())
+
+	return cmd
+}
+
+// statsCommit is what the report needs from one committed checkpoint.
+type statsCommit struct {
+	CreatedAt      time.Time
+	AgentLines     int
+	TotalCommitted int
+	// AutonomousAgentLines is the part of AgentLines from sessions that ran
+	// without a person supervising them (CI, headless agents, bots).
+	AutonomousAgentLines int
+	FilesTouched         []string
+	TokenUsage           agent.TokenUsage
+	// TaskLines are the agent lines per session task type ("" for untagged sessions).
+	TaskLines map[string]int
+}
+
+func runStats(ctx context.Context, w io.Writer, opts statsOptions, jsonOutput bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	commitRange, err := resolveCommitRange(repo, opts.Range)
+	if err != nil {
+		return err
+	}
+	commits, err := loadStatsCommits(ctx, checkpoint.NewGitStore(repo), commitRange, opts.TaskType)
+	if err != nil {
+		return err
+	}
+
+	report := buildStatsReport(commits, opts, time.Now())
+	// Uncommitted work isn't linked from any commit, so it's never in a range
+	if commitRange == nil {
+		states, err := strategy.ListSessionStates()
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		report.UncommittedEpochs = buildStatsEpochs(states, opts)
+	}
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	switch {
+	case len(commits) == 0 && commitRange != nil:
+		fmt.Fprintf(w, "No committed checkpoints in %s.\n", commitRange.Spec)
+	case len(commits) == 0:
+		fmt.Fprintln(w, "No committed checkpoints yet.")
+	case report.Commits == 0:
+		fmt.Fprintf(w, "No committed checkpoints %s.\n", describeStatsScope(opts))
+	default:
+		printStatsReport(w, report)
+	}
+	printStatsEpochs(w, report.UncommittedEpochs)
+	return nil
+}
+
+// buildStatsEpochs returns the checkpoint epochs of the sessions in states
+// that overlap opts.Period, counting only sessions of opts.TaskType if it is
+// set. The checkpoints from before epochs were recorded have no times and
+// only count when the period is unbounded.
+func buildStatsEpochs(states []*strategy.SessionState, opts statsOptions) []statsEpoch {
+	var epochs []statsEpoch
+	for _, state := range states {
+		if opts.TaskType != "" && state.TaskType != opts.TaskType {
+			continue
+		}
+		for _, e := range checkpointEpochsForDisplay(state) {
+			if e.StartedAt.IsZero() {
+				if opts.Period.IsBounded() {
+					continue
+				}
+			} else if !opts.Period.Overlaps(e.StartedAt, e.EndedAt) {
+				continue
+			}
+			epoch := statsEpoch{
+				SessionID:       state.SessionID,
+				Index:           e.Index,
+				Checkpoints:     e.Count(),
+				TaskCheckpoints: e.TaskCheckpoints,
+				StartedAt:       reportfmt.NewTime(e.StartedAt),
+				EndedAt:         reportfmt.NewTime(e.EndedAt),
+			}
+			if e.LastCommit != "" {
+				files := len(e.FilesTouched)
+				epoch.Files = &files
+			}
+			epochs = append(epochs, epoch)
+		}
+	}
+	sort.SliceStable(epochs, func(i, j int) bool {
+		return epochs[i].StartedAt.Before(epochs[j].StartedAt.Time)
+	})
+	return epochs
+}
+
+func printStatsEpochs(w io.Writer, epochs []statsEpoch) {
+	if len(epochs) == 0 {
+		return
+	}
+	width := len("Session")
+	for _, e := range epochs {
+		width = max(width, len(e.SessionID))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Uncommitted checkpoints by epoch")
+	fmt.Fprintf(w, "  %-*s  %-5s  %-11s  %-5s  %-16s  %-16s  %s\n", width, "Session", "Epoch", "Checkpoints", "Tasks", "Started", "Ended", "Files")
+	for _, e := range epochs {
+		files := "-"
+		if e.Files != nil {
+			files = strconv.Itoa(*e.Files)
+		}
+		fmt.Fprintf(w, "  %-*s  %-5d  %-11d  %-5d  %-16s  %-16s  %s\n", width, e.SessionID, e.Index, e.Checkpoints, e.TaskCheckpoints,
+			formatEpochTime(e.StartedAt.Time), formatEpochTime(e.EndedAt.Time), files)
+	}
+}
+
+// loadStatsCommits reads the attribution and token usage of every committed
+// checkpoint in commitRange (nil = all), counting only the sessions of
+// taskType if it is set. Only session metadata is read, never transcripts.
+func loadStatsCommits(ctx context.Context, store *checkpoint.GitStore, commitRange *commitRange, taskType string) ([]statsCommit, error) {
+	infos, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	commits := make([]statsCommit, 0, len(infos))
+	for _, info := range infos {
+		if !commitRange.ContainsCheckpoint(info.CheckpointID) {
+			continue
+		}
+		if c, ok := readStatsCommit(ctx, store, info, taskType); ok {
+			commits = append(commits, c)
+		}
+	}
+	return commits, nil
+}
+
+// readStatsCommit reads the attribution and token usage of one committed
+// checkpoint. With taskType set, only sessions of that task type count, and
+// ok is false if the checkpoint has none.
+func readStatsCommit(ctx context.Context, store *checkpoint.GitStore, info checkpoint.CommittedInfo, taskType string) (c statsCommit, ok bool) {
+	c = statsCommit{CreatedAt: info.CreatedAt, FilesTouched: info.FilesTouched, TaskLines: make(map[string]int)}
+	ok = taskType == ""
+	for i := range max(info.SessionCount, 1) {
+		metadata, err := store.ReadSessionMetadata(ctx, info.CheckpointID, i)
+		if err != nil {
+			continue // Partially written or older checkpoints still count by date
+		}
+		if taskType != "" {
+			if metadata.TaskType != taskType {
+				continue
+			}
+			ok = true
+		}
+		// Superseded attributions were folded into another checkpoint by a fixup
+		if attr := metadata.InitialAttribution; attr != nil && attr.SupersededBy == "" {
+			// Sessions share the commit: agent lines add up, the commit size doesn't.
+			c.AgentLines += attr.AgentLines
+			c.TotalCommitted = max(c.TotalCommitted, attr.TotalCommitted)
+			if len(metadata.Automation) > 0 {
+				c.AutonomousAgentLines += attr.AgentLines
+			}
+			c.TaskLines[metadata.TaskType] += attr.AgentLines
+		}
+		if metadata.TokenUsage != nil {
+			addTokenUsage(&c.TokenUsage, metadata.TokenUsage)
+		}
+	}
+	return c, ok
+}
+
+// describeStatsScope describes the --range, --task-type and --since/--until
+// limits, e.g. "in v1.2..v1.3 of bugfix sessions since 2026-01-01 00:00".
+func describeStatsScope(opts statsOptions) string {
+	var parts []string
+	if opts.Range != "" {
+		parts = append(parts, "in "+opts.Range)
+	}
+	if opts.TaskType != "" {
+		parts = append(parts, "of "+opts.TaskType+" sessions")
+	}
+	if period := opts.Period.Describe(); period != "" {
+		parts = append(parts, period)
+	}
+	return strings.Join(parts, " ")
+}
+
+// addTokenUsage adds usage, including subagent usage, into total.
+func addTokenUsage(total *agent.TokenUsage, usage *agent.TokenUsage) {
+	for u := usage; u != nil; u = u.SubagentTokens {
+		total.InputTokens += u.InputTokens
+		total.CacheCreationTokens += u.CacheCreationTokens
+		total.CacheReadTokens += u.CacheReadTokens
+		total.OutputTokens += u.OutputTokens
+		total.APICallCount += u.APICallCount
+	}
+}
+
+type statsWeek struct {
+	Start          reportfmt.Time `json:"start"`
+	AgentLines     int            `json:"agent_lines"`
+	TotalCommitted int            `json:"total_committed"`
+	// AgentShare is nil for weeks without attributed commits.
+	AgentShare *reportfmt.Percent `json:"agent_share,omitempty"`
+}
+
+type statsDir struct {
+	Path       string `json:"path"`
+	AgentLines int    `json:"agent_lines"`
+}
+
+// statsUntaggedTaskType labels sessions recorded before task types were.
+const statsUntaggedTaskType = "untagged"
+
+type statsTaskType struct {
+	TaskType   string `json:"task_type"`
+	Commits    int    `json:"commits"`
+	AgentLines int    `json:"agent_lines"`
+	// TotalCommitted is the size of the commits with sessions of this type.
+	TotalCommitted int `json:"total_committed"`
+	// AgentShare is nil when those commits have no committed lines.
+	AgentShare *reportfmt.Percent `json:"agent_share,omitempty"`
+}
+
+type statsDay struct {
+	Date   string          `json:"date"`
+	Tokens int             `json:"tokens"`
+	Cost   reportfmt.Money `json:"cost,omitempty"`
+}
+
+// statsEpoch is one checkpoint epoch of a session that hasn't been condensed.
+type statsEpoch struct {
+	SessionID       string         `json:"session_id"`
+	Index           int            `json:"index"`
+	Checkpoints     int            `json:"checkpoints"`
+	TaskCheckpoints int            `json:"task_checkpoints"`
+	StartedAt       reportfmt.Time `json:"started_at,omitzero"`
+	EndedAt         reportfmt.Time `json:"ended_at,omitzero"`
+	// Files is nil for the checkpoints before epochs were recorded
+	Files *int `json:"files,omitempty"`
+}
+
+type statsReport struct {
+	Range    string         `json:"range,omitempty"`
+	TaskType string         `json:"task_type,omitempty"`
+	Since    reportfmt.Time `json:"since,omitzero"`
+	Until    reportfmt.Time `json:"until,omitzero"`
+	Commits  int            `json:"commits"`
+	// Agent lines in the period split by who drove the session
+	SupervisedAgentLines int             `json:"supervised_agent_lines"`
+	AutonomousAgentLines int             `json:"autonomous_agent_lines"`
+	Weeks                []statsWeek     `json:"weeks"`
+	Directories          []statsDir      `json:"directories"`
+	TaskTypes            []statsTaskType `json:"task_types"`
+	Days                 []statsDay      `json:"days"`
+	HasCost              bool            `json:"has_cost"`
+	// UncommittedEpochs are the checkpoint epochs of sessions whose work
+	// isn't committed yet, so not part of the trends above
+	UncommittedEpochs []statsEpoch `json:"uncommitted_epochs,omitempty"`
+
+	period reportPeriod
+}
+
+func buildStatsReport(commits []statsCommit, opts statsOptions, now time.Time) statsReport {
+	period := opts.Period
+	if period.Location == nil {
+		period.Location = now.Location()
+	}
+
+	// The trends end at the last instant of the period
+	end := now
+	if !period.Until.IsZero() && period.Until.Before(now) {
+		end = period.Until.Add(-time.Nanosecond)
+	}
+	thisWeek := period.StartOfWeek(end)
+	today := period.StartOfDay(end)
+
+	numWeeks, numDays := opts.Weeks, opts.Days
+	if !period.Since.IsZero() {
+		numWeeks = max(1, calendarDaysBetween(period.StartOfWeek(period.Since), thisWeek)/7+1)
+		numDays = max(1, min(numDays, calendarDaysBetween(period.StartOfDay(period.Since), today)+1))
+	}
+
+	report := statsReport{
+		TaskType:    opts.TaskType,
+		Range:       opts.Range,
+		Since:       reportfmt.NewTime(period.Since),
+		Until:       reportfmt.NewTime(period.Until),
+		Weeks:       make([]statsWeek, numWeeks),
+		Directories: []statsDir{},
+		TaskTypes:   []statsTaskType{},
+		Days:        make([]statsDay, numDays),
+		HasCost:     opts.hasPrices(),
+		period:      period,
+	}
+
+	for i := range report.Weeks {
+		report.Weeks[i].Start = reportfmt.NewTime(thisWeek.AddDate(0, 0, -7*(numWeeks-1-i)))
+	}
+	for i := range report.Days {
+		report.Days[i].Date = today.AddDate(0, 0, -(numDays - 1 - i)).Format(time.DateOnly)
+	}
+
+	dirLines := make(map[string]int)
+	taskTypes := make(map[string]*statsTaskType)
+	for _, c := range commits {
+		if !period.Contains(c.CreatedAt) {
+			continue
+		}
+		report.Commits++
+		report.SupervisedAgentLines += c.AgentLines - c.AutonomousAgentLines
+		report.AutonomousAgentLines += c.AutonomousAgentLines
+
+		weeksAgo := calendarDaysBetween(period.StartOfWeek(c.CreatedAt), thisWeek) / 7
+		if weeksAgo >= 0 && weeksAgo < numWeeks {
+			week := &report.Weeks[numWeeks-1-weeksAgo]
+			week.AgentLines += c.AgentLines
+			week.TotalCommitted += c.TotalCommitted
+		}
+
+		daysAgo := calendarDaysBetween(period.StartOfDay(c.CreatedAt), today)
+		if daysAgo >= 0 && daysAgo < numDays {
+			day := &report.Days[numDays-1-daysAgo]
+			u := c.TokenUsage
+			day.Tokens += u.InputTokens + u.CacheCreationTokens + u.CacheReadTokens + u.OutputTokens
+			day.Cost += reportfmt.Money(tokenCost(u, opts))
+		}
+
+		for dir, lines := range splitAgentLines(c.AgentLines, c.FilesTouched) {
+			dirLines[dir] += lines
+		}
+
+		for taskType, lines := range c.TaskLines {
+			if taskType == "" {
+				taskType = statsUntaggedTaskType
+			}
+			t := taskTypes[taskType]
+			if t == nil {
+				t = &statsTaskType{TaskType: taskType}
+				taskTypes[taskType] = t
+			}
+			t.Commits++
+			t.AgentLines += lines
+			t.TotalCommitted += c.TotalCommitted
+		}
+	}
+
+	for i := range report.Weeks {
+		if week := &report.Weeks[i]; week.TotalCommitted > 0 {
+			share := reportfmt.Percent(math.Min(100, float64(week.AgentLines)*100/float64(week.TotalCommitted)))
+			week.AgentShare = &share
+		}
+	}
+
+	for dir, lines := range dirLines {
+		if lines > 0 {
+			report.Directories = append(report.Directories, statsDir{Path: dir, AgentLines: lines})
+		}
+	}
+	sort.Slice(report.Directories, func(i, j int) bool {
+		if report.Directories[i].AgentLines != report.Directories[j].AgentLines {
+			return report.Directories[i].AgentLines > report.Directories[j].AgentLines
+		}
+		return report.Directories[i].Path < report.Directories[j].Path
+	})
+	if len(report.Directories) > opts.Top {
+		report.Directories = report.Directories[:opts.Top]
+	}
+
+	for _, t := range taskTypes {
+		if t.TotalCommitted > 0 {
+			share := reportfmt.Percent(math.Min(100, float64(t.AgentLines)*100/float64(t.TotalCommitted)))
+			t.AgentShare = &share
+		}
+		report.TaskTypes = append(report.TaskTypes, *t)
+	}
+	sort.Slice(report.TaskTypes, func(i, j int) bool {
+		if report.TaskTypes[i].AgentLines != report.TaskTypes[j].AgentLines {
+			return report.TaskTypes[i].AgentLines > report.TaskTypes[j].AgentLines
+		}
+		return report.TaskTypes[i].TaskType < report.TaskTypes[j].TaskType
+	})
+
+	return report
+}
+
+// splitAgentLines spreads a commit's agent lines evenly over the directories of
+// the touched files. The remainder goes to the first files so totals are exact.
+func splitAgentLines(agentLines int, files []string) map[string]int {
+	if agentLines <= 0 || len(files) == 0 {
+		return nil
+	}
+	result := make(map[string]int)
+	per, rest := agentLines/len(files), agentLines%len(files)
+	for i, file := range files {
+		lines := per
+		if i < rest {
+			lines++
+		}
+		result[statsDirOf(file)] += lines
+	}
+	return result
+}
+
+// statsDirOf returns the directory of file, truncated to statsDirDepth components.
+func statsDirOf(file string) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > statsDirDepth {
+		parts = parts[:statsDirDepth]
+	}
+	return strings.Join(parts, "/")
+}
+
+func tokenCost(u agent.TokenUsage, opts statsOptions) float64 {
+	cacheReadPrice := opts.CacheReadPrice
+	if cacheReadPrice == 0 {
+		cacheReadPrice = opts.InputPrice
+	}
+	return (float64(u.InputTokens+u.CacheCreationTokens)*opts.InputPrice +
+		float64(u.CacheReadTokens)*cacheReadPrice +
+		float64(u.OutputTokens)*opts.OutputPrice) / 1_000_000
+}
+
+// calendarDaysBetween returns the number of calendar days from midnight from
+// to midnight to, rounding away DST shifts.
+func calendarDaysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+func printStatsReport(w io.Writer, report statsReport) {
+	loc := reportfmt.DetectLocale()
+
+	// Agent share per week
+	shares := make([]float64, len(report.Weeks))
+	var latest, sum float64
+	var weeksWithData int
+	for i, week := range report.Weeks {
+		shares[i] = -1
+		if week.AgentShare != nil {
+			shares[i] = float64(*week.AgentShare)
+			latest = shares[i]
+			sum += shares[i]
+			weeksWithData++
+		}
+	}
+	if report.period.IsBounded() || report.Range != "" || report.TaskType != "" {
+		fmt.Fprintf(w, "%d commits %s\n\n", report.Commits, describeStatsScope(statsOptions{Period: report.period, Range: report.Range, TaskType: report.TaskType}))
+	}
+	fmt.Fprintf(w, "Agent share per week (last %d weeks)\n", len(report.Weeks))
+	if weeksWithData == 0 {
+		fmt.Fprintln(w, "  No attributed commits in this period.")
+	} else {
+		fmt.Fprintf(w, "  %s  latest %s · avg %s\n", sparkline(shares, 100), loc.Percent(latest, 0), loc.Percent(sum/float64(weeksWithData), 0))
+		fmt.Fprintf(w, "  %s → %s\n", report.Weeks[0].Start.Format(time.DateOnly), report.Weeks[len(report.Weeks)-1].Start.Format(time.DateOnly))
+	}
+	if report.AutonomousAgentLines > 0 {
+		total := report.SupervisedAgentLines + report.AutonomousAgentLines
+		fmt.Fprintf(w, "  Supervised %d lines (%s) · autonomous %d lines (%s)\n",
+			report.SupervisedAgentLines, loc.Percent(float64(report.SupervisedAgentLines)*100/float64(total), 0),
+			report.AutonomousAgentLines, loc.Percent(float64(report.AutonomousAgentLines)*100/float64(total), 0))
+	}
+	fmt.Fprintln(w)
+
+	// Top directories
+	fmt.Fprintln(w, "Top directories by agent lines")
+	if len(report.Directories) == 0 {
+		fmt.Fprintln(w, "  No agent lines recorded.")
+	} else {
+		width := 0
+		for _, d := range report.Directories {
+			width = max(width, len([]rune(d.Path)))
+		}
+		top := report.Directories[0].AgentLines
+		for _, d := range report.Directories {
+			fmt.Fprintf(w, "  %-*s  %-20s %d\n", width, d.Path, bar(d.AgentLines, top, 20), d.AgentLines)
+		}
+	}
+	fmt.Fprintln(w)
+
+	// Agent lines by task type, unless the report is limited to one
+	if report.TaskType == "" && len(report.TaskTypes) > 0 && report.TaskTypes[0].AgentLines > 0 {
+		fmt.Fprintln(w, "Agent lines by task type")
+		width := 0
+		for _, t := range report.TaskTypes {
+			width = max(width, len(t.TaskType))
+		}
+		top := report.TaskTypes[0].AgentLines
+		for _, t := range report.TaskTypes {
+			share := "-"
+			if t.AgentShare != nil {
+				share = loc.Percent(float64(*t.AgentShare), 0)
+			}
+			fmt.Fprintf(w, "  %-*s  %-20s %d lines in %d commits (%s of their lines)\n", width, t.TaskType, bar(t.AgentLines, top, 20), t.AgentLines, t.Commits, share)
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Usage per day
+	values := make([]float64, len(report.Days))
+	var total, peak float64
+	peakDay := ""
+	for i, day := range report.Days {
+		values[i] = float64(day.Tokens)
+		if report.HasCost {
+			values[i] = float64(day.Cost)
+		}
+		total += values[i]
+		if values[i] > peak {
+			peak, peakDay = values[i], day.Date
+		}
+	}
+	format := func(v float64) string { return formatTokenCount(loc, v) }
+	title := "Tokens per day"
+	if report.HasCost {
+		format = loc.Money
+		title = "Cost per day"
+	}
+	fmt.Fprintf(w, "%s (last %d days)\n", title, len(report.Days))
+	if total == 0 {
+		fmt.Fprintln(w, "  No token usage recorded in this period.")
+		return
+	}
+	fmt.Fprintf(w, "  %s  total %s · peak %s on %s\n", sparkline(values, peak), format(total), format(peak), peakDay)
+}
+
+// sparkline renders values scaled to maxValue. Negative values are gaps.
+func sparkline(values []float64, maxValue float64) string {
+	var sb strings.Builder
+	for _, v := range values {
+		if v < 0 {
+			sb.WriteRune(sparkGap)
+			continue
+		}
+		level := 0
+		if maxValue > 0 {
+			level = int(math.Round(v / maxValue * float64(len(sparkTicks)-1)))
+		}
+		sb.WriteRune(sparkTicks[min(max(level, 0), len(sparkTicks)-1)])
+	}
+	return sb.String()
+}
+
+// bar renders value as a horizontal bar of up to width cells, scaled to maxValue.
+func bar(value, maxValue, width int) string {
+	if maxValue <= 0 || value <= 0 {
+		return ""
+	}
+	cells := max(1, int(math.Round(float64(value)/float64(maxValue)*float64(width))))
+	return strings.Repeat("█", min(cells, width))
+}
+
+func formatTokenCount(loc reportfmt.Locale, v float64) string {
+	switch {
+	case v >= 1_000_000:
+		return loc.Decimal(v/1_000_000, 1) + "M"
+	case v >= 1_000:
+		return loc.Decimal(v/1_000, 1) + "k"
+	default:
+		return loc.Decimal(v, 0)
+	}
+}
@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+)
+
+// statsConfidenceZ is the normal quantile of the reported confidence intervals (95%).
+const statsConfidenceZ = 1.96
+
+// statsStratification is one way of grouping checkpoints into strata, finest first.
+type statsStratification struct {
+	Name string
+	Key  func(info checkpoint.CommittedInfo, period reportPeriod) string
+}
+
+var statsStratifications = []statsStratification{
+	{"week and directory", func(info checkpoint.CommittedInfo, period reportPeriod) string {
+		return period.StartOfWeek(info.CreatedAt).Format(time.DateOnly) + " " + statsTopDirOf(info.FilesTouched)
+	}},
+	{"week", func(info checkpoint.CommittedInfo, period reportPeriod) string {
+		return period.StartOfWeek(info.CreatedAt).Format(time.DateOnly)
+	}},
+	{"directory", func(info checkpoint.CommittedInfo, _ reportPeriod) string {
+		return statsTopDirOf(info.FilesTouched)
+	}},
+}
+
+// statsEstimate is an estimated value with its 95% confidence interval.
+type statsEstimate struct {
+	Estimate float64 `json:"estimate"`
+	Low      float64 `json:"low"`
+	High     float64 `json:"high"`
+}
+
+// MarshalJSON writes the estimate and its interval with fixed precision.
+func (e statsEstimate) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(struct {
+		Estimate reportfmt.Decimal `json:"estimate"`
+		Low      reportfmt.Decimal `json:"low"`
+		High     reportfmt.Decimal `json:"high"`
+	}{reportfmt.Decimal(e.Estimate), reportfmt.Decimal(e.Low), reportfmt.Decimal(e.High)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal estimate: %w", err)
+	}
+	return data, nil
+}
+
+type statsSampleReport struct {
+	Range string         `json:"range,omitempty"`
+	Since reportfmt.Time `json:"since,omitzero"`
+	Until reportfmt.Time `json:"until,omitzero"`
+	// Population is the number of checkpoints in scope, Sampled how many were read.
+	Population   int    `json:"population"`
+	Sampled      int    `json:"sampled"`
+	Strata       int    `json:"strata"`
+	StratifiedBy string `json:"stratified_by,omitempty"`
+	Seed         uint64 `json:"seed"`
+	// AgentShare is in percent; nil if no sampled checkpoint had attribution.
+	AgentShare     *statsEstimate `json:"agent_share,omitempty"`
+	AgentLines     statsEstimate  `json:"agent_lines"`
+	TotalCommitted statsEstimate  `json:"total_committed"`
+
+	period reportPeriod
+}
+
+func runStatsSample(ctx context.Context, w io.Writer, opts statsOptions, jsonOutput bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	commitRange, err := resolveCommitRange(repo, opts.Range)
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewGitStore(repo)
+	infos, err := store.ListCommitted(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	if opts.Period.Location == nil {
+		opts.Period.Location = time.Now().Location()
+	}
+	inScope := make([]checkpoint.CommittedInfo, 0, len(infos))
+	for _, info := range infos {
+		if commitRange.ContainsCheckpoint(info.CheckpointID) && opts.Period.Contains(info.CreatedAt) {
+			inScope = append(inScope, info)
+		}
+	}
+
+	report := buildStatsSample(inScope, opts, func(info checkpoint.CommittedInfo) statsCommit {
+		c, _ := readStatsCommit(ctx, store, info, "")
+		return c
+	})
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+	if report.Population == 0 {
+		if scope := describeStatsScope(opts); scope != "" {
+			fmt.Fprintf(w, "No committed checkpoints %s.\n", scope)
+		} else {
+			fmt.Fprintln(w, "No committed checkpoints yet.")
+		}
+		return nil
+	}
+	printStatsSample(w, report)
+	return nil
+}
+
+// buildStatsSample estimates agent lines, committed lines and the agent share
+// of infos from a stratified random sample of opts.Sample of them, read with
+// read. Strata get at least one checkpoint each and the rest in proportion to
+// their size; the stratification is the finest one leaving at least two
+// checkpoints per stratum on average.
+func buildStatsSample(infos []checkpoint.CommittedInfo, opts statsOptions, read func(checkpoint.CommittedInfo) statsCommit) statsSampleReport {
+	report := statsSampleReport{
+		Range:      opts.Range,
+		Since:      reportfmt.NewTime(opts.Period.Since),
+		Until:      reportfmt.NewTime(opts.Period.Until),
+		Population: len(infos),
+		Seed:       opts.Seed,
+		period:     opts.Period,
+	}
+	if len(infos) == 0 {
+		return report
+	}
+	n := min(opts.Sample, len(infos))
+
+	strata := map[string][]checkpoint.CommittedInfo{"": infos}
+	for _, s := range statsStratifications {
+		grouped := make(map[string][]checkpoint.CommittedInfo)
+		for _, info := range infos {
+			key := s.Key(info, opts.Period)
+			grouped[key] = append(grouped[key], info)
+		}
+		if 2*len(grouped) <= n {
+			strata, report.StratifiedBy = grouped, s.Name
+			break
+		}
+	}
+	keys := make([]string, 0, len(strata))
+	for key := range strata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	report.Strata = len(keys)
+	sizes := make([]int, len(keys))
+	for i, key := range keys {
+		sizes[i] = len(strata[key])
+	}
+	alloc := allocateStatsSample(sizes, n)
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed)) //nolint:gosec // sampling, not security
+	type stratumSample struct {
+		size  int
+		agent []float64
+		total []float64
+	}
+	samples := make([]stratumSample, len(keys))
+	var agentTotal, committedTotal float64
+	for i, key := range keys {
+		members := strata[key]
+		// Sorted first so a seed always picks the same checkpoints
+		sort.Slice(members, func(a, b int) bool { return members[a].CheckpointID < members[b].CheckpointID })
+		picked := rng.Perm(len(members))[:alloc[i]]
+		sort.Ints(picked)
+		s := stratumSample{size: len(members)}
+		for _, j := range picked {
+			c := read(members[j])
+			s.agent = append(s.agent, float64(c.AgentLines))
+			s.total = append(s.total, float64(c.TotalCommitted))
+		}
+		agentTotal += float64(s.size) * sampleMean(s.agent)
+		committedTotal += float64(s.size) * sampleMean(s.total)
+		samples[i] = s
+		report.Sampled += len(picked)
+	}
+
+	// Strata with a single sampled checkpoint borrow the whole sample's variance
+	var allAgent, allTotal []float64
+	for _, s := range samples {
+		allAgent = append(allAgent, s.agent...)
+		allTotal = append(allTotal, s.total...)
+	}
+	share := 0.0
+	if committedTotal > 0 {
+		share = agentTotal / committedTotal
+	}
+	residuals := func(agent, total []float64) []float64 {
+		d := make([]float64, len(agent))
+		for i := range agent {
+			d[i] = agent[i] - share*total[i]
+		}
+		return d
+	}
+	pooledAgent, pooledTotal := sampleVariance(allAgent), sampleVariance(allTotal)
+	pooledResidual := sampleVariance(residuals(allAgent, allTotal))
+
+	var varAgent, varTotal, varResidual float64
+	for _, s := range samples {
+		nh, bigN := float64(len(s.agent)), float64(s.size)
+		weight := bigN * bigN * (1 - nh/bigN) / nh
+		if weight == 0 {
+			continue // Fully read
+		}
+		va, vt, vr := pooledAgent, pooledTotal, pooledResidual
+		if len(s.agent) > 1 {
+			va, vt, vr = sampleVariance(s.agent), sampleVariance(s.total), sampleVariance(residuals(s.agent, s.total))
+		}
+		varAgent += weight * va
+		varTotal += weight * vt
+		varResidual += weight * vr
+	}
+
+	report.AgentLines = newStatsEstimate(agentTotal, math.Sqrt(varAgent), 0, math.Inf(1))
+	report.TotalCommitted = newStatsEstimate(committedTotal, math.Sqrt(varTotal), 0, math.Inf(1))
+	if committedTotal > 0 {
+		se := math.Sqrt(varResidual) / committedTotal * 100
+		estimate := newStatsEstimate(share*100, se, 0, 100)
+		report.AgentShare = &estimate
+	}
+	return report
+}
+
+// allocateStatsSample splits n sampled checkpoints over strata of the given
+// sizes: one each, the rest in proportion to size by largest remainder, never
+// more than a stratum has.
+func allocateStatsSample(sizes []int, n int) []int {
+	alloc := make([]int, len(sizes))
+	population := 0
+	for _, size := range sizes {
+		population += size
+	}
+	if n >= population {
+		copy(alloc, sizes)
+		return alloc
+	}
+	remaining := n
+	for i := range sizes {
+		if remaining > 0 {
+			alloc[i] = 1
+			remaining--
+		}
+	}
+	spare := remaining
+	remainders := make([]float64, len(sizes))
+	for i, size := range sizes {
+		share := float64(spare) * float64(size) / float64(population)
+		extra := min(int(share), size-alloc[i])
+		alloc[i] += extra
+		remaining -= extra
+		remainders[i] = share - math.Floor(share)
+	}
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	// Hand out what's left by largest remainder, then wherever there's room
+	for remaining > 0 {
+		progress := false
+		for _, i := range order {
+			if remaining > 0 && alloc[i] < sizes[i] {
+				alloc[i]++
+				remaining--
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	return alloc
+}
+
+func newStatsEstimate(estimate, standardError, low, high float64) statsEstimate {
+	margin := statsConfidenceZ * standardError
+	return statsEstimate{
+		Estimate: estimate,
+		Low:      math.Max(low, estimate-margin),
+		High:     math.Min(high, estimate+margin),
+	}
+}
+
+func sampleMean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// sampleVariance returns the unbiased variance of values (0 for fewer than two).
+func sampleVariance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := sampleMean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+// statsTopDirOf returns the top-level directory most of files are in ("." for
+// files at the root or no files), preferring the first alphabetically on ties.
+func statsTopDirOf(files []string) string {
+	counts := make(map[string]int)
+	for _, file := range files {
+		top, _, _ := strings.Cut(statsDirOf(file), "/")
+		counts[top]++
+	}
+	best := "."
+	for dir, count := range counts {
+		if count > counts[best] || (count == counts[best] && dir < best) {
+			best = dir
+		}
+	}
+	return best
+}
+
+func printStatsSample(w io.Writer, report statsSampleReport) {
+	how := ""
+	if report.StratifiedBy != "" {
+		how = fmt.Sprintf(", stratified by %s", report.StratifiedBy)
+	}
+	scope := describeStatsScope(statsOptions{Period: report.period, Range: report.Range})
+	if scope != "" {
+		scope = " " + scope
+	}
+	fmt.Fprintf(w, "Estimated from %d of %d checkpoints%s (seed %d%s)\n\n", report.Sampled, report.Population, scope, report.Seed, how)
+
+	loc := reportfmt.DetectLocale()
+	formatRange := func(e statsEstimate) string {
+		return fmt.Sprintf("%s  (95%% CI %s–%s)", formatTokenCount(loc, e.Estimate), formatTokenCount(loc, e.Low), formatTokenCount(loc, e.High))
+	}
+	if a := report.AgentShare; a != nil {
+		fmt.Fprintf(w, "  Agent share      %s  (95%% CI %s–%s)\n", loc.Percent(a.Estimate, 1), loc.Decimal(a.Low, 1), loc.Percent(a.High, 1))
+	} else {
+		fmt.Fprintln(w, "  Agent share      no attributed checkpoints in the sample")
+	}
+	fmt.Fprintf(w, "  Agent lines      %s\n", formatRange(report.AgentLines))
+	fmt.Fprintf(w, "  Lines committed  %s\n", formatRange(report.TotalCommitted))
+}
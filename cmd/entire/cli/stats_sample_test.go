@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+func TestBuildStatsSample(t *testing.T) {
+	t.Parallel()
+
+	// 20 weeks × 2 directories; "web" checkpoints are mostly by agents
+	start := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	var infos []checkpoint.CommittedInfo
+	commits := make(map[id.CheckpointID]statsCommit)
+	var agentLines, totalLines int
+	for i := range 4000 {
+		cpID := id.MustCheckpointID(fmt.Sprintf("%012x", i+1))
+		dir := "api"
+		c := statsCommit{AgentLines: i % 5, TotalCommitted: 10}
+		if i%2 == 1 {
+			dir = "web"
+			c.AgentLines = 6 + i%5
+		}
+		info := checkpoint.CommittedInfo{
+			CheckpointID: cpID,
+			CreatedAt:    start.Add(time.Duration(i%140) * 24 * time.Hour),
+			FilesTouched: []string{dir + "/main.go"},
+		}
+		infos = append(infos, info)
+		commits[cpID] = c
+		agentLines += c.AgentLines
+		totalLines += c.TotalCommitted
+	}
+	trueShare := float64(agentLines) * 100 / float64(totalLines)
+	period := reportPeriod{Location: time.UTC, WeekStart: time.Monday}
+
+	reads := 0
+	read := func(info checkpoint.CommittedInfo) statsCommit {
+		reads++
+		return commits[info.CheckpointID]
+	}
+	report := buildStatsSample(slices.Clone(infos), statsOptions{Sample: 400, Seed: 2, Period: period}, read)
+	if reads != 400 || report.Sampled != 400 || report.Population != 4000 {
+		t.Fatalf("read %d, sampled %d of %d; want 400 of 4000", reads, report.Sampled, report.Population)
+	}
+	if report.StratifiedBy != "week and directory" || report.Strata != 40 {
+		t.Errorf("stratified by %q into %d strata, want week and directory into 40", report.StratifiedBy, report.Strata)
+	}
+	share := report.AgentShare
+	if share == nil || share.Low > trueShare || share.High < trueShare || share.High-share.Low > 10 {
+		t.Errorf("agent share = %+v, want a narrow interval around %.1f", share, trueShare)
+	}
+	if report.AgentLines.Low > float64(agentLines) || report.AgentLines.High < float64(agentLines) {
+		t.Errorf("agent lines = %+v, want an interval around %d", report.AgentLines, agentLines)
+	}
+
+	var stdout bytes.Buffer
+	printStatsSample(&stdout, report)
+	if want := "Estimated from 400 of 4000 checkpoints (seed 2, stratified by week and directory)"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, stdout.String())
+	}
+
+	// The same seed picks the same sample
+	again := buildStatsSample(slices.Clone(infos), statsOptions{Sample: 400, Seed: 2, Period: period}, read)
+	if *again.AgentShare != *share {
+		t.Errorf("same seed gave %+v, then %+v", *share, *again.AgentShare)
+	}
+
+	// Too few to stratify by week: by directory
+	small := buildStatsSample(slices.Clone(infos), statsOptions{Sample: 10, Seed: 1, Period: period}, read)
+	if small.StratifiedBy != "directory" || small.Strata != 2 {
+		t.Errorf("small sample stratified by %q into %d strata, want directory into 2", small.StratifiedBy, small.Strata)
+	}
+
+	// Sampling everything is exact
+	all := buildStatsSample(slices.Clone(infos), statsOptions{Sample: 5000, Seed: 1, Period: period}, read)
+	if all.Sampled != 4000 || all.AgentShare.Low != all.AgentShare.High || all.AgentLines.Estimate != float64(agentLines) {
+		t.Errorf("full sample = %+v, want exact totals", all)
+	}
+}
+
+func TestAllocateStatsSample(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sizes []int
+		n     int
+		want  []int
+	}{
+		{[]int{100, 100}, 10, []int{5, 5}},
+		{[]int{900, 90, 10}, 20, []int{16, 3, 1}},
+		// Small strata are capped; the rest goes elsewhere
+		{[]int{2, 98}, 60, []int{2, 58}},
+		{[]int{3, 4}, 10, []int{3, 4}},
+	}
+	for _, tt := range tests {
+		if got := allocateStatsSample(tt.sizes, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("allocateStatsSample(%v, %d) = %v, want %v", tt.sizes, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestStatsTopDirOf(t *testing.T) {
+	t.Parallel()
+
+	if got := statsTopDirOf([]string{"cmd/a.go", "docs/x.md", "docs/y.md"}); got != "docs" {
+		t.Errorf("statsTopDirOf() = %q, want docs", got)
+	}
+	if got := statsTopDirOf([]string{"README.md"}); got != "." {
+		t.Errorf("statsTopDirOf(root file) = %q, want .", got)
+	}
+	if got := statsTopDirOf(nil); got != "." {
+		t.Errorf("statsTopDirOf(nil) = %q, want .", got)
+	}
+}
@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/reportfmt"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+)
+
+// survivalBucketBounds are the upper bounds (exclusive) of the age buckets
+// of `entire stats survival`; the last bucket is open-ended.
+var (
+	survivalAgeBounds     = []int{7, 30, 90}
+	survivalAgeLabels     = []string{"< 1 week", "1-4 weeks", "1-3 months", "3+ months"}
+	survivalCommitsBounds = []int{10, 50, 200}
+	survivalCommitsLabels = []string{"< 10", "10-49", "50-199", "200+"}
+)
+
+func newStatsSurvivalCmd() *cobra.Command {
+	var revFlag, rangeFlag string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "survival",
+		Short: "Show how much agent-written code survives over time",
+		Long: `Blames every file agents wrote in, as of HEAD, to find which of the lines
+each checkpoint's agent wrote are still there, and reports the fraction that
+survives by how long ago, in days and in commits, the checkpoint was committed:
+
+  By age:
+    < 1 week      97%
+    1-3 months    71%
+
+A line survives while 'entire blame' still traces it to the agent of the
+checkpoint that wrote it; rewritten, moved or deleted lines don't. Only
+checkpoints linked from commits reachable from --rev count (and in --range,
+if given), and only those whose attribution recorded agent lines.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runStatsSurvival(cmd.Context(), cmd.OutOrStdout(), revFlag, rangeFlag, jsonFlag, time.Now())
+		},
+	}
+
+	cmd.Flags().StringVar(&revFlag, "rev", "HEAD", "Measure survival as of this revision")
+	addCommitRangeFlag(cmd, &rangeFlag)
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// survivalCheckpoint is one checkpoint's agent lines and how many survive.
+type survivalCheckpoint struct {
+	CheckpointID   id.CheckpointID    `json:"checkpoint_id"`
+	Commit         string             `json:"commit"`
+	CommittedAt    reportfmt.Time     `json:"committed_at"`
+	AgeDays        int                `json:"age_days"`
+	CommitsSince   int                `json:"commits_since"`
+	AgentLines     int                `json:"agent_lines"`
+	SurvivingLines int                `json:"surviving_lines"`
+	Survival       *reportfmt.Percent `json:"survival,omitempty"`
+
+	// files are the files the checkpoint's agent wrote lines in
+	files []string
+}
+
+type survivalBucket struct {
+	Label          string             `json:"label"`
+	Checkpoints    int                `json:"checkpoints"`
+	AgentLines     int                `json:"agent_lines"`
+	SurvivingLines int                `json:"surviving_lines"`
+	Survival       *reportfmt.Percent `json:"survival,omitempty"`
+}
+
+type survivalReport struct {
+	Revision       string             `json:"revision"`
+	Range          string             `json:"range,omitempty"`
+	AgentLines     int                `json:"agent_lines"`
+	SurvivingLines int                `json:"surviving_lines"`
+	Survival       *reportfmt.Percent `json:"survival,omitempty"`
+	// MixedLines are lines of checkpointed commits whose attribution can't
+	// tell agent from human; they don't count as surviving.
+	MixedLines  int                  `json:"mixed_lines"`
+	ByAge       []survivalBucket     `json:"by_age"`
+	ByCommits   []survivalBucket     `json:"by_commits"`
+	Checkpoints []survivalCheckpoint `json:"checkpoints"`
+}
+
+func runStatsSurvival(ctx context.Context, w io.Writer, rev, rangeSpec string, jsonOutput bool, now time.Time) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("revision not found: %s", rev)
+	}
+	head, err := repo.CommitObject(*hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	commitRange, err := resolveCommitRange(repo, rangeSpec)
+	if err != nil {
+		return err
+	}
+
+	report, err := measureSurvival(ctx, repo, head, commitRange, now)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal survival: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+	if len(report.Checkpoints) == 0 && commitRange != nil {
+		fmt.Fprintf(w, "No checkpoints with agent lines are linked from %s in %s.\n", rev, commitRange.Spec)
+		return nil
+	}
+	if len(report.Checkpoints) == 0 {
+		fmt.Fprintf(w, "No checkpoints with agent lines are linked from %s.\n", rev)
+		return nil
+	}
+	printSurvivalReport(w, report)
+	return nil
+}
+
+// measureSurvival blames, as of head, every file a checkpoint's agent wrote
+// lines in, and counts the lines still traced to that checkpoint's agent.
+// Only checkpoints of commits in commitRange (nil = all) count.
+func measureSurvival(ctx context.Context, repo *git.Repository, head *object.Commit, commitRange *commitRange, now time.Time) (*survivalReport, error) {
+	store := checkpoint.NewGitStore(repo)
+	report := &survivalReport{Revision: head.Hash.String(), Checkpoints: []survivalCheckpoint{}}
+	if commitRange != nil {
+		report.Range = commitRange.Spec
+	}
+
+	// Commits newest first, so a commit's index is the number of commits since
+	iter, err := repo.Log(&git.LogOptions{From: head.Hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk history: %w", err)
+	}
+	byCommit := make(map[plumbing.Hash]int)
+	index := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		defer func() { index++ }()
+		if commitRange != nil && !commitRange.Commits[c.Hash] {
+			return nil
+		}
+		cpID, ok := trailers.ParseCheckpoint(c.Message)
+		if !ok {
+			return nil
+		}
+		cp, ok := readSurvivalCheckpoint(ctx, store, cpID)
+		if !ok {
+			return nil
+		}
+		cp.Commit = c.Hash.String()
+		cp.CommittedAt = reportfmt.NewTime(c.Committer.When)
+		cp.AgeDays = max(0, int(now.Sub(c.Committer.When).Hours()/24))
+		cp.CommitsSince = index
+		byCommit[c.Hash] = len(report.Checkpoints)
+		report.Checkpoints = append(report.Checkpoints, cp)
+		return nil
+	})
+	iter.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk history: %w", err)
+	}
+
+	fileSet := make(map[string]bool)
+	for _, cp := range report.Checkpoints {
+		for _, f := range cp.files {
+			fileSet[f] = true
+		}
+	}
+	files := make([]string, 0, len(fileSet))
+	for f := range fileSet {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	for _, relPath := range files {
+		content, err := fileContentAt(head, relPath)
+		if err != nil {
+			continue // Deleted since: none of its lines survive
+		}
+		blame, err := git.Blame(head, relPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to blame %s: %w", relPath, err)
+		}
+		commits := make(map[plumbing.Hash]*blameCommit)
+		for i, line := range blame.Lines {
+			cpIndex, ok := byCommit[line.Hash]
+			if !ok {
+				continue
+			}
+			info, ok := commits[line.Hash]
+			if !ok {
+				if info, err = loadBlameCommit(ctx, repo, store, line.Hash, relPath, content); err != nil {
+					return nil, err
+				}
+				commits[line.Hash] = info
+			}
+			var entry blameLineJSON
+			classifyBlameLine(&entry, info, i)
+			switch entry.Origin {
+			case blameOriginAgent:
+				report.Checkpoints[cpIndex].SurvivingLines++
+			case blameOriginMixed:
+				report.MixedLines++
+			}
+		}
+	}
+
+	report.ByAge = survivalBuckets(survivalAgeLabels)
+	report.ByCommits = survivalBuckets(survivalCommitsLabels)
+	for i := range report.Checkpoints {
+		cp := &report.Checkpoints[i]
+		// Recorded agent lines can undercount what blame finds, e.g. after
+		// the agent's lines were moved within the commit
+		cp.SurvivingLines = min(cp.SurvivingLines, cp.AgentLines)
+		cp.Survival = survivalPercent(cp.SurvivingLines, cp.AgentLines)
+		report.AgentLines += cp.AgentLines
+		report.SurvivingLines += cp.SurvivingLines
+		addToSurvivalBucket(&report.ByAge[survivalBucketIndex(cp.AgeDays, survivalAgeBounds)], cp)
+		addToSurvivalBucket(&report.ByCommits[survivalBucketIndex(cp.CommitsSince, survivalCommitsBounds)], cp)
+	}
+	report.Survival = survivalPercent(report.SurvivingLines, report.AgentLines)
+	for _, buckets := range [][]survivalBucket{report.ByAge, report.ByCommits} {
+		for i := range buckets {
+			buckets[i].Survival = survivalPercent(buckets[i].SurvivingLines, buckets[i].AgentLines)
+		}
+	}
+	return report, nil
+}
+
+// readSurvivalCheckpoint reads the agent lines a checkpoint's sessions
+// recorded. ok is false if there are none.
+func readSurvivalCheckpoint(ctx context.Context, store *checkpoint.GitStore, cpID id.CheckpointID) (cp survivalCheckpoint, ok bool) {
+	summary, err := store.ReadCommitted(ctx, cpID)
+	if err != nil || summary == nil {
+		return cp, false
+	}
+	cp.CheckpointID = cpID
+	files := make(map[string]bool)
+	for i := range summary.Sessions {
+		metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+		if err != nil {
+			continue
+		}
+		// Superseded attributions were folded into another checkpoint by a fixup
+		attr := metadata.InitialAttribution
+		if attr == nil || attr.SupersededBy != "" {
+			continue
+		}
+		cp.AgentLines += attr.AgentLines
+		for _, f := range attr.Files {
+			if f.AgentLines > 0 {
+				files[f.Path] = true
+			}
+		}
+		if len(attr.Files) == 0 {
+			for _, f := range metadata.FilesTouched {
+				files[f] = true
+			}
+		}
+	}
+	for f := range files {
+		cp.files = append(cp.files, f)
+	}
+	return cp, cp.AgentLines > 0
+}
+
+func survivalBuckets(labels []string) []survivalBucket {
+	buckets := make([]survivalBucket, len(labels))
+	for i, label := range labels {
+		buckets[i].Label = label
+	}
+	return buckets
+}
+
+// survivalBucketIndex returns the bucket of v: the first bound it's below,
+// or the open-ended last bucket.
+func survivalBucketIndex(v int, bounds []int) int {
+	for i, bound := range bounds {
+		if v < bound {
+			return i
+		}
+	}
+	return len(bounds)
+}
+
+func addToSurvivalBucket(b *survivalBucket, cp *survivalCheckpoint) {
+	b.Checkpoints++
+	b.AgentLines += cp.AgentLines
+	b.SurvivingLines += cp.SurvivingLines
+}
+
+func survivalPercent(surviving, written int) *reportfmt.Percent {
+	if written == 0 {
+		return nil
+	}
+	p := reportfmt.Percent(math.Min(100, float64(surviving)*100/float64(written)))
+	return &p
+}
+
+func printSurvivalReport(w io.Writer, report *survivalReport) {
+	loc := reportfmt.DetectLocale()
+	fmt.Fprintf(w, "Agent code survival at %s\n\n", report.Revision[:7])
+	fmt.Fprintf(w, "  %s of %s agent lines from %d checkpoint(s) survive (%s)\n",
+		loc.Int(report.SurvivingLines), loc.Int(report.AgentLines), len(report.Checkpoints),
+		loc.Percent(float64(*report.Survival), 0))
+	if report.MixedLines > 0 {
+		fmt.Fprintf(w, "  %s more line(s) from checkpoints without line-level attribution aren't counted\n", loc.Int(report.MixedLines))
+	}
+
+	for _, section := range []struct {
+		title   string
+		buckets []survivalBucket
+	}{
+		{"By age", report.ByAge},
+		{"By commits since", report.ByCommits},
+	} {
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, b := range section.buckets {
+			if b.Checkpoints == 0 {
+				fmt.Fprintf(w, "  %-11s %-20s  -\n", b.Label, "")
+				continue
+			}
+			fmt.Fprintf(w, "  %-11s %-20s  %s  (%s of %s lines, %d checkpoint(s))\n",
+				b.Label, bar(b.SurvivingLines, b.AgentLines, 20), loc.Percent(float64(*b.Survival), 0),
+				loc.Int(b.SurvivingLines), loc.Int(b.AgentLines), b.Checkpoints)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+)
+
+func TestRunStatsSurvival(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	repo, dir := initTestRepo(t)
+
+	commitBlameTestFile(t, repo, dir, "package main\n\n", "human start")
+	// The agent wrote lines 3-4
+	cpID := id.MustCheckpointID("d1a2e3c4d5f6")
+	writeTestCheckpoint(t, repo, checkpoint.WriteCommittedOptions{
+		CheckpointID: cpID,
+		SessionID:    "2026-10-14-survival",
+		FilesTouched: []string{"main.go"},
+		InitialAttribution: &checkpoint.InitialAttribution{
+			AgentLines: 2, TotalCommitted: 2,
+			Files: []checkpoint.FileAttribution{{
+				Path: "main.go", AgentLines: 2, TotalCommitted: 2,
+				AgentRanges: []checkpoint.LineRange{{Start: 3, End: 4}},
+			}},
+		},
+	})
+	commitBlameTestFile(t, repo, dir, "package main\n\nfunc main() {\n}\n",
+		"add main\n\nEntire-Checkpoint: "+cpID.String()+"\n")
+	// A human rewrites one of them later
+	commitBlameTestFile(t, repo, dir, "package main\n\nfunc main() { run() }\n}\n", "human edit")
+
+	now := time.Now().Add(10 * 24 * time.Hour)
+	var buf bytes.Buffer
+	if err := runStatsSurvival(context.Background(), &buf, "HEAD", "", true, now); err != nil {
+		t.Fatalf("runStatsSurvival() error = %v", err)
+	}
+	var report struct {
+		AgentLines     int     `json:"agent_lines"`
+		SurvivingLines int     `json:"surviving_lines"`
+		Survival       float64 `json:"survival"`
+		ByAge          []struct {
+			Label       string `json:"label"`
+			Checkpoints int    `json:"checkpoints"`
+		} `json:"by_age"`
+		Checkpoints []struct {
+			CheckpointID string `json:"checkpoint_id"`
+			CommitsSince int    `json:"commits_since"`
+			AgeDays      int    `json:"age_days"`
+		} `json:"checkpoints"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if report.AgentLines != 2 || report.SurvivingLines != 1 || report.Survival != 50 {
+		t.Errorf("survival = %d of %d (%v%%), want 1 of 2 (50%%)", report.SurvivingLines, report.AgentLines, report.Survival)
+	}
+	if len(report.Checkpoints) != 1 || report.Checkpoints[0].CheckpointID != cpID.String() ||
+		report.Checkpoints[0].CommitsSince != 1 || report.Checkpoints[0].AgeDays != 10 {
+		t.Errorf("checkpoints = %+v, want %s, 1 commit and 10 days ago", report.Checkpoints, cpID)
+	}
+	if report.ByAge[1].Label != "1-4 weeks" || report.ByAge[1].Checkpoints != 1 {
+		t.Errorf("by age = %+v, want the checkpoint in 1-4 weeks", report.ByAge)
+	}
+
+	buf.Reset()
+	if err := runStatsSurvival(context.Background(), &buf, "HEAD", "", false, now); err != nil {
+		t.Fatalf("runStatsSurvival() error = %v", err)
+	}
+	for _, want := range []string{"1 of 2 agent lines from 1 checkpoint(s) survive (50%)", "1-4 weeks", "< 10"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	// --range only counts the checkpoints of commits in the range
+	buf.Reset()
+	if err := runStatsSurvival(context.Background(), &buf, "HEAD", "HEAD~1..", false, now); err != nil {
+		t.Fatalf("runStatsSurvival(range) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "No checkpoints with agent lines are linked from HEAD in HEAD~1..") {
+		t.Errorf("checkpoint outside the range was counted:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := runStatsSurvival(context.Background(), &buf, "HEAD", "HEAD~2..HEAD~1", false, now); err != nil {
+		t.Fatalf("runStatsSurvival(range) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "1 of 2 agent lines from 1 checkpoint(s) survive (50%)") {
+		t.Errorf("checkpoint in the range is missing:\n%s", buf.String())
+	}
+}
+
+func TestSurvivalBucketIndex(t *testing.T) {
+	t.Parallel()
+	for v, want := range map[int]int{0: 0, 6: 0, 7: 1, 29: 1, 30: 2, 90: 3, 1000: 3} {
+		if got := survivalBucketIndex(v, survivalAgeBounds); got != want {
+			t.Errorf("survivalBucketIndex(%d) = %d, want %d", v, got, want)
+		}
+	}
+}
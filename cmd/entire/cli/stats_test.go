@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestBuildStatsReport(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday; the current week starts Monday 2026-10-12
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	commits := []statsCommit{
+		{
+			CreatedAt:      time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC),
+			AgentLines:     30,
+			TotalCommitted: 40,
+			FilesTouched:   []string{"cmd/entire/cli/stats.go", "cmd/entire/cli/root.go", "README.md"},
+			TokenUsage:     agent.TokenUsage{InputTokens: 1000, OutputTokens: 500},
+		},
+		{
+			CreatedAt:      time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC),
+			AgentLines:     10,
+			TotalCommitted: 60,
+			FilesTouched:   []string{"docs/architecture/stats.md"},
+			TokenUsage:     agent.TokenUsage{InputTokens: 2000, CacheReadTokens: 1000},
+			// A CI run
+			AutonomousAgentLines: 10,
+		},
+		{
+			// Previous week
+			CreatedAt:      time.Date(2026, 10, 11, 23, 0, 0, 0, time.UTC),
+			AgentLines:     5,
+			TotalCommitted: 5,
+		},
+		{
+			// Outside both windows
+			CreatedAt:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			AgentLines:     100,
+			TotalCommitted: 100,
+			FilesTouched:   []string{"old/file.go"},
+		},
+	}
+
+	report := buildStatsReport(commits, statsOptions{Weeks: 3, Days: 2, Top: 2, InputPrice: 3, OutputPrice: 15, Period: reportPeriod{WeekStart: time.Monday}}, now)
+
+	if report.Commits != 4 {
+		t.Errorf("Commits = %d, want 4", report.Commits)
+	}
+	if report.SupervisedAgentLines != 135 || report.AutonomousAgentLines != 10 {
+		t.Errorf("supervised/autonomous = %d/%d, want 135/10", report.SupervisedAgentLines, report.AutonomousAgentLines)
+	}
+
+	// Weeks: oldest first, ending with the current week
+	if len(report.Weeks) != 3 {
+		t.Fatalf("got %d weeks, want 3", len(report.Weeks))
+	}
+	if got := report.Weeks[2].Start.Format(time.DateOnly); got != "2026-10-12" {
+		t.Errorf("current week start = %s, want 2026-10-12", got)
+	}
+	if report.Weeks[0].AgentShare != nil {
+		t.Errorf("week without commits has share %v", *report.Weeks[0].AgentShare)
+	}
+	if share := report.Weeks[1].AgentShare; share == nil || *share != 100 {
+		t.Errorf("previous week share = %v, want 100", share)
+	}
+	if share := report.Weeks[2].AgentShare; share == nil || *share != 40 {
+		t.Errorf("current week share = %v, want 40", share)
+	}
+
+	// Directories: 30 lines over 3 files, 10 in docs, 100 in old/ (all-time)
+	wantDirs := []statsDir{{Path: "old", AgentLines: 100}, {Path: "cmd/entire", AgentLines: 20}}
+	if len(report.Directories) != len(wantDirs) {
+		t.Fatalf("Directories = %+v, want %+v", report.Directories, wantDirs)
+	}
+	for i, want := range wantDirs {
+		if report.Directories[i] != want {
+			t.Errorf("Directories[%d] = %+v, want %+v", i, report.Directories[i], want)
+		}
+	}
+
+	// Days: yesterday and today
+	if len(report.Days) != 2 || report.Days[0].Date != "2026-10-13" || report.Days[1].Date != "2026-10-14" {
+		t.Fatalf("Days = %+v", report.Days)
+	}
+	if report.Days[0].Tokens != 1500 || report.Days[1].Tokens != 3000 {
+		t.Errorf("day tokens = %d, %d; want 1500, 3000", report.Days[0].Tokens, report.Days[1].Tokens)
+	}
+	// 1000*3 + 500*15 per million
+	if got, want := float64(report.Days[0].Cost), 0.0105; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("day cost = %v, want %v", got, want)
+	}
+	// Cache reads default to the input price: 3000*3 per million
+	if got, want := float64(report.Days[1].Cost), 0.009; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("day cost = %v, want %v", got, want)
+	}
+	if !report.HasCost {
+		t.Error("HasCost = false with prices set")
+	}
+}
+
+func TestSplitAgentLines(t *testing.T) {
+	t.Parallel()
+
+	got := splitAgentLines(10, []string{"a/x.go", "a/y.go", "b/z.go"})
+	if got["a"] != 7 || got["b"] != 3 {
+		t.Errorf("splitAgentLines() = %v, want a:7 b:3", got)
+	}
+	if got := splitAgentLines(5, nil); got != nil {
+		t.Errorf("splitAgentLines() without files = %v, want nil", got)
+	}
+}
+
+func TestStatsDirOf(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"README.md":               ".",
+		"docs/guide.md":           "docs",
+		"cmd/entire/cli/stats.go": "cmd/entire",
+	}
+	for file, want := range tests {
+		if got := statsDirOf(file); got != want {
+			t.Errorf("statsDirOf(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	t.Parallel()
+
+	if got := sparkline([]float64{0, 50, 100, -1}, 100); got != "▁▅█·" {
+		t.Errorf("sparkline() = %q, want %q", got, "▁▅█·")
+	}
+	if got := sparkline([]float64{0, 0}, 0); got != "▁▁" {
+		t.Errorf("sparkline() with zero max = %q, want %q", got, "▁▁")
+	}
+}
+
+func TestBar(t *testing.T) {
+	t.Parallel()
+
+	if got := bar(10, 10, 4); got != "████" {
+		t.Errorf("bar(10, 10, 4) = %q", got)
+	}
+	if got := bar(1, 100, 4); got != "█" {
+		t.Errorf("bar(1, 100, 4) = %q, want a single cell", got)
+	}
+	if got := bar(0, 100, 4); got != "" {
+		t.Errorf("bar(0, 100, 4) = %q, want empty", got)
+	}
+}
+
+func TestBuildStatsReport_Period(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	commits := []statsCommit{
+		{CreatedAt: time.Date(2026, 9, 20, 12, 0, 0, 0, time.UTC), AgentLines: 1, TotalCommitted: 1},
+		{CreatedAt: time.Date(2026, 9, 29, 12, 0, 0, 0, time.UTC), AgentLines: 2, TotalCommitted: 4, TokenUsage: agent.TokenUsage{InputTokens: 100}},
+		{CreatedAt: time.Date(2026, 10, 4, 23, 30, 0, 0, time.UTC), AgentLines: 3, TotalCommitted: 3},
+		{CreatedAt: time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC), AgentLines: 7, TotalCommitted: 7},
+	}
+
+	// Sunday-first weeks in UTC-2: the 2026-10-04 commit lands on Sunday evening
+	period, err := parseReportPeriod("2026-09-28", "2026-10-05", now, time.FixedZone("UTC-2", -2*60*60), time.Sunday)
+	if err != nil {
+		t.Fatalf("parseReportPeriod() error = %v", err)
+	}
+	report := buildStatsReport(commits, statsOptions{Weeks: 12, Days: 3, Top: 5, Period: period}, now)
+
+	if report.Commits != 2 {
+		t.Errorf("Commits = %d, want 2 (only commits in the period)", report.Commits)
+	}
+	// 2026-09-27 (Sun) through the week containing 2026-10-05
+	if len(report.Weeks) != 2 {
+		t.Fatalf("got %d weeks, want 2", len(report.Weeks))
+	}
+	if got := report.Weeks[0].Start.Format(time.DateOnly); got != "2026-09-27" {
+		t.Errorf("first week start = %s, want 2026-09-27", got)
+	}
+	if report.Weeks[0].AgentLines != 2 || report.Weeks[1].AgentLines != 3 {
+		t.Errorf("week agent lines = %d, %d; want 2, 3", report.Weeks[0].AgentLines, report.Weeks[1].AgentLines)
+	}
+	// Days: the last --days days of the period
+	if len(report.Days) != 3 || report.Days[2].Date != "2026-10-05" {
+		t.Errorf("days = %+v, want 3 days ending 2026-10-05", report.Days)
+	}
+}
+
+func TestBuildStatsReport_TaskTypes(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	commits := []statsCommit{
+		{CreatedAt: now, AgentLines: 30, TotalCommitted: 40, TaskLines: map[string]int{"bugfix": 20, "tests": 10}},
+		{CreatedAt: now, AgentLines: 10, TotalCommitted: 10, TaskLines: map[string]int{"bugfix": 10}},
+		{CreatedAt: now, AgentLines: 5, TotalCommitted: 50, TaskLines: map[string]int{"": 5}},
+	}
+	report := buildStatsReport(commits, statsOptions{Weeks: 1, Days: 1, Top: 5, Period: reportPeriod{WeekStart: time.Monday}}, now)
+
+	if len(report.TaskTypes) != 3 {
+		t.Fatalf("TaskTypes = %+v, want bugfix, tests and untagged", report.TaskTypes)
+	}
+	bugfix := report.TaskTypes[0]
+	if bugfix.TaskType != "bugfix" || bugfix.Commits != 2 || bugfix.AgentLines != 30 || bugfix.TotalCommitted != 50 {
+		t.Errorf("bugfix = %+v, want 30 lines in 2 commits of 50 lines", bugfix)
+	}
+	if bugfix.AgentShare == nil || *bugfix.AgentShare != 60 {
+		t.Errorf("bugfix share = %v, want 60", bugfix.AgentShare)
+	}
+	if report.TaskTypes[1].TaskType != "tests" || report.TaskTypes[2].TaskType != statsUntaggedTaskType {
+		t.Errorf("TaskTypes order = %+v, want most agent lines first", report.TaskTypes)
+	}
+
+	var stdout bytes.Buffer
+	printStatsReport(&stdout, report)
+	if want := "bugfix    ████████████████████ 30 lines in 2 commits (60% of their lines)"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, stdout.String())
+	}
+}
+
+func TestPrintStatsReport(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	report := buildStatsReport([]statsCommit{{
+		CreatedAt:      now,
+		AgentLines:     8,
+		TotalCommitted: 10,
+		FilesTouched:   []string{"src/main.go"},
+		TokenUsage:     agent.TokenUsage{InputTokens: 1500},
+	}}, statsOptions{Weeks: 4, Days: 3, Top: 5, Period: reportPeriod{WeekStart: time.Monday}}, now)
+
+	var stdout bytes.Buffer
+	printStatsReport(&stdout, report)
+	output := stdout.String()
+
+	for _, want := range []string{
+		"Agent share per week (last 4 weeks)",
+		"···▇  latest 80% · avg 80%",
+		"src  ████████████████████ 8",
+		"Tokens per day (last 3 days)",
+		"total 1.5k · peak 1.5k on 2026-10-14",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestStatsReport_Formatting(t *testing.T) {
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	report := buildStatsReport([]statsCommit{{
+		CreatedAt:      now,
+		AgentLines:     2,
+		TotalCommitted: 3,
+		FilesTouched:   []string{"src/main.go"},
+		TokenUsage:     agent.TokenUsage{InputTokens: 1500, OutputTokens: 1},
+	}}, statsOptions{Weeks: 1, Days: 1, Top: 5, InputPrice: 3, OutputPrice: 15, Period: reportPeriod{WeekStart: time.Monday}}, now)
+
+	// JSON is the same in every locale: fixed decimals, UTC timestamps
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"start":"2026-10-11T22:00:00Z"`, `"agent_share":66.67`, `"cost":0.0045`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON missing %s: %s", want, data)
+		}
+	}
+
+	var stdout bytes.Buffer
+	printStatsReport(&stdout, report)
+	for _, want := range []string{"latest 67\u00a0% · avg 67\u00a0%", "total $0,00"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("German output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRunStats_NoCheckpoints(t *testing.T) {
+	setupCleanTestRepo(t)
+
+	var stdout bytes.Buffer
+	if err := runStats(context.Background(), &stdout, statsOptions{Weeks: 12, Days: 14, Top: 5, Period: reportPeriod{WeekStart: time.Monday}}, false); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "No committed checkpoints yet.") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestBuildStatsEpochs(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC) }
+	states := []*strategy.SessionState{
+		{
+			SessionID: "refactor-session",
+			TaskType:  "refactor",
+			StepCount: 2,
+			CheckpointEpochs: []session.CheckpointEpoch{
+				{FirstStep: 1, LastStep: 2, LastCommit: "abc", StartedAt: day(10), EndedAt: day(11), FilesTouched: []string{"a.go"}, TaskCheckpoints: 1},
+			},
+		},
+		{
+			// Two steps from before epochs were recorded, then one epoch
+			SessionID: "bugfix-session",
+			TaskType:  "bugfix",
+			StepCount: 3,
+			CheckpointEpochs: []session.CheckpointEpoch{
+				{FirstStep: 3, LastStep: 3, LastCommit: "def", StartedAt: day(2), EndedAt: day(2)},
+			},
+		},
+	}
+
+	all := buildStatsEpochs(states, statsOptions{})
+	if len(all) != 3 {
+		t.Fatalf("got %d epochs, want 3: %+v", len(all), all)
+	}
+	if !all[0].StartedAt.IsZero() || all[0].SessionID != "bugfix-session" || all[0].Checkpoints != 2 || all[0].Files != nil {
+		t.Errorf("first epoch = %+v, want the un-indexed bugfix steps", all[0])
+	}
+	if all[2].SessionID != "refactor-session" || all[2].TaskCheckpoints != 1 || all[2].Files == nil || *all[2].Files != 1 {
+		t.Errorf("last epoch = %+v, want the refactor epoch", all[2])
+	}
+
+	since := buildStatsEpochs(states, statsOptions{Period: reportPeriod{Since: day(5)}})
+	if len(since) != 1 || since[0].SessionID != "refactor-session" {
+		t.Errorf("--since epochs = %+v, want only the refactor epoch", since)
+	}
+
+	bugfix := buildStatsEpochs(states, statsOptions{TaskType: "bugfix"})
+	if len(bugfix) != 2 || bugfix[1].Index != 1 {
+		t.Errorf("--task-type bugfix epochs = %+v, want both bugfix epochs", bugfix)
+	}
+
+	var stdout bytes.Buffer
+	printStatsEpochs(&stdout, all)
+	for _, want := range []string{"Uncommitted checkpoints by epoch", "refactor-session  0      2            1"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
@@ -12,10 +12,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/session"
 	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
 	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
 
 	"github.com/spf13/cobra"
 )
@@ -26,7 +29,10 @@ func newStatusCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show Entire status",
-		Long:  "Show whether Entire is currently enabled or disabled",
+		Long: `Show whether Entire is currently enabled or disabled, the active sessions,
+and where this worktree's sessions stand: checkpoints since the last commit,
+files the agent touched that are still uncommitted, and whether HEAD moved away
+from the session's base commit.`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return runStatus(cmd.OutOrStdout(), detailed)
 		},
@@ -85,6 +91,11 @@ func runStatus(w io.Writer, detailed bool) error {
 
 	if settings.Enabled {
 		writeActiveSessions(w)
+		writeWorktreeStatus(w)
+		writeFilesystemStatus(w)
+		writeQuotaStatus(w)
+		writeCIStatus(w)
+		writeVCSStatus(w)
 	}
 
 	return nil
@@ -120,11 +131,56 @@ func runStatusDetailed(w io.Writer, settingsPath, localSettingsPath string, proj
 
 	if effectiveSettings.Enabled {
 		writeActiveSessions(w)
+		writeWorktreeStatus(w)
+		writeFilesystemStatus(w)
+		writeQuotaStatus(w)
+		writeCIStatus(w)
+		writeVCSStatus(w)
 	}
 
 	return nil
 }
 
+// writeQuotaStatus shows Entire's footprint against the quota. Writes nothing
+// without a quota.
+func writeQuotaStatus(w io.Writer) {
+	status, err := strategy.CheckQuota(context.Background(), false)
+	if err != nil || status == nil {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Storage: %s of %s quota\n", settings.FormatByteSize(status.Used), settings.FormatByteSize(status.Limit))
+	if status.Exceeded() {
+		fmt.Fprintln(w, "  Over quota: checkpoints are metadata-only (no file contents or transcripts), free space with `entire checkpoint prune`")
+	}
+}
+
+// writeCIStatus notes CI mode. Writes nothing outside CI.
+func writeCIStatus(w io.Writer) {
+	env := cienv.Detect()
+	if !env.Enabled {
+		return
+	}
+	fmt.Fprintln(w)
+	branch := ""
+	if env.Branch != "" {
+		branch = fmt.Sprintf(", branch %s", env.Branch)
+	}
+	fmt.Fprintf(w, "CI mode (%s%s): shadow branches are keyed by commit, prompts are skipped\n", env.Provider, branch)
+}
+
+// writeVCSStatus notes where checkpoints go in jj repositories. Writes
+// nothing for plain git.
+func writeVCSStatus(w io.Writer) {
+	worktreePath, err := paths.RepoRoot()
+	if err != nil || vcs.DetectPath(worktreePath) != vcs.Jujutsu {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Jujutsu repository: checkpoints are hidden refs under %s, not bookmarks\n", vcs.JujutsuShadowRefPrefix)
+	fmt.Fprintln(w, "  jj doesn't run git hooks: commits made with `jj commit` aren't linked to checkpoints, commit with git for that")
+}
+
 // formatSettingsStatusShort formats a short settings status line.
 // Output format: "Enabled (manual-commit)" or "Disabled (auto-commit)"
 func formatSettingsStatusShort(settings *EntireSettings) string {
@@ -286,6 +342,67 @@ func writeActiveSessions(w io.Writer) {
 	}
 }
 
+// maxStatusFiles caps the uncommitted files listed per session.
+const maxStatusFiles = 5
+
+// writeWorktreeStatus writes where this worktree's sessions stand: checkpoints
+// not yet in a commit, the agent's uncommitted files, and whether HEAD moved
+// away from the session's base. Writes nothing outside a worktree.
+func writeWorktreeStatus(w io.Writer) {
+	statuses, err := strategy.WorktreeSessionStatuses(context.Background())
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "This Worktree:")
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "  No active session")
+		return
+	}
+	for _, status := range statuses {
+		st := status.State
+		agentLabel := string(st.AgentType)
+		if agentLabel == "" {
+			agentLabel = unknownPlaceholder
+		}
+		phase := string(st.Phase)
+		if phase == "" {
+			phase = unknownPlaceholder
+		}
+		fmt.Fprintf(w, "  [%s] %s (%s)\n", agentLabel, st.SessionID, phase)
+
+		switch st.StepCount {
+		case 0:
+			fmt.Fprintln(w, "    No checkpoints since the last commit")
+		case 1:
+			fmt.Fprintln(w, "    1 checkpoint since the last commit")
+		default:
+			fmt.Fprintf(w, "    %d checkpoints since the last commit\n", st.StepCount)
+		}
+
+		if files := status.UncommittedFiles; len(files) > 0 {
+			list := strings.Join(files[:min(len(files), maxStatusFiles)], ", ")
+			if len(files) > maxStatusFiles {
+				list += fmt.Sprintf(" (+%d more)", len(files)-maxStatusFiles)
+			}
+			fmt.Fprintf(w, "    Uncommitted agent changes: %s\n", list)
+		}
+
+		if status.Diverged() {
+			base := st.BaseCommit[:min(len(st.BaseCommit), 7)]
+			if status.HeadAhead {
+				fmt.Fprintf(w, "    HEAD moved past the session base %s\n", base)
+			} else {
+				fmt.Fprintf(w, "    HEAD diverged from the session base %s (rebase, reset or branch switch)\n", base)
+			}
+			if status.MigrationPending {
+				fmt.Fprintf(w, "    Shadow branch migration pending: %s moves to HEAD on the next prompt\n", status.ShadowBranch)
+			}
+		}
+	}
+}
+
 // resolveWorktreeBranch resolves the current branch for a worktree path.
 func resolveWorktreeBranch(worktreePath string) string {
 	cmd := exec.CommandContext(context.Background(), "git", "-C", worktreePath, "rev-parse", "--abbrev-ref", "HEAD")
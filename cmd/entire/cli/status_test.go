@@ -3,12 +3,14 @@ package cli
 import (
 	"bytes"
 	"context"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
 )
 
 func TestRunStatus_Enabled(t *testing.T) {
@@ -387,3 +389,56 @@ func TestWriteActiveSessions_EndedSessionsExcluded(t *testing.T) {
 		t.Errorf("Expected empty output with only ended sessions, got: %s", buf.String())
 	}
 }
+
+func TestWriteWorktreeStatus(t *testing.T) {
+	repo, _ := setupCleanTestRepo(t)
+
+	var buf bytes.Buffer
+	writeWorktreeStatus(&buf)
+	if !strings.Contains(buf.String(), "No active session") {
+		t.Errorf("Expected no active session, got: %s", buf.String())
+	}
+
+	worktreePath, err := strategy.GetWorktreePath()
+	if err != nil {
+		t.Fatalf("GetWorktreePath() error = %v", err)
+	}
+	store, err := session.NewStateStore()
+	if err != nil {
+		t.Fatalf("NewStateStore() error = %v", err)
+	}
+	// The session started on a commit HEAD no longer descends from, and its
+	// shadow branch is still there
+	state := &session.State{
+		SessionID:    "status-session",
+		BaseCommit:   testBaseCommit,
+		WorktreePath: worktreePath,
+		StartedAt:    time.Now().Add(-10 * time.Minute),
+		Phase:        session.PhaseIdle,
+		StepCount:    2,
+		FilesTouched: []string{"agent.go", "committed.go"},
+		AgentType:    agent.AgentTypeClaudeCode,
+	}
+	if err := store.Save(context.Background(), state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	createShadowBranchRef(t, repo, testBaseCommit, "")
+	if err := os.WriteFile("agent.go", []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	buf.Reset()
+	writeWorktreeStatus(&buf)
+	output := buf.String()
+	for _, want := range []string{
+		"[Claude Code] status-session (idle)",
+		"2 checkpoints since the last commit",
+		"Uncommitted agent changes: agent.go\n",
+		"HEAD diverged from the session base abcdef1",
+		"Shadow branch migration pending: entire/abcdef1",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package strategy
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	cpkg "github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// amendedCommit returns the commit that head replaced with `git commit --amend`:
+// previous, if it's a different commit with the same parents. Returns nil
+// otherwise.
+func amendedCommit(repo *git.Repository, head *object.Commit, previous string) *object.Commit {
+	if previous == "" || previous == head.Hash.String() {
+		return nil
+	}
+	prev, err := repo.CommitObject(plumbing.NewHash(previous))
+	if err != nil || !slices.Equal(prev.ParentHashes, head.ParentHashes) {
+		return nil
+	}
+	return prev
+}
+
+// attributionBase returns the commit the session's next attribution is
+// calculated against.
+func attributionBase(state *SessionState) string {
+	if state.AttributionBaseCommit == "" {
+		return state.BaseCommit // backward compat
+	}
+	return state.AttributionBaseCommit
+}
+
+// sessionAttribution returns the index and attribution of sessionID in a
+// committed checkpoint, or -1 if the checkpoint has no such session.
+func sessionAttribution(store *cpkg.GitStore, checkpointID id.CheckpointID, sessionID string) (int, *cpkg.InitialAttribution) {
+	ctx := context.Background()
+	summary, err := store.ReadCommitted(ctx, checkpointID)
+	if err != nil || summary == nil {
+		return -1, nil
+	}
+	for i := range summary.Sessions {
+		metadata, err := store.ReadSessionMetadata(ctx, checkpointID, i)
+		if err == nil && metadata.SessionID == sessionID {
+			return i, metadata.InitialAttribution
+		}
+	}
+	return -1, nil
+}
+
+// foldAmendedAttribution adds the attribution the session recorded for the
+// amended commit to next, the attribution of what the amend changed, so
+// the rewritten record covers the whole commit. next is returned unchanged if
+// head isn't an amend of the session's last condensed commit.
+func foldAmendedAttribution(repo *git.Repository, store *cpkg.GitStore, checkpointID id.CheckpointID, state *SessionState, head *object.Commit, next *cpkg.InitialAttribution) *cpkg.InitialAttribution {
+	if next == nil || state.LastCheckpointID != checkpointID {
+		return next
+	}
+	amended := amendedCommit(repo, head, attributionBase(state))
+	if amended == nil {
+		return next
+	}
+	_, previous := sessionAttribution(store, checkpointID, state.SessionID)
+	if previous == nil {
+		return next
+	}
+	amendedTree, err := amended.Tree()
+	if err != nil {
+		return next
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return next
+	}
+	logging.Info(logging.WithComponent(context.Background(), "attribution"), "attribution: folding in amended commit",
+		slog.String("session_id", state.SessionID),
+		slog.String("amended", amended.Hash.String()))
+	return foldAttribution(previous, next, amended.Hash.String(), func(path string, ranges []cpkg.LineRange) []cpkg.LineRange {
+		return carryLineRanges(ranges, getFileContent(amendedTree, path), getFileContent(headTree, path))
+	}, nil)
+}
+
+// recordAmendWithoutCondensation updates the attributions of an amended
+// commit when the amend brought no new agent work: whatever it changed is
+// the human's. Each of the commit's sessions describes the whole commit, so
+// every session condensed into the amended commit is updated.
+func recordAmendWithoutCondensation(repo *git.Repository, checkpointID id.CheckpointID, state *SessionState, head *object.Commit) bool {
+	if state.LastCheckpointID != checkpointID {
+		return false
+	}
+	amended := amendedCommit(repo, head, attributionBase(state))
+	if amended == nil {
+		return false
+	}
+	store := cpkg.NewGitStore(repo)
+	index, previous := sessionAttribution(store, checkpointID, state.SessionID)
+	if previous == nil {
+		return false
+	}
+	amendedTree, err := amended.Tree()
+	if err != nil {
+		return false
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return false
+	}
+
+	// Attributed as if the agent touched the changed files but wrote nothing
+	changed := getAllChangedFilesBetweenTrees(amendedTree, headTree)
+	delta := calculateAttribution(configuredAttributionGranularity(), amendedTree, amendedTree, headTree, nil, changed, nil, nil)
+	if delta == nil {
+		// Message-only amend
+		delta = &cpkg.InitialAttribution{CalculatedAt: time.Now().UTC()}
+	}
+	folded := foldAttribution(previous, delta, amended.Hash.String(), func(path string, ranges []cpkg.LineRange) []cpkg.LineRange {
+		return carryLineRanges(ranges, getFileContent(amendedTree, path), getFileContent(headTree, path))
+	}, nil)
+	folded.Commit = head.Hash.String()
+	logCtx := logging.WithComponent(context.Background(), "attribution")
+	if err := store.UpdateSessionAttribution(context.Background(), checkpointID, index, folded); err != nil {
+		logging.Warn(logCtx, "failed to update attribution of amended commit",
+			slog.String("checkpoint_id", checkpointID.String()),
+			slog.String("error", err.Error()))
+		return false
+	}
+	logging.Info(logCtx, "attribution updated for amended commit",
+		slog.String("session_id", state.SessionID),
+		slog.String("amended", amended.Hash.String()),
+		slog.Int("human_added", delta.HumanAdded))
+	return true
+}
+
+// foldRebaseFixup handles the post-commit of a `fixup`/`squash` step of an
+// interactive rebase (as run by --autosquash): the attribution of the
+// squashed-in commit's checkpoint is folded into head's checkpoint, and the
+// squashed-in checkpoint is marked superseded so it isn't counted twice.
+func foldRebaseFixup(repo *git.Repository, head *object.Commit, checkpointID id.CheckpointID) {
+	logCtx := logging.WithComponent(context.Background(), "attribution")
+	fixup := lastRebaseFixup(repo)
+	if fixup == nil {
+		return
+	}
+	fixupID, ok := trailers.ParseCheckpoint(fixup.Message)
+	if !ok || fixupID == checkpointID {
+		return
+	}
+	store := cpkg.NewGitStore(repo)
+	ctx := context.Background()
+	fixupSummary, err := store.ReadCommitted(ctx, fixupID)
+	if err != nil || fixupSummary == nil {
+		return
+	}
+	targetSummary, err := store.ReadCommitted(ctx, checkpointID)
+	if err != nil || targetSummary == nil || len(targetSummary.Sessions) == 0 {
+		return
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return
+	}
+	fixupTree, err := fixup.Tree()
+	if err != nil {
+		return
+	}
+	var fixupParentTree *object.Tree
+	if parent, parentErr := fixup.Parent(0); parentErr == nil {
+		fixupParentTree, _ = parent.Tree() //nolint:errcheck // nil tree means an empty base
+	}
+
+	for i := range fixupSummary.Sessions {
+		metadata, err := store.ReadSessionMetadata(ctx, fixupID, i)
+		if err != nil || metadata.InitialAttribution == nil || metadata.InitialAttribution.SupersededBy != "" {
+			continue
+		}
+		// Into the same session if it worked on both commits, else the latest
+		index, target := sessionAttribution(store, checkpointID, metadata.SessionID)
+		if target == nil {
+			index = len(targetSummary.Sessions) - 1
+			if targetMetadata, err := store.ReadSessionMetadata(ctx, checkpointID, index); err == nil {
+				target = targetMetadata.InitialAttribution
+			}
+		}
+		if target == nil {
+			continue
+		}
+		// The target's lines moved by what the fixup changed; the fixup's
+		// lines are where the fixup left them
+		folded := foldAttribution(target, metadata.InitialAttribution, fixup.Hash.String(),
+			func(path string, ranges []cpkg.LineRange) []cpkg.LineRange {
+				return carryLineRanges(ranges, getFileContent(fixupParentTree, path), getFileContent(headTree, path))
+			},
+			func(path string, ranges []cpkg.LineRange) []cpkg.LineRange {
+				return carryLineRanges(ranges, getFileContent(fixupTree, path), getFileContent(headTree, path))
+			})
+		folded.Commit = head.Hash.String()
+		if err := store.UpdateSessionAttribution(ctx, checkpointID, index, folded); err != nil {
+			logging.Warn(logCtx, "failed to fold fixup attribution",
+				slog.String("checkpoint_id", checkpointID.String()),
+				slog.String("error", err.Error()))
+			return
+		}
+		superseded := *metadata.InitialAttribution
+		superseded.SupersededBy = checkpointID
+		if err := store.UpdateSessionAttribution(ctx, fixupID, i, &superseded); err != nil {
+			logging.Warn(logCtx, "failed to mark fixup attribution superseded",
+				slog.String("checkpoint_id", fixupID.String()),
+				slog.String("error", err.Error()))
+		}
+	}
+	logging.Info(logCtx, "attribution: folded fixup into its target",
+		slog.String("checkpoint_id", checkpointID.String()),
+		slog.String("fixup_checkpoint_id", fixupID.String()))
+}
+
+// lastRebaseFixup returns the commit squashed in by the rebase step that just
+// ran, if it was a fixup or squash. Returns nil otherwise.
+func lastRebaseFixup(repo *git.Repository) *object.Commit {
+	gitDir, err := GetGitDir()
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(gitDir, "rebase-merge", "done")) //nolint:gosec // path is inside the git dir
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var last string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			last = line
+		}
+	}
+	fields := strings.Fields(last)
+	if len(fields) < 2 {
+		return nil
+	}
+	switch fields[0] {
+	case "fixup", "f", "squash", "s":
+	default:
+		return nil
+	}
+	rev := fields[1]
+	if (rev == "-C" || rev == "-c") && len(fields) > 2 {
+		rev = fields[2]
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil
+	}
+	return commit
+}
+
+// foldAttribution adds next's counts to previous's, for the commit that now
+// contains both. amended is the commit previous (or next, for a fixup) was
+// recorded for. carryPrevious and carryNext move each record's agent line
+// ranges onto the new commit's files; nil keeps them as they are.
+func foldAttribution(previous, next *cpkg.InitialAttribution, amended string, carryPrevious, carryNext func(path string, ranges []cpkg.LineRange) []cpkg.LineRange) *cpkg.InitialAttribution {
+	carry := func(f func(string, []cpkg.LineRange) []cpkg.LineRange, path string, ranges []cpkg.LineRange) []cpkg.LineRange {
+		if f == nil || len(ranges) == 0 {
+			return ranges
+		}
+		return f(path, ranges)
+	}
+
+	folded := *next
+	folded.AgentLines += previous.AgentLines
+	folded.HumanAdded += previous.HumanAdded
+	folded.HumanModified += previous.HumanModified
+	folded.HumanRemoved += previous.HumanRemoved
+	folded.TotalCommitted += previous.TotalCommitted
+	folded.AgentPercentage = attributionPercentage(folded.AgentLines, folded.TotalCommitted)
+	folded.AmendedFrom = append(append(slices.Clone(previous.AmendedFrom), amended), next.AmendedFrom...)
+	if folded.Granularity == "" {
+		folded.Granularity = previous.Granularity
+	}
+	if folded.Normalization == "" {
+		folded.Normalization = previous.Normalization
+	}
+
+	foldFiles := func(previousFiles, nextFiles []cpkg.FileAttribution) []cpkg.FileAttribution {
+		byPath := make(map[string]cpkg.FileAttribution)
+		for _, f := range nextFiles {
+			f.AgentRanges = carry(carryNext, f.Path, f.AgentRanges)
+			byPath[f.Path] = f
+		}
+		for _, p := range previousFiles {
+			f, ok := byPath[p.Path]
+			if !ok {
+				p.AgentRanges = carry(carryPrevious, p.Path, p.AgentRanges)
+				byPath[p.Path] = p
+				continue
+			}
+			f.AgentLines += p.AgentLines
+			f.HumanAdded += p.HumanAdded
+			f.HumanModified += p.HumanModified
+			f.HumanRemoved += p.HumanRemoved
+			f.TotalCommitted += p.TotalCommitted
+			f.AgentPercentage = attributionPercentage(f.AgentLines, f.TotalCommitted)
+			f.AgentRanges = mergeLineRanges(carry(carryPrevious, p.Path, p.AgentRanges), f.AgentRanges)
+			byPath[p.Path] = f
+		}
+		files := make([]cpkg.FileAttribution, 0, len(byPath))
+		for _, f := range byPath {
+			files = append(files, f)
+		}
+		slices.SortFunc(files, func(a, b cpkg.FileAttribution) int { return strings.Compare(a.Path, b.Path) })
+		return files
+	}
+	folded.Files = foldFiles(previous.Files, next.Files)
+	if len(previous.GeneratedFiles) > 0 || len(next.GeneratedFiles) > 0 {
+		folded.GeneratedFiles = foldFiles(previous.GeneratedFiles, next.GeneratedFiles)
+	}
+	folded.GeneratedAgentLines += previous.GeneratedAgentLines
+	folded.GeneratedTotalCommitted += previous.GeneratedTotalCommitted
+
+	folded.BinaryAgentBytes += previous.BinaryAgentBytes
+	folded.BinaryHumanBytes += previous.BinaryHumanBytes
+	binaryByPath := make(map[string]cpkg.BinaryFileAttribution)
+	for _, f := range next.BinaryFiles {
+		binaryByPath[f.Path] = f
+	}
+	for _, p := range previous.BinaryFiles {
+		f, ok := binaryByPath[p.Path]
+		if !ok {
+			binaryByPath[p.Path] = p
+			continue
+		}
+		f.AgentBytes += p.AgentBytes
+		f.HumanBytes += p.HumanBytes
+		binaryByPath[p.Path] = f
+	}
+	folded.BinaryFiles = nil
+	for _, f := range binaryByPath {
+		folded.BinaryFiles = append(folded.BinaryFiles, f)
+	}
+	slices.SortFunc(folded.BinaryFiles, func(a, b cpkg.BinaryFileAttribution) int { return strings.Compare(a.Path, b.Path) })
+
+	// A submodule moved by both commits goes from the first one's commit to
+	// the last one's, and is the agent's if the last move was
+	submoduleByPath := make(map[string]cpkg.SubmoduleAttribution)
+	for _, s := range previous.Submodules {
+		submoduleByPath[s.Path] = s
+	}
+	for _, s := range next.Submodules {
+		if p, ok := submoduleByPath[s.Path]; ok {
+			s.From = p.From
+		}
+		submoduleByPath[s.Path] = s
+	}
+	folded.Submodules = nil
+	for _, s := range submoduleByPath {
+		if s.From != s.To {
+			folded.Submodules = append(folded.Submodules, s)
+		}
+	}
+	slices.SortFunc(folded.Submodules, func(a, b cpkg.SubmoduleAttribution) int { return strings.Compare(a.Path, b.Path) })
+	return &folded
+}
+
+func attributionPercentage(agentLines, totalCommitted int) float64 {
+	if totalCommitted <= 0 {
+		return 0
+	}
+	return float64(agentLines) / float64(totalCommitted) * 100
+}
+
+// carryLineRanges maps ranges of lines in before onto after, keeping the lines
+// that are unchanged between the two.
+func carryLineRanges(ranges []cpkg.LineRange, before, after string) []cpkg.LineRange {
+	covered := func(line int) bool {
+		for _, r := range ranges {
+			if line >= r.Start && line <= r.End {
+				return true
+			}
+		}
+		return false
+	}
+	var carried []cpkg.LineRange
+	beforeLine, afterLine := 0, 0
+	for _, d := range lineDiffOps(before, after) {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for k := range n {
+				if covered(beforeLine + k + 1) {
+					carried = appendLineToRanges(carried, afterLine+k+1)
+				}
+			}
+			beforeLine += n
+			afterLine += n
+		case diffmatchpatch.DiffInsert:
+			afterLine += n
+		case diffmatchpatch.DiffDelete:
+			beforeLine += n
+		}
+	}
+	return carried
+}
+
+// mergeLineRanges returns the union of a and b, sorted and coalesced.
+func mergeLineRanges(a, b []cpkg.LineRange) []cpkg.LineRange {
+	all := append(slices.Clone(a), b...)
+	slices.SortFunc(all, func(x, y cpkg.LineRange) int { return x.Start - y.Start })
+	var merged []cpkg.LineRange
+	for _, r := range all {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End+1 {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
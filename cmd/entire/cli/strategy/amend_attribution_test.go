@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostCommit_AmendWithoutNewWork_UpdatesAttribution verifies that amending
+// a condensed commit with human edits updates its attribution instead of
+// leaving it describing the commit before the amend.
+func TestPostCommit_AmendWithoutNewWork_UpdatesAttribution(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-postcommit-amend"
+	setupSessionWithCheckpoint(t, s, repo, dir, sessionID)
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	state.Phase = session.PhaseIdle
+	state.FilesTouched = []string{"test.txt"}
+	require.NoError(t, s.saveSessionState(state))
+
+	cpID := id.MustCheckpointID("c3d4e5f6a1b2")
+	commitWithCheckpointTrailer(t, repo, dir, cpID.String())
+	require.NoError(t, s.PostCommit())
+	before, err := repo.Head()
+	require.NoError(t, err)
+
+	store := checkpoint.NewGitStore(repo)
+	_, original := sessionAttribution(store, cpID, sessionID)
+	require.NotNil(t, original, "condensation should record attribution")
+
+	// Amend with three lines of the user's own
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("one\ntwo\nthree\n"), 0o644))
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("notes.txt")
+	require.NoError(t, err)
+	_, err = wt.Commit("test commit, amended\n\n"+trailers.CheckpointTrailerKey+": "+cpID.String()+"\n", &git.CommitOptions{
+		Amend:  true,
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.PostCommit())
+
+	_, amended := sessionAttribution(store, cpID, sessionID)
+	require.NotNil(t, amended)
+	assert.Equal(t, original.HumanAdded+3, amended.HumanAdded)
+	assert.Equal(t, original.TotalCommitted+3, amended.TotalCommitted)
+	assert.Equal(t, []string{before.Hash().String()}, amended.AmendedFrom)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, head.Hash().String(), state.AttributionBaseCommit,
+		"the amend's changes are recorded, so the next attribution starts after them")
+
+	// Amending again without changes records nothing twice
+	_, err = wt.Commit("test commit, reworded\n\n"+trailers.CheckpointTrailerKey+": "+cpID.String()+"\n", &git.CommitOptions{
+		Amend:  true,
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.PostCommit())
+	_, reworded := sessionAttribution(store, cpID, sessionID)
+	require.NotNil(t, reworded)
+	assert.Equal(t, amended.HumanAdded, reworded.HumanAdded)
+	assert.Len(t, reworded.AmendedFrom, 2)
+}
+
+func TestFoldAttribution(t *testing.T) {
+	t.Parallel()
+
+	previous := &checkpoint.InitialAttribution{
+		AgentLines: 8, HumanAdded: 2, TotalCommitted: 10,
+		Files: []checkpoint.FileAttribution{
+			{Path: "a.go", AgentLines: 8, HumanAdded: 2, TotalCommitted: 10, AgentRanges: []checkpoint.LineRange{{Start: 1, End: 8}}},
+		},
+	}
+	next := &checkpoint.InitialAttribution{
+		AgentLines: 5, HumanAdded: 5, TotalCommitted: 10,
+		Files: []checkpoint.FileAttribution{
+			{Path: "a.go", AgentLines: 2, TotalCommitted: 2, AgentRanges: []checkpoint.LineRange{{Start: 11, End: 12}}},
+			{Path: "b.go", AgentLines: 3, HumanAdded: 5, TotalCommitted: 8},
+		},
+	}
+	// The amend inserted two lines at the top of a.go
+	shift := func(_ string, ranges []checkpoint.LineRange) []checkpoint.LineRange {
+		out := make([]checkpoint.LineRange, len(ranges))
+		for i, r := range ranges {
+			out[i] = checkpoint.LineRange{Start: r.Start + 2, End: r.End + 2}
+		}
+		return out
+	}
+
+	folded := foldAttribution(previous, next, "abc123", shift, nil)
+	assert.Equal(t, 13, folded.AgentLines)
+	assert.Equal(t, 20, folded.TotalCommitted)
+	assert.InDelta(t, 65.0, folded.AgentPercentage, 0.001)
+	assert.Equal(t, []string{"abc123"}, folded.AmendedFrom)
+	require.Len(t, folded.Files, 2)
+	a := folded.Files[0]
+	assert.Equal(t, "a.go", a.Path)
+	assert.Equal(t, 10, a.AgentLines)
+	assert.Equal(t, []checkpoint.LineRange{{Start: 3, End: 12}}, a.AgentRanges)
+	assert.Equal(t, "b.go", folded.Files[1].Path)
+}
+
+func TestCarryLineRanges(t *testing.T) {
+	t.Parallel()
+
+	before := "a\nb\nc\nd\n"
+	after := "new\na\nc\nd\n"
+	got := carryLineRanges([]checkpoint.LineRange{{Start: 1, End: 3}}, before, after)
+	// a moves down a line, b is gone, c follows a
+	assert.Equal(t, []checkpoint.LineRange{{Start: 2, End: 3}}, got)
+}
+
+func TestLastRebaseFixup(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	rebaseDir := filepath.Join(dir, ".git", "rebase-merge")
+	require.NoError(t, os.MkdirAll(rebaseDir, 0o755))
+	done := filepath.Join(rebaseDir, "done")
+
+	require.NoError(t, os.WriteFile(done, []byte("pick 1111111 first\nfixup "+head.Hash().String()[:7]+" fixup! first\n"), 0o644))
+	fixup := lastRebaseFixup(repo)
+	require.NotNil(t, fixup)
+	assert.Equal(t, head.Hash(), fixup.Hash)
+
+	require.NoError(t, os.WriteFile(done, []byte("fixup "+head.Hash().String()[:7]+" fixup! first\npick "+head.Hash().String()[:7]+" second\n"), 0o644))
+	assert.Nil(t, lastRebaseFixup(repo), "the last step was a pick")
+}
@@ -0,0 +1,161 @@
+package strategy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+)
+
+// AttributionCacheDirName is the state directory per-file attribution diffs
+// are cached in (see fsenv.StateDir).
+const AttributionCacheDirName = "entire-attribution-cache"
+
+// maxCachedAttributionDiffs bounds the cache; the least recently used
+// results are pruned after each attribution.
+const maxCachedAttributionDiffs = 4096
+
+// attributionDiffCacheVersion is part of every key, so results computed by
+// an older diff never get reused.
+const attributionDiffCacheVersion = "v1"
+
+// attributionDiffCache keeps per-file diff results on disk, one file per
+// result, keyed on the blobs diffed, the granularity and the normalization. Blobs are immutable,
+// so a result never goes stale: rebases, amends and repeated runs of stats
+// or verification reuse it instead of diffing the file again. Reading a
+// result touches it, which is what pruning orders by.
+type attributionDiffCache struct {
+	dir    string
+	stored bool
+}
+
+// openAttributionDiffCache returns the repository's attribution diff cache,
+// nil if the git common dir can't be found. A variable so tests can turn
+// caching off.
+var openAttributionDiffCache = func() *attributionDiffCache {
+	commonDir, err := GetGitCommonDir()
+	if err != nil {
+		return nil
+	}
+	return &attributionDiffCache{dir: fsenv.StateDir(commonDir, AttributionCacheDirName)}
+}
+
+// agentDiffCacheKey is the key of an agent-touched file's result: its base,
+// committed and checkpoint versions, then those of earlier checkpoints.
+func agentDiffCacheKey(granularity AttributionGranularity, normalizer *attributionNormalizer, base, head binaryBlob, agentVersions []binaryBlob) string {
+	hashes := []string{"agent", base.hash.String(), head.hash.String()}
+	for _, v := range agentVersions {
+		hashes = append(hashes, v.hash.String())
+	}
+	return diffCacheKey(granularity, normalizer, hashes)
+}
+
+// userDiffCacheKey is the key of the base → head result of a file only the
+// user changed.
+func userDiffCacheKey(granularity AttributionGranularity, normalizer *attributionNormalizer, base, head binaryBlob) string {
+	return diffCacheKey(granularity, normalizer, []string{"user", base.hash.String(), head.hash.String()})
+}
+
+func diffCacheKey(granularity AttributionGranularity, normalizer *attributionNormalizer, parts []string) string {
+	sum := sha256.Sum256([]byte(attributionDiffCacheVersion + " " + string(granularity) + " " + normalizer.cacheKey() + " " + strings.Join(parts, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *attributionDiffCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// load returns the cached result for key, false if there is none.
+func (c *attributionDiffCache) load(key string) (fileDiffResult, bool) {
+	if c == nil {
+		return fileDiffResult{}, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return fileDiffResult{}, false
+	}
+	var result fileDiffResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fileDiffResult{}, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now) //nolint:errcheck // only affects pruning order
+	return result, true
+}
+
+// store caches result under key. Failures only cost a diff next time.
+func (c *attributionDiffCache) store(key string, result fileDiffResult) {
+	if c == nil {
+		return
+	}
+	if err := c.write(key, result); err == nil {
+		c.stored = true
+	}
+}
+
+func (c *attributionDiffCache) write(key string, result fileDiffResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode attribution diff: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create attribution cache: %w", err)
+	}
+	// Write to a temp file and rename, so concurrent readers never see a partial result
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write attribution diff: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write attribution diff: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write attribution diff: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to write attribution diff: %w", err)
+	}
+	return nil
+}
+
+// prune removes the least recently used results over the bound, once
+// something was stored.
+func (c *attributionDiffCache) prune() {
+	if c == nil || !c.stored {
+		return
+	}
+	c.stored = false
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type result struct {
+		name    string
+		modTime int64
+	}
+	var results []result
+	for _, e := range dirEntries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			results = append(results, result{e.Name(), info.ModTime().UnixNano()})
+		}
+	}
+	if len(results) <= maxCachedAttributionDiffs {
+		return
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].modTime > results[j].modTime })
+	for _, r := range results[maxCachedAttributionDiffs:] {
+		_ = os.Remove(filepath.Join(c.dir, r.name))
+	}
+}
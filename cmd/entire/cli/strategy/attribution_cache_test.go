@@ -0,0 +1,99 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCalculateAttribution_CachesFileDiffs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), AttributionCacheDirName)
+	previous := openAttributionDiffCache
+	openAttributionDiffCache = func() *attributionDiffCache { return &attributionDiffCache{dir: dir} }
+	t.Cleanup(func() { openAttributionDiffCache = previous })
+
+	base, shadow, head, filesTouched := manyFileTrees(t, 8)
+	cachedDiffs := func() int {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	first := CalculateAttributionWithAccumulated(GranularityLine, base, shadow, head, filesTouched, nil)
+	// The two files only the user changed have the same versions, so they
+	// share a result
+	if n := cachedDiffs(); n != 9 {
+		t.Fatalf("cached %d diffs, want one per distinct file change (9)", n)
+	}
+	second := CalculateAttributionWithAccumulated(GranularityLine, base, shadow, head, filesTouched, nil)
+	second.CalculatedAt = first.CalculatedAt
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("cached attribution = %+v, want %+v", second, first)
+	}
+	if n := cachedDiffs(); n != 9 {
+		t.Errorf("cached %d diffs after a repeated run, want 9", n)
+	}
+
+	// A repeated run reads the cached results instead of diffing
+	key := userDiffCacheKey(GranularityLine, nil, binaryBlobOf(base, "user000.go"), binaryBlobOf(head, "user000.go"))
+	data, err := json.Marshal(fileDiffResult{Added: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, key), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	third := CalculateAttributionWithAccumulated(GranularityLine, base, shadow, head, filesTouched, nil)
+	for _, f := range third.Files {
+		if f.Path == "user000.go" && f.HumanAdded != 100 {
+			t.Errorf("user000.go human lines = %d, want the cached 100", f.HumanAdded)
+		}
+	}
+
+	// Other granularities have their own results
+	CalculateAttributionWithAccumulated(GranularityWord, base, shadow, head, filesTouched, nil)
+	if n := cachedDiffs(); n != 18 {
+		t.Errorf("cached %d diffs with two granularities, want 18", n)
+	}
+}
+
+func TestAttributionDiffCache_PrunesLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	cache := &attributionDiffCache{dir: t.TempDir()}
+	old := time.Now().Add(-time.Hour)
+	for i := range maxCachedAttributionDiffs + 5 {
+		key := diffCacheKey(GranularityLine, nil, []string{fmt.Sprint(i)})
+		cache.store(key, fileDiffResult{Added: i})
+		if err := os.Chtimes(cache.path(key), old, old.Add(time.Duration(i)*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading the oldest result makes it the most recently used
+	oldest := diffCacheKey(GranularityLine, nil, []string{"0"})
+	if result, ok := cache.load(oldest); !ok || result.Added != 0 {
+		t.Fatalf("load() = %+v, %v", result, ok)
+	}
+	cache.prune()
+
+	entries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != maxCachedAttributionDiffs {
+		t.Errorf("%d results after pruning, want %d", len(entries), maxCachedAttributionDiffs)
+	}
+	if _, ok := cache.load(oldest); !ok {
+		t.Error("prune() removed the result just read")
+	}
+	if _, ok := cache.load(diffCacheKey(GranularityLine, nil, []string{"1"})); ok {
+		t.Error("prune() kept the least recently used result")
+	}
+}
@@ -0,0 +1,207 @@
+package strategy
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// AttributionGranularity is the unit human edits are measured in.
+//
+// Line-level diffs count a line as modified if anything on it changed, so a
+// user renaming one identifier on an agent-written line takes the whole line
+// away from the agent. With word or char granularity, each replaced block of
+// lines is compared token by token and only counts as the changed fraction:
+// renaming one of ten words on a line counts as 0.1 lines added and removed.
+// Fractions are summed per file and rounded, so the trailer still reports
+// whole lines.
+type AttributionGranularity string
+
+const (
+	GranularityLine AttributionGranularity = settings.AttributionGranularityLine
+	GranularityWord AttributionGranularity = settings.AttributionGranularityWord
+	GranularityChar AttributionGranularity = settings.AttributionGranularityChar
+)
+
+// configuredAttributionGranularity returns the granularity from settings,
+// falling back to line-level if settings are missing or invalid.
+func configuredAttributionGranularity() AttributionGranularity {
+	s, err := settings.Load()
+	if err != nil {
+		return GranularityLine
+	}
+	g, err := s.Attribution.EffectiveGranularity()
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring attribution settings", slog.String("error", err.Error()))
+		return GranularityLine
+	}
+	return AttributionGranularity(g)
+}
+
+// diff compares two file contents and returns (unchanged, added, removed)
+// line counts, weighted by granularity.
+func (g AttributionGranularity) diff(before, after string) (unchanged, added, removed int) {
+	var tokenize func(string) []string
+	switch g {
+	case GranularityWord:
+		tokenize = wordTokens
+	case GranularityChar:
+		tokenize = charTokens
+	case GranularityLine:
+		return diffLines(before, after)
+	default:
+		return diffLines(before, after)
+	}
+	return diffLinesWeighted(before, after, tokenize)
+}
+
+// recorded returns the value stored in InitialAttribution.Granularity.
+func (g AttributionGranularity) recorded() string {
+	if g == GranularityLine {
+		return ""
+	}
+	return string(g)
+}
+
+// diffLinesWeighted is diffLines with replaced lines weighted by the share of
+// their tokens that changed. Pure insertions and deletions count in full.
+func diffLinesWeighted(before, after string, tokenize func(string) []string) (unchanged, added, removed int) {
+	if before == after || before == "" || after == "" {
+		return diffLines(before, after)
+	}
+
+	dmp := diffmatchpatch.New()
+	text1, text2, lineArray := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(text1, text2, false), lineArray)
+
+	var unchangedF, addedF, removedF float64
+	for i := 0; i < len(diffs); i++ {
+		d := diffs[i]
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			unchangedF += float64(countLinesStr(d.Text))
+		case diffmatchpatch.DiffDelete, diffmatchpatch.DiffInsert:
+			// A delete next to an insert is a replacement: weigh it by tokens
+			if i+1 < len(diffs) && diffs[i+1].Type != diffmatchpatch.DiffEqual && diffs[i+1].Type != d.Type {
+				deleted, inserted := d.Text, diffs[i+1].Text
+				if d.Type == diffmatchpatch.DiffInsert {
+					deleted, inserted = inserted, deleted
+				}
+				removedShare, addedShare := changedTokenShares(dmp, tokenize(deleted), tokenize(inserted))
+				deletedLines, insertedLines := float64(countLinesStr(deleted)), float64(countLinesStr(inserted))
+				removedF += deletedLines * removedShare
+				addedF += insertedLines * addedShare
+				unchangedF += insertedLines * (1 - addedShare)
+				i++
+				continue
+			}
+			if d.Type == diffmatchpatch.DiffInsert {
+				addedF += float64(countLinesStr(d.Text))
+			} else {
+				removedF += float64(countLinesStr(d.Text))
+			}
+		}
+	}
+
+	return int(math.Round(unchangedF)), int(math.Round(addedF)), int(math.Round(removedF))
+}
+
+// changedTokenShares diffs two token lists and returns the share of each that
+// changed. Blocks without any tokens (whitespace only) don't count.
+func changedTokenShares(dmp *diffmatchpatch.DiffMatchPatch, deleted, inserted []string) (removedShare, addedShare float64) {
+	runes1, runes2, ok := tokensToRunes(deleted, inserted)
+	if !ok {
+		return 1, 1
+	}
+	var removedTokens, addedTokens int
+	for _, d := range dmp.DiffMainRunes(runes1, runes2, false) {
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			removedTokens += utf8.RuneCountInString(d.Text)
+		case diffmatchpatch.DiffInsert:
+			addedTokens += utf8.RuneCountInString(d.Text)
+		case diffmatchpatch.DiffEqual:
+		}
+	}
+	if len(deleted) > 0 {
+		removedShare = float64(removedTokens) / float64(len(deleted))
+	}
+	if len(inserted) > 0 {
+		addedShare = float64(addedTokens) / float64(len(inserted))
+	}
+	return removedShare, addedShare
+}
+
+// tokensToRunes maps each distinct token to a rune so the token lists can be
+// diffed with DiffMainRunes, the same trick DiffLinesToChars uses for lines.
+// Returns false if there are more distinct tokens than usable runes.
+func tokensToRunes(a, b []string) ([]rune, []rune, bool) {
+	ids := make(map[string]rune)
+	next := rune(0x100) // skip control characters and ASCII
+	encode := func(tokens []string) ([]rune, bool) {
+		runes := make([]rune, len(tokens))
+		for i, token := range tokens {
+			r, ok := ids[token]
+			if !ok {
+				if next >= 0xD800 && next <= 0xDFFF {
+					next = 0xE000 // surrogates aren't valid runes
+				}
+				if next > utf8.MaxRune {
+					return nil, false
+				}
+				r = next
+				ids[token] = r
+				next++
+			}
+			runes[i] = r
+		}
+		return runes, true
+	}
+	runes1, ok1 := encode(a)
+	runes2, ok2 := encode(b)
+	return runes1, runes2, ok1 && ok2
+}
+
+// wordTokens splits text into words (runs of letters, digits and '_') and
+// individual punctuation characters, dropping whitespace.
+func wordTokens(text string) []string {
+	var tokens []string
+	start := -1
+	for i, r := range text {
+		isWord := r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, text[start:i])
+			start = -1
+		}
+		if !unicode.IsSpace(r) {
+			tokens = append(tokens, string(r))
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// charTokens splits text into its non-whitespace characters.
+func charTokens(text string) []string {
+	tokens := make([]string, 0, len(text))
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			tokens = append(tokens, string(r))
+		}
+	}
+	return tokens
+}
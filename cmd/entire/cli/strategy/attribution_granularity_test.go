@@ -0,0 +1,80 @@
+package strategy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWordTokens(t *testing.T) {
+	got := wordTokens("  user_id := lookup(name, 42)\n")
+	want := []string{"user_id", ":", "=", "lookup", "(", "name", ",", "42", ")"}
+	if !slices.Equal(got, want) {
+		t.Errorf("wordTokens() = %q, want %q", got, want)
+	}
+	if got := charTokens("a b\tc"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("charTokens() = %q", got)
+	}
+}
+
+func TestAttributionGranularity_Diff(t *testing.T) {
+	before := "func handle(req Request, resp Response) error {\n\treturn process(req, resp)\n}\n"
+	// One identifier renamed on the first line
+	after := "func handle(r Request, resp Response) error {\n\treturn process(req, resp)\n}\n"
+
+	tests := []struct {
+		granularity              AttributionGranularity
+		wantAdded, wantRemoved   int
+		wantUnchangedAtLeastLine int
+	}{
+		{GranularityLine, 1, 1, 2},
+		// 1 of 12 tokens changed: well under half a line
+		{GranularityWord, 0, 0, 3},
+		{GranularityChar, 0, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.granularity), func(t *testing.T) {
+			unchanged, added, removed := tt.granularity.diff(before, after)
+			if added != tt.wantAdded || removed != tt.wantRemoved {
+				t.Errorf("diff() added, removed = %d, %d; want %d, %d", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+			if unchanged < tt.wantUnchangedAtLeastLine {
+				t.Errorf("diff() unchanged = %d, want at least %d", unchanged, tt.wantUnchangedAtLeastLine)
+			}
+		})
+	}
+
+	// Rewritten lines still count in full
+	rewritten := "const limit = 10\nconst name = \"x\"\n}\n"
+	if _, added, removed := GranularityWord.diff(before, rewritten); added != 2 || removed != 2 {
+		t.Errorf("word diff of rewritten lines = +%d -%d, want +2 -2", added, removed)
+	}
+	// Pure insertions count in full
+	if _, added, removed := GranularityWord.diff(before, before+"\n// trailing\n"); added != 2 || removed != 0 {
+		t.Errorf("word diff of insertion = +%d -%d, want +2 -0", added, removed)
+	}
+}
+
+func TestCalculateAttributionWithAccumulated_WordGranularity(t *testing.T) {
+	baseTree := buildTestTree(t, map[string]string{"main.go": ""})
+	agentCode := "func add(left int, right int) int {\n\treturn left + right\n}\n"
+	shadowTree := buildTestTree(t, map[string]string{"main.go": agentCode})
+	// User renames one parameter's type on the first line
+	headTree := buildTestTree(t, map[string]string{"main.go": "func add(left int, right int64) int {\n\treturn left + right\n}\n"})
+
+	line := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"main.go"}, nil)
+	word := CalculateAttributionWithAccumulated(GranularityWord, baseTree, shadowTree, headTree, []string{"main.go"}, nil)
+	if line == nil || word == nil {
+		t.Fatal("expected non-nil results")
+	}
+
+	if line.AgentLines != 2 || line.HumanModified != 1 {
+		t.Errorf("line: AgentLines = %d, HumanModified = %d; want 2, 1", line.AgentLines, line.HumanModified)
+	}
+	if word.AgentLines != 3 || word.HumanModified != 0 || word.AgentPercentage != 100 {
+		t.Errorf("word: AgentLines = %d, HumanModified = %d, AgentPercentage = %v; want 3, 0, 100",
+			word.AgentLines, word.HumanModified, word.AgentPercentage)
+	}
+	if line.Granularity != "" || word.Granularity != "word" {
+		t.Errorf("Granularity = %q, %q; want \"\", \"word\"", line.Granularity, word.Granularity)
+	}
+}
@@ -0,0 +1,202 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// Running gofmt or prettier after the agent reindents and realigns the
+// agent's lines, so diffs count them as the human's. With
+// attribution.normalize, both sides of every attribution diff are normalized
+// first: "whitespace" collapses the whitespace within each line, "formatter"
+// also runs the file's formatter. Whitespace normalization keeps the lines
+// where they are, so the agent's line ranges still point at committed lines.
+
+// formatterTimeout bounds one run of a formatter command.
+const formatterTimeout = 10 * time.Second
+
+// attributionNormalizer normalizes file contents before they're diffed. A
+// nil normalizer leaves them as they are.
+type attributionNormalizer struct {
+	mode       string            // settings.AttributionNormalizeWhitespace or Formatter
+	formatters map[string]string // File pattern → formatter command
+	repoRoot   string
+}
+
+// configuredAttributionNormalizer returns the normalizer from settings, nil
+// if normalization is off or settings are missing or invalid.
+func configuredAttributionNormalizer() *attributionNormalizer {
+	s, err := settings.Load()
+	if err != nil {
+		return nil
+	}
+	mode, err := s.Attribution.EffectiveNormalize()
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring attribution settings", slog.String("error", err.Error()))
+		return nil
+	}
+	return newAttributionNormalizer(mode, s.Attribution)
+}
+
+// recordedAttributionNormalizer returns the normalizer an attribution
+// recorded with mode (InitialAttribution.Normalization) was computed with.
+// Formatter commands aren't recorded, so the configured ones run.
+func recordedAttributionNormalizer(mode string) *attributionNormalizer {
+	if mode == "" {
+		return nil
+	}
+	var a *settings.AttributionSettings
+	if s, err := settings.Load(); err == nil {
+		a = s.Attribution
+	}
+	return newAttributionNormalizer(mode, a)
+}
+
+// newAttributionNormalizer returns the normalizer for mode, taking formatter
+// commands from a. Returns nil for "none" or an unknown mode.
+func newAttributionNormalizer(mode string, a *settings.AttributionSettings) *attributionNormalizer {
+	switch mode {
+	case settings.AttributionNormalizeWhitespace:
+		return &attributionNormalizer{mode: mode}
+	case settings.AttributionNormalizeFormatter:
+		n := &attributionNormalizer{mode: mode}
+		if a != nil {
+			n.formatters = a.Formatters
+		}
+		n.repoRoot, _ = paths.RepoRoot() //nolint:errcheck // formatters then run in the current directory
+		return n
+	default:
+		return nil
+	}
+}
+
+// normalize returns content as attribution diffs it. A file whose formatter
+// fails (e.g. on a snapshot that doesn't parse) is only whitespace-normalized.
+func (n *attributionNormalizer) normalize(filePath, content string) string {
+	if n == nil || content == "" {
+		return content
+	}
+	if command := n.formatterFor(filePath); command != "" {
+		formatted, err := n.format(command, filePath, content)
+		if err == nil {
+			content = formatted
+		} else {
+			logging.Debug(context.Background(), "formatter failed, normalizing whitespace only",
+				slog.String("file", filePath),
+				slog.String("error", err.Error()))
+		}
+	}
+	return normalizeWhitespace(content)
+}
+
+// lines is normalize without the formatter, which keeps every line where it
+// was. Agent line ranges are computed on it.
+func (n *attributionNormalizer) lines(content string) string {
+	if n == nil {
+		return content
+	}
+	return normalizeWhitespace(content)
+}
+
+// formatterFor returns the formatter command for filePath, "" if there is
+// none. Patterns without a slash match the file name; of several matching
+// patterns, the longest wins.
+func (n *attributionNormalizer) formatterFor(filePath string) string {
+	if n.mode != settings.AttributionNormalizeFormatter {
+		return ""
+	}
+	var best string
+	for pattern := range n.formatters {
+		name := filePath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(filePath)
+		}
+		if ok, err := path.Match(pattern, name); err != nil || !ok {
+			continue
+		}
+		if len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return n.formatters[best]
+}
+
+// format runs command with content on stdin in the repository root. The
+// file's path is in ENTIRE_FORMAT_PATH, for formatters that pick their rules
+// by it (prettier --stdin-filepath "$ENTIRE_FORMAT_PATH").
+func (n *attributionNormalizer) format(command, filePath, content string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), formatterTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = n.repoRoot
+	cmd.Env = append(os.Environ(), "ENTIRE_FORMAT_PATH="+filePath)
+	cmd.Stdin = strings.NewReader(content)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("formatter %q failed: %w", command, err)
+	}
+	if len(out) == 0 && strings.TrimSpace(content) != "" {
+		return "", fmt.Errorf("formatter %q printed nothing", command)
+	}
+	return string(out), nil
+}
+
+// recorded returns the value stored in InitialAttribution.Normalization.
+func (n *attributionNormalizer) recorded() string {
+	if n == nil {
+		return ""
+	}
+	return n.mode
+}
+
+// cacheKey identifies the normalization in attribution diff cache keys, so
+// changing a formatter command doesn't reuse results of the old one.
+func (n *attributionNormalizer) cacheKey() string {
+	if n == nil {
+		return ""
+	}
+	parts := []string{n.mode}
+	if n.mode == settings.AttributionNormalizeFormatter {
+		patterns := make([]string, 0, len(n.formatters))
+		for pattern := range n.formatters {
+			patterns = append(patterns, pattern)
+		}
+		slices.Sort(patterns)
+		for _, pattern := range patterns {
+			parts = append(parts, pattern+"="+n.formatters[pattern])
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// normalizeWhitespace trims each line and collapses the runs of whitespace
+// within it to single spaces. Line endings become "\n", and the last line
+// gets one, so "no newline at end of file" isn't a change either.
+func normalizeWhitespace(content string) string {
+	if content == "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var b strings.Builder
+	b.Grow(len(content))
+	for _, line := range lines {
+		b.WriteString(strings.Join(strings.Fields(line), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
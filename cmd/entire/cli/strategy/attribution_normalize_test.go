@@ -0,0 +1,102 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+func TestNormalizeWhitespace(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"":                              "",
+		"a := 1":                        "a := 1\n",
+		"\tx   =  1\r\n\n  y = 2  \n":   "x = 1\n\ny = 2\n",
+		"func f() {\n\treturn\n}\n":     "func f() {\nreturn\n}\n",
+		"func f() {\n        return\n}": "func f() {\nreturn\n}\n",
+	}
+	for in, want := range tests {
+		if got := normalizeWhitespace(in); got != want {
+			t.Errorf("normalizeWhitespace(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAttributionNormalizer_FormatterFor(t *testing.T) {
+	t.Parallel()
+	n := newAttributionNormalizer(settings.AttributionNormalizeFormatter, &settings.AttributionSettings{
+		Formatters: map[string]string{"*.go": "gofmt", "web/*.ts": "prettier", "*.ts": "deno fmt -"},
+	})
+	tests := map[string]string{
+		"main.go":       "gofmt",
+		"cmd/x/main.go": "gofmt",
+		"web/app.ts":    "prettier",
+		"api/client.ts": "deno fmt -",
+		"README.md":     "",
+	}
+	for file, want := range tests {
+		if got := n.formatterFor(file); got != want {
+			t.Errorf("formatterFor(%q) = %q, want %q", file, got, want)
+		}
+	}
+	if n.cacheKey() == newAttributionNormalizer(settings.AttributionNormalizeWhitespace, nil).cacheKey() {
+		t.Error("formatter and whitespace normalization share diff cache keys")
+	}
+}
+
+func TestCalculateAttributionWithAccumulated_Normalize(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	writeSettings := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, ".entire"), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	baseTree := buildTestTree(t, map[string]string{"main.go": "package main\n"})
+	shadowTree := buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc add(a, b int) int {\n  return a+b;\n}\n"})
+	// The human ran a formatter: reindented, and the semicolon dropped
+	headTree := buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc add(a, b int) int {\n\treturn a+b\n}\n"})
+	attribute := func() (agentLines, humanModified int, normalization string) {
+		t.Helper()
+		attr := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"main.go"}, nil)
+		if attr == nil {
+			t.Fatal("expected attribution")
+		}
+		return attr.AgentLines, attr.HumanModified, attr.Normalization
+	}
+
+	if agent, modified, normalization := attribute(); agent != 3 || modified != 1 || normalization != "" {
+		t.Errorf("without normalization: %d agent, %d modified, %q; want 3, 1, \"\"", agent, modified, normalization)
+	}
+
+	// Whitespace only: the indentation is ignored, the semicolon isn't
+	writeSettings(`{"attribution": {"normalize": "whitespace"}}`)
+	if agent, modified, normalization := attribute(); agent != 3 || modified != 1 || normalization != "whitespace" {
+		t.Errorf("whitespace: %d agent, %d modified, %q; want 3, 1, whitespace", agent, modified, normalization)
+	}
+	headTree = buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc add(a, b int) int {\n\treturn a+b;\n}\n"})
+	if agent, modified, _ := attribute(); agent != 4 || modified != 0 {
+		t.Errorf("whitespace, reindented only: %d agent, %d modified; want 4, 0", agent, modified)
+	}
+
+	// A formatter that drops semicolons makes both sides the same
+	headTree = buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc add(a, b int) int {\n\treturn a+b\n}\n"})
+	writeSettings(`{"attribution": {"normalize": "formatter", "formatters": {"*.go": "sed 's/;$//'"}}}`)
+	if agent, modified, normalization := attribute(); agent != 4 || modified != 0 || normalization != "formatter" {
+		t.Errorf("formatter: %d agent, %d modified, %q; want 4, 0, formatter", agent, modified, normalization)
+	}
+	// A failing formatter falls back to whitespace normalization
+	writeSettings(`{"attribution": {"normalize": "formatter", "formatters": {"*.go": "exit 1"}}}`)
+	if agent, modified, _ := attribute(); agent != 3 || modified != 1 {
+		t.Errorf("failing formatter: %d agent, %d modified; want 3, 1", agent, modified)
+	}
+}
@@ -0,0 +1,101 @@
+package strategy
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+)
+
+// Attribution diffs every file a commit changes, so commits touching hundreds
+// of files are dominated by the diffs. calculateAttribution reads the files'
+// contents first, sequentially, since go-git repositories aren't safe for
+// concurrent use, then diffs them on a worker pool. Each file's results land
+// at its index and are summed in file order afterwards, so the attribution
+// doesn't depend on how the workers were scheduled.
+
+// attributionWorkers is how many files calculateAttribution diffs at once.
+// Tests and benchmarks change it.
+var attributionWorkers = runtime.GOMAXPROCS(0)
+
+// forEachParallel calls fn for every index in [0, n) on up to workers
+// goroutines and returns when all calls have.
+func forEachParallel(n, workers int, fn func(i int)) {
+	workers = min(workers, n)
+	if workers <= 1 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// fileDiffResult is what calculateAttribution sums for one file, as cached
+// in the attribution diff cache.
+type fileDiffResult struct {
+	// WorkAdded is the lines base → shadow added (agent-touched files only)
+	WorkAdded int `json:"work_added,omitempty"`
+	// Added and Removed are the lines shadow → head (agent-touched files)
+	// or base → head (other files) added and removed
+	Added   int `json:"added,omitempty"`
+	Removed int `json:"removed,omitempty"`
+	// AgentRanges are the committed lines the agent wrote
+	AgentRanges []checkpoint.LineRange `json:"agent_ranges,omitempty"`
+}
+
+// agentFileDiff is an agent-touched file's contents and, once diffed, what
+// calculateAttribution sums for it. Contents aren't read for files whose
+// result was cached.
+type agentFileDiff struct {
+	path               string
+	base, shadow, head string
+	earlier            []string // The file in the session's earlier checkpoints
+
+	key    string // Attribution diff cache key
+	cached bool
+	fileDiffResult
+}
+
+// diff diffs the file.
+func (f *agentFileDiff) diff(granularity AttributionGranularity, normalizer *attributionNormalizer) {
+	if len(f.earlier) > 0 {
+		f.shadow = squashedShadowContent(f.base, f.shadow, f.head, f.earlier)
+	}
+	base, shadow, head := normalizer.normalize(f.path, f.base), normalizer.normalize(f.path, f.shadow), normalizer.normalize(f.path, f.head)
+	_, f.WorkAdded, _ = granularity.diff(base, shadow)
+	_, f.Added, f.Removed = granularity.diff(shadow, head)
+	// Without a change from base to shadow there are no agent lines
+	if base != shadow {
+		f.AgentRanges = agentLineRanges(normalizer.lines(f.base), normalizer.lines(f.shadow), normalizer.lines(f.head))
+	}
+}
+
+// userFileDiff is a file only the user changed, and its base → head diff.
+type userFileDiff struct {
+	path       string
+	base, head string
+
+	key    string
+	cached bool
+	fileDiffResult
+}
+
+func (f *userFileDiff) diff(granularity AttributionGranularity, normalizer *attributionNormalizer) {
+	_, f.Added, f.Removed = granularity.diff(normalizer.normalize(f.path, f.base), normalizer.normalize(f.path, f.head))
+}
@@ -0,0 +1,91 @@
+package strategy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// manyFileTrees builds base, shadow and head trees of n files of about 200
+// lines: the agent rewrites a quarter of each file and the user edits some
+// of the agent's lines, plus n/4 files only the user changed.
+func manyFileTrees(tb testing.TB, n int) (base, shadow, head *object.Tree, filesTouched []string) {
+	tb.Helper()
+	baseFiles, shadowFiles, headFiles := map[string]string{}, map[string]string{}, map[string]string{}
+	for i := range n {
+		var b, s, h strings.Builder
+		for line := range 200 {
+			fmt.Fprintf(&b, "func f%d_%d() int { return %d }\n", i, line, line)
+			switch {
+			case line%4 != 0:
+				fmt.Fprintf(&s, "func f%d_%d() int { return %d }\n", i, line, line)
+				fmt.Fprintf(&h, "func f%d_%d() int { return %d }\n", i, line, line)
+			case line%8 == 0:
+				fmt.Fprintf(&s, "func agent%d_%d() string { return %q }\n", i, line, "agent")
+				fmt.Fprintf(&h, "func user%d_%d() string { return %q }\n", i, line, "user")
+			default:
+				fmt.Fprintf(&s, "func agent%d_%d() string { return %q }\n", i, line, "agent")
+				fmt.Fprintf(&h, "func agent%d_%d() string { return %q }\n", i, line, "agent")
+			}
+		}
+		name := fmt.Sprintf("file%03d.go", i)
+		baseFiles[name], shadowFiles[name], headFiles[name] = b.String(), s.String(), h.String()
+		filesTouched = append(filesTouched, name)
+	}
+	for i := range n / 4 {
+		name := fmt.Sprintf("user%03d.go", i)
+		baseFiles[name] = "package user\n"
+		headFiles[name] = "package user\n\nfunc User() {}\n"
+	}
+	return buildTestTree(tb, baseFiles), buildTestTree(tb, shadowFiles), buildTestTree(tb, headFiles), filesTouched
+}
+
+func TestCalculateAttribution_ParallelMatchesSequential(t *testing.T) {
+	base, shadow, head, filesTouched := manyFileTrees(t, 40)
+	prompts := []PromptAttribution{{CheckpointNumber: 1, UserLinesAdded: 3, UserAddedPerFile: map[string]int{"file001.go": 3}}}
+
+	attribute := func(workers int) *checkpoint.InitialAttribution {
+		t.Helper()
+		previous := attributionWorkers
+		attributionWorkers = workers
+		defer func() { attributionWorkers = previous }()
+		result := CalculateAttributionWithAccumulated(GranularityLine, base, shadow, head, filesTouched, prompts)
+		if result == nil {
+			t.Fatal("expected attribution")
+		}
+		result.CalculatedAt = result.CalculatedAt.Truncate(0).UTC()
+		return result
+	}
+
+	sequential := attribute(1)
+	if len(sequential.Files) != 50 || sequential.AgentLines == 0 || sequential.HumanAdded == 0 {
+		t.Fatalf("sequential attribution = %d files, %d agent lines, %d human; want every file attributed",
+			len(sequential.Files), sequential.AgentLines, sequential.HumanAdded)
+	}
+	for _, workers := range []int{2, 8, 64} {
+		parallel := attribute(workers)
+		parallel.CalculatedAt = sequential.CalculatedAt
+		if !reflect.DeepEqual(parallel, sequential) {
+			t.Errorf("attribution with %d workers differs from the sequential one:\n%+v\nwant\n%+v", workers, parallel, sequential)
+		}
+	}
+}
+
+func BenchmarkCalculateAttribution(b *testing.B) {
+	base, shadow, head, filesTouched := manyFileTrees(b, 300)
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			previous := attributionWorkers
+			attributionWorkers = workers
+			defer func() { attributionWorkers = previous }()
+			for b.Loop() {
+				CalculateAttributionWithAccumulated(GranularityLine, base, shadow, head, filesTouched, nil)
+			}
+		})
+	}
+}
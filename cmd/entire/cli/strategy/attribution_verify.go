@@ -0,0 +1,235 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// AttributionVerification is what VerifyAttribution found.
+type AttributionVerification struct {
+	// Derived is set when the record was compared with the commit, not only
+	// checked for consistency. Otherwise Note says why not.
+	Derived bool
+	Note    string
+
+	// Problems are the ways the record disagrees with the commit (drift) or
+	// with itself (tampering), one sentence each.
+	Problems []string
+}
+
+// VerifyAttribution checks attr, the attribution recorded for commit, without
+// touching the worktree or any session state. filesTouched are the files the
+// session's agent touched.
+//
+// The split of the agent-touched files between agent and human depends on the
+// session's temporary checkpoints, which are gone once the commit is
+// condensed, so for those files only the record's invariants are checked.
+// What follows from the commit alone is recomputed from attr.BaseCommit and
+// must match exactly: which files changed, the lines of the files the agent
+// didn't touch, and the size changes of binary files.
+func VerifyAttribution(repo *git.Repository, commit *object.Commit, filesTouched []string, attr *checkpoint.InitialAttribution) *AttributionVerification {
+	v := &AttributionVerification{}
+	if attr.Skipped != "" {
+		if attr.Skipped == AttributionSkippedMerge && commit.NumParents() < 2 {
+			v.problemf("attribution was skipped as a merge, but the commit has %d parent(s)", commit.NumParents())
+		}
+		if attr.AgentLines != 0 || attr.TotalCommitted != 0 || len(attr.Files) != 0 {
+			v.problemf("attribution was skipped (%s) but records line counts", attr.Skipped)
+		}
+		v.Note = "attribution was skipped (" + attr.Skipped + ")"
+		return v
+	}
+
+	v.checkConsistency(filesTouched, attr)
+
+	switch {
+	case attr.SupersededBy != "":
+		v.Note = "attribution was folded into checkpoint " + attr.SupersededBy.String()
+	case len(attr.AmendedFrom) > 0:
+		v.Note = "attribution folds in amended commits, which can't be recomputed"
+	case attr.BaseCommit == "":
+		v.Note = "recorded before attribution stored its base commit"
+	default:
+		v.derive(repo, commit, filesTouched, attr)
+	}
+	return v
+}
+
+func (v *AttributionVerification) problemf(format string, args ...any) {
+	v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+}
+
+// checkConsistency checks the invariants calculateAttribution guarantees.
+func (v *AttributionVerification) checkConsistency(filesTouched []string, attr *checkpoint.InitialAttribution) {
+	v.checkCounts("", attr.AgentLines, attr.HumanAdded, attr.HumanModified, attr.HumanRemoved, attr.TotalCommitted, attr.AgentPercentage)
+
+	for i, f := range attr.Files {
+		if i > 0 && attr.Files[i-1].Path >= f.Path {
+			v.problemf("files are not sorted by path at %s", f.Path)
+		}
+		v.checkCounts(f.Path+": ", f.AgentLines, f.HumanAdded, f.HumanModified, f.HumanRemoved, f.TotalCommitted, f.AgentPercentage)
+		if f.AgentLines > 0 && len(filesTouched) > 0 && !slices.Contains(filesTouched, f.Path) {
+			v.problemf("%s: %d agent lines, but the session's agent didn't touch the file", f.Path, f.AgentLines)
+		}
+		for j, r := range f.AgentRanges {
+			if r.Start < 1 || r.End < r.Start || (j > 0 && r.Start <= f.AgentRanges[j-1].End) {
+				v.problemf("%s: invalid agent line range %d-%d", f.Path, r.Start, r.End)
+				break
+			}
+		}
+	}
+
+	for i, f := range attr.GeneratedFiles {
+		if i > 0 && attr.GeneratedFiles[i-1].Path >= f.Path {
+			v.problemf("generated files are not sorted by path at %s", f.Path)
+		}
+		v.checkCounts(f.Path+" (generated): ", f.AgentLines, f.HumanAdded, f.HumanModified, f.HumanRemoved, f.TotalCommitted, f.AgentPercentage)
+	}
+	if attr.GeneratedAgentLines < 0 || attr.GeneratedAgentLines > attr.GeneratedTotalCommitted {
+		v.problemf("%d generated agent lines don't fit the %d generated lines committed", attr.GeneratedAgentLines, attr.GeneratedTotalCommitted)
+	}
+
+	var agentBytes, humanBytes int64
+	for _, f := range attr.BinaryFiles {
+		if f.AgentBytes < 0 || f.HumanBytes < 0 || (f.AgentBytes > 0 && f.HumanBytes > 0) {
+			v.problemf("%s: binary change split %d/%d bytes; it belongs to the agent or the human whole", f.Path, f.AgentBytes, f.HumanBytes)
+		}
+		agentBytes += f.AgentBytes
+		humanBytes += f.HumanBytes
+	}
+	if agentBytes != attr.BinaryAgentBytes || humanBytes != attr.BinaryHumanBytes {
+		v.problemf("binary byte totals %d/%d don't add up to the files' %d/%d", attr.BinaryAgentBytes, attr.BinaryHumanBytes, agentBytes, humanBytes)
+	}
+}
+
+func (v *AttributionVerification) checkCounts(prefix string, agentLines, humanAdded, humanModified, humanRemoved, totalCommitted int, percentage float64) {
+	if agentLines < 0 || humanAdded < 0 || humanModified < 0 || humanRemoved < 0 || totalCommitted < 0 {
+		v.problemf("%snegative line counts", prefix)
+	}
+	if agentLines > totalCommitted {
+		v.problemf("%s%d agent lines exceed the %d committed lines", prefix, agentLines, totalCommitted)
+	}
+	if want := attributionPercentage(agentLines, totalCommitted); math.Abs(percentage-want) > 0.01 {
+		v.problemf("%sagent percentage %.2f doesn't match %d of %d lines (%.2f)", prefix, percentage, agentLines, totalCommitted, want)
+	}
+}
+
+// derive recomputes from the commit what doesn't depend on the session's
+// temporary checkpoints, as calculateAttribution does.
+func (v *AttributionVerification) derive(repo *git.Repository, commit *object.Commit, filesTouched []string, attr *checkpoint.InitialAttribution) {
+	baseCommit, err := repo.CommitObject(plumbing.NewHash(attr.BaseCommit))
+	if err != nil {
+		v.Note = "base commit " + attr.BaseCommit[:min(7, len(attr.BaseCommit))] + " isn't available (fetch more history)"
+		return
+	}
+	baseTree, err := baseCommit.Tree()
+	if err != nil {
+		v.Note = "base commit's tree isn't available"
+		return
+	}
+	headTree, err := commit.Tree()
+	if err != nil {
+		v.Note = "commit's tree isn't available"
+		return
+	}
+	var notOurs map[string]bool
+	if commit.NumParents() > 1 {
+		if _, merged, mergeErr := mergeAttributionBase(commit, headTree); mergeErr == nil {
+			notOurs = merged
+		}
+	}
+	granularity := GranularityLine
+	if attr.Granularity != "" {
+		granularity = AttributionGranularity(attr.Granularity)
+	}
+	normalizer := recordedAttributionNormalizer(attr.Normalization)
+	v.Derived = true
+
+	recordedFiles := make(map[string]checkpoint.FileAttribution, len(attr.Files)+len(attr.GeneratedFiles))
+	for _, f := range append(slices.Clone(attr.Files), attr.GeneratedFiles...) {
+		recordedFiles[f.Path] = f
+	}
+	recordedBinary := make(map[string]checkpoint.BinaryFileAttribution, len(attr.BinaryFiles))
+	for _, f := range attr.BinaryFiles {
+		recordedBinary[f.Path] = f
+	}
+
+	changed := make(map[string]bool)
+	for _, path := range getAllChangedFilesBetweenTrees(baseTree, headTree) {
+		touched := slices.Contains(filesTouched, path)
+		if notOurs[path] && !touched {
+			continue
+		}
+		changed[path] = true
+
+		recorded, hasBinary := recordedBinary[path]
+		expected, isBinary := binaryFileAttribution(path, binaryBlobOf(baseTree, path), binaryBlobOf(headTree, path), nil)
+		switch {
+		case isBinary && !hasBinary:
+			v.problemf("%s: binary change of %d bytes isn't recorded", path, expected.HumanBytes)
+		case !isBinary && hasBinary:
+			v.problemf("%s: recorded as a binary change, but the commit changes no binary file there", path)
+		case isBinary && (recorded.Size != expected.Size || recorded.AgentBytes+recorded.HumanBytes != expected.HumanBytes):
+			v.problemf("%s: recorded as %d bytes changed to %d, the commit changes %d bytes to %d",
+				path, recorded.AgentBytes+recorded.HumanBytes, recorded.Size, expected.HumanBytes, expected.Size)
+		case isBinary && !touched && recorded.AgentBytes > 0:
+			v.problemf("%s: %d agent bytes, but the session's agent didn't touch the file", path, recorded.AgentBytes)
+		}
+
+		headContent := getFileContent(headTree, path)
+		if f, ok := recordedFiles[path]; ok {
+			if lines := countLinesStr(headContent); len(f.AgentRanges) > 0 && f.AgentRanges[len(f.AgentRanges)-1].End > lines {
+				v.problemf("%s: agent lines up to line %d, but the committed file has %d lines", path, f.AgentRanges[len(f.AgentRanges)-1].End, lines)
+			}
+		}
+		if touched {
+			continue
+		}
+		_, added, removed := granularity.diff(normalizer.normalize(path, getFileContent(baseTree, path)), normalizer.normalize(path, headContent))
+		want := appendFileAttribution(nil, path, 0, added, removed, 0)
+		got, ok := recordedFiles[path]
+		switch {
+		case len(want) == 0 && ok:
+			v.problemf("%s: records line changes, but the commit changes no lines", path)
+		case len(want) > 0 && !ok:
+			v.problemf("%s: the commit's %d added and %d removed lines aren't recorded", path, added, removed)
+		case len(want) > 0 && !sameFileCounts(got, want[0]):
+			v.problemf("%s: recorded %s, the commit has %s", path, fileCounts(got), fileCounts(want[0]))
+		}
+	}
+
+	for _, f := range append(slices.Clone(attr.Files), attr.GeneratedFiles...) {
+		if !changed[f.Path] && !slices.Contains(filesTouched, f.Path) {
+			v.problemf("%s: recorded, but the commit doesn't change the file", f.Path)
+		}
+	}
+	for _, f := range attr.BinaryFiles {
+		if !changed[f.Path] {
+			v.problemf("%s: recorded as a binary change, but the commit doesn't change the file", f.Path)
+		}
+	}
+}
+
+func sameFileCounts(a, b checkpoint.FileAttribution) bool {
+	return a.AgentLines == b.AgentLines && a.HumanAdded == b.HumanAdded &&
+		a.HumanModified == b.HumanModified && a.HumanRemoved == b.HumanRemoved && a.TotalCommitted == b.TotalCommitted
+}
+
+func fileCounts(f checkpoint.FileAttribution) string {
+	parts := []string{
+		fmt.Sprintf("%d agent", f.AgentLines),
+		fmt.Sprintf("%d added", f.HumanAdded),
+		fmt.Sprintf("%d modified", f.HumanModified),
+		fmt.Sprintf("%d removed", f.HumanRemoved),
+		fmt.Sprintf("%d committed", f.TotalCommitted),
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,73 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestVerifyAttribution(t *testing.T) {
+	storage := memory.NewStorage()
+	repo, err := git.Init(storage, nil)
+	if err != nil {
+		t.Fatalf("git.Init() error = %v", err)
+	}
+	base := storeTestCommit(t, storage, map[string]string{"main.go": "package main\n", "README.md": "# App\n"})
+	shadowFiles := map[string]string{"main.go": "package main\n\nfunc main() {}\n", "README.md": "# App\n"}
+	headFiles := map[string]string{"main.go": "package main\n\nfunc main() {}\n", "README.md": "# App\n\nUsage.\n"}
+	shadow := storeTestCommit(t, storage, shadowFiles, base)
+	head := storeTestCommit(t, storage, headFiles, base)
+
+	tree := func(c *object.Commit) *object.Tree {
+		t.Helper()
+		tr, err := c.Tree()
+		if err != nil {
+			t.Fatalf("Tree() error = %v", err)
+		}
+		return tr
+	}
+	baseCommit, _ := repo.CommitObject(base)     //nolint:errcheck // stored above
+	shadowCommit, _ := repo.CommitObject(shadow) //nolint:errcheck // stored above
+	headCommit, _ := repo.CommitObject(head)     //nolint:errcheck // stored above
+	filesTouched := []string{"main.go"}
+	attr := calculateAttribution(GranularityLine, tree(baseCommit), tree(shadowCommit), tree(headCommit), nil, filesTouched, nil, nil)
+	attr.BaseCommit = base.String()
+
+	v := VerifyAttribution(repo, headCommit, filesTouched, attr)
+	if !v.Derived || len(v.Problems) != 0 {
+		t.Fatalf("VerifyAttribution() = %+v, want derived without problems", v)
+	}
+
+	// Editing the record is caught
+	tampered := *attr
+	tampered.Files = append(tampered.Files[:0:0], attr.Files...)
+	tampered.AgentPercentage = 100
+	for i := range tampered.Files {
+		if tampered.Files[i].Path == "README.md" {
+			tampered.Files[i].HumanAdded = 0
+			tampered.Files[i].AgentLines = 2
+			tampered.Files[i].AgentPercentage = 100
+		}
+	}
+	v = VerifyAttribution(repo, headCommit, filesTouched, &tampered)
+	got := strings.Join(v.Problems, "\n")
+	for _, want := range []string{
+		"agent percentage 100.00 doesn't match",
+		"README.md: 2 agent lines, but the session's agent didn't touch the file",
+		"README.md: recorded 2 agent, 0 added",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("problems =\n%s\nwant one containing %q", got, want)
+		}
+	}
+
+	// Without a base commit only consistency is checked
+	old := *attr
+	old.BaseCommit = ""
+	if v := VerifyAttribution(repo, headCommit, filesTouched, &old); v.Derived || v.Note == "" || len(v.Problems) != 0 {
+		t.Errorf("VerifyAttribution() without base = %+v, want a note and no problems", v)
+	}
+}
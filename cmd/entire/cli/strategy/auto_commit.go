@@ -18,6 +18,7 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
 
 	"github.com/go-git/go-git/v5"
@@ -65,6 +66,9 @@ type AutoCommitStrategy struct {
 	checkpointStoreOnce sync.Once
 	// checkpointStoreErr captures any error during initialization
 	checkpointStoreErr error
+	// stacked makes this the stacked strategy (see stacked.go): the same
+	// commits, which also carry stack trailers for review tools
+	stacked bool
 }
 
 // getCheckpointStore returns the checkpoint store, initializing it lazily if needed.
@@ -89,10 +93,16 @@ func NewAutoCommitStrategy() Strategy { //nolint:ireturn // already present in c
 }
 
 func (s *AutoCommitStrategy) Name() string {
+	if s.stacked {
+		return StrategyNameStacked
+	}
 	return StrategyNameAutoCommit
 }
 
 func (s *AutoCommitStrategy) Description() string {
+	if s.stacked {
+		return "Commits each prompt to active branch as a reviewable stack with metadata on entire/checkpoints/v1"
+	}
 	return "Auto-commits code to active branch with metadata on entire/checkpoints/v1"
 }
 
@@ -111,13 +121,17 @@ func (s *AutoCommitStrategy) ValidateRepository() error {
 }
 
 // PrePush is called by the git pre-push hook before pushing to a remote.
-// It pushes the entire/checkpoints/v1 branch alongside the user's push.
+// It pushes the entire/checkpoints/v1 branch (and commit notes, if enabled)
+// alongside the user's push.
 // Configuration options (stored in .entire/settings.json under strategy_options.push_sessions):
 //   - "auto": always push automatically
 //   - "prompt" (default): ask user with option to enable auto
 //   - "false"/"off"/"no": never push
 func (s *AutoCommitStrategy) PrePush(remote string) error {
-	return pushSessionsBranchCommon(remote, paths.MetadataBranchName)
+	if err := pushSessionsBranchCommon(remote, paths.MetadataBranchName); err != nil {
+		return err
+	}
+	return pushCommitNotesCommon(remote)
 }
 
 func (s *AutoCommitStrategy) SaveChanges(ctx SaveContext) error {
@@ -145,7 +159,7 @@ func (s *AutoCommitStrategy) SaveChanges(ctx SaveContext) error {
 	if !codeResult.Created {
 		logCtx := logging.WithComponent(context.Background(), "checkpoint")
 		logging.Info(logCtx, "checkpoint skipped (no changes)",
-			slog.String("strategy", "auto-commit"),
+			slog.String("strategy", s.Name()),
 			slog.String("checkpoint_type", "session"),
 		)
 		fmt.Fprintf(os.Stderr, "Skipped checkpoint (no changes since last commit)\n")
@@ -158,11 +172,12 @@ func (s *AutoCommitStrategy) SaveChanges(ctx SaveContext) error {
 	if err != nil {
 		return fmt.Errorf("failed to commit metadata to entire/checkpoints/v1 branch: %w", err)
 	}
+	writeCommitNote(repo, codeResult.CommitHash, cpID)
 
 	// Log checkpoint creation
 	logCtx := logging.WithComponent(context.Background(), "checkpoint")
 	logging.Info(logCtx, "checkpoint saved",
-		slog.String("strategy", "auto-commit"),
+		slog.String("strategy", s.Name()),
 		slog.String("checkpoint_type", "session"),
 		slog.String("checkpoint_id", cpID.String()),
 		slog.Int("modified_files", len(ctx.ModifiedFiles)),
@@ -210,7 +225,8 @@ func (s *AutoCommitStrategy) commitCodeToActive(repo *git.Repository, ctx SaveCo
 	StageFiles(worktree, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles, StageForSession)
 
 	// Add checkpoint ID trailer to commit message
-	commitMsg := ctx.CommitMessage + "\n\n" + trailers.CheckpointTrailerKey + ": " + checkpointID.String()
+	message := checkpointMessage(repo, s.Name(), ctx, headBefore.Hash().String(), nil)
+	commitMsg := message + "\n\n" + trailers.CheckpointTrailerKey + ": " + checkpointID.String() + s.stackTrailers(filepath.Base(ctx.MetadataDir), checkpointID)
 
 	author := &object.Signature{
 		Name:  ctx.AuthorName,
@@ -244,7 +260,7 @@ func (s *AutoCommitStrategy) commitMetadataToMetadataBranch(repo *git.Repository
 	sessionID := filepath.Base(ctx.MetadataDir)
 
 	// Get current branch name
-	branchName := GetCurrentBranchName(repo)
+	branchName := checkpointBranchName(repo)
 
 	// Combine all file changes into FilesTouched (same as manual-commit)
 	filesTouched := mergeFilesTouched(nil, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
@@ -253,17 +269,20 @@ func (s *AutoCommitStrategy) commitMetadataToMetadataBranch(repo *git.Repository
 	err = store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
 		CheckpointID:                checkpointID,
 		SessionID:                   sessionID,
-		Strategy:                    StrategyNameAutoCommit, // Use new strategy name
+		Strategy:                    s.Name(),
 		Branch:                      branchName,
 		MetadataDir:                 ctx.MetadataDirAbs, // Copy all files from metadata dir
 		AuthorName:                  ctx.AuthorName,
 		AuthorEmail:                 ctx.AuthorEmail,
 		Agent:                       ctx.AgentType,
+		Automation:                  sessionAutomation(sessionID),
+		TaskType:                    sessionTaskType(sessionID),
 		TranscriptIdentifierAtStart: ctx.StepTranscriptIdentifier,
 		CheckpointTranscriptStart:   ctx.StepTranscriptStart,
 		TokenUsage:                  ctx.TokenUsage,
 		CheckpointsCount:            1,            // Each auto-commit checkpoint = 1
 		FilesTouched:                filesTouched, // Track modified files (same as manual-commit)
+		MetadataOnly:                checkpointsMetadataOnly(context.Background()),
 	})
 	if err != nil {
 		return plumbing.ZeroHash, fmt.Errorf("failed to write committed checkpoint: %w", err)
@@ -515,7 +534,7 @@ func (s *AutoCommitStrategy) SaveTaskCheckpoint(ctx TaskCheckpointContext) error
 	// Log task checkpoint creation
 	logCtx := logging.WithComponent(context.Background(), "checkpoint")
 	attrs := []any{
-		slog.String("strategy", "auto-commit"),
+		slog.String("strategy", s.Name()),
 		slog.String("checkpoint_type", "task"),
 		slog.String("checkpoint_id", cpID.String()),
 		slog.String("checkpoint_uuid", ctx.CheckpointUUID),
@@ -579,11 +598,11 @@ func (s *AutoCommitStrategy) commitTaskCodeToActive(repo *git.Repository, ctx Ta
 			shortToolUseID,
 		)
 	} else {
-		subject = FormatSubagentEndMessage(ctx.SubagentType, ctx.TaskDescription, shortToolUseID)
+		subject = taskMessage(s.Name(), ctx, FormatSubagentEndMessage(ctx.SubagentType, ctx.TaskDescription, shortToolUseID))
 	}
 
 	// Add checkpoint ID trailer to commit message
-	commitMsg := subject + "\n\n" + trailers.CheckpointTrailerKey + ": " + checkpointID.String()
+	commitMsg := subject + "\n\n" + trailers.CheckpointTrailerKey + ": " + checkpointID.String() + s.stackTrailers(ctx.SessionID, checkpointID)
 
 	author := &object.Signature{
 		Name:  ctx.AuthorName,
@@ -632,17 +651,32 @@ func (s *AutoCommitStrategy) commitTaskMetadataToMetadataBranch(repo *git.Reposi
 			shortToolUseID,
 		)
 	} else {
-		messageSubject = FormatSubagentEndMessage(ctx.SubagentType, ctx.TaskDescription, shortToolUseID)
+		messageSubject = taskMessage(s.Name(), ctx, FormatSubagentEndMessage(ctx.SubagentType, ctx.TaskDescription, shortToolUseID))
 	}
 
 	// Get current branch name
-	branchName := GetCurrentBranchName(repo)
+	branchName := checkpointBranchName(repo)
+
+	// The task checkpoint is its own committed checkpoint here; record the
+	// subagent on it so it shows as a child of the session like with
+	// manual-commit. Its transcript is already under tasks/<tool-use-id>/.
+	var subagents []checkpoint.SubagentMetadata
+	if !ctx.IsIncremental {
+		subagents = []checkpoint.SubagentMetadata{{
+			ToolUseID:    ctx.ToolUseID,
+			AgentID:      ctx.AgentID,
+			SubagentType: ctx.SubagentType,
+			Description:  ctx.TaskDescription,
+			Checkpoints:  1,
+			FilesTouched: mergeFilesTouched(nil, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles),
+		}}
+	}
 
 	// Write committed checkpoint using the checkpoint store
 	err = store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
 		CheckpointID:           checkpointID,
 		SessionID:              ctx.SessionID,
-		Strategy:               StrategyNameAutoCommit,
+		Strategy:               s.Name(),
 		Branch:                 branchName,
 		IsTask:                 true,
 		ToolUseID:              ctx.ToolUseID,
@@ -658,6 +692,10 @@ func (s *AutoCommitStrategy) commitTaskMetadataToMetadataBranch(repo *git.Reposi
 		AuthorName:             ctx.AuthorName,
 		AuthorEmail:            ctx.AuthorEmail,
 		Agent:                  ctx.AgentType,
+		Automation:             sessionAutomation(ctx.SessionID),
+		TaskType:               sessionTaskType(ctx.SessionID),
+		Subagents:              subagents,
+		MetadataOnly:           checkpointsMetadataOnly(context.Background()),
 	})
 	if err != nil {
 		return plumbing.ZeroHash, fmt.Errorf("failed to write task checkpoint: %w", err)
@@ -912,48 +950,47 @@ func (s *AutoCommitStrategy) InitializeSession(sessionID string, agentType agent
 
 	baseCommit := head.Hash().String()
 
-	// Check if session state already exists (e.g., session resuming)
-	existing, err := LoadSessionState(sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to check existing session state: %w", err)
-	}
-	if existing != nil {
-		// Session already initialized — update last interaction time on every prompt submit
+	// Update the session's state if it already exists (e.g., session resuming),
+	// create it otherwise, under the state lock as other hooks may be saving it
+	err = UpsertSessionState(sessionID, func(existing *SessionState) (*SessionState, error) {
 		now := time.Now()
-		existing.LastInteractionTime = &now
-
-		// Backfill FirstPrompt if empty (for sessions
-		// created before the first_prompt field was added, or resumed sessions)
-		if existing.FirstPrompt == "" && userPrompt != "" {
-			existing.FirstPrompt = truncatePromptForStorage(userPrompt)
-		}
-
-		if err := SaveSessionState(existing); err != nil {
-			return fmt.Errorf("failed to update session state: %w", err)
+		if existing != nil {
+			// Session already initialized — update last interaction time on every prompt submit
+			existing.LastInteractionTime = &now
+
+			// Backfill FirstPrompt if empty (for sessions
+			// created before the first_prompt field was added, or resumed sessions)
+			if existing.FirstPrompt == "" && userPrompt != "" {
+				existing.FirstPrompt = truncatePromptForStorage(userPrompt)
+			}
+			if existing.Title == "" {
+				existing.Title = session.DeriveTitle(existing.FirstPrompt)
+			}
+			updateTaskType(existing, userPrompt)
+			return existing, nil
 		}
-		return nil
-	}
 
-	// Create new session state
-	now := time.Now()
-	state := &SessionState{
-		SessionID:           sessionID,
-		CLIVersion:          buildinfo.Version,
-		BaseCommit:          baseCommit,
-		StartedAt:           now,
-		LastInteractionTime: &now,
-		StepCount:           0,
-		// CheckpointTranscriptStart defaults to 0 (start from beginning of transcript)
-		FilesTouched:   []string{},
-		AgentType:      agentType,
-		TranscriptPath: transcriptPath,
-		FirstPrompt:    truncatePromptForStorage(userPrompt),
-	}
-
-	if err := SaveSessionState(state); err != nil {
+		// Create new session state
+		return &SessionState{
+			SessionID:           sessionID,
+			CLIVersion:          buildinfo.Version,
+			BaseCommit:          baseCommit,
+			StartedAt:           now,
+			LastInteractionTime: &now,
+			StepCount:           0,
+			// CheckpointTranscriptStart defaults to 0 (start from beginning of transcript)
+			FilesTouched:   []string{},
+			AgentType:      agentType,
+			Automation:     detectAutomation(repo),
+			TranscriptPath: transcriptPath,
+			FirstPrompt:    truncatePromptForStorage(userPrompt),
+			Title:          session.DeriveTitle(userPrompt),
+			TaskType:       classifyTaskType(userPrompt),
+		}, nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to save session state: %w", err)
 	}
-
 	return nil
 }
 
@@ -990,7 +1027,7 @@ func (s *AutoCommitStrategy) ListOrphanedItems() ([]CleanupItem, error) {
 			continue
 		}
 		// Only consider checkpoints created by this strategy
-		if summary.Strategy == StrategyNameAutoCommit {
+		if summary.Strategy == s.Name() {
 			autoCommitCheckpoints[cp.CheckpointID.String()] = true
 		}
 	}
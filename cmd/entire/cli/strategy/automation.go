@@ -0,0 +1,83 @@
+package strategy
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// Kinds of automation signal recorded on sessions (see detectAutomation).
+const (
+	AutomationCI       = "ci"
+	AutomationHeadless = "headless"
+	AutomationBot      = "bot"
+)
+
+// claudeEntrypointEnv is set by Claude Code for its subprocesses, hooks
+// included: "cli" interactively, "sdk-cli" for `claude -p` and "sdk-ts" or
+// "sdk-py" under the Agent SDK.
+const claudeEntrypointEnv = "CLAUDE_CODE_ENTRYPOINT"
+
+// detectAutomation returns the signals that a session is driven by automation
+// rather than supervised by a person, as "kind:detail": a CI runner, an agent
+// running headless, or a git identity that belongs to a bot. Returns nil for
+// interactive sessions.
+func detectAutomation(repo *git.Repository) []string {
+	var signals []string
+	if env := cienv.Detect(); env.Enabled {
+		signals = append(signals, AutomationCI+":"+env.Provider)
+	}
+	if entrypoint := os.Getenv(claudeEntrypointEnv); strings.HasPrefix(entrypoint, "sdk") {
+		signals = append(signals, AutomationHeadless+":"+entrypoint)
+	}
+	if identity := botIdentity(repo); identity != "" {
+		signals = append(signals, AutomationBot+":"+identity)
+	}
+	return signals
+}
+
+// botIdentity returns the git author name or email that identifies a bot, or
+// "" for a person. Names and emails ending in "[bot]" (GitHub App
+// identities) always match; settings.bot_identities adds glob patterns.
+func botIdentity(repo *git.Repository) string {
+	name, email := os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+	if name == "" || email == "" {
+		repoName, repoEmail := GetGitAuthorFromRepo(repo)
+		if name == "" {
+			name = repoName
+		}
+		if email == "" {
+			email = repoEmail
+		}
+	}
+	var patterns []string
+	if s, err := settings.Load(); err == nil {
+		patterns = s.BotIdentities
+	}
+	for _, identity := range []string{name, email} {
+		lower := strings.ToLower(identity)
+		if strings.HasSuffix(lower, "[bot]") || strings.Contains(lower, "[bot]@") {
+			return identity
+		}
+		for _, pattern := range patterns {
+			if ok, err := path.Match(strings.ToLower(pattern), lower); err == nil && ok {
+				return identity
+			}
+		}
+	}
+	return ""
+}
+
+// sessionAutomation returns the automation signals recorded for a session.
+func sessionAutomation(sessionID string) []string {
+	state, err := LoadSessionState(sessionID)
+	if err != nil || state == nil {
+		return nil
+	}
+	return state.Automation
+}
@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestDetectAutomation(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	t.Setenv(cienv.EnvVar, "0")
+	t.Setenv(claudeEntrypointEnv, "cli")
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	t.Setenv("GIT_AUTHOR_EMAIL", "")
+
+	if got := detectAutomation(repo); len(got) != 0 {
+		t.Errorf("interactive session = %v, want no signals", got)
+	}
+
+	t.Setenv(cienv.EnvVar, "1")
+	t.Setenv(claudeEntrypointEnv, "sdk-cli")
+	t.Setenv("GIT_AUTHOR_NAME", "renovate[bot]")
+	// The provider depends on the runner the tests happen to run on
+	got := detectAutomation(repo)
+	if len(got) != 3 || !strings.HasPrefix(got[0], "ci:") {
+		t.Fatalf("detectAutomation() = %v, want a CI, a headless and a bot signal", got)
+	}
+	if want := []string{"headless:sdk-cli", "bot:renovate[bot]"}; !slices.Equal(got[1:], want) {
+		t.Errorf("detectAutomation()[1:] = %v, want %v", got[1:], want)
+	}
+}
+
+func TestBotIdentity_Settings(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	t.Setenv("GIT_AUTHOR_EMAIL", "")
+
+	// setupGitRepo commits as test@test.com
+	if got := botIdentity(repo); got != "" {
+		t.Errorf("botIdentity() = %q, want a person", got)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".entire"), 0o750); err != nil {
+		t.Fatalf("failed to create .entire: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(`{"strategy": "manual-commit", "enabled": true, "bot_identities": ["TEST@*"]}`), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	if got := botIdentity(repo); got != "test@test.com" {
+		t.Errorf("botIdentity() = %q, want the email matched by bot_identities", got)
+	}
+}
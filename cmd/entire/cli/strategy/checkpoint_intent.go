@@ -0,0 +1,165 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Creating a checkpoint moves the shadow branch and then saves session state.
+// The step is journaled (see session.Intent) so a crash in between can't leave
+// a shadow branch ahead of its session state:
+//   - Crashed before the state was known: the shadow branch is moved back, and the
+//     changes are picked up by the next checkpoint (shadow trees are full snapshots)
+//   - Crashed after: the state the process was about to save is saved
+
+// Operations recorded in checkpoint intents.
+const (
+	intentOpCheckpoint     = "checkpoint"
+	intentOpTaskCheckpoint = "task-checkpoint"
+)
+
+// beginShadowBranchIntent records that op is about to move the session's
+// shadow branch. The caller must Close the journal.
+func (s *ManualCommitStrategy) beginShadowBranchIntent(repo *git.Repository, state *SessionState, op string) (*session.IntentJournal, plumbing.ReferenceName, error) {
+	store, err := s.getStateStore()
+	if err != nil {
+		return nil, "", err
+	}
+	refName := checkpoint.ShadowRefName(repo, checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID))
+	var old string
+	if ref, err := repo.Reference(refName, true); err == nil {
+		old = ref.Hash().String()
+	}
+	journal, err := store.BeginIntent(context.Background(), &session.Intent{
+		SessionID: state.SessionID,
+		Operation: op,
+		Refs:      []session.IntentRef{{Name: refName.String(), Old: old}},
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to record checkpoint intent: %w", err)
+	}
+	return journal, refName, nil
+}
+
+// saveStateAndCompleteIntent applies update to state and saves it after the
+// shadow branch moved to head, journaling the state first so recovery can
+// finish the save. Under the state lock, update is applied again to the state
+// on disk, so changes other processes saved meanwhile are kept.
+func (s *ManualCommitStrategy) saveStateAndCompleteIntent(journal *session.IntentJournal, refName plumbing.ReferenceName, head plumbing.Hash, state *SessionState, update func(*SessionState)) error {
+	update(state)
+	if err := journal.RefsUpdated(map[string]string{refName.String(): head.String()}, state); err != nil {
+		return err //nolint:wrapcheck // already wrapped by the journal
+	}
+	// Apply the update to the state on disk rather than saving state, which
+	// would drop what other hooks saved since it was loaded
+	err := s.updateSessionState(state.SessionID, func(latest *SessionState) error {
+		update(latest)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	completeIntent(journal)
+	return nil
+}
+
+// completeIntent removes a finished intent. Failing to remove it is harmless:
+// recovery would save the same state again.
+func completeIntent(journal *session.IntentJournal) {
+	if err := journal.Complete(); err != nil {
+		logging.Warn(logging.WithComponent(context.Background(), "checkpoint"), "failed to complete checkpoint intent",
+			slog.String("error", err.Error()))
+	}
+}
+
+// RecoverInterruptedCheckpoints finishes or undoes checkpoints that a crashed
+// process left half done, in any worktree. Hooks call it before doing anything
+// else. Returns the number of checkpoints recovered.
+func RecoverInterruptedCheckpoints() (int, error) {
+	store, err := sessionStateStore()
+	if err != nil {
+		return 0, err
+	}
+	var repo *git.Repository
+	return store.RecoverIntents(context.Background(), func(intent *session.Intent) error { //nolint:wrapcheck // already wrapped by RecoverIntents
+		if intent.RefsUpdated() {
+			// The checkpoint exists; only the state save may be missing
+			return store.Save(context.Background(), intent.State) //nolint:wrapcheck // already wrapped by Save
+		}
+		if repo == nil {
+			if repo, err = OpenRepository(); err != nil {
+				return fmt.Errorf("failed to open git repository: %w", err)
+			}
+		}
+		for _, ref := range intent.Refs {
+			if err := undoIntentRef(repo, intent.SessionID, ref); err != nil {
+				return err
+			}
+		}
+		logging.Info(logging.WithComponent(context.Background(), "checkpoint"), "rolled back interrupted checkpoint",
+			slog.String("session_id", intent.SessionID),
+			slog.String("operation", intent.Operation))
+		return nil
+	})
+}
+
+// undoIntentRef moves ref back to its old value if it points at the commit the
+// interrupted operation wrote: a commit of the session directly on top of the
+// old value. If anything else moved the ref since, it is left alone.
+func undoIntentRef(repo *git.Repository, sessionID string, ref session.IntentRef) error {
+	name := plumbing.ReferenceName(ref.Name)
+	current, err := repo.Reference(name, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", ref.Name, err)
+	}
+	if current.Hash().String() == ref.Old {
+		return nil // The ref never moved
+	}
+	commit, err := repo.CommitObject(current.Hash())
+	if err != nil || !isIntentCommit(commit.Message, commit.ParentHashes, sessionID, ref.Old) {
+		return nil //nolint:nilerr // not the interrupted operation's commit
+	}
+
+	if ref.Old == "" {
+		branch, ok := vcs.Detect(repo).ShadowBranch(name)
+		if !ok {
+			branch = name.Short()
+		}
+		if err := DeleteBranchCLI(branch); err != nil && !errors.Is(err, ErrBranchNotFound) {
+			return err
+		}
+		return nil
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(name, plumbing.NewHash(ref.Old))); err != nil {
+		return fmt.Errorf("failed to reset %s: %w", ref.Name, err)
+	}
+	return nil
+}
+
+// isIntentCommit reports whether a commit is one a checkpoint of sessionID
+// created on top of old (no parent if old is empty).
+func isIntentCommit(message string, parents []plumbing.Hash, sessionID, old string) bool {
+	if id, ok := trailers.ParseSession(message); !ok || id != sessionID {
+		return false
+	}
+	if old == "" {
+		return len(parents) == 0
+	}
+	return len(parents) == 1 && parents[0].String() == old
+}
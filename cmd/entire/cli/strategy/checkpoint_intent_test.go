@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeShadowCommit creates a shadow branch commit for sessionID on top of
+// parent (none if zero) and points refName at it, as a checkpoint does.
+func writeShadowCommit(t *testing.T, repo *git.Repository, refName plumbing.ReferenceName, sessionID string, parent plumbing.Hash) plumbing.Hash {
+	t.Helper()
+	head, err := repo.Head()
+	require.NoError(t, err)
+	headCommit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+
+	sig := object.Signature{Name: "test", Email: "test@test.com", When: time.Now()}
+	commit := &object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   trailers.FormatShadowCommit("checkpoint", ".entire/metadata/"+sessionID, sessionID),
+		TreeHash:  headCommit.TreeHash,
+	}
+	if parent != plumbing.ZeroHash {
+		commit.ParentHashes = []plumbing.Hash{parent}
+	}
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(t, commit.Encode(obj))
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(refName, hash)))
+	return hash
+}
+
+func setupIntentTest(t *testing.T, sessionID string) (*git.Repository, *ManualCommitStrategy, *SessionState) {
+	t.Helper()
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	state := &SessionState{SessionID: sessionID, BaseCommit: head.Hash().String(), StartedAt: time.Now()}
+	require.NoError(t, SaveSessionState(state))
+	return repo, &ManualCommitStrategy{}, state
+}
+
+func TestRecoverInterruptedCheckpoints_RollsBackBeforeStateIsKnown(t *testing.T) {
+	repo, s, state := setupIntentTest(t, "2026-10-14-crash-early")
+
+	journal, refName, err := s.beginShadowBranchIntent(repo, state, intentOpCheckpoint)
+	require.NoError(t, err)
+	writeShadowCommit(t, repo, refName, state.SessionID, plumbing.ZeroHash)
+	journal.Close() // Crash before the state was journaled
+
+	recovered, err := RecoverInterruptedCheckpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	_, err = repo.Reference(refName, true)
+	require.ErrorIs(t, err, plumbing.ErrReferenceNotFound, "shadow branch should be rolled back")
+
+	// The intent is gone
+	recovered, err = RecoverInterruptedCheckpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 0, recovered)
+}
+
+func TestRecoverInterruptedCheckpoints_ResetsToPreviousCheckpoint(t *testing.T) {
+	repo, s, state := setupIntentTest(t, "2026-10-14-crash-second")
+
+	refName := plumbing.NewBranchReferenceName(checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID))
+	first := writeShadowCommit(t, repo, refName, state.SessionID, plumbing.ZeroHash)
+
+	journal, _, err := s.beginShadowBranchIntent(repo, state, intentOpCheckpoint)
+	require.NoError(t, err)
+	writeShadowCommit(t, repo, refName, state.SessionID, first)
+	journal.Close()
+
+	_, err = RecoverInterruptedCheckpoints()
+	require.NoError(t, err)
+
+	ref, err := repo.Reference(refName, true)
+	require.NoError(t, err)
+	assert.Equal(t, first, ref.Hash())
+}
+
+func TestRecoverInterruptedCheckpoints_SavesJournaledState(t *testing.T) {
+	repo, s, state := setupIntentTest(t, "2026-10-14-crash-late")
+
+	journal, refName, err := s.beginShadowBranchIntent(repo, state, intentOpCheckpoint)
+	require.NoError(t, err)
+	commit := writeShadowCommit(t, repo, refName, state.SessionID, plumbing.ZeroHash)
+
+	updated := *state
+	updated.StepCount = 1
+	require.NoError(t, journal.RefsUpdated(map[string]string{refName.String(): commit.String()}, &updated))
+	journal.Close() // Crash before the state was saved
+
+	recovered, err := RecoverInterruptedCheckpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	loaded, err := LoadSessionState(state.SessionID)
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, 1, loaded.StepCount)
+
+	ref, err := repo.Reference(refName, true)
+	require.NoError(t, err)
+	assert.Equal(t, commit, ref.Hash(), "checkpoint should be kept")
+}
+
+func TestRecoverInterruptedCheckpoints_KeepsOtherSessionsCommits(t *testing.T) {
+	repo, s, state := setupIntentTest(t, "2026-10-14-crash-shared")
+
+	journal, refName, err := s.beginShadowBranchIntent(repo, state, intentOpCheckpoint)
+	require.NoError(t, err)
+	// A concurrent session in the same worktree created the branch instead
+	other := writeShadowCommit(t, repo, refName, "2026-10-14-other", plumbing.ZeroHash)
+	journal.Close()
+
+	_, err = RecoverInterruptedCheckpoints()
+	require.NoError(t, err)
+
+	ref, err := repo.Reference(refName, true)
+	require.NoError(t, err)
+	assert.Equal(t, other, ref.Hash())
+}
+
+func TestRecoverInterruptedCheckpoints_SkipsCheckpointsInProgress(t *testing.T) {
+	repo, s, state := setupIntentTest(t, "2026-10-14-in-progress")
+
+	journal, refName, err := s.beginShadowBranchIntent(repo, state, intentOpCheckpoint)
+	require.NoError(t, err)
+	defer journal.Close()
+	commit := writeShadowCommit(t, repo, refName, state.SessionID, plumbing.ZeroHash)
+
+	recovered, err := RecoverInterruptedCheckpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 0, recovered)
+
+	ref, err := repo.Reference(refName, true)
+	require.NoError(t, err)
+	assert.Equal(t, commit, ref.Hash())
+}
@@ -0,0 +1,27 @@
+package strategy
+
+import (
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// ShadowWorktreeID returns the worktree ID shadow branches are named with.
+// In CI every job checks out into a fresh, often linked, worktree whose name
+// means nothing to the next job, so shadow branches are keyed by commit alone.
+func ShadowWorktreeID(worktreePath string) (string, error) {
+	if cienv.Enabled() {
+		return "", nil
+	}
+	return paths.GetWorktreeID(worktreePath) //nolint:wrapcheck // Callers wrap with context
+}
+
+// checkpointBranchName returns the branch recorded in checkpoint metadata.
+// CI runners usually build a detached HEAD; the provider's branch is used then.
+func checkpointBranchName(repo *git.Repository) string {
+	if branch := GetCurrentBranchName(repo); branch != "" {
+		return branch
+	}
+	return cienv.Detect().Branch
+}
@@ -0,0 +1,54 @@
+package strategy
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestCIMode_LinkedWorktreeOnDetachedHead(t *testing.T) {
+	dir := setupGitRepo(t)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	branch := GetCurrentBranchName(repo)
+	wt := filepath.Join(t.TempDir(), "runner-1234")
+	if out, err := exec.Command("git", "-C", dir, "worktree", "add", "--detach", wt).CombinedOutput(); err != nil {
+		t.Fatalf("git worktree add failed: %v\n%s", err, out)
+	}
+	wtRepo, err := git.PlainOpenWithOptions(wt, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	t.Setenv(cienv.EnvVar, "0")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REF_NAME", "main")
+	if id, err := ShadowWorktreeID(wt); err != nil || id != "runner-1234" {
+		t.Errorf("ShadowWorktreeID outside CI = %q, %v; want the worktree name", id, err)
+	}
+	if branch := checkpointBranchName(wtRepo); branch != "" {
+		t.Errorf("checkpointBranchName outside CI = %q, want empty for a detached HEAD", branch)
+	}
+
+	t.Setenv(cienv.EnvVar, "")
+	if id, err := ShadowWorktreeID(wt); err != nil || id != "" {
+		t.Errorf("ShadowWorktreeID in CI = %q, %v; want empty so shadow branches follow the commit", id, err)
+	}
+	if branch := checkpointBranchName(wtRepo); branch != "main" {
+		t.Errorf("checkpointBranchName in CI = %q, want the provider's branch", branch)
+	}
+	if got := checkpointBranchName(repo); got != branch {
+		t.Errorf("checkpointBranchName on a branch = %q, want the checked-out %q", got, branch)
+	}
+
+	t.Setenv("ENTIRE_TEST_TTY", "")
+	if hasTTY() {
+		t.Error("hasTTY() in CI = true, want false")
+	}
+}
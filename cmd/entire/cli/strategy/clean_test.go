@@ -2,13 +2,17 @@ package strategy
 
 import (
 	"context"
+	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -241,6 +245,61 @@ func TestDeleteShadowBranches(t *testing.T) {
 	}
 }
 
+func TestShadowBranches_JujutsuHiddenRefs(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".jj", "repo"), 0o755); err != nil {
+		t.Fatalf("failed to create .jj: %v", err)
+	}
+
+	t.Chdir(dir)
+
+	emptyTreeHash := plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904")
+	commitHash, err := createCommit(repo, emptyTreeHash, plumbing.ZeroHash, "initial commit", "test", "test@test.com")
+	if err != nil {
+		t.Fatalf("failed to create initial commit: %v", err)
+	}
+
+	// A hidden shadow ref, and a branch jj would show as a bookmark
+	hidden := plumbing.NewHashReference(vcs.Jujutsu.ShadowRef("entire/abc1234-e3b0c4"), commitHash)
+	if err := repo.Storer.SetReference(hidden); err != nil {
+		t.Fatalf("failed to create hidden ref: %v", err)
+	}
+	branch := plumbing.NewHashReference(plumbing.NewBranchReferenceName("entire/def5678"), commitHash)
+	if err := repo.Storer.SetReference(branch); err != nil {
+		t.Fatalf("failed to create branch: %v", err)
+	}
+
+	branches, err := ListShadowBranches()
+	if err != nil {
+		t.Fatalf("ListShadowBranches() error = %v", err)
+	}
+	if len(branches) != 1 || branches[0] != "entire/abc1234-e3b0c4" {
+		t.Fatalf("ListShadowBranches() = %v, want [entire/abc1234-e3b0c4]", branches)
+	}
+
+	deleted, failed, err := DeleteShadowBranches(branches)
+	if err != nil {
+		t.Fatalf("DeleteShadowBranches() error = %v", err)
+	}
+	if len(deleted) != 1 || len(failed) != 0 {
+		t.Fatalf("DeleteShadowBranches() deleted %v, failed %v", deleted, failed)
+	}
+	out, err := exec.CommandContext(context.Background(), "git", "for-each-ref", "refs/entire/hidden/").Output()
+	if err != nil {
+		t.Fatalf("git for-each-ref failed: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("hidden ref still exists after deletion: %s", out)
+	}
+	if err := DeleteBranchCLI("entire/abc1234-e3b0c4"); !errors.Is(err, ErrBranchNotFound) {
+		t.Errorf("DeleteBranchCLI() of deleted ref = %v, want ErrBranchNotFound", err)
+	}
+}
+
 func TestDeleteShadowBranches_NonExistent(t *testing.T) {
 	// Setup: create a temp git repo
 	dir := t.TempDir()
@@ -4,15 +4,20 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
 
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -87,16 +92,15 @@ func ListShadowBranches() ([]string, error) {
 	}
 
 	var shadowBranches []string
+	backend := vcs.Detect(repo)
 
 	err = refs.ForEach(func(ref *plumbing.Reference) error {
-		// Only look at branch references
-		if !ref.Name().IsBranch() {
+		// Only look at refs in the backend's shadow namespace (branches for git)
+		branchName, ok := backend.ShadowBranch(ref.Name())
+		if !ok {
 			return nil
 		}
 
-		// Extract branch name without refs/heads/ prefix
-		branchName := strings.TrimPrefix(ref.Name().String(), "refs/heads/")
-
 		if IsShadowBranch(branchName) {
 			shadowBranches = append(shadowBranches, branchName)
 		}
@@ -136,10 +140,66 @@ func DeleteShadowBranches(branches []string) (deleted []string, failed []string,
 	return deleted, failed, nil
 }
 
+// ArchiveShadowBranches moves branches to ArchivedShadowRefPrefix, keeping
+// their checkpoints for git but out of the branch list. Returns the branches
+// moved and the ones that failed.
+func ArchiveShadowBranches(branches []string) (archived []string, failed []string, err error) {
+	if len(branches) == 0 {
+		return []string{}, []string{}, nil
+	}
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	for _, branch := range branches {
+		ref, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+		if err != nil {
+			failed = append(failed, branch)
+			continue
+		}
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(ArchivedShadowRefPrefix+branch), ref.Hash())); err != nil {
+			failed = append(failed, branch)
+			continue
+		}
+		if err := deleteShadowBranch(repo, branch); err != nil {
+			failed = append(failed, branch)
+			continue
+		}
+		archived = append(archived, branch)
+	}
+	return archived, failed, nil
+}
+
+// StateEntryPrefix starts the name of every state directory and file Entire
+// keeps outside the worktree (see fsenv.StateDir).
+const StateEntryPrefix = "entire-"
+
+// ListStateEntries returns the paths of Entire's state directories and files,
+// sorted, except those named in skip.
+func ListStateEntries(skip ...string) ([]string, error) {
+	commonDir, err := GetGitCommonDir()
+	if err != nil {
+		return nil, err
+	}
+	stateRoot := fsenv.StateDir(commonDir, "")
+	dirEntries, err := os.ReadDir(stateRoot)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list state directory: %w", err)
+	}
+	var entries []string
+	for _, e := range dirEntries {
+		if strings.HasPrefix(e.Name(), StateEntryPrefix) && !slices.Contains(skip, e.Name()) {
+			entries = append(entries, filepath.Join(stateRoot, e.Name()))
+		}
+	}
+	return entries, nil
+}
+
 // ListOrphanedSessionStates returns session state files that are orphaned.
 // A session state is orphaned if:
 //   - No checkpoints on entire/checkpoints/v1 reference this session ID
 //   - No shadow branch exists for the session's base commit
+//   - The session has ended, or the worktree it ran in no longer exists
 //
 // This is strategy-agnostic as session states are shared by all strategies.
 func ListOrphanedSessionStates() ([]CleanupItem, error) {
@@ -181,6 +241,10 @@ func ListOrphanedSessionStates() ([]CleanupItem, error) {
 		shadowBranchSet[branch] = true
 	}
 
+	// Sessions still running in any worktree are never orphaned
+	worktrees, _ := ListWorktrees() //nolint:errcheck // Best effort; falls back to checking directories
+	refs := countSessionRefs(states, worktrees)
+
 	var orphaned []CleanupItem
 	now := time.Now()
 
@@ -190,6 +254,9 @@ func ListOrphanedSessionStates() ([]CleanupItem, error) {
 		if now.Sub(state.StartedAt) < sessionGracePeriod {
 			continue
 		}
+		if refs.IsRunning(state.SessionID) {
+			continue
+		}
 
 		// Check if session has checkpoints on entire/checkpoints/v1
 		hasCheckpoints := sessionsWithCheckpoints[state.SessionID]
@@ -495,6 +562,11 @@ func DeleteAllCleanupItems(items []CleanupItem) (*CleanupResult, error) {
 			slog.Int("failed_checkpoints", len(result.FailedCheckpoints)),
 		)
 	}
+	if totalDeleted > 0 {
+		// Measure again so hooks stop writing metadata-only checkpoints
+		// as soon as the footprint is back under quota
+		_, _ = CheckQuota(context.Background(), true) //nolint:errcheck // best-effort
+	}
 
 	return result, nil
 }
@@ -0,0 +1,194 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CommitMessageData is the data available to commit message templates
+// (commit_messages in settings), e.g. "{{.PromptSummary}} ({{len .FilesTouched}} files)".
+type CommitMessageData struct {
+	SessionID string
+	Agent     string
+	Strategy  string
+
+	// Prompt is the user's last prompt as typed.
+	Prompt string
+	// PromptSummary is the built-in message derived from the prompt.
+	PromptSummary string
+
+	// FilesTouched are the files changed in this checkpoint (repo-relative).
+	FilesTouched []string
+
+	// Summary is the session's summary from 'entire transcript summarize',
+	// empty if it has none, e.g. "{{.Summary.Intent}}".
+	Summary session.Summary
+
+	// Task checkpoint fields (only set for the task template)
+	ToolUseID       string
+	SubagentType    string
+	TaskDescription string
+
+	// attribution computes the checkpoint's attribution on first use, so
+	// templates that don't reference it don't pay for the tree diff.
+	attribution func() *checkpoint.InitialAttribution
+}
+
+// AgentPercentage returns the percentage of lines added since the session's base
+// commit that were written by the agent, as if the checkpoint were committed now.
+// Returns 0 if attribution can't be calculated.
+func (d CommitMessageData) AgentPercentage() float64 {
+	if d.attribution == nil {
+		return 0
+	}
+	if attr := d.attribution(); attr != nil {
+		return attr.AgentPercentage
+	}
+	return 0
+}
+
+var commitMessageFuncs = template.FuncMap{
+	"join": strings.Join,
+	"truncate": func(maxRunes int, s string) string {
+		return stringutil.TruncateRunes(s, maxRunes, "...")
+	},
+	"firstLine": func(s string) string {
+		line, _, _ := strings.Cut(s, "\n")
+		return line
+	},
+}
+
+// parseCommitMessageTemplate parses a commit message template with the template funcs.
+func parseCommitMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("commit_message").Funcs(commitMessageFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderCommitMessage renders text with data. Returns fallback if text is empty,
+// or if the template fails or renders to nothing; hooks must never fail on a
+// bad template, so failures are logged instead.
+func renderCommitMessage(text string, data CommitMessageData, fallback string) string {
+	if strings.TrimSpace(text) == "" {
+		return fallback
+	}
+	logCtx := logging.WithComponent(context.Background(), "commit-message")
+
+	tmpl, err := parseCommitMessageTemplate(text)
+	if err != nil {
+		logging.Warn(logCtx, "using built-in commit message", slog.String("error", err.Error()))
+		return fallback
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		logging.Warn(logCtx, "using built-in commit message",
+			slog.String("error", fmt.Errorf("commit message template failed: %w", err).Error()))
+		return fallback
+	}
+
+	message := strings.TrimSpace(sb.String())
+	if message == "" {
+		return fallback
+	}
+	return message
+}
+
+// commitMessageTemplates returns the configured templates, or empty templates
+// if none are configured or settings can't be loaded.
+func commitMessageTemplates() settings.CommitMessageTemplates {
+	s, err := settings.Load()
+	if err != nil || s.CommitMessages == nil {
+		return settings.CommitMessageTemplates{}
+	}
+	return *s.CommitMessages
+}
+
+// checkpointMessage renders the checkpoint template for ctx.
+// baseCommit and promptAttributions feed AgentPercentage; baseCommit may be empty.
+func checkpointMessage(repo *git.Repository, strategyName string, ctx SaveContext, baseCommit string, promptAttributions []PromptAttribution) string {
+	text := commitMessageTemplates().Checkpoint
+	if text == "" {
+		return ctx.CommitMessage
+	}
+
+	files := mergeFilesTouched(nil, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+	data := CommitMessageData{
+		SessionID:     ctx.SessionID,
+		Agent:         string(ctx.AgentType),
+		Strategy:      strategyName,
+		Prompt:        ctx.Prompt,
+		PromptSummary: ctx.CommitMessage,
+		FilesTouched:  files,
+		Summary:       storedSessionSummary(ctx.SessionID),
+		attribution:   lazyWorktreeAttribution(repo, baseCommit, files, promptAttributions),
+	}
+	return renderCommitMessage(text, data, ctx.CommitMessage)
+}
+
+// taskMessage renders the task template for ctx, falling back to subject.
+func taskMessage(strategyName string, ctx TaskCheckpointContext, subject string) string {
+	text := commitMessageTemplates().Task
+	if text == "" {
+		return subject
+	}
+
+	data := CommitMessageData{
+		SessionID:       ctx.SessionID,
+		Agent:           string(ctx.AgentType),
+		Strategy:        strategyName,
+		PromptSummary:   subject,
+		FilesTouched:    mergeFilesTouched(nil, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles),
+		Summary:         storedSessionSummary(ctx.SessionID),
+		ToolUseID:       ctx.ToolUseID,
+		SubagentType:    ctx.SubagentType,
+		TaskDescription: ctx.TaskDescription,
+	}
+	return renderCommitMessage(text, data, subject)
+}
+
+// storedSessionSummary returns the summary stored in the session's state,
+// empty if there is none.
+func storedSessionSummary(sessionID string) session.Summary {
+	state, err := LoadSessionState(sessionID)
+	if err != nil || state == nil || state.Summary == nil {
+		return session.Summary{}
+	}
+	return *state.Summary
+}
+
+// lazyWorktreeAttribution returns a function computing the attribution of the
+// current worktree against baseCommit, treating the worktree as the agent's
+// checkpoint. The result is computed at most once.
+func lazyWorktreeAttribution(repo *git.Repository, baseCommit string, files []string, promptAttributions []PromptAttribution) func() *checkpoint.InitialAttribution {
+	return sync.OnceValue(func() *checkpoint.InitialAttribution {
+		candidate, err := buildCommitCandidateTree(repo, true)
+		if err != nil {
+			return nil
+		}
+		var baseTree *object.Tree
+		if baseCommit != "" {
+			if commit, err := repo.CommitObject(plumbing.NewHash(baseCommit)); err == nil {
+				if tree, err := commit.Tree(); err == nil {
+					baseTree = tree
+				}
+			}
+		}
+		return CalculateAttributionWithAccumulated(configuredAttributionGranularity(), baseTree, candidate, candidate, files, promptAttributions)
+	})
+}
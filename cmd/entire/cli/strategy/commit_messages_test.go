@@ -0,0 +1,140 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRenderCommitMessage(t *testing.T) {
+	data := CommitMessageData{
+		SessionID:     "2026-01-01-abc",
+		Agent:         "Claude Code",
+		Strategy:      StrategyNameManualCommit,
+		Prompt:        "Add a login form\nwith validation",
+		PromptSummary: "Add a login form",
+		FilesTouched:  []string{"a.go", "b.go"},
+		Summary:       session.Summary{Intent: "Add a validated login form", Decisions: []string{"Validate on the client"}},
+		attribution: func() *checkpoint.InitialAttribution {
+			return &checkpoint.InitialAttribution{AgentPercentage: 75}
+		},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty template", "", "fallback"},
+		{"placeholders", "{{.PromptSummary}} [{{.Agent}}]", "Add a login form [Claude Code]"},
+		{"files", "{{len .FilesTouched}} files: {{join .FilesTouched \", \"}}", "2 files: a.go, b.go"},
+		{"attribution", "agent {{printf \"%.0f\" .AgentPercentage}}%", "agent 75%"},
+		{"first line and truncate", "{{.Prompt | firstLine | truncate 7}}", "Add ..."},
+		{"session", "{{.SessionID}} via {{.Strategy}}", "2026-01-01-abc via manual-commit"},
+		{"summary", "{{.Summary.Intent}}\n\n{{range .Summary.Decisions}}- {{.}}{{end}}", "Add a validated login form\n\n- Validate on the client"},
+		{"no summary", "{{.Summary.Outcome}}", "fallback"},
+		{"parse error", "{{.PromptSummary", "fallback"},
+		{"unknown field", "{{.Nope}}", "fallback"},
+		{"renders empty", "{{if false}}x{{end}}  ", "fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderCommitMessage(tt.text, data, "fallback"); got != tt.want {
+				t.Errorf("renderCommitMessage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitMessageData_AgentPercentage_NoAttribution(t *testing.T) {
+	if got := (CommitMessageData{}).AgentPercentage(); got != 0 {
+		t.Errorf("AgentPercentage() = %v, want 0", got)
+	}
+	data := CommitMessageData{attribution: func() *checkpoint.InitialAttribution { return nil }}
+	if got := data.AgentPercentage(); got != 0 {
+		t.Errorf("AgentPercentage() = %v, want 0", got)
+	}
+}
+
+func TestAutoCommitStrategy_SaveChanges_UsesCheckpointTemplate(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	settingsDir := filepath.Join(dir, paths.EntireDir)
+	if err := os.MkdirAll(settingsDir, 0o755); err != nil {
+		t.Fatalf("failed to create settings dir: %v", err)
+	}
+	settingsJSON := `{"strategy": "auto-commit", "commit_messages": {"checkpoint": "agent: {{.PromptSummary}} ({{len .FilesTouched}} files, {{printf \"%.0f\" .AgentPercentage}}% agent)"}}`
+	if err := os.WriteFile(filepath.Join(settingsDir, paths.SettingsFileName), []byte(settingsJSON), 0o644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	if _, err := worktree.Add(filepath.Join(paths.EntireDir, paths.SettingsFileName)); err != nil {
+		t.Fatalf("failed to add settings: %v", err)
+	}
+	if _, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com"},
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+
+	s := NewAutoCommitStrategy()
+	if err := s.EnsureSetup(); err != nil {
+		t.Fatalf("EnsureSetup() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	sessionID := "2026-01-01-template-session"
+	metadataDir := filepath.Join(paths.EntireMetadataDir, sessionID)
+	metadataDirAbs := filepath.Join(dir, metadataDir)
+	if err := os.MkdirAll(metadataDirAbs, 0o750); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(metadataDirAbs, paths.TranscriptFileName), []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+
+	if err := s.SaveChanges(SaveContext{
+		SessionID:      sessionID,
+		CommitMessage:  "Add main",
+		Prompt:         "add a main function",
+		MetadataDir:    metadataDir,
+		MetadataDirAbs: metadataDirAbs,
+		NewFiles:       []string{"main.go"},
+		AuthorName:     "Test",
+		AuthorEmail:    "test@test.com",
+	}); err != nil {
+		t.Fatalf("SaveChanges() error = %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get HEAD commit: %v", err)
+	}
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	if want := "agent: Add main (1 files, 100% agent)"; subject != want {
+		t.Errorf("commit subject = %q, want %q", subject, want)
+	}
+}
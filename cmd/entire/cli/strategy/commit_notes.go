@@ -0,0 +1,123 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// commitNotesEnabled reports whether commit_notes is set in settings.
+func commitNotesEnabled() bool {
+	s, err := settings.Load()
+	if err != nil {
+		return false
+	}
+	return s.CommitNotes
+}
+
+// writeCommitNote attaches the metadata of checkpointID to commit as a git
+// note, if commit notes are enabled. The metadata branch stays the source of
+// truth, so failures are logged and never fail the hook.
+func writeCommitNote(repo *git.Repository, commit plumbing.Hash, checkpointID id.CheckpointID) {
+	if !commitNotesEnabled() {
+		return
+	}
+	logCtx := logging.WithComponent(context.Background(), "checkpoint")
+	store := checkpoint.NewGitStore(repo)
+	note, err := store.BuildCommitNote(context.Background(), checkpointID)
+	if err == nil {
+		authorName, authorEmail := GetGitAuthorFromRepo(repo)
+		err = store.WriteCommitNotes(map[plumbing.Hash]*checkpoint.CommitNote{commit: note}, authorName, authorEmail)
+	}
+	if err != nil {
+		logging.Warn(logCtx, "failed to write commit note",
+			slog.String("checkpoint_id", checkpointID.String()),
+			slog.String("commit", commit.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// pushCommitNotesCommon pushes refs/notes/entire alongside the metadata branch.
+// Notes fetched from the remote are merged in first, and again if the push is
+// rejected, so notes written in other clones are kept.
+func pushCommitNotesCommon(remote string) error {
+	if !commitNotesEnabled() || isPushSessionsDisabled() {
+		return nil
+	}
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil //nolint:nilerr // Hook must be silent on failure
+	}
+	if _, err := repo.Reference(plumbing.ReferenceName(paths.NotesRefName), true); err != nil {
+		return nil //nolint:nilerr // No notes yet
+	}
+
+	if err := mergeRemoteCommitNotes(repo, remote); err != nil {
+		fmt.Fprintf(os.Stderr, "[entire] Warning: couldn't merge remote commit notes: %v\n", err)
+	}
+	localRef, err := repo.Reference(plumbing.ReferenceName(paths.NotesRefName), true)
+	if err != nil {
+		return nil //nolint:nilerr // Hook must be silent on failure
+	}
+	if remoteRef, err := repo.Reference(checkpoint.RemoteNotesRefName(remote), true); err == nil && remoteRef.Hash() == localRef.Hash() {
+		return nil // Nothing to push
+	}
+
+	if err := tryPushSessionsCommon(remote, paths.NotesRefName); err != nil {
+		if err := fetchCommitNotes(remote); err != nil {
+			fmt.Fprintf(os.Stderr, "[entire] Warning: couldn't sync commit notes: %v\n", err)
+			return nil // Don't fail the main push
+		}
+		if err := mergeRemoteCommitNotes(repo, remote); err != nil {
+			fmt.Fprintf(os.Stderr, "[entire] Warning: couldn't merge remote commit notes: %v\n", err)
+			return nil
+		}
+		if err := tryPushSessionsCommon(remote, paths.NotesRefName); err != nil {
+			fmt.Fprintf(os.Stderr, "[entire] Warning: failed to push commit notes after sync: %v\n", err)
+			return nil
+		}
+	}
+
+	// Record what the remote now has so the next push can skip
+	if localRef, err = repo.Reference(plumbing.ReferenceName(paths.NotesRefName), true); err == nil {
+		_ = repo.Storer.SetReference(plumbing.NewHashReference(checkpoint.RemoteNotesRefName(remote), localRef.Hash())) //nolint:errcheck // only an optimization
+	}
+	return nil
+}
+
+// fetchCommitNotes fetches the notes of remote into its remote notes ref.
+func fetchCommitNotes(remote string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "fetch", remote, checkpoint.NotesFetchRefspec(remote))
+	cmd.Stdin = nil
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("fetch failed: %s", output)
+	}
+	return nil
+}
+
+// mergeRemoteCommitNotes merges the fetched notes of remote, if any, into the
+// local notes ref.
+func mergeRemoteCommitNotes(repo *git.Repository, remote string) error {
+	remoteRef, err := repo.Reference(checkpoint.RemoteNotesRefName(remote), true)
+	if err != nil {
+		return nil //nolint:nilerr // Nothing fetched yet
+	}
+	authorName, authorEmail := GetGitAuthorFromRepo(repo)
+	return checkpoint.NewGitStore(repo).MergeCommitNotes(remoteRef.Hash(), authorName, authorEmail) //nolint:wrapcheck // already wrapped by the store
+}
@@ -0,0 +1,369 @@
+package strategy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	cpkg "github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// A cherry-pick copies a commit's change to another branch, a revert undoes
+// it; neither gets a checkpoint of its own. The post-commit hook detects them
+// by patch ID: a cherry-pick changes the same lines as its original, a revert
+// the same lines the other way round. Candidates come from the message (the
+// "(cherry picked from commit ...)" line of -x, "This reverts commit ...")
+// and from a trailer the cherry-pick copied. The original's records list the
+// commit in cherry_picked_to or reverted_by; with commit notes, the commit's
+// note carries the derived records.
+
+// Kinds of commit provenance.
+const (
+	ProvenanceCherryPick = "cherry-pick"
+	ProvenanceRevert     = "revert"
+)
+
+var (
+	cherryPickedFromRegex = regexp.MustCompile(`\(cherry picked from commit ([0-9a-f]{40})\)`)
+	revertsCommitRegex    = regexp.MustCompile(`This reverts commit ([0-9a-f]{40})`)
+)
+
+// commitProvenance is what a commit was detected as a cherry-pick or revert of.
+type commitProvenance struct {
+	kind   string // ProvenanceCherryPick or ProvenanceRevert
+	source *object.Commit
+}
+
+// recordCommitProvenance records head as a cherry-pick or revert of the
+// commit it was detected as one of. Failures are logged and never fail the
+// hook.
+func recordCommitProvenance(repo *git.Repository, head *object.Commit) {
+	ctx := context.Background()
+	logCtx := logging.WithComponent(ctx, "attribution")
+	store := cpkg.NewGitStore(repo)
+	p := detectCommitProvenance(ctx, repo, store, head)
+	if p == nil {
+		return
+	}
+
+	if cpID, ok := trailers.ParseCheckpoint(p.source.Message); ok {
+		if err := linkProvenance(ctx, store, cpID, p, head.Hash.String()); err != nil {
+			logging.Warn(logCtx, "failed to link "+p.kind+" to its original's attribution",
+				slog.String("checkpoint_id", cpID.String()),
+				slog.String("error", err.Error()))
+		}
+	}
+	if commitNotesEnabled() {
+		note, err := provenanceNote(ctx, store, head, p)
+		if err == nil && note != nil {
+			authorName, authorEmail := GetGitAuthorFromRepo(repo)
+			err = store.WriteCommitNotes(map[plumbing.Hash]*cpkg.CommitNote{head.Hash: note}, authorName, authorEmail)
+		}
+		if err != nil {
+			logging.Warn(logCtx, "failed to write commit note",
+				slog.String("commit", head.Hash.String()),
+				slog.String("error", err.Error()))
+		}
+	}
+	logging.Info(logCtx, "attribution: commit detected as "+p.kind,
+		slog.String("commit", head.Hash.String()),
+		slog.String("source", p.source.Hash.String()))
+}
+
+// ProvenanceNote returns the note derived for head from the commit it
+// cherry-picks or reverts, for commits without a note of their own. Returns
+// nil if head is neither or its original has no attribution.
+func ProvenanceNote(ctx context.Context, repo *git.Repository, head *object.Commit) (*cpkg.CommitNote, error) {
+	store := cpkg.NewGitStore(repo)
+	p := detectCommitProvenance(ctx, repo, store, head)
+	if p == nil {
+		return nil, nil //nolint:nilnil // nil note means "no provenance"
+	}
+	return provenanceNote(ctx, store, head, p)
+}
+
+// detectCommitProvenance returns what head cherry-picks or reverts, nil if
+// nothing. Commits replayed by a rebase, amends (same parents) and commits
+// that change nothing aren't cherry-picks.
+func detectCommitProvenance(ctx context.Context, repo *git.Repository, store *cpkg.GitStore, head *object.Commit) *commitProvenance {
+	if head.NumParents() != 1 || isRebaseInProgress() {
+		return nil
+	}
+	candidates := provenanceCandidates(ctx, store, head)
+	if len(candidates) == 0 {
+		return nil
+	}
+	headPatch := commitPatchID(head, false)
+	if headPatch == "" {
+		return nil
+	}
+	for _, candidate := range candidates {
+		source, err := repo.CommitObject(plumbing.NewHash(candidate))
+		if err != nil || source.Hash == head.Hash || source.NumParents() != 1 || slices.Equal(source.ParentHashes, head.ParentHashes) {
+			continue
+		}
+		switch headPatch {
+		case commitPatchID(source, false):
+			return &commitProvenance{kind: ProvenanceCherryPick, source: source}
+		case commitPatchID(source, true):
+			return &commitProvenance{kind: ProvenanceRevert, source: source}
+		}
+	}
+	return nil
+}
+
+// provenanceCandidates returns the commits head may cherry-pick or revert:
+// those its message names, and those its trailer's checkpoint was recorded
+// for, which a cherry-pick without -x copies.
+func provenanceCandidates(ctx context.Context, store *cpkg.GitStore, head *object.Commit) []string {
+	var candidates []string
+	add := func(commit string) {
+		if commit != "" && commit != head.Hash.String() && !slices.Contains(candidates, commit) {
+			candidates = append(candidates, commit)
+		}
+	}
+	for _, re := range []*regexp.Regexp{cherryPickedFromRegex, revertsCommitRegex} {
+		for _, m := range re.FindAllStringSubmatch(head.Message, -1) {
+			add(m[1])
+		}
+	}
+	if cpID, ok := trailers.ParseCheckpoint(head.Message); ok {
+		if summary, err := store.ReadCommitted(ctx, cpID); err == nil && summary != nil {
+			for i := range summary.Sessions {
+				if m, err := store.ReadSessionMetadata(ctx, cpID, i); err == nil && m.InitialAttribution != nil {
+					add(m.InitialAttribution.Commit)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// linkProvenance lists head in cherry_picked_to or reverted_by of the
+// records of checkpoint cpID that describe p's source.
+func linkProvenance(ctx context.Context, store *cpkg.GitStore, cpID id.CheckpointID, p *commitProvenance, head string) error {
+	summary, err := store.ReadCommitted(ctx, cpID)
+	if err != nil || summary == nil {
+		return err //nolint:wrapcheck // already describes the checkpoint
+	}
+	for i := range summary.Sessions {
+		metadata, err := store.ReadSessionMetadata(ctx, cpID, i)
+		if err != nil {
+			return fmt.Errorf("failed to read session %d of checkpoint %s: %w", i, cpID, err)
+		}
+		a := metadata.InitialAttribution
+		if a == nil || (a.Commit != "" && a.Commit != p.source.Hash.String()) {
+			continue
+		}
+		linked := *a
+		if p.kind == ProvenanceCherryPick {
+			if slices.Contains(linked.CherryPickedTo, head) {
+				continue
+			}
+			linked.CherryPickedTo = append(slices.Clone(linked.CherryPickedTo), head)
+		} else {
+			if slices.Contains(linked.RevertedBy, head) {
+				continue
+			}
+			linked.RevertedBy = append(slices.Clone(linked.RevertedBy), head)
+		}
+		if err := store.UpdateSessionAttribution(ctx, cpID, i, &linked); err != nil {
+			return fmt.Errorf("failed to update session %d of checkpoint %s: %w", i, cpID, err)
+		}
+	}
+	return nil
+}
+
+// provenanceNote derives head's note from the note of p's source: built from
+// its checkpoint, or its own commit note.
+func provenanceNote(ctx context.Context, store *cpkg.GitStore, head *object.Commit, p *commitProvenance) (*cpkg.CommitNote, error) {
+	var source *cpkg.CommitNote
+	if cpID, ok := trailers.ParseCheckpoint(p.source.Message); ok {
+		note, err := store.BuildCommitNote(ctx, cpID)
+		switch {
+		case err == nil:
+			source = note
+		case !errors.Is(err, cpkg.ErrCheckpointNotFound):
+			return nil, fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+		}
+	}
+	if source == nil {
+		note, err := store.ReadCommitNote(p.source.Hash)
+		if err != nil || note == nil {
+			return nil, err //nolint:wrapcheck // already describes the note
+		}
+		source = note
+	}
+
+	// A cherry-pick's records are its original's, so reverting the
+	// cherry-pick negates those
+	sourceHash := p.source.Hash.String()
+	note := *source
+	note.Sessions = make([]cpkg.CommitNoteSession, 0, len(source.Sessions))
+	for _, session := range source.Sessions {
+		if a := session.Attribution; a != nil {
+			if a.Commit != "" && a.Commit != sourceHash && !slices.Contains(a.CherryPickedTo, sourceHash) {
+				continue // Recorded for another commit sharing the checkpoint
+			}
+			session.Attribution = derivedAttribution(a, p, head.Hash.String())
+		}
+		note.Sessions = append(note.Sessions, session)
+	}
+	if len(note.Sessions) == 0 {
+		return nil, nil //nolint:nilnil // nil note means "no provenance"
+	}
+	return &note, nil
+}
+
+// derivedAttribution returns a's record for commit, a cherry-pick or revert
+// of the commit a describes.
+func derivedAttribution(a *cpkg.InitialAttribution, p *commitProvenance, commit string) *cpkg.InitialAttribution {
+	d := *a
+	d.CalculatedAt = time.Now().UTC()
+	d.Commit = commit
+	d.CherryPickedTo, d.RevertedBy = nil, nil
+	d.CherryPickOf, d.RevertOf = "", ""
+	if p.kind == ProvenanceCherryPick {
+		d.CherryPickOf = p.source.Hash.String()
+		return &d
+	}
+
+	d.RevertOf = p.source.Hash.String()
+	d.AgentLines, d.HumanAdded, d.HumanModified, d.HumanRemoved = -a.AgentLines, -a.HumanAdded, -a.HumanModified, -a.HumanRemoved
+	d.TotalCommitted = -a.TotalCommitted
+	d.Files = negateFileAttributions(a.Files)
+	d.GeneratedFiles = negateFileAttributions(a.GeneratedFiles)
+	d.GeneratedAgentLines, d.GeneratedTotalCommitted = -a.GeneratedAgentLines, -a.GeneratedTotalCommitted
+	d.BinaryFiles = make([]cpkg.BinaryFileAttribution, len(a.BinaryFiles))
+	for i, f := range a.BinaryFiles {
+		d.BinaryFiles[i] = cpkg.BinaryFileAttribution{Path: f.Path, AgentBytes: -f.AgentBytes, HumanBytes: -f.HumanBytes}
+	}
+	d.BinaryAgentBytes, d.BinaryHumanBytes = -a.BinaryAgentBytes, -a.BinaryHumanBytes
+	d.Submodules = make([]cpkg.SubmoduleAttribution, len(a.Submodules))
+	for i, sm := range a.Submodules {
+		d.Submodules[i] = cpkg.SubmoduleAttribution{Path: sm.Path, From: sm.To, To: sm.From, Agent: sm.Agent}
+	}
+	return &d
+}
+
+// negateFileAttributions returns files with their counts negated. The agent
+// line ranges are dropped: the revert removed those lines.
+func negateFileAttributions(files []cpkg.FileAttribution) []cpkg.FileAttribution {
+	if files == nil {
+		return nil
+	}
+	negated := make([]cpkg.FileAttribution, len(files))
+	for i, f := range files {
+		negated[i] = cpkg.FileAttribution{
+			Path:            f.Path,
+			AgentLines:      -f.AgentLines,
+			HumanAdded:      -f.HumanAdded,
+			HumanModified:   -f.HumanModified,
+			HumanRemoved:    -f.HumanRemoved,
+			TotalCommitted:  -f.TotalCommitted,
+			AgentPercentage: f.AgentPercentage,
+		}
+	}
+	return negated
+}
+
+// commitPatchID returns the patch ID of commit's change to its first parent,
+// or of the reverse change. Empty if it can't be computed or the commit
+// changes nothing.
+func commitPatchID(commit *object.Commit, reverse bool) string {
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return ""
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return ""
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return ""
+	}
+	if reverse {
+		parentTree, tree = tree, parentTree
+	}
+	patchID, err := treePatchID(parentTree, tree)
+	if err != nil {
+		return ""
+	}
+	return patchID
+}
+
+// treePatchID identifies the change from one tree to another the way
+// `git patch-id` does: by the lines each file's diff removes and adds,
+// ignoring whitespace, line numbers and context. Binary files count by their
+// blobs. Empty if the trees are the same.
+func treePatchID(from, to *object.Tree) (string, error) {
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff trees: %w", err)
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		path := change.To.Name
+		if path == "" {
+			path = change.From.Name
+		}
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		h.Write([]byte(path + "\x00"))
+		before, after := getFileContent(from, path), getFileContent(to, path)
+		if before == "" && after == "" {
+			h.Write([]byte(treeFileHash(from, path).String() + " " + treeFileHash(to, path).String() + "\x00"))
+			continue
+		}
+		writePatchLines(h, before, after)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writePatchLines writes the lines the diff from before to after removes and
+// adds to h, in order, without whitespace.
+func writePatchLines(h hash.Hash, before, after string) {
+	dmp := diffmatchpatch.New()
+	text1, text2, lineArray := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(text1, text2, false), lineArray)
+	for _, d := range diffs {
+		var prefix string
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffEqual:
+			continue
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				h.Write([]byte(prefix + strings.Join(strings.Fields(line), "") + "\n"))
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreePatchID(t *testing.T) {
+	t.Parallel()
+	patchID := func(from, to *object.Tree) string {
+		t.Helper()
+		p, err := treePatchID(from, to)
+		require.NoError(t, err)
+		return p
+	}
+	base := buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc a() {}\n", "other.go": "package main\n"})
+	changed := buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc a() {}\n\nfunc b() {}\n", "other.go": "package main\n"})
+	// The same change on top of other work, reindented
+	otherBase := buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc a() {}\n", "other.go": "package main\n\nvar x = 1\n"})
+	otherChanged := buildTestTree(t, map[string]string{"main.go": "package main\n\nfunc a() {}\n\n  func b()  {}\n", "other.go": "package main\n\nvar x = 1\n"})
+
+	change := patchID(base, changed)
+	assert.NotEmpty(t, change)
+	assert.Equal(t, change, patchID(otherBase, otherChanged), "a cherry-picked change has its original's patch ID")
+	assert.NotEqual(t, change, patchID(changed, base), "reversing a change changes its patch ID")
+	assert.Equal(t, patchID(changed, base), patchID(otherChanged, otherBase))
+	assert.Empty(t, patchID(base, base))
+}
+
+func TestPostCommit_RecordsCherryPickAndRevert(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	s := &ManualCommitStrategy{}
+	const sessionID = "test-provenance"
+	setupSessionWithCheckpoint(t, s, repo, dir, sessionID)
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	state.Phase = session.PhaseIdle
+	state.FilesTouched = []string{"test.txt"}
+	require.NoError(t, s.saveSessionState(state))
+
+	cpID := id.MustCheckpointID("d4e5f6a1b2c3")
+	commitWithCheckpointTrailer(t, repo, dir, cpID.String())
+	require.NoError(t, s.PostCommit())
+	original, err := repo.Head()
+	require.NoError(t, err)
+	store := checkpoint.NewGitStore(repo)
+	_, attribution := sessionAttribution(store, cpID, sessionID)
+	require.NotNil(t, attribution)
+	require.Equal(t, original.Hash().String(), attribution.Commit)
+
+	// Cherry-picked onto a branch with other work: the copy keeps the trailer
+	gitRun("checkout", "-q", "-b", "release", "HEAD~1")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("release notes\n"), 0o644))
+	gitRun("add", "other.txt")
+	gitRun("commit", "-q", "-m", "release work")
+	gitRun("cherry-pick", original.Hash().String())
+	require.NoError(t, s.PostCommit())
+	picked, err := repo.Head()
+	require.NoError(t, err)
+
+	_, attribution = sessionAttribution(store, cpID, sessionID)
+	require.NotNil(t, attribution)
+	assert.Equal(t, []string{picked.Hash().String()}, attribution.CherryPickedTo)
+	pickedCommit, err := repo.CommitObject(picked.Hash())
+	require.NoError(t, err)
+	note, err := ProvenanceNote(context.Background(), repo, pickedCommit)
+	require.NoError(t, err)
+	require.NotNil(t, note)
+	require.Len(t, note.Sessions, 1)
+	assert.Equal(t, cpID, note.CheckpointID)
+	assert.Equal(t, original.Hash().String(), note.Sessions[0].Attribution.CherryPickOf)
+	assert.Equal(t, picked.Hash().String(), note.Sessions[0].Attribution.Commit)
+	assert.Equal(t, attribution.AgentLines, note.Sessions[0].Attribution.AgentLines)
+
+	// Reverted on the release branch: the revert's record is the original's, negated
+	gitRun("revert", "--no-edit", picked.Hash().String())
+	require.NoError(t, s.PostCommit())
+	// The original's records describe the original, which wasn't reverted
+	_, attribution = sessionAttribution(store, cpID, sessionID)
+	assert.Empty(t, attribution.RevertedBy)
+	revertedPick, err := repo.Head()
+	require.NoError(t, err)
+	revertedPickCommit, err := repo.CommitObject(revertedPick.Hash())
+	require.NoError(t, err)
+	note, err = ProvenanceNote(context.Background(), repo, revertedPickCommit)
+	require.NoError(t, err)
+	require.NotNil(t, note)
+	assert.Equal(t, picked.Hash().String(), note.Sessions[0].Attribution.RevertOf)
+	assert.Equal(t, -attribution.AgentLines, note.Sessions[0].Attribution.AgentLines)
+
+	gitRun("checkout", "-q", "master")
+	gitRun("revert", "--no-edit", original.Hash().String())
+	require.NoError(t, s.PostCommit())
+	reverted, err := repo.Head()
+	require.NoError(t, err)
+	_, attribution = sessionAttribution(store, cpID, sessionID)
+	require.NotNil(t, attribution)
+	assert.Equal(t, []string{reverted.Hash().String()}, attribution.RevertedBy)
+	revertCommit, err := repo.CommitObject(reverted.Hash())
+	require.NoError(t, err)
+	note, err = ProvenanceNote(context.Background(), repo, revertCommit)
+	require.NoError(t, err)
+	require.NotNil(t, note)
+	derived := note.Sessions[0].Attribution
+	assert.Equal(t, original.Hash().String(), derived.RevertOf)
+	assert.Equal(t, -attribution.AgentLines, derived.AgentLines)
+	assert.Equal(t, -attribution.TotalCommitted, derived.TotalCommitted)
+	assert.Empty(t, derived.RevertedBy)
+
+	// Commits that are neither derive nothing
+	head, err := repo.CommitObject(original.Hash())
+	require.NoError(t, err)
+	note, err = ProvenanceNote(context.Background(), repo, head)
+	require.NoError(t, err)
+	assert.Nil(t, note)
+}
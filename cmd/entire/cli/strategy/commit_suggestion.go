@@ -0,0 +1,177 @@
+package strategy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// With commit_messages.suggest, the prepare-commit-msg hook suggests a
+// commit message in the editor, derived from the prompts since the last
+// commit and the agent's checkpointed changes to the staged files. It is
+// inserted as a comment block above the Entire-Checkpoint trailer: git
+// strips it unless the user uncomments it.
+
+// Bounds of the built-in suggestion, so it stays a commit message.
+const (
+	maxSuggestionBullets = 8
+	maxSuggestionFiles   = 5
+)
+
+// suggestionCommentHeader opens the suggestion's comment block.
+const suggestionCommentHeader = "# Suggested message from the %s session (uncomment to use it):"
+
+// commitSuggestion returns the message suggested for committing the staged
+// changes of state's session, "" if the session has no prompts since the
+// last commit on its shadow branch.
+func (s *ManualCommitStrategy) commitSuggestion(repo *git.Repository, state *SessionState) string {
+	shadowRef, err := repo.Reference(checkpoint.ShadowRefName(repo, getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)), true)
+	if err != nil {
+		return ""
+	}
+	sessionData, err := s.extractSessionData(repo, shadowRef.Hash(), state.SessionID, nil, state.AgentType, state.TranscriptPath, 0)
+	if err != nil || len(sessionData.Prompts) == 0 {
+		return ""
+	}
+	prompts := extractUserPrompts(state.AgentType, string(transcript.SliceFromLine(sessionData.Transcript, state.CheckpointTranscriptStart)))
+	if len(prompts) == 0 {
+		prompts = sessionData.Prompts[len(sessionData.Prompts)-1:]
+	}
+
+	var stats []fileLineStats
+	if shadowCommit, err := repo.CommitObject(shadowRef.Hash()); err == nil {
+		if shadowTree, err := shadowCommit.Tree(); err == nil {
+			stats = agentLineStats(repo, shadowTree, agentStagedFiles(repo, state))
+		}
+	}
+	suggestion := buildCommitSuggestion(prompts, stats)
+	if suggestion == "" {
+		return ""
+	}
+
+	files := make([]string, len(stats))
+	for i, st := range stats {
+		files[i] = st.path
+	}
+	data := CommitMessageData{
+		SessionID:     state.SessionID,
+		Agent:         string(state.AgentType),
+		Strategy:      StrategyNameManualCommit,
+		Prompt:        prompts[len(prompts)-1],
+		PromptSummary: suggestion,
+		FilesTouched:  files,
+		Summary:       storedSessionSummary(state.SessionID),
+	}
+	return renderCommitMessage(commitMessageTemplates().Suggestion, data, suggestion)
+}
+
+// fileLineStats counts the lines the agent's checkpoint adds to and removes
+// from a file, compared with HEAD.
+type fileLineStats struct {
+	path           string
+	added, removed int
+}
+
+// agentStagedFiles returns the staged files the session touched, sorted.
+func agentStagedFiles(repo *git.Repository, state *SessionState) []string {
+	var files []string
+	for _, path := range getStagedFiles(repo) {
+		if slices.Contains(state.FilesTouched, path) {
+			files = append(files, path)
+		}
+	}
+	slices.Sort(files)
+	return files
+}
+
+// agentLineStats diffs files in shadowTree against HEAD. Files the
+// checkpoint doesn't change (e.g. binary ones) are left out.
+func agentLineStats(repo *git.Repository, shadowTree *object.Tree, files []string) []fileLineStats {
+	var headTree *object.Tree
+	if head, err := repo.Head(); err == nil {
+		if commit, err := repo.CommitObject(head.Hash()); err == nil {
+			headTree, _ = commit.Tree() //nolint:errcheck // a missing HEAD tree diffs against nothing
+		}
+	}
+	var stats []fileLineStats
+	for _, path := range files {
+		added, removed := computeDiffStats([]byte(getFileContent(headTree, path)), []byte(getFileContent(shadowTree, path)))
+		if added > 0 || removed > 0 {
+			stats = append(stats, fileLineStats{path: path, added: added, removed: removed})
+		}
+	}
+	return stats
+}
+
+// buildCommitSuggestion builds the built-in suggestion: the first prompt,
+// as a title, for the subject, later prompts as bullets, and the files the
+// agent changed.
+func buildCommitSuggestion(prompts []string, stats []fileLineStats) string {
+	var subject string
+	var bullets []string
+	for _, prompt := range prompts {
+		title := session.DeriveTitle(prompt)
+		switch {
+		case title == "":
+		case subject == "":
+			subject = title
+		case title != subject && !slices.Contains(bullets, title) && len(bullets) < maxSuggestionBullets:
+			bullets = append(bullets, title)
+		}
+	}
+	if subject == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(subject)
+	if len(bullets) > 0 {
+		sb.WriteString("\n\n")
+		for _, bullet := range bullets {
+			sb.WriteString("- " + bullet + "\n")
+		}
+	}
+	if len(stats) > 0 {
+		files := make([]string, 0, maxSuggestionFiles)
+		for _, st := range stats[:min(len(stats), maxSuggestionFiles)] {
+			files = append(files, fmt.Sprintf("%s (+%d/-%d)", st.path, st.added, st.removed))
+		}
+		if more := len(stats) - len(files); more > 0 {
+			files = append(files, fmt.Sprintf("%d more", more))
+		}
+		if len(bullets) == 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\nChanged: " + strings.Join(files, ", "))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// addCommitSuggestion inserts suggestion as a comment block above the
+// Entire-Checkpoint trailer of message, so that uncommenting it keeps the
+// trailer in the last paragraph. Returns message unchanged if it has no
+// trailer.
+func addCommitSuggestion(message, suggestion, agentName string) string {
+	lines := strings.Split(message, "\n")
+	trailerLine := slices.IndexFunc(lines, func(line string) bool {
+		return strings.HasPrefix(line, trailers.CheckpointTrailerKey+":")
+	})
+	if trailerLine == -1 || suggestion == "" {
+		return message
+	}
+
+	block := []string{fmt.Sprintf(suggestionCommentHeader, agentName)}
+	for _, line := range strings.Split(suggestion, "\n") {
+		block = append(block, strings.TrimRight("# "+line, " "))
+	}
+	block = append(block, "")
+	return strings.Join(slices.Insert(lines, trailerLine, block...), "\n")
+}
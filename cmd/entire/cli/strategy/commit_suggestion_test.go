@@ -0,0 +1,126 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCommitSuggestion(t *testing.T) {
+	t.Parallel()
+	if got := buildCommitSuggestion(nil, nil); got != "" {
+		t.Errorf("buildCommitSuggestion(no prompts) = %q, want empty", got)
+	}
+
+	got := buildCommitSuggestion([]string{"Add a retry to the uploader", "handle 429 responses too", "Add a retry to the uploader"}, nil)
+	want := "Add a retry to the uploader\n\n- Handle 429 responses too"
+	if got != want {
+		t.Errorf("buildCommitSuggestion() = %q, want %q", got, want)
+	}
+
+	stats := []fileLineStats{
+		{path: "a.go", added: 10, removed: 2}, {path: "b.go", added: 1}, {path: "c.go", added: 1},
+		{path: "d.go", added: 1}, {path: "e.go", added: 1}, {path: "f.go", added: 1}, {path: "g.go", removed: 3},
+	}
+	got = buildCommitSuggestion([]string{"Add a retry to the uploader"}, stats)
+	want = "Add a retry to the uploader\n\nChanged: a.go (+10/-2), b.go (+1/-0), c.go (+1/-0), d.go (+1/-0), e.go (+1/-0), 2 more"
+	if got != want {
+		t.Errorf("buildCommitSuggestion(stats) = %q, want %q", got, want)
+	}
+}
+
+func TestAddCommitSuggestion(t *testing.T) {
+	t.Parallel()
+	message := "\n\n" + trailers.CheckpointTrailerKey + ": a1b2c3d4e5f6\n# Remove the Entire-Checkpoint trailer above if you don't want to link this commit to Claude Code session context.\n"
+	got := addCommitSuggestion(message, "Add a retry\n\n- Handle 429s", "Claude Code")
+	want := "\n\n# Suggested message from the Claude Code session (uncomment to use it):\n# Add a retry\n#\n# - Handle 429s\n\n" +
+		trailers.CheckpointTrailerKey + ": a1b2c3d4e5f6\n# Remove the Entire-Checkpoint trailer above if you don't want to link this commit to Claude Code session context.\n"
+	if got != want {
+		t.Errorf("addCommitSuggestion() = %q, want %q", got, want)
+	}
+
+	if got := addCommitSuggestion("fix typo\n", "Add a retry", "Claude Code"); got != "fix typo\n" {
+		t.Errorf("addCommitSuggestion(no trailer) = %q, want the message unchanged", got)
+	}
+	if got := addCommitSuggestion(message, "", "Claude Code"); got != message {
+		t.Errorf("addCommitSuggestion(no suggestion) = %q, want the message unchanged", got)
+	}
+}
+
+func TestPrepareCommitMsg_CommitSuggestion(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	t.Setenv("ENTIRE_TEST_TTY", "1")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	s := &ManualCommitStrategy{}
+	const sessionID = "test-commit-suggestion"
+
+	metadataDir := ".entire/metadata/" + sessionID
+	metadataDirAbs := filepath.Join(dir, metadataDir)
+	require.NoError(t, os.MkdirAll(metadataDirAbs, 0o755))
+	transcript := `{"type":"user","message":{"content":"add a greeting to test.txt"}}
+{"type":"assistant","message":{"content":"done"}}
+{"type":"user","message":{"content":"make it friendlier"}}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(metadataDirAbs, paths.TranscriptFileName), []byte(transcript), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte("initial content\nhello there\n"), 0o644))
+	require.NoError(t, s.SaveChanges(SaveContext{
+		SessionID:      sessionID,
+		ModifiedFiles:  []string{"test.txt"},
+		MetadataDir:    metadataDir,
+		MetadataDirAbs: metadataDirAbs,
+		CommitMessage:  "Checkpoint 1",
+		AuthorName:     "Test",
+		AuthorEmail:    "test@test.com",
+	}))
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	state.Phase = session.PhaseIdle
+	require.NoError(t, s.saveSessionState(state))
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("test.txt")
+	require.NoError(t, err)
+
+	prepare := func() string {
+		t.Helper()
+		msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+		require.NoError(t, os.WriteFile(msgFile, []byte(""), 0o644))
+		require.NoError(t, s.PrepareCommitMsg(msgFile, ""))
+		content, err := os.ReadFile(msgFile)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	if msg := prepare(); strings.Contains(msg, "Suggested message") {
+		t.Errorf("suggestion added without commit_messages.suggest:\n%s", msg)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(`{"commit_messages": {"suggest": true}}`), 0o644))
+	msg := prepare()
+	for _, want := range []string{"# Add a greeting to test.txt\n", "# - Make it friendlier\n", "# Changed: test.txt (+1/-0)\n"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("suggestion is missing %q:\n%s", want, msg)
+		}
+	}
+	if strings.Index(msg, "Suggested message") > strings.Index(msg, trailers.CheckpointTrailerKey+":") {
+		t.Errorf("suggestion should come before the trailer:\n%s", msg)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(`{"commit_messages": {"suggest": true, "suggestion": "feat: {{.PromptSummary | firstLine}}"}}`), 0o644))
+	if msg := prepare(); !strings.Contains(msg, "# feat: Add a greeting to test.txt\n\n") {
+		t.Errorf("suggestion template wasn't used:\n%s", msg)
+	}
+}
@@ -16,14 +16,18 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/faultinject"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/vcs"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
 )
 
 // Common branch name constants for default branch detection.
@@ -200,8 +204,9 @@ const (
 // Protected directories include git internals, entire metadata, and all
 // registered agent config directories.
 func isProtectedPath(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
 	for _, dir := range protectedDirs() {
-		if relPath == dir || strings.HasPrefix(relPath, dir+string(filepath.Separator)) {
+		if relPath == dir || strings.HasPrefix(relPath, dir+"/") {
 			return true
 		}
 	}
@@ -501,7 +506,7 @@ func ReadAllSessionPromptsFromTree(tree *object.Tree, checkpointPath string, ses
 func ReadSessionPromptFromShadow(repo *git.Repository, baseCommit, worktreeID, sessionID string) string {
 	// Get shadow branch for this base commit using worktree-specific naming
 	shadowBranchName := checkpoint.ShadowBranchNameForCommit(baseCommit, worktreeID)
-	ref, err := repo.Reference(plumbing.NewBranchReferenceName(shadowBranchName), true)
+	ref, err := repo.Reference(checkpoint.ShadowRefName(repo, shadowBranchName), true)
 	if err != nil {
 		return ""
 	}
@@ -572,7 +577,76 @@ func OpenRepository() (*git.Repository, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
-	return repo, nil
+	return withFaults(withRefQueue(repo)), nil
+}
+
+// withFaults reopens repo on top of a faultinject.Storer when ENTIRE_FAULTS
+// is set, so tests can check hooks survive failing ref writes. Repositories
+// already wrapped in a ref queue are returned as is.
+func withFaults(repo *git.Repository) *git.Repository {
+	if !faultinject.Enabled() {
+		return repo
+	}
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return repo
+	}
+	wrapped := &faultinject.Storer{Storage: fsStorage}
+	wt, err := repo.Worktree()
+	if err != nil {
+		if faulty, err := git.Open(wrapped, nil); err == nil {
+			return faulty
+		}
+		return repo
+	}
+	faulty, err := git.Open(wrapped, wt.Filesystem)
+	if err != nil {
+		return repo
+	}
+	return faulty
+}
+
+// withRefQueue reopens repo on top of a fsenv.RefQueueStorer when its git dir is
+// read-only or on a network filesystem, so ref writes that fail there are
+// queued instead of failing the hook. Other repositories are returned as is.
+func withRefQueue(repo *git.Repository) *git.Repository {
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return repo
+	}
+	wrapped := fsenv.WrapStorage(fsStorage, storageCommonDir(fsStorage))
+	if wrapped == nil {
+		return repo
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		// Bare repository
+		if queued, err := git.Open(wrapped, nil); err == nil {
+			return queued
+		}
+		return repo
+	}
+	queued, err := git.Open(wrapped, wt.Filesystem)
+	if err != nil {
+		return repo
+	}
+	return queued
+}
+
+// storageCommonDir returns the shared git dir of fsStorage, following the
+// commondir file that linked worktrees have.
+func storageCommonDir(fsStorage *filesystem.Storage) string {
+	gitDirPath := fsStorage.Filesystem().Root()
+	data, err := os.ReadFile(filepath.Join(gitDirPath, "commondir")) //nolint:gosec // path is inside the git dir
+	if err != nil {
+		return gitDirPath
+	}
+	commonDir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDirPath, commonDir)
+	}
+	return filepath.Clean(commonDir)
 }
 
 // IsInsideWorktree returns true if the current directory is inside a git worktree
@@ -1033,6 +1107,15 @@ func StageFiles(worktree *git.Worktree, modified, newFiles, deleted []string, st
 	for _, file := range modified {
 		// Resolve path relative to repo root for existence check
 		absPath := filepath.Join(repoRoot, file)
+		if _, ok := checkpoint.SubmoduleHead(absPath); ok {
+			// go-git would stage the submodule's files; git stages its commit
+			if err := stageSubmodule(repoRoot, file); err != nil {
+				fmt.Fprintf(os.Stderr, "  Failed to stage submodule %s: %v\n", file, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "  Staged submodule: %s\n", file)
+			}
+			continue
+		}
 		if fileExists(absPath) {
 			if _, err := worktree.Add(file); err != nil {
 				fmt.Fprintf(os.Stderr, "  Failed to stage %s: %v\n", file, err)
@@ -1069,6 +1152,16 @@ func StageFiles(worktree *git.Worktree, modified, newFiles, deleted []string, st
 	}
 }
 
+// stageSubmodule stages the commit the submodule at path has checked out.
+func stageSubmodule(repoRoot, path string) error {
+	cmd := exec.CommandContext(context.Background(), "git", "add", "--", path)
+	cmd.Dir = repoRoot
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git add failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
 // getTaskCheckpointFromTree retrieves a task checkpoint from a commit tree.
 // Shared implementation for shadow and linear-shadow strategies.
 func getTaskCheckpointFromTree(point RewindPoint) (*TaskCheckpoint, error) {
@@ -1161,7 +1254,8 @@ func getTaskTranscriptFromTree(point RewindPoint) ([]byte, error) {
 // ErrBranchNotFound is returned by DeleteBranchCLI when the branch does not exist.
 var ErrBranchNotFound = errors.New("branch not found")
 
-// DeleteBranchCLI deletes a git branch using the git CLI.
+// DeleteBranchCLI deletes a git branch using the git CLI. Shadow branches of
+// the jj backend are hidden refs and deleted with `git update-ref -d`.
 // Uses `git branch -D` instead of go-git's RemoveReference because go-git v5
 // doesn't properly persist deletions when refs are packed (.git/packed-refs)
 // or in a worktree context. This is the same class of go-git v5 bug that
@@ -1177,7 +1271,8 @@ func DeleteBranchCLI(branchName string) error {
 	// git show-ref exits 1 for "not found" and 128+ for fatal errors (corrupt
 	// repo, permissions, not a git directory). Only map exit code 1 to
 	// ErrBranchNotFound; propagate other failures as-is.
-	check := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+branchName) //nolint:gosec // branchName comes from internal shadow branch naming
+	refName, hidden := shadowRefCLI(branchName)
+	check := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", refName) //nolint:gosec // branchName comes from internal shadow branch naming
 	if err := check.Run(); err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
@@ -1187,6 +1282,9 @@ func DeleteBranchCLI(branchName string) error {
 	}
 
 	cmd := exec.CommandContext(ctx, "git", "branch", "-D", "--", branchName)
+	if hidden {
+		cmd = exec.CommandContext(ctx, "git", "update-ref", "-d", refName) //nolint:gosec // refName comes from internal shadow branch naming
+	}
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to delete branch %s: %s: %w", branchName, strings.TrimSpace(string(output)), err)
 	}
@@ -1197,13 +1295,27 @@ func DeleteBranchCLI(branchName string) error {
 // Returns nil if the branch exists, or an error if it does not.
 func branchExistsCLI(branchName string) error {
 	ctx := context.Background()
-	cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+branchName) //nolint:gosec // branchName comes from internal shadow branch naming
+	refName, _ := shadowRefCLI(branchName)
+	cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", refName) //nolint:gosec // branchName comes from internal shadow branch naming
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("branch %s not found: %w", branchName, err)
 	}
 	return nil
 }
 
+// shadowRefCLI returns the full ref branchName is stored under, and whether
+// that is a hidden ref of the jj backend rather than a branch.
+func shadowRefCLI(branchName string) (string, bool) {
+	if IsShadowBranch(branchName) {
+		if worktreePath, err := GetWorktreePath(); err == nil {
+			if backend := vcs.DetectPath(worktreePath); backend != vcs.Git {
+				return backend.ShadowRef(branchName).String(), true
+			}
+		}
+	}
+	return plumbing.NewBranchReferenceName(branchName).String(), false
+}
+
 // HardResetWithProtection performs a git reset --hard to the specified commit.
 // Uses the git CLI instead of go-git because go-git's HardReset incorrectly
 // deletes untracked directories (like .entire/) even when they're in .gitignore.
@@ -1261,7 +1373,7 @@ func collectUntrackedFiles() ([]string, error) {
 			return nil
 		}
 
-		untrackedFiles = append(untrackedFiles, relPath)
+		untrackedFiles = append(untrackedFiles, filepath.ToSlash(relPath))
 		return nil
 	})
 	if err != nil {
@@ -0,0 +1,149 @@
+package strategy
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/entireignore"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+)
+
+// Regenerating mocks, protobufs or lockfiles changes thousands of lines
+// nobody wrote, and whoever ran the generator gets them. Files marked as
+// generated, by linguist-generated in the root .gitattributes (as GitHub
+// uses it) or by attribution.generated_files, are attributed on their own:
+// the commit's totals and agent percentage leave them out, and their lines
+// are recorded in InitialAttribution.GeneratedFiles. With
+// attribution.generated "include" they count like any other file.
+
+// linguistGeneratedAttr is the attribute that marks generated files.
+const linguistGeneratedAttr = "linguist-generated"
+
+// generatedFiles tells which files are generated. A nil generatedFiles marks
+// nothing.
+type generatedFiles struct {
+	attributes gitattributes.Matcher // Nil without linguist-generated attributes
+	patterns   *entireignore.Matcher
+}
+
+// configuredGeneratedFiles returns the generated files from settings and
+// .gitattributes, nil if there are none or they count like other files.
+func configuredGeneratedFiles() *generatedFiles {
+	var patterns []string
+	if s, err := settings.Load(); err == nil {
+		mode, err := s.Attribution.EffectiveGenerated()
+		if err != nil {
+			logging.Warn(context.Background(), "ignoring attribution settings", slog.String("error", err.Error()))
+		}
+		if mode == settings.AttributionGeneratedInclude {
+			return nil
+		}
+		if s.Attribution != nil {
+			patterns = s.Attribution.GeneratedFiles
+		}
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil
+	}
+	return loadGeneratedFiles(repoRoot, patterns)
+}
+
+// loadGeneratedFiles reads the linguist-generated attributes in repoRoot's
+// .gitattributes and adds patterns. Returns nil if neither marks anything.
+func loadGeneratedFiles(repoRoot string, patterns []string) *generatedFiles {
+	g := &generatedFiles{patterns: entireignore.Parse([]byte(strings.Join(patterns, "\n")))}
+	data, err := os.ReadFile(filepath.Join(repoRoot, ".gitattributes")) //nolint:gosec // fixed name under the repo root
+	if err == nil && bytes.Contains(data, []byte(linguistGeneratedAttr)) {
+		if attrs, err := gitattributes.ReadAttributes(bytes.NewReader(data), nil, true); err == nil {
+			g.attributes = gitattributes.NewMatcher(attrs)
+		}
+	}
+	if g.attributes == nil && g.patterns == nil {
+		return nil
+	}
+	return g
+}
+
+// match reports whether path is generated. linguist-generated=false (or
+// -linguist-generated) overrides a pattern, as it does on GitHub.
+func (g *generatedFiles) match(path string) bool {
+	if g == nil {
+		return false
+	}
+	if g.attributes != nil {
+		results, _ := g.attributes.Match(strings.Split(path, "/"), []string{linguistGeneratedAttr})
+		if attr, ok := results[linguistGeneratedAttr]; ok {
+			switch {
+			case attr.IsSet(), attr.IsValueSet() && attr.Value() == "true":
+				return true
+			case attr.IsUnset(), attr.IsValueSet() && attr.Value() == "false":
+				return false
+			}
+		}
+	}
+	return g.patterns.Match(path)
+}
+
+// splitGeneratedPromptAttributions splits the user edits prompt attributions
+// recorded into those to other files and those to generated files. Only
+// additions are recorded per file, so removals all stay with other files.
+func splitGeneratedPromptAttributions(promptAttributions []PromptAttribution, generated *generatedFiles) (own, gen []PromptAttribution) {
+	for _, pa := range promptAttributions {
+		genPA := PromptAttribution{CheckpointNumber: pa.CheckpointNumber, UserAddedPerFile: make(map[string]int)}
+		ownPerFile := make(map[string]int, len(pa.UserAddedPerFile))
+		for path, added := range pa.UserAddedPerFile {
+			if generated.match(path) {
+				genPA.UserAddedPerFile[path] = added
+				genPA.UserLinesAdded += added
+			} else {
+				ownPerFile[path] = added
+			}
+		}
+		pa.UserAddedPerFile = ownPerFile
+		pa.UserLinesAdded = max(0, pa.UserLinesAdded-genPA.UserLinesAdded)
+		own = append(own, pa)
+		gen = append(gen, genPA)
+	}
+	return own, gen
+}
+
+// withGeneratedAttribution records the attribution of the generated files,
+// generatedAttribution, in attribution, the other files'. Either can be nil
+// when the commit changed no such files. Generated binary files are listed
+// with the others: bytes don't count toward the percentage anyway.
+func withGeneratedAttribution(attribution, generatedAttribution *checkpoint.InitialAttribution) *checkpoint.InitialAttribution {
+	if generatedAttribution == nil || (len(generatedAttribution.Files) == 0 && len(generatedAttribution.BinaryFiles) == 0) {
+		return attribution
+	}
+	if attribution == nil {
+		attribution = &checkpoint.InitialAttribution{
+			CalculatedAt:  generatedAttribution.CalculatedAt,
+			Submodules:    generatedAttribution.Submodules,
+			Granularity:   generatedAttribution.Granularity,
+			Normalization: generatedAttribution.Normalization,
+		}
+	}
+	if len(generatedAttribution.BinaryFiles) > 0 {
+		attribution.BinaryFiles = append(attribution.BinaryFiles, generatedAttribution.BinaryFiles...)
+		slices.SortFunc(attribution.BinaryFiles, func(a, b checkpoint.BinaryFileAttribution) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+		attribution.BinaryAgentBytes += generatedAttribution.BinaryAgentBytes
+		attribution.BinaryHumanBytes += generatedAttribution.BinaryHumanBytes
+	}
+	attribution.GeneratedFiles = generatedAttribution.Files
+	attribution.GeneratedAgentLines = generatedAttribution.AgentLines
+	attribution.GeneratedTotalCommitted = generatedAttribution.TotalCommitted
+	return attribution
+}
@@ -0,0 +1,98 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+func TestGeneratedFiles_Match(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("*.pb.go linguist-generated\nvendor/** linguist-generated=true\nmocks/keep.go -linguist-generated\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g := loadGeneratedFiles(dir, []string{"mocks/", "*.lock"})
+	tests := map[string]bool{
+		"api/service.pb.go":  true,
+		"vendor/lib/x.go":    true,
+		"mocks/store.go":     true,
+		"mocks/keep.go":      false,
+		"Cargo.lock":         true,
+		"api/service.go":     false,
+		"cmd/mocks_test.go":  false,
+		"docs/generated.txt": false,
+	}
+	for path, want := range tests {
+		if got := g.match(path); got != want {
+			t.Errorf("match(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if loadGeneratedFiles(t.TempDir(), nil) != nil {
+		t.Error("loadGeneratedFiles() without attributes or patterns should be nil")
+	}
+}
+
+func TestCalculateAttributionWithAccumulated_GeneratedFiles(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("*.pb.go linguist-generated\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	generated := strings.Repeat("// generated\n", 100)
+	baseTree := buildTestTree(t, map[string]string{"main.go": "package main\n"})
+	// The agent wrote 4 lines and regenerated the protobufs; the human then
+	// regenerated mocks
+	shadowFiles := map[string]string{"main.go": "package main\n\nfunc main() {\n}\n", "api.pb.go": generated}
+	shadowTree := buildTestTree(t, shadowFiles)
+	headTree := buildTestTree(t, map[string]string{"main.go": shadowFiles["main.go"], "api.pb.go": generated, "mocks_store.go": "package mocks\n\ntype Store struct{}\n"})
+	touched := []string{"main.go", "api.pb.go"}
+
+	writeSettings := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, ".entire"), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSettings(`{"attribution": {"generated_files": ["mocks_*.go"]}}`)
+
+	attr := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, touched, nil)
+	if attr == nil {
+		t.Fatal("expected attribution")
+	}
+	if attr.AgentLines != 3 || attr.TotalCommitted != 3 || attr.AgentPercentage != 100 {
+		t.Errorf("totals = %d of %d lines (%.0f%%); want generated files left out: 3 of 3", attr.AgentLines, attr.TotalCommitted, attr.AgentPercentage)
+	}
+	if len(attr.Files) != 1 || attr.Files[0].Path != "main.go" {
+		t.Errorf("Files = %+v, want only main.go", attr.Files)
+	}
+	if len(attr.GeneratedFiles) != 2 || attr.GeneratedFiles[0].Path != "api.pb.go" || attr.GeneratedFiles[1].Path != "mocks_store.go" {
+		t.Fatalf("GeneratedFiles = %+v, want api.pb.go and mocks_store.go", attr.GeneratedFiles)
+	}
+	if attr.GeneratedFiles[0].AgentLines != 100 || attr.GeneratedFiles[1].HumanAdded != 3 {
+		t.Errorf("GeneratedFiles = %+v, want 100 agent lines and 3 human lines", attr.GeneratedFiles)
+	}
+	if attr.GeneratedAgentLines != 100 || attr.GeneratedTotalCommitted != 103 {
+		t.Errorf("generated totals = %d of %d, want 100 of 103", attr.GeneratedAgentLines, attr.GeneratedTotalCommitted)
+	}
+
+	// Only generated files touched by the agent still get a record
+	attr = CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"api.pb.go"}, nil)
+	if attr == nil || attr.TotalCommitted != 0 || len(attr.GeneratedFiles) != 2 {
+		t.Errorf("agent only regenerated: %+v, want an empty record with the generated file", attr)
+	}
+
+	writeSettings(`{"attribution": {"generated": "include", "generated_files": ["mocks_*.go"]}}`)
+	attr = CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, touched, nil)
+	if attr == nil || attr.AgentLines != 103 || attr.TotalCommitted != 106 || len(attr.GeneratedFiles) != 0 {
+		t.Errorf("include: %+v; want generated files counted like others", attr)
+	}
+}
@@ -16,7 +16,7 @@ import (
 const entireHookMarker = "Entire CLI hooks"
 
 // gitHookNames are the git hooks managed by Entire CLI
-var gitHookNames = []string{"prepare-commit-msg", "commit-msg", "post-commit", "pre-push"}
+var gitHookNames = []string{"prepare-commit-msg", "commit-msg", "post-commit", "pre-push", "post-rewrite", "post-checkout"}
 
 // GetGitDir returns the actual git directory path by delegating to git itself.
 // This handles both regular repositories and worktrees, and inherits git's
@@ -143,9 +143,11 @@ func InstallGitHook(silent bool) (int, error) {
 	prePushPath := filepath.Join(hooksDir, "pre-push")
 	prePushContent := fmt.Sprintf(`#!/bin/sh
 # %s
-# Pre-push hook: push session logs alongside user's push
-# $1 is the remote name (e.g., "origin")
-%s hooks git pre-push "$1" || true
+# Pre-push hook: enforce the push policy, push session logs alongside user's push
+# $1 is the remote name (e.g., "origin"); git passes the pushed refs on stdin
+%s hooks git pre-push "$1"
+# Exit status 1 means the push policy blocked the push; other failures never do
+[ $? -ne 1 ] || exit 1
 `, entireHookMarker, cmdPrefix)
 
 	written, err = writeHookFile(prePushPath, prePushContent)
@@ -156,8 +158,42 @@ func InstallGitHook(silent bool) (int, error) {
 		installedCount++
 	}
 
+	// Install post-rewrite hook
+	// $1 = "amend" or "rebase"; git passes the rewritten commits on stdin
+	postRewritePath := filepath.Join(hooksDir, "post-rewrite")
+	postRewriteContent := fmt.Sprintf(`#!/bin/sh
+# %s
+# Post-rewrite hook: move shadow branches of sessions based on rewritten commits
+%s hooks git post-rewrite "$1" 2>/dev/null || true
+`, entireHookMarker, cmdPrefix)
+
+	written, err = writeHookFile(postRewritePath, postRewriteContent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to install post-rewrite hook: %w", err)
+	}
+	if written {
+		installedCount++
+	}
+
+	// Install post-checkout hook
+	// $1 = previous HEAD, $2 = new HEAD, $3 = 1 for a branch checkout, 0 for files
+	postCheckoutPath := filepath.Join(hooksDir, "post-checkout")
+	postCheckoutContent := fmt.Sprintf(`#!/bin/sh
+# %s
+# Post-checkout hook: move shadow branches of sessions to the checked out commit
+%s hooks git post-checkout "$1" "$2" "$3" 2>/dev/null || true
+`, entireHookMarker, cmdPrefix)
+
+	written, err = writeHookFile(postCheckoutPath, postCheckoutContent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to install post-checkout hook: %w", err)
+	}
+	if written {
+		installedCount++
+	}
+
 	if !silent {
-		fmt.Println("✓ Installed git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push)")
+		fmt.Println("✓ Installed git hooks (prepare-commit-msg, commit-msg, post-commit, pre-push, post-rewrite, post-checkout)")
 		fmt.Println("  Hooks delegate to the current strategy at runtime")
 	}
 
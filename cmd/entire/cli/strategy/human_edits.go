@@ -0,0 +1,182 @@
+package strategy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/binary"
+)
+
+// Hooks only see the worktree when the agent starts or stops, so edits the
+// human makes in between are counted at the next prompt or commit, with no
+// record of when they happened. `entire daemon` watches the worktree and
+// hands file changes to a HumanEditRecorder, which adds them to the idle
+// session as HumanEdits. While the agent is mid-turn its edits can't be told
+// apart from the human's, so nothing is recorded; the recorder only keeps up
+// with the files' contents.
+
+// HumanEditRecorder turns file changes in a worktree into human edits of the
+// worktree's sessions. It remembers the content it last saw of each file, so
+// each edit counts only what changed since the previous one.
+type HumanEditRecorder struct {
+	worktreeRoot string
+	granularity  AttributionGranularity
+	normalizer   *attributionNormalizer
+	contents     map[string]string
+}
+
+// NewHumanEditRecorder returns a recorder for the worktree at worktreeRoot.
+func NewHumanEditRecorder(worktreeRoot string) *HumanEditRecorder {
+	return &HumanEditRecorder{
+		worktreeRoot: worktreeRoot,
+		granularity:  configuredAttributionGranularity(),
+		normalizer:   configuredAttributionNormalizer(),
+		contents:     make(map[string]string),
+	}
+}
+
+// Record records changes to files (relative to the worktree root) as a human
+// edit of the worktree's most recently used session. It records nothing, and
+// returns a nil edit, when the worktree has no session, when an agent is
+// mid-turn, or when the changes add or remove no lines.
+func (r *HumanEditRecorder) Record(ctx context.Context, files []string) (string, *session.HumanEdit, error) {
+	states, err := ListSessionStates()
+	if err != nil {
+		return "", nil, err
+	}
+	var target *SessionState
+	agentActive := false
+	for _, state := range states {
+		if state.WorktreePath != r.worktreeRoot || state.BaseCommit == "" || state.Phase == session.PhaseEnded {
+			continue
+		}
+		if state.Phase.IsActive() {
+			agentActive = true
+		}
+		if target == nil || lastInteraction(state).After(lastInteraction(target)) {
+			target = state
+		}
+	}
+
+	var baseline *object.Tree
+	if target != nil {
+		baseline = r.baselineTree(target)
+	}
+	edit := r.diff(files, baseline)
+	if target == nil || agentActive || edit == nil {
+		return "", nil, nil
+	}
+
+	store, err := sessionStateStore()
+	if err != nil {
+		return "", nil, err
+	}
+	recorded := false
+	err = store.Update(ctx, target.SessionID, func(state *SessionState) (*SessionState, error) {
+		if state == nil || state.Phase.IsActive() {
+			return nil, nil //nolint:nilnil // the turn started meanwhile, nothing to save
+		}
+		state.HumanEdits = append(state.HumanEdits, *edit)
+		recorded = true
+		return state, nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to record human edit: %w", err)
+	}
+	if !recorded {
+		return "", nil, nil
+	}
+	return target.SessionID, edit, nil
+}
+
+// diff updates the remembered contents of files and returns their changes,
+// nil if no lines changed. Files seen for the first time are compared with
+// baseline.
+func (r *HumanEditRecorder) diff(files []string, baseline *object.Tree) *session.HumanEdit {
+	edit := &session.HumanEdit{At: time.Now().UTC()}
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if strings.HasPrefix(file, paths.EntireMetadataDir+"/") || strings.HasPrefix(file, ".entire/") || strings.HasPrefix(file, ".git/") {
+			continue
+		}
+		var after string
+		if data, err := os.ReadFile(filepath.Join(r.worktreeRoot, file)); err == nil { //nolint:gosec // file is under the watched worktree
+			if isBinary, binErr := binary.IsBinary(bytes.NewReader(data)); binErr != nil || isBinary {
+				continue
+			}
+			after = string(data)
+		} else if !os.IsNotExist(err) {
+			continue
+		}
+		before, seen := r.contents[file]
+		if !seen && baseline != nil {
+			before = getFileContent(baseline, file)
+		}
+		r.contents[file] = after
+		_, added, removed := r.granularity.diff(r.normalizer.normalize(file, before), r.normalizer.normalize(file, after))
+		if added == 0 && removed == 0 {
+			continue
+		}
+		edit.Files = append(edit.Files, file)
+		edit.LinesAdded += added
+		edit.LinesRemoved += removed
+	}
+	if len(edit.Files) == 0 {
+		return nil
+	}
+	return edit
+}
+
+// baselineTree returns the tree the session's files were last known in: its
+// last checkpoint, or its base commit before the first one.
+func (r *HumanEditRecorder) baselineTree(state *SessionState) *object.Tree {
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil
+	}
+	hash := plumbing.NewHash(state.BaseCommit)
+	if ref, err := repo.Reference(checkpoint.ShadowRefName(repo, checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)), true); err == nil {
+		hash = ref.Hash()
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil
+	}
+	return tree
+}
+
+// lastInteraction returns when the session was last used.
+func lastInteraction(state *SessionState) time.Time {
+	if state.LastInteractionTime != nil {
+		return *state.LastInteractionTime
+	}
+	return state.StartedAt
+}
+
+// condensedHumanEdits returns the session's human edits for the committed
+// checkpoint.
+func condensedHumanEdits(state *SessionState) []checkpoint.HumanEdit {
+	if len(state.HumanEdits) == 0 {
+		return nil
+	}
+	edits := make([]checkpoint.HumanEdit, 0, len(state.HumanEdits))
+	for _, e := range state.HumanEdits {
+		edits = append(edits, checkpoint.HumanEdit(e))
+	}
+	return edits
+}
@@ -0,0 +1,93 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestHumanEditRecorder_Record(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	ctx := context.Background()
+
+	root, err := GetWorktreePath()
+	if err != nil {
+		t.Fatalf("GetWorktreePath() error = %v", err)
+	}
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	state := &SessionState{
+		SessionID:    "2026-10-14-human-edits",
+		BaseCommit:   head.Hash().String(),
+		WorktreePath: root,
+		Phase:        session.PhaseIdle,
+	}
+	if err := SaveSessionState(state); err != nil {
+		t.Fatalf("failed to save session state: %v", err)
+	}
+	setPhase := func(phase session.Phase) {
+		t.Helper()
+		loaded, err := LoadSessionState(state.SessionID)
+		if err != nil {
+			t.Fatalf("LoadSessionState() error = %v", err)
+		}
+		loaded.Phase = phase
+		if err := SaveSessionState(loaded); err != nil {
+			t.Fatalf("failed to save session state: %v", err)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write test.txt: %v", err)
+		}
+	}
+
+	recorder := NewHumanEditRecorder(root)
+	write("initial content\nsecond line\n")
+	sessionID, edit, err := recorder.Record(ctx, []string{"test.txt"})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if sessionID != state.SessionID || edit == nil || len(edit.Files) != 1 || edit.Files[0] != "test.txt" || edit.LinesAdded != 2 || edit.LinesRemoved != 1 {
+		t.Fatalf("Record() = %q, %+v; want test.txt +2/-1 in the idle session", sessionID, edit)
+	}
+
+	// Nothing changed since the last edit
+	if _, edit, err := recorder.Record(ctx, []string{"test.txt"}); err != nil || edit != nil {
+		t.Errorf("Record() without changes = %+v, %v; want nothing recorded", edit, err)
+	}
+
+	// The agent's turn: its edits aren't recorded, but are remembered
+	setPhase(session.PhaseActive)
+	write("initial content\nsecond line\nagent line\n")
+	if _, edit, err := recorder.Record(ctx, []string{"test.txt"}); err != nil || edit != nil {
+		t.Errorf("Record() mid-turn = %+v, %v; want nothing recorded", edit, err)
+	}
+
+	setPhase(session.PhaseIdle)
+	write("initial content\nsecond line\nagent line\nmine\n")
+	if _, edit, err := recorder.Record(ctx, []string{"test.txt"}); err != nil || edit == nil || edit.LinesAdded != 1 || edit.LinesRemoved != 0 {
+		t.Errorf("Record() after the turn = %+v, %v; want only the new line", edit, err)
+	}
+
+	loaded, err := LoadSessionState(state.SessionID)
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	if len(loaded.HumanEdits) != 2 {
+		t.Errorf("HumanEdits = %+v, want 2 recorded edits", loaded.HumanEdits)
+	}
+}
@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"math"
+	"sort"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CalculateImportedAttribution attributes a commit that was made without
+// Entire, from a convention saying an agent wrote it (an "[AI]" prefix, a
+// co-author trailer, ...). There are no checkpoints to compare against, so
+// agentShare (0 to 1) of the lines the commit added are counted as the
+// agent's and the rest as human additions. Returns nil if the commit changes
+// no text files.
+func CalculateImportedAttribution(parentTree, commitTree *object.Tree, agentShare float64) *checkpoint.InitialAttribution {
+	changed := getAllChangedFilesBetweenTrees(parentTree, commitTree)
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+
+	// The commit's tree doubles as the agent's final checkpoint
+	attribution := CalculateAttributionWithAccumulated(configuredAttributionGranularity(), parentTree, commitTree, commitTree, changed, nil)
+	if attribution == nil || len(attribution.Files) == 0 || agentShare >= 1 {
+		return attribution
+	}
+
+	attribution.AgentLines, attribution.HumanAdded = splitImportedLines(attribution.AgentLines, attribution.HumanAdded, agentShare)
+	attribution.AgentPercentage = attributionPercentage(attribution.AgentLines, attribution.TotalCommitted)
+	for i := range attribution.Files {
+		f := &attribution.Files[i]
+		f.AgentLines, f.HumanAdded = splitImportedLines(f.AgentLines, f.HumanAdded, agentShare)
+		f.AgentPercentage = attributionPercentage(f.AgentLines, f.TotalCommitted)
+		// Which lines were the agent's isn't known
+		f.AgentRanges = nil
+	}
+	return attribution
+}
+
+func splitImportedLines(agentLines, humanAdded int, agentShare float64) (int, int) {
+	agent := int(math.Round(float64(agentLines) * max(0, agentShare)))
+	return agent, humanAdded + agentLines - agent
+}
@@ -0,0 +1,13 @@
+package strategy
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Unit tests attribute in-memory trees from the package directory; keep
+	// their diffs out of the repository the tests run in
+	openAttributionDiffCache = func() *attributionDiffCache { return nil }
+	os.Exit(m.Run())
+}
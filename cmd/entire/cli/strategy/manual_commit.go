@@ -125,12 +125,20 @@ func (s *ManualCommitStrategy) EnsureSetup() error {
 func (s *ManualCommitStrategy) ListOrphanedItems() ([]CleanupItem, error) {
 	var items []CleanupItem
 
-	// Shadow branches (should have been auto-cleaned after condensation)
+	// Shadow branches (should have been auto-cleaned after condensation).
+	// Branches a live session in any worktree still needs are not orphaned.
 	branches, err := ListShadowBranches()
 	if err != nil {
 		return nil, err
 	}
+	refs, err := CollectSessionRefs()
+	if err != nil {
+		return nil, err
+	}
 	for _, branch := range branches {
+		if refs.ShadowBranchRefCount(branch) > 0 {
+			continue
+		}
 		items = append(items, CleanupItem{
 			Type:   CleanupTypeShadowBranch,
 			ID:     branch,
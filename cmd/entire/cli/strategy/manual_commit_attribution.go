@@ -1,15 +1,38 @@
 package strategy
 
 import (
+	"context"
+	"log/slog"
 	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/entireignore"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
+// configuredEntireIgnore returns the repository's .entireignore patterns, or
+// nil if there are none or they can't be read.
+func configuredEntireIgnore() *entireignore.Matcher {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil
+	}
+	ignore, err := entireignore.Load(repoRoot)
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring .entireignore", slog.String("error", err.Error()))
+		return nil
+	}
+	return ignore
+}
+
 // getAllChangedFilesBetweenTrees returns a list of all files that differ between two trees.
 // This includes files that were added, modified, or deleted in either tree.
 // Uses git blob hashes for efficient comparison without reading file contents.
@@ -61,16 +84,11 @@ func getAllChangedFilesBetweenTrees(tree1, tree2 *object.Tree) []string {
 // getFileContent retrieves the content of a file from a tree.
 // Returns empty string if the file doesn't exist, can't be read, or is a binary file.
 //
-// Binary files are silently excluded from attribution calculations because line-based
-// diffing doesn't apply to binary content. This means binary files (images, compiled
-// binaries, etc.) won't appear in attribution metrics even if they were added or modified.
-// This is intentional - attribution measures code contributions via line counting,
-// which only makes sense for text files.
+// Binary files are excluded from line counts because line-based diffing doesn't
+// apply to binary content; calculateAttribution tracks them separately by byte
+// size (see binaryFileAttribution).
 //
 // Uses go-git's IsBinary() which implements git's binary detection algorithm.
-//
-// TODO: Consider tracking binary file counts separately (e.g., BinaryFilesChanged field)
-// to provide visibility into non-text file modifications.
 func getFileContent(tree *object.Tree, path string) string {
 	if tree == nil {
 		return ""
@@ -86,8 +104,13 @@ func getFileContent(tree *object.Tree, path string) string {
 	if err != nil || isBinary {
 		return ""
 	}
+	// Git LFS pointers stand in for content that isn't in the tree
+	if _, ok := lfsPointerOf(file); ok {
+		return ""
+	}
 
-	content, err := file.Contents()
+	// Shadow trees may store large files chunked
+	content, err := checkpoint.FileContents(tree, file)
 	if err != nil {
 		return ""
 	}
@@ -95,6 +118,126 @@ func getFileContent(tree *object.Tree, path string) string {
 	return content
 }
 
+// binaryBlob is a version of a file as attribution of binary files sees it.
+type binaryBlob struct {
+	hash   plumbing.Hash // Zero if the file doesn't exist
+	size   int64
+	binary bool
+}
+
+// binaryBlobOf returns the version of path in tree.
+func binaryBlobOf(tree *object.Tree, path string) binaryBlob {
+	if tree == nil {
+		return binaryBlob{}
+	}
+	file, err := tree.File(path)
+	if err != nil {
+		return binaryBlob{}
+	}
+	// A Git LFS pointer counts as the binary it points to
+	if p, ok := lfsPointerOf(file); ok {
+		return binaryBlob{hash: file.Hash, size: p.Size, binary: true}
+	}
+	isBinary, err := file.IsBinary()
+	return binaryBlob{hash: file.Hash, size: file.Size, binary: err == nil && isBinary}
+}
+
+// lfsPointerOf returns the Git LFS pointer file is, if it is one.
+func lfsPointerOf(file *object.File) (checkpoint.LFSPointer, bool) {
+	if file.Size > checkpoint.LFSPointerMaxSize {
+		return checkpoint.LFSPointer{}, false
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return checkpoint.LFSPointer{}, false
+	}
+	return checkpoint.ParseLFSPointer(content)
+}
+
+// byteDelta is how many bytes the file grew or shrank by from a to b.
+func byteDelta(a, b binaryBlob) int64 {
+	if b.size >= a.size {
+		return b.size - a.size
+	}
+	return a.size - b.size
+}
+
+// binaryFileAttribution attributes a binary file changed from base to head.
+// The change is the agent's when head is a version one of the agent's
+// checkpoints wrote (agentVersions), the human's otherwise. Returns false if
+// the file is text or unchanged.
+func binaryFileAttribution(path string, base, head binaryBlob, agentVersions []binaryBlob) (checkpoint.BinaryFileAttribution, bool) {
+	if (!base.binary && !head.binary) || base.hash == head.hash {
+		return checkpoint.BinaryFileAttribution{}, false
+	}
+	attribution := checkpoint.BinaryFileAttribution{Path: path, Size: head.size}
+	agents := false
+	for _, v := range agentVersions {
+		if v.hash == head.hash {
+			agents = true
+			break
+		}
+	}
+	if agents {
+		attribution.AgentBytes = byteDelta(base, head)
+	} else {
+		attribution.HumanBytes = byteDelta(base, head)
+	}
+	return attribution, true
+}
+
+// submoduleAttributions attributes the submodules whose commit changed from
+// base to head, sorted by path. A change is the agent's when one of the
+// agent's checkpoints (agentTrees) recorded the commit head points at.
+func submoduleAttributions(baseTree, headTree *object.Tree, agentTrees []*object.Tree) []checkpoint.SubmoduleAttribution {
+	base := checkpoint.SubmoduleCommits(baseTree)
+	head := checkpoint.SubmoduleCommits(headTree)
+	changed := make(map[string]bool)
+	for path, commit := range head {
+		if base[path] != commit {
+			changed[path] = true
+		}
+	}
+	for path := range base {
+		if _, ok := head[path]; !ok {
+			changed[path] = true
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	agents := make([]map[string]plumbing.Hash, 0, len(agentTrees))
+	for _, tree := range agentTrees {
+		if tree != nil {
+			agents = append(agents, checkpoint.SubmoduleCommits(tree))
+		}
+	}
+
+	submodules := make([]checkpoint.SubmoduleAttribution, 0, len(changed))
+	for path := range changed {
+		s := checkpoint.SubmoduleAttribution{Path: path}
+		from, inBase := base[path]
+		to, inHead := head[path]
+		if inBase {
+			s.From = from.String()
+		}
+		if inHead {
+			s.To = to.String()
+		}
+		for _, agent := range agents {
+			if commit, ok := agent[path]; ok == inHead && (!ok || commit == to) {
+				s.Agent = true
+				break
+			}
+		}
+		submodules = append(submodules, s)
+	}
+	slices.SortFunc(submodules, func(a, b checkpoint.SubmoduleAttribution) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return submodules
+}
+
 // diffLines compares two strings and returns line-level diff stats.
 // Returns (unchanged, added, removed) line counts.
 func diffLines(checkpointContent, committedContent string) (unchanged, added, removed int) {
@@ -158,18 +301,83 @@ func countLinesStr(content string) int {
 // 4. Estimate user self-modifications vs agent modifications using per-file tracking
 // 5. Compute percentages
 //
+// granularity selects how partly edited lines are weighted (see AttributionGranularity).
+//
 // Note: Binary files (detected by null bytes) are silently excluded from attribution
 // calculations since line-based diffing only applies to text files.
 //
 // See docs/architecture/attribution.md for details on the per-file tracking approach.
 func CalculateAttributionWithAccumulated(
+	granularity AttributionGranularity,
+	baseTree *object.Tree,
+	shadowTree *object.Tree,
+	headTree *object.Tree,
+	filesTouched []string,
+	promptAttributions []PromptAttribution,
+) *checkpoint.InitialAttribution {
+	return calculateAttribution(granularity, baseTree, shadowTree, headTree, nil, filesTouched, promptAttributions, nil)
+}
+
+// calculateAttribution is CalculateAttributionWithAccumulated, ignoring the
+// changes to the files in notOurs that aren't agent-touched (e.g. files a merge
+// took from another branch). Lines the commit kept from earlierTrees, the
+// session's checkpoints before shadowTree, count as the agent's (see
+// squashedShadowContent). Generated files are attributed on their own (see
+// withGeneratedAttribution).
+func calculateAttribution(
+	granularity AttributionGranularity,
+	baseTree *object.Tree,
+	shadowTree *object.Tree,
+	headTree *object.Tree,
+	earlierTrees []*object.Tree,
+	filesTouched []string,
+	promptAttributions []PromptAttribution,
+	notOurs map[string]bool,
+) *checkpoint.InitialAttribution {
+	generated := configuredGeneratedFiles()
+	if generated == nil {
+		return attributeFiles(granularity, baseTree, shadowTree, headTree, earlierTrees, filesTouched, promptAttributions, notOurs, nil, false)
+	}
+	ownPrompts, generatedPrompts := splitGeneratedPromptAttributions(promptAttributions, generated)
+	attribution := attributeFiles(granularity, baseTree, shadowTree, headTree, earlierTrees, filesTouched, ownPrompts, notOurs, generated.match, false)
+	// Generated files only the human changed are reported along with the
+	// agent's other files
+	generatedAttribution := attributeFiles(granularity, baseTree, shadowTree, headTree, earlierTrees, filesTouched, generatedPrompts, notOurs,
+		func(path string) bool { return !generated.match(path) }, attribution != nil)
+	return withGeneratedAttribution(attribution, generatedAttribution)
+}
+
+// attributeFiles is calculateAttribution for the files skip doesn't match.
+// It returns nil if the agent touched none of them, unless humanOnly is set.
+func attributeFiles(
+	granularity AttributionGranularity,
 	baseTree *object.Tree,
 	shadowTree *object.Tree,
 	headTree *object.Tree,
+	earlierTrees []*object.Tree,
 	filesTouched []string,
 	promptAttributions []PromptAttribution,
+	notOurs map[string]bool,
+	skip func(path string) bool,
+	humanOnly bool,
 ) *checkpoint.InitialAttribution {
-	if len(filesTouched) == 0 {
+	_, span := tracing.Start(context.Background(), "attribution.diff", slog.Int("files_touched", len(filesTouched)))
+	defer span.End()
+
+	// Files .entireignore ignores count for neither the agent nor the human,
+	// nor do files the checkpoint skipped for their size: its tree holds a
+	// stale version of them
+	ignore := configuredEntireIgnore()
+	normalizer := configuredAttributionNormalizer()
+	skipped := checkpoint.ReadSkippedFiles(shadowTree)
+	if skip == nil {
+		skip = func(string) bool { return false }
+	}
+	filesTouched = slices.DeleteFunc(slices.Clone(ignore.Filter(filesTouched)), func(p string) bool {
+		_, ok := skipped[p]
+		return ok || skip(p)
+	})
+	if len(filesTouched) == 0 && !humanOnly {
 		return nil
 	}
 
@@ -193,40 +401,117 @@ func CalculateAttributionWithAccumulated(
 	var totalAgentAndUserWork int
 	var postCheckpointUserAdded, postCheckpointUserRemoved int
 	postCheckpointUserRemovedPerFile := make(map[string]int)
+	var files []checkpoint.FileAttribution
+	var binaryFiles []checkpoint.BinaryFileAttribution
+
+	cache := openAttributionDiffCache()
+	agentFiles := make([]agentFileDiff, len(filesTouched))
+	for i, filePath := range filesTouched {
+		base, head := binaryBlobOf(baseTree, filePath), binaryBlobOf(headTree, filePath)
+		agentVersions := []binaryBlob{binaryBlobOf(shadowTree, filePath)}
+		for _, tree := range earlierTrees {
+			agentVersions = append(agentVersions, binaryBlobOf(tree, filePath))
+		}
+		if binaryFile, ok := binaryFileAttribution(filePath, base, head, agentVersions); ok {
+			binaryFiles = append(binaryFiles, binaryFile)
+		}
 
-	for _, filePath := range filesTouched {
-		baseContent := getFileContent(baseTree, filePath)
-		shadowContent := getFileContent(shadowTree, filePath)
-		headContent := getFileContent(headTree, filePath)
+		f := &agentFiles[i]
+		f.path = filePath
+		f.key = agentDiffCacheKey(granularity, normalizer, base, head, agentVersions)
+		if f.fileDiffResult, f.cached = cache.load(f.key); f.cached {
+			continue
+		}
+		f.base = getFileContent(baseTree, filePath)
+		f.shadow = getFileContent(shadowTree, filePath)
+		f.head = getFileContent(headTree, filePath)
+		for _, tree := range earlierTrees {
+			f.earlier = append(f.earlier, getFileContent(tree, filePath))
+		}
+	}
+	forEachParallel(len(agentFiles), attributionWorkers, func(i int) {
+		if !agentFiles[i].cached {
+			agentFiles[i].diff(granularity, normalizer)
+		}
+	})
+
+	for i, filePath := range filesTouched {
+		f := &agentFiles[i]
+		if !f.cached {
+			cache.store(f.key, f.fileDiffResult)
+		}
 
 		// Total work in shadow: base → shadow (agent + accumulated user work for this file)
-		_, workAdded, _ := diffLines(baseContent, shadowContent)
-		totalAgentAndUserWork += workAdded
+		totalAgentAndUserWork += f.WorkAdded
 
 		// Post-checkpoint user edits: shadow → head (only post-checkpoint edits for this file)
-		_, postUserAdded, postUserRemoved := diffLines(shadowContent, headContent)
-		postCheckpointUserAdded += postUserAdded
-		postCheckpointUserRemoved += postUserRemoved
+		postCheckpointUserAdded += f.Added
+		postCheckpointUserRemoved += f.Removed
 
 		// Track per-file removals for self-modification estimation
-		if postUserRemoved > 0 {
-			postCheckpointUserRemovedPerFile[filePath] = postUserRemoved
+		if f.Removed > 0 {
+			postCheckpointUserRemovedPerFile[filePath] = f.Removed
+		}
+
+		accumulated := accumulatedUserAddedPerFile[filePath]
+		before := len(files)
+		files = appendFileAttribution(files, filePath,
+			max(0, f.WorkAdded-accumulated), accumulated+f.Added, f.Removed, min(f.Removed, accumulated))
+		if len(files) > before {
+			files[before].AgentRanges = f.AgentRanges
 		}
 	}
 
 	// Calculate total user edits to non-agent files (files not in filesTouched)
 	// These files are not in the shadow tree, so base→head captures ALL their user edits
-	nonAgentFiles := getAllChangedFilesBetweenTrees(baseTree, headTree)
-	var allUserEditsToNonAgentFiles int
-	for _, filePath := range nonAgentFiles {
+	var userPaths []string
+	var userFiles []userFileDiff
+	for _, filePath := range getAllChangedFilesBetweenTrees(baseTree, headTree) {
 		if slices.Contains(filesTouched, filePath) {
 			continue // Skip agent-touched files
 		}
+		if _, ok := skipped[filePath]; ok || notOurs[filePath] || ignore.Match(filePath) || skip(filePath) {
+			continue
+		}
 
-		baseContent := getFileContent(baseTree, filePath)
-		headContent := getFileContent(headTree, filePath)
-		_, userAdded, _ := diffLines(baseContent, headContent)
-		allUserEditsToNonAgentFiles += userAdded
+		base, head := binaryBlobOf(baseTree, filePath), binaryBlobOf(headTree, filePath)
+		if binaryFile, ok := binaryFileAttribution(filePath, base, head, nil); ok {
+			binaryFiles = append(binaryFiles, binaryFile)
+		}
+
+		f := userFileDiff{path: filePath, key: userDiffCacheKey(granularity, normalizer, base, head)}
+		if f.fileDiffResult, f.cached = cache.load(f.key); !f.cached {
+			f.base = getFileContent(baseTree, filePath)
+			f.head = getFileContent(headTree, filePath)
+		}
+		userPaths = append(userPaths, filePath)
+		userFiles = append(userFiles, f)
+	}
+	forEachParallel(len(userFiles), attributionWorkers, func(i int) {
+		if !userFiles[i].cached {
+			userFiles[i].diff(granularity, normalizer)
+		}
+	})
+	var allUserEditsToNonAgentFiles int
+	for i, filePath := range userPaths {
+		f := &userFiles[i]
+		if !f.cached {
+			cache.store(f.key, f.fileDiffResult)
+		}
+		allUserEditsToNonAgentFiles += f.Added
+		files = appendFileAttribution(files, filePath, 0, f.Added, f.Removed, 0)
+	}
+	cache.prune()
+	slices.SortFunc(files, func(a, b checkpoint.FileAttribution) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	slices.SortFunc(binaryFiles, func(a, b checkpoint.BinaryFileAttribution) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	var binaryAgentBytes, binaryHumanBytes int64
+	for _, f := range binaryFiles {
+		binaryAgentBytes += f.AgentBytes
+		binaryHumanBytes += f.HumanBytes
 	}
 
 	// Separate accumulated edits by file type using per-file tracking data.
@@ -295,16 +580,118 @@ func CalculateAttributionWithAccumulated(
 	}
 
 	return &checkpoint.InitialAttribution{
-		CalculatedAt:    time.Now().UTC(),
-		AgentLines:      agentLinesInCommit,
-		HumanAdded:      pureUserAdded,
-		HumanModified:   totalHumanModified, // Total modifications (for reporting)
-		HumanRemoved:    pureUserRemoved,
-		TotalCommitted:  totalCommitted,
-		AgentPercentage: agentPercentage,
+		CalculatedAt:     time.Now().UTC(),
+		AgentLines:       agentLinesInCommit,
+		HumanAdded:       pureUserAdded,
+		HumanModified:    totalHumanModified, // Total modifications (for reporting)
+		HumanRemoved:     pureUserRemoved,
+		TotalCommitted:   totalCommitted,
+		AgentPercentage:  agentPercentage,
+		Files:            files,
+		BinaryFiles:      binaryFiles,
+		BinaryAgentBytes: binaryAgentBytes,
+		BinaryHumanBytes: binaryHumanBytes,
+		Submodules:       submoduleAttributions(baseTree, headTree, append([]*object.Tree{shadowTree}, earlierTrees...)),
+		Granularity:      granularity.recorded(),
+		Normalization:    normalizer.recorded(),
 	}
 }
 
+// appendFileAttribution attributes a single file with the same rules as
+// CalculateAttributionWithAccumulated applies to the totals, and appends it
+// unless the file has no line changes (e.g. binary files).
+//   - agentAdded: lines the agent added to the file
+//   - userAdded, userRemoved: lines the user added and removed
+//   - userSelfModified: removals that targeted the user's own earlier additions
+func appendFileAttribution(files []checkpoint.FileAttribution, path string, agentAdded, userAdded, userRemoved, userSelfModified int) []checkpoint.FileAttribution {
+	if agentAdded == 0 && userAdded == 0 && userRemoved == 0 {
+		return files
+	}
+
+	modified := min(userAdded, userRemoved)
+	modifiedAgent := max(0, modified-userSelfModified)
+	pureAdded := userAdded - modified
+	pureRemoved := userRemoved - modified
+
+	total := agentAdded + pureAdded - pureRemoved
+	if total <= 0 {
+		total = max(0, agentAdded)
+	}
+	agentLines := max(0, agentAdded-pureRemoved-modifiedAgent)
+
+	var percentage float64
+	if total > 0 {
+		percentage = float64(agentLines) / float64(total) * 100
+	}
+	return append(files, checkpoint.FileAttribution{
+		Path:            path,
+		AgentLines:      agentLines,
+		HumanAdded:      pureAdded,
+		HumanModified:   modified,
+		HumanRemoved:    pureRemoved,
+		TotalCommitted:  total,
+		AgentPercentage: percentage,
+	})
+}
+
+// agentLineRanges replays the agent's checkpoint against the committed file and
+// returns the committed lines that the checkpoint added relative to the base
+// and that survived unchanged into the commit. Always line-based, whatever the
+// attribution granularity.
+func agentLineRanges(baseContent, shadowContent, headContent string) []checkpoint.LineRange {
+	// Which shadow lines the checkpoint added relative to the base
+	var agentAdded []bool
+	for _, d := range lineDiffOps(baseContent, shadowContent) {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			agentAdded = append(agentAdded, slices.Repeat([]bool{true}, n)...)
+		case diffmatchpatch.DiffEqual:
+			agentAdded = append(agentAdded, make([]bool, n)...)
+		case diffmatchpatch.DiffDelete:
+		}
+	}
+
+	var ranges []checkpoint.LineRange
+	shadowLine, headLine := 0, 0
+	for _, d := range lineDiffOps(shadowContent, headContent) {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for k := range n {
+				if agentAdded[shadowLine+k] {
+					ranges = appendLineToRanges(ranges, headLine+k+1)
+				}
+			}
+			shadowLine += n
+			headLine += n
+		case diffmatchpatch.DiffInsert:
+			headLine += n
+		case diffmatchpatch.DiffDelete:
+			shadowLine += n
+		}
+	}
+	return ranges
+}
+
+// lineDiffOps diffs a and b line by line. Each diff's Text holds one rune per
+// line rather than the lines themselves.
+func lineDiffOps(a, b string) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	text1, text2, _ := dmp.DiffLinesToChars(a, b)
+	return dmp.DiffMain(text1, text2, false)
+}
+
+// appendLineToRanges adds line to ranges, extending the last range when the
+// line directly follows it. Lines must be added in increasing order.
+func appendLineToRanges(ranges []checkpoint.LineRange, line int) []checkpoint.LineRange {
+	if n := len(ranges); n > 0 && ranges[n-1].End == line-1 {
+		ranges[n-1].End = line
+		return ranges
+	}
+	return append(ranges, checkpoint.LineRange{Start: line, End: line})
+}
+
 // estimateUserSelfModifications estimates how many removed lines were the user's own additions.
 // Uses LIFO assumption: when a user removes lines from a file, they likely remove their own
 // recent additions before touching agent lines.
@@ -327,6 +714,7 @@ func estimateUserSelfModifications(
 // This captures user edits since the last checkpoint BEFORE the agent makes changes.
 //
 // Parameters:
+//   - granularity: how partly edited lines are weighted (see AttributionGranularity)
 //   - baseTree: the tree at session start (the base commit)
 //   - lastCheckpointTree: the tree from the previous checkpoint (nil if first checkpoint)
 //   - worktreeFiles: map of file path → current worktree content for files that changed
@@ -339,6 +727,7 @@ func estimateUserSelfModifications(
 // Note: Binary files (detected by null bytes) in reference trees are silently excluded
 // from attribution calculations since line-based diffing only applies to text files.
 func CalculatePromptAttribution(
+	granularity AttributionGranularity,
 	baseTree *object.Tree,
 	lastCheckpointTree *object.Tree,
 	worktreeFiles map[string]string,
@@ -359,13 +748,18 @@ func CalculatePromptAttribution(
 		referenceTree = baseTree
 	}
 
+	ignore := configuredEntireIgnore()
+	normalizer := configuredAttributionNormalizer()
 	for filePath, worktreeContent := range worktreeFiles {
-		referenceContent := getFileContent(referenceTree, filePath)
-		baseContent := getFileContent(baseTree, filePath)
+		if ignore.Match(filePath) {
+			continue
+		}
+		worktreeContent = normalizer.normalize(filePath, worktreeContent)
+		referenceContent := normalizer.normalize(filePath, getFileContent(referenceTree, filePath))
 
 		// User changes: diff(reference, worktree)
 		// These are changes since the last checkpoint that the agent didn't make
-		_, userAdded, userRemoved := diffLines(referenceContent, worktreeContent)
+		_, userAdded, userRemoved := granularity.diff(referenceContent, worktreeContent)
 		result.UserLinesAdded += userAdded
 		result.UserLinesRemoved += userRemoved
 
@@ -378,8 +772,9 @@ func CalculatePromptAttribution(
 		// Agent lines so far: diff(base, lastCheckpoint)
 		// Only calculate if we have a previous checkpoint
 		if lastCheckpointTree != nil {
-			checkpointContent := getFileContent(lastCheckpointTree, filePath)
-			_, agentAdded, agentRemoved := diffLines(baseContent, checkpointContent)
+			baseContent := normalizer.normalize(filePath, getFileContent(baseTree, filePath))
+			checkpointContent := normalizer.normalize(filePath, getFileContent(lastCheckpointTree, filePath))
+			_, agentAdded, agentRemoved := granularity.diff(baseContent, checkpointContent)
 			result.AgentLinesAdded += agentAdded
 			result.AgentLinesRemoved += agentRemoved
 		}
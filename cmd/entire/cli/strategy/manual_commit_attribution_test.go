@@ -1,10 +1,20 @@
 package strategy
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 )
@@ -184,12 +194,19 @@ func TestDiffLines_ModifiedEstimation(t *testing.T) {
 
 // buildTestTree creates an object.Tree from a map of file paths to content.
 // This is a test helper for creating trees without a full git repository.
-func buildTestTree(t *testing.T, files map[string]string) *object.Tree {
+func buildTestTree(t testing.TB, files map[string]string) *object.Tree {
 	t.Helper()
 
 	if len(files) == 0 {
 		return nil
 	}
+	return buildTestTreeWithSubmodules(t, files, nil)
+}
+
+// buildTestTreeWithSubmodules is buildTestTree with gitlink entries for the
+// submodules at the given commits.
+func buildTestTreeWithSubmodules(t testing.TB, files map[string]string, submodules map[string]plumbing.Hash) *object.Tree {
+	t.Helper()
 
 	// Use memory storage to build a tree
 	storage := memory.NewStorage()
@@ -223,6 +240,10 @@ func buildTestTree(t *testing.T, files map[string]string) *object.Tree {
 		})
 	}
 
+	for path, commit := range submodules {
+		entries = append(entries, object.TreeEntry{Name: path, Mode: filemode.Submodule, Hash: commit})
+	}
+
 	// Sort entries by name (required by git tree format)
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Name < entries[j].Name
@@ -278,6 +299,7 @@ func TestCalculateAttributionWithAccumulated_BasicCase(t *testing.T) {
 	promptAttributions := []PromptAttribution{} // No intermediate checkpoints
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -336,6 +358,7 @@ func TestCalculateAttributionWithAccumulated_BugScenario(t *testing.T) {
 	promptAttributions := []PromptAttribution{} // No intermediate checkpoints
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -394,6 +417,7 @@ func TestCalculateAttributionWithAccumulated_DeletionOnly(t *testing.T) {
 	promptAttributions := []PromptAttribution{} // No intermediate checkpoints
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -444,6 +468,7 @@ func TestCalculateAttributionWithAccumulated_NoUserEdits(t *testing.T) {
 	promptAttributions := []PromptAttribution{} // No intermediate checkpoints
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -497,6 +522,7 @@ func TestCalculateAttributionWithAccumulated_NoAgentWork(t *testing.T) {
 	promptAttributions := []PromptAttribution{} // No intermediate checkpoints
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -552,6 +578,7 @@ func TestCalculateAttributionWithAccumulated_UserRemovesAllAgentLines(t *testing
 	promptAttributions := []PromptAttribution{} // No intermediate checkpoints
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -622,6 +649,7 @@ func TestCalculateAttributionWithAccumulated_WithPromptAttributions(t *testing.T
 	}
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -666,6 +694,7 @@ func TestCalculateAttributionWithAccumulated_EmptyFilesTouched(t *testing.T) {
 	headTree := buildTestTree(t, map[string]string{})
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, []string{}, []PromptAttribution{},
 	)
 
@@ -719,6 +748,7 @@ func TestCalculateAttributionWithAccumulated_UserEditsNonAgentFile(t *testing.T)
 	}
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -764,6 +794,50 @@ func TestCalculateAttributionWithAccumulated_UserEditsNonAgentFile(t *testing.T)
 }
 
 // TestGetAllChangedFilesBetweenTrees tests the hash-based file change detection.
+func TestCalculateAttributionWithAccumulated_PerFile(t *testing.T) {
+	baseTree := buildTestTree(t, map[string]string{
+		"agent.go":  "",
+		"mixed.go":  "a\nb\n",
+		"README.md": "old\n",
+	})
+	// Agent writes agent.go and adds two lines to mixed.go
+	shadowTree := buildTestTree(t, map[string]string{
+		"agent.go":  "1\n2\n3\n4\n",
+		"mixed.go":  "a\nb\nc\nd\n",
+		"README.md": "old\n",
+	})
+	// User then adds two lines to mixed.go and rewrites README.md
+	headTree := buildTestTree(t, map[string]string{
+		"agent.go":  "1\n2\n3\n4\n",
+		"mixed.go":  "a\nb\nc\nd\ne\nf\n",
+		"README.md": "new\nmore\n",
+	})
+
+	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
+		baseTree, shadowTree, headTree, []string{"mixed.go", "agent.go"}, nil,
+	)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+
+	want := []checkpoint.FileAttribution{
+		{Path: "README.md", HumanAdded: 1, HumanModified: 1, TotalCommitted: 1},
+		{Path: "agent.go", AgentLines: 4, TotalCommitted: 4, AgentPercentage: 100,
+			AgentRanges: []checkpoint.LineRange{{Start: 1, End: 4}}},
+		{Path: "mixed.go", AgentLines: 2, HumanAdded: 2, TotalCommitted: 4, AgentPercentage: 50,
+			AgentRanges: []checkpoint.LineRange{{Start: 3, End: 4}}},
+	}
+	if len(result.Files) != len(want) {
+		t.Fatalf("Files = %+v, want %d entries", result.Files, len(want))
+	}
+	for i, w := range want {
+		if !reflect.DeepEqual(result.Files[i], w) {
+			t.Errorf("Files[%d] = %+v, want %+v", i, result.Files[i], w)
+		}
+	}
+}
+
 func TestGetAllChangedFilesBetweenTrees(t *testing.T) {
 	storer := memory.NewStorage()
 
@@ -1000,6 +1074,7 @@ func TestCalculateAttributionWithAccumulated_UserSelfModification(t *testing.T)
 	}
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -1072,6 +1147,7 @@ func TestCalculateAttributionWithAccumulated_MixedModifications(t *testing.T) {
 	}
 
 	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
 		baseTree, shadowTree, headTree, filesTouched, promptAttributions,
 	)
 
@@ -1132,7 +1208,7 @@ func TestCalculatePromptAttribution_PopulatesPerFile(t *testing.T) {
 		"b.go": "line1\nagent1\nagent2\nuser1\n",       // +1 user line
 	}
 
-	result := CalculatePromptAttribution(baseTree, lastCheckpointTree, worktreeFiles, 2)
+	result := CalculatePromptAttribution(GranularityLine, baseTree, lastCheckpointTree, worktreeFiles, 2)
 
 	if result.UserLinesAdded != 4 {
 		t.Errorf("UserLinesAdded = %d, want 4 (3 + 1)", result.UserLinesAdded)
@@ -1149,3 +1225,140 @@ func TestCalculatePromptAttribution_PopulatesPerFile(t *testing.T) {
 		t.Errorf("UserAddedPerFile[b.go] = %d, want 1", result.UserAddedPerFile["b.go"])
 	}
 }
+
+func TestCalculateAttributionWithAccumulated_BinaryFiles(t *testing.T) {
+	baseTree := buildTestTree(t, map[string]string{
+		"main.go":  "package main\n",
+		"logo.png": "\x89PNG\x00\x00\x00\x00\x00\x00",
+	})
+	// The agent adds a protobuf and redraws the logo
+	shadowTree := buildTestTree(t, map[string]string{
+		"main.go":  "package main\n\nfunc main() {}\n",
+		"agent.pb": "\x08\x01\x00" + strings.Repeat("x", 17),
+		"logo.png": "\x89PNG\x00" + strings.Repeat("a", 25),
+	})
+	// The human redraws the logo again and adds a font
+	headTree := buildTestTree(t, map[string]string{
+		"main.go":  "package main\n\nfunc main() {}\n",
+		"agent.pb": "\x08\x01\x00" + strings.Repeat("x", 17),
+		"logo.png": "\x89PNG\x00" + strings.Repeat("h", 11),
+		"font.bin": "\x00\x01\x02\x03\x04\x05\x06\x07",
+	})
+
+	result := CalculateAttributionWithAccumulated(
+		GranularityLine,
+		baseTree, shadowTree, headTree, []string{"main.go", "agent.pb", "logo.png"}, nil,
+	)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+
+	want := []checkpoint.BinaryFileAttribution{
+		{Path: "agent.pb", AgentBytes: 20, Size: 20},
+		{Path: "font.bin", HumanBytes: 8, Size: 8},
+		{Path: "logo.png", HumanBytes: 6, Size: 16},
+	}
+	if !reflect.DeepEqual(result.BinaryFiles, want) {
+		t.Errorf("BinaryFiles = %+v, want %+v", result.BinaryFiles, want)
+	}
+	if result.BinaryAgentBytes != 20 || result.BinaryHumanBytes != 14 {
+		t.Errorf("BinaryAgentBytes, BinaryHumanBytes = %d, %d; want 20, 14", result.BinaryAgentBytes, result.BinaryHumanBytes)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "main.go" || result.AgentLines != 2 {
+		t.Errorf("Files = %+v, AgentLines = %d; want only main.go with 2 agent lines", result.Files, result.AgentLines)
+	}
+}
+
+func TestCalculateAttributionWithAccumulated_Submodules(t *testing.T) {
+	var (
+		libV1    = plumbing.NewHash("1111111111111111111111111111111111111111")
+		libV2    = plumbing.NewHash("2222222222222222222222222222222222222222")
+		vendorV1 = plumbing.NewHash("3333333333333333333333333333333333333333")
+		vendorV2 = plumbing.NewHash("4444444444444444444444444444444444444444")
+	)
+	baseTree := buildTestTreeWithSubmodules(t, map[string]string{"main.go": "package main\n"},
+		map[string]plumbing.Hash{"lib": libV1, "vendor": vendorV1, "docs": libV1})
+	// The agent moves lib; the human then moves vendor and removes docs
+	shadowTree := buildTestTreeWithSubmodules(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"},
+		map[string]plumbing.Hash{"lib": libV2, "vendor": vendorV1, "docs": libV1})
+	headTree := buildTestTreeWithSubmodules(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"},
+		map[string]plumbing.Hash{"lib": libV2, "vendor": vendorV2})
+
+	result := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"main.go", "lib"}, nil)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	want := []checkpoint.SubmoduleAttribution{
+		{Path: "docs", From: libV1.String()},
+		{Path: "lib", From: libV1.String(), To: libV2.String(), Agent: true},
+		{Path: "vendor", From: vendorV1.String(), To: vendorV2.String()},
+	}
+	if !reflect.DeepEqual(result.Submodules, want) {
+		t.Errorf("Submodules = %+v, want %+v", result.Submodules, want)
+	}
+	if result.AgentLines != 2 || len(result.Files) != 1 {
+		t.Errorf("AgentLines = %d, Files = %+v; want only main.go's 2 lines", result.AgentLines, result.Files)
+	}
+}
+
+func TestCalculateAttributionWithAccumulated_LFSPointers(t *testing.T) {
+	pointer := func(oid byte, size int) string {
+		return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", strings.Repeat(string(oid), 64), size)
+	}
+	baseTree := buildTestTree(t, map[string]string{"model.bin": pointer('a', 1000)})
+	shadowTree := buildTestTree(t, map[string]string{"model.bin": pointer('b', 4000)})
+	headTree := buildTestTree(t, map[string]string{"model.bin": pointer('b', 4000)})
+
+	result := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"model.bin"}, nil)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	// The pointer is attributed as the 4000-byte binary, not as 3 text lines
+	want := []checkpoint.BinaryFileAttribution{{Path: "model.bin", AgentBytes: 3000, Size: 4000}}
+	if !reflect.DeepEqual(result.BinaryFiles, want) {
+		t.Errorf("BinaryFiles = %+v, want %+v", result.BinaryFiles, want)
+	}
+	if result.AgentLines != 0 || len(result.Files) != 0 {
+		t.Errorf("AgentLines = %d, Files = %+v; want no line attribution", result.AgentLines, result.Files)
+	}
+}
+
+func TestCalculateAttribution_SkipsEntireIgnoredFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".entireignore"), []byte("*.gen.go\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .entireignore: %v", err)
+	}
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+
+	baseTree := buildTestTree(t, map[string]string{"main.go": "package main\n"})
+	shadowTree := buildTestTree(t, map[string]string{
+		"main.go":    "package main\n\nfunc a() {}\n",
+		"api.gen.go": "package main\n\nvar generated = 1\n",
+	})
+	headTree := buildTestTree(t, map[string]string{
+		"main.go":    "package main\n\nfunc a() {}\n",
+		"api.gen.go": "package main\n\nvar generated = 1\n",
+		"z.gen.go":   "package main\n",
+	})
+
+	attr := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"main.go", "api.gen.go"}, nil)
+	if attr == nil {
+		t.Fatal("expected attribution")
+	}
+	if attr.AgentLines != 2 || attr.HumanAdded != 0 {
+		t.Errorf("agent lines = %d, human added = %d, want 2 and 0 (generated files ignored)", attr.AgentLines, attr.HumanAdded)
+	}
+	for _, f := range attr.Files {
+		if f.Path != "main.go" {
+			t.Errorf("attribution has ignored file %s", f.Path)
+		}
+	}
+
+	if attr := CalculateAttributionWithAccumulated(GranularityLine, baseTree, shadowTree, headTree, []string{"api.gen.go"}, nil); attr != nil {
+		t.Errorf("attribution of only ignored files = %+v, want nil", attr)
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/agent/aider"
 	"github.com/entireio/cli/cmd/entire/cli/agent/claudecode"
 	"github.com/entireio/cli/cmd/entire/cli/agent/geminicli"
 	cpkg "github.com/entireio/cli/cmd/entire/cli/checkpoint"
@@ -20,6 +22,7 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/settings"
 	"github.com/entireio/cli/cmd/entire/cli/summarize"
 	"github.com/entireio/cli/cmd/entire/cli/textutil"
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
 	"github.com/entireio/cli/cmd/entire/cli/transcript"
 
 	"github.com/go-git/go-git/v5"
@@ -108,9 +111,19 @@ func (s *ManualCommitStrategy) getCheckpointLog(checkpointID id.CheckpointID) ([
 // Metadata is stored at sharded path: <checkpoint_id[:2]>/<checkpoint_id[2:]>/
 // Uses checkpoint.GitStore.WriteCommitted for the git operations.
 func (s *ManualCommitStrategy) CondenseSession(repo *git.Repository, checkpointID id.CheckpointID, state *SessionState) (*CondenseResult, error) {
+	_, span := tracing.Start(context.Background(), "strategy.condense_session",
+		slog.String("checkpoint_id", checkpointID.String()),
+		slog.String("session_id", state.SessionID),
+	)
+	result, err := s.condenseSession(repo, checkpointID, state)
+	span.EndWithError(err)
+	return result, err
+}
+
+func (s *ManualCommitStrategy) condenseSession(repo *git.Repository, checkpointID id.CheckpointID, state *SessionState) (*CondenseResult, error) {
 	// Get shadow branch
 	shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := cpkg.ShadowRefName(repo, shadowBranchName)
 	ref, err := repo.Reference(refName, true)
 	if err != nil {
 		return nil, fmt.Errorf("shadow branch not found: %w", err)
@@ -134,11 +147,23 @@ func (s *ManualCommitStrategy) CondenseSession(repo *git.Repository, checkpointI
 		return nil, fmt.Errorf("failed to get checkpoint store: %w", err)
 	}
 
+	var shadowTree *object.Tree
+	if shadowCommit, commitErr := repo.CommitObject(ref.Hash()); commitErr == nil {
+		shadowTree, _ = shadowCommit.Tree() //nolint:errcheck // subagent transcripts are optional
+	}
+	subagents, subagentTranscripts := condensedSubagents(shadowTree, state)
+
 	// Get author info
 	authorName, authorEmail := GetGitAuthorFromRepo(repo)
 	attribution := calculateSessionAttributions(repo, ref, sessionData, state)
+	// An amend re-condenses into the same record; keep what it said
+	if head, headErr := repo.Head(); headErr == nil {
+		if headCommit, commitErr := repo.CommitObject(head.Hash()); commitErr == nil {
+			attribution = foldAmendedAttribution(repo, store, checkpointID, state, headCommit, attribution)
+		}
+	}
 	// Get current branch name
-	branchName := GetCurrentBranchName(repo)
+	branchName := checkpointBranchName(repo)
 
 	// Generate summary if enabled
 	var summary *cpkg.Summary
@@ -188,6 +213,14 @@ func (s *ManualCommitStrategy) CondenseSession(repo *git.Repository, checkpointI
 		AuthorName:                  authorName,
 		AuthorEmail:                 authorEmail,
 		Agent:                       state.AgentType,
+		Automation:                  state.Automation,
+		TaskType:                    state.TaskType,
+		Subagents:                   subagents,
+		SubagentTranscripts:         subagentTranscripts,
+		HumanEdits:                  condensedHumanEdits(state),
+		Notes:                       condensedNotes(state),
+		ReconstructionConfidence:    state.ReconstructionConfidence,
+		MetadataOnly:                checkpointsMetadataOnly(context.Background()),
 		TranscriptIdentifierAtStart: state.TranscriptIdentifierAtStart,
 		CheckpointTranscriptStart:   state.CheckpointTranscriptStart,
 		TokenUsage:                  sessionData.TokenUsage,
@@ -242,13 +275,12 @@ func calculateSessionAttributions(repo *git.Repository, shadowRef *plumbing.Refe
 					} else {
 						// Get base tree (state before session started)
 						var baseTree *object.Tree
-						attrBase := state.AttributionBaseCommit
-						if attrBase == "" {
-							attrBase = state.BaseCommit // backward compat
-						}
+						var baseHash string
+						attrBase := attributionBase(state)
 						if baseCommit, baseErr := repo.CommitObject(plumbing.NewHash(attrBase)); baseErr == nil {
 							if tree, baseTErr := baseCommit.Tree(); baseTErr == nil {
 								baseTree = tree
+								baseHash = baseCommit.Hash.String()
 							} else {
 								logging.Debug(logCtx, "attribution: base tree unavailable",
 									slog.String("error", baseTErr.Error()))
@@ -259,6 +291,29 @@ func calculateSessionAttributions(repo *git.Repository, shadowRef *plumbing.Refe
 								slog.String("attribution_base", attrBase))
 						}
 
+						// Merges are attributed against the first parent, leaving out
+						// files taken unchanged from the merged branch, or not at all
+						var mergedFiles map[string]bool
+						if headCommit.NumParents() > 1 {
+							if configuredMergeCommitAttribution() == settings.AttributionMergeSkip {
+								logging.Info(logCtx, "attribution skipped: merge commit",
+									slog.String("commit", headCommit.Hash.String()))
+								return &cpkg.InitialAttribution{
+									CalculatedAt: time.Now().UTC(),
+									Skipped:      AttributionSkippedMerge,
+								}
+							}
+							firstParentTree, notOurs, mergeErr := mergeAttributionBase(headCommit, headTree)
+							if mergeErr != nil {
+								logging.Debug(logCtx, "attribution skipped: failed to read merge parents",
+									slog.String("error", mergeErr.Error()))
+								return nil
+							}
+							baseTree = firstParentTree
+							baseHash = headCommit.ParentHashes[0].String()
+							mergedFiles = notOurs
+						}
+
 						// Log accumulated prompt attributions for debugging
 						var totalUserAdded, totalUserRemoved int
 						for i, pa := range state.PromptAttributions {
@@ -273,13 +328,34 @@ func calculateSessionAttributions(repo *git.Repository, shadowRef *plumbing.Refe
 								slog.Int("index", i))
 						}
 
-						attribution = CalculateAttributionWithAccumulated(
+						// Optionally count lines kept from the session's earlier checkpoints
+						var earlierTrees []*object.Tree
+						if configuredCheckpointAttribution() == settings.AttributionCheckpointsUnion {
+							trees, treesErr := earlierCheckpointTrees(repo, shadowRef, state)
+							if treesErr != nil {
+								logging.Debug(logCtx, "attribution: earlier checkpoints unavailable",
+									slog.String("error", treesErr.Error()))
+							}
+							earlierTrees = trees
+						}
+
+						attribution = calculateAttribution(
+							configuredAttributionGranularity(),
 							baseTree,
 							shadowTree,
 							headTree,
+							earlierTrees,
 							sessionData.FilesTouched,
 							state.PromptAttributions,
+							mergedFiles,
 						)
+						if attribution != nil && len(earlierTrees) > 0 {
+							attribution.Checkpoints = settings.AttributionCheckpointsUnion
+						}
+						if attribution != nil {
+							attribution.BaseCommit = baseHash
+							attribution.Commit = headCommit.Hash.String()
+						}
 
 						if attribution != nil {
 							logging.Info(logCtx, "attribution calculated",
@@ -402,6 +478,15 @@ func extractUserPrompts(agentType agent.AgentType, content string) []string {
 		return nil
 	}
 
+	// Aider stores its Markdown chat history as the transcript
+	if agentType == agent.AgentTypeAider {
+		chat, ok := aider.LatestChat([]byte(content))
+		if !ok {
+			return nil
+		}
+		return chat.Prompts(0)
+	}
+
 	// Try Gemini format first if agentType is Gemini, or as fallback if Unknown
 	if agentType == agent.AgentTypeGemini || agentType == agent.AgentTypeUnknown {
 		prompts, err := geminicli.ExtractAllUserPrompts([]byte(content))
@@ -435,6 +520,15 @@ func calculateTokenUsage(agentType agent.AgentType, data []byte, startOffset int
 		return &agent.TokenUsage{}
 	}
 
+	// Aider reports token usage as "Tokens: ..." lines in its chat history
+	if agentType == agent.AgentTypeAider {
+		chat, ok := aider.LatestChat(data)
+		if !ok {
+			return &agent.TokenUsage{}
+		}
+		return chat.TokenUsage(startOffset)
+	}
+
 	// Try Gemini format first if agentType is Gemini, or as fallback if Unknown
 	if agentType == agent.AgentTypeGemini || agentType == agent.AgentTypeUnknown {
 		// Attempt to parse as Gemini JSON
@@ -557,6 +651,8 @@ func (s *ManualCommitStrategy) CondenseSessionByID(sessionID string) error {
 	if state == nil {
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
+	// Condensation takes a while; hooks may save the session meanwhile
+	changes := s.trackSessionState(state)
 
 	// Open repository
 	repo, err := OpenRepository()
@@ -572,7 +668,7 @@ func (s *ManualCommitStrategy) CondenseSessionByID(sessionID string) error {
 
 	// Check if shadow branch exists (required for condensation)
 	shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := cpkg.ShadowRefName(repo, shadowBranchName)
 	_, refErr := repo.Reference(refName, true)
 	hasShadowBranch := refErr == nil
 
@@ -603,6 +699,10 @@ func (s *ManualCommitStrategy) CondenseSessionByID(sessionID string) error {
 
 	// Update session state: reset step count and transition to idle
 	state.StepCount = 0
+	state.ReconstructionConfidence = 0
+	state.Subagents = nil
+	state.HumanEdits = nil
+	state.Notes = nil
 	state.CheckpointTranscriptStart = result.TotalTranscriptLines
 	state.Phase = session.PhaseIdle
 	state.LastCheckpointID = checkpointID
@@ -610,8 +710,9 @@ func (s *ManualCommitStrategy) CondenseSessionByID(sessionID string) error {
 	state.AttributionBaseCommit = state.BaseCommit
 	state.PromptAttributions = nil
 	state.PendingPromptAttribution = nil
+	state.CheckpointEpochs = nil
 
-	if err := s.saveSessionState(state); err != nil {
+	if err := changes.Save(); err != nil {
 		return fmt.Errorf("failed to save session state: %w", err)
 	}
 
@@ -5,14 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
 
 	"github.com/go-git/go-git/v5"
@@ -63,7 +64,6 @@ func (s *ManualCommitStrategy) SaveChanges(ctx SaveContext) error {
 	var promptAttr PromptAttribution
 	if state.PendingPromptAttribution != nil {
 		promptAttr = *state.PendingPromptAttribution
-		state.PendingPromptAttribution = nil // Clear after use
 	} else {
 		// No pending attribution (e.g., first checkpoint or session initialized without it)
 		promptAttr = PromptAttribution{CheckpointNumber: state.StepCount + 1}
@@ -79,8 +79,22 @@ func (s *ManualCommitStrategy) SaveChanges(ctx SaveContext) error {
 		slog.Int("agent_removed", promptAttr.AgentLinesRemoved),
 		slog.String("session_id", sessionID))
 
+	attributionBase := state.AttributionBaseCommit
+	if attributionBase == "" {
+		attributionBase = state.BaseCommit
+	}
+
+	journal, refName, err := s.beginShadowBranchIntent(repo, state, intentOpCheckpoint)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
 	// Use WriteTemporary to create the checkpoint
+	backend := checkpointBackend(repo)
+	defer backend.Close()
 	isFirstCheckpointOfSession := state.StepCount == 0
+	writeStart := time.Now()
 	result, err := store.WriteTemporary(context.Background(), checkpoint.WriteTemporaryOptions{
 		SessionID:         sessionID,
 		BaseCommit:        state.BaseCommit,
@@ -90,14 +104,20 @@ func (s *ManualCommitStrategy) SaveChanges(ctx SaveContext) error {
 		DeletedFiles:      ctx.DeletedFiles,
 		MetadataDir:       ctx.MetadataDir,
 		MetadataDirAbs:    ctx.MetadataDirAbs,
-		CommitMessage:     ctx.CommitMessage,
+		CommitMessage:     checkpointMessage(repo, StrategyNameManualCommit, ctx, attributionBase, append(state.PromptAttributions, promptAttr)),
 		AuthorName:        ctx.AuthorName,
 		AuthorEmail:       ctx.AuthorEmail,
 		IsFirstCheckpoint: isFirstCheckpointOfSession,
+		ChunkThreshold:    configuredChunkThreshold(),
+		SizeLimits:        configuredSizeLimits(),
+		TreeListings:      treeListingCache(),
+		MetadataOnly:      checkpointsMetadataOnly(context.Background()),
+		Backend:           backend,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write temporary checkpoint: %w", err)
 	}
+	recordCheckpointLatency(backend, time.Since(writeStart))
 
 	// If checkpoint was skipped due to deduplication (no changes), return early
 	if result.Skipped {
@@ -108,43 +128,44 @@ func (s *ManualCommitStrategy) SaveChanges(ctx SaveContext) error {
 			slog.Int("checkpoint_count", state.StepCount),
 			slog.String("shadow_branch", shadowBranchName),
 		)
-		fmt.Fprintf(os.Stderr, "Skipped checkpoint (no changes since last checkpoint)\n")
+		completeIntent(journal)
 		return nil
 	}
 
 	// Update session state
-	state.StepCount++
-
 	// Note: PendingCheckpointID is intentionally NOT cleared here.
 	// It is set by PostCommit (ACTIVE → ACTIVE_COMMITTED) and consumed by
 	// handleTurnEndCondense. Clearing it here would cause a mismatch between
 	// the checkpoint ID in the commit trailer and the condensed metadata.
+	savedAt := time.Now()
+	epochFiles := mergeFilesTouched(nil, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+	update := func(state *SessionState) {
+		state.StepCount++
+		state.PendingPromptAttribution = nil // Consumed above
 
-	// Store the prompt attribution we calculated before saving
-	state.PromptAttributions = append(state.PromptAttributions, promptAttr)
+		// Store the prompt attribution we calculated before saving
+		state.PromptAttributions = append(state.PromptAttributions, promptAttr)
 
-	// Track touched files (modified, new, and deleted)
-	state.FilesTouched = mergeFilesTouched(state.FilesTouched, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+		// Track touched files (modified, new, and deleted)
+		state.FilesTouched = mergeFilesTouched(state.FilesTouched, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
 
-	// On first checkpoint, record the transcript identifier for this session
-	if state.StepCount == 1 {
-		state.TranscriptIdentifierAtStart = ctx.StepTranscriptIdentifier
-	}
+		// Roll this checkpoint into the current epoch
+		state.RecordCheckpointEpoch(result.CommitHash.String(), savedAt, epochFiles)
 
-	// Accumulate token usage
-	if ctx.TokenUsage != nil {
-		state.TokenUsage = accumulateTokenUsage(state.TokenUsage, ctx.TokenUsage)
-	}
+		// On first checkpoint, record the transcript identifier for this session
+		if state.StepCount == 1 {
+			state.TranscriptIdentifierAtStart = ctx.StepTranscriptIdentifier
+		}
 
-	// Save updated state
-	if err := s.saveSessionState(state); err != nil {
-		return fmt.Errorf("failed to save session state: %w", err)
+		// Accumulate token usage
+		if ctx.TokenUsage != nil {
+			state.TokenUsage = accumulateTokenUsage(state.TokenUsage, ctx.TokenUsage)
+		}
 	}
 
-	if !branchExisted {
-		fmt.Fprintf(os.Stderr, "Created shadow branch '%s' and committed changes\n", shadowBranchName)
-	} else {
-		fmt.Fprintf(os.Stderr, "Committed changes to shadow branch '%s'\n", shadowBranchName)
+	// Save updated state
+	if err := s.saveStateAndCompleteIntent(journal, refName, result.CommitHash, state, update); err != nil {
+		return err
 	}
 
 	// Log checkpoint creation
@@ -217,7 +238,7 @@ func (s *ManualCommitStrategy) SaveTaskCheckpoint(ctx TaskCheckpointContext) err
 			shortToolUseID,
 		)
 	} else {
-		messageSubject = FormatSubagentEndMessage(ctx.SubagentType, ctx.TaskDescription, shortToolUseID)
+		messageSubject = taskMessage(StrategyNameManualCommit, ctx, FormatSubagentEndMessage(ctx.SubagentType, ctx.TaskDescription, shortToolUseID))
 	}
 	commitMsg := trailers.FormatShadowTaskCommit(
 		messageSubject,
@@ -225,8 +246,17 @@ func (s *ManualCommitStrategy) SaveTaskCheckpoint(ctx TaskCheckpointContext) err
 		ctx.SessionID,
 	)
 
+	journal, refName, err := s.beginShadowBranchIntent(repo, state, intentOpTaskCheckpoint)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
 	// Use WriteTemporaryTask to create the checkpoint
-	_, err = store.WriteTemporaryTask(context.Background(), checkpoint.WriteTemporaryTaskOptions{
+	backend := checkpointBackend(repo)
+	defer backend.Close()
+	writeStart := time.Now()
+	commitHash, err := store.WriteTemporaryTask(context.Background(), checkpoint.WriteTemporaryTaskOptions{
 		SessionID:              ctx.SessionID,
 		BaseCommit:             state.BaseCommit,
 		WorktreeID:             state.WorktreeID,
@@ -245,23 +275,31 @@ func (s *ManualCommitStrategy) SaveTaskCheckpoint(ctx TaskCheckpointContext) err
 		IncrementalSequence:    ctx.IncrementalSequence,
 		IncrementalType:        ctx.IncrementalType,
 		IncrementalData:        ctx.IncrementalData,
+		ChunkThreshold:         configuredChunkThreshold(),
+		SizeLimits:             configuredSizeLimits(),
+		TreeListings:           treeListingCache(),
+		MetadataOnly:           checkpointsMetadataOnly(context.Background()),
+		Backend:                backend,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write task checkpoint: %w", err)
 	}
+	recordCheckpointLatency(backend, time.Since(writeStart))
 
-	// Track touched files (modified, new, and deleted)
-	state.FilesTouched = mergeFilesTouched(state.FilesTouched, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+	savedAt := time.Now()
+	epochFiles := mergeFilesTouched(nil, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+	update := func(state *SessionState) {
+		// Track touched files (modified, new, and deleted)
+		state.FilesTouched = mergeFilesTouched(state.FilesTouched, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+		recordSubagentRun(state, ctx)
 
-	// Save updated state
-	if err := s.saveSessionState(state); err != nil {
-		return fmt.Errorf("failed to save session state: %w", err)
+		// Roll this checkpoint into the current epoch
+		state.RecordTaskCheckpointEpoch(commitHash.String(), savedAt, epochFiles)
 	}
 
-	if !branchExisted {
-		fmt.Fprintf(os.Stderr, "Created shadow branch '%s' and committed task checkpoint\n", shadowBranchName)
-	} else {
-		fmt.Fprintf(os.Stderr, "Committed task checkpoint to shadow branch '%s'\n", shadowBranchName)
+	// Save updated state
+	if err := s.saveStateAndCompleteIntent(journal, refName, commitHash, state, update); err != nil {
+		return err
 	}
 
 	// Log task checkpoint creation
@@ -361,3 +399,28 @@ func deleteShadowBranch(_ *git.Repository, branchName string) error {
 	}
 	return nil
 }
+
+// configuredChunkThreshold returns the size from which checkpoints store text
+// files chunked, 0 if chunking is off or settings can't be loaded.
+// configuredSizeLimits returns the checkpoint size limits from settings,
+// the defaults if settings are missing or invalid.
+func configuredSizeLimits() checkpoint.SizeLimits {
+	s, err := settings.Load()
+	if err != nil {
+		return checkpoint.SizeLimits{MaxFileSize: settings.DefaultMaxFileSize, MaxCheckpointSize: settings.DefaultMaxCheckpointSize}
+	}
+	maxFile, maxCheckpoint, err := s.SizeLimits.Limits()
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring size limit settings", slog.String("error", err.Error()))
+		maxFile, maxCheckpoint, _ = (*settings.SizeLimitsSettings)(nil).Limits() //nolint:errcheck // the defaults don't fail
+	}
+	return checkpoint.SizeLimits{MaxFileSize: maxFile, MaxCheckpointSize: maxCheckpoint}
+}
+
+func configuredChunkThreshold() int64 {
+	s, err := settings.Load()
+	if err != nil {
+		return 0
+	}
+	return s.Chunking.Threshold()
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
 	"github.com/entireio/cli/cmd/entire/cli/session"
@@ -32,10 +33,15 @@ import (
 // In test environments, ENTIRE_TEST_TTY overrides the real check:
 //   - ENTIRE_TEST_TTY=1 → simulate human (TTY available)
 //   - ENTIRE_TEST_TTY=0 → simulate agent (no TTY)
+//
+// CI runners never have a human to ask, even when a pseudo-terminal is attached.
 func hasTTY() bool {
 	if v := os.Getenv("ENTIRE_TEST_TTY"); v != "" {
 		return v == "1"
 	}
+	if cienv.Enabled() {
+		return false
+	}
 	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
 	if err != nil {
 		return false
@@ -52,7 +58,7 @@ func askConfirmTTY(prompt string, context string, defaultYes bool) bool {
 	// In test mode, don't try to interact with the real TTY — just use the default.
 	// ENTIRE_TEST_TTY=1 simulates "a human is present" for the hasTTY() check
 	// but we can't actually read from the TTY in tests.
-	if os.Getenv("ENTIRE_TEST_TTY") != "" {
+	if os.Getenv("ENTIRE_TEST_TTY") != "" || cienv.Enabled() {
 		return defaultYes
 	}
 
@@ -165,6 +171,27 @@ func stripCheckpointTrailer(message string) string {
 	return strings.Join(result, "\n")
 }
 
+// isRebaseInProgress reports whether git rebase is in progress.
+func isRebaseInProgress() bool {
+	gitDir, err := GetGitDir()
+	if err != nil {
+		return false
+	}
+	return isRebaseInProgressIn(gitDir)
+}
+
+// isRebaseInProgressIn reports whether the git directory gitDir has rebase
+// state directories.
+func isRebaseInProgressIn(gitDir string) bool {
+	if _, err := os.Stat(filepath.Join(gitDir, "rebase-merge")); err == nil {
+		return true
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "rebase-apply")); err == nil {
+		return true
+	}
+	return false
+}
+
 // isGitSequenceOperation checks if git is currently in the middle of a rebase,
 // cherry-pick, or revert operation. During these operations, commits are being
 // replayed and should not be linked to agent sessions.
@@ -180,11 +207,7 @@ func isGitSequenceOperation() bool {
 		return false // Can't determine, assume not in sequence operation
 	}
 
-	// Check for rebase state directories
-	if _, err := os.Stat(filepath.Join(gitDir, "rebase-merge")); err == nil {
-		return true
-	}
-	if _, err := os.Stat(filepath.Join(gitDir, "rebase-apply")); err == nil {
+	if isRebaseInProgressIn(gitDir) {
 		return true
 	}
 
@@ -403,18 +426,18 @@ func (s *ManualCommitStrategy) PrepareCommitMsg(commitMsgFile string, source str
 	// Determine agent type and last prompt from session
 	agentType := DefaultAgentType // default for backward compatibility
 	var lastPrompt string
+	var promptSession *SessionState
 	if hasNewContent && len(sessionsWithContent) > 0 {
-		session := sessionsWithContent[0]
-		if session.AgentType != "" {
-			agentType = session.AgentType
-		}
-		lastPrompt = s.getLastPrompt(repo, session)
+		promptSession = sessionsWithContent[0]
 	} else if reusedSession != nil {
 		// Reusing checkpoint from existing session - get agent type and prompt from that session
-		if reusedSession.AgentType != "" {
-			agentType = reusedSession.AgentType
+		promptSession = reusedSession
+	}
+	if promptSession != nil {
+		if promptSession.AgentType != "" {
+			agentType = promptSession.AgentType
 		}
-		lastPrompt = s.getLastPrompt(repo, reusedSession)
+		lastPrompt = s.getLastPrompt(repo, promptSession)
 	}
 
 	// Prepare prompt for display: collapse newlines/whitespace, then truncate (rune-safe)
@@ -465,6 +488,9 @@ func (s *ManualCommitStrategy) PrepareCommitMsg(commitMsgFile string, source str
 	default:
 		// Normal editor flow: add trailer with explanatory comment (will be stripped by git)
 		message = addCheckpointTrailerWithComment(message, checkpointID, string(agentType), displayPrompt)
+		if promptSession != nil && commitMessageTemplates().Suggest {
+			message = addCommitSuggestion(message, s.commitSuggestion(repo, promptSession), string(agentType))
+		}
 	}
 
 	logging.Info(logCtx, "prepare-commit-msg: trailer added",
@@ -603,6 +629,10 @@ func (s *ManualCommitStrategy) PostCommit() error {
 		return nil //nolint:nilerr // Hook must be silent on failure
 	}
 
+	// Cherry-picks and reverts of attributed commits carry their original's
+	// attribution (see commit_provenance.go)
+	recordCommitProvenance(repo, commit)
+
 	// Check if commit has checkpoint trailer (ParseCheckpoint validates format)
 	checkpointID, found := trailers.ParseCheckpoint(commit.Message)
 	if !found {
@@ -627,6 +657,13 @@ func (s *ManualCommitStrategy) PostCommit() error {
 		return nil //nolint:nilerr // Intentional: hooks must be silent on failure
 	}
 
+	// Condensation takes a while, so save only what changes and keep what
+	// other hooks save meanwhile
+	tracked := make(map[*SessionState]*SessionStateChanges, len(sessions))
+	for _, state := range sessions {
+		tracked[state] = s.trackSessionState(state)
+	}
+
 	// Build transition context
 	isRebase := isGitSequenceOperation()
 	transitionCtx := session.TransitionContext{
@@ -637,6 +674,7 @@ func (s *ManualCommitStrategy) PostCommit() error {
 		logging.Debug(logCtx, "post-commit: rebase/sequence in progress, skipping phase transitions",
 			slog.String("strategy", "manual-commit"),
 		)
+		foldRebaseFixup(repo, commit, checkpointID)
 	}
 
 	// Track shadow branch names and whether they can be deleted
@@ -653,6 +691,7 @@ func (s *ManualCommitStrategy) PostCommit() error {
 		state *SessionState
 	}
 	var pendingMigrations []pendingMigration
+	amendRecorded := false
 
 	// Pass 1: Run transitions and dispatch condensation/discard actions.
 	// Defer migration actions to pass 2.
@@ -674,6 +713,8 @@ func (s *ManualCommitStrategy) PostCommit() error {
 
 		// Run the state machine transition
 		remaining := TransitionAndLog(state, session.EventGitCommit, transitionCtx)
+		// Whether the session's attribution is written now or by a later condensation
+		attributed := false
 
 		// Dispatch strategy-specific actions.
 		// Each branch handles its own BaseCommit update so there is no
@@ -684,6 +725,7 @@ func (s *ManualCommitStrategy) PostCommit() error {
 			switch action {
 			case session.ActionCondense:
 				if hasNew {
+					attributed = true
 					s.condenseAndUpdateState(logCtx, repo, checkpointID, state, head, shadowBranchName, shadowBranchesToDelete)
 					// condenseAndUpdateState updates BaseCommit on success.
 					// On failure, BaseCommit is preserved so the shadow branch remains accessible.
@@ -696,6 +738,7 @@ func (s *ManualCommitStrategy) PostCommit() error {
 				// but hasNew is an additional content-level check (transcript has
 				// new content beyond what was previously condensed).
 				if len(state.FilesTouched) > 0 && hasNew {
+					attributed = true
 					s.condenseAndUpdateState(logCtx, repo, checkpointID, state, head, shadowBranchName, shadowBranchesToDelete)
 					// On failure, BaseCommit is preserved (same as ActionCondense).
 				} else {
@@ -714,6 +757,7 @@ func (s *ManualCommitStrategy) PostCommit() error {
 				// Store checkpointID so HandleTurnEnd can reuse it for deferred condensation.
 				state.PendingCheckpointID = checkpointID.String()
 				pendingMigrations = append(pendingMigrations, pendingMigration{state: state})
+				attributed = true
 			case session.ActionClearEndedAt, session.ActionUpdateLastInteraction:
 				// Handled by session.ApplyCommonActions above
 			case session.ActionWarnStaleSession:
@@ -721,9 +765,18 @@ func (s *ManualCommitStrategy) PostCommit() error {
 			}
 		}
 
+		// An amend without new agent work still changes the commit
+		if !attributed && !isRebase && recordAmendWithoutCondensation(repo, checkpointID, state, commit) {
+			state.AttributionBaseCommit = newHead
+			amendRecorded = true
+		}
+
 		// Save the updated state
-		if err := s.saveSessionState(state); err != nil {
-			fmt.Fprintf(os.Stderr, "[entire] Warning: failed to update session state: %v\n", err)
+		if err := tracked[state].Save(); err != nil {
+			logging.Warn(logCtx, "post-commit: failed to update session state",
+				slog.String("session_id", state.SessionID),
+				slog.String("error", err.Error()),
+			)
 		}
 
 		// Track whether any session on this shadow branch is still active
@@ -732,6 +785,10 @@ func (s *ManualCommitStrategy) PostCommit() error {
 		}
 	}
 
+	if amendRecorded {
+		writeCommitNote(repo, head.Hash(), checkpointID)
+	}
+
 	// Pass 2: Run deferred migrations now that all condensations are complete.
 	for _, pm := range pendingMigrations {
 		if _, migErr := s.migrateShadowBranchIfNeeded(repo, pm.state); migErr != nil {
@@ -741,8 +798,11 @@ func (s *ManualCommitStrategy) PostCommit() error {
 			)
 		}
 		// Save the migrated state
-		if err := s.saveSessionState(pm.state); err != nil {
-			fmt.Fprintf(os.Stderr, "[entire] Warning: failed to update session state after migration: %v\n", err)
+		if err := tracked[pm.state].Save(); err != nil {
+			logging.Warn(logCtx, "post-commit: failed to update session state after migration",
+				slog.String("session_id", pm.state.SessionID),
+				slog.String("error", err.Error()),
+			)
 		}
 	}
 
@@ -755,9 +815,11 @@ func (s *ManualCommitStrategy) PostCommit() error {
 			continue
 		}
 		if err := deleteShadowBranch(repo, shadowBranchName); err != nil {
-			fmt.Fprintf(os.Stderr, "[entire] Warning: failed to clean up %s: %v\n", shadowBranchName, err)
+			logging.Warn(logCtx, "post-commit: failed to delete shadow branch",
+				slog.String("shadow_branch", shadowBranchName),
+				slog.String("error", err.Error()),
+			)
 		} else {
-			fmt.Fprintf(os.Stderr, "[entire] Cleaned up shadow branch: %s\n", shadowBranchName)
 			logging.Info(logCtx, "shadow branch deleted",
 				slog.String("strategy", "manual-commit"),
 				slog.String("shadow_branch", shadowBranchName),
@@ -781,8 +843,6 @@ func (s *ManualCommitStrategy) condenseAndUpdateState(
 ) {
 	result, err := s.CondenseSession(repo, checkpointID, state)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[entire] Warning: condensation failed for session %s: %v\n",
-			state.SessionID, err)
 		logging.Warn(logCtx, "post-commit: condensation failed",
 			slog.String("session_id", state.SessionID),
 			slog.String("error", err.Error()),
@@ -793,12 +853,20 @@ func (s *ManualCommitStrategy) condenseAndUpdateState(
 	// Track this shadow branch for cleanup
 	shadowBranchesToDelete[shadowBranchName] = struct{}{}
 
+	// Rewritten after each session so the note covers every session condensed so far
+	writeCommitNote(repo, head.Hash(), checkpointID)
+
 	// Update session state for the new base commit
 	newHead := head.Hash().String()
 	state.BaseCommit = newHead
 	state.AttributionBaseCommit = newHead
 	state.StepCount = 0
+	state.ReconstructionConfidence = 0
+	state.Subagents = nil
+	state.HumanEdits = nil
+	state.Notes = nil
 	state.CheckpointTranscriptStart = result.TotalTranscriptLines
+	state.CheckpointEpochs = nil
 
 	// Clear attribution tracking — condensation already used these values
 	state.PromptAttributions = nil
@@ -870,8 +938,15 @@ func (s *ManualCommitStrategy) postCommitUpdateBaseCommitOnly(logCtx context.Con
 				slog.String("new_head", truncateHash(newHead)),
 			)
 			state.BaseCommit = newHead
-			if err := s.saveSessionState(state); err != nil {
-				fmt.Fprintf(os.Stderr, "[entire] Warning: failed to update session state: %v\n", err)
+			err := s.updateSessionState(state.SessionID, func(latest *SessionState) error {
+				latest.BaseCommit = newHead
+				return nil
+			})
+			if err != nil {
+				logging.Warn(logCtx, "post-commit (no trailer): failed to update session state",
+					slog.String("session_id", state.SessionID),
+					slog.String("error", err.Error()),
+				)
 			}
 		}
 	}
@@ -910,7 +985,7 @@ func (s *ManualCommitStrategy) filterSessionsWithNewContent(repo *git.Repository
 func (s *ManualCommitStrategy) sessionHasNewContent(repo *git.Repository, state *SessionState) (bool, error) {
 	// Get shadow branch
 	shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := checkpoint.ShadowRefName(repo, shadowBranchName)
 	ref, err := repo.Reference(refName, true)
 	if err != nil {
 		// No shadow branch means no Stop has happened since the last condensation.
@@ -1221,6 +1296,10 @@ func (s *ManualCommitStrategy) InitializeSession(sessionID string, agentType age
 	}
 
 	if state != nil && state.BaseCommit != "" {
+		// Computing attribution and migrating take a while, so save only what
+		// changes and keep what other hooks save meanwhile
+		changes := s.trackSessionState(state)
+
 		// Session is fully initialized — apply phase transition for TurnStart
 		TransitionAndLog(state, session.EventTurnStart, session.TransitionContext{})
 
@@ -1233,6 +1312,10 @@ func (s *ManualCommitStrategy) InitializeSession(sessionID string, agentType age
 		if state.FirstPrompt == "" && userPrompt != "" {
 			state.FirstPrompt = truncatePromptForStorage(userPrompt)
 		}
+		if state.Title == "" {
+			state.Title = session.DeriveTitle(state.FirstPrompt)
+		}
+		updateTaskType(state, userPrompt)
 
 		// Update transcript path if provided (may change on session resume)
 		if transcriptPath != "" && state.TranscriptPath != transcriptPath {
@@ -1263,7 +1346,7 @@ func (s *ManualCommitStrategy) InitializeSession(sessionID string, agentType age
 			return fmt.Errorf("failed to check/migrate shadow branch: %w", err)
 		}
 
-		if err := s.saveSessionState(state); err != nil {
+		if err := changes.Save(); err != nil {
 			return fmt.Errorf("failed to update session state: %w", err)
 		}
 		return nil
@@ -1276,6 +1359,7 @@ func (s *ManualCommitStrategy) InitializeSession(sessionID string, agentType age
 	if err != nil {
 		return fmt.Errorf("failed to initialize session: %w", err)
 	}
+	changes := s.trackSessionState(state)
 
 	// Apply phase transition: new session starts as ACTIVE
 	TransitionAndLog(state, session.EventTurnStart, session.TransitionContext{})
@@ -1284,11 +1368,14 @@ func (s *ManualCommitStrategy) InitializeSession(sessionID string, agentType age
 	// This captures any user edits made before the first prompt
 	promptAttr := s.calculatePromptAttributionAtStart(repo, state)
 	state.PendingPromptAttribution = &promptAttr
-	if err = s.saveSessionState(state); err != nil {
+	if err = changes.Save(); err != nil {
 		return fmt.Errorf("failed to save attribution: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Initialized shadow session: %s\n", sessionID)
+	logging.Info(logging.WithComponent(context.Background(), "session"), "shadow session initialized",
+		slog.String("session_id", sessionID),
+		slog.String("agent", string(agentType)),
+	)
 	return nil
 }
 
@@ -1313,7 +1400,7 @@ func (s *ManualCommitStrategy) calculatePromptAttributionAtStart(
 	// CalculatePromptAttribution will use baseTree as the reference instead.
 	var lastCheckpointTree *object.Tree
 	shadowBranchName := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := checkpoint.ShadowRefName(repo, shadowBranchName)
 	ref, err := repo.Reference(refName, true)
 	if err != nil {
 		logging.Debug(logCtx, "prompt attribution: no shadow branch yet (first checkpoint)",
@@ -1399,7 +1486,7 @@ func (s *ManualCommitStrategy) calculatePromptAttributionAtStart(
 	}
 
 	// Use CalculatePromptAttribution from manual_commit_attribution.go
-	result = CalculatePromptAttribution(baseTree, lastCheckpointTree, changedFiles, nextCheckpointNum)
+	result = CalculatePromptAttribution(configuredAttributionGranularity(), baseTree, lastCheckpointTree, changedFiles, nextCheckpointNum)
 
 	return result
 }
@@ -1430,7 +1517,7 @@ func getStagedFiles(repo *git.Repository) []string {
 // Returns empty string if no prompt can be retrieved.
 func (s *ManualCommitStrategy) getLastPrompt(repo *git.Repository, state *SessionState) string {
 	shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := checkpoint.ShadowRefName(repo, shadowBranchName)
 	ref, err := repo.Reference(refName, true)
 	if err != nil {
 		return ""
@@ -1542,9 +1629,11 @@ func (s *ManualCommitStrategy) handleTurnEndCondense(logCtx context.Context, sta
 			continue
 		}
 		if err := deleteShadowBranch(repo, branchName); err != nil {
-			fmt.Fprintf(os.Stderr, "[entire] Warning: failed to clean up %s: %v\n", branchName, err)
+			logging.Warn(logCtx, "turn-end: failed to delete shadow branch",
+				slog.String("shadow_branch", branchName),
+				slog.String("error", err.Error()),
+			)
 		} else {
-			fmt.Fprintf(os.Stderr, "[entire] Cleaned up shadow branch: %s\n", branchName)
 			logging.Info(logCtx, "shadow branch deleted (turn-end)",
 				slog.String("strategy", "manual-commit"),
 				slog.String("shadow_branch", branchName),
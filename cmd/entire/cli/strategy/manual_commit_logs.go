@@ -52,7 +52,7 @@ func (s *ManualCommitStrategy) GetSessionInfo() (*SessionInfo, error) {
 	// Return info for most recent session
 	state := sessions[0]
 	shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := checkpoint.ShadowRefName(repo, shadowBranchName)
 
 	info := &SessionInfo{
 		SessionID: state.SessionID,
@@ -233,7 +233,7 @@ func (s *ManualCommitStrategy) getDescriptionFromShadowBranch(sessionID, baseCom
 	}
 
 	shadowBranchName := getShadowBranchNameForCommit(baseCommit, worktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := checkpoint.ShadowRefName(repo, shadowBranchName)
 	ref, err := repo.Reference(refName, true)
 	if err != nil {
 		return ""
@@ -1,10 +1,12 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"log/slog"
 
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -28,34 +30,44 @@ func (s *ManualCommitStrategy) migrateShadowBranchIfNeeded(repo *git.Repository,
 		return false, fmt.Errorf("failed to get HEAD: %w", err)
 	}
 
-	currentHead := head.Hash().String()
-	if state.BaseCommit == currentHead {
+	return s.moveShadowBranch(repo, state, head.Hash().String(), "HEAD changed during session")
+}
+
+// moveShadowBranch moves state's shadow branch to newBase and updates
+// state.BaseCommit; reason says why, for the log. The caller persists state.
+// Returns true if the base changed.
+func (s *ManualCommitStrategy) moveShadowBranch(repo *git.Repository, state *SessionState, newBase, reason string) (bool, error) {
+	if state.BaseCommit == newBase {
 		return false, nil // No migration needed
 	}
 
-	// HEAD changed - check if old shadow branch exists and migrate it
+	// Base changed - check if old shadow branch exists and migrate it
 	oldShadowBranch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	newShadowBranch := checkpoint.ShadowBranchNameForCommit(currentHead, state.WorktreeID)
+	newShadowBranch := checkpoint.ShadowBranchNameForCommit(newBase, state.WorktreeID)
 
 	// Guard against hash prefix collision: if both commits produce the same
 	// shadow branch name (same 7-char prefix), just update state - no ref rename needed
 	if oldShadowBranch == newShadowBranch {
-		state.BaseCommit = currentHead
+		state.BaseCommit = newBase
 		return true, nil
 	}
 
-	oldRefName := plumbing.NewBranchReferenceName(oldShadowBranch)
+	logCtx := logging.WithComponent(context.Background(), "migration")
+	oldRefName := checkpoint.ShadowRefName(repo, oldShadowBranch)
 	oldRef, err := repo.Reference(oldRefName, true)
 	if err != nil {
 		// Old shadow branch doesn't exist - just update state.BaseCommit
 		// This can happen if this is the first checkpoint after HEAD changed
-		state.BaseCommit = currentHead
-		fmt.Fprintf(os.Stderr, "Updated session base commit to %s (HEAD changed during session)\n", currentHead[:7])
+		state.BaseCommit = newBase
+		logging.Info(logCtx, "session base commit updated ("+reason+")",
+			slog.String("session_id", state.SessionID),
+			slog.String("new_base", truncateHash(newBase)),
+		)
 		return true, nil //nolint:nilerr // err is "reference not found" which is fine - just need to update state
 	}
 
 	// Old shadow branch exists - move it to new base commit
-	newRefName := plumbing.NewBranchReferenceName(newShadowBranch)
+	newRefName := checkpoint.ShadowRefName(repo, newShadowBranch)
 
 	// Create new reference pointing to same commit as old shadow branch
 	newRef := plumbing.NewHashReference(newRefName, oldRef.Hash())
@@ -66,14 +78,20 @@ func (s *ManualCommitStrategy) migrateShadowBranchIfNeeded(repo *git.Repository,
 	// Delete old reference via CLI (go-git v5's RemoveReference doesn't persist with packed refs/worktrees)
 	if err := DeleteBranchCLI(oldShadowBranch); err != nil {
 		// Non-fatal: log but continue - the important thing is the new branch exists
-		fmt.Fprintf(os.Stderr, "Warning: failed to remove old shadow branch %s: %v\n", oldShadowBranch, err)
+		logging.Warn(logCtx, "failed to remove old shadow branch",
+			slog.String("shadow_branch", oldShadowBranch),
+			slog.String("error", err.Error()),
+		)
 	}
 
-	fmt.Fprintf(os.Stderr, "Moved shadow branch from %s to %s (HEAD changed during session)\n",
-		oldShadowBranch, newShadowBranch)
+	logging.Info(logCtx, "shadow branch moved ("+reason+")",
+		slog.String("session_id", state.SessionID),
+		slog.String("old_shadow_branch", oldShadowBranch),
+		slog.String("shadow_branch", newShadowBranch),
+	)
 
 	// Update state with new base commit
-	state.BaseCommit = currentHead
+	state.BaseCommit = newBase
 	return true, nil
 }
 
@@ -85,7 +103,11 @@ func (s *ManualCommitStrategy) migrateAndPersistIfNeeded(repo *git.Repository, s
 		return fmt.Errorf("failed to check/migrate shadow branch: %w", err)
 	}
 	if migrated {
-		if err := s.saveSessionState(state); err != nil {
+		err := s.updateSessionState(state.SessionID, func(latest *SessionState) error {
+			latest.BaseCommit = state.BaseCommit
+			return nil
+		})
+		if err != nil {
 			return fmt.Errorf("failed to save session state after migration: %w", err)
 		}
 	}
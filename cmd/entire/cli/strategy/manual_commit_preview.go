@@ -0,0 +1,230 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// PreviewAttribution computes the attribution each session in the current worktree
+// would receive if the user committed now, without creating a commit or touching
+// session state.
+//
+// The calculation is the same one PostCommit runs during condensation
+// (CalculateAttributionWithAccumulated), but the committed tree is replaced by a
+// candidate tree built from the index (or, with includeWorktree, the index plus
+// all worktree changes). Building the candidate tree writes blob and tree objects
+// to the object store; they are unreferenced and reclaimed by git gc.
+//
+// Sessions without a shadow branch (no checkpoints yet) are returned with a nil
+// Attribution so callers can explain why there is nothing to preview.
+func (s *ManualCommitStrategy) PreviewAttribution(includeWorktree bool) ([]AttributionPreview, error) {
+	logCtx := logging.WithComponent(context.Background(), "attribution")
+
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	worktreePath, err := GetWorktreePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree path: %w", err)
+	}
+
+	sessions, err := s.findSessionsForWorktree(worktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	candidateTree, err := buildCommitCandidateTree(repo, includeWorktree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build candidate commit tree: %w", err)
+	}
+
+	previews := make([]AttributionPreview, 0, len(sessions))
+	granularity := configuredAttributionGranularity()
+	for _, state := range sessions {
+		preview := AttributionPreview{
+			SessionID:    state.SessionID,
+			AgentType:    state.AgentType,
+			BaseCommit:   state.AttributionBaseCommit,
+			StepCount:    state.StepCount,
+			FilesTouched: state.FilesTouched,
+		}
+		if preview.BaseCommit == "" {
+			preview.BaseCommit = state.BaseCommit
+		}
+
+		shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+		ref, refErr := repo.Reference(checkpoint.ShadowRefName(repo, shadowBranchName), true)
+		if refErr != nil {
+			logging.Debug(logCtx, "attribution preview: no shadow branch for session",
+				slog.String("session_id", state.SessionID),
+				slog.String("shadow_branch", shadowBranchName))
+			previews = append(previews, preview)
+			continue
+		}
+		preview.CheckpointCommit = ref.Hash().String()
+
+		shadowCommit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shadow commit for session %s: %w", state.SessionID, err)
+		}
+		shadowTree, err := shadowCommit.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shadow tree for session %s: %w", state.SessionID, err)
+		}
+
+		// A missing base tree is tolerated the same way condensation tolerates it:
+		// every line in the checkpoint is then counted as new.
+		var baseTree *object.Tree
+		if baseCommit, baseErr := repo.CommitObject(plumbing.NewHash(preview.BaseCommit)); baseErr == nil {
+			if tree, treeErr := baseCommit.Tree(); treeErr == nil {
+				baseTree = tree
+			}
+		}
+
+		preview.Attribution = CalculateAttributionWithAccumulated(
+			granularity,
+			baseTree,
+			shadowTree,
+			candidateTree,
+			state.FilesTouched,
+			state.PromptAttributions,
+		)
+		previews = append(previews, preview)
+	}
+
+	return previews, nil
+}
+
+// buildCommitCandidateTree builds the tree a commit made right now would record.
+// Without includeWorktree this mirrors the index (what `git commit` records).
+// With includeWorktree, every modified, deleted and untracked (non-ignored) file
+// in the worktree is overlaid on top of the index, roughly `git add -A && git commit`.
+// Paths under .entire/ are never included.
+func buildCommitCandidateTree(repo *git.Repository, includeWorktree bool) (*object.Tree, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	entries := make(map[string]object.TreeEntry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		// Skip unmerged entries; a conflicted index can't be committed as-is anyway.
+		// Resolved entries have stage 0 on disk (go-git's index.Merged constant is 1,
+		// which collides with AncestorMode, so compare against zero explicitly).
+		if e.Stage != 0 {
+			continue
+		}
+		if isEntireMetadataPath(e.Name) {
+			continue
+		}
+		entries[e.Name] = object.TreeEntry{Name: e.Name, Mode: e.Mode, Hash: e.Hash}
+	}
+
+	if includeWorktree {
+		if err := overlayWorktreeChanges(repo, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	treeHash, err := checkpoint.BuildTreeFromEntries(repo, entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tree: %w", err)
+	}
+	tree, err := repo.TreeObject(treeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built tree: %w", err)
+	}
+	return tree, nil
+}
+
+// overlayWorktreeChanges updates entries with the current worktree content of every
+// file git status reports as changed in the worktree.
+func overlayWorktreeChanges(repo *git.Repository, entries map[string]object.TreeEntry) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	// Status paths are relative to the repository root, not the CWD.
+	worktreeRoot := worktree.Filesystem.Root()
+
+	for filePath, fileStatus := range status {
+		if fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+		if isEntireMetadataPath(filePath) {
+			continue
+		}
+
+		absPath := filepath.Join(worktreeRoot, filePath)
+		info, statErr := os.Lstat(absPath)
+		if errors.Is(statErr, os.ErrNotExist) || fileStatus.Worktree == git.Deleted {
+			delete(entries, filePath)
+			continue
+		}
+		if statErr != nil {
+			return fmt.Errorf("failed to stat %s: %w", filePath, statErr)
+		}
+
+		var content []byte
+		mode := filemode.Regular
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, linkErr := os.Readlink(absPath)
+			if linkErr != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", filePath, linkErr)
+			}
+			content = []byte(target)
+			mode = filemode.Symlink
+		case info.IsDir():
+			// Untracked directories that git status reports as a single entry
+			// (e.g. nested repositories) have no blob representation.
+			continue
+		default:
+			data, readErr := os.ReadFile(absPath) //nolint:gosec // filePath is from git worktree status
+			if readErr != nil {
+				return fmt.Errorf("failed to read %s: %w", filePath, readErr)
+			}
+			content = data
+			if info.Mode()&0o111 != 0 {
+				mode = filemode.Executable
+			}
+		}
+
+		blobHash, blobErr := checkpoint.CreateBlobFromContent(repo, content)
+		if blobErr != nil {
+			return fmt.Errorf("failed to create blob for %s: %w", filePath, blobErr)
+		}
+		entries[filePath] = object.TreeEntry{Name: filePath, Mode: mode, Hash: blobHash}
+	}
+	return nil
+}
+
+// isEntireMetadataPath reports whether a repo-relative path belongs to Entire's own
+// .entire/ directory, which is never part of a user commit.
+func isEntireMetadataPath(filePath string) bool {
+	return strings.HasPrefix(filePath, paths.EntireDir+"/")
+}
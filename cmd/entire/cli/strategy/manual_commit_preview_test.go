@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreviewAttribution_StagedVsWorktree verifies that the preview uses the index
+// by default and the worktree when requested, matching what a commit would record.
+func TestPreviewAttribution_StagedVsWorktree(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-preview-session"
+	require.NoError(t, s.InitializeSession(sessionID, "Claude Code", "", ""))
+	setupSessionWithFileChange(t, s, repo, dir, sessionID)
+
+	// Nothing staged yet: the candidate commit matches HEAD, so no agent lines land.
+	previews, err := s.PreviewAttribution(false)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	require.NotNil(t, previews[0].Attribution)
+	assert.Equal(t, sessionID, previews[0].SessionID)
+	assert.Equal(t, 0, previews[0].Attribution.AgentLines)
+	assert.NotEmpty(t, previews[0].CheckpointCommit)
+
+	// Including the worktree picks up the agent's unstaged edit.
+	previews, err = s.PreviewAttribution(true)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	require.NotNil(t, previews[0].Attribution)
+	worktreeAgentLines := previews[0].Attribution.AgentLines
+	assert.Positive(t, worktreeAgentLines)
+	assert.Equal(t, 0, previews[0].Attribution.HumanAdded)
+
+	// Staging the edit makes the default (index) preview agree.
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("test.txt")
+	require.NoError(t, err)
+
+	previews, err = s.PreviewAttribution(false)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	require.NotNil(t, previews[0].Attribution)
+	assert.Equal(t, worktreeAgentLines, previews[0].Attribution.AgentLines)
+}
+
+// TestPreviewAttribution_HumanEditsInWorktree verifies that lines the user adds on
+// top of the agent's checkpoint are attributed to the human.
+func TestPreviewAttribution_HumanEditsInWorktree(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-preview-human"
+	require.NoError(t, s.InitializeSession(sessionID, "Claude Code", "", ""))
+	setupSessionWithFileChange(t, s, repo, dir, sessionID)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "human.txt"), []byte("one\ntwo\n"), 0o644))
+
+	previews, err := s.PreviewAttribution(true)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	a := previews[0].Attribution
+	require.NotNil(t, a)
+	assert.Positive(t, a.AgentLines)
+	assert.Equal(t, 2, a.HumanAdded)
+	assert.Equal(t, a.AgentLines+2, a.TotalCommitted)
+}
+
+// TestPreviewAttribution_DoesNotModifyRepo verifies the preview leaves HEAD, the
+// index and session state untouched.
+func TestPreviewAttribution_DoesNotModifyRepo(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-preview-readonly"
+	require.NoError(t, s.InitializeSession(sessionID, "Claude Code", "", ""))
+	setupSessionWithFileChange(t, s, repo, dir, sessionID)
+
+	headBefore, err := repo.Head()
+	require.NoError(t, err)
+	stateBefore, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+
+	_, err = s.PreviewAttribution(true)
+	require.NoError(t, err)
+
+	headAfter, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, headBefore.Hash(), headAfter.Hash())
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	status, err := wt.Status()
+	require.NoError(t, err)
+	assert.Equal(t, git.Unmodified, status.File("test.txt").Staging, "index should not change")
+
+	stateAfter, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, stateBefore.StepCount, stateAfter.StepCount)
+	assert.Equal(t, stateBefore.Phase, stateAfter.Phase)
+}
+
+// TestPreviewAttribution_NoShadowBranch verifies sessions without checkpoints are
+// reported with a nil Attribution rather than an error.
+func TestPreviewAttribution_NoShadowBranch(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-preview-no-shadow"
+	require.NoError(t, s.InitializeSession(sessionID, "Claude Code", "", ""))
+
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	shadowBranch := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+	_, refErr := repo.Reference(plumbing.NewBranchReferenceName(shadowBranch), true)
+	require.Error(t, refErr, "precondition: no shadow branch yet")
+
+	previews, err := s.PreviewAttribution(false)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	assert.Nil(t, previews[0].Attribution)
+	assert.Empty(t, previews[0].CheckpointCommit)
+}
+
+func TestIsEntireMetadataPath(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isEntireMetadataPath(".entire/metadata/abc/full.jsonl"))
+	assert.True(t, isEntireMetadataPath(".entire/settings.json"))
+	assert.False(t, isEntireMetadataPath(".entirely/file.go"))
+	assert.False(t, isEntireMetadataPath("src/.entire/file.go"))
+}
@@ -4,9 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/entireio/cli/cmd/entire/cli/paths"
-
-	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 )
 
 // isAccessibleMode returns true if accessibility mode should be enabled.
@@ -34,7 +32,7 @@ func (s *ManualCommitStrategy) Reset() error {
 	if err != nil {
 		return fmt.Errorf("failed to get worktree path: %w", err)
 	}
-	worktreeID, err := paths.GetWorktreeID(worktreePath)
+	worktreeID, err := ShadowWorktreeID(worktreePath)
 	if err != nil {
 		return fmt.Errorf("failed to get worktree ID: %w", err)
 	}
@@ -43,7 +41,7 @@ func (s *ManualCommitStrategy) Reset() error {
 	shadowBranchName := getShadowBranchNameForCommit(head.Hash().String(), worktreeID)
 
 	// Check if shadow branch exists
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := checkpoint.ShadowRefName(repo, shadowBranchName)
 	_, err = repo.Reference(refName, true)
 	hasShadowBranch := err == nil
 
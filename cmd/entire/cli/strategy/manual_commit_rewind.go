@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
@@ -14,7 +15,9 @@ import (
 	cpkg "github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
 	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
 	"github.com/entireio/cli/cmd/entire/cli/trailers"
+	"github.com/entireio/cli/cmd/entire/cli/validation"
 
 	"github.com/charmbracelet/huh"
 	"github.com/go-git/go-git/v5"
@@ -77,6 +80,7 @@ func (s *ManualCommitStrategy) GetRewindPoints(limit int) ([]RewindPoint, error)
 				Date:             cp.Timestamp,
 				IsTaskCheckpoint: cp.IsTaskCheckpoint,
 				ToolUseID:        cp.ToolUseID,
+				IsMetadataOnly:   cp.MetadataOnly,
 				SessionID:        cp.SessionID,
 				SessionPrompt:    sessionPrompt,
 				Agent:            state.AgentType,
@@ -262,6 +266,10 @@ func (s *ManualCommitStrategy) Rewind(point RewindPoint) error {
 		return fmt.Errorf("failed to get commit: %w", err)
 	}
 
+	if trailers.IsMetadataOnly(commit.Message) {
+		return fmt.Errorf("checkpoint %s: %w", commitHash.String()[:7], ErrMetadataOnlyCheckpoint)
+	}
+
 	tree, err := commit.Tree()
 	if err != nil {
 		return fmt.Errorf("failed to get tree: %w", err)
@@ -288,6 +296,8 @@ func (s *ManualCommitStrategy) Rewind(point RewindPoint) error {
 	}
 
 	// Build set of files in the checkpoint tree (excluding metadata)
+	ignore := configuredEntireIgnore()
+	skipped := cpkg.ReadSkippedFiles(tree)
 	checkpointFiles := make(map[string]bool)
 	err = tree.Files().ForEach(func(f *object.File) error {
 		if !strings.HasPrefix(f.Name, entireDir) {
@@ -339,6 +349,7 @@ func (s *ManualCommitStrategy) Rewind(point RewindPoint) error {
 		if relErr != nil {
 			return nil //nolint:nilerr // Skip paths we can't make relative
 		}
+		relPath = filepath.ToSlash(relPath) // Tree paths use forward slashes
 
 		// Skip directories and protected paths
 		if info.IsDir() {
@@ -368,6 +379,12 @@ func (s *ManualCommitStrategy) Rewind(point RewindPoint) error {
 			return nil
 		}
 
+		// Checkpoints don't capture files .entireignore ignores or skip for
+		// their size
+		if _, ok := skipped[relPath]; ok || ignore.Match(relPath) {
+			return nil
+		}
+
 		// File is untracked and not in checkpoint - delete it (use absolute path)
 		if removeErr := os.Remove(path); removeErr == nil {
 			fmt.Fprintf(os.Stderr, "  Deleted: %s\n", relPath)
@@ -386,10 +403,35 @@ func (s *ManualCommitStrategy) Rewind(point RewindPoint) error {
 		if strings.HasPrefix(f.Name, entireDir) {
 			return nil
 		}
+		// Ignored files weren't captured: the tree has their base contents
+		if ignore.Match(f.Name) {
+			return nil
+		}
+		// Windows would write a file named like a device (aux.c) to the device
+		if runtime.GOOS == "windows" {
+			if err := validation.ValidatePortablePath(f.Name); err != nil {
+				fmt.Fprintf(os.Stderr, "  Warning: not restoring %s: %v\n", f.Name, err)
+				return nil
+			}
+		}
+		// Files skipped for their size: the tree has an older version
+		if sf, ok := skipped[f.Name]; ok {
+			fmt.Fprintf(os.Stderr, "  Skipped: %s (%s, over the checkpoint size limits)\n", f.Name, settings.FormatByteSize(sf.Size))
+			return nil
+		}
 
-		contents, err := f.Contents()
+		contents, err := cpkg.FileContents(tree, f)
 		if err != nil {
-			return fmt.Errorf("failed to read file %s: %w", f.Name, err)
+			return err //nolint:wrapcheck // already names the file
+		}
+		// Git LFS files are stored as pointers: restore the object they point to
+		if p, ok := cpkg.ParseLFSPointer(contents); ok {
+			object, readErr := cpkg.ReadLFSObject(repoRoot, p)
+			if readErr != nil {
+				fmt.Fprintf(os.Stderr, "  Warning: not restoring %s: %v\n", f.Name, readErr)
+				return nil
+			}
+			contents = string(object)
 		}
 
 		// Ensure directory exists
@@ -449,7 +491,7 @@ func (s *ManualCommitStrategy) resetShadowBranchToCheckpoint(repo *git.Repositor
 
 	// Reset the shadow branch to the checkpoint commit
 	shadowBranchName := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-	refName := plumbing.NewBranchReferenceName(shadowBranchName)
+	refName := cpkg.ShadowRefName(repo, shadowBranchName)
 
 	// Update the reference to point to the checkpoint commit
 	ref := plumbing.NewHashReference(refName, commit.Hash)
@@ -508,12 +550,16 @@ func (s *ManualCommitStrategy) PreviewRewind(point RewindPoint) (*RewindPreview,
 	}
 
 	// Build set of files in the checkpoint tree (excluding metadata)
+	ignore := configuredEntireIgnore()
+	skipped := cpkg.ReadSkippedFiles(tree)
 	checkpointFiles := make(map[string]bool)
 	var filesToRestore []string
 	err = tree.Files().ForEach(func(f *object.File) error {
 		if !strings.HasPrefix(f.Name, entireDir) {
 			checkpointFiles[f.Name] = true
-			filesToRestore = append(filesToRestore, f.Name)
+			if _, ok := skipped[f.Name]; !ok && !ignore.Match(f.Name) {
+				filesToRestore = append(filesToRestore, f.Name)
+			}
 		}
 		return nil
 	})
@@ -560,6 +606,7 @@ func (s *ManualCommitStrategy) PreviewRewind(point RewindPoint) (*RewindPreview,
 		if relErr != nil {
 			return nil //nolint:nilerr // Skip paths we can't make relative
 		}
+		relPath = filepath.ToSlash(relPath) // Tree paths use forward slashes
 
 		// Skip directories and protected paths
 		if info.IsDir() {
@@ -589,6 +636,12 @@ func (s *ManualCommitStrategy) PreviewRewind(point RewindPoint) (*RewindPreview,
 			return nil
 		}
 
+		// Checkpoints don't capture files .entireignore ignores or skip for
+		// their size
+		if _, ok := skipped[relPath]; ok || ignore.Match(relPath) {
+			return nil
+		}
+
 		// File is untracked and not in checkpoint - will be deleted
 		filesToDelete = append(filesToDelete, relPath)
 		return nil
@@ -0,0 +1,140 @@
+package strategy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// A rebase or a branch switch leaves sessions based on a commit HEAD no
+// longer points at. migrateShadowBranchIfNeeded catches up on the next
+// prompt or checkpoint; the post-rewrite and post-checkout hooks move the
+// shadow branches and BaseCommit as soon as history changes, so commits made
+// in between still find their sessions.
+
+// rewriteTypeRebase is the post-rewrite hook's argument after git rebase.
+const rewriteTypeRebase = "rebase"
+
+// CommitRewrite is a commit git replaced, with its replacement.
+type CommitRewrite struct {
+	Old, New string
+}
+
+// ParseCommitRewrites reads the post-rewrite hook's stdin:
+// "<old sha> <new sha> [<extra info>]" per line.
+func ParseCommitRewrites(r io.Reader) []CommitRewrite {
+	var rewrites []CommitRewrite
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		rewrites = append(rewrites, CommitRewrite{Old: fields[0], New: fields[1]})
+	}
+	return rewrites
+}
+
+// PostRewrite moves the sessions based on a rewritten commit to its
+// replacement. After a rebase, other sessions that aren't ended move to
+// HEAD, as the next prompt would move them.
+func (s *ManualCommitStrategy) PostRewrite(rewriteType string, rewrites []CommitRewrite) error {
+	logCtx := logging.WithComponent(context.Background(), "migration")
+
+	repo, sessions := s.worktreeSessions()
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	replacements := make(map[string]string, len(rewrites))
+	for _, rewrite := range rewrites {
+		replacements[rewrite.Old] = rewrite.New
+	}
+
+	for _, state := range sessions {
+		changes := s.trackSessionState(state)
+		var moved bool
+		var err error
+		if newBase, ok := replacements[state.BaseCommit]; ok {
+			moved, err = s.moveShadowBranch(repo, state, newBase, "base commit rewritten by "+rewriteType)
+		} else if rewriteType == rewriteTypeRebase && state.Phase != session.PhaseEnded {
+			moved, err = s.migrateShadowBranchIfNeeded(repo, state)
+		}
+		s.saveMovedSession(logCtx, "post-rewrite", changes, moved, err)
+	}
+	return nil
+}
+
+// PostCheckout moves the sessions that aren't ended to the checked out
+// commit. Their last checkpoint belongs to the branch they came from, so a
+// commit on this one doesn't reuse it.
+func (s *ManualCommitStrategy) PostCheckout(previousHead, newHead string, branchCheckout bool) error {
+	// A rebase checks out its onto commit first; post-rewrite moves sessions
+	// once it's done
+	if !branchCheckout || previousHead == newHead || isGitSequenceOperation() {
+		return nil
+	}
+	logCtx := logging.WithComponent(context.Background(), "migration")
+
+	repo, sessions := s.worktreeSessions()
+	for _, state := range sessions {
+		if state.Phase == session.PhaseEnded {
+			continue
+		}
+		changes := s.trackSessionState(state)
+		moved, err := s.moveShadowBranch(repo, state, newHead, "branch checked out")
+		if moved {
+			state.LastCheckpointID = ""
+		}
+		s.saveMovedSession(logCtx, "post-checkout", changes, moved, err)
+	}
+	return nil
+}
+
+// worktreeSessions opens the repository and returns the sessions of the
+// current worktree, none if either fails.
+func (s *ManualCommitStrategy) worktreeSessions() (*git.Repository, []*SessionState) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, nil
+	}
+	worktreePath, err := GetWorktreePath()
+	if err != nil {
+		return nil, nil
+	}
+	sessions, err := s.findSessionsForWorktree(worktreePath)
+	if err != nil {
+		return nil, nil
+	}
+	return repo, sessions
+}
+
+// saveMovedSession saves the fields the move changed if the session's shadow
+// branch moved, leaving what agent hooks saved meanwhile alone, and logs
+// failures of the move (err) or the save. Hooks must be silent on failure.
+func (s *ManualCommitStrategy) saveMovedSession(logCtx context.Context, hookName string, changes *SessionStateChanges, moved bool, err error) {
+	state := changes.state
+	if err != nil {
+		logging.Warn(logCtx, hookName+": shadow branch migration failed",
+			slog.String("session_id", state.SessionID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if !moved {
+		return
+	}
+	if err := changes.Save(); err != nil {
+		logging.Warn(logCtx, hookName+": failed to update session state after migration",
+			slog.String("session_id", state.SessionID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
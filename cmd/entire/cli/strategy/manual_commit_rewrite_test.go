@@ -0,0 +1,145 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitRewrites(t *testing.T) {
+	t.Parallel()
+	rewrites := ParseCommitRewrites(strings.NewReader("aaa bbb\nccc ddd extra info\n\nbroken\n"))
+	assert.Equal(t, []CommitRewrite{{Old: "aaa", New: "bbb"}, {Old: "ccc", New: "ddd"}}, rewrites)
+}
+
+// commitTestFile commits content to test.txt and returns the new commit.
+func commitTestFile(t *testing.T, repo *git.Repository, dir, content string) plumbing.Hash {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte(content), 0o644))
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("test.txt")
+	require.NoError(t, err)
+	hash, err := wt.Commit(content, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	return hash
+}
+
+func TestPostRewrite_MovesSessionsToReplacements(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	s := &ManualCommitStrategy{}
+	const sessionID = "test-post-rewrite"
+	setupSessionWithCheckpoint(t, s, repo, dir, sessionID)
+
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	state.Phase = session.PhaseIdle
+	require.NoError(t, s.saveSessionState(state))
+	oldBase := state.BaseCommit
+	oldBranch := getShadowBranchNameForCommit(oldBase, state.WorktreeID)
+	require.True(t, shadowBranchExists(repo, oldBranch))
+
+	// An unrelated rewrite leaves the session alone
+	newBase := commitTestFile(t, repo, dir, "amended")
+	require.NoError(t, s.PostRewrite("amend", []CommitRewrite{{Old: strings.Repeat("a", 40), New: newBase.String()}}))
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, oldBase, state.BaseCommit)
+
+	require.NoError(t, s.PostRewrite("amend", []CommitRewrite{{Old: oldBase, New: newBase.String()}}))
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, newBase.String(), state.BaseCommit)
+	assert.False(t, shadowBranchExists(repo, oldBranch), "old shadow branch should be gone")
+	assert.True(t, shadowBranchExists(repo, getShadowBranchNameForCommit(newBase.String(), state.WorktreeID)))
+	assert.Equal(t, oldBase, state.AttributionBaseCommit, "attribution base only moves on condensation")
+
+	// After a rebase, a session whose base wasn't rewritten follows HEAD
+	head := commitTestFile(t, repo, dir, "rebased")
+	require.NoError(t, s.PostRewrite("rebase", nil))
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, head.String(), state.BaseCommit)
+}
+
+func TestPostCheckout_MovesSessionsToNewHead(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	s := &ManualCommitStrategy{}
+	const sessionID = "test-post-checkout"
+	setupSessionWithCheckpoint(t, s, repo, dir, sessionID)
+
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	state.Phase = session.PhaseIdle
+	state.LastCheckpointID = "a1b2c3d4e5f6"
+	require.NoError(t, s.saveSessionState(state))
+	oldBase := state.BaseCommit
+
+	newHead := commitTestFile(t, repo, dir, "other branch")
+
+	// File checkouts don't move HEAD
+	require.NoError(t, s.PostCheckout(oldBase, oldBase, false))
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, oldBase, state.BaseCommit)
+
+	require.NoError(t, s.PostCheckout(oldBase, newHead.String(), true))
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, newHead.String(), state.BaseCommit)
+	assert.True(t, shadowBranchExists(repo, getShadowBranchNameForCommit(newHead.String(), state.WorktreeID)))
+	assert.True(t, state.LastCheckpointID.IsEmpty(), "the last checkpoint belongs to the previous branch")
+
+	// Ended sessions stay where they ended
+	state.Phase = session.PhaseEnded
+	require.NoError(t, s.saveSessionState(state))
+	require.NoError(t, s.PostCheckout(newHead.String(), oldBase, true))
+	state, err = s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, newHead.String(), state.BaseCommit)
+}
+
+func TestSaveMovedSession_KeepsConcurrentHookSaves(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	s := &ManualCommitStrategy{}
+	const sessionID = "test-moved-session"
+	require.NoError(t, s.saveSessionState(&SessionState{SessionID: sessionID, BaseCommit: "old", StepCount: 1}))
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	changes := s.trackSessionState(state)
+
+	// An agent hook checkpoints while the git hook moves the session
+	require.NoError(t, s.updateSessionState(sessionID, func(other *SessionState) error {
+		other.StepCount = 2
+		return nil
+	}))
+	state.BaseCommit = "new"
+	s.saveMovedSession(t.Context(), "post-rewrite", changes, true, nil)
+
+	loaded, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "new", loaded.BaseCommit)
+	assert.Equal(t, 2, loaded.StepCount, "the hook's checkpoint was overwritten")
+}
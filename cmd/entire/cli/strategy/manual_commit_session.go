@@ -8,10 +8,9 @@ import (
 	"github.com/entireio/cli/cmd/entire/cli/agent"
 	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
-	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
 )
 
 // Shadow strategy session state methods.
@@ -42,6 +41,22 @@ func (s *ManualCommitStrategy) saveSessionState(state *SessionState) error {
 	return nil
 }
 
+// updateSessionState applies fn to session state under the state lock (see
+// UpdateSessionState).
+func (s *ManualCommitStrategy) updateSessionState(sessionID string, fn func(*SessionState) error) error {
+	store, err := s.getStateStore()
+	if err != nil {
+		return err
+	}
+	return updateSessionStateIn(store, sessionID, fn)
+}
+
+// trackSessionState starts tracking changes to a just loaded session state
+// (see TrackSessionState).
+func (s *ManualCommitStrategy) trackSessionState(state *SessionState) *SessionStateChanges {
+	return trackSessionState(state, s.getStateStore)
+}
+
 // clearSessionState clears session state using the StateStore.
 func (s *ManualCommitStrategy) clearSessionState(sessionID string) error {
 	store, err := s.getStateStore()
@@ -86,7 +101,7 @@ func (s *ManualCommitStrategy) listAllSessionStates() ([]*SessionState, error) {
 		// Clean up everything else: stale pre-state-machine sessions (empty phase),
 		// IDLE/ENDED sessions that were never condensed, etc.
 		shadowBranch := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
-		refName := plumbing.NewBranchReferenceName(shadowBranch)
+		refName := checkpoint.ShadowRefName(repo, shadowBranch)
 		if _, err := repo.Reference(refName, true); err != nil {
 			if !state.Phase.IsActive() && state.LastCheckpointID.IsEmpty() {
 				//nolint:errcheck,gosec // G104: Cleanup is best-effort, shouldn't fail the list operation
@@ -203,7 +218,7 @@ func (s *ManualCommitStrategy) initializeSession(repo *git.Repository, sessionID
 	}
 
 	// Get worktree ID for shadow branch naming
-	worktreeID, err := paths.GetWorktreeID(worktreePath)
+	worktreeID, err := ShadowWorktreeID(worktreePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get worktree ID: %w", err)
 	}
@@ -229,8 +244,11 @@ func (s *ManualCommitStrategy) initializeSession(repo *git.Repository, sessionID
 		StepCount:             0,
 		UntrackedFilesAtStart: untrackedFiles,
 		AgentType:             agentType,
+		Automation:            detectAutomation(repo),
 		TranscriptPath:        transcriptPath,
 		FirstPrompt:           truncatePromptForStorage(userPrompt),
+		Title:                 session.DeriveTitle(userPrompt),
+		TaskType:              classifyTaskType(userPrompt),
 	}
 
 	if err := s.saveSessionState(state); err != nil {
@@ -1,10 +1,12 @@
 package strategy
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -1805,6 +1807,48 @@ func TestInitializeSession_BackfillsUnknownAgentType(t *testing.T) {
 	}
 }
 
+// TestInitializeSession_TagsTaskType tests that the session is tagged with the
+// task type of its prompts, and that an "other" tag is replaced by a later prompt.
+func TestInitializeSession_TagsTaskType(t *testing.T) {
+	dir := t.TempDir()
+	initTestRepo(t, dir)
+
+	t.Chdir(dir)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "2026-10-14-task-type"
+
+	taskType := func() string {
+		t.Helper()
+		state, err := s.loadSessionState(sessionID)
+		if err != nil {
+			t.Fatalf("failed to load session state: %v", err)
+		}
+		return state.TaskType
+	}
+
+	if err := s.InitializeSession(sessionID, agent.AgentTypeClaudeCode, "", "hello there"); err != nil {
+		t.Fatalf("InitializeSession() error = %v", err)
+	}
+	if got := taskType(); got != "other" {
+		t.Errorf("TaskType = %q, want other", got)
+	}
+
+	if err := s.InitializeSession(sessionID, agent.AgentTypeClaudeCode, "", "fix the crash on empty input"); err != nil {
+		t.Fatalf("InitializeSession() second call error = %v", err)
+	}
+	if got := taskType(); got != "bugfix" {
+		t.Errorf("TaskType = %q, want bugfix", got)
+	}
+
+	if err := s.InitializeSession(sessionID, agent.AgentTypeClaudeCode, "", "now update the README"); err != nil {
+		t.Fatalf("InitializeSession() third call error = %v", err)
+	}
+	if got := taskType(); got != "bugfix" {
+		t.Errorf("TaskType = %q, want bugfix kept", got)
+	}
+}
+
 // TestCountTranscriptItems tests counting lines/messages in different transcript formats.
 func TestCountTranscriptItems(t *testing.T) {
 	tests := []struct {
@@ -2082,6 +2126,7 @@ func TestCondenseSession_IncludesInitialAttribution(t *testing.T) {
 			HumanRemoved    int     `json:"human_removed"`
 			TotalCommitted  int     `json:"total_committed"`
 			AgentPercentage float64 `json:"agent_percentage"`
+			BaseCommit      string  `json:"base_commit"`
 		} `json:"initial_attribution"`
 	}
 	if err := json.Unmarshal([]byte(content), &metadata); err != nil {
@@ -2091,6 +2136,9 @@ func TestCondenseSession_IncludesInitialAttribution(t *testing.T) {
 	if metadata.InitialAttribution == nil {
 		t.Fatal("InitialAttribution should be present in session metadata.json for manual-commit")
 	}
+	if metadata.InitialAttribution.BaseCommit == "" {
+		t.Error("BaseCommit should record the commit attribution was measured from")
+	}
 
 	// Verify the attribution values are reasonable
 	// Agent added new function, human added a comment line
@@ -2948,3 +2996,116 @@ func TestCondenseSession_GeminiMultiCheckpoint(t *testing.T) {
 		t.Error("Prompts should contain second prompt")
 	}
 }
+
+// TestSaveChanges_RecordsCheckpointEpoch verifies that each checkpoint is rolled into
+// the session's current epoch and that the epoch bounds can be walked on the shadow branch.
+func TestSaveChanges_RecordsCheckpointEpoch(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-epoch-session"
+	if err := s.InitializeSession(sessionID, "Claude Code", "", ""); err != nil {
+		t.Fatalf("InitializeSession() error = %v", err)
+	}
+	setupSessionWithFileChange(t, s, repo, dir, sessionID)
+
+	state, err := s.loadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("loadSessionState() error = %v", err)
+	}
+	if len(state.CheckpointEpochs) != 1 {
+		t.Fatalf("CheckpointEpochs = %d, want 1", len(state.CheckpointEpochs))
+	}
+	epoch := state.CheckpointEpochs[0]
+	if epoch.FirstStep != 1 || epoch.LastStep != 1 {
+		t.Errorf("epoch steps = %d-%d, want 1-1", epoch.FirstStep, epoch.LastStep)
+	}
+	if len(epoch.FilesTouched) != 1 || epoch.FilesTouched[0] != "test.txt" {
+		t.Errorf("epoch FilesTouched = %v, want [test.txt]", epoch.FilesTouched)
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(
+		getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)), true)
+	if err != nil {
+		t.Fatalf("shadow branch not found: %v", err)
+	}
+	if epoch.LastCommit != ref.Hash().String() || epoch.FirstCommit != epoch.LastCommit {
+		t.Errorf("epoch commits = %s..%s, want both %s", epoch.FirstCommit, epoch.LastCommit, ref.Hash())
+	}
+
+	store := checkpoint.NewGitStore(repo)
+	points, err := store.ListCheckpointsInRange(context.Background(),
+		plumbing.NewHash(epoch.LastCommit), plumbing.NewHash(epoch.FirstCommit), sessionID)
+	if err != nil {
+		t.Fatalf("ListCheckpointsInRange() error = %v", err)
+	}
+	if len(points) != 1 || points[0].CommitHash != ref.Hash() {
+		t.Errorf("ListCheckpointsInRange() = %+v, want the shadow branch tip", points)
+	}
+}
+
+func TestSaveTaskCheckpoint_RecordsCheckpointEpoch(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-task-epoch-session"
+	if err := s.InitializeSession(sessionID, "Claude Code", "", ""); err != nil {
+		t.Fatalf("InitializeSession() error = %v", err)
+	}
+	setupSessionWithFileChange(t, s, repo, dir, sessionID)
+
+	if err := os.WriteFile(filepath.Join(dir, "task_output.txt"), []byte("task result"), 0o644); err != nil {
+		t.Fatalf("failed to write task output: %v", err)
+	}
+	transcriptPath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(transcriptPath, []byte(`{"type":"test"}`), 0o644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	if err := s.SaveTaskCheckpoint(TaskCheckpointContext{
+		SessionID:      sessionID,
+		ToolUseID:      "toolu_epoch123",
+		CheckpointUUID: "checkpoint-uuid-epoch",
+		TranscriptPath: transcriptPath,
+		NewFiles:       []string{"task_output.txt"},
+		AuthorName:     "Test",
+		AuthorEmail:    "test@test.com",
+	}); err != nil {
+		t.Fatalf("SaveTaskCheckpoint() error = %v", err)
+	}
+
+	state, err := s.loadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("loadSessionState() error = %v", err)
+	}
+	if len(state.CheckpointEpochs) != 1 {
+		t.Fatalf("CheckpointEpochs = %d, want 1", len(state.CheckpointEpochs))
+	}
+	epoch := state.CheckpointEpochs[0]
+	if epoch.Count() != 1 || epoch.TaskCheckpoints != 1 {
+		t.Errorf("epoch has %d checkpoints and %d task checkpoints, want 1 and 1", epoch.Count(), epoch.TaskCheckpoints)
+	}
+	if !slices.Equal(epoch.FilesTouched, []string{"task_output.txt", "test.txt"}) {
+		t.Errorf("epoch FilesTouched = %v, want [task_output.txt test.txt]", epoch.FilesTouched)
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(
+		getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)), true)
+	if err != nil {
+		t.Fatalf("shadow branch not found: %v", err)
+	}
+	if epoch.LastCommit != ref.Hash().String() || epoch.FirstCommit == epoch.LastCommit {
+		t.Errorf("epoch commits = %s..%s, want the task checkpoint %s last", epoch.FirstCommit, epoch.LastCommit, ref.Hash())
+	}
+}
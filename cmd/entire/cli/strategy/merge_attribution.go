@@ -0,0 +1,77 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// AttributionSkippedMerge is InitialAttribution.Skipped for merge commits
+// that weren't attributed.
+const AttributionSkippedMerge = "merge"
+
+// configuredMergeCommitAttribution returns attribution.merge_commits from
+// settings, falling back to first-parent if settings are missing or invalid.
+func configuredMergeCommitAttribution() string {
+	s, err := settings.Load()
+	if err != nil {
+		return settings.AttributionMergeFirstParent
+	}
+	m, err := s.Attribution.EffectiveMergeCommits()
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring attribution settings", slog.String("error", err.Error()))
+		return settings.AttributionMergeFirstParent
+	}
+	return m
+}
+
+// mergeAttributionBase returns what a merge commit is attributed against: the
+// tree of its first parent, and the files whose merged version came unchanged
+// from another parent. Those changes were made (and attributed) on the merged
+// branch, not in this commit.
+func mergeAttributionBase(merge *object.Commit, mergeTree *object.Tree) (*object.Tree, map[string]bool, error) {
+	firstParent, err := merge.Parent(0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read first parent: %w", err)
+	}
+	baseTree, err := firstParent.Tree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read first parent tree: %w", err)
+	}
+
+	changed := getAllChangedFilesBetweenTrees(baseTree, mergeTree)
+	notOurs := make(map[string]bool)
+	for i := 1; i < merge.NumParents(); i++ {
+		parent, err := merge.Parent(i)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read parent %d: %w", i+1, err)
+		}
+		parentTree, err := parent.Tree()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read parent %d tree: %w", i+1, err)
+		}
+		for _, path := range changed {
+			// Also matches files the merged branch deleted
+			if treeFileHash(parentTree, path) == treeFileHash(mergeTree, path) {
+				notOurs[path] = true
+			}
+		}
+	}
+	return baseTree, notOurs, nil
+}
+
+// treeFileHash returns the blob hash of path in tree, or the zero hash if
+// there is no such file.
+func treeFileHash(tree *object.Tree, path string) plumbing.Hash {
+	f, err := tree.File(path)
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return f.Hash
+}
@@ -0,0 +1,114 @@
+package strategy
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestMergeAttributionBase(t *testing.T) {
+	storage := memory.NewStorage()
+	base := storeTestCommit(t, storage, map[string]string{"main.go": "package main\n", "old.go": "old\n"})
+	// The current branch: the agent's work
+	ours := storeTestCommit(t, storage, map[string]string{"main.go": "package main\n\nfunc main() {}\n", "old.go": "old\n"}, base)
+	// The merged branch adds a file and deletes another
+	theirs := storeTestCommit(t, storage, map[string]string{"main.go": "package main\n", "lib.go": "package main\n\nfunc lib() {}\n"}, base)
+	mergeFiles := map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+		"lib.go":  "package main\n\nfunc lib() {}\n",
+		// Resolved by hand, so counts as this commit's
+		"notes.txt": "merged\n",
+	}
+	merge := storeTestCommit(t, storage, mergeFiles, ours, theirs)
+
+	mergeCommit, err := object.GetCommit(storage, merge)
+	if err != nil {
+		t.Fatalf("failed to read merge commit: %v", err)
+	}
+	mergeTree, err := mergeCommit.Tree()
+	if err != nil {
+		t.Fatalf("failed to read merge tree: %v", err)
+	}
+	baseTree, notOurs, err := mergeAttributionBase(mergeCommit, mergeTree)
+	if err != nil {
+		t.Fatalf("mergeAttributionBase() error = %v", err)
+	}
+	oursCommit, err := object.GetCommit(storage, ours)
+	if err != nil {
+		t.Fatalf("failed to read first parent: %v", err)
+	}
+	if baseTree.Hash != oursCommit.TreeHash {
+		t.Errorf("base tree = %s, want the first parent's %s", baseTree.Hash, oursCommit.TreeHash)
+	}
+	var got []string
+	for path := range notOurs {
+		got = append(got, path)
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "lib.go" || got[1] != "old.go" {
+		t.Errorf("files from the merged branch = %v, want [lib.go old.go]", got)
+	}
+
+	// The agent wrote nothing during the merge; the merged branch's lines
+	// don't count as the human's either
+	attribution := calculateAttribution(GranularityLine, baseTree, baseTree, mergeTree, nil, []string{"main.go"}, nil, notOurs)
+	if attribution == nil {
+		t.Fatal("expected non-nil attribution")
+	}
+	if attribution.HumanAdded != 1 || attribution.HumanRemoved != 0 {
+		t.Errorf("HumanAdded, HumanRemoved = %d, %d; want 1, 0 (notes.txt only)", attribution.HumanAdded, attribution.HumanRemoved)
+	}
+	for _, f := range attribution.Files {
+		if notOurs[f.Path] {
+			t.Errorf("merged file %s was attributed", f.Path)
+		}
+	}
+}
+
+// storeTestCommit stores a commit of files with the given parents.
+func storeTestCommit(t *testing.T, storage *memory.Storage, files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+	t.Helper()
+	var entries []object.TreeEntry
+	for path, content := range files {
+		obj := storage.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		writer, err := obj.Writer()
+		if err != nil {
+			t.Fatalf("failed to create blob writer: %v", err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write blob content: %v", err)
+		}
+		writer.Close()
+		hash, err := storage.SetEncodedObject(obj)
+		if err != nil {
+			t.Fatalf("failed to store blob: %v", err)
+		}
+		entries = append(entries, object.TreeEntry{Name: path, Mode: 0o100644, Hash: hash})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	treeObj := storage.NewEncodedObject()
+	if err := (&object.Tree{Entries: entries}).Encode(treeObj); err != nil {
+		t.Fatalf("failed to encode tree: %v", err)
+	}
+	treeHash, err := storage.SetEncodedObject(treeObj)
+	if err != nil {
+		t.Fatalf("failed to store tree: %v", err)
+	}
+
+	sig := object.Signature{Name: "Test", Email: "test@test.com"}
+	commitObj := storage.NewEncodedObject()
+	commit := &object.Commit{TreeHash: treeHash, ParentHashes: parents, Author: sig, Committer: sig, Message: "commit\n"}
+	if err := commit.Encode(commitObj); err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	hash, err := storage.SetEncodedObject(commitObj)
+	if err != nil {
+		t.Fatalf("failed to store commit: %v", err)
+	}
+	return hash
+}
@@ -0,0 +1,87 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+)
+
+// Agents record notes about their own work ("I assumed X", "TODO: revisit
+// Y") with the entire_note MCP tool. They are kept in the session state and
+// condensed into the next commit's checkpoint, so reviewers see them next to
+// the commit in `entire show` and `entire notes`.
+
+// maxNoteLength bounds a note's text, in bytes.
+const maxNoteLength = 2000
+
+// ErrNoSessionForNote is returned when a note has no session to go to.
+var ErrNoSessionForNote = errors.New("no session in progress in this worktree")
+
+// AddSessionNote records a note in the session sessionID, or the most
+// recently used session in this worktree if sessionID is empty. Kind defaults
+// to "note". Returns the session the note was added to.
+func AddSessionNote(ctx context.Context, sessionID, kind, text string, files []string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("note text is empty")
+	}
+	if len(text) > maxNoteLength {
+		return "", fmt.Errorf("note text is longer than %d bytes", maxNoteLength)
+	}
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		kind = session.NoteKindNote
+	}
+	if !slices.Contains(session.NoteKinds, kind) {
+		return "", fmt.Errorf("invalid note kind %q: use one of %s", kind, strings.Join(session.NoteKinds, ", "))
+	}
+	if sessionID == "" {
+		if sessionID = FindMostRecentSession(); sessionID == "" {
+			return "", ErrNoSessionForNote
+		}
+	}
+
+	store, err := sessionStateStore()
+	if err != nil {
+		return "", err
+	}
+	found := false
+	err = store.Update(ctx, sessionID, func(state *SessionState) (*SessionState, error) {
+		if state == nil {
+			return nil, nil //nolint:nilnil // reported below
+		}
+		found = true
+		state.Notes = append(state.Notes, session.Note{
+			At:    time.Now().UTC(),
+			Kind:  kind,
+			Text:  text,
+			Files: files,
+		})
+		return state, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to record note: %w", err)
+	}
+	if !found {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+	return sessionID, nil
+}
+
+// condensedNotes returns the session's notes for the committed checkpoint.
+func condensedNotes(state *SessionState) []checkpoint.Note {
+	if len(state.Notes) == 0 {
+		return nil
+	}
+	notes := make([]checkpoint.Note, 0, len(state.Notes))
+	for _, n := range state.Notes {
+		notes = append(notes, checkpoint.Note(n))
+	}
+	return notes
+}
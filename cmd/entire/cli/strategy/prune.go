@@ -0,0 +1,286 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// PruneOptions configures the retention policies for ListPruneCandidates.
+// Policies are combined: an item is pruned only if it matches every policy
+// that is set. At least one policy must be set.
+type PruneOptions struct {
+	// OlderThan prunes items whose most recent checkpoint is older than this.
+	// Zero disables the age policy.
+	OlderThan time.Duration
+
+	// KeepPerSession always keeps this many of the most recent items per session.
+	// Zero disables the count policy.
+	KeepPerSession int
+
+	// MergedInto restricts pruning to items whose work is already reachable from
+	// this branch: shadow branches whose base commit is behind the branch tip, and
+	// committed checkpoints referenced by a commit on the branch. Empty disables
+	// the merged policy.
+	MergedInto string
+
+	// IncludeCommitted also prunes condensed checkpoints on entire/checkpoints/v1.
+	// These are permanent history (linked from commit trailers), so they are only
+	// considered when explicitly requested.
+	IncludeCommitted bool
+
+	// Now is the reference time for the age policy. Zero means time.Now().
+	Now time.Time
+}
+
+// ErrNoPrunePolicy is returned when PruneOptions has no retention policy set.
+var ErrNoPrunePolicy = errors.New("no retention policy specified")
+
+// pruneCandidate is an item considered by the retention policies.
+type pruneCandidate struct {
+	Item      CleanupItem
+	SessionID string
+	Timestamp time.Time
+	Merged    bool // Only meaningful when PruneOptions.MergedInto is set
+	Protected bool // Belongs to an active session; never pruned
+}
+
+// ListPruneCandidates returns the shadow branches (and, with IncludeCommitted,
+// committed checkpoints) selected by the retention policies in opts.
+// Shadow branches belonging to sessions that are currently active are never selected.
+// The returned items can be deleted with DeleteAllCleanupItems.
+func ListPruneCandidates(opts PruneOptions) ([]CleanupItem, error) {
+	if opts.OlderThan <= 0 && opts.KeepPerSession <= 0 && opts.MergedInto == "" {
+		return nil, ErrNoPrunePolicy
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	var merged *mergedHistory
+	if opts.MergedInto != "" {
+		merged, err = loadMergedHistory(repo, opts.MergedInto)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates, err := listShadowPruneCandidates(repo, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.IncludeCommitted {
+		committed, err := listCommittedPruneCandidates(repo, merged)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, committed...)
+	}
+
+	return selectPruneCandidates(candidates, opts), nil
+}
+
+// listShadowPruneCandidates returns one candidate per shadow branch.
+func listShadowPruneCandidates(repo *git.Repository, merged *mergedHistory) ([]pruneCandidate, error) {
+	store := checkpoint.NewGitStore(repo)
+	temps, err := store.ListTemporary(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow branches: %w", err)
+	}
+
+	// Shadow branches of active sessions hold in-progress work.
+	protected := make(map[string]bool)
+	if states, listErr := ListSessionStates(); listErr == nil {
+		for _, state := range states {
+			if state.Phase.IsActive() {
+				protected[checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)] = true
+			}
+		}
+	}
+
+	candidates := make([]pruneCandidate, 0, len(temps))
+	for _, info := range temps {
+		if !IsShadowBranch(info.BranchName) {
+			continue
+		}
+		c := pruneCandidate{
+			Item: CleanupItem{
+				Type: CleanupTypeShadowBranch,
+				ID:   info.BranchName,
+			},
+			SessionID: info.SessionID,
+			Timestamp: info.Timestamp,
+			Protected: protected[info.BranchName],
+		}
+		if merged != nil {
+			c.Merged = merged.isBaseBehind(repo, info.BaseCommit)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// listCommittedPruneCandidates returns one candidate per committed checkpoint.
+func listCommittedPruneCandidates(repo *git.Repository, merged *mergedHistory) ([]pruneCandidate, error) {
+	store := checkpoint.NewGitStore(repo)
+	committed, err := store.ListCommitted(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	candidates := make([]pruneCandidate, 0, len(committed))
+	for _, info := range committed {
+		c := pruneCandidate{
+			Item: CleanupItem{
+				Type: CleanupTypeCheckpoint,
+				ID:   info.CheckpointID.String(),
+			},
+			SessionID: info.SessionID,
+			Timestamp: info.CreatedAt,
+		}
+		if merged != nil {
+			c.Merged = merged.checkpointIDs[info.CheckpointID.String()]
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// selectPruneCandidates applies the retention policies and fills in each
+// selected item's Reason.
+func selectPruneCandidates(candidates []pruneCandidate, opts PruneOptions) []CleanupItem {
+	// Rank items within each session (and type), newest first, for KeepPerSession.
+	rank := make(map[int]int, len(candidates))
+	if opts.KeepPerSession > 0 {
+		groups := make(map[string][]int)
+		for i, c := range candidates {
+			key := string(c.Item.Type) + "\x00" + c.SessionID
+			groups[key] = append(groups[key], i)
+		}
+		for _, idxs := range groups {
+			sort.SliceStable(idxs, func(a, b int) bool {
+				return candidates[idxs[a]].Timestamp.After(candidates[idxs[b]].Timestamp)
+			})
+			for r, i := range idxs {
+				rank[i] = r
+			}
+		}
+	}
+
+	items := []CleanupItem{}
+	for i, c := range candidates {
+		if c.Protected {
+			continue
+		}
+
+		var reasons []string
+		if opts.OlderThan > 0 {
+			if opts.Now.Sub(c.Timestamp) < opts.OlderThan {
+				continue
+			}
+			reasons = append(reasons, "older than "+formatRetention(opts.OlderThan))
+		}
+		if opts.KeepPerSession > 0 {
+			if rank[i] < opts.KeepPerSession {
+				continue
+			}
+			reasons = append(reasons, fmt.Sprintf("beyond %d most recent for session", opts.KeepPerSession))
+		}
+		if opts.MergedInto != "" {
+			if !c.Merged {
+				continue
+			}
+			reasons = append(reasons, "merged into "+opts.MergedInto)
+		}
+
+		item := c.Item
+		item.Reason = strings.Join(reasons, ", ")
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(a, b int) bool {
+		if items[a].Type != items[b].Type {
+			return items[a].Type > items[b].Type // shadow branches before checkpoints
+		}
+		return items[a].ID < items[b].ID
+	})
+	return items
+}
+
+// formatRetention renders a retention duration in days when it is a whole
+// number of days, and as a Go duration otherwise.
+func formatRetention(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// mergedHistory is the set of commits reachable from a branch, plus the
+// checkpoint IDs referenced by their Entire-Checkpoint trailers.
+type mergedHistory struct {
+	tip           plumbing.Hash
+	commits       map[plumbing.Hash]bool
+	checkpointIDs map[string]bool
+}
+
+// loadMergedHistory walks the full history of branch once.
+func loadMergedHistory(repo *git.Repository, branch string) (*mergedHistory, error) {
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return nil, fmt.Errorf("branch %q not found: %w", branch, err)
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: ref.Hash()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", branch, err)
+	}
+	defer iter.Close()
+
+	history := &mergedHistory{
+		tip:           ref.Hash(),
+		commits:       make(map[plumbing.Hash]bool),
+		checkpointIDs: make(map[string]bool),
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		history.commits[c.Hash] = true
+		if cpID, ok := trailers.ParseCheckpoint(c.Message); ok {
+			history.checkpointIDs[cpID.String()] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", branch, err)
+	}
+	return history, nil
+}
+
+// isBaseBehind reports whether a shadow branch base commit (short hash) is in the
+// branch history but is not the branch tip itself, i.e. the branch has moved on.
+func (h *mergedHistory) isBaseBehind(repo *git.Repository, baseCommit string) bool {
+	if baseCommit == "" {
+		return false
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(baseCommit))
+	if err != nil {
+		return false
+	}
+	return *hash != h.tip && h.commits[*hash]
+}
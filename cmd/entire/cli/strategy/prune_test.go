@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPruneCandidates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	shadow := func(id, sessionID string, age time.Duration) pruneCandidate {
+		return pruneCandidate{
+			Item:      CleanupItem{Type: CleanupTypeShadowBranch, ID: id},
+			SessionID: sessionID,
+			Timestamp: now.Add(-age),
+		}
+	}
+	day := 24 * time.Hour
+
+	candidates := []pruneCandidate{
+		shadow("entire/aaaaaaa-111111", "s1", 40*day),
+		shadow("entire/bbbbbbb-111111", "s1", 20*day),
+		shadow("entire/ccccccc-111111", "s1", 1*day),
+		shadow("entire/ddddddd-111111", "s2", 60*day),
+	}
+
+	t.Run("older than", func(t *testing.T) {
+		t.Parallel()
+		items := selectPruneCandidates(candidates, PruneOptions{OlderThan: 30 * day, Now: now})
+		ids := cleanupItemIDs(items)
+		assert.Equal(t, []string{"entire/aaaaaaa-111111", "entire/ddddddd-111111"}, ids)
+		assert.Equal(t, "older than 30d", items[0].Reason)
+	})
+
+	t.Run("keep per session", func(t *testing.T) {
+		t.Parallel()
+		items := selectPruneCandidates(candidates, PruneOptions{KeepPerSession: 1, Now: now})
+		assert.Equal(t, []string{"entire/aaaaaaa-111111", "entire/bbbbbbb-111111"}, cleanupItemIDs(items))
+	})
+
+	t.Run("policies combine", func(t *testing.T) {
+		t.Parallel()
+		items := selectPruneCandidates(candidates, PruneOptions{OlderThan: 30 * day, KeepPerSession: 1, Now: now})
+		assert.Equal(t, []string{"entire/aaaaaaa-111111"}, cleanupItemIDs(items))
+		assert.Equal(t, "older than 30d, beyond 1 most recent for session", items[0].Reason)
+	})
+
+	t.Run("protected and unmerged are kept", func(t *testing.T) {
+		t.Parallel()
+		withFlags := append([]pruneCandidate(nil), candidates...)
+		withFlags[0].Merged = true
+		withFlags[3].Merged = true
+		withFlags[3].Protected = true
+		items := selectPruneCandidates(withFlags, PruneOptions{MergedInto: "main", Now: now})
+		assert.Equal(t, []string{"entire/aaaaaaa-111111"}, cleanupItemIDs(items))
+		assert.Equal(t, "merged into main", items[0].Reason)
+	})
+}
+
+func TestListPruneCandidates_RequiresPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := ListPruneCandidates(PruneOptions{IncludeCommitted: true})
+	require.ErrorIs(t, err, ErrNoPrunePolicy)
+}
+
+// TestListPruneCandidates_MergedShadowBranch verifies that a shadow branch whose base
+// commit is behind the branch tip is selected by --merged, that one based on the tip is
+// not, and that active sessions are protected.
+func TestListPruneCandidates_MergedShadowBranch(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	branch := head.Name().Short()
+
+	s := &ManualCommitStrategy{}
+	require.NoError(t, s.InitializeSession("prune-old", "Claude Code", "", ""))
+	setupSessionWithFileChange(t, s, repo, dir, "prune-old")
+	oldState, err := s.loadSessionState("prune-old")
+	require.NoError(t, err)
+	oldState.Phase = session.PhaseIdle
+	require.NoError(t, s.saveSessionState(oldState))
+	oldBranch := getShadowBranchNameForCommit(oldState.BaseCommit, oldState.WorktreeID)
+
+	// Advance the branch so the first shadow branch's base is behind the tip.
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x\n"), 0o644))
+	_, err = wt.Add("other.txt")
+	require.NoError(t, err)
+	_, err = wt.Commit("advance", &git.CommitOptions{})
+	require.NoError(t, err)
+
+	// A second, active session based on the new tip.
+	require.NoError(t, s.InitializeSession("prune-active", "Claude Code", "", ""))
+	setupSessionWithFileChange(t, s, repo, dir, "prune-active")
+	activeState, err := s.loadSessionState("prune-active")
+	require.NoError(t, err)
+	activeBranch := getShadowBranchNameForCommit(activeState.BaseCommit, activeState.WorktreeID)
+	require.NotEqual(t, oldBranch, activeBranch)
+
+	items, err := ListPruneCandidates(PruneOptions{MergedInto: branch})
+	require.NoError(t, err)
+	assert.Equal(t, []string{oldBranch}, cleanupItemIDs(items))
+
+	// By age, everything is recent; with a zero-tolerance age, only the
+	// active session is protected.
+	items, err = ListPruneCandidates(PruneOptions{OlderThan: time.Nanosecond, Now: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{oldBranch}, cleanupItemIDs(items))
+}
+
+func cleanupItemIDs(items []CleanupItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
@@ -0,0 +1,192 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// Entire's footprint in a repository is what removing Entire would free: the
+// git objects reachable only from its refs (shadow branches, the metadata
+// branch, refs/entire/* and its notes) plus its state directories. Measuring
+// it walks their history, so hooks reuse a measurement for quotaMeasureTTL.
+// While the footprint is at or over the quota setting, checkpoints are
+// written metadata-only.
+
+// ErrMetadataOnlyCheckpoint is returned when rewinding to a checkpoint that
+// was written over quota and doesn't hold the files it changed.
+var ErrMetadataOnlyCheckpoint = errors.New("checkpoint was written metadata-only while the repository was over its quota; its file contents weren't saved")
+
+// quotaMeasureTTL is how long hooks reuse a footprint measurement.
+const quotaMeasureTTL = 10 * time.Minute
+
+// quotaCacheFileName is the state file holding the last measurement.
+const quotaCacheFileName = "entire-quota.json"
+
+// quotaStateDirNames are the state directories counted in the footprint.
+// "entire-hook-traces" is where `entire hooks trace` records invocations.
+var quotaStateDirNames = []string{session.SessionStateDirName, checkpoint.TreeListingCacheDirName, AttributionCacheDirName, "entire-hook-traces"}
+
+// QuotaStatus is Entire's footprint in the repository against its quota.
+type QuotaStatus struct {
+	// Limit is the quota in bytes.
+	Limit int64 `json:"limit"`
+	// Used is the footprint in bytes.
+	Used       int64     `json:"used"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// Exceeded reports whether checkpoints are written metadata-only.
+func (q *QuotaStatus) Exceeded() bool {
+	return q != nil && q.Limit > 0 && q.Used >= q.Limit
+}
+
+// CheckQuota returns Entire's footprint against the quota, nil if no quota is
+// set. A measurement younger than quotaMeasureTTL is reused unless refresh.
+func CheckQuota(ctx context.Context, refresh bool) (*QuotaStatus, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	limit, err := s.Quota.Limit()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already describes the setting
+	}
+	if limit == 0 {
+		return nil, nil //nolint:nilnil // no quota
+	}
+
+	commonDir, err := GetGitCommonDir()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := fsenv.StateDir(commonDir, quotaCacheFileName)
+	if !refresh {
+		if data, readErr := os.ReadFile(cachePath); readErr == nil { //nolint:gosec // path in the state dir
+			var cached QuotaStatus
+			if json.Unmarshal(data, &cached) == nil && time.Since(cached.MeasuredAt) < quotaMeasureTTL {
+				cached.Limit = limit
+				return &cached, nil
+			}
+		}
+	}
+
+	used, err := MeasureFootprint(ctx, commonDir)
+	if err != nil {
+		return nil, err
+	}
+	status := &QuotaStatus{Limit: limit, Used: used, MeasuredAt: time.Now()}
+	if data, marshalErr := jsonutil.MarshalIndentWithNewline(status, "", "  "); marshalErr == nil {
+		if mkErr := os.MkdirAll(filepath.Dir(cachePath), 0o750); mkErr == nil {
+			_ = os.WriteFile(cachePath, data, 0o600) //nolint:errcheck // the next hook measures again
+		}
+	}
+	return status, nil
+}
+
+// MeasureFootprint returns Entire's footprint in bytes in the repository
+// whose git common directory is commonDir.
+func MeasureFootprint(ctx context.Context, commonDir string) (int64, error) {
+	used, err := entireObjectsDiskUsage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range quotaStateDirNames {
+		used += dirSize(fsenv.StateDir(commonDir, name))
+	}
+	return used, nil
+}
+
+// entireObjectsDiskUsage returns the on-disk size of the objects reachable
+// from Entire's refs but not from branches, remote branches or tags.
+func entireObjectsDiskUsage(ctx context.Context) (int64, error) {
+	args := []string{"rev-list", "--objects", "--disk-usage",
+		"--glob=refs/heads/" + strings.TrimSuffix(checkpoint.ShadowBranchPrefix, "/"),
+		"--glob=refs/entire",
+	}
+	if repo, err := OpenRepository(); err == nil {
+		if _, err := repo.Reference(paths.NotesRefName, true); err == nil {
+			args = append(args, paths.NotesRefName)
+		}
+	}
+	// The metadata branch and shadow branches are branches too, and their
+	// remote-tracking copies hold the same objects
+	args = append(args, "--not",
+		"--exclude="+checkpoint.ShadowBranchPrefix+"*", "--branches",
+		"--exclude=*/"+checkpoint.ShadowBranchPrefix+"*", "--remotes",
+		"--tags")
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		msg := err.Error()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			msg = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		return 0, fmt.Errorf("failed to measure checkpoint objects: %s", msg)
+	}
+	used, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse disk usage %q: %w", strings.TrimSpace(string(output)), err)
+	}
+	return used, nil
+}
+
+// dirSize returns the total size of the files under dir, 0 if it doesn't exist.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error { //nolint:errcheck // best-effort
+		if err != nil {
+			return nil //nolint:nilerr // skip unreadable entries
+		}
+		if info, infoErr := d.Info(); infoErr == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+var quotaWarnOnce sync.Once
+
+// checkpointsMetadataOnly reports whether the repository is over its quota,
+// so checkpoints must be written metadata-only, and warns on stderr once per
+// process if it is. Errors measuring the footprint don't enforce the quota.
+func checkpointsMetadataOnly(ctx context.Context) bool {
+	status, err := CheckQuota(ctx, false)
+	if err != nil {
+		logging.Warn(logging.WithComponent(ctx, "quota"), "failed to check quota",
+			slog.String("error", err.Error()))
+		return false
+	}
+	if !status.Exceeded() {
+		return false
+	}
+	quotaWarnOnce.Do(func() {
+		fmt.Fprintf(os.Stderr, "[entire] Warning: Entire uses %s of its %s quota in this repository. Checkpoints are metadata-only (no file contents or transcripts) until you free space, e.g. with 'entire checkpoint prune'.\n",
+			settings.FormatByteSize(status.Used), settings.FormatByteSize(status.Limit))
+		logging.Warn(logging.WithComponent(ctx, "quota"), "quota exceeded, writing metadata-only checkpoints",
+			slog.Int64("used", status.Used),
+			slog.Int64("limit", status.Limit))
+	})
+	return true
+}
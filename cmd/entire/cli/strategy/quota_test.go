@@ -0,0 +1,75 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+func TestCheckQuota(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	ctx := context.Background()
+
+	status, err := CheckQuota(ctx, false)
+	if err != nil || status != nil {
+		t.Fatalf("CheckQuota() without a quota = %+v, %v; want nil", status, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".entire"), 0o750); err != nil {
+		t.Fatalf("failed to create .entire: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(`{"strategy": "manual-commit", "enabled": true, "quota": {"max_size": "1B"}}`), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	// Objects the user's branches reach don't count
+	status, err = CheckQuota(ctx, true)
+	if err != nil {
+		t.Fatalf("CheckQuota() error = %v", err)
+	}
+	if status.Used != 0 || status.Exceeded() {
+		t.Errorf("CheckQuota() = %+v, want nothing used before any checkpoint", status)
+	}
+
+	git := func(stdin string, args ...string) string {
+		t.Helper()
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Stdin = strings.NewReader(stdin)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	blob := git("checkpoint contents\n", "hash-object", "-w", "--stdin")
+	tree := git("100644 blob "+blob+"\tmain.go\n", "mktree")
+	commit := git("", "commit-tree", tree, "-m", "Checkpoint")
+	git("", "update-ref", "refs/heads/entire/abc1234", commit)
+	git("", "update-ref", "refs/heads/"+paths.MetadataBranchName, commit)
+
+	// Hooks reuse the last measurement
+	cached, err := CheckQuota(ctx, false)
+	if err != nil {
+		t.Fatalf("CheckQuota() error = %v", err)
+	}
+	if cached.Used != 0 || !cached.MeasuredAt.Equal(status.MeasuredAt) {
+		t.Errorf("CheckQuota() = %+v, want the cached measurement %+v", cached, status)
+	}
+
+	status, err = CheckQuota(ctx, true)
+	if err != nil {
+		t.Fatalf("CheckQuota() error = %v", err)
+	}
+	if status.Used == 0 || status.Limit != 1 || !status.Exceeded() {
+		t.Errorf("CheckQuota() = %+v, want the shadow branch's objects over the 1 byte quota", status)
+	}
+	if !checkpointsMetadataOnly(ctx) {
+		t.Error("checkpointsMetadataOnly() = false over quota")
+	}
+}
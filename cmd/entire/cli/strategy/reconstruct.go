@@ -0,0 +1,379 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	cpkg "github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/stringutil"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// When a session's shadow branch is gone (deleted by hand, pruned, or never
+// fetched into a fresh clone) its temporary checkpoints, and with them the
+// attribution of the next commit, are lost. The transcript still records
+// every file edit the agent made, so the checkpoints can be approximated:
+// replay the Write/Edit/MultiEdit tool inputs of each turn against the base
+// commit and commit the result to a new shadow branch. Edits whose old text
+// isn't found are skipped, and the share that replayed cleanly is recorded as
+// the reconstruction's confidence.
+
+// ErrReconstructUnsupported is returned for agents whose transcripts don't
+// record file edits as tool inputs.
+var ErrReconstructUnsupported = errors.New("transcript has no replayable file edits for this agent")
+
+// ReconstructResult describes a rebuilt shadow branch.
+type ReconstructResult struct {
+	SessionID    string
+	ShadowBranch string
+	// Checkpoints is the number of checkpoints written, one per turn that
+	// changed files.
+	Checkpoints int
+	// Files are the repo-relative files the reconstructed checkpoints change.
+	Files        []string
+	AppliedEdits int
+	SkippedEdits int
+	// Confidence is AppliedEdits over all edits, 0-1.
+	Confidence float64
+}
+
+// replayedTurn is the state of the files after one turn of the transcript.
+type replayedTurn struct {
+	prompt string
+	// files holds every file changed so far, not only in this turn.
+	files map[string]string
+}
+
+// replayResult is the outcome of replaying a transcript.
+type replayResult struct {
+	turns   []replayedTurn
+	applied int
+	skipped int
+}
+
+// editToolInput is the union of the Write, Edit and MultiEdit tool inputs.
+type editToolInput struct {
+	FilePath   string `json:"file_path"`
+	Content    string `json:"content"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+	Edits      []struct {
+		OldString  string `json:"old_string"`
+		NewString  string `json:"new_string"`
+		ReplaceAll bool   `json:"replace_all"`
+	} `json:"edits"`
+}
+
+// replayTranscript replays the file edits of a JSONL transcript. base returns
+// a file's content at the base commit; root is the worktree the transcript's
+// absolute paths are relative to. Edits of files outside root are ignored.
+func replayTranscript(content []byte, root string, base func(path string) (string, bool)) (replayResult, error) {
+	lines, err := transcript.ParseFromBytes(content)
+	if err != nil {
+		return replayResult{}, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	var result replayResult
+	files := make(map[string]string)
+	var prompt string
+	changed := false
+	flush := func() {
+		if !changed {
+			return
+		}
+		snapshot := make(map[string]string, len(files))
+		for k, v := range files {
+			snapshot[k] = v
+		}
+		result.turns = append(result.turns, replayedTurn{prompt: prompt, files: snapshot})
+		changed = false
+	}
+	current := func(path string) (string, bool) {
+		if c, ok := files[path]; ok {
+			return c, true
+		}
+		return base(path)
+	}
+
+	for _, line := range lines {
+		switch line.Type {
+		case transcript.TypeUser:
+			if text := transcript.ExtractUserContent(line.Message); text != "" {
+				flush()
+				prompt = text
+			}
+		case transcript.TypeAssistant:
+			var msg transcript.AssistantMessage
+			if err := json.Unmarshal(line.Message, &msg); err != nil {
+				continue
+			}
+			for _, block := range msg.Content {
+				if block.Type != transcript.ContentTypeToolUse {
+					continue
+				}
+				var input editToolInput
+				if err := json.Unmarshal(block.Input, &input); err != nil {
+					continue
+				}
+				path, ok := replayPath(root, input.FilePath)
+				if !ok {
+					continue
+				}
+				switch block.Name {
+				case "Write":
+					files[path] = input.Content
+					result.applied++
+					changed = true
+				case "Edit":
+					if applyEdit(files, current, path, input.OldString, input.NewString, input.ReplaceAll) {
+						result.applied++
+						changed = true
+					} else {
+						result.skipped++
+					}
+				case "MultiEdit":
+					for _, e := range input.Edits {
+						if applyEdit(files, current, path, e.OldString, e.NewString, e.ReplaceAll) {
+							result.applied++
+							changed = true
+						} else {
+							result.skipped++
+						}
+					}
+				}
+			}
+		}
+	}
+	flush()
+	return result, nil
+}
+
+// applyEdit replaces oldText with newText in path. Returns false if the file
+// or oldText doesn't exist, which happens when the user edited the file in
+// between or an earlier edit couldn't be replayed.
+func applyEdit(files map[string]string, current func(string) (string, bool), path, oldText, newText string, replaceAll bool) bool {
+	content, ok := current(path)
+	if !ok {
+		if oldText != "" {
+			return false
+		}
+		files[path] = newText
+		return true
+	}
+	if oldText == "" || !strings.Contains(content, oldText) {
+		return false
+	}
+	if replaceAll {
+		files[path] = strings.ReplaceAll(content, oldText, newText)
+	} else {
+		files[path] = strings.Replace(content, oldText, newText, 1)
+	}
+	return true
+}
+
+// replayPath converts a tool's file path to a repo-relative path, or false if
+// it is outside root.
+func replayPath(root, filePath string) (string, bool) {
+	if filePath == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(root, filePath)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(filePath))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == paths.EntireDir || strings.HasPrefix(rel, paths.EntireDir+"/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// NeedsReconstruction reports whether a session has lost its shadow branch:
+// it created checkpoints since its last commit, the branch is gone, and the
+// transcript they were taken from is still readable.
+func NeedsReconstruction(repo *git.Repository, state *SessionState) bool {
+	if state.StepCount == 0 || state.BaseCommit == "" || state.TranscriptPath == "" {
+		return false
+	}
+	shadowBranch := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+	if _, err := repo.Reference(cpkg.ShadowRefName(repo, shadowBranch), true); err == nil {
+		return false
+	}
+	_, err := os.Stat(state.TranscriptPath)
+	return err == nil
+}
+
+// ReconstructSession rebuilds the shadow branch of a session from its
+// transcript, one checkpoint per turn since the last commit that changed
+// files, and records the confidence in the session state so condensation
+// marks the committed checkpoint as reconstructed. With dryRun it only
+// replays the transcript and reports what it would write.
+func ReconstructSession(ctx context.Context, state *SessionState, dryRun bool) (*ReconstructResult, error) {
+	switch state.AgentType {
+	case agent.AgentTypeClaudeCode, agent.AgentTypeUnknown, "":
+	default:
+		return nil, fmt.Errorf("%s: %w", state.AgentType, ErrReconstructUnsupported)
+	}
+
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, err
+	}
+	baseCommit, err := repo.CommitObject(plumbing.NewHash(state.BaseCommit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base commit %s: %w", state.BaseCommit, err)
+	}
+	baseTree, err := baseCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base tree: %w", err)
+	}
+
+	fullTranscript, err := os.ReadFile(state.TranscriptPath) //nolint:gosec // path from session state
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	root := state.WorktreePath
+	if root == "" {
+		if root, err = paths.RepoRoot(); err != nil {
+			return nil, fmt.Errorf("failed to get repo root: %w", err)
+		}
+	}
+
+	replay, err := replayTranscript(transcript.SliceFromLine(fullTranscript, state.CheckpointTranscriptStart), root, treeFileContent(baseTree))
+	if err != nil {
+		return nil, err
+	}
+
+	shadowBranch := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+	result := &ReconstructResult{
+		SessionID:    state.SessionID,
+		ShadowBranch: shadowBranch,
+		Checkpoints:  len(replay.turns),
+		AppliedEdits: replay.applied,
+		SkippedEdits: replay.skipped,
+	}
+	if total := replay.applied + replay.skipped; total > 0 {
+		result.Confidence = float64(replay.applied) / float64(total)
+	}
+	if len(replay.turns) > 0 {
+		for file := range replay.turns[len(replay.turns)-1].files {
+			result.Files = append(result.Files, file)
+		}
+		sort.Strings(result.Files)
+	}
+	if dryRun || len(replay.turns) == 0 {
+		return result, nil
+	}
+
+	store := cpkg.NewGitStore(repo)
+	authorName, authorEmail := GetGitAuthorFromRepo(repo)
+	for _, turn := range replay.turns {
+		files := make(map[string][]byte, len(turn.files))
+		for file, content := range turn.files {
+			files[file] = []byte(content)
+		}
+		message := "Reconstructed checkpoint"
+		if turn.prompt != "" {
+			message += ": " + stringutil.TruncateRunes(strings.Join(strings.Fields(turn.prompt), " "), 60, "...")
+		}
+		if _, err := store.WriteReconstructed(ctx, cpkg.WriteReconstructedOptions{
+			SessionID:     state.SessionID,
+			BaseCommit:    state.BaseCommit,
+			WorktreeID:    state.WorktreeID,
+			Files:         files,
+			Transcript:    fullTranscript,
+			CommitMessage: message,
+			Confidence:    result.Confidence,
+			AuthorName:    authorName,
+			AuthorEmail:   authorEmail,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to write reconstructed checkpoint: %w", err)
+		}
+	}
+
+	state.ReconstructionConfidence = result.Confidence
+	if state.FilesTouched == nil {
+		state.FilesTouched = result.Files
+	}
+	// Save under the state lock: replaying takes a while, and a hook may have
+	// saved the session meanwhile.
+	err = UpdateSessionState(state.SessionID, func(s *SessionState) error {
+		s.ReconstructionConfidence = state.ReconstructionConfidence
+		if s.FilesTouched == nil {
+			s.FilesTouched = state.FilesTouched
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save session state: %w", err)
+	}
+
+	logging.Info(logging.WithComponent(ctx, "reconstruct"), "reconstructed shadow branch from transcript",
+		slog.String("session_id", state.SessionID),
+		slog.String("shadow_branch", shadowBranch),
+		slog.Int("checkpoints", result.Checkpoints),
+		slog.Int("skipped_edits", result.SkippedEdits))
+	return result, nil
+}
+
+// ReconstructLostShadowBranches rebuilds the shadow branch of every session
+// that NeedsReconstruction. Sessions that can't be rebuilt are reported in
+// the returned error and don't stop the others.
+func ReconstructLostShadowBranches(ctx context.Context, dryRun bool) ([]ReconstructResult, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, err
+	}
+	states, err := ListSessionStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session states: %w", err)
+	}
+
+	var results []ReconstructResult
+	var errs []error
+	for _, state := range states {
+		if !NeedsReconstruction(repo, state) {
+			continue
+		}
+		result, err := ReconstructSession(ctx, state, dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", state.SessionID, err))
+			continue
+		}
+		results = append(results, *result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// treeFileContent returns a lookup of file contents in tree.
+func treeFileContent(tree *object.Tree) func(string) (string, bool) {
+	return func(path string) (string, bool) {
+		file, err := tree.File(path)
+		if err != nil {
+			return "", false
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return "", false
+		}
+		return content, true
+	}
+}
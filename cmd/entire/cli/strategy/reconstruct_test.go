@@ -0,0 +1,177 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	cpkg "github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// reconstructTranscript has two turns: the first writes main.go and edits the
+// README, the second edits main.go twice (one edit can't be replayed) and
+// tries to write outside the worktree.
+func reconstructTranscript(root string) string {
+	return `{"type":"user","message":{"content":"add a main package"}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Write","input":{"file_path":"` + filepath.Join(root, "main.go") + `","content":"package main\n\nfunc main() {}\n"}}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Edit","input":{"file_path":"` + filepath.Join(root, "README.md") + `","old_string":"# Test","new_string":"# Demo"}}]}}
+{"type":"user","message":{"content":"print a greeting"}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"MultiEdit","input":{"file_path":"` + filepath.Join(root, "main.go") + `","edits":[{"old_string":"func main() {}","new_string":"func main() { println(\"hi\") }"},{"old_string":"not in the file","new_string":"x"}]}}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Write","input":{"file_path":"/elsewhere/notes.txt","content":"outside"}}]}}
+`
+}
+
+func TestReplayTranscript(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(string(filepath.Separator), "work", "repo")
+	base := func(path string) (string, bool) {
+		if path == "README.md" {
+			return "# Test", true
+		}
+		return "", false
+	}
+
+	result, err := replayTranscript([]byte(reconstructTranscript(root)), root, base)
+	if err != nil {
+		t.Fatalf("replayTranscript() error = %v", err)
+	}
+	if result.applied != 3 || result.skipped != 1 {
+		t.Errorf("applied, skipped = %d, %d, want 3, 1", result.applied, result.skipped)
+	}
+	if len(result.turns) != 2 {
+		t.Fatalf("got %d turns, want 2", len(result.turns))
+	}
+
+	first := result.turns[0]
+	if first.prompt != "add a main package" || first.files["README.md"] != "# Demo" || first.files["main.go"] != "package main\n\nfunc main() {}\n" {
+		t.Errorf("first turn = %+v", first)
+	}
+	second := result.turns[1]
+	if !strings.Contains(second.files["main.go"], `println("hi")`) || second.files["README.md"] != "# Demo" {
+		t.Errorf("second turn = %+v", second)
+	}
+	if _, ok := second.files["notes.txt"]; ok || len(second.files) != 2 {
+		t.Errorf("second turn has files %v, want only main.go and README.md", second.files)
+	}
+}
+
+func TestReplayPath(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(string(filepath.Separator), "work", "repo")
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{filepath.Join(root, "pkg", "a.go"), "pkg/a.go", true},
+		{"pkg/b.go", "pkg/b.go", true},
+		{filepath.Join(root, "..", "other", "c.go"), "", false},
+		{filepath.Join(root, ".entire", "settings.json"), "", false},
+		{root, "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := replayPath(root, tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("replayPath(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReconstructSession(t *testing.T) {
+	dir := t.TempDir()
+	initTestRepo(t, dir)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(transcriptPath, []byte(reconstructTranscript(dir)), 0o600); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	state := &SessionState{
+		SessionID:      "2026-10-14-reconstruct",
+		BaseCommit:     head.Hash().String(),
+		WorktreePath:   dir,
+		AgentType:      agent.AgentTypeClaudeCode,
+		TranscriptPath: transcriptPath,
+		StepCount:      2,
+	}
+	if err := SaveSessionState(state); err != nil {
+		t.Fatalf("failed to save session state: %v", err)
+	}
+	if !NeedsReconstruction(repo, state) {
+		t.Fatal("NeedsReconstruction() = false for a session without shadow branch")
+	}
+
+	dry, err := ReconstructSession(context.Background(), state, true)
+	if err != nil {
+		t.Fatalf("ReconstructSession(dryRun) error = %v", err)
+	}
+	if dry.Checkpoints != 2 || !NeedsReconstruction(repo, state) {
+		t.Errorf("dry run = %+v, wrote a shadow branch: %v", dry, !NeedsReconstruction(repo, state))
+	}
+
+	result, err := ReconstructSession(context.Background(), state, false)
+	if err != nil {
+		t.Fatalf("ReconstructSession() error = %v", err)
+	}
+	if result.Confidence != 0.75 || strings.Join(result.Files, ",") != "README.md,main.go" {
+		t.Errorf("result = %+v", result)
+	}
+	if NeedsReconstruction(repo, state) {
+		t.Error("NeedsReconstruction() = true after reconstructing")
+	}
+
+	ref, err := repo.Reference(cpkg.ShadowRefName(repo, result.ShadowBranch), true)
+	if err != nil {
+		t.Fatalf("shadow branch %s not created: %v", result.ShadowBranch, err)
+	}
+	tip, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("failed to read shadow commit: %v", err)
+	}
+	if confidence, ok := trailers.ParseReconstructed(tip.Message); !ok || confidence != 0.75 {
+		t.Errorf("shadow commit trailer = %v, %v in %q", confidence, ok, tip.Message)
+	}
+	if tip.NumParents() != 1 {
+		t.Errorf("tip has %d parents, want the first reconstructed checkpoint", tip.NumParents())
+	}
+	tree, err := tip.Tree()
+	if err != nil {
+		t.Fatalf("failed to read shadow tree: %v", err)
+	}
+	if content, ok := treeFileContent(tree)("main.go"); !ok || !strings.Contains(content, `println("hi")`) {
+		t.Errorf("main.go in shadow tree = %q, %v", content, ok)
+	}
+
+	saved, err := LoadSessionState(state.SessionID)
+	if err != nil {
+		t.Fatalf("failed to load session state: %v", err)
+	}
+	if saved.ReconstructionConfidence != 0.75 {
+		t.Errorf("saved ReconstructionConfidence = %v, want 0.75", saved.ReconstructionConfidence)
+	}
+}
+
+func TestReconstructSession_UnsupportedAgent(t *testing.T) {
+	t.Parallel()
+	state := &SessionState{SessionID: "s", AgentType: agent.AgentTypeGemini}
+	if _, err := ReconstructSession(context.Background(), state, true); !errors.Is(err, ErrReconstructUnsupported) {
+		t.Errorf("ReconstructSession() error = %v, want ErrReconstructUnsupported", err)
+	}
+}
@@ -53,8 +53,16 @@ func List() []string {
 const (
 	StrategyNameManualCommit = "manual-commit"
 	StrategyNameAutoCommit   = "auto-commit"
+	StrategyNameStacked      = "stacked"
 )
 
+// CommitsToActiveBranch reports whether the named strategy commits the
+// agent's changes to the active branch itself, as auto-commit and stacked
+// do, instead of leaving commits to the user.
+func CommitsToActiveBranch(name string) bool {
+	return name == StrategyNameAutoCommit || name == StrategyNameStacked
+}
+
 // DefaultStrategyName is the name of the default strategy.
 // Manual-commit is the recommended strategy for most workflows.
 const DefaultStrategyName = StrategyNameManualCommit
@@ -0,0 +1,111 @@
+package strategy
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// slowGoGitFileName is the state file that records a go-git checkpoint write
+// over the latency budget. While it exists the auto backend uses the git CLI.
+const slowGoGitFileName = "entire-slow-go-git"
+
+// checkpointBackend returns the backend checkpoints in repo are written with
+// (see settings.RepositoryBackendSettings). Falls back to go-git when the
+// worktree can't be found.
+func checkpointBackend(repo *git.Repository) checkpoint.Backend {
+	kind, maxFiles, _ := configuredRepositoryBackend()
+	wt, err := repo.Worktree()
+	if err != nil || kind == settings.RepositoryBackendGoGit {
+		return checkpoint.NewGoGitBackend(repo)
+	}
+	repoRoot := wt.Filesystem.Root()
+	if kind == settings.RepositoryBackendGit {
+		return checkpoint.NewGitCLIBackend(repoRoot)
+	}
+
+	if n, ok := indexEntryCount(); ok && n > maxFiles {
+		logging.Debug(context.Background(), "using the git CLI backend for a large repository",
+			slog.Int("index_entries", n), slog.Int("max_files", maxFiles))
+		return checkpoint.NewGitCLIBackend(repoRoot)
+	}
+	if path, ok := slowGoGitPath(); ok && fileExists(path) {
+		return checkpoint.NewGitCLIBackend(repoRoot)
+	}
+	return checkpoint.NewGoGitBackend(repo)
+}
+
+// recordCheckpointLatency notes a go-git checkpoint write that took longer
+// than the latency budget, so the auto backend uses the git CLI from then on.
+func recordCheckpointLatency(backend checkpoint.Backend, elapsed time.Duration) {
+	kind, _, budget := configuredRepositoryBackend()
+	if kind != settings.RepositoryBackendAuto || backend.Name() != checkpoint.BackendGoGit || elapsed <= budget {
+		return
+	}
+	path, ok := slowGoGitPath()
+	if !ok {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, []byte(elapsed.String()+"\n"), 0o600); err != nil {
+		return
+	}
+	logging.Info(context.Background(), "checkpoint write over the latency budget, switching to the git CLI backend",
+		slog.Duration("elapsed", elapsed), slog.Duration("budget", budget))
+}
+
+// configuredRepositoryBackend returns the repository backend settings, the
+// defaults if settings are missing or invalid.
+func configuredRepositoryBackend() (kind string, maxFiles int, budget time.Duration) {
+	s, err := settings.Load()
+	if err != nil {
+		s = &settings.EntireSettings{}
+	}
+	kind, maxFiles, budget, err = s.RepositoryBackend.Effective()
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring repository backend settings", slog.String("error", err.Error()))
+		kind, maxFiles, budget, _ = (*settings.RepositoryBackendSettings)(nil).Effective() //nolint:errcheck // the defaults don't fail
+	}
+	return kind, maxFiles, budget
+}
+
+func slowGoGitPath() (string, bool) {
+	commonDir, err := GetGitCommonDir()
+	if err != nil {
+		return "", false
+	}
+	return fsenv.StateDir(commonDir, slowGoGitFileName), true
+}
+
+// indexEntryCount returns the number of entries in the worktree's index,
+// read from its header so a large index isn't parsed.
+func indexEntryCount() (int, bool) {
+	gitDir, err := GetGitDir()
+	if err != nil {
+		return 0, false
+	}
+	f, err := os.Open(filepath.Join(gitDir, "index")) //nolint:gosec // path from git rev-parse
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	// "DIRC", the version and the entry count, big-endian
+	var header [12]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil || string(header[:4]) != "DIRC" {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(header[8:])), true
+}
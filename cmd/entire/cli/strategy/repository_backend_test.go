@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+func TestCheckpointBackend(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	repo, err := OpenRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSettings := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, ".entire"), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".entire", "settings.json"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := checkpointBackend(repo).Name(); got != checkpoint.BackendGoGit {
+		t.Errorf("default backend = %s, want go-git for a small repository", got)
+	}
+
+	// Above max_files index entries auto picks the git CLI
+	if err := os.WriteFile(filepath.Join(dir, "second.txt"), []byte("second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.CommandContext(context.Background(), "git", "add", "second.txt")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, output)
+	}
+	writeSettings(`{"repository_backend": {"max_files": 1}}`)
+	if got := checkpointBackend(repo).Name(); got != checkpoint.BackendGitCLI {
+		t.Errorf("backend above max_files = %s, want git", got)
+	}
+
+	// A go-git write over the latency budget switches auto to the git CLI
+	writeSettings(`{"repository_backend": {"latency_budget": "100ms"}}`)
+	goGit := checkpointBackend(repo)
+	recordCheckpointLatency(goGit, 50*time.Millisecond)
+	if got := checkpointBackend(repo).Name(); got != checkpoint.BackendGoGit {
+		t.Errorf("backend after a fast write = %s, want go-git", got)
+	}
+	recordCheckpointLatency(goGit, 200*time.Millisecond)
+	if got := checkpointBackend(repo).Name(); got != checkpoint.BackendGitCLI {
+		t.Errorf("backend after a slow write = %s, want git", got)
+	}
+
+	// An explicit kind wins
+	writeSettings(`{"repository_backend": {"kind": "go-git"}}`)
+	if got := checkpointBackend(repo).Name(); got != checkpoint.BackendGoGit {
+		t.Errorf("go-git backend = %s", got)
+	}
+}
@@ -0,0 +1,245 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Refs a session bundle's git bundles carry. They exist locally only while a
+// bundle is written or read.
+const (
+	bundleCheckpointsRef = "refs/entire/export/checkpoints"
+	bundleShadowRef      = "refs/entire/export/shadow"
+	importCheckpointsRef = "refs/entire/import/checkpoints"
+	importShadowRef      = "refs/entire/import/shadow"
+)
+
+// ErrBundlePrerequisites is returned when a shadow branch bundle builds on a
+// commit this repository doesn't have.
+var ErrBundlePrerequisites = errors.New("bundle builds on a commit this repository doesn't have")
+
+// ShadowImport is what importing a shadow branch bundle did.
+type ShadowImport int
+
+const (
+	// ShadowImportNone means the local branch already had the bundle's checkpoints.
+	ShadowImportNone ShadowImport = iota
+	// ShadowImportApplied means the branch was created or fast-forwarded.
+	ShadowImportApplied
+	// ShadowImportConflict means the local branch has other checkpoints and
+	// was left alone.
+	ShadowImportConflict
+)
+
+// WriteCheckpointsBundle writes a git bundle to path holding the directories
+// of the given checkpoints on the metadata branch, as one commit without
+// parents. Checkpoints shared with other sessions are written whole.
+func WriteCheckpointsBundle(ctx context.Context, repo *git.Repository, checkpointIDs []id.CheckpointID, path string) error {
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(paths.MetadataBranchName), true)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", paths.MetadataBranchName, err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", paths.MetadataBranchName, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", paths.MetadataBranchName, err)
+	}
+
+	entries := make(map[string]object.TreeEntry)
+	for _, cpID := range checkpointIDs {
+		subtree, err := tree.Tree(cpID.Path())
+		if err != nil {
+			return fmt.Errorf("checkpoint %s not found: %w", cpID, err)
+		}
+		if err := checkpoint.FlattenTree(repo, subtree, cpID.Path(), entries); err != nil {
+			return fmt.Errorf("failed to read checkpoint %s: %w", cpID, err)
+		}
+	}
+	treeHash, err := checkpoint.BuildTreeFromEntries(repo, entries)
+	if err != nil {
+		return fmt.Errorf("failed to build checkpoints tree: %w", err)
+	}
+	exportHash, err := createMergeCommitCommon(repo, treeHash, nil, fmt.Sprintf("Export %d checkpoint(s)", len(checkpointIDs)))
+	if err != nil {
+		return err
+	}
+	return writeBundle(ctx, repo, bundleCheckpointsRef, exportHash, path)
+}
+
+// WriteShadowBundle writes a git bundle to path holding the shadow branch's
+// checkpoints since baseCommit, which the importing repository needs.
+func WriteShadowBundle(ctx context.Context, repo *git.Repository, branch, baseCommit, path string) error {
+	ref, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", branch, err)
+	}
+	return writeBundle(ctx, repo, bundleShadowRef, ref.Hash(), path, "^"+baseCommit)
+}
+
+// writeBundle points refName at hash for as long as it takes to bundle it.
+func writeBundle(ctx context.Context, repo *git.Repository, refName string, hash plumbing.Hash, path string, exclude ...string) error {
+	name := plumbing.ReferenceName(refName)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+		return fmt.Errorf("failed to create %s: %w", refName, err)
+	}
+	defer func() {
+		_ = repo.Storer.RemoveReference(name) //nolint:errcheck // best-effort cleanup of a temporary ref
+	}()
+
+	args := append([]string{"bundle", "create", "-q", path, refName}, exclude...)
+	output, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ImportCheckpointsBundle adds the checkpoints of a bundle written by
+// WriteCheckpointsBundle to the metadata branch. Files the branch already has
+// are kept. Returns the IDs of the checkpoints that were new.
+func ImportCheckpointsBundle(ctx context.Context, path, message string) ([]string, error) {
+	repo, upstream, err := fetchBundleRef(ctx, path, bundleCheckpointsRef, importCheckpointsRef)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = repo.Storer.RemoveReference(importCheckpointsRef) //nolint:errcheck // best-effort cleanup of a temporary ref
+	}()
+
+	imported := make(map[string]object.TreeEntry)
+	if err := flattenCommitTree(repo, upstream, imported); err != nil {
+		return nil, err
+	}
+	entries := make(map[string]object.TreeEntry)
+	var parents []plumbing.Hash
+	branchRef := plumbing.NewBranchReferenceName(paths.MetadataBranchName)
+	if local, err := repo.Reference(branchRef, true); err == nil {
+		if err := flattenCommitTree(repo, local.Hash(), entries); err != nil {
+			return nil, err
+		}
+		parents = []plumbing.Hash{local.Hash()}
+	}
+
+	var added []string
+	for name, entry := range imported {
+		if _, ok := entries[name]; ok {
+			continue
+		}
+		entries[name] = entry
+		// A checkpoint is new if its metadata.json is
+		if dir, ok := strings.CutSuffix(name, "/"+paths.MetadataFileName); ok && strings.Count(dir, "/") == 1 {
+			added = append(added, strings.ReplaceAll(dir, "/", ""))
+		}
+	}
+	sort.Strings(added)
+	if len(added) == 0 && len(parents) > 0 {
+		return nil, nil
+	}
+
+	treeHash, err := checkpoint.BuildTreeFromEntries(repo, entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build metadata tree: %w", err)
+	}
+	commitHash, err := createMergeCommitCommon(repo, treeHash, parents, message)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(branchRef, commitHash)); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", paths.MetadataBranchName, err)
+	}
+	return added, nil
+}
+
+// ImportShadowBundle creates branch from a bundle written by
+// WriteShadowBundle, or fast-forwards it. A branch with checkpoints the
+// bundle doesn't have is left alone. Returns ErrBundlePrerequisites if the
+// repository doesn't have the commit the checkpoints build on.
+func ImportShadowBundle(ctx context.Context, path, branch string) (ShadowImport, error) {
+	repo, tip, err := fetchBundleRef(ctx, path, bundleShadowRef, importShadowRef)
+	if err != nil {
+		return ShadowImportNone, err
+	}
+	defer func() {
+		_ = repo.Storer.RemoveReference(importShadowRef) //nolint:errcheck // best-effort cleanup of a temporary ref
+	}()
+
+	update, err := updateShadowBranchFromRemote(repo, branch, tip, false)
+	if err != nil {
+		return ShadowImportNone, err
+	}
+	switch update {
+	case shadowUpdateApplied:
+		return ShadowImportApplied, nil
+	case shadowUpdateConflict:
+		return ShadowImportConflict, nil
+	default:
+		return ShadowImportNone, nil
+	}
+}
+
+// fetchBundleRef fetches ref of the bundle at path into localRef and returns
+// the repository, opened after the fetch, with the commit ref points at.
+func fetchBundleRef(ctx context.Context, path, ref, localRef string) (*git.Repository, plumbing.Hash, error) {
+	if err := fetchBundle(ctx, path, ref, localRef); err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	fetched, err := repo.Reference(plumbing.ReferenceName(localRef), true)
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", localRef, err)
+	}
+	return repo, fetched.Hash(), nil
+}
+
+// fetchBundle fetches ref of the bundle at path into localRef.
+func fetchBundle(ctx context.Context, path, ref, localRef string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if output, err := exec.CommandContext(ctx, "git", "bundle", "verify", path).CombinedOutput(); err != nil {
+		if strings.Contains(string(output), "prerequisite") {
+			return fmt.Errorf("%w: %s", ErrBundlePrerequisites, strings.TrimSpace(string(output)))
+		}
+		return fmt.Errorf("invalid bundle: %s", strings.TrimSpace(string(output)))
+	}
+	cmd := exec.CommandContext(ctx, "git", "fetch", "-q", "--no-tags", path, "+"+ref+":"+localRef) //nolint:gosec // path is passed as a single argument
+	cmd.Stdin = nil
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to fetch bundle: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func flattenCommitTree(repo *git.Repository, hash plumbing.Hash, entries map[string]object.TreeEntry) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to read tree of %s: %w", hash, err)
+	}
+	if err := checkpoint.FlattenTree(repo, tree, "", entries); err != nil {
+		return fmt.Errorf("failed to flatten tree of %s: %w", hash, err)
+	}
+	return nil
+}
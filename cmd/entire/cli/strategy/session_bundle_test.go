@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowBundle_RoundTrip(t *testing.T) {
+	const branch = "entire/abc1234-e3b0c4"
+	ctx := context.Background()
+	source := setupGitRepo(t)
+	repo, err := git.PlainOpen(source)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	first := writeShadowCommit(t, repo, checkpoint.ShadowRefName(repo, branch), "s1", head.Hash())
+	tip := writeShadowCommit(t, repo, checkpoint.ShadowRefName(repo, branch), "s1", first)
+
+	bundle := filepath.Join(t.TempDir(), "shadow.bundle")
+	t.Chdir(source)
+	require.NoError(t, WriteShadowBundle(ctx, repo, branch, head.Hash().String(), bundle))
+
+	// A clone has the base commit, so the checkpoints import
+	clone := t.TempDir()
+	_, err = git.PlainClone(clone, false, &git.CloneOptions{URL: source})
+	require.NoError(t, err)
+	t.Chdir(clone)
+	paths.ClearRepoRootCache()
+	result, err := ImportShadowBundle(ctx, bundle, branch)
+	require.NoError(t, err)
+	assert.Equal(t, ShadowImportApplied, result)
+	cloneRepo, err := git.PlainOpen(clone)
+	require.NoError(t, err)
+	ref, err := cloneRepo.Reference(checkpoint.ShadowRefName(cloneRepo, branch), true)
+	require.NoError(t, err)
+	assert.Equal(t, tip, ref.Hash())
+	_, err = cloneRepo.Reference(importShadowRef, true)
+	require.Error(t, err, "the temporary import ref is left behind")
+
+	result, err = ImportShadowBundle(ctx, bundle, branch)
+	require.NoError(t, err)
+	assert.Equal(t, ShadowImportNone, result)
+
+	// An unrelated repository lacks it
+	unrelated := t.TempDir()
+	initTestRepo(t, unrelated)
+	t.Chdir(unrelated)
+	paths.ClearRepoRootCache()
+	_, err = ImportShadowBundle(ctx, bundle, branch)
+	require.ErrorIs(t, err, ErrBundlePrerequisites)
+}
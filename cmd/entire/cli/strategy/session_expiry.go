@@ -0,0 +1,176 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// A session whose agent crashed or whose terminal was closed never gets its
+// Stop or SessionEnd hook, so it stays active and keeps being counted as a
+// concurrent session. Once it has had no hook for the session_expiry TTL,
+// its state is moved to the archive directory of the state directory and its
+// shadow branch to ArchivedShadowRefPrefix, where nothing but git sees them.
+
+// ArchivedShadowRefPrefix is where the shadow branches of expired sessions
+// are kept, followed by the branch name.
+const ArchivedShadowRefPrefix = "refs/entire/archive/"
+
+// sessionExpiryCheckInterval is how often hooks look for stale sessions.
+const sessionExpiryCheckInterval = time.Hour
+
+// sessionExpiryStampFileName is the state file holding when a hook last
+// looked for stale sessions.
+const sessionExpiryStampFileName = "entire-session-expiry-checked"
+
+// ExpiredSession is a stale session that was (or, in a dry run, would be)
+// archived.
+type ExpiredSession struct {
+	SessionID    string        `json:"session_id"`
+	Phase        session.Phase `json:"phase"`
+	LastActivity time.Time     `json:"last_activity"`
+	// ShadowBranch is the session's shadow branch, empty if it has none or
+	// another session still uses it; those are left in place.
+	ShadowBranch string `json:"shadow_branch,omitempty"`
+	// ArchivedRef is where ShadowBranch was moved.
+	ArchivedRef string `json:"archived_ref,omitempty"`
+	// ArchivedState is where the session's state file was moved.
+	ArchivedState string `json:"archived_state,omitempty"`
+}
+
+// SessionExpiryTTL returns how long a session may go without a hook before
+// it is archived, 0 if expiry is turned off.
+func SessionExpiryTTL() (time.Duration, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return s.SessionExpiry.EffectiveTTL() //nolint:wrapcheck // already describes the setting
+}
+
+// sessionLastActivity is when the session last had a hook.
+func sessionLastActivity(state *SessionState) time.Time {
+	if state.LastInteractionTime != nil {
+		return *state.LastInteractionTime
+	}
+	return state.StartedAt
+}
+
+// ExpireStaleSessions archives the sessions of every worktree that haven't
+// ended and have had no hook for ttl, except keepSessionID. With dryRun
+// nothing is changed. Sessions are returned oldest first.
+func ExpireStaleSessions(ctx context.Context, ttl time.Duration, keepSessionID string, dryRun bool) ([]ExpiredSession, error) {
+	states, err := ListSessionStates()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var stale []*SessionState
+	// Shadow branches sessions that stay still need
+	kept := make(map[string]bool)
+	for _, state := range states {
+		branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+		if state.Phase != session.PhaseEnded && state.SessionID != keepSessionID && now.Sub(sessionLastActivity(state)) > ttl {
+			stale = append(stale, state)
+			continue
+		}
+		kept[branch] = true
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return sessionLastActivity(stale[i]).Before(sessionLastActivity(stale[j]))
+	})
+
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	store, err := sessionStateStore()
+	if err != nil {
+		return nil, err
+	}
+	expired := make([]ExpiredSession, 0, len(stale))
+	archivedBranches := make(map[string]bool)
+	for _, state := range stale {
+		e := ExpiredSession{SessionID: state.SessionID, Phase: state.Phase, LastActivity: sessionLastActivity(state)}
+		branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+		if state.BaseCommit != "" && !kept[branch] {
+			// Two stale sessions can share a branch; it's moved with the first
+			if archivedBranches[branch] {
+				e.ShadowBranch, e.ArchivedRef = branch, ArchivedShadowRefPrefix+branch
+			} else if ref, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true); err == nil {
+				e.ShadowBranch, e.ArchivedRef = branch, ArchivedShadowRefPrefix+branch
+				if !dryRun {
+					if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(e.ArchivedRef), ref.Hash())); err != nil {
+						return expired, fmt.Errorf("failed to archive %s: %w", branch, err)
+					}
+					if err := deleteShadowBranch(repo, branch); err != nil {
+						return expired, fmt.Errorf("failed to archive %s: %w", branch, err)
+					}
+				}
+				archivedBranches[branch] = true
+			}
+		}
+		if !dryRun {
+			if e.ArchivedState, err = store.Archive(ctx, state); err != nil {
+				return expired, fmt.Errorf("failed to archive session %s: %w", state.SessionID, err)
+			}
+		}
+		expired = append(expired, e)
+	}
+	return expired, nil
+}
+
+// ExpireStaleSessionsIfDue archives stale sessions at most once per
+// sessionExpiryCheckInterval, for hooks. The session being hooked is kept.
+// Failures are only logged.
+func ExpireStaleSessionsIfDue(ctx context.Context, currentSessionID string) {
+	logCtx := logging.WithComponent(ctx, "session-expiry")
+	ttl, err := SessionExpiryTTL()
+	if err != nil || ttl == 0 {
+		return
+	}
+	commonDir, err := GetGitCommonDir()
+	if err != nil {
+		return
+	}
+	stamp := fsenv.StateDir(commonDir, sessionExpiryStampFileName)
+	if data, err := os.ReadFile(stamp); err == nil { //nolint:gosec // path in the state dir
+		if checked, err := time.Parse(time.RFC3339, string(data)); err == nil && time.Since(checked) < sessionExpiryCheckInterval {
+			return
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(stamp), 0o750); err != nil {
+		return
+	}
+	// Stamped first, so hooks running at the same time don't all look
+	if err := os.WriteFile(stamp, []byte(time.Now().UTC().Format(time.RFC3339)), 0o600); err != nil {
+		return
+	}
+
+	expired, err := ExpireStaleSessions(ctx, ttl, currentSessionID, false)
+	if err != nil {
+		logging.Warn(logCtx, "failed to expire stale sessions", slog.String("error", err.Error()))
+	}
+	for _, e := range expired {
+		logging.Info(logCtx, "archived stale session",
+			slog.String("session_id", e.SessionID),
+			slog.String("phase", string(e.Phase)),
+			slog.Time("last_activity", e.LastActivity),
+			slog.String("archived_ref", e.ArchivedRef))
+	}
+}
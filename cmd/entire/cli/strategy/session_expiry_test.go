@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpireStaleSessions(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	// A crashed session on an older base commit, with its own shadow branch
+	weekAgo := time.Now().Add(-8 * 24 * time.Hour)
+	oldBase := writeShadowCommit(t, repo, "refs/heads/scratch", "old", head.Hash())
+	crashedBranch := checkpoint.ShadowBranchNameForCommit(oldBase.String(), "")
+	crashedTip := writeShadowCommit(t, repo, checkpoint.ShadowRefName(repo, crashedBranch), "2026-10-01-crashed", oldBase)
+	for _, state := range []*SessionState{
+		{SessionID: "2026-10-01-crashed", BaseCommit: oldBase.String(), Phase: session.PhaseActive, StartedAt: weekAgo, LastInteractionTime: &weekAgo},
+		// Stale too, but shares its shadow branch with a live session
+		{SessionID: "2026-10-01-idle", BaseCommit: head.Hash().String(), Phase: session.PhaseIdle, StartedAt: weekAgo.Add(-time.Hour)},
+		{SessionID: "2026-10-14-live", BaseCommit: head.Hash().String(), Phase: session.PhaseActive, StartedAt: time.Now()},
+		{SessionID: "2026-10-01-ended", BaseCommit: head.Hash().String(), Phase: session.PhaseEnded, StartedAt: weekAgo},
+	} {
+		require.NoError(t, SaveSessionState(state))
+	}
+	liveBranch := checkpoint.ShadowBranchNameForCommit(head.Hash().String(), "")
+	writeShadowCommit(t, repo, checkpoint.ShadowRefName(repo, liveBranch), "2026-10-14-live", head.Hash())
+
+	ctx := context.Background()
+	planned, err := ExpireStaleSessions(ctx, 7*24*time.Hour, "", true)
+	require.NoError(t, err)
+	require.Len(t, planned, 2)
+	state, err := LoadSessionState("2026-10-01-crashed")
+	require.NoError(t, err)
+	require.NotNil(t, state, "a dry run archived the session")
+
+	expired, err := ExpireStaleSessions(ctx, 7*24*time.Hour, "", false)
+	require.NoError(t, err)
+	require.Len(t, expired, 2)
+	assert.Equal(t, "2026-10-01-idle", expired[0].SessionID, "oldest first")
+	assert.Empty(t, expired[0].ArchivedRef, "a shadow branch a live session uses was archived")
+	crashed := expired[1]
+	assert.Equal(t, "2026-10-01-crashed", crashed.SessionID)
+	assert.Equal(t, ArchivedShadowRefPrefix+crashedBranch, crashed.ArchivedRef)
+
+	for _, id := range []string{"2026-10-01-crashed", "2026-10-01-idle"} {
+		state, err := LoadSessionState(id)
+		require.NoError(t, err)
+		assert.Nil(t, state, "%s still has its state", id)
+	}
+	_, err = os.Stat(crashed.ArchivedState)
+	require.NoError(t, err, "the archived state is missing")
+	_, err = repo.Reference(checkpoint.ShadowRefName(repo, crashedBranch), true)
+	require.ErrorIs(t, err, plumbing.ErrReferenceNotFound)
+	archived, err := repo.Reference(plumbing.ReferenceName(crashed.ArchivedRef), true)
+	require.NoError(t, err)
+	assert.Equal(t, crashedTip, archived.Hash())
+	_, err = repo.Reference(checkpoint.ShadowRefName(repo, liveBranch), true)
+	require.NoError(t, err, "the live session's shadow branch was archived")
+
+	states, err := ListSessionStates()
+	require.NoError(t, err)
+	assert.Len(t, states, 2, "the live and ended sessions stay")
+}
+
+func TestExpireStaleSessions_KeepsCurrentSession(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	weekAgo := time.Now().Add(-8 * 24 * time.Hour)
+	require.NoError(t, SaveSessionState(&SessionState{SessionID: "2026-10-01-resumed", Phase: session.PhaseIdle, StartedAt: weekAgo}))
+
+	expired, err := ExpireStaleSessions(context.Background(), 7*24*time.Hour, "2026-10-01-resumed", false)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+}
@@ -0,0 +1,161 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+)
+
+// Shadow branches and session state live in the git common dir, shared by every
+// worktree. Cleanup run from one worktree must not remove data that a session in
+// a sibling worktree still needs, so it counts references from the live sessions
+// of all worktrees rather than looking at the current one.
+
+// WorktreeInfo is a worktree registered with the repository.
+type WorktreeInfo struct {
+	Path   string
+	Head   string
+	Branch string // short branch name, empty if detached
+	// Prunable is true if git reports the worktree's directory as missing.
+	Prunable bool
+}
+
+// ListWorktrees returns the main worktree and all linked worktrees,
+// as reported by `git worktree list --porcelain`.
+func ListWorktrees() ([]WorktreeInfo, error) {
+	cmd := exec.CommandContext(context.Background(), "git", "worktree", "list", "--porcelain")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	return parseWorktreeList(string(output)), nil
+}
+
+// parseWorktreeList parses `git worktree list --porcelain` output: one block of
+// "key value" lines per worktree, separated by blank lines.
+func parseWorktreeList(output string) []WorktreeInfo {
+	var worktrees []WorktreeInfo
+	var current *WorktreeInfo
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, WorktreeInfo{Path: filepath.Clean(value)})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "prunable":
+			if current != nil {
+				current.Prunable = true
+			}
+		case "":
+			current = nil
+		}
+	}
+	return worktrees
+}
+
+// SessionRefs records which live sessions, in any worktree, still need each
+// shadow branch.
+type SessionRefs struct {
+	shadowBranches map[string][]*SessionState
+	running        map[string]*SessionState
+}
+
+// ShadowBranchUsers returns the live sessions that still need branch.
+func (r *SessionRefs) ShadowBranchUsers(branch string) []*SessionState {
+	return r.shadowBranches[branch]
+}
+
+// ShadowBranchRefCount returns how many live sessions still need branch.
+// A branch with a non-zero count must not be deleted.
+func (r *SessionRefs) ShadowBranchRefCount(branch string) int {
+	return len(r.shadowBranches[branch])
+}
+
+// IsRunning reports whether the session hasn't ended and its worktree still exists.
+func (r *SessionRefs) IsRunning(sessionID string) bool {
+	return r.running[sessionID] != nil
+}
+
+// CollectSessionRefs discovers the sessions of all worktrees (their state is
+// shared in the git common dir) and counts the shadow branches they reference.
+func CollectSessionRefs() (*SessionRefs, error) {
+	states, err := ListSessionStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session states: %w", err)
+	}
+	worktrees, err := ListWorktrees()
+	if err != nil {
+		// Without the worktree list, fall back to checking directories
+		worktrees = nil
+	}
+	return countSessionRefs(states, worktrees), nil
+}
+
+// countSessionRefs builds SessionRefs from session states:
+//   - A session that hasn't ended needs its shadow branch, unless its worktree is gone
+//   - An ended session still needs its shadow branch while it has checkpoints that
+//     were never condensed (`entire doctor` can still condense them)
+func countSessionRefs(states []*SessionState, worktrees []WorktreeInfo) *SessionRefs {
+	refs := &SessionRefs{
+		shadowBranches: make(map[string][]*SessionState),
+		running:        make(map[string]*SessionState),
+	}
+	registered := make(map[string]bool, len(worktrees))
+	for _, wt := range worktrees {
+		if !wt.Prunable {
+			registered[wt.Path] = true
+		}
+	}
+
+	for _, state := range states {
+		ended := state.EndedAt != nil || state.Phase == session.PhaseEnded
+		if ended && state.StepCount == 0 {
+			continue
+		}
+		if !worktreeExists(state.WorktreePath, registered) {
+			continue
+		}
+		if !ended {
+			refs.running[state.SessionID] = state
+		}
+		branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+		refs.shadowBranches[branch] = append(refs.shadowBranches[branch], state)
+	}
+	return refs
+}
+
+// worktreeExists reports whether the worktree a session ran in is still there.
+// Sessions that didn't record their worktree are assumed to be in one that exists.
+func worktreeExists(path string, registered map[string]bool) bool {
+	if path == "" || registered[filepath.Clean(path)] {
+		return true
+	}
+	// Not registered under this exact path (symlinks, or the worktree list is
+	// unavailable): the directory existing is enough to keep it
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// AcquireGCLock takes the repository-wide lock held while cleanup lists and
+// deletes data. Returns session.ErrGCRunning if another process holds it.
+func AcquireGCLock() (func(), error) {
+	store, err := session.NewStateStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state store: %w", err)
+	}
+	return store.LockGC() //nolint:wrapcheck // already wrapped by LockGC
+}
@@ -0,0 +1,112 @@
+package strategy
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorktreeList(t *testing.T) {
+	t.Parallel()
+
+	output := "worktree /repo\nHEAD 1111111111111111111111111111111111111111\nbranch refs/heads/main\n\n" +
+		"worktree /repo-feature\nHEAD 2222222222222222222222222222222222222222\ndetached\n\n" +
+		"worktree /gone\nHEAD 3333333333333333333333333333333333333333\nbranch refs/heads/old\nprunable gitdir file points to non-existent location\n\n"
+
+	got := parseWorktreeList(output)
+	want := []WorktreeInfo{
+		{Path: "/repo", Head: "1111111111111111111111111111111111111111", Branch: "main"},
+		{Path: "/repo-feature", Head: "2222222222222222222222222222222222222222"},
+		{Path: "/gone", Head: "3333333333333333333333333333333333333333", Branch: "old", Prunable: true},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestCountSessionRefs(t *testing.T) {
+	t.Parallel()
+
+	main := t.TempDir()
+	sibling := t.TempDir()
+	gone := filepath.Join(t.TempDir(), "removed")
+	ended := time.Now()
+	base := "abc1234def5678abc1234def5678abc1234def56"
+
+	states := []*SessionState{
+		{SessionID: "main-idle", BaseCommit: base, WorktreePath: main, Phase: session.PhaseIdle},
+		{SessionID: "sibling-active", BaseCommit: base, WorktreeID: "feature", WorktreePath: sibling, Phase: session.PhaseActive},
+		{SessionID: "ended-uncondensed", BaseCommit: base, WorktreeID: "done", WorktreePath: main, Phase: session.PhaseEnded, EndedAt: &ended, StepCount: 2},
+		{SessionID: "ended-condensed", BaseCommit: base, WorktreeID: "old", WorktreePath: main, Phase: session.PhaseEnded, EndedAt: &ended},
+		{SessionID: "worktree-removed", BaseCommit: base, WorktreeID: "gone", WorktreePath: gone, Phase: session.PhaseIdle},
+	}
+	refs := countSessionRefs(states, []WorktreeInfo{{Path: main}, {Path: sibling}, {Path: gone, Prunable: true}})
+
+	tests := []struct {
+		worktreeID string
+		wantCount  int
+	}{
+		{"", 1},
+		{"feature", 1},
+		{"done", 1},
+		{"old", 0},
+		{"gone", 0},
+	}
+	for _, tt := range tests {
+		branch := checkpoint.ShadowBranchNameForCommit(base, tt.worktreeID)
+		assert.Equal(t, tt.wantCount, refs.ShadowBranchRefCount(branch), "worktree %q", tt.worktreeID)
+	}
+
+	assert.True(t, refs.IsRunning("sibling-active"))
+	assert.False(t, refs.IsRunning("ended-uncondensed"), "ended sessions only pin their shadow branch")
+	assert.False(t, refs.IsRunning("worktree-removed"))
+}
+
+// TestListOrphanedItems_KeepsSiblingWorktreeSession checks that cleanup run in the
+// main worktree keeps the shadow branch of a session running in a linked worktree.
+func TestListOrphanedItems_KeepsSiblingWorktreeSession(t *testing.T) {
+	dir := setupGitRepo(t)
+	siblingDir := filepath.Join(t.TempDir(), "feature")
+	cmd := exec.Command("git", "worktree", "add", "-b", "feature", siblingDir)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	live := &SessionState{
+		SessionID:    "sibling-session",
+		BaseCommit:   head.Hash().String(),
+		WorktreeID:   "feature",
+		WorktreePath: siblingDir,
+		StartedAt:    time.Now().Add(-2 * time.Hour),
+		Phase:        session.PhaseIdle,
+		StepCount:    1,
+	}
+	require.NoError(t, SaveSessionState(live))
+
+	liveBranch := checkpoint.ShadowBranchNameForCommit(live.BaseCommit, live.WorktreeID)
+	staleBranch := "entire/" + head.Hash().String()[:7]
+	for _, branch := range []string{liveBranch, staleBranch} {
+		require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), head.Hash())))
+	}
+
+	items, err := (&ManualCommitStrategy{}).ListOrphanedItems()
+	require.NoError(t, err)
+	assert.Equal(t, []string{staleBranch}, cleanupItemIDs(items))
+
+	states, err := ListOrphanedSessionStates()
+	require.NoError(t, err)
+	assert.Empty(t, states, "session running in the sibling worktree must not be orphaned")
+}
@@ -0,0 +1,245 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// An agent restarted with the same session ID (claude --resume) finds the
+// session as it was left, unless HEAD moved while the agent wasn't running,
+// the session expired and was archived, or its shadow branch is gone.
+// ResumeSession puts the session back in order when it starts, instead of
+// leaving the next prompt to start it over, and records what it found in
+// the session state.
+
+// loadResumedSessionState loads the session's state, bringing it back from
+// the archive if it expired. Returns nil if Entire doesn't know the session.
+func loadResumedSessionState(ctx context.Context, sessionID string) (*SessionState, bool, error) {
+	store, err := sessionStateStore()
+	if err != nil {
+		return nil, false, err
+	}
+	state, err := store.Load(ctx, sessionID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load session state: %w", err)
+	}
+	if state != nil {
+		return state, false, nil
+	}
+	state, err = store.Unarchive(ctx, sessionID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to unarchive session state: %w", err)
+	}
+	return state, state != nil, nil
+}
+
+// saveResume records the resume in the session state and logs it. apply
+// makes the resume's changes to the state; it runs under the state lock on
+// the latest state, since putting the session back in order may take a while.
+func saveResume(ctx context.Context, state *SessionState, record session.ResumeRecord, apply func(*SessionState)) (*session.ResumeRecord, error) {
+	err := UpdateSessionState(state.SessionID, func(latest *SessionState) error {
+		apply(latest)
+		latest.RecordResume(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.Info(logging.WithComponent(ctx, "session-resume"), "session resumed",
+		slog.String("session_id", state.SessionID),
+		slog.String("source", record.Source),
+		slog.String("previous_base", truncateHash(record.PreviousBase)),
+		slog.String("base", truncateHash(record.Base)),
+		slog.Bool("unarchived", record.Unarchived),
+		slog.String("checkpoints", record.Checkpoints),
+		slog.Int("lost_checkpoints", record.LostCheckpoints))
+	return &record, nil
+}
+
+// ResumeSession re-validates the session's base commit and shadow branch
+// when the agent resumes it: an archived session is unarchived along with
+// its shadow branch, a branch left on the old base is moved to HEAD, and a
+// lost branch is rebuilt from the transcript if it can be. Checkpoints that
+// can't be recovered are dropped, so the cycle starts from HEAD.
+func (s *ManualCommitStrategy) ResumeSession(ctx context.Context, sessionID, source string) (*session.ResumeRecord, error) {
+	state, unarchived, err := loadResumedSessionState(ctx, sessionID)
+	if err != nil || state == nil || state.BaseCommit == "" {
+		return nil, err
+	}
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	record := session.ResumeRecord{At: time.Now(), Source: source, Unarchived: unarchived}
+
+	branch := getShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+	hasBranch := shadowBranchExists(repo, branch)
+	if !hasBranch {
+		if hasBranch, err = restoreArchivedShadowBranch(ctx, repo, branch); err != nil {
+			return nil, err
+		}
+		if hasBranch {
+			record.Checkpoints = session.ResumeCheckpointsRestored
+		}
+	}
+
+	if base := state.BaseCommit; base != head.Hash().String() {
+		record.PreviousBase = base
+		if _, err := repo.CommitObject(plumbing.NewHash(base)); err != nil {
+			record.BaseMissing = true
+		}
+		if record.BaseMissing && state.AttributionBaseCommit == base {
+			// Attribution can't be measured from a commit that's gone
+			state.AttributionBaseCommit = head.Hash().String()
+		}
+		if _, err := s.migrateShadowBranchIfNeeded(repo, state); err != nil {
+			return nil, err
+		}
+		if hasBranch && record.Checkpoints == "" {
+			record.Checkpoints = session.ResumeCheckpointsMoved
+		}
+	}
+	if hasBranch && record.Checkpoints == "" && state.StepCount > 0 {
+		record.Checkpoints = session.ResumeCheckpointsKept
+	}
+
+	if !hasBranch && state.StepCount > 0 {
+		if NeedsReconstruction(repo, state) {
+			if _, err := ReconstructSession(ctx, state, false); err == nil {
+				record.Checkpoints = session.ResumeCheckpointsReconstructed
+			} else {
+				logging.Warn(logging.WithComponent(ctx, "session-resume"), "failed to reconstruct shadow branch",
+					slog.String("session_id", state.SessionID),
+					slog.String("error", err.Error()))
+			}
+		}
+		if record.Checkpoints != session.ResumeCheckpointsReconstructed {
+			record.Checkpoints = session.ResumeCheckpointsLost
+			record.LostCheckpoints = state.StepCount
+			state.StepCount = 0
+			state.CheckpointEpochs = nil
+			state.PromptAttributions = nil
+			state.ReconstructionConfidence = 0
+		}
+	}
+
+	record.Base = state.BaseCommit
+	return saveResume(ctx, state, record, func(latest *SessionState) {
+		if record.PreviousBase != "" {
+			latest.BaseCommit = record.Base
+			if record.BaseMissing && latest.AttributionBaseCommit == record.PreviousBase {
+				latest.AttributionBaseCommit = record.Base
+			}
+		}
+		if record.Checkpoints == session.ResumeCheckpointsLost {
+			latest.StepCount = 0
+			latest.CheckpointEpochs = nil
+			latest.PromptAttributions = nil
+			latest.ReconstructionConfidence = 0
+		}
+	})
+}
+
+// shadowBranchExists reports whether the shadow branch exists.
+func shadowBranchExists(repo *git.Repository, branch string) bool {
+	_, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+	return err == nil
+}
+
+// restoreArchivedShadowBranch moves a shadow branch archived with its
+// expired session back. Returns false if it wasn't archived.
+func restoreArchivedShadowBranch(ctx context.Context, repo *git.Repository, branch string) (bool, error) {
+	archivedRef := ArchivedShadowRefPrefix + branch
+	ref, err := repo.Reference(plumbing.ReferenceName(archivedRef), true)
+	if err != nil {
+		return false, nil //nolint:nilerr // not archived
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(checkpoint.ShadowRefName(repo, branch), ref.Hash())); err != nil {
+		return false, fmt.Errorf("failed to restore %s: %w", branch, err)
+	}
+	// go-git's RemoveReference doesn't persist with packed refs
+	cmd := exec.CommandContext(ctx, "git", "update-ref", "-d", archivedRef) //nolint:gosec // archivedRef comes from internal shadow branch naming
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return true, fmt.Errorf("failed to remove %s: %s: %w", archivedRef, strings.TrimSpace(string(output)), err)
+	}
+	return true, nil
+}
+
+// ResumeSession unarchives an expired session and records the resume.
+// Auto-commit checkpoints are commits already, so there is nothing to
+// re-link; a moved HEAD only becomes the session's new base.
+func (s *AutoCommitStrategy) ResumeSession(ctx context.Context, sessionID, source string) (*session.ResumeRecord, error) {
+	state, unarchived, err := loadResumedSessionState(ctx, sessionID)
+	if err != nil || state == nil || state.BaseCommit == "" {
+		return nil, err
+	}
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	record := session.ResumeRecord{At: time.Now(), Source: source, Unarchived: unarchived}
+	if base := state.BaseCommit; base != head.Hash().String() {
+		record.PreviousBase = base
+		if _, err := repo.CommitObject(plumbing.NewHash(base)); err != nil {
+			record.BaseMissing = true
+		}
+		state.BaseCommit = head.Hash().String()
+	}
+	record.Base = state.BaseCommit
+	return saveResume(ctx, state, record, func(latest *SessionState) {
+		latest.BaseCommit = record.Base
+	})
+}
+
+// DescribeResume summarizes what a resume found, e.g. "HEAD moved from
+// 1a2b3c4 to 5d6e7f8; its checkpoints were moved onto HEAD", "" if the
+// session continued where it was left.
+func DescribeResume(r *session.ResumeRecord) string {
+	if r == nil {
+		return ""
+	}
+	var parts []string
+	if r.Unarchived {
+		parts = append(parts, "had expired and was restored")
+	}
+	if r.PreviousBase != "" {
+		moved := fmt.Sprintf("HEAD moved from %s to %s", truncateHash(r.PreviousBase), truncateHash(r.Base))
+		if r.BaseMissing {
+			moved += fmt.Sprintf(" (%s no longer exists)", truncateHash(r.PreviousBase))
+		}
+		parts = append(parts, moved)
+	}
+	switch r.Checkpoints {
+	case session.ResumeCheckpointsMoved:
+		parts = append(parts, "its checkpoints were moved onto HEAD")
+	case session.ResumeCheckpointsRestored:
+		parts = append(parts, "its checkpoints were restored from the archive")
+	case session.ResumeCheckpointsReconstructed:
+		parts = append(parts, "its checkpoints were rebuilt from the transcript")
+	case session.ResumeCheckpointsLost:
+		parts = append(parts, fmt.Sprintf("%d checkpoint(s) since the last commit were lost", r.LostCheckpoints))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "; ")
+}
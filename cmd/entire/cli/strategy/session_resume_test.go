@@ -0,0 +1,133 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualCommitStrategy_ResumeSession(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	ctx := context.Background()
+	s := &ManualCommitStrategy{}
+
+	record, err := s.ResumeSession(ctx, "2026-10-14-unknown", "resume")
+	require.NoError(t, err)
+	assert.Nil(t, record, "a session Entire doesn't know was recorded as resumed")
+
+	// Archived while the agent was closed, and HEAD moved on since
+	weekAgo := time.Now().Add(-8 * 24 * time.Hour)
+	oldBase := writeShadowCommit(t, repo, "refs/heads/scratch", "old", head.Hash())
+	oldBranch := checkpoint.ShadowBranchNameForCommit(oldBase.String(), "")
+	tip := writeShadowCommit(t, repo, checkpoint.ShadowRefName(repo, oldBranch), "2026-10-01-expired", oldBase)
+	require.NoError(t, SaveSessionState(&SessionState{
+		SessionID: "2026-10-01-expired", BaseCommit: oldBase.String(), Phase: session.PhaseIdle,
+		StartedAt: weekAgo, LastInteractionTime: &weekAgo, StepCount: 2,
+	}))
+	expired, err := ExpireStaleSessions(ctx, 7*24*time.Hour, "", false)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+
+	record, err = s.ResumeSession(ctx, "2026-10-01-expired", "resume")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.Unarchived)
+	assert.Equal(t, oldBase.String(), record.PreviousBase)
+	assert.Equal(t, head.Hash().String(), record.Base)
+	assert.False(t, record.BaseMissing)
+	assert.Equal(t, session.ResumeCheckpointsRestored, record.Checkpoints)
+	newBranch := checkpoint.ShadowBranchNameForCommit(head.Hash().String(), "")
+	ref, err := repo.Reference(checkpoint.ShadowRefName(repo, newBranch), true)
+	require.NoError(t, err, "the restored shadow branch wasn't moved onto HEAD")
+	assert.Equal(t, tip, ref.Hash())
+	_, err = repo.Reference(plumbing.ReferenceName(ArchivedShadowRefPrefix+oldBranch), true)
+	require.ErrorIs(t, err, plumbing.ErrReferenceNotFound, "the archived shadow branch is left behind")
+	state, err := LoadSessionState("2026-10-01-expired")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, head.Hash().String(), state.BaseCommit)
+	assert.Equal(t, 2, state.StepCount)
+	require.Len(t, state.Resumes, 1)
+	assert.Equal(t, "resume", state.Resumes[0].Source)
+	assert.Equal(t, "had expired and was restored; HEAD moved from "+oldBase.String()[:7]+" to "+head.Hash().String()[:7]+"; its checkpoints were restored from the archive", DescribeResume(record))
+
+	// Resumed again as it was left
+	record, err = s.ResumeSession(ctx, "2026-10-01-expired", "resume")
+	require.NoError(t, err)
+	assert.Equal(t, session.ResumeCheckpointsKept, record.Checkpoints)
+	assert.Empty(t, DescribeResume(record))
+}
+
+func TestManualCommitStrategy_ResumeSession_LostCheckpoints(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	// The shadow branch was deleted and there is no transcript to rebuild it from
+	missingBase := "0123456789abcdef0123456789abcdef01234567"
+	require.NoError(t, SaveSessionState(&SessionState{
+		SessionID: "2026-10-14-lost", BaseCommit: missingBase, AttributionBaseCommit: missingBase,
+		StartedAt: time.Now(), StepCount: 3, PromptAttributions: []session.PromptAttribution{{CheckpointNumber: 1}},
+	}))
+
+	record, err := (&ManualCommitStrategy{}).ResumeSession(context.Background(), "2026-10-14-lost", "")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.BaseMissing)
+	assert.Equal(t, session.ResumeCheckpointsLost, record.Checkpoints)
+	assert.Equal(t, 3, record.LostCheckpoints)
+	assert.Contains(t, DescribeResume(record), "(0123456 no longer exists); 3 checkpoint(s) since the last commit were lost")
+
+	state, err := LoadSessionState("2026-10-14-lost")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, 0, state.StepCount, "lost checkpoints are still counted")
+	assert.Empty(t, state.PromptAttributions)
+	assert.Equal(t, head.Hash().String(), state.BaseCommit)
+	assert.Equal(t, head.Hash().String(), state.AttributionBaseCommit, "attribution still measured from a missing commit")
+}
+
+func TestSaveResume_KeepsConcurrentSaves(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	require.NoError(t, SaveSessionState(&SessionState{SessionID: "2026-10-14-resumed", BaseCommit: "old", StepCount: 1}))
+	stale, err := LoadSessionState("2026-10-14-resumed")
+	require.NoError(t, err)
+
+	// A hook saves the session while the resume is putting it in order
+	require.NoError(t, UpdateSessionState("2026-10-14-resumed", func(s *SessionState) error {
+		s.StepCount = 2
+		s.FirstPrompt = "fix the login bug"
+		return nil
+	}))
+
+	record := session.ResumeRecord{Source: "resume", PreviousBase: "old", Base: "new"}
+	_, err = saveResume(context.Background(), stale, record, func(latest *SessionState) {
+		latest.BaseCommit = record.Base
+	})
+	require.NoError(t, err)
+
+	state, err := LoadSessionState("2026-10-14-resumed")
+	require.NoError(t, err)
+	assert.Equal(t, "new", state.BaseCommit)
+	assert.Equal(t, 2, state.StepCount)
+	assert.Equal(t, "fix the login bug", state.FirstPrompt)
+	require.Len(t, state.Resumes, 1)
+}
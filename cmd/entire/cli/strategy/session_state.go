@@ -1,105 +1,213 @@
 package strategy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
 
-	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
 	"github.com/entireio/cli/cmd/entire/cli/logging"
 	"github.com/entireio/cli/cmd/entire/cli/session"
-	"github.com/entireio/cli/cmd/entire/cli/validation"
 )
 
 // Session state management functions shared across all strategies.
-// SessionState is stored in .git/entire-sessions/{session_id}.json
+// SessionState is stored in .git/entire-sessions/{session_id}.json, or
+// .git/entire-sessions/worktrees/{worktree_id}/{session_id}.json for linked worktrees.
 
 // getSessionStateDir returns the path to the session state directory.
-// This is stored in the git common dir so it's shared across all worktrees.
+// This is stored in the git common dir so it's shared across all worktrees,
+// unless the git dir is read-only or on a network filesystem (see fsenv.StateDir).
 func getSessionStateDir() (string, error) {
 	commonDir, err := GetGitCommonDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(commonDir, session.SessionStateDirName), nil
+	return fsenv.StateDir(commonDir, session.SessionStateDirName), nil
 }
 
-// sessionStateFile returns the path to a session state file.
-func sessionStateFile(sessionID string) (string, error) {
-	stateDir, err := getSessionStateDir()
+// LoadSessionState loads the session state for the given session ID,
+// preferring the current worktree's if the session exists in several.
+// Returns (nil, nil) when session file doesn't exist (not an error condition).
+func LoadSessionState(sessionID string) (*SessionState, error) {
+	store, err := sessionStateStore()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return filepath.Join(stateDir, sessionID+".json"), nil
+	state, err := store.Load(context.Background(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session state: %w", err)
+	}
+	return state, nil
 }
 
-// LoadSessionState loads the session state for the given session ID.
-// Returns (nil, nil) when session file doesn't exist (not an error condition).
-func LoadSessionState(sessionID string) (*SessionState, error) {
-	// Validate session ID to prevent path traversal
-	if err := validation.ValidateSessionID(sessionID); err != nil {
-		return nil, fmt.Errorf("invalid session ID: %w", err)
+// SaveSessionState saves the session state atomically.
+func SaveSessionState(state *SessionState) error {
+	store, err := sessionStateStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Save(context.Background(), state); err != nil {
+		return fmt.Errorf("failed to save session state: %w", err)
 	}
+	return nil
+}
 
-	stateFile, err := sessionStateFile(sessionID)
+// UpdateSessionState applies fn to the session state and saves the result,
+// holding the state lock so concurrent hooks and commands don't overwrite each
+// other's changes. It's a no-op if the session has no state. If fn returns an
+// error, nothing is saved.
+func UpdateSessionState(sessionID string, fn func(*SessionState) error) error {
+	store, err := sessionStateStore()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get session state file path: %w", err)
+		return err
 	}
+	return updateSessionStateIn(store, sessionID, fn)
+}
 
-	data, err := os.ReadFile(stateFile) //nolint:gosec // stateFile is derived from sessionID, not user input
-	if os.IsNotExist(err) {
-		return nil, nil //nolint:nilnil // nil,nil indicates session not found (expected case)
-	}
+func updateSessionStateIn(store *session.StateStore, sessionID string, fn func(*SessionState) error) error {
+	err := store.Update(context.Background(), sessionID, func(state *SessionState) (*SessionState, error) {
+		if state == nil {
+			return nil, nil //nolint:nilnil // no state, nothing to save
+		}
+		if err := fn(state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read session state: %w", err)
+		return fmt.Errorf("failed to update session state: %w", err)
 	}
+	return nil
+}
 
-	var state SessionState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal session state: %w", err)
+// UpsertSessionState is UpdateSessionState for callers that create missing
+// state: fn gets nil if the session has none and returns the state to save,
+// nil to save nothing.
+func UpsertSessionState(sessionID string, fn func(*SessionState) (*SessionState, error)) error {
+	store, err := sessionStateStore()
+	if err != nil {
+		return err
 	}
-	state.NormalizeAfterLoad()
-	return &state, nil
+	if err := store.Update(context.Background(), sessionID, fn); err != nil {
+		return fmt.Errorf("failed to update session state: %w", err)
+	}
+	return nil
 }
 
-// SaveSessionState saves the session state atomically.
-func SaveSessionState(state *SessionState) error {
-	// Validate session ID to prevent path traversal
-	if err := validation.ValidateSessionID(state.SessionID); err != nil {
-		return fmt.Errorf("invalid session ID: %w", err)
-	}
+// SessionStateChanges remembers a session state as it was loaded, so changes
+// made to it while not holding the state lock (during slow git work, say) can
+// be saved without overwriting what other processes saved meanwhile.
+type SessionStateChanges struct {
+	state  *SessionState
+	loaded map[string]json.RawMessage
+	store  func() (*session.StateStore, error)
+}
 
-	stateDir, err := getSessionStateDir()
+// TrackSessionState starts tracking changes to state, which should have just
+// been loaded.
+func TrackSessionState(state *SessionState) *SessionStateChanges {
+	return trackSessionState(state, sessionStateStore)
+}
+
+func trackSessionState(state *SessionState, store func() (*session.StateStore, error)) *SessionStateChanges {
+	loaded, err := stateFields(state)
 	if err != nil {
-		return fmt.Errorf("failed to get session state directory: %w", err)
+		loaded = nil // Save then saves every field
 	}
+	return &SessionStateChanges{state: state, loaded: loaded, store: store}
+}
 
-	if err := os.MkdirAll(stateDir, 0o750); err != nil {
-		return fmt.Errorf("failed to create session state directory: %w", err)
+// Save writes the fields of the tracked state that changed since it was
+// loaded (or last saved) to the state on disk, under the state lock, and
+// updates the tracked state to the result. Fields other processes changed
+// meanwhile are kept unless this process changed them too. It's a no-op if
+// the session state was removed meanwhile.
+func (c *SessionStateChanges) Save() error {
+	store, err := c.store()
+	if err != nil {
+		return err
+	}
+	ours, err := stateFields(c.state)
+	if err != nil {
+		return err
+	}
+	var merged *SessionState
+	err = store.Update(context.Background(), c.state.SessionID, func(latest *SessionState) (*SessionState, error) {
+		if latest == nil {
+			return nil, nil //nolint:nilnil // the session was removed, nothing to save
+		}
+		fields, err := stateFields(latest)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range ours {
+			if !bytes.Equal(value, c.loaded[name]) {
+				fields[name] = value
+			}
+		}
+		for name := range c.loaded {
+			if _, ok := ours[name]; !ok {
+				delete(fields, name) // Cleared by this process
+			}
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal session state: %w", err)
+		}
+		merged = &SessionState{}
+		if err := json.Unmarshal(data, merged); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session state: %w", err)
+		}
+		return merged, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session state: %w", err)
+	}
+	if merged != nil {
+		*c.state = *merged
+		if c.loaded, err = stateFields(merged); err != nil {
+			c.loaded = nil
+		}
 	}
+	return nil
+}
 
-	data, err := jsonutil.MarshalIndentWithNewline(state, "", "  ")
+// stateFields returns the JSON fields of state.
+func stateFields(state *SessionState) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(state)
 	if err != nil {
-		return fmt.Errorf("failed to marshal session state: %w", err)
+		return nil, fmt.Errorf("failed to marshal session state: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session state: %w", err)
 	}
+	return fields, nil
+}
 
-	stateFile, err := sessionStateFile(state.SessionID)
+// sessionStateStore returns a StateStore for the session state directory.
+func sessionStateStore() (*session.StateStore, error) {
+	stateDir, err := getSessionStateDir()
 	if err != nil {
-		return fmt.Errorf("failed to get session state file path: %w", err)
+		return nil, fmt.Errorf("failed to get session state directory: %w", err)
 	}
+	return session.NewStateStoreWithDir(stateDir).ForWorktree(currentWorktreeID()), nil
+}
 
-	// Atomic write: write to temp file, then rename
-	tmpFile := stateFile + ".tmp"
-	if err := os.WriteFile(tmpFile, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write session state: %w", err)
+// currentWorktreeID returns the worktree ID sessions started here get
+// (see ShadowWorktreeID), or "" if it can't be determined.
+func currentWorktreeID() string {
+	worktreePath, err := GetWorktreePath()
+	if err != nil {
+		return ""
 	}
-	if err := os.Rename(tmpFile, stateFile); err != nil {
-		return fmt.Errorf("failed to rename session state file: %w", err)
+	worktreeID, err := ShadowWorktreeID(worktreePath)
+	if err != nil {
+		return ""
 	}
-	return nil
+	return worktreeID
 }
 
 // ListSessionStates returns all session states from the state directory.
@@ -197,21 +305,12 @@ func TransitionAndLog(state *SessionState, event session.Event, ctx session.Tran
 
 // ClearSessionState removes the session state file for the given session ID.
 func ClearSessionState(sessionID string) error {
-	// Validate session ID to prevent path traversal
-	if err := validation.ValidateSessionID(sessionID); err != nil {
-		return fmt.Errorf("invalid session ID: %w", err)
-	}
-
-	stateFile, err := sessionStateFile(sessionID)
+	store, err := sessionStateStore()
 	if err != nil {
-		return fmt.Errorf("failed to get session state file path: %w", err)
+		return err
 	}
-
-	if err := os.Remove(stateFile); err != nil {
-		if os.IsNotExist(err) {
-			return nil // Already gone, not an error
-		}
-		return fmt.Errorf("failed to remove session state file: %w", err)
+	if err := store.Clear(context.Background(), sessionID); err != nil {
+		return fmt.Errorf("failed to clear session state: %w", err)
 	}
 	return nil
 }
@@ -1,10 +1,15 @@
 package strategy
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
 	"github.com/go-git/go-git/v5"
 )
 
@@ -380,3 +385,170 @@ func TestFindMostRecentSession_FallsBackWhenNoWorktreeMatch(t *testing.T) {
 		t.Logf("cleanup warning: %v", err)
 	}
 }
+
+// TestSessionStateChanges_KeepsConcurrentSaves verifies that saving tracked
+// changes keeps fields another process saved since the state was loaded.
+func TestSessionStateChanges_KeepsConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	t.Chdir(dir)
+
+	sessionID := "test-session-changes"
+	if err := SaveSessionState(&SessionState{SessionID: sessionID, StepCount: 1, Title: "old"}); err != nil {
+		t.Fatalf("SaveSessionState() error = %v", err)
+	}
+	state, err := LoadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	changes := TrackSessionState(state)
+
+	// Another hook saves while this one works
+	err = UpdateSessionState(sessionID, func(other *SessionState) error {
+		other.Title = "renamed"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateSessionState() error = %v", err)
+	}
+
+	state.StepCount = 2
+	if err := changes.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if state.Title != "renamed" {
+		t.Errorf("tracked state Title = %q, want the saved %q", state.Title, "renamed")
+	}
+
+	loaded, err := LoadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	if loaded.StepCount != 2 {
+		t.Errorf("StepCount = %d, want 2", loaded.StepCount)
+	}
+	if loaded.Title != "renamed" {
+		t.Errorf("Title = %q, want %q from the concurrent save", loaded.Title, "renamed")
+	}
+}
+
+func TestUpsertSessionState(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	t.Chdir(dir)
+
+	sessionID := "test-session-upsert"
+	upsert := func() {
+		t.Helper()
+		err := UpsertSessionState(sessionID, func(state *SessionState) (*SessionState, error) {
+			if state == nil {
+				state = &SessionState{SessionID: sessionID, Title: "created"}
+			}
+			state.StepCount++
+			return state, nil
+		})
+		if err != nil {
+			t.Fatalf("UpsertSessionState() error = %v", err)
+		}
+	}
+	upsert()
+	upsert()
+
+	loaded, err := LoadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("LoadSessionState() error = %v", err)
+	}
+	if loaded == nil || loaded.StepCount != 2 || loaded.Title != "created" {
+		t.Errorf("state = %+v, want it created once and updated once", loaded)
+	}
+
+	if err := UpsertSessionState("test-session-none", func(*SessionState) (*SessionState, error) { return nil, nil }); err != nil { //nolint:nilnil // create nothing
+		t.Fatalf("UpsertSessionState() error = %v", err)
+	}
+	if none, err := LoadSessionState("test-session-none"); err != nil || none != nil {
+		t.Errorf("LoadSessionState() = %+v, %v; want no state", none, err)
+	}
+}
+
+// TestSaveChanges_ConcurrentHookUpdates runs checkpoint saves while other
+// hooks update the same session, and checks that no update is lost.
+func TestSaveChanges_ConcurrentHookUpdates(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	s := &ManualCommitStrategy{}
+	sessionID := "test-concurrent-hooks"
+	if err := s.InitializeSession(sessionID, "Claude Code", "", ""); err != nil {
+		t.Fatalf("InitializeSession() error = %v", err)
+	}
+	metadataDir := ".entire/metadata/" + sessionID
+	metadataDirAbs := filepath.Join(dir, metadataDir)
+	if err := os.MkdirAll(metadataDirAbs, 0o755); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+
+	const checkpoints = 5
+	const hooks = 4
+	const editsPerHook = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, checkpoints+hooks*editsPerHook)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range checkpoints {
+			if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte(fmt.Sprintf("agent change %d", i)), 0o644); err != nil {
+				errs <- err
+				return
+			}
+			errs <- s.SaveChanges(SaveContext{
+				SessionID:      sessionID,
+				ModifiedFiles:  []string{"test.txt"},
+				MetadataDir:    metadataDir,
+				MetadataDirAbs: metadataDirAbs,
+				AuthorName:     "Test",
+				AuthorEmail:    "test@test.com",
+			})
+		}
+	}()
+	for h := range hooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range editsPerHook {
+				errs <- UpdateSessionState(sessionID, func(state *SessionState) error {
+					state.HumanEdits = append(state.HumanEdits, session.HumanEdit{
+						At:    time.Now(),
+						Files: []string{fmt.Sprintf("hook-%d-%d", h, i)},
+					})
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent update error = %v", err)
+		}
+	}
+
+	state, err := s.loadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("loadSessionState() error = %v", err)
+	}
+	if state.StepCount != checkpoints {
+		t.Errorf("StepCount = %d, want %d", state.StepCount, checkpoints)
+	}
+	if len(state.CheckpointEpochs) == 0 || state.CheckpointEpochs[len(state.CheckpointEpochs)-1].LastStep != checkpoints {
+		t.Errorf("CheckpointEpochs = %+v, want one ending at step %d", state.CheckpointEpochs, checkpoints)
+	}
+	if len(state.HumanEdits) != hooks*editsPerHook {
+		t.Errorf("HumanEdits = %d, want %d (updates lost)", len(state.HumanEdits), hooks*editsPerHook)
+	}
+}
@@ -0,0 +1,144 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// SessionStatus is where a session of the current worktree stands relative
+// to HEAD, for `entire status`.
+type SessionStatus struct {
+	State *SessionState
+
+	// Head is the commit HEAD points at.
+	Head string
+
+	// UncommittedFiles are the files the agent touched that still have
+	// uncommitted changes (staged, unstaged or untracked).
+	UncommittedFiles []string
+
+	// HeadAhead is true when HEAD moved past the session's base commit (the
+	// base is an ancestor), false when HEAD is at the base or diverged from
+	// it (rebase, reset, branch switch).
+	HeadAhead bool
+
+	// ShadowBranch is the session's shadow branch. MigrationPending is true
+	// when it's still on the old base and moves to HEAD on the next prompt.
+	ShadowBranch     string
+	MigrationPending bool
+}
+
+// Diverged reports whether HEAD is somewhere other than the session's base
+// commit.
+func (s SessionStatus) Diverged() bool {
+	return s.State.BaseCommit != "" && s.State.BaseCommit != s.Head
+}
+
+// WorktreeSessionStatuses returns the status of each session of the current
+// worktree that hasn't ended, most recently started first.
+func WorktreeSessionStatuses(ctx context.Context) ([]SessionStatus, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, err
+	}
+	worktreePath, err := GetWorktreePath()
+	if err != nil {
+		return nil, err
+	}
+	s := &ManualCommitStrategy{}
+	sessions, err := s.findSessionsForWorktree(worktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	var statuses []SessionStatus
+	for _, state := range sessions {
+		if state.EndedAt != nil {
+			continue
+		}
+		status := SessionStatus{
+			State:        state,
+			Head:         head.Hash().String(),
+			ShadowBranch: checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID),
+		}
+		status.UncommittedFiles, err = uncommittedFiles(ctx, worktreePath, state.FilesTouched)
+		if err != nil {
+			return nil, err
+		}
+		if status.Diverged() {
+			status.HeadAhead = isAncestorOf(repo, plumbing.NewHash(state.BaseCommit), head.Hash())
+			_, refErr := repo.Reference(checkpoint.ShadowRefName(repo, status.ShadowBranch), true)
+			status.MigrationPending = refErr == nil &&
+				status.ShadowBranch != checkpoint.ShadowBranchNameForCommit(status.Head, state.WorktreeID)
+		}
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses, func(a, b SessionStatus) int {
+		return b.State.StartedAt.Compare(a.State.StartedAt)
+	})
+	return statuses, nil
+}
+
+// uncommittedFiles returns the files of touched that `git status` reports
+// changes for, in touched's order.
+func uncommittedFiles(ctx context.Context, worktreePath string, touched []string) ([]string, error) {
+	if len(touched) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"status", "--porcelain", "-z", "--"}, touched...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = worktreePath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	// Format: XY filename\0, renames and copies add the old name as an entry
+	changed := make(map[string]bool)
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 3 {
+			continue
+		}
+		changed[entry[3:]] = true
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+
+	var files []string
+	for _, file := range touched {
+		if changed[file] {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// isAncestorOf reports whether ancestor is an ancestor of commit; false if
+// either can't be read.
+func isAncestorOf(repo *git.Repository, ancestor, commit plumbing.Hash) bool {
+	a, err := repo.CommitObject(ancestor)
+	if err != nil {
+		return false
+	}
+	c, err := repo.CommitObject(commit)
+	if err != nil {
+		return false
+	}
+	ok, err := a.IsAncestor(c)
+	return err == nil && ok
+}
@@ -0,0 +1,51 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeSessionStatuses(t *testing.T) {
+	dir := setupGitRepo(t)
+	t.Chdir(dir)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	s := &ManualCommitStrategy{}
+	const sessionID = "test-session-status"
+	setupSessionWithCheckpoint(t, s, repo, dir, sessionID)
+	state, err := s.loadSessionState(sessionID)
+	require.NoError(t, err)
+	state.Phase = session.PhaseIdle
+	state.FilesTouched = []string{"test.txt", "new.txt"}
+	require.NoError(t, s.saveSessionState(state))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte("agent change\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0o644))
+
+	statuses, err := WorktreeSessionStatuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, sessionID, statuses[0].State.SessionID)
+	assert.ElementsMatch(t, []string{"test.txt", "new.txt"}, statuses[0].UncommittedFiles)
+	assert.False(t, statuses[0].Diverged())
+	assert.False(t, statuses[0].MigrationPending)
+
+	// Committing without the hooks leaves the session on its old base
+	head := commitTestFile(t, repo, dir, "committed")
+	statuses, err = WorktreeSessionStatuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, head.String(), statuses[0].Head)
+	assert.Equal(t, []string{"new.txt"}, statuses[0].UncommittedFiles)
+	assert.True(t, statuses[0].Diverged())
+	assert.True(t, statuses[0].HeadAhead)
+	assert.True(t, statuses[0].MigrationPending)
+}
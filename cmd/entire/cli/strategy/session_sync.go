@@ -0,0 +1,395 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Shadow branches carry a session's checkpoints, but continuing the session
+// on another machine also needs its state: the attribution baselines, the
+// transcript offset of the current cycle and the live transcript itself.
+// `entire sync push` pushes them next to the shadow branches:
+//
+//	local and remote  refs/entire/sessions/<session-id>
+//
+// Each push of a changed state is a commit on the session's ref holding
+// state.json and transcript.jsonl. It builds on the state last pushed or
+// pulled here, so a push that would drop another machine's newer state is
+// rejected like any non-fast-forward push. `entire sessions pull` fetches
+// one session's ref and shadow branch.
+
+// SessionSyncRefPrefix is where session states are stored, locally and on
+// the remote, followed by the session ID.
+const SessionSyncRefPrefix = "refs/entire/sessions/"
+
+// Files of a session state commit
+const (
+	sessionSyncStateFile      = "state.json"
+	sessionSyncTranscriptFile = "transcript.jsonl"
+)
+
+// ErrSessionNotSynced is returned by PullSessionState when the remote has
+// no state of the session.
+var ErrSessionNotSynced = errors.New("session not pushed to the remote")
+
+// ErrLocalSessionNewer is returned by PullSessionState when the session was
+// active here after the remote's state was pushed.
+var ErrLocalSessionNewer = errors.New("session has newer activity here")
+
+// ErrSessionBaseMissing is returned by PullSessionState when a commit the
+// session's checkpoints or attribution build on isn't in this repository.
+var ErrSessionBaseMissing = errors.New("commit not in this repository")
+
+// SessionSyncTrackingPrefix returns where pulled session states of remote
+// are kept.
+func SessionSyncTrackingPrefix(remote string) string {
+	return "refs/entire/remotes/" + remote + "/sessions/"
+}
+
+// sessionSyncRefName returns the ref of a session's state, and whether the
+// session ID can be used in a ref name.
+func sessionSyncRefName(sessionID string) (plumbing.ReferenceName, bool) {
+	name := plumbing.ReferenceName(SessionSyncRefPrefix + sessionID)
+	return name, name.Validate() == nil
+}
+
+// SessionSyncResult is what a session state push did, by session ID.
+type SessionSyncResult struct {
+	Pushed   []string
+	UpToDate []string
+	// Behind are sessions whose state on the remote is newer than the one
+	// last pulled here; they are left alone until pulled.
+	Behind []string
+}
+
+// PushSessionStates pushes the state of every session whose state changed
+// since it was last pushed or pulled. With force, newer states on the remote
+// are overwritten.
+func PushSessionStates(ctx context.Context, remote string, force bool) (*SessionSyncResult, error) {
+	states, err := ListSessionStates()
+	if err != nil {
+		return nil, err
+	}
+	result := &SessionSyncResult{}
+	if len(states) == 0 {
+		return result, nil
+	}
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	authorName, authorEmail := GetGitAuthorFromRepo(repo)
+
+	args := []string{"push", "--no-verify", "--porcelain", remote}
+	refToSession := make(map[string]string, len(states))
+	tips := make(map[string]plumbing.Hash, len(states))
+	for _, state := range states {
+		// Sessions imported from pulled shadow branches never ran here and
+		// have no baselines to share
+		if state.TranscriptPath == "" {
+			continue
+		}
+		refName, ok := sessionSyncRefName(state.SessionID)
+		if !ok {
+			continue
+		}
+		if _, seen := refToSession[refName.String()]; seen {
+			continue // Resumed in several worktrees; the first state found wins
+		}
+		tip, err := commitSessionSyncState(repo, refName, state, authorName, authorEmail)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record state of session %s: %w", state.SessionID, err)
+		}
+		refToSession[refName.String()] = state.SessionID
+		tips[state.SessionID] = tip
+		spec := refName.String() + ":" + refName.String()
+		if force {
+			spec = "+" + spec
+		}
+		args = append(args, spec)
+	}
+	if len(refToSession) == 0 {
+		return result, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = nil
+	output, runErr := cmd.CombinedOutput()
+	// A rejected ref makes push exit non-zero; the porcelain lines say which
+	parsed := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || len(fields[0]) != 1 {
+			continue
+		}
+		_, to, ok := strings.Cut(fields[1], ":")
+		sessionID, known := refToSession[to]
+		if !ok || !known {
+			continue
+		}
+		parsed++
+		switch fields[0] {
+		case "=":
+			result.UpToDate = append(result.UpToDate, sessionID)
+		case "!":
+			result.Behind = append(result.Behind, sessionID)
+			continue
+		default:
+			result.Pushed = append(result.Pushed, sessionID)
+		}
+		tracking := plumbing.ReferenceName(SessionSyncTrackingPrefix(remote) + sessionID)
+		_ = repo.Storer.SetReference(plumbing.NewHashReference(tracking, tips[sessionID])) //nolint:errcheck // best effort, only records what the remote has
+	}
+	if runErr != nil && parsed == 0 {
+		return nil, fmt.Errorf("failed to push session states to %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	sort.Strings(result.Pushed)
+	sort.Strings(result.UpToDate)
+	sort.Strings(result.Behind)
+	return result, nil
+}
+
+// commitSessionSyncState records the session's state and live transcript as
+// a commit on refName, unless they are unchanged since its tip. Returns the
+// tip.
+func commitSessionSyncState(repo *git.Repository, refName plumbing.ReferenceName, state *SessionState, authorName, authorEmail string) (plumbing.Hash, error) {
+	data, err := jsonutil.MarshalIndentWithNewline(state, "", "  ")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to marshal session state: %w", err)
+	}
+	entries := make(map[string]object.TreeEntry, 2)
+	stateBlob, err := checkpoint.CreateBlobFromContent(repo, data)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to store session state: %w", err)
+	}
+	entries[sessionSyncStateFile] = object.TreeEntry{Name: sessionSyncStateFile, Mode: filemode.Regular, Hash: stateBlob}
+	if state.TranscriptPath != "" {
+		if transcript, err := os.ReadFile(state.TranscriptPath); err == nil { //nolint:gosec // path from session state
+			blob, err := checkpoint.CreateBlobFromContent(repo, transcript)
+			if err != nil {
+				return plumbing.ZeroHash, fmt.Errorf("failed to store transcript: %w", err)
+			}
+			entries[sessionSyncTranscriptFile] = object.TreeEntry{Name: sessionSyncTranscriptFile, Mode: filemode.Regular, Hash: blob}
+		}
+	}
+	treeHash, err := checkpoint.BuildTreeFromEntries(repo, entries)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to build tree: %w", err)
+	}
+
+	parent := plumbing.ZeroHash
+	if ref, err := repo.Reference(refName, true); err == nil {
+		parent = ref.Hash()
+		if tip, err := repo.CommitObject(parent); err == nil && tip.TreeHash == treeHash {
+			return parent, nil
+		}
+	}
+	hash, err := createCommit(repo, treeHash, parent, "Session state "+state.SessionID, authorName, authorEmail)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, hash)); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to update %s: %w", refName, err)
+	}
+	return hash, nil
+}
+
+// PulledSession is a session state fetched by PullSessionState.
+type PulledSession struct {
+	// State is the remote's state, moved to this worktree and ended, and
+	// not saved yet: its TranscriptPath is the other machine's.
+	State *SessionState
+	// Transcript is the live transcript as of the push, nil if there was none.
+	Transcript []byte
+	// ShadowBranch is the local shadow branch the session's checkpoints were
+	// pulled to, "" if they weren't pushed.
+	ShadowBranch string
+	// ShadowTip is the tip of ShadowBranch.
+	ShadowTip plumbing.Hash
+	// PushedAt is when the state was pushed.
+	PushedAt time.Time
+}
+
+// PullSessionState fetches the state and shadow branch of one session from
+// remote, so it can be continued here. The shadow branch is created or
+// fast-forwarded, and reset with force if it diverged, as with
+// PullShadowBranches. A local state with newer activity is kept unless force
+// is set. Nothing is changed if a commit the session needs is missing.
+func PullSessionState(ctx context.Context, remote, sessionID string, force bool) (*PulledSession, error) {
+	refName, ok := sessionSyncRefName(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session ID %q can't be synced", sessionID)
+	}
+	tracking := plumbing.ReferenceName(SessionSyncTrackingPrefix(remote) + sessionID)
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", remote, refName.String())
+	cmd.Stdin = nil
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session states on %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotSynced, sessionID)
+	}
+	if err := fetchSyncRefs(ctx, remote, "+"+refName.String()+":"+tracking.String()); err != nil {
+		return nil, err
+	}
+
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	pulled, stateTip, err := readSessionSyncState(repo, tracking)
+	if err != nil {
+		return nil, err
+	}
+	state := pulled.State
+	if state.SessionID != sessionID {
+		return nil, fmt.Errorf("state on %s is of session %s, not %s", remote, state.SessionID, sessionID)
+	}
+	for _, base := range []string{state.BaseCommit, state.AttributionBaseCommit} {
+		if base == "" {
+			continue
+		}
+		if _, err := repo.CommitObject(plumbing.NewHash(base)); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrSessionBaseMissing, base)
+		}
+	}
+	existing, err := LoadSessionState(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	// Checked again by SavePulledSessionState; failing here leaves the refs alone
+	if existing != nil && !force && localSessionNewer(existing, state) {
+		return nil, fmt.Errorf("%w: %s", ErrLocalSessionNewer, sessionID)
+	}
+
+	worktreePath, err := GetWorktreePath()
+	if err != nil {
+		return nil, err
+	}
+	worktreeID, err := ShadowWorktreeID(worktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree ID: %w", err)
+	}
+	if state.BaseCommit != "" {
+		remoteBranch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)
+		remoteTips, err := lsRemoteShadowBranches(ctx, remote)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := remoteTips[remoteBranch]; ok {
+			shadowTracking := ShadowSyncTrackingPrefix(remote) + strings.TrimPrefix(remoteBranch, checkpoint.ShadowBranchPrefix)
+			if err := fetchSyncRefs(ctx, remote, "+"+shadowSyncRemoteRefName(remoteBranch)+":"+shadowTracking); err != nil {
+				return nil, err
+			}
+			ref, err := repo.Reference(plumbing.ReferenceName(shadowTracking), true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read fetched %s: %w", remoteBranch, err)
+			}
+			// Named after this worktree, which may differ from the pusher's
+			branch := checkpoint.ShadowBranchNameForCommit(state.BaseCommit, worktreeID)
+			update, err := updateShadowBranchFromRemote(repo, branch, ref.Hash(), force)
+			if err != nil {
+				return nil, err
+			}
+			if update == shadowUpdateConflict {
+				return nil, fmt.Errorf("%s has other checkpoints here; pull with --force to replace them", branch)
+			}
+			pulled.ShadowBranch, pulled.ShadowTip = branch, ref.Hash()
+		}
+	}
+	// The next push builds on what was pulled
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, stateTip)); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", refName, err)
+	}
+
+	state.WorktreePath, state.WorktreeID = worktreePath, worktreeID
+	state.Phase = session.PhaseEnded
+	if state.EndedAt == nil {
+		endedAt := pulled.PushedAt
+		state.EndedAt = &endedAt
+	}
+	return pulled, nil
+}
+
+// SavePulledSessionState saves the state of a pulled session over the local
+// one, under the state lock. Unless force is set, a local state that got
+// newer activity while pulling is kept and ErrLocalSessionNewer returned.
+func SavePulledSessionState(state *SessionState, force bool) error {
+	return UpsertSessionState(state.SessionID, func(existing *SessionState) (*SessionState, error) {
+		if existing != nil && !force && localSessionNewer(existing, state) {
+			return nil, fmt.Errorf("%w: %s", ErrLocalSessionNewer, state.SessionID)
+		}
+		return state, nil
+	})
+}
+
+// localSessionNewer reports whether the local state had activity after the
+// pulled one.
+func localSessionNewer(local, pulled *SessionState) bool {
+	return sessionLastActivity(local).After(sessionLastActivity(pulled))
+}
+
+// readSessionSyncState reads the session state commit at refName.
+func readSessionSyncState(repo *git.Repository, refName plumbing.ReferenceName) (*PulledSession, plumbing.Hash, error) {
+	ref, err := repo.Reference(refName, true)
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", refName, err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", refName, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", refName, err)
+	}
+	file, err := tree.File(sessionSyncStateFile)
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("%s has no %s: %w", refName, sessionSyncStateFile, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", sessionSyncStateFile, err)
+	}
+	var state SessionState
+	if err := json.Unmarshal([]byte(content), &state); err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("failed to parse %s: %w", sessionSyncStateFile, err)
+	}
+	state.NormalizeAfterLoad()
+	pulled := &PulledSession{State: &state, PushedAt: commit.Committer.When}
+	if file, err := tree.File(sessionSyncTranscriptFile); err == nil {
+		transcript, err := file.Contents()
+		if err != nil {
+			return nil, plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", sessionSyncTranscriptFile, err)
+		}
+		pulled.Transcript = []byte(transcript)
+	}
+	return pulled, ref.Hash(), nil
+}
+
+// fetchSyncRefs fetches refspecs from remote.
+func fetchSyncRefs(ctx context.Context, remote string, refspecs ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"fetch", "--no-tags", remote}, refspecs...)...)
+	cmd.Stdin = nil
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to fetch from %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,686 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Shadow branches normally never leave the machine. `entire sync` copies them
+// to a remote so checkpoints that weren't committed yet can be rewound to on
+// another machine:
+//
+//	local  refs/heads/entire/<commit>-<worktree>
+//	       refs/entire/hidden/<commit>-<worktree> (colocated jj, see vcs.Backend)
+//	remote refs/entire/shadow/<commit>-<worktree>
+//
+// They are kept out of the remote's refs/heads so they don't show up as
+// branches. Pulled refs land in refs/entire/remotes/<remote>/shadow/ first and
+// only then update local shadow branches, so a pull never discards local
+// checkpoints: a shadow branch is a series of full snapshots and two
+// machines' checkpoints on the same base can't be merged, so diverged
+// branches are reported as conflicts unless forced.
+
+// ShadowSyncRefPrefix is where shadow branches are stored on the remote.
+const ShadowSyncRefPrefix = "refs/entire/shadow/"
+
+// shadowSyncRemoteRefName returns the remote-side ref of a shadow branch.
+func shadowSyncRemoteRefName(branch string) string {
+	return ShadowSyncRefPrefix + strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix)
+}
+
+// ShadowSyncTrackingPrefix returns where pulled shadow branches of remote are kept.
+func ShadowSyncTrackingPrefix(remote string) string {
+	return "refs/entire/remotes/" + remote + "/shadow/"
+}
+
+// ShadowSyncFetchRefspec returns the fetch refspec for the shadow branches of remote.
+func ShadowSyncFetchRefspec(remote string) string {
+	return "+" + ShadowSyncRefPrefix + "*:" + ShadowSyncTrackingPrefix(remote) + "*"
+}
+
+// ShadowSyncResult is what a shadow branch push or pull did, by branch name.
+type ShadowSyncResult struct {
+	Updated  []string // Pushed, or created/fast-forwarded locally.
+	UpToDate []string
+	// Conflicts diverged from the other side and were left alone.
+	Conflicts []string
+	// Behind are pushed branches the remote has newer checkpoints of; there
+	// is nothing to push until they are pulled.
+	Behind []string
+	// NewCheckpoints counts the checkpoints a push sent, i.e. the shadow
+	// branch commits the remote didn't have yet.
+	NewCheckpoints int
+	// SessionsImported counts session states recreated from pulled branches.
+	SessionsImported int
+}
+
+// PushShadowBranches pushes the checkpoints of local shadow branches that
+// remote doesn't have. The remote's shadow branch tips are listed first, so
+// branches it already has aren't part of the push at all, and git only sends
+// the objects of new checkpoints (a thin pack against the remote's tips).
+// With force, diverged remote branches are overwritten.
+func PushShadowBranches(ctx context.Context, remote string, force bool) (*ShadowSyncResult, error) {
+	branches, err := ListShadowBranches()
+	if err != nil {
+		return nil, err
+	}
+	result := &ShadowSyncResult{}
+	if len(branches) == 0 {
+		return result, nil
+	}
+	sort.Strings(branches)
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	remoteTips, err := lsRemoteShadowBranches(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"push", "--no-verify", "--porcelain", "--thin", remote}
+	remoteToBranch := make(map[string]string, len(branches))
+	localTips := make(map[string]plumbing.Hash, len(branches))
+	newCheckpoints := make(map[string]int, len(branches))
+	for _, branch := range branches {
+		ref, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+		if err != nil {
+			continue // Removed since listing
+		}
+		local := ref.Hash()
+		remoteHash, onRemote := remoteTips[branch]
+		if onRemote && remoteHash == local {
+			result.UpToDate = append(result.UpToDate, branch)
+			setShadowSyncTrackingRef(repo, remote, branch, local)
+			continue
+		}
+		since := plumbing.ZeroHash
+		if onRemote {
+			switch compareShadowTips(repo, local, remoteHash) {
+			case shadowTipsBehind:
+				if !force {
+					result.Behind = append(result.Behind, branch)
+					continue
+				}
+			case shadowTipsDiverged:
+				if !force {
+					result.Conflicts = append(result.Conflicts, branch)
+					continue
+				}
+			case shadowTipsAhead:
+				since = remoteHash
+			}
+		}
+		pending, err := checkpointsSince(repo, local, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", branch, err)
+		}
+
+		spec := ref.Name().String() + ":" + shadowSyncRemoteRefName(branch)
+		if force {
+			spec = "+" + spec
+		}
+		args = append(args, spec)
+		remoteToBranch[shadowSyncRemoteRefName(branch)] = branch
+		localTips[branch] = local
+		newCheckpoints[branch] = len(pending)
+	}
+	if len(remoteToBranch) == 0 {
+		return result, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = nil
+	output, runErr := cmd.CombinedOutput()
+	// A rejected ref makes push exit non-zero; the porcelain lines say which
+	parsed := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || len(fields[0]) != 1 {
+			continue
+		}
+		_, to, ok := strings.Cut(fields[1], ":")
+		branch, known := remoteToBranch[to]
+		if !ok || !known {
+			continue
+		}
+		parsed++
+		switch fields[0] {
+		case "=":
+			result.UpToDate = append(result.UpToDate, branch)
+			setShadowSyncTrackingRef(repo, remote, branch, localTips[branch])
+		case "!":
+			// Someone pushed between listing and pushing
+			result.Conflicts = append(result.Conflicts, branch)
+		default:
+			result.Updated = append(result.Updated, branch)
+			result.NewCheckpoints += newCheckpoints[branch]
+			setShadowSyncTrackingRef(repo, remote, branch, localTips[branch])
+		}
+	}
+	if runErr != nil && parsed == 0 {
+		return nil, fmt.Errorf("failed to push shadow branches to %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	sort.Strings(result.UpToDate)
+	sort.Strings(result.Conflicts)
+	return result, nil
+}
+
+// lsRemoteShadowBranches returns the tips of the shadow branches on remote,
+// by local shadow branch name.
+func lsRemoteShadowBranches(ctx context.Context, remote string) (map[string]plumbing.Hash, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", remote, ShadowSyncRefPrefix+"*")
+	cmd.Stdin = nil
+	output, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("failed to list shadow branches on %s: %s", remote, msg)
+	}
+	tips := make(map[string]plumbing.Hash)
+	for _, line := range strings.Split(string(output), "\n") {
+		hash, name, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(name, ShadowSyncRefPrefix); ok {
+			tips[checkpoint.ShadowBranchPrefix+suffix] = plumbing.NewHash(hash)
+		}
+	}
+	return tips, nil
+}
+
+// setShadowSyncTrackingRef records that remote has branch at hash, the same
+// ref a pull would leave, so the sync status can be shown without asking the
+// remote. Best effort: a stale tracking ref only affects that status.
+func setShadowSyncTrackingRef(repo *git.Repository, remote, branch string, hash plumbing.Hash) {
+	name := plumbing.ReferenceName(ShadowSyncTrackingPrefix(remote) + strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix))
+	_ = repo.Storer.SetReference(plumbing.NewHashReference(name, hash)) //nolint:errcheck // best effort, see above
+}
+
+type shadowTips int
+
+const (
+	// shadowTipsAhead: the local branch contains the remote's tip.
+	shadowTipsAhead shadowTips = iota
+	// shadowTipsBehind: the remote branch contains the local tip.
+	shadowTipsBehind
+	// shadowTipsDiverged: both have checkpoints the other lacks, or the
+	// remote's tip hasn't been fetched so it can't be compared.
+	shadowTipsDiverged
+)
+
+// compareShadowTips compares a local shadow branch tip with the remote's.
+func compareShadowTips(repo *git.Repository, local, remote plumbing.Hash) shadowTips {
+	localCommit, err := repo.CommitObject(local)
+	if err != nil {
+		return shadowTipsDiverged
+	}
+	remoteCommit, err := repo.CommitObject(remote)
+	if err != nil {
+		return shadowTipsDiverged
+	}
+	if ok, err := remoteCommit.IsAncestor(localCommit); err == nil && ok {
+		return shadowTipsAhead
+	}
+	if ok, err := localCommit.IsAncestor(remoteCommit); err == nil && ok {
+		return shadowTipsBehind
+	}
+	return shadowTipsDiverged
+}
+
+// checkpointsSince returns the commits in the history of tip that aren't in
+// the history of since (everything if since is zero), newest first.
+func checkpointsSince(repo *git.Repository, tip, since plumbing.Hash) ([]*object.Commit, error) {
+	known := make(map[plumbing.Hash]bool)
+	if !since.IsZero() {
+		iter, err := repo.Log(&git.LogOptions{From: since})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		if err := iter.ForEach(func(c *object.Commit) error {
+			known[c.Hash] = true
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+	}
+	iter, err := repo.Log(&git.LogOptions{From: tip})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var commits []*object.Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		if known[c.Hash] {
+			return storer.ErrStop
+		}
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return commits, nil
+}
+
+// ShadowSyncStatus is how a local shadow branch compares to its copy on the
+// sync remote.
+type ShadowSyncStatus struct {
+	Branch string
+	// OnRemote is false if the branch was never pushed.
+	OnRemote bool
+	// RemoteAhead is true if the remote has checkpoints of the branch that
+	// haven't been pulled.
+	RemoteAhead bool
+	Sessions    []SessionSyncLag
+}
+
+// SessionSyncLag is how far behind the remote is on one session's
+// checkpoints of a shadow branch.
+type SessionSyncLag struct {
+	SessionID string
+	// Checkpoints is the session's number of checkpoints on the branch.
+	Checkpoints int
+	// Unpushed counts the checkpoints the remote doesn't have.
+	Unpushed int
+	// OldestUnpushed is when the oldest unpushed checkpoint was made; zero
+	// if all are pushed.
+	OldestUnpushed time.Time
+}
+
+// ShadowSyncStatusReport is the sync status of every local shadow branch.
+type ShadowSyncStatusReport struct {
+	Remote string
+	// Offline is true when remote couldn't be reached and the status is as
+	// of the last push or pull.
+	Offline  bool
+	Branches []ShadowSyncStatus
+}
+
+// GetShadowSyncStatus compares the local shadow branches with their copies
+// on remote. If remote can't be reached, it falls back to what the last push
+// or pull recorded.
+func GetShadowSyncStatus(ctx context.Context, remote string) (*ShadowSyncStatusReport, error) {
+	branches, err := ListShadowBranches()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(branches)
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	report := &ShadowSyncStatusReport{Remote: remote}
+	remoteTips, err := lsRemoteShadowBranches(ctx, remote)
+	if err != nil {
+		report.Offline = true
+		remoteTips = make(map[string]plumbing.Hash)
+		for _, branch := range branches {
+			name := plumbing.ReferenceName(ShadowSyncTrackingPrefix(remote) + strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix))
+			if ref, err := repo.Reference(name, true); err == nil {
+				remoteTips[branch] = ref.Hash()
+			}
+		}
+	}
+
+	for _, branch := range branches {
+		ref, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+		if err != nil {
+			continue
+		}
+		status := ShadowSyncStatus{Branch: branch}
+		since := plumbing.ZeroHash
+		if remoteHash, ok := remoteTips[branch]; ok {
+			status.OnRemote = true
+			switch compareShadowTips(repo, ref.Hash(), remoteHash) {
+			case shadowTipsAhead:
+				since = remoteHash
+			case shadowTipsBehind:
+				since = ref.Hash()
+				status.RemoteAhead = true
+			case shadowTipsDiverged:
+				status.RemoteAhead = true
+				// Count what the local branch has beyond the last state both
+				// sides agreed on, if a push or pull recorded one
+				if tracked, err := repo.Reference(plumbing.ReferenceName(ShadowSyncTrackingPrefix(remote)+strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix)), true); err == nil &&
+					compareShadowTips(repo, ref.Hash(), tracked.Hash()) == shadowTipsAhead {
+					since = tracked.Hash()
+				}
+			}
+		}
+
+		sessions, err := shadowBranchSessions(repo, ref.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", branch, err)
+		}
+		lag := make(map[string]*SessionSyncLag, len(sessions))
+		for _, s := range sessions {
+			status.Sessions = append(status.Sessions, SessionSyncLag{SessionID: s.SessionID, Checkpoints: s.Steps})
+		}
+		for i := range status.Sessions {
+			lag[status.Sessions[i].SessionID] = &status.Sessions[i]
+		}
+		if since != ref.Hash() {
+			pending, err := checkpointsSince(repo, ref.Hash(), since)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", branch, err)
+			}
+			for _, c := range pending {
+				sessionID, ok := trailers.ParseSession(c.Message)
+				if !ok || lag[sessionID] == nil {
+					continue
+				}
+				l := lag[sessionID]
+				l.Unpushed++
+				if l.OldestUnpushed.IsZero() || c.Author.When.Before(l.OldestUnpushed) {
+					l.OldestUnpushed = c.Author.When
+				}
+			}
+		}
+		report.Branches = append(report.Branches, status)
+	}
+	return report, nil
+}
+
+// PullShadowBranches fetches the shadow branches of remote and creates or
+// fast-forwards the local ones. With force, diverged local branches are reset
+// to the remote's. Sessions of pulled branches of this worktree get a session
+// state (ended) if they have none, so rewind finds their checkpoints.
+func PullShadowBranches(ctx context.Context, remote string, force bool) (*ShadowSyncResult, error) {
+	if err := FetchShadowBranches(ctx, remote); err != nil {
+		return nil, err
+	}
+
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	tracking := make(map[string]plumbing.Hash)
+	prefix := ShadowSyncTrackingPrefix(remote)
+	_ = refs.ForEach(func(ref *plumbing.Reference) error { //nolint:errcheck // callback never fails
+		if name, ok := strings.CutPrefix(ref.Name().String(), prefix); ok {
+			tracking[checkpoint.ShadowBranchPrefix+name] = ref.Hash()
+		}
+		return nil
+	})
+
+	branches := make([]string, 0, len(tracking))
+	for branch := range tracking {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	result := &ShadowSyncResult{}
+	for _, branch := range branches {
+		if !IsShadowBranch(branch) {
+			continue
+		}
+		remoteHash := tracking[branch]
+		updated, err := updateShadowBranchFromRemote(repo, branch, remoteHash, force)
+		if err != nil {
+			return nil, err
+		}
+		switch updated {
+		case shadowUpdateConflict:
+			result.Conflicts = append(result.Conflicts, branch)
+			continue
+		case shadowUpdateNone:
+			result.UpToDate = append(result.UpToDate, branch)
+		case shadowUpdateApplied:
+			result.Updated = append(result.Updated, branch)
+		}
+		n, err := importShadowBranchSessions(repo, branch)
+		if err != nil {
+			return nil, err
+		}
+		result.SessionsImported += n
+	}
+	return result, nil
+}
+
+// FetchShadowBranches updates ShadowSyncTrackingPrefix(remote) from the
+// shadow branches on remote, without touching local shadow branches.
+func FetchShadowBranches(ctx context.Context, remote string) error {
+	cmd := exec.CommandContext(ctx, "git", "fetch", "--no-tags", "--prune", remote, ShadowSyncFetchRefspec(remote))
+	cmd.Stdin = nil
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to fetch shadow branches from %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SyncedShadowBranch is a shadow branch someone pushed with `entire sync push`.
+type SyncedShadowBranch struct {
+	// Branch is the shadow branch name, e.g. entire/abc1234-e3b0c4.
+	Branch string
+	// Remote is the remote it was pulled from; empty if it was pushed to this
+	// repository.
+	Remote     string
+	BaseCommit string // 7-char prefix from the branch name.
+	Tip        plumbing.Hash
+	UpdatedAt  time.Time
+	Sessions   []ShadowBranchSession
+}
+
+// ShadowBranchSession is one session's checkpoints on a shadow branch.
+type ShadowBranchSession struct {
+	SessionID string
+	// Author is who made the session's latest checkpoint.
+	Author    string
+	Steps     int
+	StartedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ListSyncedShadowBranches returns the shadow branches pushed to this
+// repository (under ShadowSyncRefPrefix) and pulled from remotes (under
+// ShadowSyncTrackingPrefix), most recently updated first.
+func ListSyncedShadowBranches(repo *git.Repository) ([]SyncedShadowBranch, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	var branches []SyncedShadowBranch
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		var remote, suffix string
+		if rest, ok := strings.CutPrefix(name, ShadowSyncRefPrefix); ok {
+			suffix = rest
+		} else if rest, ok := strings.CutPrefix(name, "refs/entire/remotes/"); ok {
+			r, tail, found := strings.Cut(rest, "/shadow/")
+			if !found {
+				return nil
+			}
+			remote, suffix = r, tail
+		} else {
+			return nil
+		}
+		branch := checkpoint.ShadowBranchPrefix + suffix
+		commitPrefix, _, ok := checkpoint.ParseShadowBranchName(branch)
+		if !ok || !IsShadowBranch(branch) {
+			return nil
+		}
+		sessions, err := shadowBranchSessions(repo, ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		b := SyncedShadowBranch{Branch: branch, Remote: remote, BaseCommit: commitPrefix, Tip: ref.Hash(), Sessions: sessions}
+		for _, s := range sessions {
+			if s.UpdatedAt.After(b.UpdatedAt) {
+				b.UpdatedAt = s.UpdatedAt
+			}
+		}
+		branches = append(branches, b)
+		return nil
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped in the callback
+	}
+	sort.SliceStable(branches, func(i, j int) bool {
+		if !branches[i].UpdatedAt.Equal(branches[j].UpdatedAt) {
+			return branches[i].UpdatedAt.After(branches[j].UpdatedAt)
+		}
+		return branches[i].Branch < branches[j].Branch
+	})
+	return branches, nil
+}
+
+// shadowBranchSessions summarizes the sessions with checkpoints in the
+// history of tip, newest session first.
+func shadowBranchSessions(repo *git.Repository, tip plumbing.Hash) ([]ShadowBranchSession, error) {
+	iter, err := repo.Log(&git.LogOptions{From: tip})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var sessions []ShadowBranchSession
+	index := make(map[string]int)
+	err = iter.ForEach(func(c *object.Commit) error {
+		sessionID, ok := trailers.ParseSession(c.Message)
+		if !ok {
+			return nil
+		}
+		i, seen := index[sessionID]
+		if !seen {
+			i = len(sessions)
+			index[sessionID] = i
+			sessions = append(sessions, ShadowBranchSession{
+				SessionID: sessionID,
+				Author:    c.Author.Name,
+				StartedAt: c.Author.When,
+				UpdatedAt: c.Author.When,
+			})
+		}
+		s := &sessions[i]
+		s.Steps++
+		if c.Author.When.Before(s.StartedAt) {
+			s.StartedAt = c.Author.When
+		}
+		if c.Author.When.After(s.UpdatedAt) {
+			s.UpdatedAt = c.Author.When
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return sessions, nil
+}
+
+type shadowUpdate int
+
+const (
+	shadowUpdateNone shadowUpdate = iota
+	shadowUpdateApplied
+	shadowUpdateConflict
+)
+
+// updateShadowBranchFromRemote moves the local shadow branch to remoteHash if
+// that loses no local checkpoints (or force is set).
+func updateShadowBranchFromRemote(repo *git.Repository, branch string, remoteHash plumbing.Hash, force bool) (shadowUpdate, error) {
+	refName := checkpoint.ShadowRefName(repo, branch)
+	local, err := repo.Reference(refName, true)
+	if err == nil {
+		if local.Hash() == remoteHash {
+			return shadowUpdateNone, nil
+		}
+		localCommit, localErr := repo.CommitObject(local.Hash())
+		remoteCommit, remoteErr := repo.CommitObject(remoteHash)
+		if localErr != nil || remoteErr != nil {
+			return shadowUpdateNone, fmt.Errorf("failed to read %s", branch)
+		}
+		if ahead, err := remoteCommit.IsAncestor(localCommit); err == nil && ahead {
+			return shadowUpdateNone, nil // Local already has everything
+		}
+		if behind, err := localCommit.IsAncestor(remoteCommit); (err != nil || !behind) && !force {
+			return shadowUpdateConflict, nil
+		}
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, remoteHash)); err != nil {
+		return shadowUpdateNone, fmt.Errorf("failed to update %s: %w", branch, err)
+	}
+	return shadowUpdateApplied, nil
+}
+
+// importShadowBranchSessions creates session states for sessions of a pulled
+// shadow branch of this worktree that don't have one. Returns how many were
+// created. Branches of other worktrees, or whose base commit hasn't been
+// fetched, are skipped.
+func importShadowBranchSessions(repo *git.Repository, branch string) (int, error) {
+	commitPrefix, worktreeHash, ok := checkpoint.ParseShadowBranchName(branch)
+	if !ok {
+		return 0, nil
+	}
+	worktreePath, err := GetWorktreePath()
+	if err != nil {
+		return 0, err
+	}
+	worktreeID, err := ShadowWorktreeID(worktreePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get worktree ID: %w", err)
+	}
+	if checkpoint.HashWorktreeID(worktreeID) != worktreeHash {
+		return 0, nil
+	}
+	baseHash, err := repo.ResolveRevision(plumbing.Revision(commitPrefix))
+	if err != nil {
+		return 0, nil //nolint:nilerr // Base commit not fetched (or ambiguous); nothing to attach sessions to
+	}
+
+	ref, err := repo.Reference(checkpoint.ShadowRefName(repo, branch), true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", branch, err)
+	}
+	sessions, err := shadowBranchSessions(repo, ref.Hash())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", branch, err)
+	}
+
+	imported := 0
+	for _, s := range sessions {
+		existing, err := LoadSessionState(s.SessionID)
+		if err != nil {
+			return imported, err
+		}
+		if existing != nil {
+			continue
+		}
+		endedAt := s.UpdatedAt
+		state := &SessionState{
+			SessionID:    s.SessionID,
+			BaseCommit:   baseHash.String(),
+			WorktreePath: worktreePath,
+			WorktreeID:   worktreeID,
+			StartedAt:    s.StartedAt,
+			EndedAt:      &endedAt,
+			Phase:        session.PhaseEnded,
+			StepCount:    s.Steps,
+		}
+		if err := SaveSessionState(state); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+	return imported, nil
+}
@@ -0,0 +1,138 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// When several checkpoints precede a commit, attribution compares the commit
+// with the last one only, so a line the agent wrote in an earlier checkpoint,
+// changed or removed later, and the human put back counts as the human's.
+// With attribution.checkpoints "union", such lines count as the agent's: each
+// line the commit added that isn't in the last checkpoint is looked up among
+// the lines the session's earlier checkpoints added to the file.
+
+// configuredCheckpointAttribution returns attribution.checkpoints from
+// settings, falling back to last if settings are missing or invalid.
+func configuredCheckpointAttribution() string {
+	s, err := settings.Load()
+	if err != nil {
+		return settings.AttributionCheckpointsLast
+	}
+	c, err := s.Attribution.EffectiveCheckpoints()
+	if err != nil {
+		logging.Warn(context.Background(), "ignoring attribution settings", slog.String("error", err.Error()))
+		return settings.AttributionCheckpointsLast
+	}
+	return c
+}
+
+// earlierCheckpointTrees returns the trees of the session's checkpoints on
+// the shadow branch before its tip, newest first, back to the last
+// condensation. Metadata-only checkpoints are left out: their trees are their
+// parents'.
+func earlierCheckpointTrees(repo *git.Repository, shadowRef *plumbing.Reference, state *SessionState) ([]*object.Tree, error) {
+	iter, err := repo.Log(&git.LogOptions{From: shadowRef.Hash()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shadow branch log: %w", err)
+	}
+	var trees []*object.Tree
+	seen := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if seen >= state.StepCount {
+			return errStop
+		}
+		if sessionID, ok := trailers.ParseSession(c.Message); !ok || sessionID != state.SessionID {
+			return nil
+		}
+		seen++
+		if seen == 1 || trailers.IsMetadataOnly(c.Message) {
+			return nil // The tip is the checkpoint attributed against anyway
+		}
+		tree, err := c.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to read checkpoint %s tree: %w", c.Hash.String()[:7], err)
+		}
+		trees = append(trees, tree)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// squashedShadowContent returns shadowContent with the lines of headContent
+// that aren't in it but were added by one of the earlier checkpoints (relative
+// to baseContent) put back where the commit has them, so diffs against it
+// count those lines as the agent's.
+func squashedShadowContent(baseContent, shadowContent, headContent string, earlierContents []string) string {
+	if shadowContent == headContent || len(earlierContents) == 0 {
+		return shadowContent
+	}
+
+	// The union of the earlier checkpoints' added lines: each line as many
+	// times as the checkpoint that added it most often did
+	agentLines := make(map[string]int)
+	for _, earlier := range earlierContents {
+		counts := make(map[string]int)
+		for _, d := range lineDiffs(baseContent, earlier) {
+			if d.Type == diffmatchpatch.DiffInsert {
+				for _, line := range splitLinesKeepEnds(d.Text) {
+					counts[line]++
+				}
+			}
+		}
+		for line, n := range counts {
+			agentLines[line] = max(agentLines[line], n)
+		}
+	}
+	if len(agentLines) == 0 {
+		return shadowContent
+	}
+
+	var sb strings.Builder
+	for _, d := range lineDiffs(shadowContent, headContent) {
+		switch d.Type {
+		case diffmatchpatch.DiffEqual, diffmatchpatch.DiffDelete:
+			sb.WriteString(d.Text)
+		case diffmatchpatch.DiffInsert:
+			for _, line := range splitLinesKeepEnds(d.Text) {
+				if agentLines[line] > 0 {
+					agentLines[line]--
+					sb.WriteString(line)
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+// lineDiffs diffs a and b line by line, with each diff's Text holding the
+// lines themselves.
+func lineDiffs(a, b string) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	text1, text2, lineArray := dmp.DiffLinesToChars(a, b)
+	return dmp.DiffCharsToLines(dmp.DiffMain(text1, text2, false), lineArray)
+}
+
+// splitLinesKeepEnds splits text into lines, each keeping its newline.
+func splitLinesKeepEnds(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestSquashedShadowContent(t *testing.T) {
+	base := "package main\n"
+	// The agent wrote helper() first, then replaced it with inline code
+	first := "package main\n\nfunc helper() {}\n"
+	last := "package main\n\nfunc main() { inline() }\n"
+	// The human went back to the first version and added a line of their own
+	head := "package main\n\nfunc helper() {}\n// mine\n"
+
+	got := squashedShadowContent(base, last, head, []string{first})
+	want := "package main\n\nfunc main() { inline() }\nfunc helper() {}\n"
+	if got != want {
+		t.Errorf("squashedShadowContent() = %q, want %q", got, want)
+	}
+
+	if got := squashedShadowContent(base, last, head, nil); got != last {
+		t.Errorf("squashedShadowContent() without earlier checkpoints = %q, want the last checkpoint", got)
+	}
+}
+
+func TestCalculateAttribution_EarlierCheckpoints(t *testing.T) {
+	storage := memory.NewStorage()
+	tree := func(content string) *object.Tree {
+		t.Helper()
+		commit, err := object.GetCommit(storage, storeTestCommit(t, storage, map[string]string{"main.go": content}))
+		if err != nil {
+			t.Fatalf("failed to read commit: %v", err)
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			t.Fatalf("failed to read tree: %v", err)
+		}
+		return tree
+	}
+	baseTree := tree("package main\n")
+	firstTree := tree("package main\n\nfunc a() {}\nfunc b() {}\n")
+	lastTree := tree("package main\n\nfunc c() {}\n")
+	headTree := tree("package main\n\nfunc a() {}\nfunc b() {}\nfunc c() {}\n")
+
+	last := calculateAttribution(GranularityLine, baseTree, lastTree, headTree, nil, []string{"main.go"}, nil, nil)
+	if last == nil || last.AgentLines != 2 || last.HumanAdded != 2 {
+		t.Fatalf("attribution against the last checkpoint = %+v, want 2 agent and 2 human lines", last)
+	}
+
+	union := calculateAttribution(GranularityLine, baseTree, lastTree, headTree, []*object.Tree{firstTree}, []string{"main.go"}, nil, nil)
+	if union == nil || union.AgentLines != 4 || union.HumanAdded != 0 || union.TotalCommitted != 4 {
+		t.Errorf("attribution against all checkpoints = %+v, want all 4 lines the agent's", union)
+	}
+	if len(union.Files) != 1 || len(union.Files[0].AgentRanges) != 1 || union.Files[0].AgentRanges[0].Start != 2 || union.Files[0].AgentRanges[0].End != 5 {
+		t.Errorf("Files = %+v, want agent lines 2-5", union.Files)
+	}
+}
@@ -0,0 +1,138 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// The stacked strategy commits like auto-commit: every prompt (and subagent
+// task) that changes files becomes a commit on the active branch, with its
+// metadata on entire/checkpoints/v1. Each commit also carries the strategy,
+// the session and a Gerrit Change-Id, which makes a session's commits a
+// stack: a series reviewed change by change instead of as one squashed
+// commit. FindStack reads the stack back for `entire stack export`.
+
+// maxStackScan bounds how many first-parent commits FindStack walks.
+const maxStackScan = 500
+
+// ErrNoStack is returned by FindStack when the current branch has no
+// stacked commits of the session.
+var ErrNoStack = errors.New("no stacked commits on the current branch")
+
+// NewStackedStrategy creates a new stacked strategy instance.
+func NewStackedStrategy() Strategy { //nolint:ireturn // already present in codebase
+	return &AutoCommitStrategy{stacked: true}
+}
+
+// stackTrailers returns the trailers marking a commit as part of the
+// session's stack, appended after the Entire-Checkpoint trailer. Empty for
+// auto-commit.
+func (s *AutoCommitStrategy) stackTrailers(sessionID string, checkpointID id.CheckpointID) string {
+	if !s.stacked {
+		return ""
+	}
+	return "\n" + trailers.StrategyTrailerKey + ": " + StrategyNameStacked +
+		"\n" + trailers.SessionTrailerKey + ": " + sessionID +
+		"\n" + trailers.ChangeIDTrailerKey + ": " + trailers.ChangeIDForCheckpoint(checkpointID)
+}
+
+// StackCommit is one commit of a stack.
+type StackCommit struct {
+	Hash    plumbing.Hash
+	Subject string
+	// CheckpointID is empty for commits made in between by someone else
+	CheckpointID id.CheckpointID
+	// ChangeID is the commit's Gerrit Change-Id, "" if it has none
+	ChangeID string
+}
+
+// Stack is a session's commits on the current branch.
+type Stack struct {
+	SessionID string
+	// Base is the commit the stack starts from, ZeroHash if the stack
+	// starts at the root commit
+	Base plumbing.Hash
+	// Commits runs from the session's first commit to HEAD, oldest first.
+	// Commits made in between, e.g. by the user, are part of the stack too.
+	Commits []StackCommit
+}
+
+// isStackCommit reports whether the message is a stacked commit, and of
+// which session.
+func isStackCommit(message string) (string, bool) {
+	if strat, ok := trailers.ParseStrategy(message); !ok || strings.TrimSpace(strat) != StrategyNameStacked {
+		return "", false
+	}
+	return trailers.ParseSession(message)
+}
+
+// FindStack returns the stack of sessionID on the current branch, or of the
+// session of the most recent stacked commit if sessionID is empty. The walk
+// follows first parents from HEAD and ends at the default branch's tip
+// (unless that is HEAD) or at another session's stack.
+func FindStack(repo *git.Repository, sessionID string) (*Stack, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	mainHash := GetMainBranchHash(repo)
+	if mainHash == head.Hash() {
+		// Stacked on the default branch itself
+		mainHash = plumbing.ZeroHash
+	}
+
+	var walked []*object.Commit
+	oldest := -1
+	hash := head.Hash()
+	for len(walked) < maxStackScan && hash != mainHash {
+		c, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		if session, ok := isStackCommit(c.Message); ok {
+			if sessionID == "" {
+				sessionID = session
+			}
+			if session == sessionID {
+				oldest = len(walked)
+			} else if oldest >= 0 {
+				break
+			}
+		}
+		walked = append(walked, c)
+		if c.NumParents() == 0 {
+			break
+		}
+		hash = c.ParentHashes[0]
+	}
+	if oldest < 0 {
+		return nil, ErrNoStack
+	}
+
+	stack := &Stack{SessionID: sessionID}
+	if first := walked[oldest]; first.NumParents() > 0 {
+		stack.Base = first.ParentHashes[0]
+	}
+	for i := oldest; i >= 0; i-- {
+		c := walked[i]
+		sc := StackCommit{Hash: c.Hash, Subject: strings.SplitN(c.Message, "\n", 2)[0]}
+		if session, ok := isStackCommit(c.Message); ok && session == sessionID {
+			sc.CheckpointID, _ = trailers.ParseCheckpoint(c.Message)
+		}
+		sc.ChangeID, _ = trailers.ParseChangeID(c.Message)
+		stack.Commits = append(stack.Commits, sc)
+	}
+	return stack, nil
+}
+
+func init() {
+	Register(StrategyNameStacked, NewStackedStrategy)
+}
@@ -0,0 +1,138 @@
+package strategy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestStackedStrategy_Registration(t *testing.T) {
+	s, err := Get(StrategyNameStacked)
+	if err != nil {
+		t.Fatalf("Get(%q) error = %v", StrategyNameStacked, err)
+	}
+	if s.Name() != StrategyNameStacked {
+		t.Errorf("Name() = %q, want %q", s.Name(), StrategyNameStacked)
+	}
+	if !CommitsToActiveBranch(s.Name()) || CommitsToActiveBranch(StrategyNameManualCommit) {
+		t.Error("CommitsToActiveBranch() should hold for stacked only, not manual-commit")
+	}
+}
+
+func TestStackedStrategy_FindStack(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	author := &object.Signature{Name: "Test", Email: "test@test.com"}
+	userCommit := func(file, message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(message), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+		if _, err := worktree.Add(file); err != nil {
+			t.Fatalf("failed to add %s: %v", file, err)
+		}
+		if _, err := worktree.Commit(message, &git.CommitOptions{Author: author}); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	userCommit("README.md", "Initial commit")
+	t.Chdir(dir)
+
+	s := NewStackedStrategy()
+	if err := s.EnsureSetup(); err != nil {
+		t.Fatalf("EnsureSetup() error = %v", err)
+	}
+	save := func(sessionID, file, message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(message), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+		metadataDir := filepath.Join(paths.EntireMetadataDir, sessionID)
+		if err := os.MkdirAll(metadataDir, 0o750); err != nil {
+			t.Fatalf("failed to create metadata dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(metadataDir, paths.TranscriptFileName), []byte("log"), 0o644); err != nil {
+			t.Fatalf("failed to write log file: %v", err)
+		}
+		err := s.SaveChanges(SaveContext{
+			SessionID:      sessionID,
+			CommitMessage:  message,
+			MetadataDir:    metadataDir,
+			MetadataDirAbs: filepath.Join(dir, metadataDir),
+			NewFiles:       []string{file},
+			AuthorName:     "Test",
+			AuthorEmail:    "test@test.com",
+		})
+		if err != nil {
+			t.Fatalf("SaveChanges() error = %v", err)
+		}
+	}
+
+	save("2026-01-01-earlier", "old.go", "Earlier session")
+	earlierHead, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	save("2026-01-02-current", "a.go", "Add parser")
+	userCommit("notes.txt", "Fix up by hand")
+	save("2026-01-02-current", "b.go", "Add tests")
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get HEAD commit: %v", err)
+	}
+	if session, ok := isStackCommit(headCommit.Message); !ok || session != "2026-01-02-current" {
+		t.Errorf("HEAD commit not marked as stacked for its session:\n%s", headCommit.Message)
+	}
+	cpID, _ := trailers.ParseCheckpoint(headCommit.Message)
+	if changeID, ok := trailers.ParseChangeID(headCommit.Message); !ok || changeID != trailers.ChangeIDForCheckpoint(cpID) {
+		t.Errorf("HEAD commit Change-Id = %q, want one derived from checkpoint %s", changeID, cpID)
+	}
+
+	stack, err := FindStack(repo, "")
+	if err != nil {
+		t.Fatalf("FindStack() error = %v", err)
+	}
+	if stack.SessionID != "2026-01-02-current" || stack.Base != earlierHead.Hash() {
+		t.Errorf("FindStack() = session %q on %s, want the current session on %s", stack.SessionID, stack.Base, earlierHead.Hash())
+	}
+	var subjects []string
+	for _, c := range stack.Commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if len(stack.Commits) != 3 || subjects[0] != "Add parser" || subjects[1] != "Fix up by hand" || subjects[2] != "Add tests" {
+		t.Fatalf("FindStack() commits = %v, want the session's commits and the one in between, oldest first", subjects)
+	}
+	if !stack.Commits[1].CheckpointID.IsEmpty() || stack.Commits[1].ChangeID != "" {
+		t.Errorf("user commit = %+v, want no checkpoint or Change-Id", stack.Commits[1])
+	}
+
+	earlier, err := FindStack(repo, "2026-01-01-earlier")
+	if err != nil {
+		t.Fatalf("FindStack(earlier) error = %v", err)
+	}
+	if len(earlier.Commits) != 4 || earlier.Commits[0].Subject != "Earlier session" {
+		t.Errorf("FindStack(earlier) = %d commits, want the earlier session's commit and everything after it", len(earlier.Commits))
+	}
+	if _, err := FindStack(repo, "2026-01-03-unknown"); !errors.Is(err, ErrNoStack) {
+		t.Errorf("FindStack(unknown) error = %v, want ErrNoStack", err)
+	}
+}
@@ -3,6 +3,7 @@
 package strategy
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
 	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
 	"github.com/entireio/cli/cmd/entire/cli/session"
 )
@@ -89,6 +91,11 @@ type RewindPoint struct {
 	// ToolUseID is the tool use ID for task checkpoints (empty for session checkpoints)
 	ToolUseID string
 
+	// IsMetadataOnly indicates a checkpoint written while the repository was
+	// over its quota: it records which files changed but not their contents,
+	// so it can't be rewound to.
+	IsMetadataOnly bool
+
 	// IsLogsOnly indicates this is a commit with session logs but no shadow branch state.
 	// The logs can be restored from entire/checkpoints/v1, but file state requires git checkout.
 	IsLogsOnly bool
@@ -167,6 +174,9 @@ type SaveContext struct {
 	// CommitMessage is the generated commit message
 	CommitMessage string
 
+	// Prompt is the user's last prompt, available to commit message templates
+	Prompt string
+
 	// TranscriptPath is the path to the transcript file
 	TranscriptPath string
 
@@ -454,6 +464,26 @@ type PrePushHandler interface {
 	PrePush(remote string) error
 }
 
+// PostRewriteHandler is an optional interface for strategies that need to
+// handle the git post-rewrite hook.
+type PostRewriteHandler interface {
+	// PostRewrite is called by the git post-rewrite hook after git commit --amend
+	// or git rebase replaced commits. rewriteType is "amend" or "rebase"; rewrites
+	// lists each replaced commit with its replacement, as git passes them on stdin.
+	// Should return nil on errors to not block subsequent operations (log warnings to stderr).
+	PostRewrite(rewriteType string, rewrites []CommitRewrite) error
+}
+
+// PostCheckoutHandler is an optional interface for strategies that need to
+// handle the git post-checkout hook.
+type PostCheckoutHandler interface {
+	// PostCheckout is called by the git post-checkout hook after git checkout or
+	// git switch updated the worktree. branchCheckout is false when only files
+	// were checked out, which leaves HEAD where it was.
+	// Should return nil on errors to not block subsequent operations (log warnings to stderr).
+	PostCheckout(previousHead, newHead string, branchCheckout bool) error
+}
+
 // TurnEndHandler is an optional interface for strategies that need to
 // handle deferred actions when an agent turn ends.
 // For example, manual-commit strategy uses this to condense session data
@@ -525,6 +555,16 @@ type ConcurrentSessionChecker interface {
 	CountOtherActiveSessionsWithCheckpoints(currentSessionID string) (int, error)
 }
 
+// SessionResumer is an optional interface for strategies that re-validate a
+// session the agent resumes after a restart (see session_resume.go).
+// This is used by the SessionStart hook.
+type SessionResumer interface {
+	// ResumeSession brings the session's state in line with the repository
+	// and records the resume. Returns nil, nil for a session Entire doesn't
+	// know yet.
+	ResumeSession(ctx context.Context, sessionID, source string) (*session.ResumeRecord, error)
+}
+
 // SessionSource is an optional interface for strategies that provide additional
 // sessions beyond those stored on the entire/checkpoints/v1 branch.
 // For example, manual-commit strategy provides active sessions from .git/entire-sessions/
@@ -548,3 +588,25 @@ type OrphanedItemsLister interface {
 	// Each strategy defines what "orphaned" means for its own data structures.
 	ListOrphanedItems() ([]CleanupItem, error)
 }
+
+// AttributionPreview describes the attribution a session would receive if the
+// user committed now. Attribution is nil when the session has no checkpoints yet.
+type AttributionPreview struct {
+	SessionID        string
+	AgentType        agent.AgentType
+	BaseCommit       string // Attribution base (commit the session's line counts are measured from)
+	CheckpointCommit string // Latest shadow branch commit (empty if no checkpoints yet)
+	StepCount        int
+	FilesTouched     []string
+	Attribution      *checkpoint.InitialAttribution
+}
+
+// AttributionPreviewer is an optional interface for strategies that can compute
+// attribution ahead of a commit. This is used by "entire attribution preview"
+// to show the agent/human split without committing.
+type AttributionPreviewer interface {
+	// PreviewAttribution returns one preview per session in the current worktree.
+	// When includeWorktree is false the candidate commit is the index; when true,
+	// unstaged and untracked worktree changes are included as well.
+	PreviewAttribution(includeWorktree bool) ([]AttributionPreview, error)
+}
@@ -0,0 +1,78 @@
+package strategy
+
+import (
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Subagents spawned with the Task tool write their checkpoints to the parent
+// session's shadow branch under tasks/<tool-use-id>/. The session state
+// keeps one SubagentRun per tool use, so condensation can record them as
+// children of the session in the committed checkpoint, each with its own
+// transcript under subagents/<tool-use-id>/.
+
+// recordSubagentRun adds a task checkpoint to the session's subagent runs.
+func recordSubagentRun(state *SessionState, ctx TaskCheckpointContext) {
+	var run *session.SubagentRun
+	for i := range state.Subagents {
+		if state.Subagents[i].ToolUseID == ctx.ToolUseID {
+			run = &state.Subagents[i]
+			break
+		}
+	}
+	if run == nil {
+		state.Subagents = append(state.Subagents, session.SubagentRun{ToolUseID: ctx.ToolUseID})
+		run = &state.Subagents[len(state.Subagents)-1]
+	}
+	if ctx.AgentID != "" {
+		run.AgentID = ctx.AgentID
+	}
+	if ctx.SubagentType != "" {
+		run.SubagentType = ctx.SubagentType
+	}
+	if ctx.TaskDescription != "" {
+		run.Description = ctx.TaskDescription
+	}
+	run.Checkpoints++
+	run.FilesTouched = mergeFilesTouched(run.FilesTouched, ctx.ModifiedFiles, ctx.NewFiles, ctx.DeletedFiles)
+	if !ctx.IsIncremental {
+		run.Done = true
+	}
+}
+
+// condensedSubagents returns the session's subagent runs for the committed
+// checkpoint, with the transcripts their final checkpoints stored on the
+// shadow branch (tree is the shadow branch tip's), keyed by tool use ID.
+func condensedSubagents(tree *object.Tree, state *SessionState) ([]checkpoint.SubagentMetadata, map[string][]byte) {
+	if len(state.Subagents) == 0 {
+		return nil, nil
+	}
+	metadataDir := paths.SessionMetadataDirFromSessionID(state.SessionID)
+	subagents := make([]checkpoint.SubagentMetadata, 0, len(state.Subagents))
+	transcripts := make(map[string][]byte)
+	for _, run := range state.Subagents {
+		subagents = append(subagents, checkpoint.SubagentMetadata{
+			ToolUseID:    run.ToolUseID,
+			AgentID:      run.AgentID,
+			SubagentType: run.SubagentType,
+			Description:  run.Description,
+			Checkpoints:  run.Checkpoints,
+			FilesTouched: run.FilesTouched,
+			Running:      !run.Done,
+		})
+		if run.AgentID == "" || tree == nil {
+			continue
+		}
+		file, err := tree.File(TaskMetadataDir(metadataDir, run.ToolUseID) + "/agent-" + run.AgentID + ".jsonl")
+		if err != nil {
+			continue
+		}
+		if content, err := file.Contents(); err == nil {
+			transcripts[run.ToolUseID] = []byte(content)
+		}
+	}
+	return subagents, transcripts
+}
@@ -0,0 +1,95 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestSubagentRuns_CondensedAsChildren(t *testing.T) {
+	dir := t.TempDir()
+	initTestRepo(t, dir)
+	t.Chdir(dir)
+
+	transcriptPath := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(transcriptPath, []byte(`{"type":"user","message":{"content":"split the parser"}}`+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	agentTranscript := filepath.Join(dir, "agent-a1b2c3.jsonl")
+	if err := os.WriteFile(agentTranscript, []byte(`{"type":"user","message":{"content":"move the lexer out"}}`+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write subagent transcript: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lexer.go"), []byte("package main\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	s := &ManualCommitStrategy{}
+	sessionID := "2026-10-14-subagents"
+	task := TaskCheckpointContext{
+		SessionID:       sessionID,
+		ToolUseID:       "toolu_01parser",
+		TranscriptPath:  transcriptPath,
+		AuthorName:      "Test",
+		AuthorEmail:     "test@test.com",
+		SubagentType:    "general-purpose",
+		TaskDescription: "Move the lexer",
+	}
+	incremental := task
+	incremental.IsIncremental = true
+	incremental.IncrementalSequence = 1
+	incremental.IncrementalType = "TodoWrite"
+	incremental.ModifiedFiles = []string{"README.md"}
+	if err := s.SaveTaskCheckpoint(incremental); err != nil {
+		t.Fatalf("SaveTaskCheckpoint(incremental) error = %v", err)
+	}
+	final := task
+	final.AgentID = "a1b2c3"
+	final.SubagentTranscriptPath = agentTranscript
+	final.NewFiles = []string{"lexer.go"}
+	if err := s.SaveTaskCheckpoint(final); err != nil {
+		t.Fatalf("SaveTaskCheckpoint(final) error = %v", err)
+	}
+
+	state, err := s.loadSessionState(sessionID)
+	if err != nil {
+		t.Fatalf("loadSessionState() error = %v", err)
+	}
+	if len(state.Subagents) != 1 {
+		t.Fatalf("Subagents = %+v, want one run", state.Subagents)
+	}
+	run := state.Subagents[0]
+	if run.Checkpoints != 2 || !run.Done || run.AgentID != "a1b2c3" || strings.Join(run.FilesTouched, ",") != "README.md,lexer.go" {
+		t.Errorf("subagent run = %+v", run)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	cpID := id.MustCheckpointID("c3d4e5f6a1b2")
+	if _, err := s.CondenseSession(repo, cpID, state); err != nil {
+		t.Fatalf("CondenseSession() error = %v", err)
+	}
+	store := checkpoint.NewGitStore(repo)
+	metadata, err := store.ReadSessionMetadata(t.Context(), cpID, 0)
+	if err != nil {
+		t.Fatalf("ReadSessionMetadata() error = %v", err)
+	}
+	if len(metadata.Subagents) != 1 {
+		t.Fatalf("committed Subagents = %+v, want one", metadata.Subagents)
+	}
+	sub := metadata.Subagents[0]
+	if sub.ToolUseID != "toolu_01parser" || sub.SubagentType != "general-purpose" || sub.Running || sub.Transcript != "subagents/toolu_01parser/full.jsonl" {
+		t.Errorf("committed subagent = %+v", sub)
+	}
+	content, err := store.ReadSubagentTranscript(t.Context(), cpID, 0, sub.ToolUseID)
+	if err != nil || !strings.Contains(string(content), "move the lexer out") {
+		t.Errorf("ReadSubagentTranscript() = %q, %v", content, err)
+	}
+}
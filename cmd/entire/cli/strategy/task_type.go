@@ -0,0 +1,42 @@
+package strategy
+
+import (
+	"context"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/classify"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// classifyTaskType tags a prompt with its task type using the classifier
+// configured in settings. Returns "" for an empty prompt.
+func classifyTaskType(prompt string) string {
+	if strings.TrimSpace(prompt) == "" {
+		return ""
+	}
+	var command string
+	if s, err := settings.Load(); err == nil {
+		command = s.ClassifierCommand()
+	}
+	return string(classify.New(command).Classify(context.Background(), prompt))
+}
+
+// updateTaskType tags a session that has no task type yet, or only "other",
+// from its latest prompt.
+func updateTaskType(state *SessionState, prompt string) {
+	if state.TaskType != "" && state.TaskType != string(classify.Other) {
+		return
+	}
+	if taskType := classifyTaskType(prompt); taskType != "" {
+		state.TaskType = taskType
+	}
+}
+
+// sessionTaskType returns the task type recorded for a session.
+func sessionTaskType(sessionID string) string {
+	state, err := LoadSessionState(sessionID)
+	if err != nil || state == nil {
+		return ""
+	}
+	return state.TaskType
+}
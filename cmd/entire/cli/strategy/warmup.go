@@ -0,0 +1,75 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/fsenv"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// treeListingCache returns the repository's cache of flattened tree listings,
+// or nil if the git common dir can't be found.
+func treeListingCache() *checkpoint.TreeListingCache {
+	commonDir, err := GetGitCommonDir()
+	if err != nil {
+		return nil
+	}
+	return checkpoint.NewTreeListingCache(fsenv.StateDir(commonDir, checkpoint.TreeListingCacheDirName))
+}
+
+// WarmUp precomputes what the first checkpoint of a session reads, so the
+// first Stop hook doesn't pay the cold-start cost. Session start runs it in
+// the background:
+//   - The listing of HEAD's tree is cached for the new shadow branch to build on
+//   - git status runs once, warming the index, the page cache and, when
+//     core.fsmonitor is set, the fsmonitor daemon. It doesn't write the index,
+//     so it never holds index.lock while the agent runs git commands.
+//
+// Nothing is cached for the fsmonitor token (it's stored in the index, which
+// isn't written) or for whether HEAD moved away from a session's base (shadow
+// branch migration is a ref comparison at Stop time), and `entire daemon`
+// isn't involved.
+func WarmUp(ctx context.Context) error {
+	repo, err := OpenRepository()
+	if err != nil {
+		return fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil //nolint:nilerr // No commits yet, nothing to warm up
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	if cache := treeListingCache(); cache != nil && !cache.Has(commit.TreeHash) {
+		tree, err := commit.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to read HEAD tree: %w", err)
+		}
+		entries := make(map[string]object.TreeEntry)
+		if err := checkpoint.FlattenTree(repo, tree, "", entries); err != nil {
+			return fmt.Errorf("failed to flatten HEAD tree: %w", err)
+		}
+		if err := cache.Store(commit.TreeHash, entries); err != nil {
+			return err //nolint:wrapcheck // already wrapped by the cache
+		}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	// Same invocation as the first checkpoint's collectChangedFiles
+	cmd := exec.CommandContext(ctx, "git", "--no-optional-locks", "status", "--porcelain", "-z", "-uall")
+	cmd.Dir = wt.Filesystem.Root()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run git status: %w", err)
+	}
+	return nil
+}
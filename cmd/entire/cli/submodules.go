@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Files the agent changes inside a git submodule can't go into this
+// repository's checkpoints: its tree only holds the commit the submodule is
+// at. Stop hooks list the submodule itself instead, so the checkpoint records
+// that commit, and with submodules.recurse also checkpoint the files in the
+// submodule's own repository, like a workspace repository's.
+
+// headSubmodules returns the commits of the submodules in HEAD's tree by
+// path, nil if there are none or HEAD can't be read.
+func headSubmodules() map[string]plumbing.Hash {
+	repo, err := openRepository()
+	if err != nil {
+		return nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil
+	}
+	commits := checkpoint.SubmoduleCommits(tree)
+	if len(commits) == 0 {
+		return nil
+	}
+	return commits
+}
+
+// submoduleRepoRoots returns the roots of the checked-out submodules when
+// submodules.recurse is set, for saveWorkspaceCheckpoints.
+func submoduleRepoRoots(repoRoot string, submodules map[string]plumbing.Hash) []string {
+	if len(submodules) == 0 {
+		return nil
+	}
+	s, err := settings.Load()
+	if err != nil || s.Submodules == nil || !s.Submodules.Recurse {
+		return nil
+	}
+	var roots []string
+	for path := range submodules {
+		root := filepath.Join(repoRoot, filepath.FromSlash(path))
+		if _, ok := checkpoint.SubmoduleHead(root); ok {
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// collapseSubmoduleFiles replaces the files (repository-relative) inside
+// submodules with the submodules' paths, each listed once.
+func collapseSubmoduleFiles(files []string, submodules map[string]plumbing.Hash) []string {
+	if len(submodules) == 0 {
+		return files
+	}
+	var result []string
+	for _, file := range files {
+		if path := submoduleOf(file, submodules); path != "" {
+			file = path
+		}
+		if !slices.Contains(result, file) {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// submoduleOf returns the path of the submodule file is in or is, "" if none.
+func submoduleOf(file string, submodules map[string]plumbing.Hash) string {
+	for path := range submodules {
+		if file == path || strings.HasPrefix(file, path+"/") {
+			return path
+		}
+	}
+	return ""
+}
+
+// movedSubmodules returns the submodules whose checked-out commit isn't the
+// one HEAD records, sorted, e.g. after the agent checked out another commit
+// in one.
+func movedSubmodules(repoRoot string, submodules map[string]plumbing.Hash) []string {
+	var moved []string
+	for path, commit := range submodules {
+		if head, ok := checkpoint.SubmoduleHead(filepath.Join(repoRoot, filepath.FromSlash(path))); ok && head != commit {
+			moved = append(moved, path)
+		}
+	}
+	sort.Strings(moved)
+	return moved
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+func TestStopHookFiles_Submodules(t *testing.T) {
+	dir := t.TempDir()
+	gitRun := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), "git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	sub := filepath.Join(dir, "lib")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(sub, "init", "-q")
+	if err := os.WriteFile(filepath.Join(sub, "lib.go"), []byte("package lib\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(sub, "add", "lib.go")
+	gitRun(sub, "commit", "-q", "-m", "first")
+	gitRun(dir, "init", "-q")
+	gitRun(dir, "add", "lib")
+	gitRun(dir, "commit", "-q", "-m", "add lib")
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subFile := filepath.Join(repoRoot, "lib", "lib.go")
+	transcriptFiles := []string{filepath.Join(repoRoot, "main.go"), subFile}
+
+	// Files inside the submodule are listed as the submodule
+	modified, _, _, workspaceFiles := stopHookFiles(transcriptFiles, &FileChanges{Deleted: []string{"lib/old.go"}}, repoRoot)
+	if !slices.Equal(modified, []string{"main.go", "lib"}) || len(workspaceFiles) != 0 {
+		t.Errorf("modified = %v, workspace files = %v; want main.go and lib", modified, workspaceFiles)
+	}
+
+	// A submodule the agent moved is listed without files changed in it
+	gitRun(sub, "commit", "-q", "--allow-empty", "-m", "second")
+	modified, _, _, _ = stopHookFiles(nil, nil, repoRoot)
+	if !slices.Equal(modified, []string{"lib"}) {
+		t.Errorf("modified = %v, want the moved submodule", modified)
+	}
+
+	// With submodules.recurse its files are checkpointed in its repository
+	if err := os.MkdirAll(filepath.Join(repoRoot, ".entire"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, ".entire", "settings.json"), []byte(`{"submodules": {"recurse": true}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modified, _, _, workspaceFiles = stopHookFiles(transcriptFiles, nil, repoRoot)
+	if !slices.Equal(modified, []string{"main.go", "lib"}) {
+		t.Errorf("modified = %v, want main.go and the moved submodule", modified)
+	}
+	if got := workspaceFiles[filepath.Join(repoRoot, "lib")]; !slices.Equal(got, []string{subFile}) {
+		t.Errorf("submodule files = %v, want %s", got, subFile)
+	}
+}
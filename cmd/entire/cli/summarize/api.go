@@ -0,0 +1,172 @@
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+)
+
+// Defaults of the API generators.
+const (
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	DefaultAnthropicModel   = "claude-sonnet-4-5"
+	DefaultOpenAIBaseURL    = "https://api.openai.com/v1"
+	DefaultOpenAIModel      = "gpt-4o-mini"
+
+	// anthropicVersion is the Messages API version requests are made against.
+	anthropicVersion = "2023-06-01"
+	// maxSummaryTokens bounds the summary's length in API requests.
+	maxSummaryTokens = 4096
+)
+
+// AnthropicGenerator generates summaries with the Anthropic Messages API.
+type AnthropicGenerator struct {
+	// APIKey is sent as x-api-key. Required.
+	APIKey string
+	// Model is the model to use. If empty, defaults to DefaultAnthropicModel.
+	Model string
+	// BaseURL is the API's base URL. If empty, defaults to DefaultAnthropicBaseURL.
+	BaseURL string
+	// HTTPClient sends the requests. If nil, uses http.DefaultClient; the
+	// context bounds how long a request may take.
+	HTTPClient *http.Client
+}
+
+// Generate creates a summary from checkpoint data with the Messages API.
+func (g *AnthropicGenerator) Generate(ctx context.Context, input Input) (*checkpoint.Summary, error) {
+	if g.APIKey == "" {
+		return nil, errors.New("anthropic API key not set")
+	}
+	request := map[string]any{
+		"model":      valueOr(g.Model, DefaultAnthropicModel),
+		"max_tokens": maxSummaryTokens,
+		"messages": []map[string]string{
+			{"role": "user", "content": buildSummarizationPrompt(FormatCondensedTranscript(input))},
+		},
+	}
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	headers := map[string]string{"x-api-key": g.APIKey, "anthropic-version": anthropicVersion}
+	url := strings.TrimSuffix(valueOr(g.BaseURL, DefaultAnthropicBaseURL), "/") + "/v1/messages"
+	if err := postJSON(ctx, g.HTTPClient, url, headers, request, &response); err != nil {
+		return nil, fmt.Errorf("anthropic API: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return parseSummary(text.String())
+}
+
+// OpenAIGenerator generates summaries with an OpenAI-compatible chat
+// completions endpoint, e.g. OpenAI's or a local Ollama or vLLM server.
+type OpenAIGenerator struct {
+	// APIKey is sent as a bearer token. Local servers may not need one.
+	APIKey string
+	// Model is the model to use. If empty, defaults to DefaultOpenAIModel.
+	Model string
+	// BaseURL is the API's base URL, up to /chat/completions. If empty,
+	// defaults to DefaultOpenAIBaseURL.
+	BaseURL string
+	// HTTPClient sends the requests. If nil, uses http.DefaultClient; the
+	// context bounds how long a request may take.
+	HTTPClient *http.Client
+}
+
+// Generate creates a summary from checkpoint data with the chat completions API.
+func (g *OpenAIGenerator) Generate(ctx context.Context, input Input) (*checkpoint.Summary, error) {
+	request := map[string]any{
+		"model":      valueOr(g.Model, DefaultOpenAIModel),
+		"max_tokens": maxSummaryTokens,
+		"messages": []map[string]string{
+			{"role": "user", "content": buildSummarizationPrompt(FormatCondensedTranscript(input))},
+		},
+	}
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	var headers map[string]string
+	if g.APIKey != "" {
+		headers = map[string]string{"Authorization": "Bearer " + g.APIKey}
+	}
+	url := strings.TrimSuffix(valueOr(g.BaseURL, DefaultOpenAIBaseURL), "/") + "/chat/completions"
+	if err := postJSON(ctx, g.HTTPClient, url, headers, request, &response); err != nil {
+		return nil, fmt.Errorf("chat completions API: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, errors.New("chat completions API returned no choices")
+	}
+	return parseSummary(response.Choices[0].Message.Content)
+}
+
+// postJSON posts in as JSON to url and decodes the JSON response into out.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "entire-cli")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr) //nolint:errcheck // the status is reported either way
+		if apiErr.Error.Message == "" {
+			apiErr.Error.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("%d %s", resp.StatusCode, apiErr.Error.Message)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// valueOr returns s, or fallback if s is empty.
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
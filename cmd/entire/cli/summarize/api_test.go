@@ -0,0 +1,127 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+const testSummaryJSON = `{"intent":"Add a parser","outcome":"Parser added","decisions":["Hand-written, not generated"],"learnings":{"repo":[],"code":[],"workflow":[]},"friction":[],"open_items":[]}`
+
+func TestAnthropicGenerator_Generate(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "sk-test" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("unexpected request %s, headers %v", r.URL.Path, r.Header)
+		}
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != DefaultAnthropicModel || len(req.Messages) != 1 ||
+			!strings.Contains(req.Messages[0].Content, "[User] add a parser") {
+			t.Errorf("unexpected request body %+v, %v", req, err)
+		}
+		resp, _ := json.Marshal(map[string]any{"content": []map[string]string{{"type": "text", "text": "```json\n" + testSummaryJSON + "\n```"}}}) //nolint:errcheck // test fixture
+		w.Write(resp)                                                                                                                              //nolint:errcheck // test server
+	}))
+	defer server.Close()
+
+	g := &AnthropicGenerator{APIKey: "sk-test", BaseURL: server.URL}
+	summary, err := g.Generate(context.Background(), Input{Transcript: []Entry{{Type: EntryTypeUser, Content: "add a parser"}}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if summary.Intent != "Add a parser" || len(summary.Decisions) != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestOpenAIGenerator_Generate(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected request %s, headers %v", r.URL.Path, r.Header)
+		}
+		resp, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"message": map[string]string{"content": testSummaryJSON}}}}) //nolint:errcheck // test fixture
+		w.Write(resp)                                                                                                                       //nolint:errcheck // test server
+	}))
+	defer server.Close()
+
+	// A local server without an API key
+	g := &OpenAIGenerator{Model: "llama3", BaseURL: server.URL + "/v1/"}
+	summary, err := g.Generate(context.Background(), Input{Transcript: []Entry{{Type: EntryTypeUser, Content: "add a parser"}}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if summary.Outcome != "Parser added" {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestAPIGenerator_ErrorStatus(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"invalid x-api-key"}}`)) //nolint:errcheck // test server
+	}))
+	defer server.Close()
+
+	g := &AnthropicGenerator{APIKey: "sk-wrong", BaseURL: server.URL}
+	_, err := g.Generate(context.Background(), Input{Transcript: []Entry{{Type: EntryTypeUser, Content: "hi"}}})
+	if err == nil || !strings.Contains(err.Error(), "401 invalid x-api-key") {
+		t.Errorf("Generate() error = %v, want the API's message", err)
+	}
+}
+
+func TestCommandGenerator_Generate(t *testing.T) {
+	t.Parallel()
+	// The prompt arrives on stdin
+	g := &CommandGenerator{Command: `grep -q '\[User\] add a parser' && printf '%s' '` + testSummaryJSON + `'`}
+	summary, err := g.Generate(context.Background(), Input{Transcript: []Entry{{Type: EntryTypeUser, Content: "add a parser"}}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if summary.Intent != "Add a parser" {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	g = &CommandGenerator{Command: "echo model unavailable >&2; exit 3"}
+	if _, err := g.Generate(context.Background(), Input{}); err == nil || !strings.Contains(err.Error(), "model unavailable") {
+		t.Errorf("Generate() error = %v, want the command's stderr", err)
+	}
+}
+
+func TestNewGenerator(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("TEAM_LLM_KEY", "sk-team")
+
+	if g, err := NewGenerator(nil); err != nil {
+		t.Errorf("NewGenerator(nil) error = %v", err)
+	} else if _, ok := g.(*ClaudeGenerator); !ok {
+		t.Errorf("NewGenerator(nil) = %T, want the claude CLI", g)
+	}
+	if _, err := NewGenerator(&settings.SummarizerSettings{Provider: "anthropic"}); err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Errorf("NewGenerator(anthropic) without a key error = %v", err)
+	}
+	g, err := NewGenerator(&settings.SummarizerSettings{Provider: "Anthropic", APIKeyEnv: "TEAM_LLM_KEY", Model: "claude-opus-4-1"})
+	if err != nil {
+		t.Fatalf("NewGenerator(anthropic) error = %v", err)
+	}
+	if a, ok := g.(*AnthropicGenerator); !ok || a.APIKey != "sk-team" || a.Model != "claude-opus-4-1" {
+		t.Errorf("NewGenerator(anthropic) = %+v", g)
+	}
+	if _, err := NewGenerator(&settings.SummarizerSettings{Provider: "command"}); err == nil {
+		t.Error("NewGenerator(command) without a command error = nil")
+	}
+	if _, err := NewGenerator(&settings.SummarizerSettings{Provider: "gemini"}); err == nil {
+		t.Error("NewGenerator(gemini) error = nil, want an unknown provider")
+	}
+}
@@ -29,6 +29,7 @@ Return a JSON object with this exact structure:
 {
   "intent": "What the user was trying to accomplish (1-2 sentences)",
   "outcome": "What was actually achieved (1-2 sentences)",
+  "decisions": ["Key decisions made along the way, and why"],
   "learnings": {
     "repo": ["Codebase-specific patterns, conventions, or gotchas discovered"],
     "code": [{"path": "file/path.go", "line": 42, "end_line": 56, "finding": "What was learned"}],
@@ -42,6 +43,7 @@ Guidelines:
 - Be concise but specific
 - Include line numbers for code learnings when the transcript references specific lines
 - Friction should capture both blockers and minor annoyances
+- Decisions are choices between approaches, not every step taken
 - Open items are things intentionally deferred, not failures
 - Empty arrays are fine if a category doesn't apply
 - Return ONLY the JSON object, no markdown formatting or explanation`
@@ -140,12 +142,14 @@ func (g *ClaudeGenerator) Generate(ctx context.Context, input Input) (*checkpoin
 	}
 
 	// The result field contains the actual JSON summary
-	resultJSON := cliResponse.Result
+	return parseSummary(cliResponse.Result)
+}
 
-	// Try to extract JSON if it's wrapped in markdown code blocks
-	resultJSON = extractJSONFromMarkdown(resultJSON)
+// parseSummary parses the summary JSON an LLM responded with, which may be
+// wrapped in a markdown code block.
+func parseSummary(response string) (*checkpoint.Summary, error) {
+	resultJSON := extractJSONFromMarkdown(response)
 
-	// Parse the summary from the result
 	var summary checkpoint.Summary
 	if err := json.Unmarshal([]byte(resultJSON), &summary); err != nil {
 		return nil, fmt.Errorf("failed to parse summary JSON: %w (response: %s)", err, resultJSON)
@@ -0,0 +1,41 @@
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+)
+
+// CommandGenerator generates summaries with a user-provided command, e.g. a
+// script calling a local model. The command reads the prompt on stdin and
+// prints the summary JSON (or an LLM response containing it) on stdout.
+type CommandGenerator struct {
+	// Command is run with sh -c.
+	Command string
+}
+
+// Generate creates a summary from checkpoint data by running the command.
+func (g *CommandGenerator) Generate(ctx context.Context, input Input) (*checkpoint.Summary, error) {
+	if strings.TrimSpace(g.Command) == "" {
+		return nil, errors.New("no summarizer command configured")
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", g.Command)
+	// Like the claude CLI, the command runs outside the repository
+	cmd.Dir = os.TempDir()
+	cmd.Env = stripGitEnv(os.Environ())
+	cmd.Stdin = strings.NewReader(buildSummarizationPrompt(FormatCondensedTranscript(input)))
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("summarizer command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseSummary(stdout.String())
+}
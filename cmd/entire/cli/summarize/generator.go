@@ -0,0 +1,46 @@
+package summarize
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+)
+
+// ConfiguredGenerator returns the generator the summarizer settings pick,
+// the claude CLI if there are none.
+func ConfiguredGenerator() (Generator, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	return NewGenerator(s.Summarizer)
+}
+
+// NewGenerator returns the generator for s. API keys are read from the
+// environment variable s names, or the provider's usual one.
+func NewGenerator(s *settings.SummarizerSettings) (Generator, error) {
+	provider, err := s.EffectiveProvider()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already describes the setting
+	}
+	var model, baseURL, apiKeyEnv string
+	if s != nil {
+		model, baseURL, apiKeyEnv = s.Model, s.BaseURL, s.APIKeyEnv
+	}
+
+	switch provider {
+	case settings.SummarizerAnthropic:
+		key := os.Getenv(valueOr(apiKeyEnv, "ANTHROPIC_API_KEY"))
+		if key == "" {
+			return nil, fmt.Errorf("summarizer provider anthropic needs an API key in %s", valueOr(apiKeyEnv, "ANTHROPIC_API_KEY"))
+		}
+		return &AnthropicGenerator{APIKey: key, Model: model, BaseURL: baseURL}, nil
+	case settings.SummarizerOpenAI:
+		return &OpenAIGenerator{APIKey: os.Getenv(valueOr(apiKeyEnv, "OPENAI_API_KEY")), Model: model, BaseURL: baseURL}, nil
+	case settings.SummarizerCommand:
+		return &CommandGenerator{Command: s.Command}, nil
+	default:
+		return &ClaudeGenerator{Model: model}, nil
+	}
+}
@@ -19,7 +19,7 @@ import (
 //   - ctx: context for cancellation
 //   - transcriptBytes: raw transcript bytes (JSONL format)
 //   - filesTouched: list of files modified during the session
-//   - generator: summary generator to use (if nil, uses default ClaudeGenerator)
+//   - generator: summary generator to use (if nil, uses ConfiguredGenerator)
 //
 // Returns nil, error if transcript is empty or cannot be parsed.
 func GenerateFromTranscript(ctx context.Context, transcriptBytes []byte, filesTouched []string, generator Generator) (*checkpoint.Summary, error) {
@@ -41,9 +41,12 @@ func GenerateFromTranscript(ctx context.Context, transcriptBytes []byte, filesTo
 		FilesTouched: filesTouched,
 	}
 
-	// Use default generator if none provided
+	// Use the configured generator if none provided
 	if generator == nil {
-		generator = &ClaudeGenerator{}
+		generator, err = ConfiguredGenerator()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	summary, err := generator.Generate(ctx, input)
@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd() *cobra.Command {
+	var statusFlag bool
+	var remote string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync shadow branches with a remote",
+		Long: fmt.Sprintf(`Push and pull shadow branches, so checkpoints that haven't been committed
+yet can be rewound to on another machine.
+
+Shadow branches are stored on the remote under %s, outside its
+branches. The remote is "sync_remote" in .entire/settings.json (default
+"origin"), or --remote.
+
+Pushes are differential: the remote's shadow branch tips are listed first and
+only checkpoints it doesn't have are sent.
+
+A shadow branch that has new checkpoints on both sides can't be merged; it is
+reported as a conflict and left alone unless --force is given.
+
+Use --status to see, per session, how many checkpoints haven't been pushed
+and for how long.`, strategy.ShadowSyncRefPrefix),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !statusFlag {
+				return cmd.Help()
+			}
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSyncStatus(cmd.Context(), cmd.OutOrStdout(), remote, jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&statusFlag, "status", false, "Show per-session checkpoints not yet pushed to the sync remote")
+	cmd.Flags().StringVar(&remote, "remote", "", "Remote to compare with (default: sync_remote setting, or origin)")
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output --status as JSON")
+
+	cmd.AddCommand(newSyncPushCmd())
+	cmd.AddCommand(newSyncPullCmd())
+	return cmd
+}
+
+func newSyncPushCmd() *cobra.Command {
+	var remote string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Push shadow branches to the sync remote",
+		Long: `Push shadow branches and the state of the sessions that ran here to the
+sync remote. A session's state holds its attribution baselines and live
+transcript, so 'entire sessions pull' can continue it on another machine.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSyncPush(cmd.Context(), cmd.OutOrStdout(), remote, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&remote, "remote", "", "Remote to push to (default: sync_remote setting, or origin)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite shadow branches and session states that diverged on the remote")
+
+	return cmd
+}
+
+func newSyncPullCmd() *cobra.Command {
+	var remote string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Pull shadow branches from the sync remote",
+		Long: `Fetch shadow branches from the sync remote and create or fast-forward the
+local ones. Sessions on pulled shadow branches of this worktree get an (ended)
+session state, so 'entire rewind' lists their checkpoints once their base
+commit is checked out.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runSyncPull(cmd.Context(), cmd.OutOrStdout(), remote, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&remote, "remote", "", "Remote to pull from (default: sync_remote setting, or origin)")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace local shadow branches that diverged from the remote")
+
+	return cmd
+}
+
+// syncRemote returns remote, or the configured sync remote if it is empty.
+func syncRemote(remote string) (string, error) {
+	if remote != "" {
+		return remote, nil
+	}
+	s, err := LoadEntireSettings()
+	if err != nil {
+		return "", err
+	}
+	return s.EffectiveSyncRemote(), nil
+}
+
+func runSyncPush(ctx context.Context, w io.Writer, remote string, force bool) error {
+	remote, err := syncRemote(remote)
+	if err != nil {
+		return err
+	}
+	result, err := strategy.PushShadowBranches(ctx, remote, force)
+	if err != nil {
+		return err //nolint:wrapcheck // already describes the failed push
+	}
+	fmt.Fprintf(w, "Pushed %d shadow branch(es) (%d new checkpoint(s)) to %s, %d already up to date.\n",
+		len(result.Updated), result.NewCheckpoints, remote, len(result.UpToDate))
+	if len(result.Behind) > 0 {
+		fmt.Fprintf(w, "%d shadow branch(es) have newer checkpoints on %s; pull them with 'entire sync pull'.\n", len(result.Behind), remote)
+	}
+	states, err := strategy.PushSessionStates(ctx, remote, force)
+	if err != nil {
+		return err //nolint:wrapcheck // already describes the failed push
+	}
+	if len(states.Pushed) > 0 {
+		fmt.Fprintf(w, "Pushed the state of %d session(s), so they can be continued elsewhere with 'entire sessions pull'.\n", len(states.Pushed))
+	}
+	for _, sessionID := range states.Behind {
+		fmt.Fprintf(w, "Session %s has a newer state on %s; pull it with 'entire sessions pull %s'.\n", sessionID, remote, sessionID)
+	}
+	return reportSyncConflicts(w, result, "Pull them with 'entire sync pull' first, or overwrite the remote's with 'entire sync push --force'.")
+}
+
+func runSyncPull(ctx context.Context, w io.Writer, remote string, force bool) error {
+	remote, err := syncRemote(remote)
+	if err != nil {
+		return err
+	}
+	result, err := strategy.PullShadowBranches(ctx, remote, force)
+	if err != nil {
+		return err //nolint:wrapcheck // already describes the failed pull
+	}
+	fmt.Fprintf(w, "Pulled %d shadow branch(es) from %s, %d already up to date.\n", len(result.Updated), remote, len(result.UpToDate))
+	if result.SessionsImported > 0 {
+		fmt.Fprintf(w, "Imported %d session(s) for rewind.\n", result.SessionsImported)
+	}
+	return reportSyncConflicts(w, result, "Keep the local checkpoints, or take the remote's with 'entire sync pull --force'.")
+}
+
+// syncSessionLagJSON is the JSON shape of one session's sync lag.
+type syncSessionLagJSON struct {
+	SessionID      string     `json:"session_id"`
+	Checkpoints    int        `json:"checkpoints"`
+	Unpushed       int        `json:"unpushed"`
+	OldestUnpushed *time.Time `json:"oldest_unpushed,omitempty"`
+}
+
+type syncBranchStatusJSON struct {
+	Branch      string               `json:"branch"`
+	OnRemote    bool                 `json:"on_remote"`
+	RemoteAhead bool                 `json:"remote_ahead"`
+	Sessions    []syncSessionLagJSON `json:"sessions"`
+}
+
+type syncStatusJSON struct {
+	Remote   string                 `json:"remote"`
+	Offline  bool                   `json:"offline"`
+	Branches []syncBranchStatusJSON `json:"branches"`
+}
+
+func runSyncStatus(ctx context.Context, w io.Writer, remote string, jsonOutput bool) error {
+	remote, err := syncRemote(remote)
+	if err != nil {
+		return err
+	}
+	report, err := strategy.GetShadowSyncStatus(ctx, remote)
+	if err != nil {
+		return fmt.Errorf("failed to get sync status: %w", err)
+	}
+
+	if jsonOutput {
+		out := syncStatusJSON{Remote: report.Remote, Offline: report.Offline, Branches: []syncBranchStatusJSON{}}
+		for _, b := range report.Branches {
+			entry := syncBranchStatusJSON{Branch: b.Branch, OnRemote: b.OnRemote, RemoteAhead: b.RemoteAhead, Sessions: []syncSessionLagJSON{}}
+			for _, s := range b.Sessions {
+				lag := syncSessionLagJSON{SessionID: s.SessionID, Checkpoints: s.Checkpoints, Unpushed: s.Unpushed}
+				if !s.OldestUnpushed.IsZero() {
+					oldest := s.OldestUnpushed
+					lag.OldestUnpushed = &oldest
+				}
+				entry.Sessions = append(entry.Sessions, lag)
+			}
+			out.Branches = append(out.Branches, entry)
+		}
+		data, err := jsonutil.MarshalIndentWithNewline(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal sync status: %w", err)
+		}
+		_, err = w.Write(data)
+		return err //nolint:wrapcheck // write to stdout
+	}
+
+	if len(report.Branches) == 0 {
+		fmt.Fprintln(w, "No shadow branches to sync.")
+		return nil
+	}
+	if report.Offline {
+		fmt.Fprintf(w, "Couldn't reach %s; showing the status as of the last sync.\n\n", remote)
+	}
+	fmt.Fprintf(w, "Shadow branches compared with %s:\n", remote)
+	for _, b := range report.Branches {
+		note := ""
+		switch {
+		case !b.OnRemote:
+			note = " (not pushed yet)"
+		case b.RemoteAhead:
+			note = " (remote has newer checkpoints, run 'entire sync pull')"
+		}
+		fmt.Fprintf(w, "\n  %s%s\n", b.Branch, note)
+		for _, s := range b.Sessions {
+			if s.Unpushed == 0 {
+				fmt.Fprintf(w, "    %s  up to date (%d checkpoint(s))\n", s.SessionID, s.Checkpoints)
+				continue
+			}
+			fmt.Fprintf(w, "    %s  %d of %d checkpoint(s) not pushed, oldest %s\n",
+				s.SessionID, s.Unpushed, s.Checkpoints, timeAgo(s.OldestUnpushed))
+		}
+	}
+	return nil
+}
+
+// reportSyncConflicts lists the branches that diverged and fails the command
+// if there are any.
+func reportSyncConflicts(w io.Writer, result *strategy.ShadowSyncResult, hint string) error {
+	if len(result.Conflicts) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\n%d shadow branch(es) diverged and were left alone:\n", len(result.Conflicts))
+	for _, branch := range result.Conflicts {
+		fmt.Fprintf(w, "  %s\n", branch)
+	}
+	fmt.Fprintln(w, hint)
+	return NewSilentError(errors.New("shadow branches diverged"))
+}
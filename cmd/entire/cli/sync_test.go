@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/trailers"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRunSync_PushPullAndConflict(t *testing.T) {
+	remoteDir := t.TempDir()
+	if out, err := exec.CommandContext(context.Background(), "git", "init", "--bare", remoteDir).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v\n%s", err, out)
+	}
+	addRemote := func(repo *git.Repository) {
+		t.Helper()
+		if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}}); err != nil {
+			t.Fatalf("failed to add remote: %v", err)
+		}
+	}
+
+	// Both clones get the same initial commit, so the shadow branch's base exists in each
+	repoA, base := setupCleanTestRepo(t)
+	addRemote(repoA)
+	branch := checkpoint.ShadowBranchNameForCommit(base.String(), "")
+	addSyncTestCheckpoint(t, repoA, branch, "2026-10-14-synced", time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC))
+	second := addSyncTestCheckpoint(t, repoA, branch, "2026-10-14-synced", time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	if err := runSyncPush(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("runSyncPush() error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Pushed 1 shadow branch(es) (2 new checkpoint(s)) to origin") {
+		t.Errorf("unexpected push output:\n%s", buf.String())
+	}
+	// Nothing new: the branch isn't pushed again
+	buf.Reset()
+	if err := runSyncPush(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("second runSyncPush() error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Pushed 0 shadow branch(es) (0 new checkpoint(s)) to origin, 1 already up to date") {
+		t.Errorf("unexpected second push output:\n%s", buf.String())
+	}
+	out, err := exec.CommandContext(context.Background(), "git", "--git-dir", remoteDir, "show-ref").Output()
+	if err != nil || !strings.Contains(string(out), second.String()+" "+strategy.ShadowSyncRefPrefix+strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix)) {
+		t.Fatalf("remote refs = %q, %v; want the shadow branch under %s", out, err, strategy.ShadowSyncRefPrefix)
+	}
+	if strings.Contains(string(out), "refs/heads/entire/") {
+		t.Errorf("shadow branch was pushed as a remote branch:\n%s", out)
+	}
+
+	repoB, _ := setupCleanTestRepo(t)
+	addRemote(repoB)
+	buf.Reset()
+	if err := runSyncPull(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("runSyncPull() error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Pulled 1 shadow branch(es)") || !strings.Contains(buf.String(), "Imported 1 session(s)") {
+		t.Errorf("unexpected pull output:\n%s", buf.String())
+	}
+	ref, err := repoB.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil || ref.Hash() != second {
+		t.Fatalf("pulled %s = %v, %v; want %s", branch, ref, err, second)
+	}
+	state, err := strategy.LoadSessionState("2026-10-14-synced")
+	if err != nil || state == nil {
+		t.Fatalf("LoadSessionState() = %v, %v; want an imported session", state, err)
+	}
+	if state.BaseCommit != base.String() || state.Phase != session.PhaseEnded || state.StepCount != 2 {
+		t.Errorf("imported state = base %s, phase %s, %d steps; want %s, ended, 2", state.BaseCommit, state.Phase, state.StepCount, base)
+	}
+	points, err := strategy.NewManualCommitStrategy().GetRewindPoints(10)
+	if err != nil || len(points) != 2 {
+		t.Errorf("GetRewindPoints() = %d points, %v; want the 2 pulled checkpoints", len(points), err)
+	}
+
+	// New checkpoints on both sides can't be reconciled
+	addSyncTestCheckpoint(t, repoB, branch, "2026-10-14-local", time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC))
+	buf.Reset()
+	err = runSyncPush(context.Background(), &buf, "origin", false)
+	if err != nil {
+		t.Fatalf("runSyncPush() from B error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "(1 new checkpoint(s))") {
+		t.Errorf("push from B should only send its new checkpoint:\n%s", buf.String())
+	}
+	t.Chdir(repoWorktree(t, repoA))
+	paths.ClearRepoRootCache()
+	addSyncTestCheckpoint(t, repoA, branch, "2026-10-14-synced", time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+	buf.Reset()
+	if err := runSyncStatus(context.Background(), &buf, "origin", false); err != nil {
+		t.Fatalf("runSyncStatus() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "remote has newer checkpoints") || !strings.Contains(buf.String(), "2026-10-14-synced  1 of 3 checkpoint(s) not pushed") {
+		t.Errorf("unexpected sync status:\n%s", buf.String())
+	}
+	buf.Reset()
+	var silent *SilentError
+	if err := runSyncPush(context.Background(), &buf, "origin", false); !errors.As(err, &silent) {
+		t.Fatalf("runSyncPush() of diverged branch error = %v, want a SilentError", err)
+	}
+	if !strings.Contains(buf.String(), "1 shadow branch(es) diverged") || !strings.Contains(buf.String(), branch) {
+		t.Errorf("unexpected conflict output:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := runSyncPull(context.Background(), &buf, "origin", false); !errors.As(err, &silent) {
+		t.Fatalf("runSyncPull() of diverged branch error = %v, want a SilentError", err)
+	}
+	buf.Reset()
+	if err := runSyncPull(context.Background(), &buf, "origin", true); err != nil {
+		t.Fatalf("runSyncPull(force) error = %v\n%s", err, buf.String())
+	}
+	remoteTip, err := repoA.Reference(plumbing.ReferenceName(strategy.ShadowSyncTrackingPrefix("origin")+strings.TrimPrefix(branch, checkpoint.ShadowBranchPrefix)), true)
+	if err != nil {
+		t.Fatalf("failed to read tracking ref: %v", err)
+	}
+	if ref, err := repoA.Reference(plumbing.NewBranchReferenceName(branch), true); err != nil || ref.Hash() != remoteTip.Hash() {
+		t.Errorf("forced pull left %s at %v, %v; want the remote's %s", branch, ref, err, remoteTip.Hash())
+	}
+}
+
+func TestRunSyncStatus_Offline(t *testing.T) {
+	repo, base := setupCleanTestRepo(t)
+	branch := checkpoint.ShadowBranchNameForCommit(base.String(), "")
+	addSyncTestCheckpoint(t, repo, branch, "2026-10-14-offline", time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	if err := runSyncStatus(context.Background(), &buf, "unreachable", false); err != nil {
+		t.Fatalf("runSyncStatus() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Couldn't reach unreachable") || !strings.Contains(out, branch+" (not pushed yet)") ||
+		!strings.Contains(out, "2026-10-14-offline  1 of 1 checkpoint(s) not pushed") {
+		t.Errorf("unexpected offline status:\n%s", out)
+	}
+}
+
+func repoWorktree(t *testing.T, repo *git.Repository) string {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	return wt.Filesystem.Root()
+}
+
+// addSyncTestCheckpoint adds a shadow branch commit for sessionID, the way
+// checkpoints are written (full tree, session trailer).
+func addSyncTestCheckpoint(t *testing.T, repo *git.Repository, branch, sessionID string, when time.Time) plumbing.Hash {
+	t.Helper()
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to read HEAD: %v", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to read HEAD commit: %v", err)
+	}
+	sig := object.Signature{Name: "test", Email: "test@test.com", When: when}
+	commit := &object.Commit{
+		TreeHash:  headCommit.TreeHash,
+		Author:    sig,
+		Committer: sig,
+		Message:   "Checkpoint\n\n" + trailers.SessionTrailerKey + ": " + sessionID + "\n",
+	}
+	if ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true); err == nil {
+		commit.ParentHashes = []plumbing.Hash{ref.Hash()}
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatalf("failed to store commit: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), hash)); err != nil {
+		t.Fatalf("failed to update %s: %v", branch, err)
+	}
+	return hash
+}
@@ -1,8 +1,8 @@
 package telemetry
 
 import (
-	"encoding/json"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -20,9 +20,9 @@ var (
 	PostHogEndpoint = "https://eu.i.posthog.com"
 )
 
-// EventPayload represents the data passed to the detached subprocess.
-// Note: APIKey and Endpoint are intentionally excluded to avoid exposing
-// them in process listings (ps/top). SendEvent reads them from package-level vars.
+// EventPayload is an analytics event.
+// Note: APIKey and Endpoint are intentionally excluded; SendBatch reads them
+// from package-level vars so they never appear in queue files or argv.
 type EventPayload struct {
 	Event      string         `json:"event"`
 	DistinctID string         `json:"distinct_id"`
@@ -84,9 +84,17 @@ func BuildEventPayload(cmd *cobra.Command, strategy, agent string, isEntireEnabl
 	}
 }
 
-// TrackCommandDetached tracks a command execution by spawning a detached subprocess.
-// This returns immediately without blocking the CLI.
-func TrackCommandDetached(cmd *cobra.Command, strategy, agent string, isEntireEnabled bool, version string) {
+// Result is the outcome of a command run.
+type Result struct {
+	Duration time.Duration
+	// ErrorCode classifies a failure without its message; empty on success.
+	ErrorCode string
+}
+
+// TrackCommandDetached queues a command run for telemetry and, when the queue
+// is due, sends it from a detached subprocess. This returns immediately
+// without blocking the CLI.
+func TrackCommandDetached(cmd *cobra.Command, strategy, agent string, isEntireEnabled bool, version string, result Result) {
 	// Check opt-out environment variables
 	if os.Getenv("ENTIRE_TELEMETRY_OPTOUT") != "" {
 		return
@@ -100,21 +108,48 @@ func TrackCommandDetached(cmd *cobra.Command, strategy, agent string, isEntireEn
 		return
 	}
 
+	dir := QueueDir()
+	if dir == "" {
+		return
+	}
+
 	payload := BuildEventPayload(cmd, strategy, agent, isEntireEnabled, version)
 	if payload == nil {
 		return
 	}
 
-	if payloadJSON, err := json.Marshal(payload); err == nil {
-		spawnDetachedAnalytics(string(payloadJSON))
+	now := time.Now()
+	sample := Sample{
+		Time:       now,
+		Properties: payload.Properties,
+		DurationMs: result.Duration.Milliseconds(),
+		ErrorCode:  result.ErrorCode,
+	}
+	if err := Enqueue(dir, sample); err != nil {
+		return
+	}
+	if !queueDue(dir, now) {
+		return
+	}
+	if batch, err := takeBatch(dir, now); err == nil {
+		spawnDetachedAnalytics(batch)
 	}
 }
 
-// SendEvent processes an event payload in the detached subprocess.
+// SendBatch sends the summary events of a queue batch file and deletes it.
 // This is called by the hidden __send_analytics command.
-func SendEvent(payloadJSON string) {
-	var payload EventPayload
-	if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+func SendBatch(path string) {
+	if dir := QueueDir(); dir == "" || filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), batchPrefix) {
+		return
+	}
+	samples, err := readSamples(path)
+	if err != nil || len(samples) == 0 {
+		_ = os.Remove(path)
+		return
+	}
+
+	machineID, err := machineid.ProtectedID("entire-cli")
+	if err != nil {
 		return
 	}
 
@@ -132,17 +167,21 @@ func SendEvent(payloadJSON string) {
 		_ = client.Close()
 	}()
 
-	// Build properties
-	props := posthog.NewProperties()
-	for k, v := range payload.Properties {
-		props.Set(k, v)
+	for _, event := range Summarize(samples, machineID, time.Now()) {
+		props := posthog.NewProperties()
+		for k, v := range event.Properties {
+			props.Set(k, v)
+		}
+
+		//nolint:errcheck // Best effort telemetry - don't block on result
+		_ = client.Enqueue(posthog.Capture{
+			DistinctId: event.DistinctID,
+			Event:      event.Event,
+			Properties: props,
+			Timestamp:  event.Timestamp,
+		})
 	}
 
-	//nolint:errcheck // Best effort telemetry - don't block on result
-	_ = client.Enqueue(posthog.Capture{
-		DistinctId: payload.DistinctID,
-		Event:      payload.Event,
-		Properties: props,
-		Timestamp:  payload.Timestamp,
-	})
+	// Best effort: a batch that fails to send is dropped rather than retried.
+	_ = os.Remove(path)
 }
@@ -2,6 +2,8 @@ package telemetry
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -55,7 +57,7 @@ func TestEventPayloadSerialization(t *testing.T) {
 
 func TestTrackCommandDetachedSkipsNilCommand(_ *testing.T) {
 	// Should not panic with nil command
-	TrackCommandDetached(nil, "manual-commit", "claude-code", true, "1.0.0")
+	TrackCommandDetached(nil, "manual-commit", "claude-code", true, "1.0.0", Result{})
 }
 
 func TestTrackCommandDetachedSkipsHiddenCommands(_ *testing.T) {
@@ -65,7 +67,7 @@ func TestTrackCommandDetachedSkipsHiddenCommands(_ *testing.T) {
 	}
 
 	// Should not panic and should skip hidden commands
-	TrackCommandDetached(hiddenCmd, "manual-commit", "claude-code", true, "1.0.0")
+	TrackCommandDetached(hiddenCmd, "manual-commit", "claude-code", true, "1.0.0", Result{})
 }
 
 func TestTrackCommandDetachedRespectsOptOut(t *testing.T) {
@@ -76,7 +78,7 @@ func TestTrackCommandDetachedRespectsOptOut(t *testing.T) {
 	}
 
 	// Should not panic and should respect opt-out
-	TrackCommandDetached(cmd, "manual-commit", "claude-code", true, "1.0.0")
+	TrackCommandDetached(cmd, "manual-commit", "claude-code", true, "1.0.0", Result{})
 }
 
 func TestBuildEventPayloadAgent(t *testing.T) {
@@ -110,9 +112,18 @@ func TestBuildEventPayloadAgent(t *testing.T) {
 	}
 }
 
-func TestSendEventHandlesInvalidJSON(_ *testing.T) {
-	// Should not panic with invalid JSON
-	SendEvent("invalid json")
-	SendEvent("")
-	SendEvent("{}")
+func TestSendBatchIgnoresFilesOutsideQueue(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	outside := filepath.Join(t.TempDir(), "batch-1.jsonl")
+	if err := os.WriteFile(outside, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Should not panic, and should not touch files it didn't queue
+	SendBatch("")
+	SendBatch("invalid json")
+	SendBatch(outside)
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("SendBatch removed a file outside the queue: %v", err)
+	}
 }
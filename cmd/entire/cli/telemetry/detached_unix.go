@@ -10,17 +10,17 @@ import (
 	"syscall"
 )
 
-// spawnDetachedAnalytics spawns a detached subprocess to send analytics.
+// spawnDetachedAnalytics spawns a detached subprocess to send a queue batch.
 // On Unix, this uses process group detachment so the subprocess continues
 // after the parent exits.
-func spawnDetachedAnalytics(payloadJSON string) {
+func spawnDetachedAnalytics(batchPath string) {
 	executable, err := os.Executable()
 	if err != nil {
 		return
 	}
 
-	//nolint:gosec // G204: payloadJSON is controlled internally, not user input
-	cmd := exec.CommandContext(context.Background(), executable, "__send_analytics", payloadJSON)
+	//nolint:gosec // G204: batchPath is controlled internally, not user input
+	cmd := exec.CommandContext(context.Background(), executable, "__send_analytics", batchPath)
 
 	// Detach from parent process group so subprocess survives parent exit
 	cmd.SysProcAttr = &syscall.SysProcAttr{
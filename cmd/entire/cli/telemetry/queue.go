@@ -0,0 +1,274 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+// Commands are not sent one by one. Each run appends a sample to a local
+// queue, and once the queue is due a detached subprocess sends one summary
+// event per command: how often it ran, its error codes and a latency
+// histogram. Samples hold the command path, flag names, an error code and the
+// duration, never arguments, file contents, prompts or error messages.
+
+const (
+	queueFile = "queue.jsonl"
+	// batchPrefix names queue files taken for sending.
+	batchPrefix = "batch-"
+
+	// flushInterval is how long samples wait before the queue is sent.
+	flushInterval = 24 * time.Hour
+	// flushSize sends the queue early once it grows this large.
+	flushSize = 64 << 10
+)
+
+// Sample is one queued command run.
+type Sample struct {
+	// Time is when the command finished.
+	Time time.Time `json:"time"`
+	// Properties are the common event properties from BuildEventPayload.
+	Properties map[string]any `json:"properties"`
+	DurationMs int64          `json:"duration_ms"`
+	// ErrorCode classifies a failed run ("usage", "canceled", "error"); empty
+	// on success.
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// latencyBuckets are the upper bounds of the latency histogram buckets.
+var latencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+}
+
+// LatencyBucket returns the histogram bucket label for d, e.g. "<500ms" or
+// ">=30s".
+func LatencyBucket(d time.Duration) string {
+	for _, b := range latencyBuckets {
+		if d < b {
+			return "<" + b.String()
+		}
+	}
+	return ">=" + latencyBuckets[len(latencyBuckets)-1].String()
+}
+
+// QueueDir returns the directory of the telemetry queue, telemetry/ in
+// paths.UserStateDir. Empty if that can't be determined.
+func QueueDir() string {
+	dir := paths.UserStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "telemetry")
+}
+
+// Enqueue appends s to the queue in dir. Each sample is a single short
+// append, so concurrent commands don't interleave their lines.
+func Enqueue(dir string, s Sample) error {
+	line, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create telemetry queue directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, queueFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open telemetry queue: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write telemetry queue: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the samples queued in dir that haven't been sent yet,
+// including batches taken for sending that a sender didn't finish.
+func Pending(dir string) ([]Sample, error) {
+	files, err := queueFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, f := range files {
+		s, err := readSamples(f)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, s...)
+	}
+	return samples, nil
+}
+
+// Clear deletes every queued sample in dir.
+func Clear(dir string) error {
+	files, err := queueFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+func queueFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry queue: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if name := e.Name(); name == queueFile || (strings.HasPrefix(name, batchPrefix) && strings.HasSuffix(name, ".jsonl")) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files, nil
+}
+
+// readSamples reads a queue file, skipping lines that don't parse (a write
+// cut short by a crash).
+func readSamples(path string) ([]Sample, error) {
+	f, err := os.Open(path) //nolint:gosec // path is in the telemetry queue directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Sample
+		if json.Unmarshal(scanner.Bytes(), &s) == nil {
+			samples = append(samples, s)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return samples, nil
+}
+
+// queueDue reports whether the queue in dir should be sent: it is large, or
+// its oldest sample has waited flushInterval.
+func queueDue(dir string, now time.Time) bool {
+	path := filepath.Join(dir, queueFile)
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+	if info.Size() >= flushSize {
+		return true
+	}
+	f, err := os.Open(path) //nolint:gosec // path is in the telemetry queue directory
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return false
+	}
+	var first Sample
+	if err := json.Unmarshal(scanner.Bytes(), &first); err != nil {
+		// Unreadable head: send what's there rather than waiting forever.
+		return true
+	}
+	return now.Sub(first.Time) >= flushInterval
+}
+
+// takeBatch renames the queue in dir to a batch file for a sender, so new
+// samples start a fresh queue. The rename is atomic, so only one command
+// takes any given batch.
+func takeBatch(dir string, now time.Time) (string, error) {
+	batch := filepath.Join(dir, batchPrefix+strconv.FormatInt(now.UnixNano(), 10)+".jsonl")
+	if err := os.Rename(filepath.Join(dir, queueFile), batch); err != nil {
+		return "", fmt.Errorf("failed to take telemetry batch: %w", err)
+	}
+	return batch, nil
+}
+
+// Summarize aggregates samples into one summary event per command, in
+// command order. distinctID identifies the machine.
+func Summarize(samples []Sample, distinctID string, now time.Time) []EventPayload {
+	type summary struct {
+		count   int
+		errors  map[string]int
+		latency map[string]int
+		flags   map[string]int
+		latest  Sample
+	}
+	byCommand := make(map[string]*summary)
+	for _, s := range samples {
+		command, _ := s.Properties["command"].(string)
+		if command == "" {
+			continue
+		}
+		sum, ok := byCommand[command]
+		if !ok {
+			sum = &summary{errors: make(map[string]int), latency: make(map[string]int), flags: make(map[string]int)}
+			byCommand[command] = sum
+		}
+		sum.count++
+		if s.ErrorCode != "" {
+			sum.errors[s.ErrorCode]++
+		}
+		sum.latency[LatencyBucket(time.Duration(s.DurationMs)*time.Millisecond)]++
+		if flags, _ := s.Properties["flags"].(string); flags != "" {
+			for _, flag := range strings.Split(flags, ",") {
+				sum.flags[flag]++
+			}
+		}
+		if !s.Time.Before(sum.latest.Time) {
+			sum.latest = s
+		}
+	}
+
+	commands := make([]string, 0, len(byCommand))
+	for command := range byCommand {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	events := make([]EventPayload, 0, len(commands))
+	for _, command := range commands {
+		sum := byCommand[command]
+		props := make(map[string]any, len(sum.latest.Properties)+3)
+		for k, v := range sum.latest.Properties {
+			props[k] = v
+		}
+		props["count"] = sum.count
+		props["error_codes"] = sum.errors
+		props["latency"] = sum.latency
+		// Flag names are counted per summary rather than taken from one run.
+		props["flags"] = sum.flags
+		events = append(events, EventPayload{
+			Event:      "cli_command_summary",
+			DistinctID: distinctID,
+			Properties: props,
+			Timestamp:  now,
+		})
+	}
+	return events
+}
@@ -0,0 +1,127 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "<100ms"},
+		{250 * time.Millisecond, "<500ms"},
+		{time.Second, "<5s"},
+		{2 * time.Minute, ">=30s"},
+	}
+	for _, tt := range tests {
+		if got := LatencyBucket(tt.d); got != tt.want {
+			t.Errorf("LatencyBucket(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestQueue_EnqueueTakeAndClear(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		s := Sample{Time: start.Add(time.Duration(i) * time.Minute), Properties: map[string]any{"command": "entire status"}}
+		if err := Enqueue(dir, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if queueDue(dir, start.Add(time.Hour)) {
+		t.Error("queue due after an hour")
+	}
+	if !queueDue(dir, start.Add(flushInterval)) {
+		t.Error("queue not due after the flush interval")
+	}
+
+	batch, err := takeBatch(dir, start.Add(flushInterval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queueDue(dir, start.Add(2*flushInterval)) {
+		t.Error("queue due right after its batch was taken")
+	}
+	if err := Enqueue(dir, Sample{Time: start, Properties: map[string]any{"command": "entire stats"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := Pending(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 4 {
+		t.Errorf("Pending() = %d samples, want 4 across the queue and the batch", len(pending))
+	}
+
+	if err := Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := Pending(dir); len(pending) != 0 {
+		t.Errorf("Pending() after Clear = %d samples", len(pending))
+	}
+	if _, err := os.Stat(batch); !os.IsNotExist(err) {
+		t.Errorf("batch survived Clear: %v", err)
+	}
+}
+
+func TestQueue_SkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	if err := Enqueue(dir, Sample{Time: time.Now(), Properties: map[string]any{"command": "entire status"}}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, queueFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"time":"2026-10-01T12:00`)
+	f.Close()
+
+	pending, err := Pending(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("Pending() = %d samples, want 1", len(pending))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{Time: now.Add(-3 * time.Hour), Properties: map[string]any{"command": "entire status", "cli_version": "1.0.0"}, DurationMs: 40},
+		{Time: now.Add(-2 * time.Hour), Properties: map[string]any{"command": "entire status", "cli_version": "1.1.0", "flags": "json"}, DurationMs: 700, ErrorCode: "error"},
+		{Time: now.Add(-time.Hour), Properties: map[string]any{"command": "entire enable", "flags": "agent,telemetry"}, DurationMs: 6000},
+		{Time: now, Properties: map[string]any{}},
+	}
+
+	events := Summarize(samples, "machine", now)
+	if len(events) != 2 {
+		t.Fatalf("Summarize() = %d events, want 2", len(events))
+	}
+	enable, status := events[0], events[1]
+	if enable.Properties["command"] != "entire enable" || status.Properties["command"] != "entire status" {
+		t.Fatalf("events not in command order: %v, %v", enable.Properties["command"], status.Properties["command"])
+	}
+	if status.Event != "cli_command_summary" || status.DistinctID != "machine" || !status.Timestamp.Equal(now) {
+		t.Errorf("status event = %+v", status)
+	}
+	if status.Properties["count"] != 2 || status.Properties["cli_version"] != "1.1.0" {
+		t.Errorf("status count/version = %v/%v, want 2 and the latest version", status.Properties["count"], status.Properties["cli_version"])
+	}
+	if errs := status.Properties["error_codes"].(map[string]int); errs["error"] != 1 {
+		t.Errorf("status error codes = %v", errs)
+	}
+	if latency := status.Properties["latency"].(map[string]int); latency["<100ms"] != 1 || latency["<1s"] != 1 {
+		t.Errorf("status latency = %v", latency)
+	}
+	if flags := enable.Properties["flags"].(map[string]int); flags["agent"] != 1 || flags["telemetry"] != 1 {
+		t.Errorf("enable flags = %v", flags)
+	}
+}
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/entireio/cli/cmd/entire/cli/buildinfo"
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/telemetry"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// telemetryOptOutEnv disables telemetry regardless of settings.
+const telemetryOptOutEnv = "ENTIRE_TELEMETRY_OPTOUT"
+
+// telemetryCollected describes what telemetry sends, for consent and status.
+const telemetryCollected = `Entire sends anonymous usage data only if you opt in: command and flag names,
+error codes, durations, CLI version, OS/arch and the strategy and agent names,
+summarized per command about once a day. Never code, prompts, transcripts,
+file paths, arguments or error messages.`
+
+func newTelemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Show or change anonymous usage analytics consent",
+		Long: `Show or change whether Entire sends anonymous usage analytics.
+
+` + telemetryCollected + `
+
+Consent is stored in the user settings file and applies to every repository.
+An opt-out anywhere wins: ENTIRE_TELEMETRY_OPTOUT, or "telemetry": false in
+any settings file, turns telemetry off.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runTelemetryStatus(cmd.OutOrStdout())
+		},
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show telemetry consent and queued samples",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runTelemetryStatus(cmd.OutOrStdout())
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "on",
+		Short: "Opt in to anonymous usage analytics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runTelemetrySet(cmd.OutOrStdout(), true)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Opt out of anonymous usage analytics and drop queued samples",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runTelemetrySet(cmd.OutOrStdout(), false)
+		},
+	})
+	return cmd
+}
+
+// telemetryConsent returns the effective telemetry consent and where it comes
+// from. An opt-out in the environment or in any settings file wins over an
+// opt-in; nil means the user hasn't been asked.
+func telemetryConsent() (*bool, string) {
+	off, on := false, true
+	if os.Getenv(telemetryOptOutEnv) != "" {
+		return &off, telemetryOptOutEnv
+	}
+	var optedIn string
+	for _, scope := range []string{configScopeLocal, configScopeProject, configScopeUser} {
+		path := configScopePath(scope)
+		if path == "" {
+			continue
+		}
+		s, err := settings.LoadFromFile(path)
+		if err != nil || s.Telemetry == nil {
+			continue
+		}
+		if !*s.Telemetry {
+			return &off, scope + " settings"
+		}
+		if optedIn == "" {
+			optedIn = scope + " settings"
+		}
+	}
+	if optedIn != "" {
+		return &on, optedIn
+	}
+	return nil, ""
+}
+
+// saveTelemetryConsent records consent in the user settings file, or in the
+// local settings file when there is no home directory.
+func saveTelemetryConsent(consent bool) (string, error) {
+	path := configScopePath(configScopeUser)
+	if path == "" {
+		path = configScopePath(configScopeLocal)
+	}
+	m, err := readSettingsMap(path)
+	if err != nil {
+		return "", err
+	}
+	m["telemetry"] = consent
+	if err := writeSettingsMap(path, m); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func runTelemetryStatus(w io.Writer) error {
+	consent, origin := telemetryConsent()
+	switch {
+	case consent == nil:
+		fmt.Fprintln(w, "Telemetry: off (not asked yet; opt in with 'entire telemetry on')")
+	case *consent:
+		fmt.Fprintf(w, "Telemetry: on (%s)\n", origin)
+	default:
+		fmt.Fprintf(w, "Telemetry: off (%s)\n", origin)
+	}
+
+	if dir := telemetry.QueueDir(); dir != "" {
+		pending, err := telemetry.Pending(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read telemetry queue: %w", err)
+		}
+		fmt.Fprintf(w, "Queue:     %d pending samples in %s\n", len(pending), dir)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, telemetryCollected)
+	return nil
+}
+
+func runTelemetrySet(w io.Writer, consent bool) error {
+	path, err := saveTelemetryConsent(consent)
+	if err != nil {
+		return err
+	}
+	if !consent {
+		if dir := telemetry.QueueDir(); dir != "" {
+			if err := telemetry.Clear(dir); err != nil {
+				return fmt.Errorf("failed to clear telemetry queue: %w", err)
+			}
+		}
+		fmt.Fprintf(w, "Telemetry off (saved in %s). Queued samples were deleted.\n", path)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Telemetry on (saved in %s). Thanks for helping improve Entire.\n", path)
+	if effective, origin := telemetryConsent(); effective != nil && !*effective {
+		fmt.Fprintf(w, "Note: telemetry stays off because of %s.\n", origin)
+	}
+	return nil
+}
+
+// askTelemetryConsent asks whether to share anonymous usage data. The default
+// is No: telemetry is opt-in.
+func askTelemetryConsent() (bool, error) {
+	consent := false
+	form := NewAccessibleForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Help improve Entire CLI?").
+				Description(telemetryCollected).
+				Affirmative("Yes").
+				Negative("No").
+				Value(&consent),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false, fmt.Errorf("telemetry prompt: %w", err)
+	}
+	return consent, nil
+}
+
+// maybeAskTelemetryConsent asks for telemetry consent on the first
+// interactive run of a command, once the command has finished. Runs without
+// a terminal, in CI or of the telemetry command itself never ask.
+func maybeAskTelemetryConsent(cmd *cobra.Command) {
+	if consent, _ := telemetryConsent(); consent != nil {
+		return
+	}
+	if cienv.Enabled() || speaksProtocolOnStdout(cmd) || strings.HasPrefix(cmd.CommandPath(), "entire telemetry") {
+		return
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintln(w)
+	consent, err := askTelemetryConsent()
+	if err != nil {
+		// Cancelled: ask again next time rather than recording an answer.
+		return
+	}
+	if _, err := saveTelemetryConsent(consent); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to save telemetry choice: %v\n", err)
+		return
+	}
+	if !consent {
+		fmt.Fprintln(w, "Telemetry off. Change it any time with 'entire telemetry on'.")
+	}
+}
+
+// TrackCommand queues a finished command run for telemetry if the user opted
+// in. main calls it after the command returns, so failures and their
+// durations are counted as well as successes.
+func TrackCommand(cmd *cobra.Command, err error, duration time.Duration) {
+	// Skip for hidden commands (walk parent chain — Cobra doesn't propagate Hidden)
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Hidden {
+			return
+		}
+	}
+	if consent, _ := telemetryConsent(); consent == nil || !*consent {
+		return
+	}
+
+	var strategyName string
+	var enabled bool
+	if s, loadErr := LoadEntireSettings(); loadErr == nil {
+		strategyName, enabled = s.Strategy, s.Enabled
+	}
+	agentStr := JoinAgentNames(GetAgentsWithHooksInstalled())
+	telemetry.TrackCommandDetached(cmd, strategyName, agentStr, enabled, buildinfo.Version, telemetry.Result{
+		Duration:  duration,
+		ErrorCode: telemetryErrorCode(err),
+	})
+}
+
+// telemetryErrorCode classifies err without its message.
+func telemetryErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case isUsageError(err):
+		return "usage"
+	default:
+		return "error"
+	}
+}
+
+// isUsageError reports whether err is cobra rejecting the command line.
+func isUsageError(err error) bool {
+	msg := err.Error()
+	for _, prefix := range []string{"unknown command", "unknown flag", "unknown shorthand flag", "invalid argument", "flag needs an argument", "accepts ", "requires at least", "if any flags in the group"} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/telemetry"
+)
+
+func TestTelemetryConsent_OptOutWins(t *testing.T) {
+	setupTestRepo(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(telemetryOptOutEnv, "")
+
+	if consent, _ := telemetryConsent(); consent != nil {
+		t.Fatalf("consent = %v before anyone was asked", *consent)
+	}
+
+	var out bytes.Buffer
+	if err := runConfigSet(&out, configScopeProject, "telemetry", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfigSet(&out, configScopeUser, "telemetry", "false"); err != nil {
+		t.Fatal(err)
+	}
+	consent, origin := telemetryConsent()
+	if consent == nil || *consent || origin != "user settings" {
+		t.Errorf("consent = %v from %q, want off from the user settings", consent, origin)
+	}
+
+	if err := runConfigSet(&out, configScopeUser, "telemetry", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if consent, origin = telemetryConsent(); consent == nil || !*consent || origin != "project settings" {
+		t.Errorf("consent = %v from %q, want on from the project settings", consent, origin)
+	}
+
+	t.Setenv(telemetryOptOutEnv, "1")
+	if consent, origin = telemetryConsent(); consent == nil || *consent || origin != telemetryOptOutEnv {
+		t.Errorf("consent = %v from %q, want the env opt-out", consent, origin)
+	}
+}
+
+func TestRunTelemetrySet(t *testing.T) {
+	setupTestRepo(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	stateDir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateDir)
+	t.Setenv(telemetryOptOutEnv, "")
+
+	var out bytes.Buffer
+	if err := runTelemetrySet(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	if consent, origin := telemetryConsent(); consent == nil || !*consent || origin != "user settings" {
+		t.Fatalf("consent = %v from %q after 'on'", consent, origin)
+	}
+
+	if err := telemetry.Enqueue(telemetry.QueueDir(), telemetry.Sample{Time: time.Now(), Properties: map[string]any{"command": "entire status"}}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := runTelemetryStatus(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Telemetry: on (user settings)") || !strings.Contains(out.String(), "1 pending samples") {
+		t.Errorf("status output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runTelemetrySet(&out, false); err != nil {
+		t.Fatal(err)
+	}
+	if consent, _ := telemetryConsent(); consent == nil || *consent {
+		t.Fatalf("consent = %v after 'off'", consent)
+	}
+	if pending, _ := telemetry.Pending(telemetry.QueueDir()); len(pending) != 0 {
+		t.Errorf("'off' left %d queued samples", len(pending))
+	}
+}
+
+func TestRunTelemetrySet_OnReportsOverride(t *testing.T) {
+	setupTestRepo(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(telemetryOptOutEnv, "")
+	writeSettings(t, `{"enabled": true, "telemetry": false}`)
+
+	var out bytes.Buffer
+	if err := runTelemetrySet(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "stays off because of project settings") {
+		t.Errorf("output:\n%s", out.String())
+	}
+}
+
+func TestTelemetryErrorCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("wrapped: %w", context.Canceled), "canceled"},
+		{errors.New(`unknown command "stauts" for "entire"`), "usage"},
+		{errors.New("accepts 1 arg(s), received 2"), "usage"},
+		{NewSilentError(errors.New("session abc not found")), "error"},
+	}
+	for _, tt := range tests {
+		if got := telemetryErrorCode(tt.err); got != tt.want {
+			t.Errorf("telemetryErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
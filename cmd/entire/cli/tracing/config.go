@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultServiceName is the service.name resource attribute when neither
+// the settings nor OTEL_SERVICE_NAME set one.
+const DefaultServiceName = "entire"
+
+// DefaultTimeout bounds each export. Exports happen while the hook's caller
+// waits, so this is much shorter than the OTLP default of 10s.
+const DefaultTimeout = time.Second
+
+// Config is where and how spans and metrics are exported.
+type Config struct {
+	// TracesEndpoint and MetricsEndpoint are the full OTLP/HTTP URLs, e.g.
+	// "http://localhost:4318/v1/traces". "" = don't export that signal.
+	TracesEndpoint  string
+	MetricsEndpoint string
+	// Headers are sent with every export request.
+	Headers        map[string]string
+	ServiceName    string
+	ServiceVersion string
+	Timeout        time.Duration
+}
+
+// Enabled reports whether anything is exported.
+func (c Config) Enabled() bool {
+	return c.TracesEndpoint != "" || c.MetricsEndpoint != ""
+}
+
+// NewConfig returns the configuration for the OTLP/HTTP base URL endpoint
+// (e.g. "http://localhost:4318") and headers, overridden by the standard
+// OTEL_* environment variables: OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_{TRACES,METRICS}_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS,
+// OTEL_EXPORTER_OTLP_TIMEOUT (milliseconds), OTEL_SERVICE_NAME and
+// OTEL_SDK_DISABLED.
+func NewConfig(endpoint string, headers map[string]string, serviceName, version string) Config {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("OTEL_SDK_DISABLED")), "true") {
+		return Config{}
+	}
+	if env := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")); env != "" {
+		endpoint = env
+	}
+	cfg := Config{
+		Headers:        map[string]string{},
+		ServiceName:    serviceName,
+		ServiceVersion: version,
+		Timeout:        DefaultTimeout,
+	}
+	if endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/"); endpoint != "" {
+		cfg.TracesEndpoint = endpoint + "/v1/traces"
+		cfg.MetricsEndpoint = endpoint + "/v1/metrics"
+	}
+	if env := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")); env != "" {
+		cfg.TracesEndpoint = env
+	}
+	if env := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")); env != "" {
+		cfg.MetricsEndpoint = env
+	}
+
+	for k, v := range headers {
+		cfg.Headers[k] = v
+	}
+	for k, v := range parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")) {
+		cfg.Headers[k] = v
+	}
+	if env := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME")); env != "" {
+		cfg.ServiceName = env
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	if ms, err := strconv.Atoi(strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TIMEOUT"))); err == nil && ms > 0 {
+		cfg.Timeout = time.Duration(ms) * time.Millisecond
+	}
+	return cfg
+}
+
+// parseHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format,
+// "key1=value1,key2=value2" with URL-encoded values. Malformed entries are
+// skipped.
+func parseHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			headers[k] = decoded
+		}
+	}
+	return headers
+}
@@ -0,0 +1,361 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// scopeName is the instrumentation scope of everything this package records.
+const scopeName = "github.com/entireio/cli"
+
+// Metric names.
+const (
+	HookExecutionsMetric = "entire.hook.executions"
+	HookDurationMetric   = "entire.hook.duration"
+)
+
+// hookDurationBounds are the explicit bucket bounds of the hook duration
+// histogram, in milliseconds.
+var hookDurationBounds = []float64{10, 50, 100, 250, 500, 1000, 2000, 5000, 10000, 30000}
+
+// metricPoint is one recorded hook execution.
+type metricPoint struct {
+	attrs    []slog.Attr
+	start    time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// RecordHook records a hook execution for the executions counter and the
+// duration histogram. attrs identify the hook (name, agent, ...); a
+// "success" attribute is added from err.
+func RecordHook(start time.Time, err error, attrs ...slog.Attr) {
+	rec := current()
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	rec.metrics = append(rec.metrics, metricPoint{attrs: attrs, start: start, duration: time.Since(start), failed: err != nil})
+	rec.mu.Unlock()
+}
+
+// Flush exports the spans and metrics recorded so far and clears them. Spans
+// that haven't ended are ended first. Each export is bounded by the
+// configured timeout.
+func Flush(ctx context.Context) error {
+	rec := current()
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	spans, metrics := rec.spans, rec.metrics
+	rec.spans, rec.metrics = nil, nil
+	rec.mu.Unlock()
+
+	var errs []error
+	if rec.cfg.TracesEndpoint != "" && len(spans) > 0 {
+		if err := rec.post(ctx, rec.cfg.TracesEndpoint, rec.tracesRequest(spans)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to export traces: %w", err))
+		}
+	}
+	if rec.cfg.MetricsEndpoint != "" && len(metrics) > 0 {
+		if err := rec.post(ctx, rec.cfg.MetricsEndpoint, rec.metricsRequest(metrics)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to export metrics: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown flushes and stops recording.
+func Shutdown(ctx context.Context) error {
+	err := Flush(ctx)
+	activeMu.Lock()
+	active = nil
+	activeMu.Unlock()
+	return err
+}
+
+func (r *recorder) post(ctx context.Context, endpoint string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range r.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16)) //nolint:errcheck // drained for connection reuse only
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return nil
+}
+
+// The types below are the subset of the OTLP/JSON protocol this package
+// writes. 64-bit integers are strings, and IDs are hex, as OTLP/JSON
+// requires.
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	Min               float64        `json:"min"`
+	Max               float64        `json:"max"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// OTLP enum values.
+const (
+	spanKindInternal            = 1
+	statusCodeError             = 2
+	aggregationTemporalityDelta = 1
+)
+
+func (r *recorder) resource() otlpResource {
+	attrs := []slog.Attr{slog.String("service.name", r.cfg.ServiceName)}
+	if r.cfg.ServiceVersion != "" {
+		attrs = append(attrs, slog.String("service.version", r.cfg.ServiceVersion))
+	}
+	return otlpResource{Attributes: otlpAttributes(attrs)}
+}
+
+func (r *recorder) scope() otlpScope {
+	return otlpScope{Name: scopeName, Version: r.cfg.ServiceVersion}
+}
+
+func (r *recorder) tracesRequest(spans []*Span) otlpTracesRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.End()
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(s.start),
+			EndTimeUnixNano:   unixNano(s.end),
+			Attributes:        otlpAttributes(s.attrs),
+		}
+		if s.errMsg != "" {
+			span.Status = otlpStatus{Code: statusCodeError, Message: s.errMsg}
+		}
+		s.mu.Unlock()
+		if s.parentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		out = append(out, span)
+	}
+
+	return otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   r.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: r.scope(), Spans: out}},
+	}}}
+}
+
+func (r *recorder) metricsRequest(points []metricPoint) otlpMetricsRequest {
+	executions := otlpMetric{
+		Name:        HookExecutionsMetric,
+		Description: "Hook executions",
+		Unit:        "{execution}",
+	}
+	executions.Sum = &otlpSum{AggregationTemporality: aggregationTemporalityDelta, IsMonotonic: true}
+	duration := otlpMetric{
+		Name:        HookDurationMetric,
+		Description: "Duration of hook executions",
+		Unit:        "ms",
+	}
+	duration.Histogram = &otlpHistogram{AggregationTemporality: aggregationTemporalityDelta}
+
+	for _, p := range points {
+		attrs := otlpAttributes(append(append([]slog.Attr{}, p.attrs...), slog.Bool("success", !p.failed)))
+		end := p.start.Add(p.duration)
+		executions.Sum.DataPoints = append(executions.Sum.DataPoints, otlpNumberDataPoint{
+			Attributes:        attrs,
+			StartTimeUnixNano: unixNano(p.start),
+			TimeUnixNano:      unixNano(end),
+			AsInt:             "1",
+		})
+
+		ms := float64(p.duration) / float64(time.Millisecond)
+		buckets := make([]string, len(hookDurationBounds)+1)
+		for i := range buckets {
+			buckets[i] = "0"
+		}
+		bucket := len(hookDurationBounds)
+		for i, bound := range hookDurationBounds {
+			if ms <= bound {
+				bucket = i
+				break
+			}
+		}
+		buckets[bucket] = "1"
+		duration.Histogram.DataPoints = append(duration.Histogram.DataPoints, otlpHistogramDataPoint{
+			Attributes:        attrs,
+			StartTimeUnixNano: unixNano(p.start),
+			TimeUnixNano:      unixNano(end),
+			Count:             "1",
+			Sum:               ms,
+			Min:               ms,
+			Max:               ms,
+			BucketCounts:      buckets,
+			ExplicitBounds:    hookDurationBounds,
+		})
+	}
+
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     r.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: r.scope(), Metrics: []otlpMetric{executions, duration}}},
+	}}}
+}
+
+// otlpAttributes converts slog attributes to OTLP key-values. Kinds OTLP
+// has no scalar for are sent as strings.
+func otlpAttributes(attrs []slog.Attr) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		var value otlpAnyValue
+		switch v.Kind() {
+		case slog.KindBool:
+			b := v.Bool()
+			value.BoolValue = &b
+		case slog.KindInt64:
+			i := strconv.FormatInt(v.Int64(), 10)
+			value.IntValue = &i
+		case slog.KindUint64:
+			i := strconv.FormatUint(v.Uint64(), 10)
+			value.IntValue = &i
+		case slog.KindFloat64:
+			f := v.Float64()
+			value.DoubleValue = &f
+		case slog.KindDuration:
+			i := strconv.FormatInt(v.Duration().Milliseconds(), 10)
+			value.IntValue = &i
+		default:
+			str := v.String()
+			value.StringValue = &str
+		}
+		out = append(out, otlpKeyValue{Key: a.Key, Value: value})
+	}
+	return out
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,201 @@
+// Package tracing records OpenTelemetry spans and metrics of hook executions
+// and exports them over OTLP/HTTP with the JSON encoding.
+//
+// It implements the small part of the OpenTelemetry SDK hooks need: every
+// hook is its own short-lived process, so spans are kept in memory and sent
+// in one request when the hook finishes. Nothing is recorded unless Init was
+// called with an endpoint; Start then returns a nil *Span, whose methods do
+// nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span is one timed operation of a trace.
+type Span struct {
+	rec      *recorder
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []slog.Attr
+	errMsg string
+	ended  bool
+}
+
+type spanKey struct{}
+
+// recorder holds the spans and metrics of the current process until Flush.
+type recorder struct {
+	cfg Config
+
+	mu sync.Mutex
+	// spans are in the order they were started. A span started from a
+	// context without one becomes a child of the latest span that hasn't
+	// ended, so code that doesn't thread a context still shows up under the
+	// operation that ran it.
+	spans   []*Span
+	remote  *remoteParent
+	metrics []metricPoint
+}
+
+// remoteParent is the span context of a TRACEPARENT environment variable,
+// so a hook run by a traced process (e.g. a CI job) joins its trace.
+type remoteParent struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+var (
+	activeMu sync.Mutex
+	active   *recorder
+)
+
+// Init starts recording spans and metrics for export to cfg's endpoints.
+// It does nothing if cfg has no endpoint.
+func Init(cfg Config) {
+	if !cfg.Enabled() {
+		return
+	}
+	rec := &recorder{cfg: cfg, remote: parseTraceparent(os.Getenv("TRACEPARENT"))}
+	activeMu.Lock()
+	active = rec
+	activeMu.Unlock()
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return current() != nil
+}
+
+func current() *recorder {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return active
+}
+
+// Start starts a span named name, a child of ctx's span. It returns a
+// context carrying the new span; callers must End it.
+func Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	rec := current()
+	if rec == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &Span{rec: rec, name: name, start: time.Now(), attrs: attrs}
+	_, _ = rand.Read(s.spanID[:]) //nolint:errcheck // crypto/rand.Read doesn't fail
+
+	rec.mu.Lock()
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil || parent.rec != rec {
+		parent = rec.openSpan()
+	}
+	switch {
+	case parent != nil:
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	case rec.remote != nil:
+		s.traceID, s.parentID = rec.remote.traceID, rec.remote.spanID
+	default:
+		_, _ = rand.Read(s.traceID[:]) //nolint:errcheck // crypto/rand.Read doesn't fail
+	}
+	rec.spans = append(rec.spans, s)
+	rec.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// openSpan returns the latest started span that hasn't ended, nil if there
+// is none. The caller holds r.mu.
+func (r *recorder) openSpan() *Span {
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		s := r.spans[i]
+		s.mu.Lock()
+		ended := s.ended
+		s.mu.Unlock()
+		if !ended {
+			return s
+		}
+	}
+	return nil
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError marks the span failed with err. A nil err does nothing.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// End ends the span. Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.ended {
+		s.ended, s.end = true, time.Now()
+	}
+	s.mu.Unlock()
+}
+
+// EndWithError is RecordError(err) followed by End, for ending a span in a
+// deferred func that reads a named error result.
+func (s *Span) EndWithError(err error) {
+	s.RecordError(err)
+	s.End()
+}
+
+// TraceID returns the span's trace ID in hex, "" for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// parseTraceparent parses a W3C traceparent header value,
+// "00-<trace id>-<span id>-<flags>". Returns nil if it is empty or invalid.
+func parseTraceparent(value string) *remoteParent {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return nil
+	}
+	var p remoteParent
+	if _, err := hex.Decode(p.traceID[:], []byte(parts[1])); err != nil {
+		return nil
+	}
+	if _, err := hex.Decode(p.spanID[:], []byte(parts[2])); err != nil {
+		return nil
+	}
+	if p.traceID == [16]byte{} || p.spanID == [8]byte{} {
+		return nil
+	}
+	return &p
+}
@@ -0,0 +1,209 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is an OTLP/HTTP endpoint that keeps the requests it receives.
+type collector struct {
+	mu      sync.Mutex
+	traces  []otlpTracesRequest
+	metrics []otlpMetricsRequest
+	headers []http.Header
+}
+
+func startCollector(t *testing.T) (*collector, string) {
+	t.Helper()
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = append(c.headers, r.Header.Clone())
+		switch r.URL.Path {
+		case "/v1/traces":
+			var req otlpTracesRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Errorf("invalid traces request: %v", err)
+			}
+			c.traces = append(c.traces, req)
+		case "/v1/metrics":
+			var req otlpMetricsRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Errorf("invalid metrics request: %v", err)
+			}
+			c.metrics = append(c.metrics, req)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv.URL
+}
+
+// clearOTelEnv keeps the environment of the test process out of NewConfig.
+func clearOTelEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"OTEL_SDK_DISABLED", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TIMEOUT",
+		"OTEL_SERVICE_NAME", "TRACEPARENT",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestStart_Disabled(t *testing.T) {
+	clearOTelEnv(t)
+	Init(NewConfig("", nil, "", "test"))
+	if Enabled() {
+		t.Fatal("Enabled() = true without an endpoint")
+	}
+	ctx, span := Start(context.Background(), "op")
+	if span != nil || ctx == nil {
+		t.Errorf("Start() = %v, %v, want the context and a nil span", ctx, span)
+	}
+	// Methods of the nil span do nothing
+	span.SetAttributes(slog.String("k", "v"))
+	span.EndWithError(errors.New("boom"))
+	if err := Flush(context.Background()); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+}
+
+func TestFlush_ExportsSpansAndMetrics(t *testing.T) {
+	clearOTelEnv(t)
+	c, url := startCollector(t)
+	Init(NewConfig(url, map[string]string{"x-api-key": "secret"}, "", "1.2.3"))
+	t.Cleanup(func() { _ = Shutdown(context.Background()) })
+
+	start := time.Now()
+	ctx, hook := Start(context.Background(), "hook stop", slog.String("hook", "stop"))
+	_, tree := Start(ctx, "checkpoint.build_tree", slog.Int("files", 3))
+	tree.End()
+	// Without a span in its context, a span nests under the open one
+	_, parse := Start(context.Background(), "transcript.parse")
+	parse.EndWithError(errors.New("bad line"))
+	hook.End()
+	RecordHook(start, nil, slog.String("hook", "stop"))
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if Enabled() {
+		t.Error("Enabled() = true after Shutdown")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.traces) != 1 || len(c.metrics) != 1 {
+		t.Fatalf("collector got %d traces and %d metrics requests, want 1 each", len(c.traces), len(c.metrics))
+	}
+	if got := c.headers[0].Get("x-api-key"); got != "secret" {
+		t.Errorf("x-api-key header = %q, want the configured one", got)
+	}
+	rs := c.traces[0].ResourceSpans[0]
+	if v := rs.Resource.Attributes[0]; v.Key != "service.name" || *v.Value.StringValue != DefaultServiceName {
+		t.Errorf("resource attribute = %+v, want service.name %s", v, DefaultServiceName)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	root, treeSpan, parseSpan := spans[0], spans[1], spans[2]
+	if root.ParentSpanID != "" || treeSpan.ParentSpanID != root.SpanID || parseSpan.ParentSpanID != root.SpanID {
+		t.Errorf("spans aren't nested under the hook: %+v", spans)
+	}
+	if treeSpan.TraceID != root.TraceID || len(root.TraceID) != 32 {
+		t.Errorf("trace IDs = %q, %q, want one 32-char trace", root.TraceID, treeSpan.TraceID)
+	}
+	if *treeSpan.Attributes[0].Value.IntValue != "3" {
+		t.Errorf("files attribute = %+v, want 3", treeSpan.Attributes[0])
+	}
+	if parseSpan.Status.Code != statusCodeError || parseSpan.Status.Message != "bad line" {
+		t.Errorf("parse span status = %+v, want the error", parseSpan.Status)
+	}
+
+	metrics := c.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 || metrics[0].Name != HookExecutionsMetric || metrics[1].Name != HookDurationMetric {
+		t.Fatalf("metrics = %+v, want executions and duration", metrics)
+	}
+	point := metrics[0].Sum.DataPoints[0]
+	if point.AsInt != "1" || len(point.Attributes) != 2 || point.Attributes[1].Key != "success" || !*point.Attributes[1].Value.BoolValue {
+		t.Errorf("executions point = %+v, want one successful execution", point)
+	}
+	if h := metrics[1].Histogram.DataPoints[0]; h.Count != "1" || len(h.BucketCounts) != len(hookDurationBounds)+1 {
+		t.Errorf("duration point = %+v, want one execution", h)
+	}
+}
+
+func TestStart_JoinsTraceparent(t *testing.T) {
+	clearOTelEnv(t)
+	t.Setenv("TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	Init(NewConfig("http://localhost:4318", nil, "", ""))
+	t.Cleanup(func() {
+		activeMu.Lock()
+		active = nil
+		activeMu.Unlock()
+	})
+
+	_, span := Start(context.Background(), "hook post-commit")
+	if span.TraceID() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("TraceID() = %q, want the TRACEPARENT trace", span.TraceID())
+	}
+}
+
+func TestFlush_ReportsCollectorErrors(t *testing.T) {
+	clearOTelEnv(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+	Init(NewConfig(srv.URL, nil, "", ""))
+
+	_, span := Start(context.Background(), "op")
+	span.End()
+	if err := Shutdown(context.Background()); err == nil {
+		t.Error("Shutdown() error = nil, want the collector's 401")
+	}
+}
+
+func TestNewConfig_Environment(t *testing.T) {
+	clearOTelEnv(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otel.example.com/")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "https://metrics.example.com/v1/metrics")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer%20abc, x-team = dev,malformed")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "250")
+	t.Setenv("OTEL_SERVICE_NAME", "entire-ci")
+
+	cfg := NewConfig("http://localhost:4318", map[string]string{"x-team": "settings", "x-other": "1"}, "from-settings", "")
+	if cfg.TracesEndpoint != "https://otel.example.com/v1/traces" {
+		t.Errorf("TracesEndpoint = %q", cfg.TracesEndpoint)
+	}
+	if cfg.MetricsEndpoint != "https://metrics.example.com/v1/metrics" {
+		t.Errorf("MetricsEndpoint = %q", cfg.MetricsEndpoint)
+	}
+	if cfg.Headers["authorization"] != "Bearer abc" || cfg.Headers["x-team"] != "dev" || cfg.Headers["x-other"] != "1" || len(cfg.Headers) != 3 {
+		t.Errorf("Headers = %v", cfg.Headers)
+	}
+	if cfg.Timeout != 250*time.Millisecond || cfg.ServiceName != "entire-ci" {
+		t.Errorf("Timeout, ServiceName = %v, %q", cfg.Timeout, cfg.ServiceName)
+	}
+
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	if NewConfig("http://localhost:4318", nil, "", "").Enabled() {
+		t.Error("NewConfig() is enabled with OTEL_SDK_DISABLED=true")
+	}
+}
@@ -4,8 +4,11 @@
 package trailers
 
 import (
+	"crypto/sha1" //nolint:gosec // Gerrit Change-Ids are SHA-1 sized
+	"encoding/hex"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	checkpointID "github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
@@ -48,6 +51,20 @@ const (
 	// AgentTrailerKey identifies the agent that created a checkpoint.
 	// Format: human-readable agent name e.g. "Claude Code", "Cursor"
 	AgentTrailerKey = "Entire-Agent"
+
+	// ReconstructedTrailerKey marks a shadow commit rebuilt from a transcript
+	// after its shadow branch was lost. The value is the confidence (0-1).
+	ReconstructedTrailerKey = "Entire-Reconstructed"
+
+	// MetadataOnlyTrailerKey marks a shadow commit written while the
+	// repository was over its quota: it records file hashes and sizes but
+	// keeps the previous checkpoint's file contents.
+	MetadataOnlyTrailerKey = "Entire-Metadata-Only"
+
+	// ChangeIDTrailerKey is Gerrit's change identifier. The stacked strategy
+	// adds it so each commit of a stack is reviewed as a change of its own.
+	// Format: "I" followed by 40 hex characters.
+	ChangeIDTrailerKey = "Change-Id"
 )
 
 // Pre-compiled regexes for trailer parsing.
@@ -60,6 +77,9 @@ var (
 	condensationTrailerRegex = regexp.MustCompile(CondensationTrailerKey + `:\s*(.+)`)
 	sessionTrailerRegex      = regexp.MustCompile(SessionTrailerKey + `:\s*(.+)`)
 	checkpointTrailerRegex   = regexp.MustCompile(CheckpointTrailerKey + `:\s*(` + checkpointID.Pattern + `)(?:\s|$)`)
+	reconstructedRegex       = regexp.MustCompile(ReconstructedTrailerKey + `:\s*([0-9.]+)`)
+	metadataOnlyRegex        = regexp.MustCompile(`(?m)^` + MetadataOnlyTrailerKey + `:\s*true\s*$`)
+	changeIDRegex            = regexp.MustCompile(`(?m)^` + ChangeIDTrailerKey + `:\s*(I[0-9a-f]{40})\s*$`)
 )
 
 // ParseStrategy extracts strategy from commit message.
@@ -124,6 +144,25 @@ func ParseSession(commitMessage string) (string, bool) {
 	return "", false
 }
 
+// ParseReconstructed extracts the confidence of a reconstructed shadow commit.
+// Returns false if the commit wasn't reconstructed.
+func ParseReconstructed(commitMessage string) (float64, bool) {
+	matches := reconstructedRegex.FindStringSubmatch(commitMessage)
+	if len(matches) < 2 {
+		return 0, false
+	}
+	confidence, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return confidence, true
+}
+
+// IsMetadataOnly reports whether a shadow commit was written metadata-only.
+func IsMetadataOnly(commitMessage string) bool {
+	return metadataOnlyRegex.MatchString(commitMessage)
+}
+
 // ParseCheckpoint extracts the checkpoint ID from a commit message.
 // Returns the CheckpointID and true if found, empty ID and false otherwise.
 func ParseCheckpoint(commitMessage string) (checkpointID.CheckpointID, bool) {
@@ -138,6 +177,35 @@ func ParseCheckpoint(commitMessage string) (checkpointID.CheckpointID, bool) {
 	return checkpointID.EmptyCheckpointID, false
 }
 
+// ParseChangeID extracts the Gerrit Change-Id from a commit message.
+// Returns the Change-Id and true if found, empty string and false otherwise.
+func ParseChangeID(commitMessage string) (string, bool) {
+	matches := changeIDRegex.FindStringSubmatch(commitMessage)
+	if len(matches) > 1 {
+		return matches[1], true
+	}
+	return "", false
+}
+
+// ChangeIDForCheckpoint derives a Gerrit Change-Id from a checkpoint ID, so
+// a commit's change is the same however often it's amended or rebased.
+func ChangeIDForCheckpoint(cpID checkpointID.CheckpointID) string {
+	sum := sha1.Sum([]byte("entire-checkpoint " + cpID.String())) //nolint:gosec // an identifier, not a security boundary
+	return "I" + hex.EncodeToString(sum[:])
+}
+
+// HasTrailer reports whether the commit message has a non-empty trailer with
+// the given key, compared case-insensitively like git does.
+func HasTrailer(commitMessage, key string) bool {
+	for _, line := range strings.Split(commitMessage, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) && strings.TrimSpace(v) != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseAllSessions extracts all session IDs from a commit message.
 // Returns a slice of session IDs (may be empty if none found).
 // Duplicate session IDs are deduplicated while preserving order.
@@ -221,6 +289,18 @@ func FormatShadowTaskCommit(message, taskMetadataDir, sessionID string) string {
 	return sb.String()
 }
 
+// FormatReconstructed appends an Entire-Reconstructed trailer to a shadow
+// commit message built by FormatShadowCommit.
+func FormatReconstructed(shadowMessage string, confidence float64) string {
+	return fmt.Sprintf("%s%s: %s\n", shadowMessage, ReconstructedTrailerKey, strconv.FormatFloat(confidence, 'f', 2, 64))
+}
+
+// FormatMetadataOnly appends an Entire-Metadata-Only trailer to a shadow
+// commit message built by FormatShadowCommit.
+func FormatMetadataOnly(shadowMessage string) string {
+	return fmt.Sprintf("%s%s: true\n", shadowMessage, MetadataOnlyTrailerKey)
+}
+
 // FormatCheckpoint creates a commit message with a checkpoint trailer.
 // This links user commits to their checkpoint metadata on entire/checkpoints/v1 branch.
 func FormatCheckpoint(message string, cpID checkpointID.CheckpointID) string {
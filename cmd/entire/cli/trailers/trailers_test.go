@@ -2,6 +2,8 @@ package trailers
 
 import (
 	"testing"
+
+	checkpointID "github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
 )
 
 func TestFormatMetadata(t *testing.T) {
@@ -318,3 +320,61 @@ func TestParseCheckpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatParseReconstructed(t *testing.T) {
+	msg := FormatReconstructed(FormatShadowCommit("Reconstructed checkpoint", ".entire/metadata/s1", "s1"), 0.756)
+	confidence, ok := ParseReconstructed(msg)
+	if !ok || confidence != 0.76 {
+		t.Errorf("ParseReconstructed() = %v, %v, want 0.76, true", confidence, ok)
+	}
+	if _, ok := ParseSession(msg); !ok {
+		t.Errorf("reconstructed message lost its session trailer: %q", msg)
+	}
+	if _, ok := ParseReconstructed("Checkpoint\n\nEntire-Session: s1\n"); ok {
+		t.Error("ParseReconstructed() found a trailer in a regular shadow commit")
+	}
+}
+
+func TestFormatMetadataOnly(t *testing.T) {
+	msg := FormatMetadataOnly(FormatShadowCommit("Checkpoint", ".entire/metadata/s1", "s1"))
+	if !IsMetadataOnly(msg) {
+		t.Errorf("IsMetadataOnly() = false for %q", msg)
+	}
+	if _, ok := ParseSession(msg); !ok {
+		t.Errorf("metadata-only message lost its session trailer: %q", msg)
+	}
+	if IsMetadataOnly(FormatShadowCommit("Checkpoint", ".entire/metadata/s1", "s1")) {
+		t.Error("IsMetadataOnly() = true for a regular shadow commit")
+	}
+}
+
+func TestHasTrailer(t *testing.T) {
+	msg := "Add parser\n\nEntire-Checkpoint: a1b2c3d4e5f6\nai-approved-by: Jane Doe\nReviewed-By:\n"
+	if !HasTrailer(msg, "AI-Approved-By") {
+		t.Error("HasTrailer() = false, want the key matched case-insensitively")
+	}
+	if HasTrailer(msg, "Reviewed-By") {
+		t.Error("HasTrailer() = true for an empty trailer")
+	}
+	if HasTrailer(msg, "Signed-off-by") {
+		t.Error("HasTrailer() = true for a missing trailer")
+	}
+}
+
+func TestChangeID(t *testing.T) {
+	cpID := checkpointID.MustCheckpointID("a1b2c3d4e5f6")
+	changeID := ChangeIDForCheckpoint(cpID)
+	if changeID != ChangeIDForCheckpoint(cpID) || len(changeID) != 41 || changeID[0] != 'I' {
+		t.Fatalf("ChangeIDForCheckpoint() = %q, want a stable I + 40 hex characters", changeID)
+	}
+	if changeID == ChangeIDForCheckpoint(checkpointID.MustCheckpointID("a1b2c3d4e5f7")) {
+		t.Error("ChangeIDForCheckpoint() gave two checkpoints the same Change-Id")
+	}
+	msg := "Add parser\n\nEntire-Checkpoint: a1b2c3d4e5f6\nChange-Id: " + changeID + "\n"
+	if got, ok := ParseChangeID(msg); !ok || got != changeID {
+		t.Errorf("ParseChangeID() = %q, %t; want %q", got, ok, changeID)
+	}
+	if _, ok := ParseChangeID("Add parser\n\nMentions Change-Id: " + changeID + " in the body\n"); ok {
+		t.Error("ParseChangeID() matched a Change-Id outside a trailer line")
+	}
+}
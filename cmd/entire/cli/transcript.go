@@ -1,16 +1,17 @@
 package cli
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/entireio/cli/cmd/entire/cli/agent/claudecode"
 	"github.com/entireio/cli/cmd/entire/cli/textutil"
+	"github.com/entireio/cli/cmd/entire/cli/tracing"
 	"github.com/entireio/cli/cmd/entire/cli/transcript"
 )
 
@@ -23,46 +24,22 @@ const (
 )
 
 // parseTranscript reads and parses a Claude Code transcript file.
-// Uses bufio.Reader to handle arbitrarily long lines.
+// Lines are decoded as they're read, so the raw file is never held in memory.
 func parseTranscript(path string) ([]transcriptLine, error) {
+	_, span := tracing.Start(context.Background(), "transcript.parse")
+	defer span.End()
+
 	file, err := os.Open(path) //nolint:gosec // Reading from controlled git metadata path
 	if err != nil {
 		return nil, err //nolint:wrapcheck // already present in codebase
 	}
 	defer func() { _ = file.Close() }()
 
-	var lines []transcriptLine
-	reader := bufio.NewReader(file)
-
-	for {
-		lineBytes, err := reader.ReadBytes('\n')
-		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("failed to read transcript: %w", err)
-		}
-
-		// Handle empty line or EOF without content
-		if len(lineBytes) == 0 {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		var line transcriptLine
-		if err := json.Unmarshal(lineBytes, &line); err == nil {
-			lines = append(lines, line)
-		}
-
-		if err == io.EOF {
-			break
-		}
-	}
-
-	return lines, nil
+	return transcript.ParseFromReader(file) //nolint:wrapcheck // already wrapped by the transcript package
 }
 
 // parseTranscriptFromLine reads and parses a transcript file starting from a specific line.
-// Uses bufio.Reader to handle arbitrarily long lines.
+// Lines before startLine are read but not decoded.
 // Returns:
 //   - lines: parsed transcript lines from startLine onwards (malformed lines skipped)
 //   - totalLines: total number of lines in the file (including malformed ones)
@@ -71,45 +48,37 @@ func parseTranscript(path string) ([]transcriptLine, error) {
 // The startLine parameter is 0-indexed (startLine=0 reads from the beginning).
 // This is useful for incremental parsing when you've already processed some lines.
 func parseTranscriptFromLine(path string, startLine int) ([]transcriptLine, int, error) {
+	lines, totalLines, _, err := parseTranscriptFromBookmark(path, startLine, nil)
+	return lines, totalLines, err
+}
+
+// parseTranscriptFromBookmark is parseTranscriptFromLine that seeks to from
+// instead of reading the lines before it, when from still matches the file.
+// It also returns the bookmark to resume from next time, nil if there is none.
+func parseTranscriptFromBookmark(path string, startLine int, from *transcript.Bookmark) ([]transcriptLine, int, *transcript.Bookmark, error) {
+	_, span := tracing.Start(context.Background(), "transcript.parse", slog.Int("start_line", startLine), slog.Bool("bookmark", from != nil))
+	defer span.End()
+
 	file, err := os.Open(path) //nolint:gosec // path is a controlled transcript file path
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to open transcript: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to open transcript: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
+	scanner, err := transcript.ResumeScanner(file, from, startLine)
+	if err != nil {
+		return nil, 0, nil, err //nolint:wrapcheck // already wrapped by the transcript package
+	}
 	var lines []transcriptLine
-	reader := bufio.NewReader(file)
-
-	totalLines := 0
-	for {
-		lineBytes, err := reader.ReadBytes('\n')
-		if err != nil && err != io.EOF {
-			return nil, 0, fmt.Errorf("failed to read transcript: %w", err)
-		}
-
-		// Handle empty line or EOF without content
-		if len(lineBytes) == 0 {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		// Count all lines for totalLines, but only parse after startLine
-		if totalLines >= startLine {
-			var line transcriptLine
-			if err := json.Unmarshal(lineBytes, &line); err == nil {
-				lines = append(lines, line)
-			}
-		}
-		totalLines++
-
-		if err == io.EOF {
-			break
-		}
+	for scanner.Scan() {
+		lines = append(lines, scanner.Line())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, nil, err //nolint:wrapcheck // already wrapped by the transcript package
 	}
 
-	return lines, totalLines, nil
+	span.SetAttributes(slog.Int("lines", len(lines)))
+	return lines, scanner.LinesRead(), scanner.Bookmark(), nil
 }
 
 // parseTranscriptFromBytes parses transcript content from a byte slice.
@@ -511,49 +480,45 @@ type TranscriptPosition struct {
 // Returns empty position if file doesn't exist or is empty.
 // Only considers UUIDs from actual messages (user/assistant), not summary rows which use leafUuid.
 func GetTranscriptPosition(path string) (TranscriptPosition, error) {
+	pos, _, err := GetTranscriptPositionFrom(path, nil)
+	return pos, err
+}
+
+// GetTranscriptPositionFrom is GetTranscriptPosition that only reads the lines
+// after from when from still matches the transcript. It also returns the
+// bookmark for the transcript's end, nil if there is none.
+func GetTranscriptPositionFrom(path string, from *transcript.Bookmark) (TranscriptPosition, *transcript.Bookmark, error) {
 	if path == "" {
-		return TranscriptPosition{}, nil
+		return TranscriptPosition{}, nil, nil
 	}
 
 	file, err := os.Open(path) //nolint:gosec // Reading from controlled transcript path
 	if err != nil {
 		if os.IsNotExist(err) {
-			return TranscriptPosition{}, nil
+			return TranscriptPosition{}, nil, nil
 		}
-		return TranscriptPosition{}, fmt.Errorf("failed to open transcript: %w", err)
+		return TranscriptPosition{}, nil, fmt.Errorf("failed to open transcript: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
-	var pos TranscriptPosition
-	reader := bufio.NewReader(file)
-
-	for {
-		lineBytes, err := reader.ReadBytes('\n')
-		if err != nil && err != io.EOF {
-			return TranscriptPosition{}, fmt.Errorf("failed to read transcript: %w", err)
-		}
-
-		if len(lineBytes) == 0 {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		pos.LineCount++
-
-		// Parse line to extract UUID (only from user/assistant messages, not summaries)
-		var line transcriptLine
-		if err := json.Unmarshal(lineBytes, &line); err == nil {
-			if line.UUID != "" {
-				pos.LastUUID = line.UUID
-			}
-		}
-
-		if err == io.EOF {
-			break
-		}
+	// Every line after the bookmark is needed for the UUID; without a usable
+	// bookmark, that's every line
+	var fromLine int
+	if from != nil {
+		fromLine = from.Line
+	}
+	scanner, err := transcript.ResumeScanner(file, from, fromLine)
+	if err == nil && !scanner.Resumed() && fromLine > 0 {
+		scanner, err = transcript.ResumeScanner(file, nil, 0)
+	}
+	if err != nil {
+		return TranscriptPosition{}, nil, err //nolint:wrapcheck // already wrapped by the transcript package
+	}
+	for scanner.Scan() { //nolint:revive // only the position is needed
+	}
+	if err := scanner.Err(); err != nil {
+		return TranscriptPosition{}, nil, err //nolint:wrapcheck // already wrapped by the transcript package
 	}
 
-	return pos, nil
+	return TranscriptPosition{LastUUID: scanner.LastUUID(), LineCount: scanner.LinesRead()}, scanner.Bookmark(), nil
 }
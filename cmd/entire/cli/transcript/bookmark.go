@@ -0,0 +1,86 @@
+package transcript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Bookmark is a position just past a complete line of a JSONL transcript,
+// stored between hooks so the next hook reads only what was appended since.
+// Claude Code only appends to transcripts, but rewind truncates them, so a
+// bookmark also fingerprints the line it follows and is only resumed while
+// that line is unchanged.
+type Bookmark struct {
+	Offset int64  `json:"offset"`         // Byte offset just past the line
+	Line   int    `json:"line"`           // Lines before Offset
+	UUID   string `json:"uuid,omitempty"` // Last message UUID before Offset
+
+	LastLineStart int64  `json:"last_line_start"`
+	LastLineHash  string `json:"last_line_hash"`
+}
+
+// Matches reports whether the transcript r still has the bookmarked line where
+// b expects it.
+func (b *Bookmark) Matches(r io.ReaderAt) bool {
+	if b == nil || b.Offset <= 0 || b.LastLineStart < 0 || b.LastLineStart >= b.Offset {
+		return false
+	}
+	buf := make([]byte, b.Offset-b.LastLineStart)
+	if _, err := r.ReadAt(buf, b.LastLineStart); err != nil {
+		return false
+	}
+	return buf[len(buf)-1] == '\n' && lineHash(buf) == b.LastLineHash
+}
+
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// ResumeScanner returns a Scanner over the transcript f that starts at
+// startLine (0-indexed), like NewScannerFromLine. When from still matches f
+// and isn't past startLine, the scanner seeks to it instead of reading the
+// transcript from the beginning.
+func ResumeScanner(f io.ReadSeeker, from *Bookmark, startLine int) (*Scanner, error) {
+	ra, ok := f.(io.ReaderAt)
+	if ok && from != nil && from.Line <= startLine && from.Matches(ra) {
+		if _, err := f.Seek(from.Offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek transcript: %w", err)
+		}
+		s := NewScannerFromLine(f, startLine)
+		s.lineNum = from.Line
+		s.offset = from.Offset
+		s.uuid = from.UUID
+		s.mark = *from
+		s.resumed = true
+		return s, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek transcript: %w", err)
+	}
+	return NewScannerFromLine(f, startLine), nil
+}
+
+// Resumed reports whether the scanner started at a bookmark rather than at
+// the beginning of the transcript.
+func (s *Scanner) Resumed() bool {
+	return s.resumed
+}
+
+// Bookmark returns the position after the last complete line read, to resume
+// from next time. It returns nil when no complete line was read, or when a
+// line was skipped without decoding after the last known UUID, since the
+// bookmark's UUID would then be unreliable.
+func (s *Scanner) Bookmark() *Bookmark {
+	if !s.uuidKnown || s.mark.Offset == 0 {
+		return nil
+	}
+	b := s.mark
+	if s.markLine != nil {
+		b.LastLineHash = lineHash(s.markLine)
+	}
+	return &b
+}
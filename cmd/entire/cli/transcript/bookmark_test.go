@@ -0,0 +1,95 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTranscriptFile(t *testing.T, path, content string) *os.File {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open transcript: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func drain(s *Scanner) {
+	for s.Scan() { //nolint:revive // only the position is needed
+	}
+}
+
+func TestResumeScanner_AfterAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	first := `{"type":"user","uuid":"u1","message":{"content":"one"}}
+{"type":"assistant","uuid":"a1","message":{"content":[]}}
+`
+	f := writeTranscriptFile(t, path, first+`{"type":"user","uuid":"u2"`)
+
+	scanner, err := ResumeScanner(f, nil, 0)
+	if err != nil {
+		t.Fatalf("ResumeScanner() error = %v", err)
+	}
+	drain(scanner)
+	b := scanner.Bookmark()
+	// The unterminated line may still be being written, so it isn't bookmarked
+	if b == nil || b.Offset != int64(len(first)) || b.Line != 2 || b.UUID != "a1" {
+		t.Fatalf("Bookmark() = %+v, want offset %d, line 2, uuid a1", b, len(first))
+	}
+
+	f = writeTranscriptFile(t, path, first+`{"type":"user","uuid":"u2","message":{"content":"two"}}
+`)
+	scanner, err = ResumeScanner(f, b, b.Line)
+	if err != nil {
+		t.Fatalf("ResumeScanner() error = %v", err)
+	}
+	if !scanner.Resumed() {
+		t.Fatal("Resumed() = false, want true")
+	}
+	var uuids []string
+	for scanner.Scan() {
+		uuids = append(uuids, scanner.Line().UUID)
+	}
+	if len(uuids) != 1 || uuids[0] != "u2" || scanner.LineNumber() != 2 || scanner.LinesRead() != 3 {
+		t.Errorf("resumed scan = %v at line %d of %d, want [u2] at line 2 of 3", uuids, scanner.LineNumber(), scanner.LinesRead())
+	}
+	if next := scanner.Bookmark(); next == nil || next.Line != 3 || next.UUID != "u2" {
+		t.Errorf("Bookmark() after resume = %+v", next)
+	}
+}
+
+func TestResumeScanner_RewrittenTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	f := writeTranscriptFile(t, path, `{"type":"user","uuid":"u1","message":{"content":"one"}}
+{"type":"assistant","uuid":"a1","message":{"content":[]}}
+`)
+	scanner, err := ResumeScanner(f, nil, 0)
+	if err != nil {
+		t.Fatalf("ResumeScanner() error = %v", err)
+	}
+	drain(scanner)
+	b := scanner.Bookmark()
+
+	// Same length, different last line, as after a rewind and new messages
+	f = writeTranscriptFile(t, path, `{"type":"user","uuid":"u1","message":{"content":"one"}}
+{"type":"assistant","uuid":"b1","message":{"content":[]}}
+`)
+	scanner, err = ResumeScanner(f, b, b.Line)
+	if err != nil {
+		t.Fatalf("ResumeScanner() error = %v", err)
+	}
+	if scanner.Resumed() {
+		t.Error("Resumed() = true for a rewritten transcript")
+	}
+	if scanner.Scan() {
+		t.Errorf("Scan() = true, want the lines before the start line skipped, got %+v", scanner.Line())
+	}
+	if scanner.LinesRead() != 2 || scanner.Bookmark() != nil {
+		t.Errorf("LinesRead() = %d, Bookmark() = %+v; want 2 and no bookmark, since the skipped lines' UUIDs are unknown", scanner.LinesRead(), scanner.Bookmark())
+	}
+}
@@ -14,34 +14,143 @@ import (
 // ParseFromBytes parses transcript content from a byte slice.
 // Uses bufio.Reader to handle arbitrarily long lines.
 func ParseFromBytes(content []byte) ([]Line, error) {
+	return ParseFromReader(bytes.NewReader(content))
+}
+
+// ParseFromReader parses a transcript from r without holding the raw content
+// in memory. Malformed lines are skipped.
+func ParseFromReader(r io.Reader) ([]Line, error) {
 	var lines []Line
-	reader := bufio.NewReader(bytes.NewReader(content))
+	scanner := NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Line())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// Scanner reads a JSONL transcript one line at a time, so callers can stop
+// early or only decode the tail of a long transcript. Like bufio.Scanner,
+// call Scan until it returns false, then check Err. Unlike bufio.Scanner it
+// has no line length limit.
+type Scanner struct {
+	reader    *bufio.Reader
+	startLine int
+	lineNum   int   // lines read so far, including skipped and malformed ones
+	offset    int64 // bytes read so far
+	line      Line
+	raw       []byte
+	err       error
+	done      bool
+
+	// Position after the last complete line, for Bookmark. uuidKnown is false
+	// once a line was skipped without decoding, since it may have had a UUID.
+	uuid      string
+	uuidKnown bool
+	mark      Bookmark
+	markLine  []byte // the line ending at mark, hashed on demand
+	resumed   bool
+}
 
-	for {
-		lineBytes, err := reader.ReadBytes('\n')
-		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("failed to read transcript: %w", err)
+// NewScanner returns a Scanner that decodes every line of r.
+func NewScanner(r io.Reader) *Scanner {
+	return NewScannerFromLine(r, 0)
+}
+
+// NewScannerFromLine returns a Scanner that starts at line startLine
+// (0-indexed). Earlier lines are read but not decoded, which makes
+// resuming from a stored line offset cheap.
+func NewScannerFromLine(r io.Reader, startLine int) *Scanner {
+	return &Scanner{reader: bufio.NewReader(r), startLine: startLine, uuidKnown: true}
+}
+
+// Scan advances to the next well-formed line at or after the start line,
+// skipping malformed ones. It returns false at the end of the input or on a
+// read error.
+func (s *Scanner) Scan() bool {
+	for !s.done {
+		lineBytes, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			s.done = true
+			if err != io.EOF {
+				s.err = fmt.Errorf("failed to read transcript: %w", err)
+				return false
+			}
 		}
 
 		// Handle empty line or EOF without content
 		if len(lineBytes) == 0 {
-			if err == io.EOF {
-				break
-			}
 			continue
 		}
 
+		lineStart := s.offset
+		s.offset += int64(len(lineBytes))
+		s.lineNum++
+
 		var line Line
-		if err := json.Unmarshal(lineBytes, &line); err == nil {
-			lines = append(lines, line)
+		decoded := false
+		if s.lineNum <= s.startLine {
+			s.uuidKnown = false
+		} else if err := json.Unmarshal(lineBytes, &line); err == nil {
+			decoded = true
+			if line.UUID != "" {
+				s.uuid = line.UUID
+				s.uuidKnown = true
+			}
+		}
+
+		// A line without its newline may still be being written; bookmarks
+		// only ever point past complete lines
+		if lineBytes[len(lineBytes)-1] == '\n' {
+			s.mark = Bookmark{Offset: s.offset, Line: s.lineNum, UUID: s.uuid, LastLineStart: lineStart}
+			s.markLine = lineBytes
 		}
 
-		if err == io.EOF {
-			break
+		if decoded {
+			s.line = line
+			s.raw = lineBytes
+			return true
 		}
 	}
+	return false
+}
 
-	return lines, nil
+// Line returns the line decoded by the last call to Scan.
+func (s *Scanner) Line() Line {
+	return s.line
+}
+
+// Bytes returns the raw JSON of the current line, including its trailing
+// newline if it had one. The slice isn't reused by later calls to Scan.
+func (s *Scanner) Bytes() []byte {
+	return s.raw
+}
+
+// LineNumber returns the 0-indexed position of the current line in the
+// transcript, counting malformed lines.
+func (s *Scanner) LineNumber() int {
+	return s.lineNum - 1
+}
+
+// LinesRead returns the number of lines read so far, counting skipped and
+// malformed ones. Once Scan has returned false it is the transcript's line
+// count, the position to resume from next time.
+func (s *Scanner) LinesRead() int {
+	return s.lineNum
+}
+
+// LastUUID returns the UUID of the last message read, or of the bookmark the
+// scanner resumed from if none was read since. Summary rows, which carry a
+// leafUuid instead, don't count.
+func (s *Scanner) LastUUID() string {
+	return s.uuid
+}
+
+// Err returns the first read error, if any. Malformed lines aren't errors.
+func (s *Scanner) Err() error {
+	return s.err
 }
 
 // SliceFromLine returns the content starting from line number `startLine` (0-indexed).
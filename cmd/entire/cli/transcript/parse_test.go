@@ -2,6 +2,7 @@ package transcript
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +70,66 @@ func TestParseFromBytes_NoTrailingNewline(t *testing.T) {
 	}
 }
 
+func TestParseFromReader_LongLine(t *testing.T) {
+	// Longer than bufio.Scanner's default 64KB token limit
+	long := strings.Repeat("x", 200*1024)
+	content := `{"type":"user","uuid":"u1","message":{"content":"` + long + `"}}` + "\n"
+
+	lines, err := ParseFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 1 || lines[0].UUID != "u1" {
+		t.Fatalf("expected the long line, got %d lines", len(lines))
+	}
+}
+
+func TestScanner_FromLine(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","message":{"content":"one"}}
+not valid json
+{"type":"assistant","uuid":"a1","message":{"content":[]}}
+{"type":"user","uuid":"u2","message":{"content":"two"}}`
+
+	scanner := NewScannerFromLine(strings.NewReader(content), 2)
+	var uuids []string
+	var positions []int
+	for scanner.Scan() {
+		uuids = append(uuids, scanner.Line().UUID)
+		positions = append(positions, scanner.LineNumber())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(uuids, ",") != "a1,u2" {
+		t.Errorf("uuids = %v, want [a1 u2]", uuids)
+	}
+	if len(positions) != 2 || positions[0] != 2 || positions[1] != 3 {
+		t.Errorf("line numbers = %v, want [2 3]", positions)
+	}
+	if scanner.LinesRead() != 4 {
+		t.Errorf("LinesRead() = %d, want 4", scanner.LinesRead())
+	}
+}
+
+func TestScanner_StopEarly(t *testing.T) {
+	content := `{"type":"user","uuid":"u1","message":{"content":"one"}}
+{"type":"user","uuid":"u2","message":{"content":"two"}}
+{"type":"user","uuid":"u3","message":{"content":"three"}}
+`
+	scanner := NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		if scanner.Line().UUID == "u2" {
+			break
+		}
+	}
+	if scanner.LinesRead() != 2 {
+		t.Errorf("LinesRead() = %d, want 2", scanner.LinesRead())
+	}
+	if !strings.HasPrefix(string(scanner.Bytes()), `{"type":"user","uuid":"u2"`) {
+		t.Errorf("Bytes() = %q", scanner.Bytes())
+	}
+}
+
 func TestExtractUserContent_StringContent(t *testing.T) {
 	msg := UserMessage{Content: "Hello, world!"}
 	raw, err := json.Marshal(msg)
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
+
+	"github.com/spf13/cobra"
+)
+
+func newTranscriptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transcript",
+		Short: "Work with session transcripts",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newTranscriptExportCmd())
+	cmd.AddCommand(newTranscriptScanCmd())
+	cmd.AddCommand(newTranscriptSummarizeCmd())
+
+	return cmd
+}
+
+func newTranscriptExportCmd() *cobra.Command {
+	var formatFlag string
+	var outputFlag string
+	var rangeFlag string
+
+	cmd := &cobra.Command{
+		Use:   "export <session-id>",
+		Short: "Export a session transcript as Markdown or HTML",
+		Long: `Converts a session transcript into a readable document with prompts,
+assistant messages, tool calls and file edits rendered as diffs.
+
+The transcript is read from the live agent transcript for active sessions, or
+from the most recent committed checkpoint for the session otherwise. With
+--range, only the part of the transcript recorded in the session's checkpoints
+linked from commits in the range is exported.
+
+Formats:
+  markdown   GitHub-flavored Markdown (default)
+  html       Standalone HTML page`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeSessionIDs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if formatFlag != exportFormatMarkdown && formatFlag != exportFormatHTML {
+				return fmt.Errorf("invalid format %q: must be %s or %s", formatFlag, exportFormatMarkdown, exportFormatHTML)
+			}
+
+			w := cmd.OutOrStdout()
+			if outputFlag != "" {
+				f, err := os.Create(outputFlag) //nolint:gosec // output path is provided by the user
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			return runTranscriptExport(cmd.Context(), w, args[0], formatFlag, rangeFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&formatFlag, "format", exportFormatMarkdown, "Output format: markdown or html")
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Write to file instead of stdout")
+	addCommitRangeFlag(cmd, &rangeFlag)
+
+	return cmd
+}
+
+func runTranscriptExport(ctx context.Context, w io.Writer, sessionID, format, rangeSpec string) error {
+	content, agentType, source, err := loadTranscriptForExport(ctx, sessionID, rangeSpec)
+	if err != nil {
+		return err
+	}
+
+	entries, err := parseTranscriptForExport(content, agentType)
+	if err != nil {
+		return fmt.Errorf("failed to parse transcript for session %s: %w", sessionID, err)
+	}
+
+	doc := exportDocument{
+		SessionID: sessionID,
+		Agent:     agentType,
+		Source:    source,
+		Entries:   entries,
+	}
+
+	var rendered string
+	if format == exportFormatHTML {
+		rendered = renderTranscriptHTML(doc)
+	} else {
+		rendered = renderTranscriptMarkdown(doc)
+	}
+
+	if _, err := io.WriteString(w, rendered); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// loadTranscriptForExport finds the transcript for a session. Active sessions are read
+// from the agent's live transcript file; otherwise the most recent committed checkpoint
+// containing the session is used. With a revision range, only checkpoints linked from
+// commits in the range count, and line-based transcripts are cut to the lines recorded
+// since the oldest of them. Returns the content, the agent type and a short description
+// of where it came from.
+func loadTranscriptForExport(ctx context.Context, sessionID, rangeSpec string) ([]byte, agent.AgentType, string, error) {
+	if rangeSpec == "" {
+		state, err := strategy.LoadSessionState(sessionID)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to load session state: %w", err)
+		}
+		if state != nil && state.TranscriptPath != "" {
+			if data, readErr := os.ReadFile(state.TranscriptPath); readErr == nil {
+				return data, state.AgentType, "live session transcript", nil
+			}
+		}
+	}
+
+	repo, err := openRepository()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("not a git repository: %w", err)
+	}
+	commitRange, err := resolveCommitRange(repo, rangeSpec)
+	if err != nil {
+		return nil, "", "", err
+	}
+	store := checkpoint.NewGitStore(repo)
+
+	committed, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	// Committed transcripts are cumulative, so the newest checkpoint has the most content.
+	var latest, earliest *checkpoint.CommittedInfo
+	for i := range committed {
+		info := &committed[i]
+		if info.SessionID != sessionID && !slices.Contains(info.SessionIDs, sessionID) {
+			continue
+		}
+		if !commitRange.ContainsCheckpoint(info.CheckpointID) {
+			continue
+		}
+		if latest == nil || info.CreatedAt.After(latest.CreatedAt) {
+			latest = info
+		}
+		if earliest == nil || info.CreatedAt.Before(earliest.CreatedAt) {
+			earliest = info
+		}
+	}
+	if latest == nil {
+		if commitRange != nil {
+			return nil, "", "", fmt.Errorf("no transcript found for session %s in %s", sessionID, commitRange.Spec)
+		}
+		return nil, "", "", fmt.Errorf("no transcript found for session %s", sessionID)
+	}
+
+	sessionContent, err := store.ReadSessionContentByID(ctx, latest.CheckpointID, sessionID)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read checkpoint %s: %w", latest.CheckpointID, err)
+	}
+	if len(sessionContent.Transcript) == 0 {
+		return nil, "", "", errors.New("checkpoint has no transcript for this session")
+	}
+
+	agentType := sessionContent.Metadata.Agent
+	if agentType == "" {
+		agentType = latest.Agent
+	}
+	if commitRange == nil {
+		return sessionContent.Transcript, agentType, "checkpoint " + latest.CheckpointID.String(), nil
+	}
+
+	source := fmt.Sprintf("checkpoint %s in %s", latest.CheckpointID, commitRange.Spec)
+	content := sessionContent.Transcript
+	if earliest.CheckpointID != latest.CheckpointID {
+		source = fmt.Sprintf("checkpoints %s to %s in %s", earliest.CheckpointID, latest.CheckpointID, commitRange.Spec)
+	}
+	// Gemini transcripts are one JSON document and Aider's are Markdown, so
+	// only JSONL transcripts can be cut by line
+	if agentType != agent.AgentTypeGemini && agentType != agent.AgentTypeAider {
+		first := sessionContent
+		if earliest.CheckpointID != latest.CheckpointID {
+			if first, err = store.ReadSessionContentByID(ctx, earliest.CheckpointID, sessionID); err != nil {
+				return nil, "", "", fmt.Errorf("failed to read checkpoint %s: %w", earliest.CheckpointID, err)
+			}
+		}
+		content = transcript.SliceFromLine(content, first.Metadata.GetTranscriptStart())
+	}
+	return content, agentType, source, nil
+}
@@ -0,0 +1,456 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/agent/aider"
+	"github.com/entireio/cli/cmd/entire/cli/agent/geminicli"
+	"github.com/entireio/cli/cmd/entire/cli/transcript"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Supported transcript export formats.
+const (
+	exportFormatMarkdown = "markdown"
+	exportFormatHTML     = "html"
+)
+
+// exportRole identifies who produced an exported transcript entry.
+type exportRole string
+
+const (
+	exportRoleUser      exportRole = "user"
+	exportRoleAssistant exportRole = "assistant"
+	exportRoleTool      exportRole = "tool"
+)
+
+// exportEntry is one agent-neutral item of an exported transcript.
+// Text is set for user and assistant entries; Tool is set for tool entries.
+type exportEntry struct {
+	Role exportRole
+	Text string
+	Tool *exportToolCall
+}
+
+// exportToolCall is a tool invocation with its raw input arguments.
+type exportToolCall struct {
+	Name  string
+	Input map[string]any
+}
+
+// exportDocument is everything needed to render an exported transcript.
+type exportDocument struct {
+	SessionID string
+	Agent     agent.AgentType
+	Source    string // Where the transcript was read from (for the header)
+	Entries   []exportEntry
+}
+
+// parseTranscriptForExport converts raw transcript bytes into export entries.
+// Gemini CLI transcripts are a single JSON document and Aider transcripts are
+// its Markdown chat history; everything else is treated as Claude Code JSONL.
+func parseTranscriptForExport(content []byte, agentType agent.AgentType) ([]exportEntry, error) {
+	if agentType == agent.AgentTypeGemini {
+		return parseGeminiTranscriptForExport(content)
+	}
+	if agentType == agent.AgentTypeAider {
+		return parseAiderTranscriptForExport(content), nil
+	}
+	return parseJSONLTranscriptForExport(content)
+}
+
+func parseJSONLTranscriptForExport(content []byte) ([]exportEntry, error) {
+	lines, err := transcript.ParseFromBytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	var entries []exportEntry
+	for _, line := range lines {
+		switch line.Type {
+		case transcript.TypeUser:
+			// Tool results are also recorded as user lines; ExtractUserContent
+			// only returns text blocks, so those are skipped here.
+			if text := transcript.ExtractUserContent(line.Message); text != "" {
+				entries = append(entries, exportEntry{Role: exportRoleUser, Text: text})
+			}
+		case transcript.TypeAssistant:
+			var msg transcript.AssistantMessage
+			if err := json.Unmarshal(line.Message, &msg); err != nil {
+				continue
+			}
+			for _, block := range msg.Content {
+				switch block.Type {
+				case transcript.ContentTypeText:
+					if block.Text != "" {
+						entries = append(entries, exportEntry{Role: exportRoleAssistant, Text: block.Text})
+					}
+				case transcript.ContentTypeToolUse:
+					var input map[string]any
+					_ = json.Unmarshal(block.Input, &input) //nolint:errcheck // Best-effort parsing
+					entries = append(entries, exportEntry{
+						Role: exportRoleTool,
+						Tool: &exportToolCall{Name: block.Name, Input: input},
+					})
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+func parseGeminiTranscriptForExport(content []byte) ([]exportEntry, error) {
+	parsed, err := geminicli.ParseTranscript(content)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already descriptive
+	}
+
+	var entries []exportEntry
+	for _, msg := range parsed.Messages {
+		switch msg.Type {
+		case geminicli.MessageTypeUser:
+			if msg.Content != "" {
+				entries = append(entries, exportEntry{Role: exportRoleUser, Text: msg.Content})
+			}
+		case geminicli.MessageTypeGemini:
+			if msg.Content != "" {
+				entries = append(entries, exportEntry{Role: exportRoleAssistant, Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				entries = append(entries, exportEntry{
+					Role: exportRoleTool,
+					Tool: &exportToolCall{Name: call.Name, Input: call.Args},
+				})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// parseAiderTranscriptForExport maps Aider chat messages to export entries.
+// Aider's tool output is informational except for edits, which become tool
+// entries so the export lists the files touched.
+func parseAiderTranscriptForExport(content []byte) []exportEntry {
+	var entries []exportEntry
+	for _, chat := range aider.ParseHistory(content) {
+		for _, msg := range chat.Messages() {
+			switch msg.Role {
+			case aider.RoleUser:
+				entries = append(entries, exportEntry{Role: exportRoleUser, Text: msg.Text})
+			case aider.RoleAssistant:
+				entries = append(entries, exportEntry{Role: exportRoleAssistant, Text: msg.Text})
+			case aider.RoleTool:
+				for _, file := range msg.EditedFiles {
+					entries = append(entries, exportEntry{
+						Role: exportRoleTool,
+						Tool: &exportToolCall{Name: "Edit", Input: map[string]any{"file_path": file}},
+					})
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// toolInputString returns the first non-empty string argument among keys.
+func (c *exportToolCall) toolInputString(keys ...string) string {
+	for _, key := range keys {
+		if v, ok := c.Input[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// filePath returns the file the tool operates on, if any.
+func (c *exportToolCall) filePath() string {
+	return c.toolInputString("file_path", "notebook_path", "absolute_path", "path")
+}
+
+// editStrings returns the old/new strings of an edit-style tool call.
+// ok is false when the call is not an edit.
+func (c *exportToolCall) editStrings() (oldText, newText string, ok bool) {
+	oldVal, hasOld := c.Input["old_string"].(string)
+	newVal, hasNew := c.Input["new_string"].(string)
+	if !hasOld || !hasNew {
+		return "", "", false
+	}
+	return oldVal, newVal, true
+}
+
+// summary returns a one-line description of the call (command, pattern, URL, ...).
+func (c *exportToolCall) summary() string {
+	if s := c.filePath(); s != "" {
+		return s
+	}
+	return c.toolInputString("description", "pattern", "url", "query", "skill")
+}
+
+// remainingInput returns the tool input as indented JSON, for tools whose
+// arguments are not rendered specially. Returns "" for empty input.
+func (c *exportToolCall) remainingInput() string {
+	if len(c.Input) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(c.Input, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// unifiedLineDiff renders a line-level diff of oldText → newText with
+// " ", "-" and "+" prefixes, like the body of a unified diff.
+func unifiedLineDiff(oldText, newText string) string {
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lineArray := dmp.DiffLinesToChars(oldText, newText)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lineArray)
+
+	var sb strings.Builder
+	for _, d := range diffs {
+		prefix := " "
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffEqual:
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			sb.WriteString(prefix)
+			sb.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// markdownFence returns a backtick fence longer than any backtick run in content,
+// so code blocks containing ``` still render correctly.
+func markdownFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func writeMarkdownCodeBlock(sb *strings.Builder, lang, content string) {
+	fence := markdownFence(content)
+	sb.WriteString(fence + lang + "\n")
+	sb.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fence + "\n\n")
+}
+
+// renderTranscriptMarkdown renders doc as a Markdown document. Prompts are numbered
+// headings so the document reads as a sequence of turns.
+func renderTranscriptMarkdown(doc exportDocument) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Session %s\n\n", doc.SessionID)
+	writeExportMetadata(&sb, doc, func(key, value string) string {
+		return fmt.Sprintf("- **%s:** %s\n", key, value)
+	})
+	sb.WriteString("\n")
+
+	prompt := 0
+	for _, entry := range doc.Entries {
+		switch entry.Role {
+		case exportRoleUser:
+			prompt++
+			fmt.Fprintf(&sb, "## Prompt %d\n\n", prompt)
+			sb.WriteString(quoteMarkdown(entry.Text))
+			sb.WriteString("\n\n")
+		case exportRoleAssistant:
+			sb.WriteString("**Assistant:**\n\n")
+			sb.WriteString(strings.TrimRight(entry.Text, "\n"))
+			sb.WriteString("\n\n")
+		case exportRoleTool:
+			writeMarkdownToolCall(&sb, entry.Tool)
+		}
+	}
+
+	return sb.String()
+}
+
+func writeMarkdownToolCall(sb *strings.Builder, call *exportToolCall) {
+	fmt.Fprintf(sb, "**Tool: %s**", call.Name)
+	if s := call.summary(); s != "" {
+		fmt.Fprintf(sb, " `%s`", strings.ReplaceAll(s, "`", "'"))
+	}
+	sb.WriteString("\n\n")
+
+	if oldText, newText, ok := call.editStrings(); ok {
+		writeMarkdownCodeBlock(sb, "diff", unifiedLineDiff(oldText, newText))
+		return
+	}
+	if command := call.toolInputString("command"); command != "" {
+		writeMarkdownCodeBlock(sb, "sh", command)
+		return
+	}
+	if content := call.toolInputString("content"); content != "" && call.filePath() != "" {
+		writeMarkdownCodeBlock(sb, "diff", unifiedLineDiff("", content))
+		return
+	}
+	if input := call.remainingInput(); input != "" && call.summary() == "" {
+		writeMarkdownCodeBlock(sb, "json", input)
+	}
+}
+
+// quoteMarkdown renders text as a Markdown blockquote.
+func quoteMarkdown(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeExportMetadata writes the document header fields using format for each row.
+func writeExportMetadata(sb *strings.Builder, doc exportDocument, format func(key, value string) string) {
+	if doc.Agent != "" {
+		sb.WriteString(format("Agent", string(doc.Agent)))
+	}
+	if doc.Source != "" {
+		sb.WriteString(format("Source", doc.Source))
+	}
+	counts := map[exportRole]int{}
+	for _, e := range doc.Entries {
+		counts[e.Role]++
+	}
+	sb.WriteString(format("Prompts", fmt.Sprintf("%d", counts[exportRoleUser])))
+	sb.WriteString(format("Tool calls", fmt.Sprintf("%d", counts[exportRoleTool])))
+	if files := exportFilesTouched(doc.Entries); len(files) > 0 {
+		sb.WriteString(format("Files", strings.Join(files, ", ")))
+	}
+}
+
+// exportFilesTouched returns the sorted, de-duplicated files passed to tools
+// that modify content (edits and whole-file writes).
+func exportFilesTouched(entries []exportEntry) []string {
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.Tool == nil {
+			continue
+		}
+		path := e.Tool.filePath()
+		if path == "" {
+			continue
+		}
+		_, _, isEdit := e.Tool.editStrings()
+		if isEdit || e.Tool.toolInputString("content") != "" {
+			seen[path] = true
+		}
+	}
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+const exportHTMLStyle = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;max-width:960px;margin:2em auto;padding:0 1em;line-height:1.5;color:#1f2328}
+.meta{color:#59636e}
+.prompt{border-left:4px solid #0969da;background:#f6f8fa;padding:.5em 1em;margin:1.5em 0 1em;white-space:pre-wrap}
+.assistant{white-space:pre-wrap;margin:1em 0}
+.tool{margin:.5em 0}
+.tool-name{font-weight:600}
+pre{background:#f6f8fa;padding:.75em;overflow-x:auto;border-radius:6px}
+.add{color:#116329;background:#dafbe1;display:block}
+.del{color:#82071e;background:#ffebe9;display:block}`
+
+// renderTranscriptHTML renders doc as a standalone HTML page. All transcript
+// content is escaped.
+func renderTranscriptHTML(doc exportDocument) string {
+	var sb strings.Builder
+
+	title := html.EscapeString("Session " + doc.SessionID)
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", title, exportHTMLStyle)
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n<ul class=\"meta\">\n", title)
+	writeExportMetadata(&sb, doc, func(key, value string) string {
+		return fmt.Sprintf("<li><strong>%s:</strong> %s</li>\n", html.EscapeString(key), html.EscapeString(value))
+	})
+	sb.WriteString("</ul>\n")
+
+	prompt := 0
+	for _, entry := range doc.Entries {
+		switch entry.Role {
+		case exportRoleUser:
+			prompt++
+			fmt.Fprintf(&sb, "<h2>Prompt %d</h2>\n<div class=\"prompt\">%s</div>\n", prompt, html.EscapeString(entry.Text))
+		case exportRoleAssistant:
+			fmt.Fprintf(&sb, "<div class=\"assistant\">%s</div>\n", html.EscapeString(strings.TrimRight(entry.Text, "\n")))
+		case exportRoleTool:
+			writeHTMLToolCall(&sb, entry.Tool)
+		}
+	}
+
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+func writeHTMLToolCall(sb *strings.Builder, call *exportToolCall) {
+	sb.WriteString("<div class=\"tool\"><span class=\"tool-name\">Tool: ")
+	sb.WriteString(html.EscapeString(call.Name))
+	sb.WriteString("</span>")
+	if s := call.summary(); s != "" {
+		fmt.Fprintf(sb, " <code>%s</code>", html.EscapeString(s))
+	}
+	sb.WriteString("\n")
+
+	switch oldText, newText, isEdit := call.editStrings(); {
+	case isEdit:
+		writeHTMLDiff(sb, unifiedLineDiff(oldText, newText))
+	case call.toolInputString("command") != "":
+		fmt.Fprintf(sb, "<pre>%s</pre>\n", html.EscapeString(call.toolInputString("command")))
+	case call.toolInputString("content") != "" && call.filePath() != "":
+		writeHTMLDiff(sb, unifiedLineDiff("", call.toolInputString("content")))
+	case call.summary() == "" && call.remainingInput() != "":
+		fmt.Fprintf(sb, "<pre>%s</pre>\n", html.EscapeString(call.remainingInput()))
+	}
+	sb.WriteString("</div>\n")
+}
+
+func writeHTMLDiff(sb *strings.Builder, diff string) {
+	sb.WriteString("<pre>")
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		escaped := html.EscapeString(strings.TrimSuffix(line, "\n"))
+		switch line[0] {
+		case '+':
+			fmt.Fprintf(sb, "<span class=\"add\">%s</span>", escaped)
+		case '-':
+			fmt.Fprintf(sb, "<span class=\"del\">%s</span>", escaped)
+		default:
+			sb.WriteString(escaped + "\n")
+		}
+	}
+	sb.WriteString("</pre>\n")
+}
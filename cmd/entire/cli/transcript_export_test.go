@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const exportTestTranscript = `{"type":"user","uuid":"u1","message":{"content":"Fix the greeting"}}
+{"type":"assistant","uuid":"a1","message":{"content":[{"type":"text","text":"I'll update main.go."},{"type":"tool_use","name":"Edit","input":{"file_path":"main.go","old_string":"hello\n","new_string":"hello, world\n"}}]}}
+{"type":"user","uuid":"u2","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+{"type":"assistant","uuid":"a2","message":{"content":[{"type":"tool_use","name":"Bash","input":{"command":"go test ./...","description":"Run tests"}}]}}
+{"type":"user","uuid":"u3","message":{"content":"Now add <b>docs</b>"}}
+{"type":"assistant","uuid":"a3","message":{"content":[{"type":"tool_use","name":"Write","input":{"file_path":"README.md","content":"# Docs\n"}}]}}
+`
+
+func TestParseTranscriptForExport_JSONL(t *testing.T) {
+	t.Parallel()
+
+	entries, err := parseTranscriptForExport([]byte(exportTestTranscript), agent.AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatalf("parseTranscriptForExport() error = %v", err)
+	}
+
+	var roles []exportRole
+	for _, e := range entries {
+		roles = append(roles, e.Role)
+	}
+	want := []exportRole{exportRoleUser, exportRoleAssistant, exportRoleTool, exportRoleTool, exportRoleUser, exportRoleTool}
+	if len(roles) != len(want) {
+		t.Fatalf("got roles %v, want %v", roles, want)
+	}
+	for i := range want {
+		if roles[i] != want[i] {
+			t.Errorf("entry %d: got role %s, want %s", i, roles[i], want[i])
+		}
+	}
+}
+
+func TestParseTranscriptForExport_Gemini(t *testing.T) {
+	t.Parallel()
+
+	content := `{"messages":[
+		{"type":"user","content":"Rename foo"},
+		{"type":"gemini","content":"Done.","toolCalls":[{"id":"1","name":"replace","args":{"file_path":"a.go","old_string":"foo","new_string":"bar"}}]}
+	]}`
+
+	entries, err := parseTranscriptForExport([]byte(content), agent.AgentTypeGemini)
+	if err != nil {
+		t.Fatalf("parseTranscriptForExport() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[2].Tool == nil || entries[2].Tool.Name != "replace" {
+		t.Errorf("expected replace tool call, got %+v", entries[2])
+	}
+}
+
+func TestRenderTranscriptMarkdown(t *testing.T) {
+	t.Parallel()
+
+	entries, err := parseTranscriptForExport([]byte(exportTestTranscript), agent.AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatalf("parseTranscriptForExport() error = %v", err)
+	}
+	out := renderTranscriptMarkdown(exportDocument{
+		SessionID: "2026-01-01-abc",
+		Agent:     agent.AgentTypeClaudeCode,
+		Entries:   entries,
+	})
+
+	for _, want := range []string{
+		"# Session 2026-01-01-abc",
+		"- **Agent:** Claude Code",
+		"- **Prompts:** 2",
+		"- **Files:** README.md, main.go",
+		"## Prompt 1\n\n> Fix the greeting",
+		"## Prompt 2",
+		"**Tool: Edit** `main.go`",
+		"```diff\n-hello\n+hello, world\n```",
+		"```sh\ngo test ./...\n```",
+		"+# Docs",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown output missing %q\n---\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderTranscriptHTML_EscapesContent(t *testing.T) {
+	t.Parallel()
+
+	entries, err := parseTranscriptForExport([]byte(exportTestTranscript), agent.AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatalf("parseTranscriptForExport() error = %v", err)
+	}
+	out := renderTranscriptHTML(exportDocument{SessionID: "s1", Entries: entries})
+
+	if strings.Contains(out, "<b>docs</b>") {
+		t.Error("HTML output should escape transcript content")
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"Now add &lt;b&gt;docs&lt;/b&gt;",
+		`<span class="del">-hello</span>`,
+		`<span class="add">+hello, world</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML output missing %q", want)
+		}
+	}
+}
+
+func TestMarkdownFence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"plain", "```"},
+		{"has ``` fence", "````"},
+		{"has ````` long fence", "``````"},
+	}
+	for _, tt := range tests {
+		if got := markdownFence(tt.content); got != tt.want {
+			t.Errorf("markdownFence(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestUnifiedLineDiff(t *testing.T) {
+	t.Parallel()
+
+	got := unifiedLineDiff("a\nb\nc\n", "a\nB\nc\n")
+	want := " a\n-b\n+B\n c\n"
+	if got != want {
+		t.Errorf("unifiedLineDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestRunTranscriptExport_Range(t *testing.T) {
+	repo, initial := setupCleanTestRepo(t)
+	sessionID := "2026-10-14-export-range"
+	store := checkpoint.NewGitStore(repo)
+
+	// Each checkpoint stores the cumulative transcript and where its own part starts
+	firstTurn := `{"type":"user","uuid":"u1","message":{"content":"Fix the greeting"}}
+{"type":"assistant","uuid":"a1","message":{"content":[{"type":"text","text":"Fixed."}]}}
+`
+	secondTurn := `{"type":"user","uuid":"u2","message":{"content":"Now add docs"}}
+{"type":"assistant","uuid":"a2","message":{"content":[{"type":"text","text":"Added."}]}}
+`
+	parent := initial
+	for i, cp := range []struct {
+		id         string
+		transcript string
+		start      int
+	}{
+		{"e1e2e3e4e5e6", firstTurn, 0},
+		{"f1f2f3f4f5f6", firstTurn + secondTurn, 2},
+	} {
+		if err := store.WriteCommitted(context.Background(), checkpoint.WriteCommittedOptions{
+			CheckpointID:              id.MustCheckpointID(cp.id),
+			SessionID:                 sessionID,
+			Strategy:                  "manual-commit",
+			Agent:                     agent.AgentTypeClaudeCode,
+			Transcript:                []byte(cp.transcript),
+			CheckpointTranscriptStart: cp.start,
+		}); err != nil {
+			t.Fatalf("WriteCommitted(%d) error = %v", i, err)
+		}
+		parent = storeRangeTestCommit(t, repo, cp.id, parent)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), parent)); err != nil {
+		t.Fatalf("failed to move master: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("master"))); err != nil {
+		t.Fatalf("failed to set HEAD: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runTranscriptExport(context.Background(), &buf, sessionID, exportFormatMarkdown, "HEAD~1.."); err != nil {
+		t.Fatalf("runTranscriptExport() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Now add docs") || strings.Contains(out, "Fix the greeting") {
+		t.Errorf("export of HEAD~1.. should only have the second turn:\n%s", out)
+	}
+	if !strings.Contains(out, "checkpoint f1f2f3f4f5f6 in HEAD~1..") {
+		t.Errorf("export doesn't name the range as its source:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runTranscriptExport(context.Background(), &buf, sessionID, exportFormatMarkdown, "HEAD~2.."); err != nil {
+		t.Fatalf("runTranscriptExport() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Fix the greeting") || !strings.Contains(out, "Now add docs") {
+		t.Errorf("export of HEAD~2.. should have both turns:\n%s", out)
+	}
+
+	if err := runTranscriptExport(context.Background(), &buf, sessionID, exportFormatMarkdown, "HEAD.."); err == nil {
+		t.Error("expected error for a range without checkpoints of the session")
+	}
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/redact"
+
+	"github.com/spf13/cobra"
+)
+
+// Stored files a transcript scan reports findings in.
+const (
+	scanFileTranscript = "transcript"
+	scanFilePrompts    = "prompts"
+	scanFileContext    = "context"
+)
+
+// transcriptScanFinding is a potential secret in a stored checkpoint.
+type transcriptScanFinding struct {
+	CheckpointID id.CheckpointID `json:"checkpoint_id"`
+	SessionID    string          `json:"session_id"`
+	File         string          `json:"file"`
+	redact.Finding
+}
+
+// transcriptScanReport is the result of scanning all stored transcripts.
+type transcriptScanReport struct {
+	Checkpoints int                     `json:"checkpoints"`
+	Sessions    int                     `json:"sessions"`
+	Findings    []transcriptScanFinding `json:"findings"`
+}
+
+func newTranscriptScanCmd() *cobra.Command {
+	var sessionFlag string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Audit stored transcripts for secrets",
+		Long: `Scans the transcripts, prompts and context of committed checkpoints for
+secrets, using the same rules that redact them before they are stored: known
+formats (API tokens, private keys, credentials in URLs and config lines) and
+high-entropy strings, minus the redaction allowlist in settings.
+
+New checkpoints are redacted when they are written, so findings usually come
+from checkpoints written by older versions or before a rule or allowlist
+change. Only a masked preview of each secret is printed.
+
+Exits with an error if anything is found, so it can run in CI.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runTranscriptScan(cmd.Context(), cmd.OutOrStdout(), sessionFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionFlag, "session", "", "Only scan transcripts of this session")
+	registerFlagCompletion(cmd, "session", completeSessionIDs)
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output findings as JSON")
+
+	return cmd
+}
+
+func runTranscriptScan(ctx context.Context, w io.Writer, sessionID string, jsonOutput bool) error {
+	repo, err := openRepository()
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	report, err := scanStoredTranscripts(ctx, checkpoint.NewGitStore(repo), sessionID)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal scan report: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write scan report: %w", err)
+		}
+	} else {
+		printTranscriptScan(w, report)
+	}
+
+	if len(report.Findings) > 0 {
+		return NewSilentError(fmt.Errorf("found %d potential secret(s) in stored transcripts", len(report.Findings)))
+	}
+	return nil
+}
+
+// scanStoredTranscripts scans every session of every committed checkpoint,
+// oldest first. A non-empty sessionID limits the scan to that session.
+func scanStoredTranscripts(ctx context.Context, store *checkpoint.GitStore, sessionID string) (*transcriptScanReport, error) {
+	committed, err := store.ListCommitted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	sort.SliceStable(committed, func(i, j int) bool {
+		return committed[i].CreatedAt.Before(committed[j].CreatedAt)
+	})
+
+	report := &transcriptScanReport{Findings: []transcriptScanFinding{}}
+	for _, info := range committed {
+		summary, err := store.ReadCommitted(ctx, info.CheckpointID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint %s: %w", info.CheckpointID, err)
+		}
+		if summary == nil {
+			continue
+		}
+
+		scanned := false
+		for i := range summary.Sessions {
+			content, err := store.ReadSessionContent(ctx, info.CheckpointID, i)
+			if errors.Is(err, checkpoint.ErrCheckpointNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read checkpoint %s: %w", info.CheckpointID, err)
+			}
+			if sessionID != "" && content.Metadata.SessionID != sessionID {
+				continue
+			}
+			scanned = true
+			report.Sessions++
+			report.Findings = appendScanFindings(report.Findings, info.CheckpointID, content)
+		}
+		if scanned {
+			report.Checkpoints++
+		}
+	}
+	return report, nil
+}
+
+func appendScanFindings(findings []transcriptScanFinding, cpID id.CheckpointID, content *checkpoint.SessionContent) []transcriptScanFinding {
+	add := func(file string, found []redact.Finding) {
+		for _, f := range found {
+			findings = append(findings, transcriptScanFinding{
+				CheckpointID: cpID,
+				SessionID:    content.Metadata.SessionID,
+				File:         file,
+				Finding:      f,
+			})
+		}
+	}
+	add(scanFileTranscript, redact.ScanJSONL(string(content.Transcript)))
+	add(scanFilePrompts, redact.Scan(content.Prompts))
+	add(scanFileContext, redact.Scan(content.Context))
+	return findings
+}
+
+func printTranscriptScan(w io.Writer, report *transcriptScanReport) {
+	for _, f := range report.Findings {
+		fmt.Fprintf(w, "%s  %s  %s:%d  %s  %s\n", f.CheckpointID, f.SessionID, f.File, f.Line, f.Rule, f.Preview)
+	}
+	if len(report.Findings) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Scanned %d session(s) in %d checkpoint(s): ", report.Sessions, report.Checkpoints)
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(w, "no secrets found")
+		return
+	}
+	fmt.Fprintf(w, "%d potential secret(s) found\n", len(report.Findings))
+	fmt.Fprintln(w, "Add false positives to redaction.allowlist in .entire/settings.json.")
+}
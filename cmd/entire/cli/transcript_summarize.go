@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/summarize"
+
+	"github.com/spf13/cobra"
+)
+
+func newTranscriptSummarizeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "summarize <session-id>",
+		Short: "Summarize a session with an LLM",
+		Long: `Summarizes a session's transcript: what it set out to do, what it achieved,
+the key decisions made along the way and the files it changed.
+
+The summary is kept in the session's state, shown by 'entire sessions show',
+and available to commit message templates as .Summary. Running the command
+again replaces it.
+
+The LLM is configured under "summarizer" in .entire/settings.json:
+  claude-cli  the claude CLI (default)
+  anthropic   the Anthropic API, with the key in ANTHROPIC_API_KEY
+  openai      an OpenAI-compatible endpoint (base_url), e.g. a local Ollama
+  command     your own command: reads the prompt on stdin, prints the summary JSON`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeSessionIDs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runTranscriptSummarize(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+// runTranscriptSummarize summarizes a session's transcript with the
+// configured generator and stores the summary in the session's state.
+func runTranscriptSummarize(ctx context.Context, w io.Writer, sessionID string) error {
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	content, _, source, err := loadTranscriptForExport(ctx, sessionID, "")
+	if err != nil {
+		return err
+	}
+	var files []string
+	if state != nil {
+		files = state.FilesTouched
+	}
+
+	generator, err := summarize.ConfiguredGenerator()
+	if err != nil {
+		return fmt.Errorf("failed to set up the summarizer: %w", err)
+	}
+	fmt.Fprintf(w, "Summarizing session %s from the %s...\n", sessionID, source)
+	generated, err := summarize.GenerateFromTranscript(ctx, content, files, generator)
+	if err != nil {
+		return fmt.Errorf("failed to summarize session %s: %w", sessionID, err)
+	}
+
+	summary := &session.Summary{
+		GeneratedAt:  time.Now().UTC(),
+		Generator:    configuredSummarizerName(),
+		Intent:       generated.Intent,
+		Outcome:      generated.Outcome,
+		Decisions:    generated.Decisions,
+		OpenItems:    generated.OpenItems,
+		FilesChanged: files,
+	}
+	fmt.Fprintln(w)
+	printSessionSummary(w, summary)
+	var details strings.Builder
+	formatSummaryDetails(&details, generated)
+	fmt.Fprint(w, details.String())
+
+	if state == nil {
+		fmt.Fprintf(w, "\nSession %s has no state in this repository, so the summary wasn't stored.\n", sessionID)
+		return nil
+	}
+	// Hooks may have updated the session during the call; only set the summary
+	if err := strategy.UpdateSessionState(sessionID, func(s *session.State) error {
+		s.Summary = summary
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sessionID, err)
+	}
+	fmt.Fprintln(w, "\n✓ Summary saved with the session")
+	return nil
+}
+
+// configuredSummarizerName names the configured summarizer provider.
+func configuredSummarizerName() string {
+	s, err := settings.Load()
+	if err != nil {
+		return ""
+	}
+	provider, err := s.Summarizer.EffectiveProvider()
+	if err != nil {
+		return ""
+	}
+	return provider
+}
+
+// printSessionSummary prints the intent, outcome and files of a summary.
+// The decisions are printed by formatSummaryDetails.
+func printSessionSummary(w io.Writer, summary *session.Summary) {
+	if summary.Intent != "" {
+		fmt.Fprintf(w, "Intent:  %s\n", summary.Intent)
+	}
+	if summary.Outcome != "" {
+		fmt.Fprintf(w, "Outcome: %s\n", summary.Outcome)
+	}
+	if len(summary.FilesChanged) > 0 {
+		fmt.Fprintf(w, "Files:   %s\n", strings.Join(summary.FilesChanged, ", "))
+	}
+}
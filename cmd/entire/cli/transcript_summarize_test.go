@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestRunTranscriptSummarize(t *testing.T) {
+	setupCleanTestRepo(t)
+	const sessionID = "2026-10-14-parser"
+	transcriptPath := filepath.Join(t.TempDir(), "parser.jsonl")
+	if err := os.WriteFile(transcriptPath, []byte(`{"type":"user","message":{"content":"add a config parser"}}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.SaveSessionState(&strategy.SessionState{
+		SessionID:      sessionID,
+		BaseCommit:     "0000001",
+		StartedAt:      time.Now(),
+		TranscriptPath: transcriptPath,
+		FilesTouched:   []string{"config/parse.go"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// A hook saves the session while the summarizer runs
+	hookState, err := strategy.LoadSessionState(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookState.StepCount = 3
+	hookStateJSON, err := json.Marshal(hookState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookStatePath := filepath.Join(t.TempDir(), "hook-state.json")
+	if err := os.WriteFile(hookStatePath, hookStateJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	statePath, err := filepath.Abs(filepath.Join(".git", session.SessionStateDirName, sessionID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The summarizer command gets the prompt, with the files, on stdin
+	responsePath := filepath.Join(t.TempDir(), "summary.json")
+	response := `{"intent":"Add a config parser","outcome":"TOML parsing works","decisions":["Use TOML over YAML"],"learnings":{"repo":[],"code":[],"workflow":[]},"friction":[],"open_items":["Validate keys"]}`
+	if err := os.WriteFile(responsePath, []byte(response), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	settingsJSON, err := json.Marshal(map[string]any{"summarizer": map[string]string{
+		"provider": "command",
+		"command": "grep -q config/parse.go && cp '" + filepath.ToSlash(hookStatePath) + "' '" + filepath.ToSlash(statePath) +
+			"' && cat '" + filepath.ToSlash(responsePath) + "'",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(".entire", 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(".entire", "settings.json"), settingsJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := runTranscriptSummarize(context.Background(), &out, sessionID); err != nil {
+		t.Fatalf("runTranscriptSummarize() error = %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"Intent:  Add a config parser", "Files:   config/parse.go", "Decisions:\n  - Use TOML over YAML", "✓ Summary saved with the session"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := state.Summary; s == nil || s.Outcome != "TOML parsing works" || s.Generator != "command" || len(s.OpenItems) != 1 || len(s.FilesChanged) != 1 {
+		t.Errorf("stored summary = %+v", state.Summary)
+	}
+	if state.StepCount != 3 {
+		t.Errorf("StepCount = %d, want the hook's 3: the summary overwrote the session", state.StepCount)
+	}
+
+	if err := runTranscriptSummarize(context.Background(), &out, "2026-10-14-unknown"); err == nil {
+		t.Error("runTranscriptSummarize() for an unknown session error = nil")
+	}
+}
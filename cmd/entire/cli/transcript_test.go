@@ -639,3 +639,59 @@ not valid json
 		t.Errorf("LastUUID = %q, want 'user-2'", pos.LastUUID)
 	}
 }
+
+func TestGetTranscriptPositionFrom_Bookmark(t *testing.T) {
+	first := `{"type":"user","uuid":"user-1","message":{"content":"Hello"}}
+{"type":"assistant","uuid":"asst-1","message":{"content":[{"type":"text","text":"Hi"}]}}
+`
+	tmpFile := createTempTranscript(t, first)
+	pos, bookmark, err := GetTranscriptPositionFrom(tmpFile, nil)
+	if err != nil || bookmark == nil {
+		t.Fatalf("GetTranscriptPositionFrom() = %+v, %+v, %v", pos, bookmark, err)
+	}
+
+	// Resuming reads only the appended lines
+	appended := first + `{"type":"summary","leafUuid":"leaf-1","summary":"Context"}
+{"type":"user","uuid":"user-2","message":{"content":"Bye"}}
+`
+	if err := os.WriteFile(tmpFile, []byte(appended), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pos, next, err := GetTranscriptPositionFrom(tmpFile, bookmark)
+	if err != nil {
+		t.Fatalf("GetTranscriptPositionFrom() error = %v", err)
+	}
+	if pos.LineCount != 4 || pos.LastUUID != "user-2" || next == nil || next.Offset != int64(len(appended)) {
+		t.Errorf("GetTranscriptPositionFrom() = %+v, %+v; want 4 lines ending at user-2", pos, next)
+	}
+
+	// A truncated transcript is read from the start
+	if err := os.WriteFile(tmpFile, []byte(`{"type":"user","uuid":"user-9","message":{"content":"Again"}}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pos, _, err = GetTranscriptPositionFrom(tmpFile, next)
+	if err != nil || pos.LineCount != 1 || pos.LastUUID != "user-9" {
+		t.Errorf("GetTranscriptPositionFrom(truncated) = %+v, %v; want 1 line ending at user-9", pos, err)
+	}
+}
+
+func TestParseTranscriptFromBookmark(t *testing.T) {
+	content := `{"type":"user","uuid":"user-1","message":{"content":"Hello"}}
+{"type":"assistant","uuid":"asst-1","message":{"content":[{"type":"text","text":"Hi"}]}}
+{"type":"user","uuid":"user-2","message":{"content":"Bye"}}
+`
+	tmpFile := createTempTranscript(t, content)
+	_, bookmark, err := GetTranscriptPositionFrom(tmpFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A bookmark past the start line can't be used; the lines before it are needed
+	lines, total, _, err := parseTranscriptFromBookmark(tmpFile, 1, bookmark)
+	if err != nil {
+		t.Fatalf("parseTranscriptFromBookmark() error = %v", err)
+	}
+	if len(lines) != 2 || lines[0].UUID != "asst-1" || total != 3 {
+		t.Errorf("parseTranscriptFromBookmark() = %d lines (total %d), want asst-1 and user-2 of 3", len(lines), total)
+	}
+}
@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// uiRewindPointsLimit matches the number of points 'entire rewind --to' can
+// restore, so every checkpoint shown in the UI can be restored from it.
+const uiRewindPointsLimit = 20
+
+// uiPane identifies the pane that has keyboard focus.
+type uiPane int
+
+const (
+	uiPaneSessions uiPane = iota
+	uiPaneCheckpoints
+	uiPaneDetail
+)
+
+// uiDetailMode is what the detail pane shows for the selected checkpoint.
+type uiDetailMode int
+
+const (
+	uiDetailDiff uiDetailMode = iota
+	uiDetailTranscript
+)
+
+// uiSession groups the rewind points of one session, newest first.
+type uiSession struct {
+	ID     string
+	Prompt string
+	Points []strategy.RewindPoint
+}
+
+var (
+	uiBorderStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240"))
+	uiFocusStyle   = uiBorderStyle.BorderForeground(lipgloss.Color("212"))
+	uiTitleStyle   = lipgloss.NewStyle().Bold(true)
+	uiCursorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	uiDimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	uiStatusStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
+	uiConfirmStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+)
+
+// uiModel is the bubbletea model behind 'entire ui'.
+type uiModel struct {
+	sessions   []uiSession
+	focus      uiPane
+	sessionIdx int
+	pointIdx   int
+	mode       uiDetailMode
+	detail     viewport.Model
+	width      int
+	height     int
+	status     string
+	confirming bool
+
+	// restore is set when the user confirmed a restore; it runs after the
+	// UI exits so rewind can print its own output and prompts.
+	restore *strategy.RewindPoint
+
+	// loadDetail renders the diff or transcript excerpt of a checkpoint.
+	loadDetail  func(point strategy.RewindPoint, mode uiDetailMode) string
+	detailCache map[string]string
+
+	// clipboard receives the OSC 52 sequence that copies a checkpoint ID.
+	clipboard io.Writer
+}
+
+func newUICmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ui",
+		Short: "Browse sessions, checkpoints and transcripts interactively",
+		Long: `Opens a terminal UI for browsing the captured history of this branch.
+
+Panes show sessions, the checkpoints of the selected session, and the file
+diff or transcript excerpt of the selected checkpoint.
+
+Keys:
+  tab / shift+tab, ←/→   move between panes
+  ↑/↓ or k/j             select, or scroll the detail pane
+  t                      toggle between diff and transcript
+  c                      copy the checkpoint ID to the clipboard
+  r                      restore the checkpoint (same as 'entire rewind --to')
+  q, esc                 quit`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			if !term.IsTerminal(int(os.Stdout.Fd())) {
+				return errors.New("entire ui requires an interactive terminal; use 'entire explain' or 'entire rewind --list' instead")
+			}
+			return runUI(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+}
+
+func runUI(ctx context.Context, w io.Writer) error {
+	points, err := GetStrategy().GetRewindPoints(uiRewindPointsLimit)
+	if err != nil {
+		return fmt.Errorf("failed to find checkpoints: %w", err)
+	}
+	if len(points) == 0 {
+		fmt.Fprintln(w, "No checkpoints found.")
+		fmt.Fprintln(w, "Checkpoints are created automatically when agent sessions end.")
+		return nil
+	}
+
+	m := newUIModel(groupUISessions(points), func(point strategy.RewindPoint, mode uiDetailMode) string {
+		return loadUIDetail(ctx, point, mode)
+	}, os.Stderr)
+	final, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err != nil {
+		return fmt.Errorf("failed to run UI: %w", err)
+	}
+
+	if fm, ok := final.(uiModel); ok && fm.restore != nil {
+		return runRewindToWithOptions(fm.restore.ID, fm.restore.IsLogsOnly, false)
+	}
+	return nil
+}
+
+// groupUISessions groups rewind points by session, most recently active
+// session first. Points without a session ID share one unnamed group.
+func groupUISessions(points []strategy.RewindPoint) []uiSession {
+	var sessions []uiSession
+	index := make(map[string]int)
+	for _, p := range points {
+		i, ok := index[p.SessionID]
+		if !ok {
+			i = len(sessions)
+			index[p.SessionID] = i
+			sessions = append(sessions, uiSession{ID: p.SessionID})
+		}
+		s := &sessions[i]
+		s.Points = append(s.Points, p)
+		if s.Prompt == "" {
+			s.Prompt = p.SessionPrompt
+		}
+	}
+	for i := range sessions {
+		sort.SliceStable(sessions[i].Points, func(a, b int) bool {
+			return sessions[i].Points[a].Date.After(sessions[i].Points[b].Date)
+		})
+	}
+	sort.SliceStable(sessions, func(a, b int) bool {
+		return sessions[a].Points[0].Date.After(sessions[b].Points[0].Date)
+	})
+	return sessions
+}
+
+// uiCheckpointRef is the argument 'checkpoint diff' and 'explain' accept
+// for a rewind point: the checkpoint ID once committed, else the shadow commit.
+func uiCheckpointRef(point strategy.RewindPoint) string {
+	if point.IsLogsOnly && !point.CheckpointID.IsEmpty() {
+		return point.CheckpointID.String()
+	}
+	return point.ID
+}
+
+// loadUIDetail reuses 'entire checkpoint diff' and 'entire explain' so the
+// UI shows exactly what those commands print.
+func loadUIDetail(ctx context.Context, point strategy.RewindPoint, mode uiDetailMode) string {
+	var buf bytes.Buffer
+	ref := uiCheckpointRef(point)
+	var err error
+	if mode == uiDetailTranscript {
+		err = runExplainCheckpoint(&buf, &buf, ref, true, true, false, false, false, false, false)
+	} else {
+		err = runCheckpointDiff(ctx, &buf, &buf, ref, "", diffModePatch)
+	}
+	if err != nil {
+		fmt.Fprintf(&buf, "\nError: %v\n", err)
+	}
+	// The viewport measures tabs as one cell
+	return strings.ReplaceAll(sanitizeForTerminal(buf.String()), "\t", "    ")
+}
+
+func newUIModel(sessions []uiSession, loadDetail func(strategy.RewindPoint, uiDetailMode) string, clipboard io.Writer) uiModel {
+	m := uiModel{
+		sessions:    sessions,
+		detail:      viewport.New(0, 0),
+		loadDetail:  loadDetail,
+		detailCache: make(map[string]string),
+		clipboard:   clipboard,
+	}
+	m.refreshDetail()
+	return m
+}
+
+func (m uiModel) Init() tea.Cmd { return nil }
+
+func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.detail.Width, m.detail.Height = m.detailSize()
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m uiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if m.confirming {
+		m.confirming = false
+		if key == "y" || key == "Y" {
+			point := m.selectedPoint()
+			m.restore = &point
+			return m, tea.Quit
+		}
+		m.status = "Restore cancelled."
+		return m, nil
+	}
+	m.status = ""
+
+	switch key {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "tab", "right", "l", "enter":
+		if m.focus < uiPaneDetail {
+			m.focus++
+		}
+	case "shift+tab", "left", "h":
+		if m.focus > uiPaneSessions {
+			m.focus--
+		}
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup", "pgdown", "ctrl+u", "ctrl+d", "home", "end":
+		if m.focus == uiPaneDetail {
+			var cmd tea.Cmd
+			m.detail, cmd = m.detail.Update(msg)
+			return m, cmd
+		}
+	case "t":
+		if m.mode == uiDetailDiff {
+			m.mode = uiDetailTranscript
+		} else {
+			m.mode = uiDetailDiff
+		}
+		m.refreshDetail()
+	case "c":
+		ref := uiCheckpointRef(m.selectedPoint())
+		if _, err := osc52.New(ref).WriteTo(m.clipboard); err != nil {
+			m.status = fmt.Sprintf("Couldn't copy %s: %v", ref, err)
+		} else {
+			m.status = fmt.Sprintf("Copied %s to the clipboard.", ref)
+		}
+	case "r":
+		m.confirming = true
+	}
+	return m, nil
+}
+
+// move changes the selection in the focused pane by delta, or scrolls the
+// detail pane.
+func (m *uiModel) move(delta int) {
+	switch m.focus {
+	case uiPaneSessions:
+		m.sessionIdx = clampIndex(m.sessionIdx+delta, len(m.sessions))
+		m.pointIdx = 0
+	case uiPaneCheckpoints:
+		m.pointIdx = clampIndex(m.pointIdx+delta, len(m.sessions[m.sessionIdx].Points))
+	case uiPaneDetail:
+		if delta < 0 {
+			m.detail.ScrollUp(-delta)
+		} else {
+			m.detail.ScrollDown(delta)
+		}
+		return
+	}
+	m.refreshDetail()
+}
+
+func clampIndex(i, n int) int {
+	return max(0, min(i, n-1))
+}
+
+func (m uiModel) selectedPoint() strategy.RewindPoint {
+	return m.sessions[m.sessionIdx].Points[m.pointIdx]
+}
+
+// refreshDetail loads the detail pane for the selected checkpoint, caching
+// by checkpoint and mode so moving back and forth stays fast.
+func (m *uiModel) refreshDetail() {
+	point := m.selectedPoint()
+	key := fmt.Sprintf("%s/%d", point.ID, m.mode)
+	content, ok := m.detailCache[key]
+	if !ok {
+		content = m.loadDetail(point, m.mode)
+		m.detailCache[key] = content
+	}
+	m.detail.SetContent(content)
+	m.detail.GotoTop()
+}
+
+// paneWidths splits the terminal width between the three panes, leaving
+// room for their borders.
+func (m uiModel) paneWidths() (int, int, int) {
+	const border = 2
+	list := max(20, m.width/4)
+	detail := max(20, m.width-2*list-3*border)
+	return list, list, detail
+}
+
+func (m uiModel) detailSize() (int, int) {
+	_, _, w := m.paneWidths()
+	// Border, title and footer lines
+	return w, max(1, m.height-5)
+}
+
+func (m uiModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+	sessionsW, pointsW, detailW := m.paneWidths()
+	_, bodyH := m.detailSize()
+
+	var sessionLines []string
+	for i, s := range m.sessions {
+		label := s.Prompt
+		if label == "" {
+			label = s.ID
+		}
+		if label == "" {
+			label = "(no session)"
+		}
+		sessionLines = append(sessionLines, m.listLine(i == m.sessionIdx, fmt.Sprintf("%s (%d)", label, len(s.Points)), sessionsW))
+	}
+
+	var pointLines []string
+	for i, p := range m.sessions[m.sessionIdx].Points {
+		ref := uiCheckpointRef(p)
+		if len(ref) > 7 && !p.IsLogsOnly {
+			ref = ref[:7]
+		}
+		line := fmt.Sprintf("%s %s %s", p.Date.Format("01-02 15:04"), ref, p.Message)
+		pointLines = append(pointLines, m.listLine(i == m.pointIdx, line, pointsW))
+	}
+
+	modeLabel := "Diff"
+	if m.mode == uiDetailTranscript {
+		modeLabel = "Transcript"
+	}
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top,
+		m.pane(uiPaneSessions, "Sessions", strings.Join(scrollToCursor(sessionLines, m.sessionIdx, bodyH), "\n"), sessionsW, bodyH),
+		m.pane(uiPaneCheckpoints, "Checkpoints", strings.Join(scrollToCursor(pointLines, m.pointIdx, bodyH), "\n"), pointsW, bodyH),
+		m.pane(uiPaneDetail, modeLabel, m.detail.View(), detailW, bodyH),
+	)
+
+	footer := uiDimStyle.Render("tab/←→ panes · ↑↓ select · t diff/transcript · c copy ID · r restore · q quit")
+	switch {
+	case m.confirming:
+		footer = uiConfirmStyle.Render(fmt.Sprintf("Restore checkpoint %s? This may overwrite files in the working tree. (y/N)", uiCheckpointRef(m.selectedPoint())))
+	case m.status != "":
+		footer = uiStatusStyle.Render(m.status)
+	}
+	return panes + "\n" + footer
+}
+
+func (m uiModel) pane(p uiPane, title, body string, width, height int) string {
+	style := uiBorderStyle
+	if m.focus == p {
+		style = uiFocusStyle
+	}
+	content := uiTitleStyle.Render(title) + "\n" + lipgloss.NewStyle().MaxHeight(height).Render(body)
+	return style.Width(width).Height(height + 1).Render(content)
+}
+
+func (m uiModel) listLine(selected bool, text string, width int) string {
+	text = strings.ReplaceAll(sanitizeForTerminal(text), "\n", " ")
+	if runes := []rune(text); len(runes) > width-2 {
+		text = string(runes[:max(0, width-3)]) + "…"
+	}
+	if selected {
+		return uiCursorStyle.Render("> " + text)
+	}
+	return "  " + text
+}
+
+// scrollToCursor returns the window of lines that keeps the cursor visible.
+func scrollToCursor(lines []string, cursor, height int) []string {
+	if len(lines) <= height {
+		return lines
+	}
+	start := max(0, min(cursor-height/2, len(lines)-height))
+	return lines[start : start+height]
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint/id"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testUIPoints() []strategy.RewindPoint {
+	base := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	return []strategy.RewindPoint{
+		{ID: "aaa1111", SessionID: "s1", SessionPrompt: "add login", Date: base, Message: "first"},
+		{ID: "bbb2222", SessionID: "s2", SessionPrompt: "fix tests", Date: base.Add(time.Hour), Message: "second"},
+		{ID: "ccc3333", SessionID: "s1", Date: base.Add(2 * time.Hour), Message: "third", IsLogsOnly: true, CheckpointID: id.MustCheckpointID("a1b2c3d4e5f6")},
+	}
+}
+
+func TestGroupUISessions(t *testing.T) {
+	sessions := groupUISessions(testUIPoints())
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+	if sessions[0].ID != "s1" || sessions[0].Prompt != "add login" {
+		t.Errorf("most recent session = %+v, want s1 with its prompt", sessions[0])
+	}
+	if got := sessions[0].Points; len(got) != 2 || got[0].ID != "ccc3333" || got[1].ID != "aaa1111" {
+		t.Errorf("s1 points aren't newest first: %+v", got)
+	}
+}
+
+func TestUIModel_Keys(t *testing.T) {
+	var loaded []string
+	var clip bytes.Buffer
+	m := newUIModel(groupUISessions(testUIPoints()), func(p strategy.RewindPoint, mode uiDetailMode) string {
+		loaded = append(loaded, p.ID)
+		return "detail of " + p.ID
+	}, &clip)
+
+	press := func(keys ...string) {
+		t.Helper()
+		for _, k := range keys {
+			var msg tea.KeyMsg
+			switch k {
+			case "tab":
+				msg = tea.KeyMsg{Type: tea.KeyTab}
+			case "down":
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			default:
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			}
+			next, _ := m.Update(msg)
+			m = next.(uiModel) //nolint:errcheck // Update always returns uiModel
+		}
+	}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = next.(uiModel) //nolint:errcheck // Update always returns uiModel
+
+	if got := m.selectedPoint().ID; got != "ccc3333" {
+		t.Fatalf("initial selection = %s, want ccc3333", got)
+	}
+
+	// Selecting the next session resets the checkpoint selection
+	press("down")
+	if got := m.selectedPoint().ID; got != "bbb2222" {
+		t.Errorf("after down in sessions, selection = %s, want bbb2222", got)
+	}
+	press("k", "tab", "down")
+	if got := m.selectedPoint().ID; got != "aaa1111" {
+		t.Errorf("after down in checkpoints, selection = %s, want aaa1111", got)
+	}
+
+	// Details are cached per checkpoint and mode
+	loadedBefore := len(loaded)
+	press("k", "j")
+	if len(loaded) != loadedBefore {
+		t.Errorf("revisiting checkpoints reloaded them: %v", loaded[loadedBefore:])
+	}
+	press("t")
+	if m.mode != uiDetailTranscript || len(loaded) != loadedBefore+1 {
+		t.Errorf("t didn't load the transcript (mode %d, loads %v)", m.mode, loaded)
+	}
+
+	press("k", "c")
+	if !strings.Contains(clip.String(), "\x1b]52;c;") || !strings.Contains(m.status, "a1b2c3d4e5f6") {
+		t.Errorf("copy wrote %q, status %q; want an OSC 52 sequence for the checkpoint ID", clip.String(), m.status)
+	}
+	if view := m.View(); !strings.Contains(view, "Copied a1b2c3d4e5f6") || !strings.Contains(view, "Sessions") {
+		t.Errorf("view is missing the status or panes:\n%s", view)
+	}
+
+	press("r", "n")
+	if m.restore != nil || m.status != "Restore cancelled." {
+		t.Errorf("declined restore: restore = %v, status = %q", m.restore, m.status)
+	}
+	press("r")
+	if !strings.Contains(m.View(), "Restore checkpoint a1b2c3d4e5f6?") {
+		t.Error("restore doesn't ask for confirmation")
+	}
+	press("y")
+	if m.restore == nil || m.restore.ID != "ccc3333" {
+		t.Errorf("confirmed restore = %+v, want ccc3333", m.restore)
+	}
+}
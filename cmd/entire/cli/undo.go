@@ -0,0 +1,372 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/entireio/cli/cmd/entire/cli/validation"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/spf13/cobra"
+)
+
+// errUndoConflicts is returned by `entire undo` when edits made since the
+// checkpoint overlap its changes. Nothing is written then.
+var errUndoConflicts = NewSilentError(errors.New("undo conflicts with later edits"))
+
+// What `entire undo` does with each file the checkpoint changed.
+const (
+	undoActionRevert   = "revert"   // the file is as the checkpoint left it
+	undoActionMerge    = "merge"    // later edits are kept around the reverted lines
+	undoActionConflict = "conflict" // later edits overlap the checkpoint's changes
+	undoActionNone     = "none"     // already as it was before the checkpoint
+)
+
+func newUndoCmd() *cobra.Command {
+	var dryRunFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Revert the changes of the most recent checkpoint",
+		Long: `Reverts exactly the code changes of the most recent checkpoint of the
+sessions on the current commit, usually the agent's last turn, and leaves
+everything else in the working tree alone.
+
+Each file the checkpoint changed is put back as it was before the checkpoint.
+If you edited the file since, your edits are kept: the checkpoint's changes
+are taken out with a three-way merge (git merge-file). When your edits overlap
+the checkpoint's changes, nothing is written and the conflicting files are
+listed; use 'entire rewind' to restore the checkpoint before in full.
+
+The checkpoint itself and the session transcript are kept, so 'entire rewind'
+can return to the undone state, and the next checkpoint records the revert.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runUndo(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), dryRunFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would be reverted without changing any files")
+
+	return cmd
+}
+
+// undoFile is the plan for one file the checkpoint changed.
+type undoFile struct {
+	Path   string
+	Action string
+	Reason string
+
+	// Content and Mode are what the file becomes; Delete removes it.
+	Content []byte
+	Mode    filemode.FileMode
+	Delete  bool
+}
+
+func runUndo(ctx context.Context, w, errW io.Writer, dryRun bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+	point, err := latestUndoPoint(GetStrategy())
+	if err != nil {
+		return err
+	}
+	if state, err := strategy.LoadSessionState(point.SessionID); err == nil && state != nil && state.Phase.IsActive() {
+		return fmt.Errorf("session %s is in the middle of a turn; wait for the agent to finish before undoing", point.SessionID)
+	}
+
+	commit, err := repo.CommitObject(plumbing.NewHash(point.ID))
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint %s: %w", point.ID[:7], err)
+	}
+	after, err := treeSnapshot(commit)
+	if err != nil {
+		return err
+	}
+	var before diffSnapshot
+	parent, err := diffCheckpointParent(repo, commit)
+	if err != nil {
+		return err
+	}
+	if parent != nil {
+		if before, err = treeSnapshot(parent); err != nil {
+			return err
+		}
+	}
+
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get repo root: %w", err)
+	}
+	plan, err := planUndo(ctx, repoRoot, before, after)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Undoing checkpoint %s: %s\n", point.ID[:7], point.Message)
+	if len(plan) == 0 {
+		fmt.Fprintln(w, "The checkpoint changed no files.")
+		return nil
+	}
+	conflicts := 0
+	for _, f := range plan {
+		switch f.Action {
+		case undoActionRevert:
+			fmt.Fprintf(w, "  reverted  %s\n", f.Path)
+		case undoActionMerge:
+			fmt.Fprintf(w, "  merged    %s (your later edits are kept)\n", f.Path)
+		case undoActionNone:
+			fmt.Fprintf(w, "  unchanged %s (already as before the checkpoint)\n", f.Path)
+		case undoActionConflict:
+			conflicts++
+			fmt.Fprintf(w, "  conflict  %s: %s\n", f.Path, f.Reason)
+		}
+	}
+	if conflicts > 0 {
+		fmt.Fprintf(errW, "\nNothing was changed: %d file(s) were edited since the checkpoint where it changed them.\n", conflicts)
+		fmt.Fprintf(errW, "Resolve them by hand, or restore the checkpoint before with 'entire rewind'.\n")
+		return errUndoConflicts
+	}
+	if dryRun {
+		fmt.Fprintln(w, "\nDry run: no files were changed.")
+		return nil
+	}
+	if err := writeWorktreeFiles(repoRoot, plan); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nUndone. Run 'entire rewind --to %s' to bring the changes back.\n", point.ID[:7])
+	return nil
+}
+
+// latestUndoPoint returns the most recent checkpoint with file contents.
+func latestUndoPoint(start strategy.Strategy) (*strategy.RewindPoint, error) {
+	points, err := start.GetRewindPoints(20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find checkpoints: %w", err)
+	}
+	for _, p := range points {
+		if p.IsLogsOnly {
+			// Committed: the changes are part of a commit, revert that instead
+			continue
+		}
+		if p.IsMetadataOnly {
+			return nil, fmt.Errorf("checkpoint %s: %w", p.ID[:7], strategy.ErrMetadataOnlyCheckpoint)
+		}
+		point := p
+		return &point, nil
+	}
+	return nil, errors.New("no checkpoint to undo on the current commit; committed changes can be reverted with git revert")
+}
+
+// planUndo decides, for each file that differs between before and after,
+// how to take the checkpoint's change out of the working tree.
+func planUndo(ctx context.Context, repoRoot string, before, after diffSnapshot) ([]undoFile, error) {
+	names := make([]string, 0, len(after))
+	for name, a := range after {
+		if b, ok := before[name]; !ok || b.hash != a.hash || b.mode != a.mode {
+			names = append(names, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	plan := make([]undoFile, 0, len(names))
+	for _, name := range names {
+		f, err := planUndoFile(ctx, repoRoot, name, before, after)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, f)
+	}
+	return plan, nil
+}
+
+func planUndoFile(ctx context.Context, repoRoot, name string, before, after diffSnapshot) (undoFile, error) {
+	f := undoFile{Path: name}
+	b, hadBefore := before[name]
+	a, hadAfter := after[name]
+
+	current, currentMode, exists, err := readWorktreeFile(filepath.Join(repoRoot, filepath.FromSlash(name)))
+	if err != nil {
+		return f, err
+	}
+	currentHash := plumbing.ComputeHash(plumbing.BlobObject, current)
+
+	var beforeContent string
+	if hadBefore {
+		if beforeContent, err = b.read(); err != nil {
+			return f, fmt.Errorf("failed to read %s before the checkpoint: %w", name, err)
+		}
+		f.Mode = b.mode
+	}
+	f.Content, f.Delete = []byte(beforeContent), !hadBefore
+
+	switch {
+	case exists == hadBefore && (!exists || (currentHash == b.hash && currentMode == b.mode)):
+		f.Action = undoActionNone
+	case exists == hadAfter && (!exists || (currentHash == a.hash && currentMode == a.mode)):
+		f.Action = undoActionRevert
+	case !exists:
+		f.Action, f.Reason = undoActionConflict, "deleted since the checkpoint"
+	case !hadAfter:
+		f.Action, f.Reason = undoActionConflict, "deleted by the checkpoint and created again since"
+	case !hadBefore:
+		f.Action, f.Reason = undoActionConflict, "created by the checkpoint and edited since"
+	default:
+		afterContent, err := a.read()
+		if err != nil {
+			return f, fmt.Errorf("failed to read %s at the checkpoint: %w", name, err)
+		}
+		if isBinaryContent(string(current)) || isBinaryContent(afterContent) || isBinaryContent(beforeContent) {
+			f.Action, f.Reason = undoActionConflict, "binary file changed since the checkpoint"
+			return f, nil
+		}
+		merged, clean, err := mergeFileContents(ctx, current, []byte(afterContent), []byte(beforeContent))
+		if err != nil {
+			return f, fmt.Errorf("failed to merge %s: %w", name, err)
+		}
+		if !clean {
+			f.Action, f.Reason = undoActionConflict, "edited since the checkpoint where it changed it"
+			return f, nil
+		}
+		f.Action, f.Content = undoActionMerge, merged
+		// A mode change of the checkpoint is undone, one made since is kept
+		f.Mode = currentMode
+		if currentMode == a.mode {
+			f.Mode = b.mode
+		}
+	}
+	return f, nil
+}
+
+// readWorktreeFile reads a file, or a symlink's target, and its git mode.
+func readWorktreeFile(path string) ([]byte, filemode.FileMode, bool, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, filemode.Empty, false, nil
+	}
+	if err != nil {
+		return nil, filemode.Empty, false, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	switch {
+	case info.IsDir():
+		return nil, filemode.Empty, false, nil
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, filemode.Empty, false, fmt.Errorf("failed to read link %s: %w", path, err)
+		}
+		return []byte(target), filemode.Symlink, true, nil
+	}
+	content, err := os.ReadFile(path) //nolint:gosec // path is a checkpoint file in the repo
+	if err != nil {
+		return nil, filemode.Empty, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	mode := filemode.Regular
+	if info.Mode()&0o111 != 0 {
+		mode = filemode.Executable
+	}
+	return content, mode, true, nil
+}
+
+// mergeFileContents takes the change from base to other into current, as
+// git merge-file does. clean is false when the changes overlap.
+func mergeFileContents(ctx context.Context, current, base, other []byte) ([]byte, bool, error) {
+	dir, err := os.MkdirTemp("", "entire-undo-")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := make([]string, 0, 3)
+	for i, content := range [][]byte{current, base, other} {
+		path := filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			return nil, false, fmt.Errorf("failed to write temp file: %w", err)
+		}
+		files = append(files, path)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "merge-file", "-p", "-q", files[0], files[1], files[2]) //nolint:gosec // arguments are temp files
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return stdout.Bytes(), true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128:
+		// The exit code is the number of conflicts
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("git merge-file: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+}
+
+// writeWorktreeFiles writes the reverted and merged files of plan to the
+// working tree.
+func writeWorktreeFiles(repoRoot string, plan []undoFile) error {
+	// Windows would write a file named like a device (aux.c) to the device
+	if runtime.GOOS == "windows" {
+		for _, f := range plan {
+			if err := validation.ValidatePortablePath(f.Path); err != nil {
+				return err //nolint:wrapcheck // already names the path
+			}
+		}
+	}
+	for _, f := range plan {
+		if f.Action != undoActionRevert && f.Action != undoActionMerge {
+			continue
+		}
+		path := filepath.Join(repoRoot, filepath.FromSlash(f.Path))
+		if f.Delete {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to delete %s: %w", f.Path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // G301: user directories in the worktree
+			return fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to replace %s: %w", f.Path, err)
+		}
+		if f.Mode == filemode.Symlink {
+			if err := os.Symlink(string(f.Content), path); err != nil {
+				return fmt.Errorf("failed to restore link %s: %w", f.Path, err)
+			}
+			continue
+		}
+		var perm os.FileMode = 0o644
+		if f.Mode == filemode.Executable {
+			perm = 0o755
+		}
+		if err := os.WriteFile(path, f.Content, perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
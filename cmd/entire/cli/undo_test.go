@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const undoTestBase = "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n"
+
+// setupUndoRepo commits main.go and records a checkpoint of the agent
+// changing it and adding new.go. Returns the repo directory.
+func setupUndoRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	t.Chdir(dir)
+	paths.ClearRepoRootCache()
+	writeUndoFile(t, "main.go", undoTestBase)
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, err := wt.Add("main.go"); err != nil {
+		t.Fatalf("failed to add main.go: %v", err)
+	}
+	head, err := wt.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()}})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	sessionID := "2026-10-14-undo"
+	if err := strategy.SaveSessionState(&strategy.SessionState{
+		SessionID:  sessionID,
+		BaseCommit: head.String(),
+		StartedAt:  time.Now(),
+		Phase:      session.PhaseIdle,
+		StepCount:  1,
+	}); err != nil {
+		t.Fatalf("SaveSessionState() error = %v", err)
+	}
+
+	// The agent's turn: edits a() and adds new.go
+	writeUndoFile(t, "main.go", strings.Replace(undoTestBase, "func a() {}", "func a() { agent() }", 1))
+	writeUndoFile(t, "new.go", "package main\n\nfunc agent() {}\n")
+	metadataDir := filepath.Join(dir, ".entire", "metadata", sessionID)
+	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+		t.Fatalf("failed to create metadata dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(metadataDir, "full.jsonl"), []byte(`{"type":"user"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	if _, err := checkpoint.NewGitStore(repo).WriteTemporary(context.Background(), checkpoint.WriteTemporaryOptions{
+		SessionID:         sessionID,
+		BaseCommit:        head.String(),
+		ModifiedFiles:     []string{"main.go"},
+		NewFiles:          []string{"new.go"},
+		MetadataDir:       ".entire/metadata/" + sessionID,
+		MetadataDirAbs:    metadataDir,
+		CommitMessage:     "Agent turn",
+		AuthorName:        "Test",
+		AuthorEmail:       "test@test.com",
+		IsFirstCheckpoint: true,
+	}); err != nil {
+		t.Fatalf("WriteTemporary() error = %v", err)
+	}
+	return dir
+}
+
+func writeUndoFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func readUndoFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestRunUndo_KeepsLaterEdits(t *testing.T) {
+	setupUndoRepo(t)
+	// The human edits c() after the turn
+	writeUndoFile(t, "main.go", strings.Replace(readUndoFile(t, "main.go"), "func c() {}", "func c() { human() }", 1))
+
+	var out, errOut bytes.Buffer
+	if err := runUndo(context.Background(), &out, &errOut, true); err != nil {
+		t.Fatalf("runUndo(dry run) error = %v\n%s", err, errOut.String())
+	}
+	if _, err := os.Stat("new.go"); err != nil {
+		t.Fatal("dry run deleted new.go")
+	}
+
+	out.Reset()
+	if err := runUndo(context.Background(), &out, &errOut, false); err != nil {
+		t.Fatalf("runUndo() error = %v\n%s", err, errOut.String())
+	}
+	for _, want := range []string{"merged    main.go", "reverted  new.go"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output =\n%s\nwant %q", out.String(), want)
+		}
+	}
+	if got, want := readUndoFile(t, "main.go"), strings.Replace(undoTestBase, "func c() {}", "func c() { human() }", 1); got != want {
+		t.Errorf("main.go =\n%s\nwant\n%s", got, want)
+	}
+	if _, err := os.Stat("new.go"); !os.IsNotExist(err) {
+		t.Error("new.go, added by the checkpoint, should have been deleted")
+	}
+}
+
+func TestRunUndo_Conflict(t *testing.T) {
+	setupUndoRepo(t)
+	// The human rewrites the line the agent changed
+	writeUndoFile(t, "main.go", strings.Replace(undoTestBase, "func a() {}", "func a() { human() }", 1))
+
+	var out, errOut bytes.Buffer
+	err := runUndo(context.Background(), &out, &errOut, false)
+	if !errors.Is(err, errUndoConflicts) {
+		t.Fatalf("runUndo() error = %v, want errUndoConflicts", err)
+	}
+	if !strings.Contains(out.String(), "conflict  main.go") {
+		t.Errorf("output =\n%s\nwant a conflict for main.go", out.String())
+	}
+	// Nothing is written, not even the files without a conflict
+	if _, err := os.Stat("new.go"); err != nil {
+		t.Error("new.go was deleted despite the conflict")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+
 	"github.com/charmbracelet/huh"
 )
 
@@ -38,9 +40,9 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-// copyFile copies a file from src to dst
+// copyFile copies a file from src to dst, decrypting src if it is encrypted
 func copyFile(src, dst string) error {
-	input, err := os.ReadFile(src) //nolint:gosec // Reading from controlled git metadata path
+	input, err := encryption.ReadFile(src)
 	if err != nil {
 		return err //nolint:wrapcheck // already present in codebase
 	}
@@ -49,3 +51,13 @@ func copyFile(src, dst string) error {
 	}
 	return nil
 }
+
+// copyToMetadata copies a file into the session metadata directory,
+// encrypting it if encryption is enabled
+func copyToMetadata(src, dst string) error {
+	input, err := os.ReadFile(src) //nolint:gosec // Reading from controlled transcript path
+	if err != nil {
+		return err //nolint:wrapcheck // already present in codebase
+	}
+	return encryption.WriteFile(dst, input, 0o600) //nolint:wrapcheck // already describes the file
+}
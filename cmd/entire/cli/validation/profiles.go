@@ -0,0 +1,283 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Reasons an ID fails validation. IDError wraps one of them, so callers can
+// branch with errors.Is.
+var (
+	ErrEmptyID           = errors.New("empty ID")
+	ErrInvalidCharacters = errors.New("invalid characters")
+	ErrPathSeparator     = errors.New("contains path separators")
+	ErrReservedName      = errors.New("reserved name")
+	ErrTooLong           = errors.New("too long")
+	ErrNotNormalized     = errors.New("not NFC-normalized")
+	ErrMixedScripts      = errors.New("mixes scripts")
+)
+
+// MaxIDLength caps every ID, whatever its profile: IDs become file names
+// (with suffixes like ".json"), which most file systems cap at 255 bytes.
+const MaxIDLength = 128
+
+// IDError describes an ID that failed validation.
+type IDError struct {
+	// Kind is what the ID is, e.g. "session ID".
+	Kind string
+	// ID is the rejected value.
+	ID string
+	// Reason is one of the Err* reasons above.
+	Reason error
+	// Detail explains the rejection, e.g. "contains path separators".
+	Detail string
+}
+
+func (e *IDError) Error() string {
+	if errors.Is(e.Reason, ErrEmptyID) {
+		return e.Kind + " cannot be empty"
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Kind, e.ID, e.Detail)
+}
+
+func (e *IDError) Unwrap() error {
+	return e.Reason
+}
+
+// IDProfile is the rule set for one kind of ID: what it must match and how
+// long it may be. Whatever the profile, IDs are used in file paths, so they
+// never contain path separators or control characters, are never "." or
+// "..", a Windows device name, or end with a dot or space. A Pattern that
+// admits characters Windows rejects in file names (such as ':') only works
+// for agents that don't run on Windows.
+//
+// IDs also end up in logs and file listings, so none may look like another:
+// they must be NFC-normalized (the same text always has the same bytes),
+// free of invisible formatting characters, and not mix scripts, as a Cyrillic
+// "а" in an otherwise Latin ID would. Latin may mix with the CJK scripts.
+type IDProfile struct {
+	// Pattern is what IDs must match; nil allows any portable file name.
+	Pattern *regexp.Regexp
+	// Requirement describes Pattern in errors, e.g. "must be alphanumeric".
+	Requirement string
+	// MaxLength caps IDs at this many bytes; 0 (or more than MaxIDLength)
+	// means MaxIDLength.
+	MaxLength int
+}
+
+// IDProfiles are the rule sets for the IDs an agent hands to Entire.
+type IDProfiles struct {
+	Session      IDProfile
+	ToolUse      IDProfile
+	Agent        IDProfile
+	AgentSession IDProfile
+}
+
+var pathSafeProfile = IDProfile{
+	Pattern:     pathSafeRegex,
+	Requirement: "must be alphanumeric with underscores/hyphens only",
+}
+
+// DefaultIDProfiles returns the rules for agents that don't bring their own:
+// session IDs can be any portable file name, the other IDs alphanumeric with
+// underscores and hyphens.
+func DefaultIDProfiles() IDProfiles {
+	return IDProfiles{
+		Session:      IDProfile{},
+		ToolUse:      pathSafeProfile,
+		Agent:        pathSafeProfile,
+		AgentSession: pathSafeProfile,
+	}
+}
+
+var (
+	profilesMu    sync.RWMutex
+	agentProfiles = make(map[string]IDProfiles)
+)
+
+// RegisterIDProfiles sets the rules for IDs from agentType (the agent type
+// stored in metadata, e.g. "Claude Code").
+func RegisterIDProfiles(agentType string, profiles IDProfiles) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	agentProfiles[agentType] = profiles
+}
+
+// ProfilesFor returns the rules for IDs from agentType, the defaults if it
+// registered none.
+func ProfilesFor(agentType string) IDProfiles {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	if profiles, ok := agentProfiles[agentType]; ok {
+		return profiles
+	}
+	return DefaultIDProfiles()
+}
+
+// registeredProfiles returns the defaults followed by every agent's rules,
+// in a stable order.
+func registeredProfiles() []IDProfiles {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	agentTypes := make([]string, 0, len(agentProfiles))
+	for agentType := range agentProfiles {
+		agentTypes = append(agentTypes, agentType)
+	}
+	slices.Sort(agentTypes)
+	all := []IDProfiles{DefaultIDProfiles()}
+	for _, agentType := range agentTypes {
+		all = append(all, agentProfiles[agentType])
+	}
+	return all
+}
+
+// validateAny validates id against the rules of every agent, for callers
+// that don't know which agent it came from. Returns the error of the
+// defaults if no agent's rules accept it.
+func validateAny(id string, validate func(IDProfiles, string) error) error {
+	var firstErr error
+	for _, profiles := range registeredProfiles() {
+		err := validate(profiles, id)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ValidateSessionID validates a session ID against these rules.
+func (p IDProfiles) ValidateSessionID(id string) error {
+	return p.Session.validate("session ID", id, true)
+}
+
+// ValidateToolUseID validates a tool use ID against these rules. Empty is
+// allowed (optional field).
+func (p IDProfiles) ValidateToolUseID(id string) error {
+	return p.ToolUse.validate("tool use ID", id, false)
+}
+
+// ValidateAgentID validates a subagent ID against these rules. Empty is
+// allowed (optional field).
+func (p IDProfiles) ValidateAgentID(id string) error {
+	return p.Agent.validate("agent ID", id, false)
+}
+
+// ValidateAgentSessionID validates an agent session ID against these rules.
+func (p IDProfiles) ValidateAgentSessionID(id string) error {
+	return p.AgentSession.validate("agent session ID", id, true)
+}
+
+func (p IDProfile) validate(kind, id string, required bool) error {
+	if id == "" {
+		if !required {
+			return nil
+		}
+		return &IDError{Kind: kind, ID: id, Reason: ErrEmptyID, Detail: "empty"}
+	}
+	reject := func(reason error, detail string) error {
+		return &IDError{Kind: kind, ID: id, Reason: reason, Detail: detail}
+	}
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > MaxIDLength {
+		maxLength = MaxIDLength
+	}
+	if len(id) > maxLength {
+		return reject(ErrTooLong, fmt.Sprintf("longer than %d bytes", maxLength))
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(id) {
+		return reject(ErrInvalidCharacters, p.Requirement)
+	}
+	if strings.ContainsAny(id, "/\\") {
+		return reject(ErrPathSeparator, "contains path separators")
+	}
+	if p.Pattern == nil && strings.ContainsAny(id, windowsInvalidChars) {
+		return reject(ErrInvalidCharacters, fmt.Sprintf("contains a character Windows doesn't allow in file names (%s)", windowsInvalidChars))
+	}
+	if strings.ContainsFunc(id, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return reject(ErrInvalidCharacters, "contains a control character")
+	}
+	if id == "." || id == ".." {
+		return reject(ErrReservedName, "reserved path name")
+	}
+	if IsWindowsReservedName(id) {
+		return reject(ErrReservedName, "reserved name on Windows")
+	}
+	if strings.HasSuffix(id, ".") || strings.HasSuffix(id, " ") {
+		return reject(ErrInvalidCharacters, "ends with a dot or space")
+	}
+	if !norm.NFC.IsNormalString(id) {
+		return reject(ErrNotNormalized, fmt.Sprintf("isn't NFC-normalized (%q is)", norm.NFC.String(id)))
+	}
+	if strings.ContainsFunc(id, func(r rune) bool { return unicode.Is(unicode.Cf, r) }) {
+		return reject(ErrInvalidCharacters, "contains an invisible formatting character")
+	}
+	if first, second := mixedScripts(id); second != "" {
+		return reject(ErrMixedScripts, fmt.Sprintf("mixes %s and %s letters", first, second))
+	}
+	return nil
+}
+
+// cjkScripts may mix with each other and with Latin, as Japanese and Korean
+// text does.
+var cjkScripts = map[string]bool{"Han": true, "Hiragana": true, "Katakana": true, "Hangul": true, "Bopomofo": true}
+
+// mixedScripts returns two scripts whose letters id mixes, empty strings if
+// its letters share a script or are Latin and CJK.
+func mixedScripts(id string) (first, second string) {
+	var scripts []string
+	for _, r := range id {
+		if r < 0x80 {
+			if unicode.IsLetter(r) && !slices.Contains(scripts, "Latin") {
+				scripts = append(scripts, "Latin")
+			}
+			continue
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if script := scriptOf(r); script != "" && !slices.Contains(scripts, script) {
+			scripts = append(scripts, script)
+		}
+	}
+	var others []string
+	for _, script := range scripts {
+		if script != "Latin" && !cjkScripts[script] {
+			others = append(others, script)
+		}
+	}
+	switch {
+	case len(others) == 0:
+		return "", ""
+	case len(scripts) > len(others):
+		// Latin or CJK alongside another script
+		for _, script := range scripts {
+			if !slices.Contains(others, script) {
+				return script, others[0]
+			}
+		}
+	case len(others) > 1:
+		return others[0], others[1]
+	}
+	return "", ""
+}
+
+// scriptOf returns the Unicode script of r, empty for characters shared by
+// scripts (Common, Inherited).
+func scriptOf(r rune) string {
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// dottedProfiles allows the dots and colons of IDs like "run.42:step", up to 32 bytes.
+var dottedProfiles = IDProfiles{
+	Session:      IDProfile{Pattern: regexp.MustCompile(`^[a-z0-9.:-]+$`), Requirement: "must be lowercase alphanumeric with dots, colons and hyphens", MaxLength: 32},
+	ToolUse:      IDProfile{Pattern: regexp.MustCompile(`^[a-z0-9.:-]+$`), Requirement: "must be lowercase alphanumeric with dots, colons and hyphens"},
+	Agent:        pathSafeProfile,
+	AgentSession: pathSafeProfile,
+}
+
+func TestIDProfiles_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		id     string
+		reason error
+	}{
+		{name: "dots and colons", id: "run.42:step"},
+		{name: "empty", id: "", reason: ErrEmptyID},
+		{name: "outside the pattern", id: "Run_42", reason: ErrInvalidCharacters},
+		{name: "too long", id: "run.0123456789.0123456789.0123456789", reason: ErrTooLong},
+		{name: "relative path segment", id: "..", reason: ErrReservedName},
+		{name: "windows device name", id: "nul.txt", reason: ErrReservedName},
+		{name: "trailing dot", id: "run.42.", reason: ErrInvalidCharacters},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := dottedProfiles.ValidateSessionID(tt.id)
+			if tt.reason == nil {
+				if err != nil {
+					t.Errorf("ValidateSessionID(%q) = %v, want nil", tt.id, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.reason) {
+				t.Errorf("ValidateSessionID(%q) = %v, want %v", tt.id, err, tt.reason)
+			}
+			var idErr *IDError
+			if !errors.As(err, &idErr) || idErr.ID != tt.id || idErr.Kind != "session ID" {
+				t.Errorf("ValidateSessionID(%q) = %#v, want an IDError for the session ID", tt.id, err)
+			}
+		})
+	}
+
+	if err := dottedProfiles.ValidateToolUseID(""); err != nil {
+		t.Errorf("ValidateToolUseID(empty) = %v, want nil", err)
+	}
+	if err := DefaultIDProfiles().ValidateToolUseID("run.42:step"); !errors.Is(err, ErrInvalidCharacters) {
+		t.Errorf("default ValidateToolUseID(run.42:step) = %v, want ErrInvalidCharacters", err)
+	}
+	if err := DefaultIDProfiles().ValidateSessionID("a/b"); !errors.Is(err, ErrPathSeparator) {
+		t.Errorf("default ValidateSessionID(a/b) = %v, want ErrPathSeparator", err)
+	}
+}
+
+func TestValidateSessionID_Spoofing(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		id     string
+		reason error
+	}{
+		{name: "cyrillic", id: "сессия-1"},
+		{name: "latin and japanese", id: "session-日本語"},
+		{name: "accented, composed", id: "caf\u00e9"},
+		{name: "at the cap", id: strings.Repeat("a", MaxIDLength)},
+		{name: "over the cap", id: strings.Repeat("a", MaxIDLength+1), reason: ErrTooLong},
+		{name: "accented, decomposed", id: "cafe\u0301", reason: ErrNotNormalized},
+		{name: "zero-width space", id: "ses\u200bsion", reason: ErrInvalidCharacters},
+		{name: "right-to-left override", id: "session\u202eexe", reason: ErrInvalidCharacters},
+		{name: "cyrillic a among latin", id: "p\u0430ypal", reason: ErrMixedScripts},
+		{name: "greek and cyrillic", id: "\u03b1\u0431", reason: ErrMixedScripts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateSessionID(tt.id)
+			if tt.reason == nil {
+				if err != nil {
+					t.Errorf("ValidateSessionID(%q) = %v, want nil", tt.id, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.reason) {
+				t.Errorf("ValidateSessionID(%q) = %v, want %v", tt.id, err, tt.reason)
+			}
+		})
+	}
+
+	// A profile can't raise the cap
+	uncapped := IDProfile{MaxLength: 1000}
+	if err := uncapped.validate("session ID", strings.Repeat("a", MaxIDLength+1), true); !errors.Is(err, ErrTooLong) {
+		t.Errorf("validate(over the cap) = %v, want ErrTooLong", err)
+	}
+	if err := ValidateSessionID("p\u0430ypal"); err == nil || !strings.Contains(err.Error(), "mixes Latin and Cyrillic letters") {
+		t.Errorf("ValidateSessionID(mixed) error = %v, want it to name the scripts", err)
+	}
+}
+
+func TestRegisterIDProfiles(t *testing.T) {
+	const agentType = "Dotted Agent"
+	t.Cleanup(func() {
+		profilesMu.Lock()
+		delete(agentProfiles, agentType)
+		profilesMu.Unlock()
+	})
+
+	if err := ValidateToolUseID("run.42:step"); err == nil {
+		t.Fatal("ValidateToolUseID(run.42:step) = nil before any agent allows it")
+	}
+	RegisterIDProfiles(agentType, dottedProfiles)
+
+	if err := ProfilesFor(agentType).ValidateToolUseID("run.42:step"); err != nil {
+		t.Errorf("ProfilesFor(%q).ValidateToolUseID() = %v, want nil", agentType, err)
+	}
+	if err := ProfilesFor("Claude Code").ValidateToolUseID("run.42:step"); err == nil {
+		t.Error("other agents should keep the default rules")
+	}
+	// Callers that don't know the agent accept what any agent's rules accept
+	if err := ValidateToolUseID("run.42:step"); err != nil {
+		t.Errorf("ValidateToolUseID(run.42:step) = %v, want nil", err)
+	}
+	if err := ValidateToolUseID("run/42"); !errors.Is(err, ErrInvalidCharacters) {
+		t.Errorf("ValidateToolUseID(run/42) = %v, want the default rules' error", err)
+	}
+}
@@ -1,9 +1,8 @@
 // Package validation provides input validation functions for the Entire CLI.
-// This package has no dependencies to avoid import cycles.
+// This package has no dependencies on other Entire packages to avoid import cycles.
 package validation
 
 import (
-	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -13,50 +12,82 @@ import (
 // Used to validate IDs that will be used in file paths.
 var pathSafeRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
-// ValidateSessionID validates that a session ID doesn't contain path separators.
-// This prevents path traversal attacks when session IDs are used in file paths.
-func ValidateSessionID(id string) error {
-	if id == "" {
-		return errors.New("session ID cannot be empty")
+// windowsReservedNames are the device names Windows reserves in every
+// directory, with or without an extension ("NUL", "con.txt").
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// windowsInvalidChars can't appear in Windows file names.
+const windowsInvalidChars = `<>:"|?*`
+
+// IsWindowsReservedName reports whether name is a device name Windows
+// reserves, such as CON or nul.txt.
+func IsWindowsReservedName(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	return windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))]
+}
+
+// ValidatePortableName validates that name can be a file name on every
+// platform: not a Windows device name, no characters Windows rejects, and no
+// trailing dot or space, which Windows strips.
+func ValidatePortableName(name string) error {
+	if IsWindowsReservedName(name) {
+		return fmt.Errorf("%q is a reserved name on Windows", name)
 	}
-	if strings.ContainsAny(id, "/\\") {
-		return fmt.Errorf("invalid session ID %q: contains path separators", id)
+	if strings.ContainsAny(name, windowsInvalidChars) {
+		return fmt.Errorf("%q contains a character Windows doesn't allow in file names (%s)", name, windowsInvalidChars)
+	}
+	for _, r := range name {
+		if r < 0x20 {
+			return fmt.Errorf("%q contains a control character", name)
+		}
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("%q ends with a dot or space", name)
+	}
+	return nil
+}
+
+// ValidatePortablePath validates every component of a slash-separated
+// repository path with ValidatePortableName.
+func ValidatePortablePath(path string) error {
+	for _, name := range strings.Split(path, "/") {
+		if name == "" || name == "." || name == ".." {
+			continue
+		}
+		if err := ValidatePortableName(name); err != nil {
+			return fmt.Errorf("invalid path %s: %w", path, err)
+		}
 	}
 	return nil
 }
 
+// ValidateSessionID validates that a session ID doesn't contain path separators
+// and can be a file name on every platform.
+// This prevents path traversal attacks when session IDs are used in file paths.
+// IDs any registered agent's rules accept are valid; use ProfilesFor when the
+// agent is known.
+func ValidateSessionID(id string) error {
+	return validateAny(id, IDProfiles.ValidateSessionID)
+}
+
 // ValidateToolUseID validates that a tool use ID contains only safe characters for paths.
 // Tool use IDs can be UUIDs or prefixed identifiers like "toolu_xxx".
 func ValidateToolUseID(id string) error {
-	if id == "" {
-		return nil // Empty is allowed (optional field)
-	}
-	if !pathSafeRegex.MatchString(id) {
-		return fmt.Errorf("invalid tool use ID %q: must be alphanumeric with underscores/hyphens only", id)
-	}
-	return nil
+	return validateAny(id, IDProfiles.ValidateToolUseID)
 }
 
 // ValidateAgentID validates that an agent ID contains only safe characters for paths.
 func ValidateAgentID(id string) error {
-	if id == "" {
-		return nil // Empty is allowed (optional field)
-	}
-	if !pathSafeRegex.MatchString(id) {
-		return fmt.Errorf("invalid agent ID %q: must be alphanumeric with underscores/hyphens only", id)
-	}
-	return nil
+	return validateAny(id, IDProfiles.ValidateAgentID)
 }
 
 // ValidateAgentSessionID validates that an agent session ID contains only safe characters for paths.
 // Agent session IDs can be UUIDs (Claude Code), test identifiers, or other formats depending on the agent.
 // This prevents path traversal attacks when the ID is used in file path construction.
 func ValidateAgentSessionID(id string) error {
-	if id == "" {
-		return errors.New("agent session ID cannot be empty")
-	}
-	if !pathSafeRegex.MatchString(id) {
-		return fmt.Errorf("invalid agent session ID %q: must be alphanumeric with underscores/hyphens only", id)
-	}
-	return nil
+	return validateAny(id, IDProfiles.ValidateAgentSessionID)
 }
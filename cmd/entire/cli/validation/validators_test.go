@@ -228,6 +228,9 @@ func TestValidateAgentSessionID(t *testing.T) {
 		// Invalid - other unsafe chars
 		{name: "dot rejected", id: "session.test", wantErr: true},
 		{name: "space rejected", id: "session test", wantErr: true},
+		// Invalid - Windows device names
+		{name: "reserved name", id: "NUL", wantErr: true},
+		{name: "reserved name lowercase", id: "com1", wantErr: true},
 	}
 
 	for _, tt := range tests {
@@ -239,3 +242,34 @@ func TestValidateAgentSessionID(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePortablePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "src/main.go", wantErr: false},
+		{path: "docs/console.md", wantErr: false},
+		{path: "lib/auxiliary/x.c", wantErr: false},
+		{path: "./a/../b.txt", wantErr: false},
+		{path: "aux.c", wantErr: true},
+		{path: "src/NUL", wantErr: true},
+		{path: "drivers/com3.h", wantErr: true},
+		{path: "lpt9 .txt", wantErr: true},
+		{path: "notes/what?.md", wantErr: true},
+		{path: "a:b", wantErr: true},
+		{path: "trailing./x", wantErr: true},
+		{path: "trailing space ", wantErr: true},
+		{path: "tab\there", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := ValidatePortablePath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePortablePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+
+	if err := ValidateSessionID("CON"); err == nil || !strings.Contains(err.Error(), "reserved name on Windows") {
+		t.Errorf("ValidateSessionID(CON) error = %v, want reserved name", err)
+	}
+}
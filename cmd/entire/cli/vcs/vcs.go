@@ -0,0 +1,104 @@
+// Package vcs abstracts over the version control frontend a repository is
+// worked on with. Entire always stores its data in git objects, but where it
+// keeps the refs pointing at temporary checkpoints depends on what the user
+// sees: plain git shows branches, while Jujutsu (jj) imports every git branch
+// of a colocated repository as a bookmark.
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Backend decides how Entire's temporary checkpoint refs are stored.
+type Backend interface {
+	// Name identifies the backend ("git" or "jj").
+	Name() string
+
+	// ShadowRef returns the reference a shadow branch (e.g.
+	// "entire/abc1234-e3b0c4") is stored under.
+	ShadowRef(branch string) plumbing.ReferenceName
+
+	// ShadowBranch is the inverse of ShadowRef. Returns false for references
+	// outside the backend's shadow namespace; callers still check the name.
+	ShadowBranch(ref plumbing.ReferenceName) (string, bool)
+}
+
+// JujutsuShadowRefPrefix is where shadow branches live in colocated jj
+// repositories. jj only imports refs/heads, refs/remotes and refs/tags, so
+// checkpoints stored here stay hidden changes that never show up as
+// bookmarks in `jj log`, and jj doesn't rewrite or abandon them.
+const JujutsuShadowRefPrefix = "refs/entire/hidden/"
+
+// shadowBranchPrefix mirrors checkpoint.ShadowBranchPrefix.
+const shadowBranchPrefix = "entire/"
+
+// ShadowRefPrefix returns the prefix of every reference b stores shadow
+// branches under, e.g. for listing them with git for-each-ref. With Git it
+// also matches entire/checkpoints/v1, which callers skip by name.
+func ShadowRefPrefix(b Backend) string {
+	return string(b.ShadowRef(shadowBranchPrefix))
+}
+
+// Git stores shadow branches as regular branches.
+var Git Backend = gitBackend{}
+
+// Jujutsu stores shadow branches below JujutsuShadowRefPrefix.
+var Jujutsu Backend = jujutsuBackend{}
+
+type gitBackend struct{}
+
+func (gitBackend) Name() string { return "git" }
+
+func (gitBackend) ShadowRef(branch string) plumbing.ReferenceName {
+	return plumbing.NewBranchReferenceName(branch)
+}
+
+func (gitBackend) ShadowBranch(ref plumbing.ReferenceName) (string, bool) {
+	if !ref.IsBranch() {
+		return "", false
+	}
+	return ref.Short(), true
+}
+
+type jujutsuBackend struct{}
+
+func (jujutsuBackend) Name() string { return "jj" }
+
+func (jujutsuBackend) ShadowRef(branch string) plumbing.ReferenceName {
+	return plumbing.ReferenceName(JujutsuShadowRefPrefix + strings.TrimPrefix(branch, shadowBranchPrefix))
+}
+
+func (jujutsuBackend) ShadowBranch(ref plumbing.ReferenceName) (string, bool) {
+	rest, ok := strings.CutPrefix(ref.String(), JujutsuShadowRefPrefix)
+	if !ok || rest == "" {
+		return "", false
+	}
+	return shadowBranchPrefix + rest, true
+}
+
+// Detect returns the backend for repo: Jujutsu if the worktree is a
+// colocated jj repository (a .jj directory next to .git), Git otherwise.
+func Detect(repo *git.Repository) Backend {
+	if repo == nil {
+		return Git
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return Git // Bare repositories have no jj workspace
+	}
+	return DetectPath(wt.Filesystem.Root())
+}
+
+// DetectPath returns the backend for the worktree rooted at root.
+func DetectPath(root string) Backend {
+	// A directory in the main workspace, a file pointing at it in secondary ones
+	if _, err := os.Stat(filepath.Join(root, ".jj", "repo")); err == nil {
+		return Jujutsu
+	}
+	return Git
+}
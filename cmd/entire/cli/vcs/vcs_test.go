@@ -0,0 +1,83 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestShadowRef_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		backend Backend
+		want    plumbing.ReferenceName
+		prefix  string
+	}{
+		{Git, "refs/heads/entire/abc1234-e3b0c4", "refs/heads/entire/"},
+		{Jujutsu, "refs/entire/hidden/abc1234-e3b0c4", "refs/entire/hidden/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend.Name(), func(t *testing.T) {
+			t.Parallel()
+			ref := tt.backend.ShadowRef("entire/abc1234-e3b0c4")
+			if ref != tt.want {
+				t.Errorf("ShadowRef() = %q, want %q", ref, tt.want)
+			}
+			branch, ok := tt.backend.ShadowBranch(ref)
+			if !ok || branch != "entire/abc1234-e3b0c4" {
+				t.Errorf("ShadowBranch(%q) = %q, %v; want entire/abc1234-e3b0c4, true", ref, branch, ok)
+			}
+			if prefix := ShadowRefPrefix(tt.backend); prefix != tt.prefix {
+				t.Errorf("ShadowRefPrefix() = %q, want %q", prefix, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestShadowBranch_OutsideNamespace(t *testing.T) {
+	t.Parallel()
+
+	for _, ref := range []plumbing.ReferenceName{"refs/tags/v1", "refs/entire/shadow/entire/abc1234", "refs/entire/hidden/"} {
+		if branch, ok := Jujutsu.ShadowBranch(ref); ok {
+			t.Errorf("Jujutsu.ShadowBranch(%q) = %q, want not ok", ref, branch)
+		}
+	}
+	if _, ok := Git.ShadowBranch("refs/entire/hidden/abc1234"); ok {
+		t.Error("Git.ShadowBranch() accepted a hidden ref")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	if got := Detect(repo); got != Git {
+		t.Errorf("Detect() = %s, want git", got.Name())
+	}
+
+	// A .jj directory without a repo isn't a jj workspace
+	if err := os.Mkdir(filepath.Join(dir, ".jj"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := Detect(repo); got != Git {
+		t.Errorf("Detect() with empty .jj = %s, want git", got.Name())
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, ".jj", "repo"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := Detect(repo); got != Jujutsu {
+		t.Errorf("Detect() = %s, want jj", got.Name())
+	}
+	if got := Detect(nil); got != Git {
+		t.Errorf("Detect(nil) = %s, want git", got.Name())
+	}
+}
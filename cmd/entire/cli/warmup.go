@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/cienv"
+	"github.com/entireio/cli/cmd/entire/cli/logging"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+	"github.com/spf13/cobra"
+)
+
+// warmupTimeout bounds a background warm-up, so a huge repository can't
+// leave it running for the whole session.
+const warmupTimeout = 2 * time.Minute
+
+// newWarmupCmd is the hidden command session start runs in the background
+// (see strategy.WarmUp).
+func newWarmupCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "__warmup",
+		Short: "Warm up the caches of a session's first checkpoint",
+		Long: `Caches the listing of HEAD's tree for the session's shadow branch and runs
+git status once, warming the index, the page cache and the fsmonitor daemon
+if core.fsmonitor is set. Session start runs it in a detached process.
+
+It doesn't go through 'entire daemon' (which is optional and has no way to
+serve hooks), and it caches neither the fsmonitor token nor divergence info:
+the token lives in the index, which the warm-up doesn't write so it never
+holds index.lock while the agent runs git, and divergence from the session's
+base is a ref comparison the Stop hook makes itself.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			runWarmup(cmd.Context())
+		},
+	}
+}
+
+func runWarmup(ctx context.Context) {
+	defer initLogging("")()
+	ctx, cancel := context.WithTimeout(ctx, warmupTimeout)
+	defer cancel()
+
+	start := time.Now()
+	logCtx := logging.WithComponent(ctx, "warmup")
+	if err := strategy.WarmUp(ctx); err != nil {
+		logging.Warn(logCtx, "warm-up failed", slog.String("error", err.Error()))
+		return
+	}
+	logging.Debug(logCtx, "warm-up done", slog.Duration("duration", time.Since(start)))
+}
+
+// startWarmup runs the warm-up in a detached process, unless ENTIRE_NO_WARMUP
+// is set. Failing to start it only costs the first Stop hook some time.
+func startWarmup() {
+	// A detached process would outlive the CI job that started it
+	if os.Getenv("ENTIRE_NO_WARMUP") != "" || cienv.Enabled() {
+		return
+	}
+	spawnDetachedWarmup()
+}
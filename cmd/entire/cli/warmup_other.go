@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cli
+
+// spawnDetachedWarmup is a no-op on non-Unix platforms, like analytics: the
+// warm-up is an optimization, and the first Stop hook computes everything
+// itself.
+func spawnDetachedWarmup() {}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+)
+
+// spawnDetachedWarmup starts `entire __warmup` in its own process group, so
+// it keeps running after the hook exits.
+func spawnDetachedWarmup() {
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return
+	}
+
+	cmd := exec.CommandContext(context.Background(), executable, "__warmup")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Dir = repoRoot
+	cmd.Env = os.Environ()
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	//nolint:errcheck // Best effort - process should continue regardless
+	_ = cmd.Process.Release()
+}
@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/entireio/cli/cmd/entire/cli/encryption"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/settings"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+// Workspace mode: an agent running in this repository may edit files in the
+// other repositories listed in the workspace setting. Hooks only run here,
+// so the stop hook saves a checkpoint of the session in each repository the
+// agent changed files in, under the same session ID, with a copy of the
+// session's metadata. Each repository condenses its checkpoints on its own
+// commits, so attribution is computed per repository; `entire sessions show`
+// puts them back together.
+
+// workspaceRepoRoots returns the roots of this repository's workspace
+// repositories that exist, nil when workspace mode is off.
+func workspaceRepoRoots() ([]string, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	if s.Workspace == nil {
+		return nil, nil
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repo root: %w", err)
+	}
+	roots, err := s.Workspace.RepoRoots(repoRoot)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already describes the setting
+	}
+	existing := roots[:0]
+	for _, root := range roots {
+		if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: workspace repository %s is not a git repository\n", root)
+			continue
+		}
+		existing = append(existing, root)
+	}
+	return existing, nil
+}
+
+// splitWorkspaceFiles takes the files in workspace repositories out of files.
+// Relative files are relative to repoRoot. Files in a repository nested in
+// another workspace repository belong to the innermost one.
+func splitWorkspaceFiles(files []string, repoRoot string, roots []string) ([]string, map[string][]string) {
+	if len(roots) == 0 {
+		return files, nil
+	}
+	var rest []string
+	byRepo := make(map[string][]string)
+	for _, file := range files {
+		abs := file
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(repoRoot, abs)
+		}
+		owner := ""
+		for _, root := range roots {
+			if rel := paths.ToRelativePath(abs, root); rel != "" && len(root) > len(owner) {
+				owner = root
+			}
+		}
+		if owner == "" {
+			rest = append(rest, file)
+			continue
+		}
+		byRepo[owner] = append(byRepo[owner], abs)
+	}
+	return rest, byRepo
+}
+
+// stopHookFiles turns the files a stop hook found changed (modified from the
+// transcript, changes from git status) into the files of this repository's
+// checkpoint, relative and filtered, and those of the workspace repositories
+// and, with submodules.recurse, submodules, by repository root. Files inside
+// submodules are listed as the submodule, as are submodules whose commit
+// changed.
+func stopHookFiles(modified []string, changes *FileChanges, repoRoot string) (modifiedFiles, newFiles, deletedFiles []string, workspaceFiles map[string][]string) {
+	roots, err := workspaceRepoRoots()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	submodules := headSubmodules()
+	roots = append(roots, submoduleRepoRoots(repoRoot, submodules)...)
+
+	modified, workspaceFiles = splitWorkspaceFiles(modified, repoRoot, roots)
+	modifiedFiles = collapseSubmoduleFiles(FilterAndNormalizePaths(modified, repoRoot), submodules)
+	addSubmodule := func(path string) {
+		if !slices.Contains(modifiedFiles, path) {
+			modifiedFiles = append(modifiedFiles, path)
+		}
+	}
+	if changes != nil {
+		created, _ := splitWorkspaceFiles(changes.New, repoRoot, roots)
+		for _, file := range FilterAndNormalizePaths(created, repoRoot) {
+			if path := submoduleOf(file, submodules); path != "" {
+				addSubmodule(path)
+			} else {
+				newFiles = append(newFiles, file)
+			}
+		}
+		deleted, _ := splitWorkspaceFiles(changes.Deleted, repoRoot, roots)
+		for _, file := range FilterAndNormalizePaths(deleted, repoRoot) {
+			if path := submoduleOf(file, submodules); path != "" && path != file {
+				addSubmodule(path)
+			} else {
+				deletedFiles = append(deletedFiles, file)
+			}
+		}
+	}
+	for _, path := range movedSubmodules(repoRoot, submodules) {
+		addSubmodule(path)
+	}
+	return modifiedFiles, newFiles, deletedFiles, workspaceFiles
+}
+
+// inWorkspaceRepo runs fn with root as the working directory, so that the
+// strategy and session state work on that repository.
+func inWorkspaceRepo(root string, fn func() error) error {
+	cwd, err := os.Getwd() //nolint:forbidigo // restored after working in the other repository
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to enter workspace repository: %w", err)
+	}
+	paths.ClearRepoRootCache()
+	defer func() {
+		if err := os.Chdir(cwd); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to return to %s: %v\n", cwd, err)
+		}
+		paths.ClearRepoRootCache()
+	}()
+	return fn()
+}
+
+// saveWorkspaceCheckpoints saves a checkpoint of the session in each
+// workspace repository of byRepo with the session's metadata from
+// saveCtx.MetadataDirAbs, and records the repositories in this repository's
+// session state. Failures are only printed: the checkpoint here is saved.
+func saveWorkspaceCheckpoints(saveCtx strategy.SaveContext, byRepo map[string][]string) {
+	if len(byRepo) == 0 {
+		return
+	}
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get repo root: %v\n", err)
+		return
+	}
+	roots := make([]string, 0, len(byRepo))
+	for root := range byRepo {
+		roots = append(roots, root)
+	}
+	slices.Sort(roots)
+
+	var saved []string
+	for _, root := range roots {
+		err := inWorkspaceRepo(root, func() error {
+			return saveWorkspaceCheckpoint(saveCtx, repoRoot, byRepo[root])
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save checkpoint in workspace repository %s: %v\n", root, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Saved checkpoint in workspace repository %s (%d file(s))\n", root, len(byRepo[root]))
+		saved = append(saved, root)
+	}
+	if len(saved) == 0 {
+		return
+	}
+
+	err = strategy.UpdateSessionState(saveCtx.SessionID, func(state *strategy.SessionState) error {
+		for _, root := range saved {
+			if !slices.Contains(state.WorkspaceRepos, root) {
+				state.WorkspaceRepos = append(state.WorkspaceRepos, root)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record workspace repositories: %v\n", err)
+	}
+}
+
+// saveWorkspaceCheckpoint saves the session's checkpoint of files (absolute)
+// in the repository of the working directory. workspaceRoot is the
+// repository whose hooks recorded the session.
+func saveWorkspaceCheckpoint(saveCtx strategy.SaveContext, workspaceRoot string, files []string) error {
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get repo root: %w", err)
+	}
+	var modified, deleted []string
+	for _, file := range FilterAndNormalizePaths(files, repoRoot) {
+		if _, err := os.Stat(filepath.Join(repoRoot, file)); errors.Is(err, os.ErrNotExist) {
+			deleted = append(deleted, file)
+		} else {
+			modified = append(modified, file)
+		}
+	}
+	if len(modified) == 0 && len(deleted) == 0 {
+		return nil
+	}
+
+	sessionDir := paths.SessionMetadataDirFromSessionID(saveCtx.SessionID)
+	sessionDirAbs, err := paths.AbsPath(sessionDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve session directory: %w", err)
+	}
+	if err := copyMetadataDir(saveCtx.MetadataDirAbs, sessionDirAbs); err != nil {
+		return err
+	}
+
+	strat := GetStrategy()
+	if err := strat.EnsureSetup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to ensure strategy setup: %v\n", err)
+	}
+	saveCtx.ModifiedFiles = modified
+	saveCtx.NewFiles = nil
+	saveCtx.DeletedFiles = deleted
+	saveCtx.MetadataDir = sessionDir
+	saveCtx.MetadataDirAbs = sessionDirAbs
+	if err := strat.SaveChanges(saveCtx); err != nil {
+		return fmt.Errorf("failed to save changes: %w", err)
+	}
+
+	err = strategy.UpdateSessionState(saveCtx.SessionID, func(state *strategy.SessionState) error {
+		state.WorkspaceRoot = workspaceRoot
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session state: %w", err)
+	}
+	transitionSessionTurnEnd(saveCtx.SessionID)
+	return nil
+}
+
+// copyMetadataDir copies the files of a session metadata directory.
+func copyMetadataDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("failed to read session directory: %w", err)
+	}
+	if err := os.MkdirAll(dst, 0o750); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := encryption.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		if err := encryption.WriteFile(filepath.Join(dst, entry.Name()), data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// workspaceRepoLabel names a workspace repository relative to this
+// repository's root when it's close by.
+func workspaceRepoLabel(repoRoot, root string) string {
+	rel, err := filepath.Rel(repoRoot, root)
+	if err != nil || strings.HasPrefix(rel, filepath.Join("..", "..", "..")) {
+		return root
+	}
+	return filepath.ToSlash(rel)
+}
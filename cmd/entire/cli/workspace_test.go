@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/paths"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestSplitWorkspaceFiles(t *testing.T) {
+	base := t.TempDir()
+	repoRoot := filepath.Join(base, "app")
+	api := filepath.Join(base, "api")
+	lib := filepath.Join(repoRoot, "vendor", "lib")
+	outside := filepath.Join(base, "notes.txt")
+
+	rest, byRepo := splitWorkspaceFiles([]string{
+		"main.go",
+		filepath.Join(api, "server.go"),
+		filepath.Join(lib, "a.go"),
+		filepath.Join("vendor", "lib", "b.go"),
+		outside,
+	}, repoRoot, []string{api, lib})
+
+	if !slices.Equal(rest, []string{"main.go", outside}) {
+		t.Errorf("rest = %v, want main.go and the file outside the workspace", rest)
+	}
+	if got := byRepo[api]; !slices.Equal(got, []string{filepath.Join(api, "server.go")}) {
+		t.Errorf("api files = %v", got)
+	}
+	if got := byRepo[lib]; !slices.Equal(got, []string{filepath.Join(lib, "a.go"), filepath.Join(lib, "b.go")}) {
+		t.Errorf("nested repository files = %v", got)
+	}
+}
+
+func TestSaveWorkspaceCheckpoints(t *testing.T) {
+	memberRepo, _ := setupCleanTestRepo(t)
+	memberWorktree, err := memberRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := memberWorktree.Filesystem.Root()
+	_, base := setupCleanTestRepo(t)
+	repoRoot, err := paths.RepoRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const sessionID = "2026-10-14-workspace"
+	if err := strategy.SaveSessionState(&strategy.SessionState{
+		SessionID:  sessionID,
+		BaseCommit: base.String(),
+		StartedAt:  time.Now(),
+		Phase:      session.PhaseActive,
+	}); err != nil {
+		t.Fatalf("SaveSessionState() error = %v", err)
+	}
+	sessionDir := paths.SessionMetadataDirFromSessionID(sessionID)
+	sessionDirAbs := filepath.Join(repoRoot, sessionDir)
+	if err := os.MkdirAll(sessionDirAbs, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessionDirAbs, paths.TranscriptFileName), []byte(`{"type":"user"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(member, "api.go"), []byte("package api\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	saveWorkspaceCheckpoints(strategy.SaveContext{
+		SessionID:      sessionID,
+		MetadataDir:    sessionDir,
+		MetadataDirAbs: sessionDirAbs,
+		CommitMessage:  "add the api",
+		AuthorName:     "test",
+		AuthorEmail:    "test@test.com",
+		AgentType:      agent.AgentTypeClaudeCode,
+	}, map[string][]string{member: {filepath.Join(member, "api.go")}})
+
+	state, err := strategy.LoadSessionState(sessionID)
+	if err != nil || state == nil {
+		t.Fatalf("LoadSessionState() = %v, %v", state, err)
+	}
+	if !slices.Equal(state.WorkspaceRepos, []string{member}) {
+		t.Errorf("WorkspaceRepos = %v, want the member repository", state.WorkspaceRepos)
+	}
+	var memberState *strategy.SessionState
+	if err := inWorkspaceRepo(member, func() error {
+		memberState, err = strategy.LoadSessionState(sessionID)
+		return err
+	}); err != nil || memberState == nil {
+		t.Fatalf("member session state = %v, %v", memberState, err)
+	}
+	if memberState.WorkspaceRoot != repoRoot || memberState.StepCount != 1 || memberState.Phase != session.PhaseIdle {
+		t.Errorf("member state = root %q, %d step(s), %s", memberState.WorkspaceRoot, memberState.StepCount, memberState.Phase)
+	}
+
+	var buf bytes.Buffer
+	if err := runSessionsShow(context.Background(), &buf, sessionID, false); err != nil {
+		t.Fatalf("runSessionsShow() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"This repository\nNo checkpoints yet.",
+		"Workspace repository " + workspaceRepoLabel(repoRoot, member) + "\n└── Uncommitted (1 checkpoint(s) on entire/",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := markSessionEnded(sessionID); err != nil {
+		t.Fatalf("markSessionEnded() error = %v", err)
+	}
+	if err := inWorkspaceRepo(member, func() error {
+		memberState, err = strategy.LoadSessionState(sessionID)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if memberState.Phase != session.PhaseEnded {
+		t.Errorf("member phase = %s, want the session ended there too", memberState.Phase)
+	}
+}
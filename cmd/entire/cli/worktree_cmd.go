@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/jsonutil"
+	"github.com/entireio/cli/cmd/entire/cli/session"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+func newWorktreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worktree",
+		Short: "Inspect how sessions are separated between git worktrees",
+		Long: `Each git worktree keeps its own session state and writes checkpoints to
+shadow branches namespaced by a hash of its worktree ID, so agents working in
+parallel worktrees never share a shadow branch.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newWorktreeListCmd())
+	cmd.AddCommand(newWorktreeCheckCmd())
+
+	return cmd
+}
+
+func newWorktreeListCmd() *cobra.Command {
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List worktrees with their shadow branch namespace and sessions",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runWorktreeList(cmd.OutOrStdout(), jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+func newWorktreeCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check that sessions in different worktrees can't collide",
+		Long: `Checks shadow branch namespacing across worktrees:
+
+  - no two worktrees hash to the same shadow branch namespace
+  - every session belongs to a worktree that still exists
+  - sessions recorded the worktree ID their worktree still has
+  - no shadow branches are left in a namespace no worktree uses
+
+Exits with an error if a problem is found.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if checkDisabledGuard(cmd.OutOrStdout()) {
+				return nil
+			}
+			return runWorktreeCheck(cmd.OutOrStdout())
+		},
+	}
+
+	return cmd
+}
+
+type worktreeJSON struct {
+	ID             string `json:"id"`
+	Path           string `json:"path"`
+	Branch         string `json:"branch,omitempty"`
+	Head           string `json:"head,omitempty"`
+	Namespace      string `json:"namespace"`
+	Sessions       int    `json:"sessions"`
+	ActiveSessions int    `json:"active_sessions"`
+	ShadowBranches int    `json:"shadow_branches"`
+	Current        bool   `json:"current,omitempty"`
+	Prunable       bool   `json:"prunable,omitempty"`
+}
+
+// worktreeContext is everything the worktree commands look at.
+type worktreeContext struct {
+	worktrees      []worktreeJSON
+	states         []*strategy.SessionState
+	shadowBranches []string
+}
+
+func loadWorktreeContext() (*worktreeContext, error) {
+	registered, err := strategy.ListWorktrees()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped by ListWorktrees
+	}
+	states, err := strategy.ListSessionStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	branches, err := strategy.ListShadowBranches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow branches: %w", err)
+	}
+	currentPath, err := strategy.GetWorktreePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree path: %w", err)
+	}
+
+	wc := &worktreeContext{states: states, shadowBranches: branches}
+	for _, wt := range registered {
+		entry := worktreeJSON{
+			Path:     wt.Path,
+			Branch:   wt.Branch,
+			Head:     wt.Head,
+			Current:  filepath.Clean(wt.Path) == filepath.Clean(currentPath),
+			Prunable: wt.Prunable,
+		}
+		if !wt.Prunable {
+			id, err := strategy.ShadowWorktreeID(wt.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get worktree ID of %s: %w", wt.Path, err)
+			}
+			entry.ID = id
+		}
+		entry.Namespace = checkpoint.HashWorktreeID(entry.ID)
+		wc.worktrees = append(wc.worktrees, entry)
+	}
+	countWorktreeUsage(wc)
+	return wc, nil
+}
+
+// countWorktreeUsage fills in the session and shadow branch counts of each worktree.
+func countWorktreeUsage(wc *worktreeContext) {
+	for i := range wc.worktrees {
+		wt := &wc.worktrees[i]
+		if wt.Prunable {
+			continue
+		}
+		for _, state := range wc.states {
+			if state.WorktreeID != wt.ID {
+				continue
+			}
+			wt.Sessions++
+			if state.EndedAt == nil && state.Phase != session.PhaseEnded {
+				wt.ActiveSessions++
+			}
+		}
+		for _, branch := range wc.shadowBranches {
+			if _, hash, ok := checkpoint.ParseShadowBranchName(branch); ok && hash == wt.Namespace {
+				wt.ShadowBranches++
+			}
+		}
+	}
+}
+
+func runWorktreeList(w io.Writer, jsonOutput bool) error {
+	wc, err := loadWorktreeContext()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		data, err := jsonutil.MarshalIndentWithNewline(wc.worktrees, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal worktrees: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	}
+
+	for _, wt := range wc.worktrees {
+		marker := " "
+		if wt.Current {
+			marker = "*"
+		}
+		branch := wt.Branch
+		if branch == "" {
+			branch = "detached"
+		}
+		if wt.Prunable {
+			fmt.Fprintf(w, "%s %s (%s, missing; run `git worktree prune`)\n", marker, wt.Path, branch)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s (%s)\n", marker, describeWorktree(wt.ID, wt.Path), branch)
+		fmt.Fprintf(w, "    namespace entire/*-%s · %d session(s), %d active · %d shadow branch(es)\n",
+			wt.Namespace, wt.Sessions, wt.ActiveSessions, wt.ShadowBranches)
+	}
+	return nil
+}
+
+func runWorktreeCheck(w io.Writer) error {
+	wc, err := loadWorktreeContext()
+	if err != nil {
+		return err
+	}
+	issues := checkWorktreeNamespaces(wc)
+	if len(issues) == 0 {
+		fmt.Fprintf(w, "✓ %d worktree(s), %d session(s): shadow branch namespaces don't collide\n", len(wc.worktrees), len(wc.states))
+		return nil
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(w, "⚠ %s\n", issue)
+	}
+	return NewSilentError(errors.New("worktree check found problems"))
+}
+
+// checkWorktreeNamespaces returns the problems with how wc's sessions and
+// shadow branches are separated between worktrees.
+func checkWorktreeNamespaces(wc *worktreeContext) []string {
+	var issues []string
+
+	byNamespace := make(map[string][]string)
+	byPath := make(map[string]string)
+	known := make(map[string]bool)
+	for _, wt := range wc.worktrees {
+		if wt.Prunable {
+			continue
+		}
+		known[wt.ID] = true
+		byPath[filepath.Clean(wt.Path)] = wt.ID
+		if ids := byNamespace[wt.Namespace]; !slices.Contains(ids, wt.ID) {
+			byNamespace[wt.Namespace] = append(ids, wt.ID)
+		}
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		if ids := byNamespace[ns]; len(ids) > 1 {
+			issues = append(issues, fmt.Sprintf("worktrees %q share shadow branch namespace %s; rename one with `git worktree move`", ids, ns))
+		}
+	}
+
+	states := append([]*strategy.SessionState(nil), wc.states...)
+	sort.Slice(states, func(i, j int) bool { return states[i].SessionID < states[j].SessionID })
+	for _, state := range states {
+		// A worktree moved or re-created under another name
+		if id, ok := byPath[filepath.Clean(state.WorktreePath)]; ok && id != state.WorktreeID {
+			issues = append(issues, fmt.Sprintf("session %s recorded worktree %q, but %s is now worktree %q; its checkpoints are on %s",
+				state.SessionID, state.WorktreeID, state.WorktreePath, id, checkpoint.ShadowBranchNameForCommit(state.BaseCommit, state.WorktreeID)))
+			continue
+		}
+		ended := state.EndedAt != nil || state.Phase == session.PhaseEnded
+		if !known[state.WorktreeID] && !ended {
+			issues = append(issues, fmt.Sprintf("session %s belongs to worktree %q, which no longer exists (run `entire clean`)", state.SessionID, state.WorktreeID))
+		}
+	}
+
+	orphaned := make(map[string]int)
+	for _, branch := range wc.shadowBranches {
+		_, hash, ok := checkpoint.ParseShadowBranchName(branch)
+		if !ok || hash == "" {
+			continue // Old branches from before worktree namespacing
+		}
+		if _, used := byNamespace[hash]; !used {
+			orphaned[hash]++
+		}
+	}
+	for _, state := range wc.states {
+		delete(orphaned, checkpoint.HashWorktreeID(state.WorktreeID))
+	}
+	orphanedNamespaces := make([]string, 0, len(orphaned))
+	for ns := range orphaned {
+		orphanedNamespaces = append(orphanedNamespaces, ns)
+	}
+	sort.Strings(orphanedNamespaces)
+	for _, ns := range orphanedNamespaces {
+		issues = append(issues, fmt.Sprintf("%d shadow branch(es) in namespace %s belong to no worktree or session (run `entire clean`)", orphaned[ns], ns))
+	}
+	return issues
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/entireio/cli/cmd/entire/cli/agent"
+	"github.com/entireio/cli/cmd/entire/cli/checkpoint"
+	"github.com/entireio/cli/cmd/entire/cli/strategy"
+)
+
+func TestCheckWorktreeNamespaces(t *testing.T) {
+	t.Parallel()
+
+	main := worktreeJSON{ID: "", Path: "/repo", Namespace: checkpoint.HashWorktreeID("")}
+	feature := worktreeJSON{ID: "feature", Path: "/repo-feature", Namespace: checkpoint.HashWorktreeID("feature")}
+	clean := &worktreeContext{
+		worktrees: []worktreeJSON{main, feature},
+		states: []*strategy.SessionState{
+			{SessionID: "a", WorktreeID: "", WorktreePath: "/repo", BaseCommit: "1234567abc"},
+			{SessionID: "b", WorktreeID: "feature", WorktreePath: "/repo-feature", BaseCommit: "1234567abc"},
+		},
+		shadowBranches: []string{
+			checkpoint.ShadowBranchNameForCommit("1234567abc", ""),
+			checkpoint.ShadowBranchNameForCommit("1234567abc", "feature"),
+			"entire/89abcde", // From before worktree namespacing
+		},
+	}
+	if issues := checkWorktreeNamespaces(clean); len(issues) != 0 {
+		t.Errorf("checkWorktreeNamespaces() = %v, want no issues", issues)
+	}
+
+	ended := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	broken := &worktreeContext{
+		worktrees: []worktreeJSON{
+			main,
+			// Forced collision: real IDs only collide in 1 of 16M pairs
+			{ID: "feature", Path: "/repo-feature", Namespace: main.Namespace},
+			{ID: "renamed", Path: "/repo-moved", Namespace: checkpoint.HashWorktreeID("renamed")},
+		},
+		states: []*strategy.SessionState{
+			{SessionID: "gone", WorktreeID: "removed", WorktreePath: "/repo-removed"},
+			{SessionID: "gone-ended", WorktreeID: "removed", EndedAt: &ended},
+			{SessionID: "moved", WorktreeID: "old-name", WorktreePath: "/repo-moved"},
+		},
+		shadowBranches: []string{checkpoint.ShadowBranchNameForCommit("1234567abc", "deleted-long-ago")},
+	}
+	issues := checkWorktreeNamespaces(broken)
+	want := []string{"share shadow branch namespace", "session gone belongs to worktree \"removed\"", "session moved recorded worktree \"old-name\"", "belong to no worktree or session"}
+	if len(issues) != len(want) {
+		t.Fatalf("checkWorktreeNamespaces() = %q, want %d issues", issues, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(issues[i], w) {
+			t.Errorf("issue %d = %q, want it to mention %q", i, issues[i], w)
+		}
+	}
+}
+
+func TestRunSessionsList_Worktrees(t *testing.T) {
+	setupCleanTestRepo(t)
+	for _, state := range []*strategy.SessionState{
+		{SessionID: "2026-10-14-main", BaseCommit: "1234567abc", StartedAt: time.Now()},
+		{SessionID: "2026-10-14-feature", BaseCommit: "1234567abc", WorktreeID: "feature", WorktreePath: "/repo-feature", StartedAt: time.Now()},
+	} {
+		if err := strategy.SaveSessionState(state); err != nil {
+			t.Fatalf("failed to save session state: %v", err)
+		}
+	}
+
+	var sb strings.Builder
+	if err := runSessionsList(&sb, io.Discard, false, listOptions{Sort: listSortNewest}, false); err != nil {
+		t.Fatalf("runSessionsList() error = %v", err)
+	}
+	if out := sb.String(); !strings.Contains(out, "2026-10") || strings.Contains(out, "worktree feature") || strings.Count(out, "entire/") != 1 {
+		t.Errorf("current worktree output = %q, want only the main worktree's session", out)
+	}
+
+	sb.Reset()
+	if err := runSessionsList(&sb, io.Discard, true, listOptions{Sort: listSortNewest}, false); err != nil {
+		t.Fatalf("runSessionsList(--all-worktrees) error = %v", err)
+	}
+	out := sb.String()
+	if !strings.Contains(out, "main worktree") || !strings.Contains(out, "worktree feature (/repo-feature)") {
+		t.Errorf("all worktrees output = %q, want both worktrees as headers", out)
+	}
+	if !strings.Contains(out, checkpoint.ShadowBranchNameForCommit("1234567abc", "feature")) {
+		t.Errorf("all worktrees output = %q, want the feature worktree's shadow branch", out)
+	}
+}
+
+func TestRunSessionsList_FilterAndPage(t *testing.T) {
+	setupCleanTestRepo(t)
+	now := time.Now()
+	for i, state := range []*strategy.SessionState{
+		{SessionID: "2026-10-14-claude-old", AgentType: agent.AgentTypeClaudeCode, StartedAt: now.Add(-2 * time.Hour)},
+		{SessionID: "2026-10-14-claude-new", AgentType: agent.AgentTypeClaudeCode, StartedAt: now.Add(-time.Hour)},
+		{SessionID: "2026-10-14-gemini", AgentType: agent.AgentTypeGemini, StartedAt: now},
+	} {
+		state.BaseCommit = fmt.Sprintf("%07d", i)
+		if err := strategy.SaveSessionState(state); err != nil {
+			t.Fatalf("failed to save session state: %v", err)
+		}
+	}
+
+	var out, errOut strings.Builder
+	opts := listOptions{Limit: 1, Agent: "claude-code", Sort: listSortNewest}
+	if err := runSessionsList(&out, &errOut, false, opts, false); err != nil {
+		t.Fatalf("runSessionsList() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "2026-10") || strings.Count(out.String(), "entire/") != 1 || strings.Contains(out.String(), "0000002") {
+		t.Errorf("first page = %q, want only the newest claude session", out.String())
+	}
+	_, cursor, ok := strings.Cut(strings.TrimSpace(errOut.String()), "--cursor ")
+	if !ok {
+		t.Fatalf("stderr = %q, want a cursor", errOut.String())
+	}
+
+	out.Reset()
+	errOut.Reset()
+	opts.Cursor = cursor
+	if err := runSessionsList(&out, &errOut, false, opts, false); err != nil {
+		t.Fatalf("runSessionsList() error = %v", err)
+	}
+	if !strings.Contains(out.String(), checkpoint.ShadowBranchNameForCommit("0000000", "")) || errOut.Len() != 0 {
+		t.Errorf("second page = %q (stderr %q), want the older claude session and no cursor", out.String(), errOut.String())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/entireio/cli/cmd/entire/cli"
 	"github.com/spf13/cobra"
@@ -27,7 +28,9 @@ func main() {
 
 	// Create and execute root command
 	rootCmd := cli.NewRootCmd()
-	err := rootCmd.ExecuteContext(ctx)
+	start := time.Now()
+	executed, err := rootCmd.ExecuteContextC(ctx)
+	cli.TrackCommand(executed, err, time.Since(start))
 
 	if err != nil {
 		var silent *cli.SilentError
@@ -18,23 +18,21 @@ var secretPattern = regexp.MustCompile(`[A-Za-z0-9/+_=-]{10,}`)
 // and tokens which tend to have entropy well above 5.0.
 const entropyThreshold = 4.5
 
-// String replaces high-entropy strings matching secretPattern with REDACTED.
+// String replaces secrets with REDACTED: values in known secret formats (API
+// tokens, private keys, credentials in URLs and config lines) and other
+// high-entropy strings matching secretPattern. Text overlapping an allowlist
+// pattern (see SetAllowlist) is kept.
 func String(s string) string {
-	locs := secretPattern.FindAllStringIndex(s, -1)
-	if len(locs) == 0 {
+	spans := findSecrets(s)
+	if len(spans) == 0 {
 		return s
 	}
 	var b strings.Builder
 	prev := 0
-	for _, loc := range locs {
-		b.WriteString(s[prev:loc[0]])
-		match := s[loc[0]:loc[1]]
-		if isSecret(match) {
-			b.WriteString("REDACTED")
-		} else {
-			b.WriteString(match)
-		}
-		prev = loc[1]
+	for _, sp := range spans {
+		b.WriteString(s[prev:sp.start])
+		b.WriteString("REDACTED")
+		prev = sp.end
 	}
 	b.WriteString(s[prev:])
 	return b.String()
@@ -110,33 +108,37 @@ func JSONLContent(content string) (string, error) {
 func collectJSONLReplacements(v any) [][2]string {
 	seen := make(map[string]bool)
 	var repls [][2]string
-	var walk func(v any)
-	walk = func(v any) {
-		switch val := v.(type) {
-		case map[string]any:
-			if shouldSkipJSONLObject(val) {
-				return
-			}
-			for k, child := range val {
-				if shouldSkipJSONLField(k) {
-					continue
-				}
-				walk(child)
-			}
-		case []any:
-			for _, child := range val {
-				walk(child)
-			}
-		case string:
-			redacted := String(val)
-			if redacted != val && !seen[val] {
-				seen[val] = true
-				repls = append(repls, [2]string{val, redacted})
+	walkJSONLStrings(v, func(val string) {
+		redacted := String(val)
+		if redacted != val && !seen[val] {
+			seen[val] = true
+			repls = append(repls, [2]string{val, redacted})
+		}
+	})
+	return repls
+}
+
+// walkJSONLStrings calls fn for every string value in a parsed JSON value that
+// is subject to redaction, skipping ID fields, signatures and image data.
+func walkJSONLStrings(v any, fn func(string)) {
+	switch val := v.(type) {
+	case map[string]any:
+		if shouldSkipJSONLObject(val) {
+			return
+		}
+		for k, child := range val {
+			if shouldSkipJSONLField(k) {
+				continue
 			}
+			walkJSONLStrings(child, fn)
 		}
+	case []any:
+		for _, child := range val {
+			walkJSONLStrings(child, fn)
+		}
+	case string:
+		fn(val)
 	}
-	walk(v)
-	return repls
 }
 
 // shouldSkipJSONLField returns true if a JSON key should be excluded from scanning/redaction.
@@ -0,0 +1,157 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ruleHighEntropy is the rule name reported for matches found by entropy
+// detection rather than a known secret format.
+const ruleHighEntropy = "high-entropy"
+
+// rule is a known secret format. Known formats catch keys whose entropy is too
+// low for entropy detection alone (AWS key IDs, passwords in config lines).
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	// group is the submatch holding the secret; 0 redacts the whole match.
+	group int
+	// check, if set, must accept the secret for it to count.
+	check func(string) bool
+}
+
+var rules = []rule{
+	{name: "private-key", pattern: regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws-access-key-id", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "aws-secret-access-key", pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), group: 1},
+	{name: "github-token", pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`)},
+	{name: "github-fine-grained-token", pattern: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,255}\b`)},
+	{name: "gitlab-token", pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{name: "slack-token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "stripe-key", pattern: regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{16,}\b`)},
+	{name: "google-api-key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "anthropic-api-key", pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{name: "openai-api-key", pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{name: "url-credentials", pattern: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s/@]{3,})@`), group: 1},
+	{
+		name:    "password-assignment",
+		pattern: regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|auth[_-]?token|client[_-]?secret)["']?\s*[:=]\s*["']?([A-Za-z0-9/+_.=~!@#$%^&*-]{8,})`),
+		group:   1,
+		// Identifiers in code ("password = defaultPassword") aren't secrets;
+		// require a mix of letters and digits
+		check: hasLettersAndDigits,
+	},
+}
+
+// allowlist holds patterns for text that must never be redacted.
+var (
+	allowlistMu sync.RWMutex
+	allowlist   []*regexp.Regexp
+)
+
+// SetAllowlist sets the regular expressions for text that must never be
+// redacted, such as well-known example keys or test fixtures. A match that
+// overlaps text matched by an allowlist pattern is kept as is.
+// Returns an error (and keeps the previous allowlist) if a pattern is invalid.
+func SetAllowlist(patterns []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid redaction allowlist pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	allowlistMu.Lock()
+	defer allowlistMu.Unlock()
+	allowlist = compiled
+	return nil
+}
+
+// span is a secret found in a string, as byte offsets.
+type span struct {
+	start, end int
+	rule       string
+}
+
+// findSecrets returns the non-overlapping secrets in s, ordered by position.
+// Overlapping matches are merged; the merged span keeps the first known-format
+// rule name, so reports say "github-token" rather than "high-entropy".
+func findSecrets(s string) []span {
+	var spans []span
+	for _, r := range rules {
+		for _, m := range r.pattern.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[2*r.group], m[2*r.group+1]
+			if start < 0 || (r.check != nil && !r.check(s[start:end])) {
+				continue
+			}
+			spans = append(spans, span{start: start, end: end, rule: r.name})
+		}
+	}
+	for _, loc := range secretPattern.FindAllStringIndex(s, -1) {
+		if isSecret(s[loc[0]:loc[1]]) {
+			spans = append(spans, span{start: loc[0], end: loc[1], rule: ruleHighEntropy})
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	spans = dropAllowlisted(s, spans)
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var merged []span
+	for _, sp := range spans {
+		if n := len(merged); n > 0 && sp.start < merged[n-1].end {
+			last := &merged[n-1]
+			last.end = max(last.end, sp.end)
+			if last.rule == ruleHighEntropy {
+				last.rule = sp.rule
+			}
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
+
+// dropAllowlisted removes spans that overlap an allowlist match in s.
+func dropAllowlisted(s string, spans []span) []span {
+	allowlistMu.RLock()
+	patterns := allowlist
+	allowlistMu.RUnlock()
+	if len(patterns) == 0 {
+		return spans
+	}
+
+	var allowed [][]int
+	for _, re := range patterns {
+		allowed = append(allowed, re.FindAllStringIndex(s, -1)...)
+	}
+	if len(allowed) == 0 {
+		return spans
+	}
+	kept := spans[:0]
+	for _, sp := range spans {
+		overlaps := false
+		for _, a := range allowed {
+			if sp.start < a[1] && a[0] < sp.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, sp)
+		}
+	}
+	return kept
+}
+
+func hasLettersAndDigits(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0 && strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
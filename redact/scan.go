@@ -0,0 +1,66 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Finding is a secret found by Scan or ScanJSONL.
+type Finding struct {
+	// Rule names the secret format, or "high-entropy".
+	Rule string `json:"rule"`
+	// Line is the 1-based line of the content the secret is on.
+	Line int `json:"line"`
+	// Preview is a masked form of the secret, safe to print.
+	Preview string `json:"preview"`
+}
+
+// Scan reports the secrets String would redact in content, without changing it.
+func Scan(content string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(content, "\n") {
+		findings = appendFindings(findings, line, i+1)
+	}
+	return findings
+}
+
+// ScanJSONL reports the secrets JSONLContent would redact in content. Like
+// JSONLContent, it skips ID fields, signatures and image data.
+func ScanJSONL(content string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		var parsed any
+		if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil {
+			findings = appendFindings(findings, line, i+1)
+			continue
+		}
+		walkJSONLStrings(parsed, func(val string) {
+			findings = appendFindings(findings, val, i+1)
+		})
+	}
+	return findings
+}
+
+func appendFindings(findings []Finding, s string, line int) []Finding {
+	for _, sp := range findSecrets(s) {
+		findings = append(findings, Finding{Rule: sp.rule, Line: line, Preview: mask(s[sp.start:sp.end])})
+	}
+	return findings
+}
+
+// mask keeps only the first four characters of a secret, and never more than
+// a quarter of it, so a report doesn't leak the secret it points to.
+func mask(secret string) string {
+	secret = strings.TrimSpace(secret)
+	n := len(secret)
+	if i := strings.IndexAny(secret, "\r\n"); i >= 0 {
+		secret = secret[:i]
+	}
+	keep := min(4, len(secret)/4)
+	return fmt.Sprintf("%s… (%d chars)", secret[:keep], n)
+}